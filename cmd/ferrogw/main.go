@@ -11,6 +11,7 @@ import (
 	// Register built-in plugins so they can be loaded from config.
 	_ "github.com/ferro-labs/ai-gateway/internal/plugins/budget"
 	_ "github.com/ferro-labs/ai-gateway/internal/plugins/cache"
+	_ "github.com/ferro-labs/ai-gateway/internal/plugins/historycompress"
 	_ "github.com/ferro-labs/ai-gateway/internal/plugins/logger"
 	_ "github.com/ferro-labs/ai-gateway/internal/plugins/maxtoken"
 	_ "github.com/ferro-labs/ai-gateway/internal/plugins/ratelimit"
@@ -44,6 +45,9 @@ func main() {
 	rootCmd.AddCommand(cli.StatusCmd)
 	rootCmd.AddCommand(cli.VersionCmd)
 	rootCmd.AddCommand(cli.AdminCmd)
+	rootCmd.AddCommand(cli.EvalCmd)
+	rootCmd.AddCommand(cli.LogsCmd)
+	rootCmd.AddCommand(cli.ModelsCmd)
 
 	// Persistent flags for CLI commands.
 	rootCmd.PersistentFlags().String("gateway-url", "",
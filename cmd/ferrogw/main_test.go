@@ -16,8 +16,8 @@ import (
 	"github.com/ferro-labs/ai-gateway/internal/admin"
 	"github.com/ferro-labs/ai-gateway/internal/apierror"
 	"github.com/ferro-labs/ai-gateway/internal/bootstrap"
-	"github.com/ferro-labs/ai-gateway/internal/handler"
 	"github.com/ferro-labs/ai-gateway/internal/httpserver"
+	"github.com/ferro-labs/ai-gateway/internal/openaiapi"
 	"github.com/ferro-labs/ai-gateway/internal/sse"
 	"github.com/ferro-labs/ai-gateway/plugin"
 	"github.com/ferro-labs/ai-gateway/providers"
@@ -212,6 +212,7 @@ func TestDashboardUIPage(t *testing.T) {
 		{"/dashboard/keys", "API Keys"},
 		{"/dashboard/logs", "Request Logs"},
 		{"/dashboard/providers", "Providers"},
+		{"/dashboard/routing", "Routing"},
 		{"/dashboard/config", "Config"},
 		{"/dashboard/analytics", "Analytics"},
 		{"/dashboard/playground", "Playground"},
@@ -292,7 +293,7 @@ func TestChatCompletions(t *testing.T) {
 }
 
 func TestDecodeChatCompletionRequest_MultipartContent(t *testing.T) {
-	req, err := handler.DecodeChatCompletionRequest(strings.NewReader(`{
+	req, err := openaiapi.DecodeChatCompletionRequest(strings.NewReader(`{
 		"model":"test-model",
 		"messages":[
 			{
@@ -306,7 +307,7 @@ func TestDecodeChatCompletionRequest_MultipartContent(t *testing.T) {
 		]
 	}`))
 	if err != nil {
-		t.Fatalf("handler.DecodeChatCompletionRequest: %v", err)
+		t.Fatalf("openaiapi.DecodeChatCompletionRequest: %v", err)
 	}
 	if got := len(req.Messages); got != 1 {
 		t.Fatalf("messages = %d, want 1", got)
@@ -320,13 +321,13 @@ func TestDecodeChatCompletionRequest_MultipartContent(t *testing.T) {
 }
 
 func TestDecodeChatCompletionRequest_ToolChoiceString(t *testing.T) {
-	req, err := handler.DecodeChatCompletionRequest(strings.NewReader(`{
+	req, err := openaiapi.DecodeChatCompletionRequest(strings.NewReader(`{
 		"model":"test-model",
 		"messages":[{"role":"user","content":"hi"}],
 		"tool_choice":"auto"
 	}`))
 	if err != nil {
-		t.Fatalf("handler.DecodeChatCompletionRequest: %v", err)
+		t.Fatalf("openaiapi.DecodeChatCompletionRequest: %v", err)
 	}
 	if req.ToolChoice != "auto" {
 		t.Fatalf("tool_choice = %#v, want %q", req.ToolChoice, "auto")
@@ -525,7 +526,7 @@ func BenchmarkDecodeChatCompletionRequest(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if _, err := handler.DecodeChatCompletionRequest(bytes.NewReader(payload)); err != nil {
+		if _, err := openaiapi.DecodeChatCompletionRequest(bytes.NewReader(payload)); err != nil {
 			b.Fatal(err)
 		}
 	}
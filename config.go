@@ -1,6 +1,12 @@
 package aigateway
 
-import "github.com/ferro-labs/ai-gateway/mcp"
+import (
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/truncation"
+	"github.com/ferro-labs/ai-gateway/mcp"
+	"github.com/ferro-labs/ai-gateway/plugin"
+)
 
 // DefaultMaxRequestBytes is the default per-request body-size cap (10 MiB).
 // Operators may lower or raise this via Config.MaxRequestBytes.
@@ -22,12 +28,24 @@ type Config struct {
 	Strategy StrategyConfig `json:"strategy" yaml:"strategy"`
 	// Targets is a list of provider targets to route requests to.
 	Targets []Target `json:"targets" yaml:"targets"`
+	// Region is the region this gateway serves, the origin region of requests
+	// that send no X-Ferro-Region header. Requests are routed to the targets in
+	// their origin region first; see Target.Region.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
 	// MaxRequestBytes caps the size of incoming request bodies on data-plane routes
 	// (/v1/*) and admin write endpoints. Requests that exceed the limit receive
 	// HTTP 413 Request Entity Too Large before any LLM call is attempted.
 	// 0 (the default when omitted) applies DefaultMaxRequestBytes (10 MiB), which
-	// is well above any realistic chat completion payload.
+	// is well above any realistic chat completion payload. The ferrogw server
+	// fills an omitted value from MAX_REQUEST_BODY_BYTES when that is set.
 	MaxRequestBytes int64 `json:"max_request_bytes,omitempty" yaml:"max_request_bytes,omitempty"`
+	// MaxStreamCaptureBytes bounds the text of a streamed response the
+	// gateway assembles, as the stream is forwarded, for the after_request
+	// plugins (request logs, cache) and usage estimates. Text past it is left
+	// out of that copy, which is then marked truncated; the client still
+	// receives the whole stream. 0 (the default when omitted) applies
+	// streamwrap.DefaultMaxCaptureBytes (1 MiB).
+	MaxStreamCaptureBytes int `json:"max_stream_capture_bytes,omitempty" yaml:"max_stream_capture_bytes,omitempty"`
 	// RequestTimeout bounds a single non-streaming request end to end — plugin
 	// stages, provider call, and every retry and fallback attempt combined — as a
 	// Go duration string (e.g. "30s"). Omitted or empty means no gateway-imposed
@@ -46,6 +64,11 @@ type Config struct {
 	// Aliases maps friendly model names (e.g. "fast", "smart") to concrete model IDs.
 	// Aliases are resolved before routing — they must not reference other aliases.
 	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	// Canaries sends a percentage of an alias's requests to other model
+	// versions, keyed by alias name, so a new version can take a slice of
+	// traffic before the alias is repointed. Requests not drawn to a canary
+	// go to the alias's own target.
+	Canaries map[string][]ModelCanary `json:"canaries,omitempty" yaml:"canaries,omitempty"`
 	// MCPServers configures external MCP tool servers for agentic tool calling.
 	// When set, the gateway injects discovered tools into every chat completion
 	// request and executes an agentic loop when the LLM returns tool_calls.
@@ -64,6 +87,362 @@ type Config struct {
 	// Compatibility configures how the gateway treats request parameters a
 	// target provider cannot express. Omitted (the default) means warn.
 	Compatibility CompatibilityConfig `json:"compatibility,omitempty" yaml:"compatibility,omitempty"`
+	// RateLimitTiers defines named limit sets (e.g. free, standard,
+	// enterprise) that API keys opt into by name. A key without a tier, or
+	// one naming a tier absent from this list, is not tier-limited.
+	RateLimitTiers []RateLimitTier `json:"rate_limit_tiers,omitempty" yaml:"rate_limit_tiers,omitempty"`
+	// ResponseValidation checks provider responses for malformed or partially
+	// filled payloads. Omitted, responses are passed on unchecked.
+	ResponseValidation *ResponseValidationConfig `json:"response_validation,omitempty" yaml:"response_validation,omitempty"`
+	// Residency tags workspaces and API keys with a data residency
+	// requirement their requests must be served within.
+	Residency *ResidencyConfig `json:"residency,omitempty" yaml:"residency,omitempty"`
+	// ContextTruncation shortens chat requests whose estimated prompt
+	// exceeds the target model's context window, per API key or workspace,
+	// instead of sending them to be refused. Omitted, no request is
+	// truncated.
+	ContextTruncation *ContextTruncationConfig `json:"context_truncation,omitempty" yaml:"context_truncation,omitempty"`
+	// Thinking caps the extended-thinking budget chat requests may ask for,
+	// per API key or workspace. Omitted, a request's thinking option is sent
+	// as asked.
+	Thinking *ThinkingConfig `json:"thinking,omitempty" yaml:"thinking,omitempty"`
+	// RequestDefaults fills in the chat parameters clients omit, per API key
+	// or workspace, so behavior can be tuned without a client release.
+	// Omitted, requests are sent as asked.
+	RequestDefaults *RequestDefaultsConfig `json:"request_defaults,omitempty" yaml:"request_defaults,omitempty"`
+	// Images limits the size of the inline (base64 data URI) images in chat
+	// requests. Omitted, images are forwarded as sent.
+	Images *ImageLimitsConfig `json:"images,omitempty" yaml:"images,omitempty"`
+	// SLOs defines latency and error objectives keyed by the model name
+	// callers send (usually an alias). The gateway tracks each over a
+	// rolling window, exports attainment and burn rate as metrics, and
+	// publishes an event when a burn rate crosses its threshold.
+	SLOs map[string]SLOConfig `json:"slos,omitempty" yaml:"slos,omitempty"`
+	// EmbeddingDimensions fixes the vector dimension of embeddings models,
+	// keyed by the model name callers send (usually an alias), so that a
+	// fallback to another provider cannot hand a vector store vectors of
+	// another size.
+	EmbeddingDimensions map[string]EmbeddingDimensionPolicy `json:"embedding_dimensions,omitempty" yaml:"embedding_dimensions,omitempty"`
+	// EvalSuites defines evaluation suites that the admin API runs on demand
+	// against one or more models, scoring each model's answers.
+	EvalSuites []EvalSuite `json:"eval_suites,omitempty" yaml:"eval_suites,omitempty"`
+	// Experiments compare a candidate model's answers with a primary
+	// model's on a sample of live traffic; the admin API reports the
+	// comparison.
+	Experiments []Experiment `json:"experiments,omitempty" yaml:"experiments,omitempty"`
+	// CORS defines cross-origin policies per route. When it lists any policy
+	// it replaces the flat CORS_ORIGINS allowlist. It is read when the server
+	// starts; changing it takes a restart.
+	CORS []CORSPolicy `json:"cors,omitempty" yaml:"cors,omitempty"`
+	// Idempotency configures replay of responses for requests sent with an
+	// Idempotency-Key header. Omitted, responses are kept for
+	// DefaultIdempotencyTTL. It is read when the server starts.
+	Idempotency *IdempotencyConfig `json:"idempotency,omitempty" yaml:"idempotency,omitempty"`
+	// Admission bounds how many data-plane requests the gateway serves at
+	// once, queueing the excess for a limited time. Omitted, requests are
+	// admitted without limit. It is read when the server starts.
+	Admission *AdmissionConfig `json:"admission,omitempty" yaml:"admission,omitempty"`
+	// Sessions configures where Assistants-style threads are kept and the
+	// replica affinity cookie, for deployments running several replicas
+	// behind a load balancer. Omitted, each replica keeps its own threads in
+	// memory. It is read when the server starts.
+	Sessions *SessionsConfig `json:"sessions,omitempty" yaml:"sessions,omitempty"`
+	// ExposeProviderErrors appends a failed provider call's raw response body,
+	// with credentials redacted, to the error returned to the client and
+	// written to the request log. It helps debug provider 4xx errors, whose
+	// wrapped message often drops the detail, but the body can echo request
+	// content, so leave it off in production.
+	ExposeProviderErrors bool `json:"expose_provider_errors,omitempty" yaml:"expose_provider_errors,omitempty"`
+	// ExposeUpstreamHeaders forwards the rate-limit and request-ID headers of
+	// the provider response (x-ratelimit-*, anthropic-ratelimit-*,
+	// Retry-After, request IDs) to the client as X-Ferro-Upstream-* headers.
+	// They are written to the request log either way. They describe the
+	// gateway's provider account, so they are off by default.
+	ExposeUpstreamHeaders bool `json:"expose_upstream_headers,omitempty" yaml:"expose_upstream_headers,omitempty"`
+	// ExposePluginMetadata allowlists the response headers and fields plugins
+	// may declare for the client. Omitted, plugins' declarations stay in the
+	// gateway.
+	ExposePluginMetadata *PluginMetadataExposure `json:"expose_plugin_metadata,omitempty" yaml:"expose_plugin_metadata,omitempty"`
+	// MaxConcurrentStreams caps the streaming responses open at once across
+	// every caller, so long-lived streams cannot take all of the server's
+	// connections. A stream past it gets HTTP 429. 0 (the default) leaves
+	// streams unlimited gateway-wide; per-key caps come from
+	// RateLimitTier.MaxConcurrentStreams.
+	MaxConcurrentStreams int `json:"max_concurrent_streams,omitempty" yaml:"max_concurrent_streams,omitempty"`
+}
+
+// ModelCanary is one model version an alias sends a share of its traffic to.
+type ModelCanary struct {
+	// Model is the concrete model ID that serves the share. It must not be
+	// an alias.
+	Model string `json:"model" yaml:"model"`
+	// Percent is the share of the alias's requests it serves, in (0, 100].
+	// An alias's canaries may not add up to more than 100.
+	Percent float64 `json:"percent" yaml:"percent"`
+}
+
+// AdmissionConfig is the gateway-wide admission queue. Requests beyond
+// MaxInFlight wait in a queue of QueueSize for up to MaxWait; a request that
+// finds the queue full, or waits out MaxWait, gets 503 with Retry-After.
+type AdmissionConfig struct {
+	// MaxInFlight is how many requests may be served at once. Required.
+	MaxInFlight int `json:"max_in_flight" yaml:"max_in_flight"`
+	// QueueSize is how many requests may wait for a slot. 0 defaults to
+	// MaxInFlight.
+	QueueSize int `json:"queue_size,omitempty" yaml:"queue_size,omitempty"`
+	// MaxWait is how long a request may wait for a slot, as a Go duration
+	// string. Omitted means DefaultAdmissionMaxWait.
+	MaxWait string `json:"max_wait,omitempty" yaml:"max_wait,omitempty"`
+}
+
+// DefaultAdmissionMaxWait is how long a request waits for an admission slot
+// when the config does not set admission.max_wait.
+const DefaultAdmissionMaxWait = 10 * time.Second
+
+// MaxWaitDuration returns how long a request may queue. It assumes the config
+// has passed ValidateConfig.
+func (c *AdmissionConfig) MaxWaitDuration() time.Duration {
+	if c.MaxWait == "" {
+		return DefaultAdmissionMaxWait
+	}
+	d, _ := time.ParseDuration(c.MaxWait)
+	return d
+}
+
+// IdempotencyConfig controls how long responses to requests carrying an
+// Idempotency-Key header are kept for replay.
+type IdempotencyConfig struct {
+	// TTL is how long a stored response is replayed for retries with the same
+	// key, as a Go duration string (e.g. "1h"). "0" disables replay.
+	TTL string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// MaxEntries and MaxBytes bound the keys held and the response bytes
+	// stored; past either, the oldest responses are forgotten early. Zero
+	// uses the defaults (10000 keys, 64 MiB).
+	MaxEntries int   `json:"max_entries,omitempty" yaml:"max_entries,omitempty"`
+	MaxBytes   int64 `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+}
+
+// DefaultIdempotencyTTL is how long responses are replayed when the config
+// does not set idempotency.ttl.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// TTLDuration returns the replay window; 0 means replay is disabled. It
+// assumes the config has passed ValidateConfig.
+func (c *IdempotencyConfig) TTLDuration() time.Duration {
+	if c == nil || c.TTL == "" {
+		return DefaultIdempotencyTTL
+	}
+	d, _ := time.ParseDuration(c.TTL)
+	return d
+}
+
+// Session store backends accepted in SessionsConfig.Store.
+const (
+	SessionStoreMemory = "memory"
+	SessionStoreRedis  = "redis"
+)
+
+// DefaultSessionKeyPrefix prefixes the Redis keys of the redis session store
+// when the config does not set sessions.key_prefix.
+const DefaultSessionKeyPrefix = "ferro:threads:"
+
+// SessionsConfig controls the storage of session state shared by replicas.
+type SessionsConfig struct {
+	// Store is "memory" (the default), keeping threads in the replica that
+	// created them, or "redis", sharing them between every replica using
+	// the same RedisURL.
+	Store string `json:"store,omitempty" yaml:"store,omitempty"`
+	// RedisURL is the redis:// or rediss:// URL of the redis store.
+	RedisURL string `json:"redis_url,omitempty" yaml:"redis_url,omitempty"`
+	// KeyPrefix prefixes the store's Redis keys; it defaults to
+	// DefaultSessionKeyPrefix.
+	KeyPrefix string `json:"key_prefix,omitempty" yaml:"key_prefix,omitempty"`
+	// TTL is how long the redis store keeps a thread after its last change,
+	// as a Go duration string; it defaults to 720h.
+	TTL string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// MaxMessagesPerThread, MaxRunsPerThread and MaxThreadsPerKey cap what
+	// one API key can store; a request past a cap is answered 400. Zero
+	// uses the defaults of 1000 each.
+	MaxMessagesPerThread int `json:"max_messages_per_thread,omitempty" yaml:"max_messages_per_thread,omitempty"`
+	MaxRunsPerThread     int `json:"max_runs_per_thread,omitempty" yaml:"max_runs_per_thread,omitempty"`
+	MaxThreadsPerKey     int `json:"max_threads_per_key,omitempty" yaml:"max_threads_per_key,omitempty"`
+	// Affinity, when set, has every data-plane response name the replica
+	// that served it, so a load balancer can pin a client's later requests
+	// to the same replica.
+	Affinity *AffinityConfig `json:"affinity,omitempty" yaml:"affinity,omitempty"`
+}
+
+// TTLDuration returns the redis store's thread TTL; 0 means the store's
+// default. It assumes the config has passed ValidateConfig.
+func (c *SessionsConfig) TTLDuration() time.Duration {
+	if c == nil || c.TTL == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(c.TTL)
+	return d
+}
+
+// DefaultAffinityCookie is the affinity cookie's name when the config does not
+// set sessions.affinity.cookie.
+const DefaultAffinityCookie = "ferro_affinity"
+
+// AffinityConfig names the replica behind each response. The replica ID is
+// sent in the X-Ferro-Replica header and in a cookie, which a load balancer
+// can hash or match on for sticky sessions.
+type AffinityConfig struct {
+	// Cookie is the cookie's name; it defaults to DefaultAffinityCookie.
+	Cookie string `json:"cookie,omitempty" yaml:"cookie,omitempty"`
+	// ReplicaID identifies this replica; it defaults to the host name, which
+	// is the pod name on Kubernetes.
+	ReplicaID string `json:"replica_id,omitempty" yaml:"replica_id,omitempty"`
+}
+
+// CORSPolicy is the cross-origin policy for the routes under one path prefix.
+// Policies are checked in order and the first whose PathPrefix matches the
+// request path applies; a request matching none gets no CORS headers, so the
+// browser blocks it.
+type CORSPolicy struct {
+	// PathPrefix selects the routes the policy covers, e.g. "/admin" or
+	// "/v1". Empty matches every route.
+	PathPrefix string `json:"path_prefix,omitempty" yaml:"path_prefix,omitempty"`
+	// AllowedOrigins lists the exact origins (scheme://host[:port]) allowed
+	// cross-origin access, or "*" for any origin.
+	AllowedOrigins []string `json:"allowed_origins" yaml:"allowed_origins"`
+	// AllowedMethods defaults to GET, POST, PUT, DELETE, OPTIONS.
+	AllowedMethods []string `json:"allowed_methods,omitempty" yaml:"allowed_methods,omitempty"`
+	// AllowedHeaders defaults to Content-Type, Authorization, X-Provider.
+	AllowedHeaders []string `json:"allowed_headers,omitempty" yaml:"allowed_headers,omitempty"`
+	// ExposedHeaders lists response headers browser scripts may read, e.g.
+	// X-Ferro-Trace-Id.
+	ExposedHeaders []string `json:"exposed_headers,omitempty" yaml:"exposed_headers,omitempty"`
+	// AllowCredentials lets browsers send cookies and HTTP auth. It cannot be
+	// combined with the "*" origin.
+	AllowCredentials bool `json:"allow_credentials,omitempty" yaml:"allow_credentials,omitempty"`
+	// MaxAge is how long browsers may cache a preflight result, as a Go
+	// duration string. Empty defaults to 24h; "0s" leaves it to the browser.
+	MaxAge string `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+}
+
+// DefaultCORSMaxAge is the preflight cache lifetime of a CORS policy that
+// does not set max_age.
+const DefaultCORSMaxAge = 24 * time.Hour
+
+// MaxAgeDuration returns the policy's preflight cache lifetime. It assumes the
+// policy has passed ValidateConfig.
+func (p CORSPolicy) MaxAgeDuration() time.Duration {
+	if p.MaxAge == "" {
+		return DefaultCORSMaxAge
+	}
+	d, _ := time.ParseDuration(p.MaxAge)
+	return d
+}
+
+// RateLimitTier is one named set of per-key limits. Each limit applies to every
+// key assigned the tier, independently; zero leaves that dimension unlimited.
+type RateLimitTier struct {
+	// Name identifies the tier; API keys reference it.
+	Name string `json:"name" yaml:"name"`
+	// RequestsPerSecond is the sustained request rate allowed per key.
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty" yaml:"requests_per_second,omitempty"`
+	// Burst is the token-bucket capacity above the sustained rate. Zero
+	// defaults to RequestsPerSecond.
+	Burst float64 `json:"burst,omitempty" yaml:"burst,omitempty"`
+	// MaxConcurrentStreams caps the streaming responses a key may hold open
+	// at once. The gateway-wide cap, Config.MaxConcurrentStreams, applies
+	// as well.
+	MaxConcurrentStreams int `json:"max_concurrent_streams,omitempty" yaml:"max_concurrent_streams,omitempty"`
+	// MonthlyTokenLimit caps the total tokens a key may consume per calendar
+	// month (UTC). A request is admitted while the key is under the cap, so
+	// the request that crosses it completes and the next one is refused.
+	// The tally is kept in process memory, so the cap applies per gateway
+	// process: it is not shared between replicas and restarts at zero.
+	MonthlyTokenLimit int64 `json:"monthly_token_limit,omitempty" yaml:"monthly_token_limit,omitempty"`
+}
+
+// Eval scoring methods.
+const (
+	// EvalScoringExactMatch passes an answer equal to the expected one,
+	// ignoring surrounding whitespace.
+	EvalScoringExactMatch = "exact_match"
+	// EvalScoringRegex passes an answer the scoring pattern matches.
+	EvalScoringRegex = "regex"
+	// EvalScoringLLMJudge asks a judge model to grade the answer from 0 to
+	// 10 against the expected answer and the rubric.
+	EvalScoringLLMJudge = "llm_judge"
+)
+
+// EvalSuite is a named set of prompts and the method that scores a model's
+// answers to them. The prompts are the suite's Cases, a sample of recorded
+// request logs, or both.
+type EvalSuite struct {
+	// Name identifies the suite in the admin API.
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Cases are fixed prompts with their expected answers.
+	Cases []EvalCase `json:"cases,omitempty" yaml:"cases,omitempty"`
+	// FromLogs samples prompts from the request log. The answer recorded
+	// for each becomes its expected answer. Only requests logged with
+	// record_content enabled carry a prompt to replay.
+	FromLogs *EvalLogSample `json:"from_logs,omitempty" yaml:"from_logs,omitempty"`
+	Scoring  EvalScoring    `json:"scoring" yaml:"scoring"`
+}
+
+// EvalCase is one prompt of an eval suite.
+type EvalCase struct {
+	// ID names the case in results; it defaults to its position.
+	ID     string `json:"id,omitempty" yaml:"id,omitempty"`
+	System string `json:"system,omitempty" yaml:"system,omitempty"`
+	Prompt string `json:"prompt" yaml:"prompt"`
+	// Expected is the reference answer. exact_match and llm_judge use it;
+	// regex ignores it.
+	Expected string `json:"expected,omitempty" yaml:"expected,omitempty"`
+}
+
+// EvalLogSample selects recorded requests to replay as eval cases, newest
+// first. Empty filters match every request.
+type EvalLogSample struct {
+	// Limit caps the number of sampled requests. Zero means 50.
+	Limit    int    `json:"limit,omitempty" yaml:"limit,omitempty"`
+	Model    string `json:"model,omitempty" yaml:"model,omitempty"`
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	// Since is a Go duration (e.g. "24h") bounding how far back to sample.
+	Since string `json:"since,omitempty" yaml:"since,omitempty"`
+}
+
+// Experiment pairs a primary model with a candidate. A sampled
+// non-streaming request served by either one is sent to the other in the
+// background, as shadow traffic whose answer the client never sees, and the
+// two answers are compared. Requests served by the primary shadow the
+// candidate; canary requests served by the candidate shadow the primary.
+type Experiment struct {
+	// ID names the experiment in the admin API.
+	ID        string `json:"id" yaml:"id"`
+	Primary   string `json:"primary" yaml:"primary"`
+	Candidate string `json:"candidate" yaml:"candidate"`
+	// SamplePercent is the share of eligible requests shadowed, in
+	// (0, 100]. Zero means 100.
+	SamplePercent float64 `json:"sample_percent,omitempty" yaml:"sample_percent,omitempty"`
+	// Embedder is the embedding model used to score how similar the two
+	// answers are. Empty skips the similarity score.
+	Embedder string `json:"embedder,omitempty" yaml:"embedder,omitempty"`
+}
+
+// EvalScoring is how an eval suite scores an answer. Every method yields a
+// score from 0 to 1.
+type EvalScoring struct {
+	// Method is exact_match, regex, or llm_judge.
+	Method string `json:"method" yaml:"method"`
+	// Pattern is the regular expression for the regex method.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// JudgeModel is the model that grades answers for llm_judge. It is
+	// routed through the gateway like any other request.
+	JudgeModel string `json:"judge_model,omitempty" yaml:"judge_model,omitempty"`
+	// Rubric is added to the judge's instructions to say what a good answer
+	// looks like.
+	Rubric string `json:"rubric,omitempty" yaml:"rubric,omitempty"`
+	// PassScore is the score at or above which an llm_judge answer passes.
+	// Zero means 0.7.
+	PassScore float64 `json:"pass_score,omitempty" yaml:"pass_score,omitempty"`
 }
 
 // CompatibilityConfig controls the gateway's handling of OpenAI request
@@ -73,10 +452,49 @@ type CompatibilityConfig struct {
 	// OnUnsupportedParam selects the behaviour for an unsupported parameter:
 	// "warn" (default) forwards it and logs, "drop" removes it from the
 	// upstream request and logs, and "reject" fails the request with HTTP 400.
-	// An empty value is treated as "warn".
+	// An empty value is treated as "warn". An extra_body parameter the
+	// provider does not accept is dropped under both "warn" and "drop".
 	OnUnsupportedParam string `json:"on_unsupported_param,omitempty" yaml:"on_unsupported_param,omitempty"`
+	// OnUnhonoredSeed selects the behaviour for a request that sets seed but
+	// is routed to a provider that does not produce reproducible output for
+	// it: "warn" (default) forwards the request and logs, and "reject" fails
+	// it with HTTP 400 so a fallback can move on to a deterministic target.
+	OnUnhonoredSeed string `json:"on_unhonored_seed,omitempty" yaml:"on_unhonored_seed,omitempty"`
+	// StripReasoningContent removes the model's reasoning text
+	// (reasoning_content on messages and stream deltas) from what is returned
+	// to clients, for clients that reject the field or should not see it.
+	// Plugins and the request log still see it.
+	StripReasoningContent bool `json:"strip_reasoning_content,omitempty" yaml:"strip_reasoning_content,omitempty"`
+	// RewriteAliasModel returns the alias a client asked for as the model of
+	// its response and stream chunks, instead of the model the alias resolved
+	// to, so clients stay unaware of the provider behind it. Plugins, the
+	// request log, and metrics still see the resolved model.
+	RewriteAliasModel bool `json:"rewrite_alias_model,omitempty" yaml:"rewrite_alias_model,omitempty"`
+}
+
+// ResponseValidationConfig controls the checks applied to each non-streaming
+// provider response: at least one choice, every choice carrying a role, and
+// non-negative token counts whose total covers prompt plus completion.
+type ResponseValidationConfig struct {
+	// Mode is "warn" to log and count an anomalous response and return it
+	// anyway, or "reject" to fail the attempt with a malformed-response error,
+	// which counts against the target's circuit breaker and lets a fallback
+	// move on. Empty disables validation.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// PayloadSampleRatio is the fraction (0.0–1.0) of anomalous responses
+	// logged with their payload's shape, its field paths and JSON types but
+	// no values; the rest are logged without it.
+	// Pointer so an explicit 0.0 is distinguishable from an omitted field;
+	// nil defaults to 0.1.
+	PayloadSampleRatio *float64 `json:"payload_sample_ratio,omitempty" yaml:"payload_sample_ratio,omitempty"`
 }
 
+// Response validation modes.
+const (
+	ResponseValidationWarn   = "warn"
+	ResponseValidationReject = "reject"
+)
+
 // Normalize applies config-level defaults in a single place. It is idempotent
 // and mutates the receiver. LoadConfig calls it after decoding so a loaded
 // Config carries its effective defaults; callers that build a Config
@@ -137,7 +555,8 @@ type ExporterConfig struct {
 	// Config is the exporter-specific configuration map. String values may
 	// reference environment variables using ${VAR} — only the braced form is a
 	// reference, a bare $ is literal data, and an undefined variable is an
-	// error. References are resolved when the exporter is constructed, not when
+	// error; a whole vault:// or awssm:// value is read from that secret
+	// manager. References are resolved when the exporter is constructed, not when
 	// the config is loaded, so the Config never carries a materialised secret
 	// into the config-history store or GET /admin/config. Resolved values are
 	// passed to Exporter.Init at gateway startup.
@@ -201,6 +620,53 @@ type StrategyConfig struct {
 	ContentConditions []ContentCondition `json:"content_conditions,omitempty" yaml:"content_conditions,omitempty"`
 	// ABVariants defines the weighted variants for the ab-test strategy.
 	ABVariants []ABVariantConfig `json:"ab_variants,omitempty" yaml:"ab_variants,omitempty"`
+	// RetryBudget caps the output one client request may generate across all
+	// of its retry and fallback attempts (optional).
+	RetryBudget *RetryBudgetConfig `json:"retry_budget,omitempty" yaml:"retry_budget,omitempty"`
+	// StreamTranscoding lets streaming requests use targets that cannot
+	// stream (optional).
+	StreamTranscoding *StreamTranscodingConfig `json:"stream_transcoding,omitempty" yaml:"stream_transcoding,omitempty"`
+}
+
+// Values of StreamTranscodingConfig.Chunking. An empty value means whole.
+const (
+	transcodeChunkWhole = "whole"
+	transcodeChunkWord  = "word"
+)
+
+// StreamTranscodingConfig makes a target whose provider cannot stream usable
+// for stream: true requests: the gateway calls Complete on it and sends the
+// finished response to the client as server-sent events. Without it such a
+// target is skipped for streaming requests, and a fallback chain whose
+// streaming-capable targets all fail has nowhere left to go.
+type StreamTranscodingConfig struct {
+	// Chunking is "whole" (default) to send the response as a single chunk,
+	// or "word" to send its content a word at a time.
+	Chunking string `json:"chunking,omitempty" yaml:"chunking,omitempty"`
+	// Pace is the delay between word chunks, as a Go duration string (e.g.
+	// "20ms"). Empty sends them back to back.
+	Pace string `json:"pace,omitempty" yaml:"pace,omitempty"`
+}
+
+// PaceDuration returns the delay between word chunks. It assumes the config
+// has passed ValidateConfig.
+func (c *StreamTranscodingConfig) PaceDuration() time.Duration {
+	d, _ := time.ParseDuration(c.Pace)
+	return d
+}
+
+// RetryBudgetConfig bounds what a retried or fallen-back request can cost. An
+// attempt that fails after the provider may already have generated output —
+// a timeout, a dropped connection, a malformed response — is charged the
+// output it was allowed, each attempt's max_tokens is capped at what is left,
+// and once nothing is left further attempts are refused with
+// ErrRetryBudgetExhausted. Zero fields are unbounded.
+type RetryBudgetConfig struct {
+	// MaxOutputTokens caps the output tokens across all attempts.
+	MaxOutputTokens int `json:"max_output_tokens,omitempty" yaml:"max_output_tokens,omitempty"`
+	// MaxCostUSD caps the output cost across all attempts, priced from the
+	// model catalog. It does not bound attempts against unpriced models.
+	MaxCostUSD float64 `json:"max_cost_usd,omitempty" yaml:"max_cost_usd,omitempty"`
 }
 
 // StrategyMode represents the routing strategy mode.
@@ -224,11 +690,299 @@ const (
 	unpricedStrategyAllow    = "allow"
 )
 
-// Condition represents a condition for conditional routing.
+// Condition represents a condition for conditional routing. It matches either
+// by Key and Value or, when Expr is set, by a CEL expression; see package
+// internal/routingrules for the variables an expression can read. A Window
+// limits either to a time window; a condition with only a Window matches every
+// request while the window is open.
 type Condition struct {
-	Key       string `json:"key" yaml:"key"`
-	Value     string `json:"value" yaml:"value"`
-	TargetKey string `json:"target_key" yaml:"target_key"`
+	Key   string `json:"key,omitempty" yaml:"key,omitempty"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Expr is a CEL expression returning bool, for example
+	// `tokens > 8000 && key.workspace == "research"`. It is compiled when the
+	// config is loaded.
+	Expr string `json:"expr,omitempty" yaml:"expr,omitempty"`
+	// Window, when set, makes the condition match only while it is open.
+	Window    *TimeWindow `json:"window,omitempty" yaml:"window,omitempty"`
+	TargetKey string      `json:"target_key" yaml:"target_key"`
+}
+
+// ResidencyConfig maps workspaces and API key IDs to the region their
+// requests must be served in, such as "eu" or "us". A target satisfies a
+// requirement when its region equals it or starts with it followed by "-", so
+// "eu" admits "eu-west" and "eu-central". Targets with no region satisfy none.
+// A requirement no healthy target satisfies fails the request rather than
+// routing it elsewhere.
+type ResidencyConfig struct {
+	// Workspaces maps a workspace to its requirement.
+	Workspaces map[string]string `json:"workspaces,omitempty" yaml:"workspaces,omitempty"`
+	// Keys maps an API key ID to its requirement, overriding its workspace's.
+	Keys map[string]string `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// Context truncation policies.
+const (
+	// TruncationDropOldest drops the oldest conversation turns until the
+	// prompt fits.
+	TruncationDropOldest = truncation.DropOldest
+	// TruncationKeepLast keeps the system messages and the last KeepLast
+	// turns, dropping older ones too if the prompt still does not fit.
+	TruncationKeepLast = truncation.KeepLast
+	// TruncationSummarize replaces the turns it must drop with a summary
+	// written by SummaryModel.
+	TruncationSummarize = truncation.Summarize
+	// TruncationNone sends the request whole, overriding a workspace's or
+	// the default policy.
+	TruncationNone = truncation.None
+)
+
+// DefaultTruncationReserveTokens is the room left for the reply when a
+// truncated request sets no max_tokens.
+const DefaultTruncationReserveTokens = 1024
+
+// ContextTruncationConfig picks the truncation policy of each request: its
+// API key's, else its workspace's, else Default. The prompt is estimated with
+// the gateway's token estimator, and a model missing from the catalog, or
+// without a context window there, is never truncated. System and developer
+// messages and the last message are always kept; a request that does not fit
+// even then is sent whole. A truncated response carries the
+// X-Ferro-Context-Truncated header.
+type ContextTruncationConfig struct {
+	// Default applies to requests no key or workspace policy covers.
+	Default *TruncationPolicy `json:"default,omitempty" yaml:"default,omitempty"`
+	// Workspaces maps a workspace to its policy.
+	Workspaces map[string]TruncationPolicy `json:"workspaces,omitempty" yaml:"workspaces,omitempty"`
+	// Keys maps an API key ID to its policy, overriding its workspace's.
+	Keys map[string]TruncationPolicy `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// TruncationPolicy is how an over-long request is shortened.
+type TruncationPolicy struct {
+	// Policy is TruncationDropOldest, TruncationKeepLast,
+	// TruncationSummarize, or TruncationNone.
+	Policy string `json:"policy" yaml:"policy"`
+	// KeepLast is how many non-system messages keep_last keeps. Required
+	// for keep_last.
+	KeepLast int `json:"keep_last,omitempty" yaml:"keep_last,omitempty"`
+	// SummaryModel writes summarize's summary of the dropped turns; a small,
+	// cheap model is the point. The summary is routed like a request of the
+	// caller's, so it must be servable under the caller's residency
+	// requirement. Required for summarize.
+	SummaryModel string `json:"summary_model,omitempty" yaml:"summary_model,omitempty"`
+	// ReserveTokens is the room left for the reply when the request sets no
+	// max_tokens. 0 means DefaultTruncationReserveTokens.
+	ReserveTokens int `json:"reserve_tokens,omitempty" yaml:"reserve_tokens,omitempty"`
+}
+
+// ThinkingConfig picks the thinking limit of each request: its key's, else
+// its workspace's, else the default.
+type ThinkingConfig struct {
+	// Default applies to requests no key or workspace limit covers.
+	Default *ThinkingLimit `json:"default,omitempty" yaml:"default,omitempty"`
+	// Workspaces maps a workspace to its limit.
+	Workspaces map[string]ThinkingLimit `json:"workspaces,omitempty" yaml:"workspaces,omitempty"`
+	// Keys maps an API key ID to its limit, overriding its workspace's.
+	Keys map[string]ThinkingLimit `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// ThinkingLimit bounds the thinking option of a request.
+type ThinkingLimit struct {
+	// MaxBudgetTokens caps budget_tokens: a larger budget is lowered to it,
+	// and thinking enabled without a budget is given it. It caps
+	// reasoning_effort too, at the effort the budget maps to: "low" up to
+	// 2048, "medium" up to 8192. 0 means no cap; otherwise it is at least
+	// 1024, the smallest budget Anthropic takes.
+	MaxBudgetTokens int `json:"max_budget_tokens,omitempty" yaml:"max_budget_tokens,omitempty"`
+}
+
+// SLOConfig is the service-level objective of one model name. A request is
+// good for the error indicator unless it failed upstream; a successful request
+// is good for the latency indicator when it answered within Latency — for a
+// stream, when its first token arrived within it. Requests canceled by the
+// client or refused as client errors (4xx other than 408 and 429) count for
+// neither.
+type SLOConfig struct {
+	// Latency is the latency threshold as a Go duration, such as "2s".
+	// Empty leaves latency untracked.
+	Latency string `json:"latency,omitempty" yaml:"latency,omitempty"`
+	// LatencyObjective is the share of successful requests that must answer
+	// within Latency, such as 0.95. Required with Latency.
+	LatencyObjective float64 `json:"latency_objective,omitempty" yaml:"latency_objective,omitempty"`
+	// ErrorObjective is the share of requests that must succeed, such as
+	// 0.999. 0 leaves errors untracked.
+	ErrorObjective float64 `json:"error_objective,omitempty" yaml:"error_objective,omitempty"`
+	// Window is the rolling window attainment is measured over, as a Go
+	// duration of at least a minute. Empty means DefaultSLOWindow.
+	Window string `json:"window,omitempty" yaml:"window,omitempty"`
+	// BurnRateThreshold is the burn rate at which the gateway publishes
+	// SubjectSLOBurnRateExceeded. 0 means DefaultSLOBurnRateThreshold.
+	BurnRateThreshold float64 `json:"burn_rate_threshold,omitempty" yaml:"burn_rate_threshold,omitempty"`
+	// MinRequests is how many requests the window must hold before a
+	// threshold crossing is published. 0 means DefaultSLOMinRequests.
+	MinRequests int `json:"min_requests,omitempty" yaml:"min_requests,omitempty"`
+}
+
+// SLO defaults.
+const (
+	DefaultSLOWindow            = time.Hour
+	DefaultSLOBurnRateThreshold = 2.0
+	DefaultSLOMinRequests       = 10
+)
+
+// LatencyDuration returns the latency threshold, or 0 when latency is
+// untracked. It assumes the config has been validated.
+func (c SLOConfig) LatencyDuration() time.Duration {
+	d, _ := time.ParseDuration(c.Latency)
+	return d
+}
+
+// WindowDuration returns the rolling window. It assumes the config has been
+// validated.
+func (c SLOConfig) WindowDuration() time.Duration {
+	if c.Window == "" {
+		return DefaultSLOWindow
+	}
+	d, _ := time.ParseDuration(c.Window)
+	return d
+}
+
+// RequestDefaultsConfig picks the parameter defaults of each request field by
+// field: its key's, else its workspace's, else the default's.
+type RequestDefaultsConfig struct {
+	// Default applies to requests no key or workspace default covers.
+	Default *RequestParamDefaults `json:"default,omitempty" yaml:"default,omitempty"`
+	// Workspaces maps a workspace to its defaults.
+	Workspaces map[string]RequestParamDefaults `json:"workspaces,omitempty" yaml:"workspaces,omitempty"`
+	// Keys maps an API key ID to its defaults, overriding its workspace's.
+	Keys map[string]RequestParamDefaults `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// RequestParamDefaults are the values given to a chat request that does not
+// set them itself.
+type RequestParamDefaults struct {
+	// Temperature is used when the request sets none.
+	Temperature *float64 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	// MaxTokens is used when the request sets neither max_tokens nor
+	// max_completion_tokens. 0 means no default.
+	MaxTokens int `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+	// SystemPrompt is prepended as a system message when the request has no
+	// system or developer message.
+	SystemPrompt string `json:"system_prompt,omitempty" yaml:"system_prompt,omitempty"`
+}
+
+// DefaultImageJPEGQuality is the quality downscaled images are re-encoded at
+// as JPEG when the config does not set images.jpeg_quality.
+const DefaultImageJPEGQuality = 85
+
+// ImageLimitsConfig bounds inline images. Images given by URL are left to
+// the provider, which fetches them itself.
+type ImageLimitsConfig struct {
+	// MaxBytes caps an inline image's decoded size. 0 leaves it uncapped.
+	MaxBytes int `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+	// MaxDimension caps an inline image's width and height in pixels. Only
+	// JPEG, PNG, and GIF images are measured. 0 leaves them uncapped.
+	MaxDimension int `json:"max_dimension,omitempty" yaml:"max_dimension,omitempty"`
+	// Downscale resizes and re-encodes an image over a limit until it fits,
+	// instead of rejecting the request. Formats other than JPEG, PNG, and
+	// GIF cannot be resized and are still rejected.
+	Downscale bool `json:"downscale,omitempty" yaml:"downscale,omitempty"`
+	// JPEGQuality is the quality (1-100) of re-encoded JPEG images; 0 uses
+	// DefaultImageJPEGQuality. Images with transparency are re-encoded as
+	// PNG.
+	JPEGQuality int `json:"jpeg_quality,omitempty" yaml:"jpeg_quality,omitempty"`
+	// Fetch bounds the image URLs the gateway downloads for targets with
+	// fetch_image_urls set. Omitted, the defaults apply.
+	Fetch *ImageFetchConfig `json:"fetch,omitempty" yaml:"fetch,omitempty"`
+}
+
+// Image URL fetching defaults, used when images.fetch omits a limit.
+const (
+	DefaultImageFetchMaxBytes  = 10 << 20
+	DefaultImageFetchMaxImages = 8
+	DefaultImageFetchTimeout   = 10 * time.Second
+)
+
+// PluginMetadataExposure names what plugins may send to the client. A plugin
+// declares headers and fields freely; only the allowlisted ones leave the
+// gateway, so a plugin cannot leak internals an operator did not clear.
+type PluginMetadataExposure struct {
+	// Headers are the X- response headers plugins may set, such as
+	// X-Ferro-Guardrail. A streamed response carries those declared before
+	// the stream starts.
+	Headers []string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Fields are the keys plugins may add to the plugin_metadata object of
+	// a non-streaming JSON response.
+	Fields []string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// ImageFetchConfig bounds image URL fetching. Whatever it allows, the gateway
+// never connects to loopback, private, link-local, or other non-public
+// addresses, so a request cannot use it to reach internal services.
+type ImageFetchConfig struct {
+	// AllowedHosts limits fetching to these hosts; "*.example.com" matches
+	// any subdomain of example.com. Empty allows any public host.
+	AllowedHosts []string `json:"allowed_hosts,omitempty" yaml:"allowed_hosts,omitempty"`
+	// AllowHTTP permits plain http:// URLs; only https:// URLs are fetched
+	// otherwise.
+	AllowHTTP bool `json:"allow_http,omitempty" yaml:"allow_http,omitempty"`
+	// MaxBytes caps a fetched image's size; 0 uses
+	// DefaultImageFetchMaxBytes. The byte and dimension limits of images
+	// apply to the fetched image as well.
+	MaxBytes int `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+	// MaxImages caps how many image URLs one request may have fetched,
+	// across its retries and fallbacks, which reuse what was fetched; 0
+	// uses DefaultImageFetchMaxImages.
+	MaxImages int `json:"max_images,omitempty" yaml:"max_images,omitempty"`
+	// Timeout bounds each fetch, as a Go duration string; it defaults to
+	// DefaultImageFetchTimeout.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// TimeoutDuration returns the fetch timeout. It assumes the config has passed
+// ValidateConfig.
+func (c *ImageFetchConfig) TimeoutDuration() time.Duration {
+	if c == nil || c.Timeout == "" {
+		return DefaultImageFetchTimeout
+	}
+	d, _ := time.ParseDuration(c.Timeout)
+	return d
+}
+
+// Embedding dimension mismatch handling.
+const (
+	// EmbeddingMismatchReject fails the target that returned vectors of the
+	// wrong dimension, so a fallback target is tried.
+	EmbeddingMismatchReject = "reject"
+	// EmbeddingMismatchPadTruncate zero-pads short vectors and truncates
+	// long ones, re-normalizing them to unit length, logging a warning.
+	EmbeddingMismatchPadTruncate = "pad_truncate"
+)
+
+// EmbeddingDimensionPolicy fixes the dimension of an embeddings model's
+// vectors. The dimension is requested from every provider that can honour the
+// dimensions parameter, replacing any the caller sent, and each returned
+// vector is checked against it.
+type EmbeddingDimensionPolicy struct {
+	// Dimensions is the required vector length.
+	Dimensions int `json:"dimensions" yaml:"dimensions"`
+	// OnMismatch is EmbeddingMismatchReject (the default) or
+	// EmbeddingMismatchPadTruncate. Resizing keeps the store accepting the
+	// vectors; it does not make vectors of different models comparable.
+	OnMismatch string `json:"on_mismatch,omitempty" yaml:"on_mismatch,omitempty"`
+}
+
+// TimeWindow is a recurring weekly time window for a routing condition.
+type TimeWindow struct {
+	// Timezone is an IANA timezone name such as "America/New_York". Empty
+	// means UTC.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	// Days lists the weekdays the window opens on, "mon" through "sun".
+	// Empty means every day.
+	Days []string `json:"days,omitempty" yaml:"days,omitempty"`
+	// Start and End are "HH:MM" local times; End may be "24:00". An End
+	// earlier than Start closes the window the next morning.
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
 }
 
 // ContentCondition maps a prompt-content matching rule to a routing target.
@@ -272,6 +1026,56 @@ type Target struct {
 	CircuitBreaker *CircuitBreakerConfig `json:"circuit_breaker,omitempty" yaml:"circuit_breaker,omitempty"`
 	// Concurrency bounds simultaneous in-flight requests to this target (optional).
 	Concurrency *ConcurrencyConfig `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	// Region is the region the target serves from, such as "us-east" or "eu".
+	// Requests prefer targets in their origin region and fall back to other
+	// regions only when none of those is healthy. A target with no region is
+	// treated as local to every region.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	// State takes the target out of service for maintenance: TargetStateDraining
+	// or TargetStateDisabled. Empty means active.
+	State string `json:"state,omitempty" yaml:"state,omitempty"`
+	// StreamOnly calls the target's provider streaming even for non-streaming
+	// requests, assembling the full response from the stream, for providers
+	// whose non-streaming API is missing, slower, or less reliable. It has no
+	// effect on a provider that cannot stream.
+	StreamOnly bool `json:"stream_only,omitempty" yaml:"stream_only,omitempty"`
+	// Params bounds the request parameters the target's provider receives,
+	// for deployments whose contract limits them (optional).
+	Params *TargetParams `json:"params,omitempty" yaml:"params,omitempty"`
+	// FetchImageURLs has the gateway download the image URLs in chat
+	// requests sent to this target and forward them inline as base64 data
+	// URIs, for providers that cannot fetch URLs themselves. Config.Images
+	// bounds what is fetched.
+	FetchImageURLs bool `json:"fetch_image_urls,omitempty" yaml:"fetch_image_urls,omitempty"`
+}
+
+// TargetParams is a per-target parameter policy, applied to every request
+// sent to the target after plugins have run, whatever the client asked for.
+type TargetParams struct {
+	// MaxTemperature lowers a higher temperature to this value. A request
+	// without a temperature keeps the provider's default.
+	MaxTemperature *float64 `json:"max_temperature,omitempty" yaml:"max_temperature,omitempty"`
+	// MaxTokens lowers max_tokens and max_completion_tokens to this value,
+	// and sets max_tokens on requests that carry neither. Zero means no cap.
+	MaxTokens int `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+	// ForbiddenTools lists function names removed from the request's tools.
+	ForbiddenTools []string `json:"forbidden_tools,omitempty" yaml:"forbidden_tools,omitempty"`
+}
+
+// Target states. A draining or disabled target receives no new requests,
+// including through the registry fallback for models it serves. Draining
+// marks a target on its way out, such as during key rotation: requests and
+// streams already in flight finish normally. Disabled marks one that is down
+// for longer; disabling a target through Gateway.SetTargetState also cancels
+// its requests and streams in flight.
+const (
+	TargetStateDraining = "draining"
+	TargetStateDisabled = "disabled"
+)
+
+// InService reports whether t accepts new requests.
+func (t Target) InService() bool {
+	return t.State == ""
 }
 
 // ConcurrencyConfig bounds how many requests may be in flight against a single
@@ -287,6 +1091,11 @@ type ConcurrencyConfig struct {
 	// reached. Requests beyond it fail fast with HTTP 429 rather than blocking.
 	// 0 (the default when omitted) applies DefaultConcurrencyQueueSize.
 	QueueSize int `json:"queue_size,omitempty" yaml:"queue_size,omitempty"`
+	// TenantWeights sets each tenant's share of the slots that free up while
+	// requests are queued, keyed by workspace, or by API key ID for keys with
+	// no workspace. Waiting tenants are served in weighted round-robin order;
+	// a tenant not listed has weight 1.
+	TenantWeights map[string]int `json:"tenant_weights,omitempty" yaml:"tenant_weights,omitempty"`
 }
 
 // RetryConfig defines retry behavior for the fallback strategy.
@@ -325,12 +1134,17 @@ type CircuitBreakerConfig struct {
 // PluginConfig holds plugin configuration. String values in Config may
 // reference environment variables using ${VAR} — only the braced form is a
 // reference, a bare $ is literal data, and an undefined variable is an error.
-// References are resolved when the plugin is constructed, not when the config
-// is loaded, so a secret never reaches the config-history store.
+// A value that is a whole vault:// or awssm:// reference is read from that
+// secret manager. References are resolved when the plugin is constructed, not
+// when the config is loaded, so a secret never reaches the config-history
+// store.
 type PluginConfig struct {
 	Name    string         `json:"name" yaml:"name"`
 	Type    string         `json:"type" yaml:"type"`
 	Stage   string         `json:"stage" yaml:"stage"`
 	Enabled bool           `json:"enabled" yaml:"enabled"`
 	Config  map[string]any `json:"config" yaml:"config"`
+	// Match limits the plugin to requests for particular models, keys, key
+	// scopes, or workspaces. Omitted, the plugin applies to all traffic.
+	Match *plugin.Match `json:"match,omitempty" yaml:"match,omitempty"`
 }
@@ -7,11 +7,15 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/routingrules"
 	"github.com/ferro-labs/ai-gateway/internal/tracingpolicy"
 	pubmcp "github.com/ferro-labs/ai-gateway/mcp"
 	"github.com/ferro-labs/ai-gateway/providers/core"
@@ -33,7 +37,13 @@ func LoadConfig(path string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("reading config file: %w", err)
 	}
+	return ParseConfig(path, data)
+}
 
+// ParseConfig parses data, the contents of the config file at path, as
+// LoadConfig does. It lets a caller check the bytes it read, such as their
+// signature, before they are parsed.
+func ParseConfig(path string, data []byte) (*Config, error) {
 	var cfg Config
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
@@ -99,6 +109,137 @@ func ValidateConfig(cfg Config) error {
 		if err := validateTargetConcurrency(t); err != nil {
 			return err
 		}
+		switch t.State {
+		case "", TargetStateDraining, TargetStateDisabled:
+		default:
+			return fmt.Errorf("target %q: state must be one of draining, disabled (or empty for active)", t.VirtualKey)
+		}
+		if err := validateTargetParams(t); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Residency != nil {
+		for _, tags := range []map[string]string{cfg.Residency.Workspaces, cfg.Residency.Keys} {
+			for name, requirement := range tags {
+				if strings.TrimSpace(requirement) == "" {
+					return fmt.Errorf("residency: %q has an empty requirement", name)
+				}
+			}
+		}
+	}
+
+	for model, p := range cfg.EmbeddingDimensions {
+		if p.Dimensions <= 0 {
+			return fmt.Errorf("embedding_dimensions[%q]: dimensions must be positive", model)
+		}
+		switch p.OnMismatch {
+		case "", EmbeddingMismatchReject, EmbeddingMismatchPadTruncate:
+		default:
+			return fmt.Errorf("embedding_dimensions[%q]: on_mismatch must be one of reject, pad_truncate, got %q", model, p.OnMismatch)
+		}
+	}
+
+	if ct := cfg.ContextTruncation; ct != nil {
+		if ct.Default != nil {
+			if err := validateTruncationPolicy(*ct.Default); err != nil {
+				return fmt.Errorf("context_truncation.default: %w", err)
+			}
+		}
+		for _, scope := range []struct {
+			name     string
+			policies map[string]TruncationPolicy
+		}{{"workspaces", ct.Workspaces}, {"keys", ct.Keys}} {
+			for name, p := range scope.policies {
+				if err := validateTruncationPolicy(p); err != nil {
+					return fmt.Errorf("context_truncation.%s[%q]: %w", scope.name, name, err)
+				}
+			}
+		}
+	}
+
+	if th := cfg.Thinking; th != nil {
+		if th.Default != nil {
+			if err := validateThinkingLimit(*th.Default); err != nil {
+				return fmt.Errorf("thinking.default: %w", err)
+			}
+		}
+		for _, scope := range []struct {
+			name   string
+			limits map[string]ThinkingLimit
+		}{{"workspaces", th.Workspaces}, {"keys", th.Keys}} {
+			for name, l := range scope.limits {
+				if err := validateThinkingLimit(l); err != nil {
+					return fmt.Errorf("thinking.%s[%q]: %w", scope.name, name, err)
+				}
+			}
+		}
+	}
+
+	if img := cfg.Images; img != nil {
+		if img.MaxBytes < 0 {
+			return fmt.Errorf("images.max_bytes must not be negative, got %d", img.MaxBytes)
+		}
+		if img.MaxDimension < 0 {
+			return fmt.Errorf("images.max_dimension must not be negative, got %d", img.MaxDimension)
+		}
+		if img.JPEGQuality < 0 || img.JPEGQuality > 100 {
+			return fmt.Errorf("images.jpeg_quality must be between 1 and 100, got %d", img.JPEGQuality)
+		}
+		if f := img.Fetch; f != nil {
+			if f.MaxBytes < 0 {
+				return fmt.Errorf("images.fetch.max_bytes must not be negative, got %d", f.MaxBytes)
+			}
+			if f.MaxImages < 0 {
+				return fmt.Errorf("images.fetch.max_images must not be negative, got %d", f.MaxImages)
+			}
+			if f.Timeout != "" {
+				if d, err := time.ParseDuration(f.Timeout); err != nil || d <= 0 {
+					return fmt.Errorf("images.fetch.timeout must be a positive duration, got %q", f.Timeout)
+				}
+			}
+			for _, h := range f.AllowedHosts {
+				if strings.TrimPrefix(h, "*.") == "" || strings.ContainsAny(h, "/:@ ") {
+					return fmt.Errorf("images.fetch.allowed_hosts: %q is not a host name", h)
+				}
+			}
+		}
+	}
+	if err := ValidateRequestDefaults(cfg.RequestDefaults); err != nil {
+		return err
+	}
+	if e := cfg.ExposePluginMetadata; e != nil {
+		for _, h := range e.Headers {
+			if !validToken(h) || len(h) < 3 || !strings.EqualFold(h[:2], "x-") {
+				return fmt.Errorf("expose_plugin_metadata.headers: %q is not an X- extension header", h)
+			}
+		}
+		for _, f := range e.Fields {
+			if f == "" {
+				return errors.New("expose_plugin_metadata.fields: field names must not be empty")
+			}
+		}
+	}
+
+	for name, slo := range cfg.SLOs {
+		if err := validateSLO(slo); err != nil {
+			return fmt.Errorf("slos[%q]: %w", name, err)
+		}
+	}
+
+	if rv := cfg.ResponseValidation; rv != nil {
+		switch rv.Mode {
+		case "", ResponseValidationWarn, ResponseValidationReject:
+		default:
+			return fmt.Errorf("response_validation.mode must be warn or reject, got %q", rv.Mode)
+		}
+		if r := rv.PayloadSampleRatio; r != nil && (*r < 0 || *r > 1) {
+			return fmt.Errorf("response_validation.payload_sample_ratio must be between 0 and 1, got %v", *r)
+		}
+	}
+
+	if cfg.MaxStreamCaptureBytes < 0 {
+		return fmt.Errorf("max_stream_capture_bytes must not be negative, got %d", cfg.MaxStreamCaptureBytes)
 	}
 
 	if cfg.RequestTimeout != "" {
@@ -111,9 +252,54 @@ func ValidateConfig(cfg Config) error {
 		}
 	}
 
+	if idem := cfg.Idempotency; idem != nil && idem.TTL != "" {
+		if d, err := time.ParseDuration(idem.TTL); err != nil || d < 0 {
+			return fmt.Errorf("idempotency.ttl must be a non-negative duration, got %q", idem.TTL)
+		}
+	}
+	if idem := cfg.Idempotency; idem != nil && (idem.MaxEntries < 0 || idem.MaxBytes < 0) {
+		return fmt.Errorf("idempotency.max_entries and max_bytes must not be negative")
+	}
+
+	if s := cfg.Sessions; s != nil {
+		if err := validateSessions(*s); err != nil {
+			return fmt.Errorf("sessions: %w", err)
+		}
+	}
+
+	if adm := cfg.Admission; adm != nil {
+		if adm.MaxInFlight <= 0 {
+			return fmt.Errorf("admission.max_in_flight must be positive, got %d", adm.MaxInFlight)
+		}
+		if adm.QueueSize < 0 {
+			return fmt.Errorf("admission.queue_size must not be negative, got %d", adm.QueueSize)
+		}
+		if adm.MaxWait != "" {
+			if d, err := time.ParseDuration(adm.MaxWait); err != nil || d < 0 {
+				return fmt.Errorf("admission.max_wait must be a non-negative duration, got %q", adm.MaxWait)
+			}
+		}
+	}
+
 	if cfg.Strategy.Mode == ModeConditional && len(cfg.Strategy.Conditions) == 0 {
 		return fmt.Errorf("conditional strategy requires at least one condition")
 	}
+	for i, cond := range cfg.Strategy.Conditions {
+		if cond.Window != nil {
+			if _, err := parseConditionWindow(cond.Window); err != nil {
+				return fmt.Errorf("condition %d: %w", i, err)
+			}
+		}
+		if cond.Expr == "" {
+			continue
+		}
+		if cond.Key != "" {
+			return fmt.Errorf("condition %d: set either key or expr, not both", i)
+		}
+		if _, err := routingrules.Compile(cond.Expr); err != nil {
+			return fmt.Errorf("condition %d: %w", i, err)
+		}
+	}
 
 	if cfg.Strategy.Mode == ModeContentBased && len(cfg.Strategy.ContentConditions) == 0 {
 		return fmt.Errorf("content-based strategy requires at least one content_condition")
@@ -131,6 +317,23 @@ func ValidateConfig(cfg Config) error {
 		}
 	}
 
+	if rb := cfg.Strategy.RetryBudget; rb != nil && (rb.MaxOutputTokens < 0 || rb.MaxCostUSD < 0) {
+		return fmt.Errorf("strategy.retry_budget limits must not be negative")
+	}
+
+	if st := cfg.Strategy.StreamTranscoding; st != nil {
+		switch st.Chunking {
+		case "", transcodeChunkWhole, transcodeChunkWord:
+		default:
+			return fmt.Errorf("strategy.stream_transcoding.chunking must be one of whole, word")
+		}
+		if st.Pace != "" {
+			if d, err := time.ParseDuration(st.Pace); err != nil || d < 0 {
+				return fmt.Errorf("strategy.stream_transcoding.pace must be a non-negative duration, got %q", st.Pace)
+			}
+		}
+	}
+
 	if cfg.Strategy.Mode == ModeLoadBalance {
 		var sum float64
 		for _, t := range cfg.Targets {
@@ -154,6 +357,11 @@ func ValidateConfig(cfg Config) error {
 	if _, ok := core.ParseUnsupportedParamMode(cfg.Compatibility.OnUnsupportedParam); !ok {
 		return fmt.Errorf("compatibility.on_unsupported_param must be one of warn, drop, reject")
 	}
+	switch cfg.Compatibility.OnUnhonoredSeed {
+	case "", seedModeWarn, seedModeReject:
+	default:
+		return fmt.Errorf("compatibility.on_unhonored_seed must be one of warn, reject")
+	}
 
 	// Validate aliases: no alias may point to another alias (no cycles/chains).
 	for name, target := range cfg.Aliases {
@@ -171,13 +379,277 @@ func ValidateConfig(cfg Config) error {
 		}
 	}
 
+	if err := validateCanaries(cfg.Canaries, cfg.Aliases); err != nil {
+		return err
+	}
+
 	if err := validateMCPServers(cfg.MCPServers); err != nil {
 		return err
 	}
 
+	if cfg.MaxConcurrentStreams < 0 {
+		return fmt.Errorf("max_concurrent_streams cannot be negative, got %d", cfg.MaxConcurrentStreams)
+	}
+
+	if err := ValidateRateLimitTiers(cfg.RateLimitTiers); err != nil {
+		return err
+	}
+
+	if err := ValidateEvalSuites(cfg.EvalSuites); err != nil {
+		return err
+	}
+
+	if err := validateExperiments(cfg.Experiments); err != nil {
+		return err
+	}
+
+	if err := validateCORS(cfg.CORS); err != nil {
+		return err
+	}
+
+	for _, p := range cfg.Plugins {
+		if err := p.Match.Validate(); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateThinkingLimit checks a thinking limit: no cap, or a budget a
+// provider can be asked for.
+func validateThinkingLimit(l ThinkingLimit) error {
+	if l.MaxBudgetTokens != 0 && l.MaxBudgetTokens < core.MinThinkingBudget {
+		return fmt.Errorf("max_budget_tokens must be 0 or at least %d, got %d", core.MinThinkingBudget, l.MaxBudgetTokens)
+	}
 	return nil
 }
 
+// validateTruncationPolicy checks that p names a policy and carries the
+// setting that policy needs.
+func validateTruncationPolicy(p TruncationPolicy) error {
+	switch p.Policy {
+	case TruncationDropOldest, TruncationNone:
+	case TruncationKeepLast:
+		if p.KeepLast <= 0 {
+			return errors.New("keep_last requires a positive keep_last")
+		}
+	case TruncationSummarize:
+		if strings.TrimSpace(p.SummaryModel) == "" {
+			return errors.New("summarize requires summary_model")
+		}
+	default:
+		return fmt.Errorf("policy must be one of drop_oldest, keep_last, summarize, none, got %q", p.Policy)
+	}
+	if p.KeepLast < 0 || p.ReserveTokens < 0 {
+		return errors.New("keep_last and reserve_tokens cannot be negative")
+	}
+	return nil
+}
+
+// ValidateRateLimitTiers checks that every tier has a unique, non-empty name
+// and no negative limit. It is exported so the admin API can reject a bad tier
+// before building a whole config around it.
+func ValidateRateLimitTiers(tiers []RateLimitTier) error {
+	seen := make(map[string]struct{}, len(tiers))
+	for i, t := range tiers {
+		if strings.TrimSpace(t.Name) == "" {
+			return fmt.Errorf("rate limit tier at index %d: name is required", i)
+		}
+		if _, dup := seen[t.Name]; dup {
+			return fmt.Errorf("rate limit tier %q: duplicate name", t.Name)
+		}
+		seen[t.Name] = struct{}{}
+		if t.RequestsPerSecond < 0 || t.Burst < 0 || t.MaxConcurrentStreams < 0 || t.MonthlyTokenLimit < 0 {
+			return fmt.Errorf("rate limit tier %q: limits cannot be negative", t.Name)
+		}
+		if t.Burst > 0 && t.RequestsPerSecond == 0 {
+			return fmt.Errorf("rate limit tier %q: burst requires requests_per_second", t.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateEvalSuites checks that every suite has a unique, non-empty name,
+// something to run, and a scoring method it can apply. It is exported so the
+// admin API can reject a bad suite before building a whole config around it.
+func ValidateEvalSuites(suites []EvalSuite) error {
+	seen := make(map[string]struct{}, len(suites))
+	for i, s := range suites {
+		if strings.TrimSpace(s.Name) == "" {
+			return fmt.Errorf("eval suite at index %d: name is required", i)
+		}
+		if _, dup := seen[s.Name]; dup {
+			return fmt.Errorf("eval suite %q: duplicate name", s.Name)
+		}
+		seen[s.Name] = struct{}{}
+		if len(s.Cases) == 0 && s.FromLogs == nil {
+			return fmt.Errorf("eval suite %q: cases or from_logs is required", s.Name)
+		}
+		for j, c := range s.Cases {
+			if strings.TrimSpace(c.Prompt) == "" {
+				return fmt.Errorf("eval suite %q: case at index %d: prompt is required", s.Name, j)
+			}
+		}
+		if l := s.FromLogs; l != nil {
+			if l.Limit < 0 {
+				return fmt.Errorf("eval suite %q: from_logs.limit cannot be negative", s.Name)
+			}
+			if l.Since != "" {
+				if d, err := time.ParseDuration(l.Since); err != nil || d <= 0 {
+					return fmt.Errorf("eval suite %q: from_logs.since must be a positive duration", s.Name)
+				}
+			}
+		}
+		sc := s.Scoring
+		switch sc.Method {
+		case EvalScoringExactMatch:
+		case EvalScoringRegex:
+			if sc.Pattern == "" {
+				return fmt.Errorf("eval suite %q: scoring.pattern is required for regex", s.Name)
+			}
+			if _, err := regexp.Compile(sc.Pattern); err != nil {
+				return fmt.Errorf("eval suite %q: scoring.pattern: %w", s.Name, err)
+			}
+		case EvalScoringLLMJudge:
+			if sc.JudgeModel == "" {
+				return fmt.Errorf("eval suite %q: scoring.judge_model is required for llm_judge", s.Name)
+			}
+		default:
+			return fmt.Errorf("eval suite %q: scoring.method must be one of %s, %s, %s", s.Name, EvalScoringExactMatch, EvalScoringRegex, EvalScoringLLMJudge)
+		}
+		if sc.PassScore < 0 || sc.PassScore > 1 {
+			return fmt.Errorf("eval suite %q: scoring.pass_score must be between 0 and 1", s.Name)
+		}
+	}
+	return nil
+}
+
+// validateExperiments checks that every experiment has a unique ID and two
+// distinct models, and a sample share within (0, 100].
+func validateExperiments(experiments []Experiment) error {
+	seen := make(map[string]struct{}, len(experiments))
+	for i, e := range experiments {
+		if strings.TrimSpace(e.ID) == "" {
+			return fmt.Errorf("experiment at index %d: id is required", i)
+		}
+		if _, dup := seen[e.ID]; dup {
+			return fmt.Errorf("experiment %q: duplicate id", e.ID)
+		}
+		seen[e.ID] = struct{}{}
+		if e.Primary == "" || e.Candidate == "" {
+			return fmt.Errorf("experiment %q: primary and candidate are required", e.ID)
+		}
+		if e.Primary == e.Candidate {
+			return fmt.Errorf("experiment %q: primary and candidate must differ", e.ID)
+		}
+		if e.SamplePercent < 0 || e.SamplePercent > 100 {
+			return fmt.Errorf("experiment %q: sample_percent must be in (0, 100]", e.ID)
+		}
+	}
+	return nil
+}
+
+// validateCanaries checks that each canary set belongs to an alias, names
+// concrete models, and claims no more than all of the alias's traffic.
+func validateCanaries(canaries map[string][]ModelCanary, aliases map[string]string) error {
+	for alias, versions := range canaries {
+		if _, ok := aliases[alias]; !ok {
+			return fmt.Errorf("canaries for %q: %q is not an alias", alias, alias)
+		}
+		total := 0.0
+		for i, c := range versions {
+			if c.Model == "" {
+				return fmt.Errorf("canaries for %q: model at index %d is required", alias, i)
+			}
+			if _, chained := aliases[c.Model]; chained {
+				return fmt.Errorf("canaries for %q: model %q is an alias; canaries must name a concrete model", alias, c.Model)
+			}
+			if c.Percent <= 0 || c.Percent > 100 {
+				return fmt.Errorf("canaries for %q: percent for %q must be in (0, 100]", alias, c.Model)
+			}
+			total += c.Percent
+		}
+		if total > 100 {
+			return fmt.Errorf("canaries for %q: percents add up to %g, more than 100", alias, total)
+		}
+	}
+	return nil
+}
+
+// validateCORS checks each policy's origins, methods, headers, and max age,
+// and that no policy is shadowed by an earlier one with the same prefix.
+func validateCORS(policies []CORSPolicy) error {
+	seen := make(map[string]struct{}, len(policies))
+	for i, p := range policies {
+		where := fmt.Sprintf("cors policy at index %d", i)
+		if p.PathPrefix != "" {
+			if !strings.HasPrefix(p.PathPrefix, "/") {
+				return fmt.Errorf("%s: path_prefix must start with /", where)
+			}
+			where = fmt.Sprintf("cors policy %q", p.PathPrefix)
+		}
+		// The middleware matches on whole segments and ignores a trailing
+		// slash, so "/v1" and "/v1/" (or "" and "/") are the same prefix.
+		prefix := strings.TrimSuffix(p.PathPrefix, "/")
+		if _, dup := seen[prefix]; dup {
+			return fmt.Errorf("%s: duplicate path_prefix", where)
+		}
+		seen[prefix] = struct{}{}
+
+		if len(p.AllowedOrigins) == 0 {
+			return fmt.Errorf("%s: allowed_origins is required", where)
+		}
+		for _, origin := range p.AllowedOrigins {
+			if origin == "*" {
+				if p.AllowCredentials {
+					return fmt.Errorf("%s: allow_credentials cannot be combined with the * origin", where)
+				}
+				continue
+			}
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+				u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+				return fmt.Errorf("%s: origin %q must be scheme://host[:port] or *", where, origin)
+			}
+		}
+		for _, m := range p.AllowedMethods {
+			if !isHTTPToken(m) || strings.ToUpper(m) != m {
+				return fmt.Errorf("%s: invalid method %q", where, m)
+			}
+		}
+		for _, h := range slices.Concat(p.AllowedHeaders, p.ExposedHeaders) {
+			if !isHTTPToken(h) {
+				return fmt.Errorf("%s: invalid header name %q", where, h)
+			}
+		}
+		if p.MaxAge != "" {
+			if d, err := time.ParseDuration(p.MaxAge); err != nil || d < 0 {
+				return fmt.Errorf("%s: max_age must be a non-negative duration", where)
+			}
+		}
+	}
+	return nil
+}
+
+// isHTTPToken reports whether s is a non-empty RFC 9110 token, the syntax of
+// method and header names.
+func isHTTPToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // validateMCPServers checks each server's transport selection: exactly one of
 // URL (Streamable HTTP) or Command (stdio) must be set. Leaving both empty fails
 // later during async initialization with a confusing error; leaving both set
@@ -228,5 +700,137 @@ func validateTargetConcurrency(t Target) error {
 	if t.Concurrency.QueueSize > MaxTargetConcurrency {
 		return fmt.Errorf("target %q: concurrency.queue_size exceeds the limit of %d", t.VirtualKey, MaxTargetConcurrency)
 	}
+	for tenant, weight := range t.Concurrency.TenantWeights {
+		if weight <= 0 || weight > MaxTargetConcurrency {
+			return fmt.Errorf("target %q: concurrency.tenant_weights[%q] must be between 1 and %d", t.VirtualKey, tenant, MaxTargetConcurrency)
+		}
+	}
+	return nil
+}
+
+// ValidateRequestDefaults checks a request_defaults section on its own, so
+// the admin API can report a malformed default before applying it.
+func ValidateRequestDefaults(cfg *RequestDefaultsConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Default != nil {
+		if err := validateRequestParamDefaults(*cfg.Default); err != nil {
+			return fmt.Errorf("request_defaults.default: %w", err)
+		}
+	}
+	for _, scope := range []struct {
+		name     string
+		defaults map[string]RequestParamDefaults
+	}{{"workspaces", cfg.Workspaces}, {"keys", cfg.Keys}} {
+		for name, d := range scope.defaults {
+			if err := validateRequestParamDefaults(d); err != nil {
+				return fmt.Errorf("request_defaults.%s[%q]: %w", scope.name, name, err)
+			}
+		}
+	}
 	return nil
 }
+
+func validateSLO(c SLOConfig) error {
+	if c.Latency == "" && c.ErrorObjective == 0 {
+		return errors.New("set latency or error_objective")
+	}
+	if c.Latency != "" {
+		if d, err := time.ParseDuration(c.Latency); err != nil || d <= 0 {
+			return fmt.Errorf("latency must be a positive duration, got %q", c.Latency)
+		}
+		if c.LatencyObjective <= 0 || c.LatencyObjective >= 1 {
+			return fmt.Errorf("latency_objective must be between 0 and 1 (exclusive), got %v", c.LatencyObjective)
+		}
+	} else if c.LatencyObjective != 0 {
+		return errors.New("latency_objective requires latency")
+	}
+	if c.ErrorObjective < 0 || c.ErrorObjective >= 1 {
+		return fmt.Errorf("error_objective must be between 0 and 1 (exclusive), got %v", c.ErrorObjective)
+	}
+	if c.Window != "" {
+		if d, err := time.ParseDuration(c.Window); err != nil || d < time.Minute {
+			return fmt.Errorf("window must be a duration of at least 1m, got %q", c.Window)
+		}
+	}
+	if c.BurnRateThreshold < 0 {
+		return fmt.Errorf("burn_rate_threshold must not be negative, got %v", c.BurnRateThreshold)
+	}
+	if c.MinRequests < 0 {
+		return fmt.Errorf("min_requests must not be negative, got %d", c.MinRequests)
+	}
+	return nil
+}
+
+func validateRequestParamDefaults(d RequestParamDefaults) error {
+	if d.Temperature != nil && (*d.Temperature < 0 || *d.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2, got %v", *d.Temperature)
+	}
+	if d.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens must not be negative, got %d", d.MaxTokens)
+	}
+	return nil
+}
+
+func validateTargetParams(t Target) error {
+	if t.Params == nil {
+		return nil
+	}
+	if mt := t.Params.MaxTemperature; mt != nil && (*mt < 0 || *mt > 2) {
+		return fmt.Errorf("target %q: params.max_temperature must be between 0 and 2", t.VirtualKey)
+	}
+	if t.Params.MaxTokens < 0 {
+		return fmt.Errorf("target %q: params.max_tokens cannot be negative", t.VirtualKey)
+	}
+	for _, name := range t.Params.ForbiddenTools {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("target %q: params.forbidden_tools contains an empty name", t.VirtualKey)
+		}
+	}
+	return nil
+}
+
+func validateSessions(s SessionsConfig) error {
+	switch s.Store {
+	case "", SessionStoreMemory:
+		if s.RedisURL != "" {
+			return errors.New("redis_url requires store redis")
+		}
+	case SessionStoreRedis:
+		if s.RedisURL == "" {
+			return errors.New("redis_url is required with store redis")
+		}
+		if u, err := url.Parse(s.RedisURL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+			return errors.New("redis_url must be a redis:// or rediss:// URL")
+		}
+	default:
+		return fmt.Errorf("unknown store %q (want %q or %q)", s.Store, SessionStoreMemory, SessionStoreRedis)
+	}
+	if s.TTL != "" {
+		if d, err := time.ParseDuration(s.TTL); err != nil || d <= 0 {
+			return fmt.Errorf("ttl must be a positive duration, got %q", s.TTL)
+		}
+	}
+	if s.MaxMessagesPerThread < 0 || s.MaxRunsPerThread < 0 || s.MaxThreadsPerKey < 0 {
+		return errors.New("max_messages_per_thread, max_runs_per_thread and max_threads_per_key must not be negative")
+	}
+	if a := s.Affinity; a != nil && a.Cookie != "" && !validToken(a.Cookie) {
+		return fmt.Errorf("affinity.cookie %q is not a valid cookie name", a.Cookie)
+	}
+	return nil
+}
+
+// validToken reports whether name is an HTTP token, as cookie and header
+// names must be: printable ASCII without separators.
+func validToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`()<>@,;:\"/[]?={}`, r) {
+			return false
+		}
+	}
+	return true
+}
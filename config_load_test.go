@@ -99,6 +99,169 @@ func TestValidateConfig_NewStrategyModes(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_ConditionExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		cond    Condition
+		wantErr bool
+	}{
+		{name: "valid expr", cond: Condition{Expr: `tokens > 8000 && key.workspace == "research"`, TargetKey: "key1"}},
+		{name: "syntax error rejected", cond: Condition{Expr: `tokens >`, TargetKey: "key1"}, wantErr: true},
+		{name: "non-bool expr rejected", cond: Condition{Expr: `model`, TargetKey: "key1"}, wantErr: true},
+		{name: "key and expr rejected", cond: Condition{Key: "model", Value: "gpt-4o", Expr: `true`, TargetKey: "key1"}, wantErr: true},
+		{name: "valid window", cond: Condition{Window: &TimeWindow{Timezone: "UTC", Days: []string{"mon"}, Start: "22:00", End: "06:00"}, TargetKey: "key1"}},
+		{name: "invalid window rejected", cond: Condition{Window: &TimeWindow{Start: "22:00", End: "22:00"}, TargetKey: "key1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				Strategy: StrategyConfig{Mode: ModeConditional, Conditions: []Condition{tt.cond}},
+				Targets:  []Target{{VirtualKey: "key1"}},
+			}
+			err := ValidateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_ResidencyRequiresRegion(t *testing.T) {
+	cfg := Config{
+		Strategy:  StrategyConfig{Mode: ModeSingle},
+		Targets:   []Target{{VirtualKey: "key1", Region: "eu-west"}},
+		Residency: &ResidencyConfig{Workspaces: map[string]string{"eu-team": "eu"}},
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid residency rejected: %v", err)
+	}
+	cfg.Residency.Keys = map[string]string{"key-1": " "}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("empty residency requirement accepted")
+	}
+}
+
+func TestValidateConfig_IdempotencyTTL(t *testing.T) {
+	cfg := Config{
+		Strategy:    StrategyConfig{Mode: ModeSingle},
+		Targets:     []Target{{VirtualKey: "key1"}},
+		Idempotency: &IdempotencyConfig{TTL: "0"},
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("ttl 0 rejected: %v", err)
+	}
+	if got := cfg.Idempotency.TTLDuration(); got != 0 {
+		t.Fatalf("ttl 0 = %v, want disabled", got)
+	}
+	cfg.Idempotency.TTL = "-1h"
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("negative idempotency ttl accepted")
+	}
+	cfg.Idempotency = &IdempotencyConfig{MaxEntries: -1}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("negative idempotency max_entries accepted")
+	}
+	if got := (*IdempotencyConfig)(nil).TTLDuration(); got != DefaultIdempotencyTTL {
+		t.Fatalf("omitted ttl = %v, want %v", got, DefaultIdempotencyTTL)
+	}
+}
+
+func TestValidateConfig_Admission(t *testing.T) {
+	cfg := Config{
+		Strategy:  StrategyConfig{Mode: ModeSingle},
+		Targets:   []Target{{VirtualKey: "key1"}},
+		Admission: &AdmissionConfig{MaxInFlight: 10},
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid admission rejected: %v", err)
+	}
+	if got := cfg.Admission.MaxWaitDuration(); got != DefaultAdmissionMaxWait {
+		t.Fatalf("omitted max_wait = %v, want %v", got, DefaultAdmissionMaxWait)
+	}
+	for _, bad := range []AdmissionConfig{
+		{},
+		{MaxInFlight: 10, QueueSize: -1},
+		{MaxInFlight: 10, MaxWait: "soon"},
+	} {
+		cfg.Admission = &bad
+		if err := ValidateConfig(cfg); err == nil {
+			t.Fatalf("invalid admission %+v accepted", bad)
+		}
+	}
+}
+
+func TestValidateConfig_Images(t *testing.T) {
+	for _, bad := range []ImageLimitsConfig{
+		{MaxBytes: -1},
+		{MaxDimension: -1},
+		{MaxDimension: 2048, Downscale: true, JPEGQuality: 101},
+		{Fetch: &ImageFetchConfig{MaxBytes: -1}},
+		{Fetch: &ImageFetchConfig{Timeout: "0s"}},
+		{Fetch: &ImageFetchConfig{AllowedHosts: []string{"https://cdn.example.com"}}},
+		{Fetch: &ImageFetchConfig{AllowedHosts: []string{""}}},
+	} {
+		cfg := Config{
+			Strategy: StrategyConfig{Mode: ModeSingle},
+			Targets:  []Target{{VirtualKey: "key1"}},
+			Images:   &bad,
+		}
+		if err := ValidateConfig(cfg); err == nil {
+			t.Fatalf("invalid images %+v accepted", bad)
+		}
+	}
+}
+
+func TestValidateConfig_ExposePluginMetadata(t *testing.T) {
+	cfg := Config{
+		Strategy:             StrategyConfig{Mode: ModeSingle},
+		Targets:              []Target{{VirtualKey: "key1"}},
+		ExposePluginMetadata: &PluginMetadataExposure{Headers: []string{"X-Ferro-Guardrail"}, Fields: []string{"guardrail"}},
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid expose_plugin_metadata rejected: %v", err)
+	}
+	for _, bad := range []PluginMetadataExposure{
+		{Headers: []string{"Set-Cookie"}},
+		{Headers: []string{"X-Bad Header"}},
+		{Headers: []string{"X-"}},
+		{Fields: []string{""}},
+	} {
+		cfg.ExposePluginMetadata = &bad
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("invalid expose_plugin_metadata %+v accepted", bad)
+		}
+	}
+}
+
+func TestValidateConfig_Sessions(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "key1"}},
+		Sessions: &SessionsConfig{
+			Store:    SessionStoreRedis,
+			RedisURL: "redis://redis:6379/0",
+			TTL:      "24h",
+			Affinity: &AffinityConfig{Cookie: "gw_replica"},
+		},
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid sessions rejected: %v", err)
+	}
+	for _, bad := range []SessionsConfig{
+		{Store: SessionStoreRedis},
+		{Store: SessionStoreRedis, RedisURL: "http://redis:6379"},
+		{RedisURL: "redis://redis:6379"},
+		{Store: "postgres"},
+		{Store: SessionStoreRedis, RedisURL: "redis://redis:6379", TTL: "0s"},
+		{Affinity: &AffinityConfig{Cookie: "gw replica"}},
+	} {
+		cfg.Sessions = &bad
+		if err := ValidateConfig(cfg); err == nil {
+			t.Fatalf("invalid sessions %+v accepted", bad)
+		}
+	}
+}
+
 func TestValidateConfig_DefaultsToSingle(t *testing.T) {
 	cfg := Config{
 		Strategy: StrategyConfig{Mode: ""},
@@ -235,6 +398,8 @@ func TestValidateConfig_ConcurrencyBounds(t *testing.T) {
 		{name: "absurd max_concurrency rejected", concurrency: &ConcurrencyConfig{MaxConcurrency: 100_000_000}, wantErr: true},
 		{name: "negative queue_size rejected", concurrency: &ConcurrencyConfig{MaxConcurrency: 10, QueueSize: -1}, wantErr: true},
 		{name: "absurd queue_size rejected", concurrency: &ConcurrencyConfig{MaxConcurrency: 10, QueueSize: 100_000_000}, wantErr: true},
+		{name: "positive tenant weight", concurrency: &ConcurrencyConfig{MaxConcurrency: 10, TenantWeights: map[string]int{"ws": 3}}, wantErr: false},
+		{name: "zero tenant weight rejected", concurrency: &ConcurrencyConfig{MaxConcurrency: 10, TenantWeights: map[string]int{"ws": 0}}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -754,3 +919,107 @@ func TestValidateMCPServers_NameRequiredAndUnique(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateConfig_OnUnhonoredSeed(t *testing.T) {
+	cfg := Config{
+		Strategy:      StrategyConfig{Mode: ModeSingle},
+		Targets:       []Target{{VirtualKey: "openai"}},
+		Compatibility: CompatibilityConfig{OnUnhonoredSeed: "drop"},
+	}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("expected an error for an unknown on_unhonored_seed mode")
+	}
+	cfg.Compatibility.OnUnhonoredSeed = "reject"
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("reject should be valid: %v", err)
+	}
+}
+
+func TestValidateConfig_ResponseValidation(t *testing.T) {
+	cfg := Config{
+		Strategy:           StrategyConfig{Mode: ModeSingle},
+		Targets:            []Target{{VirtualKey: "openai"}},
+		ResponseValidation: &ResponseValidationConfig{Mode: "drop"},
+	}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("expected an error for an unknown response_validation mode")
+	}
+	cfg.ResponseValidation.Mode = ResponseValidationReject
+	ratio := 1.5
+	cfg.ResponseValidation.PayloadSampleRatio = &ratio
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("expected an error for payload_sample_ratio above 1")
+	}
+	ratio = 0
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("reject with a zero sample ratio should be valid: %v", err)
+	}
+}
+
+func TestValidateConfig_EvalSuites(t *testing.T) {
+	base := Config{Strategy: StrategyConfig{Mode: ModeSingle}, Targets: []Target{{VirtualKey: "openai"}}}
+	exact := EvalScoring{Method: EvalScoringExactMatch}
+	cases := []EvalCase{{Prompt: "2+2?", Expected: "4"}}
+	for _, tt := range []struct {
+		name   string
+		suites []EvalSuite
+		ok     bool
+	}{
+		{name: "valid", suites: []EvalSuite{
+			{Name: "math", Cases: cases, Scoring: exact},
+			{Name: "replay", FromLogs: &EvalLogSample{Since: "24h"}, Scoring: EvalScoring{Method: EvalScoringLLMJudge, JudgeModel: "gpt-4o"}},
+		}, ok: true},
+		{name: "empty name", suites: []EvalSuite{{Cases: cases, Scoring: exact}}},
+		{name: "duplicate", suites: []EvalSuite{{Name: "a", Cases: cases, Scoring: exact}, {Name: "a", Cases: cases, Scoring: exact}}},
+		{name: "nothing to run", suites: []EvalSuite{{Name: "a", Scoring: exact}}},
+		{name: "empty prompt", suites: []EvalSuite{{Name: "a", Cases: []EvalCase{{Expected: "4"}}, Scoring: exact}}},
+		{name: "bad since", suites: []EvalSuite{{Name: "a", FromLogs: &EvalLogSample{Since: "yesterday"}, Scoring: exact}}},
+		{name: "bad pattern", suites: []EvalSuite{{Name: "a", Cases: cases, Scoring: EvalScoring{Method: EvalScoringRegex, Pattern: "("}}}},
+		{name: "judge without model", suites: []EvalSuite{{Name: "a", Cases: cases, Scoring: EvalScoring{Method: EvalScoringLLMJudge}}}},
+		{name: "unknown method", suites: []EvalSuite{{Name: "a", Cases: cases, Scoring: EvalScoring{Method: "fuzzy"}}}},
+		{name: "pass score out of range", suites: []EvalSuite{{Name: "a", Cases: cases, Scoring: EvalScoring{Method: EvalScoringLLMJudge, JudgeModel: "m", PassScore: 7}}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.EvalSuites = tt.suites
+			if err := ValidateConfig(cfg); (err == nil) != tt.ok {
+				t.Fatalf("ValidateConfig() error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_CORS(t *testing.T) {
+	base := Config{Strategy: StrategyConfig{Mode: ModeSingle}, Targets: []Target{{VirtualKey: "openai"}}}
+	origins := []string{"https://dash.example.com"}
+	for _, tt := range []struct {
+		name     string
+		policies []CORSPolicy
+		ok       bool
+	}{
+		{name: "valid", policies: []CORSPolicy{
+			{PathPrefix: "/admin", AllowedOrigins: origins, AllowCredentials: true, MaxAge: "10m"},
+			{PathPrefix: "/v1", AllowedOrigins: []string{"*"}, AllowedMethods: []string{"POST"}, ExposedHeaders: []string{"X-Ferro-Trace-Id"}},
+			{AllowedOrigins: []string{"http://localhost:3000"}, MaxAge: "0s"},
+		}, ok: true},
+		{name: "no origins", policies: []CORSPolicy{{PathPrefix: "/v1"}}},
+		{name: "origin with path", policies: []CORSPolicy{{AllowedOrigins: []string{"https://example.com/app"}}}},
+		{name: "origin without scheme", policies: []CORSPolicy{{AllowedOrigins: []string{"example.com"}}}},
+		{name: "wildcard with credentials", policies: []CORSPolicy{{AllowedOrigins: []string{"*"}, AllowCredentials: true}}},
+		{name: "relative prefix", policies: []CORSPolicy{{PathPrefix: "v1", AllowedOrigins: origins}}},
+		{name: "duplicate prefix", policies: []CORSPolicy{{PathPrefix: "/v1", AllowedOrigins: origins}, {PathPrefix: "/v1", AllowedOrigins: origins}}},
+		{name: "duplicate prefix with a trailing slash", policies: []CORSPolicy{{PathPrefix: "/v1", AllowedOrigins: origins}, {PathPrefix: "/v1/", AllowedOrigins: origins}}},
+		{name: "root and empty prefix", policies: []CORSPolicy{{PathPrefix: "/", AllowedOrigins: origins}, {AllowedOrigins: origins}}},
+		{name: "lowercase method", policies: []CORSPolicy{{AllowedOrigins: origins, AllowedMethods: []string{"get"}}}},
+		{name: "bad header", policies: []CORSPolicy{{AllowedOrigins: origins, AllowedHeaders: []string{"X Bad"}}}},
+		{name: "negative max age", policies: []CORSPolicy{{AllowedOrigins: origins, MaxAge: "-1s"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.CORS = tt.policies
+			if err := ValidateConfig(cfg); (err == nil) != tt.ok {
+				t.Fatalf("ValidateConfig() error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"log/slog"
 	"maps"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
@@ -25,7 +26,6 @@ import (
 	"github.com/ferro-labs/ai-gateway/internal/mcp"
 	"github.com/ferro-labs/ai-gateway/internal/metrics"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
-	"github.com/ferro-labs/ai-gateway/internal/strategies"
 	"github.com/ferro-labs/ai-gateway/models"
 	"github.com/ferro-labs/ai-gateway/observability"
 	"github.com/ferro-labs/ai-gateway/plugin"
@@ -40,7 +40,6 @@ type Gateway struct {
 	catalog          models.Catalog
 	providers        map[string]providers.Provider
 	providerNames    []string
-	strategy         strategies.Strategy
 	streamingContent []streamingContentCondition
 	plugins          *plugin.Manager
 	requestLogWriter requestlog.Writer
@@ -52,6 +51,7 @@ type Gateway struct {
 	closed             bool
 	hooks              *hookBus
 	catalogRefreshDone sync.WaitGroup
+	sloTickDone        sync.WaitGroup
 	// pendingMCPCloses tracks registries retired by a config reload, whose
 	// teardown runs asynchronously. Close waits on it so a reload landing just
 	// before shutdown cannot leave a subprocess termination ladder running past
@@ -59,15 +59,40 @@ type Gateway struct {
 	pendingMCPCloses sync.WaitGroup
 	// shutdownCtx is a lifecycle context, not a request context. Storing it on the
 	// struct is the intended idiom here: it is created once in New, parents the
-	// gateway's background workers (hook dispatch, catalog refresh, MCP init), and
+	// gateway's background workers (hook dispatch, catalog refresh, SLO ticks, MCP init), and
 	// is cancelled by Close() to signal shutdown. It is never a per-request context.
 	shutdownCtx      context.Context
 	shutdownCancel   context.CancelFunc
 	circuitBreakers  map[string]*circuitbreaker.CircuitBreaker
 	limiters         map[string]*providerLimiter
+	tiers            *tierEnforcer
 	discoveredModels map[string][]providers.ModelInfo
 	latencyTracker   *latency.Tracker
-	modelIndex       modelLookupIndex
+	outcomes         *outcomeTracker
+	// slos holds the tracker of each configured SLO, by model name.
+	slos       map[string]*sloTracker
+	warmup     warmupStates
+	modelIndex modelLookupIndex
+
+	// experimentObserver receives experiment samples; experimentSlots
+	// bounds the shadow requests in flight. See SetExperimentObserver.
+	experimentObserver ExperimentObserverFunc
+	experimentSlots    chan struct{}
+
+	// deadLetters keeps requests that failed on every target. See
+	// SetDeadLetterSink.
+	deadLetters DeadLetterSink
+
+	// routing is the copy-on-write routing snapshot requests read without
+	// g.mu; nil until the first request after a change builds it. See
+	// routingSnapshot.
+	routing atomic.Pointer[routingSnapshot]
+
+	// targetStates holds the target states set by SetTargetState, read
+	// without g.mu; targetStops holds the contexts that disabling a target
+	// cancels, guarded by g.mu. See gateway_target_state.go.
+	targetStates atomic.Pointer[map[string]string]
+	targetStops  map[string]targetStop
 
 	// obs is the observability provider used to emit per-request spans.
 	// Defaults to observability.NoOp() when SetObservability has not
@@ -130,20 +155,25 @@ func New(cfg Config) (*Gateway, error) {
 		plugins:          plugin.NewManager(),
 		circuitBreakers:  make(map[string]*circuitbreaker.CircuitBreaker),
 		limiters:         make(map[string]*providerLimiter),
+		targetStops:      make(map[string]targetStop),
+		tiers:            newTierEnforcer(),
 		discoveredModels: make(map[string][]providers.ModelInfo),
 		latencyTracker:   latency.New(0), // default window size (100 samples)
+		outcomes:         newOutcomeTracker(0),
 		modelIndex: modelLookupIndex{
 			exactProviders:       make(map[string][]string),
 			exactStreamProviders: make(map[string][]string),
 			exactEmbedProviders:  make(map[string][]string),
 			exactImageProviders:  make(map[string][]string),
 		},
-		hooks: newHookBus(hookDispatchQueueSize),
-		obs:   observability.NoOp(),
+		hooks:           newHookBus(hookDispatchQueueSize),
+		experimentSlots: make(chan struct{}, maxShadowRequests),
+		obs:             observability.NoOp(),
 	}
 	gw.shutdownCtx, gw.shutdownCancel = context.WithCancel(context.Background()) //nolint:gosec // canceled by Gateway.Close()
 	gw.hooks.start(gw.shutdownCtx)
 	gw.startCatalogRefresh()
+	gw.startSLOTicker()
 
 	// Wire MCP from config. In New the gateway is not yet published, so no lock
 	// is held here; the field writes are safe.
@@ -152,6 +182,7 @@ func New(cfg Config) (*Gateway, error) {
 	gw.mu.Lock()
 	gw.ensureCircuitBreakersLocked()
 	gw.ensureProviderLimitersLocked()
+	gw.ensureSLOTrackersLocked()
 	gw.mu.Unlock()
 
 	return gw, nil
@@ -252,9 +283,8 @@ func (g *Gateway) refreshCatalog(ctx context.Context) {
 	// 24h refresh would leave routing frozen at the startup catalog while
 	// /v1/models reflects the new one.
 	g.rebuildModelIndexesLocked()
-	if g.config.Strategy.Mode == ModeCostOptimized {
-		g.strategy = nil
-	}
+	// The routing snapshot captures the catalog for cost-optimized ranking.
+	g.invalidateRoutingLocked()
 	g.mu.Unlock()
 
 	slog.Info("model catalog refreshed", "url", result.URLForLog(), "models", len(result.Catalog))
@@ -275,6 +305,26 @@ func recordCatalogLoad(source models.LoadSource, err error) {
 const (
 	SubjectRequestCompleted = "gateway.request.completed"
 	SubjectRequestFailed    = "gateway.request.failed"
+	// SubjectKeyExpiring is published ahead of an API key's expiry, and
+	// SubjectKeyExpired when an expired key is deactivated.
+	SubjectKeyExpiring = "gateway.key.expiring"
+	SubjectKeyExpired  = "gateway.key.expired"
+	// SubjectKeyCreated, SubjectKeyRotated, SubjectKeyRevoked, and
+	// SubjectKeyDeleted are published when the admin API creates, rotates,
+	// revokes, or deletes an API key.
+	SubjectKeyCreated = "gateway.key.created"
+	SubjectKeyRotated = "gateway.key.rotated"
+	SubjectKeyRevoked = "gateway.key.revoked"
+	SubjectKeyDeleted = "gateway.key.deleted"
+	// SubjectKeyQuotaExceeded is published when an API key first runs out of
+	// a quota: its tier's monthly token limit, or a plugin's per-key limit
+	// such as the budget plugin's spend limit.
+	SubjectKeyQuotaExceeded = "gateway.key.quota_exceeded"
+	// SubjectSLOBurnRateExceeded is published when an SLO indicator's burn
+	// rate reaches its threshold, and SubjectSLOBurnRateRecovered when it
+	// falls back below it.
+	SubjectSLOBurnRateExceeded  = "gateway.slo.burn_rate_exceeded"
+	SubjectSLOBurnRateRecovered = "gateway.slo.burn_rate_recovered"
 
 	roleUser = "user"
 )
@@ -288,7 +338,7 @@ func (g *Gateway) RegisterProvider(p providers.Provider) {
 	}
 	g.providers[p.Name()] = p
 	g.rebuildModelIndexesLocked()
-	g.strategy = nil // force strategy rebuild
+	g.invalidateRoutingLocked()
 }
 
 // RegisterPlugin registers a plugin at the given lifecycle stage.
@@ -298,6 +348,14 @@ func (g *Gateway) RegisterPlugin(stage plugin.Stage, p plugin.Plugin) error {
 	return g.plugins.Register(stage, p)
 }
 
+// Plugins returns the plugin instances currently serving requests. The slice
+// is a snapshot: a later config reload swaps in new instances and closes these.
+func (g *Gateway) Plugins() []plugin.Plugin {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.plugins.Plugins()
+}
+
 // ReloadConfig validates and applies a new configuration, forcing strategy rebuild on next request.
 //
 // The context satisfies the admin ConfigManager seam; the in-memory reload below
@@ -335,11 +393,14 @@ func (g *Gateway) ReloadConfig(ctx context.Context, cfg Config) error {
 	g.streamingContent = streamingContent
 	g.plugins = plugins
 	pluginsInstalled = true
-	g.strategy = nil // force rebuild on next request
+	g.invalidateRoutingLocked()
+	// Lookup order follows the target list.
+	g.rebuildModelIndexesLocked()
 	g.circuitBreakers = make(map[string]*circuitbreaker.CircuitBreaker)
 	g.ensureCircuitBreakersLocked()
 	g.limiters = make(map[string]*providerLimiter)
 	g.ensureProviderLimitersLocked()
+	g.ensureSLOTrackersLocked()
 
 	// Re-register MCP servers from the new config (clears MCP state when none).
 	g.wireMCPLocked(cfg, "mcp: server initialization failed after reload")
@@ -403,6 +464,12 @@ func (g *Gateway) buildPluginManager(configs []PluginConfig) (*plugin.Manager, e
 				r.SetRequestLogWriter(sharedLogWriter)
 			}
 		}
+		if r, ok := p.(plugin.CompleterReceiver); ok {
+			r.SetCompleter(directCompleter{g})
+		}
+		if r, ok := p.(plugin.QuotaNotifierReceiver); ok {
+			r.SetQuotaNotifier(quotaNotifier{g})
+		}
 		// Resolve ${VAR} references into the plugin's own config at construction.
 		// The Config itself keeps the references, so the secret is never persisted
 		// to the config store nor served by GET /admin/config.
@@ -418,7 +485,7 @@ func (g *Gateway) buildPluginManager(configs []PluginConfig) (*plugin.Manager, e
 			return nil, fmt.Errorf("plugin %s init failed: %w", pc.Name, err)
 		}
 		stage := plugin.Stage(pc.Stage)
-		if err := plugins.Register(stage, p); err != nil {
+		if err := plugins.RegisterMatched(stage, p, pc.Match); err != nil {
 			_ = plugins.Close()
 			_ = p.Close()
 			return nil, fmt.Errorf("plugin %s register failed: %w", pc.Name, err)
@@ -574,6 +641,7 @@ func (g *Gateway) Close() error {
 			g.pendingMCPCloses.Wait()
 			g.hooks.wait()
 			g.catalogRefreshDone.Wait()
+			g.sloTickDone.Wait()
 			close(done)
 		}()
 		select {
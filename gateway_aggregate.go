@@ -0,0 +1,52 @@
+package aigateway
+
+import (
+	"context"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// aggregatingProvider serves non-streaming requests for a stream_only target:
+// Complete calls CompleteStream, reads the stream to the end, and returns the
+// response it assembles. Streaming requests pass straight through.
+type aggregatingProvider struct {
+	providers.StreamProvider
+}
+
+// aggregateStream wraps p for a stream_only target. It returns p unchanged
+// when the target is not stream_only or p cannot stream.
+func aggregateStream(p providers.Provider, streamOnly bool) providers.Provider {
+	if !streamOnly {
+		return p
+	}
+	sp, ok := p.(providers.StreamProvider)
+	if !ok {
+		return p
+	}
+	return &aggregatingProvider{StreamProvider: sp}
+}
+
+func (p *aggregatingProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	req.Stream = true
+	// Ask for the usage chunk, which the OpenAI-compatible providers only
+	// send on request; the client never sees the stream.
+	req.StreamOptions = &core.StreamOptions{IncludeUsage: true}
+	ch, err := p.CompleteStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	if req.N != nil {
+		n = *req.N
+	}
+	resp, err := core.CollectStream(ch, n)
+	if err != nil {
+		return nil, err
+	}
+	resp.Provider = p.Name()
+	if resp.Model == "" {
+		resp.Model = req.Model
+	}
+	return resp, nil
+}
@@ -0,0 +1,69 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func newAggregateTestGateway(t *testing.T, streamOnly bool) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "streamer", StreamOnly: streamOnly}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{
+			name:   "streamer",
+			models: []string{"gpt-4o"},
+			err:    errors.New("non-streaming calls are not supported"),
+		},
+		streamFn: func(_ context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+			if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+				t.Errorf("stream request = stream %v, options %+v; want a stream with usage", req.Stream, req.StreamOptions)
+			}
+			ch := make(chan providers.StreamChunk, 3)
+			ch <- providers.StreamChunk{ID: "s1", Model: "gpt-4o", Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Role: "assistant", Content: "hello "}}}}
+			ch <- providers.StreamChunk{ID: "s1", Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: "world"}, FinishReason: "stop"}}}
+			ch <- providers.StreamChunk{ID: "s1", Usage: &providers.Usage{PromptTokens: 2, CompletionTokens: 2, TotalTokens: 4}}
+			close(ch)
+			return ch, nil
+		},
+	})
+	return gw
+}
+
+func TestRoute_StreamOnlyTargetAggregatesStream(t *testing.T) {
+	gw := newAggregateTestGateway(t, true)
+
+	resp, err := gw.Route(context.Background(), providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "s1" || len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "hello world" {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if resp.Choices[0].FinishReason != "stop" || resp.Usage.TotalTokens != 4 {
+		t.Fatalf("finish %q, usage %+v", resp.Choices[0].FinishReason, resp.Usage)
+	}
+}
+
+func TestRoute_WithoutStreamOnlyCallsComplete(t *testing.T) {
+	gw := newAggregateTestGateway(t, false)
+
+	_, err := gw.Route(context.Background(), providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+	})
+	if err == nil {
+		t.Fatal("want the provider's Complete error")
+	}
+}
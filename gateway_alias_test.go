@@ -0,0 +1,84 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func newAliasRewriteGateway(t *testing.T) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy:      StrategyConfig{Mode: ModeSingle},
+		Targets:       []Target{{VirtualKey: "openai"}},
+		Aliases:       map[string]string{"fast": "gpt-4o-mini"},
+		Compatibility: CompatibilityConfig{RewriteAliasModel: true},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{
+			name:   "openai",
+			models: []string{"gpt-4o-mini", "gpt-4o"},
+			completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+				return &providers.Response{ID: "r1", Model: req.Model + "-2024-07-18", Choices: []providers.Choice{{
+					Message: providers.Message{Role: "assistant", Content: "hi"},
+				}}}, nil
+			},
+		},
+		streamFn: func(_ context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+			ch := make(chan providers.StreamChunk, 2)
+			ch <- providers.StreamChunk{ID: "s1", Model: req.Model + "-2024-07-18", Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: "hi"}}}}
+			ch <- providers.StreamChunk{ID: "s1", Model: req.Model + "-2024-07-18", Choices: []providers.StreamChoice{{FinishReason: "stop"}}}
+			close(ch)
+			return ch, nil
+		},
+	})
+	return gw
+}
+
+func TestRoute_RewriteAliasModel(t *testing.T) {
+	gw := newAliasRewriteGateway(t)
+	msgs := []providers.Message{{Role: "user", Content: "hi"}}
+
+	resp, err := gw.Route(context.Background(), providers.Request{Model: "fast", Messages: msgs})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.Model != "fast" {
+		t.Errorf("model = %q, want the alias", resp.Model)
+	}
+
+	resp, err = gw.Route(context.Background(), providers.Request{Model: "gpt-4o", Messages: msgs})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.Model != "gpt-4o-2024-07-18" {
+		t.Errorf("model = %q, want the provider's for a request without an alias", resp.Model)
+	}
+}
+
+func TestRouteStream_RewriteAliasModel(t *testing.T) {
+	gw := newAliasRewriteGateway(t)
+
+	ch, err := gw.RouteStream(context.Background(), providers.Request{
+		Model:    "fast",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		Stream:   true,
+	})
+	if err != nil {
+		t.Fatalf("RouteStream: %v", err)
+	}
+	n := 0
+	for chunk := range ch {
+		n++
+		if chunk.Model != "fast" {
+			t.Errorf("chunk model = %q, want the alias", chunk.Model)
+		}
+	}
+	if n != 2 {
+		t.Fatalf("got %d chunks, want 2", n)
+	}
+}
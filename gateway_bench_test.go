@@ -144,6 +144,69 @@ func BenchmarkGateway_RouteStream(b *testing.B) {
 	}
 }
 
+// BenchmarkGateway_RouteStreamParallel measures RouteStream() under concurrent
+// load. Stream start resolves the strategy and each target's decorated
+// provider per request, so it exercises the routing snapshot read path.
+func BenchmarkGateway_RouteStreamParallel(b *testing.B) {
+	silenceLogs(b)
+
+	gw, err := newTestGateway(b, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: "bench-stream"}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	gw.RegisterProvider(&mockBenchStreamProvider{
+		mockProvider: mockProvider{
+			name:   "bench-stream",
+			models: []string{"gpt-4o"},
+		},
+	})
+
+	req := providers.Request{
+		Model:    "gpt-4o",
+		Stream:   true,
+		Messages: []providers.Message{{Role: "user", Content: "hello"}},
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			out, err := gw.RouteStream(ctx, req)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for range out { //nolint:revive // empty-block: intentionally draining the stream to completion
+			}
+		}
+	})
+}
+
+// BenchmarkGateway_GetStrategyParallel measures resolving the built strategy,
+// which every Route and RouteStream call does, under concurrent load.
+func BenchmarkGateway_GetStrategyParallel(b *testing.B) {
+	gw, err := newTestGateway(b, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "bench"}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	gw.RegisterProvider(&mockProvider{name: "bench", models: []string{"gpt-4o"}})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := gw.getStrategy(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 // BenchmarkGateway_RouteWithPlugins measures Route() with a two-plugin before_request
 // chain (word-filter + max-token) loaded via LoadPlugins.
 func BenchmarkGateway_RouteWithPlugins(b *testing.B) {
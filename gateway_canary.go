@@ -0,0 +1,16 @@
+package aigateway
+
+import "math/rand/v2"
+
+// pickCanary draws a model for one request to an alias: each canary wins its
+// Percent of draws and target takes whatever share is left.
+func pickCanary(canaries []ModelCanary, target string) string {
+	r := rand.Float64() * 100 //nolint:gosec // G404: math/rand is fine for canary traffic splitting, not security-sensitive
+	for _, c := range canaries {
+		if r < c.Percent {
+			return c.Model
+		}
+		r -= c.Percent
+	}
+	return target
+}
@@ -0,0 +1,89 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestPickCanary_SplitsByPercent(t *testing.T) {
+	canaries := []ModelCanary{{Model: "gpt-4.1", Percent: 20}}
+	counts := map[string]int{}
+	const draws = 20000
+	for range draws {
+		counts[pickCanary(canaries, "gpt-4o")]++
+	}
+	// 20% of 20000 is 4000; allow a wide margin so the test never flakes.
+	if got := counts["gpt-4.1"]; got < 3400 || got > 4600 {
+		t.Fatalf("canary served %d of %d draws, want about 4000", got, draws)
+	}
+	if counts["gpt-4.1"]+counts["gpt-4o"] != draws {
+		t.Fatalf("draws went to unexpected models: %v", counts)
+	}
+}
+
+func TestPickCanary_FullShareAlwaysWins(t *testing.T) {
+	canaries := []ModelCanary{{Model: "a", Percent: 40}, {Model: "b", Percent: 60}}
+	for range 1000 {
+		if got := pickCanary(canaries, "target"); got == "target" {
+			t.Fatal("the alias target was picked though the canaries claim 100%")
+		}
+	}
+}
+
+func TestRoute_AliasCanaryServesShare(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai"}},
+		Aliases:  map[string]string{"prod-chat": "gpt-4o"},
+		Canaries: map[string][]ModelCanary{"prod-chat": {{Model: "gpt-4.1", Percent: 100}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw.RegisterProvider(&mockProvider{name: "openai", models: []string{"gpt-4o", "gpt-4.1"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			return &providers.Response{ID: "r1", Model: req.Model}, nil
+		}})
+
+	resp, err := gw.Route(context.Background(), providers.Request{
+		Model:    "prod-chat",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.Model != "gpt-4.1" {
+		t.Fatalf("served by %q, want the canary gpt-4.1", resp.Model)
+	}
+}
+
+func TestValidateConfig_Canaries(t *testing.T) {
+	base := func(canaries []ModelCanary) Config {
+		return Config{
+			Strategy: StrategyConfig{Mode: ModeSingle},
+			Targets:  []Target{{VirtualKey: "openai"}},
+			Aliases:  map[string]string{"prod-chat": "gpt-4o", "fast": "gpt-4o-mini"},
+			Canaries: map[string][]ModelCanary{"prod-chat": canaries},
+		}
+	}
+	if err := ValidateConfig(base([]ModelCanary{{Model: "gpt-4.1", Percent: 5}})); err != nil {
+		t.Fatalf("valid canary rejected: %v", err)
+	}
+	for name, canaries := range map[string][]ModelCanary{
+		"no model":        {{Percent: 5}},
+		"zero percent":    {{Model: "gpt-4.1"}},
+		"over 100":        {{Model: "gpt-4.1", Percent: 60}, {Model: "o3", Percent: 50}},
+		"alias as canary": {{Model: "fast", Percent: 5}},
+	} {
+		if err := ValidateConfig(base(canaries)); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+	cfg := base(nil)
+	cfg.Canaries = map[string][]ModelCanary{"gpt-4o": {{Model: "gpt-4.1", Percent: 5}}}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Error("want an error for canaries on a name that is not an alias")
+	}
+}
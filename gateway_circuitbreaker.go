@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
+	"github.com/ferro-labs/ai-gateway/internal/jsonstream"
 	"github.com/ferro-labs/ai-gateway/internal/metrics"
 	"github.com/ferro-labs/ai-gateway/providers"
 )
@@ -102,11 +103,14 @@ func shouldRecordCircuitBreakerFailure(ctx context.Context, err error) bool {
 		return false
 	}
 
-	// Errors the gateway produced itself, without ever calling the provider: an
-	// unsupported-parameter rejection, and shedding under our own concurrency
-	// limit. Neither is evidence that the upstream is unhealthy.
+	// Errors the gateway produced itself: an unsupported-parameter rejection,
+	// an image URL it could not fetch for the provider, shedding under our own
+	// concurrency limit, a spent retry budget, JSON-mode output that failed
+	// validation, and a request canceled by disabling its target. None is evidence that the upstream is unhealthy.
 	var unsupportedParam *providers.UnsupportedParamError
-	if errors.As(err, &unsupportedParam) || errors.Is(err, providers.ErrProviderSaturated) {
+	var imageFetch *providers.ImageFetchError
+	if errors.As(err, &unsupportedParam) || errors.As(err, &imageFetch) || errors.Is(err, providers.ErrProviderSaturated) ||
+		errors.Is(err, providers.ErrRetryBudgetExhausted) || errors.Is(err, jsonstream.ErrInvalidJSON) || errors.Is(err, errTargetDisabled) {
 		return false
 	}
 
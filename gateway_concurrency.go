@@ -3,10 +3,12 @@ package aigateway
 import (
 	"context"
 	"fmt"
+	"sync"
 	"sync/atomic"
 
 	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
 	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/capabilities"
 )
 
 // Per-target concurrency limiting: a semaphore applied at the call site, composed
@@ -38,11 +40,18 @@ const DefaultConcurrencyQueueSize = 1000
 const MaxTargetConcurrency = 10_000
 
 // providerLimiter bounds how many requests may be in flight against a single
-// target, and how many may wait for a slot.
+// target, and how many may wait for a slot. Waiters are served fairly across
+// tenants (see fairQueue): a released slot passes straight to the next waiter
+// rather than back to the pool, so a new arrival cannot jump the queue.
 type providerLimiter struct {
 	slots   chan struct{} // capacity == max in-flight requests
 	waiting atomic.Int64  // requests currently queued for a slot
 	maxWait int64
+
+	// mu serializes taking a free slot against queueing, so a slot never sits
+	// free while a request waits.
+	mu    sync.Mutex
+	queue fairQueue
 }
 
 // newProviderLimiter builds a limiter admitting maxConcurrency simultaneous
@@ -64,28 +73,56 @@ func newProviderLimiter(maxConcurrency, queueSize int) *providerLimiter {
 // and ctx.Err() when the caller goes away while waiting, so a cancelled request
 // never occupies a slot.
 func (l *providerLimiter) acquire(ctx context.Context) error {
-	select {
-	case l.slots <- struct{}{}:
-		return nil // a slot was free: no queueing, no contention
-	default:
+	l.mu.Lock()
+	if l.queue.empty() {
+		select {
+		case l.slots <- struct{}{}:
+			l.mu.Unlock()
+			return nil // a slot was free: no queueing, no contention
+		default:
+		}
 	}
 
 	if l.waiting.Add(1) > l.maxWait {
 		l.waiting.Add(-1)
+		l.mu.Unlock()
 		return providers.ErrProviderSaturated
 	}
-	defer l.waiting.Add(-1)
+	tenant := tenantOf(ctx)
+	w := &waiter{ready: make(chan struct{}, 1)}
+	l.queue.push(tenant, w)
+	l.mu.Unlock()
 
 	select {
-	case l.slots <- struct{}{}:
+	case <-w.ready:
 		return nil
 	case <-ctx.Done():
+		l.mu.Lock()
+		queued := l.queue.remove(tenant, w)
+		if queued {
+			l.waiting.Add(-1)
+		}
+		l.mu.Unlock()
+		if !queued {
+			// The slot was handed over as ctx ended; pass it on.
+			l.release()
+		}
 		return ctx.Err()
 	}
 }
 
-// release returns an in-flight slot.
-func (l *providerLimiter) release() { <-l.slots }
+// release returns an in-flight slot, handing it to the next fair waiter if
+// any.
+func (l *providerLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w := l.queue.pop(); w != nil {
+		l.waiting.Add(-1)
+		w.ready <- struct{}{}
+		return
+	}
+	<-l.slots
+}
 
 // limitedProvider gates a provider's upstream calls through a per-target
 // semaphore. Like cbProvider it embeds the base Provider interface only, and is
@@ -149,13 +186,32 @@ func (p *limitedProvider) CompleteStream(ctx context.Context, req providers.Requ
 
 // decorateProvider composes the per-target decorators around p.
 //
-// The order is load-bearing: the concurrency limiter is INNERMOST so it gates only
-// the upstream call, and the circuit breaker is OUTERMOST so an open circuit fails
-// fast without ever occupying an in-flight slot or a queue position.
-func decorateProvider(name string, p providers.Provider, cb *circuitbreaker.CircuitBreaker, lim *providerLimiter) providers.Provider {
+// The order is load-bearing: stop-sequence emulation, the extra_body filter,
+// the top_k and seed guards, and then the concurrency limiter are INNERMOST so
+// the limiter gates only the upstream call (emulation adds no upstream work of
+// its own), and the circuit breaker is OUTERMOST so an open circuit fails fast
+// without ever occupying an in-flight slot or a queue position. The streaming
+// path relies on the breaker being the outermost layer when present (see
+// RouteStream). A seed, top_k, or extra_body rejection never counts against the
+// breaker: it is an UnsupportedParamError. Outcome recording for RoutingState
+// sits just inside the breaker, so breaker rejections are not counted; a nil
+// outcomes skips it.
+func decorateProvider(name string, p providers.Provider, cb *circuitbreaker.CircuitBreaker, lim *providerLimiter, outcomes *outcomeTracker) providers.Provider {
+	p = stopEmulation(name, p)
+	p = &extraProvider{Provider: p, name: name}
+	p = &attemptProvider{Provider: p, name: name}
+	if capabilities.SupportOf(p.Name(), "top_k") == capabilities.Unsupported {
+		p = &topKProvider{Provider: p, name: name}
+	}
+	if !capabilities.HonorsSeed(p.Name()) {
+		p = &seedProvider{Provider: p, name: name}
+	}
 	if lim != nil {
 		p = &limitedProvider{Provider: p, lim: lim, name: name}
 	}
+	if outcomes != nil {
+		p = &outcomeProvider{Provider: p, outcomes: outcomes, name: name}
+	}
 	if cb != nil {
 		p = &cbProvider{Provider: p, cb: cb, name: name}
 	}
@@ -197,9 +253,11 @@ func (g *Gateway) ensureProviderLimitersLocked() {
 		if _, exists := g.limiters[t.VirtualKey]; exists {
 			continue
 		}
-		g.limiters[t.VirtualKey] = newProviderLimiter(
+		lim := newProviderLimiter(
 			t.Concurrency.MaxConcurrency,
 			t.Concurrency.QueueSize,
 		)
+		lim.queue.weights = t.Concurrency.TenantWeights
+		g.limiters[t.VirtualKey] = lim
 	}
 }
@@ -137,7 +137,7 @@ func TestProviderLimiter_CancelWhileWaitingHoldsNoSlot(t *testing.T) {
 func TestLimitedProvider_CapsConcurrentCompletes(t *testing.T) {
 	const maxConcurrency = 2
 	inner := newBlockingProvider("capped")
-	p := decorateProvider("capped", inner, nil, newProviderLimiter(maxConcurrency, 100))
+	p := decorateProvider("capped", inner, nil, newProviderLimiter(maxConcurrency, 100), nil)
 
 	var wg sync.WaitGroup
 	for range 6 {
@@ -166,7 +166,7 @@ func TestLimitedProvider_CapsConcurrentCompletes(t *testing.T) {
 // The decorator must expose exactly the base Provider surface, like cbProvider.
 func TestLimitedProvider_DoesNotForgeCapabilities(t *testing.T) {
 	chatOnly := &mockProvider{name: "chat-only", models: []string{"gpt-4o"}}
-	decorated := decorateProvider("chat-only", chatOnly, nil, newProviderLimiter(1, 1))
+	decorated := decorateProvider("chat-only", chatOnly, nil, newProviderLimiter(1, 1), nil)
 
 	if _, ok := decorated.(providers.EmbeddingProvider); ok {
 		t.Error("decorated chat-only provider must not satisfy EmbeddingProvider")
@@ -211,7 +211,7 @@ func TestLimitedProvider_StreamHoldsSlotForWholeStream(t *testing.T) {
 		release:      make(chan struct{}),
 	}
 	lim := newProviderLimiter(1, 0) // exactly one in-flight slot
-	sp, ok := decorateProvider("streamer", inner, nil, lim).(providers.StreamProvider)
+	sp, ok := decorateProvider("streamer", inner, nil, lim, nil).(providers.StreamProvider)
 	if !ok {
 		t.Fatal("decorated stream provider must satisfy StreamProvider")
 	}
@@ -253,7 +253,7 @@ func TestDecorateProvider_CircuitBreakerIsOutermost(t *testing.T) {
 	cb := circuitbreaker.New(1, 1, 1, time.Minute)
 	cb.RecordFailure() // threshold 1 → open
 
-	p := decorateProvider("tripped", inner, cb, lim)
+	p := decorateProvider("tripped", inner, cb, lim, nil)
 
 	_, err := p.Complete(context.Background(), providers.Request{Model: "gpt-4o"})
 	if !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
@@ -0,0 +1,158 @@
+package aigateway
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// DeadLetter is a chat request that failed on every target it was routed to,
+// kept so it can be sent again once the outage is over. A request that
+// failed before reaching a provider, or that one provider answered, is not a
+// dead letter.
+type DeadLetter struct {
+	TraceID string
+	// KeyID is the ID of the API key that sent the request, or "".
+	KeyID string
+	// Request is the request as the client sent it, before aliases,
+	// defaults, and plugins applied, with recognised credentials redacted
+	// from its message text.
+	Request providers.Request
+	// Strategy is the routing mode and Attempts the provider calls it made.
+	Strategy string
+	Attempts []plugin.Attempt
+	// Errors is the error chain, outermost first, redacted like Attempts.
+	Errors     []string
+	StatusCode int
+	Latency    time.Duration
+	Time       time.Time
+}
+
+// DeadLetterSink receives the gateway's dead letters. WriteDeadLetter runs on
+// the failed request's goroutine before the client is answered, so it should
+// be quick.
+type DeadLetterSink interface {
+	WriteDeadLetter(ctx context.Context, dl DeadLetter)
+}
+
+// SetDeadLetterSink installs the sink dead letters are written to. Without
+// one, failed requests are not kept.
+//
+// Safe to call only at startup, before serving traffic.
+func (g *Gateway) SetDeadLetterSink(sink DeadLetterSink) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.deadLetters = sink
+}
+
+// DeadLetterSink returns the sink installed by SetDeadLetterSink, or nil.
+func (g *Gateway) DeadLetterSink() DeadLetterSink {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.deadLetters
+}
+
+type noDeadLetterKey struct{}
+
+// WithoutDeadLetter returns a context whose request is not dead-lettered when
+// it fails, as a request re-driven from the dead-letter queue must not be
+// queued a second time.
+func WithoutDeadLetter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noDeadLetterKey{}, true)
+}
+
+// deadLetterRequest returns the copy of req a dead letter keeps, or nil when
+// failures are not kept. Its messages are copied because plugins rewrite
+// them in place.
+func deadLetterRequest(ctx context.Context, sink DeadLetterSink, req providers.Request) *providers.Request {
+	if sink == nil || ctx.Value(noDeadLetterKey{}) != nil {
+		return nil
+	}
+	req.Messages = slices.Clone(req.Messages)
+	return &req
+}
+
+// writeDeadLetter hands the failure of original to sink when every provider
+// call the request made failed.
+func writeDeadLetter(ctx context.Context, sink DeadLetterSink, original *providers.Request, strategy string, err error, latency time.Duration) {
+	if sink == nil || original == nil {
+		return
+	}
+	log := attemptLogFrom(ctx)
+	if log == nil {
+		return
+	}
+	log.mu.Lock()
+	attempts := slices.Clone(log.attempts)
+	log.mu.Unlock()
+	if len(attempts) == 0 {
+		return
+	}
+	for _, a := range attempts {
+		if a.Error == "" {
+			return
+		}
+	}
+	dl := DeadLetter{
+		TraceID:  logging.TraceIDFromContext(ctx),
+		Request:  redactRequest(*original),
+		Strategy: strategy,
+		Attempts: attempts,
+		Errors:   errorChain(err),
+		Latency:  latency,
+		Time:     time.Now().UTC(),
+	}
+	dl.KeyID, _ = authctx.KeyID(ctx)
+	dl.StatusCode, _, _ = apierror.RouteErrorDetails(err)
+	sink.WriteDeadLetter(ctx, dl)
+}
+
+// redactRequest redacts recognised credentials from the text of req's
+// messages, copying what it changes.
+func redactRequest(req providers.Request) providers.Request {
+	msgs := make([]providers.Message, len(req.Messages))
+	for i, m := range req.Messages {
+		m.Content = redact.String(m.Content)
+		if len(m.ContentParts) > 0 {
+			parts := slices.Clone(m.ContentParts)
+			for j := range parts {
+				parts[j].Text = redact.String(parts[j].Text)
+			}
+			m.ContentParts = parts
+		}
+		msgs[i] = m
+	}
+	req.Messages = msgs
+	return req
+}
+
+// errorChain returns the redacted messages of err and the errors it wraps,
+// outermost first, skipping a message that repeats its predecessor's.
+func errorChain(err error) []string {
+	var chain []string
+	queue := []error{err}
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		if e == nil {
+			continue
+		}
+		if msg := redact.ErrorMessage(e); len(chain) == 0 || chain[len(chain)-1] != msg {
+			chain = append(chain, msg)
+		}
+		switch u := e.(type) {
+		case interface{ Unwrap() error }:
+			queue = append(queue, u.Unwrap())
+		case interface{ Unwrap() []error }:
+			queue = append(queue, u.Unwrap()...)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,139 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// recordingDeadLetterSink keeps the dead letters it is handed.
+type recordingDeadLetterSink struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+func (s *recordingDeadLetterSink) WriteDeadLetter(_ context.Context, dl DeadLetter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, dl)
+}
+
+func (s *recordingDeadLetterSink) all() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DeadLetter(nil), s.letters...)
+}
+
+func newDeadLetterGateway(t *testing.T, fallbackErr error) (*Gateway, *recordingDeadLetterSink) {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: mockProviderName}, {VirtualKey: "anthropic"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		err:    &core.HTTPStatusError{StatusCode: http.StatusServiceUnavailable, Message: "mock API error (503): overloaded"},
+	})
+	fallback := &mockProvider{name: "anthropic", models: []string{"gpt-4o"}, err: fallbackErr}
+	if fallbackErr == nil {
+		fallback.resp = &providers.Response{ID: "resp-1", Model: "gpt-4o"}
+	}
+	gw.RegisterProvider(fallback)
+	sink := &recordingDeadLetterSink{}
+	gw.SetDeadLetterSink(sink)
+	return gw, sink
+}
+
+func TestRoute_DeadLetterWhenEveryTargetFails(t *testing.T) {
+	gw, sink := newDeadLetterGateway(t, errors.New("connection refused"))
+	key := "sk-" + strings.Repeat("b", 40)
+	if _, err := gw.Route(context.Background(), providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "my key is " + key}},
+	}); err == nil {
+		t.Fatal("Route succeeded, want both targets to fail")
+	}
+
+	letters := sink.all()
+	if len(letters) != 1 {
+		t.Fatalf("dead letters = %d, want 1", len(letters))
+	}
+	dl := letters[0]
+	if dl.Strategy != string(ModeFallback) || dl.Request.Model != "gpt-4o" || dl.StatusCode == 0 || dl.Time.IsZero() {
+		t.Errorf("unexpected dead letter: %+v", dl)
+	}
+	if content := dl.Request.Messages[0].Content; strings.Contains(content, key) || !strings.HasPrefix(content, "my key is ") {
+		t.Errorf("message = %q, want the key redacted", content)
+	}
+	if len(dl.Attempts) < 2 || dl.Attempts[0].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("attempts = %+v, want one per target", dl.Attempts)
+	}
+	if len(dl.Errors) == 0 || !strings.Contains(strings.Join(dl.Errors, "\n"), "connection refused") {
+		t.Errorf("errors = %q, want the chain to name the last failure", dl.Errors)
+	}
+}
+
+func TestRoute_NoDeadLetter(t *testing.T) {
+	t.Run("fallback succeeds", func(t *testing.T) {
+		gw, sink := newDeadLetterGateway(t, nil)
+		if _, err := gw.Route(context.Background(), providers.Request{
+			Model:    "gpt-4o",
+			Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		}); err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if n := len(sink.all()); n != 0 {
+			t.Fatalf("dead letters = %d, want none when a target answers", n)
+		}
+	})
+
+	t.Run("marked context", func(t *testing.T) {
+		gw, sink := newDeadLetterGateway(t, errors.New("connection refused"))
+		if _, err := gw.Route(WithoutDeadLetter(context.Background()), providers.Request{
+			Model:    "gpt-4o",
+			Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		}); err == nil {
+			t.Fatal("Route succeeded, want both targets to fail")
+		}
+		if n := len(sink.all()); n != 0 {
+			t.Fatalf("dead letters = %d, want none for a re-driven request", n)
+		}
+	})
+}
+
+func TestRouteStream_DeadLetterWhenEveryTargetFails(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: mockProviderName, models: []string{"gpt-4o"}},
+		streamErr:    &core.HTTPStatusError{StatusCode: http.StatusBadGateway, Message: "mock API error (502): bad gateway"},
+	})
+	sink := &recordingDeadLetterSink{}
+	gw.SetDeadLetterSink(sink)
+	if _, err := gw.RouteStream(context.Background(), providers.Request{
+		Model:    "gpt-4o",
+		Stream:   true,
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+	}); err == nil {
+		t.Fatal("RouteStream succeeded, want both targets to fail")
+	}
+	letters := sink.all()
+	if len(letters) != 1 || !letters[0].Request.Stream {
+		t.Fatalf("dead letters = %+v, want the streaming request", letters)
+	}
+}
@@ -0,0 +1,86 @@
+package aigateway
+
+import (
+	"context"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// requestDefaultsFor returns the parameter defaults of ctx's request, each
+// field taken from its key's defaults, else its workspace's, else the
+// default's.
+func requestDefaultsFor(ctx context.Context, cfg *RequestDefaultsConfig) RequestParamDefaults {
+	var out RequestParamDefaults
+	if cfg == nil {
+		return out
+	}
+	// Narrowest scope first: a field already set is not overwritten.
+	var scopes []RequestParamDefaults
+	if keyID, ok := authctx.KeyID(ctx); ok {
+		if d, ok := cfg.Keys[keyID]; ok {
+			scopes = append(scopes, d)
+		}
+	}
+	if id, ok := authctx.Identity(ctx); ok && id.Workspace != "" {
+		if d, ok := cfg.Workspaces[id.Workspace]; ok {
+			scopes = append(scopes, d)
+		}
+	}
+	if cfg.Default != nil {
+		scopes = append(scopes, *cfg.Default)
+	}
+	for _, d := range scopes {
+		if out.Temperature == nil {
+			out.Temperature = d.Temperature
+		}
+		if out.MaxTokens == 0 {
+			out.MaxTokens = d.MaxTokens
+		}
+		if out.SystemPrompt == "" {
+			out.SystemPrompt = d.SystemPrompt
+		}
+	}
+	return out
+}
+
+// applyRequestDefaults fills in the parameters req omits from its caller's
+// defaults. Pointers and the message slice are replaced, not edited, since
+// the caller may share them.
+func applyRequestDefaults(ctx context.Context, cfg *RequestDefaultsConfig, req *providers.Request) {
+	if cfg == nil {
+		return
+	}
+	d := requestDefaultsFor(ctx, cfg)
+	var applied []string
+	if d.Temperature != nil && req.Temperature == nil {
+		v := *d.Temperature
+		req.Temperature = &v
+		applied = append(applied, "temperature")
+	}
+	if d.MaxTokens > 0 && req.MaxTokens == nil && req.MaxCompletionTokens == nil {
+		v := d.MaxTokens
+		req.MaxTokens = &v
+		applied = append(applied, "max_tokens")
+	}
+	if d.SystemPrompt != "" && !hasSystemMessage(req.Messages) {
+		msgs := make([]providers.Message, 0, len(req.Messages)+1)
+		msgs = append(msgs, providers.Message{Role: "system", Content: d.SystemPrompt})
+		req.Messages = append(msgs, req.Messages...)
+		applied = append(applied, "system_prompt")
+	}
+	if len(applied) > 0 {
+		logging.FromContext(ctx).Debug("request defaults applied",
+			"model", req.Model, "params", applied)
+	}
+}
+
+func hasSystemMessage(msgs []providers.Message) bool {
+	for _, m := range msgs {
+		if m.Role == "system" || m.Role == "developer" {
+			return true
+		}
+	}
+	return false
+}
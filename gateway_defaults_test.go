@@ -0,0 +1,104 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestRoute_RequestDefaultsFillOmittedParams(t *testing.T) {
+	warm, cool := 0.9, 0.2
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+		RequestDefaults: &RequestDefaultsConfig{
+			Default:    &RequestParamDefaults{Temperature: &warm, MaxTokens: 4096},
+			Workspaces: map[string]RequestParamDefaults{"support": {SystemPrompt: "You are a support agent."}},
+			Keys:       map[string]RequestParamDefaults{"key-a": {Temperature: &cool}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var sent providers.Request
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			sent = req
+			return &providers.Response{ID: "ok", Model: req.Model, Choices: []providers.Choice{{
+				Message: providers.Message{Role: "assistant", Content: "hi"},
+			}}}, nil
+		},
+	})
+
+	ctx := authctx.WithIdentity(authctx.WithKeyID(context.Background(), "key-a"), authctx.KeyIdentity{Workspace: "support"})
+	user := []providers.Message{{Role: "user", Content: "hi"}}
+
+	t.Run("omitted params", func(t *testing.T) {
+		if _, err := gw.Route(ctx, providers.Request{Model: "gpt-4o", Messages: user}); err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if sent.Temperature == nil || *sent.Temperature != 0.2 {
+			t.Errorf("temperature = %v, want the key's 0.2", sent.Temperature)
+		}
+		if sent.MaxTokens == nil || *sent.MaxTokens != 4096 {
+			t.Errorf("max_tokens = %v, want the default's 4096", sent.MaxTokens)
+		}
+		if len(sent.Messages) != 2 || sent.Messages[0].Role != "system" || sent.Messages[0].Content != "You are a support agent." {
+			t.Errorf("messages = %+v, want the workspace's system prompt first", sent.Messages)
+		}
+		if len(user) != 1 {
+			t.Error("the caller's messages were modified")
+		}
+	})
+
+	t.Run("client values win", func(t *testing.T) {
+		temp, completion := 1.4, 100
+		if _, err := gw.Route(ctx, providers.Request{
+			Model:               "gpt-4o",
+			Messages:            []providers.Message{{Role: "system", Content: "Be terse."}, {Role: "user", Content: "hi"}},
+			Temperature:         &temp,
+			MaxCompletionTokens: &completion,
+		}); err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if *sent.Temperature != 1.4 {
+			t.Errorf("temperature = %v, want the client's 1.4", *sent.Temperature)
+		}
+		if sent.MaxTokens == nil || *sent.MaxTokens != 100 {
+			t.Errorf("max_tokens = %v, want 100 from max_completion_tokens", sent.MaxTokens)
+		}
+		if len(sent.Messages) != 2 || sent.Messages[0].Content != "Be terse." {
+			t.Errorf("messages = %+v, want the client's system prompt alone", sent.Messages)
+		}
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		if _, err := gw.Route(authctx.WithKeyID(context.Background(), "key-b"), providers.Request{Model: "gpt-4o", Messages: user}); err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if *sent.Temperature != 0.9 || len(sent.Messages) != 1 {
+			t.Errorf("temperature = %v, messages = %+v; want only the default's", *sent.Temperature, sent.Messages)
+		}
+	})
+}
+
+func TestValidateConfig_RequestDefaults(t *testing.T) {
+	hot := 3.0
+	for name, d := range map[string]RequestParamDefaults{
+		"temperature": {Temperature: &hot},
+		"max_tokens":  {MaxTokens: -1},
+	} {
+		cfg := Config{
+			Strategy:        StrategyConfig{Mode: ModeSingle},
+			Targets:         []Target{{VirtualKey: "openai"}},
+			RequestDefaults: &RequestDefaultsConfig{Keys: map[string]RequestParamDefaults{"key-a": d}},
+		}
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%s: ValidateConfig accepted %+v", name, d)
+		}
+	}
+}
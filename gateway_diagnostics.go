@@ -0,0 +1,113 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// Error diagnostics: a request with plugins carries an attempt log in its
+// context, which attemptProvider fills with every provider call the routing
+// strategy makes. When the request fails, setDiagnostics turns the log into
+// the plugin.ErrorDiagnostics on_error plugins read.
+
+type attemptLogKey struct{}
+
+// attemptLog records the provider calls of one request. Hedged and racing
+// strategies call providers concurrently, so it is safe for concurrent use.
+type attemptLog struct {
+	mu       sync.Mutex
+	attempts []plugin.Attempt
+	body     string // error body of the last call that failed with a status
+}
+
+// withAttemptLog returns a context whose provider calls are recorded.
+func withAttemptLog(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptLogKey{}, &attemptLog{})
+}
+
+func attemptLogFrom(ctx context.Context) *attemptLog {
+	log, _ := ctx.Value(attemptLogKey{}).(*attemptLog)
+	return log
+}
+
+func (l *attemptLog) record(target, model string, err error, latency time.Duration) {
+	a := plugin.Attempt{Target: target, Model: model, Latency: latency}
+	var body string
+	if err != nil {
+		a.Error = redact.ErrorMessage(err)
+		var statusErr *core.HTTPStatusError
+		if errors.As(err, &statusErr) {
+			a.StatusCode = statusErr.StatusCode
+			body = statusErr.Body
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts = append(l.attempts, a)
+	if body != "" {
+		l.body = body
+	}
+}
+
+// attemptProvider records each call to the provider it wraps in the calling
+// request's attempt log, when it has one.
+type attemptProvider struct {
+	providers.Provider
+	name string
+}
+
+func (p *attemptProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	log := attemptLogFrom(ctx)
+	if log == nil {
+		return p.Provider.Complete(ctx, req)
+	}
+	start := time.Now()
+	resp, err := p.Provider.Complete(ctx, req)
+	log.record(p.name, req.Model, err, time.Since(start))
+	return resp, err
+}
+
+func (p *attemptProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	log := attemptLogFrom(ctx)
+	if log == nil {
+		return sp.CompleteStream(ctx, req)
+	}
+	start := time.Now()
+	ch, err := sp.CompleteStream(ctx, req)
+	log.record(p.name, req.Model, err, time.Since(start))
+	return ch, err
+}
+
+// setDiagnostics describes err, the failure of a request after latency, in
+// pctx for the on_error stage.
+func setDiagnostics(ctx context.Context, pctx *plugin.Context, err error, latency time.Duration) {
+	d := &plugin.ErrorDiagnostics{Latency: latency}
+	d.StatusCode, _, _ = apierror.RouteErrorDetails(err)
+	if log := attemptLogFrom(ctx); log != nil {
+		log.mu.Lock()
+		d.Attempts = append([]plugin.Attempt(nil), log.attempts...)
+		d.ProviderBody = log.body
+		log.mu.Unlock()
+	}
+	if d.ProviderBody == "" {
+		var statusErr *core.HTTPStatusError
+		if errors.As(err, &statusErr) {
+			d.ProviderBody = statusErr.Body
+		}
+	}
+	d.ProviderBody = redact.String(d.ProviderBody)
+	pctx.Diagnostics = d
+}
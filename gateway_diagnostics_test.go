@@ -0,0 +1,114 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+func TestRoute_OnErrorDiagnostics(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: mockProviderName}, {VirtualKey: "anthropic"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	key := "sk-" + strings.Repeat("a", 40)
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		err: &core.HTTPStatusError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "mock API error (503): overloaded",
+			Body:       `{"error":"overloaded","key":"` + key + `"}`,
+		},
+	})
+	gw.RegisterProvider(&mockProvider{
+		name:   "anthropic",
+		models: []string{"gpt-4o"},
+		err:    errors.New("connection refused"),
+	})
+
+	var got *plugin.ErrorDiagnostics
+	_ = gw.RegisterPlugin(plugin.StageOnError, &testPlugin{
+		name: "dlq",
+		typ:  plugin.TypeLogging,
+		execFn: func(_ context.Context, pctx *plugin.Context) error {
+			got = pctx.Diagnostics
+			return nil
+		},
+	})
+
+	if _, err := gw.Route(context.Background(), providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+	}); err == nil {
+		t.Fatal("Route succeeded, want both targets to fail")
+	}
+	if got == nil {
+		t.Fatal("on_error plugin saw no diagnostics")
+	}
+	if len(got.Attempts) < 2 {
+		t.Fatalf("attempts = %+v, want one per target", got.Attempts)
+	}
+	first, last := got.Attempts[0], got.Attempts[len(got.Attempts)-1]
+	if first.Target != mockProviderName || first.Model != "gpt-4o" || first.StatusCode != http.StatusServiceUnavailable || first.Error == "" {
+		t.Errorf("first attempt = %+v, want the mock's 503", first)
+	}
+	if last.Target != "anthropic" || last.StatusCode != 0 || !strings.Contains(last.Error, "connection refused") {
+		t.Errorf("last attempt = %+v, want anthropic's transport error", last)
+	}
+	if got.StatusCode == 0 {
+		t.Error("status = 0, want the status the client is answered with")
+	}
+	if !strings.Contains(got.ProviderBody, "overloaded") || strings.Contains(got.ProviderBody, key) {
+		t.Errorf("provider body = %q, want the 503 body with its key redacted", got.ProviderBody)
+	}
+	if got.Latency <= 0 {
+		t.Errorf("latency = %v, want the request's latency", got.Latency)
+	}
+}
+
+func TestRouteStream_OnErrorDiagnostics(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: mockProviderName, models: []string{"gpt-4o"}},
+		streamFn: func(context.Context, providers.Request) (<-chan providers.StreamChunk, error) {
+			return nil, &core.HTTPStatusError{StatusCode: http.StatusBadRequest, Message: "mock API error (400): bad", Body: `{"error":"bad"}`}
+		},
+	})
+
+	var got *plugin.ErrorDiagnostics
+	_ = gw.RegisterPlugin(plugin.StageOnError, &testPlugin{
+		name: "dlq",
+		typ:  plugin.TypeLogging,
+		execFn: func(_ context.Context, pctx *plugin.Context) error {
+			got = pctx.Diagnostics
+			return nil
+		},
+	})
+
+	if _, err := gw.RouteStream(context.Background(), streamTestRequest()); err == nil {
+		t.Fatal("RouteStream succeeded, want the start to fail")
+	}
+	if got == nil || len(got.Attempts) != 1 || got.Attempts[0].StatusCode != http.StatusBadRequest {
+		t.Fatalf("diagnostics = %+v, want the one failed start", got)
+	}
+	// The client is answered 500 for a provider's 400; the attempt keeps the 400.
+	if got.StatusCode != http.StatusInternalServerError || got.ProviderBody != `{"error":"bad"}` {
+		t.Errorf("status = %d, body = %q, want the client's 500 and the provider's body", got.StatusCode, got.ProviderBody)
+	}
+}
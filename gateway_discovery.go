@@ -25,22 +25,30 @@ import (
 // span's operation and as a metrics label, so it is fixed here rather than
 // spelled out at each of its call sites.
 const (
-	surfaceEmbeddings = "embeddings"
-	surfaceImages     = "images"
+	surfaceEmbeddings  = "embeddings"
+	surfaceImages      = "images"
+	surfaceModerations = "moderations"
 )
 
 // Gateway model alias resolution, the multi-modal (embedding / image) routing
 // endpoints, and background model auto-discovery.
 
 // resolveModelAlias returns the alias target for model, or model unchanged.
+// An alias with canaries resolves to one of its canary models for their
+// configured share of calls.
 func (g *Gateway) resolveModelAlias(model string) string {
 	g.mu.RLock()
 	target, ok := g.config.Aliases[model]
+	canaries := g.config.Canaries[model]
 	g.mu.RUnlock()
-	if ok {
-		return target
+	if !ok {
+		return model
+	}
+	if len(canaries) > 0 {
+		target = pickCanary(canaries, target)
+		metrics.AliasRequestsTotal.WithLabelValues(model, target).Inc()
 	}
-	return model
+	return target
 }
 
 // resolveAlias replaces req.Model with its configured alias target (if any).
@@ -74,6 +82,7 @@ func (g *Gateway) runSurfaceGovernance(ctx context.Context, surface string, span
 		return err
 	}
 
+	start := time.Now()
 	pctx := plugin.NewContext(nil)
 	pctx.Span = span
 	defer plugin.PutContext(pctx)
@@ -97,6 +106,7 @@ func (g *Gateway) runSurfaceGovernance(ctx context.Context, surface string, span
 	usage, err := call(ctx)
 	if err != nil {
 		pctx.Error = err
+		setDiagnostics(ctx, pctx, err, time.Since(start))
 		plugins.RunOnError(ctx, pctx)
 		return err
 	}
@@ -124,6 +134,7 @@ func (g *Gateway) Embed(ctx context.Context, req providers.EmbeddingRequest) (*p
 	strategyMode := string(g.config.Strategy.Mode)
 	obs := g.obs
 	obsEventsActive := g.obsEventsActive
+	embeddingDims := g.config.EmbeddingDimensions
 	g.mu.RUnlock()
 	ctx, cancelDeadline := withRequestDeadline(ctx, requestTimeout)
 	defer cancelDeadline()
@@ -137,13 +148,23 @@ func (g *Gateway) Embed(ctx context.Context, req providers.EmbeddingRequest) (*p
 	defer span.End()
 
 	// Resolve model alias so embedding endpoints honour the same aliases as chat.
+	// A dimension policy is looked up by the name the caller sent first, so
+	// one keyed by the alias wins over one keyed by its model.
+	dims, ok := embeddingDims[req.Model]
 	req.Model = g.resolveModelAlias(req.Model)
+	if !ok {
+		dims, ok = embeddingDims[req.Model]
+	}
+	var dimsPolicy *EmbeddingDimensionPolicy
+	if ok {
+		dimsPolicy = &dims
+	}
 
 	var resp *providers.EmbeddingResponse
 	var providerName string
 	err := g.runSurfaceGovernance(ctx, surfaceEmbeddings, span, func(ctx context.Context) (*providers.Usage, error) {
 		var routeErr error
-		resp, providerName, routeErr = g.routeEmbedding(ctx, req)
+		resp, providerName, routeErr = g.routeEmbedding(ctx, req, dimsPolicy)
 		if routeErr != nil {
 			return nil, routeErr
 		}
@@ -310,34 +331,60 @@ func (g *Gateway) recordSurfaceError(ctx context.Context, span observability.Spa
 
 // surfaceTargetOrder resolves the candidate target keys for one embeddings/
 // images request, honouring strategy.mode the same way chat's getStrategy
-// does. mode is returned so routeEmbedding/routeImage know whether to advance
-// to the next target on failure (ModeFallback) or stop at the first attempt.
-func (g *Gateway) surfaceTargetOrder(model, surface string, usage models.Usage) ([]string, StrategyMode, error) {
-	g.mu.Lock()
-	g.ensureCircuitBreakersLocked()
-	g.ensureProviderLimitersLocked()
-	mode := g.config.Strategy.Mode
-	targets := append([]Target(nil), g.config.Targets...)
-	if mode == ModeLatency || mode == ModeCostOptimized || mode == ModeLoadBalance {
-		// Snapshot everything ranking needs, then release g.mu before doing any
-		// of the actual work. rankConfiguredSurfaceTargets loops every target
-		// doing latency/catalog lookups and, for ModeLoadBalance, reads the
-		// system CSPRNG (secureRandomUnit) — none of that should run while
-		// every chat request, admin config read, and provider registration
-		// blocks on this single global write lock. Mirrors getStrategy's
-		// snapshot-then-release pattern (gateway_strategy.go): map values
-		// (Provider) are themselves immutable references, and g.catalog is
-		// replaced wholesale rather than mutated in place (see
-		// refreshCatalog), so a plain reference copy is safe without cloning.
-		providerSnap := maps.Clone(g.providers)
-		catalog := g.catalog
-		unpricedStrategy := g.config.Strategy.UnpricedStrategy
-		g.mu.Unlock()
+// does. It keeps only the targets satisfying the request's residency
+// requirement, if it has one, and otherwise puts the targets local to the
+// request's region first while one is healthy. A request pinned by
+// RequestOptions.Target gets just that target. mode is returned so routeEmbedding/routeImage know whether to
+// advance to the next target on failure (ModeFallback) or stop at the first
+// attempt.
+func (g *Gateway) surfaceTargetOrder(ctx context.Context, model, surface string, usage models.Usage) ([]string, StrategyMode, error) {
+	snap, err := g.loadRouting()
+	if err != nil {
+		return nil, "", err
+	}
+	var keys []string
+	var mode StrategyMode
+	if target := pinnedTarget(ctx); target != "" {
+		keys, mode = []string{target}, ModeSingle
+	} else if keys, mode, err = g.surfaceStrategyOrder(snap, model, surface, usage); err != nil {
+		return nil, "", err
+	}
+	keys = inServiceKeys(snap, keys)
+	if requirement := snap.residencyOf(ctx); requirement != "" {
+		keys, err = snap.residency[requirement].filter(keys)
+		if err != nil {
+			return nil, "", err
+		}
+	} else if r := snap.regional[snap.requestRegion(ctx)]; r != nil {
+		keys = r.preferLocal(keys)
+	}
+	return keys, mode, nil
+}
 
-		keys, err := g.rankConfiguredSurfaceTargets(targets, providerSnap, catalog, unpricedStrategy, model, surface, usage, mode)
+// inServiceKeys drops the keys of draining and disabled targets. The
+// surfaces resolve keys against g.providers, which still holds them.
+func inServiceKeys(snap *routingSnapshot, keys []string) []string {
+	out := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if snap.inService(key) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// surfaceStrategyOrder is surfaceTargetOrder's order before the region
+// preference.
+func (g *Gateway) surfaceStrategyOrder(snap *routingSnapshot, model, surface string, usage models.Usage) ([]string, StrategyMode, error) {
+	mode, targets := snap.mode, snap.targets
+	if mode == ModeLatency || mode == ModeCostOptimized || mode == ModeLoadBalance {
+		// Ranking loops every target doing latency/catalog lookups and, for
+		// ModeLoadBalance, reads the system CSPRNG (secureRandomUnit). It runs
+		// against the routing snapshot, so none of it holds g.mu, and the
+		// snapshot is never mutated, so it is shared rather than copied.
+		keys, err := g.rankConfiguredSurfaceTargets(targets, snap.providers, snap.catalog, snap.unpricedStrategy, model, surface, usage, mode)
 		return keys, mode, err
 	}
-	g.mu.Unlock()
 
 	if mode == ModeContentBased {
 		// Content rules only look at req.Messages (prompt_contains /
@@ -361,11 +408,7 @@ func (g *Gateway) surfaceTargetOrder(model, surface string, usage models.Usage)
 	// Single, Fallback, Conditional, and ABTest key off req.Model (or nothing
 	// at all) — none of them need a prompt — so the shared strategy can
 	// resolve them exactly as it does for chat.
-	strategy, err := g.getStrategy()
-	if err != nil {
-		return nil, "", err
-	}
-	keys, err := strategy.SelectTargets(providers.Request{Model: model})
+	keys, err := snap.strategy.SelectTargets(providers.Request{Model: model})
 	if err != nil {
 		return nil, "", err
 	}
@@ -414,9 +457,13 @@ func (g *Gateway) rankConfiguredSurfaceTargets(targets []Target, providerSnap ma
 		// Weight only providers that implement this surface. Running the generic
 		// load-balancer first would let a chat-only target distort which embedding
 		// or image provider is selected even though it can never serve the call.
+		// Weights are scaled by each target's throttle factor, as in the chat
+		// load balancer.
+		weights := make([]float64, len(candidates))
 		totalWeight := 0.0
-		for _, candidate := range candidates {
-			totalWeight += surfaceWeight(candidate.weight)
+		for i, candidate := range candidates {
+			weights[i] = surfaceWeight(candidate.weight) * g.outcomes.throttleFactor(candidate.key)
+			totalWeight += weights[i]
 		}
 		randomUnit, err := secureRandomUnit()
 		if err != nil {
@@ -424,8 +471,8 @@ func (g *Gateway) rankConfiguredSurfaceTargets(targets []Target, providerSnap ma
 		}
 		pick := randomUnit * totalWeight
 		start := 0
-		for i, candidate := range candidates {
-			pick -= surfaceWeight(candidate.weight)
+		for i := range candidates {
+			pick -= weights[i]
 			if pick < 0 {
 				start = i
 				break
@@ -532,6 +579,12 @@ func providerSupportsSurface(p providers.Provider, surface string) bool {
 	case surfaceImages:
 		_, ok := p.(providers.ImageProvider)
 		return ok
+	case surfaceModerations:
+		_, ok := p.(providers.ModerationProvider)
+		return ok
+	case surfaceRealtime:
+		_, ok := p.(providers.ProxiableProvider)
+		return ok
 	default:
 		return false
 	}
@@ -545,9 +598,10 @@ func providerSupportsSurface(p providers.Provider, surface string) bool {
 // registered embedding-capable provider for it, preserving v1.3.0's guarantee
 // that a provider registered outside the target list stays reachable for the
 // models it serves — mirroring startStreamWithStrategy's use of
-// resolveFallbackStreamProviderLocked for the streaming surface.
-func (g *Gateway) routeEmbedding(ctx context.Context, req providers.EmbeddingRequest) (*providers.EmbeddingResponse, string, error) {
-	keys, mode, err := g.surfaceTargetOrder(req.Model, surfaceEmbeddings, models.Usage{PromptTokens: 1})
+// resolveFallbackStreamProviderLocked for the streaming surface. A non-nil
+// dims holds every target to a fixed vector dimension (see embedWithDimensions).
+func (g *Gateway) routeEmbedding(ctx context.Context, req providers.EmbeddingRequest, dims *EmbeddingDimensionPolicy) (*providers.EmbeddingResponse, string, error) {
+	keys, mode, err := g.surfaceTargetOrder(ctx, req.Model, surfaceEmbeddings, models.Usage{PromptTokens: 1})
 	if err != nil {
 		return nil, "", err
 	}
@@ -565,7 +619,7 @@ func (g *Gateway) routeEmbedding(ctx context.Context, req providers.EmbeddingReq
 		providerName := p.Name()
 
 		resp, callErr := routeSurfaceTarget(ctx, g, key, func(callCtx context.Context) (*providers.EmbeddingResponse, error) {
-			return ep.Embed(callCtx, req)
+			return embedWithDimensions(callCtx, ep, req, dims)
 		})
 		if callErr == nil {
 			return resp, providerName, nil
@@ -576,13 +630,13 @@ func (g *Gateway) routeEmbedding(ctx context.Context, req providers.EmbeddingReq
 		}
 	}
 
-	if !anyViable {
+	if !anyViable && !g.residencyBound(ctx) && pinnedTarget(ctx) == "" {
 		g.mu.RLock()
 		name, ep, ok := g.findEmbeddingProviderByModelLocked(req.Model)
 		g.mu.RUnlock()
 		if ok {
 			resp, callErr := routeSurfaceTarget(ctx, g, name, func(callCtx context.Context) (*providers.EmbeddingResponse, error) {
-				return ep.Embed(callCtx, req)
+				return embedWithDimensions(callCtx, ep, req, dims)
 			})
 			if callErr == nil {
 				return resp, name, nil
@@ -604,7 +658,7 @@ func (g *Gateway) routeImage(ctx context.Context, req providers.ImageRequest) (*
 	if req.N != nil && *req.N > 0 {
 		imageCount = *req.N
 	}
-	keys, mode, err := g.surfaceTargetOrder(req.Model, surfaceImages, models.Usage{ImageCount: imageCount})
+	keys, mode, err := g.surfaceTargetOrder(ctx, req.Model, surfaceImages, models.Usage{ImageCount: imageCount})
 	if err != nil {
 		return nil, "", err
 	}
@@ -633,7 +687,7 @@ func (g *Gateway) routeImage(ctx context.Context, req providers.ImageRequest) (*
 		}
 	}
 
-	if !anyViable {
+	if !anyViable && !g.residencyBound(ctx) && pinnedTarget(ctx) == "" {
 		g.mu.RLock()
 		name, ip, ok := g.findImageProviderByModelLocked(req.Model)
 		g.mu.RUnlock()
@@ -667,6 +721,7 @@ func routeSurfaceTarget[T any](ctx context.Context, g *Gateway, key string, call
 			return g.withTargetSlot(breakerCtx, key, func(slotCtx context.Context) error {
 				var callErr error
 				response, callErr = call(slotCtx)
+				g.outcomes.record(slotCtx, key, callErr)
 				return callErr
 			})
 		})
@@ -703,14 +758,12 @@ func (g *Gateway) StartDiscovery(ctx context.Context, interval time.Duration) er
 
 func (g *Gateway) runDiscovery(ctx context.Context, log *slog.Logger) {
 	g.mu.RLock()
-	providersCopy := make(map[string]providers.Provider, len(g.providers))
-	for k, v := range g.providers {
-		providersCopy[k] = v
-	}
+	names := append([]string(nil), g.providerNames...)
+	providersCopy := maps.Clone(g.providers)
 	g.mu.RUnlock()
 
-	for name, p := range providersCopy {
-		dp, ok := p.(providers.DiscoveryProvider)
+	for _, name := range names {
+		dp, ok := providersCopy[name].(providers.DiscoveryProvider)
 		if !ok {
 			continue
 		}
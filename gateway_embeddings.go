@@ -0,0 +1,116 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Embedding dimensions: models of different providers return vectors of
+// different lengths, so a fallback can silently hand a vector store vectors it
+// will not accept. A model with an EmbeddingDimensionPolicy has its dimension
+// requested from every provider that honours the dimensions parameter, and
+// each response is checked before it counts as the target's success. A
+// mismatch either fails the target, as a malformed response, so the fallback
+// moves on, or is resized with a warning.
+
+// Mismatch actions, used as the metric label.
+const (
+	dimensionRejected  = "rejected"
+	dimensionPadded    = "padded"
+	dimensionTruncated = "truncated"
+)
+
+// embedWithDimensions calls ep for req and, when dims is set, holds the
+// response to its dimension.
+func embedWithDimensions(ctx context.Context, ep providers.EmbeddingProvider, req providers.EmbeddingRequest, dims *EmbeddingDimensionPolicy) (*providers.EmbeddingResponse, error) {
+	if dims == nil {
+		return ep.Embed(ctx, req)
+	}
+	req.Dimensions = nil
+	if supportsEmbeddingDimensions(ep, req.Model) {
+		n := dims.Dimensions
+		req.Dimensions = &n
+	}
+	resp, err := ep.Embed(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := conformEmbeddings(ctx, ep.Name(), resp, dims); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// supportsEmbeddingDimensions reports whether ep honours a requested
+// dimension for model. Providers that do not say are assumed not to, so the
+// field never reaches an API that rejects it.
+func supportsEmbeddingDimensions(ep providers.EmbeddingProvider, model string) bool {
+	if s, ok := ep.(providers.EmbeddingDimensionsSupporter); ok {
+		return s.SupportsEmbeddingDimensions(model)
+	}
+	return false
+}
+
+// conformEmbeddings checks every vector in resp against dims, rejecting the
+// response or resizing its vectors in place per dims.OnMismatch. A truncated
+// vector is scaled back to unit length, since cosine and dot-product search
+// expect the normalized vectors embedding models return; padding with zeros
+// keeps the length as it was.
+func conformEmbeddings(ctx context.Context, providerName string, resp *providers.EmbeddingResponse, dims *EmbeddingDimensionPolicy) error {
+	want := dims.Dimensions
+	got := -1
+	for _, e := range resp.Data {
+		if len(e.Embedding) != want {
+			got = len(e.Embedding)
+			break
+		}
+	}
+	if got < 0 {
+		return nil
+	}
+	if dims.OnMismatch != EmbeddingMismatchPadTruncate {
+		metrics.EmbeddingDimensionMismatchesTotal.WithLabelValues(providerName, dimensionRejected).Inc()
+		return fmt.Errorf("%s: %w: embedding has %d dimensions, want %d",
+			providerName, providers.ErrMalformedResponse, got, want)
+	}
+
+	action := dimensionPadded
+	if got > want {
+		action = dimensionTruncated
+	}
+	for i := range resp.Data {
+		v := resp.Data[i].Embedding
+		switch {
+		case len(v) > want:
+			resp.Data[i].Embedding = normalizeEmbedding(v[:want:want])
+		case len(v) < want:
+			resp.Data[i].Embedding = append(v, make([]float64, want-len(v))...)
+		}
+	}
+	metrics.EmbeddingDimensionMismatchesTotal.WithLabelValues(providerName, action).Inc()
+	logging.FromContext(ctx).Warn("embedding dimension mismatch; vectors resized",
+		"provider", providerName, "model", resp.Model, "dimensions", got, "want", want, "action", action)
+	return nil
+}
+
+// normalizeEmbedding scales v to unit length in place. A zero vector is left
+// as it is.
+func normalizeEmbedding(v []float64) []float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := math.Sqrt(sum)
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// vectorsOf returns an embedFn answering with one vector per length, recording
+// the dimensions each call asked for.
+func vectorsOf(asked *[]*int, lengths ...int) func(context.Context, providers.EmbeddingRequest) (*providers.EmbeddingResponse, error) {
+	return func(_ context.Context, req providers.EmbeddingRequest) (*providers.EmbeddingResponse, error) {
+		*asked = append(*asked, req.Dimensions)
+		resp := &providers.EmbeddingResponse{Model: req.Model}
+		for i, n := range lengths {
+			v := make([]float64, n)
+			for j := range v {
+				v[j] = 1
+			}
+			resp.Data = append(resp.Data, providers.Embedding{Embedding: v, Index: i})
+		}
+		return resp, nil
+	}
+}
+
+// fixedDimEmbedder is an embedding provider whose models cannot honour a
+// requested dimension.
+type fixedDimEmbedder struct{ mockEmbeddingProvider }
+
+func (fixedDimEmbedder) SupportsEmbeddingDimensions(string) bool { return false }
+
+// dimEmbedder is an embedding provider whose models honour a requested
+// dimension.
+type dimEmbedder struct{ mockEmbeddingProvider }
+
+func (dimEmbedder) SupportsEmbeddingDimensions(string) bool { return true }
+
+func TestEmbed_DimensionMismatchFallsBack(t *testing.T) {
+	var askedFirst, askedSecond []*int
+	first := &dimEmbedder{mockEmbeddingProvider{mockProvider: mockProvider{name: "first", models: []string{"embed-a"}}}}
+	first.embedFn = vectorsOf(&askedFirst, 3)
+	second := &mockEmbeddingProvider{mockProvider: mockProvider{name: "second", models: []string{"embed-a"}}}
+	second.embedFn = vectorsOf(&askedSecond, 4)
+	gw, _ := newTestGateway(t, Config{
+		Strategy:            StrategyConfig{Mode: ModeFallback},
+		Targets:             []Target{{VirtualKey: "first"}, {VirtualKey: "second"}},
+		Aliases:             map[string]string{"vectors": "embed-a"},
+		EmbeddingDimensions: map[string]EmbeddingDimensionPolicy{"vectors": {Dimensions: 4}},
+	})
+	gw.RegisterProvider(first)
+	gw.RegisterProvider(second)
+
+	resp, err := gw.Embed(context.Background(), providers.EmbeddingRequest{Model: "vectors", Input: "hi"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(resp.Data) != 1 || len(resp.Data[0].Embedding) != 4 {
+		t.Fatalf("got %+v, want one 4-dimension vector from the second target", resp.Data)
+	}
+	if len(askedFirst) != 1 || askedFirst[0] == nil || *askedFirst[0] != 4 {
+		t.Errorf("first target asked for dimensions %v, want 4", askedFirst)
+	}
+	if len(askedSecond) != 1 || askedSecond[0] != nil {
+		t.Errorf("second target asked for dimensions %v, want one call without: a provider that does not say is sent none", askedSecond)
+	}
+}
+
+func TestEmbed_DimensionMismatchRejected(t *testing.T) {
+	var asked []*int
+	ep := &mockEmbeddingProvider{mockProvider: mockProvider{name: mockProviderName, models: []string{"embed-a"}}}
+	ep.embedFn = vectorsOf(&asked, 3)
+	gw, _ := newTestGateway(t, Config{
+		Strategy:            StrategyConfig{Mode: ModeSingle},
+		Targets:             []Target{{VirtualKey: mockProviderName}},
+		EmbeddingDimensions: map[string]EmbeddingDimensionPolicy{"embed-a": {Dimensions: 4, OnMismatch: EmbeddingMismatchReject}},
+	})
+	gw.RegisterProvider(ep)
+
+	_, err := gw.Embed(context.Background(), providers.EmbeddingRequest{Model: "embed-a", Input: "hi"})
+	if !errors.Is(err, providers.ErrMalformedResponse) {
+		t.Fatalf("Embed error = %v, want ErrMalformedResponse", err)
+	}
+}
+
+func TestEmbed_DimensionPadTruncate(t *testing.T) {
+	var asked []*int
+	ep := &fixedDimEmbedder{mockEmbeddingProvider{mockProvider: mockProvider{name: mockProviderName, models: []string{"embed-a"}}}}
+	ep.embedFn = vectorsOf(&asked, 6, 2, 4)
+	gw, _ := newTestGateway(t, Config{
+		Strategy:            StrategyConfig{Mode: ModeSingle},
+		Targets:             []Target{{VirtualKey: mockProviderName}},
+		EmbeddingDimensions: map[string]EmbeddingDimensionPolicy{"embed-a": {Dimensions: 4, OnMismatch: EmbeddingMismatchPadTruncate}},
+	})
+	gw.RegisterProvider(ep)
+
+	dims := 8
+	resp, err := gw.Embed(context.Background(), providers.EmbeddingRequest{Model: "embed-a", Input: []string{"a", "b", "c"}, Dimensions: &dims})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(asked) != 1 || asked[0] != nil {
+		t.Errorf("provider without dimension support was sent dimensions %v", asked)
+	}
+	// The truncated vector is scaled back to unit length; padding keeps it.
+	want := [][]float64{{0.5, 0.5, 0.5, 0.5}, {1, 1, 0, 0}, {1, 1, 1, 1}}
+	for i, e := range resp.Data {
+		if len(e.Embedding) != 4 {
+			t.Fatalf("vector %d has %d dimensions, want 4", i, len(e.Embedding))
+		}
+		for j := range want[i] {
+			if e.Embedding[j] != want[i][j] {
+				t.Errorf("vector %d = %v, want %v", i, e.Embedding, want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestValidateConfig_EmbeddingDimensions(t *testing.T) {
+	base := Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai"}},
+	}
+	for name, p := range map[string]EmbeddingDimensionPolicy{
+		"zero dimensions":     {},
+		"unknown on_mismatch": {Dimensions: 8, OnMismatch: "pad"},
+	} {
+		cfg := base
+		cfg.EmbeddingDimensions = map[string]EmbeddingDimensionPolicy{"vectors": p}
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%s: ValidateConfig accepted %+v", name, p)
+		}
+	}
+	base.EmbeddingDimensions = map[string]EmbeddingDimensionPolicy{"vectors": {Dimensions: 8, OnMismatch: EmbeddingMismatchPadTruncate}}
+	if err := ValidateConfig(base); err != nil {
+		t.Errorf("ValidateConfig rejected a valid policy: %v", err)
+	}
+}
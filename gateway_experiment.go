@@ -0,0 +1,142 @@
+package aigateway
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/strategies"
+	"github.com/ferro-labs/ai-gateway/internal/tokens"
+	"github.com/ferro-labs/ai-gateway/internal/upstreamhdr"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// maxShadowRequests bounds the experiment shadow requests in flight at once.
+// A sample drawn while every slot is busy is skipped rather than queued, so
+// shadow traffic can never pile up behind a slow candidate.
+const maxShadowRequests = 16
+
+// shadowTimeout bounds one shadow request, which runs after the client has
+// its answer and so has no request deadline of its own.
+const shadowTimeout = 2 * time.Minute
+
+// ExperimentSample is one compared pair of answers: the primary model's and
+// the candidate's to the same request. Err is set, and the shadowed side's
+// output is empty, when the shadow request failed.
+type ExperimentSample struct {
+	Experiment Experiment
+	// Shadowed is the model the gateway called in the background; the
+	// other one served the client.
+	Shadowed        string
+	PrimaryOutput   string
+	CandidateOutput string
+	// ShadowLatency is how long the shadow request took.
+	ShadowLatency time.Duration
+	Err           error
+}
+
+// ExperimentObserverFunc receives each experiment sample. It runs on the
+// shadow request's goroutine, off the request path.
+type ExperimentObserverFunc func(ctx context.Context, sample ExperimentSample)
+
+// SetExperimentObserver installs the function experiment samples are
+// reported to. Without one, experiments send no shadow traffic.
+//
+// Safe to call only at startup, before serving traffic.
+func (g *Gateway) SetExperimentObserver(fn ExperimentObserverFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.experimentObserver = fn
+}
+
+// shadowExperiments starts a shadow request for every experiment whose
+// primary or candidate served req and whose sample draw req wins. resp is
+// the served answer; its content is read before shadowExperiments returns.
+// tiers is the caller's config snapshot: a shadow request is admitted against,
+// and its tokens charged to, the rate-limit tier of the key that sent req.
+func (g *Gateway) shadowExperiments(ctx context.Context, s strategies.Strategy, tiers []RateLimitTier, experiments []Experiment, observe ExperimentObserverFunc, req providers.Request, resp *providers.Response) {
+	if observe == nil {
+		return
+	}
+	for _, e := range experiments {
+		var shadowed string
+		switch req.Model {
+		case e.Primary:
+			shadowed = e.Candidate
+		case e.Candidate:
+			shadowed = e.Primary
+		default:
+			continue
+		}
+		if e.SamplePercent > 0 && rand.Float64()*100 >= e.SamplePercent { //nolint:gosec // G404: math/rand is fine for experiment sampling, not security-sensitive
+			continue
+		}
+		select {
+		case g.experimentSlots <- struct{}{}:
+		default:
+			logging.FromContext(ctx).Debug("experiment shadow skipped, too many in flight", "experiment", e.ID)
+			continue
+		}
+		// A shadow request spends the key's tokens like any other, and is
+		// skipped rather than sent when its tier would refuse it.
+		admission, err := g.admitTier(ctx, tiers, false, 0)
+		if err != nil {
+			<-g.experimentSlots
+			logging.FromContext(ctx).Debug("experiment shadow skipped, key's tier refused it", "experiment", e.ID, "error", err)
+			continue
+		}
+		sample := ExperimentSample{Experiment: e, Shadowed: shadowed}
+		served := firstChoiceContent(resp)
+		if shadowed == e.Candidate {
+			sample.PrimaryOutput = served
+		} else {
+			sample.CandidateOutput = served
+		}
+		shadowReq := req
+		shadowReq.Model = shadowed
+		go g.runShadow(upstreamhdr.WithoutRecorder(context.WithoutCancel(ctx)), s, admission, shadowReq, sample, observe)
+	}
+}
+
+// runShadow sends the shadow request, charges its tokens to admission, and
+// reports the completed sample. ctx keeps the original request's values but
+// not its cancellation; the shadow request is bounded by shadowTimeout and
+// ends with the gateway.
+func (g *Gateway) runShadow(ctx context.Context, s strategies.Strategy, admission *tierAdmission, req providers.Request, sample ExperimentSample, observe ExperimentObserverFunc) {
+	defer func() { <-g.experimentSlots }()
+	ctx, cancel := context.WithTimeout(ctx, shadowTimeout)
+	defer cancel()
+	if g.shutdownCtx != nil {
+		stop := context.AfterFunc(g.shutdownCtx, cancel)
+		defer stop()
+	}
+
+	start := time.Now()
+	resp, err := s.Execute(ctx, req)
+	sample.ShadowLatency = time.Since(start)
+	if err != nil {
+		sample.Err = err
+		observe(ctx, sample)
+		return
+	}
+	usage := resp.Usage
+	if tokens.Missing(usage) {
+		usage = tokens.Estimate(tokens.Prompt(req), resp)
+	}
+	admission.done(usage.TotalTokens)
+	if req.Model == sample.Experiment.Candidate {
+		sample.CandidateOutput = firstChoiceContent(resp)
+	} else {
+		sample.PrimaryOutput = firstChoiceContent(resp)
+	}
+	observe(ctx, sample)
+}
+
+// firstChoiceContent returns the text of resp's first choice.
+func firstChoiceContent(resp *providers.Response) string {
+	if resp == nil || len(resp.Choices) == 0 {
+		return ""
+	}
+	return resp.Choices[0].Message.Content
+}
@@ -0,0 +1,119 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestRoute_ExperimentShadowsCandidate(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy:    StrategyConfig{Mode: ModeSingle},
+		Targets:     []Target{{VirtualKey: "openai"}},
+		Experiments: []Experiment{{ID: "e", Primary: "gpt-4o", Candidate: "gpt-4.1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw.RegisterProvider(&mockProvider{name: "openai", models: []string{"gpt-4o", "gpt-4.1"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			return &providers.Response{ID: "r", Model: req.Model, Choices: []providers.Choice{{
+				Message: providers.Message{Role: "assistant", Content: "from " + req.Model},
+			}}}, nil
+		}})
+	samples := make(chan ExperimentSample, 1)
+	gw.SetExperimentObserver(func(_ context.Context, s ExperimentSample) { samples <- s })
+
+	resp, err := gw.Route(context.Background(), providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.Model != "gpt-4o" {
+		t.Fatalf("client served by %q, want the primary", resp.Model)
+	}
+	select {
+	case s := <-samples:
+		if s.Err != nil || s.Shadowed != "gpt-4.1" || s.PrimaryOutput != "from gpt-4o" || s.CandidateOutput != "from gpt-4.1" {
+			t.Fatalf("sample = %+v", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no experiment sample observed")
+	}
+}
+
+// A shadow request is charged to the tier of the key that sent the original,
+// and skipped when that tier refuses it.
+func TestRoute_ExperimentShadowChargesKeyTier(t *testing.T) {
+	for name, tc := range map[string]struct {
+		tier       RateLimitTier
+		wantSample bool
+		wantTokens int64
+	}{
+		"charged": {RateLimitTier{Name: "free", MonthlyTokenLimit: 1000}, true, 80},
+		"refused": {RateLimitTier{Name: "free", RequestsPerSecond: 1}, false, 40},
+	} {
+		gw, err := newTestGateway(t, Config{
+			Strategy:       StrategyConfig{Mode: ModeSingle},
+			Targets:        []Target{{VirtualKey: "openai"}},
+			Experiments:    []Experiment{{ID: "e", Primary: "gpt-4o", Candidate: "gpt-4.1"}},
+			RateLimitTiers: []RateLimitTier{tc.tier},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gw.RegisterProvider(&mockProvider{name: "openai", models: []string{"gpt-4o", "gpt-4.1"},
+			completeFn: func(context.Context, providers.Request) (*providers.Response, error) {
+				return &providers.Response{ID: "r", Usage: providers.Usage{TotalTokens: 40}}, nil
+			}})
+		samples := make(chan ExperimentSample, 1)
+		gw.SetExperimentObserver(func(_ context.Context, s ExperimentSample) { samples <- s })
+
+		if _, err := gw.Route(tierContext("key-a", "free"), providers.Request{
+			Model:    "gpt-4o",
+			Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		}); err != nil {
+			t.Fatalf("%s: Route: %v", name, err)
+		}
+		select {
+		case <-samples:
+			if !tc.wantSample {
+				t.Fatalf("%s: a refused shadow was sent", name)
+			}
+		case <-time.After(200 * time.Millisecond):
+			if tc.wantSample {
+				t.Fatalf("%s: no experiment sample observed", name)
+			}
+		}
+		if got := gw.tiers.monthlyTokens("key-a"); got != tc.wantTokens {
+			t.Errorf("%s: tokens charged to the key = %d, want %d", name, got, tc.wantTokens)
+		}
+	}
+}
+
+func TestValidateConfig_Experiments(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai"}},
+	}
+	for name, exps := range map[string][]Experiment{
+		"no id":        {{Primary: "a", Candidate: "b"}},
+		"same models":  {{ID: "e", Primary: "a", Candidate: "a"}},
+		"no candidate": {{ID: "e", Primary: "a"}},
+		"bad sample":   {{ID: "e", Primary: "a", Candidate: "b", SamplePercent: 150}},
+		"duplicate id": {{ID: "e", Primary: "a", Candidate: "b"}, {ID: "e", Primary: "c", Candidate: "d"}},
+	} {
+		cfg.Experiments = exps
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+	cfg.Experiments = []Experiment{{ID: "e", Primary: "a", Candidate: "b", SamplePercent: 5}}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid experiment rejected: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/capabilities"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// extraProvider checks a request's extra_body parameters against the target
+// provider's allowlist (capabilities.AcceptsExtra) before it is sent. An
+// extra the provider does not accept is never forwarded: it is dropped with a
+// warning, or the request is refused with an UnsupportedParamError when the
+// compatibility mode is reject. Unlike a standard parameter, an extra is not
+// forwarded in warn mode, since nothing vouches that the provider would
+// tolerate it. Checking per target means a fallback chain forwards each
+// extra only to the providers that understand it.
+type extraProvider struct {
+	providers.Provider
+	name string
+}
+
+func (p *extraProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	req, err := p.filter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *extraProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	req, err := p.filter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return sp.CompleteStream(ctx, req)
+}
+
+// filter returns req with the extras the provider does not accept removed.
+// req.Extra is copied rather than edited, since the same request may go on to
+// another target.
+func (p *extraProvider) filter(ctx context.Context, req providers.Request) (providers.Request, error) {
+	var rejected []string
+	for name := range req.Extra {
+		if !capabilities.AcceptsExtra(p.Provider.Name(), name) {
+			rejected = append(rejected, name)
+		}
+	}
+	if len(rejected) == 0 {
+		return req, nil
+	}
+	slices.Sort(rejected)
+	if core.UnsupportedParamModeFromContext(ctx) == core.UnsupportedParamReject {
+		return req, core.NewUnsupportedParamError(p.name, prefixed(rejected))
+	}
+	logging.FromContext(ctx).Warn(
+		"provider does not accept extra_body parameter(s); dropping",
+		"provider", p.name,
+		"model", req.Model,
+		"dropped_params", prefixed(rejected),
+	)
+	extra := maps.Clone(req.Extra)
+	for _, name := range rejected {
+		delete(extra, name)
+	}
+	if len(extra) == 0 {
+		extra = nil
+	}
+	req.Extra = extra
+	return req, nil
+}
+
+// prefixed names extras as the client sent them, e.g. "extra_body.top_k".
+func prefixed(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = "extra_body." + name
+	}
+	return out
+}
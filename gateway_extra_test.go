@@ -0,0 +1,63 @@
+package aigateway
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// newExtraTestGateway returns a fallback gateway over the mock provider,
+// which accepts no extras, and "anthropic", which accepts thinking. Each
+// provider records the extras it was sent.
+func newExtraTestGateway(t *testing.T, mode string) (*Gateway, map[string][]map[string]json.RawMessage) {
+	t.Helper()
+	return newRecordingGateway(t,
+		Config{Compatibility: CompatibilityConfig{OnUnsupportedParam: mode}},
+		func(req providers.Request) map[string]json.RawMessage { return req.Extra },
+		recordingTarget{name: mockProviderName}, recordingTarget{name: "anthropic"},
+	)
+}
+
+func extraRequest() providers.Request {
+	return providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		Extra:    map[string]json.RawMessage{"thinking": json.RawMessage(`{"type":"enabled"}`)},
+	}
+}
+
+func TestGateway_ExtraNotAcceptedIsDropped(t *testing.T) {
+	gw, sent := newExtraTestGateway(t, "")
+
+	req := extraRequest()
+	resp, err := gw.Route(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "from-"+mockProviderName {
+		t.Fatalf("got %q, want the first target", resp.ID)
+	}
+	if got := sent[mockProviderName]; len(got) != 1 || got[0] != nil {
+		t.Fatalf("mock was sent extras %v, want none", got)
+	}
+	if len(req.Extra) != 1 {
+		t.Fatal("the caller's extras were modified")
+	}
+}
+
+func TestGateway_ExtraRejectFallsBackToAcceptingProvider(t *testing.T) {
+	gw, sent := newExtraTestGateway(t, "reject")
+
+	resp, err := gw.Route(context.Background(), extraRequest())
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "from-anthropic" || len(sent[mockProviderName]) != 0 {
+		t.Fatalf("got %q (mock calls %d), want the provider accepting thinking", resp.ID, len(sent[mockProviderName]))
+	}
+	if got := sent["anthropic"]; len(got) != 1 || got[0]["thinking"] == nil {
+		t.Fatalf("anthropic was sent extras %v, want thinking", got)
+	}
+}
@@ -0,0 +1,121 @@
+package aigateway
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+)
+
+// Fair queueing for the concurrency limiter. A single FIFO of waiters lets one
+// tenant's burst fill the queue and take every slot that frees up, so every
+// other tenant of the target waits behind it. Instead each tenant queues
+// separately and a freed slot goes to the tenants in weighted round-robin
+// order: a tenant with weight w is granted up to w slots per turn. Tenants with
+// nothing queued are skipped, so a lone tenant still gets every slot.
+
+// tenantOf returns the fairness tenant of ctx's request: the authenticated
+// key's workspace, else its key ID. Unauthenticated requests share the ""
+// tenant.
+func tenantOf(ctx context.Context) string {
+	if id, ok := authctx.Identity(ctx); ok && id.Workspace != "" {
+		return id.Workspace
+	}
+	keyID, _ := authctx.KeyID(ctx)
+	return keyID
+}
+
+// waiter is one request queued for an in-flight slot. ready is buffered so a
+// slot can be handed over without blocking the releaser.
+type waiter struct {
+	ready chan struct{}
+}
+
+// fairQueue is the set of per-tenant waiter queues. It is not safe for
+// concurrent use; providerLimiter guards it with its mutex.
+type fairQueue struct {
+	weights map[string]int
+	queues  map[string][]*waiter
+	// order lists the tenants with waiters in round-robin order; turn indexes
+	// the tenant being served and credit is what is left of its turn.
+	order  []string
+	turn   int
+	credit int
+}
+
+func (q *fairQueue) weight(tenant string) int {
+	if w := q.weights[tenant]; w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (q *fairQueue) empty() bool { return len(q.order) == 0 }
+
+// push queues w behind tenant's earlier waiters.
+func (q *fairQueue) push(tenant string, w *waiter) {
+	if q.queues == nil {
+		q.queues = make(map[string][]*waiter)
+	}
+	if len(q.queues[tenant]) == 0 {
+		q.order = append(q.order, tenant)
+	}
+	q.queues[tenant] = append(q.queues[tenant], w)
+}
+
+// pop dequeues the next waiter in weighted round-robin order, or nil when no
+// tenant has one.
+func (q *fairQueue) pop() *waiter {
+	if q.empty() {
+		return nil
+	}
+	if q.turn >= len(q.order) {
+		q.turn = 0
+	}
+	tenant := q.order[q.turn]
+	if q.credit <= 0 {
+		q.credit = q.weight(tenant)
+	}
+	queue := q.queues[tenant]
+	w := queue[0]
+	queue[0] = nil
+	q.credit--
+	if len(queue) == 1 {
+		q.dropTenant(q.turn)
+	} else {
+		q.queues[tenant] = queue[1:]
+		if q.credit == 0 {
+			q.turn++
+		}
+	}
+	return w
+}
+
+// remove dequeues w, whose request gave up waiting, and reports whether it
+// was still queued.
+func (q *fairQueue) remove(tenant string, w *waiter) bool {
+	queue := q.queues[tenant]
+	i := slices.Index(queue, w)
+	if i < 0 {
+		return false
+	}
+	if len(queue) == 1 {
+		q.dropTenant(slices.Index(q.order, tenant))
+		return true
+	}
+	q.queues[tenant] = slices.Delete(queue, i, i+1)
+	return true
+}
+
+// dropTenant removes the tenant at order[i], whose queue is now empty.
+func (q *fairQueue) dropTenant(i int) {
+	delete(q.queues, q.order[i])
+	q.order = slices.Delete(q.order, i, i+1)
+	switch {
+	case i < q.turn:
+		q.turn--
+	case i == q.turn:
+		// The next tenant slides into this position and starts a fresh turn.
+		q.credit = 0
+	}
+}
@@ -0,0 +1,106 @@
+package aigateway
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+)
+
+func TestFairQueue_WeightedRoundRobin(t *testing.T) {
+	q := fairQueue{weights: map[string]int{"heavy": 2}}
+	owner := map[*waiter]string{}
+	enqueue := func(tenant string, n int) {
+		for range n {
+			w := &waiter{}
+			owner[w] = tenant
+			q.push(tenant, w)
+		}
+	}
+	enqueue("burst", 5)
+	enqueue("heavy", 4)
+	enqueue("light", 1)
+
+	var got []string
+	for w := q.pop(); w != nil; w = q.pop() {
+		got = append(got, owner[w])
+	}
+	want := []string{"burst", "heavy", "heavy", "light", "burst", "heavy", "heavy", "burst", "burst", "burst"}
+	if !slices.Equal(got, want) {
+		t.Errorf("service order = %v, want %v", got, want)
+	}
+	if !q.empty() {
+		t.Error("queue not empty after draining")
+	}
+}
+
+func TestFairQueue_RemoveKeepsRotation(t *testing.T) {
+	var q fairQueue
+	a1, a2, b, c := &waiter{}, &waiter{}, &waiter{}, &waiter{}
+	q.push("a", a1)
+	q.push("a", a2)
+	q.push("b", b)
+	q.push("c", c)
+
+	if q.pop() != a1 {
+		t.Fatal("first pop did not serve a")
+	}
+	// b gives up; c is next, then a again.
+	if !q.remove("b", b) {
+		t.Fatal("remove did not find a queued waiter")
+	}
+	if q.remove("b", b) {
+		t.Error("remove found a waiter twice")
+	}
+	if got := q.pop(); got != c {
+		t.Error("second pop did not serve c")
+	}
+	if got := q.pop(); got != a2 {
+		t.Error("third pop did not serve a")
+	}
+	if q.pop() != nil || !q.empty() {
+		t.Error("queue not empty after draining")
+	}
+}
+
+// TestProviderLimiter_BurstDoesNotStarveOtherTenants queues a burst from one
+// workspace ahead of a single request from another; the second workspace must
+// get the next slot rather than wait out the burst.
+func TestProviderLimiter_BurstDoesNotStarveOtherTenants(t *testing.T) {
+	lim := newProviderLimiter(1, 100)
+	if err := lim.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	workspace := func(name string) context.Context {
+		return authctx.WithIdentity(context.Background(), authctx.KeyIdentity{Workspace: name})
+	}
+	served := make(chan string, 6)
+	wait := func(tenant string) {
+		if err := lim.acquire(workspace(tenant)); err != nil {
+			t.Error(err)
+			return
+		}
+		served <- tenant
+	}
+	for i := range 5 {
+		go wait("burst")
+		waitFor(t, func() bool { return lim.waiting.Load() == int64(i+1) })
+	}
+	go wait("quiet")
+	waitFor(t, func() bool { return lim.waiting.Load() == 6 })
+
+	var order []string
+	for range 6 {
+		lim.release()
+		order = append(order, <-served)
+	}
+	lim.release()
+	if order[1] != "quiet" {
+		t.Errorf("service order = %v; the quiet workspace waited behind the burst", order)
+	}
+	if got := lim.waiting.Load(); got != 0 {
+		t.Errorf("waiting = %d after draining, want 0", got)
+	}
+}
@@ -35,6 +35,9 @@ type ProviderReadiness struct {
 	// "half-open". A provider without a configured circuit breaker reports
 	// "closed".
 	Circuit string
+	// Warmup is the provider's startup warm-up state: "pending", "ok", or
+	// "failed", and empty when no warm-up has run (see WarmProviders).
+	Warmup string
 }
 
 // MCPServerReadiness reports a single MCP server's availability.
@@ -73,7 +76,7 @@ func (g *Gateway) Readiness() Readiness {
 		if circuit != circuitOpen {
 			ready = true
 		}
-		provs = append(provs, ProviderReadiness{Name: name, Circuit: circuit})
+		provs = append(provs, ProviderReadiness{Name: name, Circuit: circuit, Warmup: g.warmup.get(name)})
 	}
 	return Readiness{Ready: ready, Providers: provs, MCPServers: g.mcpReadiness()}
 }
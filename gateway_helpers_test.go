@@ -66,6 +66,62 @@ func (m *mockStreamProvider) CompleteStream(ctx context.Context, req providers.R
 	return ch, nil
 }
 
+// recordingTarget is one provider of newRecordingGateway. It fails every
+// request with err when err is set.
+type recordingTarget struct {
+	name string
+	err  error
+}
+
+// newRecordingGateway returns a fallback gateway over targets, in order. Each
+// is a mock provider of "gpt-4o" that appends record(req) to sent[name] for
+// every request it is sent and, unless it fails, answers with ID
+// "from-<name>".
+func newRecordingGateway[T any](t *testing.T, cfg Config, record func(providers.Request) T, targets ...recordingTarget) (*Gateway, map[string][]T) {
+	t.Helper()
+	cfg.Strategy.Mode = ModeFallback
+	cfg.Targets = make([]Target, 0, len(targets))
+	for _, target := range targets {
+		cfg.Targets = append(cfg.Targets, Target{VirtualKey: target.name})
+	}
+	gw, err := newTestGateway(t, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sent := map[string][]T{}
+	for _, target := range targets {
+		gw.RegisterProvider(&mockProvider{
+			name:   target.name,
+			models: []string{"gpt-4o"},
+			completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+				sent[target.name] = append(sent[target.name], record(req))
+				if target.err != nil {
+					return nil, target.err
+				}
+				return &providers.Response{
+					ID:    "from-" + target.name,
+					Model: req.Model,
+					Choices: []providers.Choice{{
+						Message:      providers.Message{Role: "assistant", Content: "ok"},
+						FinishReason: "stop",
+					}},
+					Usage: providers.Usage{PromptTokens: 3, CompletionTokens: 5, TotalTokens: 8},
+				}, nil
+			},
+		})
+	}
+	return gw, sent
+}
+
+// intOrUnset returns *v, or -1 when v is nil, for recording optional
+// parameters.
+func intOrUnset(v *int) int {
+	if v == nil {
+		return -1
+	}
+	return *v
+}
+
 func counterValue(t *testing.T, c prometheus.Counter) float64 {
 	t.Helper()
 	m := &dto.Metric{}
@@ -125,7 +181,7 @@ func requireKeys(t *testing.T, got []string, want ...string) {
 // returns the streaming target order it selects, failing on any error.
 func streamTargetOrder(t *testing.T, gw *Gateway, req providers.Request) []string {
 	t.Helper()
-	s, err := gw.getStrategy()
+	s, err := gw.getStrategy(context.Background())
 	if err != nil {
 		t.Fatalf("getStrategy: %v", err)
 	}
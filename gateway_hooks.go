@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
 	"github.com/ferro-labs/ai-gateway/internal/events"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/metrics"
@@ -19,9 +20,9 @@ import (
 // does not spawn an unbounded number of hook workers.
 const maxHookWorkers = 4
 
-// EventHookFunc is called asynchronously after a gateway event (request
-// completed or failed). It replaces the old EventPublisher interface with a
-// simpler function-based hook pattern.
+// EventHookFunc is called asynchronously after a gateway event: a request
+// completed or failed, or an API key lifecycle event. It replaces the old
+// EventPublisher interface with a simpler function-based hook pattern.
 type EventHookFunc func(ctx context.Context, subject string, data map[string]any)
 
 // hookDispatch is a work item handed to the async hook workers over a channel.
@@ -168,13 +169,69 @@ func (b *hookBus) wait() {
 }
 
 // AddHook registers an EventHookFunc that is called asynchronously on each
-// completed or failed request. Multiple hooks may be registered; all are
-// invoked for every event on the shared bounded hook worker pool, so hook
-// implementations should return promptly and avoid indefinite blocking.
+// completed or failed request and each API key lifecycle event. Multiple
+// hooks may be registered; all are invoked for every event on the shared
+// bounded hook worker pool, so hook implementations should return promptly
+// and avoid indefinite blocking.
 func (g *Gateway) AddHook(fn EventHookFunc) {
 	g.hooks.add(fn)
 }
 
+// PublishKeyEvent publishes an API key lifecycle event, one of the
+// SubjectKey* subjects, to the registered hooks and observability exporters.
+// The hook payload carries key_id, key_name, expires_at (null for a zero
+// expiresAt, a key that never expires), and timestamp, and the exporter event
+// the same as ferro.key.* attributes; never the key's secret.
+func (g *Gateway) PublishKeyEvent(ctx context.Context, subject, keyID, keyName string, expiresAt time.Time) {
+	g.publishKeyEvent(ctx, events.KeyEvent(subject, keyID, keyName, expiresAt))
+}
+
+// publishQuotaExceeded publishes SubjectKeyQuotaExceeded for keyID, naming the
+// key from the request's identity and carrying reason in the payload.
+func (g *Gateway) publishQuotaExceeded(ctx context.Context, keyID, reason string) {
+	var name string
+	if id, ok := authctx.Identity(ctx); ok {
+		name = id.Name
+	}
+	he := events.KeyEvent(SubjectKeyQuotaExceeded, keyID, name, time.Time{})
+	he.Reason = reason
+	g.publishKeyEvent(ctx, he)
+}
+
+// quotaNotifier is the plugin.QuotaNotifier handed to plugins that enforce a
+// per-key quota.
+type quotaNotifier struct{ g *Gateway }
+
+func (n quotaNotifier) QuotaExceeded(ctx context.Context, keyID, reason string) {
+	n.g.publishQuotaExceeded(ctx, keyID, reason)
+}
+
+func (g *Gateway) publishKeyEvent(ctx context.Context, he events.HookEvent) {
+	if g.hasHooks() {
+		g.publishEvent(ctx, he)
+	}
+	g.mu.RLock()
+	obs, obsEventsActive := g.obs, g.obsEventsActive
+	g.mu.RUnlock()
+	if obsEventsActive {
+		attrs := map[string]any{
+			"ferro.key.id":   he.KeyID,
+			"ferro.key.name": he.KeyName,
+		}
+		if !he.ExpiresAt.IsZero() {
+			attrs["ferro.key.expires_at"] = he.ExpiresAt
+		}
+		if he.Reason != "" {
+			attrs["ferro.key.reason"] = he.Reason
+		}
+		obs.RecordEvent(ctx, observability.Event{
+			Subject:    he.Subject,
+			Timestamp:  he.Timestamp,
+			Attributes: attrs,
+		})
+	}
+}
+
 func (g *Gateway) hasHooks() bool {
 	return g.hooks.hasHooks()
 }
@@ -230,6 +287,7 @@ func obsEventFromHook(e events.HookEvent) observability.Event {
 			ReasoningUSD:  e.Cost.ReasoningUSD,
 			ModelFound:    e.Cost.ModelFound,
 		},
-		Timestamp: e.Timestamp,
+		Timestamp:   e.Timestamp,
+		CacheStatus: e.CacheStatus,
 	}
 }
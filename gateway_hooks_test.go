@@ -109,6 +109,36 @@ func TestGateway_PublishEvent_CallsAllHooks(t *testing.T) {
 	}
 }
 
+func TestGateway_PublishKeyEvent(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "unused"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type call struct {
+		subject string
+		data    map[string]any
+	}
+	called := make(chan call, 1)
+	gw.AddHook(func(_ context.Context, subject string, data map[string]any) {
+		called <- call{subject, data}
+	})
+	expires := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	gw.PublishKeyEvent(context.Background(), SubjectKeyExpiring, "key-1", "ci", expires)
+
+	select {
+	case c := <-called:
+		if c.subject != SubjectKeyExpiring || c.data["key_id"] != "key-1" || c.data["expires_at"] != expires {
+			t.Fatalf("hook got %s %v", c.subject, c.data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the key event")
+	}
+}
+
 func TestGateway_PublishEvent_EnqueuesEachHookIndividually(t *testing.T) {
 	gw := &Gateway{
 		hooks: newHookBus(2),
@@ -0,0 +1,372 @@
+package aigateway
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Image URL fetching: a target with fetch_image_urls set has the image URLs
+// of its chat requests downloaded by the gateway and forwarded inline, for
+// providers that accept only base64 images. imageFetchProvider sits below the
+// strategy, like paramsProvider, so only the targets that need it pay for the
+// download, and a fallback to a target that fetches URLs itself forwards the
+// request untouched.
+//
+// Requests choose the URLs, so fetching guards against server-side request
+// forgery: only http(s) URLs on allowed hosts are fetched, every connection —
+// including each redirect's — is refused unless its resolved address is
+// public, and proxies from the environment are not used, as they would resolve
+// the host out of the gateway's sight. A failed fetch tells the client only
+// that the image could not be fetched, so probing with URLs learns nothing of
+// the network behind the gateway; the log has the reason and the address.
+
+// maxImageFetchRedirects bounds the redirects one fetch follows.
+const maxImageFetchRedirects = 3
+
+// imageFetchFailed is the reason a client is given for a failed fetch.
+const imageFetchFailed = "the image could not be fetched"
+
+// nonPublicPrefixes are address ranges netip's predicates do not cover that
+// must not be reachable through a fetch either.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which can reach private IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, which can embed private IPv4
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// publicAddress reports whether addr may be connected to by a fetch.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// refuseNonPublic is a net.Dialer Control function refusing connections to
+// non-public addresses. It runs after name resolution, on the address about
+// to be dialed, so a host name that resolves, or re-resolves, to an internal
+// address is caught too.
+func refuseNonPublic(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected dial address %q", address)
+	}
+	if !publicAddress(ap.Addr()) {
+		return fmt.Errorf("address %s is not public", ap.Addr())
+	}
+	return nil
+}
+
+// imageFetcher downloads image URLs within an ImageFetchConfig.
+type imageFetcher struct {
+	client    *http.Client
+	allowed   []string
+	allowHTTP bool
+	maxBytes  int
+	maxImages int
+	// limits are the images section the fetcher was built from; its byte
+	// and dimension limits apply to fetched images as to inline ones.
+	limits *ImageLimitsConfig
+}
+
+// newImageFetcher builds the fetcher for the images section of a config, nil
+// or not. control vets each address dialed; it is refuseNonPublic outside
+// tests.
+func newImageFetcher(images *ImageLimitsConfig, control func(network, address string, c syscall.RawConn) error) *imageFetcher {
+	var cfg *ImageFetchConfig
+	if images != nil {
+		cfg = images.Fetch
+	}
+	f := &imageFetcher{maxBytes: DefaultImageFetchMaxBytes, maxImages: DefaultImageFetchMaxImages, limits: images}
+	if cfg != nil {
+		f.allowed = cfg.AllowedHosts
+		f.allowHTTP = cfg.AllowHTTP
+		if cfg.MaxBytes > 0 {
+			f.maxBytes = cfg.MaxBytes
+		}
+		if cfg.MaxImages > 0 {
+			f.maxImages = cfg.MaxImages
+		}
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	f.client = &http.Client{
+		Timeout: cfg.TimeoutDuration(),
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: cfg.TimeoutDuration(),
+			MaxIdleConnsPerHost:   2,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxImageFetchRedirects {
+				return fmt.Errorf("more than %d redirects", maxImageFetchRedirects)
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+	return f
+}
+
+// checkURL reports why u may not be fetched, or nil.
+func (f *imageFetcher) checkURL(u *url.URL) error {
+	switch {
+	case u.Scheme == "http" && !f.allowHTTP:
+		return errors.New("http URLs are not allowed; use https")
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Errorf("%s URLs cannot be fetched", u.Scheme)
+	case u.User != nil:
+		return errors.New("URLs with credentials are not allowed")
+	}
+	if len(f.allowed) == 0 {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, a := range f.allowed {
+		a = strings.ToLower(a)
+		if host == a || strings.HasPrefix(a, "*.") && strings.HasSuffix(host, a[1:]) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s is not in images.fetch.allowed_hosts", host)
+}
+
+// fetch downloads u, which has passed checkURL, and returns it as a base64
+// data URI, with the address last dialed for it, for the log.
+func (f *imageFetcher) fetch(ctx context.Context, u *url.URL) (string, string, error) {
+	var (
+		mu   sync.Mutex
+		addr string
+	)
+	record := func(a string) {
+		mu.Lock()
+		addr = a
+		mu.Unlock()
+	}
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectDone: func(_, a string, _ error) { record(a) },
+		GotConn:     func(info httptrace.GotConnInfo) { record(info.Conn.RemoteAddr().String()) },
+	})
+	uri, err := f.download(ctx, u)
+	mu.Lock()
+	defer mu.Unlock()
+	return uri, addr, err
+}
+
+// download does fetch's work.
+func (f *imageFetcher) download(ctx context.Context, u *url.URL) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "image/*")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch failed: %w", unwrapURLError(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch returned HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > int64(f.maxBytes) {
+		return "", fmt.Errorf("%d bytes is over the %d byte fetch limit", resp.ContentLength, f.maxBytes)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(f.maxBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("fetch failed: %w", err)
+	}
+	if len(body) > f.maxBytes {
+		return "", fmt.Errorf("image is over the %d byte fetch limit", f.maxBytes)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("content type %s is not an image", mediaType)
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(body), nil
+}
+
+// unwrapURLError drops the *url.Error wrapping, which repeats the full URL.
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// fetchedImagesKey carries a request's *fetchedImages.
+type fetchedImagesKey struct{}
+
+// fetchedImages holds the images fetched for one client request, by URL, so
+// its retries and fallbacks reuse them, and counts its fetches against
+// images.fetch.max_images. Attempts may run at once, hence the mutex.
+type fetchedImages struct {
+	mu      sync.Mutex
+	fetches int
+	uris    map[string]string
+}
+
+// withFetchedImages attaches an empty fetchedImages to ctx when req has image
+// parts a target might fetch. Like withRetryBudget, it leaves ctx untouched
+// otherwise.
+func withFetchedImages(ctx context.Context, req providers.Request) context.Context {
+	for _, m := range req.Messages {
+		for _, part := range m.ContentParts {
+			if part.Type == "image_url" && part.ImageURL != nil {
+				return context.WithValue(ctx, fetchedImagesKey{}, &fetchedImages{})
+			}
+		}
+	}
+	return ctx
+}
+
+// fetchOnce returns the data URI of u, fetching it for target name unless
+// the request already has it. shown is u without its query string, for the
+// log.
+func (f *imageFetcher) fetchOnce(ctx context.Context, fetched *fetchedImages, name string, u *url.URL, shown string) (string, error) {
+	key := u.String()
+	fetched.mu.Lock()
+	if uri, ok := fetched.uris[key]; ok {
+		fetched.mu.Unlock()
+		return uri, nil
+	}
+	if fetched.fetches >= f.maxImages {
+		fetched.mu.Unlock()
+		return "", fmt.Errorf("a request may have at most %d image URLs fetched", f.maxImages)
+	}
+	fetched.fetches++
+	fetched.mu.Unlock()
+
+	uri, addr, err := f.fetch(ctx, u)
+	if err != nil {
+		logging.FromContext(ctx).Warn("image URL fetch failed",
+			"provider", name, "url", shown, "address", addr, "error", err)
+		return "", errors.New(imageFetchFailed)
+	}
+	logging.FromContext(ctx).Debug("image URL fetched for provider",
+		"provider", name, "url", shown, "address", addr)
+	fetched.mu.Lock()
+	defer fetched.mu.Unlock()
+	if fetched.uris == nil {
+		fetched.uris = make(map[string]string)
+	}
+	fetched.uris[key] = uri
+	return uri, nil
+}
+
+// inline returns req with the image URLs of its messages replaced by the
+// images' data URIs, fetched for target name. Messages it changes are
+// copied, never modified in place.
+func (f *imageFetcher) inline(ctx context.Context, name string, req providers.Request) (providers.Request, error) {
+	fetched, _ := ctx.Value(fetchedImagesKey{}).(*fetchedImages)
+	if fetched == nil {
+		fetched = &fetchedImages{}
+	}
+	copied := false
+	for i, m := range req.Messages {
+		partsCopied := false
+		for j, part := range m.ContentParts {
+			if part.Type != "image_url" || part.ImageURL == nil {
+				continue
+			}
+			u, err := url.Parse(part.ImageURL.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+			shown := *u
+			shown.RawQuery, shown.Fragment, shown.User = "", "", nil
+			var uri string
+			err = f.checkURL(u)
+			if err == nil {
+				uri, err = f.fetchOnce(ctx, fetched, name, u, shown.String())
+			}
+			if err == nil && f.limits != nil {
+				uri, _, err = limitImage(ctx, f.limits, uri)
+			}
+			if err != nil {
+				return req, &providers.ImageFetchError{Provider: name, URL: shown.String(), Reason: err.Error()}
+			}
+			if !copied {
+				req.Messages = append([]providers.Message(nil), req.Messages...)
+				copied = true
+			}
+			if !partsCopied {
+				req.Messages[i].ContentParts = append([]providers.ContentPart(nil), m.ContentParts...)
+				partsCopied = true
+			}
+			img := *part.ImageURL
+			img.URL = uri
+			req.Messages[i].ContentParts[j].ImageURL = &img
+		}
+	}
+	return req, nil
+}
+
+// imageFetchProvider inlines the image URLs of every attempt sent to one
+// target.
+type imageFetchProvider struct {
+	providers.Provider
+	name    string
+	fetcher *imageFetcher
+}
+
+// fetchImageURLs wraps p when its target fetches image URLs; fetcher is nil
+// when it does not.
+func fetchImageURLs(name string, p providers.Provider, fetcher *imageFetcher) providers.Provider {
+	if fetcher == nil {
+		return p
+	}
+	return &imageFetchProvider{Provider: p, name: name, fetcher: fetcher}
+}
+
+func (p *imageFetchProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	req, err := p.fetcher.inline(ctx, p.name, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *imageFetchProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	req, err := p.fetcher.inline(ctx, p.name, req)
+	if err != nil {
+		return nil, err
+	}
+	return sp.CompleteStream(ctx, req)
+}
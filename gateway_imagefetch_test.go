@@ -0,0 +1,187 @@
+package aigateway
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestPublicAddress(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.215.14":         true,
+		"2606:2800:21f:cb07::1": true,
+		"127.0.0.1":             false,
+		"10.1.2.3":              false,
+		"172.16.0.1":            false,
+		"192.168.1.1":           false,
+		"169.254.169.254":       false, // cloud metadata
+		"100.64.0.1":            false,
+		"0.0.0.0":               false,
+		"::1":                   false,
+		"fd00::1":               false,
+		"fe80::1":               false,
+		"::ffff:10.0.0.1":       false,
+		"64:ff9b::a00:1":        false,
+	} {
+		if got := publicAddress(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("publicAddress(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestImageFetcher_CheckURL(t *testing.T) {
+	f := newImageFetcher(&ImageLimitsConfig{Fetch: &ImageFetchConfig{AllowedHosts: []string{"cdn.example.com", "*.images.example.org"}}}, refuseNonPublic)
+	for raw, ok := range map[string]bool{
+		"https://cdn.example.com/cat.png":         true,
+		"https://a.images.example.org/cat.png":    true,
+		"https://images.example.org/cat.png":      false,
+		"https://evil.com/cat.png":                false,
+		"http://cdn.example.com/cat.png":          false,
+		"ftp://cdn.example.com/cat.png":           false,
+		"https://user:pw@cdn.example.com/cat.png": false,
+	} {
+		u, _ := url.Parse(raw)
+		if err := f.checkURL(u); (err == nil) != ok {
+			t.Errorf("checkURL(%s) = %v, want allowed %v", raw, err, ok)
+		}
+	}
+}
+
+func newImageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	png := pngDataURI(t, 40, 20, false, false)
+	_, payload, _ := strings.Cut(png, ",")
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(raw)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/redirect":
+			http.Redirect(w, r, "/cat.png", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestImageFetcher_Inline(t *testing.T) {
+	srv := newImageServer(t)
+	// The test server listens on loopback, which refuseNonPublic refuses.
+	f := newImageFetcher(&ImageLimitsConfig{MaxDimension: 20, Downscale: true, Fetch: &ImageFetchConfig{AllowHTTP: true}}, nil)
+
+	req := imageRequest(srv.URL + "/redirect?token=secret")
+	caller := req.Messages
+	got, err := f.inline(context.Background(), "ollama", req)
+	if err != nil {
+		t.Fatalf("inline: %v", err)
+	}
+	mime, cfg := decodeDataURI(t, got.Messages[0].ContentParts[1].ImageURL.URL)
+	if mime != "image/jpeg" || cfg.Width != 20 || cfg.Height != 10 {
+		t.Errorf("inlined %s %dx%d, want the image downscaled to the images limits", mime, cfg.Width, cfg.Height)
+	}
+	if !strings.HasPrefix(caller[0].ContentParts[1].ImageURL.URL, srv.URL) {
+		t.Error("the caller's message was modified")
+	}
+
+	// Why a fetch failed is logged, not told to the client.
+	for _, path := range []string{"/page.html", "/missing"} {
+		_, err := f.inline(context.Background(), "ollama", imageRequest(srv.URL+path+"?token=secret"))
+		var fetchErr *providers.ImageFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Reason != imageFetchFailed {
+			t.Errorf("%s: err = %v, want an ImageFetchError with the generic reason", path, err)
+			continue
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("%s: err = %q leaks the query string", path, err)
+		}
+	}
+
+	small := newImageFetcher(&ImageLimitsConfig{Fetch: &ImageFetchConfig{AllowHTTP: true, MaxBytes: 10}}, nil)
+	if _, err := small.inline(context.Background(), "ollama", imageRequest(srv.URL+"/cat.png")); err == nil || !strings.Contains(err.Error(), imageFetchFailed) {
+		t.Errorf("err = %v, want the image over the fetch limit refused", err)
+	}
+}
+
+// A request's retries and fallbacks reuse its fetched images, and it may
+// have only images.fetch.max_images URLs fetched.
+func TestImageFetcher_FetchesOncePerRequest(t *testing.T) {
+	var hits atomic.Int32
+	img := newImageServer(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		img.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	f := newImageFetcher(&ImageLimitsConfig{Fetch: &ImageFetchConfig{AllowHTTP: true, MaxImages: 1}}, nil)
+
+	req := imageRequest(srv.URL + "/cat.png")
+	ctx := withFetchedImages(context.Background(), req)
+	for range 3 {
+		if _, err := f.inline(ctx, "ollama", req); err != nil {
+			t.Fatalf("inline: %v", err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("image fetched %d times, want once for the request", n)
+	}
+
+	_, err := f.inline(ctx, "ollama", imageRequest(srv.URL+"/cat.png", srv.URL+"/redirect"))
+	var fetchErr *providers.ImageFetchError
+	if !errors.As(err, &fetchErr) || !strings.Contains(fetchErr.Reason, "at most 1") {
+		t.Errorf("err = %v, want the max_images cap", err)
+	}
+}
+
+func TestRoute_FetchImageURLsRefusesInternalAddresses(t *testing.T) {
+	srv := newImageServer(t)
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName, FetchImageURLs: true}},
+		Images:   &ImageLimitsConfig{Fetch: &ImageFetchConfig{AllowHTTP: true}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	called := false
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		completeFn: func(context.Context, providers.Request) (*providers.Response, error) {
+			called = true
+			return &providers.Response{ID: "ok"}, nil
+		},
+	})
+
+	_, err = gw.Route(context.Background(), imageRequest(srv.URL+"/cat.png"))
+	var fetchErr *providers.ImageFetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Reason != imageFetchFailed {
+		t.Fatalf("err = %v, want the loopback address refused", err)
+	}
+	if strings.Contains(err.Error(), "not public") {
+		t.Errorf("err = %q tells the client why the address was refused", err)
+	}
+	if providers.ParseStatusCode(err) != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", providers.ParseStatusCode(err))
+	}
+	if called {
+		t.Error("the provider was called with an unfetched image")
+	}
+}
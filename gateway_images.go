@@ -0,0 +1,219 @@
+package aigateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers GIF with image.Decode
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Image limits: an inline image — a base64 data URI in an image_url content
+// part — over Config.Images' byte or dimension limit is downscaled and
+// re-encoded until it fits, when the config allows it, or else fails the
+// request with ErrImageTooLarge before any plugin or provider sees it.
+// Measuring an image only reads its header; it is decoded in full only to be
+// resized, when the header shows it under maxDecodePixels, and at most
+// maxConcurrentDecodes at a time.
+
+const (
+	// maxDecodePixels bounds the images the gateway decodes to resize: a
+	// small, highly compressed file can declare dimensions whose pixels
+	// alone would take gigabytes. A decode holds the decoded image and its
+	// RGBA copy, about 8 bytes a pixel, so 24 MP is under 200 MB.
+	maxDecodePixels = 24_000_000
+	// maxConcurrentDecodes bounds the downscales running at once, across
+	// requests, so concurrent large images cannot multiply that memory.
+	maxConcurrentDecodes = 4
+	// maxDownscaleAttempts bounds the re-encodes spent shrinking an image
+	// toward max_bytes.
+	maxDownscaleAttempts = 5
+)
+
+// decodeSlots holds one token per downscale in progress.
+var decodeSlots = make(chan struct{}, maxConcurrentDecodes)
+
+// limitImages applies cfg to the inline images of req's messages. Messages it
+// changes are copied, never modified in place.
+func limitImages(ctx context.Context, cfg *ImageLimitsConfig, req *providers.Request) error {
+	if cfg == nil || (cfg.MaxBytes == 0 && cfg.MaxDimension == 0) {
+		return nil
+	}
+	copied := false
+	for i, m := range req.Messages {
+		partsCopied := false
+		n := 0
+		for j, part := range m.ContentParts {
+			if part.Type != "image_url" || part.ImageURL == nil {
+				continue
+			}
+			n++
+			url, changed, err := limitImage(ctx, cfg, part.ImageURL.URL)
+			if err != nil {
+				metrics.ImageLimitActionsTotal.WithLabelValues("rejected").Inc()
+				return fmt.Errorf("%w: message %d, image %d: %s", providers.ErrImageTooLarge, i+1, n, err)
+			}
+			if !changed {
+				continue
+			}
+			metrics.ImageLimitActionsTotal.WithLabelValues("downscaled").Inc()
+			if !copied {
+				req.Messages = append([]providers.Message(nil), req.Messages...)
+				copied = true
+			}
+			if !partsCopied {
+				req.Messages[i].ContentParts = append([]providers.ContentPart(nil), m.ContentParts...)
+				partsCopied = true
+			}
+			u := *part.ImageURL
+			u.URL = url
+			req.Messages[i].ContentParts[j].ImageURL = &u
+		}
+	}
+	return nil
+}
+
+// limitImage checks one image URL against cfg, returning its downscaled
+// replacement when it had to change. URLs that are not base64 data URIs are
+// returned unchanged. An error describes why the image is over its limits.
+func limitImage(ctx context.Context, cfg *ImageLimitsConfig, url string) (string, bool, error) {
+	payload, ok := dataURIPayload(url)
+	if !ok {
+		return url, false, nil
+	}
+	size := base64.StdEncoding.DecodedLen(len(payload)) - strings.Count(payload[max(0, len(payload)-2):], "=")
+	header, format, headerErr := image.DecodeConfig(base64.NewDecoder(base64.StdEncoding, strings.NewReader(payload)))
+	measured := headerErr == nil
+
+	var over []string
+	if cfg.MaxBytes > 0 && size > cfg.MaxBytes {
+		over = append(over, fmt.Sprintf("%d bytes is over the %d byte limit", size, cfg.MaxBytes))
+	}
+	if cfg.MaxDimension > 0 && measured && max(header.Width, header.Height) > cfg.MaxDimension {
+		over = append(over, fmt.Sprintf("%dx%d pixels is over the %d pixel limit", header.Width, header.Height, cfg.MaxDimension))
+	}
+	if len(over) == 0 {
+		return url, false, nil
+	}
+	reason := strings.Join(over, "; ")
+	switch {
+	case !cfg.Downscale:
+		return "", false, errors.New(reason)
+	case !measured:
+		return "", false, fmt.Errorf("%s, and only JPEG, PNG, and GIF images can be downscaled", reason)
+	case header.Width*header.Height > maxDecodePixels:
+		return "", false, fmt.Errorf("%s, and at %dx%d pixels it is too large to downscale", reason, header.Width, header.Height)
+	}
+
+	select {
+	case decodeSlots <- struct{}{}:
+		defer func() { <-decodeSlots }()
+	case <-ctx.Done():
+		return "", false, fmt.Errorf("%s, and the request ended waiting to downscale it: %w", reason, ctx.Err())
+	}
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false, fmt.Errorf("%s, and its base64 data is invalid", reason)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return "", false, fmt.Errorf("%s, and it cannot be decoded: %v", reason, err)
+	}
+	b := decoded.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Rect, decoded, b.Min, draw.Src)
+
+	quality := cfg.JPEGQuality
+	if quality == 0 {
+		quality = DefaultImageJPEGQuality
+	}
+	scale := 1.0
+	if long := max(b.Dx(), b.Dy()); cfg.MaxDimension > 0 && long > cfg.MaxDimension {
+		scale = float64(cfg.MaxDimension) / float64(long)
+	}
+	for range maxDownscaleAttempts {
+		dst := src
+		if scale < 1 {
+			dst = resizeBox(src, max(1, int(float64(b.Dx())*scale)), max(1, int(float64(b.Dy())*scale)))
+		}
+		out, mime, err := encodeImage(dst, quality)
+		if err != nil {
+			return "", false, fmt.Errorf("%s, and re-encoding it failed: %v", reason, err)
+		}
+		if cfg.MaxBytes == 0 || len(out) <= cfg.MaxBytes {
+			logging.FromContext(ctx).Debug("inline image downscaled",
+				"format", format, "from_bytes", size, "to_bytes", len(out),
+				"from", fmt.Sprintf("%dx%d", b.Dx(), b.Dy()), "to", fmt.Sprintf("%dx%d", dst.Rect.Dx(), dst.Rect.Dy()))
+			return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(out), true, nil
+		}
+		// Bytes shrink roughly with the pixel count, so scale each side by
+		// the square root of the overshoot, with a margin.
+		scale *= math.Sqrt(float64(cfg.MaxBytes)/float64(len(out))) * 0.9
+	}
+	return "", false, fmt.Errorf("%s, and downscaling could not bring it under the limit", reason)
+}
+
+// dataURIPayload returns the base64 data of a data URI such as
+// "data:image/png;base64,iVBOR...".
+func dataURIPayload(url string) (string, bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", false
+	}
+	meta, payload, ok := strings.Cut(rest, ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return "", false
+	}
+	return payload, true
+}
+
+// encodeImage encodes img as JPEG, or as PNG when it has transparency JPEG
+// cannot carry, returning the bytes and their MIME type.
+func encodeImage(img *image.RGBA, quality int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if img.Opaque() {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", err
+}
+
+// resizeBox shrinks src to w by h, averaging the source pixels each
+// destination pixel covers. It only scales down.
+func resizeBox(src *image.RGBA, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	for y := range h {
+		y0 := y * sh / h
+		y1 := max((y+1)*sh/h, y0+1)
+		for x := range w {
+			x0 := x * sw / w
+			x1 := max((x+1)*sw/w, x0+1)
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[src.PixOffset(x0, sy):src.PixOffset(x1, sy)]
+				for k, v := range row {
+					sum[k%4] += uint64(v)
+				}
+			}
+			n := uint64((x1 - x0) * (y1 - y0))
+			px := dst.Pix[dst.PixOffset(x, y):]
+			for k := range sum {
+				px[k] = uint8(sum[k] / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,203 @@
+package aigateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// pngDataURI returns a w by h PNG as a data URI. Noisy images compress
+// poorly; transparent ones have an alpha channel.
+func pngDataURI(t *testing.T, w, h int, noisy, transparent bool) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for y := range h {
+		for x := range w {
+			c := color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255}
+			if noisy {
+				c.R, c.G, c.B = uint8(rng.IntN(256)), uint8(rng.IntN(256)), uint8(rng.IntN(256))
+			}
+			if transparent {
+				c.A = 100
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func imageRequest(urls ...string) providers.Request {
+	parts := []providers.ContentPart{{Type: "text", Text: "describe"}}
+	for _, u := range urls {
+		parts = append(parts, providers.ContentPart{Type: "image_url", ImageURL: &providers.ImageURLPart{URL: u, Detail: "high"}})
+	}
+	return providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", ContentParts: parts}}}
+}
+
+// decodeDataURI returns the MIME type and header of a data URI image.
+func decodeDataURI(t *testing.T, uri string) (string, image.Config) {
+	t.Helper()
+	meta, payload, _ := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("decode image: %v", err)
+	}
+	return strings.TrimSuffix(meta, ";base64"), cfg
+}
+
+func TestLimitImages_RejectsWithoutDownscale(t *testing.T) {
+	req := imageRequest("https://example.com/cat.png", pngDataURI(t, 200, 50, false, false))
+	err := limitImages(context.Background(), &ImageLimitsConfig{MaxDimension: 100}, &req)
+	if !errors.Is(err, providers.ErrImageTooLarge) {
+		t.Fatalf("err = %v, want ErrImageTooLarge", err)
+	}
+	if !strings.Contains(err.Error(), "message 1, image 2: 200x50 pixels is over the 100 pixel limit") {
+		t.Errorf("err = %q, want it to name the image and its size", err)
+	}
+}
+
+func TestLimitImages_DownscalesToMaxDimension(t *testing.T) {
+	orig := pngDataURI(t, 200, 50, false, false)
+	req := imageRequest(orig)
+	if err := limitImages(context.Background(), &ImageLimitsConfig{MaxDimension: 100, Downscale: true}, &req); err != nil {
+		t.Fatalf("limitImages: %v", err)
+	}
+	got := req.Messages[0].ContentParts[1].ImageURL
+	mime, cfg := decodeDataURI(t, got.URL)
+	if mime != "image/jpeg" || cfg.Width != 100 || cfg.Height != 25 {
+		t.Errorf("downscaled to %s %dx%d, want image/jpeg 100x25", mime, cfg.Width, cfg.Height)
+	}
+	if got.Detail != "high" {
+		t.Errorf("detail = %q, want it kept", got.Detail)
+	}
+
+	small := imageRequest(pngDataURI(t, 80, 80, false, false))
+	before := small.Messages[0].ContentParts[1].ImageURL.URL
+	if err := limitImages(context.Background(), &ImageLimitsConfig{MaxDimension: 100, Downscale: true}, &small); err != nil {
+		t.Fatalf("limitImages: %v", err)
+	}
+	if small.Messages[0].ContentParts[1].ImageURL.URL != before {
+		t.Error("an image within the limits was re-encoded")
+	}
+}
+
+func TestLimitImages_KeepsTransparencyAsPNG(t *testing.T) {
+	req := imageRequest(pngDataURI(t, 200, 200, false, true))
+	if err := limitImages(context.Background(), &ImageLimitsConfig{MaxDimension: 50, Downscale: true}, &req); err != nil {
+		t.Fatalf("limitImages: %v", err)
+	}
+	if mime, _ := decodeDataURI(t, req.Messages[0].ContentParts[1].ImageURL.URL); mime != "image/png" {
+		t.Errorf("mime = %s, want image/png for a transparent image", mime)
+	}
+}
+
+func TestLimitImages_ShrinksToMaxBytes(t *testing.T) {
+	orig := pngDataURI(t, 400, 400, true, false)
+	req := imageRequest(orig)
+	caller := req.Messages
+	cfg := &ImageLimitsConfig{MaxBytes: 20_000, Downscale: true}
+	if err := limitImages(context.Background(), cfg, &req); err != nil {
+		t.Fatalf("limitImages: %v", err)
+	}
+	payload, _ := dataURIPayload(req.Messages[0].ContentParts[1].ImageURL.URL)
+	if n := base64.StdEncoding.DecodedLen(len(payload)); n > 20_000+2 {
+		t.Errorf("downscaled image is %d bytes, want at most 20000", n)
+	}
+	if caller[0].ContentParts[1].ImageURL.URL != orig {
+		t.Error("the caller's message was modified")
+	}
+}
+
+func TestLimitImages_RejectsUndecodableOverLimit(t *testing.T) {
+	uri := "data:image/webp;base64," + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 2048))
+	req := imageRequest(uri)
+	err := limitImages(context.Background(), &ImageLimitsConfig{MaxBytes: 1024, Downscale: true}, &req)
+	if !errors.Is(err, providers.ErrImageTooLarge) || !strings.Contains(err.Error(), "2048 bytes") {
+		t.Fatalf("err = %v, want ErrImageTooLarge naming the size", err)
+	}
+}
+
+func TestLimitImages_RefusesHugeDecodes(t *testing.T) {
+	// A GIF header declaring 6000x5000 pixels: a few bytes that would
+	// decode to gigabytes.
+	header := []byte("GIF89a\x70\x17\x88\x13\x00\x00\x00")
+	req := imageRequest("data:image/gif;base64," + base64.StdEncoding.EncodeToString(header))
+	err := limitImages(context.Background(), &ImageLimitsConfig{MaxDimension: 1024, Downscale: true}, &req)
+	if !errors.Is(err, providers.ErrImageTooLarge) || !strings.Contains(err.Error(), "too large to downscale") {
+		t.Fatalf("err = %v, want ErrImageTooLarge refusing to decode", err)
+	}
+}
+
+func TestLimitImages_BoundsConcurrentDecodes(t *testing.T) {
+	for range maxConcurrentDecodes {
+		decodeSlots <- struct{}{}
+	}
+	defer func() {
+		for range maxConcurrentDecodes {
+			<-decodeSlots
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := imageRequest(pngDataURI(t, 200, 200, false, false))
+	err := limitImages(ctx, &ImageLimitsConfig{MaxDimension: 100, Downscale: true}, &req)
+	if !errors.Is(err, providers.ErrImageTooLarge) || !strings.Contains(err.Error(), "waiting to downscale") {
+		t.Fatalf("err = %v, want the request to give up waiting for a decode slot", err)
+	}
+}
+
+func TestRoute_ImageLimits(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+		Images:   &ImageLimitsConfig{MaxDimension: 64, MaxBytes: 1 << 20, Downscale: true},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var sent *providers.Request
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			sent = &req
+			return &providers.Response{ID: "ok", Model: req.Model, Choices: []providers.Choice{{
+				Message: providers.Message{Role: "assistant", Content: "a gradient"},
+			}}}, nil
+		},
+	})
+
+	if _, err := gw.Route(context.Background(), imageRequest(pngDataURI(t, 256, 128, false, false))); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if _, cfg := decodeDataURI(t, sent.Messages[0].ContentParts[1].ImageURL.URL); cfg.Width != 64 || cfg.Height != 32 {
+		t.Errorf("provider got %dx%d, want 64x32", cfg.Width, cfg.Height)
+	}
+
+	sent = nil
+	huge := "data:image/webp;base64," + base64.StdEncoding.EncodeToString(make([]byte, 2<<20))
+	if _, err := gw.Route(context.Background(), imageRequest(huge)); !errors.Is(err, providers.ErrImageTooLarge) {
+		t.Fatalf("err = %v, want ErrImageTooLarge", err)
+	}
+	if sent != nil {
+		t.Error("a rejected request reached the provider")
+	}
+}
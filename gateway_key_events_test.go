@@ -0,0 +1,103 @@
+package aigateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/plugins/budget"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// quotaEvents registers a hook on gw that forwards its quota events.
+func quotaEvents(gw *Gateway) <-chan map[string]any {
+	ch := make(chan map[string]any, 4)
+	gw.AddHook(func(_ context.Context, subject string, data map[string]any) {
+		if subject == SubjectKeyQuotaExceeded {
+			ch <- data
+		}
+	})
+	return ch
+}
+
+func awaitQuotaEvent(t *testing.T, ch <-chan map[string]any) map[string]any {
+	t.Helper()
+	select {
+	case data := <-ch:
+		return data
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the quota event")
+		return nil
+	}
+}
+
+func TestRoute_TierMonthlyTokensPublishQuotaExceeded(t *testing.T) {
+	gw := newTieredGateway(t, RateLimitTier{Name: "free", MonthlyTokenLimit: 50})
+	events := quotaEvents(gw)
+	ctx := authctx.WithIdentity(tierContext("key-a", "free"), authctx.KeyIdentity{Name: "ci"})
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	for range 2 {
+		if _, err := gw.Route(ctx, req); err != nil {
+			t.Fatalf("request under the cap: %v", err)
+		}
+	}
+	for range 2 {
+		if _, err := gw.Route(ctx, req); err == nil {
+			t.Fatal("request over the cap should be refused")
+		}
+	}
+
+	data := awaitQuotaEvent(t, events)
+	if data["key_id"] != "key-a" || data["key_name"] != "ci" || !strings.Contains(data["reason"].(string), "monthly token limit") {
+		t.Fatalf("quota event = %v", data)
+	}
+	select {
+	case data := <-events:
+		t.Fatalf("the second refusal should not be published: %v", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRoute_BudgetPluginPublishesQuotaExceeded(t *testing.T) {
+	budgetCfg := map[string]any{
+		"store_id":           "gateway-quota-event",
+		"spend_limit_usd":    1.0,
+		"input_per_m_tokens": 1_000_000.0, // $1 a prompt token
+	}
+	t.Cleanup(func() { budget.ResetStore("gateway-quota-event") })
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+		Plugins: []PluginConfig{
+			{Name: "budget", Type: "ratelimit", Stage: "before_request", Enabled: true, Config: budgetCfg},
+			{Name: "budget", Type: "ratelimit", Stage: "after_request", Enabled: true, Config: budgetCfg},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := gw.LoadPlugins(); err != nil {
+		t.Fatalf("LoadPlugins: %v", err)
+	}
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		resp:   &providers.Response{ID: "ok", Usage: providers.Usage{PromptTokens: 1, TotalTokens: 1}},
+	})
+	events := quotaEvents(gw)
+	ctx := authctx.WithKeyID(context.Background(), "key-b")
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	if _, err := gw.Route(ctx, req); err != nil {
+		t.Fatalf("request within budget: %v", err)
+	}
+	if data := awaitQuotaEvent(t, events); data["key_id"] != "key-b" || !strings.Contains(data["reason"].(string), "budget exceeded") {
+		t.Fatalf("quota event = %v", data)
+	}
+	if _, err := gw.Route(ctx, req); err == nil {
+		t.Fatal("request over budget should be refused")
+	}
+}
@@ -0,0 +1,62 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// tryModels runs attempt for req and, while it fails, again for each of
+// req.FallbackModels in turn, so a client-supplied model list
+// ("models": [...]) can fall back across providers the way a configured
+// fallback chain falls back across targets. Each candidate is routed as a
+// request of its own: its alias is resolved, fit shapes the request's turns
+// to it (the candidates' context windows differ, so each starts from the
+// turns as they arrived), and the strategy picks its targets afresh. On
+// return req is the request last attempted: its Model names the model that
+// served it, or the last one tried.
+//
+// Cancellation, an expired deadline, and a spent retry budget end the list
+// early; every other failure moves on to the next model, since a model the
+// first provider rejects (say, for context length) may still be served by
+// another.
+func (g *Gateway) tryModels(ctx context.Context, req *providers.Request, fit func(*providers.Request), attempt func(providers.Request) error) error {
+	fallbacks := req.FallbackModels
+	req.FallbackModels = nil
+	requested := req.Model
+	messages := req.Messages
+	fit(req)
+	err := attempt(*req)
+	for _, model := range fallbacks {
+		if err == nil || !modelFallbackAllowed(ctx, err) {
+			break
+		}
+		logging.FromContext(ctx).Warn("model failed, trying fallback model",
+			"model", req.Model, "fallback_model", model, "error", err)
+		req.Model = g.resolveModelAlias(model)
+		req.Messages = messages
+		fit(req)
+		err = attempt(*req)
+	}
+	if err == nil && req.Model != requested {
+		metrics.ModelFallbacksTotal.WithLabelValues(g.metricModel(requested), g.metricModel(req.Model)).Inc()
+		if logging.Enabled(ctx, slog.LevelInfo) {
+			logging.FromContext(ctx).Info("request served by fallback model",
+				"requested_model", requested, "model", req.Model)
+		}
+	}
+	return err
+}
+
+// modelFallbackAllowed reports whether a failed model may give way to the
+// next one in the request's list.
+func modelFallbackAllowed(ctx context.Context, err error) bool {
+	return ctx.Err() == nil &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, providers.ErrRetryBudgetExhausted)
+}
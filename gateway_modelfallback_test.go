@@ -0,0 +1,125 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// modelFallbackGateway returns a fallback gateway where "openai" serves
+// gpt-4o and always fails, and "anthropic" serves claude-3-5-sonnet. It
+// records the models each provider was sent.
+func modelFallbackGateway(t *testing.T) (*Gateway, map[string][]string) {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: "openai"}, {VirtualKey: "anthropic"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := map[string][]string{}
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: "openai", models: []string{"gpt-4o"},
+			completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+				sent["openai"] = append(sent["openai"], req.Model)
+				return nil, errors.New("provider API error (500): boom")
+			}},
+		streamErr: errors.New("provider API error (500): boom"),
+	})
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: "anthropic", models: []string{"claude-3-5-sonnet"},
+			completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+				sent["anthropic"] = append(sent["anthropic"], req.Model)
+				if len(req.FallbackModels) != 0 {
+					t.Errorf("fallback models forwarded upstream: %v", req.FallbackModels)
+				}
+				return &providers.Response{ID: "r1", Model: req.Model, Choices: []providers.Choice{{
+					Message: providers.Message{Role: "assistant", Content: "ok"}, FinishReason: "stop",
+				}}}, nil
+			}},
+		streamFn: func(_ context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+			sent["anthropic"] = append(sent["anthropic"], req.Model)
+			ch := make(chan providers.StreamChunk, 1)
+			ch <- providers.StreamChunk{ID: "s1", Model: req.Model, Choices: []providers.StreamChoice{{
+				Delta: providers.MessageDelta{Role: "assistant", Content: "ok"}, FinishReason: "stop",
+			}}}
+			close(ch)
+			return ch, nil
+		},
+	})
+	return gw, sent
+}
+
+func modelFallbackRequest(fallbacks ...string) providers.Request {
+	return providers.Request{
+		Model:          "gpt-4o",
+		FallbackModels: fallbacks,
+		Messages:       []providers.Message{{Role: "user", Content: "hi"}},
+	}
+}
+
+func TestRoute_FallsBackToNextModel(t *testing.T) {
+	gw, sent := modelFallbackGateway(t)
+
+	resp, err := gw.Route(context.Background(), modelFallbackRequest("claude-3-5-sonnet"))
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.Model != "claude-3-5-sonnet" || resp.Provider != "anthropic" {
+		t.Fatalf("served by %s/%s, want anthropic/claude-3-5-sonnet", resp.Provider, resp.Model)
+	}
+	if len(sent["openai"]) != 1 || len(sent["anthropic"]) != 1 {
+		t.Fatalf("attempts = %v, want one per model", sent)
+	}
+}
+
+func TestRoute_ModelListExhaustedReturnsLastError(t *testing.T) {
+	gw, sent := modelFallbackGateway(t)
+
+	if _, err := gw.Route(context.Background(), modelFallbackRequest("no-such-model")); err == nil {
+		t.Fatal("want an error when no model in the list is served")
+	}
+	if len(sent["anthropic"]) != 0 {
+		t.Fatalf("anthropic called with %v for models it does not serve", sent["anthropic"])
+	}
+}
+
+func TestRoute_CancelledRequestDoesNotFallBack(t *testing.T) {
+	gw, sent := modelFallbackGateway(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := gw.Route(ctx, modelFallbackRequest("claude-3-5-sonnet")); err == nil {
+		t.Fatal("want an error for a cancelled request")
+	}
+	if len(sent["anthropic"]) != 0 {
+		t.Fatal("a cancelled request moved on to the next model")
+	}
+}
+
+func TestRouteStream_FallsBackToNextModel(t *testing.T) {
+	gw, sent := modelFallbackGateway(t)
+	req := modelFallbackRequest("claude-3-5-sonnet")
+	req.Stream = true
+
+	ch, err := gw.RouteStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RouteStream: %v", err)
+	}
+	var models []string
+	for chunk := range ch {
+		if chunk.Error != nil {
+			t.Fatalf("stream error: %v", chunk.Error)
+		}
+		models = append(models, chunk.Model)
+	}
+	if len(models) != 1 || models[0] != "claude-3-5-sonnet" {
+		t.Fatalf("stream chunks from models %v, want claude-3-5-sonnet", models)
+	}
+	if got := sent["anthropic"]; len(got) != 1 {
+		t.Fatalf("anthropic stream attempts = %v, want one", got)
+	}
+}
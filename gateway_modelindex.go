@@ -4,12 +4,20 @@ import "github.com/ferro-labs/ai-gateway/providers"
 
 // modelLookupIndex holds the exact model→provider-name maps used for O(1)
 // routing lookups. Each map is keyed by model ID and rebuilt under g.mu whenever
-// the provider set or catalog changes (see rebuildModelIndexesLocked).
+// the provider set, catalog, or target list changes (see
+// rebuildModelIndexesLocked).
+//
+// order is the provider precedence every model lookup follows: providers named
+// by a configured target first, in target order, then the rest in registration
+// order. Each index lists a model's providers in that order, so when several
+// providers serve a model the one chosen is the same on every run.
 type modelLookupIndex struct {
-	exactProviders       map[string][]string
-	exactStreamProviders map[string][]string
-	exactEmbedProviders  map[string][]string
-	exactImageProviders  map[string][]string
+	order                    []string
+	exactProviders           map[string][]string
+	exactStreamProviders     map[string][]string
+	exactEmbedProviders      map[string][]string
+	exactImageProviders      map[string][]string
+	exactModerationProviders map[string][]string
 }
 
 // modelsForRoutingLocked returns the routable model IDs for a provider: its
@@ -46,19 +54,37 @@ func (g *Gateway) modelsForRoutingLocked(name string, p providers.Provider) []st
 	return out
 }
 
+// providerOrderLocked returns the registered provider names in lookup
+// precedence (see modelLookupIndex.order). Caller must hold g.mu.
+func (g *Gateway) providerOrderLocked() []string {
+	order := make([]string, 0, len(g.providerNames))
+	seen := make(map[string]bool, len(g.providerNames))
+	for _, t := range g.config.Targets {
+		if _, ok := g.providers[t.VirtualKey]; ok && !seen[t.VirtualKey] {
+			seen[t.VirtualKey] = true
+			order = append(order, t.VirtualKey)
+		}
+	}
+	for _, name := range g.providerNames {
+		if !seen[name] {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
 // rebuildModelIndexesLocked repopulates every exact model→provider index from
 // the current provider set. Caller must hold g.mu (write).
 func (g *Gateway) rebuildModelIndexesLocked() {
+	g.modelIndex.order = g.providerOrderLocked()
 	g.modelIndex.exactProviders = make(map[string][]string)
 	g.modelIndex.exactStreamProviders = make(map[string][]string)
 	g.modelIndex.exactEmbedProviders = make(map[string][]string)
 	g.modelIndex.exactImageProviders = make(map[string][]string)
+	g.modelIndex.exactModerationProviders = make(map[string][]string)
 
-	for _, name := range g.providerNames {
-		p, ok := g.providers[name]
-		if !ok {
-			continue
-		}
+	for _, name := range g.modelIndex.order {
+		p := g.providers[name]
 		models := g.modelsForRoutingLocked(name, p)
 		for _, model := range models {
 			g.modelIndex.exactProviders[model] = append(g.modelIndex.exactProviders[model], name)
@@ -66,6 +92,7 @@ func (g *Gateway) rebuildModelIndexesLocked() {
 		indexModelsIfImplements[providers.StreamProvider](p, name, models, g.modelIndex.exactStreamProviders)
 		indexModelsIfImplements[providers.EmbeddingProvider](p, name, models, g.modelIndex.exactEmbedProviders)
 		indexModelsIfImplements[providers.ImageProvider](p, name, models, g.modelIndex.exactImageProviders)
+		indexModelsIfImplements[providers.ModerationProvider](p, name, models, g.modelIndex.exactModerationProviders)
 	}
 }
 
@@ -82,18 +109,18 @@ func indexModelsIfImplements[T any](p providers.Provider, name string, models []
 }
 
 // findByModelLocked resolves model to a provider implementing capability T. It
-// consults the exact-match index first (returning the first registered provider
-// for that model), then falls back to a linear scan of providerNames for any
+// consults the exact-match index first (returning the first provider in lookup
+// order for that model), then falls back to a scan, in the same order, for any
 // provider that SupportsModel(model) and implements T. Caller must hold g.mu.
 func findByModelLocked[T any](g *Gateway, index map[string][]string, model string) (name string, impl T, ok bool) {
-	if exact := index[model]; len(exact) > 0 {
+	if exact := index[model]; len(exact) > 0 && g.inServiceLocked(exact[0]) {
 		if t, is := any(g.providers[exact[0]]).(T); is {
 			return exact[0], t, true
 		}
 	}
-	for _, n := range g.providerNames {
+	for _, n := range g.modelIndex.order {
 		p, exists := g.providers[n]
-		if !exists || !p.SupportsModel(model) {
+		if !exists || !p.SupportsModel(model) || !g.inServiceLocked(n) {
 			continue
 		}
 		if t, is := any(p).(T); is {
@@ -104,6 +131,17 @@ func findByModelLocked[T any](g *Gateway, index map[string][]string, model strin
 	return "", zero, false
 }
 
+// inServiceLocked reports whether the provider name is not a draining or
+// disabled target. Caller must hold g.mu.
+func (g *Gateway) inServiceLocked(name string) bool {
+	for _, t := range g.config.Targets {
+		if t.VirtualKey == name && g.targetState(t) != "" {
+			return false
+		}
+	}
+	return true
+}
+
 func (g *Gateway) findProviderByModelLocked(model string) (providers.Provider, bool) {
 	_, p, ok := findByModelLocked[providers.Provider](g, g.modelIndex.exactProviders, model)
 	return p, ok
@@ -130,3 +168,10 @@ func (g *Gateway) findEmbeddingProviderByModelLocked(model string) (string, prov
 func (g *Gateway) findImageProviderByModelLocked(model string) (string, providers.ImageProvider, bool) {
 	return findByModelLocked[providers.ImageProvider](g, g.modelIndex.exactImageProviders, model)
 }
+
+// findModerationProviderByModelLocked also returns the provider's registry
+// name, for the same reason as findEmbeddingProviderByModelLocked. Caller must
+// hold g.mu.
+func (g *Gateway) findModerationProviderByModelLocked(model string) (string, providers.ModerationProvider, bool) {
+	return findByModelLocked[providers.ModerationProvider](g, g.modelIndex.exactModerationProviders, model)
+}
@@ -0,0 +1,48 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFindByModel_FollowsTargetThenRegistrationOrder asserts that when several
+// providers serve a model, lookups prefer the one named first in the target
+// list, then the first registered, and follow the target list across reloads.
+func TestFindByModel_FollowsTargetThenRegistrationOrder(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: "unregistered"}, {VirtualKey: "c"}, {VirtualKey: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		gw.RegisterProvider(&mockStreamProvider{mockProvider: mockProvider{name: name, models: []string{"shared"}}})
+	}
+
+	assertFound := func(want string) {
+		t.Helper()
+		p, ok := gw.FindByModel("shared")
+		if !ok || p.Name() != want {
+			t.Fatalf("FindByModel = %v, want %s", p, want)
+		}
+		sp, ok := gw.FindStreamingByModel("shared")
+		if !ok || sp.Name() != want {
+			t.Fatalf("FindStreamingByModel = %v, want %s", sp, want)
+		}
+	}
+	assertFound("c")
+
+	cfg := gw.GetConfig()
+	cfg.Targets = []Target{{VirtualKey: "b"}}
+	if err := gw.ReloadConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	assertFound("b")
+
+	cfg.Targets = []Target{{VirtualKey: "unregistered"}}
+	if err := gw.ReloadConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	assertFound("a")
+}
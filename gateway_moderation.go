@@ -0,0 +1,119 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/observability"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// Moderate classifies req's input with a moderation model, routing across
+// configured, capable targets like Embed does, under the same governance
+// pipeline and observability signals. A request without a model uses
+// providers.DefaultModerationModel. Results are normalized (see
+// providers.ModerationResponse.Normalize), so callers such as a moderation
+// guardrail can compare scores across providers.
+func (g *Gateway) Moderate(ctx context.Context, req providers.ModerationRequest) (*providers.ModerationResponse, error) {
+	log := logging.FromContext(ctx)
+	start := time.Now()
+	hooksEnabled := g.hasHooks()
+
+	g.mu.RLock()
+	requestTimeout := g.config.RequestTimeout
+	strategyMode := string(g.config.Strategy.Mode)
+	obs := g.obs
+	obsEventsActive := g.obsEventsActive
+	g.mu.RUnlock()
+	ctx, cancelDeadline := withRequestDeadline(ctx, requestTimeout)
+	defer cancelDeadline()
+
+	if req.Model == "" {
+		req.Model = providers.DefaultModerationModel
+	}
+	ctx, span := obs.StartRequestSpan(ctx, observability.RequestAttrs{
+		Operation:       surfaceModerations,
+		RequestModel:    req.Model,
+		TraceID:         logging.TraceIDFromContext(ctx),
+		RoutingStrategy: strategyMode,
+	})
+	defer span.End()
+
+	req.Model = g.resolveModelAlias(req.Model)
+
+	var resp *providers.ModerationResponse
+	var providerName string
+	err := g.runSurfaceGovernance(ctx, surfaceModerations, span, func(ctx context.Context) (*providers.Usage, error) {
+		var routeErr error
+		resp, providerName, routeErr = g.routeModeration(ctx, req)
+		return nil, routeErr // moderation responses carry no token usage
+	})
+	latency := time.Since(start)
+	if err != nil {
+		safeErr := g.recordSurfaceError(ctx, span, obs, providerName, req.Model, err, latency, hooksEnabled, obsEventsActive)
+		log.Error("moderation request failed", "model", req.Model, "error", safeErr)
+		return nil, err
+	}
+
+	g.recordSurfaceSuccess(ctx, span, obs, providerName, req.Model, models.Usage{}, latency, hooksEnabled, obsEventsActive)
+
+	log.Info("moderation request completed", "model", req.Model, "results", len(resp.Results))
+	return resp, nil
+}
+
+// routeModeration is routeEmbedding's counterpart for moderation; see its doc
+// comment for the shared retry/fallback/registry-fallback shape.
+func (g *Gateway) routeModeration(ctx context.Context, req providers.ModerationRequest) (*providers.ModerationResponse, string, error) {
+	keys, mode, err := g.surfaceTargetOrder(ctx, req.Model, surfaceModerations, models.Usage{})
+	if err != nil {
+		return nil, "", err
+	}
+	var lastErr error
+	anyViable := false
+	for _, key := range keys {
+		g.mu.RLock()
+		p, ok := g.providers[key]
+		mp, capable := p.(providers.ModerationProvider)
+		g.mu.RUnlock()
+		if !ok || !capable || !p.SupportsModel(req.Model) {
+			continue
+		}
+		anyViable = true
+		providerName := p.Name()
+
+		resp, callErr := routeSurfaceTarget(ctx, g, key, func(callCtx context.Context) (*providers.ModerationResponse, error) {
+			return mp.Moderate(callCtx, req)
+		})
+		if callErr == nil {
+			return resp, providerName, nil
+		}
+		lastErr = fmt.Errorf("moderation target %s: %w", key, callErr)
+		if mode != ModeFallback {
+			return nil, providerName, lastErr
+		}
+	}
+
+	if !anyViable && !g.residencyBound(ctx) && pinnedTarget(ctx) == "" {
+		g.mu.RLock()
+		name, mp, ok := g.findModerationProviderByModelLocked(req.Model)
+		g.mu.RUnlock()
+		if ok {
+			resp, callErr := routeSurfaceTarget(ctx, g, name, func(callCtx context.Context) (*providers.ModerationResponse, error) {
+				return mp.Moderate(callCtx, req)
+			})
+			if callErr == nil {
+				return resp, name, nil
+			}
+			return nil, name, fmt.Errorf("moderation target %s: %w", name, callErr)
+		}
+	}
+
+	if lastErr != nil {
+		return nil, "", lastErr
+	}
+	return nil, "", fmt.Errorf("%w: no moderation provider for %q", core.ErrNoCapableProvider, req.Model)
+}
@@ -0,0 +1,92 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+type mockModerationProvider struct {
+	mockProvider
+	capturedModel string
+	calls         int
+	err           error
+}
+
+func (m *mockModerationProvider) Moderate(_ context.Context, req providers.ModerationRequest) (*providers.ModerationResponse, error) {
+	m.calls++
+	m.capturedModel = req.Model
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &providers.ModerationResponse{
+		ID:    "modr-1",
+		Model: req.Model,
+		Results: []providers.ModerationResult{{
+			Flagged:        true,
+			Categories:     map[string]bool{"violence": true},
+			CategoryScores: map[string]float64{"violence": 0.9},
+		}},
+	}, nil
+}
+
+func TestGateway_Moderate_DefaultsModel(t *testing.T) {
+	mp := &mockModerationProvider{
+		mockProvider: mockProvider{name: mockProviderName, models: []string{providers.DefaultModerationModel}},
+	}
+	gw, _ := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+	})
+	gw.RegisterProvider(mp)
+
+	resp, err := gw.Moderate(context.Background(), providers.ModerationRequest{Input: "hello"})
+	if err != nil {
+		t.Fatalf("Moderate: %v", err)
+	}
+	if mp.capturedModel != providers.DefaultModerationModel {
+		t.Errorf("model = %q, want %q", mp.capturedModel, providers.DefaultModerationModel)
+	}
+	if len(resp.Results) != 1 || !resp.Results[0].Flagged {
+		t.Errorf("results = %+v", resp.Results)
+	}
+}
+
+func TestGateway_Moderate_FallsBackAcrossTargets(t *testing.T) {
+	failing := &mockModerationProvider{
+		mockProvider: mockProvider{name: "first", models: []string{"omni-moderation-latest"}},
+		err:          errors.New("upstream down"),
+	}
+	healthy := &mockModerationProvider{
+		mockProvider: mockProvider{name: "second", models: []string{"omni-moderation-latest"}},
+	}
+	gw, _ := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: "first"}, {VirtualKey: "second"}},
+	})
+	gw.RegisterProvider(failing)
+	gw.RegisterProvider(healthy)
+
+	if _, err := gw.Moderate(context.Background(), providers.ModerationRequest{Model: "omni-moderation-latest", Input: "x"}); err != nil {
+		t.Fatalf("Moderate: %v", err)
+	}
+	if failing.calls == 0 || healthy.calls != 1 {
+		t.Errorf("calls: first=%d second=%d, want the second target to answer", failing.calls, healthy.calls)
+	}
+}
+
+func TestGateway_Moderate_NoCapableProvider(t *testing.T) {
+	gw, _ := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+	})
+	gw.RegisterProvider(&mockProvider{name: mockProviderName, models: []string{providers.DefaultModerationModel}})
+
+	_, err := gw.Moderate(context.Background(), providers.ModerationRequest{Input: "hello"})
+	if !errors.Is(err, core.ErrNoCapableProvider) {
+		t.Fatalf("err = %v, want ErrNoCapableProvider", err)
+	}
+}
@@ -0,0 +1,102 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// paramsProvider applies a target's parameter policy to every attempt sent
+// to it. It sits below the strategy, so the policy holds after plugins have
+// rewritten the request and on every fallback, whatever the client sent.
+type paramsProvider struct {
+	providers.Provider
+	name   string
+	policy *TargetParams
+	tools  map[string]bool
+}
+
+// enforceParams wraps p when the target has a parameter policy.
+func enforceParams(name string, p providers.Provider, policy *TargetParams) providers.Provider {
+	if policy == nil {
+		return p
+	}
+	tools := make(map[string]bool, len(policy.ForbiddenTools))
+	for _, t := range policy.ForbiddenTools {
+		tools[t] = true
+	}
+	return &paramsProvider{Provider: p, name: name, policy: policy, tools: tools}
+}
+
+func (p *paramsProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	return p.Provider.Complete(ctx, p.apply(ctx, req))
+}
+
+func (p *paramsProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	return sp.CompleteStream(ctx, p.apply(ctx, req))
+}
+
+// apply returns req within the policy. It replaces the pointers and slices
+// it changes instead of writing through them, as they are shared with the
+// caller's request.
+func (p *paramsProvider) apply(ctx context.Context, req providers.Request) providers.Request {
+	var clamped []string
+	if mt := p.policy.MaxTemperature; mt != nil && req.Temperature != nil && *req.Temperature > *mt {
+		v := *mt
+		req.Temperature = &v
+		clamped = append(clamped, "temperature")
+	}
+	if limit := p.policy.MaxTokens; limit > 0 {
+		if req.MaxTokens == nil && req.MaxCompletionTokens == nil || req.MaxTokens != nil && *req.MaxTokens > limit {
+			v := limit
+			req.MaxTokens = &v
+			clamped = append(clamped, "max_tokens")
+		}
+		if req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > limit {
+			v := limit
+			req.MaxCompletionTokens = &v
+			clamped = append(clamped, "max_completion_tokens")
+		}
+	}
+	if len(p.tools) > 0 && len(req.Tools) > 0 {
+		kept := make([]providers.Tool, 0, len(req.Tools))
+		for _, t := range req.Tools {
+			if !p.tools[t.Function.Name] {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) < len(req.Tools) {
+			if len(kept) == 0 {
+				kept = nil
+			}
+			req.Tools = kept
+			if len(kept) == 0 || p.tools[toolChoiceName(req.ToolChoice)] {
+				req.ToolChoice = nil
+			}
+			clamped = append(clamped, "tools")
+		}
+	}
+	if len(clamped) > 0 {
+		logging.FromContext(ctx).Debug("target parameter policy applied",
+			"target", p.name, "model", req.Model, "params", clamped)
+	}
+	return req
+}
+
+// toolChoiceName returns the function a tool_choice object forces, or "" for
+// the string modes ("auto", "none", "required").
+func toolChoiceName(choice any) string {
+	m, ok := choice.(map[string]any)
+	if !ok {
+		return ""
+	}
+	fn, _ := m["function"].(map[string]any)
+	name, _ := fn["name"].(string)
+	return name
+}
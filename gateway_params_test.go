@@ -0,0 +1,156 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func newParamsTestGateway(t *testing.T, policy *TargetParams, seen *providers.Request) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "azure", Params: policy}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	record := func(req providers.Request) { *seen = req }
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{
+			name:   "azure",
+			models: []string{"gpt-4o"},
+			completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+				record(req)
+				return &providers.Response{ID: "r1", Choices: []providers.Choice{{Message: providers.Message{Role: "assistant"}}}}, nil
+			},
+		},
+		streamFn: func(_ context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+			record(req)
+			ch := make(chan providers.StreamChunk)
+			close(ch)
+			return ch, nil
+		},
+	})
+	return gw
+}
+
+func paramsTool(name string) providers.Tool {
+	return providers.Tool{Type: "function", Function: providers.Function{Name: name}}
+}
+
+func TestRoute_TargetParamsClampRequest(t *testing.T) {
+	maxTemp := 0.5
+	var seen providers.Request
+	gw := newParamsTestGateway(t, &TargetParams{
+		MaxTemperature: &maxTemp,
+		MaxTokens:      1000,
+		ForbiddenTools: []string{"run_shell"},
+	}, &seen)
+
+	temp, maxTokens := 1.5, 4000
+	req := providers.Request{
+		Model:       "gpt-4o",
+		Messages:    []providers.Message{{Role: "user", Content: "hi"}},
+		Temperature: &temp,
+		MaxTokens:   &maxTokens,
+		Tools:       []providers.Tool{paramsTool("search"), paramsTool("run_shell")},
+		ToolChoice:  map[string]any{"type": "function", "function": map[string]any{"name": "run_shell"}},
+	}
+	if _, err := gw.Route(context.Background(), req); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+
+	if seen.Temperature == nil || *seen.Temperature != 0.5 {
+		t.Errorf("temperature = %v, want 0.5", seen.Temperature)
+	}
+	if seen.MaxTokens == nil || *seen.MaxTokens != 1000 {
+		t.Errorf("max_tokens = %v, want 1000", seen.MaxTokens)
+	}
+	if len(seen.Tools) != 1 || seen.Tools[0].Function.Name != "search" {
+		t.Errorf("tools = %+v, want only search", seen.Tools)
+	}
+	if seen.ToolChoice != nil {
+		t.Errorf("tool_choice = %v, want it dropped with the forbidden tool", seen.ToolChoice)
+	}
+	if temp != 1.5 || maxTokens != 4000 || len(req.Tools) != 2 {
+		t.Error("the policy wrote through to the caller's request")
+	}
+}
+
+func TestRoute_TargetParamsLeaveCompliantRequest(t *testing.T) {
+	maxTemp := 1.0
+	var seen providers.Request
+	gw := newParamsTestGateway(t, &TargetParams{
+		MaxTemperature: &maxTemp,
+		MaxTokens:      1000,
+		ForbiddenTools: []string{"run_shell"},
+	}, &seen)
+
+	completion := 200
+	if _, err := gw.Route(context.Background(), providers.Request{
+		Model:               "gpt-4o",
+		Messages:            []providers.Message{{Role: "user", Content: "hi"}},
+		MaxCompletionTokens: &completion,
+		Tools:               []providers.Tool{paramsTool("search")},
+		ToolChoice:          "required",
+	}); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+
+	if seen.Temperature != nil {
+		t.Errorf("temperature = %v, want the provider default", *seen.Temperature)
+	}
+	if seen.MaxCompletionTokens == nil || *seen.MaxCompletionTokens != 200 {
+		t.Errorf("max_completion_tokens = %v, want 200", seen.MaxCompletionTokens)
+	}
+	if len(seen.Tools) != 1 || seen.ToolChoice != "required" {
+		t.Errorf("tools = %+v, tool_choice = %v; want them unchanged", seen.Tools, seen.ToolChoice)
+	}
+}
+
+func TestRouteStream_TargetParamsStripAllTools(t *testing.T) {
+	var seen providers.Request
+	gw := newParamsTestGateway(t, &TargetParams{
+		MaxTokens:      512,
+		ForbiddenTools: []string{"run_shell"},
+	}, &seen)
+
+	ch, err := gw.RouteStream(context.Background(), providers.Request{
+		Model:      "gpt-4o",
+		Messages:   []providers.Message{{Role: "user", Content: "hi"}},
+		Stream:     true,
+		Tools:      []providers.Tool{paramsTool("run_shell")},
+		ToolChoice: "required",
+	})
+	if err != nil {
+		t.Fatalf("RouteStream: %v", err)
+	}
+	for range ch {
+	}
+
+	if seen.MaxTokens == nil || *seen.MaxTokens != 512 {
+		t.Errorf("max_tokens = %v, want 512 set on a request without a limit", seen.MaxTokens)
+	}
+	if seen.Tools != nil || seen.ToolChoice != nil {
+		t.Errorf("tools = %+v, tool_choice = %v; want both cleared", seen.Tools, seen.ToolChoice)
+	}
+}
+
+func TestValidateConfig_TargetParams(t *testing.T) {
+	tooHot := 2.5
+	for name, params := range map[string]*TargetParams{
+		"temperature": {MaxTemperature: &tooHot},
+		"max_tokens":  {MaxTokens: -1},
+		"tool name":   {ForbiddenTools: []string{" "}},
+	} {
+		cfg := Config{
+			Strategy: StrategyConfig{Mode: ModeSingle},
+			Targets:  []Target{{VirtualKey: "azure", Params: params}},
+		}
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%s: ValidateConfig accepted %+v", name, params)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	_ "github.com/ferro-labs/ai-gateway/internal/plugins/historycompress"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
 	"github.com/ferro-labs/ai-gateway/plugin"
 	"github.com/ferro-labs/ai-gateway/providers"
@@ -304,3 +305,46 @@ func TestGateway_ReloadConfig_DefersOldPluginCloseUntilInFlightRouteFinishes(t *
 		t.Fatal("timed out waiting for old plugin close")
 	}
 }
+
+// A plugin that calls a model of its own, history-compress here, is handed
+// the gateway's Completer and reaches the summary model's provider.
+func TestGateway_SuppliesCompleterToPlugins(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+		Aliases:  map[string]string{"summarizer": "cheap"},
+		Plugins: []PluginConfig{{
+			Name: "history-compress", Type: "transform", Stage: "before_request", Enabled: true,
+			Config: map[string]any{"summary_model": "summarizer", "threshold_tokens": 20, "keep_last": 1},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sent := map[string][]providers.Message{}
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o", "cheap"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			sent[req.Model] = req.Messages
+			return &providers.Response{ID: "ok", Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "earlier: greetings"}}}}, nil
+		},
+	})
+	if err := gw.LoadPlugins(); err != nil {
+		t.Fatalf("LoadPlugins: %v", err)
+	}
+
+	long := "a long opening message with many many words in it to pass the threshold"
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{
+		{Role: "user", Content: long}, {Role: "assistant", Content: long}, {Role: "user", Content: "and?"},
+	}}
+	if _, err := gw.Route(context.Background(), req); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if sent["cheap"] == nil {
+		t.Fatal("the summary model was never called")
+	}
+	if got := sent["gpt-4o"]; len(got) != 2 || got[1].Content != "and?" {
+		t.Fatalf("model was sent %+v, want the summary and the last turn", got)
+	}
+}
@@ -0,0 +1,88 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func newScopedFilterGateway(t *testing.T, match *plugin.Match) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+		Plugins: []PluginConfig{{
+			Name:    "word-filter",
+			Type:    "guardrail",
+			Stage:   "before_request",
+			Enabled: true,
+			Config:  map[string]any{"blocked_words": []any{"secret"}},
+			Match:   match,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o", "claude-sonnet-4-6"},
+		resp:   &providers.Response{ID: "ok"},
+	})
+	if err := gw.LoadPlugins(); err != nil {
+		t.Fatalf("LoadPlugins: %v", err)
+	}
+	return gw
+}
+
+func routeSecret(ctx context.Context, gw *Gateway, model string) error {
+	_, err := gw.Route(ctx, providers.Request{
+		Model:    model,
+		Messages: []providers.Message{{Role: "user", Content: "the secret is out"}},
+	})
+	return err
+}
+
+func TestRoute_PluginMatchModels(t *testing.T) {
+	gw := newScopedFilterGateway(t, &plugin.Match{Models: []string{"gpt-4*"}})
+
+	var rejection *plugin.RejectionError
+	if err := routeSecret(context.Background(), gw, "gpt-4o"); !errors.As(err, &rejection) {
+		t.Fatalf("matching model: got %v, want a word-filter rejection", err)
+	}
+	if err := routeSecret(context.Background(), gw, "claude-sonnet-4-6"); err != nil {
+		t.Fatalf("non-matching model must bypass the plugin: %v", err)
+	}
+}
+
+func TestRoute_PluginMatchWorkspaces(t *testing.T) {
+	gw := newScopedFilterGateway(t, &plugin.Match{Workspaces: []string{"payments"}})
+
+	inWorkspace := authctx.WithIdentity(authctx.WithKeyID(context.Background(), "key-a"), authctx.KeyIdentity{Name: "svc", Workspace: "payments"})
+	var rejection *plugin.RejectionError
+	if err := routeSecret(inWorkspace, gw, "gpt-4o"); !errors.As(err, &rejection) {
+		t.Fatalf("key in the workspace: got %v, want a word-filter rejection", err)
+	}
+
+	elsewhere := authctx.WithIdentity(authctx.WithKeyID(context.Background(), "key-b"), authctx.KeyIdentity{Name: "svc", Workspace: "search"})
+	if err := routeSecret(elsewhere, gw, "gpt-4o"); err != nil {
+		t.Fatalf("key in another workspace must bypass the plugin: %v", err)
+	}
+	if err := routeSecret(context.Background(), gw, "gpt-4o"); err != nil {
+		t.Fatalf("unauthenticated request must not meet a workspace rule: %v", err)
+	}
+}
+
+func TestValidateConfig_PluginMatch(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai"}},
+		Plugins:  []PluginConfig{{Name: "word-filter", Stage: "before_request", Match: &plugin.Match{Models: []string{""}}}},
+	}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("expected an empty match value to be rejected")
+	}
+}
@@ -0,0 +1,62 @@
+package aigateway
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ferro-labs/ai-gateway/internal/pluginmeta"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Plugin metadata exposure: plugins declare response headers and fields on
+// their plugin.Context, and the gateway passes on only the names
+// Config.ExposePluginMetadata allowlists — headers through a pluginmeta
+// recorder the HTTP layer writes from, fields in the response's
+// plugin_metadata object. Anything else a plugin declares stays internal.
+
+// exposePluginHeaders records pctx's allowlisted response headers for the
+// HTTP layer.
+func exposePluginHeaders(ctx context.Context, expose *PluginMetadataExposure, pctx *plugin.Context) {
+	if expose == nil || pctx == nil || len(pctx.ResponseHeaders) == 0 {
+		return
+	}
+	var out map[string]string
+	for _, name := range expose.Headers {
+		v, ok := pctx.ResponseHeaders[http.CanonicalHeaderKey(name)]
+		if !ok || v == "" {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(expose.Headers))
+		}
+		out[http.CanonicalHeaderKey(name)] = v
+	}
+	pluginmeta.Record(ctx, out)
+}
+
+// withPluginFields returns resp with pctx's allowlisted response fields in its
+// plugin_metadata, copying resp rather than modifying it, as it may be a
+// cached response other requests share.
+func withPluginFields(resp *providers.Response, expose *PluginMetadataExposure, pctx *plugin.Context) *providers.Response {
+	if expose == nil || pctx == nil || len(pctx.ResponseFields) == 0 {
+		return resp
+	}
+	var fields map[string]any
+	for _, name := range expose.Fields {
+		v, ok := pctx.ResponseFields[name]
+		if !ok {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]any, len(expose.Fields))
+		}
+		fields[name] = v
+	}
+	if fields == nil {
+		return resp
+	}
+	out := *resp
+	out.PluginMetadata = fields
+	return &out
+}
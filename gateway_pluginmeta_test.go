@@ -0,0 +1,103 @@
+package aigateway
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/pluginmeta"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestRoute_ExposesAllowlistedPluginMetadata(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+		ExposePluginMetadata: &PluginMetadataExposure{
+			Headers: []string{"x-ferro-guardrail"},
+			Fields:  []string{"guardrail"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	shared := &providers.Response{ID: "ok"}
+	gw.RegisterProvider(&mockProvider{name: mockProviderName, models: []string{"gpt-4o"}, resp: shared})
+	reject := false
+	_ = gw.RegisterPlugin(plugin.StageBeforeRequest, &testPlugin{
+		name: "declarer",
+		typ:  plugin.TypeGuardrail,
+		execFn: func(_ context.Context, pctx *plugin.Context) error {
+			pctx.SetResponseHeader("X-Ferro-Guardrail", "pii=pass")
+			pctx.SetResponseHeader("X-Ferro-Internal-Node", "10.0.0.7")
+			pctx.SetResponseField("guardrail", "pass")
+			pctx.SetResponseField("internal_score", 0.93)
+			if reject {
+				pctx.Reject, pctx.Reason = true, "blocked"
+			}
+			return nil
+		},
+	})
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	ctx, rec := pluginmeta.WithRecorder(context.Background())
+	resp, err := gw.Route(ctx, req)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	h := http.Header{}
+	rec.WriteHeaders(h)
+	if h.Get("X-Ferro-Guardrail") != "pii=pass" || h.Get("X-Ferro-Internal-Node") != "" {
+		t.Errorf("headers = %v, want only X-Ferro-Guardrail", h)
+	}
+	if len(resp.PluginMetadata) != 1 || resp.PluginMetadata["guardrail"] != "pass" {
+		t.Errorf("plugin_metadata = %v, want only guardrail", resp.PluginMetadata)
+	}
+	if shared.PluginMetadata != nil {
+		t.Error("the provider's response was modified")
+	}
+
+	// A rejected request still reports the headers declared before it.
+	reject = true
+	ctx, rec = pluginmeta.WithRecorder(context.Background())
+	if _, err := gw.Route(ctx, req); err == nil {
+		t.Fatal("Route succeeded, want the rejection")
+	}
+	h = http.Header{}
+	rec.WriteHeaders(h)
+	if h.Get("X-Ferro-Guardrail") != "pii=pass" {
+		t.Errorf("headers = %v on rejection, want X-Ferro-Guardrail", h)
+	}
+}
+
+func TestRoute_PluginMetadataNotExposedByDefault(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockProvider{name: mockProviderName, models: []string{"gpt-4o"}, resp: &providers.Response{ID: "ok"}})
+	_ = gw.RegisterPlugin(plugin.StageBeforeRequest, &testPlugin{
+		name: "declarer",
+		typ:  plugin.TypeGuardrail,
+		execFn: func(_ context.Context, pctx *plugin.Context) error {
+			pctx.SetResponseHeader("X-Ferro-Guardrail", "pii=pass")
+			pctx.SetResponseField("guardrail", "pass")
+			return nil
+		},
+	})
+
+	ctx, rec := pluginmeta.WithRecorder(context.Background())
+	resp, err := gw.Route(ctx, providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	h := http.Header{}
+	rec.WriteHeaders(h)
+	if len(h) != 0 || resp.PluginMetadata != nil {
+		t.Errorf("headers = %v, plugin_metadata = %v, want nothing exposed", h, resp.PluginMetadata)
+	}
+}
@@ -0,0 +1,170 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/observability"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// surfaceRealtime is the span operation of a realtime session.
+const surfaceRealtime = "realtime"
+
+// RealtimeProvider resolves the provider a realtime session with model is
+// tunnelled to, routed as the other surfaces are (see surfaceTargetOrder): the
+// in-service targets serving the model, in strategy order, within the
+// request's residency requirement, or just the target a
+// RequestOptions.Target pins. With neither a requirement nor a pin, a
+// registered provider serving the model outside the target list is used when
+// no target serves it. model may be empty only for a pinned target.
+func (g *Gateway) RealtimeProvider(ctx context.Context, model string) (providers.Provider, error) {
+	keys, _, err := g.surfaceTargetOrder(ctx, model, surfaceRealtime, models.Usage{})
+	if err != nil {
+		return nil, err
+	}
+	target := pinnedTarget(ctx)
+	registryFallback := model != "" && target == "" && !g.residencyBound(ctx)
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, key := range keys {
+		p, ok := g.providers[key]
+		if ok && providerSupportsSurface(p, surfaceRealtime) && (model == "" || p.SupportsModel(model)) {
+			return p, nil
+		}
+	}
+	if registryFallback {
+		if p, ok := g.findProviderByModelLocked(model); ok && providerSupportsSurface(p, surfaceRealtime) {
+			return p, nil
+		}
+	}
+	if target != "" {
+		return nil, fmt.Errorf("%w: target %s is not in service for realtime sessions", core.ErrNoCapableProvider, target)
+	}
+	return nil, fmt.Errorf("%w: no realtime provider for %q", core.ErrNoCapableProvider, model)
+}
+
+// RealtimeSession accounts for one realtime WebSocket session proxied to a
+// provider. The proxy reports each response's usage as the upstream sends it,
+// so tokens and cost show up in the metrics while the session is still open;
+// the session as a whole is counted as one request, with its span and
+// lifecycle event, when it ends.
+type RealtimeSession struct {
+	g               *Gateway
+	ctx             context.Context
+	span            observability.Span
+	obs             observability.Provider
+	provider        string
+	model           string
+	started         time.Time
+	hooksEnabled    bool
+	obsEventsActive bool
+	metrics         *metrics.RequestMetricHandles
+
+	mu    sync.Mutex
+	usage models.Usage
+	cost  models.CostResult
+}
+
+// StartRealtimeSession opens the accounting for a realtime session with
+// model on providerName. The returned context carries the session's span and
+// should be used for the proxied connection. The caller must call End.
+func (g *Gateway) StartRealtimeSession(ctx context.Context, providerName, model string) (context.Context, *RealtimeSession) {
+	g.mu.RLock()
+	obs := g.obs
+	obsEventsActive := g.obsEventsActive
+	g.mu.RUnlock()
+
+	ctx, span := obs.StartRequestSpan(ctx, observability.RequestAttrs{
+		Operation:    surfaceRealtime,
+		RequestModel: model,
+		TraceID:      logging.TraceIDFromContext(ctx),
+	})
+	span.SetAttribute(observability.AttrGenAISystem, providerName)
+	metrics.RealtimeSessionsActive.WithLabelValues(providerName).Inc()
+	return ctx, &RealtimeSession{
+		g:               g,
+		ctx:             ctx,
+		span:            span,
+		obs:             obs,
+		provider:        providerName,
+		model:           model,
+		started:         time.Now(),
+		hooksEnabled:    g.hasHooks(),
+		obsEventsActive: obsEventsActive,
+		metrics:         metrics.ForRequest(providerName, g.metricModel(model)),
+	}
+}
+
+// RecordUsage accounts for the usage of one response of the session, priced
+// at the model's catalog token rates.
+func (s *RealtimeSession) RecordUsage(usage models.Usage) {
+	s.g.mu.RLock()
+	catalog := s.g.catalog
+	s.g.mu.RUnlock()
+	cost := models.Calculate(catalog, s.provider+"/"+s.model, usage)
+
+	s.metrics.TokensIn.Add(float64(usage.PromptTokens))
+	s.metrics.TokensOut.Add(float64(usage.CompletionTokens))
+	if cost.TotalUSD > 0 {
+		s.metrics.CostUSD.Add(cost.TotalUSD)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.PromptTokens += usage.PromptTokens
+	s.usage.CompletionTokens += usage.CompletionTokens
+	s.usage.CacheReadTokens += usage.CacheReadTokens
+	s.cost.ModelFound = cost.ModelFound
+	s.cost.TotalUSD += cost.TotalUSD
+	s.cost.InputUSD += cost.InputUSD
+	s.cost.OutputUSD += cost.OutputUSD
+	s.cost.CacheReadUSD += cost.CacheReadUSD
+}
+
+// End closes the session's accounting. err is why the session could not be
+// established, or nil once an established session closes.
+func (s *RealtimeSession) End(err error) {
+	defer s.span.End()
+	duration := time.Since(s.started)
+	metrics.RealtimeSessionsActive.WithLabelValues(s.provider).Dec()
+	if err != nil {
+		s.g.recordSurfaceError(s.ctx, s.span, s.obs, s.provider, s.model, err, duration, s.hooksEnabled, s.obsEventsActive)
+		return
+	}
+
+	s.mu.Lock()
+	usage, cost := s.usage, s.cost
+	s.mu.Unlock()
+	s.metrics.Success.Inc()
+	metrics.RealtimeSessionDuration.WithLabelValues(s.provider, s.g.metricModel(s.model)).Observe(duration.Seconds())
+
+	s.span.SetAttribute(observability.AttrGenAIResponseModel, s.model)
+	s.span.SetTokens(usage.PromptTokens, usage.CompletionTokens, 0)
+	s.span.SetCost(observability.CostBreakdown{
+		TotalUSD:     cost.TotalUSD,
+		InputUSD:     cost.InputUSD,
+		OutputUSD:    cost.OutputUSD,
+		CacheReadUSD: cost.CacheReadUSD,
+		ModelFound:   cost.ModelFound,
+	})
+	if s.hooksEnabled || s.obsEventsActive {
+		he := completedEventData(
+			logging.TraceIDFromContext(s.ctx),
+			s.provider,
+			s.model,
+			duration,
+			true,
+			usage.PromptTokens,
+			usage.CompletionTokens,
+			cost,
+		)
+		s.g.dispatchRequestEvent(s.ctx, s.obs, s.hooksEnabled, s.obsEventsActive, he)
+	}
+}
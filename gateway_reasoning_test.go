@@ -0,0 +1,86 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestGateway_RouteAdaptsReasoningParams(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var got providers.Request
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"o3-mini"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			got = req
+			return &providers.Response{ID: "r", Model: req.Model}, nil
+		},
+	})
+
+	temp, maxTokens := 0.7, 256
+	_, err = gw.Route(context.Background(), providers.Request{
+		Model:           "o3-mini",
+		Messages:        []providers.Message{{Role: "user", Content: "hi"}},
+		Temperature:     &temp,
+		MaxTokens:       &maxTokens,
+		ReasoningEffort: "low",
+	})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if got.Temperature != nil {
+		t.Error("temperature reached an o-series model")
+	}
+	if got.MaxCompletionTokens == nil || *got.MaxCompletionTokens != 256 {
+		t.Errorf("max_completion_tokens = %v, want 256", got.MaxCompletionTokens)
+	}
+	if got.ReasoningEffort != "low" {
+		t.Errorf("reasoning_effort = %q, want low", got.ReasoningEffort)
+	}
+}
+
+func TestGateway_RouteStripsReasoningContent(t *testing.T) {
+	for _, strip := range []bool{false, true} {
+		gw, err := newTestGateway(t, Config{
+			Strategy:      StrategyConfig{Mode: ModeSingle},
+			Targets:       []Target{{VirtualKey: mockProviderName}},
+			Compatibility: CompatibilityConfig{StripReasoningContent: strip},
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		upstream := &providers.Response{ID: "r", Choices: []providers.Choice{{
+			Message: providers.Message{Role: "assistant", Content: "42", ReasoningContent: "thinking"},
+		}}}
+		gw.RegisterProvider(&mockProvider{name: mockProviderName, models: []string{"deepseek-reasoner"}, resp: upstream})
+
+		resp, err := gw.Route(context.Background(), providers.Request{
+			Model:    "deepseek-reasoner",
+			Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		})
+		if err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		want := "thinking"
+		if strip {
+			want = ""
+		}
+		if got := resp.Choices[0].Message.ReasoningContent; got != want {
+			t.Errorf("strip=%v: reasoning_content = %q, want %q", strip, got, want)
+		}
+		if resp.Choices[0].Message.Content != "42" {
+			t.Errorf("strip=%v: content = %q, want 42", strip, resp.Choices[0].Message.Content)
+		}
+		if upstream.Choices[0].Message.ReasoningContent != "thinking" {
+			t.Errorf("strip=%v: provider's response was modified in place", strip)
+		}
+	}
+}
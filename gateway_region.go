@@ -0,0 +1,175 @@
+package aigateway
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
+	"github.com/ferro-labs/ai-gateway/internal/routingrules"
+	"github.com/ferro-labs/ai-gateway/internal/strategies"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Region-aware routing: targets may declare the region they serve from, and
+// each request has an origin region, taken from its RegionHeader or else the
+// gateway's configured region. A request is routed by the configured strategy
+// over the targets local to its region — those in the region plus those with
+// no region, which are treated as reachable from anywhere — and crosses to the
+// other regions' targets only when no local target is healthy, that is, every
+// local target's circuit is open or its provider unregistered. Rule and
+// variant targets named explicitly in the strategy config are honoured as
+// configured.
+
+// RegionHeader is the request header naming the region a request originates
+// from. It overrides Config.Region.
+const RegionHeader = "X-Ferro-Region"
+
+// regionalStrategy prefers the targets local to one region.
+type regionalStrategy struct {
+	local, remote strategies.Strategy
+	localKeys     []string
+	healthy       func(key string) bool
+}
+
+// localHealthy reports whether any local target can take a request.
+func (r *regionalStrategy) localHealthy() bool {
+	return slices.ContainsFunc(r.localKeys, r.healthy)
+}
+
+// Execute routes to the local targets while one is healthy. A local failure
+// falls over to the remote targets only when it leaves no local target
+// healthy.
+func (r *regionalStrategy) Execute(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	if r.localHealthy() {
+		resp, err := r.local.Execute(ctx, req)
+		if err == nil || r.localHealthy() {
+			return resp, err
+		}
+	}
+	return r.remote.Execute(ctx, req)
+}
+
+// SelectTargets returns the local order followed by the remote one, or the
+// reverse when no local target is healthy.
+func (r *regionalStrategy) SelectTargets(req providers.Request) ([]string, error) {
+	return r.SelectTargetsContext(context.Background(), req)
+}
+
+// SelectTargetsContext is SelectTargets with the request context.
+func (r *regionalStrategy) SelectTargetsContext(ctx context.Context, req providers.Request) ([]string, error) {
+	first, second := r.local, r.remote
+	if !r.localHealthy() {
+		first, second = second, first
+	}
+	keys, err := strategies.SelectTargetsContext(ctx, first, req)
+	if err != nil {
+		return nil, err
+	}
+	more, err := strategies.SelectTargetsContext(ctx, second, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range more {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// preferLocal reorders keys so the local targets come first while one of
+// them is healthy, keeping the order within each group.
+func (r *regionalStrategy) preferLocal(keys []string) []string {
+	if !r.localHealthy() {
+		return keys
+	}
+	ordered := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if slices.Contains(r.localKeys, key) {
+			ordered = append(ordered, key)
+		}
+	}
+	for _, key := range keys {
+		if !slices.Contains(r.localKeys, key) {
+			ordered = append(ordered, key)
+		}
+	}
+	return ordered
+}
+
+// requestRegion returns the origin region of ctx's request.
+func (s *routingSnapshot) requestRegion(ctx context.Context) string {
+	if region := routingrules.HeaderValue(ctx, RegionHeader); region != "" {
+		return region
+	}
+	return s.region
+}
+
+// strategyFor returns the strategy routing ctx's request: the one for its
+// residency requirement, if it has one, else the regional one for its origin
+// region, if there is one, else the configured strategy.
+func (s *routingSnapshot) strategyFor(ctx context.Context) strategies.Strategy {
+	if requirement := s.residencyOf(ctx); requirement != "" {
+		return s.residency[requirement]
+	}
+	if r := s.regional[s.requestRegion(ctx)]; r != nil {
+		return r
+	}
+	return s.strategy
+}
+
+// buildRegionalStrategiesLocked builds a regionalStrategy for every region
+// with both local and remote targets. Single mode has one target and none.
+// Caller must hold g.mu.
+func (g *Gateway) buildRegionalStrategiesLocked(lookup strategies.ProviderLookup, providerSnap map[string]providers.Provider, cbSnap map[string]*circuitbreaker.CircuitBreaker) (map[string]*regionalStrategy, error) {
+	if g.config.Strategy.Mode == ModeSingle || g.config.Strategy.Mode == "" {
+		return nil, nil
+	}
+	healthy := func(key string) bool {
+		if _, ok := providerSnap[key]; !ok {
+			return false
+		}
+		cb := cbSnap[key]
+		return cb == nil || cb.State() != circuitbreaker.StateOpen
+	}
+
+	var regional map[string]*regionalStrategy
+	for _, t := range g.config.Targets {
+		region := t.Region
+		if region == "" || regional[region] != nil {
+			continue
+		}
+		var local, remote []strategies.Target
+		var localKeys []string
+		for _, c := range g.config.Targets {
+			target := strategies.Target{VirtualKey: c.VirtualKey, Weight: c.Weight}
+			if c.Region == "" || c.Region == region {
+				local = append(local, target)
+				localKeys = append(localKeys, c.VirtualKey)
+			} else {
+				remote = append(remote, target)
+			}
+		}
+		if len(remote) == 0 {
+			continue
+		}
+		localStrategy, err := g.buildStrategyLocked(local, lookup)
+		if err != nil {
+			return nil, err
+		}
+		remoteStrategy, err := g.buildStrategyLocked(remote, lookup)
+		if err != nil {
+			return nil, err
+		}
+		if regional == nil {
+			regional = make(map[string]*regionalStrategy)
+		}
+		regional[region] = &regionalStrategy{
+			local:     localStrategy,
+			remote:    remoteStrategy,
+			localKeys: localKeys,
+			healthy:   healthy,
+		}
+	}
+	return regional, nil
+}
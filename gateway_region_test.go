@@ -0,0 +1,129 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/routingrules"
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// fromRegion returns a context for a request sent with the region header.
+func fromRegion(region string) context.Context {
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	r.Header.Set(RegionHeader, region)
+	return routingrules.WithRequest(context.Background(), r)
+}
+
+func newRegionalGateway(t *testing.T, targets []Target) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Region:   "us",
+		Targets:  targets,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, t := range targets {
+		gw.RegisterProvider(&mockProvider{name: t.VirtualKey, models: []string{"gpt-4o"}, resp: &providers.Response{ID: t.VirtualKey}})
+	}
+	return gw
+}
+
+func TestGateway_PrefersRequestRegion(t *testing.T) {
+	gw := newRegionalGateway(t, []Target{
+		{VirtualKey: "us-east", Region: "us"},
+		{VirtualKey: "global"},
+		{VirtualKey: "eu-west", Region: "eu"},
+	})
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantResp string
+		wantKeys []string
+	}{
+		{"configured region", context.Background(), "us-east", []string{"us-east", "global", "eu-west"}},
+		{"header region", fromRegion("eu"), "global", []string{"global", "eu-west", "us-east"}},
+		{"unknown region", fromRegion("ap"), "us-east", []string{"us-east", "global", "eu-west"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := gw.Route(tt.ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.ID != tt.wantResp {
+				t.Errorf("Route served by %s, want %s", resp.ID, tt.wantResp)
+			}
+			snap, err := gw.loadRouting()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The streaming order.
+			s := snap.strategyFor(tt.ctx)
+			keys, err := s.SelectTargets(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("SelectTargets = %v, want %v", keys, tt.wantKeys)
+			}
+			// The embeddings and images order.
+			keys, _, err = gw.surfaceTargetOrder(tt.ctx, "gpt-4o", surfaceEmbeddings, models.Usage{PromptTokens: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("surfaceTargetOrder = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestGateway_CrossesRegionOnlyWhenLocalUnhealthy(t *testing.T) {
+	gw := newRegionalGateway(t, []Target{
+		{VirtualKey: "us-east", Region: "us"},
+		{
+			VirtualKey:     "eu-west",
+			Region:         "eu",
+			CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 2, Timeout: "1m"},
+		},
+	})
+	eu := &mockProvider{name: "eu-west", models: []string{"gpt-4o"}, err: errors.New("upstream unavailable")}
+	gw.RegisterProvider(eu)
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	// The first failure leaves the eu target healthy: the error is returned
+	// rather than the request crossing to the us region.
+	if _, err := gw.Route(fromRegion("eu"), req); err == nil {
+		t.Fatal("Route succeeded while the only local target was failing and healthy")
+	}
+	// The second opens its circuit, so the request falls over to us.
+	resp, err := gw.Route(fromRegion("eu"), req)
+	if err != nil {
+		t.Fatalf("Route after the local circuit opened: %v", err)
+	}
+	if resp.ID != "us-east" {
+		t.Errorf("Route served by %s, want us-east", resp.ID)
+	}
+
+	snap, err := gw.loadRouting()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := snap.strategyFor(fromRegion("eu")).SelectTargets(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"us-east", "eu-west"}; !slices.Equal(keys, want) {
+		t.Errorf("SelectTargets with the local circuit open = %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,102 @@
+package aigateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/strategies"
+	"github.com/ferro-labs/ai-gateway/plugin"
+)
+
+// RequestOptions overrides routing and pipeline behaviour for a single
+// request. They come from the Go caller, never from the HTTP request, so they
+// suit trusted tooling: an admin console replaying a request against one
+// provider, or a retry aimed at a target other than the one that failed.
+type RequestOptions struct {
+	// Strategy routes the request with this strategy mode, over the
+	// configured targets and the strategy's configured rules, instead of
+	// strategy.mode. Region preference does not apply to it, and it is
+	// ignored for requests under a data residency requirement, which always
+	// route with the configured strategy.
+	Strategy StrategyMode
+	// Target pins the request to the target or registered provider with this
+	// key, bypassing the strategy; it takes precedence over Strategy. A
+	// request under a data residency requirement fails with
+	// ErrResidencyUnsatisfied when the target is outside it.
+	Target string
+	// SkipPlugins names plugins that do not run, at any stage, for the
+	// request.
+	SkipPlugins []string
+	// Timeout bounds the request in place of Config.RequestTimeout, and like
+	// it only bounds a stream until it starts. Zero keeps the configured
+	// timeout.
+	Timeout time.Duration
+}
+
+// requestOptionsKey carries a request's RequestOptions.
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns ctx carrying opts, which Route and RouteStream
+// apply to the request made with it. Embed and GenerateImage apply all but
+// Strategy.
+//
+//	ctx = aigateway.WithRequestOptions(ctx, aigateway.RequestOptions{Target: "anthropic"})
+//	resp, err := gw.Route(ctx, req)
+func WithRequestOptions(ctx context.Context, opts RequestOptions) context.Context {
+	ctx = plugin.WithoutPlugins(ctx, opts.SkipPlugins...)
+	return context.WithValue(ctx, requestOptionsKey{}, opts)
+}
+
+// requestOptionsFrom returns the RequestOptions ctx carries, if any.
+func requestOptionsFrom(ctx context.Context) (RequestOptions, bool) {
+	opts, ok := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return opts, ok
+}
+
+// pinnedTarget returns the target ctx's request is pinned to, or "". A pinned
+// request never falls back to a provider outside the target list.
+func pinnedTarget(ctx context.Context) string {
+	opts, _ := requestOptionsFrom(ctx)
+	return opts.Target
+}
+
+// requestStrategy returns the strategy routing ctx's request, and its mode:
+// the snapshot's own choice, unless the request's options pin a target or
+// override the mode.
+func (g *Gateway) requestStrategy(ctx context.Context, snap *routingSnapshot) (strategies.Strategy, StrategyMode, error) {
+	opts, ok := requestOptionsFrom(ctx)
+	if !ok || (opts.Target == "" && (opts.Strategy == "" || opts.Strategy == snap.mode)) {
+		return snap.strategyFor(ctx), snap.mode, nil
+	}
+	requirement := snap.residencyOf(ctx)
+	if opts.Target != "" {
+		if requirement != "" {
+			if _, err := snap.residency[requirement].filter([]string{opts.Target}); err != nil {
+				return nil, "", err
+			}
+		}
+		return strategies.NewSingle(strategies.Target{VirtualKey: opts.Target}, snap.lookup), ModeSingle, nil
+	}
+	if requirement != "" {
+		return snap.residency[requirement], snap.mode, nil
+	}
+	s, err := g.strategyOverride(snap, opts.Strategy)
+	return s, opts.Strategy, err
+}
+
+// strategyOverride returns snap's strategy for mode, building it on first
+// use. It is built from the current config, which is the config snap was
+// built from unless a change has since invalidated snap.
+func (g *Gateway) strategyOverride(snap *routingSnapshot, mode StrategyMode) (strategies.Strategy, error) {
+	if s, ok := snap.overrides.Load(mode); ok {
+		return s.(strategies.Strategy), nil
+	}
+	g.mu.RLock()
+	s, err := g.buildStrategyModeLocked(mode, snap.strategyTargets, snap.lookup)
+	g.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := snap.overrides.LoadOrStore(mode, s)
+	return actual.(strategies.Strategy), nil
+}
@@ -0,0 +1,133 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// newOptionsTestGateway builds a gateway routing "primary" then "secondary",
+// both serving gpt-4o and answering with their own name as the response ID.
+func newOptionsTestGateway(t *testing.T, mode StrategyMode) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: mode},
+		Targets:  []Target{{VirtualKey: "primary"}, {VirtualKey: "secondary"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, name := range []string{"primary", "secondary"} {
+		gw.RegisterProvider(&mockProvider{
+			name:   name,
+			models: []string{"gpt-4o"},
+			resp:   &providers.Response{ID: name, Choices: []providers.Choice{{Message: providers.Message{Role: "assistant"}}}},
+		})
+	}
+	return gw
+}
+
+func TestRequestOptions_TargetPinsTheRequest(t *testing.T) {
+	gw := newOptionsTestGateway(t, ModeFallback)
+
+	ctx := WithRequestOptions(context.Background(), RequestOptions{Target: "secondary"})
+	resp, err := gw.Route(ctx, providers.Request{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "secondary" {
+		t.Fatalf("answered by %q, want the pinned secondary", resp.ID)
+	}
+
+	resp, err = gw.Route(context.Background(), providers.Request{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("Route without options: %v", err)
+	}
+	if resp.ID != "primary" {
+		t.Fatalf("answered by %q without options, want primary", resp.ID)
+	}
+}
+
+func TestRequestOptions_TargetUnknownFails(t *testing.T) {
+	gw := newOptionsTestGateway(t, ModeFallback)
+
+	ctx := WithRequestOptions(context.Background(), RequestOptions{Target: "missing"})
+	if _, err := gw.Route(ctx, providers.Request{Model: "gpt-4o"}); err == nil {
+		t.Fatal("Route pinned to an unregistered target succeeded")
+	}
+}
+
+func TestRequestOptions_StrategyOverride(t *testing.T) {
+	gw := newOptionsTestGateway(t, ModeSingle)
+	gw.RegisterProvider(&mockProvider{
+		name:   "primary",
+		models: []string{"gpt-4o"},
+		err:    errors.New("primary down"),
+	})
+
+	if _, err := gw.Route(context.Background(), providers.Request{Model: "gpt-4o"}); err == nil {
+		t.Fatal("single strategy succeeded with its only target down")
+	}
+
+	ctx := WithRequestOptions(context.Background(), RequestOptions{Strategy: ModeFallback})
+	resp, err := gw.Route(ctx, providers.Request{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("Route with fallback override: %v", err)
+	}
+	if resp.ID != "secondary" {
+		t.Fatalf("answered by %q, want secondary after falling back", resp.ID)
+	}
+
+	ctx = WithRequestOptions(context.Background(), RequestOptions{Strategy: "no-such-mode"})
+	if _, err := gw.Route(ctx, providers.Request{Model: "gpt-4o"}); err == nil {
+		t.Fatal("Route with an unknown strategy override succeeded")
+	}
+}
+
+func TestRequestOptions_SkipPlugins(t *testing.T) {
+	gw := newOptionsTestGateway(t, ModeSingle)
+	_ = gw.RegisterPlugin(plugin.StageBeforeRequest, &testPlugin{
+		name: "blocker",
+		typ:  plugin.TypeGuardrail,
+		execFn: func(_ context.Context, pctx *plugin.Context) error {
+			pctx.Reject = true
+			pctx.Reason = "blocked"
+			return nil
+		},
+	})
+
+	if _, err := gw.Route(context.Background(), providers.Request{Model: "gpt-4o"}); err == nil {
+		t.Fatal("blocker plugin did not reject the request")
+	}
+	ctx := WithRequestOptions(context.Background(), RequestOptions{SkipPlugins: []string{"blocker"}})
+	if _, err := gw.Route(ctx, providers.Request{Model: "gpt-4o"}); err != nil {
+		t.Fatalf("Route skipping the blocker: %v", err)
+	}
+}
+
+func TestRequestOptions_TimeoutOverridesConfig(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "slow"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&slowCompleteProvider{
+		mockProvider: mockProvider{name: "slow", models: []string{"gpt-4o"}},
+	})
+
+	ctx := WithRequestOptions(context.Background(), RequestOptions{Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	_, err = gw.Route(ctx, providers.Request{Model: "gpt-4o"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Route error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("request took %v — the 50ms option timeout did not bound it", elapsed)
+	}
+}
@@ -0,0 +1,180 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/strategies"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// Data residency enforcement: a request whose API key or workspace carries a
+// residency requirement (Config.Residency) is routed only over the targets
+// whose region satisfies it. A target outside the requirement that a rule or
+// variant names explicitly fails with ErrResidencyUnsatisfied instead of being
+// called, and the registry fallbacks that reach providers outside the target
+// list are skipped, so such a request never leaves its region. The requirement
+// takes precedence over region preference.
+
+// satisfiesResidency reports whether a target in region may serve a request
+// that requires requirement.
+func satisfiesResidency(region, requirement string) bool {
+	return region == requirement || strings.HasPrefix(region, requirement+"-")
+}
+
+// residencyOf returns the residency requirement of ctx's request, or "".
+func (s *routingSnapshot) residencyOf(ctx context.Context) string {
+	if s.residencyConfig == nil {
+		return ""
+	}
+	if keyID, ok := authctx.KeyID(ctx); ok {
+		if requirement := s.residencyConfig.Keys[keyID]; requirement != "" {
+			return requirement
+		}
+	}
+	if id, ok := authctx.Identity(ctx); ok && id.Workspace != "" {
+		return s.residencyConfig.Workspaces[id.Workspace]
+	}
+	return ""
+}
+
+// residencyBound reports whether ctx's request carries a residency
+// requirement, so it must not reach providers outside the target list.
+func (g *Gateway) residencyBound(ctx context.Context) bool {
+	snap, err := g.loadRouting()
+	return err == nil && snap.residencyOf(ctx) != ""
+}
+
+// CheckPassthrough reports whether ctx's request may be forwarded as it is to
+// the provider name, outside routing, as the /v1/* pass-through proxy does.
+// A request bound to a residency requirement may reach only the targets in a
+// satisfying region; any other provider fails with ErrResidencyUnsatisfied.
+func (g *Gateway) CheckPassthrough(ctx context.Context, name string) error {
+	snap, err := g.loadRouting()
+	if err != nil {
+		return err
+	}
+	requirement := snap.residencyOf(ctx)
+	if requirement == "" {
+		return nil
+	}
+	if r := snap.residency[requirement]; r != nil && slices.Contains(r.allowed, name) {
+		return nil
+	}
+	return residencyError(requirement, fmt.Sprintf("provider %s is not a target in that region", name))
+}
+
+// residencyError describes a request its requirement cannot be served under.
+func residencyError(requirement, detail string) error {
+	return fmt.Errorf("%w: the key requires region %q; %s", core.ErrResidencyUnsatisfied, requirement, detail)
+}
+
+// residencyStrategy routes requests bound to one residency requirement.
+type residencyStrategy struct {
+	requirement string
+	// inner is the configured strategy over the satisfying targets, or nil
+	// when no target satisfies the requirement.
+	inner   strategies.Strategy
+	allowed []string
+}
+
+func (r *residencyStrategy) Execute(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	if r.inner == nil {
+		return nil, residencyError(r.requirement, "no configured target is in that region")
+	}
+	return r.inner.Execute(ctx, req)
+}
+
+func (r *residencyStrategy) SelectTargets(req providers.Request) ([]string, error) {
+	return r.SelectTargetsContext(context.Background(), req)
+}
+
+// SelectTargetsContext returns the inner order without the targets outside
+// the requirement.
+func (r *residencyStrategy) SelectTargetsContext(ctx context.Context, req providers.Request) ([]string, error) {
+	if r.inner == nil {
+		return nil, residencyError(r.requirement, "no configured target is in that region")
+	}
+	keys, err := strategies.SelectTargetsContext(ctx, r.inner, req)
+	if err != nil {
+		return nil, err
+	}
+	return r.filter(keys)
+}
+
+// filter drops the keys outside the requirement, failing when none is left.
+func (r *residencyStrategy) filter(keys []string) ([]string, error) {
+	kept := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if slices.Contains(r.allowed, key) {
+			kept = append(kept, key)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, residencyError(r.requirement, "no target in that region serves the request")
+	}
+	return kept, nil
+}
+
+// deniedProvider stands in for a target outside a residency requirement. It
+// embeds the real provider, so model matching is unchanged, but never calls
+// it.
+type deniedProvider struct {
+	providers.Provider
+	key, region, requirement string
+}
+
+func (p *deniedProvider) Complete(context.Context, providers.Request) (*providers.Response, error) {
+	region := p.region
+	if region == "" {
+		region = "none"
+	}
+	return nil, residencyError(p.requirement, fmt.Sprintf("target %s is in region %s", p.key, region))
+}
+
+// buildResidencyStrategiesLocked builds a residencyStrategy for every
+// requirement in the residency config. Caller must hold g.mu.
+func (g *Gateway) buildResidencyStrategiesLocked(lookup strategies.ProviderLookup) (map[string]*residencyStrategy, error) {
+	if g.config.Residency == nil {
+		return nil, nil
+	}
+	built := make(map[string]*residencyStrategy)
+	for _, tags := range []map[string]string{g.config.Residency.Workspaces, g.config.Residency.Keys} {
+		for _, requirement := range tags {
+			if built[requirement] != nil {
+				continue
+			}
+			r := &residencyStrategy{requirement: requirement}
+			regions := make(map[string]string, len(g.config.Targets))
+			var targets []strategies.Target
+			for _, t := range g.config.Targets {
+				regions[t.VirtualKey] = t.Region
+				if satisfiesResidency(t.Region, requirement) {
+					targets = append(targets, strategies.Target{VirtualKey: t.VirtualKey, Weight: t.Weight})
+					r.allowed = append(r.allowed, t.VirtualKey)
+				}
+			}
+			if len(targets) > 0 {
+				allowed := r.allowed
+				restricted := func(name string) (providers.Provider, bool) {
+					p, ok := lookup(name)
+					if !ok || slices.Contains(allowed, name) {
+						return p, ok
+					}
+					return &deniedProvider{Provider: p, key: name, region: regions[name], requirement: requirement}, true
+				}
+				inner, err := g.buildStrategyLocked(targets, restricted)
+				if err != nil {
+					return nil, err
+				}
+				r.inner = inner
+			}
+			built[requirement] = r
+		}
+	}
+	return built, nil
+}
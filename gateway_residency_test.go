@@ -0,0 +1,147 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func inWorkspace(ctx context.Context, workspace string) context.Context {
+	return authctx.WithIdentity(ctx, authctx.KeyIdentity{Workspace: workspace})
+}
+
+func newResidencyGateway(t *testing.T, strategy StrategyConfig) (*Gateway, map[string]int) {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: strategy,
+		Targets: []Target{
+			{VirtualKey: "us-east", Region: "us-east"},
+			{VirtualKey: "global"},
+			{VirtualKey: "eu-west", Region: "eu-west"},
+		},
+		Residency: &ResidencyConfig{
+			Workspaces: map[string]string{"eu-team": "eu", "apac-team": "ap"},
+			Keys:       map[string]string{"key-us": "us"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var mu sync.Mutex
+	calls := map[string]int{}
+	for _, name := range []string{"us-east", "global", "eu-west"} {
+		gw.RegisterProvider(&mockProvider{name: name, models: []string{"gpt-4o"}, completeFn: func(context.Context, providers.Request) (*providers.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[name]++
+			return &providers.Response{ID: name}, nil
+		}})
+	}
+	return gw, calls
+}
+
+func TestGateway_Residency_RoutesWithinRequirement(t *testing.T) {
+	gw, _ := newResidencyGateway(t, StrategyConfig{Mode: ModeFallback})
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"unbound request", context.Background(), "us-east"},
+		{"workspace requirement", inWorkspace(context.Background(), "eu-team"), "eu-west"},
+		{"key overrides workspace", authctx.WithKeyID(inWorkspace(context.Background(), "eu-team"), "key-us"), "us-east"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := gw.Route(tt.ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.ID != tt.want {
+				t.Errorf("Route served by %s, want %s", resp.ID, tt.want)
+			}
+		})
+	}
+
+	ctx := inWorkspace(context.Background(), "eu-team")
+	snap, err := gw.loadRouting()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := snap.strategyFor(ctx).SelectTargets(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keys, []string{"eu-west"}) {
+		t.Errorf("stream order = %v, want only eu-west", keys)
+	}
+	keys, _, err = gw.surfaceTargetOrder(ctx, "gpt-4o", surfaceEmbeddings, models.Usage{PromptTokens: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keys, []string{"eu-west"}) {
+		t.Errorf("surface order = %v, want only eu-west", keys)
+	}
+}
+
+func TestGateway_Residency_FailsClosed(t *testing.T) {
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	t.Run("no target in the region", func(t *testing.T) {
+		gw, calls := newResidencyGateway(t, StrategyConfig{Mode: ModeFallback})
+		ctx := inWorkspace(context.Background(), "apac-team")
+		if _, err := gw.Route(ctx, req); !errors.Is(err, providers.ErrResidencyUnsatisfied) {
+			t.Errorf("Route error = %v, want ErrResidencyUnsatisfied", err)
+		}
+		if _, err := gw.RouteStream(ctx, req); !errors.Is(err, providers.ErrResidencyUnsatisfied) {
+			t.Errorf("RouteStream error = %v, want ErrResidencyUnsatisfied", err)
+		}
+		if _, _, err := gw.surfaceTargetOrder(ctx, "gpt-4o", surfaceEmbeddings, models.Usage{PromptTokens: 1}); !errors.Is(err, providers.ErrResidencyUnsatisfied) {
+			t.Errorf("surfaceTargetOrder error = %v, want ErrResidencyUnsatisfied", err)
+		}
+		for name, n := range calls {
+			if n > 0 {
+				t.Errorf("provider %s was called", name)
+			}
+		}
+	})
+
+	t.Run("rule names a target outside the region", func(t *testing.T) {
+		gw, calls := newResidencyGateway(t, StrategyConfig{
+			Mode:       ModeConditional,
+			Conditions: []Condition{{Key: "model", Value: "gpt-4o", TargetKey: "us-east"}},
+		})
+		_, err := gw.Route(inWorkspace(context.Background(), "eu-team"), req)
+		if !errors.Is(err, providers.ErrResidencyUnsatisfied) {
+			t.Errorf("Route error = %v, want ErrResidencyUnsatisfied", err)
+		}
+		if calls["us-east"] > 0 {
+			t.Error("the out-of-region rule target was called")
+		}
+	})
+}
+
+func TestGateway_CheckPassthrough(t *testing.T) {
+	gw, _ := newResidencyGateway(t, StrategyConfig{Mode: ModeFallback})
+	eu := inWorkspace(context.Background(), "eu-team")
+
+	if err := gw.CheckPassthrough(context.Background(), "us-east"); err != nil {
+		t.Errorf("unbound request: %v", err)
+	}
+	if err := gw.CheckPassthrough(eu, "eu-west"); err != nil {
+		t.Errorf("in-region provider: %v", err)
+	}
+	for _, name := range []string{"us-east", "global", "not-a-target"} {
+		if err := gw.CheckPassthrough(eu, name); !errors.Is(err, providers.ErrResidencyUnsatisfied) {
+			t.Errorf("CheckPassthrough(%s) = %v, want ErrResidencyUnsatisfied", name, err)
+		}
+	}
+}
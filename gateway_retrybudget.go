@@ -0,0 +1,191 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// retryBudgetKey carries a request's *retryLedger.
+type retryBudgetKey struct{}
+
+// retryLedger tracks the output one client request has generated across its
+// attempts, against strategy.retry_budget. It is shared by every attempt the
+// request makes, including a stream attempt abandoned mid-start that is still
+// running, hence the mutex.
+type retryLedger struct {
+	maxTokens int
+	maxCost   float64
+	catalog   models.Catalog
+
+	mu          sync.Mutex
+	spentTokens int
+	spentCost   float64
+}
+
+// withRetryBudget attaches a ledger for cfg to ctx. Like withSeedMode, it
+// leaves ctx untouched when there is no budget.
+func withRetryBudget(ctx context.Context, cfg *RetryBudgetConfig, catalog models.Catalog) context.Context {
+	if cfg == nil || (cfg.MaxOutputTokens <= 0 && cfg.MaxCostUSD <= 0) {
+		return ctx
+	}
+	return context.WithValue(ctx, retryBudgetKey{}, &retryLedger{
+		maxTokens: cfg.MaxOutputTokens,
+		maxCost:   cfg.MaxCostUSD,
+		catalog:   catalog,
+	})
+}
+
+func retryLedgerFrom(ctx context.Context) *retryLedger {
+	l, _ := ctx.Value(retryBudgetKey{}).(*retryLedger)
+	return l
+}
+
+// allowance returns how many output tokens the next attempt against
+// target/model may generate, and false when the budget cannot bound it (a
+// cost-only budget for an unpriced model).
+func (l *retryLedger) allowance(target, model string) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tokens, bounded := 0, false
+	if l.maxTokens > 0 {
+		tokens, bounded = l.maxTokens-l.spentTokens, true
+	}
+	if l.maxCost > 0 {
+		if perToken := l.outputPrice(target, model); perToken > 0 {
+			byCost := int((l.maxCost - l.spentCost) / perToken)
+			if !bounded || byCost < tokens {
+				tokens = byCost
+			}
+			bounded = true
+		}
+	}
+	return max(tokens, 0), bounded
+}
+
+// charge records n output tokens generated by an attempt against
+// target/model.
+func (l *retryLedger) charge(target, model string, n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.spentTokens += n
+	l.spentCost += float64(n) * l.outputPrice(target, model)
+}
+
+// outputPrice returns the catalog's USD price of one output token, or 0.
+func (l *retryLedger) outputPrice(target, model string) float64 {
+	const perM = 1_000_000
+	cost := models.Calculate(l.catalog, target+"/"+model, models.Usage{CompletionTokens: perM})
+	return cost.OutputUSD / perM
+}
+
+// budgetProvider enforces the request's retry budget on every attempt
+// against one target: it refuses the attempt once the budget is spent, caps
+// its max_tokens at what is left, and charges the ledger for what it
+// generated. It passes requests without a budget straight through.
+type budgetProvider struct {
+	providers.Provider
+	name string
+}
+
+// budgetAttempts wraps p when a retry budget is configured, so targets pay
+// nothing otherwise.
+func budgetAttempts(name string, p providers.Provider, enabled bool) providers.Provider {
+	if !enabled {
+		return p
+	}
+	return &budgetProvider{Provider: p, name: name}
+}
+
+func (p *budgetProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	ledger := retryLedgerFrom(ctx)
+	if ledger == nil {
+		return p.Provider.Complete(ctx, req)
+	}
+	req, allowed, err := p.limit(ledger, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.Complete(ctx, req)
+	switch {
+	case err == nil && resp != nil:
+		ledger.charge(p.name, req.Model, resp.Usage.CompletionTokens)
+	case err != nil && mayHaveGenerated(err):
+		// What the failed attempt generated is unknown; charge what it was
+		// allowed, so the budget holds in the worst case.
+		ledger.charge(p.name, req.Model, allowed)
+	}
+	return resp, err
+}
+
+func (p *budgetProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	ledger := retryLedgerFrom(ctx)
+	if ledger == nil {
+		return sp.CompleteStream(ctx, req)
+	}
+	req, allowed, err := p.limit(ledger, req)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := sp.CompleteStream(ctx, req)
+	if err != nil && mayHaveGenerated(err) {
+		ledger.charge(p.name, req.Model, allowed)
+	}
+	// A started stream is never retried, so its own output needs no charge.
+	return ch, err
+}
+
+// limit caps req's output at the ledger's allowance for this target,
+// returning the capped request and the allowance (0 when unbounded).
+func (p *budgetProvider) limit(ledger *retryLedger, req providers.Request) (providers.Request, int, error) {
+	allowed, bounded := ledger.allowance(p.name, req.Model)
+	if !bounded {
+		return req, 0, nil
+	}
+	if allowed <= 0 {
+		return req, 0, fmt.Errorf("%s: %w", p.name, providers.ErrRetryBudgetExhausted)
+	}
+	if req.MaxTokens == nil || *req.MaxTokens > allowed {
+		req.MaxTokens = &allowed
+	} else {
+		allowed = *req.MaxTokens
+	}
+	if req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > allowed {
+		req.MaxCompletionTokens = &allowed
+	}
+	return req, allowed, nil
+}
+
+// mayHaveGenerated reports whether a failed attempt may have produced output
+// before failing. An upstream that answered with an error status, or was never
+// reached, generated nothing; a timeout, a dropped connection, or a response
+// the gateway rejected may have generated all it was allowed.
+func mayHaveGenerated(err error) bool {
+	if errors.Is(err, providers.ErrMalformedResponse) {
+		return true
+	}
+	if providers.ParseStatusCode(err) != 0 ||
+		errors.Is(err, providers.ErrRetryBudgetExhausted) ||
+		errors.Is(err, providers.ErrProviderSaturated) ||
+		errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// budgetTestGateway returns a fallback gateway over "first" and "second"
+// with the given retry budget. first fails with firstErr; second succeeds.
+// Each provider records the max_tokens it was sent, or -1 when unset.
+func budgetTestGateway(t *testing.T, budget *RetryBudgetConfig, firstErr error) (*Gateway, map[string][]int) {
+	t.Helper()
+	return newRecordingGateway(t,
+		Config{Strategy: StrategyConfig{RetryBudget: budget}},
+		func(req providers.Request) int { return intOrUnset(req.MaxTokens) },
+		recordingTarget{name: "first", err: firstErr}, recordingTarget{name: "second"},
+	)
+}
+
+func budgetRequest(maxTokens int) providers.Request {
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	if maxTokens > 0 {
+		req.MaxTokens = &maxTokens
+	}
+	return req
+}
+
+func TestRetryBudget_CapsFallbackAttemptAtRemainingOutput(t *testing.T) {
+	gw, sent := budgetTestGateway(t, &RetryBudgetConfig{MaxOutputTokens: 100}, errors.New("connection reset by peer"))
+
+	if _, err := gw.Route(context.Background(), budgetRequest(80)); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	// The failed attempt may have generated all 80 tokens it was allowed, so
+	// the fallback may generate only the 20 left.
+	if got := sent["first"]; len(got) != 1 || got[0] != 80 {
+		t.Fatalf("first attempt max_tokens = %v, want [80]", got)
+	}
+	if got := sent["second"]; len(got) != 1 || got[0] != 20 {
+		t.Fatalf("fallback max_tokens = %v, want [20]", got)
+	}
+}
+
+func TestRetryBudget_ExhaustedBudgetStopsFallback(t *testing.T) {
+	gw, sent := budgetTestGateway(t, &RetryBudgetConfig{MaxOutputTokens: 100}, fmt.Errorf("read response: %w", context.DeadlineExceeded))
+
+	_, err := gw.Route(context.Background(), budgetRequest(0))
+	if !errors.Is(err, providers.ErrRetryBudgetExhausted) {
+		t.Fatalf("want ErrRetryBudgetExhausted, got %v", err)
+	}
+	if got := sent["first"]; len(got) != 1 || got[0] != 100 {
+		t.Fatalf("first attempt max_tokens = %v, want the whole budget [100]", got)
+	}
+	if got := sent["second"]; len(got) != 0 {
+		t.Fatalf("fallback target called %v after the budget was spent", got)
+	}
+}
+
+func TestRetryBudget_UpstreamErrorStatusIsNotCharged(t *testing.T) {
+	gw, sent := budgetTestGateway(t, &RetryBudgetConfig{MaxOutputTokens: 100}, errors.New("provider API error (503): overloaded"))
+
+	if _, err := gw.Route(context.Background(), budgetRequest(0)); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if got := sent["second"]; len(got) != 1 || got[0] != 100 {
+		t.Fatalf("fallback max_tokens = %v, want the full budget [100]", got)
+	}
+}
+
+func TestRetryBudget_UnsetLeavesRequestsAlone(t *testing.T) {
+	gw, sent := budgetTestGateway(t, nil, errors.New("connection reset by peer"))
+
+	if _, err := gw.Route(context.Background(), budgetRequest(0)); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if got := sent["second"]; len(got) != 1 || got[0] != -1 {
+		t.Fatalf("fallback max_tokens = %v, want unset", got)
+	}
+}
+
+func TestValidateConfig_RetryBudget(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeFallback, RetryBudget: &RetryBudgetConfig{MaxOutputTokens: -1}},
+		Targets:  []Target{{VirtualKey: "openai"}},
+	}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("want an error for a negative retry budget")
+	}
+	cfg.Strategy.RetryBudget = &RetryBudgetConfig{MaxOutputTokens: 4000, MaxCostUSD: 0.5}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid retry budget rejected: %v", err)
+	}
+}
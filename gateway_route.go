@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"runtime/trace"
+	"slices"
 	"time"
 
 	"github.com/ferro-labs/ai-gateway/internal/authctx"
@@ -16,6 +17,8 @@ import (
 	"github.com/ferro-labs/ai-gateway/internal/metrics"
 	"github.com/ferro-labs/ai-gateway/internal/redact"
 	"github.com/ferro-labs/ai-gateway/internal/strategies"
+	"github.com/ferro-labs/ai-gateway/internal/tokens"
+	"github.com/ferro-labs/ai-gateway/internal/upstreamhdr"
 	"github.com/ferro-labs/ai-gateway/models"
 	"github.com/ferro-labs/ai-gateway/observability"
 	"github.com/ferro-labs/ai-gateway/plugin"
@@ -30,8 +33,9 @@ import (
 // runBeforePlugins runs before-request plugins and returns an early response
 // when a plugin (e.g. response-cache) sets Skip=true. It also propagates any
 // request mutations the plugins made. RunAfter is called before returning the
-// early response so logging/metrics plugins still fire.
-func (g *Gateway) runBeforePlugins(ctx context.Context, plugins *plugin.Manager, pctx *plugin.Context, req *providers.Request) (*providers.Response, error) {
+// early response so logging/metrics plugins still fire. start is when the
+// request arrived.
+func (g *Gateway) runBeforePlugins(ctx context.Context, plugins *plugin.Manager, pctx *plugin.Context, req *providers.Request, start time.Time) (*providers.Response, error) {
 	if err := plugins.RunBefore(ctx, pctx); err != nil {
 		return nil, err
 	}
@@ -41,6 +45,7 @@ func (g *Gateway) runBeforePlugins(ctx context.Context, plugins *plugin.Manager,
 	if pctx.Skip && pctx.Response != nil {
 		if err := plugins.RunAfter(ctx, pctx); err != nil {
 			pctx.Error = err
+			setDiagnostics(ctx, pctx, err, time.Since(start))
 			plugins.RunOnError(ctx, pctx)
 			return nil, err
 		}
@@ -95,12 +100,16 @@ func requestDeadline(requestTimeout string) time.Duration {
 	return d
 }
 
-// withRequestDeadline bounds ctx by the configured per-request timeout, tagging
-// the cancellation with ErrRequestTimeout so downstream code can attribute the
-// deadline to the gateway rather than to the caller. It returns ctx untouched,
-// with a no-op cancel, when no timeout is configured.
+// withRequestDeadline bounds ctx by the configured per-request timeout, or the
+// request's RequestOptions.Timeout, tagging the cancellation with
+// ErrRequestTimeout so downstream code can attribute the deadline to the
+// gateway rather than to the caller. It returns ctx untouched, with a no-op
+// cancel, when no timeout applies.
 func withRequestDeadline(ctx context.Context, requestTimeout string) (context.Context, context.CancelFunc) {
 	d := requestDeadline(requestTimeout)
+	if opts, ok := requestOptionsFrom(ctx); ok && opts.Timeout > 0 {
+		d = opts.Timeout
+	}
 	if d <= 0 {
 		return ctx, noopCancel
 	}
@@ -118,6 +127,56 @@ func withUnsupportedParamMode(ctx context.Context, compatMode string) context.Co
 	return ctx
 }
 
+// adaptReasoningParams reshapes req for a reasoning model once its alias is
+// resolved, so plugins and every routing target see the parameters the model
+// actually takes.
+func adaptReasoningParams(ctx context.Context, req *providers.Request) {
+	if dropped := req.AdaptReasoningParams(); len(dropped) > 0 {
+		logging.FromContext(ctx).Debug(
+			"reasoning model does not take request parameter(s); dropping",
+			"model", req.Model,
+			"dropped_params", dropped,
+		)
+	}
+}
+
+// stripReasoningContent returns resp without the reasoning text on its
+// choices. resp itself is left alone, since a cached response shares its
+// choices with every other hit.
+func stripReasoningContent(resp *providers.Response) *providers.Response {
+	if !slices.ContainsFunc(resp.Choices, func(c providers.Choice) bool { return c.Message.ReasoningContent != "" }) {
+		return resp
+	}
+	stripped := *resp
+	stripped.Choices = slices.Clone(resp.Choices)
+	for i := range stripped.Choices {
+		stripped.Choices[i].Message.ReasoningContent = ""
+	}
+	return &stripped
+}
+
+// clientModel returns the model name to report to the client of a request
+// for requested that resolved to resolved: the alias when rewrite is set and
+// requested was one, else "" to report the response's own model.
+func clientModel(rewrite bool, requested, resolved string) string {
+	if !rewrite || requested == resolved {
+		return ""
+	}
+	return requested
+}
+
+// withModel returns resp reporting model, or resp itself when model is "".
+// resp is copied rather than edited, since a cached response is shared with
+// every other hit.
+func withModel(resp *providers.Response, model string) *providers.Response {
+	if model == "" || resp.Model == model {
+		return resp
+	}
+	out := *resp
+	out.Model = model
+	return &out
+}
+
 // Route routes a request to the appropriate provider based on the configuration.
 func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.Response, error) {
 	ctx, task := trace.NewTask(ctx, "gateway.route")
@@ -132,7 +191,24 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 	g.mu.RLock()
 	strategyMode := string(g.config.Strategy.Mode)
 	compatMode := g.config.Compatibility.OnUnsupportedParam
+	seedMode := g.config.Compatibility.OnUnhonoredSeed
+	stripReasoning := g.config.Compatibility.StripReasoningContent
+	rewriteAlias := g.config.Compatibility.RewriteAliasModel
 	requestTimeout := g.config.RequestTimeout
+	retryBudget := g.config.Strategy.RetryBudget
+	budgetCatalog := g.catalog
+	exposeErrors := g.config.ExposeProviderErrors
+	exposeUpstream := g.config.ExposeUpstreamHeaders
+	exposePlugins := g.config.ExposePluginMetadata
+	experiments := g.config.Experiments
+	observeExperiment := g.experimentObserver
+	deadLetters := g.deadLetters
+	tiers := g.config.RateLimitTiers
+	truncationCfg := g.config.ContextTruncation
+	thinkingCfg := g.config.Thinking
+	defaultsCfg := g.config.RequestDefaults
+	imagesCfg := g.config.Images
+	slo := g.sloTrackerForLocked(ctx, req.Model)
 	obs := g.obs
 	obsEventsActive := g.obsEventsActive
 	mcpRegistrySnapshot := g.mcpRegistry
@@ -154,6 +230,15 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 	defer cancelDeadline()
 
 	ctx = withUnsupportedParamMode(ctx, compatMode)
+	ctx = withSeedMode(ctx, seedMode)
+	ctx = withRetryBudget(ctx, retryBudget, budgetCatalog)
+	ctx = withFetchedImages(ctx, req)
+	ctx, upstream := upstreamhdr.WithRecorder(ctx)
+	upstream.SetExposed(exposeUpstream)
+	if plugins.HasPlugins() || deadLetters != nil {
+		ctx = withAttemptLog(ctx)
+	}
+	dlReq := deadLetterRequest(ctx, deadLetters, req)
 	ctx, span := obs.StartRequestSpan(ctx, observability.RequestAttrs{
 		Operation:       "chat",
 		RequestModel:    req.Model,
@@ -164,9 +249,18 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 	defer span.End()
 
 	// Resolve model alias before routing.
+	requestedModel := req.Model
 	trace.WithRegion(ctx, "gateway.route.resolve_alias", func() {
 		req = g.resolveAlias(req)
 	})
+	reportedModel := clientModel(rewriteAlias, requestedModel, req.Model)
+	applyRequestDefaults(ctx, defaultsCfg, &req)
+	capThinking(ctx, thinkingCfg, &req)
+	adaptReasoningParams(ctx, &req)
+	if err := limitImages(ctx, imagesCfg, &req); err != nil {
+		metrics.ForRequest("", g.metricModel(req.Model)).Rejected.Inc()
+		return nil, err
+	}
 
 	// Captured before the agentic MCP loop forces req.Stream = false, and
 	// before any early plugin short-circuit, so hook/observability consumers
@@ -182,17 +276,28 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 	// caller who was entitled to a real stream.
 	callerSentTools := len(req.Tools) > 0
 
-	s, err := g.getStrategy()
+	s, err := g.getStrategy(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// Admit against the caller's rate-limit tier before any plugin or provider
+	// work. Tokens are only tallied for responses the provider produced.
+	admission, err := g.admitTier(ctx, tiers, false, 0)
+	if err != nil {
+		metrics.ForRequest("", g.metricModel(req.Model)).Rejected.Inc()
+		return nil, err
+	}
+
 	// Run before-request plugins (guardrails, transforms, rate-limit).
 	var pctx *plugin.Context
 	if plugins.HasPlugins() {
 		pctx = plugin.NewContext(&req)
 		pctx.Span = span // per-plugin child spans nest under the request span.
 		defer plugin.PutContext(pctx)
+		// Deferred after PutContext so it runs first, on every return:
+		// a rejection's headers reach the client too.
+		defer func() { exposePluginHeaders(ctx, exposePlugins, pctx) }()
 		// Propagate the opaque key identifier so per-key plugins (rate-limit,
 		// budget) can scope limits to the authenticated caller. The raw bearer
 		// secret is never exposed here — only the stable APIKey.ID.
@@ -201,7 +306,7 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 		}
 		var early *providers.Response
 		trace.WithRegion(ctx, "gateway.route.plugins.before", func() {
-			early, err = g.runBeforePlugins(ctx, plugins, pctx, &req)
+			early, err = g.runBeforePlugins(ctx, plugins, pctx, &req, start)
 		})
 		if err != nil {
 			recordPluginAbort(metrics.ForRequest("", g.metricModel(req.Model)), err)
@@ -216,14 +321,24 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 			}
 			earlyLatency := time.Since(start)
 			g.recordSuccess(ctx, span, obs, early, earlyLatency, originalStream, hooksEnabled, obsEventsActive)
+			g.recordSLO(ctx, slo, early.Provider, earlyLatency, nil)
 			early.OverheadMs = float64(earlyLatency.Microseconds()) / 1000.0
-			return early, nil
+			if stripReasoning {
+				early = stripReasoningContent(early)
+			}
+			return withPluginFields(withModel(early, reportedModel), exposePlugins, pctx), nil
 		}
 	}
 
+	// Truncate after the plugins, so guardrails and redaction see, and
+	// rewrite, the turns before any of them are summarized. tryModels fits
+	// the turns to each model it tries.
+	fit := func(req *providers.Request) { g.truncateContext(ctx, truncationCfg, budgetCatalog, req) }
+
 	// Inject MCP tool definitions into the request when servers are ready.
+	// A summary the gateway asks for itself never takes part.
 	var mcpTools []mcp.Tool
-	if mcpRegistrySnapshot != nil {
+	if mcpRegistrySnapshot != nil && !isSummaryRequest(ctx) {
 		mcpTools = mcpRegistrySnapshot.AllTools()
 	}
 
@@ -267,15 +382,24 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 	var providerDuration time.Duration
 	providerStart := time.Now()
 	trace.WithRegion(ctx, "gateway.route.provider.execute", func() {
-		resp, err = s.Execute(ctx, req)
+		err = g.tryModels(ctx, &req, fit, func(req providers.Request) (err error) {
+			resp, err = s.Execute(ctx, req)
+			return err
+		})
 	})
 	providerDuration += time.Since(providerStart)
 	latency := time.Since(start)
 
 	if err != nil {
+		err = exposeProviderBody(err, exposeErrors)
 		g.routeError(ctx, span, obs, pctx, plugins, "", req.Model, err, latency, originalStream, hooksEnabled, obsEventsActive)
+		g.recordSLO(ctx, slo, "", latency, err)
+		writeDeadLetter(ctx, deadLetters, dlReq, strategyMode, err, latency)
 		return nil, err
 	}
+	if len(experiments) > 0 && !mcpActive && !isSummaryRequest(ctx) {
+		g.shadowExperiments(ctx, s, tiers, experiments, observeExperiment, req, resp)
+	}
 
 	// Ensure OpenAI-compatible envelope fields are always set.
 	if resp.Object == "" {
@@ -299,10 +423,23 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 		resp, loopDuration, loopProvider, err = g.runMCPLoop(ctx, mcpExecutorSnapshot, s, &req, resp)
 		providerDuration += loopDuration
 		if err != nil {
+			err = exposeProviderBody(err, exposeErrors)
 			g.routeError(ctx, span, obs, pctx, plugins, loopProvider, req.Model, err, time.Since(start), originalStream, hooksEnabled, obsEventsActive)
+			g.recordSLO(ctx, slo, loopProvider, time.Since(start), err)
 			return nil, err
 		}
 	}
+	// A provider that reported no usage would otherwise be accounted as free:
+	// estimate it, marked so cost consumers know it is approximate.
+	if tokens.Missing(resp.Usage) {
+		resp.Usage = tokens.Estimate(tokens.Prompt(req), resp)
+	}
+	// Likewise a provider that counts thinking among the completion tokens
+	// without reporting it separately.
+	if resp.Usage.ReasoningTokens == 0 {
+		resp.Usage.ReasoningTokens = tokens.Reasoning(resp, resp.Usage.CompletionTokens)
+	}
+
 	// originalStream is included in the completed event so hook consumers
 	// can distinguish streaming vs non-streaming requests (Phase 1.5 note:
 	// when final-response streaming lands, remove the force-to-false above).
@@ -310,12 +447,18 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 	// Run after-request plugins (logging, caching).
 	if pctx != nil {
 		pctx.Response = resp
+		g.mu.RLock()
+		catalog := g.catalog
+		g.mu.RUnlock()
+		recordUpstreamHeaders(upstream, pctx)
+		pctx.Metadata["cost_usd"] = responseCost(catalog, resp).TotalUSD
 		trace.WithRegion(ctx, "gateway.route.plugins.after", func() {
 			err = plugins.RunAfter(ctx, pctx)
 		})
 		if err != nil {
 			recordPluginAbort(metrics.ForRequest(resp.Provider, resp.Model), err)
 			pctx.Error = err
+			setDiagnostics(ctx, pctx, err, time.Since(start))
 			plugins.RunOnError(ctx, pctx)
 			return nil, err
 		}
@@ -330,10 +473,15 @@ func (g *Gateway) Route(ctx context.Context, req providers.Request) (*providers.
 	// accumulated providerDuration so OverheadMs stays non-negative.
 	latency = time.Since(start)
 	g.recordSuccess(ctx, span, obs, resp, latency, originalStream, hooksEnabled, obsEventsActive)
+	g.recordSLO(ctx, slo, resp.Provider, latency, nil)
+	admission.done(resp.Usage.TotalTokens)
 
 	resp.OverheadMs = float64((latency - providerDuration).Microseconds()) / 1000.0
+	if stripReasoning {
+		resp = stripReasoningContent(resp)
+	}
 
-	return resp, nil
+	return withPluginFields(withModel(resp, reportedModel), exposePlugins, pctx), nil
 }
 
 // metricModel bounds the Prometheus "model" label. Client-supplied model names
@@ -409,6 +557,16 @@ func (g *Gateway) dispatchRequestEvent(ctx context.Context, obs observability.Pr
 	}
 }
 
+// exposeProviderBody attaches a failed provider call's raw response body to
+// err when expose_provider_errors is on, so it reaches the client and the
+// request log. Both redact it.
+func exposeProviderBody(err error, enabled bool) error {
+	if !enabled {
+		return err
+	}
+	return providers.WithProviderBody(err)
+}
+
 // routeError finalizes a failed Route call: runs plugin error hooks, records
 // error metrics, stamps the span with the error, logs the failure, and
 // dispatches the failed lifecycle event. Shared by the initial provider call
@@ -416,7 +574,9 @@ func (g *Gateway) dispatchRequestEvent(ctx context.Context, obs observability.Pr
 // stay in sync.
 func (g *Gateway) routeError(ctx context.Context, span observability.Span, obs observability.Provider, pctx *plugin.Context, plugins *plugin.Manager, provider, model string, err error, latency time.Duration, originalStream, hooksEnabled, obsEventsActive bool) {
 	if pctx != nil {
+		recordUpstreamHeaders(upstreamhdr.FromContext(ctx), pctx)
 		pctx.Error = err
+		setDiagnostics(ctx, pctx, err, latency)
 		plugins.RunOnError(ctx, pctx)
 	}
 
@@ -450,6 +610,17 @@ func (g *Gateway) routeError(ctx context.Context, span observability.Span, obs o
 	}
 }
 
+// responseCost prices resp's usage against catalog.
+func responseCost(catalog models.Catalog, resp *providers.Response) models.CostResult {
+	return models.Calculate(catalog, resp.Provider+"/"+resp.Model, models.Usage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		ReasoningTokens:  resp.Usage.ReasoningTokens,
+		CacheReadTokens:  resp.Usage.CacheReadTokens,
+		CacheWriteTokens: resp.Usage.CacheWriteTokens,
+	})
+}
+
 // recordSuccess emits Prometheus + cost metrics, stamps the root span with the
 // resolved provider/model/usage/cost, logs at debug level, and dispatches the
 // completed lifecycle event.
@@ -463,13 +634,7 @@ func (g *Gateway) recordSuccess(ctx context.Context, span observability.Span, ob
 	g.mu.RLock()
 	catalog := g.catalog
 	g.mu.RUnlock()
-	cost := models.Calculate(catalog, resp.Provider+"/"+resp.Model, models.Usage{
-		PromptTokens:     resp.Usage.PromptTokens,
-		CompletionTokens: resp.Usage.CompletionTokens,
-		ReasoningTokens:  resp.Usage.ReasoningTokens,
-		CacheReadTokens:  resp.Usage.CacheReadTokens,
-		CacheWriteTokens: resp.Usage.CacheWriteTokens,
-	})
+	cost := responseCost(catalog, resp)
 	if cost.TotalUSD > 0 {
 		requestMetrics.CostUSD.Add(cost.TotalUSD)
 	}
@@ -514,6 +679,9 @@ func (g *Gateway) recordSuccess(ctx context.Context, span observability.Span, ob
 			resp.Usage.CompletionTokens,
 			cost,
 		)
+		if resp.Cache != nil {
+			he.CacheStatus = resp.Cache.Status
+		}
 		g.dispatchRequestEvent(ctx, obs, hooksEnabled, obsEventsActive, he)
 	}
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ferro-labs/ai-gateway/models"
 	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
 )
 
 // TestGateway_RouteStreamMatchesRouteTargetOrder asserts Route (Strategy.Execute) and
@@ -259,6 +260,43 @@ func TestGateway_Route_Fallback(t *testing.T) {
 	}
 }
 
+func TestGateway_Route_BackfillsMissingUsage(t *testing.T) {
+	gw, _ := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "quiet"}},
+	})
+	reported := providers.Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}
+	var usage providers.Usage
+	gw.RegisterProvider(&mockProvider{
+		name:   "quiet",
+		models: []string{"gpt-4o"},
+		completeFn: func(context.Context, providers.Request) (*providers.Response, error) {
+			return &providers.Response{
+				Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "hello world"}}},
+				Usage:   usage,
+			}, nil
+		},
+	})
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	resp, err := gw.Route(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if u := resp.Usage; !u.Estimated || u.PromptTokens == 0 || u.CompletionTokens != 4 || u.TotalTokens != u.PromptTokens+u.CompletionTokens {
+		t.Fatalf("usage = %+v, want an estimate marked Estimated", u)
+	}
+
+	usage = reported
+	resp, err = gw.Route(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.Usage != reported {
+		t.Fatalf("usage = %+v, want the provider's %+v untouched", resp.Usage, reported)
+	}
+}
+
 func TestGateway_Route_CostOptimizedPassesUnpricedStrategy(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -348,3 +386,36 @@ func TestGateway_Route_NoTargets(t *testing.T) {
 		t.Fatal("expected error for no targets")
 	}
 }
+
+func TestGateway_Route_ExposeProviderErrors(t *testing.T) {
+	body := `{"error":{"message":"Invalid value","param":"temperature"}}`
+	for _, expose := range []bool{false, true} {
+		gw, err := newTestGateway(t, Config{
+			Strategy:             StrategyConfig{Mode: ModeSingle},
+			Targets:              []Target{{VirtualKey: "openai"}},
+			ExposeProviderErrors: expose,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gw.RegisterProvider(&mockProvider{
+			name:   "openai",
+			models: []string{"gpt-4o"},
+			err:    core.APIError("openai", 400, []byte(body)),
+		})
+
+		_, err = gw.Route(context.Background(), providers.Request{
+			Model:    "gpt-4o",
+			Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		})
+		if err == nil {
+			t.Fatal("want the provider error")
+		}
+		if got := strings.Contains(err.Error(), `"param":"temperature"`); got != expose {
+			t.Fatalf("expose_provider_errors=%v: raw body in error = %v (%v)", expose, got, err)
+		}
+		if providers.ParseStatusCode(err) != 400 {
+			t.Fatalf("status lost: %v", err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// defaultOutcomeWindow is the number of recent upstream outcomes kept per
+// target for RoutingState's error rate, matching the latency tracker's window.
+const defaultOutcomeWindow = 100
+
+// RoutingState is a point-in-time view of how the gateway routes requests:
+// the strategy mode and, for every configured target, its weight, circuit
+// breaker state, and recent latency and error rate. It backs the admin API's
+// GET /admin/routing/state and the dashboard's routing page.
+type RoutingState struct {
+	Strategy StrategyMode         `json:"strategy"`
+	Targets  []TargetRoutingState `json:"targets"`
+}
+
+// TargetRoutingState is one target's entry in RoutingState.
+type TargetRoutingState struct {
+	VirtualKey string `json:"virtual_key"`
+	// Region is the target's configured region, empty for one local to every
+	// region.
+	Region string `json:"region,omitempty"`
+	// Registered is false when no provider is registered under the virtual
+	// key, so the target can never be selected.
+	Registered bool `json:"registered"`
+	// Weight is the configured weight; WeightShare is its fraction of the
+	// total, with unset weights counting as 1 as the load balancer treats
+	// them. The share only drives selection in loadbalance mode.
+	Weight      float64 `json:"weight"`
+	WeightShare float64 `json:"weight_share"`
+	// CircuitBreaker is "closed", "open", or "half_open", and empty when the
+	// target configures no breaker.
+	CircuitBreaker string `json:"circuit_breaker,omitempty"`
+	// LatencyP50Ms is the median latency of recent successful requests, or
+	// zero before the first one.
+	LatencyP50Ms float64 `json:"latency_p50_ms"`
+	// RecentRequests and RecentErrors cover the last upstream calls to the
+	// target (up to 100), counting only failures that would also count
+	// against its circuit breaker: caller cancellations, rate limits, and
+	// gateway-side rejections are left out.
+	RecentRequests int     `json:"recent_requests"`
+	RecentErrors   int     `json:"recent_errors"`
+	ErrorRate      float64 `json:"error_rate"`
+	// ThrottleFactor scales the target's load-balancing weight after it
+	// answers 429: 1 when it is not throttled, halved by each rate-limit
+	// response down to 1/16, and recovering once its Retry-After passes.
+	ThrottleFactor float64 `json:"throttle_factor"`
+}
+
+// RoutingState returns the current routing state. The breaker states and
+// rolling statistics are read live, so consecutive calls may differ even when
+// the config does not.
+func (g *Gateway) RoutingState() RoutingState {
+	g.mu.RLock()
+	mode := g.config.Strategy.Mode
+	targets := make([]TargetRoutingState, len(g.config.Targets))
+	totalWeight := 0.0
+	for i, t := range g.config.Targets {
+		_, registered := g.providers[t.VirtualKey]
+		targets[i] = TargetRoutingState{
+			VirtualKey: t.VirtualKey,
+			Region:     t.Region,
+			Registered: registered,
+			Weight:     t.Weight,
+		}
+		if cb := g.circuitBreakers[t.VirtualKey]; cb != nil {
+			targets[i].CircuitBreaker = cb.State().String()
+		}
+		totalWeight += surfaceWeight(t.Weight)
+	}
+	g.mu.RUnlock()
+
+	for i := range targets {
+		t := &targets[i]
+		if totalWeight > 0 {
+			t.WeightShare = surfaceWeight(t.Weight) / totalWeight
+		}
+		if g.latencyTracker != nil {
+			t.LatencyP50Ms = float64(g.latencyTracker.P50(t.VirtualKey).Microseconds()) / 1000
+		}
+		t.RecentRequests, t.RecentErrors = g.outcomes.counts(t.VirtualKey)
+		t.ThrottleFactor = g.outcomes.throttleFactor(t.VirtualKey)
+		if t.RecentRequests > 0 {
+			t.ErrorRate = float64(t.RecentErrors) / float64(t.RecentRequests)
+		}
+	}
+	return RoutingState{Strategy: mode, Targets: targets}
+}
+
+// outcomeTracker keeps each target's most recent upstream outcomes in a
+// fixed-size ring. A nil tracker records nothing, so gateways built as
+// struct literals in tests need not set one.
+type outcomeTracker struct {
+	mu        sync.Mutex
+	windows   map[string]*outcomeWindow
+	throttles map[string]*throttleState
+	size      int
+	// now overrides time.Now in tests.
+	now func() time.Time
+}
+
+type outcomeWindow struct {
+	failed []bool
+	next   int
+	errors int
+}
+
+func newOutcomeTracker(size int) *outcomeTracker {
+	if size <= 0 {
+		size = defaultOutcomeWindow
+	}
+	return &outcomeTracker{
+		windows:   make(map[string]*outcomeWindow),
+		throttles: make(map[string]*throttleState),
+		size:      size,
+	}
+}
+
+func (t *outcomeTracker) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// record adds the outcome of one upstream call to target. Errors that are not
+// the provider's fault are skipped, by the same rule the circuit breaker uses;
+// of those, a 429 throttles the target instead (see throttleLocked).
+func (t *outcomeTracker) record(ctx context.Context, target string, err error) {
+	if t == nil || target == "" {
+		return
+	}
+	if err != nil && isRateLimitError(err) {
+		t.mu.Lock()
+		t.throttleLocked(target, providers.RetryAfterFrom(err), t.clock())
+		t.mu.Unlock()
+		return
+	}
+	if err != nil && !shouldRecordCircuitBreakerFailure(ctx, err) {
+		return
+	}
+	failed := err != nil
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := t.windows[target]
+	if w == nil {
+		w = &outcomeWindow{failed: make([]bool, 0, t.size)}
+		t.windows[target] = w
+	}
+	if len(w.failed) < t.size {
+		w.failed = append(w.failed, failed)
+	} else {
+		if w.failed[w.next] {
+			w.errors--
+		}
+		w.failed[w.next] = failed
+		w.next = (w.next + 1) % t.size
+	}
+	if failed {
+		w.errors++
+	}
+}
+
+// counts returns the number of outcomes in target's window and how many of
+// them failed.
+func (t *outcomeTracker) counts(target string) (requests, errors int) {
+	if t == nil {
+		return 0, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := t.windows[target]
+	if w == nil {
+		return 0, 0
+	}
+	return len(w.failed), w.errors
+}
+
+// outcomeProvider records each call's outcome for RoutingState. It sits just
+// inside the circuit breaker, so a call the open breaker rejects is not
+// counted as an upstream error. A stream is counted once it fails to start
+// here; one that starts is counted when it finishes, by RouteStream.
+type outcomeProvider struct {
+	providers.Provider
+	outcomes *outcomeTracker
+	name     string
+}
+
+func (p *outcomeProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	p.outcomes.record(ctx, p.name, err)
+	return resp, err
+}
+
+func (p *outcomeProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	ch, err := sp.CompleteStream(ctx, req)
+	if err != nil {
+		p.outcomes.record(ctx, p.name, err)
+	}
+	return ch, err
+}
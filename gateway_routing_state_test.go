@@ -0,0 +1,116 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestGateway_RoutingState(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets: []Target{
+			{VirtualKey: "flaky", Weight: 3, CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 1}},
+			{VirtualKey: "steady", Weight: 1},
+			{VirtualKey: "missing"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockProvider{name: "flaky", models: []string{"gpt-4o"}, err: errors.New("upstream 500")})
+	gw.RegisterProvider(&mockProvider{name: "steady", models: []string{"gpt-4o"}, resp: &providers.Response{Provider: "steady"}})
+
+	if _, err := gw.Route(context.Background(), providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+	}); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+
+	state := gw.RoutingState()
+	if state.Strategy != ModeFallback || len(state.Targets) != 3 {
+		t.Fatalf("state = %+v", state)
+	}
+	flaky, steady, missing := state.Targets[0], state.Targets[1], state.Targets[2]
+	if flaky.CircuitBreaker != "open" || flaky.RecentRequests != 1 || flaky.RecentErrors != 1 || flaky.ErrorRate != 1 {
+		t.Errorf("flaky = %+v", flaky)
+	}
+	if steady.CircuitBreaker != "" || steady.RecentRequests != 1 || steady.RecentErrors != 0 || !steady.Registered {
+		t.Errorf("steady = %+v", steady)
+	}
+	if missing.Registered || missing.RecentRequests != 0 {
+		t.Errorf("missing = %+v", missing)
+	}
+	// An unset weight counts as 1: 3 / (3 + 1 + 1).
+	if flaky.WeightShare != 0.6 || missing.WeightShare != 0.2 {
+		t.Errorf("weight shares = %v, %v, %v", flaky.WeightShare, steady.WeightShare, missing.WeightShare)
+	}
+}
+
+func TestGateway_RoutingState_CountsStreamOutcomes(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "stream"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: "stream", models: []string{"gpt-4o"}},
+		streamFn: func(context.Context, providers.Request) (<-chan providers.StreamChunk, error) {
+			ch := make(chan providers.StreamChunk, 1)
+			ch <- providers.StreamChunk{Error: errors.New("connection reset")}
+			close(ch)
+			return ch, nil
+		},
+	})
+
+	ch, err := gw.RouteStream(context.Background(), streamTestRequest())
+	if err != nil {
+		t.Fatalf("RouteStream: %v", err)
+	}
+	//nolint:revive // empty-block: draining the stream is what finishes it
+	for range ch {
+	}
+
+	target := gw.RoutingState().Targets[0]
+	if target.RecentRequests != 1 || target.RecentErrors != 1 {
+		t.Fatalf("stream target = %+v, want one failed call", target)
+	}
+}
+
+func TestOutcomeTracker_Window(t *testing.T) {
+	tracker := newOutcomeTracker(3)
+	ctx := context.Background()
+	failure := errors.New("upstream 500")
+
+	tracker.record(ctx, "a", failure)
+	tracker.record(ctx, "a", nil)
+	tracker.record(ctx, "a", failure)
+	if requests, errs := tracker.counts("a"); requests != 3 || errs != 2 {
+		t.Fatalf("counts = %d, %d; want 3, 2", requests, errs)
+	}
+
+	// The oldest outcome, a failure, drops out of the window.
+	tracker.record(ctx, "a", nil)
+	if requests, errs := tracker.counts("a"); requests != 3 || errs != 1 {
+		t.Fatalf("counts = %d, %d; want 3, 1", requests, errs)
+	}
+
+	// Failures that are not the provider's fault are not counted.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	tracker.record(canceled, "a", context.Canceled)
+	if requests, _ := tracker.counts("a"); requests != 3 {
+		t.Fatalf("canceled call changed the window")
+	}
+
+	var nilTracker *outcomeTracker
+	nilTracker.record(ctx, "a", failure)
+	if requests, errs := nilTracker.counts("a"); requests != 0 || errs != 0 {
+		t.Fatalf("nil tracker counts = %d, %d", requests, errs)
+	}
+}
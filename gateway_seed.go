@@ -0,0 +1,73 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// Values of compatibility.on_unhonored_seed. An empty value means warn.
+const (
+	seedModeWarn   = "warn"
+	seedModeReject = "reject"
+)
+
+// rejectUnhonoredSeedKey marks a request context whose seeded requests must be
+// refused by providers that cannot honour the seed.
+type rejectUnhonoredSeedKey struct{}
+
+// withSeedMode carries compatibility.on_unhonored_seed to seedProvider via
+// ctx. Like withUnsupportedParamMode, it leaves ctx untouched for the default
+// mode.
+func withSeedMode(ctx context.Context, mode string) context.Context {
+	if mode == seedModeReject {
+		return context.WithValue(ctx, rejectUnhonoredSeedKey{}, true)
+	}
+	return ctx
+}
+
+// seedProvider guards a provider that cannot honour seed: a seeded request is
+// logged and forwarded, or refused before it leaves the gateway when the
+// context asks for rejection. It wraps only such providers, so deterministic
+// targets pay nothing. The rejection is an UnsupportedParamError, which the
+// HTTP layer maps to 400.
+type seedProvider struct {
+	providers.Provider
+	name string
+}
+
+func (p *seedProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	if err := p.check(ctx, req); err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *seedProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	if err := p.check(ctx, req); err != nil {
+		return nil, err
+	}
+	return sp.CompleteStream(ctx, req)
+}
+
+func (p *seedProvider) check(ctx context.Context, req providers.Request) error {
+	if req.Seed == nil {
+		return nil
+	}
+	if reject, _ := ctx.Value(rejectUnhonoredSeedKey{}).(bool); reject {
+		return core.NewUnsupportedParamError(p.name, []string{"seed"})
+	}
+	logging.FromContext(ctx).Warn(
+		"provider does not honor seed; output may not be reproducible",
+		"provider", p.name,
+		"model", req.Model,
+	)
+	return nil
+}
@@ -0,0 +1,100 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func seededRequest() providers.Request {
+	seed := int64(42)
+	return providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		Seed:     &seed,
+	}
+}
+
+func newSeedTestGateway(t *testing.T, mode string) (*Gateway, *int) {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy:      StrategyConfig{Mode: ModeFallback},
+		Targets:       []Target{{VirtualKey: mockProviderName}, {VirtualKey: "openai"}},
+		Compatibility: CompatibilityConfig{OnUnhonoredSeed: mode},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var mockCalls int
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		completeFn: func(context.Context, providers.Request) (*providers.Response, error) {
+			mockCalls++
+			return &providers.Response{ID: "from-mock", Model: "gpt-4o"}, nil
+		},
+	})
+	gw.RegisterProvider(&mockProvider{
+		name:   "openai",
+		models: []string{"gpt-4o"},
+		resp:   &providers.Response{ID: "from-openai", Model: "gpt-4o", SystemFingerprint: "fp_1"},
+	})
+	return gw, &mockCalls
+}
+
+func TestGateway_UnhonoredSeedWarnForwards(t *testing.T) {
+	gw, mockCalls := newSeedTestGateway(t, "")
+
+	resp, err := gw.Route(context.Background(), seededRequest())
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "from-mock" || *mockCalls != 1 {
+		t.Fatalf("warn mode should forward to the first target, got %q", resp.ID)
+	}
+}
+
+func TestGateway_UnhonoredSeedRejectFallsBack(t *testing.T) {
+	gw, mockCalls := newSeedTestGateway(t, seedModeReject)
+
+	resp, err := gw.Route(context.Background(), seededRequest())
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "from-openai" || *mockCalls != 0 {
+		t.Fatalf("seeded request should skip the provider that cannot honor it, got %q (mock calls %d)", resp.ID, *mockCalls)
+	}
+	if resp.SystemFingerprint != "fp_1" {
+		t.Fatalf("system_fingerprint = %q, want fp_1", resp.SystemFingerprint)
+	}
+
+	// Unseeded requests are unaffected by the mode.
+	req := seededRequest()
+	req.Seed = nil
+	resp, err = gw.Route(context.Background(), req)
+	if err != nil || resp.ID != "from-mock" {
+		t.Fatalf("unseeded request: resp %+v, err %v", resp, err)
+	}
+}
+
+func TestGateway_UnhonoredSeedRejectStream(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy:      StrategyConfig{Mode: ModeSingle},
+		Targets:       []Target{{VirtualKey: mockProviderName}},
+		Compatibility: CompatibilityConfig{OnUnhonoredSeed: seedModeReject},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{mockProvider: mockProvider{name: mockProviderName, models: []string{"gpt-4o"}}})
+
+	req := seededRequest()
+	req.Stream = true
+	_, err = gw.RouteStream(context.Background(), req)
+	var unsupported *providers.UnsupportedParamError
+	if !errors.As(err, &unsupported) || unsupported.Params[0] != "seed" {
+		t.Fatalf("RouteStream error = %v, want an UnsupportedParamError naming seed", err)
+	}
+}
@@ -0,0 +1,347 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/events"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/observability"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// SLO tracking: each configured SLO (Config.SLOs) counts the outcomes of the
+// requests for its model name in a ring of buckets spanning its window. Every
+// recorded outcome, and every tick of sloTickInterval, refreshes the
+// attainment and burn-rate gauges, and a burn rate that reaches or leaves the
+// threshold publishes an event, which a hook can act on — for example by
+// draining the provider named in it through the admin API. The tick lets a
+// window slide, and an alert recover, while the model gets no traffic.
+
+// SLO indicators, used as the indicator metric label and in SLO events.
+const (
+	sloLatency = "latency"
+	sloErrors  = "error"
+)
+
+// sloBuckets is how many buckets a window is divided into; the window slides
+// one bucket at a time.
+const sloBuckets = 60
+
+// sloTickInterval is how often every tracker is re-evaluated without a new
+// outcome.
+const sloTickInterval = 5 * time.Second
+
+type sloCounts struct {
+	ok, failed, slow int
+}
+
+type sloBucket struct {
+	// index is the bucket's position in time, in bucket widths since the
+	// epoch; a slot holding an older index is stale.
+	index int64
+	sloCounts
+}
+
+// sloTracker tracks one SLO.
+type sloTracker struct {
+	name      string
+	cfg       SLOConfig
+	latency   time.Duration
+	window    time.Duration
+	threshold float64
+	minReqs   int
+	now       func() time.Time
+
+	mu       sync.Mutex
+	buckets  [sloBuckets]sloBucket
+	alerting map[string]bool
+}
+
+func newSLOTracker(name string, cfg SLOConfig) *sloTracker {
+	t := &sloTracker{
+		name:      name,
+		cfg:       cfg,
+		latency:   cfg.LatencyDuration(),
+		window:    cfg.WindowDuration(),
+		threshold: cfg.BurnRateThreshold,
+		minReqs:   cfg.MinRequests,
+		now:       time.Now,
+		alerting:  make(map[string]bool),
+	}
+	if t.threshold == 0 {
+		t.threshold = DefaultSLOBurnRateThreshold
+	}
+	if t.minReqs == 0 {
+		t.minReqs = DefaultSLOMinRequests
+	}
+	return t
+}
+
+// record counts one request outcome and returns the threshold crossings it
+// caused, with the subject to publish each under.
+func (t *sloTracker) record(latency time.Duration, failed bool) []sloCrossing {
+	index := t.bucketIndex()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := &t.buckets[index%sloBuckets]
+	if b.index != index {
+		*b = sloBucket{index: index}
+	}
+	switch {
+	case failed:
+		b.failed++
+	case t.latency > 0 && latency > t.latency:
+		b.ok++
+		b.slow++
+	default:
+		b.ok++
+	}
+	return t.evaluateWindow(index)
+}
+
+// tick re-evaluates the window as of now, without an outcome, and returns the
+// threshold crossings its sliding caused.
+func (t *sloTracker) tick() []sloCrossing {
+	index := t.bucketIndex()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.evaluateWindow(index)
+}
+
+// bucketIndex returns the position in time of the current bucket.
+func (t *sloTracker) bucketIndex() int64 {
+	return t.now().UnixNano() / int64(t.window/sloBuckets)
+}
+
+// evaluateWindow totals the buckets of the window ending at index and
+// evaluates each indicator over them. t.mu must be held.
+func (t *sloTracker) evaluateWindow(index int64) []sloCrossing {
+	var total sloCounts
+	for _, b := range t.buckets {
+		if index-b.index < sloBuckets {
+			total.ok += b.ok
+			total.failed += b.failed
+			total.slow += b.slow
+		}
+	}
+
+	var crossings []sloCrossing
+	if t.cfg.ErrorObjective > 0 {
+		crossings = t.evaluate(crossings, sloErrors, t.cfg.ErrorObjective, total.failed, total.ok+total.failed)
+	}
+	if t.latency > 0 {
+		crossings = t.evaluate(crossings, sloLatency, t.cfg.LatencyObjective, total.slow, total.ok)
+	}
+	return crossings
+}
+
+// evaluate refreshes one indicator's gauges from bad of requests and appends
+// its threshold crossing, if any. A window with no requests burns nothing, so
+// it recovers an alerting indicator and otherwise leaves the gauges as they
+// are. t.mu must be held.
+func (t *sloTracker) evaluate(crossings []sloCrossing, indicator string, objective float64, bad, requests int) []sloCrossing {
+	if requests == 0 && !t.alerting[indicator] {
+		return crossings
+	}
+	var badShare float64
+	if requests > 0 {
+		badShare = float64(bad) / float64(requests)
+	}
+	burn := badShare / (1 - objective)
+	metrics.SLOAttainment.WithLabelValues(t.name, indicator).Set(1 - badShare)
+	metrics.SLOBurnRate.WithLabelValues(t.name, indicator).Set(burn)
+
+	var subject string
+	switch {
+	case !t.alerting[indicator] && burn >= t.threshold && requests >= t.minReqs:
+		subject = SubjectSLOBurnRateExceeded
+	case t.alerting[indicator] && burn < t.threshold:
+		subject = SubjectSLOBurnRateRecovered
+	default:
+		return crossings
+	}
+	t.alerting[indicator] = subject == SubjectSLOBurnRateExceeded
+	return append(crossings, sloCrossing{subject: subject, burn: events.SLOBurn{
+		Name:       t.name,
+		Indicator:  indicator,
+		BurnRate:   burn,
+		Threshold:  t.threshold,
+		Attainment: 1 - badShare,
+		Objective:  objective,
+		Window:     t.window,
+		Requests:   requests,
+	}})
+}
+
+// forget drops the tracker's gauges, once it is no longer configured.
+func (t *sloTracker) forget() {
+	for _, indicator := range []string{sloLatency, sloErrors} {
+		metrics.SLOAttainment.DeleteLabelValues(t.name, indicator)
+		metrics.SLOBurnRate.DeleteLabelValues(t.name, indicator)
+	}
+}
+
+type sloCrossing struct {
+	subject string
+	burn    events.SLOBurn
+}
+
+// ensureSLOTrackersLocked rebuilds g.slos from the config, keeping the
+// tracker, and so the window, of every SLO whose config is unchanged. Caller
+// must hold g.mu.
+func (g *Gateway) ensureSLOTrackersLocked() {
+	if len(g.config.SLOs) == 0 && len(g.slos) == 0 {
+		return
+	}
+	trackers := make(map[string]*sloTracker, len(g.config.SLOs))
+	for name, cfg := range g.config.SLOs {
+		if t, ok := g.slos[name]; ok && t.cfg == cfg {
+			trackers[name] = t
+			continue
+		}
+		trackers[name] = newSLOTracker(name, cfg)
+	}
+	for name, t := range g.slos {
+		if trackers[name] != t {
+			t.forget()
+		}
+	}
+	g.slos = trackers
+}
+
+// sloCountsFailure reports whether err counts against the error indicator,
+// and whether the request counts at all: client cancellations and client
+// errors are the caller's doing, not the gateway's or the provider's.
+func sloCountsFailure(err error) (failed, counted bool) {
+	if err == nil {
+		return false, true
+	}
+	if errors.Is(err, context.Canceled) {
+		return false, false
+	}
+	switch status := providers.ParseStatusCode(err); {
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+	case status >= 400 && status < 500:
+		return false, false
+	}
+	return true, true
+}
+
+// recordSLO records the outcome of one request against t, and publishes the
+// threshold crossings it causes. provider is the provider that served or
+// failed the request, when known. A nil t records nothing.
+func (g *Gateway) recordSLO(ctx context.Context, t *sloTracker, provider string, latency time.Duration, err error) {
+	if t == nil {
+		return
+	}
+	failed, counted := sloCountsFailure(err)
+	if !counted {
+		return
+	}
+	// The request may already be over; the event must not die with it.
+	g.publishSLOCrossings(context.WithoutCancel(ctx), provider, t.record(latency, failed))
+}
+
+// startSLOTicker re-evaluates every SLO tracker each sloTickInterval until
+// the gateway shuts down, so a window slides, and an alert recovers, without
+// new traffic.
+func (g *Gateway) startSLOTicker() {
+	g.sloTickDone.Add(1)
+	go func() {
+		defer g.sloTickDone.Done()
+		ticker := time.NewTicker(sloTickInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-g.shutdownCtx.Done():
+				return
+			case <-ticker.C:
+				g.tickSLOs(g.shutdownCtx)
+			}
+		}
+	}()
+}
+
+// tickSLOs re-evaluates every configured SLO tracker and publishes the
+// threshold crossings its window sliding causes.
+func (g *Gateway) tickSLOs(ctx context.Context) {
+	g.mu.RLock()
+	trackers := slices.Collect(maps.Values(g.slos))
+	g.mu.RUnlock()
+	for _, t := range trackers {
+		g.publishSLOCrossings(ctx, "", t.tick())
+	}
+}
+
+// publishSLOCrossings publishes each crossing as an event and, when enabled,
+// an observability event. provider is the provider that served or failed the
+// request that caused them, or "" for a tick.
+func (g *Gateway) publishSLOCrossings(ctx context.Context, provider string, crossings []sloCrossing) {
+	if len(crossings) == 0 {
+		return
+	}
+	g.mu.RLock()
+	obs, obsEventsActive := g.obs, g.obsEventsActive
+	g.mu.RUnlock()
+	for _, c := range crossings {
+		c.burn.Provider = provider
+		he := events.SLOEvent(c.subject, c.burn)
+		if g.hasHooks() {
+			g.publishEvent(ctx, he)
+		}
+		if obsEventsActive {
+			obs.RecordEvent(ctx, observability.Event{
+				Subject:   he.Subject,
+				Provider:  provider,
+				Model:     c.burn.Name,
+				Timestamp: he.Timestamp,
+				Attributes: map[string]any{
+					"ferro.slo.name":                c.burn.Name,
+					"ferro.slo.indicator":           c.burn.Indicator,
+					"ferro.slo.burn_rate":           c.burn.BurnRate,
+					"ferro.slo.burn_rate_threshold": c.burn.Threshold,
+					"ferro.slo.attainment":          c.burn.Attainment,
+					"ferro.slo.objective":           c.burn.Objective,
+				},
+			})
+		}
+	}
+}
+
+// streamOutcomeErr rebuilds the error of a stream outcome from its message,
+// keeping a client cancellation recognizable.
+func streamOutcomeErr(msg string) error {
+	switch msg {
+	case "":
+		return nil
+	case context.Canceled.Error():
+		return context.Canceled
+	}
+	return errors.New(msg)
+}
+
+type sloTrackerKey struct{}
+
+// withSLOTracker marks ctx's request as tracked by t, for a RouteStream call
+// that hands its request to Route after resolving the alias Route would
+// otherwise look the SLO up by.
+func withSLOTracker(ctx context.Context, t *sloTracker) context.Context {
+	return context.WithValue(ctx, sloTrackerKey{}, t)
+}
+
+// sloTrackerForLocked returns the tracker of ctx's request for model: the
+// one ctx carries, else model's. Caller must hold g.mu.
+func (g *Gateway) sloTrackerForLocked(ctx context.Context, model string) *sloTracker {
+	if t, ok := ctx.Value(sloTrackerKey{}).(*sloTracker); ok {
+		return t
+	}
+	return g.slos[model]
+}
@@ -0,0 +1,201 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSLOTracker_BurnRateCrossings(t *testing.T) {
+	tr := newSLOTracker("fast", SLOConfig{ErrorObjective: 0.75, Window: "1m", MinRequests: 4})
+	now := time.Unix(1_700_000_000, 0)
+	tr.now = func() time.Time { return now }
+
+	// 2 failures in 4 is a 50% error rate, twice the 25% budget, but the
+	// window only reaches min_requests with the fourth request.
+	var got []sloCrossing
+	for i := range 4 {
+		got = tr.record(0, i < 2)
+		if i < 3 && len(got) != 0 {
+			t.Fatalf("request %d crossed before min_requests: %+v", i+1, got)
+		}
+	}
+	if len(got) != 1 || got[0].subject != SubjectSLOBurnRateExceeded {
+		t.Fatalf("crossings = %+v, want one exceeded", got)
+	}
+	if b := got[0].burn; b.Indicator != sloErrors || b.BurnRate != 2 || b.Attainment != 0.5 || b.Requests != 4 {
+		t.Fatalf("burn = %+v", b)
+	}
+	if v := testutil.ToFloat64(metrics.SLOBurnRate.WithLabelValues("fast", sloErrors)); v != 2 {
+		t.Fatalf("burn rate gauge = %v, want 2", v)
+	}
+
+	// Still burning: no repeat event.
+	if got := tr.record(0, true); len(got) != 0 {
+		t.Fatalf("repeat crossing: %+v", got)
+	}
+
+	// Once the window has slid past the failures, a success recovers it.
+	now = now.Add(2 * time.Minute)
+	got = tr.record(0, false)
+	if len(got) != 1 || got[0].subject != SubjectSLOBurnRateRecovered || got[0].burn.Requests != 1 {
+		t.Fatalf("crossings = %+v, want one recovered over a fresh window", got)
+	}
+}
+
+// A tick slides the window without new traffic, so an alert recovers once
+// its failures age out.
+func TestSLOTracker_TickRecoversIdleWindow(t *testing.T) {
+	tr := newSLOTracker("idle", SLOConfig{ErrorObjective: 0.9, Window: "1m", MinRequests: 1})
+	now := time.Unix(1_700_000_000, 0)
+	tr.now = func() time.Time { return now }
+
+	if got := tr.record(0, true); len(got) != 1 || got[0].subject != SubjectSLOBurnRateExceeded {
+		t.Fatalf("crossings = %+v, want one exceeded", got)
+	}
+	now = now.Add(30 * time.Second)
+	if got := tr.tick(); len(got) != 0 {
+		t.Fatalf("tick inside the window crossed: %+v", got)
+	}
+
+	now = now.Add(time.Minute)
+	got := tr.tick()
+	if len(got) != 1 || got[0].subject != SubjectSLOBurnRateRecovered || got[0].burn.Requests != 0 {
+		t.Fatalf("crossings = %+v, want one recovered over an empty window", got)
+	}
+	if v := testutil.ToFloat64(metrics.SLOBurnRate.WithLabelValues("idle", sloErrors)); v != 0 {
+		t.Fatalf("burn rate gauge = %v, want 0", v)
+	}
+	if got := tr.tick(); len(got) != 0 {
+		t.Fatalf("repeat tick crossed: %+v", got)
+	}
+}
+
+func TestSLOTracker_LatencyCountsSuccessesOnly(t *testing.T) {
+	tr := newSLOTracker("fast", SLOConfig{Latency: "1s", LatencyObjective: 0.75, MinRequests: 1, BurnRateThreshold: 2.5})
+	tr.record(2*time.Second, true) // a failure is not slow
+	tr.record(100*time.Millisecond, false)
+	got := tr.record(3*time.Second, false)
+	if len(got) != 0 {
+		t.Fatalf("1 slow in 2 is a burn rate of 2, below 2.5: %+v", got)
+	}
+	got = tr.record(3*time.Second, false)
+	if len(got) != 1 || got[0].burn.Indicator != sloLatency || got[0].burn.Requests != 3 {
+		t.Fatalf("crossings = %+v, want the latency indicator over 3 successes", got)
+	}
+}
+
+func TestSLOCountsFailure(t *testing.T) {
+	for _, tt := range []struct {
+		err             error
+		failed, counted bool
+	}{
+		{nil, false, true},
+		{context.Canceled, false, false},
+		{context.DeadlineExceeded, true, true},
+		{errors.New("openai: bad request (400)"), false, false},
+		{errors.New("openai: slow down (429)"), true, true},
+		{errors.New("openai: unavailable (503)"), true, true},
+		{errors.New("connection reset"), true, true},
+	} {
+		failed, counted := sloCountsFailure(tt.err)
+		if failed != tt.failed || counted != tt.counted {
+			t.Errorf("sloCountsFailure(%v) = %v, %v; want %v, %v", tt.err, failed, counted, tt.failed, tt.counted)
+		}
+	}
+}
+
+func TestRoute_SLOBurnRatePublishesEvent(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai"}},
+		Aliases:  map[string]string{"fast": "gpt-4o-mini"},
+		SLOs:     map[string]SLOConfig{"fast": {ErrorObjective: 0.99, MinRequests: 2}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockProvider{
+		name:   "openai",
+		models: []string{"gpt-4o-mini"},
+		err:    errors.New("openai: bad gateway (502)"),
+	})
+	received := make(chan map[string]any, 4)
+	gw.AddHook(func(_ context.Context, subject string, data map[string]any) {
+		if subject == SubjectSLOBurnRateExceeded {
+			received <- data
+		}
+	})
+
+	req := providers.Request{Model: "fast", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	for range 2 {
+		if _, err := gw.Route(context.Background(), req); err == nil {
+			t.Fatal("Route succeeded against a failing provider")
+		}
+	}
+
+	select {
+	case data := <-received:
+		if data["slo"] != "fast" || data["indicator"] != sloErrors || data["requests"] != 2 {
+			t.Fatalf("event = %v", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no burn-rate event published")
+	}
+}
+
+func TestReloadConfig_KeepsUnchangedSLOTracker(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai"}},
+		SLOs: map[string]SLOConfig{
+			"fast":  {ErrorObjective: 0.99},
+			"smart": {Latency: "5s", LatencyObjective: 0.9},
+		},
+	}
+	gw, err := newTestGateway(t, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fast, smart := gw.slos["fast"], gw.slos["smart"]
+
+	cfg.SLOs = map[string]SLOConfig{
+		"fast":  {ErrorObjective: 0.99},
+		"smart": {Latency: "2s", LatencyObjective: 0.9},
+	}
+	if err := gw.ReloadConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	if gw.slos["fast"] != fast {
+		t.Error("an unchanged SLO lost its window on reload")
+	}
+	if gw.slos["smart"] == smart || gw.slos["smart"].latency != 2*time.Second {
+		t.Error("a changed SLO kept its old tracker")
+	}
+}
+
+func TestValidateConfig_SLOs(t *testing.T) {
+	for name, slo := range map[string]SLOConfig{
+		"empty":                  {},
+		"latency objective only": {LatencyObjective: 0.9, ErrorObjective: 0.99},
+		"latency without target": {Latency: "1s"},
+		"bad latency":            {Latency: "soon", LatencyObjective: 0.9},
+		"objective of 1":         {ErrorObjective: 1},
+		"short window":           {ErrorObjective: 0.99, Window: "30s"},
+		"negative threshold":     {ErrorObjective: 0.99, BurnRateThreshold: -1},
+	} {
+		cfg := Config{
+			Strategy: StrategyConfig{Mode: ModeSingle},
+			Targets:  []Target{{VirtualKey: "openai"}},
+			SLOs:     map[string]SLOConfig{"fast": slo},
+		}
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%s: ValidateConfig accepted %+v", name, slo)
+		}
+	}
+}
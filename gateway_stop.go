@@ -0,0 +1,71 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ferro-labs/ai-gateway/internal/stopseq"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/capabilities"
+)
+
+// stopProvider emulates stop sequences for a provider that cannot take them
+// (the capability matrix marks stop Unsupported) or caps how many it accepts.
+// It forwards what the provider can express — nothing, or the first limit
+// sequences — and enforces the full list on the output itself. A request whose
+// stop list fits the provider passes through untouched.
+type stopProvider struct {
+	providers.Provider
+	name  string
+	limit int // 0 when the provider cannot take stop at all
+}
+
+// stopEmulation returns the decorator for p, or p itself when the provider
+// takes any number of stop sequences.
+func stopEmulation(name string, p providers.Provider) providers.Provider {
+	id := p.Name()
+	if capabilities.SupportOf(id, "stop") == capabilities.Unsupported {
+		return &stopProvider{Provider: p, name: name}
+	}
+	if limit := capabilities.StopLimit(id); limit > 0 {
+		return &stopProvider{Provider: p, name: name, limit: limit}
+	}
+	return p
+}
+
+// split returns the request to forward and the matcher to enforce, or an
+// empty matcher when the provider handles req's stop list natively.
+func (p *stopProvider) split(req providers.Request) (providers.Request, stopseq.Matcher) {
+	if len(req.Stop) == 0 || (p.limit > 0 && len(req.Stop) <= p.limit) {
+		return req, stopseq.Matcher{}
+	}
+	m := stopseq.New(req.Stop)
+	if p.limit > 0 {
+		req.Stop = req.Stop[:p.limit:p.limit]
+	} else {
+		req.Stop = nil
+	}
+	return req, m
+}
+
+func (p *stopProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	req, m := p.split(req)
+	resp, err := p.Provider.Complete(ctx, req)
+	if err == nil {
+		m.Truncate(resp)
+	}
+	return resp, err
+}
+
+func (p *stopProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	req, m := p.split(req)
+	ch, err := sp.CompleteStream(ctx, req)
+	if err != nil || m.Empty() {
+		return ch, err
+	}
+	return m.Stream(ctx, ch), nil
+}
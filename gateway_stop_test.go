@@ -0,0 +1,123 @@
+package aigateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestGateway_StopEmulatedForUnsupportedProvider(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "bedrock"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var forwarded []string
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{
+			name:   "bedrock",
+			models: []string{"gpt-4o"},
+			completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+				forwarded = req.Stop
+				return &providers.Response{ID: "r", Model: "gpt-4o", Choices: []providers.Choice{{
+					Message:      providers.Message{Role: "assistant", Content: "Answer: 42\nQuestion: next"},
+					FinishReason: "length",
+				}}}, nil
+			},
+		},
+		streamFn: func(context.Context, providers.Request) (<-chan providers.StreamChunk, error) {
+			ch := make(chan providers.StreamChunk, 3)
+			ch <- providers.StreamChunk{Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: "Answer: 42\nQue"}}}}
+			ch <- providers.StreamChunk{Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: "stion: next"}}}}
+			ch <- providers.StreamChunk{Choices: []providers.StreamChoice{{FinishReason: "length"}}}
+			close(ch)
+			return ch, nil
+		},
+	})
+
+	req := providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		Stop:     []string{"\nQuestion:"},
+	}
+	resp, err := gw.Route(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if forwarded != nil {
+		t.Fatalf("stop forwarded to a provider that cannot take it: %q", forwarded)
+	}
+	if got := resp.Choices[0]; got.Message.Content != "Answer: 42" || got.FinishReason != "stop" {
+		t.Fatalf("response = %q/%q, want truncated at the stop sequence", got.Message.Content, got.FinishReason)
+	}
+
+	req.Stream = true
+	ch, err := gw.RouteStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RouteStream: %v", err)
+	}
+	var text strings.Builder
+	var finish string
+	for chunk := range ch {
+		for _, c := range chunk.Choices {
+			text.WriteString(c.Delta.Content)
+			if c.FinishReason != "" {
+				finish = c.FinishReason
+			}
+		}
+	}
+	if text.String() != "Answer: 42" || finish != "stop" {
+		t.Fatalf("stream = %q/%q, want truncated at the stop sequence", text.String(), finish)
+	}
+}
+
+func TestGateway_StopOverProviderLimit(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var forwarded []string
+	gw.RegisterProvider(&mockProvider{
+		name:   "openai",
+		models: []string{"gpt-4o"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			forwarded = req.Stop
+			return &providers.Response{ID: "r", Model: "gpt-4o", Choices: []providers.Choice{{
+				Message:      providers.Message{Role: "assistant", Content: "one two five six"},
+				FinishReason: "length",
+			}}}, nil
+		},
+	})
+
+	req := providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "count"}},
+		Stop:     []string{"a", "b", "c", "d", " five"},
+	}
+	resp, err := gw.Route(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if len(forwarded) != 4 {
+		t.Fatalf("forwarded %q, want the provider's four-sequence cap", forwarded)
+	}
+	if got := resp.Choices[0]; got.Message.Content != "one two" || got.FinishReason != "stop" {
+		t.Fatalf("response = %q/%q, want the fifth sequence enforced by the gateway", got.Message.Content, got.FinishReason)
+	}
+
+	// Within the cap the provider handles stop itself.
+	req.Stop = req.Stop[:2]
+	if _, err := gw.Route(context.Background(), req); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if len(forwarded) != 2 {
+		t.Fatalf("forwarded %q, want the list unchanged", forwarded)
+	}
+}
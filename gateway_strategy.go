@@ -1,11 +1,17 @@
 package aigateway
 
 import (
+	"context"
 	"fmt"
 	"maps"
 	"regexp"
+	"sync"
+	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
+	"github.com/ferro-labs/ai-gateway/internal/routingrules"
 	"github.com/ferro-labs/ai-gateway/internal/strategies"
+	"github.com/ferro-labs/ai-gateway/models"
 	"github.com/ferro-labs/ai-gateway/providers"
 )
 
@@ -17,15 +23,105 @@ type streamingContentCondition struct {
 	re *regexp.Regexp
 }
 
-// getStrategy lazily builds the strategy from config and registered providers.
-// Circuit breakers are built once and applied in the provider lookup closure.
-func (g *Gateway) getStrategy() (strategies.Strategy, error) {
+// routingSnapshot is the routing state every request reads: the built
+// strategy and the config, provider, breaker, limiter, and catalog state it was
+// built from. It is copy-on-write. A snapshot is never modified after it is
+// published through Gateway.routing; anything that changes the state it
+// captured calls invalidateRoutingLocked, and the next request builds a fresh
+// one. Requests therefore resolve routing with one atomic load instead of
+// taking g.mu.
+type routingSnapshot struct {
+	strategy         strategies.Strategy
+	mode             StrategyMode
+	targets          []Target
+	unpricedStrategy string
+	catalog          models.Catalog
+	// The maps are private copies; their values are shared references that
+	// are safe for concurrent use.
+	providers       map[string]providers.Provider
+	circuitBreakers map[string]*circuitbreaker.CircuitBreaker
+	limiters        map[string]*providerLimiter
+	// region is the gateway's own region, the origin of requests that do not
+	// name one; regional holds the region-preferring strategy for each region
+	// with both local and remote targets (see gateway_region.go).
+	region   string
+	regional map[string]*regionalStrategy
+	// residency holds the strategy for each data residency requirement in
+	// residencyConfig (see gateway_residency.go).
+	residencyConfig *ResidencyConfig
+	residency       map[string]*residencyStrategy
+	// retryBudget reports whether strategy.retry_budget is set, so attempts
+	// are wrapped with budgetProvider.
+	retryBudget bool
+	// params holds each target's parameter policy, applied to its attempts
+	// by paramsProvider.
+	params map[string]*TargetParams
+	// fetchImages holds the image fetcher of each target with
+	// fetch_image_urls set, applied to its attempts by imageFetchProvider.
+	fetchImages map[string]*imageFetcher
+	// transcoding is strategy.stream_transcoding; when set, targets that
+	// cannot stream serve streaming requests through transcodingProvider.
+	transcoding *StreamTranscodingConfig
+	// lookup and strategyTargets are what strategy was built from, kept to
+	// build RequestOptions overrides; overrides caches those by StrategyMode.
+	lookup          strategies.ProviderLookup
+	strategyTargets []strategies.Target
+	overrides       sync.Map
+}
+
+// inService reports whether key is not a draining or disabled target.
+func (snap *routingSnapshot) inService(key string) bool {
+	for _, t := range snap.targets {
+		if t.VirtualKey == key && !t.InService() {
+			return false
+		}
+	}
+	return true
+}
+
+// getStrategy returns the routing strategy for ctx's request, building it on
+// first use after a change.
+func (g *Gateway) getStrategy(ctx context.Context) (strategies.Strategy, error) {
+	snap, err := g.loadRouting()
+	if err != nil {
+		return nil, err
+	}
+	s, _, err := g.requestStrategy(ctx, snap)
+	return s, err
+}
+
+// loadRouting returns the current routing snapshot. Only the first request
+// after an invalidation takes g.mu, to build it.
+func (g *Gateway) loadRouting() (*routingSnapshot, error) {
+	if snap := g.routing.Load(); snap != nil {
+		return snap, nil
+	}
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	if g.strategy != nil {
-		return g.strategy, nil
+	// Another request may have built it while this one waited for the lock.
+	if snap := g.routing.Load(); snap != nil {
+		return snap, nil
+	}
+	snap, err := g.buildRoutingLocked()
+	if err != nil {
+		return nil, err
 	}
+	g.routing.Store(snap)
+	return snap, nil
+}
 
+// invalidateRoutingLocked drops the routing snapshot so the next request
+// rebuilds it. Every change to state a snapshot captures must call it, under
+// the same g.mu write lock as the change, so a build cannot publish a
+// snapshot of the old state after it. Caller must hold g.mu.
+func (g *Gateway) invalidateRoutingLocked() {
+	g.routing.Store(nil)
+}
+
+// buildRoutingLocked builds the strategy from config and registered
+// providers. Circuit breakers are built once and applied in the provider
+// lookup closure. Caller must hold g.mu.
+func (g *Gateway) buildRoutingLocked() (*routingSnapshot, error) {
 	g.ensureCircuitBreakersLocked()
 	g.ensureProviderLimitersLocked()
 
@@ -35,8 +131,42 @@ func (g *Gateway) getStrategy() (strategies.Strategy, error) {
 	// maps.Clone is a shallow copy — safe because map values (Provider, *CB) are
 	// themselves immutable references; we never mutate through them in the closure.
 	providerSnap := maps.Clone(g.providers)
+	// Draining and disabled targets leave the snapshot, so no strategy,
+	// surface, or pinned request can reach them; requests already holding
+	// one of them finish against the snapshot they started with, unless
+	// SetTargetState disables the target and cancels them.
+	effective := g.effectiveTargetsLocked()
+	stops := make(map[string]context.Context, len(effective))
+	for _, t := range effective {
+		if !t.InService() {
+			delete(providerSnap, t.VirtualKey)
+			continue
+		}
+		stops[t.VirtualKey] = g.targetStopLocked(t.VirtualKey)
+	}
 	cbSnap := maps.Clone(g.circuitBreakers)
 	limSnap := maps.Clone(g.limiters)
+	outcomes := g.outcomes
+	validation := g.config.ResponseValidation
+	retryBudget := g.config.Strategy.RetryBudget != nil
+	streamOnly := make(map[string]bool)
+	params := make(map[string]*TargetParams)
+	fetchImages := make(map[string]*imageFetcher)
+	var fetcher *imageFetcher
+	for _, t := range g.config.Targets {
+		if t.StreamOnly {
+			streamOnly[t.VirtualKey] = true
+		}
+		if t.Params != nil {
+			params[t.VirtualKey] = t.Params
+		}
+		if t.FetchImageURLs {
+			if fetcher == nil {
+				fetcher = newImageFetcher(g.config.Images, refuseNonPublic)
+			}
+			fetchImages[t.VirtualKey] = fetcher
+		}
+	}
 
 	// Provider lookup with transparent circuit-breaker and concurrency-limit
 	// decoration.
@@ -49,7 +179,16 @@ func (g *Gateway) getStrategy() (strategies.Strategy, error) {
 		if !ok {
 			return nil, false
 		}
-		return decorateProvider(name, p, cbSnap[name], limSnap[name]), true
+		// Validation sits innermost, just above stream aggregation, so a
+		// rejected response counts against the target like any other
+		// upstream failure.
+		p = stopOnDisable(name, p, stops[name])
+		p = aggregateStream(p, streamOnly[name])
+		p = enforceParams(name, p, params[name])
+		p = fetchImageURLs(name, p, fetchImages[name])
+		p = validateResponses(name, p, validation)
+		p = budgetAttempts(name, p, retryBudget)
+		return decorateProvider(name, p, cbSnap[name], limSnap[name], outcomes), true
 	}
 
 	targets := make([]strategies.Target, len(g.config.Targets))
@@ -60,8 +199,52 @@ func (g *Gateway) getStrategy() (strategies.Strategy, error) {
 		}
 	}
 
+	s, err := g.buildStrategyLocked(targets, lookup)
+	if err != nil {
+		return nil, err
+	}
+	regional, err := g.buildRegionalStrategiesLocked(lookup, providerSnap, cbSnap)
+	if err != nil {
+		return nil, err
+	}
+	residency, err := g.buildResidencyStrategiesLocked(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	return &routingSnapshot{
+		strategy:         s,
+		mode:             g.config.Strategy.Mode,
+		targets:          effective,
+		unpricedStrategy: g.config.Strategy.UnpricedStrategy,
+		catalog:          g.catalog,
+		providers:        providerSnap,
+		circuitBreakers:  cbSnap,
+		limiters:         limSnap,
+		region:           g.config.Region,
+		regional:         regional,
+		residencyConfig:  g.config.Residency,
+		residency:        residency,
+		retryBudget:      retryBudget,
+		params:           params,
+		fetchImages:      fetchImages,
+		transcoding:      g.config.Strategy.StreamTranscoding,
+		lookup:           lookup,
+		strategyTargets:  targets,
+	}, nil
+}
+
+// buildStrategyLocked builds the configured strategy over targets. Caller
+// must hold g.mu.
+func (g *Gateway) buildStrategyLocked(targets []strategies.Target, lookup strategies.ProviderLookup) (strategies.Strategy, error) {
+	return g.buildStrategyModeLocked(g.config.Strategy.Mode, targets, lookup)
+}
+
+// buildStrategyModeLocked builds a mode strategy over targets, with the
+// configured rules for that mode. Caller must hold g.mu.
+func (g *Gateway) buildStrategyModeLocked(mode StrategyMode, targets []strategies.Target, lookup strategies.ProviderLookup) (strategies.Strategy, error) {
 	var s strategies.Strategy
-	switch g.config.Strategy.Mode {
+	switch mode {
 	case ModeSingle, "":
 		if len(targets) == 0 {
 			return nil, fmt.Errorf("no targets configured for single strategy")
@@ -77,7 +260,7 @@ func (g *Gateway) getStrategy() (strategies.Strategy, error) {
 		}
 		s = fb
 	case ModeLoadBalance:
-		s = strategies.NewLoadBalance(targets, lookup)
+		s = strategies.NewLoadBalance(targets, lookup).WithWeightScale(g.outcomes.throttleFactor)
 	case ModeLatency:
 		if len(targets) == 0 {
 			return nil, fmt.Errorf("no targets configured for least-latency strategy")
@@ -97,11 +280,26 @@ func (g *Gateway) getStrategy() (strategies.Strategy, error) {
 		}
 		var rules []strategies.ConditionRule
 		for _, cond := range g.config.Strategy.Conditions {
-			rules = append(rules, strategies.ConditionRule{
+			rule := strategies.ConditionRule{
 				Key:    cond.Key,
 				Value:  cond.Value,
 				Target: strategies.Target{VirtualKey: cond.TargetKey},
-			})
+			}
+			if cond.Expr != "" {
+				compiled, err := routingrules.Compile(cond.Expr)
+				if err != nil {
+					return nil, err
+				}
+				rule.Match = compiled.Match
+			}
+			if cond.Window != nil {
+				window, err := parseConditionWindow(cond.Window)
+				if err != nil {
+					return nil, err
+				}
+				rule.Match = windowed(window, rule.Match)
+			}
+			rules = append(rules, rule)
 		}
 		s = strategies.NewConditional(rules, targets[0], lookup).WithRoutingTargets(targets)
 	case ModeContentBased:
@@ -117,10 +315,8 @@ func (g *Gateway) getStrategy() (strategies.Strategy, error) {
 		}
 		s = abt
 	default:
-		return nil, fmt.Errorf("unknown strategy mode: %s", g.config.Strategy.Mode)
+		return nil, fmt.Errorf("unknown strategy mode: %s", mode)
 	}
-
-	g.strategy = s
 	return s, nil
 }
 
@@ -167,6 +363,26 @@ func (g *Gateway) buildABTestStrategy(targets []strategies.Target, lookup strate
 	return abt.WithRoutingTargets(targets), nil
 }
 
+// parseConditionWindow builds a condition's time window.
+func parseConditionWindow(w *TimeWindow) (*routingrules.Window, error) {
+	window, err := routingrules.ParseWindow(w.Timezone, w.Days, w.Start, w.End)
+	if err != nil {
+		return nil, fmt.Errorf("window: %w", err)
+	}
+	return window, nil
+}
+
+// windowed limits match to the times window is open. A nil match matches
+// every request in the window.
+func windowed(window *routingrules.Window, match func(context.Context, providers.Request) bool) func(context.Context, providers.Request) bool {
+	return func(ctx context.Context, req providers.Request) bool {
+		if !window.Contains(time.Now()) {
+			return false
+		}
+		return match == nil || match(ctx, req)
+	}
+}
+
 func compileStreamingContentConditions(mode StrategyMode, conditions []ContentCondition) ([]streamingContentCondition, error) {
 	if mode != ModeContentBased {
 		return nil, nil
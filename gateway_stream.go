@@ -12,9 +12,13 @@ import (
 	"github.com/ferro-labs/ai-gateway/internal/authctx"
 	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
 	"github.com/ferro-labs/ai-gateway/internal/events"
+	"github.com/ferro-labs/ai-gateway/internal/jsonstream"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/internal/strategies"
 	"github.com/ferro-labs/ai-gateway/internal/streamwrap"
+	"github.com/ferro-labs/ai-gateway/internal/tokens"
+	"github.com/ferro-labs/ai-gateway/internal/upstreamhdr"
 	"github.com/ferro-labs/ai-gateway/observability"
 	"github.com/ferro-labs/ai-gateway/plugin"
 	"github.com/ferro-labs/ai-gateway/providers"
@@ -60,15 +64,40 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 	g.mu.RLock()
 	strategyMode := string(g.config.Strategy.Mode)
 	compatMode := g.config.Compatibility.OnUnsupportedParam
+	seedMode := g.config.Compatibility.OnUnhonoredSeed
+	stripReasoning := g.config.Compatibility.StripReasoningContent
+	rewriteAlias := g.config.Compatibility.RewriteAliasModel
 	requestTimeout := g.config.RequestTimeout
+	retryBudget := g.config.Strategy.RetryBudget
+	budgetCatalog := g.catalog
+	exposeErrors := g.config.ExposeProviderErrors
+	exposeUpstream := g.config.ExposeUpstreamHeaders
+	exposePlugins := g.config.ExposePluginMetadata
+	tiers := g.config.RateLimitTiers
+	truncationCfg := g.config.ContextTruncation
+	thinkingCfg := g.config.Thinking
+	defaultsCfg := g.config.RequestDefaults
+	imagesCfg := g.config.Images
+	slo := g.sloTrackerForLocked(ctx, req.Model)
+	maxStreams := g.config.MaxConcurrentStreams
 	obs := g.obs
 	obsEventsActive := g.obsEventsActive
 	mcpRegistrySnapshot := g.mcpRegistry
 	plugins := g.plugins
 	releasePlugins := acquirePluginManager(plugins)
+	deadLetters := g.deadLetters
 	g.mu.RUnlock()
 
 	ctx = withUnsupportedParamMode(ctx, compatMode)
+	ctx = withSeedMode(ctx, seedMode)
+	ctx = withRetryBudget(ctx, retryBudget, budgetCatalog)
+	ctx = withFetchedImages(ctx, req)
+	ctx, upstream := upstreamhdr.WithRecorder(ctx)
+	upstream.SetExposed(exposeUpstream)
+	if plugins.HasPlugins() || deadLetters != nil {
+		ctx = withAttemptLog(ctx)
+	}
+	dlReq := deadLetterRequest(ctx, deadLetters, req)
 	var releasePluginsOnce sync.Once
 	releasePluginManager := func() {
 		releasePluginsOnce.Do(releasePlugins)
@@ -88,9 +117,19 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 	}()
 
 	// Resolve model alias before routing.
+	requestedModel := req.Model
 	trace.WithRegion(ctx, "gateway.route_stream.resolve_alias", func() {
 		req = g.resolveAlias(req)
 	})
+	reportedModel := clientModel(rewriteAlias, requestedModel, req.Model)
+	applyRequestDefaults(ctx, defaultsCfg, &req)
+	capThinking(ctx, thinkingCfg, &req)
+	adaptReasoningParams(ctx, &req)
+	if err := limitImages(ctx, imagesCfg, &req); err != nil {
+		releasePluginManager()
+		metrics.ForRequest("", g.metricModel(req.Model)).Rejected.Inc()
+		return nil, err
+	}
 
 	// MCP redirect: when tool servers have advertised tools, the agentic loop
 	// must run to completion before any response is sent. Route() handles this
@@ -112,22 +151,42 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 		// Do not force req.Stream = false here: let Route() capture the
 		// original stream flag via its own originalStream variable so that
 		// emitted events correctly reflect stream: true for RouteStream callers.
-		resp, err := g.Route(ctx, req)
+		resp, err := g.Route(withSLOTracker(ctx, slo), req)
 		if err != nil {
 			return nil, err
 		}
 		_ = start // latency already recorded inside Route()
-		return responseStream(resp), nil
+		// Route saw the resolved model, so the alias is restored here.
+		return responseStream(withModel(resp, reportedModel)), nil
+	}
+
+	// Admit against the caller's rate-limit tier and the gateway-wide stream
+	// cap. An admitted stream holds its concurrent-stream slots until it
+	// drains; every return below that does not hand the stream to the meter
+	// must give them back.
+	admission, err := g.admitTier(ctx, tiers, true, maxStreams)
+	if err != nil {
+		releasePluginManager()
+		metrics.ForRequest("", g.metricModel(req.Model)).Rejected.Inc()
+		return nil, err
 	}
 
 	// Run before-request plugins (word-filter, max-token, rate-limit, etc.).
-	pctx, early, err := g.runBeforePluginsStream(ctx, span, obs, plugins, releasePluginManager, &req, start, hooksEnabled, obsEventsActive)
+	pctx, early, err := g.runBeforePluginsStream(ctx, span, obs, plugins, releasePluginManager, exposePlugins, &req, start, hooksEnabled, obsEventsActive)
 	if err != nil {
+		admission.done(0)
 		return nil, err
 	}
 	if early != nil {
-		return responseStream(early), nil
+		admission.done(0)
+		g.recordSLO(ctx, slo, early.Provider, time.Since(start), nil)
+		if stripReasoning {
+			early = stripReasoningContent(early)
+		}
+		return responseStream(withModel(early, reportedModel)), nil
 	}
+	// As in Route, truncation follows the plugins, per model tried.
+	fit := func(req *providers.Request) { g.truncateContext(ctx, truncationCfg, budgetCatalog, req) }
 
 	// Select and start the provider according to strategy mode. This is the
 	// only safe retry window: CompleteStream has not returned a channel yet,
@@ -142,7 +201,15 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 	// timer, deferred here purely so a panic can't leak it.
 	startCtx, cancelStart := withRequestDeadline(ctx, requestTimeout)
 	defer cancelStart()
-	sp, providerName, rawCh, err := g.startStreamWithStrategy(startCtx, ctx, req)
+	var (
+		sp           providers.StreamProvider
+		providerName string
+		rawCh        <-chan providers.StreamChunk
+	)
+	err = g.tryModels(startCtx, &req, fit, func(req providers.Request) (err error) {
+		sp, providerName, rawCh, err = g.startStreamWithStrategy(startCtx, ctx, req)
+		return err
+	})
 	span.SetAttribute(observability.AttrGenAISystem, providerName)
 	// Stamp the resolved target key (virtual key = provider name in this routing layer).
 	if providerName != "" {
@@ -152,12 +219,16 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 		logging.FromContext(ctx).Debug("stream request started", "model", req.Model, "provider", providerName)
 	}
 	if err != nil {
+		err = exposeProviderBody(err, exposeErrors)
+		admission.done(0)
 		errType := "provider_error"
 		if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
 			errType = "circuit_open"
 		}
 		if pctx != nil {
+			recordUpstreamHeaders(upstream, pctx)
 			pctx.Error = err
+			setDiagnostics(ctx, pctx, err, time.Since(start))
 			plugins.RunOnError(ctx, pctx)
 			plugin.PutContext(pctx)
 			releasePluginManager()
@@ -178,6 +249,8 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 			)
 			g.dispatchRequestEvent(ctx, obs, hooksEnabled, obsEventsActive, he)
 		}
+		g.recordSLO(ctx, slo, providerName, time.Since(start), err)
+		writeDeadLetter(ctx, deadLetters, dlReq, strategyMode, err, time.Since(start))
 		return nil, err
 	}
 
@@ -185,6 +258,7 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 	// metrics and event hooks once the stream completes.
 	g.mu.RLock()
 	catalog := g.catalog
+	captureLimit := g.config.MaxStreamCaptureBytes
 	g.mu.RUnlock()
 
 	meta := streamwrap.MeterMeta{
@@ -200,27 +274,46 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 		// Usage is always requested upstream so metering, cost, and the budget
 		// plugin see real numbers; a caller that asked not to receive it just
 		// does not get the chunk forwarded.
-		SuppressUsageForClient: req.ClientStreamOptions != nil && !req.ClientStreamOptions.IncludeUsage,
+		SuppressUsageForClient:  req.ClientStreamOptions != nil && !req.ClientStreamOptions.IncludeUsage,
+		IncludeUsageForClient:   req.ClientStreamOptions != nil && req.ClientStreamOptions.IncludeUsage,
+		StripReasoningForClient: stripReasoning,
+		ModelForClient:          reportedModel,
+		PromptTokensEstimate:    tokens.Prompt(req),
+		MaxCaptureBytes:         captureLimit,
 	}
 	if hooksEnabled {
 		meta.PublishFn = g.publishEvent
 	}
+	// The stream's final outcome feeds the target's breaker, when it has
+	// one, and RoutingState's error rate.
+	outcomes := g.outcomes
 	if wrapped, ok := sp.(*cbProvider); ok {
 		cb := wrapped.cb
 		cbName := wrapped.name
 		meta.CircuitBreakerOutcome = func(err error) {
 			recordCircuitBreakerOutcome(ctx, cb, cbName, err)
+			outcomes.record(ctx, providerName, err)
+		}
+	} else if outcomes != nil {
+		meta.CircuitBreakerOutcome = func(err error) {
+			outcomes.record(ctx, providerName, err)
 		}
 	}
 	if pctx != nil {
-		meta.CompletionFn = func(ctx context.Context, resp *providers.Response) error {
+		meta.CompletionFn = func(ctx context.Context, resp *providers.Response, truncated bool) error {
 			pctx.Response = resp
+			recordUpstreamHeaders(upstream, pctx)
+			if truncated {
+				pctx.Metadata["response_truncated"] = true
+			}
+			pctx.Metadata["cost_usd"] = responseCost(catalog, resp).TotalUSD
 			err := plugins.RunAfter(ctx, pctx)
 			if pctx.Response != nil {
 				*resp = *pctx.Response
 			}
 			if err != nil {
 				pctx.Error = err
+				setDiagnostics(ctx, pctx, err, time.Since(start))
 				plugins.RunOnError(ctx, pctx)
 			}
 			plugin.PutContext(pctx)
@@ -232,7 +325,9 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 			if pctx == nil {
 				return
 			}
+			recordUpstreamHeaders(upstream, pctx)
 			pctx.Error = err
+			setDiagnostics(ctx, pctx, err, time.Since(start))
 			plugins.RunOnError(ctx, pctx)
 			plugin.PutContext(pctx)
 			pctx = nil
@@ -251,6 +346,7 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 	obsProvider := obs
 	traceID := logging.TraceIDFromContext(ctx)
 	meta.SpanFinisher = streamwrap.SpanFinisherFunc(func(o streamwrap.StreamOutcome) {
+		admission.done(o.TokensIn + o.TokensOut)
 		finishSpan.SetTokens(o.TokensIn, o.TokensOut, o.ReasoningIn)
 		finishSpan.SetCost(observability.CostBreakdown{
 			TotalUSD:      o.Cost.TotalUSD,
@@ -262,6 +358,8 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 			ModelFound:    o.Cost.ModelFound,
 		})
 		finishSpan.SetStreamTimings(o.TTFTMs, o.TTLTMs)
+		// A stream's latency is its time to first token.
+		g.recordSLO(ctx, slo, providerName, time.Duration(o.TTFTMs*float64(time.Millisecond)), streamOutcomeErr(o.ErrorMsg))
 		if o.ErrorMsg != "" {
 			finishSpan.SetError(errors.New(o.ErrorMsg))
 		}
@@ -300,6 +398,12 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 			obsProvider.RecordEvent(context.WithoutCancel(ctx), obsEventFromHook(he))
 		}
 	})
+	// JSON mode: hold the stream to one valid JSON value, stripping a
+	// markdown fence, so a client parsing the concatenated content never sees
+	// a broken document — it gets a stream error instead.
+	if jsonstream.Wants(req.ResponseFormat) {
+		rawCh = jsonstream.Stream(ctx, rawCh)
+	}
 	return streamwrap.Meter(ctx, rawCh, start, meta), nil
 }
 
@@ -311,7 +415,7 @@ func (g *Gateway) RouteStream(ctx context.Context, req providers.Request) (<-cha
 // immediately (success recording and release already done). Otherwise the
 // returned pctx (nil if no plugins are configured, non-nil and still live
 // otherwise) is what the rest of RouteStream continues to use.
-func (g *Gateway) runBeforePluginsStream(ctx context.Context, span observability.Span, obs observability.Provider, plugins *plugin.Manager, releasePluginManager func(), req *providers.Request, start time.Time, hooksEnabled, obsEventsActive bool) (pctx *plugin.Context, early *providers.Response, err error) {
+func (g *Gateway) runBeforePluginsStream(ctx context.Context, span observability.Span, obs observability.Provider, plugins *plugin.Manager, releasePluginManager func(), exposePlugins *PluginMetadataExposure, req *providers.Request, start time.Time, hooksEnabled, obsEventsActive bool) (pctx *plugin.Context, early *providers.Response, err error) {
 	if !plugins.HasPlugins() {
 		releasePluginManager()
 		return nil, nil, nil
@@ -326,8 +430,11 @@ func (g *Gateway) runBeforePluginsStream(ctx context.Context, span observability
 		pctx.Metadata["api_key"] = keyID
 	}
 	trace.WithRegion(ctx, "gateway.route_stream.plugins.before", func() {
-		early, err = g.runBeforePlugins(ctx, plugins, pctx, req)
+		early, err = g.runBeforePlugins(ctx, plugins, pctx, req, start)
 	})
+	// Headers go out before the first chunk, so a stream carries those the
+	// before_request plugins declared.
+	exposePluginHeaders(ctx, exposePlugins, pctx)
 	if err != nil {
 		plugin.PutContext(pctx)
 		releasePluginManager()
@@ -366,18 +473,21 @@ func (g *Gateway) runBeforePluginsStream(ctx context.Context, span observability
 // alive, so a start-phase timeout attached to streamCtx would tear down an
 // already-successful stream the moment the clock ran out.
 func (g *Gateway) startStreamWithStrategy(startCtx, streamCtx context.Context, req providers.Request) (providers.StreamProvider, string, <-chan providers.StreamChunk, error) {
-	g.mu.Lock()
-	g.ensureCircuitBreakersLocked()
-	g.ensureProviderLimitersLocked()
-	g.mu.Unlock()
-
-	orderedKeys, err := g.streamingTargetOrder(req)
+	// The target order comes from the same strategy Route executes, so both
+	// paths share one ordering implementation, and the targets are resolved
+	// from the same snapshot without taking g.mu.
+	snap, err := g.loadRouting()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	s, mode, err := g.requestStrategy(startCtx, snap)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	orderedKeys, err := strategies.SelectTargetsContext(startCtx, s, req)
 	if err != nil {
 		return nil, "", nil, err
 	}
-	g.mu.RLock()
-	mode := g.config.Strategy.Mode
-	g.mu.RUnlock()
 
 	var (
 		lastErr      error
@@ -385,9 +495,7 @@ func (g *Gateway) startStreamWithStrategy(startCtx, streamCtx context.Context, r
 		anyViable    bool
 	)
 	for _, key := range orderedKeys {
-		g.mu.RLock()
-		sp, ok := g.streamingProviderForTargetLocked(key, req.Model)
-		g.mu.RUnlock()
+		sp, ok := g.streamingProviderForTarget(snap, key, req.Model)
 		if !ok {
 			continue
 		}
@@ -410,8 +518,10 @@ func (g *Gateway) startStreamWithStrategy(startCtx, streamCtx context.Context, r
 	// runs regardless of strategy mode, exactly like the resolution-only
 	// fallback it replaces: it is never reached when at least one configured
 	// target was viable, so it never overrides a real fallback-mode failure
-	// above with an unconfigured provider.
-	if !anyViable {
+	// above with an unconfigured provider. A request bound to a residency
+	// requirement never takes it: an unconfigured provider has no region. Nor
+	// does one pinned to a target.
+	if !anyViable && snap.residencyOf(startCtx) == "" && pinnedTarget(startCtx) == "" {
 		g.mu.RLock()
 		name, sp, ok := g.resolveFallbackStreamProviderLocked(req.Model)
 		g.mu.RUnlock()
@@ -487,18 +597,6 @@ func raceCompleteStream(waitCtx, streamCtx context.Context, sp providers.StreamP
 	}
 }
 
-// streamingTargetOrder resolves the strategy — the same object Route executes —
-// and asks it for the streaming target order, so both paths share one ordering
-// implementation. A getStrategy error surfaces identically on both paths; for
-// ValidateConfig-passing gateways getStrategy does not error here.
-func (g *Gateway) streamingTargetOrder(req providers.Request) ([]string, error) {
-	s, err := g.getStrategy()
-	if err != nil {
-		return nil, err
-	}
-	return s.SelectTargets(req)
-}
-
 func responseStream(resp *providers.Response) <-chan providers.StreamChunk {
 	ch := make(chan providers.StreamChunk, 1)
 	streamChoices := make([]providers.StreamChoice, len(resp.Choices))
@@ -506,9 +604,10 @@ func responseStream(resp *providers.Response) <-chan providers.StreamChunk {
 		streamChoices[i] = providers.StreamChoice{
 			Index: c.Index,
 			Delta: providers.MessageDelta{
-				Role:      c.Message.Role,
-				Content:   c.Message.Content,
-				ToolCalls: c.Message.ToolCalls,
+				Role:             c.Message.Role,
+				Content:          c.Message.Content,
+				ToolCalls:        c.Message.ToolCalls,
+				ReasoningContent: c.Message.ReasoningContent,
 			},
 			FinishReason: c.FinishReason,
 		}
@@ -520,6 +619,8 @@ func responseStream(resp *providers.Response) <-chan providers.StreamChunk {
 		Model:   resp.Model,
 		Choices: streamChoices,
 		Usage:   &resp.Usage,
+
+		SystemFingerprint: resp.SystemFingerprint,
 	}
 	close(ch)
 	return ch
@@ -537,29 +638,38 @@ func (g *Gateway) resolveFallbackStreamProviderLocked(model string) (string, pro
 	if !ok {
 		return "", nil, false
 	}
-	if decorated, dok := decorateProvider(name, g.providers[name], g.circuitBreakers[name], g.limiters[name]).(providers.StreamProvider); dok {
+	p := budgetAttempts(name, g.providers[name], g.config.Strategy.RetryBudget != nil)
+	if decorated, dok := decorateProvider(name, p, g.circuitBreakers[name], g.limiters[name], g.outcomes).(providers.StreamProvider); dok {
 		return name, decorated, true
 	}
 	return name, fallback, true
 }
 
-// streamingProviderForTargetLocked resolves the streaming-capable provider
-// for a single configured target key, applying its circuit breaker and
-// concurrency limiter decoration. Caller must hold g.mu (a read lock is
-// sufficient).
-func (g *Gateway) streamingProviderForTargetLocked(key, model string) (providers.StreamProvider, bool) {
-	p, ok := g.providers[key]
+// streamingProviderForTarget resolves the streaming-capable provider for a
+// single configured target key from snap, applying its circuit breaker and
+// concurrency limiter decoration. A provider that cannot stream is usable only
+// when stream transcoding is configured.
+func (g *Gateway) streamingProviderForTarget(snap *routingSnapshot, key, model string) (providers.StreamProvider, bool) {
+	p, ok := snap.providers[key]
 	if !ok || !p.SupportsModel(model) {
 		return nil, false
 	}
 
 	sp, ok := p.(providers.StreamProvider)
 	if !ok {
-		return nil, false
+		if snap.transcoding == nil {
+			return nil, false
+		}
+		sp = transcodeStream(p, snap.transcoding)
+		p = sp
 	}
 
-	// Apply the circuit breaker and concurrency limit configured for this target.
-	if decorated, ok := decorateProvider(key, p, g.circuitBreakers[key], g.limiters[key]).(providers.StreamProvider); ok {
+	// Apply the parameter policy, image fetching, circuit breaker and
+	// concurrency limit configured for this target.
+	p = enforceParams(key, p, snap.params[key])
+	p = fetchImageURLs(key, p, snap.fetchImages[key])
+	p = budgetAttempts(key, p, snap.retryBudget)
+	if decorated, ok := decorateProvider(key, p, snap.circuitBreakers[key], snap.limiters[key], g.outcomes).(providers.StreamProvider); ok {
 		return decorated, true
 	}
 	return sp, true
@@ -698,3 +698,59 @@ func TestGateway_StreamingCostOrderFallsBackWithoutStreamingCandidates(t *testin
 	got := streamTargetOrder(t, gw, providers.Request{Model: "gpt-4o"})
 	requireKeys(t, got, "plain", "unsupported", "missing")
 }
+
+func TestGateway_RouteStream_JSONModeStripsFenceAndFlagsInvalidOutput(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "json-stream"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pieces []string
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: "json-stream", models: []string{"gpt-4o"}},
+		streamFn: func(context.Context, providers.Request) (<-chan providers.StreamChunk, error) {
+			ch := make(chan providers.StreamChunk, len(pieces))
+			for _, p := range pieces {
+				ch <- providers.StreamChunk{Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: p}}}}
+			}
+			close(ch)
+			return ch, nil
+		},
+	})
+	route := func(format string) (string, error) {
+		req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+		if format != "" {
+			req.ResponseFormat = &providers.ResponseFormat{Type: format}
+		}
+		ch, err := gw.RouteStream(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		var streamErr error
+		for chunk := range ch {
+			for _, c := range chunk.Choices {
+				b.WriteString(c.Delta.Content)
+			}
+			if chunk.Error != nil {
+				streamErr = chunk.Error
+			}
+		}
+		return b.String(), streamErr
+	}
+
+	pieces = []string{"```json\n{\"a\":", "1}\n```"}
+	if got, err := route("json_object"); err != nil || got != `{"a":1}` {
+		t.Fatalf("json_object stream = %q, %v; want the fence stripped", got, err)
+	}
+	if got, _ := route(""); got != "```json\n{\"a\":1}\n```" {
+		t.Fatalf("text stream = %q; want it untouched", got)
+	}
+
+	pieces = []string{`{"a":`, `1`}
+	if _, err := route("json_object"); err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Fatalf("truncated json_object stream error = %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Provider maintenance at runtime. SetTargetState drains, disables, or
+// re-enables a target without reloading the config, so plugin state, circuit
+// breakers, and rate limiters carry on as they were. The states it sets live
+// in their own copy-on-write map, take precedence over the targets' config
+// state, and last until the process exits; set state in the config for a
+// state that survives a restart.
+
+// ErrUnknownTarget is returned by SetTargetState for a name that is not a
+// configured target.
+var ErrUnknownTarget = errors.New("not a configured target")
+
+// errTargetDisabled cancels the requests in flight against a target when it
+// is disabled.
+var errTargetDisabled = errors.New("target disabled")
+
+// SetTargetState sets the state of the target name: TargetStateDraining,
+// TargetStateDisabled, or "" for active. Draining stops new requests and lets
+// those in flight finish; disabling also cancels the requests and streams in
+// flight against it.
+func (g *Gateway) SetTargetState(name, state string) error {
+	switch state {
+	case "", TargetStateDraining, TargetStateDisabled:
+	default:
+		return fmt.Errorf("invalid target state %q", state)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !slices.ContainsFunc(g.config.Targets, func(t Target) bool { return t.VirtualKey == name }) {
+		return fmt.Errorf("%w: %s", ErrUnknownTarget, name)
+	}
+	states := map[string]string{}
+	if cur := g.targetStates.Load(); cur != nil {
+		states = maps.Clone(*cur)
+	}
+	states[name] = state
+	g.targetStates.Store(&states)
+	if state == TargetStateDisabled {
+		if stop, ok := g.targetStops[name]; ok {
+			stop.cancel(errTargetDisabled)
+			delete(g.targetStops, name)
+		}
+	}
+	g.invalidateRoutingLocked()
+	return nil
+}
+
+// TargetState returns the state of the target name, set by SetTargetState or
+// else by its config, and false when name is not a configured target.
+func (g *Gateway) TargetState(name string) (string, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, t := range g.config.Targets {
+		if t.VirtualKey == name {
+			return g.targetState(t), true
+		}
+	}
+	return "", false
+}
+
+// targetState returns t's state, with a state set by SetTargetState taking
+// precedence over the config's.
+func (g *Gateway) targetState(t Target) string {
+	if states := g.targetStates.Load(); states != nil {
+		if state, ok := (*states)[t.VirtualKey]; ok {
+			return state
+		}
+	}
+	return t.State
+}
+
+// effectiveTargetsLocked returns the config's targets with the states set by
+// SetTargetState applied. Caller must hold g.mu.
+func (g *Gateway) effectiveTargetsLocked() []Target {
+	targets := slices.Clone(g.config.Targets)
+	for i := range targets {
+		targets[i].State = g.targetState(targets[i])
+	}
+	return targets
+}
+
+// targetStop is canceled when its target is disabled.
+type targetStop struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// targetStopLocked returns the context that disabling the target name
+// cancels, creating it on first use. Caller must hold g.mu.
+func (g *Gateway) targetStopLocked(name string) context.Context {
+	stop, ok := g.targetStops[name]
+	if !ok {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		stop = targetStop{ctx: ctx, cancel: cancel}
+		g.targetStops[name] = stop
+	}
+	return stop.ctx
+}
+
+// stoppableProvider cancels its requests when stop is canceled, which
+// disabling its target does.
+type stoppableProvider struct {
+	providers.Provider
+	name string
+	stop context.Context
+}
+
+// stopOnDisable wraps p so disabling the target name cancels its requests in
+// flight.
+func stopOnDisable(name string, p providers.Provider, stop context.Context) providers.Provider {
+	if stop == nil {
+		return p
+	}
+	return &stoppableProvider{Provider: p, name: name, stop: stop}
+}
+
+// withStop returns ctx canceled when the target is disabled, and the func
+// that releases it.
+func (p *stoppableProvider) withStop(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	release := context.AfterFunc(p.stop, func() { cancel(context.Cause(p.stop)) })
+	return ctx, func() {
+		release()
+		cancel(nil)
+	}
+}
+
+// disabledErr returns err, or the target's disabled error when disabling it
+// canceled the request.
+func (p *stoppableProvider) disabledErr(err error) error {
+	if err != nil && p.stop.Err() != nil {
+		return fmt.Errorf("provider %s: %w", p.name, errTargetDisabled)
+	}
+	return err
+}
+
+func (p *stoppableProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	ctx, release := p.withStop(ctx)
+	defer release()
+	resp, err := p.Provider.Complete(ctx, req)
+	return resp, p.disabledErr(err)
+}
+
+func (p *stoppableProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	streamCtx, release := p.withStop(ctx)
+	upstream, err := sp.CompleteStream(streamCtx, req)
+	if err != nil {
+		release()
+		return nil, p.disabledErr(err)
+	}
+	out := make(chan providers.StreamChunk)
+	go func() {
+		defer release()
+		defer close(out)
+		for chunk := range upstream {
+			if p.stop.Err() != nil {
+				break
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// The consumer abandoned the stream; keep draining so the
+				// provider's sender can finish.
+				//nolint:revive // empty-block: consuming the remaining chunks IS the work
+				for range upstream {
+				}
+				return
+			}
+		}
+		if p.stop.Err() == nil {
+			return
+		}
+		// Disabled mid-stream: end it with an error, not a clean finish.
+		select {
+		case out <- providers.StreamChunk{Error: p.disabledErr(errTargetDisabled)}:
+		case <-ctx.Done():
+		}
+		//nolint:revive // empty-block: consuming the remaining chunks IS the work
+		for range upstream {
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestGateway_DrainingTargetGetsNoNewRequests(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: "primary"}, {VirtualKey: "secondary"}},
+	}
+	gw, err := newTestGateway(t, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, name := range []string{"primary", "secondary", "unlisted"} {
+		gw.RegisterProvider(&mockProvider{name: name, models: []string{"gpt-4o"}, resp: &providers.Response{ID: name}})
+	}
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	servedBy := func() string {
+		t.Helper()
+		resp, err := gw.Route(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		return resp.ID
+	}
+
+	if got := servedBy(); got != "primary" {
+		t.Fatalf("served by %s, want primary", got)
+	}
+
+	cfg.Targets = []Target{{VirtualKey: "primary", State: TargetStateDraining}, {VirtualKey: "secondary"}}
+	if err := gw.ReloadConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	if got := servedBy(); got != "secondary" {
+		t.Errorf("draining: served by %s, want secondary", got)
+	}
+	keys, _, err := gw.surfaceTargetOrder(context.Background(), "gpt-4o", surfaceEmbeddings, models.Usage{PromptTokens: 1})
+	if err != nil || !slices.Equal(keys, []string{"secondary"}) {
+		t.Errorf("surface order = %v, %v; want [secondary]", keys, err)
+	}
+
+	// With every target out of service, the registry fallback must not pick
+	// a disabled one back up either.
+	cfg.Targets = []Target{{VirtualKey: "primary", State: TargetStateDraining}, {VirtualKey: "secondary", State: TargetStateDisabled}}
+	if err := gw.ReloadConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	gw.mu.RLock()
+	p, ok := gw.findProviderByModelLocked("gpt-4o")
+	gw.mu.RUnlock()
+	if !ok || p.Name() != "unlisted" {
+		t.Errorf("registry fallback = %v, %v; want the in-service unlisted provider", p, ok)
+	}
+
+	cfg.Targets = []Target{{VirtualKey: "primary"}, {VirtualKey: "secondary"}}
+	if err := gw.ReloadConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	if got := servedBy(); got != "primary" {
+		t.Errorf("re-enabled: served by %s, want primary", got)
+	}
+}
+
+func TestValidateConfig_TargetState(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "openai", State: "paused"}},
+	}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("expected an unknown target state to be rejected")
+	}
+	cfg.Targets[0].State = TargetStateDisabled
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("disabled state rejected: %v", err)
+	}
+}
+
+func TestGateway_SetTargetState(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "primary"}},
+	}
+	gw, err := newTestGateway(t, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	gw.RegisterProvider(&mockProvider{name: "primary", models: []string{"gpt-4o"}, completeFn: func(ctx context.Context, _ providers.Request) (*providers.Response, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return &providers.Response{ID: "primary"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}})
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	inFlight := func() <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := gw.Route(context.Background(), req)
+			done <- err
+		}()
+		<-started
+		return done
+	}
+	plugins := gw.plugins
+
+	// Draining refuses new requests and lets the one in flight finish.
+	done := inFlight()
+	if err := gw.SetTargetState("primary", TargetStateDraining); err != nil {
+		t.Fatalf("SetTargetState: %v", err)
+	}
+	if _, err := gw.Route(context.Background(), req); err == nil {
+		t.Error("a draining target took a new request")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("in-flight request on a draining target: %v", err)
+	}
+	if state, _ := gw.TargetState("primary"); state != TargetStateDraining {
+		t.Errorf("TargetState = %q, want draining", state)
+	}
+	if gw.plugins != plugins || gw.config.Targets[0].State != "" {
+		t.Error("draining reloaded the config")
+	}
+
+	// Disabling cancels the request in flight.
+	release = make(chan struct{})
+	if err := gw.SetTargetState("primary", ""); err != nil {
+		t.Fatalf("SetTargetState: %v", err)
+	}
+	done = inFlight()
+	if err := gw.SetTargetState("primary", TargetStateDisabled); err != nil {
+		t.Fatalf("SetTargetState: %v", err)
+	}
+	if err := <-done; !errors.Is(err, errTargetDisabled) {
+		t.Errorf("in-flight request on a disabled target: err = %v, want errTargetDisabled", err)
+	}
+
+	// Re-enabled, it serves again.
+	if err := gw.SetTargetState("primary", ""); err != nil {
+		t.Fatalf("SetTargetState: %v", err)
+	}
+	done = inFlight()
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("re-enabled: %v", err)
+	}
+
+	if err := gw.SetTargetState("missing", TargetStateDraining); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("unknown target: err = %v, want ErrUnknownTarget", err)
+	}
+	if err := gw.SetTargetState("primary", "paused"); err == nil {
+		t.Error("expected an unknown state to be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+package aigateway
+
+import (
+	"context"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// thinkingLimitFor returns the thinking limit of ctx's request: its key's,
+// else its workspace's, else the default.
+func thinkingLimitFor(ctx context.Context, cfg *ThinkingConfig) (ThinkingLimit, bool) {
+	if cfg == nil {
+		return ThinkingLimit{}, false
+	}
+	if keyID, ok := authctx.KeyID(ctx); ok {
+		if l, ok := cfg.Keys[keyID]; ok {
+			return l, true
+		}
+	}
+	if id, ok := authctx.Identity(ctx); ok && id.Workspace != "" {
+		if l, ok := cfg.Workspaces[id.Workspace]; ok {
+			return l, true
+		}
+	}
+	if cfg.Default != nil {
+		return *cfg.Default, true
+	}
+	return ThinkingLimit{}, false
+}
+
+// capThinking bounds req's thinking budget, and its reasoning_effort, by its
+// caller's limit. The effort is capped at the one the limit's budget maps to
+// (see core.EffortForBudget). The thinking option is replaced, not edited,
+// since the caller may share it.
+func capThinking(ctx context.Context, cfg *ThinkingConfig, req *providers.Request) {
+	limit, ok := thinkingLimitFor(ctx, cfg)
+	if !ok || limit.MaxBudgetTokens == 0 {
+		return
+	}
+	if req.ReasoningEffort != "" {
+		if maxEffort := core.EffortForBudget(limit.MaxBudgetTokens); core.EffortExceeds(req.ReasoningEffort, maxEffort) {
+			logging.FromContext(ctx).Debug("reasoning effort capped",
+				"model", req.Model, "reasoning_effort", req.ReasoningEffort, "max_budget_tokens", limit.MaxBudgetTokens)
+			req.ReasoningEffort = maxEffort
+		}
+	}
+	t := req.Thinking
+	if t == nil || !t.Enabled {
+		return
+	}
+	if t.BudgetTokens > 0 && t.BudgetTokens <= limit.MaxBudgetTokens {
+		return
+	}
+	logging.FromContext(ctx).Debug("thinking budget capped",
+		"model", req.Model, "budget_tokens", t.BudgetTokens, "max_budget_tokens", limit.MaxBudgetTokens)
+	req.Thinking = &providers.Thinking{Enabled: true, BudgetTokens: limit.MaxBudgetTokens}
+}
@@ -0,0 +1,114 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestRoute_ThinkingCappedPerKey(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: mockProviderName}},
+		Thinking: &ThinkingConfig{
+			Default: &ThinkingLimit{MaxBudgetTokens: 8000},
+			Keys:    map[string]ThinkingLimit{"key-a": {MaxBudgetTokens: 2000}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var sent *providers.Thinking
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"claude-sonnet-4-6"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			sent = req.Thinking
+			return &providers.Response{ID: "ok", Model: req.Model, Choices: []providers.Choice{{
+				Message: providers.Message{Role: "assistant", Content: "42", ReasoningContent: "six times seven"},
+			}}, Usage: providers.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30}}, nil
+		},
+	})
+
+	for _, tt := range []struct {
+		name     string
+		keyID    string
+		thinking providers.Thinking
+		want     providers.Thinking
+	}{
+		{"key cap", "key-a", providers.Thinking{Enabled: true, BudgetTokens: 16000}, providers.Thinking{Enabled: true, BudgetTokens: 2000}},
+		{"default cap", "key-b", providers.Thinking{Enabled: true, BudgetTokens: 16000}, providers.Thinking{Enabled: true, BudgetTokens: 8000}},
+		{"no budget given the cap", "key-a", providers.Thinking{Enabled: true}, providers.Thinking{Enabled: true, BudgetTokens: 2000}},
+		{"within cap", "key-b", providers.Thinking{Enabled: true, BudgetTokens: 4000}, providers.Thinking{Enabled: true, BudgetTokens: 4000}},
+		{"disabled", "key-a", providers.Thinking{}, providers.Thinking{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			thinking := tt.thinking
+			resp, err := gw.Route(authctx.WithKeyID(context.Background(), tt.keyID), providers.Request{
+				Model:    "claude-sonnet-4-6",
+				Messages: []providers.Message{{Role: "user", Content: "6*7?"}},
+				Thinking: &thinking,
+			})
+			if err != nil {
+				t.Fatalf("Route: %v", err)
+			}
+			if sent == nil || *sent != tt.want {
+				t.Fatalf("thinking sent = %+v, want %+v", sent, tt.want)
+			}
+			if thinking != tt.thinking {
+				t.Fatalf("caller's thinking option modified: %+v", thinking)
+			}
+			// The provider counted the thinking among the completion tokens
+			// without saying how many; the gateway estimates them.
+			if resp.Usage.ReasoningTokens != 5 {
+				t.Fatalf("reasoning tokens = %d, want 5", resp.Usage.ReasoningTokens)
+			}
+		})
+	}
+}
+
+// reasoning_effort is capped at the effort the limit's budget maps to.
+func TestCapThinking_ReasoningEffort(t *testing.T) {
+	cfg := &ThinkingConfig{Default: &ThinkingLimit{MaxBudgetTokens: 2000}}
+	for effort, want := range map[string]string{
+		"high":    "low",
+		"xhigh":   "low",
+		"medium":  "low",
+		"low":     "low",
+		"minimal": "minimal",
+		"":        "",
+	} {
+		req := providers.Request{Model: "o3", ReasoningEffort: effort}
+		capThinking(context.Background(), cfg, &req)
+		if req.ReasoningEffort != want {
+			t.Errorf("reasoning_effort %q: sent %q, want %q", effort, req.ReasoningEffort, want)
+		}
+	}
+}
+
+func TestValidateConfig_Thinking(t *testing.T) {
+	base := Config{Strategy: StrategyConfig{Mode: ModeSingle}, Targets: []Target{{VirtualKey: "openai"}}}
+	for _, tt := range []struct {
+		name string
+		cfg  ThinkingConfig
+		ok   bool
+	}{
+		{name: "valid", cfg: ThinkingConfig{
+			Default:    &ThinkingLimit{MaxBudgetTokens: 8000},
+			Workspaces: map[string]ThinkingLimit{"a": {MaxBudgetTokens: 0}},
+		}, ok: true},
+		{name: "negative default", cfg: ThinkingConfig{Default: &ThinkingLimit{MaxBudgetTokens: -1}}},
+		{name: "negative key", cfg: ThinkingConfig{Keys: map[string]ThinkingLimit{"k": {MaxBudgetTokens: -1}}}},
+		{name: "below the minimum budget", cfg: ThinkingConfig{Workspaces: map[string]ThinkingLimit{"a": {MaxBudgetTokens: 500}}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.Thinking = &tt.cfg
+			if err := ValidateConfig(cfg); (err == nil) != tt.ok {
+				t.Fatalf("ValidateConfig() error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
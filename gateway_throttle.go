@@ -0,0 +1,89 @@
+package aigateway
+
+import "time"
+
+// Adaptive throttling: a 429 from a target is not counted against its circuit
+// breaker, since rate limits are expected and temporary, but sending it an
+// unchanged share of traffic only collects more of them. Instead each 429
+// halves the target's throttle factor, which scales its load-balancing weight.
+// The factor holds for the upstream's Retry-After hint, then recovers linearly
+// to 1 over throttleRecovery. A burst of 429s from requests already in flight
+// cuts the factor once, not once per response.
+const (
+	// minThrottleFactor keeps a throttled target in rotation, so it is still
+	// probed and its recovery observed.
+	minThrottleFactor = 1.0 / 16
+	// defaultThrottleHold applies when a 429 carries no Retry-After hint.
+	defaultThrottleHold = 5 * time.Second
+	// maxThrottleHold caps how long a Retry-After hint can pin the factor.
+	maxThrottleHold = 5 * time.Minute
+	// throttleRecovery is how long a factor takes to climb from its floor back
+	// to 1 once the hold ends.
+	throttleRecovery = time.Minute
+	// throttleDebounce is the minimum interval between two cuts.
+	throttleDebounce = time.Second
+)
+
+// throttleState is one target's throttle: the factor set by the last cut,
+// held until until and recovering after it.
+type throttleState struct {
+	base    float64
+	until   time.Time
+	lastCut time.Time
+}
+
+// factorAt returns the throttle factor at now.
+func (s *throttleState) factorAt(now time.Time) float64 {
+	if now.Before(s.until) {
+		return s.base
+	}
+	recovered := float64(now.Sub(s.until)) / float64(throttleRecovery)
+	if recovered >= 1 {
+		return 1
+	}
+	return s.base + (1-s.base)*recovered
+}
+
+// throttleLocked records a 429 from target. retryAfter is the upstream hint, or 0.
+// Caller must hold t.mu.
+func (t *outcomeTracker) throttleLocked(target string, retryAfter time.Duration, now time.Time) {
+	hold := retryAfter
+	if hold <= 0 {
+		hold = defaultThrottleHold
+	}
+	hold = min(hold, maxThrottleHold)
+
+	s := t.throttles[target]
+	if s == nil {
+		s = &throttleState{base: 1}
+		t.throttles[target] = s
+	}
+	if now.Sub(s.lastCut) >= throttleDebounce {
+		s.base = max(s.factorAt(now)/2, minThrottleFactor)
+		s.lastCut = now
+	}
+	if until := now.Add(hold); until.After(s.until) {
+		s.until = until
+	}
+}
+
+// throttleFactor returns target's current throttle factor: 1 when it is not
+// throttled, down to minThrottleFactor. It is a strategies.WeightScale.
+func (t *outcomeTracker) throttleFactor(target string) float64 {
+	if t == nil {
+		return 1
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.throttles[target]
+	if s == nil {
+		return 1
+	}
+	f := s.factorAt(t.clock())
+	if f >= 1 {
+		// Fully recovered: drop the entry so unthrottled targets cost one map
+		// miss.
+		delete(t.throttles, target)
+	}
+	return f
+}
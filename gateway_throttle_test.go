@@ -0,0 +1,103 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+func rateLimited(retryAfter time.Duration) error {
+	return &core.HTTPStatusError{StatusCode: 429, Message: "API error (429): rate limited", RetryAfter: retryAfter}
+}
+
+func TestOutcomeTracker_Throttle(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tr := newOutcomeTracker(0)
+	tr.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if got := tr.throttleFactor("a"); got != 1 {
+		t.Fatalf("unthrottled factor = %v, want 1", got)
+	}
+
+	tr.record(ctx, "a", rateLimited(10*time.Second))
+	if got := tr.throttleFactor("a"); got != 0.5 {
+		t.Fatalf("factor after one 429 = %v, want 0.5", got)
+	}
+	// A burst of 429s from requests already in flight cuts once.
+	tr.record(ctx, "a", rateLimited(0))
+	if got := tr.throttleFactor("a"); got != 0.5 {
+		t.Errorf("factor after a debounced 429 = %v, want 0.5", got)
+	}
+	if requests, _ := tr.counts("a"); requests != 0 {
+		t.Errorf("a 429 was counted as an outcome: requests = %d", requests)
+	}
+
+	// The factor holds for Retry-After, then recovers linearly.
+	now = now.Add(10 * time.Second)
+	if got := tr.throttleFactor("a"); got != 0.5 {
+		t.Errorf("factor at the end of the hold = %v, want 0.5", got)
+	}
+	now = now.Add(throttleRecovery / 2)
+	if got := tr.throttleFactor("a"); got != 0.75 {
+		t.Errorf("factor halfway through recovery = %v, want 0.75", got)
+	}
+	now = now.Add(throttleRecovery / 2)
+	if got := tr.throttleFactor("a"); got != 1 {
+		t.Errorf("factor after recovery = %v, want 1", got)
+	}
+	if _, ok := tr.throttles["a"]; ok {
+		t.Error("a recovered target's throttle state was kept")
+	}
+
+	// Repeated 429s floor the factor rather than starving the target.
+	for range 10 {
+		now = now.Add(throttleDebounce)
+		tr.record(ctx, "b", rateLimited(0))
+	}
+	if got := tr.throttleFactor("b"); got != minThrottleFactor {
+		t.Errorf("factor after repeated 429s = %v, want %v", got, minThrottleFactor)
+	}
+	if got := tr.throttleFactor("a"); got != 1 {
+		t.Errorf("throttling b changed a's factor to %v", got)
+	}
+
+	var nilTracker *outcomeTracker
+	if got := nilTracker.throttleFactor("a"); got != 1 {
+		t.Errorf("nil tracker factor = %v, want 1", got)
+	}
+}
+
+func TestGateway_ThrottlesRateLimitedTarget(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeLoadBalance},
+		Targets:  []Target{{VirtualKey: "limited", Weight: 1}, {VirtualKey: "spare", Weight: 1}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	limited := &mockProvider{name: "limited", models: []string{"gpt-4o"}, err: rateLimited(time.Minute)}
+	spare := &mockProvider{name: "spare", models: []string{"gpt-4o"}, resp: &providers.Response{Provider: "spare"}}
+	gw.RegisterProvider(limited)
+	gw.RegisterProvider(spare)
+
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	// Route until the load balancer has picked the rate-limited target once.
+	for range 100 {
+		if _, err := gw.Route(context.Background(), req); err != nil {
+			break
+		}
+	}
+
+	state := gw.RoutingState()
+	factors := map[string]float64{}
+	for _, target := range state.Targets {
+		factors[target.VirtualKey] = target.ThrottleFactor
+	}
+	if factors["limited"] != 0.5 || factors["spare"] != 1 {
+		t.Errorf("throttle factors = %v, want limited 0.5 and spare 1", factors)
+	}
+}
@@ -0,0 +1,261 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/internal/ratelimit"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Per-key rate-limit tiers. A key names its tier (admin.APIKey.Tier); the auth
+// middleware carries that name on the request context, and Route/RouteStream
+// admit the request against the tier's limits before any plugin or provider
+// runs.
+//
+// Limit state is keyed by API key ID and lives for the gateway's lifetime, not
+// a config's: a reload that edits a tier must not hand every key a fresh bucket
+// or, worse, a fresh month of tokens. A key whose tier's rate changes gets a new
+// bucket on its next request. It is not persisted or shared: each process
+// enforces the limits on its own, and a restart starts the month's token
+// tally over.
+//
+// The gateway-wide stream cap (Config.MaxConcurrentStreams) is counted here
+// too, so a stream takes its key's slot and the gateway's under one lock.
+
+// tierKeyState is one key's usage against its tier.
+type tierKeyState struct {
+	bucket      *ratelimit.Limiter
+	bucketRate  float64
+	bucketBurst float64
+	streams     int
+	month       string // "2006-01" in UTC; tokens resets when it changes
+	tokens      int64
+	// exhausted records that the key's running out of monthly tokens was
+	// published, so it is published once a month rather than per refusal.
+	exhausted bool
+}
+
+// tierEnforcer applies RateLimitTier limits per API key.
+type tierEnforcer struct {
+	mu      sync.Mutex
+	keys    map[string]*tierKeyState
+	streams int // gateway-wide streams holding a slot
+	now     func() time.Time
+}
+
+func newTierEnforcer() *tierEnforcer {
+	return &tierEnforcer{
+		keys: make(map[string]*tierKeyState),
+		now:  time.Now,
+	}
+}
+
+// stateLocked returns keyID's state, rolling its token tally over when a new
+// month has begun. Caller must hold e.mu.
+func (e *tierEnforcer) stateLocked(keyID string) *tierKeyState {
+	st, ok := e.keys[keyID]
+	if !ok {
+		st = &tierKeyState{}
+		e.keys[keyID] = st
+	}
+	if month := e.now().UTC().Format("2006-01"); st.month != month {
+		st.month = month
+		st.tokens = 0
+		st.exhausted = false
+	}
+	return st
+}
+
+// admit checks one request from keyID against tier. A stream admission takes a
+// concurrent-stream slot the caller must hand back with releaseStream.
+//
+// The checks run cheapest-to-refund first: a request refused for its monthly
+// tokens or its stream slot must not also have spent a rate-limit token.
+func (e *tierEnforcer) admit(keyID string, tier RateLimitTier, stream bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.stateLocked(keyID)
+	if tier.MonthlyTokenLimit > 0 && st.tokens >= tier.MonthlyTokenLimit {
+		return fmt.Errorf("%w: tier %q monthly token limit of %d reached", providers.ErrTierLimitExceeded, tier.Name, tier.MonthlyTokenLimit)
+	}
+	if stream && tier.MaxConcurrentStreams > 0 && st.streams >= tier.MaxConcurrentStreams {
+		return fmt.Errorf("%w: %w: tier %q allows %d concurrent streams", providers.ErrStreamLimitExceeded, providers.ErrTierLimitExceeded, tier.Name, tier.MaxConcurrentStreams)
+	}
+	if tier.RequestsPerSecond > 0 {
+		if st.bucket == nil || st.bucketRate != tier.RequestsPerSecond || st.bucketBurst != tier.Burst {
+			st.bucket = ratelimit.New(tier.RequestsPerSecond, tier.Burst)
+			st.bucketRate = tier.RequestsPerSecond
+			st.bucketBurst = tier.Burst
+		}
+		if !st.bucket.Allow() {
+			return fmt.Errorf("%w: tier %q allows %g requests per second", providers.ErrTierLimitExceeded, tier.Name, tier.RequestsPerSecond)
+		}
+	}
+	if stream {
+		st.streams++
+	}
+	return nil
+}
+
+// releaseStream returns a concurrent-stream slot taken by admit.
+func (e *tierEnforcer) releaseStream(keyID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if st, ok := e.keys[keyID]; ok && st.streams > 0 {
+		st.streams--
+	}
+}
+
+// acquireGlobalStream takes one of the max gateway-wide stream slots; the
+// caller hands it back with releaseGlobalStream.
+func (e *tierEnforcer) acquireGlobalStream(maxStreams int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.streams >= maxStreams {
+		return fmt.Errorf("%w: the gateway allows %d concurrent streams", providers.ErrStreamLimitExceeded, maxStreams)
+	}
+	e.streams++
+	return nil
+}
+
+// releaseGlobalStream returns a slot taken by acquireGlobalStream.
+func (e *tierEnforcer) releaseGlobalStream() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.streams > 0 {
+		e.streams--
+	}
+}
+
+// recordTokens adds a completed request's token usage to keyID's monthly tally.
+func (e *tierEnforcer) recordTokens(keyID string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stateLocked(keyID).tokens += int64(tokens)
+}
+
+// markExhausted reports whether keyID has used up limit monthly tokens and
+// has not been reported as having done so this month, and marks it reported.
+func (e *tierEnforcer) markExhausted(keyID string, limit int64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.stateLocked(keyID)
+	if st.exhausted || st.tokens < limit {
+		return false
+	}
+	st.exhausted = true
+	return true
+}
+
+// monthlyTokens reports keyID's token usage for the current month.
+func (e *tierEnforcer) monthlyTokens(keyID string) int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stateLocked(keyID).tokens
+}
+
+// tierAdmission is the outcome of admitting a request against its key's tier
+// and the gateway-wide stream cap. The zero value means the request is not
+// limited, and every method on it is then a no-op.
+type tierAdmission struct {
+	enforcer *tierEnforcer
+	keyID    string // set when the request is tier-limited
+	tier     string
+	stream   bool // holds a slot of the key's tier
+	global   bool // holds a gateway-wide stream slot
+	once     sync.Once
+}
+
+// done records the request's token usage and, for a stream, frees its slots.
+// Safe to call more than once; only the first call counts.
+func (a *tierAdmission) done(tokens int) {
+	if a == nil || a.enforcer == nil {
+		return
+	}
+	a.once.Do(func() {
+		if a.keyID != "" {
+			a.enforcer.recordTokens(a.keyID, tokens)
+		}
+		if a.stream {
+			a.enforcer.releaseStream(a.keyID)
+		}
+		if a.global {
+			a.enforcer.releaseGlobalStream()
+		}
+		if a.stream || a.global {
+			metrics.StreamSlotsInUse.WithLabelValues(a.tier).Dec()
+		}
+	})
+}
+
+// admitTier admits the request carried by ctx against the tier its API key is
+// assigned and, for a stream, against maxStreams gateway-wide (0 means no
+// cap). tiers and maxStreams come from the config snapshot the caller took
+// under g.mu. Requests without an authenticated key, without a tier, or
+// naming a tier that is no longer configured are not tier-limited. The first
+// refusal of a month for a key out of monthly tokens publishes
+// SubjectKeyQuotaExceeded.
+func (g *Gateway) admitTier(ctx context.Context, tiers []RateLimitTier, stream bool, maxStreams int) (*tierAdmission, error) {
+	a := &tierAdmission{enforcer: g.tiers}
+	if stream && maxStreams > 0 {
+		if err := g.tiers.acquireGlobalStream(maxStreams); err != nil {
+			metrics.StreamLimitRejectionsTotal.WithLabelValues("gateway").Inc()
+			return nil, err
+		}
+		a.global = true
+	}
+	if tier, keyID, ok := requestTier(ctx, tiers); ok {
+		if err := g.tiers.admit(keyID, tier, stream); err != nil {
+			if a.global {
+				g.tiers.releaseGlobalStream()
+			}
+			if errors.Is(err, providers.ErrStreamLimitExceeded) {
+				metrics.StreamLimitRejectionsTotal.WithLabelValues("key").Inc()
+			}
+			if tier.MonthlyTokenLimit > 0 && g.tiers.markExhausted(keyID, tier.MonthlyTokenLimit) {
+				g.publishQuotaExceeded(ctx, keyID, fmt.Sprintf("tier %q monthly token limit of %d reached", tier.Name, tier.MonthlyTokenLimit))
+			}
+			return nil, err
+		}
+		a.keyID, a.tier, a.stream = keyID, tier.Name, stream
+	}
+	if !a.global && a.keyID == "" {
+		return nil, nil
+	}
+	if a.stream || a.global {
+		metrics.StreamSlotsInUse.WithLabelValues(a.tier).Inc()
+	}
+	return a, nil
+}
+
+// requestTier returns the configured tier assigned to the API key that
+// authenticated ctx, and that key's ID.
+func requestTier(ctx context.Context, tiers []RateLimitTier) (RateLimitTier, string, bool) {
+	if len(tiers) == 0 {
+		return RateLimitTier{}, "", false
+	}
+	name, ok := authctx.Tier(ctx)
+	if !ok {
+		return RateLimitTier{}, "", false
+	}
+	keyID, ok := authctx.KeyID(ctx)
+	if !ok {
+		return RateLimitTier{}, "", false
+	}
+	for _, t := range tiers {
+		if t.Name == name {
+			return t, keyID, true
+		}
+	}
+	return RateLimitTier{}, "", false
+}
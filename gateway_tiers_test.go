@@ -0,0 +1,267 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func tierContext(keyID, tier string) context.Context {
+	return authctx.WithTier(authctx.WithKeyID(context.Background(), keyID), tier)
+}
+
+func tierStreams(e *tierEnforcer, keyID string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if st, ok := e.keys[keyID]; ok {
+		return st.streams
+	}
+	return 0
+}
+
+func newTieredGateway(t *testing.T, tiers ...RateLimitTier) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy:       StrategyConfig{Mode: ModeSingle},
+		Targets:        []Target{{VirtualKey: mockProviderName}},
+		RateLimitTiers: tiers,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{mockProvider: mockProvider{
+		name:   mockProviderName,
+		models: []string{"gpt-4o"},
+		resp:   &providers.Response{ID: "ok", Usage: providers.Usage{TotalTokens: 40}},
+	}})
+	return gw
+}
+
+func TestTierEnforcer_RequestsPerSecond(t *testing.T) {
+	e := newTierEnforcer()
+	tier := RateLimitTier{Name: "free", RequestsPerSecond: 1, Burst: 2}
+
+	for i := range 2 {
+		if err := e.admit("key-a", tier, false); err != nil {
+			t.Fatalf("request %d within burst rejected: %v", i, err)
+		}
+	}
+	if err := e.admit("key-a", tier, false); !errors.Is(err, providers.ErrTierLimitExceeded) {
+		t.Fatalf("request past burst: got %v, want ErrTierLimitExceeded", err)
+	}
+	if err := e.admit("key-b", tier, false); err != nil {
+		t.Fatalf("another key on the same tier must have its own bucket: %v", err)
+	}
+}
+
+func TestTierEnforcer_ConcurrentStreams(t *testing.T) {
+	e := newTierEnforcer()
+	tier := RateLimitTier{Name: "free", MaxConcurrentStreams: 1}
+
+	if err := e.admit("key-a", tier, true); err != nil {
+		t.Fatalf("first stream rejected: %v", err)
+	}
+	if err := e.admit("key-a", tier, true); !errors.Is(err, providers.ErrTierLimitExceeded) {
+		t.Fatalf("second concurrent stream: got %v, want ErrTierLimitExceeded", err)
+	}
+	if err := e.admit("key-a", tier, false); err != nil {
+		t.Fatalf("non-streaming request must not count against the stream cap: %v", err)
+	}
+	e.releaseStream("key-a")
+	if err := e.admit("key-a", tier, true); err != nil {
+		t.Fatalf("stream after release rejected: %v", err)
+	}
+}
+
+func TestTierEnforcer_MonthlyTokensResetWithTheMonth(t *testing.T) {
+	e := newTierEnforcer()
+	now := time.Date(2026, time.January, 31, 23, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	tier := RateLimitTier{Name: "free", MonthlyTokenLimit: 100}
+
+	e.recordTokens("key-a", 100)
+	if err := e.admit("key-a", tier, false); !errors.Is(err, providers.ErrTierLimitExceeded) {
+		t.Fatalf("request over the monthly cap: got %v, want ErrTierLimitExceeded", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := e.admit("key-a", tier, false); err != nil {
+		t.Fatalf("request in a new month rejected: %v", err)
+	}
+	if got := e.monthlyTokens("key-a"); got != 0 {
+		t.Fatalf("monthly tokens after rollover = %d, want 0", got)
+	}
+}
+
+func TestTierEnforcer_MarkExhaustedOncePerMonth(t *testing.T) {
+	e := newTierEnforcer()
+	now := time.Date(2026, time.January, 31, 23, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	e.recordTokens("key-a", 99)
+	if e.markExhausted("key-a", 100) {
+		t.Fatal("a key under its limit is not exhausted")
+	}
+	e.recordTokens("key-a", 1)
+	if !e.markExhausted("key-a", 100) || e.markExhausted("key-a", 100) {
+		t.Fatal("an exhausted key should be marked once")
+	}
+
+	now = now.Add(2 * time.Hour)
+	e.recordTokens("key-a", 100)
+	if !e.markExhausted("key-a", 100) {
+		t.Fatal("a key exhausted again in a new month should be marked again")
+	}
+}
+
+func TestRoute_EnforcesTierMonthlyTokens(t *testing.T) {
+	gw := newTieredGateway(t, RateLimitTier{Name: "free", MonthlyTokenLimit: 50})
+	ctx := tierContext("key-a", "free")
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	if _, err := gw.Route(ctx, req); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if got := gw.tiers.monthlyTokens("key-a"); got != 40 {
+		t.Fatalf("recorded tokens = %d, want 40", got)
+	}
+	if _, err := gw.Route(ctx, req); err != nil {
+		t.Fatalf("second request under the cap: %v", err)
+	}
+	if _, err := gw.Route(ctx, req); !errors.Is(err, providers.ErrTierLimitExceeded) {
+		t.Fatalf("request over the cap: got %v, want ErrTierLimitExceeded", err)
+	}
+
+	// Untiered and unknown-tier callers are not limited.
+	if _, err := gw.Route(authctx.WithKeyID(context.Background(), "key-a"), req); err != nil {
+		t.Fatalf("untiered request: %v", err)
+	}
+	if _, err := gw.Route(tierContext("key-a", "retired"), req); err != nil {
+		t.Fatalf("unknown-tier request: %v", err)
+	}
+}
+
+func TestRoute_TierRequestsPerSecond(t *testing.T) {
+	gw := newTieredGateway(t, RateLimitTier{Name: "free", RequestsPerSecond: 1})
+	ctx := tierContext("key-a", "free")
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	if _, err := gw.Route(ctx, req); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if _, err := gw.Route(ctx, req); !errors.Is(err, providers.ErrTierLimitExceeded) {
+		t.Fatalf("second request in the same second: got %v, want ErrTierLimitExceeded", err)
+	}
+}
+
+func TestRouteStream_TierStreamSlotReleasedWhenStreamEnds(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy:       StrategyConfig{Mode: ModeSingle},
+		Targets:        []Target{{VirtualKey: mockProviderName}},
+		RateLimitTiers: []RateLimitTier{{Name: "free", MaxConcurrentStreams: 1}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	upstream := make(chan providers.StreamChunk)
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: mockProviderName, models: []string{"gpt-4o"}},
+		streamFn: func(context.Context, providers.Request) (<-chan providers.StreamChunk, error) {
+			return upstream, nil
+		},
+	})
+	ctx := tierContext("key-a", "free")
+	req := providers.Request{Model: "gpt-4o", Stream: true, Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	ch, err := gw.RouteStream(ctx, req)
+	if err != nil {
+		t.Fatalf("first stream: %v", err)
+	}
+	if _, err := gw.RouteStream(ctx, req); !errors.Is(err, providers.ErrTierLimitExceeded) || !errors.Is(err, providers.ErrStreamLimitExceeded) {
+		t.Fatalf("concurrent stream: got %v, want ErrTierLimitExceeded and ErrStreamLimitExceeded", err)
+	}
+
+	close(upstream)
+	drainStream(t, ch)
+	// The meter releases the slot after the channel closes; wait for it rather
+	// than racing the finisher.
+	waitFor(t, func() bool { return tierStreams(gw.tiers, "key-a") == 0 })
+}
+
+func TestRouteStream_GatewayStreamLimit(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy:             StrategyConfig{Mode: ModeSingle},
+		Targets:              []Target{{VirtualKey: mockProviderName}},
+		RateLimitTiers:       []RateLimitTier{{Name: "free", RequestsPerSecond: 1}},
+		MaxConcurrentStreams: 1,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	upstream := make(chan providers.StreamChunk)
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: mockProviderName, models: []string{"gpt-4o"}},
+		streamFn: func(context.Context, providers.Request) (<-chan providers.StreamChunk, error) {
+			return upstream, nil
+		},
+	})
+	req := providers.Request{Model: "gpt-4o", Stream: true, Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+
+	// The cap applies across keys, tiered or not.
+	ch, err := gw.RouteStream(authctx.WithKeyID(context.Background(), "key-a"), req)
+	if err != nil {
+		t.Fatalf("first stream: %v", err)
+	}
+	tiered := tierContext("key-b", "free")
+	if _, err := gw.RouteStream(tiered, req); !errors.Is(err, providers.ErrStreamLimitExceeded) {
+		t.Fatalf("stream past the gateway cap: got %v, want ErrStreamLimitExceeded", err)
+	}
+
+	close(upstream)
+	drainStream(t, ch)
+	waitFor(t, func() bool {
+		gw.tiers.mu.Lock()
+		defer gw.tiers.mu.Unlock()
+		return gw.tiers.streams == 0
+	})
+	// The refused stream must not have spent key-b's only request this second.
+	upstream = make(chan providers.StreamChunk)
+	close(upstream)
+	ch, err = gw.RouteStream(tiered, req)
+	if err != nil {
+		t.Fatalf("stream after the slot was freed: %v", err)
+	}
+	drainStream(t, ch)
+}
+
+func TestValidateConfig_RateLimitTiers(t *testing.T) {
+	base := Config{Strategy: StrategyConfig{Mode: ModeSingle}, Targets: []Target{{VirtualKey: "openai"}}}
+	for _, tt := range []struct {
+		name  string
+		tiers []RateLimitTier
+		ok    bool
+	}{
+		{name: "valid", tiers: []RateLimitTier{{Name: "free", RequestsPerSecond: 1}, {Name: "enterprise"}}, ok: true},
+		{name: "empty name", tiers: []RateLimitTier{{RequestsPerSecond: 1}}},
+		{name: "duplicate", tiers: []RateLimitTier{{Name: "free"}, {Name: "free"}}},
+		{name: "negative", tiers: []RateLimitTier{{Name: "free", MonthlyTokenLimit: -1}}},
+		{name: "burst without rate", tiers: []RateLimitTier{{Name: "free", Burst: 5}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.RateLimitTiers = tt.tiers
+			if err := ValidateConfig(cfg); (err == nil) != tt.ok {
+				t.Fatalf("ValidateConfig() error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+
+	base.MaxConcurrentStreams = -1
+	if err := ValidateConfig(base); err == nil {
+		t.Fatal("ValidateConfig accepted a negative max_concurrent_streams")
+	}
+}
@@ -0,0 +1,58 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// topKProvider strips top_k from requests to a provider that lacks it, or
+// refuses them when the compatibility mode is reject. top_k is not an OpenAI
+// parameter, so the OpenAI API and most compatible servers fail a request
+// carrying it; unlike the parameters the matrix governs, it is therefore
+// stripped in warn mode too. It wraps only such providers. The rejection is an
+// UnsupportedParamError, which the HTTP layer maps to 400.
+type topKProvider struct {
+	providers.Provider
+	name string
+}
+
+func (p *topKProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	req, err := p.strip(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *topKProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	req, err := p.strip(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return sp.CompleteStream(ctx, req)
+}
+
+func (p *topKProvider) strip(ctx context.Context, req providers.Request) (providers.Request, error) {
+	if req.TopK == nil {
+		return req, nil
+	}
+	if core.UnsupportedParamModeFromContext(ctx) == core.UnsupportedParamReject {
+		return req, core.NewUnsupportedParamError(p.name, []string{"top_k"})
+	}
+	logging.FromContext(ctx).Warn(
+		"provider does not support request parameter(s); dropping",
+		"provider", p.name,
+		"model", req.Model,
+		"dropped_params", []string{"top_k"},
+	)
+	req.TopK = nil
+	return req, nil
+}
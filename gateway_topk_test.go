@@ -0,0 +1,59 @@
+package aigateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func topKRequest() providers.Request {
+	k := 40
+	return providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		TopK:     &k,
+	}
+}
+
+// newTopKTestGateway returns a fallback gateway over "openai", which lacks
+// top_k, and "anthropic", which has it. Each provider records the top_k it
+// was sent, or -1 when unset.
+func newTopKTestGateway(t *testing.T, mode string) (*Gateway, map[string][]int) {
+	t.Helper()
+	return newRecordingGateway(t,
+		Config{Compatibility: CompatibilityConfig{OnUnsupportedParam: mode}},
+		func(req providers.Request) int { return intOrUnset(req.TopK) },
+		recordingTarget{name: "openai"}, recordingTarget{name: "anthropic"},
+	)
+}
+
+func TestGateway_TopKStrippedForProviderWithoutIt(t *testing.T) {
+	gw, sent := newTopKTestGateway(t, "")
+
+	resp, err := gw.Route(context.Background(), topKRequest())
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "from-openai" {
+		t.Fatalf("got %q, want the first target", resp.ID)
+	}
+	if got := sent["openai"]; len(got) != 1 || got[0] != -1 {
+		t.Fatalf("openai was sent top_k %v, want it stripped", got)
+	}
+}
+
+func TestGateway_TopKRejectFallsBackToProviderWithIt(t *testing.T) {
+	gw, sent := newTopKTestGateway(t, "reject")
+
+	resp, err := gw.Route(context.Background(), topKRequest())
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if resp.ID != "from-anthropic" || len(sent["openai"]) != 0 {
+		t.Fatalf("got %q (openai calls %d), want the provider with top_k", resp.ID, len(sent["openai"]))
+	}
+	if got := sent["anthropic"]; len(got) != 1 || got[0] != 40 {
+		t.Fatalf("anthropic was sent top_k %v, want 40", got)
+	}
+}
@@ -0,0 +1,145 @@
+package aigateway
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// transcodingProvider serves streaming requests from a provider that cannot
+// stream: CompleteStream calls Complete and replays the finished response as
+// stream chunks. It sits innermost, under the per-target decorators, so the
+// breaker, limiter, and retry budget see the Complete call like any other
+// attempt. The client gets nothing until the whole response is ready; word
+// chunking and pacing only shape how it is then delivered.
+type transcodingProvider struct {
+	providers.Provider
+	chunking string
+	pace     time.Duration
+}
+
+// transcodeStream wraps p, which must not already stream, per cfg.
+func transcodeStream(p providers.Provider, cfg *StreamTranscodingConfig) *transcodingProvider {
+	return &transcodingProvider{Provider: p, chunking: cfg.Chunking, pace: cfg.PaceDuration()}
+}
+
+func (p *transcodingProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	req.Stream = false
+	req.StreamOptions = nil
+	resp, err := p.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Debug("transcoded a non-streaming response to a stream",
+		"provider", p.Name(), "model", req.Model)
+	if p.chunking != transcodeChunkWord {
+		return responseStream(resp), nil
+	}
+	return wordStream(ctx, resp, p.pace), nil
+}
+
+// wordStream sends resp as OpenAI sends a stream: for each choice a chunk
+// with its role (and any reasoning), one chunk per word of its content, a
+// chunk with its tool calls, and a chunk with its finish reason; the last
+// chunk carries the usage. pace is slept between word chunks. The producer
+// stops when ctx is done.
+func wordStream(ctx context.Context, resp *providers.Response, pace time.Duration) <-chan providers.StreamChunk {
+	ch := make(chan providers.StreamChunk)
+	go func() {
+		defer close(ch)
+		chunk := func(c providers.StreamChoice) providers.StreamChunk {
+			return providers.StreamChunk{
+				ID:                resp.ID,
+				Object:            "chat.completion.chunk",
+				Created:           resp.Created,
+				Model:             resp.Model,
+				Choices:           []providers.StreamChoice{c},
+				SystemFingerprint: resp.SystemFingerprint,
+			}
+		}
+		send := func(c providers.StreamChunk) bool {
+			select {
+			case ch <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		wait := func() bool {
+			if pace <= 0 {
+				return true
+			}
+			t := time.NewTimer(pace)
+			defer t.Stop()
+			select {
+			case <-t.C:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for i, c := range resp.Choices {
+			first := providers.StreamChoice{Index: c.Index, Delta: providers.MessageDelta{
+				Role:             c.Message.Role,
+				ReasoningContent: c.Message.ReasoningContent,
+			}}
+			if !send(chunk(first)) {
+				return
+			}
+			for j, word := range splitWords(c.Message.Content) {
+				if j > 0 && !wait() {
+					return
+				}
+				if !send(chunk(providers.StreamChoice{Index: c.Index, Delta: providers.MessageDelta{Content: word}})) {
+					return
+				}
+			}
+			if len(c.Message.ToolCalls) > 0 {
+				if !send(chunk(providers.StreamChoice{Index: c.Index, Delta: providers.MessageDelta{ToolCalls: c.Message.ToolCalls}})) {
+					return
+				}
+			}
+			last := chunk(providers.StreamChoice{Index: c.Index, FinishReason: c.FinishReason})
+			if i == len(resp.Choices)-1 {
+				last.Usage = &resp.Usage
+			}
+			if !send(last) {
+				return
+			}
+		}
+		if len(resp.Choices) == 0 {
+			usage := chunk(providers.StreamChoice{})
+			usage.Choices, usage.Usage = nil, &resp.Usage
+			send(usage)
+		}
+	}()
+	return ch
+}
+
+// splitWords splits s after each run of whitespace, so the pieces concatenate
+// back to s.
+func splitWords(s string) []string {
+	var words []string
+	for s != "" {
+		i := strings.IndexFunc(s, unicode.IsSpace)
+		if i < 0 {
+			return append(words, s)
+		}
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		words = append(words, s[:i])
+		s = s[i:]
+	}
+	return words
+}
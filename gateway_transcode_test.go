@@ -0,0 +1,166 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// newTranscodeTestGateway returns a fallback gateway whose first target
+// streams but fails, and whose second, "batch", cannot stream at all.
+func newTranscodeTestGateway(t *testing.T, transcoding *StreamTranscodingConfig) *Gateway {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback, StreamTranscoding: transcoding},
+		Targets:  []Target{{VirtualKey: "streamer"}, {VirtualKey: "batch"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockStreamProvider{
+		mockProvider: mockProvider{name: "streamer", models: []string{"gpt-4o"}},
+		streamErr:    errors.New("provider API error (503): overloaded"),
+	})
+	gw.RegisterProvider(&mockProvider{
+		name:   "batch",
+		models: []string{"gpt-4o"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			if req.Stream {
+				t.Error("the non-streaming target was asked to stream")
+			}
+			return &providers.Response{
+				ID:    "resp-1",
+				Model: "gpt-4o",
+				Choices: []providers.Choice{{
+					Message:      providers.Message{Role: "assistant", Content: "hello brave new world"},
+					FinishReason: "stop",
+				}},
+				Usage: providers.Usage{PromptTokens: 2, CompletionTokens: 4, TotalTokens: 6},
+			}, nil
+		},
+	})
+	return gw
+}
+
+func streamRequest() providers.Request {
+	return providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: "hi"}},
+		Stream:   true,
+	}
+}
+
+func collectStream(t *testing.T, ch <-chan providers.StreamChunk) (content []string, finish string, usage *providers.Usage) {
+	t.Helper()
+	for c := range ch {
+		if c.Error != nil {
+			t.Fatalf("stream error: %v", c.Error)
+		}
+		if c.Usage != nil {
+			usage = c.Usage
+		}
+		for _, choice := range c.Choices {
+			if choice.Delta.Content != "" {
+				content = append(content, choice.Delta.Content)
+			}
+			if choice.FinishReason != "" {
+				finish = choice.FinishReason
+			}
+		}
+	}
+	return content, finish, usage
+}
+
+func TestRouteStream_NonStreamingTargetSkippedWithoutTranscoding(t *testing.T) {
+	gw := newTranscodeTestGateway(t, nil)
+
+	if _, err := gw.RouteStream(context.Background(), streamRequest()); err == nil {
+		t.Fatal("want the streaming target's error with nowhere to fall back")
+	}
+}
+
+func TestRouteStream_TranscodesWholeResponse(t *testing.T) {
+	gw := newTranscodeTestGateway(t, &StreamTranscodingConfig{})
+
+	ch, err := gw.RouteStream(context.Background(), streamRequest())
+	if err != nil {
+		t.Fatalf("RouteStream: %v", err)
+	}
+	content, finish, usage := collectStream(t, ch)
+	if strings.Join(content, "") != "hello brave new world" || len(content) != 1 {
+		t.Fatalf("content = %q, want the response in one chunk", content)
+	}
+	if finish != "stop" || usage == nil || usage.TotalTokens != 6 {
+		t.Fatalf("finish %q, usage %+v", finish, usage)
+	}
+}
+
+func TestRouteStream_TranscodesWordByWord(t *testing.T) {
+	gw := newTranscodeTestGateway(t, &StreamTranscodingConfig{Chunking: transcodeChunkWord, Pace: "1ms"})
+
+	ch, err := gw.RouteStream(context.Background(), streamRequest())
+	if err != nil {
+		t.Fatalf("RouteStream: %v", err)
+	}
+	content, finish, usage := collectStream(t, ch)
+	want := []string{"hello ", "brave ", "new ", "world"}
+	if strings.Join(content, "|") != strings.Join(want, "|") {
+		t.Fatalf("content = %q, want %q", content, want)
+	}
+	if finish != "stop" || usage == nil || usage.TotalTokens != 6 {
+		t.Fatalf("finish %q, usage %+v", finish, usage)
+	}
+}
+
+func TestWordStream_StopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := &providers.Response{Choices: []providers.Choice{{Message: providers.Message{Content: "a b c d"}}}}
+	ch := wordStream(ctx, resp, time.Hour)
+	<-ch // role
+	<-ch // first word; the next waits out the pace
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("got another chunk after the context ended")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the producer did not stop")
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	for in, want := range map[string]string{
+		"":             "",
+		"one":          "one",
+		"a  b\nc":      "a  |b\n|c",
+		" lead trail ": " |lead |trail ",
+		"héllo wörld ": "héllo |wörld ",
+	} {
+		if got := strings.Join(splitWords(in), "|"); got != want {
+			t.Errorf("splitWords(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestValidateConfig_StreamTranscoding(t *testing.T) {
+	cfg := Config{
+		Strategy: StrategyConfig{Mode: ModeFallback, StreamTranscoding: &StreamTranscodingConfig{Chunking: "token"}},
+		Targets:  []Target{{VirtualKey: "openai"}},
+	}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("want an error for an unknown chunking")
+	}
+	cfg.Strategy.StreamTranscoding = &StreamTranscodingConfig{Chunking: transcodeChunkWord, Pace: "-1ms"}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("want an error for a negative pace")
+	}
+	cfg.Strategy.StreamTranscoding = &StreamTranscodingConfig{Chunking: transcodeChunkWord, Pace: "25ms"}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("valid stream_transcoding rejected: %v", err)
+	}
+}
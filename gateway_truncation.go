@@ -0,0 +1,172 @@
+package aigateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/internal/tokens"
+	"github.com/ferro-labs/ai-gateway/internal/truncation"
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Context truncation: a chat request whose estimated prompt would overflow
+// its model's context window is shortened by the caller's policy
+// (Config.ContextTruncation) after its before-request plugins and before any
+// provider sees it, so the request is served on a shorter history instead of
+// refused upstream. The outcome is recorded on the context for the HTTP
+// layer's response header.
+
+// summaryMaxTokens bounds the summary a summarize policy asks for. The summary
+// may also take at most a quarter of the prompt's room, which is kept free for
+// it when choosing what to drop.
+const summaryMaxTokens = 512
+
+// truncationPolicyFor returns the policy of ctx's request: its key's, else its
+// workspace's, else the default.
+func truncationPolicyFor(ctx context.Context, cfg *ContextTruncationConfig) (TruncationPolicy, bool) {
+	if cfg == nil {
+		return TruncationPolicy{}, false
+	}
+	if keyID, ok := authctx.KeyID(ctx); ok {
+		if p, ok := cfg.Keys[keyID]; ok {
+			return p, true
+		}
+	}
+	if id, ok := authctx.Identity(ctx); ok && id.Workspace != "" {
+		if p, ok := cfg.Workspaces[id.Workspace]; ok {
+			return p, true
+		}
+	}
+	if cfg.Default != nil {
+		return *cfg.Default, true
+	}
+	return TruncationPolicy{}, false
+}
+
+// truncateContext shortens req's messages to fit its model's context window
+// when its policy calls for it. req.Model must already be alias-resolved.
+func (g *Gateway) truncateContext(ctx context.Context, cfg *ContextTruncationConfig, catalog models.Catalog, req *providers.Request) {
+	if isSummaryRequest(ctx) {
+		return
+	}
+	policy, ok := truncationPolicyFor(ctx, cfg)
+	if !ok || policy.Policy == TruncationNone {
+		return
+	}
+	m, ok := catalog.Get(req.Model)
+	if !ok || m.ContextWindow <= 0 {
+		return
+	}
+	reserve := policy.ReserveTokens
+	if reserve == 0 {
+		reserve = DefaultTruncationReserveTokens
+	}
+	if req.MaxTokens != nil && *req.MaxTokens > 0 {
+		reserve = *req.MaxTokens
+	}
+	limit := m.ContextWindow - reserve
+	estimate := func(msgs []providers.Message) int {
+		r := *req
+		r.Messages = msgs
+		return tokens.Prompt(r)
+	}
+	if estimate(req.Messages) <= limit {
+		return
+	}
+
+	keepLast := 0
+	if policy.Policy == TruncationKeepLast {
+		keepLast = policy.KeepLast
+	}
+	summaryTokens := 0
+	if policy.Policy == TruncationSummarize {
+		summaryTokens = min(summaryMaxTokens, limit/4)
+	}
+	kept, dropped, fits := truncation.Fit(req.Messages, keepLast, limit-summaryTokens, estimate)
+	log := logging.FromContext(ctx)
+	if !fits {
+		log.Warn("context truncation: prompt exceeds the context window even truncated; sending it whole",
+			"model", req.Model, "policy", policy.Policy, "context_window", m.ContextWindow)
+		return
+	}
+
+	info := truncation.Info{Policy: policy.Policy, Dropped: len(dropped)}
+	if policy.Policy == TruncationSummarize {
+		summary, err := g.summarizeTurns(ctx, policy.SummaryModel, summaryTokens, dropped)
+		if err != nil {
+			log.Warn("context truncation: summary failed; dropping the turns instead",
+				"model", req.Model, "summary_model", policy.SummaryModel, "error", err)
+		} else {
+			kept = insertSummary(kept, summary)
+			info.Summarized = true
+		}
+	}
+	req.Messages = kept
+	truncation.Record(ctx, info)
+	metrics.ContextTruncationsTotal.WithLabelValues(policy.Policy).Inc()
+	log.Info("context truncated", "model", req.Model, "policy", policy.Policy,
+		"dropped_messages", len(dropped), "summarized", info.Summarized)
+}
+
+// summaryRequestKey marks the context of a summary truncation asks for.
+type summaryRequestKey struct{}
+
+// isSummaryRequest reports whether ctx's request is a summary the gateway
+// asked for while truncating another request.
+func isSummaryRequest(ctx context.Context) bool {
+	return ctx.Value(summaryRequestKey{}) != nil
+}
+
+// summarizeTurns asks model to summarize msgs in at most maxTokens. The
+// summary is routed as a request of the caller's own, so its key's residency
+// requirement, tier limits, budget, and logging apply to it as to any other;
+// it is never itself truncated, dead-lettered, given MCP tools, or shadowed
+// by experiments, and a target or strategy pinned for the caller's request
+// does not carry over to it.
+func (g *Gateway) summarizeTurns(ctx context.Context, model string, maxTokens int, msgs []providers.Message) (string, error) {
+	req := truncation.SummaryRequest(model, maxTokens, "", msgs)
+	ctx = WithoutDeadLetter(context.WithValue(ctx, summaryRequestKey{}, true))
+	if opts, ok := requestOptionsFrom(ctx); ok {
+		opts.Target, opts.Strategy = "", ""
+		ctx = context.WithValue(ctx, requestOptionsKey{}, opts)
+	}
+	resp, err := g.Route(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	summary := truncation.SummaryText(resp)
+	if summary == "" {
+		return "", fmt.Errorf("summary model %q returned no text", model)
+	}
+	return summary, nil
+}
+
+// directCompleter is the plugin.Completer the gateway hands to plugins: it
+// resolves aliases and calls the model's provider directly.
+type directCompleter struct{ g *Gateway }
+
+func (c directCompleter) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	req = c.g.resolveAlias(req)
+	p, ok := c.g.FindByModel(req.Model)
+	if !ok {
+		return nil, fmt.Errorf("no provider serves model %q", req.Model)
+	}
+	return p.Complete(ctx, req)
+}
+
+// insertSummary places summary, as a system message, after msgs' leading
+// system messages, where the dropped turns began.
+func insertSummary(msgs []providers.Message, summary string) []providers.Message {
+	i := 0
+	for i < len(msgs) && truncation.Pinned(msgs[i]) {
+		i++
+	}
+	out := make([]providers.Message, 0, len(msgs)+1)
+	out = append(out, msgs[:i]...)
+	out = append(out, providers.Message{Role: "system", Content: truncation.SummaryPrefix + summary})
+	return append(out, msgs[i:]...)
+}
@@ -0,0 +1,245 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/truncation"
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// newTruncatingGateway serves "small" (a 200-token window) and "cheap" from
+// one mock provider, and returns the messages each model was last sent.
+func newTruncatingGateway(t *testing.T, cfg *ContextTruncationConfig) (*Gateway, map[string][]providers.Message) {
+	t.Helper()
+	gw, err := newTestGateway(t, Config{
+		Strategy:          StrategyConfig{Mode: ModeSingle},
+		Targets:           []Target{{VirtualKey: mockProviderName}},
+		ContextTruncation: cfg,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.catalog = models.Catalog{
+		mockProviderName + "/small": {Provider: mockProviderName, ModelID: "small", Mode: models.ModeChat, ContextWindow: 200},
+	}
+	sent := map[string][]providers.Message{}
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"small", "cheap"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			sent[req.Model] = req.Messages
+			return &providers.Response{ID: "ok", Model: req.Model, Choices: []providers.Choice{
+				{Message: providers.Message{Role: "assistant", Content: "they discussed the weather"}},
+			}}, nil
+		},
+	})
+	return gw, sent
+}
+
+// longChat is a conversation of turns of 30-odd tokens each, far past 200.
+func longChat() providers.Request {
+	maxTokens := 50
+	req := providers.Request{Model: "small", MaxTokens: &maxTokens,
+		Messages: []providers.Message{{Role: "system", Content: "You are terse."}}}
+	for i := range 20 {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		req.Messages = append(req.Messages, providers.Message{Role: role, Content: strings.Repeat("word ", 30)})
+	}
+	req.Messages = append(req.Messages, providers.Message{Role: "user", Content: "and now?"})
+	return req
+}
+
+func TestRoute_ContextTruncationDropOldest(t *testing.T) {
+	gw, sent := newTruncatingGateway(t, &ContextTruncationConfig{
+		Default: &TruncationPolicy{Policy: TruncationDropOldest},
+		Keys:    map[string]TruncationPolicy{"key-whole": {Policy: TruncationNone}},
+	})
+	ctx, rec := truncation.WithRecorder(authctx.WithKeyID(context.Background(), "key-a"))
+	req := longChat()
+
+	if _, err := gw.Route(ctx, req); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	got := sent["small"]
+	if len(got) >= len(req.Messages) || got[0].Role != "system" || got[len(got)-1].Content != "and now?" {
+		t.Fatalf("sent %d of %d messages, first %q, last %q", len(got), len(req.Messages), got[0].Role, got[len(got)-1].Content)
+	}
+	info, ok := rec.Info()
+	if !ok || info.Policy != TruncationDropOldest || info.Dropped != len(req.Messages)-len(got) {
+		t.Fatalf("recorded %+v, %v", info, ok)
+	}
+
+	// A key whose policy is none is sent whole, as is a request that fits.
+	ctx, rec = truncation.WithRecorder(authctx.WithKeyID(context.Background(), "key-whole"))
+	if _, err := gw.Route(ctx, req); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if len(sent["small"]) != len(req.Messages) {
+		t.Errorf("none policy: sent %d of %d messages", len(sent["small"]), len(req.Messages))
+	}
+	if _, ok := rec.Info(); ok {
+		t.Error("none policy recorded a truncation")
+	}
+}
+
+// Each model of a fallback list gets the turns fitted to its own window.
+func TestRoute_ContextTruncationPerFallbackModel(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy:          StrategyConfig{Mode: ModeSingle},
+		Targets:           []Target{{VirtualKey: mockProviderName}},
+		ContextTruncation: &ContextTruncationConfig{Default: &TruncationPolicy{Policy: TruncationDropOldest}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.catalog = models.Catalog{
+		mockProviderName + "/small": {Provider: mockProviderName, ModelID: "small", Mode: models.ModeChat, ContextWindow: 200},
+		mockProviderName + "/large": {Provider: mockProviderName, ModelID: "large", Mode: models.ModeChat, ContextWindow: 100_000},
+	}
+	sent := map[string]int{}
+	failing := ""
+	gw.RegisterProvider(&mockProvider{
+		name:   mockProviderName,
+		models: []string{"small", "large"},
+		completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+			sent[req.Model] = len(req.Messages)
+			if req.Model == failing {
+				return nil, errors.New("provider API error (500): boom")
+			}
+			return &providers.Response{ID: "ok", Model: req.Model, Choices: []providers.Choice{
+				{Message: providers.Message{Role: "assistant", Content: "ok"}},
+			}}, nil
+		},
+	})
+	whole := len(longChat().Messages)
+
+	failing = "small"
+	req := longChat()
+	req.FallbackModels = []string{"large"}
+	if _, err := gw.Route(context.Background(), req); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if sent["small"] >= whole || sent["large"] != whole {
+		t.Fatalf("small then large: sent %v of %d messages, want small truncated and large whole", sent, whole)
+	}
+
+	failing = "large"
+	clear(sent)
+	req = longChat()
+	req.Model, req.FallbackModels = "large", []string{"small"}
+	if _, err := gw.Route(context.Background(), req); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if sent["large"] != whole || sent["small"] >= whole {
+		t.Fatalf("large then small: sent %v of %d messages, want large whole and small truncated", sent, whole)
+	}
+}
+
+func TestRoute_ContextTruncationSummarize(t *testing.T) {
+	gw, sent := newTruncatingGateway(t, &ContextTruncationConfig{
+		Workspaces: map[string]TruncationPolicy{"team": {Policy: TruncationSummarize, SummaryModel: "cheap"}},
+	})
+	ctx := authctx.WithIdentity(authctx.WithKeyID(context.Background(), "key-a"), authctx.KeyIdentity{Workspace: "team"})
+	ctx, rec := truncation.WithRecorder(ctx)
+
+	if _, err := gw.Route(ctx, longChat()); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if len(sent["cheap"]) != 2 || !strings.Contains(sent["cheap"][1].Content, "word word") {
+		t.Fatalf("summary request = %+v", sent["cheap"])
+	}
+	got := sent["small"]
+	if len(got) < 2 || got[1].Role != "system" || !strings.Contains(got[1].Content, "they discussed the weather") {
+		t.Fatalf("summary not inserted after the system prompt: %+v", got[:2])
+	}
+	if info, ok := rec.Info(); !ok || !info.Summarized {
+		t.Fatalf("recorded %+v, %v", info, ok)
+	}
+
+	// Outside the workspace, with no default, nothing is truncated.
+	sent["cheap"] = nil
+	if _, err := gw.Route(authctx.WithKeyID(context.Background(), "key-b"), longChat()); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if sent["cheap"] != nil || len(sent["small"]) != len(longChat().Messages) {
+		t.Error("a request without a policy was truncated")
+	}
+}
+
+// The summary is routed as the caller's request: a summary model outside the
+// key's residency requirement is never called, and the turns are dropped.
+func TestRoute_ContextTruncationSummaryKeepsResidency(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeFallback},
+		Targets:  []Target{{VirtualKey: "eu-west", Region: "eu-west"}, {VirtualKey: "us-east", Region: "us-east"}},
+		ContextTruncation: &ContextTruncationConfig{
+			Default: &TruncationPolicy{Policy: TruncationSummarize, SummaryModel: "cheap"},
+		},
+		Residency: &ResidencyConfig{Keys: map[string]string{"key-eu": "eu"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.catalog = models.Catalog{
+		"eu-west/small": {Provider: "eu-west", ModelID: "small", Mode: models.ModeChat, ContextWindow: 200},
+	}
+	sent := map[string][]providers.Message{}
+	for name, served := range map[string]string{"eu-west": "small", "us-east": "cheap"} {
+		gw.RegisterProvider(&mockProvider{name: name, models: []string{served},
+			completeFn: func(_ context.Context, req providers.Request) (*providers.Response, error) {
+				sent[req.Model] = req.Messages
+				return &providers.Response{ID: "ok", Model: req.Model, Choices: []providers.Choice{
+					{Message: providers.Message{Role: "assistant", Content: "a summary"}},
+				}}, nil
+			}})
+	}
+	ctx, rec := truncation.WithRecorder(authctx.WithKeyID(context.Background(), "key-eu"))
+
+	if _, err := gw.Route(ctx, longChat()); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if sent["cheap"] != nil {
+		t.Fatal("the summary left the key's region")
+	}
+	if info, ok := rec.Info(); !ok || info.Summarized || info.Dropped == 0 {
+		t.Fatalf("recorded %+v, %v; want the turns dropped unsummarized", info, ok)
+	}
+	if got := sent["small"]; got[1].Role != "user" {
+		t.Fatalf("kept history starts on %q, want a user turn", got[1].Role)
+	}
+}
+
+func TestValidateConfig_ContextTruncation(t *testing.T) {
+	base := Config{Strategy: StrategyConfig{Mode: ModeSingle}, Targets: []Target{{VirtualKey: "openai"}}}
+	for _, tt := range []struct {
+		name string
+		cfg  ContextTruncationConfig
+		ok   bool
+	}{
+		{name: "valid", cfg: ContextTruncationConfig{
+			Default:    &TruncationPolicy{Policy: TruncationDropOldest},
+			Workspaces: map[string]TruncationPolicy{"a": {Policy: TruncationKeepLast, KeepLast: 6}},
+			Keys:       map[string]TruncationPolicy{"k": {Policy: TruncationNone}},
+		}, ok: true},
+		{name: "unknown policy", cfg: ContextTruncationConfig{Default: &TruncationPolicy{Policy: "shorten"}}},
+		{name: "keep_last without count", cfg: ContextTruncationConfig{Keys: map[string]TruncationPolicy{"k": {Policy: TruncationKeepLast}}}},
+		{name: "summarize without model", cfg: ContextTruncationConfig{Workspaces: map[string]TruncationPolicy{"a": {Policy: TruncationSummarize}}}},
+		{name: "negative reserve", cfg: ContextTruncationConfig{Default: &TruncationPolicy{Policy: TruncationDropOldest, ReserveTokens: -1}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.ContextTruncation = &tt.cfg
+			if err := ValidateConfig(cfg); (err == nil) != tt.ok {
+				t.Fatalf("ValidateConfig() error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package aigateway
+
+import (
+	"github.com/ferro-labs/ai-gateway/internal/upstreamhdr"
+	"github.com/ferro-labs/ai-gateway/plugin"
+)
+
+// recordUpstreamHeaders copies the rate-limit and request-ID headers of the
+// request's last provider response into pctx, for the request logger.
+func recordUpstreamHeaders(rec *upstreamhdr.Recorder, pctx *plugin.Context) {
+	if pctx == nil {
+		return
+	}
+	if headers := rec.Headers(); headers != nil {
+		pctx.Metadata["upstream_headers"] = headers
+	}
+}
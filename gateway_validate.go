@@ -0,0 +1,189 @@
+package aigateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Response validation: a provider that changes its response schema, or
+// answers a request only partially, usually still decodes without error, and
+// the damage shows up downstream as a response with no choices or an empty
+// role that clients cannot use. validatingProvider checks each non-streaming
+// response against the invariants clients rely on, counts every anomaly, and
+// logs it, with the payload's shape for a sample so drift can be diagnosed
+// without flooding the logs. The shape lists field paths and JSON types,
+// never values: a response carries the conversation's content. In reject mode the response is replaced by an
+// ErrMalformedResponse, which the decorators outside it count as an upstream
+// failure.
+
+// Response anomalies, used as the anomaly metric label.
+const (
+	anomalyNilResponse   = "nil_response"
+	anomalyNoChoices     = "no_choices"
+	anomalyMissingRole   = "missing_role"
+	anomalyNegativeUsage = "negative_usage"
+	anomalyUsageTotal    = "usage_total_mismatch"
+)
+
+const (
+	// defaultPayloadSampleRatio applies when payload_sample_ratio is omitted.
+	defaultPayloadSampleRatio = 0.1
+	// maxLoggedShape caps the shape bytes attached to an anomaly log line.
+	maxLoggedShape = 4 << 10
+)
+
+// validateResponse returns the invariants resp breaks, or nil.
+func validateResponse(resp *providers.Response) []string {
+	if resp == nil {
+		return []string{anomalyNilResponse}
+	}
+	var anomalies []string
+	if len(resp.Choices) == 0 {
+		anomalies = append(anomalies, anomalyNoChoices)
+	}
+	for _, c := range resp.Choices {
+		if c.Message.Role == "" {
+			anomalies = append(anomalies, anomalyMissingRole)
+			break
+		}
+	}
+	u := resp.Usage
+	if u.PromptTokens < 0 || u.CompletionTokens < 0 || u.TotalTokens < 0 {
+		anomalies = append(anomalies, anomalyNegativeUsage)
+	} else if u.TotalTokens > 0 && u.TotalTokens < u.PromptTokens+u.CompletionTokens {
+		// A zero total is common for providers that report no usage at all.
+		anomalies = append(anomalies, anomalyUsageTotal)
+	}
+	return anomalies
+}
+
+// validatingProvider applies response validation to one target. Streams are
+// forwarded unchecked: their chunks are partial by design.
+type validatingProvider struct {
+	providers.Provider
+	name        string
+	reject      bool
+	sampleRatio float64
+}
+
+// validateResponses wraps p per cfg, or returns it unchanged when validation
+// is off.
+func validateResponses(name string, p providers.Provider, cfg *ResponseValidationConfig) providers.Provider {
+	if cfg == nil || cfg.Mode == "" {
+		return p
+	}
+	ratio := defaultPayloadSampleRatio
+	if cfg.PayloadSampleRatio != nil {
+		ratio = *cfg.PayloadSampleRatio
+	}
+	return &validatingProvider{
+		Provider:    p,
+		name:        name,
+		reject:      cfg.Mode == ResponseValidationReject,
+		sampleRatio: ratio,
+	}
+}
+
+func (p *validatingProvider) Complete(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	anomalies := validateResponse(resp)
+	if len(anomalies) == 0 {
+		return resp, nil
+	}
+	p.report(ctx, req, resp, anomalies)
+	if p.reject {
+		return nil, fmt.Errorf("%s: %w: %s", p.name, providers.ErrMalformedResponse, strings.Join(anomalies, ", "))
+	}
+	return resp, nil
+}
+
+func (p *validatingProvider) CompleteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error) {
+	sp, ok := p.Provider.(providers.StreamProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support streaming", p.name)
+	}
+	return sp.CompleteStream(ctx, req)
+}
+
+// report counts resp's anomalies and logs them, attaching the payload's shape
+// for a sampled share.
+func (p *validatingProvider) report(ctx context.Context, req providers.Request, resp *providers.Response, anomalies []string) {
+	for _, a := range anomalies {
+		metrics.ProviderResponseAnomaliesTotal.WithLabelValues(p.name, a).Inc()
+	}
+	attrs := []any{
+		"provider", p.name,
+		"model", req.Model,
+		"anomalies", strings.Join(anomalies, ","),
+		"rejected", p.reject,
+	}
+	if p.sampleRatio > 0 && (p.sampleRatio >= 1 || rand.Float64() < p.sampleRatio) { //nolint:gosec // G404: math/rand is fine for log sampling, not security-sensitive
+		if shape := payloadShape(resp); shape != "" {
+			attrs = append(attrs, "payload_shape", shape)
+		}
+	}
+	logging.FromContext(ctx).Warn("provider returned a malformed response", attrs...)
+}
+
+// payloadShape describes resp's JSON as its field paths and their types, such
+// as "choices[0].message.content:string", without any value. An array is
+// described by its first element.
+func payloadShape(resp *providers.Response) string {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	var paths []string
+	var walk func(path string, v any)
+	walk = func(path string, v any) {
+		switch val := v.(type) {
+		case map[string]any:
+			if len(val) == 0 {
+				paths = append(paths, path+":object")
+			}
+			for k, elem := range val {
+				if path == "" {
+					walk(k, elem)
+				} else {
+					walk(path+"."+k, elem)
+				}
+			}
+		case []any:
+			if len(val) == 0 {
+				paths = append(paths, path+":array")
+				return
+			}
+			walk(path+"[0]", val[0])
+		case string:
+			paths = append(paths, path+":string")
+		case float64:
+			paths = append(paths, path+":number")
+		case bool:
+			paths = append(paths, path+":bool")
+		case nil:
+			paths = append(paths, path+":null")
+		}
+	}
+	walk("", v)
+	slices.Sort(paths)
+	shape := strings.Join(paths, ",")
+	if len(shape) > maxLoggedShape {
+		shape = shape[:maxLoggedShape] + "…"
+	}
+	return shape
+}
@@ -0,0 +1,106 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestValidateResponse(t *testing.T) {
+	ok := providers.Choice{Message: providers.Message{Role: "assistant", Content: "hi"}}
+	tests := []struct {
+		name string
+		resp *providers.Response
+		want []string
+	}{
+		{"valid", &providers.Response{Choices: []providers.Choice{ok}, Usage: providers.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}}, nil},
+		{"no usage reported", &providers.Response{Choices: []providers.Choice{ok}}, nil},
+		{"nil", nil, []string{anomalyNilResponse}},
+		{"no choices", &providers.Response{}, []string{anomalyNoChoices}},
+		{"missing role", &providers.Response{Choices: []providers.Choice{ok, {}}}, []string{anomalyMissingRole}},
+		{"negative usage", &providers.Response{Choices: []providers.Choice{ok}, Usage: providers.Usage{PromptTokens: -1}}, []string{anomalyNegativeUsage}},
+		{"total short", &providers.Response{Choices: []providers.Choice{ok}, Usage: providers.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 4}}, []string{anomalyUsageTotal}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateResponse(tt.resp); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("validateResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGateway_Route_ResponseValidation(t *testing.T) {
+	empty := &providers.Response{ID: "empty"}
+	good := &providers.Response{ID: "good", Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "hi"}}}}
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	ratio := 1.0
+
+	newGateway := func(t *testing.T, mode string) *Gateway {
+		t.Helper()
+		gw, err := newTestGateway(t, Config{
+			Strategy:           StrategyConfig{Mode: ModeFallback},
+			Targets:            []Target{{VirtualKey: "drifted"}, {VirtualKey: "healthy"}},
+			ResponseValidation: &ResponseValidationConfig{Mode: mode, PayloadSampleRatio: &ratio},
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		gw.RegisterProvider(&mockProvider{name: "drifted", models: []string{"gpt-4o"}, resp: empty})
+		gw.RegisterProvider(&mockProvider{name: "healthy", models: []string{"gpt-4o"}, resp: good})
+		return gw
+	}
+
+	t.Run("warn passes the response on", func(t *testing.T) {
+		resp, err := newGateway(t, ResponseValidationWarn).Route(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if resp.ID != "empty" {
+			t.Fatalf("got ID %q, want the unchecked response", resp.ID)
+		}
+	})
+
+	t.Run("reject falls back", func(t *testing.T) {
+		resp, err := newGateway(t, ResponseValidationReject).Route(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if resp.ID != "good" {
+			t.Fatalf("got ID %q, want the fallback's response", resp.ID)
+		}
+	})
+
+	t.Run("reject surfaces the error", func(t *testing.T) {
+		gw, err := newTestGateway(t, Config{
+			Strategy:           StrategyConfig{Mode: ModeSingle},
+			Targets:            []Target{{VirtualKey: "drifted"}},
+			ResponseValidation: &ResponseValidationConfig{Mode: ResponseValidationReject},
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		gw.RegisterProvider(&mockProvider{name: "drifted", models: []string{"gpt-4o"}, resp: empty})
+		if _, err := gw.Route(context.Background(), req); !errors.Is(err, providers.ErrMalformedResponse) {
+			t.Fatalf("Route error = %v, want ErrMalformedResponse", err)
+		}
+	})
+}
+
+// The logged shape names the fields a response has, never what they hold.
+func TestPayloadShape(t *testing.T) {
+	resp := &providers.Response{ID: "r1", Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "my card is 4111"}}}}
+	shape := payloadShape(resp)
+	if strings.Contains(shape, "4111") || strings.Contains(shape, "r1") {
+		t.Fatalf("shape leaks values: %s", shape)
+	}
+	for _, want := range []string{"id:string", "choices[0].message.content:string", "usage.prompt_tokens:number"} {
+		if !strings.Contains(shape, want) {
+			t.Errorf("shape %q lacks %q", shape, want)
+		}
+	}
+}
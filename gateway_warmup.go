@@ -0,0 +1,130 @@
+package aigateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/httpclient"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Provider warm-up states reported by Readiness. A provider the warm-up has
+// not reached, or a gateway that never ran one, reports "".
+const (
+	WarmupPending = "pending"
+	WarmupOK      = "ok"
+	WarmupFailed  = "failed"
+)
+
+// defaultWarmupTimeout bounds each provider's warm-up.
+const defaultWarmupTimeout = 10 * time.Second
+
+// Most providers do no network work at construction: the first request pays
+// for any credential fetch plus the DNS lookup, TCP connect, and TLS handshake
+// to the upstream, which shows up as a latency spike on the first call to each
+// provider after a start or deploy. WarmProviders moves that cost to startup.
+//
+// A provider is warmed in two steps. If it implements providers.Warmer, Warm
+// runs first; Bedrock fetches its AWS credentials there and Vertex AI its OAuth
+// token. Then, if it exposes a BaseURL, a HEAD request to it through the
+// provider's pooled HTTP client opens a connection that stays in the pool for
+// the first real request. Any HTTP response counts as success: a 401 or 404
+// from the API root still proves the connection is up. Streaming requests use
+// a separate transport, which is not warmed.
+
+// warmupStates holds each provider's warm-up state. The zero value is ready
+// to use, so gateways built as struct literals in tests need not set one.
+type warmupStates struct {
+	mu     sync.Mutex
+	status map[string]string
+}
+
+func (w *warmupStates) set(name, status string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == nil {
+		w.status = make(map[string]string)
+	}
+	w.status[name] = status
+}
+
+func (w *warmupStates) get(name string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status[name]
+}
+
+// WarmProviders warms every registered provider concurrently, bounding each
+// by timeout (10s when non-positive), and returns when all have finished or
+// ctx is cancelled. Each provider's progress is reported by Readiness; a
+// failure is logged and otherwise harmless, since the first request simply
+// retries the work.
+func (g *Gateway) WarmProviders(ctx context.Context, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultWarmupTimeout
+	}
+	log := logging.FromContext(ctx)
+
+	g.mu.RLock()
+	names := append([]string(nil), g.providerNames...)
+	targets := make([]providers.Provider, len(names))
+	for i, name := range names {
+		targets[i] = g.providers[name]
+	}
+	g.mu.RUnlock()
+
+	for _, name := range names {
+		g.warmup.set(name, WarmupPending)
+	}
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(name string, p providers.Provider) {
+			defer wg.Done()
+			warmCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			started := time.Now()
+			if err := warmProvider(warmCtx, p); err != nil {
+				g.warmup.set(name, WarmupFailed)
+				log.Warn("provider warm-up failed; the first request will retry it",
+					"provider", name, "error", redact.ErrorMessage(err))
+				return
+			}
+			g.warmup.set(name, WarmupOK)
+			log.Debug("provider warmed", "provider", name, "duration", time.Since(started))
+		}(name, targets[i])
+	}
+	wg.Wait()
+}
+
+// warmProvider runs p's Warm hook, if any, then opens a pooled connection to
+// its base URL, if it has one.
+func warmProvider(ctx context.Context, p providers.Provider) error {
+	if w, ok := p.(providers.Warmer); ok {
+		if err := w.Warm(ctx); err != nil {
+			return err
+		}
+	}
+	pp, ok := p.(providers.ProxiableProvider)
+	if !ok || pp.BaseURL() == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, pp.BaseURL(), nil)
+	if err != nil {
+		return err
+	}
+	// Providers take their pooled client from httpclient.ForProvider under
+	// their own name, so the connection lands in the pool they will use.
+	resp, err := httpclient.ForProvider(p.Name()).Do(req)
+	if err != nil {
+		return err
+	}
+	// Draining the body lets the transport return the connection to the pool.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
@@ -0,0 +1,82 @@
+package aigateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// warmupProvider is a mockProvider with a base URL and an optional Warm hook.
+type warmupProvider struct {
+	mockProvider
+	baseURL string
+	warmErr error
+	warmed  atomic.Bool
+}
+
+func (p *warmupProvider) BaseURL() string                { return p.baseURL }
+func (p *warmupProvider) AuthHeaders() map[string]string { return nil }
+
+func (p *warmupProvider) Warm(context.Context) error {
+	p.warmed.Store(true)
+	return p.warmErr
+}
+
+// warmupOf returns name's warm-up state as Readiness reports it.
+func warmupOf(gw *Gateway, name string) string {
+	for _, pr := range gw.Readiness().Providers {
+		if pr.Name == name {
+			return pr.Warmup
+		}
+	}
+	return ""
+}
+
+func TestWarmProviders(t *testing.T) {
+	var heads atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+		// The API root typically rejects the probe; any response is a warm
+		// connection.
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	gw := newReadinessGateway(t, []Target{{VirtualKey: "plain"}})
+	ok := &warmupProvider{mockProvider: mockProvider{name: "reachable"}, baseURL: upstream.URL}
+	failing := &warmupProvider{mockProvider: mockProvider{name: "no-creds"}, baseURL: upstream.URL, warmErr: errors.New("no credentials")}
+	unreachable := &warmupProvider{mockProvider: mockProvider{name: "unreachable"}, baseURL: "http://127.0.0.1:1"}
+	for _, p := range []*warmupProvider{ok, failing, unreachable} {
+		gw.RegisterProvider(p)
+	}
+
+	if got := warmupOf(gw, "reachable"); got != "" {
+		t.Fatalf("warmup before WarmProviders = %q, want empty", got)
+	}
+	gw.WarmProviders(t.Context(), 5*time.Second)
+
+	want := map[string]string{
+		"plain":       WarmupOK,
+		"reachable":   WarmupOK,
+		"no-creds":    WarmupFailed,
+		"unreachable": WarmupFailed,
+	}
+	for name, status := range want {
+		if got := warmupOf(gw, name); got != status {
+			t.Errorf("%s warmup = %q, want %q", name, got, status)
+		}
+	}
+	if !ok.warmed.Load() || !failing.warmed.Load() {
+		t.Error("Warm hook was not called")
+	}
+	// The failing Warm hook stops its provider before the connection step.
+	if got := heads.Load(); got != 1 {
+		t.Errorf("HEAD requests = %d, want 1", got)
+	}
+}
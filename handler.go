@@ -0,0 +1,65 @@
+package aigateway
+
+import (
+	"net/http"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/openaiapi"
+	"github.com/ferro-labs/ai-gateway/internal/routingrules"
+)
+
+// HandlerOptions selects the endpoints Handler serves beyond non-streaming
+// chat completions.
+type HandlerOptions struct {
+	// Streaming answers chat completion requests with "stream": true as
+	// server-sent events. Without it they are refused with 400.
+	Streaming bool
+	// Embeddings serves POST /v1/embeddings.
+	Embeddings bool
+	// Images serves POST /v1/images/generations.
+	Images bool
+	// Moderations serves POST /v1/moderations.
+	Moderations bool
+	// MaxRequestBytes caps each request body. 0 uses the gateway config's
+	// max_request_bytes, or DefaultMaxRequestBytes when that is unset too.
+	MaxRequestBytes int64
+}
+
+// Handler returns an http.Handler serving gw's OpenAI-compatible inference
+// API — POST /v1/chat/completions, plus the endpoints opts enables — with the
+// same request decoding, validation, error envelopes, and response headers as
+// ferrogw. Any other path gets a 404 in the OpenAI error format.
+//
+// It is meant for embedding the gateway in an existing server, so it leaves
+// authentication, CORS, and rate limiting to the caller's own middleware:
+//
+//	mux.Handle("/v1/", aigateway.Handler(gw, aigateway.HandlerOptions{Streaming: true}))
+func Handler(gw *Gateway, opts HandlerOptions) http.Handler {
+	limit := opts.MaxRequestBytes
+	if limit <= 0 {
+		limit = gw.GetConfig().MaxRequestBytes
+	}
+	if limit <= 0 {
+		limit = DefaultMaxRequestBytes
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/chat/completions", openaiapi.ChatCompletions(gw, opts.Streaming))
+	if opts.Embeddings {
+		mux.Handle("POST /v1/embeddings", openaiapi.Embeddings(gw))
+	}
+	if opts.Images {
+		mux.Handle("POST /v1/images/generations", openaiapi.Images(gw))
+	}
+	if opts.Moderations {
+		mux.Handle("POST /v1/moderations", openaiapi.Moderations(gw))
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		apierror.WriteOpenAI(w, http.StatusNotFound, "unknown endpoint: "+r.Method+" "+r.URL.Path, "invalid_request_error", "not_found")
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		mux.ServeHTTP(w, r.WithContext(routingrules.WithRequest(r.Context(), r)))
+	})
+}
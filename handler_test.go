@@ -0,0 +1,113 @@
+package aigateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestHandler(t *testing.T) {
+	gw, err := newTestGateway(t, Config{
+		Strategy: StrategyConfig{Mode: ModeSingle},
+		Targets:  []Target{{VirtualKey: "mock"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(&mockProvider{
+		name:   "mock",
+		models: []string{"gpt-4o"},
+		resp: &providers.Response{
+			ID:      "chatcmpl-1",
+			Model:   "gpt-4o",
+			Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "hello"}}},
+		},
+	})
+	h := Handler(gw, HandlerOptions{MaxRequestBytes: 512})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), method, path, strings.NewReader(body)))
+		return w
+	}
+	chat := `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`
+
+	t.Run("chat completion", func(t *testing.T) {
+		w := serve(http.MethodPost, "/v1/chat/completions", chat)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body)
+		}
+		var resp providers.Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.ID != "chatcmpl-1" || resp.Choices[0].Message.Content != "hello" {
+			t.Fatalf("response = %+v", resp)
+		}
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		if w := serve(http.MethodPost, "/v1/chat/completions", `{"model":"gpt-4o"}`); w.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("streaming not enabled", func(t *testing.T) {
+		body := `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+		if w := serve(http.MethodPost, "/v1/chat/completions", body); w.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("endpoint not enabled", func(t *testing.T) {
+		w := serve(http.MethodPost, "/v1/embeddings", `{"model":"m","input":"x"}`)
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"not_found"`) {
+			t.Fatalf("status = %d, body %s; want an OpenAI 404", w.Code, w.Body)
+		}
+	})
+
+	t.Run("moderations", func(t *testing.T) {
+		mgw, err := newTestGateway(t, Config{
+			Strategy: StrategyConfig{Mode: ModeSingle},
+			Targets:  []Target{{VirtualKey: mockProviderName}},
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		mgw.RegisterProvider(&mockModerationProvider{
+			mockProvider: mockProvider{name: mockProviderName, models: []string{providers.DefaultModerationModel}},
+		})
+		mh := Handler(mgw, HandlerOptions{Moderations: true})
+
+		w := httptest.NewRecorder()
+		mh.ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/moderations", strings.NewReader(`{"input":"hi"}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body)
+		}
+		var resp providers.ModerationResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(resp.Results) != 1 || resp.Results[0].CategoryScores["violence"] != 0.9 {
+			t.Fatalf("response = %+v", resp)
+		}
+
+		w = httptest.NewRecorder()
+		mh.ServeHTTP(w, httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/moderations", strings.NewReader(`{"model":"omni-moderation-latest"}`)))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("missing input: status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		body := `{"model":"gpt-4o","messages":[{"role":"user","content":"` + strings.Repeat("x", 1024) + `"}]}`
+		if w := serve(http.MethodPost, "/v1/chat/completions", body); w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("status = %d, want 413", w.Code)
+		}
+	})
+}
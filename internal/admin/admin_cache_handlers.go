@@ -0,0 +1,354 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/cache"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/openaiapi"
+	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/go-chi/chi/v5"
+)
+
+// responseCaches returns the live response-cache instances. Several may be
+// configured (e.g. one per stage); every admin operation applies to all of
+// them so an operator never has to know how the pipeline is wired.
+func (h *Handlers) responseCaches() []cache.Admin {
+	if h.Plugins == nil {
+		return nil
+	}
+	var caches []cache.Admin
+	for _, p := range h.Plugins.Plugins() {
+		if c, ok := p.(cache.Admin); ok {
+			caches = append(caches, c)
+		}
+	}
+	return caches
+}
+
+func writeCacheNotEnabled(w http.ResponseWriter) {
+	writeError(w, http.StatusNotImplemented, "response cache is not enabled", "not_implemented_error", "not_implemented")
+}
+
+func (h *Handlers) cacheStats(w http.ResponseWriter, _ *http.Request) {
+	type namespaceInfo struct {
+		Namespace  string `json:"namespace"`
+		Entries    int    `json:"entries"`
+		TTLSeconds int64  `json:"ttl_seconds"`
+	}
+	type cacheInfo struct {
+		Entries           int             `json:"entries"`
+		Capacity          int             `json:"capacity"`
+		DefaultTTLSeconds int64           `json:"default_ttl_seconds"`
+		Hits              int64           `json:"hits"`
+		Misses            int64           `json:"misses"`
+		Stale             int64           `json:"stale"`
+		Evictions         int64           `json:"evictions"`
+		Namespaces        []namespaceInfo `json:"namespaces"`
+	}
+
+	caches := h.responseCaches()
+	if len(caches) == 0 {
+		writeCacheNotEnabled(w)
+		return
+	}
+
+	result := make([]cacheInfo, 0, len(caches))
+	var entries int
+	var hits, misses, stale int64
+	for _, c := range caches {
+		s := c.Stats()
+		namespaces := make([]namespaceInfo, 0, len(s.Namespaces))
+		for _, ns := range s.Namespaces {
+			namespaces = append(namespaces, namespaceInfo{
+				Namespace:  ns.Namespace,
+				Entries:    ns.Entries,
+				TTLSeconds: int64(ns.TTL / time.Second),
+			})
+		}
+		result = append(result, cacheInfo{
+			Entries:           s.Entries,
+			Capacity:          s.Capacity,
+			DefaultTTLSeconds: int64(s.DefaultTTL / time.Second),
+			Hits:              s.Hits,
+			Misses:            s.Misses,
+			Stale:             s.Stale,
+			Evictions:         s.Evictions,
+			Namespaces:        namespaces,
+		})
+		entries += s.Entries
+		hits += s.Hits
+		misses += s.Misses
+		stale += s.Stale
+	}
+
+	hitRate := 0.0
+	if lookups := hits + misses; lookups > 0 {
+		hitRate = float64(hits) / float64(lookups)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"data": result,
+		"summary": map[string]any{
+			"entries":  entries,
+			"hits":     hits,
+			"misses":   misses,
+			"stale":    stale,
+			"hit_rate": hitRate,
+		},
+	})
+}
+
+// purgeCache removes entries matching the model, namespace, key_prefix, and
+// user query filters. At least one filter or all=true is required, so a bare
+// DELETE cannot wipe every tenant's cache by accident.
+func (h *Handlers) purgeCache(w http.ResponseWriter, r *http.Request) {
+	caches := h.responseCaches()
+	if len(caches) == 0 {
+		writeCacheNotEnabled(w)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := cache.PurgeFilter{
+		Namespace: q.Get("namespace"),
+		Model:     q.Get("model"),
+		KeyPrefix: q.Get("key_prefix"),
+		User:      q.Get("user"),
+	}
+	if filter == (cache.PurgeFilter{}) && q.Get("all") != "true" {
+		writeError(w, http.StatusBadRequest, "a model, namespace, key_prefix, or user filter is required; pass all=true to purge everything", "invalid_request_error", "invalid_request")
+		return
+	}
+
+	purged := 0
+	for _, c := range caches {
+		purged += c.Purge(filter)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"purged": purged,
+		"filters": map[string]any{
+			"namespace":  filter.Namespace,
+			"model":      filter.Model,
+			"key_prefix": filter.KeyPrefix,
+			"user":       filter.User,
+		},
+	})
+}
+
+// setCacheNamespaceTTL overrides the TTL for one namespace. It applies to
+// entries stored from now on, and lasts until the next config reload rebuilds
+// the plugin; persistent overrides belong in the plugin's namespace_ttls.
+func (h *Handlers) setCacheNamespaceTTL(w http.ResponseWriter, r *http.Request) {
+	caches := h.responseCaches()
+	if len(caches) == 0 {
+		writeCacheNotEnabled(w)
+		return
+	}
+
+	var body struct {
+		TTLSeconds int64 `json:"ttl_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	if body.TTLSeconds <= 0 {
+		writeError(w, http.StatusBadRequest, "ttl_seconds must be a positive integer", "invalid_request_error", "invalid_request")
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	for _, c := range caches {
+		c.SetNamespaceTTL(namespace, time.Duration(body.TTLSeconds)*time.Second)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"namespace":   namespace,
+		"ttl_seconds": body.TTLSeconds,
+	})
+}
+
+// clearCacheNamespaceTTL drops a namespace's TTL override so it falls back to
+// the cache-wide default.
+func (h *Handlers) clearCacheNamespaceTTL(w http.ResponseWriter, r *http.Request) {
+	caches := h.responseCaches()
+	if len(caches) == 0 {
+		writeCacheNotEnabled(w)
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	for _, c := range caches {
+		c.SetNamespaceTTL(namespace, 0)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxWarmRequests bounds the requests one warm-up call runs, and
+// warmConcurrency how many of them are in flight at once.
+const (
+	maxWarmRequests = 100
+	warmConcurrency = 4
+)
+
+// warmCacheRequest is the body of POST /admin/cache/warm. Requests are chat
+// completion bodies, cached under exactly the key a client sending the same
+// body gets; Prompts and Models are shorthand for one single-message request
+// per prompt and model.
+type warmCacheRequest struct {
+	Requests []json.RawMessage `json:"requests"`
+	Prompts  []string          `json:"prompts"`
+	Models   []string          `json:"models"`
+	// KeyID stores the entries for this API key. Entries are namespaced by
+	// key, so they are served only to requests made with it; omitted, they
+	// serve requests made without a key.
+	KeyID string `json:"key_id"`
+	// Provider runs the requests against this target or provider (a mock or
+	// an off-peak deployment) instead of routing them.
+	Provider string `json:"provider"`
+}
+
+// warmCacheResult reports one warmed request. Status is "warmed" when the
+// response was fetched and stored, "cached" when a fresh entry was already
+// there, "stale" when only an expired one was (it is refreshed by the next
+// request), "skipped" when no response cache ran for the request (a plugin
+// match rule excludes it), or "error".
+type warmCacheResult struct {
+	Model  string `json:"model"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// warmCache runs chat requests through the gateway so the response cache
+// stores their responses, ahead of traffic that will ask for them (a launch,
+// a demo). Each request takes the whole pipeline, plugins included, so it is
+// cached exactly as a client's would be; one that is already cached is served
+// from the cache and reported as such.
+func (h *Handlers) warmCache(w http.ResponseWriter, r *http.Request) {
+	caches := h.responseCaches()
+	if len(caches) == 0 || h.Chat == nil {
+		writeCacheNotEnabled(w)
+		return
+	}
+
+	var body warmCacheRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	if len(body.Prompts) > 0 && len(body.Models) == 0 {
+		writeError(w, http.StatusBadRequest, "prompts need at least one model", "invalid_request_error", "invalid_request")
+		return
+	}
+	reqs := make([]providers.Request, 0, len(body.Requests)+len(body.Prompts)*len(body.Models))
+	for i, raw := range body.Requests {
+		req, err := openaiapi.DecodeChatCompletionRequest(bytes.NewReader(raw))
+		if err == nil {
+			err = req.Validate()
+		}
+		if err == nil && req.Stream {
+			err = errors.New("streamed requests cannot be warmed")
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("requests[%d]: %v", i, err), "invalid_request_error", "invalid_request")
+			return
+		}
+		reqs = append(reqs, req)
+	}
+	for _, model := range body.Models {
+		for _, prompt := range body.Prompts {
+			reqs = append(reqs, providers.Request{
+				Model:    model,
+				Messages: []providers.Message{{Role: "user", Content: prompt}},
+			})
+		}
+	}
+	if len(reqs) == 0 {
+		writeError(w, http.StatusBadRequest, "requests, or prompts and models, are required", "invalid_request_error", "invalid_request")
+		return
+	}
+	if len(reqs) > maxWarmRequests {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d requests can be warmed at once", maxWarmRequests), "invalid_request_error", "invalid_request")
+		return
+	}
+
+	// The requests run as the key they are warmed for, not as the admin key
+	// that asked, so they land in its cache namespace and pass its plugins.
+	ctx, cancel := context.WithCancel(logging.WithTraceID(context.Background(), logging.TraceIDFromContext(r.Context())))
+	defer cancel()
+	defer context.AfterFunc(r.Context(), cancel)()
+	if body.KeyID != "" {
+		var key *APIKey
+		ok := false
+		if h.Keys != nil {
+			key, ok = h.Keys.Get(r.Context(), body.KeyID)
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "api key not found", "not_found_error", "resource_not_found")
+			return
+		}
+		ctx = storeKeyInContext(ctx, key)
+	}
+	if body.Provider != "" {
+		ctx = aigateway.WithRequestOptions(ctx, aigateway.RequestOptions{Target: body.Provider})
+	}
+
+	results := make([]warmCacheResult, len(reqs))
+	sem := make(chan struct{}, warmConcurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = warmOne(ctx, h.Chat, req)
+		}()
+	}
+	wg.Wait()
+
+	summary := map[string]int{}
+	for _, res := range results {
+		summary[res.Status]++
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"data":    results,
+		"summary": summary,
+	})
+}
+
+func warmOne(ctx context.Context, chat ChatRouter, req providers.Request) warmCacheResult {
+	res := warmCacheResult{Model: req.Model}
+	resp, err := chat.Route(ctx, req)
+	switch {
+	case err != nil:
+		res.Status = "error"
+		res.Error = redact.ErrorMessage(err)
+	case resp.Cache == nil:
+		res.Status = "skipped"
+	case resp.Cache.Status == providers.CacheStatusHit:
+		res.Status = "cached"
+	case resp.Cache.Status == providers.CacheStatusStale:
+		res.Status = "stale"
+	default:
+		res.Status = "warmed"
+	}
+	return res
+}
@@ -0,0 +1,493 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/envref"
+	"github.com/ferro-labs/ai-gateway/internal/signing"
+	"github.com/ferro-labs/ai-gateway/mcp"
+)
+
+// A config bundle moves a gateway's configuration between environments. It
+// carries the whole runtime config, so the aliases, plugins, and rate-limit
+// tiers travel with the targets and strategy. Its secrets never leave the
+// gateway. ${VAR} references are kept as they are and resolve in the
+// importing environment. Literal values of secret-bearing keys (API keys,
+// tokens, passwords, DSNs, credentialed URLs) are exported as [REDACTED], and
+// an import restores each one from the same place in the importing gateway's
+// config, which must already hold it. Every other value travels verbatim, so
+// a bundle without literal secrets imports into an empty gateway.
+
+// BundleSignatureHeader carries the detached signature of an imported bundle
+// or of a config written with POST or PUT /admin/config: a cosign base64
+// signature as it is, or a minisign signature file base64-encoded.
+const BundleSignatureHeader = "X-Ferro-Bundle-Signature"
+
+// configBundleVersion is the bundle format GET /admin/config/export writes
+// and POST /admin/config/import accepts.
+const configBundleVersion = 1
+
+// ConfigBundle is the body of a config export and import.
+type ConfigBundle struct {
+	BundleVersion int              `json:"bundle_version"`
+	ExportedAt    time.Time        `json:"exported_at"`
+	Config        aigateway.Config `json:"config"`
+	// Redacted lists the paths of the literal secrets exported as
+	// [REDACTED].
+	Redacted []string `json:"redacted,omitempty"`
+	// EnvRefs lists the environment variables the config's ${VAR}
+	// references name. Each must be set where the bundle is imported.
+	EnvRefs []string `json:"env_refs,omitempty"`
+}
+
+// configChange is one difference between the active config and an imported
+// one.
+type configChange struct {
+	Path string `json:"path"`
+	Op   string `json:"op"` // "added", "removed", or "changed"
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+func (h *Handlers) exportConfig(w http.ResponseWriter, _ *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	cfg := h.Configs.GetConfig()
+
+	bundle := ConfigBundle{
+		BundleVersion: configBundleVersion,
+		ExportedAt:    time.Now().UTC(),
+	}
+	refs := make(map[string]bool)
+	bundle.Config = mapSecretStrings(cfg, func(path, v string) string {
+		switch {
+		case isEnvRef(v):
+			refs[v[2:len(v)-1]] = true
+		case isBundleSecret(path, v):
+			bundle.Redacted = append(bundle.Redacted, path)
+			return redactedPlaceholder
+		}
+		return v
+	})
+	for name := range refs {
+		bundle.EnvRefs = append(bundle.EnvRefs, name)
+	}
+	slices.Sort(bundle.Redacted)
+	slices.Sort(bundle.EnvRefs)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Disposition", `attachment; filename="ferrogw-config.json"`)
+	_ = json.NewEncoder(w).Encode(bundle)
+}
+
+// importConfig applies a config bundle. With ?dry_run=true it only validates
+// the bundle and reports the changes it would make.
+func (h *Handlers) importConfig(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	dryRun, ok := parseDryRun(w, r)
+	if !ok {
+		return
+	}
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	// The signature covers the bundle's bytes as sent, so it is checked
+	// before they are decoded.
+	if !h.verifyConfigSignature(w, r, raw) {
+		return
+	}
+	var bundle ConfigBundle
+	if err := json.Unmarshal(raw, &bundle); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	if err := checkBundleVersion(bundle); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_bundle")
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg, changes, err := planBundle(bundle, h.Configs.GetConfig())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_config")
+		return
+	}
+
+	status := "dry_run"
+	if !dryRun {
+		status = "imported"
+		if len(changes) == 0 {
+			status = "unchanged"
+		} else {
+			if err := h.Configs.ReloadConfig(r.Context(), cfg); err != nil {
+				writeConfigReloadError(w, err)
+				return
+			}
+			h.appendConfigHistoryLocked(r.Context(), cfg, nil)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"status":  status,
+		"changes": changes,
+	})
+}
+
+// verifyConfigSignature checks the signature sent with a config write over
+// raw, the request body as sent. On failure it writes the error and returns
+// false.
+func (h *Handlers) verifyConfigSignature(w http.ResponseWriter, r *http.Request, raw []byte) bool {
+	v := bundleVerifier(h.Configs)
+	if v == nil {
+		return true
+	}
+	if err := v.Verify(raw, signing.DecodeHeader(r.Header.Get(BundleSignatureHeader))); err != nil {
+		writeError(w, http.StatusBadRequest, "config signature: "+err.Error(), "invalid_request_error", "invalid_signature")
+		return false
+	}
+	return true
+}
+
+// requireSignedConfig refuses, under a strict verifier, config writes that
+// carry no config document to sign, such as rollbacks and plugin edits: the
+// config then changes only through signed documents.
+func (h *Handlers) requireSignedConfig(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := bundleVerifier(h.Configs); v != nil && v.Strict {
+			writeError(w, http.StatusConflict, "config changes must be signed; write the whole config with a signature (PUT /admin/config or POST /admin/config/import)",
+				"invalid_request_error", "config_requires_signature")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bundleVerifier returns the verifier cm's config bundles must pass, or nil
+// when cm does not check signatures.
+func bundleVerifier(cm ConfigManager) *signing.Verifier {
+	src, ok := cm.(interface{ BundleVerifier() *signing.Verifier })
+	if !ok {
+		return nil
+	}
+	return src.BundleVerifier()
+}
+
+// checkBundleVersion rejects a bundle written in a format this gateway does
+// not read.
+func checkBundleVersion(bundle ConfigBundle) error {
+	if bundle.BundleVersion != configBundleVersion {
+		return fmt.Errorf("unsupported bundle_version %d; this gateway reads version %d", bundle.BundleVersion, configBundleVersion)
+	}
+	return nil
+}
+
+// planBundle resolves and validates the config bundle carries and lists the
+// changes applying it over current would make.
+func planBundle(bundle ConfigBundle, current aigateway.Config) (aigateway.Config, []configChange, error) {
+	cfg, err := resolveBundleSecrets(bundle.Config, current)
+	if err == nil {
+		err = aigateway.ValidateConfig(cfg)
+	}
+	if err != nil {
+		return cfg, nil, err
+	}
+	return cfg, diffConfigs(current, cfg), nil
+}
+
+// resolveBundleSecrets returns cfg with each [REDACTED] value restored from
+// the same path in current. It fails when a redacted value has no
+// counterpart in current, or when a ${VAR} reference names a variable that
+// is not set.
+func resolveBundleSecrets(cfg, current aigateway.Config) (aigateway.Config, error) {
+	known := secretStrings(current)
+
+	var unresolved, undefined []string
+	cfg = mapSecretStrings(cfg, func(path, v string) string {
+		if v == redactedPlaceholder {
+			restored, ok := known[path]
+			if !ok {
+				unresolved = append(unresolved, path)
+			}
+			return restored
+		}
+		if _, err := envref.Expand(v); err != nil {
+			undefined = append(undefined, path+": "+err.Error())
+		}
+		return v
+	})
+	if len(unresolved) > 0 {
+		slices.Sort(unresolved)
+		return cfg, fmt.Errorf("redacted secrets with no value in this gateway's config (set them here first or replace them with ${VAR} references): %s", strings.Join(unresolved, ", "))
+	}
+	if len(undefined) > 0 {
+		slices.Sort(undefined)
+		return cfg, fmt.Errorf("config references unset environment variables: %s", strings.Join(undefined, "; "))
+	}
+	return cfg, nil
+}
+
+// secretKeyWords are the words that mark a config key as secret-bearing.
+var secretKeyWords = map[string]bool{
+	"secret": true, "password": true, "passwd": true, "token": true,
+	"dsn": true, "credential": true, "credentials": true,
+	"authorization": true, "auth": true, "cookie": true, "apikey": true,
+}
+
+// secretKeyQualifiers are the words that make a following "key" a secret
+// (api_key, private_key, X-Api-Key) rather than a name (key_prefix).
+var secretKeyQualifiers = map[string]bool{
+	"api": true, "access": true, "private": true, "secret": true,
+	"signing": true, "encryption": true, "master": true, "client": true,
+	"subscription": true, "license": true,
+}
+
+// isBundleSecret reports whether the literal v at path is secret-bearing and
+// so must not leave the gateway in a bundle. A value is secret when the key
+// it sits under names a credential, or when it is a URL carrying a
+// password.
+func isBundleSecret(path, v string) bool {
+	if u, err := url.Parse(v); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return true
+		}
+	}
+	// The key is the last path segment, after any list indices.
+	for strings.HasSuffix(path, "]") {
+		i := strings.LastIndexByte(path, '[')
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	key := strings.ToLower(path[strings.LastIndexByte(path, '.')+1:])
+	words := strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+	for i, word := range words {
+		if secretKeyWords[word] {
+			return true
+		}
+		if word == "key" && i > 0 && secretKeyQualifiers[words[i-1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubBundleSecrets returns cfg with the literal values isBundleSecret
+// selects replaced by [REDACTED].
+func scrubBundleSecrets(cfg aigateway.Config) aigateway.Config {
+	return mapSecretStrings(cfg, func(path, v string) string {
+		if !isEnvRef(v) && isBundleSecret(path, v) {
+			return redactedPlaceholder
+		}
+		return v
+	})
+}
+
+// secretStrings returns every string mapSecretStrings visits in cfg, keyed
+// by path.
+func secretStrings(cfg aigateway.Config) map[string]string {
+	out := make(map[string]string)
+	mapSecretStrings(cfg, func(path, v string) string {
+		out[path] = v
+		return v
+	})
+	return out
+}
+
+// mapSecretStrings returns a copy of cfg with fn applied to every string in
+// the fields scrubConfigSecrets redacts. fn receives each string's path,
+// which names plugins and MCP servers rather than numbering them so the
+// same secret has the same path in every environment. The containers along
+// the way are copied; cfg is never mutated.
+func mapSecretStrings(cfg aigateway.Config, fn func(path, v string) string) aigateway.Config {
+	cfg.Observability.Tracing.Headers = mapSecretStringMap("observability.tracing.headers", cfg.Observability.Tracing.Headers, fn)
+
+	if cfg.MCPServers != nil {
+		servers := make([]mcp.ServerConfig, len(cfg.MCPServers))
+		for i, server := range cfg.MCPServers {
+			prefix := "mcp_servers[" + server.Name + "]"
+			server.Headers = mapSecretStringMap(prefix+".headers", server.Headers, fn)
+			server.Env = mapSecretStringMap(prefix+".env", server.Env, fn)
+			servers[i] = server
+		}
+		cfg.MCPServers = servers
+	}
+
+	if cfg.Observability.Exporters != nil {
+		exporters := make([]aigateway.ExporterConfig, len(cfg.Observability.Exporters))
+		for i, exp := range cfg.Observability.Exporters {
+			if exp.Config != nil {
+				exp.Config = mapSecretAny("observability.exporters["+strconv.Itoa(i)+"].config", exp.Config, fn).(map[string]any)
+			}
+			exporters[i] = exp
+		}
+		cfg.Observability.Exporters = exporters
+	}
+
+	if cfg.Plugins != nil {
+		plugins := make([]aigateway.PluginConfig, len(cfg.Plugins))
+		for i, p := range cfg.Plugins {
+			if p.Config != nil {
+				p.Config = mapSecretAny("plugins["+p.Name+"].config", p.Config, fn).(map[string]any)
+			}
+			plugins[i] = p
+		}
+		cfg.Plugins = plugins
+	}
+
+	return cfg
+}
+
+func mapSecretStringMap(path string, m map[string]string, fn func(path, v string) string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fn(path+"."+k, v)
+	}
+	return out
+}
+
+// mapSecretAny walks the shapes a decoded JSON or YAML config holds. Other
+// values are returned as they are.
+func mapSecretAny(path string, v any, fn func(path, v string) string) any {
+	switch val := v.(type) {
+	case string:
+		return fn(path, val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, elem := range val {
+			out[k] = mapSecretAny(path+"."+k, elem, fn)
+		}
+		return out
+	case map[string]string:
+		return mapSecretStringMap(path, val, fn)
+	case []any:
+		out := make([]any, len(val))
+		for i, elem := range val {
+			out[i] = mapSecretAny(path+"["+strconv.Itoa(i)+"]", elem, fn)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, elem := range val {
+			out[i] = fn(path+"["+strconv.Itoa(i)+"]", elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// diffConfigs lists the differences between two configs by JSON path. Both
+// sides have their secrets scrubbed first. A literal secret replaced by
+// another literal then looks unchanged, so those are listed after the rest
+// under their secret path, with both values redacted.
+func diffConfigs(from, to aigateway.Config) []configChange {
+	changes := []configChange{}
+	diffValues("", genericJSON(scrubBundleSecrets(from)), genericJSON(scrubBundleSecrets(to)), &changes)
+
+	var replaced []string
+	fromSecrets := secretStrings(from)
+	for path, v := range secretStrings(to) {
+		if old, ok := fromSecrets[path]; ok && old != v && !isEnvRef(old) && !isEnvRef(v) && (isBundleSecret(path, old) || isBundleSecret(path, v)) {
+			replaced = append(replaced, path)
+		}
+	}
+	slices.Sort(replaced)
+	for _, path := range replaced {
+		changes = append(changes, configChange{Path: path, Op: "changed", From: redactedPlaceholder, To: redactedPlaceholder})
+	}
+	return changes
+}
+
+// genericJSON round-trips v through JSON into maps, slices, and scalars.
+func genericJSON(v any) any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+func diffValues(path string, from, to any, changes *[]configChange) {
+	fromMap, fromIsMap := from.(map[string]any)
+	toMap, toIsMap := to.(map[string]any)
+	if fromIsMap && toIsMap {
+		keys := make([]string, 0, len(fromMap)+len(toMap))
+		for k := range fromMap {
+			keys = append(keys, k)
+		}
+		for k := range toMap {
+			if _, ok := fromMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			fromV, inFrom := fromMap[k]
+			toV, inTo := toMap[k]
+			switch {
+			case !inFrom:
+				*changes = append(*changes, configChange{Path: child, Op: "added", To: toV})
+			case !inTo:
+				*changes = append(*changes, configChange{Path: child, Op: "removed", From: fromV})
+			default:
+				diffValues(child, fromV, toV, changes)
+			}
+		}
+		return
+	}
+
+	fromList, fromIsList := from.([]any)
+	toList, toIsList := to.([]any)
+	if fromIsList && toIsList {
+		for i := range max(len(fromList), len(toList)) {
+			child := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(fromList):
+				*changes = append(*changes, configChange{Path: child, Op: "added", To: toList[i]})
+			case i >= len(toList):
+				*changes = append(*changes, configChange{Path: child, Op: "removed", From: fromList[i]})
+			default:
+				diffValues(child, fromList[i], toList[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, configChange{Path: path, Op: "changed", From: from, To: to})
+	}
+}
@@ -1,8 +1,10 @@
 package admin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -19,10 +21,12 @@ const maxConfigHistoryEntries = 200
 
 // ConfigHistoryEntry captures a runtime config update snapshot.
 type ConfigHistoryEntry struct {
-	Version        int              `json:"version"`
-	UpdatedAt      time.Time        `json:"updated_at"`
-	Config         aigateway.Config `json:"config"`
-	RolledBackFrom *int             `json:"rolled_back_from,omitempty"`
+	Version   int              `json:"version"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Config    aigateway.Config `json:"config"`
+	// AuthorKeyID is the ID of the API key that made the change.
+	AuthorKeyID    string `json:"author_key_id,omitempty"`
+	RolledBackFrom *int   `json:"rolled_back_from,omitempty"`
 }
 
 func (h *Handlers) getConfig(w http.ResponseWriter, _ *http.Request) {
@@ -36,13 +40,17 @@ func (h *Handlers) getConfig(w http.ResponseWriter, _ *http.Request) {
 	_ = json.NewEncoder(w).Encode(scrubConfigSecrets(h.Configs.GetConfig()))
 }
 
-func (h *Handlers) getConfigHistory(w http.ResponseWriter, _ *http.Request) {
+func (h *Handlers) getConfigHistory(w http.ResponseWriter, r *http.Request) {
 	if h.Configs == nil {
 		writeError(w, http.StatusNotImplemented, "config management is not enabled", "not_implemented_error", "not_implemented")
 		return
 	}
 
-	history := h.getConfigHistorySnapshot()
+	history, err := h.configHistorySnapshot(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error(), "server_error", "internal_error")
+		return
+	}
 
 	// Redact secret-bearing config values on each copied entry before encoding.
 	// scrubConfigSecrets operates on a copy so the live history is never mutated.
@@ -74,8 +82,16 @@ func (h *Handlers) applyConfigUpdate(w http.ResponseWriter, r *http.Request, sta
 		return
 	}
 
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	if !h.verifyConfigSignature(w, r, raw) {
+		return
+	}
 	var cfg aigateway.Config
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+	if err := json.Unmarshal(raw, &cfg); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
 		return
 	}
@@ -90,7 +106,7 @@ func (h *Handlers) applyConfigUpdate(w http.ResponseWriter, r *http.Request, sta
 		return
 	}
 
-	h.appendConfigHistoryLocked(cfg, nil)
+	h.appendConfigHistoryLocked(r.Context(), cfg, nil)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -119,7 +135,7 @@ func (h *Handlers) deleteConfig(w http.ResponseWriter, r *http.Request) {
 
 	// Reading the config back after the reset is only safe because configMu is
 	// still held: no concurrent mutation can replace it before it is recorded.
-	h.appendConfigHistoryLocked(h.Configs.GetConfig(), nil)
+	h.appendConfigHistoryLocked(r.Context(), h.Configs.GetConfig(), nil)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
@@ -143,6 +159,11 @@ func (h *Handlers) rollbackConfig(w http.ResponseWriter, r *http.Request) {
 	h.configMu.Lock()
 	defer h.configMu.Unlock()
 
+	if store, ok := h.persistedHistory(); ok {
+		h.rollbackPersistedConfig(w, r, store, requestedVersion)
+		return
+	}
+
 	h.historyMu.Lock()
 	var target *ConfigHistoryEntry
 	latestVersion := 0
@@ -169,7 +190,7 @@ func (h *Handlers) rollbackConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rollbackFrom := latestVersion
-	historySize := h.appendConfigHistoryLocked(target.Config, &rollbackFrom)
+	historySize := h.appendConfigHistoryLocked(r.Context(), target.Config, &rollbackFrom)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
@@ -179,19 +200,85 @@ func (h *Handlers) rollbackConfig(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// rollbackPersistedConfig is rollbackConfig against a config manager that
+// persists its history, so any retained version can be restored, including
+// ones from before a restart. The store records the version rolled back
+// from. The caller holds h.configMu.
+func (h *Handlers) rollbackPersistedConfig(w http.ResponseWriter, r *http.Request, store ConfigHistoryStore, requestedVersion int) {
+	target, ok, err := store.LoadVersion(r.Context(), requestedVersion)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error(), "server_error", "internal_error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "config version not found", "not_found_error", "resource_not_found")
+		return
+	}
+
+	if err := h.Configs.ReloadConfig(withConfigRollback(r.Context()), target.Config); err != nil {
+		writeConfigReloadError(w, err)
+		return
+	}
+	h.appendConfigHistoryLocked(r.Context(), target.Config, nil)
+
+	history, err := store.LoadHistory(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error(), "server_error", "internal_error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"status":               "rolled_back",
+		"rolled_back_to":       requestedVersion,
+		"current_history_size": len(history),
+	})
+}
+
+// persistedHistory returns the config manager's durable history store when
+// it has one. History and rollback then read it rather than the in-memory
+// history, which starts empty on every restart.
+func (h *Handlers) persistedHistory() (ConfigHistoryStore, bool) {
+	src, ok := h.Configs.(interface {
+		HistoryStore() (ConfigHistoryStore, bool)
+	})
+	if !ok {
+		return nil, false
+	}
+	return src.HistoryStore()
+}
+
+// configHistorySnapshot returns a copy of the config history, from the
+// durable store when there is one.
+func (h *Handlers) configHistorySnapshot(ctx context.Context) ([]ConfigHistoryEntry, error) {
+	store, ok := h.persistedHistory()
+	if !ok {
+		return h.getConfigHistorySnapshot(), nil
+	}
+	persisted, err := store.LoadHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]ConfigHistoryEntry, len(persisted))
+	for i, v := range persisted {
+		history[i] = v.entry()
+	}
+	return history, nil
+}
+
 // appendConfigHistoryLocked records cfg as the newest history version and
-// returns the resulting history size.
+// returns the resulting history size. The author is the API key on ctx.
 //
 // The caller must already hold h.configMu: the entry is only a truthful record
 // of the active config if no other mutation can run between applying cfg and
 // appending it. h.historyMu is taken here, for the slice write alone.
-func (h *Handlers) appendConfigHistoryLocked(cfg aigateway.Config, rolledBackFrom *int) int {
+func (h *Handlers) appendConfigHistoryLocked(ctx context.Context, cfg aigateway.Config, rolledBackFrom *int) int {
 	h.historyMu.Lock()
 	defer h.historyMu.Unlock()
 
 	// Derive the next version from the last entry's Version rather than the
 	// slice length: once old entries are evicted below, length no longer
 	// tracks the cumulative version count.
+	authorKeyID, _ := KeyIDFromContext(ctx)
 	nextVersion := 1
 	if n := len(h.configHistory); n > 0 {
 		nextVersion = h.configHistory[n-1].Version + 1
@@ -201,6 +288,7 @@ func (h *Handlers) appendConfigHistoryLocked(cfg aigateway.Config, rolledBackFro
 		Version:        nextVersion,
 		UpdatedAt:      time.Now().UTC(),
 		Config:         cfg,
+		AuthorKeyID:    authorKeyID,
 		RolledBackFrom: rolledBackFrom,
 	})
 
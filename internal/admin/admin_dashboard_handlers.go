@@ -89,8 +89,21 @@ func (h *Handlers) listProviders(w http.ResponseWriter, _ *http.Request) {
 	type providerInfo struct {
 		Name   string                `json:"name"`
 		Models []providers.ModelInfo `json:"models"`
+		// State is the maintenance state of a configured target; it is
+		// omitted for providers that are not targets.
+		State string `json:"state,omitempty"`
 	}
 
+	states := map[string]string{}
+	if h.Configs != nil {
+		for _, t := range h.Configs.GetConfig().Targets {
+			state := t.State
+			if h.Targets != nil {
+				state, _ = h.Targets.TargetState(t.VirtualKey)
+			}
+			states[t.VirtualKey] = targetStateName(state)
+		}
+	}
 	var result []providerInfo
 	entries, _ := h.listProviderStatus()
 	for _, e := range entries {
@@ -100,6 +113,7 @@ func (h *Handlers) listProviders(w http.ResponseWriter, _ *http.Request) {
 		result = append(result, providerInfo{
 			Name:   e.name,
 			Models: e.provider.Models(),
+			State:  states[e.name],
 		})
 	}
 	if result == nil {
@@ -109,30 +123,6 @@ func (h *Handlers) listProviders(w http.ResponseWriter, _ *http.Request) {
 	_ = json.NewEncoder(w).Encode(result)
 }
 
-func (h *Handlers) listPlugins(w http.ResponseWriter, _ *http.Request) {
-	type pluginInfo struct {
-		Name    string `json:"name"`
-		Type    string `json:"type"`
-		Enabled bool   `json:"enabled"`
-	}
-
-	var result []pluginInfo
-	if h.Configs != nil {
-		for _, p := range h.Configs.GetConfig().Plugins {
-			result = append(result, pluginInfo{
-				Name:    p.Name,
-				Type:    p.Type,
-				Enabled: p.Enabled,
-			})
-		}
-	}
-	if result == nil {
-		result = []pluginInfo{}
-	}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(result)
-}
-
 func (h *Handlers) healthCheck(w http.ResponseWriter, r *http.Request) {
 	type providerHealth struct {
 		Name    string `json:"name"`
@@ -0,0 +1,231 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
+	"github.com/ferro-labs/ai-gateway/internal/streamio"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/go-chi/chi/v5"
+)
+
+// Dead letters are requests that failed on every target, kept when a
+// dead-letter store is configured (DEAD_LETTER_STORE_BACKEND). After an
+// outage an operator lists them and re-drives them, one at a time or every
+// pending one at once; a re-driven request is routed like a client's.
+
+const (
+	defaultDeadLettersLimit = 50
+	maxDeadLettersLimit     = 500
+	// A bulk re-drive sends its requests one after another, so its limit
+	// bounds how long the admin request runs.
+	defaultRedriveLimit = 100
+	maxRedriveLimit     = 1000
+)
+
+// redriveResult is the outcome of re-driving one dead letter.
+type redriveResult struct {
+	Entry    deadletter.Entry    `json:"entry"`
+	Response *providers.Response `json:"response,omitempty"`
+}
+
+func writeDeadLettersNotEnabled(w http.ResponseWriter) {
+	writeError(w, http.StatusNotImplemented, "dead-letter queue is not enabled", "not_implemented_error", "not_implemented")
+}
+
+func writeDeadLetterNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "dead letter not found", "not_found_error", "resource_not_found")
+}
+
+// deadLetterPayloads returns the function that prepares entries for r's
+// caller, and whether the caller may read their requests. As with request-log
+// payloads, a sealed request is decrypted only for a key holding
+// ScopeLogsDecrypt; every other caller gets it sealed as stored. A request
+// that fails to open is left sealed.
+func (h *Handlers) deadLetterPayloads(r *http.Request) (func(deadletter.Entry) deadletter.Entry, bool) {
+	key, authed := APIKeyFromContext(r.Context())
+	if !authed || !slices.Contains(key.Scopes, ScopeLogsDecrypt) {
+		return func(e deadletter.Entry) deadletter.Entry { return e }, false
+	}
+	return func(e deadletter.Entry) deadletter.Entry {
+		opened, err := h.DeadLetters.Open(e)
+		if err != nil {
+			return e
+		}
+		return opened
+	}, true
+}
+
+// deadLetterStatus reads the optional "status" query parameter.
+func deadLetterStatus(w http.ResponseWriter, r *http.Request) (string, bool) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", deadletter.StatusPending, deadletter.StatusRedriving, deadletter.StatusRedriven:
+		return status, true
+	}
+	writeError(w, http.StatusBadRequest, "invalid status: must be pending or redriven", "invalid_request_error", "invalid_request")
+	return "", false
+}
+
+// listDeadLetters returns the kept requests, newest first. The status query
+// parameter restricts them to pending or re-driven ones.
+func (h *Handlers) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if h.DeadLetters == nil {
+		writeDeadLettersNotEnabled(w)
+		return
+	}
+	status, ok := deadLetterStatus(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(w, r, defaultDeadLettersLimit, maxDeadLettersLimit)
+	if !ok {
+		return
+	}
+	entries, err := h.DeadLetters.Store().List(r.Context(), deadletter.Query{Status: status, Limit: limit})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list dead letters", "server_error", "internal_error")
+		return
+	}
+	prepare, _ := h.deadLetterPayloads(r)
+	for i := range entries {
+		entries[i] = prepare(entries[i])
+	}
+	writeEvalJSON(w, http.StatusOK, map[string]any{
+		"data": entries,
+		"summary": map[string]any{
+			"total_entries": len(entries),
+		},
+	})
+}
+
+func (h *Handlers) getDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if h.DeadLetters == nil {
+		writeDeadLettersNotEnabled(w)
+		return
+	}
+	entry, ok, err := h.DeadLetters.Store().Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get dead letter", "server_error", "internal_error")
+		return
+	}
+	if !ok {
+		writeDeadLetterNotFound(w)
+		return
+	}
+	prepare, _ := h.deadLetterPayloads(r)
+	writeEvalJSON(w, http.StatusOK, prepare(entry))
+}
+
+func (h *Handlers) deleteDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if h.DeadLetters == nil {
+		writeDeadLettersNotEnabled(w)
+		return
+	}
+	deleted, err := h.DeadLetters.Store().Delete(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete dead letter", "server_error", "internal_error")
+		return
+	}
+	if !deleted {
+		writeDeadLetterNotFound(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// redriveDeadLetter sends one kept request again and returns its updated
+// entry, with the response when it succeeded. A request that fails again is
+// still a 200: the failure is in the entry's last_redrive_error. The response
+// to a sealed request is returned only to a caller allowed to read it.
+func (h *Handlers) redriveDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if h.DeadLetters == nil || h.Chat == nil {
+		writeDeadLettersNotEnabled(w)
+		return
+	}
+	entry, resp, err := h.DeadLetters.Redrive(r.Context(), h.Chat, h.runAsKey, chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, deadletter.ErrNotFound):
+		writeDeadLetterNotFound(w)
+		return
+	case errors.Is(err, deadletter.ErrRedriven):
+		writeError(w, http.StatusConflict, "dead letter was already re-driven", "invalid_request_error", "resource_conflict")
+		return
+	case errors.Is(err, deadletter.ErrRedriving):
+		writeError(w, http.StatusConflict, "dead letter is being re-driven", "invalid_request_error", "resource_conflict")
+		return
+	case errors.Is(err, deadletter.ErrKeyUnavailable):
+		writeError(w, http.StatusConflict, err.Error(), "invalid_request_error", "resource_conflict")
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to re-drive dead letter", "server_error", "internal_error")
+		return
+	}
+	prepare, decrypt := h.deadLetterPayloads(r)
+	if entry.Sealed() && !decrypt {
+		resp = nil
+	}
+	writeEvalJSON(w, http.StatusOK, redriveResult{Entry: prepare(entry), Response: resp})
+}
+
+// redriveDeadLetters sends every pending request again, oldest first, up to
+// the limit query parameter, and reports each outcome. It stops early when
+// the admin request is cancelled.
+func (h *Handlers) redriveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if h.DeadLetters == nil || h.Chat == nil {
+		writeDeadLettersNotEnabled(w)
+		return
+	}
+	limit, ok := parseLimit(w, r, defaultRedriveLimit, maxRedriveLimit)
+	if !ok {
+		return
+	}
+	pending, err := h.DeadLetters.Store().List(r.Context(), deadletter.Query{Status: deadletter.StatusPending})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list dead letters", "server_error", "internal_error")
+		return
+	}
+	// List is newest first; the oldest failures go first.
+	if len(pending) > limit {
+		pending = pending[len(pending)-limit:]
+	}
+	// Re-driving many requests outlasts the server's write timeout.
+	_ = streamio.ClearWriteDeadline(http.NewResponseController(w))
+
+	prepare, _ := h.deadLetterPayloads(r)
+	results := make([]deadletter.Entry, 0, len(pending))
+	redriven, failed, keyUnavailable := 0, 0, 0
+	for i := len(pending) - 1; i >= 0 && r.Context().Err() == nil; i-- {
+		entry, _, err := h.DeadLetters.Redrive(r.Context(), h.Chat, h.runAsKey, pending[i].ID)
+		if errors.Is(err, deadletter.ErrNotFound) || errors.Is(err, deadletter.ErrRedriven) || errors.Is(err, deadletter.ErrRedriving) {
+			// Deleted or re-driven since it was listed, or being re-driven
+			// by another caller.
+			continue
+		}
+		if errors.Is(err, deadletter.ErrKeyUnavailable) {
+			// Sent by a key that is gone; it stays pending.
+			keyUnavailable++
+			continue
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to re-drive dead letter", "server_error", "internal_error")
+			return
+		}
+		if entry.Status == deadletter.StatusRedriven {
+			redriven++
+		} else {
+			failed++
+		}
+		results = append(results, prepare(entry))
+	}
+	writeEvalJSON(w, http.StatusOK, map[string]any{
+		"data": results,
+		"summary": map[string]any{
+			"redriven":        redriven,
+			"failed":          failed,
+			"key_unavailable": keyUnavailable,
+		},
+	})
+}
@@ -0,0 +1,214 @@
+package admin
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/go-chi/chi/v5"
+)
+
+// Request parameter defaults live in the gateway config (request_defaults),
+// so, as with tiers, every mutation here is a config change that goes
+// through ReloadConfig and lands in the config history.
+
+// Request default scopes, as they appear in the path.
+const (
+	defaultsScopeWorkspaces = "workspaces"
+	defaultsScopeKeys       = "keys"
+)
+
+func writeDefaultsNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "request defaults not found", "not_found_error", "resource_not_found")
+}
+
+// cloneRequestDefaults returns a copy of cfg whose maps can be modified
+// without touching the active config.
+func cloneRequestDefaults(cfg *aigateway.RequestDefaultsConfig) *aigateway.RequestDefaultsConfig {
+	if cfg == nil {
+		return &aigateway.RequestDefaultsConfig{}
+	}
+	out := *cfg
+	out.Workspaces = maps.Clone(cfg.Workspaces)
+	out.Keys = maps.Clone(cfg.Keys)
+	return &out
+}
+
+// scopeMap returns the map of defaults for scope in cfg, creating it when
+// create is set, or false for an unknown scope.
+func scopeMap(cfg *aigateway.RequestDefaultsConfig, scope string, create bool) (map[string]aigateway.RequestParamDefaults, bool) {
+	var m *map[string]aigateway.RequestParamDefaults
+	switch scope {
+	case defaultsScopeWorkspaces:
+		m = &cfg.Workspaces
+	case defaultsScopeKeys:
+		m = &cfg.Keys
+	default:
+		return nil, false
+	}
+	if *m == nil && create {
+		*m = make(map[string]aigateway.RequestParamDefaults)
+	}
+	return *m, true
+}
+
+// applyRequestDefaultsLocked installs defaults as the active config's
+// request_defaults and records the result in the config history. The caller
+// must hold h.configMu and must have read the config it is modifying under
+// that same hold.
+func (h *Handlers) applyRequestDefaultsLocked(w http.ResponseWriter, r *http.Request, cfg aigateway.Config, defaults *aigateway.RequestDefaultsConfig) bool {
+	if defaults.Default == nil && len(defaults.Workspaces) == 0 && len(defaults.Keys) == 0 {
+		defaults = nil
+	}
+	cfg.RequestDefaults = defaults
+	if err := h.Configs.ReloadConfig(r.Context(), cfg); err != nil {
+		writeConfigReloadError(w, err)
+		return false
+	}
+	h.appendConfigHistoryLocked(r.Context(), cfg, nil)
+	return true
+}
+
+func (h *Handlers) getRequestDefaults(w http.ResponseWriter, _ *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	defaults := h.Configs.GetConfig().RequestDefaults
+	if defaults == nil {
+		defaults = &aigateway.RequestDefaultsConfig{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(defaults)
+}
+
+// decodeRequestDefaults reads a set of defaults from the request body and
+// validates it on its own, so a malformed value is reported as such rather
+// than as a generic config reload failure.
+func decodeRequestDefaults(w http.ResponseWriter, r *http.Request) (aigateway.RequestParamDefaults, bool) {
+	var d aigateway.RequestParamDefaults
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return d, false
+	}
+	if err := aigateway.ValidateRequestDefaults(&aigateway.RequestDefaultsConfig{Default: &d}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
+		return d, false
+	}
+	return d, true
+}
+
+// setDefaultRequestDefaults replaces the defaults of requests no key or
+// workspace default covers.
+func (h *Handlers) setDefaultRequestDefaults(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	d, ok := decodeRequestDefaults(w, r)
+	if !ok {
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	defaults := cloneRequestDefaults(cfg.RequestDefaults)
+	defaults.Default = &d
+	if !h.applyRequestDefaultsLocked(w, r, cfg, defaults) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(d)
+}
+
+func (h *Handlers) deleteDefaultRequestDefaults(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	if cfg.RequestDefaults == nil || cfg.RequestDefaults.Default == nil {
+		writeDefaultsNotFound(w)
+		return
+	}
+	defaults := cloneRequestDefaults(cfg.RequestDefaults)
+	defaults.Default = nil
+	if !h.applyRequestDefaultsLocked(w, r, cfg, defaults) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setScopedRequestDefaults replaces the defaults of one workspace or API key.
+// A key must exist; a workspace need not, so its defaults can be set up
+// before its first key is issued.
+func (h *Handlers) setScopedRequestDefaults(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	scope, name := chi.URLParam(r, "scope"), chi.URLParam(r, "name")
+	switch scope {
+	case defaultsScopeWorkspaces:
+	case defaultsScopeKeys:
+		if _, ok := h.Keys.Get(r.Context(), name); !ok {
+			writeError(w, http.StatusNotFound, "api key not found", "not_found_error", "resource_not_found")
+			return
+		}
+	default:
+		writeDefaultsNotFound(w)
+		return
+	}
+	d, ok := decodeRequestDefaults(w, r)
+	if !ok {
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	defaults := cloneRequestDefaults(cfg.RequestDefaults)
+	m, _ := scopeMap(defaults, scope, true)
+	m[name] = d
+	if !h.applyRequestDefaultsLocked(w, r, cfg, defaults) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(d)
+}
+
+func (h *Handlers) deleteScopedRequestDefaults(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	scope, name := chi.URLParam(r, "scope"), chi.URLParam(r, "name")
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	defaults := cloneRequestDefaults(cfg.RequestDefaults)
+	m, ok := scopeMap(defaults, scope, false)
+	if !ok {
+		writeDefaultsNotFound(w)
+		return
+	}
+	if _, ok := m[name]; !ok {
+		writeDefaultsNotFound(w)
+		return
+	}
+	delete(m, name)
+	if !h.applyRequestDefaultsLocked(w, r, cfg, defaults) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,297 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/evals"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/streamio"
+	"github.com/go-chi/chi/v5"
+)
+
+// Eval suites live in the gateway config (eval_suites), so defining, editing,
+// or deleting one is a config change recorded in the config history, exactly
+// like a rate-limit tier. Their scored runs are kept by the eval runner's
+// store, separately from the config, so a rollback never discards results.
+
+type evalSuiteInfo struct {
+	aigateway.EvalSuite
+	// Latest holds the newest run of each model, for comparison.
+	Latest []evals.Run `json:"latest"`
+}
+
+func findEvalSuite(suites []aigateway.EvalSuite, name string) (int, bool) {
+	for i, s := range suites {
+		if s.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func writeEvalSuiteNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "eval suite not found", "not_found_error", "resource_not_found")
+}
+
+func writeEvalsNotEnabled(w http.ResponseWriter) {
+	writeError(w, http.StatusNotImplemented, "evals are not enabled", "not_implemented_error", "not_implemented")
+}
+
+func writeEvalJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// applyEvalSuitesLocked installs suites as the active config's eval_suites and
+// records the result in the config history. The caller must hold h.configMu
+// and must have read the config it is modifying under that same hold.
+func (h *Handlers) applyEvalSuitesLocked(w http.ResponseWriter, r *http.Request, cfg aigateway.Config, suites []aigateway.EvalSuite) bool {
+	cfg.EvalSuites = suites
+	if err := h.Configs.ReloadConfig(r.Context(), cfg); err != nil {
+		writeConfigReloadError(w, err)
+		return false
+	}
+	h.appendConfigHistoryLocked(r.Context(), cfg, nil)
+	return true
+}
+
+// latestEvalRuns returns the newest run of each model for suite; there are
+// none when no runner is configured.
+func (h *Handlers) latestEvalRuns(r *http.Request, suite string) ([]evals.Run, error) {
+	if h.Evals == nil {
+		return []evals.Run{}, nil
+	}
+	runs, err := h.Evals.Store().List(r.Context(), suite, "")
+	if err != nil {
+		return nil, err
+	}
+	return evals.Latest(runs), nil
+}
+
+func (h *Handlers) listEvalSuites(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	suites := h.Configs.GetConfig().EvalSuites
+	result := make([]evalSuiteInfo, 0, len(suites))
+	for _, s := range suites {
+		latest, err := h.latestEvalRuns(r, s.Name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list eval runs", "server_error", "internal_error")
+			return
+		}
+		result = append(result, evalSuiteInfo{EvalSuite: s, Latest: latest})
+	}
+	writeEvalJSON(w, http.StatusOK, map[string]any{
+		"data": result,
+		"summary": map[string]any{
+			"total_suites": len(result),
+		},
+	})
+}
+
+func (h *Handlers) getEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	suites := h.Configs.GetConfig().EvalSuites
+	i, ok := findEvalSuite(suites, chi.URLParam(r, "name"))
+	if !ok {
+		writeEvalSuiteNotFound(w)
+		return
+	}
+	latest, err := h.latestEvalRuns(r, suites[i].Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list eval runs", "server_error", "internal_error")
+		return
+	}
+	writeEvalJSON(w, http.StatusOK, evalSuiteInfo{EvalSuite: suites[i], Latest: latest})
+}
+
+// decodeEvalSuite reads a suite from the request body and validates it on its
+// own, so a malformed suite is reported as such rather than as a generic
+// config reload failure.
+func decodeEvalSuite(w http.ResponseWriter, r *http.Request) (aigateway.EvalSuite, bool) {
+	var suite aigateway.EvalSuite
+	if err := json.NewDecoder(r.Body).Decode(&suite); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return suite, false
+	}
+	if err := aigateway.ValidateEvalSuites([]aigateway.EvalSuite{suite}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
+		return suite, false
+	}
+	return suite, true
+}
+
+func (h *Handlers) createEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	suite, ok := decodeEvalSuite(w, r)
+	if !ok {
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	if _, exists := findEvalSuite(cfg.EvalSuites, suite.Name); exists {
+		writeError(w, http.StatusConflict, "eval suite already exists: "+suite.Name, "invalid_request_error", "resource_conflict")
+		return
+	}
+	if !h.applyEvalSuitesLocked(w, r, cfg, append(slices.Clone(cfg.EvalSuites), suite)) {
+		return
+	}
+	writeEvalJSON(w, http.StatusCreated, suite)
+}
+
+// updateEvalSuite replaces a suite. The name comes from the path; a name in
+// the body must match it, since renaming would orphan the suite's runs.
+func (h *Handlers) updateEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	name := chi.URLParam(r, "name")
+	var suite aigateway.EvalSuite
+	if err := json.NewDecoder(r.Body).Decode(&suite); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	if suite.Name != "" && suite.Name != name {
+		writeError(w, http.StatusBadRequest, "suite name in body does not match the path; suites cannot be renamed", "invalid_request_error", "invalid_request")
+		return
+	}
+	suite.Name = name
+	if err := aigateway.ValidateEvalSuites([]aigateway.EvalSuite{suite}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	i, ok := findEvalSuite(cfg.EvalSuites, name)
+	if !ok {
+		writeEvalSuiteNotFound(w)
+		return
+	}
+	suites := slices.Clone(cfg.EvalSuites)
+	suites[i] = suite
+	if !h.applyEvalSuitesLocked(w, r, cfg, suites) {
+		return
+	}
+	writeEvalJSON(w, http.StatusOK, suite)
+}
+
+// deleteEvalSuite removes a suite and its stored runs.
+func (h *Handlers) deleteEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	i, ok := findEvalSuite(cfg.EvalSuites, name)
+	if !ok {
+		writeEvalSuiteNotFound(w)
+		return
+	}
+	if !h.applyEvalSuitesLocked(w, r, cfg, slices.Delete(slices.Clone(cfg.EvalSuites), i, i+1)) {
+		return
+	}
+	if h.Evals != nil {
+		_ = h.Evals.Store().DeleteSuite(r.Context(), name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runEvalSuite runs a suite against the models in the body and returns their
+// scored runs. The request blocks until every model has finished.
+func (h *Handlers) runEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil || h.Evals == nil {
+		writeEvalsNotEnabled(w)
+		return
+	}
+	var body struct {
+		Models []string `json:"models"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	if len(body.Models) == 0 || slices.Contains(body.Models, "") {
+		writeError(w, http.StatusBadRequest, "models must list at least one model", "invalid_request_error", "invalid_request")
+		return
+	}
+
+	suites := h.Configs.GetConfig().EvalSuites
+	i, ok := findEvalSuite(suites, chi.URLParam(r, "name"))
+	if !ok {
+		writeEvalSuiteNotFound(w)
+		return
+	}
+	// A run outlasts the server's write timeout as readily as a stream does.
+	_ = streamio.ClearWriteDeadline(http.NewResponseController(w))
+	key, _ := APIKeyFromContext(r.Context())
+	decrypt := key != nil && slices.Contains(key.Scopes, ScopeLogsDecrypt)
+	runs, err := h.Evals.Run(r.Context(), suites[i], body.Models, decrypt)
+	if err != nil {
+		if errors.Is(err, evals.ErrNoCases) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error(), "invalid_request_error", "invalid_request")
+			return
+		}
+		logging.Logger.Error("admin eval run failed", "suite", suites[i].Name, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error", "server_error", "internal_error")
+		return
+	}
+	writeEvalJSON(w, http.StatusOK, map[string]any{"data": runs})
+}
+
+// listEvalRuns returns a suite's stored runs, newest first, without their case
+// results. The model query parameter restricts them to one model.
+func (h *Handlers) listEvalRuns(w http.ResponseWriter, r *http.Request) {
+	if h.Evals == nil {
+		writeEvalsNotEnabled(w)
+		return
+	}
+	runs, err := h.Evals.Store().List(r.Context(), chi.URLParam(r, "name"), r.URL.Query().Get("model"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list eval runs", "server_error", "internal_error")
+		return
+	}
+	writeEvalJSON(w, http.StatusOK, map[string]any{"data": runs})
+}
+
+func (h *Handlers) getEvalRun(w http.ResponseWriter, r *http.Request) {
+	if h.Evals == nil {
+		writeEvalsNotEnabled(w)
+		return
+	}
+	run, ok, err := h.Evals.Store().Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get eval run", "server_error", "internal_error")
+		return
+	}
+	if !ok || run.Suite != chi.URLParam(r, "name") {
+		writeError(w, http.StatusNotFound, "eval run not found", "not_found_error", "resource_not_found")
+		return
+	}
+	writeEvalJSON(w, http.StatusOK, run)
+}
@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// getExperimentReport returns the comparison report of one configured
+// experiment. The experiment's definition comes from the active config; its
+// scores from the experiment recorder.
+func (h *Handlers) getExperimentReport(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	if h.Experiments == nil {
+		writeError(w, http.StatusNotImplemented, "experiments are not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	id := chi.URLParam(r, "id")
+	for _, e := range h.Configs.GetConfig().Experiments {
+		if e.ID == id {
+			writeEvalJSON(w, http.StatusOK, h.Experiments.Report(e))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "experiment not found", "not_found_error", "resource_not_found")
+}
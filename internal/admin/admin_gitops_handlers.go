@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// gitOps returns the GitOps sync managing the config, if one does.
+func (h *Handlers) gitOps() (*GitOpsSync, bool) {
+	g, ok := h.Configs.(*GitOpsSync)
+	return g, ok
+}
+
+// gitOpsStatus serves GET /admin/gitops.
+func (h *Handlers) gitOpsStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	g, ok := h.gitOps()
+	if !ok {
+		_ = json.NewEncoder(w).Encode(map[string]any{"enabled": false})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(struct {
+		Enabled bool `json:"enabled"`
+		GitOpsStatus
+	}{true, g.Status()})
+}
+
+// syncGitOps serves POST /admin/gitops/sync: it syncs at once rather than
+// waiting for the next interval. A bundle the gateway refuses answers 422; a
+// source it cannot reach, 502.
+func (h *Handlers) syncGitOps(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.gitOps()
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "gitops sync is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	if err := g.Sync(r.Context()); err != nil {
+		if errors.Is(err, errInvalidBundle) {
+			writeError(w, http.StatusUnprocessableEntity, "gitops sync failed: "+err.Error(), "invalid_request_error", "invalid_request")
+			return
+		}
+		writeError(w, http.StatusBadGateway, "gitops sync failed: "+err.Error(), "server_error", "gitops_sync_failed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(g.Status())
+}
+
+// requireConfigWritable refuses config writes while GitOps sync manages the
+// config: the source is its only writer.
+func (h *Handlers) requireConfigWritable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g, ok := h.gitOps(); ok {
+			writeError(w, http.StatusConflict, "config is managed by gitops sync from "+g.Source.String()+"; change it there",
+				"invalid_request_error", "config_managed_by_gitops")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -3,12 +3,15 @@ package admin
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"sort"
 	"strconv"
 	"time"
 
+	aigateway "github.com/ferro-labs/ai-gateway"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/webhook"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -28,9 +31,12 @@ func writeKeyStoreError(w http.ResponseWriter, err error) {
 
 func (h *Handlers) createKey(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		Name      string   `json:"name"`
-		Scopes    []string `json:"scopes"`
-		ExpiresAt string   `json:"expires_at"`
+		Name       string   `json:"name"`
+		Scopes     []string `json:"scopes"`
+		ExpiresAt  string   `json:"expires_at"`
+		Tier       string   `json:"tier"`
+		Workspace  string   `json:"workspace"`
+		WebhookURL string   `json:"webhook_url"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
@@ -40,6 +46,16 @@ func (h *Handlers) createKey(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "name is required", "invalid_request_error", "invalid_request")
 		return
 	}
+	if body.Tier != "" && !h.tierExists(body.Tier) {
+		writeUnknownTier(w, body.Tier)
+		return
+	}
+	if body.WebhookURL != "" {
+		if err := webhook.ValidateURL(body.WebhookURL); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid webhook_url: "+err.Error(), "invalid_request_error", "invalid_request")
+			return
+		}
+	}
 
 	var expiresAt *time.Time
 	if body.ExpiresAt != "" {
@@ -57,6 +73,37 @@ func (h *Handlers) createKey(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal server error", "server_error", "internal_error")
 		return
 	}
+	if body.Tier != "" {
+		if err := h.Keys.SetTier(r.Context(), key.ID, body.Tier); err != nil {
+			// Never hand back a key that would run without the limits the
+			// caller asked for.
+			_ = h.Keys.Delete(r.Context(), key.ID)
+			logging.Logger.Error("admin create key failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal server error", "server_error", "internal_error")
+			return
+		}
+		key.Tier = body.Tier
+	}
+	if body.Workspace != "" {
+		if err := h.Keys.SetWorkspace(r.Context(), key.ID, body.Workspace); err != nil {
+			// A key outside its workspace would escape the plugins scoped to it.
+			_ = h.Keys.Delete(r.Context(), key.ID)
+			logging.Logger.Error("admin create key failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal server error", "server_error", "internal_error")
+			return
+		}
+		key.Workspace = body.Workspace
+	}
+	if body.WebhookURL != "" {
+		if err := h.Keys.SetWebhookURL(r.Context(), key.ID, body.WebhookURL); err != nil {
+			_ = h.Keys.Delete(r.Context(), key.ID)
+			logging.Logger.Error("admin create key failed", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal server error", "server_error", "internal_error")
+			return
+		}
+		key.WebhookURL = body.WebhookURL
+	}
+	h.notifyKeyEvent(r.Context(), aigateway.SubjectKeyCreated, key)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-store")
@@ -211,11 +258,28 @@ func (h *Handlers) updateKey(w http.ResponseWriter, r *http.Request) {
 		Scopes          []string `json:"scopes"`
 		ExpiresAt       string   `json:"expires_at"`
 		ClearExpiration bool     `json:"clear_expiration"`
+		// Tier is a pointer so an omitted field leaves the tier alone while
+		// an explicit "" clears it.
+		Tier *string `json:"tier"`
+		// Workspace follows the same omitted-versus-empty rule as Tier.
+		Workspace *string `json:"workspace"`
+		// WebhookURL follows the same rule too.
+		WebhookURL *string `json:"webhook_url"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
 		return
 	}
+	if body.Tier != nil && *body.Tier != "" && !h.tierExists(*body.Tier) {
+		writeUnknownTier(w, *body.Tier)
+		return
+	}
+	if body.WebhookURL != nil && *body.WebhookURL != "" {
+		if err := webhook.ValidateURL(*body.WebhookURL); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid webhook_url: "+err.Error(), "invalid_request_error", "invalid_request")
+			return
+		}
+	}
 
 	var expiresAt *time.Time
 	if !body.ClearExpiration && body.ExpiresAt != "" {
@@ -248,16 +312,41 @@ func (h *Handlers) updateKey(w http.ResponseWriter, r *http.Request) {
 		key.ExpiresAt = &t
 	}
 
+	if body.Tier != nil {
+		if err := h.Keys.SetTier(r.Context(), id, *body.Tier); err != nil {
+			writeKeyStoreError(w, err)
+			return
+		}
+		key.Tier = *body.Tier
+	}
+	if body.Workspace != nil {
+		if err := h.Keys.SetWorkspace(r.Context(), id, *body.Workspace); err != nil {
+			writeKeyStoreError(w, err)
+			return
+		}
+		key.Workspace = *body.Workspace
+	}
+	if body.WebhookURL != nil {
+		if err := h.Keys.SetWebhookURL(r.Context(), id, *body.WebhookURL); err != nil {
+			writeKeyStoreError(w, err)
+			return
+		}
+		key.WebhookURL = *body.WebhookURL
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(key)
 }
 
 func (h *Handlers) deleteKey(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	// Read first: once deleted, the key's name is gone with it.
+	key, _ := h.Keys.Get(r.Context(), id)
 	if err := h.Keys.Delete(r.Context(), id); err != nil {
 		writeKeyStoreError(w, err)
 		return
 	}
+	h.notifyKeyEvent(r.Context(), aigateway.SubjectKeyDeleted, key)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -267,17 +356,42 @@ func (h *Handlers) revokeKey(w http.ResponseWriter, r *http.Request) {
 		writeKeyStoreError(w, err)
 		return
 	}
+	if key, ok := h.Keys.Get(r.Context(), id); ok {
+		h.notifyKeyEvent(r.Context(), aigateway.SubjectKeyRevoked, key)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "revoked"})
 }
 
+// rotateKey replaces a key's secret. The optional overlap in the body, a Go
+// duration such as "24h", is how long the previous secret keeps working so
+// deploys can roll the new one out; "0" cuts it off at once. Without it the
+// handler's KeyRotationOverlap applies.
 func (h *Handlers) rotateKey(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Overlap *string `json:"overlap"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	overlap := h.KeyRotationOverlap
+	if body.Overlap != nil {
+		d, err := time.ParseDuration(*body.Overlap)
+		if err != nil || d < 0 || d > MaxKeyRotationOverlap {
+			writeError(w, http.StatusBadRequest, "invalid overlap: must be a duration between 0 and 720h", "invalid_request_error", "invalid_request")
+			return
+		}
+		overlap = d
+	}
+
 	id := chi.URLParam(r, "id")
-	key, err := h.Keys.RotateKey(r.Context(), id)
+	key, err := h.Keys.RotateKey(r.Context(), id, overlap)
 	if err != nil {
 		writeKeyStoreError(w, err)
 		return
 	}
+	h.notifyKeyEvent(r.Context(), aigateway.SubjectKeyRotated, key)
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-store")
 	_ = json.NewEncoder(w).Encode(key)
@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/go-chi/chi/v5"
+)
+
+// replayLogRequest overrides where a replayed request goes. Both fields are
+// optional; an empty body replays the request as it was recorded.
+type replayLogRequest struct {
+	// Model replaces the recorded request's model.
+	Model string `json:"model"`
+	// Provider runs the request against this target or provider instead of
+	// routing it.
+	Provider string `json:"provider"`
+}
+
+// replayOutcome is what a request came to: the answer it got, or its error.
+type replayOutcome struct {
+	Model            string `json:"model,omitempty"`
+	Provider         string `json:"provider,omitempty"`
+	Content          string `json:"content,omitempty"`
+	FinishReason     string `json:"finish_reason,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	TotalTokens      int    `json:"total_tokens"`
+	Error            string `json:"error,omitempty"`
+}
+
+// replayChange is one field whose value differs between the original outcome
+// and the replay's.
+type replayChange struct {
+	Field    string `json:"field"`
+	Original any    `json:"original"`
+	Replay   any    `json:"replay"`
+}
+
+// replayDiff compares a replay's outcome to the original's.
+type replayDiff struct {
+	Identical bool           `json:"identical"`
+	Changes   []replayChange `json:"changes"`
+}
+
+// replayLogResult is the response of a replay.
+type replayLogResult struct {
+	TraceID       string              `json:"trace_id"`
+	ReplayTraceID string              `json:"replay_trace_id,omitempty"`
+	LatencyMs     int64               `json:"latency_ms"`
+	Original      replayOutcome       `json:"original"`
+	Replay        replayOutcome       `json:"replay"`
+	Diff          replayDiff          `json:"diff"`
+	Response      *providers.Response `json:"response,omitempty"`
+}
+
+// replayLog sends the request recorded for a trace through the gateway again,
+// optionally with another model or pinned to one provider, and returns the
+// new response with a diff against the recorded one. The request runs
+// non-streaming, as the key that first sent it, so its residency, limits, and
+// per-key plugins apply, and is never dead-lettered. The trace must have been
+// recorded with content; an encrypted one is replayed only for a key holding
+// ScopeLogsDecrypt.
+func (h *Handlers) replayLog(w http.ResponseWriter, r *http.Request) {
+	if h.Logs == nil {
+		writeError(w, http.StatusNotImplemented, "request log storage is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	if h.Chat == nil {
+		writeError(w, http.StatusNotImplemented, "request replay is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	var body replayLogRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	traceID := chi.URLParam(r, "trace_id")
+
+	result, err := h.Logs.List(r.Context(), requestlog.Query{TraceID: traceID, Limit: maxLogsLimit})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load request logs", "server_error", "internal_error")
+		return
+	}
+	if len(result.Data) == 0 {
+		writeError(w, http.StatusNotFound, "no request logs for trace: "+traceID, "not_found_error", "resource_not_found")
+		return
+	}
+	prepare := h.logPayloads(r)
+	for i := range result.Data {
+		result.Data[i] = prepare(result.Data[i])
+	}
+	t := buildTranscript(traceID, result.Data)
+	if requestlog.Sealed(t.Request) {
+		writeError(w, http.StatusForbidden, "the recorded request is encrypted; replaying it requires the logs_decrypt scope", "permission_error", "insufficient_scope")
+		return
+	}
+	var recorded requestlog.Request
+	if len(t.Request) == 0 || json.Unmarshal(t.Request, &recorded) != nil || len(recorded.Messages) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "trace was recorded without its request body", "invalid_request_error", "request_not_recorded")
+		return
+	}
+	req := recorded.Chat()
+	req.Stream = false
+	req.StreamOptions = nil
+	if body.Model != "" {
+		req.Model = body.Model
+	}
+
+	ctx, err := h.runAsKey(r.Context(), transcriptKeyID(result.Data))
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error(), "invalid_request_error", "resource_conflict")
+		return
+	}
+	ctx = aigateway.WithoutDeadLetter(ctx)
+	if body.Provider != "" {
+		ctx = aigateway.WithRequestOptions(ctx, aigateway.RequestOptions{Target: body.Provider})
+	}
+	start := time.Now()
+	resp, routeErr := h.Chat.Route(ctx, req)
+
+	out := replayLogResult{
+		TraceID:       traceID,
+		ReplayTraceID: logging.TraceIDFromContext(r.Context()),
+		LatencyMs:     time.Since(start).Milliseconds(),
+		Original:      transcriptOutcome(t),
+		Replay:        responseOutcome(resp, routeErr),
+	}
+	if routeErr == nil {
+		out.Response = resp
+	}
+	out.Diff = diffOutcomes(out.Original, out.Replay)
+	writeEvalJSON(w, http.StatusOK, out)
+}
+
+// transcriptKeyID returns the API key a trace's entries were recorded for, or
+// "" when it was sent without one.
+func transcriptKeyID(entries []requestlog.Entry) string {
+	for _, e := range entries {
+		if e.KeyID != "" {
+			return e.KeyID
+		}
+	}
+	return ""
+}
+
+// transcriptOutcome is the recorded outcome of a trace. The answer's content
+// and finish reason are known only when its response was recorded.
+func transcriptOutcome(t logTranscript) replayOutcome {
+	o := replayOutcome{
+		Model:            t.Model,
+		Provider:         t.Provider,
+		PromptTokens:     t.PromptTokens,
+		CompletionTokens: t.CompletionTokens,
+		TotalTokens:      t.TotalTokens,
+		Error:            t.ErrorMessage,
+	}
+	var resp providers.Response
+	if len(t.Response) > 0 && !requestlog.Sealed(t.Response) && json.Unmarshal(t.Response, &resp) == nil && len(resp.Choices) > 0 {
+		o.Content = resp.Choices[0].Message.Content
+		o.FinishReason = resp.Choices[0].FinishReason
+	}
+	return o
+}
+
+func responseOutcome(resp *providers.Response, err error) replayOutcome {
+	if err != nil {
+		return replayOutcome{Error: redact.ErrorMessage(err)}
+	}
+	o := replayOutcome{
+		Model:            resp.Model,
+		Provider:         resp.Provider,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}
+	if len(resp.Choices) > 0 {
+		o.Content = resp.Choices[0].Message.Content
+		o.FinishReason = resp.Choices[0].FinishReason
+	}
+	return o
+}
+
+// diffOutcomes lists the fields of replay that differ from original.
+func diffOutcomes(original, replay replayOutcome) replayDiff {
+	changes := make([]replayChange, 0)
+	add := func(field string, a, b any) {
+		if a != b {
+			changes = append(changes, replayChange{Field: field, Original: a, Replay: b})
+		}
+	}
+	add("model", original.Model, replay.Model)
+	add("provider", original.Provider, replay.Provider)
+	add("content", original.Content, replay.Content)
+	add("finish_reason", original.FinishReason, replay.FinishReason)
+	add("prompt_tokens", original.PromptTokens, replay.PromptTokens)
+	add("completion_tokens", original.CompletionTokens, replay.CompletionTokens)
+	add("total_tokens", original.TotalTokens, replay.TotalTokens)
+	add("error", original.Error, replay.Error)
+	return replayDiff{Identical: len(changes) == 0, Changes: changes}
+}
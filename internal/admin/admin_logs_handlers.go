@@ -1,12 +1,22 @@
 package admin
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"sort"
+	"strconv"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/cache"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/internal/streamio"
+	"github.com/go-chi/chi/v5"
 )
 
 func (h *Handlers) listLogs(w http.ResponseWriter, r *http.Request) {
@@ -44,6 +54,10 @@ func (h *Handlers) listLogs(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to list request logs", "server_error", "internal_error")
 		return
 	}
+	prepare := h.logPayloads(r)
+	for i := range result.Data {
+		result.Data[i] = prepare(result.Data[i])
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-store")
@@ -64,6 +78,297 @@ func (h *Handlers) listLogs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// logPayloads returns the function that prepares entries for r's caller. When
+// request-log payloads are encrypted, a key holding ScopeLogsDecrypt reads
+// them decrypted; every other caller gets the sealed values as stored. A
+// payload that fails to open is left sealed.
+func (h *Handlers) logPayloads(r *http.Request) func(requestlog.Entry) requestlog.Entry {
+	opener, ok := h.Logs.(requestlog.PayloadOpener)
+	key, authed := APIKeyFromContext(r.Context())
+	if !ok || !authed || !slices.Contains(key.Scopes, ScopeLogsDecrypt) {
+		return func(e requestlog.Entry) requestlog.Entry { return e }
+	}
+	return func(e requestlog.Entry) requestlog.Entry {
+		opened, err := opener.OpenPayloads(e)
+		if err != nil {
+			return e
+		}
+		return opened
+	}
+}
+
+// logStreamBuffer bounds the entries queued for one log-tail client; a client
+// further behind than this loses entries rather than slowing request logging.
+const logStreamBuffer = 256
+
+// logStreamHeartbeat is the interval of the SSE comment that keeps an idle
+// log tail from being closed by proxies.
+var logStreamHeartbeat = 15 * time.Second
+
+// streamLogs pushes request-log entries as they are written, as SSE "log"
+// events, until the client disconnects. The stage, model, provider, and
+// trace_id query parameters filter them as they do on /admin/logs. Entries
+// are those written by this gateway process.
+func (h *Handlers) streamLogs(w http.ResponseWriter, r *http.Request) {
+	if h.Logs == nil {
+		writeError(w, http.StatusNotImplemented, "request log storage is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	sub, ok := h.Logs.(requestlog.Subscriber)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "request log storage does not support live tailing", "not_implemented_error", "not_implemented")
+		return
+	}
+	entries, cancel := sub.Subscribe(requestlog.Query{
+		TraceID:  r.URL.Query().Get("trace_id"),
+		Stage:    r.URL.Query().Get("stage"),
+		Model:    r.URL.Query().Get("model"),
+		Provider: r.URL.Query().Get("provider"),
+	}, logStreamBuffer)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	controller := http.NewResponseController(w)
+	// A tail stays open far longer than the server's write timeout.
+	_ = streamio.ClearWriteDeadline(controller)
+	ctx := r.Context()
+	send := func(event string) error {
+		return streamio.WriteAndFlush(ctx, controller, nil, func() error {
+			_, err := io.WriteString(w, event)
+			return err
+		})
+	}
+	if send(": connected\n\n") != nil {
+		return
+	}
+
+	prepare := h.logPayloads(r)
+	heartbeat := time.NewTicker(logStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if send(": heartbeat\n\n") != nil {
+				return
+			}
+		case entry := <-entries:
+			data, err := json.Marshal(prepare(entry))
+			if err != nil {
+				continue
+			}
+			if send("event: log\ndata: "+string(data)+"\n\n") != nil {
+				return
+			}
+		}
+	}
+}
+
+// logTranscript is one request's log entries folded into a single record: the
+// request as received, the response returned, and what happened in between.
+type logTranscript struct {
+	TraceID          string             `json:"trace_id"`
+	Model            string             `json:"model,omitempty"`
+	Provider         string             `json:"provider,omitempty"`
+	Request          json.RawMessage    `json:"request,omitempty"`
+	Response         json.RawMessage    `json:"response,omitempty"`
+	PromptTokens     int                `json:"prompt_tokens"`
+	CompletionTokens int                `json:"completion_tokens"`
+	TotalTokens      int                `json:"total_tokens"`
+	CostUSD          float64            `json:"cost_usd"`
+	CacheStatus      string             `json:"cache_status,omitempty"`
+	ErrorMessage     string             `json:"error_message,omitempty"`
+	PluginDecisions  json.RawMessage    `json:"plugin_decisions,omitempty"`
+	StartedAt        time.Time          `json:"started_at"`
+	CompletedAt      time.Time          `json:"completed_at"`
+	Entries          []requestlog.Entry `json:"entries"`
+}
+
+// getLogTranscript returns everything recorded for one trace. Request and
+// response bodies are present only when the request logger records content.
+func (h *Handlers) getLogTranscript(w http.ResponseWriter, r *http.Request) {
+	if h.Logs == nil {
+		writeError(w, http.StatusNotImplemented, "request log storage is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	traceID := chi.URLParam(r, "trace_id")
+
+	result, err := h.Logs.List(r.Context(), requestlog.Query{TraceID: traceID, Limit: maxLogsLimit})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load request logs", "server_error", "internal_error")
+		return
+	}
+	if len(result.Data) == 0 {
+		writeError(w, http.StatusNotFound, "no request logs for trace: "+traceID, "not_found_error", "resource_not_found")
+		return
+	}
+	prepare := h.logPayloads(r)
+	for i := range result.Data {
+		result.Data[i] = prepare(result.Data[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(buildTranscript(traceID, result.Data))
+}
+
+// buildTranscript folds a trace's entries, oldest first, into one record. Each
+// stage's entry supplies what that stage knows; plugin decisions accumulate
+// over the request, so the latest entry's list is the complete one.
+func buildTranscript(traceID string, entries []requestlog.Entry) logTranscript {
+	entries = append([]requestlog.Entry(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+
+	t := logTranscript{
+		TraceID:     traceID,
+		StartedAt:   entries[0].CreatedAt,
+		CompletedAt: entries[len(entries)-1].CreatedAt,
+		Entries:     entries,
+	}
+	for _, e := range entries {
+		if e.Model != "" {
+			t.Model = e.Model
+		}
+		if e.Provider != "" {
+			t.Provider = e.Provider
+		}
+		if e.Request != nil {
+			t.Request = e.Request
+		}
+		if e.Response != nil {
+			t.Response = e.Response
+		}
+		if e.PluginDecisions != nil {
+			t.PluginDecisions = e.PluginDecisions
+		}
+		if e.ErrorMessage != "" {
+			t.ErrorMessage = e.ErrorMessage
+		}
+		if e.CacheStatus != "" {
+			t.CacheStatus = e.CacheStatus
+		}
+		t.PromptTokens += e.PromptTokens
+		t.CompletionTokens += e.CompletionTokens
+		t.TotalTokens += e.TotalTokens
+		t.CostUSD += e.CostUSD
+	}
+	return t
+}
+
+// logExportColumns is the CSV header. Bodies and plugin decisions are written
+// as JSON text in their cells.
+var logExportColumns = []string{
+	"trace_id", "stage", "model", "provider",
+	"prompt_tokens", "completion_tokens", "total_tokens", "cost_usd",
+	"cache_status", "error_message", "created_at",
+	"request", "response", "plugin_decisions",
+	"plugin", "decision", "reason",
+}
+
+// exportLogs writes every entry matching the list filters, newest first, as
+// JSON Lines (the default) or CSV. limit caps the number of rows; entries
+// written while the export runs are left out.
+func (h *Handlers) exportLogs(w http.ResponseWriter, r *http.Request) {
+	if h.Logs == nil {
+		writeError(w, http.StatusNotImplemented, "request log storage is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	if format != "jsonl" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "invalid format: must be jsonl or csv", "invalid_request_error", "invalid_request")
+		return
+	}
+
+	limit, ok := parseLimit(w, r, defaultLogsExportLimit, maxLogsExportLimit)
+	if !ok {
+		return
+	}
+
+	since, ok := parseSince(w, r)
+	if !ok {
+		return
+	}
+
+	until := time.Now().UTC()
+	query := requestlog.Query{
+		Limit:    maxLogsLimit,
+		TraceID:  r.URL.Query().Get("trace_id"),
+		Stage:    r.URL.Query().Get("stage"),
+		Model:    r.URL.Query().Get("model"),
+		Provider: r.URL.Query().Get("provider"),
+		Since:    since,
+		Until:    &until,
+	}
+
+	// Fetch the first page before writing anything, so a store failure can
+	// still be reported as an error response.
+	page, err := h.Logs.List(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list request logs", "server_error", "internal_error")
+		return
+	}
+
+	prepare := h.logPayloads(r)
+	var write func(requestlog.Entry) error
+	var flush func() error
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="request-logs.csv"`)
+		cw := csv.NewWriter(w)
+		_ = cw.Write(logExportColumns)
+		write = func(e requestlog.Entry) error { return cw.Write(logExportRow(prepare(e))) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="request-logs.jsonl"`)
+		enc := json.NewEncoder(w)
+		write = func(e requestlog.Entry) error { return enc.Encode(prepare(e)) }
+		flush = func() error { return nil }
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	written := 0
+	for written < limit {
+		for _, e := range page.Data {
+			if written == limit {
+				break
+			}
+			if write(e) != nil {
+				return
+			}
+			written++
+		}
+		if len(page.Data) < query.Limit || written == limit {
+			break
+		}
+		query.Offset += len(page.Data)
+		// Headers are already sent, so a failure here can only end the
+		// export early.
+		if page, err = h.Logs.List(r.Context(), query); err != nil {
+			break
+		}
+	}
+	_ = flush()
+}
+
+func logExportRow(e requestlog.Entry) []string {
+	return []string{
+		e.TraceID, e.Stage, e.Model, e.Provider,
+		strconv.Itoa(e.PromptTokens), strconv.Itoa(e.CompletionTokens), strconv.Itoa(e.TotalTokens),
+		strconv.FormatFloat(e.CostUSD, 'f', -1, 64),
+		e.CacheStatus, e.ErrorMessage, e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		string(e.Request), string(e.Response), string(e.PluginDecisions),
+		e.Plugin, e.Decision, e.Reason,
+	}
+}
+
 func (h *Handlers) deleteLogs(w http.ResponseWriter, r *http.Request) {
 	if h.LogAdmin == nil {
 		writeError(w, http.StatusNotImplemented, "request log storage is not enabled", "not_implemented_error", "not_implemented")
@@ -105,6 +410,76 @@ func (h *Handlers) deleteLogs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// deleteLogsByUser erases what the gateway stored about one end user, for a
+// right-to-erasure request: every request-log entry, response-cache entry,
+// and item of the other stores in h.Erasers recorded for the user query
+// parameter (the requests' `user` field), the key_id parameter (an API key),
+// or both together. It reports how many of each were removed, and under
+// not_erased the stores that cannot be searched by the filters given, such
+// as threads, which belong to API keys and do not record the end user.
+//
+// Request-log entries written before the end user was recorded are found by
+// the user field of their recorded request body; those recorded without
+// content, or encrypted, cannot be found by user and are left until they
+// age out or are erased by key_id.
+func (h *Handlers) deleteLogsByUser(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	keyID := r.URL.Query().Get("key_id")
+	if user == "" && keyID == "" {
+		writeError(w, http.StatusBadRequest, "user or key_id is required", "invalid_request_error", "invalid_request")
+		return
+	}
+	caches := h.responseCaches()
+	if h.LogAdmin == nil && len(caches) == 0 && len(h.Erasers) == 0 {
+		writeError(w, http.StatusNotImplemented, "no store holding end-user content is enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+
+	deletedLogs := 0
+	if h.LogAdmin != nil {
+		var err error
+		deletedLogs, err = h.LogAdmin.Delete(r.Context(), requestlog.MaintenanceQuery{User: user, KeyID: keyID})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to delete request logs", "server_error", "internal_error")
+			return
+		}
+	}
+	// Cache entries are namespaced by API key ID.
+	purged := 0
+	for _, c := range caches {
+		purged += c.Purge(cache.PurgeFilter{User: user, Namespace: keyID})
+	}
+
+	deleted := map[string]int{
+		"request_logs":  deletedLogs,
+		"cache_entries": purged,
+	}
+	notErased := make([]string, 0)
+	for _, name := range slices.Sorted(maps.Keys(h.Erasers)) {
+		n, err := h.Erasers[name].EraseUser(r.Context(), user, keyID)
+		switch {
+		case errors.Is(err, ErrEraseUnsupported):
+			notErased = append(notErased, name)
+			continue
+		case err != nil:
+			logging.Logger.Error("admin user erasure failed", "store", name, "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to erase "+name, "server_error", "internal_error")
+			return
+		}
+		deleted[name] = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"deleted":    deleted,
+		"not_erased": notErased,
+		"filters": map[string]any{
+			"user":   user,
+			"key_id": keyID,
+		},
+	})
+}
+
 func (h *Handlers) logsStats(w http.ResponseWriter, r *http.Request) {
 	if h.Logs == nil {
 		writeError(w, http.StatusNotImplemented, "request log storage is not enabled", "not_implemented_error", "not_implemented")
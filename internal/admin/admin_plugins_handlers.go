@@ -0,0 +1,232 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/go-chi/chi/v5"
+)
+
+// Plugin management is config management: enabling, disabling, or
+// re-initializing a plugin edits its entry in the active config and reloads
+// it, which rebuilds the plugin pipeline with fresh instances. The change is
+// recorded in the config history and undone by a config rollback like any
+// other.
+//
+// A plugin name may appear more than once in the config (the response cache,
+// for one, is commonly configured at both stages). Every operation applies to
+// all of a name's entries unless the stage query parameter picks one.
+
+type pluginInfo struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Stage string `json:"stage,omitempty"`
+	// Enabled is the configured state; Loaded reports whether a live
+	// instance is currently serving requests.
+	Enabled bool `json:"enabled"`
+	Loaded  bool `json:"loaded"`
+	// Configured is false for a plugin that is registered in this binary
+	// but has no config entry.
+	Configured bool           `json:"configured"`
+	Config     map[string]any `json:"config,omitempty"`
+	Match      *plugin.Match  `json:"match,omitempty"`
+}
+
+// listPlugins returns every configured plugin entry, in config order,
+// followed by the registered plugins that have none. Config values are
+// scrubbed exactly as GET /admin/config scrubs them.
+func (h *Handlers) listPlugins(w http.ResponseWriter, _ *http.Request) {
+	loaded := make(map[string]bool)
+	if h.Plugins != nil {
+		for _, p := range h.Plugins.Plugins() {
+			loaded[p.Name()] = true
+		}
+	}
+
+	result := make([]pluginInfo, 0)
+	configured := make(map[string]bool)
+	if h.Configs != nil {
+		for _, p := range h.Configs.GetConfig().Plugins {
+			configured[p.Name] = true
+			result = append(result, pluginInfo{
+				Name:       p.Name,
+				Type:       p.Type,
+				Stage:      p.Stage,
+				Enabled:    p.Enabled,
+				Loaded:     p.Enabled && loaded[p.Name],
+				Configured: true,
+				Config:     scrubAnyMap(p.Config),
+				Match:      p.Match,
+			})
+		}
+	}
+
+	registered := plugin.RegisteredPlugins()
+	sort.Strings(registered)
+	for _, name := range registered {
+		if configured[name] {
+			continue
+		}
+		result = append(result, pluginInfo{Name: name})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handlers) enablePlugin(w http.ResponseWriter, r *http.Request) {
+	h.mutatePlugin(w, r, func(p *aigateway.PluginConfig) error { p.Enabled = true; return nil })
+}
+
+func (h *Handlers) disablePlugin(w http.ResponseWriter, r *http.Request) {
+	h.mutatePlugin(w, r, func(p *aigateway.PluginConfig) error { p.Enabled = false; return nil })
+}
+
+// updatePlugin re-initializes a plugin. A config in the body replaces the
+// entry's config wholesale and an enabled field sets its state; an empty body
+// re-initializes the plugin with the config it already has.
+func (h *Handlers) updatePlugin(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Config  map[string]any `json:"config"`
+		Enabled *bool          `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	h.mutatePlugin(w, r, func(p *aigateway.PluginConfig) error {
+		if body.Config != nil {
+			// A config read from GET and sent back carries its secrets as
+			// [REDACTED]; those keep the values they replace.
+			restored, ok := restoreRedacted(body.Config, p.Config)
+			if !ok {
+				return errors.New("config has a [REDACTED] value with no current value to keep")
+			}
+			p.Config = restored.(map[string]any)
+		}
+		if body.Enabled != nil {
+			p.Enabled = *body.Enabled
+		}
+		return nil
+	})
+}
+
+// restoreRedacted returns next with each [REDACTED] string replaced by the
+// value at the same place in current. ok is false when a placeholder has no
+// counterpart there.
+func restoreRedacted(next, current any) (restored any, ok bool) {
+	switch v := next.(type) {
+	case string:
+		if v != redactedPlaceholder {
+			return v, true
+		}
+		if current == nil {
+			return v, false
+		}
+		return current, true
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		ok = true
+		for k, elem := range v {
+			var elemOK bool
+			out[k], elemOK = restoreRedacted(elem, elementAt(current, reflect.ValueOf(k)))
+			ok = ok && elemOK
+		}
+		return out, ok
+	case []any:
+		out := make([]any, len(v))
+		ok = true
+		for i, elem := range v {
+			var elemOK bool
+			out[i], elemOK = restoreRedacted(elem, elementAt(current, reflect.ValueOf(i)))
+			ok = ok && elemOK
+		}
+		return out, ok
+	default:
+		return next, true
+	}
+}
+
+// elementAt returns the value under key in the map, or at index key in the
+// slice, container; nil when there is none.
+func elementAt(container any, key reflect.Value) any {
+	rv := reflect.ValueOf(container)
+	switch {
+	case rv.Kind() == reflect.Map && key.Kind() == reflect.String && rv.Type().Key().Kind() == reflect.String:
+		if elem := rv.MapIndex(key.Convert(rv.Type().Key())); elem.IsValid() {
+			return elem.Interface()
+		}
+	case rv.Kind() == reflect.Slice && key.Kind() == reflect.Int:
+		if i := int(key.Int()); i < rv.Len() {
+			return rv.Index(i).Interface()
+		}
+	}
+	return nil
+}
+
+// mutatePlugin applies fn to the config entries selected by the name path
+// parameter and the optional stage query parameter, then reloads the config.
+// An error from fn is the caller's and answers 400.
+// A rejected config — a plugin whose Init fails on the new settings, say —
+// leaves the running pipeline untouched.
+func (h *Handlers) mutatePlugin(w http.ResponseWriter, r *http.Request, fn func(*aigateway.PluginConfig) error) {
+	if h.Configs == nil {
+		writeError(w, http.StatusNotImplemented, "config management is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	name := chi.URLParam(r, "name")
+	stage := r.URL.Query().Get("stage")
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	plugins := slices.Clone(cfg.Plugins)
+	var updated []aigateway.PluginConfig
+	for i := range plugins {
+		if plugins[i].Name != name || (stage != "" && plugins[i].Stage != stage) {
+			continue
+		}
+		if err := fn(&plugins[i]); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
+			return
+		}
+		updated = append(updated, plugins[i])
+	}
+	if len(updated) == 0 {
+		writeError(w, http.StatusNotFound, "plugin is not configured: "+name, "not_found_error", "resource_not_found")
+		return
+	}
+
+	cfg.Plugins = plugins
+	if err := h.Configs.ReloadConfig(r.Context(), cfg); err != nil {
+		writeConfigReloadError(w, err)
+		return
+	}
+	h.appendConfigHistoryLocked(r.Context(), cfg, nil)
+
+	result := make([]pluginInfo, 0, len(updated))
+	for _, p := range updated {
+		result = append(result, pluginInfo{
+			Name:       p.Name,
+			Type:       p.Type,
+			Stage:      p.Stage,
+			Enabled:    p.Enabled,
+			Loaded:     p.Enabled,
+			Configured: true,
+			Config:     scrubAnyMap(p.Config),
+			Match:      p.Match,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": result})
+}
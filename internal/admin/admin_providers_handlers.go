@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/go-chi/chi/v5"
+)
+
+// Provider maintenance drains, disables, or re-enables a provider's target
+// in the running gateway, without reloading the config: plugin state,
+// circuit breakers, and rate limiters carry on. Draining stops new requests
+// and lets those in flight finish; disabling also cancels the ones in
+// flight. The state is not written to the config, so it does not survive a
+// restart; set the target's state in the config for that.
+
+// targetStateInfo is the body returned by the provider maintenance endpoints.
+type targetStateInfo struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// targetStateName is how a target's state is reported: "active" for the
+// empty state.
+func targetStateName(state string) string {
+	if state == "" {
+		return "active"
+	}
+	return state
+}
+
+func (h *Handlers) drainProvider(w http.ResponseWriter, r *http.Request) {
+	h.setProviderState(w, r, aigateway.TargetStateDraining)
+}
+
+func (h *Handlers) disableProvider(w http.ResponseWriter, r *http.Request) {
+	h.setProviderState(w, r, aigateway.TargetStateDisabled)
+}
+
+func (h *Handlers) enableProvider(w http.ResponseWriter, r *http.Request) {
+	h.setProviderState(w, r, "")
+}
+
+// setProviderState sets the state of the target named by the name path
+// parameter.
+func (h *Handlers) setProviderState(w http.ResponseWriter, r *http.Request, state string) {
+	if h.Targets == nil {
+		writeError(w, http.StatusNotImplemented, "provider maintenance is not enabled", "not_implemented_error", "not_implemented")
+		return
+	}
+	name := chi.URLParam(r, "name")
+	if err := h.Targets.SetTargetState(name, state); err != nil {
+		if errors.Is(err, aigateway.ErrUnknownTarget) {
+			writeError(w, http.StatusNotFound, "provider is not a configured target: "+name, "not_found_error", "resource_not_found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error(), "server_error", "internal_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(targetStateInfo{Name: name, State: targetStateName(state)})
+}
@@ -0,0 +1,159 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/ferro-labs/ai-gateway/internal/ratelimit"
+	"github.com/go-chi/chi/v5"
+)
+
+// Rate-limit inspection covers the in-process token buckets: the per-IP
+// RATE_LIMIT_RPS middleware ("ip") and the layers of a rate-limit plugin using
+// the memory backend ("global", "api_key", "user"). Each replica reports only
+// its own buckets; limits kept in Redis are not listed.
+
+const (
+	defaultRateLimitBuckets = 100
+	maxRateLimitBuckets     = 1000
+)
+
+// rateLimitPlugin is implemented by the rate-limit plugin.
+type rateLimitPlugin interface {
+	Limiters() map[string]ratelimit.Inspectable
+}
+
+type namedLimiter struct {
+	name string
+	ratelimit.Inspectable
+}
+
+// rateLimiters returns every inspectable limiter, the per-IP one first and
+// plugin layers after it in name order. A layer configured by more than one
+// plugin entry appears once per entry.
+func (h *Handlers) rateLimiters() []namedLimiter {
+	var limiters []namedLimiter
+	if h.RateLimits != nil {
+		limiters = append(limiters, namedLimiter{name: "ip", Inspectable: h.RateLimits})
+	}
+	if h.Plugins == nil {
+		return limiters
+	}
+	for _, p := range h.Plugins.Plugins() {
+		rl, ok := p.(rateLimitPlugin)
+		if !ok {
+			continue
+		}
+		layers := rl.Limiters()
+		names := make([]string, 0, len(layers))
+		for name := range layers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			limiters = append(limiters, namedLimiter{name: name, Inspectable: layers[name]})
+		}
+	}
+	return limiters
+}
+
+func writeRateLimitsNotEnabled(w http.ResponseWriter) {
+	writeError(w, http.StatusNotImplemented, "no in-memory rate limiters are enabled", "not_implemented_error", "not_implemented")
+}
+
+// listRateLimits reports each limiter's buckets, most-rejected first, so the
+// keys behind a burst of 429s are at the top. The limiter and key query
+// parameters narrow the result; limit caps the buckets listed per limiter.
+func (h *Handlers) listRateLimits(w http.ResponseWriter, r *http.Request) {
+	type limiterInfo struct {
+		Name       string                  `json:"name"`
+		Buckets    int                     `json:"buckets"`
+		Rejections int64                   `json:"rejections"`
+		Data       []ratelimit.BucketState `json:"data"`
+	}
+
+	limiters := h.rateLimiters()
+	if len(limiters) == 0 {
+		writeRateLimitsNotEnabled(w)
+		return
+	}
+	limit, ok := parseLimit(w, r, defaultRateLimitBuckets, maxRateLimitBuckets)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	name, key := q.Get("limiter"), q.Get("key")
+
+	result := make([]limiterInfo, 0, len(limiters))
+	for _, l := range limiters {
+		if name != "" && l.name != name {
+			continue
+		}
+		states := l.States()
+		info := limiterInfo{Name: l.name, Buckets: len(states), Data: make([]ratelimit.BucketState, 0)}
+		for _, s := range states {
+			info.Rejections += s.Rejections
+		}
+		sort.SliceStable(states, func(i, j int) bool { return states[i].Rejections > states[j].Rejections })
+		for _, s := range states {
+			if key != "" && s.Key != key {
+				continue
+			}
+			if len(info.Data) == limit {
+				break
+			}
+			info.Data = append(info.Data, s)
+		}
+		result = append(result, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": result})
+}
+
+// resetRateLimit refills one key's bucket in the named limiter, or all of
+// them with all=true, so a caller wrongly held at 429 is let through at once.
+// Like purgeCache, a bare DELETE is refused rather than resetting everything.
+func (h *Handlers) resetRateLimit(w http.ResponseWriter, r *http.Request) {
+	limiters := h.rateLimiters()
+	if len(limiters) == 0 {
+		writeRateLimitsNotEnabled(w)
+		return
+	}
+
+	name := chi.URLParam(r, "limiter")
+	q := r.URL.Query()
+	key := q.Get("key")
+	if key == "" && q.Get("all") != "true" {
+		writeError(w, http.StatusBadRequest, "a key is required; pass all=true to reset every bucket", "invalid_request_error", "invalid_request")
+		return
+	}
+
+	found, reset := false, false
+	for _, l := range limiters {
+		if l.name != name {
+			continue
+		}
+		found = true
+		if l.Reset(key) {
+			reset = true
+		}
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "rate limiter not found", "not_found_error", "resource_not_found")
+		return
+	}
+	if !reset && key != "" {
+		writeError(w, http.StatusNotFound, "no bucket is tracked for this key", "not_found_error", "resource_not_found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"limiter": name,
+		"key":     key,
+		"reset":   true,
+	})
+}
@@ -0,0 +1,232 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/reconcile"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+)
+
+// usagePeriodLayout is the format of a usage report's billing period: a
+// calendar month in UTC, as provider invoices are issued.
+const usagePeriodLayout = "2006-01"
+
+// usageReportColumns is the CSV header after the period and dimension columns.
+var usageReportColumns = []string{
+	"requests", "errors", "cached_requests",
+	"prompt_tokens", "completion_tokens", "total_tokens", "cost_usd",
+}
+
+// usageReport aggregates the request log over one billing period for
+// reconciling against provider invoices. period picks the month (YYYY-MM,
+// default the current one); group_by is a comma-separated subset of provider,
+// model, key, and workspace (default all four); format=csv downloads the rows
+// as CSV instead of JSON.
+//
+// The figures come from the request logger's persisted entries, so requests
+// made while it was disabled, or before key and workspace were recorded, are
+// missing or grouped under empty values.
+func (h *Handlers) usageReport(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := h.Logs.(requestlog.UsageReporter)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "usage reports require request log storage", "not_implemented_error", "not_implemented")
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "invalid format: must be json or csv", "invalid_request_error", "invalid_request")
+		return
+	}
+
+	period := q.Get("period")
+	if period == "" {
+		period = time.Now().UTC().Format(usagePeriodLayout)
+	}
+	since, err := time.Parse(usagePeriodLayout, period)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid period: must be a month as YYYY-MM", "invalid_request_error", "invalid_request")
+		return
+	}
+	until := since.AddDate(0, 1, 0)
+
+	groupBy := requestlog.UsageDimensions
+	if raw := q.Get("group_by"); raw != "" {
+		groupBy = nil
+		for _, name := range strings.Split(raw, ",") {
+			d := requestlog.UsageDimension(strings.TrimSpace(name))
+			if !slices.Contains(requestlog.UsageDimensions, d) {
+				writeError(w, http.StatusBadRequest, "invalid group_by: must list provider, model, key, or workspace", "invalid_request_error", "invalid_request")
+				return
+			}
+			if !slices.Contains(groupBy, d) {
+				groupBy = append(groupBy, d)
+			}
+		}
+	}
+
+	rows, err := reporter.Usage(r.Context(), requestlog.UsageQuery{Since: since, Until: until, GroupBy: groupBy})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build usage report", "server_error", "internal_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="usage-`+period+`.csv"`)
+		writeUsageCSV(w, period, groupBy, rows)
+		return
+	}
+
+	var totals requestlog.UsageRow
+	for _, row := range rows {
+		totals.Requests += row.Requests
+		totals.Errors += row.Errors
+		totals.CachedRequests += row.CachedRequests
+		totals.PromptTokens += row.PromptTokens
+		totals.CompletionTokens += row.CompletionTokens
+		totals.TotalTokens += row.TotalTokens
+		totals.CostUSD += row.CostUSD
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"period":   period,
+		"since":    since,
+		"until":    until,
+		"group_by": groupBy,
+		"data":     rows,
+		"totals":   totals,
+	})
+}
+
+// writeUsageCSV writes rows with one column per grouped dimension, in
+// group_by order.
+func writeUsageCSV(w http.ResponseWriter, period string, groupBy []requestlog.UsageDimension, rows []requestlog.UsageRow) {
+	cw := csv.NewWriter(w)
+	header := []string{"period"}
+	for _, d := range groupBy {
+		header = append(header, string(d))
+	}
+	_ = cw.Write(append(header, usageReportColumns...))
+	for _, row := range rows {
+		record := []string{period}
+		for _, d := range groupBy {
+			record = append(record, usageDimensionValue(row, d))
+		}
+		record = append(record,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.Errors, 10),
+			strconv.FormatInt(row.CachedRequests, 10),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+			strconv.FormatFloat(row.CostUSD, 'f', -1, 64),
+		)
+		if cw.Write(record) != nil {
+			return
+		}
+	}
+	cw.Flush()
+}
+
+func usageDimensionValue(row requestlog.UsageRow, d requestlog.UsageDimension) string {
+	switch d {
+	case requestlog.UsageByProvider:
+		return row.Provider
+	case requestlog.UsageByModel:
+		return row.Model
+	case requestlog.UsageByKey:
+		return row.KeyID
+	case requestlog.UsageByWorkspace:
+		return row.Workspace
+	}
+	return ""
+}
+
+// reconcileUsage matches a provider's usage export, sent as the CSV request
+// body, against the usage the gateway recorded for that provider over the
+// export's days, by UTC day and model, and reports the discrepancies. source
+// names the export's format (openai or anthropic; detected from its header
+// when omitted); provider is the gateway provider the export covers (default
+// the source); tolerance is the relative difference below which figures
+// match (default 0.01).
+//
+// Models are matched by name, so an export naming dated snapshots matches
+// only requests recorded under the same name.
+func (h *Handlers) reconcileUsage(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := h.Logs.(requestlog.UsageReporter)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "usage reconciliation requires request log storage", "not_implemented_error", "not_implemented")
+		return
+	}
+
+	q := r.URL.Query()
+	source := q.Get("source")
+	if source != "" && !slices.Contains(reconcile.Sources, source) {
+		writeError(w, http.StatusBadRequest, "invalid source: must be openai or anthropic", "invalid_request_error", "invalid_request")
+		return
+	}
+	tolerance := reconcile.DefaultTolerance
+	if raw := q.Get("tolerance"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 || v > 1 {
+			writeError(w, http.StatusBadRequest, "invalid tolerance: must be between 0 and 1", "invalid_request_error", "invalid_request")
+			return
+		}
+		tolerance = v
+	}
+
+	exp, err := reconcile.Parse(r.Body, source)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid usage export: "+err.Error(), "invalid_request_error", "invalid_request")
+		return
+	}
+	if len(exp.Rows) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid usage export: it has no rows", "invalid_request_error", "invalid_request")
+		return
+	}
+	provider := q.Get("provider")
+	if provider == "" {
+		provider = exp.Source
+	}
+
+	// Rows are ordered by day, so the first and last bound the export.
+	since, _ := time.Parse(time.DateOnly, exp.Rows[0].Day)
+	until, _ := time.Parse(time.DateOnly, exp.Rows[len(exp.Rows)-1].Day)
+	until = until.AddDate(0, 0, 1)
+	usage, err := reporter.Usage(r.Context(), requestlog.UsageQuery{
+		Since:   since,
+		Until:   until,
+		GroupBy: []requestlog.UsageDimension{requestlog.UsageByProvider, requestlog.UsageByModel},
+		Daily:   true,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build usage report", "server_error", "internal_error")
+		return
+	}
+	rows, summary := reconcile.Compare(exp, reconcile.GatewayUsage(usage, provider), tolerance)
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"source":    exp.Source,
+		"provider":  provider,
+		"since":     since,
+		"until":     until,
+		"tolerance": tolerance,
+		"compared":  exp.Compared,
+		"data":      rows,
+		"summary":   summary,
+	})
+}
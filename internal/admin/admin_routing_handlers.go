@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// routingState returns the strategy mode and each target's weight share,
+// circuit breaker state, and recent latency and error rate. The dashboard's
+// routing page polls it.
+func (h *Handlers) routingState(w http.ResponseWriter, _ *http.Request) {
+	if h.Routing == nil {
+		writeError(w, http.StatusNotImplemented, "routing state is not available", "not_implemented_error", "not_implemented")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(h.Routing.RoutingState())
+}
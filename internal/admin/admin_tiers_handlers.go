@@ -0,0 +1,223 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/go-chi/chi/v5"
+)
+
+// Rate-limit tiers live in the gateway config (rate_limit_tiers), so every
+// mutation here is a config change: it goes through ReloadConfig and lands in
+// the config history like any other, and a rollback restores the tiers with
+// the rest of the config.
+
+// tierExists reports whether the active config defines a tier called name.
+func (h *Handlers) tierExists(name string) bool {
+	if h.Configs == nil {
+		return false
+	}
+	_, ok := findTier(h.Configs.GetConfig().RateLimitTiers, name)
+	return ok
+}
+
+func findTier(tiers []aigateway.RateLimitTier, name string) (int, bool) {
+	for i, t := range tiers {
+		if t.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func writeUnknownTier(w http.ResponseWriter, name string) {
+	writeError(w, http.StatusBadRequest, "unknown rate limit tier: "+name, "invalid_request_error", "invalid_request")
+}
+
+func writeTierNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "rate limit tier not found", "not_found_error", "resource_not_found")
+}
+
+func writeConfigNotEnabled(w http.ResponseWriter) {
+	writeError(w, http.StatusNotImplemented, "config management is not enabled", "not_implemented_error", "not_implemented")
+}
+
+// applyTiersLocked installs tiers as the active config's rate_limit_tiers and
+// records the result in the config history. The caller must hold h.configMu
+// and must have read the config it is modifying under that same hold.
+func (h *Handlers) applyTiersLocked(w http.ResponseWriter, r *http.Request, cfg aigateway.Config, tiers []aigateway.RateLimitTier) bool {
+	cfg.RateLimitTiers = tiers
+	if err := h.Configs.ReloadConfig(r.Context(), cfg); err != nil {
+		writeConfigReloadError(w, err)
+		return false
+	}
+	h.appendConfigHistoryLocked(r.Context(), cfg, nil)
+	return true
+}
+
+func (h *Handlers) listTiers(w http.ResponseWriter, r *http.Request) {
+	type tierInfo struct {
+		aigateway.RateLimitTier
+		Keys int `json:"keys"`
+	}
+
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+
+	keysPerTier := make(map[string]int)
+	for _, k := range h.Keys.List(r.Context()) {
+		if k.Tier != "" {
+			keysPerTier[k.Tier]++
+		}
+	}
+
+	tiers := h.Configs.GetConfig().RateLimitTiers
+	result := make([]tierInfo, 0, len(tiers))
+	for _, t := range tiers {
+		result = append(result, tierInfo{RateLimitTier: t, Keys: keysPerTier[t.Name]})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"data": result,
+		"summary": map[string]any{
+			"total_tiers": len(result),
+		},
+	})
+}
+
+func (h *Handlers) getTier(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	tiers := h.Configs.GetConfig().RateLimitTiers
+	i, ok := findTier(tiers, chi.URLParam(r, "name"))
+	if !ok {
+		writeTierNotFound(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tiers[i])
+}
+
+// decodeTier reads a tier from the request body and validates it on its own,
+// so a malformed tier is reported as such rather than as a generic config
+// reload failure.
+func decodeTier(w http.ResponseWriter, r *http.Request) (aigateway.RateLimitTier, bool) {
+	var tier aigateway.RateLimitTier
+	if err := json.NewDecoder(r.Body).Decode(&tier); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return tier, false
+	}
+	if err := aigateway.ValidateRateLimitTiers([]aigateway.RateLimitTier{tier}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
+		return tier, false
+	}
+	return tier, true
+}
+
+func (h *Handlers) createTier(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	tier, ok := decodeTier(w, r)
+	if !ok {
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	if _, exists := findTier(cfg.RateLimitTiers, tier.Name); exists {
+		writeError(w, http.StatusConflict, "rate limit tier already exists: "+tier.Name, "invalid_request_error", "resource_conflict")
+		return
+	}
+	tiers := append(slices.Clone(cfg.RateLimitTiers), tier)
+	if !h.applyTiersLocked(w, r, cfg, tiers) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(tier)
+}
+
+// updateTier replaces a tier's limits. The name comes from the path; a name in
+// the body must match it, since renaming would orphan every key on the tier.
+func (h *Handlers) updateTier(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	name := chi.URLParam(r, "name")
+	var tier aigateway.RateLimitTier
+	if err := json.NewDecoder(r.Body).Decode(&tier); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error", "invalid_request")
+		return
+	}
+	if tier.Name != "" && tier.Name != name {
+		writeError(w, http.StatusBadRequest, "tier name in body does not match the path; tiers cannot be renamed", "invalid_request_error", "invalid_request")
+		return
+	}
+	tier.Name = name
+	if err := aigateway.ValidateRateLimitTiers([]aigateway.RateLimitTier{tier}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
+		return
+	}
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	i, ok := findTier(cfg.RateLimitTiers, name)
+	if !ok {
+		writeTierNotFound(w)
+		return
+	}
+	tiers := slices.Clone(cfg.RateLimitTiers)
+	tiers[i] = tier
+	if !h.applyTiersLocked(w, r, cfg, tiers) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tier)
+}
+
+// deleteTier removes a tier. A tier still assigned to keys is refused: those
+// keys would silently lose their limits.
+func (h *Handlers) deleteTier(w http.ResponseWriter, r *http.Request) {
+	if h.Configs == nil {
+		writeConfigNotEnabled(w)
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+
+	cfg := h.Configs.GetConfig()
+	i, ok := findTier(cfg.RateLimitTiers, name)
+	if !ok {
+		writeTierNotFound(w)
+		return
+	}
+	for _, k := range h.Keys.List(r.Context()) {
+		if k.Tier == name {
+			writeError(w, http.StatusConflict, "rate limit tier is still assigned to API keys: "+name, "invalid_request_error", "resource_conflict")
+			return
+		}
+	}
+	tiers := slices.Delete(slices.Clone(cfg.RateLimitTiers), i, i+1)
+	if !h.applyTiersLocked(w, r, cfg, tiers) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// First-run bootstrap. A deployment without MASTER_KEY starts with no admin
+// credential, so until its key store holds a key the gateway accepts a
+// bootstrap credential: ADMIN_BOOTSTRAP_KEY when set, otherwise a one-time
+// token IssueBootstrapToken generates at startup. Creating the first key with
+// it (POST /admin/keys) locks bootstrap for good. The lock is recorded in the
+// store, so neither deleting every key nor a restart re-opens it.
+
+// bootstrapTokens maps a Store to the one-time token issued for it.
+var bootstrapTokens sync.Map
+
+// BootstrapEnabledFromEnv reports whether ADMIN_BOOTSTRAP_ENABLED leaves
+// bootstrap credentials on. Unset enables them; a value strconv.ParseBool
+// rejects is an error, since a typo meant to turn bootstrap off must not
+// leave it on.
+func BootstrapEnabledFromEnv() (bool, error) {
+	raw := strings.TrimSpace(os.Getenv("ADMIN_BOOTSTRAP_ENABLED"))
+	if raw == "" {
+		return true, nil
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("ADMIN_BOOTSTRAP_ENABLED must be true or false, got %q", raw)
+	}
+	return enabled, nil
+}
+
+// IssueBootstrapToken generates the one-time bootstrap token for store and
+// returns it for the caller to print. It returns "" when no token is needed:
+// ADMIN_BOOTSTRAP_KEY is set, ADMIN_BOOTSTRAP_ENABLED is false, or the store
+// is past bootstrap, and an error when ADMIN_BOOTSTRAP_ENABLED is invalid.
+// The token grants admin scope until the first key is created or the process
+// exits; call it only when no master key is set.
+func IssueBootstrapToken(ctx context.Context, store Store) (string, error) {
+	enabled, err := BootstrapEnabledFromEnv()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(os.Getenv("ADMIN_BOOTSTRAP_KEY")) != "" || !enabled {
+		return "", nil
+	}
+	open, err := bootstrapOpen(ctx, store)
+	if err != nil || !open {
+		return "", err
+	}
+	token, err := generateAPIKeyString()
+	if err != nil {
+		return "", err
+	}
+	bootstrapTokens.Store(store, token)
+	return token, nil
+}
+
+// issuedBootstrapToken returns the token IssueBootstrapToken issued for
+// store, or "".
+func issuedBootstrapToken(store Store) string {
+	v, _ := bootstrapTokens.Load(store)
+	token, _ := v.(string)
+	return token
+}
+
+// bootstrapOpen reports whether store still accepts bootstrap credentials:
+// it must hold no keys and never have held one.
+func bootstrapOpen(ctx context.Context, store Store) (bool, error) {
+	locked, err := store.BootstrapLocked(ctx)
+	if err != nil || locked {
+		return false, err
+	}
+	return store.IsEmpty(ctx)
+}
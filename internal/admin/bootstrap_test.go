@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIssueBootstrapToken(t *testing.T) {
+	store := NewKeyStore()
+	token, err := IssueBootstrapToken(context.Background(), store)
+	if err != nil || token == "" {
+		t.Fatalf("IssueBootstrapToken = (%q, %v), want a token", token, err)
+	}
+
+	handler := AuthMiddleware(store, "")(RequireScope(ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	status := func(bearer string) int {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if got := status(token); got != http.StatusOK {
+		t.Fatalf("bootstrap token: got status %d, want %d", got, http.StatusOK)
+	}
+	if got := status(token + "x"); got != http.StatusUnauthorized {
+		t.Errorf("wrong token: got status %d, want %d", got, http.StatusUnauthorized)
+	}
+	if _, err := store.Create(context.Background(), "first-admin", []string{ScopeAdmin}, nil); err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	if got := status(token); got != http.StatusUnauthorized {
+		t.Errorf("token after the first key: got status %d, want %d", got, http.StatusUnauthorized)
+	}
+	if token, err := IssueBootstrapToken(context.Background(), store); err != nil || token != "" {
+		t.Errorf("IssueBootstrapToken on a locked store = (%q, %v), want none", token, err)
+	}
+}
+
+func TestIssueBootstrapToken_NotNeeded(t *testing.T) {
+	t.Setenv("ADMIN_BOOTSTRAP_KEY", "bootstrap-secret")
+	if token, _ := IssueBootstrapToken(context.Background(), NewKeyStore()); token != "" {
+		t.Errorf("token issued although ADMIN_BOOTSTRAP_KEY is set")
+	}
+
+	t.Setenv("ADMIN_BOOTSTRAP_KEY", "")
+	t.Setenv("ADMIN_BOOTSTRAP_ENABLED", "false")
+	if token, _ := IssueBootstrapToken(context.Background(), NewKeyStore()); token != "" {
+		t.Errorf("token issued although bootstrap is disabled")
+	}
+}
+
+// A value meant to turn bootstrap off that ParseBool does not know fails
+// rather than leaving bootstrap on.
+func TestIssueBootstrapToken_InvalidEnabled(t *testing.T) {
+	t.Setenv("ADMIN_BOOTSTRAP_KEY", "bootstrap-secret")
+	for _, raw := range []string{"off", "no", "flase"} {
+		t.Setenv("ADMIN_BOOTSTRAP_ENABLED", raw)
+		store := NewKeyStore()
+		if token, err := IssueBootstrapToken(context.Background(), store); err == nil || token != "" {
+			t.Errorf("ADMIN_BOOTSTRAP_ENABLED=%q: IssueBootstrapToken = (%q, %v), want an error", raw, token, err)
+		}
+
+		handler := AuthMiddleware(store, "")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer bootstrap-secret")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("ADMIN_BOOTSTRAP_ENABLED=%q: bootstrap key got status %d, want %d", raw, rr.Code, http.StatusUnauthorized)
+		}
+	}
+}
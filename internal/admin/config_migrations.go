@@ -1,6 +1,10 @@
 package admin
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+
 	"github.com/ferro-labs/ai-gateway/internal/migrations"
 	"github.com/ferro-labs/ai-gateway/internal/sqldb"
 )
@@ -16,12 +20,26 @@ const configLedger = "config_schema_migrations"
 // Version 1 is the pre-runner schema. Databases created before the runner
 // existed already have this shape, so Run adopts it as a baseline rather than
 // executing it. Version 2 adds config_history, the durable audit trail that Save
-// writes in the same transaction as the active-config upsert.
+// writes in the same transaction as the active-config upsert. Version 3 records
+// who made each change and which version a rollback came from.
 func configStoreSteps(dialect sqldb.Dialect) []migrations.Step {
 	return []migrations.Step{
 		{Version: 1, Name: "gateway_config_baseline", SQL: configBaselineDDL(dialect)},
 		{Version: 2, Name: "config_history", SQL: configHistoryDDL(dialect)},
+		{Version: 3, Name: "config_history_provenance", Fn: addConfigHistoryProvenance},
+	}
+}
+
+func addConfigHistoryProvenance(ctx context.Context, tx *sql.Tx) error {
+	for _, stmt := range []string{
+		"ALTER TABLE config_history ADD COLUMN author_key_id TEXT NULL",
+		"ALTER TABLE config_history ADD COLUMN rolled_back_from INTEGER NULL",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("add config history provenance: %w", err)
+		}
 	}
+	return nil
 }
 
 func configBaselineDDL(dialect sqldb.Dialect) string {
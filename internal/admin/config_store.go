@@ -12,6 +12,7 @@ import (
 
 	aigateway "github.com/ferro-labs/ai-gateway"
 	"github.com/ferro-labs/ai-gateway/internal/migrations"
+	"github.com/ferro-labs/ai-gateway/internal/signing"
 	"github.com/ferro-labs/ai-gateway/internal/sqldb"
 )
 
@@ -25,6 +26,25 @@ type ConfigStore interface {
 	Delete(ctx context.Context) error
 }
 
+// ConfigHistoryStore is a ConfigStore that keeps past config versions, so
+// config history and rollback survive a restart.
+type ConfigHistoryStore interface {
+	LoadHistory(ctx context.Context) ([]PersistedConfigVersion, error)
+	LoadVersion(ctx context.Context, version int) (PersistedConfigVersion, bool, error)
+}
+
+// DefaultConfigHistoryLimit is how many config versions a SQLConfigStore
+// keeps unless SetHistoryLimit says otherwise.
+const DefaultConfigHistoryLimit = maxConfigHistoryEntries
+
+// configRollbackContextKey marks the context of a config change that is a
+// rollback, so Save can record the version it rolled back from.
+const configRollbackContextKey contextKey = "config_rollback"
+
+func withConfigRollback(ctx context.Context) context.Context {
+	return context.WithValue(ctx, configRollbackContextKey, true)
+}
+
 // ConfigResetter provides reset semantics for config CRUD APIs.
 type ConfigResetter interface {
 	ResetConfig(ctx context.Context) error
@@ -37,8 +57,9 @@ var (
 
 // SQLConfigStore persists config snapshots in SQLite/Postgres.
 type SQLConfigStore struct {
-	db      *sql.DB
-	dialect sqldb.Dialect
+	db           *sql.DB
+	dialect      sqldb.Dialect
+	historyLimit int
 }
 
 // PersistedConfigVersion is one durable config_history record: a config snapshot
@@ -47,6 +68,22 @@ type PersistedConfigVersion struct {
 	Version   int
 	Config    aigateway.Config
 	UpdatedAt time.Time
+	// AuthorKeyID is the ID of the API key that made the change, or empty
+	// when it was not made through an authenticated request.
+	AuthorKeyID string
+	// RolledBackFrom is the version a rollback replaced, or nil.
+	RolledBackFrom *int
+}
+
+// entry converts v to the form the admin history endpoint serves.
+func (v PersistedConfigVersion) entry() ConfigHistoryEntry {
+	return ConfigHistoryEntry{
+		Version:        v.Version,
+		UpdatedAt:      v.UpdatedAt,
+		Config:         v.Config,
+		AuthorKeyID:    v.AuthorKeyID,
+		RolledBackFrom: v.RolledBackFrom,
+	}
 }
 
 // NewSQLiteConfigStore creates a SQLite-backed config store.
@@ -55,7 +92,7 @@ func NewSQLiteConfigStore(ctx context.Context, dsn string) (*SQLConfigStore, err
 	if err != nil {
 		return nil, err
 	}
-	s := &SQLConfigStore{db: db, dialect: sqldb.SQLite}
+	s := &SQLConfigStore{db: db, dialect: sqldb.SQLite, historyLimit: DefaultConfigHistoryLimit}
 	if err := s.init(ctx); err != nil {
 		_ = db.Close()
 		return nil, err
@@ -69,7 +106,7 @@ func NewPostgresConfigStore(ctx context.Context, dsn string) (*SQLConfigStore, e
 	if err != nil {
 		return nil, err
 	}
-	s := &SQLConfigStore{db: db, dialect: sqldb.Postgres}
+	s := &SQLConfigStore{db: db, dialect: sqldb.Postgres, historyLimit: DefaultConfigHistoryLimit}
 	if err := s.init(ctx); err != nil {
 		_ = db.Close()
 		return nil, err
@@ -77,6 +114,12 @@ func NewPostgresConfigStore(ctx context.Context, dsn string) (*SQLConfigStore, e
 	return s, nil
 }
 
+// SetHistoryLimit sets how many config versions the store keeps. Each Save
+// prunes the versions older than the newest n; n <= 0 keeps them all.
+func (s *SQLConfigStore) SetHistoryLimit(n int) {
+	s.historyLimit = n
+}
+
 func (s *SQLConfigStore) init(ctx context.Context) error {
 	if err := migrations.RunNamed(ctx, s.db, s.dialect, configLedger, "gateway_config", configStoreSteps(s.dialect)); err != nil {
 		return fmt.Errorf("migrate %s config schema: %w", s.dialect, err)
@@ -114,17 +157,67 @@ ON CONFLICT(id) DO UPDATE SET config_json = excluded.config_json, updated_at = e
 		return fmt.Errorf("save config: %w", err)
 	}
 
+	if err := s.appendHistory(ctx, tx, data, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit config save: %w", err)
+	}
+	return nil
+}
+
+// Reset removes the persisted config snapshot and records cfg, the config
+// the gateway falls back to, as the newest history version, in a single
+// transaction.
+func (s *SQLConfigStore) Reset(ctx context.Context, cfg aigateway.Config) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin config reset: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM gateway_config WHERE id = 1`); err != nil {
+		return fmt.Errorf("delete config: %w", err)
+	}
+	if err := s.appendHistory(ctx, tx, data, time.Now().UTC()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit config reset: %w", err)
+	}
+	return nil
+}
+
+// appendHistory records data as the next config_history version, with the
+// author and rollback origin ctx carries, and prunes the versions past the
+// history limit.
+func (s *SQLConfigStore) appendHistory(ctx context.Context, tx *sql.Tx, data []byte, now time.Time) error {
 	var nextVersion int
 	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) + 1 FROM config_history").Scan(&nextVersion); err != nil {
 		return fmt.Errorf("next config history version: %w", err)
 	}
-	histInsert := sqldb.Bind(s.dialect, "INSERT INTO config_history(version, config_json, updated_at) VALUES(?, ?, ?)")
-	if _, err := tx.ExecContext(ctx, histInsert, nextVersion, string(data), now); err != nil {
+	var author sql.NullString
+	if id, ok := KeyIDFromContext(ctx); ok {
+		author = sql.NullString{String: id, Valid: true}
+	}
+	var rolledBackFrom sql.NullInt64
+	if rollback, _ := ctx.Value(configRollbackContextKey).(bool); rollback && nextVersion > 1 {
+		rolledBackFrom = sql.NullInt64{Int64: int64(nextVersion - 1), Valid: true}
+	}
+	histInsert := sqldb.Bind(s.dialect, "INSERT INTO config_history(version, config_json, updated_at, author_key_id, rolled_back_from) VALUES(?, ?, ?, ?, ?)")
+	if _, err := tx.ExecContext(ctx, histInsert, nextVersion, string(data), now, author, rolledBackFrom); err != nil {
 		return fmt.Errorf("append config history: %w", err)
 	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit config save: %w", err)
+	if s.historyLimit > 0 && nextVersion > s.historyLimit {
+		prune := sqldb.Bind(s.dialect, "DELETE FROM config_history WHERE version <= ?")
+		if _, err := tx.ExecContext(ctx, prune, nextVersion-s.historyLimit); err != nil {
+			return fmt.Errorf("prune config history: %w", err)
+		}
 	}
 	return nil
 }
@@ -137,9 +230,10 @@ ON CONFLICT(id) DO UPDATE SET config_json = excluded.config_json, updated_at = e
 // The read is capped at maxConfigHistoryEntries — matching the in-memory
 // history bound — so a gateway with a long audit trail never decodes every
 // stored snapshot into memory to answer one call. The cap is a read bound
-// only: no history row is ever deleted, and older versions stay queryable.
+// only: the read deletes nothing, older retained versions stay reachable
+// through LoadVersion, and retention is Save's history limit alone.
 func (s *SQLConfigStore) LoadHistory(ctx context.Context) ([]PersistedConfigVersion, error) {
-	query := sqldb.Bind(s.dialect, "SELECT version, config_json, updated_at FROM config_history ORDER BY version DESC LIMIT ?")
+	query := sqldb.Bind(s.dialect, "SELECT "+configHistoryColumns+" FROM config_history ORDER BY version DESC LIMIT ?")
 	rows, err := s.db.QueryContext(ctx, query, maxConfigHistoryEntries)
 	if err != nil {
 		return nil, fmt.Errorf("load config history: %w", err)
@@ -148,15 +242,9 @@ func (s *SQLConfigStore) LoadHistory(ctx context.Context) ([]PersistedConfigVers
 
 	var history []PersistedConfigVersion
 	for rows.Next() {
-		var (
-			rec PersistedConfigVersion
-			raw string
-		)
-		if err := rows.Scan(&rec.Version, &raw, &rec.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("scan config history row: %w", err)
-		}
-		if err := json.Unmarshal([]byte(raw), &rec.Config); err != nil {
-			return nil, fmt.Errorf("decode config history: %w", err)
+		rec, err := scanConfigVersion(rows)
+		if err != nil {
+			return nil, err
 		}
 		history = append(history, rec)
 	}
@@ -169,6 +257,45 @@ func (s *SQLConfigStore) LoadHistory(ctx context.Context) ([]PersistedConfigVers
 	return history, nil
 }
 
+// LoadVersion returns one retained config version.
+func (s *SQLConfigStore) LoadVersion(ctx context.Context, version int) (PersistedConfigVersion, bool, error) {
+	query := sqldb.Bind(s.dialect, "SELECT "+configHistoryColumns+" FROM config_history WHERE version = ?")
+	rec, err := scanConfigVersion(s.db.QueryRowContext(ctx, query, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return PersistedConfigVersion{}, false, nil
+	}
+	if err != nil {
+		return PersistedConfigVersion{}, false, err
+	}
+	return rec, true, nil
+}
+
+const configHistoryColumns = "version, config_json, updated_at, author_key_id, rolled_back_from"
+
+func scanConfigVersion(row interface{ Scan(dest ...any) error }) (PersistedConfigVersion, error) {
+	var (
+		rec            PersistedConfigVersion
+		raw            string
+		author         sql.NullString
+		rolledBackFrom sql.NullInt64
+	)
+	if err := row.Scan(&rec.Version, &raw, &rec.UpdatedAt, &author, &rolledBackFrom); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return rec, err
+		}
+		return rec, fmt.Errorf("scan config history row: %w", err)
+	}
+	if err := json.Unmarshal([]byte(raw), &rec.Config); err != nil {
+		return rec, fmt.Errorf("decode config history: %w", err)
+	}
+	rec.AuthorKeyID = author.String
+	if rolledBackFrom.Valid {
+		v := int(rolledBackFrom.Int64)
+		rec.RolledBackFrom = &v
+	}
+	return rec, nil
+}
+
 // Load returns the persisted config snapshot when one exists.
 func (s *SQLConfigStore) Load(ctx context.Context) (aigateway.Config, bool, error) {
 	query := `SELECT config_json FROM gateway_config WHERE id = 1`
@@ -227,10 +354,11 @@ type GatewayConfigManager struct {
 	// The lock belongs here rather than only in the admin handlers: this type
 	// owns both the store and the gateway, and it is the only place that knows
 	// the two must move together. Callers get the invariant for free.
-	mu      sync.Mutex
-	gw      *aigateway.Gateway
-	initial aigateway.Config
-	store   ConfigStore
+	mu       sync.Mutex
+	gw       *aigateway.Gateway
+	initial  aigateway.Config
+	store    ConfigStore
+	verifier *signing.Verifier
 }
 
 // NewGatewayConfigManager creates a config manager backed by an optional persistent store.
@@ -262,6 +390,30 @@ func NewGatewayConfigManager(gw *aigateway.Gateway, store ConfigStore) (*Gateway
 	return m, nil
 }
 
+// HistoryStore returns the manager's store when it keeps config versions.
+func (m *GatewayConfigManager) HistoryStore() (ConfigHistoryStore, bool) {
+	if m == nil || m.store == nil {
+		return nil, false
+	}
+	hs, ok := m.store.(ConfigHistoryStore)
+	return hs, ok
+}
+
+// SetBundleVerifier makes config written, imported, or synced through m carry
+// a signature v verifies. It must be called before m is in use.
+func (m *GatewayConfigManager) SetBundleVerifier(v *signing.Verifier) {
+	m.verifier = v
+}
+
+// BundleVerifier returns the verifier applied config must pass, or nil when
+// signatures are not checked.
+func (m *GatewayConfigManager) BundleVerifier() *signing.Verifier {
+	if m == nil {
+		return nil
+	}
+	return m.verifier
+}
+
 // GetConfig returns the active runtime config.
 func (m *GatewayConfigManager) GetConfig() aigateway.Config {
 	return m.gw.GetConfig()
@@ -335,7 +487,14 @@ func (m *GatewayConfigManager) ResetConfig(ctx context.Context) error {
 		return err
 	}
 	if m.store != nil {
-		if err := m.store.Delete(ctx); err != nil {
+		clearOverride := m.store.Delete
+		if resetter, ok := m.store.(interface {
+			Reset(ctx context.Context, cfg aigateway.Config) error
+		}); ok {
+			// A store that keeps history records the reset as a version.
+			clearOverride = func(ctx context.Context) error { return resetter.Reset(ctx, m.initial) }
+		}
+		if err := clearOverride(ctx); err != nil {
 			// The apply already succeeded, so the gateway is running the
 			// startup config while the store still holds the override it
 			// replaced — and a restart would load that override back. Record
@@ -557,3 +557,52 @@ func TestSQLConfigStore_LoadHistoryBoundsRowsRead(t *testing.T) {
 		t.Fatalf("config_history has %d rows, want %d: rows must never be deleted", total, seeded)
 	}
 }
+
+// TestSQLConfigStore_RecordsProvenanceAndPrunes covers what each history
+// version records about its change and the retention limit Save enforces.
+func TestSQLConfigStore_RecordsProvenanceAndPrunes(t *testing.T) {
+	store, err := NewSQLiteConfigStore(t.Context(), filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatalf("new config store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	store.SetHistoryLimit(3)
+
+	ctx := ContextWithAPIKey(context.Background(), &APIKey{ID: "key-1"})
+	if err := store.Save(ctx, singleConfig()); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := store.Save(withConfigRollback(ctx), fallbackConfig()); err != nil {
+		t.Fatalf("save rollback: %v", err)
+	}
+	v2, ok, err := store.LoadVersion(context.Background(), 2)
+	if err != nil || !ok {
+		t.Fatalf("load version 2: ok=%v err=%v", ok, err)
+	}
+	if v2.AuthorKeyID != "key-1" || v2.RolledBackFrom == nil || *v2.RolledBackFrom != 1 {
+		t.Fatalf("version 2 author=%q rolled_back_from=%v, want key-1 and 1", v2.AuthorKeyID, v2.RolledBackFrom)
+	}
+
+	if err := store.Save(context.Background(), singleConfig()); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := store.Reset(context.Background(), fallbackConfig()); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if _, ok, _ := store.Load(context.Background()); ok {
+		t.Fatal("reset left the persisted config in place")
+	}
+	history, err := store.LoadHistory(context.Background())
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if len(history) != 3 || history[0].Version != 2 || history[2].Version != 4 {
+		t.Fatalf("history = %+v, want versions 2..4 after pruning", history)
+	}
+	if history[2].AuthorKeyID != "" || history[2].Config.Strategy.Mode != aigateway.ModeFallback {
+		t.Fatalf("reset version = %+v, want the reset config with no author", history[2])
+	}
+	if _, ok, err := store.LoadVersion(context.Background(), 1); ok || err != nil {
+		t.Fatalf("pruned version 1: ok=%v err=%v, want gone", ok, err)
+	}
+}
@@ -0,0 +1,642 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/signing"
+)
+
+// GitOps sync runs the gateway's config as code. A GitOpsSync pulls a config
+// bundle (the format GET /admin/config/export writes) from a Git repository,
+// an HTTP URL, or an OCI artifact, validates it, and applies it when it
+// differs from the running config. While it runs, the admin API's config
+// writes are refused: the source is the only way to change the config.
+
+// maxGitOpsBundleBytes caps the size of a fetched config bundle.
+const maxGitOpsBundleBytes = 10 << 20
+
+// bundleSignatureSuffix names a bundle's detached signature: the file or URL
+// of the bundle with it appended, or the OCI layer titled that way.
+const bundleSignatureSuffix = ".sig"
+
+// GitOpsSource is where a GitOpsSync pulls its config bundle from.
+type GitOpsSource interface {
+	// Fetch returns the bundle and a revision naming it: a commit hash or a
+	// content digest.
+	Fetch(ctx context.Context) (bundle []byte, revision string, err error)
+	// String describes the source without its credentials.
+	String() string
+}
+
+// gitOpsSignedSource is a source that also fetched the detached signature
+// published beside the bundle. Signature returns it for the last Fetch, or
+// nil when none was published.
+type gitOpsSignedSource interface {
+	Signature() []byte
+}
+
+// GitOpsSourceOptions configures NewGitOpsSource.
+type GitOpsSourceOptions struct {
+	// Path is the bundle's file in a Git repository (default
+	// ferrogw-config.json) or the title of its layer in an OCI artifact
+	// (default: the first layer).
+	Path string
+	// Ref is the Git branch or tag to read (default: the remote's HEAD).
+	Ref string
+	// Username and Token authenticate to an OCI registry. Token alone is
+	// sent as a bearer token to an HTTP source. Git sources use the host's
+	// Git credentials.
+	Username string
+	Token    string
+	// Client makes HTTP and OCI requests. Nil uses http.DefaultClient.
+	Client *http.Client
+	// Signature also fetches the bundle's detached signature, published
+	// beside it with a .sig suffix, for the sync to verify.
+	Signature bool
+}
+
+// NewGitOpsSource parses raw into a source:
+//
+//   - git+https://host/org/repo.git, git+ssh://..., git+file://..., or
+//     git@host:org/repo.git clones a Git repository with the git CLI;
+//   - oci://registry/repository[:tag|@digest] pulls an OCI artifact;
+//   - http:// and https:// URLs are fetched as they are.
+func NewGitOpsSource(raw string, opts GitOpsSourceOptions) (GitOpsSource, error) {
+	raw = strings.TrimSpace(raw)
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	switch {
+	case strings.HasPrefix(raw, "git+") || strings.HasPrefix(raw, "git@"):
+		path := opts.Path
+		if path == "" {
+			path = "ferrogw-config.json"
+		}
+		if !filepath.IsLocal(path) {
+			return nil, fmt.Errorf("gitops path %q must stay inside the repository", path)
+		}
+		return &gitGitOpsSource{url: strings.TrimPrefix(raw, "git+"), path: path, ref: opts.Ref, signed: opts.Signature}, nil
+	case strings.HasPrefix(raw, "oci://"):
+		src, err := parseOCIReference(strings.TrimPrefix(raw, "oci://"))
+		if err != nil {
+			return nil, err
+		}
+		src.scheme = "https"
+		src.layer = opts.Path
+		src.username, src.token = opts.Username, opts.Token
+		src.client = client
+		src.signed = opts.Signature
+		return src, nil
+	case strings.HasPrefix(raw, "https://") || strings.HasPrefix(raw, "http://"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse gitops source: %w", err)
+		}
+		return &httpGitOpsSource{url: u, token: opts.Token, client: client, signed: opts.Signature}, nil
+	default:
+		return nil, fmt.Errorf("unsupported gitops source %q: use a git+, oci://, or https:// URL", raw)
+	}
+}
+
+// GitOpsStatus reports the state of a GitOpsSync.
+type GitOpsStatus struct {
+	Source string `json:"source"`
+	// State is "pending" before the first sync, then "synced" or "failed"
+	// after each one. A failed sync leaves the running config as it was.
+	State string `json:"state"`
+	// Revision names the bundle the running config was last synced from.
+	Revision      string     `json:"revision,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	LastSyncedAt  *time.Time `json:"last_synced_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	// Changes lists what the last successful sync applied. It is empty when
+	// that sync found the config already in step with the source.
+	Changes []configChange `json:"changes,omitempty"`
+}
+
+// GitOpsSync wraps the gateway's ConfigManager and keeps the config it manages
+// in step with Source. Admin handlers given a GitOpsSync as their Configs
+// refuse config writes and serve its status at /admin/gitops.
+type GitOpsSync struct {
+	ConfigManager
+	Source GitOpsSource
+
+	syncMu sync.Mutex // serializes syncs
+
+	mu     sync.Mutex
+	status GitOpsStatus
+}
+
+// NewGitOpsSync returns a GitOpsSync applying source's bundles through cm.
+func NewGitOpsSync(cm ConfigManager, source GitOpsSource) *GitOpsSync {
+	return &GitOpsSync{
+		ConfigManager: cm,
+		Source:        source,
+		status:        GitOpsStatus{Source: source.String(), State: "pending"},
+	}
+}
+
+// errInvalidBundle marks a sync that fetched the bundle but refused its
+// content: a bad signature, an undecodable or unsupported bundle, or a config
+// that fails validation.
+var errInvalidBundle = errors.New("invalid config bundle")
+
+// Run syncs at once and then every interval until ctx is done.
+func (g *GitOpsSync) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_ = g.Sync(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync pulls the bundle once and applies it if it changes the config. A
+// bundle that cannot be fetched, decoded, or validated is logged, recorded
+// in the status, and returned; the running config stays as it was.
+func (g *GitOpsSync) Sync(ctx context.Context) error {
+	g.syncMu.Lock()
+	defer g.syncMu.Unlock()
+
+	now := time.Now().UTC()
+	revision, changes, err := g.apply(ctx)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.status.LastAttemptAt = &now
+	if err != nil {
+		g.status.State = "failed"
+		g.status.LastError = err.Error()
+		logging.Logger.Warn("gitops sync failed", "source", g.status.Source, "error", err)
+		return err
+	}
+	g.status.State = "synced"
+	g.status.Revision = revision
+	g.status.LastSyncedAt = &now
+	g.status.LastError = ""
+	g.status.Changes = changes
+	if len(changes) > 0 {
+		logging.Logger.Info("gitops config applied", "source", g.status.Source, "revision", revision, "changes", len(changes))
+	}
+	return nil
+}
+
+func (g *GitOpsSync) apply(ctx context.Context) (string, []configChange, error) {
+	data, revision, err := g.Source.Fetch(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if v := g.BundleVerifier(); v != nil {
+		var signature []byte
+		if signed, ok := g.Source.(gitOpsSignedSource); ok {
+			signature = signed.Signature()
+		}
+		if err := v.Verify(data, signature); err != nil {
+			return "", nil, fmt.Errorf("%w: config bundle %s: %w", errInvalidBundle, revision, err)
+		}
+	}
+	var bundle ConfigBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return "", nil, fmt.Errorf("%w: decode config bundle %s: %w", errInvalidBundle, revision, err)
+	}
+	if err := checkBundleVersion(bundle); err != nil {
+		return "", nil, fmt.Errorf("%w: %w", errInvalidBundle, err)
+	}
+	cfg, changes, err := planBundle(bundle, g.GetConfig())
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: config bundle %s: %w", errInvalidBundle, revision, err)
+	}
+	if len(changes) > 0 {
+		if err := g.ReloadConfig(ctx, cfg); err != nil {
+			if !errors.Is(err, errConfigPersistence) {
+				err = fmt.Errorf("%w: %w", errInvalidBundle, err)
+			}
+			return "", nil, fmt.Errorf("apply config bundle %s: %w", revision, err)
+		}
+	}
+	return revision, changes, nil
+}
+
+// Status returns the sync's current status.
+func (g *GitOpsSync) Status() GitOpsStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.status
+}
+
+// HistoryStore passes through the wrapped manager's durable config history.
+func (g *GitOpsSync) HistoryStore() (ConfigHistoryStore, bool) {
+	src, ok := g.ConfigManager.(interface {
+		HistoryStore() (ConfigHistoryStore, bool)
+	})
+	if !ok {
+		return nil, false
+	}
+	return src.HistoryStore()
+}
+
+// BundleVerifier passes through the wrapped manager's bundle verifier.
+func (g *GitOpsSync) BundleVerifier() *signing.Verifier {
+	return bundleVerifier(g.ConfigManager)
+}
+
+// Close closes the wrapped manager when it holds resources.
+func (g *GitOpsSync) Close() error {
+	if closer, ok := g.ConfigManager.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// readBundle reads at most maxGitOpsBundleBytes from r.
+func readBundle(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxGitOpsBundleBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxGitOpsBundleBytes {
+		return nil, fmt.Errorf("config bundle exceeds %d bytes", maxGitOpsBundleBytes)
+	}
+	return data, nil
+}
+
+func contentDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// httpGitOpsSource fetches the bundle from a URL, such as a file's raw URL on
+// a Git host.
+type httpGitOpsSource struct {
+	url    *url.URL
+	token  string
+	client *http.Client
+	signed bool
+
+	// signature is the one fetched with the last bundle. Syncs are
+	// serialized, so it needs no lock.
+	signature []byte
+}
+
+func (s *httpGitOpsSource) Fetch(ctx context.Context) ([]byte, string, error) {
+	data, err := s.get(ctx, s.url, false)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch config bundle: %w", err)
+	}
+	s.signature = nil
+	if s.signed {
+		sigURL := *s.url
+		sigURL.Path += bundleSignatureSuffix
+		sigURL.RawPath = ""
+		if s.signature, err = s.get(ctx, &sigURL, true); err != nil {
+			return nil, "", fmt.Errorf("fetch config bundle signature: %w", err)
+		}
+	}
+	return data, contentDigest(data), nil
+}
+
+// get reads u. With optional set, a 404 reads as no content.
+func (s *httpGitOpsSource) get(ctx context.Context, u *url.URL, optional bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if optional && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", u.Redacted(), resp.Status)
+	}
+	return readBundle(resp.Body)
+}
+
+func (s *httpGitOpsSource) Signature() []byte { return s.signature }
+
+func (s *httpGitOpsSource) String() string { return s.url.Redacted() }
+
+// gitGitOpsSource reads the bundle from a shallow clone of a Git repository.
+type gitGitOpsSource struct {
+	url, path, ref string
+	signed         bool
+
+	signature []byte // fetched with the last bundle; syncs are serialized
+}
+
+func (s *gitGitOpsSource) Fetch(ctx context.Context) ([]byte, string, error) {
+	dir, err := os.MkdirTemp("", "ferrogw-gitops-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if s.ref != "" {
+		args = append(args, "--branch", s.ref)
+	}
+	args = append(args, "--", s.url, dir)
+	if _, err := runGit(ctx, args...); err != nil {
+		return nil, "", err
+	}
+	revision, err := runGit(ctx, "-C", dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(s.path)))
+	if err != nil {
+		return nil, "", fmt.Errorf("read config bundle at %s: %w", s.path, err)
+	}
+	defer func() { _ = f.Close() }()
+	data, err := readBundle(f)
+	if err != nil {
+		return nil, "", err
+	}
+	s.signature = nil
+	if s.signed {
+		sig, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(s.path+bundleSignatureSuffix)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, "", fmt.Errorf("read config bundle signature: %w", err)
+		}
+		s.signature = sig
+	}
+	return data, revision, nil
+}
+
+func (s *gitGitOpsSource) Signature() []byte { return s.signature }
+
+func (s *gitGitOpsSource) String() string {
+	desc := "git+" + redactURL(s.url) + "//" + s.path
+	if s.ref != "" {
+		desc += "@" + s.ref
+	}
+	return desc
+}
+
+func runGit(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...) //nolint:gosec // arguments come from the operator's GITOPS_* settings, not request input
+	// Never block on a credential prompt: there is no terminal to answer it.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// redactURL hides the password of a URL that carries one.
+func redactURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return raw
+}
+
+// ociGitOpsSource pulls the bundle from an OCI artifact through the registry
+// HTTP API: it reads the artifact's manifest and then the blob of the layer
+// holding the bundle.
+type ociGitOpsSource struct {
+	scheme     string
+	registry   string
+	repository string
+	reference  string // a tag or a digest
+	layer      string
+	username   string
+	token      string
+	client     *http.Client
+	signed     bool
+
+	signature []byte // fetched with the last bundle
+
+	// bearer is the registry token from the last challenge. Syncs are
+	// serialized, so it needs no lock.
+	bearer string
+}
+
+func parseOCIReference(ref string) (*ociGitOpsSource, error) {
+	registry, repo, ok := strings.Cut(ref, "/")
+	if !ok || registry == "" || repo == "" {
+		return nil, fmt.Errorf("invalid oci reference %q: want registry/repository[:tag|@digest]", ref)
+	}
+	src := &ociGitOpsSource{registry: registry, reference: "latest"}
+	if name, digest, ok := strings.Cut(repo, "@"); ok {
+		repo, src.reference = name, digest
+	} else if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo, src.reference = repo[:i], repo[i+1:]
+	}
+	if repo == "" || src.reference == "" {
+		return nil, fmt.Errorf("invalid oci reference %q: want registry/repository[:tag|@digest]", ref)
+	}
+	src.repository = repo
+	return src, nil
+}
+
+type ociManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+func (s *ociGitOpsSource) Fetch(ctx context.Context) ([]byte, string, error) {
+	raw, resp, err := s.get(ctx, "manifests/"+s.reference,
+		"application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json")
+	if err != nil {
+		return nil, "", err
+	}
+	revision := resp.Header.Get("Docker-Content-Digest")
+	if revision == "" {
+		revision = contentDigest(raw)
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, "", fmt.Errorf("decode oci manifest: %w", err)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, "", errors.New("oci artifact has no layers")
+	}
+	digest, title := manifest.Layers[0].Digest, manifest.Layers[0].Annotations[ociTitleAnnotation]
+	if s.layer != "" {
+		digest, title = manifest.layerTitled(s.layer), s.layer
+		if digest == "" {
+			return nil, "", fmt.Errorf("oci artifact has no layer titled %q", s.layer)
+		}
+	}
+
+	data, err := s.blob(ctx, digest)
+	if err != nil {
+		return nil, "", err
+	}
+	s.signature = nil
+	if s.signed && title != "" {
+		if sigDigest := manifest.layerTitled(title + bundleSignatureSuffix); sigDigest != "" {
+			if s.signature, err = s.blob(ctx, sigDigest); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	return data, revision, nil
+}
+
+func (s *ociGitOpsSource) Signature() []byte { return s.signature }
+
+// ociTitleAnnotation names an OCI layer, as oras push sets it from the file name.
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+// layerTitled returns the digest of the layer titled title, or "".
+func (m ociManifest) layerTitled(title string) string {
+	for _, l := range m.Layers {
+		if l.Annotations[ociTitleAnnotation] == title {
+			return l.Digest
+		}
+	}
+	return ""
+}
+
+// blob reads the blob digest names and checks it matches.
+func (s *ociGitOpsSource) blob(ctx context.Context, digest string) ([]byte, error) {
+	data, _, err := s.get(ctx, "blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(digest, "sha256:") && contentDigest(data) != digest {
+		return nil, fmt.Errorf("oci blob does not match its digest %s", digest)
+	}
+	return data, nil
+}
+
+func (s *ociGitOpsSource) String() string {
+	sep := ":"
+	if strings.Contains(s.reference, ":") {
+		sep = "@"
+	}
+	return "oci://" + s.registry + "/" + s.repository + sep + s.reference
+}
+
+// get reads one registry API path, answering a bearer-token challenge once.
+func (s *ociGitOpsSource) get(ctx context.Context, path, accept string) ([]byte, *http.Response, error) {
+	target := s.scheme + "://" + s.registry + "/v2/" + s.repository + "/" + path
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if s.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+s.bearer)
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetch oci %s: %w", path, err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			_ = resp.Body.Close()
+			if err := s.authenticate(ctx, challenge); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		data, err := readBundle(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetch oci %s: %w", path, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("fetch oci %s: registry returned %s", path, resp.Status)
+		}
+		return data, resp, nil
+	}
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate gets a registry token for a Bearer challenge, anonymously or
+// with the source's credentials.
+func (s *ociGitOpsSource) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("oci registry %s requires unsupported authentication %q", s.registry, scheme)
+	}
+	values := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(params, -1) {
+		values[m[1]] = m[2]
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("oci registry %s sent an invalid token realm", s.registry)
+	}
+	query := realm.Query()
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := values["scope"]
+	if scope == "" {
+		scope = "repository:" + s.repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if s.token != "" {
+		req.SetBasicAuth(s.username, s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oci registry token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oci registry token: %s returned %s", realm.Host, resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return fmt.Errorf("oci registry token: %w", err)
+	}
+	s.bearer = body.Token
+	if s.bearer == "" {
+		s.bearer = body.AccessToken
+	}
+	if s.bearer == "" {
+		return errors.New("oci registry token: response carried no token")
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+)
+
+func gitOpsBundle(t *testing.T, cfg aigateway.Config) []byte {
+	t.Helper()
+	data, err := json.Marshal(ConfigBundle{BundleVersion: configBundleVersion, Config: cfg})
+	if err != nil {
+		t.Fatalf("marshal bundle: %v", err)
+	}
+	return data
+}
+
+func TestGitOpsSync(t *testing.T) {
+	var body atomic.Value
+	body.Store(gitOpsBundle(t, aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+		Aliases:  map[string]string{"fast": "gpt-4o-mini"},
+	}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer repo-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(body.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	source, err := NewGitOpsSource(srv.URL+"/ferrogw-config.json", GitOpsSourceOptions{Token: "repo-token"})
+	if err != nil {
+		t.Fatalf("NewGitOpsSource: %v", err)
+	}
+	cm := &testConfigManager{cfg: aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+	}}
+	sync := NewGitOpsSync(cm, source)
+	h, r := setupTestRouterWithConfigManager(sync)
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/gitops/sync", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("sync: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cm.cfg.Aliases["fast"] != "gpt-4o-mini" {
+		t.Fatalf("sync did not apply the bundle: %+v", cm.cfg)
+	}
+	status := sync.Status()
+	if status.State != "synced" || !strings.HasPrefix(status.Revision, "sha256:") || len(status.Changes) != 1 {
+		t.Fatalf("status = %+v, want synced with one change", status)
+	}
+
+	// An unchanged source syncs without changes.
+	if err := sync.Sync(t.Context()); err != nil || len(sync.Status().Changes) != 0 {
+		t.Fatalf("resync: err=%v changes=%v, want none", err, sync.Status().Changes)
+	}
+
+	// An invalid bundle fails the sync and leaves the config running.
+	body.Store([]byte(`{"bundle_version":1,"config":{"strategy":{"mode":"single"}}}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/gitops/sync", "", adminKey))
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"invalid_request"`) {
+		t.Fatalf("invalid bundle: expected 422 invalid_request, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/gitops", "", createReadOnlyKey(t, h)))
+	var got struct {
+		Enabled bool `json:"enabled"`
+		GitOpsStatus
+	}
+	decodeJSON(t, w.Body, &got)
+	if !got.Enabled || got.State != "failed" || got.LastError == "" || got.Revision != status.Revision {
+		t.Fatalf("status after failure = %+v, want failed at the last good revision", got)
+	}
+	if cm.cfg.Aliases["fast"] != "gpt-4o-mini" {
+		t.Fatal("a failed sync changed the config")
+	}
+
+	for _, tc := range []struct{ method, url, body string }{
+		{http.MethodPut, "/admin/config", fallbackConfigBody},
+		{http.MethodPost, "/admin/config/import", `{}`},
+		{http.MethodPost, "/admin/tiers", `{"name":"free"}`},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(tc.method, tc.url, tc.body, adminKey))
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s %s: expected 409, got %d: %s", tc.method, tc.url, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGitOpsSync_Signature(t *testing.T) {
+	current := aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+	}
+	next := current
+	next.Aliases = map[string]string{"fast": "gpt-4o-mini"}
+	bundle := gitOpsBundle(t, next)
+	verifier, sign := testBundleSigner(t)
+
+	var signature atomic.Value
+	signature.Store("")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ferrogw-config.json":
+			_, _ = w.Write(bundle)
+		case "/ferrogw-config.json.sig":
+			if sig := signature.Load().(string); sig != "" {
+				_, _ = w.Write([]byte(sig))
+				return
+			}
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	source, err := NewGitOpsSource(srv.URL+"/ferrogw-config.json", GitOpsSourceOptions{Signature: true})
+	if err != nil {
+		t.Fatalf("NewGitOpsSource: %v", err)
+	}
+	cm := &testConfigManager{cfg: current}
+	sync := NewGitOpsSync(signedConfigManager{testConfigManager: cm, verifier: verifier}, source)
+
+	if err := sync.Sync(t.Context()); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Fatalf("unsigned bundle: Sync = %v, want a not signed error", err)
+	}
+	signature.Store(sign([]byte(`{"bundle_version":1}`)))
+	if err := sync.Sync(t.Context()); err == nil || cm.cfg.Aliases != nil {
+		t.Fatalf("badly signed bundle: Sync = %v, config = %+v; want it refused", err, cm.cfg)
+	}
+	signature.Store(sign(bundle) + "\n")
+	if err := sync.Sync(t.Context()); err != nil {
+		t.Fatalf("signed bundle: Sync = %v", err)
+	}
+	if cm.cfg.Aliases["fast"] != "gpt-4o-mini" {
+		t.Fatalf("signed bundle was not applied: %+v", cm.cfg)
+	}
+}
+
+func TestGitOpsStatus_Disabled(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/gitops", "", adminKey))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"enabled":false`) {
+		t.Fatalf("got %d %s, want enabled false", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/gitops/sync", "", adminKey))
+	if w.Code != http.StatusNotImplemented || !strings.Contains(w.Body.String(), `"not_implemented"`) {
+		t.Fatalf("sync: expected 501 not_implemented, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGitOpsSource_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	want := gitOpsBundle(t, aigateway.Config{Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle}})
+	if err := os.MkdirAll(filepath.Join(repo, "gateway"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "gateway", "config.json"), want, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "config")
+
+	source, err := NewGitOpsSource("git+file://"+repo, GitOpsSourceOptions{Path: "gateway/config.json", Ref: "main"})
+	if err != nil {
+		t.Fatalf("NewGitOpsSource: %v", err)
+	}
+	data, revision, err := source.Fetch(t.Context())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if string(data) != string(want) || len(revision) != 40 {
+		t.Fatalf("Fetch = %q at %q, want the committed bundle at a commit hash", data, revision)
+	}
+
+	if _, err := NewGitOpsSource("git+file://"+repo, GitOpsSourceOptions{Path: "../escape.json"}); err == nil {
+		t.Error("expected a path outside the repository to be rejected")
+	}
+}
+
+func TestGitOpsSource_OCI(t *testing.T) {
+	blob := gitOpsBundle(t, aigateway.Config{Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle}})
+	manifest := `{"schemaVersion":2,"layers":[` +
+		`{"digest":"sha256:0000","annotations":{"org.opencontainers.image.title":"README.md"}},` +
+		`{"digest":"` + contentDigest(blob) + `","annotations":{"org.opencontainers.image.title":"ferrogw-config.json"}}]}`
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if user, pass, _ := r.BasicAuth(); user != "robot" || pass != "registry-token" || r.URL.Query().Get("scope") != "repository:team/gateway:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"pull-token"}`))
+		case r.Header.Get("Authorization") != "Bearer pull-token":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="registry",scope="repository:team/gateway:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/team/gateway/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", "sha256:manifest")
+			_, _ = w.Write([]byte(manifest))
+		case r.URL.Path == "/v2/team/gateway/blobs/"+contentDigest(blob):
+			_, _ = w.Write(blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	src, err := NewGitOpsSource("oci://"+strings.TrimPrefix(srv.URL, "http://")+"/team/gateway:v1",
+		GitOpsSourceOptions{Path: "ferrogw-config.json", Username: "robot", Token: "registry-token", Client: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewGitOpsSource: %v", err)
+	}
+	src.(*ociGitOpsSource).scheme = "http"
+	data, revision, err := src.Fetch(t.Context())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if string(data) != string(blob) || revision != "sha256:manifest" {
+		t.Fatalf("Fetch = %q at %q, want the titled layer at the manifest digest", data, revision)
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	for ref, want := range map[string]string{
+		"ghcr.io/team/gateway":             "oci://ghcr.io/team/gateway:latest",
+		"ghcr.io/team/gateway:v2":          "oci://ghcr.io/team/gateway:v2",
+		"localhost:5000/gateway@sha256:ab": "oci://localhost:5000/gateway@sha256:ab",
+	} {
+		src, err := parseOCIReference(ref)
+		if err != nil || src.String() != want {
+			t.Errorf("parseOCIReference(%q) = %v, %v; want %s", ref, src, err, want)
+		}
+	}
+	if _, err := parseOCIReference("gateway"); err == nil {
+		t.Error("expected a reference without a registry to be rejected")
+	}
+}
@@ -5,10 +5,17 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
+	"github.com/ferro-labs/ai-gateway/internal/evals"
+	"github.com/ferro-labs/ai-gateway/internal/experiments"
+	"github.com/ferro-labs/ai-gateway/internal/ratelimit"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/plugin"
 	"github.com/ferro-labs/ai-gateway/providers"
 	"github.com/go-chi/chi/v5"
 )
@@ -22,6 +29,51 @@ type ConfigManager interface {
 	Ping(ctx context.Context) error
 }
 
+// PluginSource exposes the plugin instances currently serving requests.
+type PluginSource interface {
+	Plugins() []plugin.Plugin
+}
+
+// ChatRouter routes chat requests through the gateway's full pipeline, as
+// cache warm-up requests must be to be cached like a client's.
+type ChatRouter interface {
+	Route(ctx context.Context, req providers.Request) (*providers.Response, error)
+}
+
+// RoutingStateSource exposes the gateway's live routing state.
+type RoutingStateSource interface {
+	RoutingState() aigateway.RoutingState
+}
+
+// TargetStateSource drains, disables, and re-enables the gateway's targets
+// without a config reload.
+type TargetStateSource interface {
+	SetTargetState(name, state string) error
+	TargetState(name string) (string, bool)
+}
+
+// UserEraser is a store holding end-user content that a right-to-erasure
+// request must reach besides the request log and the response cache.
+type UserEraser interface {
+	// EraseUser deletes what the store holds for user (a request's `user`
+	// field), for the API key keyID, or for both together, and returns how
+	// many items it removed. It returns ErrEraseUnsupported when the store
+	// cannot select its items by the filters given.
+	EraseUser(ctx context.Context, user, keyID string) (int, error)
+}
+
+// UserEraserFunc adapts a function to UserEraser.
+type UserEraserFunc func(ctx context.Context, user, keyID string) (int, error)
+
+// EraseUser implements UserEraser.
+func (f UserEraserFunc) EraseUser(ctx context.Context, user, keyID string) (int, error) {
+	return f(ctx, user, keyID)
+}
+
+// ErrEraseUnsupported reports that a store cannot be erased by the filters
+// of an erasure request; the request reports the store as not erased.
+var ErrEraseUnsupported = errors.New("store cannot be erased by these filters")
+
 // Handlers holds dependencies for admin HTTP handlers.
 type Handlers struct {
 	Keys      Store
@@ -29,6 +81,35 @@ type Handlers struct {
 	Configs   ConfigManager
 	Logs      requestlog.Reader
 	LogAdmin  requestlog.Maintainer
+	Plugins   PluginSource
+	Routing   RoutingStateSource
+	// Targets sets provider maintenance states, nil when there is no
+	// gateway.
+	Targets TargetStateSource
+	// Chat runs cache warm-up and dead-letter re-drive requests, nil when
+	// there is no gateway.
+	Chat ChatRouter
+	// RateLimits is the per-IP rate-limit store, nil when RATE_LIMIT_RPS
+	// is unset.
+	RateLimits ratelimit.Inspectable
+	Evals      *evals.Runner
+	// Experiments scores experiment shadow traffic, nil when the gateway
+	// sends none.
+	Experiments *experiments.Recorder
+	// DeadLetters keeps requests that failed on every target, nil when
+	// DEAD_LETTER_STORE_BACKEND is unset.
+	DeadLetters *deadletter.Queue
+	// KeyEvents receives aigateway.SubjectKeyCreated, SubjectKeyRotated,
+	// SubjectKeyRevoked, and SubjectKeyDeleted as the key endpoints change a
+	// key. Nil sends nothing.
+	KeyEvents func(ctx context.Context, subject string, key *APIKey)
+	// Erasers are the other stores an erasure by user reaches, by the name
+	// the response reports their deletions under.
+	Erasers map[string]UserEraser
+	// KeyRotationOverlap is how long a rotated key's previous secret stays
+	// valid when the rotate request names no overlap. Zero invalidates it at
+	// once.
+	KeyRotationOverlap time.Duration
 
 	// configMu serializes whole config mutations: applying a config and
 	// recording it in configHistory must happen as one step, or a concurrent
@@ -57,11 +138,32 @@ func (h *Handlers) Routes() chi.Router {
 		r.Get("/keys/{id}", h.getKey)
 		r.Get("/logs", h.listLogs)
 		r.Get("/logs/stats", h.logsStats)
+		r.Get("/logs/export", h.exportLogs)
+		r.Get("/logs/stream", h.streamLogs)
+		r.Get("/logs/{trace_id}", h.getLogTranscript)
 		r.Get("/providers", h.listProviders)
 		r.Get("/health", h.healthCheck)
 		r.Get("/plugins", h.listPlugins)
+		r.Get("/routing/state", h.routingState)
+		r.Get("/ratelimits", h.listRateLimits)
+		r.Get("/reports/usage", h.usageReport)
+		// Reads only: the export is compared, not stored.
+		r.Post("/reports/reconcile", h.reconcileUsage)
 		r.Get("/config", h.getConfig)
 		r.Get("/config/history", h.getConfigHistory)
+		r.Get("/config/export", h.exportConfig)
+		r.Get("/gitops", h.gitOpsStatus)
+		r.Get("/cache", h.cacheStats)
+		r.Get("/tiers", h.listTiers)
+		r.Get("/tiers/{name}", h.getTier)
+		r.Get("/defaults", h.getRequestDefaults)
+		r.Get("/evals", h.listEvalSuites)
+		r.Get("/evals/{name}", h.getEvalSuite)
+		r.Get("/evals/{name}/runs", h.listEvalRuns)
+		r.Get("/evals/{name}/runs/{id}", h.getEvalRun)
+		r.Get("/experiments/{id}/report", h.getExperimentReport)
+		r.Get("/dead-letters", h.listDeadLetters)
+		r.Get("/dead-letters/{id}", h.getDeadLetter)
 	})
 
 	// Write endpoints (admin scope only).
@@ -73,10 +175,50 @@ func (h *Handlers) Routes() chi.Router {
 		r.Post("/keys/{id}/revoke", h.revokeKey)
 		r.Post("/keys/{id}/rotate", h.rotateKey)
 		r.Delete("/logs", h.deleteLogs)
-		r.Post("/config", h.createConfig)
-		r.Put("/config", h.updateConfig)
-		r.Delete("/config", h.deleteConfig)
-		r.Post("/config/rollback/{version}", h.rollbackConfig)
+		r.Delete("/logs/by-user", h.deleteLogsByUser)
+		r.Post("/logs/{trace_id}/replay", h.replayLog)
+		r.Delete("/cache", h.purgeCache)
+		r.Post("/cache/warm", h.warmCache)
+		r.Put("/cache/namespaces/{namespace}/ttl", h.setCacheNamespaceTTL)
+		r.Delete("/ratelimits/{limiter}", h.resetRateLimit)
+		r.Delete("/cache/namespaces/{namespace}/ttl", h.clearCacheNamespaceTTL)
+		r.Post("/evals/{name}/run", h.runEvalSuite)
+		r.Post("/gitops/sync", h.syncGitOps)
+		r.Post("/dead-letters/redrive", h.redriveDeadLetters)
+		r.Post("/dead-letters/{id}/redrive", h.redriveDeadLetter)
+		r.Delete("/dead-letters/{id}", h.deleteDeadLetter)
+		r.Post("/providers/{name}/drain", h.drainProvider)
+		r.Post("/providers/{name}/disable", h.disableProvider)
+		r.Post("/providers/{name}/enable", h.enableProvider)
+
+		// Config writes, refused while GitOps sync manages the config.
+		r.Group(func(r chi.Router) {
+			r.Use(h.requireConfigWritable)
+			r.Post("/config", h.createConfig)
+			r.Put("/config", h.updateConfig)
+			r.Post("/config/import", h.importConfig)
+			r.Post("/evals", h.createEvalSuite)
+			r.Put("/evals/{name}", h.updateEvalSuite)
+			r.Delete("/evals/{name}", h.deleteEvalSuite)
+
+			// Edits that apply no signable document, refused in strict
+			// signing mode.
+			r.Group(func(r chi.Router) {
+				r.Use(h.requireSignedConfig)
+				r.Delete("/config", h.deleteConfig)
+				r.Post("/config/rollback/{version}", h.rollbackConfig)
+				r.Post("/plugins/{name}/enable", h.enablePlugin)
+				r.Post("/plugins/{name}/disable", h.disablePlugin)
+				r.Put("/plugins/{name}", h.updatePlugin)
+				r.Post("/tiers", h.createTier)
+				r.Put("/tiers/{name}", h.updateTier)
+				r.Delete("/tiers/{name}", h.deleteTier)
+				r.Put("/defaults/default", h.setDefaultRequestDefaults)
+				r.Delete("/defaults/default", h.deleteDefaultRequestDefaults)
+				r.Put("/defaults/{scope}/{name}", h.setScopedRequestDefaults)
+				r.Delete("/defaults/{scope}/{name}", h.deleteScopedRequestDefaults)
+			})
+		})
 	})
 
 	return r
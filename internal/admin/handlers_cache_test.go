@@ -0,0 +1,258 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/cache"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// fakeCachePlugin is a response-cache stand-in: a plugin whose promoted
+// *cache.Memory methods satisfy cache.Admin.
+type fakeCachePlugin struct {
+	*cache.Memory
+}
+
+func (fakeCachePlugin) Name() string                                   { return "response-cache" }
+func (fakeCachePlugin) Type() plugin.PluginType                        { return plugin.TypeTransform }
+func (fakeCachePlugin) Init(map[string]any) error                      { return nil }
+func (fakeCachePlugin) Execute(context.Context, *plugin.Context) error { return nil }
+func (fakeCachePlugin) Close() error                                   { return nil }
+
+type fakePluginSource []plugin.Plugin
+
+func (f fakePluginSource) Plugins() []plugin.Plugin { return f }
+
+func setupTestRouterWithCache(t *testing.T) (*Handlers, http.Handler, *cache.Memory) {
+	t.Helper()
+	mem := cache.NewMemory(10, time.Minute)
+	mem.SetIn("key-a", "k1", &providers.Response{Model: "gpt-4o"})
+	mem.SetIn("key-a", "k2", &providers.Response{Model: "claude"})
+	mem.SetIn("key-b", "k1", &providers.Response{Model: "gpt-4o"})
+
+	h, r := setupTestRouter()
+	h.Plugins = fakePluginSource{fakeCachePlugin{mem}}
+	return h, r, mem
+}
+
+func TestCacheEndpointNotEnabled(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+
+	req := authedRequest(http.MethodGet, "/admin/cache", "", adminKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", w.Code)
+	}
+}
+
+func TestCacheStatsEndpoint(t *testing.T) {
+	h, r, mem := setupTestRouterWithCache(t)
+	mem.GetIn("key-a", "k1")
+	mem.GetIn("key-a", "missing")
+	readOnly := createReadOnlyKey(t, h)
+
+	req := authedRequest(http.MethodGet, "/admin/cache", "", readOnly)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var payload struct {
+		Data []struct {
+			Entries    int `json:"entries"`
+			Namespaces []struct {
+				Namespace string `json:"namespace"`
+				Entries   int    `json:"entries"`
+			} `json:"namespaces"`
+		} `json:"data"`
+		Summary struct {
+			Hits    int64   `json:"hits"`
+			Misses  int64   `json:"misses"`
+			HitRate float64 `json:"hit_rate"`
+		} `json:"summary"`
+	}
+	decodeJSON(t, w.Body, &payload)
+
+	if len(payload.Data) != 1 || payload.Data[0].Entries != 3 {
+		t.Fatalf("unexpected cache data: %+v", payload.Data)
+	}
+	if ns := payload.Data[0].Namespaces; len(ns) != 2 || ns[0].Namespace != "key-a" || ns[0].Entries != 2 {
+		t.Fatalf("unexpected namespaces: %+v", ns)
+	}
+	if payload.Summary.Hits != 1 || payload.Summary.Misses != 1 || payload.Summary.HitRate != 0.5 {
+		t.Fatalf("unexpected summary: %+v", payload.Summary)
+	}
+}
+
+func TestCachePurgeEndpoint(t *testing.T) {
+	h, r, mem := setupTestRouterWithCache(t)
+	adminKey := createAdminKey(t, h)
+
+	req := authedRequest(http.MethodDelete, "/admin/cache", "", adminKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unfiltered purge: expected 400, got %d", w.Code)
+	}
+
+	req = authedRequest(http.MethodDelete, "/admin/cache?namespace=key-a&model=gpt-4o", "", adminKey)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var payload struct {
+		Purged int `json:"purged"`
+	}
+	decodeJSON(t, w.Body, &payload)
+	if payload.Purged != 1 {
+		t.Fatalf("purged = %d, want 1", payload.Purged)
+	}
+	if _, _, ok := mem.GetIn("key-b", "k1"); !ok {
+		t.Fatal("purge by namespace removed another namespace's entry")
+	}
+
+	req = authedRequest(http.MethodDelete, "/admin/cache?all=true", "", adminKey)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || mem.Len() != 0 {
+		t.Fatalf("purge all: status %d, %d entries left", w.Code, mem.Len())
+	}
+}
+
+func TestCachePurgeRequiresAdminScope(t *testing.T) {
+	h, r, _ := setupTestRouterWithCache(t)
+	readOnly := createReadOnlyKey(t, h)
+
+	req := authedRequest(http.MethodDelete, "/admin/cache?all=true", "", readOnly)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestCacheNamespaceTTLEndpoints(t *testing.T) {
+	h, r, mem := setupTestRouterWithCache(t)
+	adminKey := createAdminKey(t, h)
+
+	req := authedRequest(http.MethodPut, "/admin/cache/namespaces/key-a/ttl", `{"ttl_seconds":0}`, adminKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("zero ttl: expected 400, got %d", w.Code)
+	}
+
+	req = authedRequest(http.MethodPut, "/admin/cache/namespaces/key-a/ttl", `{"ttl_seconds":30}`, adminKey)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := mem.TTLFor("key-a"); got != 30*time.Second {
+		t.Fatalf("TTLFor(key-a) = %v, want 30s", got)
+	}
+
+	req = authedRequest(http.MethodDelete, "/admin/cache/namespaces/key-a/ttl", "", adminKey)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if got := mem.TTLFor("key-a"); got != time.Minute {
+		t.Fatalf("TTLFor(key-a) after clear = %v, want default 1m", got)
+	}
+}
+
+// fakeChatRouter answers warm-up requests, recording the key each ran as. A
+// model named "cached-model" is a cache hit; "bad-model" fails.
+type fakeChatRouter struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (f *fakeChatRouter) Route(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	keyID, _ := authctx.KeyID(ctx)
+	f.mu.Lock()
+	f.keys = append(f.keys, keyID)
+	f.mu.Unlock()
+	switch req.Model {
+	case "bad-model":
+		return nil, errors.New("no provider supports model")
+	case "cached-model":
+		return &providers.Response{Model: req.Model, Cache: &providers.CacheInfo{Status: providers.CacheStatusHit}}, nil
+	}
+	return &providers.Response{Model: req.Model, Cache: &providers.CacheInfo{Status: providers.CacheStatusMiss}}, nil
+}
+
+func TestCacheWarmEndpoint(t *testing.T) {
+	h, r, _ := setupTestRouterWithCache(t)
+	adminKey := createAdminKey(t, h)
+	client := createTestKey(t, h, "client", []string{ScopeReadOnly}, nil)
+	chat := &fakeChatRouter{}
+	h.Chat = chat
+
+	body := `{"key_id":"` + client.ID + `","models":["gpt-4o","cached-model","bad-model"],"prompts":["hi"],` +
+		`"requests":[{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}],"temperature":0}]}`
+	req := authedRequest(http.MethodPost, "/admin/cache/warm", body, adminKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var payload struct {
+		Data []struct {
+			Model  string `json:"model"`
+			Status string `json:"status"`
+		} `json:"data"`
+		Summary map[string]int `json:"summary"`
+	}
+	decodeJSON(t, w.Body, &payload)
+	if len(payload.Data) != 4 {
+		t.Fatalf("results = %+v, want 4", payload.Data)
+	}
+	if payload.Summary["warmed"] != 2 || payload.Summary["cached"] != 1 || payload.Summary["error"] != 1 {
+		t.Errorf("summary = %v", payload.Summary)
+	}
+	for _, keyID := range chat.keys {
+		if keyID != client.ID {
+			t.Errorf("warm-up ran as key %q, want %q", keyID, client.ID)
+		}
+	}
+}
+
+func TestCacheWarmEndpointRejectsBadBodies(t *testing.T) {
+	h, r, _ := setupTestRouterWithCache(t)
+	adminKey := createAdminKey(t, h)
+	h.Chat = &fakeChatRouter{}
+
+	for name, tc := range map[string]struct {
+		body string
+		want int
+	}{
+		"empty":            {`{}`, http.StatusBadRequest},
+		"prompts no model": {`{"prompts":["hi"]}`, http.StatusBadRequest},
+		"stream":           {`{"requests":[{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hi"}]}]}`, http.StatusBadRequest},
+		"unknown key":      {`{"key_id":"nope","models":["gpt-4o"],"prompts":["hi"]}`, http.StatusNotFound},
+	} {
+		req := authedRequest(http.MethodPost, "/admin/cache/warm", tc.body, adminKey)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
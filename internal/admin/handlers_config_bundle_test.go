@@ -0,0 +1,346 @@
+package admin
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/signing"
+)
+
+func bundleTestRouter(cfg aigateway.Config) (*Handlers, http.Handler) {
+	cm := &testConfigManager{cfg: cfg}
+	cm.initial = cfg
+	return setupTestRouterWithConfigManager(cm)
+}
+
+func TestConfigExportImport(t *testing.T) {
+	t.Setenv("FERRO_TEST_HOOK_URL", "https://hooks.example.com")
+	source, sourceRouter := bundleTestRouter(aigateway.Config{
+		Strategy:       aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:        []aigateway.Target{{VirtualKey: "openai"}},
+		Aliases:        map[string]string{"fast": "gpt-4o-mini"},
+		RateLimitTiers: []aigateway.RateLimitTier{{Name: "free", RequestsPerSecond: 1}},
+		Plugins: []aigateway.PluginConfig{{
+			Name: "request-logger", Type: "logging", Stage: "after_request", Enabled: true,
+			Config: map[string]any{"api_key": "sk-source-123", "webhook": "${FERRO_TEST_HOOK_URL}"},
+		}},
+	})
+
+	w := httptest.NewRecorder()
+	sourceRouter.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/config/export", "", createReadOnlyKey(t, source)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	exported := w.Body.String()
+	if strings.Contains(exported, "sk-source-123") {
+		t.Fatal("export leaked a literal secret")
+	}
+	var bundle ConfigBundle
+	decodeJSON(t, strings.NewReader(exported), &bundle)
+	if bundle.BundleVersion != configBundleVersion {
+		t.Errorf("bundle_version = %d, want %d", bundle.BundleVersion, configBundleVersion)
+	}
+	if !slices.Equal(bundle.Redacted, []string{"plugins[request-logger].config.api_key"}) {
+		t.Errorf("redacted = %v", bundle.Redacted)
+	}
+	if !slices.Equal(bundle.EnvRefs, []string{"FERRO_TEST_HOOK_URL"}) {
+		t.Errorf("env_refs = %v", bundle.EnvRefs)
+	}
+
+	// The destination already holds its own value for the redacted secret.
+	dest, destRouter := bundleTestRouter(aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+		Plugins: []aigateway.PluginConfig{{
+			Name: "request-logger", Type: "logging", Stage: "after_request", Enabled: true,
+			Config: map[string]any{"api_key": "sk-dest-456"},
+		}},
+	})
+	adminKey := createAdminKey(t, dest)
+
+	w = httptest.NewRecorder()
+	destRouter.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/config/import?dry_run=true", exported, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var dryRun struct {
+		Status  string         `json:"status"`
+		Changes []configChange `json:"changes"`
+	}
+	decodeJSON(t, w.Body, &dryRun)
+	var paths []string
+	for _, c := range dryRun.Changes {
+		paths = append(paths, c.Op+" "+c.Path)
+	}
+	want := []string{"added aliases", "added plugins[0].config.webhook", "added rate_limit_tiers"}
+	if dryRun.Status != "dry_run" || !slices.Equal(paths, want) {
+		t.Fatalf("dry run = %s %v, want dry_run %v", dryRun.Status, paths, want)
+	}
+	if dest.Configs.GetConfig().Aliases != nil {
+		t.Fatal("dry run changed the config")
+	}
+
+	w = httptest.NewRecorder()
+	destRouter.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/config/import", exported, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	got := dest.Configs.GetConfig()
+	if got.Aliases["fast"] != "gpt-4o-mini" || len(got.RateLimitTiers) != 1 {
+		t.Errorf("imported aliases %v, tiers %v", got.Aliases, got.RateLimitTiers)
+	}
+	if key := got.Plugins[0].Config["api_key"]; key != "sk-dest-456" {
+		t.Errorf("redacted secret restored as %v, want the destination's own value", key)
+	}
+	if n := len(dest.configHistory); n != 1 {
+		t.Errorf("expected 1 config history entry, got %d", n)
+	}
+
+	w = httptest.NewRecorder()
+	destRouter.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/config/import", exported, adminKey))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"unchanged"`) {
+		t.Errorf("re-import: got %d %s, want unchanged", w.Code, w.Body.String())
+	}
+}
+
+// Only secret-bearing values are redacted, so a bundle whose other plugin
+// strings are plain data imports into a gateway that has never held them.
+func TestConfigExportImport_FreshGateway(t *testing.T) {
+	source, sourceRouter := bundleTestRouter(aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+		Plugins: []aigateway.PluginConfig{{
+			Name: "word-filter", Type: "guardrail", Stage: "before_request", Enabled: true,
+			Config: map[string]any{"blocked_words": []any{"secret-project"}, "redis_url": "redis://:hunter2@cache:6379", "key_prefix": "wf:"},
+		}},
+	})
+	w := httptest.NewRecorder()
+	sourceRouter.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/config/export", "", createReadOnlyKey(t, source)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var bundle ConfigBundle
+	decodeJSON(t, strings.NewReader(w.Body.String()), &bundle)
+	if !slices.Equal(bundle.Redacted, []string{"plugins[word-filter].config.redis_url"}) {
+		t.Fatalf("redacted = %v, want only the credentialed URL", bundle.Redacted)
+	}
+
+	// Replace the one literal secret the way an operator would before moving
+	// the bundle to a fresh environment.
+	t.Setenv("FERRO_TEST_REDIS_URL", "redis://cache:6379")
+	bundle.Config.Plugins[0].Config["redis_url"] = "${FERRO_TEST_REDIS_URL}"
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest, destRouter := bundleTestRouter(aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+	})
+	w = httptest.NewRecorder()
+	destRouter.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/config/import", string(body), createAdminKey(t, dest)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	got := dest.Configs.GetConfig().Plugins
+	if len(got) != 1 {
+		t.Fatalf("imported plugins = %+v", got)
+	}
+	words, _ := got[0].Config["blocked_words"].([]any)
+	if len(words) != 1 || words[0] != "secret-project" || got[0].Config["key_prefix"] != "wf:" {
+		t.Errorf("imported plugin config = %v, want the source's values verbatim", got[0].Config)
+	}
+}
+
+func TestIsBundleSecret(t *testing.T) {
+	for _, tc := range []struct {
+		path, value string
+		want        bool
+	}{
+		{"plugins[p].config.api_key", "sk-1", true},
+		{"plugins[p].config.dsn", "host=db", true},
+		{"plugins[p].config.auth_token", "t", true},
+		{"mcp_servers[gh].env.GITHUB_TOKEN", "ghp", true},
+		{"observability.tracing.headers.X-Api-Key", "k", true},
+		{"observability.tracing.headers.Authorization", "Bearer k", true},
+		{"plugins[p].config.webhook", "https://u:p@hooks.example.com", true},
+		{"plugins[p].config.blocked_words[0]", "password", false},
+		{"plugins[p].config.key_prefix", "wf:", false},
+		{"plugins[p].config.max_tokens", "100", false},
+		{"plugins[p].config.webhook", "https://hooks.example.com", false},
+	} {
+		if got := isBundleSecret(tc.path, tc.value); got != tc.want {
+			t.Errorf("isBundleSecret(%q, %q) = %v, want %v", tc.path, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestConfigImport_Rejects(t *testing.T) {
+	h, r := bundleTestRouter(aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+	})
+	adminKey := createAdminKey(t, h)
+	withPlugin := func(value string) string {
+		return `{"bundle_version":1,"config":{"strategy":{"mode":"single"},"targets":[{"virtual_key":"openai"}],` +
+			`"plugins":[{"name":"request-logger","type":"logging","stage":"after_request","config":{"api_key":"` + value + `"}}]}}`
+	}
+
+	for _, tc := range []struct {
+		name, url, body string
+		key             *APIKey
+		want            int
+	}{
+		{"redacted secret with no counterpart", "/admin/config/import", withPlugin(redactedPlaceholder), adminKey, http.StatusBadRequest},
+		{"unset environment variable", "/admin/config/import", withPlugin("${FERRO_TEST_UNSET_VAR}"), adminKey, http.StatusBadRequest},
+		{"unknown bundle version", "/admin/config/import", `{"bundle_version":2,"config":{}}`, adminKey, http.StatusBadRequest},
+		{"invalid config", "/admin/config/import", `{"bundle_version":1,"config":{"strategy":{"mode":"single"}}}`, adminKey, http.StatusBadRequest},
+		{"invalid dry_run", "/admin/config/import?dry_run=maybe", withPlugin("x"), adminKey, http.StatusBadRequest},
+		{"read-only key", "/admin/config/import", withPlugin("x"), createReadOnlyKey(t, h), http.StatusForbidden},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodPost, tc.url, tc.body, tc.key))
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if h.Configs.GetConfig().Plugins != nil {
+		t.Error("a rejected import changed the config")
+	}
+}
+
+// Scrubbing hides a literal secret replaced by another literal, so the diff
+// must still report it or an import would skip it as unchanged.
+func TestDiffConfigs_ReplacedSecret(t *testing.T) {
+	cfg := func(key string) aigateway.Config {
+		return aigateway.Config{Plugins: []aigateway.PluginConfig{{Name: "request-logger", Config: map[string]any{"api_key": key}}}}
+	}
+	changes := diffConfigs(cfg("sk-old"), cfg("sk-new"))
+	if len(changes) != 1 || changes[0].Path != "plugins[request-logger].config.api_key" || changes[0].To != redactedPlaceholder {
+		t.Fatalf("changes = %+v, want one redacted change to the api_key", changes)
+	}
+	if changes := diffConfigs(cfg("sk-old"), cfg("sk-old")); len(changes) != 0 {
+		t.Errorf("identical configs produced changes: %+v", changes)
+	}
+}
+
+// signedConfigManager is a testConfigManager that requires signed bundles.
+type signedConfigManager struct {
+	*testConfigManager
+	verifier *signing.Verifier
+}
+
+func (m signedConfigManager) BundleVerifier() *signing.Verifier { return m.verifier }
+
+// testBundleSigner returns a strict verifier trusting a fresh Ed25519 key and
+// a function signing with that key as cosign sign-blob does.
+func testBundleSigner(t *testing.T) (*signing.Verifier, func([]byte) string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := signing.NewVerifier(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, func(data []byte) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+	}
+}
+
+func TestConfigImport_Signature(t *testing.T) {
+	current := aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+	}
+	verifier, sign := testBundleSigner(t)
+	cm := signedConfigManager{testConfigManager: &testConfigManager{cfg: current, initial: current}, verifier: verifier}
+	h, r := setupTestRouterWithConfigManager(cm)
+	adminKey := createAdminKey(t, h)
+
+	next := current
+	next.Aliases = map[string]string{"fast": "gpt-4o-mini"}
+	bundle := string(gitOpsBundle(t, next))
+
+	for name, signature := range map[string]string{
+		"unsigned":     "",
+		"wrong bundle": sign([]byte(`{"bundle_version":1}`)),
+		"garbage":      "not-a-signature",
+	} {
+		req := authedRequest(http.MethodPost, "/admin/config/import", bundle, adminKey)
+		if signature != "" {
+			req.Header.Set(BundleSignatureHeader, signature)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid_signature") {
+			t.Errorf("%s: expected 400 invalid_signature, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+	if cm.cfg.Aliases != nil {
+		t.Fatal("a refused bundle changed the config")
+	}
+
+	req := authedRequest(http.MethodPost, "/admin/config/import", bundle, adminKey)
+	req.Header.Set(BundleSignatureHeader, sign([]byte(bundle)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("signed import: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cm.cfg.Aliases["fast"] != "gpt-4o-mini" {
+		t.Fatalf("signed import did not apply the bundle: %+v", cm.cfg)
+	}
+}
+
+// Under a strict verifier every config write is signed: whole configs carry
+// a signature, and edits with no document to sign are refused.
+func TestConfigWrites_StrictSignature(t *testing.T) {
+	current := aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+	}
+	verifier, sign := testBundleSigner(t)
+	cm := signedConfigManager{testConfigManager: &testConfigManager{cfg: current, initial: current}, verifier: verifier}
+	h, r := setupTestRouterWithConfigManager(cm)
+	adminKey := createAdminKey(t, h)
+
+	body := `{"strategy":{"mode":"single"},"targets":[{"virtual_key":"anthropic"}]}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/config", body, adminKey))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid_signature") {
+		t.Fatalf("unsigned PUT: expected 400 invalid_signature, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := authedRequest(http.MethodPut, "/admin/config", body, adminKey)
+	req.Header.Set(BundleSignatureHeader, sign([]byte(body)))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || cm.cfg.Targets[0].VirtualKey != "anthropic" {
+		t.Fatalf("signed PUT: expected 200 and the new config, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/admin/config/rollback/1", "/admin/plugins/word-filter/disable", "/admin/tiers"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodPost, path, `{"name":"gold"}`, adminKey))
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "config_requires_signature") {
+			t.Errorf("POST %s: expected 409 config_requires_signature, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+}
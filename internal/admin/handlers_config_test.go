@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
@@ -331,3 +332,70 @@ func TestReadOnlyCannotRollbackConfig(t *testing.T) {
 		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
 	}
 }
+
+// TestConfigHistorySurvivesRestart runs a SQLite-backed config manager, then
+// rebuilds it over the same database the way a restart would: the history is
+// still there and an older version can still be rolled back to.
+func TestConfigHistorySurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.db")
+	initial := aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+	}
+	start := func() (*Handlers, http.Handler) {
+		t.Helper()
+		store, err := NewSQLiteConfigStore(t.Context(), path)
+		if err != nil {
+			t.Fatalf("new config store: %v", err)
+		}
+		t.Cleanup(func() { _ = store.Close() })
+		gw, err := newTestGateway(t, initial)
+		if err != nil {
+			t.Fatalf("new gateway: %v", err)
+		}
+		mgr, err := NewGatewayConfigManager(gw, store)
+		if err != nil {
+			t.Fatalf("new config manager: %v", err)
+		}
+		return setupTestRouterWithConfigManager(mgr)
+	}
+
+	h, r := start()
+	adminKey := createAdminKey(t, h)
+	for _, body := range []string{fallbackConfigBody, `{"strategy":{"mode":"single"},"targets":[{"virtual_key":"gemini"}]}`} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/config", body, adminKey))
+		if w.Code != http.StatusOK {
+			t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	h, r = start()
+	adminKey = createAdminKey(t, h)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/config/history", "", adminKey))
+	var history struct {
+		Data []ConfigHistoryEntry `json:"data"`
+	}
+	decodeJSON(t, w.Body, &history)
+	if len(history.Data) != 2 || history.Data[0].AuthorKeyID == "" {
+		t.Fatalf("history after restart = %+v, want 2 versions with an author", history.Data)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/config/rollback/1", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("rollback: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if mode := h.Configs.GetConfig().Strategy.Mode; mode != aigateway.ModeFallback {
+		t.Fatalf("mode after rollback = %q, want fallback", mode)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/config/history", "", adminKey))
+	decodeJSON(t, w.Body, &history)
+	last := history.Data[len(history.Data)-1]
+	if len(history.Data) != 3 || last.RolledBackFrom == nil || *last.RolledBackFrom != 2 {
+		t.Fatalf("history after rollback = %+v, want version 3 rolled back from 2", history.Data)
+	}
+}
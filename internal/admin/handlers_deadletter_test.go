@@ -0,0 +1,252 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// outageRouter fails requests for the model "down" and echoes the rest.
+type outageRouter struct{}
+
+func (outageRouter) Route(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	if req.Model == "down" {
+		return nil, errors.New("provider unavailable")
+	}
+	return echoCompleter{}.Route(ctx, req)
+}
+
+func setupTestRouterWithDeadLetters(t *testing.T, models ...string) (*Handlers, http.Handler, *APIKey) {
+	t.Helper()
+	h, r := setupTestRouter()
+	h.Chat = outageRouter{}
+	h.DeadLetters = deadletter.NewQueue(deadletter.NewMemoryStore(deadletter.Retention{}))
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, model := range models {
+		if err := h.DeadLetters.Store().Add(t.Context(), deadletter.Entry{
+			ID:        "dl_" + model,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+			Model:     model,
+			Status:    deadletter.StatusPending,
+			Request: deadletter.Request{Request: providers.Request{
+				Model:    model,
+				Messages: []providers.Message{{Role: providers.RoleUser, Content: "hi"}},
+			}},
+		}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	return h, r, createAdminKey(t, h)
+}
+
+func TestDeadLetters_ListAndRedrive(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithDeadLetters(t, "good", "down")
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/dead-letters", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Data []deadletter.Entry `json:"data"`
+	}
+	decodeJSON(t, w.Body, &list)
+	if len(list.Data) != 2 || list.Data[0].ID != "dl_down" {
+		t.Fatalf("list should be newest first: %+v", list.Data)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/dl_good/redrive", "", readOnly))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("redrive with a read-only key: expected 403, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/dl_good/redrive", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("redrive: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result redriveResult
+	decodeJSON(t, w.Body, &result)
+	if result.Entry.Status != deadletter.StatusRedriven || result.Response == nil || result.Response.Choices[0].Message.Content != "good" {
+		t.Fatalf("unexpected redrive result: %+v", result)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/dl_good/redrive", "", adminKey))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second redrive: expected 409, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/dl_down/redrive", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("failing redrive: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var failed redriveResult
+	decodeJSON(t, w.Body, &failed)
+	if failed.Entry.Status != deadletter.StatusPending || failed.Entry.LastRedriveError == "" || failed.Response != nil {
+		t.Fatalf("failing redrive should keep the entry pending: %+v", failed)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/dead-letters?status=pending", "", readOnly))
+	decodeJSON(t, w.Body, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != "dl_down" {
+		t.Fatalf("pending list: %+v", list.Data)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/dead-letters?status=lost", "", readOnly))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid status: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/dead-letters/dl_down", "", adminKey))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/dead-letters/dl_down", "", readOnly))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("get deleted: expected 404, got %d", w.Code)
+	}
+}
+
+// keyRouter records the key each request was routed as.
+type keyRouter struct{ keys *[]string }
+
+func (k keyRouter) Route(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	keyID, _ := authctx.KeyID(ctx)
+	*k.keys = append(*k.keys, keyID)
+	return echoCompleter{}.Route(ctx, req)
+}
+
+// A re-drive is sent as the key that first sent the request, not as the
+// admin asking for it, and not at all once that key is revoked.
+func TestDeadLetters_RedriveRunsAsOriginalKey(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithDeadLetters(t)
+	var keys []string
+	h.Chat = keyRouter{keys: &keys}
+	owner := createReadOnlyKey(t, h)
+	for _, id := range []string{"dl_1", "dl_2"} {
+		if err := h.DeadLetters.Store().Add(t.Context(), deadletter.Entry{
+			ID: id, CreatedAt: time.Now().UTC(), KeyID: owner.ID, Model: "m", Status: deadletter.StatusPending,
+			Request: deadletter.Request{Request: providers.Request{Model: "m", Messages: []providers.Message{{Role: providers.RoleUser, Content: "hi"}}}},
+		}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/dl_1/redrive", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("redrive: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(keys) != 1 || keys[0] != owner.ID {
+		t.Fatalf("redrive ran as %v, want the entry's key %s", keys, owner.ID)
+	}
+
+	if err := h.Keys.Revoke(t.Context(), owner.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/dl_2/redrive", "", adminKey))
+	if w.Code != http.StatusConflict || len(keys) != 1 {
+		t.Fatalf("redrive for a revoked key: got %d with %d requests sent, want 409 and none", w.Code, len(keys)-1)
+	}
+}
+
+func TestDeadLetters_BulkRedrive(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithDeadLetters(t, "a", "down", "b")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/redrive?limit=2", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bulk redrive: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var payload struct {
+		Data    []deadletter.Entry `json:"data"`
+		Summary struct {
+			Redriven int `json:"redriven"`
+			Failed   int `json:"failed"`
+		} `json:"summary"`
+	}
+	decodeJSON(t, w.Body, &payload)
+	if len(payload.Data) != 2 || payload.Data[0].ID != "dl_a" || payload.Data[1].ID != "dl_down" {
+		t.Fatalf("bulk redrive should take the oldest pending entries first: %+v", payload.Data)
+	}
+	if payload.Summary.Redriven != 1 || payload.Summary.Failed != 1 {
+		t.Fatalf("unexpected summary: %+v", payload.Summary)
+	}
+	if e, _, _ := h.DeadLetters.Store().Get(t.Context(), "dl_b"); e.Redrives != 0 {
+		t.Fatalf("entry beyond the limit was re-driven: %+v", e)
+	}
+}
+
+func TestDeadLetters_NotEnabled(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/dead-letters", "", adminKey))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without a dead-letter store, got %d", w.Code)
+	}
+}
+
+func TestDeadLetters_SealedRequests(t *testing.T) {
+	payloads, err := requestlog.ParsePayloadKeys("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, r := setupTestRouter()
+	h.Chat = outageRouter{}
+	h.DeadLetters = deadletter.NewQueue(deadletter.NewMemoryStore(deadletter.Retention{}))
+	h.DeadLetters.SetPayloadCipher(payloads)
+	h.DeadLetters.WriteDeadLetter(t.Context(), aigateway.DeadLetter{
+		Request: providers.Request{Model: "good", Messages: []providers.Message{{Role: providers.RoleUser, Content: "secret prompt"}}},
+		Time:    time.Now().UTC(),
+	})
+	adminKey := createAdminKey(t, h)
+	decryptKey := createTestKey(t, h, "decrypt", []string{ScopeAdmin, ScopeLogsDecrypt}, nil)
+
+	list := func(key *APIKey) deadletter.Entry {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/dead-letters", "", key))
+		var body struct {
+			Data []deadletter.Entry `json:"data"`
+		}
+		decodeJSON(t, w.Body, &body)
+		if len(body.Data) != 1 {
+			t.Fatalf("list: %d entries, want 1", len(body.Data))
+		}
+		return body.Data[0]
+	}
+	if e := list(adminKey); !e.Sealed() || len(e.Request.Messages) != 0 {
+		t.Fatalf("admin key without logs_decrypt should get the request sealed: %+v", e)
+	}
+	e := list(decryptKey)
+	if e.Sealed() || e.Request.Messages[0].Content != "secret prompt" {
+		t.Fatalf("admin key with logs_decrypt should get the request opened: %+v", e)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/dead-letters/"+e.ID+"/redrive", "", adminKey))
+	var result redriveResult
+	decodeJSON(t, w.Body, &result)
+	if w.Code != http.StatusOK || result.Entry.Status != deadletter.StatusRedriven || result.Response != nil {
+		t.Fatalf("redrive without logs_decrypt should succeed without the response: %d %+v", w.Code, result)
+	}
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+)
+
+func TestSetRequestDefaults(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+	key := createTestKey(t, h, "app", []string{ScopeReadOnly}, nil)
+
+	for _, tt := range []struct {
+		path string
+		body string
+	}{
+		{"/admin/defaults/default", `{"max_tokens":2048}`},
+		{"/admin/defaults/workspaces/support", `{"system_prompt":"You are a support agent."}`},
+		{"/admin/defaults/keys/" + key.ID, `{"temperature":0.2}`},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodPut, tt.path, tt.body, adminKey))
+		if w.Code != http.StatusOK {
+			t.Fatalf("PUT %s: expected 200, got %d: %s", tt.path, w.Code, w.Body.String())
+		}
+	}
+	if history := h.getConfigHistorySnapshot(); len(history) != 3 {
+		t.Fatalf("each change should record a config history entry, got %d", len(history))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/defaults", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: expected 200, got %d", w.Code)
+	}
+	var got aigateway.RequestDefaultsConfig
+	decodeJSON(t, w.Body, &got)
+	if got.Default == nil || got.Default.MaxTokens != 2048 ||
+		got.Workspaces["support"].SystemPrompt != "You are a support agent." ||
+		got.Keys[key.ID].Temperature == nil || *got.Keys[key.ID].Temperature != 0.2 {
+		t.Fatalf("unexpected defaults: %+v", got)
+	}
+
+	for _, tt := range []struct {
+		name, path, body string
+		want             int
+	}{
+		{"unknown key", "/admin/defaults/keys/missing", `{"temperature":0.2}`, http.StatusNotFound},
+		{"unknown scope", "/admin/defaults/teams/a", `{"temperature":0.2}`, http.StatusNotFound},
+		{"out of range", "/admin/defaults/workspaces/support", `{"temperature":5}`, http.StatusBadRequest},
+		{"bad body", "/admin/defaults/default", `{`, http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodPut, tt.path, tt.body, adminKey))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestDeleteRequestDefaults(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+	cm := h.Configs.(*testConfigManager)
+	cm.cfg.RequestDefaults = &aigateway.RequestDefaultsConfig{
+		Default:    &aigateway.RequestParamDefaults{MaxTokens: 1024},
+		Workspaces: map[string]aigateway.RequestParamDefaults{"support": {SystemPrompt: "Be kind."}},
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/defaults/workspaces/support", "", adminKey))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/defaults/workspaces/support", "", adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("repeat delete: expected 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/defaults/default", "", adminKey))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if d := h.Configs.GetConfig().RequestDefaults; d != nil {
+		t.Fatalf("emptied defaults should leave the config section unset, got %+v", d)
+	}
+}
+
+func TestRequestDefaultsWritesRequireAdminScope(t *testing.T) {
+	h, r := setupTestRouter()
+	readOnly := createTestKey(t, h, "viewer", []string{ScopeReadOnly}, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/defaults/default", `{"max_tokens":10}`, readOnly))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/defaults", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("read-only GET: expected 200, got %d", w.Code)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -24,7 +25,7 @@ func (s *dbFailKeyStore) Delete(context.Context, string) error {
 	return fmt.Errorf("delete key: %w", errors.New("db connection lost"))
 }
 
-func (s *dbFailKeyStore) RotateKey(context.Context, string) (*APIKey, error) {
+func (s *dbFailKeyStore) RotateKey(context.Context, string, time.Duration) (*APIKey, error) {
 	return nil, fmt.Errorf("rotate key: %w", errors.New("db connection lost"))
 }
 
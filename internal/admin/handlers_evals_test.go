@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/evals"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// echoCompleter answers every request with the model's name, so an
+// exact-match suite expecting "good" passes only for the model called good.
+type echoCompleter struct{}
+
+func (echoCompleter) Route(_ context.Context, req providers.Request) (*providers.Response, error) {
+	return &providers.Response{
+		Model:   req.Model,
+		Choices: []providers.Choice{{Message: providers.Message{Role: providers.RoleAssistant, Content: req.Model}}},
+	}, nil
+}
+
+const evalSuiteBody = `{"name":"smoke","cases":[{"id":"c1","prompt":"Who are you?","expected":"good"}],"scoring":{"method":"exact_match"}}`
+
+func setupTestRouterWithEvals(t *testing.T) (*Handlers, http.Handler, *APIKey) {
+	t.Helper()
+	h, r := setupTestRouter()
+	h.Evals = evals.NewRunner(echoCompleter{}, nil, evals.NewMemoryStore(0))
+	return h, r, createAdminKey(t, h)
+}
+
+func TestEvalSuiteLifecycle(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithEvals(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/evals", evalSuiteBody, adminKey))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if suites := h.Configs.GetConfig().EvalSuites; len(suites) != 1 || suites[0].Name != "smoke" {
+		t.Fatalf("suite not added to the config: %+v", suites)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/evals", evalSuiteBody, adminKey))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("duplicate: expected 409, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/evals/smoke/run", `{"models":["good","bad"]}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("run: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var runPayload struct {
+		Data []evals.Run `json:"data"`
+	}
+	decodeJSON(t, w.Body, &runPayload)
+	if len(runPayload.Data) != 2 || runPayload.Data[0].Score != 1 || runPayload.Data[1].Score != 0 {
+		t.Fatalf("unexpected runs: %+v", runPayload.Data)
+	}
+
+	readOnly := createReadOnlyKey(t, h)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/evals/smoke", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var suite struct {
+		Name   string      `json:"name"`
+		Latest []evals.Run `json:"latest"`
+	}
+	decodeJSON(t, w.Body, &suite)
+	if suite.Name != "smoke" || len(suite.Latest) != 2 {
+		t.Fatalf("suite should report the latest run per model: %+v", suite)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/evals/smoke/runs?model=bad", "", readOnly))
+	var runs struct {
+		Data []evals.Run `json:"data"`
+	}
+	decodeJSON(t, w.Body, &runs)
+	if len(runs.Data) != 1 || runs.Data[0].Model != "bad" || runs.Data[0].Cases != nil {
+		t.Fatalf("runs should be filtered summaries: %+v", runs.Data)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/evals/smoke/runs/"+runs.Data[0].ID, "", readOnly))
+	var run evals.Run
+	decodeJSON(t, w.Body, &run)
+	if w.Code != http.StatusOK || len(run.Cases) != 1 || run.Cases[0].Output != "bad" {
+		t.Fatalf("run detail: %d %+v", w.Code, run)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/evals/smoke", "", adminKey))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	if len(h.Configs.GetConfig().EvalSuites) != 0 {
+		t.Fatal("suite still in the config")
+	}
+	if history := h.getConfigHistorySnapshot(); len(history) != 2 {
+		t.Fatalf("each suite change should record a config history entry, got %d", len(history))
+	}
+}
+
+func TestEvalSuiteErrors(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithEvals(t)
+	h.Configs.(*testConfigManager).cfg.EvalSuites = []aigateway.EvalSuite{{
+		Name:    "smoke",
+		Cases:   []aigateway.EvalCase{{Prompt: "hi"}},
+		Scoring: aigateway.EvalScoring{Method: aigateway.EvalScoringExactMatch},
+	}}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"unknown scoring", http.MethodPost, "/admin/evals", `{"name":"x","cases":[{"prompt":"hi"}],"scoring":{"method":"vibes"}}`, http.StatusBadRequest},
+		{"judge without model", http.MethodPost, "/admin/evals", `{"name":"x","cases":[{"prompt":"hi"}],"scoring":{"method":"llm_judge"}}`, http.StatusBadRequest},
+		{"no cases", http.MethodPost, "/admin/evals", `{"name":"x","scoring":{"method":"exact_match"}}`, http.StatusBadRequest},
+		{"rename", http.MethodPut, "/admin/evals/smoke", `{"name":"other","cases":[{"prompt":"hi"}],"scoring":{"method":"exact_match"}}`, http.StatusBadRequest},
+		{"run without models", http.MethodPost, "/admin/evals/smoke/run", `{"models":[]}`, http.StatusBadRequest},
+		{"run unknown suite", http.MethodPost, "/admin/evals/missing/run", `{"models":["m"]}`, http.StatusNotFound},
+		{"unknown run", http.MethodGet, "/admin/evals/smoke/runs/evr_missing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authedRequest(tt.method, tt.path, tt.body, adminKey))
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	readOnly := createReadOnlyKey(t, h)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/evals/smoke/run", `{"models":["m"]}`, readOnly))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("read-only run: expected 403, got %d", w.Code)
+	}
+
+	h.Evals = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/evals/smoke/run", `{"models":["m"]}`, adminKey))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("no runner: expected 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/experiments"
+)
+
+func TestExperimentReport(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+	exp := aigateway.Experiment{ID: "gpt41", Primary: "gpt-4o", Candidate: "gpt-4.1"}
+	cfg := h.Configs.GetConfig()
+	cfg.Experiments = []aigateway.Experiment{exp}
+	if err := h.Configs.ReloadConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/experiments/gpt41/report", "", adminKey))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("without a recorder: expected 501, got %d", w.Code)
+	}
+
+	h.Experiments = experiments.NewRecorder(nil)
+	h.Experiments.Observe(context.Background(), aigateway.ExperimentSample{
+		Experiment:      exp,
+		Shadowed:        "gpt-4.1",
+		PrimaryOutput:   "four",
+		CandidateOutput: "four!",
+		ShadowLatency:   time.Millisecond,
+	})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/experiments/gpt41/report", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("report: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report experiments.Report
+	decodeJSON(t, w.Body, &report)
+	if report.Samples != 1 || report.Candidate != "gpt-4.1" {
+		t.Fatalf("report = %+v, want one sample for gpt-4.1", report)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/experiments/missing/report", "", adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unknown experiment: expected 404, got %d", w.Code)
+	}
+}
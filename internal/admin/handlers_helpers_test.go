@@ -40,6 +40,9 @@ func (f *fakeLogReader) Stats(_ context.Context, _ requestlog.Query) (requestlog
 func (f *fakeLogReader) List(_ context.Context, query requestlog.Query) (requestlog.ListResult, error) {
 	filtered := make([]requestlog.Entry, 0)
 	for _, entry := range f.entries {
+		if query.TraceID != "" && entry.TraceID != query.TraceID {
+			continue
+		}
 		if query.Stage != "" && entry.Stage != query.Stage {
 			continue
 		}
@@ -52,6 +55,9 @@ func (f *fakeLogReader) List(_ context.Context, query requestlog.Query) (request
 		if query.Since != nil && entry.CreatedAt.Before(*query.Since) {
 			continue
 		}
+		if query.Until != nil && entry.CreatedAt.After(*query.Until) {
+			continue
+		}
 		filtered = append(filtered, entry)
 	}
 
@@ -89,14 +95,22 @@ func (f *fakeLogStore) Stats(_ context.Context, _ requestlog.Query) (requestlog.
 }
 
 func (f *fakeLogStore) Delete(_ context.Context, query requestlog.MaintenanceQuery) (int, error) {
-	if query.Before == nil {
+	if query.Before == nil && query.User == "" && query.KeyID == "" {
 		return 0, nil
 	}
 
 	remaining := make([]requestlog.Entry, 0, len(f.entries))
 	deleted := 0
 	for _, entry := range f.entries {
-		if !entry.CreatedAt.Before(*query.Before) {
+		if query.Before != nil && !entry.CreatedAt.Before(*query.Before) {
+			remaining = append(remaining, entry)
+			continue
+		}
+		if query.User != "" && entry.User != query.User {
+			remaining = append(remaining, entry)
+			continue
+		}
+		if query.KeyID != "" && entry.KeyID != query.KeyID {
 			remaining = append(remaining, entry)
 			continue
 		}
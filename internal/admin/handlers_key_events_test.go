@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+)
+
+func TestKeyEvents(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+	var got []string
+	h.KeyEvents = func(_ context.Context, subject string, key *APIKey) {
+		got = append(got, subject+" "+key.Name)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/keys", `{"name":"ci"}`, adminKey))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created APIKey
+	decodeJSON(t, w.Body, &created)
+
+	for _, step := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/admin/keys/" + created.ID + "/rotate", http.StatusOK},
+		{http.MethodPost, "/admin/keys/" + created.ID + "/revoke", http.StatusOK},
+		{http.MethodDelete, "/admin/keys/" + created.ID, http.StatusNoContent},
+		// A failed change publishes nothing.
+		{http.MethodPost, "/admin/keys/missing/revoke", http.StatusNotFound},
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(step.method, step.path, "", adminKey))
+		if w.Code != step.want {
+			t.Fatalf("%s %s: expected %d, got %d: %s", step.method, step.path, step.want, w.Code, w.Body.String())
+		}
+	}
+
+	want := []string{
+		aigateway.SubjectKeyCreated + " ci",
+		aigateway.SubjectKeyRotated + " ci",
+		aigateway.SubjectKeyRevoked + " ci",
+		aigateway.SubjectKeyDeleted + " ci",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v\nwant %v", got, want)
+	}
+}
@@ -1,13 +1,16 @@
 package admin
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/cache"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/providers"
 )
 
 func TestLogsEndpointNotEnabled(t *testing.T) {
@@ -310,3 +313,86 @@ func TestDeleteLogsEndpointNotEnabled(t *testing.T) {
 		t.Fatalf("expected 501, got %d", w.Code)
 	}
 }
+
+func TestDeleteLogsByUserEndpoint(t *testing.T) {
+	now := time.Now().UTC()
+	store := &fakeLogStore{entries: []requestlog.Entry{
+		{TraceID: "1", Stage: "before_request", User: "alice", KeyID: "key-a", CreatedAt: now},
+		{TraceID: "1", Stage: "after_request", User: "alice", KeyID: "key-a", CreatedAt: now},
+		{TraceID: "2", Stage: "after_request", User: "bob", KeyID: "key-a", CreatedAt: now},
+	}}
+	h, r := setupTestRouterWithLogs(store)
+	mem := cache.NewMemory(10, time.Minute)
+	mem.SetInFor("key-a", "k1", "alice", &providers.Response{Model: "gpt-4o"})
+	mem.SetInFor("key-a", "k2", "bob", &providers.Response{Model: "gpt-4o"})
+	h.Plugins = fakePluginSource{fakeCachePlugin{mem}}
+	h.Erasers = map[string]UserEraser{
+		"idempotency_keys": UserEraserFunc(func(context.Context, string, string) (int, error) { return 3, nil }),
+		"threads": UserEraserFunc(func(_ context.Context, user, _ string) (int, error) {
+			if user != "" {
+				return 0, ErrEraseUnsupported
+			}
+			return 2, nil
+		}),
+	}
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/logs/by-user?user=alice", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var payload struct {
+		Deleted struct {
+			RequestLogs     int `json:"request_logs"`
+			CacheEntries    int `json:"cache_entries"`
+			IdempotencyKeys int `json:"idempotency_keys"`
+			Threads         int `json:"threads"`
+		} `json:"deleted"`
+		NotErased []string `json:"not_erased"`
+	}
+	decodeJSON(t, w.Body, &payload)
+	if payload.Deleted.RequestLogs != 2 || payload.Deleted.CacheEntries != 1 || payload.Deleted.IdempotencyKeys != 3 {
+		t.Fatalf("unexpected counts: %+v", payload.Deleted)
+	}
+	if len(payload.NotErased) != 1 || payload.NotErased[0] != "threads" {
+		t.Fatalf("threads cannot be erased by user and should be reported: %v", payload.NotErased)
+	}
+	if len(store.entries) != 1 || store.entries[0].User != "bob" || mem.Len() != 1 {
+		t.Fatalf("only bob's data should remain: logs=%+v cache=%d", store.entries, mem.Len())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/logs/by-user?key_id=key-a", "", adminKey))
+	payload.NotErased = nil
+	decodeJSON(t, w.Body, &payload)
+	if payload.Deleted.RequestLogs != 1 || payload.Deleted.CacheEntries != 1 || payload.Deleted.Threads != 2 || len(payload.NotErased) != 0 {
+		t.Fatalf("unexpected counts deleting by key: %+v, not erased %v", payload.Deleted, payload.NotErased)
+	}
+}
+
+func TestDeleteLogsByUserEndpointValidation(t *testing.T) {
+	h, r := setupTestRouterWithLogs(&fakeLogStore{})
+	adminKey := createAdminKey(t, h)
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/logs/by-user", "", adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("missing user: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/logs/by-user?user=alice", "", readOnly))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("read-only key: expected 403, got %d", w.Code)
+	}
+
+	h, r = setupTestRouter()
+	adminKey = createAdminKey(t, h)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/logs/by-user?user=alice", "", adminKey))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("no stores: expected 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// recordingRouter answers every request with "hello there" and keeps the
+// requests it routed.
+type recordingRouter struct {
+	reqs []providers.Request
+	keys []string
+}
+
+func (c *recordingRouter) Route(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	c.reqs = append(c.reqs, req)
+	keyID, _ := authctx.KeyID(ctx)
+	c.keys = append(c.keys, keyID)
+	return &providers.Response{
+		ID:       "chatcmpl-replay",
+		Model:    req.Model,
+		Provider: "anthropic",
+		Choices:  []providers.Choice{{Message: providers.Message{Role: providers.RoleAssistant, Content: "hello there"}, FinishReason: "stop"}},
+		Usage:    providers.Usage{PromptTokens: 12, CompletionTokens: 2, TotalTokens: 14},
+	}, nil
+}
+
+func TestReplayLog(t *testing.T) {
+	now := time.Now().UTC()
+	reader := &fakeLogReader{entries: []requestlog.Entry{
+		{TraceID: "t1", Stage: "after_request", Model: "gpt-4o", Provider: "openai", PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15,
+			Response: json.RawMessage(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`), CreatedAt: now},
+		{TraceID: "t1", Stage: "before_request", Model: "gpt-4o",
+			Request: json.RawMessage(`{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hello"}]}`), CreatedAt: now.Add(-time.Second)},
+		{TraceID: "t2", Stage: "before_request", Model: "gpt-4o", CreatedAt: now},
+	}}
+	h, r := setupTestRouterWithLogs(reader)
+	chat := &recordingRouter{}
+	h.Chat = chat
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/logs/t1/replay", `{"model":"claude-3-5-sonnet","provider":"anthropic"}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got replayLogResult
+	decodeJSON(t, w.Body, &got)
+	if len(chat.reqs) != 1 || chat.reqs[0].Model != "claude-3-5-sonnet" || chat.reqs[0].Stream || chat.reqs[0].Messages[0].Content != "hello" {
+		t.Fatalf("replayed request should be the recorded one, non-streaming, with the new model: %+v", chat.reqs)
+	}
+	if got.Response == nil || got.Response.ID != "chatcmpl-replay" {
+		t.Fatalf("new response missing: %+v", got)
+	}
+	if got.Original.Content != "hi" || got.Original.Provider != "openai" || got.Replay.Content != "hello there" {
+		t.Fatalf("unexpected outcomes: original=%+v replay=%+v", got.Original, got.Replay)
+	}
+	changed := map[string]bool{}
+	for _, c := range got.Diff.Changes {
+		changed[c.Field] = true
+	}
+	if got.Diff.Identical || !changed["model"] || !changed["provider"] || !changed["content"] || !changed["completion_tokens"] || changed["prompt_tokens"] || changed["finish_reason"] {
+		t.Fatalf("unexpected diff: %+v", got.Diff)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/logs/t2/replay", "", adminKey))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("trace without a request body: expected 422, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/logs/missing/replay", "", adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unknown trace: expected 404, got %d", w.Code)
+	}
+
+	readOnly := createReadOnlyKey(t, h)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/logs/t1/replay", "", readOnly))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("read-only key: expected 403, got %d", w.Code)
+	}
+}
+
+// A replay is the recorded request in full, sent as the key that sent it.
+func TestReplayLog_AsRecorded(t *testing.T) {
+	reader := &fakeLogReader{}
+	h, r := setupTestRouterWithLogs(reader)
+	chat := &recordingRouter{}
+	h.Chat = chat
+	owner := createReadOnlyKey(t, h)
+	reader.entries = []requestlog.Entry{{
+		TraceID: "t1", Stage: "before_request", Model: "claude-3-7-sonnet", KeyID: owner.ID, CreatedAt: time.Now().UTC(),
+		Request: json.RawMessage(`{"model":"claude-3-7-sonnet","messages":[{"role":"user","content":"hello"}],` +
+			`"thinking":{"type":"enabled","budget_tokens":2048},"extra":{"top_a":0.5},"fallback_models":["gpt-4o"]}`),
+	}}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/logs/t1/replay", "", createAdminKey(t, h)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	req := chat.reqs[0]
+	if req.Thinking == nil || req.Thinking.BudgetTokens != 2048 || string(req.Extra["top_a"]) != "0.5" || len(req.FallbackModels) != 1 {
+		t.Fatalf("replay dropped recorded fields: thinking=%+v extra=%v fallbacks=%v", req.Thinking, req.Extra, req.FallbackModels)
+	}
+	if chat.keys[0] != owner.ID {
+		t.Fatalf("replay ran as %q, want the recorded key %s", chat.keys[0], owner.ID)
+	}
+}
+
+func TestReplayLog_EncryptedRequiresScope(t *testing.T) {
+	cipher, err := requestlog.ParsePayloadKeys("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := cipher.Seal(json.RawMessage(`{"model":"gpt-4o","messages":[{"role":"user","content":"secret prompt"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := sealingLogReader{
+		fakeLogReader: &fakeLogReader{entries: []requestlog.Entry{
+			{TraceID: "t1", Stage: "before_request", Request: sealed, CreatedAt: time.Now().UTC()},
+		}},
+		cipher: cipher,
+	}
+	h, r := setupTestRouterWithLogs(reader)
+	chat := &recordingRouter{}
+	h.Chat = chat
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/logs/t1/replay", "", createAdminKey(t, h)))
+	if w.Code != http.StatusForbidden || len(chat.reqs) != 0 {
+		t.Fatalf("admin key without logs_decrypt: expected 403 and no request, got %d", w.Code)
+	}
+
+	key := createTestKey(t, h, "decrypt", []string{ScopeAdmin, ScopeLogsDecrypt}, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/logs/t1/replay", "", key))
+	if w.Code != http.StatusOK || len(chat.reqs) != 1 || chat.reqs[0].Messages[0].Content != "secret prompt" {
+		t.Fatalf("admin key with logs_decrypt: expected the request replayed, got %d: %s", w.Code, w.Body.String())
+	}
+}
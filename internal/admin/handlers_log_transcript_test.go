@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+)
+
+func TestLogTranscript(t *testing.T) {
+	now := time.Now().UTC()
+	reader := &fakeLogReader{entries: []requestlog.Entry{
+		{TraceID: "t1", Stage: "after_request", Model: "gpt-4o", Provider: "openai", PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15, CostUSD: 0.01,
+			Response: json.RawMessage(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`), PluginDecisions: json.RawMessage(`[{"plugin":"word-filter","stage":"before_request","outcome":"ok"},{"plugin":"request-logger","stage":"before_request","outcome":"ok"}]`), CreatedAt: now},
+		{TraceID: "t1", Stage: "before_request", Model: "gpt-4o",
+			Request: json.RawMessage(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`), PluginDecisions: json.RawMessage(`[{"plugin":"word-filter","stage":"before_request","outcome":"ok"}]`), CreatedAt: now.Add(-time.Second)},
+		{TraceID: "t2", Stage: "before_request", Model: "gpt-4o", CreatedAt: now},
+	}}
+	h, r := setupTestRouterWithLogs(reader)
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/logs/t1", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got logTranscript
+	decodeJSON(t, w.Body, &got)
+	if got.Provider != "openai" || got.TotalTokens != 15 || got.CostUSD != 0.01 {
+		t.Fatalf("unexpected transcript summary: %+v", got)
+	}
+	if !strings.Contains(string(got.Request), `"hello"`) || !strings.Contains(string(got.Response), `"hi"`) {
+		t.Fatalf("transcript missing bodies: request=%s response=%s", got.Request, got.Response)
+	}
+	if !strings.Contains(string(got.PluginDecisions), "request-logger") {
+		t.Fatalf("transcript should carry the latest, complete decision list: %s", got.PluginDecisions)
+	}
+	if len(got.Entries) != 2 || got.Entries[0].Stage != "before_request" || !got.StartedAt.Before(got.CompletedAt) {
+		t.Fatalf("entries should be oldest first: %+v", got.Entries)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/logs/missing", "", readOnly))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unknown trace: expected 404, got %d", w.Code)
+	}
+}
+
+func exportTestReader(n int) *fakeLogReader {
+	now := time.Now().UTC().Add(-time.Hour)
+	reader := &fakeLogReader{}
+	for i := range n {
+		reader.entries = append(reader.entries, requestlog.Entry{
+			TraceID:   fmt.Sprintf("t%d", i),
+			Stage:     "after_request",
+			Model:     "gpt-4o",
+			Provider:  "openai",
+			Response:  json.RawMessage(`{"id":"r"}`),
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return reader
+}
+
+func TestExportLogsJSONL(t *testing.T) {
+	// More entries than one store page, so the export has to page.
+	h, r := setupTestRouterWithLogs(exportTestReader(maxLogsLimit + 5))
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/logs/export", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	lines := 0
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(w.Body)
+	for sc.Scan() {
+		var e requestlog.Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %d is not an entry: %v", lines, err)
+		}
+		seen[e.TraceID] = true
+		lines++
+	}
+	if lines != maxLogsLimit+5 || len(seen) != lines {
+		t.Fatalf("exported %d lines (%d distinct), want %d", lines, len(seen), maxLogsLimit+5)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/logs/export?limit=3", "", adminKey))
+	if n := strings.Count(w.Body.String(), "\n"); n != 3 {
+		t.Fatalf("limit=3 exported %d lines", n)
+	}
+}
+
+func TestExportLogsCSV(t *testing.T) {
+	h, r := setupTestRouterWithLogs(exportTestReader(2))
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/logs/export?format=csv&trace_id=t1", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != 2 || records[0][0] != "trace_id" || records[1][0] != "t1" {
+		t.Fatalf("unexpected csv: %v", records)
+	}
+	if got := records[1][slices.Index(logExportColumns, "response")]; got != `{"id":"r"}` {
+		t.Fatalf("response cell = %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/logs/export?format=xml", "", adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown format: expected 400, got %d", w.Code)
+	}
+}
+
+// sealingLogReader serves entries sealed by cipher and opens them, as an
+// encrypting store does.
+type sealingLogReader struct {
+	*fakeLogReader
+	cipher *requestlog.PayloadCipher
+}
+
+func (s sealingLogReader) OpenPayloads(e requestlog.Entry) (requestlog.Entry, error) {
+	var err error
+	if e.Request, err = s.cipher.Open(e.Request); err != nil {
+		return e, err
+	}
+	e.Response, err = s.cipher.Open(e.Response)
+	return e, err
+}
+
+func TestLogTranscript_DecryptRequiresScope(t *testing.T) {
+	cipher, err := requestlog.ParsePayloadKeys("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := cipher.Seal(json.RawMessage(`{"messages":[{"role":"user","content":"secret prompt"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := sealingLogReader{
+		fakeLogReader: &fakeLogReader{entries: []requestlog.Entry{
+			{TraceID: "t1", Stage: "before_request", Request: sealed, CreatedAt: time.Now().UTC()},
+		}},
+		cipher: cipher,
+	}
+	h, r := setupTestRouterWithLogs(reader)
+
+	for _, tc := range []struct {
+		name      string
+		scopes    []string
+		plaintext bool
+	}{
+		{"admin", []string{ScopeAdmin}, false},
+		{"read_only", []string{ScopeReadOnly}, false},
+		{"read_only+logs_decrypt", []string{ScopeReadOnly, ScopeLogsDecrypt}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			key := createTestKey(t, h, tc.name, tc.scopes, nil)
+			for _, path := range []string{"/admin/logs/t1", "/admin/logs", "/admin/logs/export"} {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, authedRequest(http.MethodGet, path, "", key))
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+				}
+				if got := strings.Contains(w.Body.String(), "secret prompt"); got != tc.plaintext {
+					t.Fatalf("%s: plaintext visible = %v, want %v: %s", path, got, tc.plaintext, w.Body.String())
+				}
+			}
+		})
+	}
+}
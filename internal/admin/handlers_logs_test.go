@@ -1,9 +1,11 @@
 package admin
 
 import (
+	"bufio"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -104,3 +106,59 @@ func TestLogsEndpointUsesSnakeCaseFields(t *testing.T) {
 		}
 	}
 }
+
+// tailingLogReader is a log reader that also supports live tailing.
+type tailingLogReader struct {
+	fakeLogReader
+	requestlog.Feed
+}
+
+func TestLogsStream(t *testing.T) {
+	reader := &tailingLogReader{}
+	h, r := setupTestRouterWithLogs(reader)
+	readOnly := createReadOnlyKey(t, h)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+"/admin/logs/stream?model=gpt-4o", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+readOnly.Key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("expected the connected comment first, got %q", lines.Text())
+	}
+	reader.Publish(requestlog.Entry{TraceID: "skip", Model: "claude"})
+	reader.Publish(requestlog.Entry{TraceID: "t1", Stage: "after_request", Model: "gpt-4o"})
+
+	var data string
+	for lines.Scan() {
+		if d, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			data = d
+			break
+		}
+	}
+	var entry requestlog.Entry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil || entry.TraceID != "t1" {
+		t.Fatalf("expected the matching entry, got %q (%v)", data, err)
+	}
+}
+
+func TestLogsStream_NotSupported(t *testing.T) {
+	h, r := setupTestRouterWithLogs(&fakeLogReader{})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/logs/stream", "", createAdminKey(t, h)))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 for a store without tailing, got %d", w.Code)
+	}
+}
@@ -0,0 +1,249 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/plugin"
+)
+
+// unconfiguredPlugin is registered but never appears in a test config, so the
+// plugin list must report it as available rather than configured.
+const unconfiguredPlugin = "admin-test-unconfigured"
+
+func init() {
+	plugin.RegisterFactory(unconfiguredPlugin, func() plugin.Plugin { return namedPlugin(unconfiguredPlugin) })
+}
+
+type namedPlugin string
+
+func (p namedPlugin) Name() string                                 { return string(p) }
+func (namedPlugin) Type() plugin.PluginType                        { return plugin.TypeGuardrail }
+func (namedPlugin) Init(map[string]any) error                      { return nil }
+func (namedPlugin) Execute(context.Context, *plugin.Context) error { return nil }
+func (namedPlugin) Close() error                                   { return nil }
+
+type pluginListEntry struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Stage      string         `json:"stage"`
+	Enabled    bool           `json:"enabled"`
+	Loaded     bool           `json:"loaded"`
+	Configured bool           `json:"configured"`
+	Config     map[string]any `json:"config"`
+	Match      *plugin.Match  `json:"match"`
+}
+
+func setupTestRouterWithPlugins(t *testing.T) (*Handlers, http.Handler, *APIKey) {
+	t.Helper()
+	h, r := setupTestRouter()
+	cm := h.Configs.(*testConfigManager)
+	cm.cfg.Plugins = []aigateway.PluginConfig{
+		{Name: "word-filter", Type: "guardrail", Stage: "before_request", Enabled: true, Config: map[string]any{"blocked_words": []any{"secret"}}, Match: &plugin.Match{Models: []string{"gpt-4*"}}},
+		{Name: "response-cache", Type: "transform", Stage: "before_request", Enabled: true},
+		{Name: "response-cache", Type: "transform", Stage: "after_request", Enabled: true},
+		{Name: "request-logger", Type: "logging", Stage: "after_request", Enabled: false, Config: map[string]any{"api_key": "sk-live-123"}},
+	}
+	h.Plugins = fakePluginSource{namedPlugin("word-filter"), namedPlugin("response-cache")}
+	return h, r, createAdminKey(t, h)
+}
+
+func TestListPlugins(t *testing.T) {
+	h, r, _ := setupTestRouterWithPlugins(t)
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/plugins", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var list []pluginListEntry
+	decodeJSON(t, w.Body, &list)
+	if len(list) < 5 {
+		t.Fatalf("expected 4 configured entries and the registered ones, got %+v", list)
+	}
+	if list[0].Name != "word-filter" || !list[0].Loaded || !list[0].Configured || list[0].Stage != "before_request" {
+		t.Fatalf("unexpected first entry: %+v", list[0])
+	}
+	if list[0].Match == nil || len(list[0].Match.Models) != 1 || list[1].Match != nil {
+		t.Fatalf("match rules not reported: %+v, %+v", list[0].Match, list[1].Match)
+	}
+	if list[2].Name != "response-cache" || list[2].Stage != "after_request" {
+		t.Fatalf("each configured entry should be listed, got %+v", list[2])
+	}
+	logger := list[3]
+	if logger.Enabled || logger.Loaded {
+		t.Fatalf("disabled plugin reported as running: %+v", logger)
+	}
+	if logger.Config["api_key"] == "sk-live-123" {
+		t.Fatal("plugin config secrets must be scrubbed")
+	}
+
+	var found bool
+	for _, p := range list[4:] {
+		if p.Name == unconfiguredPlugin {
+			found = !p.Configured && !p.Enabled && !p.Loaded
+		}
+	}
+	if !found {
+		t.Fatalf("registered plugin without config missing or misreported: %+v", list[4:])
+	}
+}
+
+func TestDisableAndEnablePlugin(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithPlugins(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/plugins/word-filter/disable", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("disable: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var payload struct {
+		Data []pluginListEntry `json:"data"`
+	}
+	decodeJSON(t, w.Body, &payload)
+	if len(payload.Data) != 1 || payload.Data[0].Enabled || payload.Data[0].Loaded {
+		t.Fatalf("unexpected disable response: %+v", payload.Data)
+	}
+	if h.Configs.GetConfig().Plugins[0].Enabled {
+		t.Fatal("plugin still enabled in the active config")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/plugins/request-logger/enable", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("enable: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !h.Configs.GetConfig().Plugins[3].Enabled {
+		t.Fatal("plugin not enabled in the active config")
+	}
+	if history := h.getConfigHistorySnapshot(); len(history) != 2 {
+		t.Fatalf("each plugin change should record a config history entry, got %d", len(history))
+	}
+}
+
+func TestDisablePluginByStage(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithPlugins(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/plugins/response-cache/disable?stage=after_request", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	plugins := h.Configs.GetConfig().Plugins
+	if !plugins[1].Enabled || plugins[2].Enabled {
+		t.Fatalf("only the after_request entry should be disabled: %+v", plugins[1:3])
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/plugins/response-cache/disable", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	plugins = h.Configs.GetConfig().Plugins
+	if plugins[1].Enabled || plugins[2].Enabled {
+		t.Fatal("without a stage every entry for the name should be disabled")
+	}
+}
+
+func TestUpdatePlugin(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithPlugins(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/plugins/word-filter", `{"config":{"blocked_words":["password"],"case_sensitive":true}}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	cfg := h.Configs.GetConfig().Plugins[0]
+	if cfg.Config["case_sensitive"] != true || !cfg.Enabled {
+		t.Fatalf("plugin config not replaced: %+v", cfg)
+	}
+
+	// An empty body re-initializes the plugin with its current config.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/plugins/word-filter", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("re-init: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := h.Configs.GetConfig().Plugins[0].Config["case_sensitive"]; got != true {
+		t.Fatalf("re-init changed the config: case_sensitive = %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/plugins/word-filter", `{"enabled":"yes"}`, adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: expected 400, got %d", w.Code)
+	}
+}
+
+// A config read from GET and sent back unchanged keeps its secrets.
+func TestUpdatePlugin_RedactedRoundTrip(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithPlugins(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/plugins", "", adminKey))
+	var listed []pluginListEntry
+	decodeJSON(t, w.Body, &listed)
+	var cfg map[string]any
+	for _, p := range listed {
+		if p.Name == "request-logger" {
+			cfg = p.Config
+		}
+	}
+	if cfg["api_key"] != redactedPlaceholder {
+		t.Fatalf("listed config = %v, want the api_key redacted", cfg)
+	}
+	cfg["level"] = "debug"
+	body, _ := json.Marshal(map[string]any{"config": cfg})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/plugins/request-logger", string(body), adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	got := h.Configs.GetConfig().Plugins[3].Config
+	if got["api_key"] != "sk-live-123" || got["level"] != "debug" {
+		t.Fatalf("stored config = %v, want the secret kept and level set", got)
+	}
+
+	// A placeholder with nothing behind it is refused, not stored.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/plugins/request-logger", `{"config":{"token":"[REDACTED]"}}`, adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown redacted value: expected 400, got %d", w.Code)
+	}
+	if _, ok := h.Configs.GetConfig().Plugins[3].Config["token"]; ok {
+		t.Fatal("a rejected update changed the config")
+	}
+}
+
+func TestPluginMutationErrors(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithPlugins(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/plugins/"+unconfiguredPlugin+"/enable", "", adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unconfigured plugin: expected 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/plugins/word-filter/disable?stage=after_request", "", adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("stage with no entry: expected 404, got %d", w.Code)
+	}
+
+	readOnly := createReadOnlyKey(t, h)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/plugins/word-filter/disable", "", readOnly))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("read-only disable: expected 403, got %d", w.Code)
+	}
+	if !h.Configs.GetConfig().Plugins[0].Enabled {
+		t.Fatal("rejected request changed the config")
+	}
+}
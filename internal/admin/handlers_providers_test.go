@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
 )
 
 func TestListProviders_NilRegistry(t *testing.T) {
@@ -27,3 +29,77 @@ func TestListProviders_NilRegistry(t *testing.T) {
 		t.Errorf("expected empty providers list, got %d", len(result))
 	}
 }
+
+// fakeTargetStates is a TargetStateSource over a fixed set of targets.
+type fakeTargetStates map[string]string
+
+func (f fakeTargetStates) SetTargetState(name, state string) error {
+	if _, ok := f[name]; !ok {
+		return aigateway.ErrUnknownTarget
+	}
+	f[name] = state
+	return nil
+}
+
+func (f fakeTargetStates) TargetState(name string) (string, bool) {
+	state, ok := f[name]
+	return state, ok
+}
+
+func TestProviderMaintenance(t *testing.T) {
+	h, r := setupTestRouter()
+	states := fakeTargetStates{"openai": ""}
+	h.Targets = states
+	adminKey := createAdminKey(t, h)
+
+	for _, step := range []struct {
+		action, want string
+	}{
+		{"drain", "draining"},
+		{"disable", "disabled"},
+		{"enable", "active"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/providers/openai/"+step.action, "", adminKey))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", step.action, w.Code, w.Body.String())
+		}
+		var info targetStateInfo
+		decodeJSON(t, w.Body, &info)
+		if info.Name != "openai" || info.State != step.want {
+			t.Fatalf("%s: got %+v, want state %q", step.action, info, step.want)
+		}
+		if got := targetStateName(states["openai"]); got != step.want {
+			t.Fatalf("%s: target state = %q, want %q", step.action, got, step.want)
+		}
+	}
+	// Maintenance does not touch the config.
+	if n := len(h.configHistory); n != 0 {
+		t.Errorf("expected no config history entries, got %d", n)
+	}
+}
+
+func TestProviderMaintenance_Errors(t *testing.T) {
+	h, r := setupTestRouter()
+	h.Targets = fakeTargetStates{"openai": ""}
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/providers/anthropic/drain", "", adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unconfigured target: expected 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/providers/openai/drain", "", createReadOnlyKey(t, h)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("read-only key: expected 403, got %d", w.Code)
+	}
+
+	h.Targets = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/providers/openai/drain", "", adminKey))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("no gateway: expected 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/ratelimit"
+)
+
+func setupTestRouterWithRateLimits(t *testing.T) (*Handlers, http.Handler, *ratelimit.Store) {
+	t.Helper()
+	store := ratelimit.NewStore(1, 1)
+	// A frozen clock keeps drained buckets from refilling mid-test.
+	now := time.Unix(100, 0)
+	store.SetNowForTest(func() time.Time { return now })
+	store.Allow("10.0.0.1")
+	store.Allow("10.0.0.2")
+	store.Allow("10.0.0.2")
+
+	h, r := setupTestRouter()
+	h.RateLimits = store
+	return h, r, store
+}
+
+func TestRateLimitsEndpointNotEnabled(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/ratelimits", "", adminKey))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", w.Code)
+	}
+}
+
+func TestListRateLimits(t *testing.T) {
+	h, r, _ := setupTestRouterWithRateLimits(t)
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/ratelimits", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data []struct {
+			Name       string                  `json:"name"`
+			Buckets    int                     `json:"buckets"`
+			Rejections int64                   `json:"rejections"`
+			Data       []ratelimit.BucketState `json:"data"`
+		} `json:"data"`
+	}
+	decodeJSON(t, w.Body, &resp)
+	if len(resp.Data) != 1 || resp.Data[0].Name != "ip" || resp.Data[0].Buckets != 2 || resp.Data[0].Rejections != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	// The most-rejected bucket is listed first.
+	if buckets := resp.Data[0].Data; len(buckets) != 2 || buckets[0].Key != "10.0.0.2" {
+		t.Fatalf("buckets = %+v", buckets)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/ratelimits?key=10.0.0.1", "", readOnly))
+	decodeJSON(t, w.Body, &resp)
+	if buckets := resp.Data[0].Data; len(buckets) != 1 || buckets[0].Key != "10.0.0.1" {
+		t.Fatalf("key filter: buckets = %+v", buckets)
+	}
+}
+
+func TestResetRateLimit(t *testing.T) {
+	h, r, store := setupTestRouterWithRateLimits(t)
+	adminKey := createAdminKey(t, h)
+	readOnly := createReadOnlyKey(t, h)
+
+	tests := []struct {
+		name string
+		key  *APIKey
+		path string
+		want int
+	}{
+		{"read-only scope", readOnly, "/admin/ratelimits/ip?key=10.0.0.2", http.StatusForbidden},
+		{"no key", adminKey, "/admin/ratelimits/ip", http.StatusBadRequest},
+		{"unknown limiter", adminKey, "/admin/ratelimits/tier?key=10.0.0.2", http.StatusNotFound},
+		{"untracked key", adminKey, "/admin/ratelimits/ip?key=10.0.0.9", http.StatusNotFound},
+		{"reset", adminKey, "/admin/ratelimits/ip?key=10.0.0.2", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, authedRequest(http.MethodDelete, tt.path, "", tt.key))
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.want == http.StatusNotFound && !strings.Contains(w.Body.String(), `"resource_not_found"`) {
+				t.Fatalf("expected the resource_not_found code: %s", w.Body.String())
+			}
+		})
+	}
+
+	if !store.Allow("10.0.0.2") {
+		t.Fatal("expected the reset IP to be allowed again")
+	}
+	if store.Allow("10.0.0.1") {
+		t.Fatal("resetting one key must leave the others alone")
+	}
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+)
+
+// usageLogReader is a fakeLogReader that also reports usage, recording the
+// query it was asked for.
+type usageLogReader struct {
+	fakeLogReader
+	rows  []requestlog.UsageRow
+	query requestlog.UsageQuery
+}
+
+func (u *usageLogReader) Usage(_ context.Context, q requestlog.UsageQuery) ([]requestlog.UsageRow, error) {
+	u.query = q
+	return u.rows, nil
+}
+
+func TestUsageReport(t *testing.T) {
+	reader := &usageLogReader{rows: []requestlog.UsageRow{
+		{Provider: "anthropic", Model: "claude", Requests: 2, TotalTokens: 40, CostUSD: 0.5},
+		{Provider: "openai", Model: "gpt-4o", Requests: 3, Errors: 1, TotalTokens: 60, CostUSD: 1.25},
+	}}
+	h, r := setupTestRouterWithLogs(reader)
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/reports/usage?period=2025-01&group_by=provider,model", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	wantSince := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !reader.query.Since.Equal(wantSince) || !reader.query.Until.Equal(wantSince.AddDate(0, 1, 0)) {
+		t.Errorf("queried [%v, %v), want January 2025", reader.query.Since, reader.query.Until)
+	}
+	if len(reader.query.GroupBy) != 2 || reader.query.GroupBy[0] != requestlog.UsageByProvider || reader.query.GroupBy[1] != requestlog.UsageByModel {
+		t.Errorf("group by = %v", reader.query.GroupBy)
+	}
+	var resp struct {
+		Period string                `json:"period"`
+		Data   []requestlog.UsageRow `json:"data"`
+		Totals requestlog.UsageRow   `json:"totals"`
+	}
+	decodeJSON(t, w.Body, &resp)
+	if resp.Period != "2025-01" || len(resp.Data) != 2 || resp.Totals.Requests != 5 || resp.Totals.Errors != 1 || resp.Totals.CostUSD != 1.75 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/reports/usage?period=2025-01&group_by=provider&format=csv", "", readOnly))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 || lines[0] != "period,provider,requests,errors,cached_requests,prompt_tokens,completion_tokens,total_tokens,cost_usd" ||
+		lines[2] != "2025-01,openai,3,1,0,0,0,60,1.25" {
+		t.Fatalf("csv = %q", w.Body.String())
+	}
+}
+
+func TestUsageReport_InvalidParams(t *testing.T) {
+	h, r := setupTestRouterWithLogs(&usageLogReader{})
+	readOnly := createReadOnlyKey(t, h)
+
+	for _, query := range []string{"period=2025-13", "period=january", "group_by=region", "format=xml"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/reports/usage?"+query, "", readOnly))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestUsageReport_NotSupported(t *testing.T) {
+	h, r := setupTestRouterWithLogs(&fakeLogReader{})
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/reports/usage", "", readOnly))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", w.Code)
+	}
+}
+
+func TestReconcileUsage(t *testing.T) {
+	reader := &usageLogReader{rows: []requestlog.UsageRow{
+		{Day: "2025-01-01", Provider: "openai", Model: "gpt-4o", Requests: 10, PromptTokens: 1000, CompletionTokens: 300},
+		{Day: "2025-01-02", Provider: "openai", Model: "gpt-4o", Requests: 4, PromptTokens: 100, CompletionTokens: 10},
+	}}
+	h, r := setupTestRouterWithLogs(reader)
+	readOnly := createReadOnlyKey(t, h)
+
+	export := "start_time_iso,model,num_model_requests,input_tokens,output_tokens\n" +
+		"2025-01-01T00:00:00+00:00,gpt-4o,10,1000,300\n" +
+		"2025-01-02T00:00:00+00:00,gpt-4o,5,150,10\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/reports/reconcile?source=openai", export, readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	wantSince := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !reader.query.Daily || !reader.query.Since.Equal(wantSince) || !reader.query.Until.Equal(wantSince.AddDate(0, 0, 2)) {
+		t.Errorf("queried %+v, want daily usage for the export's two days", reader.query)
+	}
+	var resp struct {
+		Provider string `json:"provider"`
+		Data     []struct {
+			Day           string   `json:"day"`
+			Status        string   `json:"status"`
+			Discrepancies []string `json:"discrepancies"`
+		} `json:"data"`
+		Summary struct {
+			Matched    int `json:"matched"`
+			Mismatched int `json:"mismatched"`
+		} `json:"summary"`
+	}
+	decodeJSON(t, w.Body, &resp)
+	if resp.Provider != "openai" || len(resp.Data) != 2 || resp.Summary.Matched != 1 || resp.Summary.Mismatched != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if d := resp.Data[1]; d.Day != "2025-01-02" || d.Status != "mismatch" || len(d.Discrepancies) != 2 {
+		t.Fatalf("second day should differ in requests and prompt tokens: %+v", d)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/reports/reconcile", "model\ngpt-4o\n", readOnly))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("export without a date column: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/reports/reconcile?tolerance=2", export, readOnly))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid tolerance: expected 400, got %d", w.Code)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRotateKey(t *testing.T) {
@@ -55,3 +56,66 @@ func TestRotateKeyNotFound(t *testing.T) {
 		t.Fatalf("expected 404, got %d", w.Code)
 	}
 }
+
+func TestRotateKeyOverlap(t *testing.T) {
+	h, r := setupTestRouter()
+	h.KeyRotationOverlap = time.Hour
+	adminKey := createAdminKey(t, h)
+
+	key, err := h.Keys.Create(context.Background(), "deploy-key", []string{ScopeReadOnly}, nil)
+	if err != nil {
+		t.Fatalf("failed to create key: %v", err)
+	}
+
+	// The handler's default overlap applies to a request without a body.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/keys/"+key.ID+"/rotate", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var rotated APIKey
+	decodeJSON(t, w.Body, &rotated)
+	if rotated.PreviousKey == "" || rotated.PreviousKeyExpiresAt == nil {
+		t.Fatalf("expected the previous key in the response, got %+v", rotated)
+	}
+	if _, ok := h.Keys.ValidateKey(context.Background(), key.Key); !ok {
+		t.Fatal("expected the previous secret to validate during the overlap")
+	}
+
+	// An explicit zero overlap cuts the previous secret off.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/keys/"+key.ID+"/rotate", `{"overlap":"0s"}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := h.Keys.ValidateKey(context.Background(), rotated.Key); ok {
+		t.Fatal("expected the previous secret to stop validating without an overlap")
+	}
+
+	for _, body := range []string{`{"overlap":"soon"}`, `{"overlap":"-1h"}`, `{"overlap":"8760h"}`} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/keys/"+key.ID+"/rotate", body, adminKey))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestKeyRotationOverlapFromEnv(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		"":    0,
+		"0":   0,
+		"15m": 15 * time.Minute,
+	} {
+		t.Setenv("KEY_ROTATION_OVERLAP", raw)
+		if got, err := KeyRotationOverlapFromEnv(); err != nil || got != want {
+			t.Errorf("KEY_ROTATION_OVERLAP=%q: got %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"bogus", "-1h", "1000h"} {
+		t.Setenv("KEY_ROTATION_OVERLAP", raw)
+		if _, err := KeyRotationOverlapFromEnv(); err == nil {
+			t.Errorf("KEY_ROTATION_OVERLAP=%q: expected an error", raw)
+		}
+	}
+}
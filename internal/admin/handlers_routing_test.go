@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+)
+
+type fakeRoutingSource aigateway.RoutingState
+
+func (f fakeRoutingSource) RoutingState() aigateway.RoutingState { return aigateway.RoutingState(f) }
+
+func TestRoutingStateEndpoint(t *testing.T) {
+	h, r := setupTestRouter()
+	h.Routing = fakeRoutingSource{
+		Strategy: aigateway.ModeLoadBalance,
+		Targets: []aigateway.TargetRoutingState{
+			{VirtualKey: "openai", Registered: true, Weight: 1, WeightShare: 1, CircuitBreaker: "half_open", RecentRequests: 4, RecentErrors: 1, ErrorRate: 0.25},
+		},
+	}
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/routing/state", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+	var got aigateway.RoutingState
+	decodeJSON(t, w.Body, &got)
+	if got.Strategy != aigateway.ModeLoadBalance || len(got.Targets) != 1 {
+		t.Fatalf("state = %+v", got)
+	}
+	if target := got.Targets[0]; target.CircuitBreaker != "half_open" || target.ErrorRate != 0.25 {
+		t.Errorf("target = %+v", target)
+	}
+}
+
+func TestRoutingStateEndpoint_NotAvailable(t *testing.T) {
+	h, r := setupTestRouter()
+	adminKey := createAdminKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/routing/state", "", adminKey))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,231 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+)
+
+func setupTestRouterWithTiers(t *testing.T) (*Handlers, http.Handler, *APIKey) {
+	t.Helper()
+	h, r := setupTestRouter()
+	cm := h.Configs.(*testConfigManager)
+	cm.cfg.RateLimitTiers = []aigateway.RateLimitTier{
+		{Name: "free", RequestsPerSecond: 1, MonthlyTokenLimit: 100_000},
+		{Name: "enterprise", RequestsPerSecond: 100, Burst: 200, MaxConcurrentStreams: 50},
+	}
+	return h, r, createAdminKey(t, h)
+}
+
+func TestListTiers(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithTiers(t)
+	key := createTestKey(t, h, "tiered", []string{ScopeReadOnly}, nil)
+	if err := h.Keys.SetTier(t.Context(), key.ID, "free"); err != nil {
+		t.Fatalf("SetTier: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/tiers", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var payload struct {
+		Data []struct {
+			Name              string  `json:"name"`
+			RequestsPerSecond float64 `json:"requests_per_second"`
+			Keys              int     `json:"keys"`
+		} `json:"data"`
+		Summary struct {
+			TotalTiers int `json:"total_tiers"`
+		} `json:"summary"`
+	}
+	decodeJSON(t, w.Body, &payload)
+	if payload.Summary.TotalTiers != 2 || len(payload.Data) != 2 {
+		t.Fatalf("unexpected tiers: %+v", payload)
+	}
+	if payload.Data[0].Name != "free" || payload.Data[0].Keys != 1 || payload.Data[1].Keys != 0 {
+		t.Fatalf("unexpected tier data: %+v", payload.Data)
+	}
+}
+
+func TestGetTier(t *testing.T) {
+	_, r, adminKey := setupTestRouterWithTiers(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/tiers/enterprise", "", adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var tier aigateway.RateLimitTier
+	decodeJSON(t, w.Body, &tier)
+	if tier.MaxConcurrentStreams != 50 {
+		t.Fatalf("unexpected tier: %+v", tier)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/tiers/missing", "", adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing tier: expected 404, got %d", w.Code)
+	}
+}
+
+func TestCreateTier(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithTiers(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/tiers", `{"name":"standard","requests_per_second":10,"monthly_token_limit":1000000}`, adminKey))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if !h.tierExists("standard") {
+		t.Fatal("created tier is not in the active config")
+	}
+	if history := h.getConfigHistorySnapshot(); len(history) != 1 {
+		t.Fatalf("tier creation should record one config history entry, got %d", len(history))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/tiers", `{"name":"standard"}`, adminKey))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("duplicate tier: expected 409, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/tiers", `{"name":"bad","requests_per_second":-1}`, adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("negative limit: expected 400, got %d", w.Code)
+	}
+}
+
+func TestUpdateTier(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithTiers(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/tiers/free", `{"requests_per_second":2,"monthly_token_limit":5000}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	tiers := h.Configs.GetConfig().RateLimitTiers
+	if tiers[0].Name != "free" || tiers[0].RequestsPerSecond != 2 || tiers[0].MonthlyTokenLimit != 5000 {
+		t.Fatalf("tier not updated: %+v", tiers[0])
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/tiers/free", `{"name":"renamed"}`, adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("rename: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/tiers/missing", `{"requests_per_second":1}`, adminKey))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing tier: expected 404, got %d", w.Code)
+	}
+}
+
+func TestDeleteTier(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithTiers(t)
+	key := createTestKey(t, h, "tiered", []string{ScopeReadOnly}, nil)
+	if err := h.Keys.SetTier(t.Context(), key.ID, "free"); err != nil {
+		t.Fatalf("SetTier: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/tiers/free", "", adminKey))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("tier in use: expected 409, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodDelete, "/admin/tiers/enterprise", "", adminKey))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if h.tierExists("enterprise") {
+		t.Fatal("deleted tier is still configured")
+	}
+}
+
+func TestTierWritesRequireAdminScope(t *testing.T) {
+	h, r, _ := setupTestRouterWithTiers(t)
+	readOnly := createReadOnlyKey(t, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodGet, "/admin/tiers", "", readOnly))
+	if w.Code != http.StatusOK {
+		t.Fatalf("read-only list: expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/tiers", `{"name":"standard"}`, readOnly))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("read-only create: expected 403, got %d", w.Code)
+	}
+}
+
+func TestCreateKeyWithTier(t *testing.T) {
+	h, r, adminKey := setupTestRouterWithTiers(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/keys", `{"name":"svc","tier":"unknown"}`, adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown tier: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPost, "/admin/keys", `{"name":"svc","tier":"free"}`, adminKey))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created APIKey
+	decodeJSON(t, w.Body, &created)
+	if created.Tier != "free" {
+		t.Fatalf("created key tier = %q, want free", created.Tier)
+	}
+	stored, ok := h.Keys.Get(t.Context(), created.ID)
+	if !ok || stored.Tier != "free" {
+		t.Fatalf("stored key tier = %+v, want free", stored)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/keys/"+created.ID, `{"tier":""}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("clear tier: expected 200, got %d", w.Code)
+	}
+	if stored, _ := h.Keys.Get(t.Context(), created.ID); stored.Tier != "" {
+		t.Fatalf("tier not cleared: %q", stored.Tier)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/keys/"+created.ID, `{"workspace":"payments"}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("set workspace: expected 200, got %d", w.Code)
+	}
+	if stored, _ := h.Keys.Get(t.Context(), created.ID); stored.Workspace != "payments" || stored.Tier != "" {
+		t.Fatalf("workspace update touched the wrong fields: %+v", stored)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/keys/"+created.ID, `{"tier":"missing"}`, adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("update to unknown tier: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/keys/"+created.ID, `{"webhook_url":"https://hooks.example.com/payments"}`, adminKey))
+	if w.Code != http.StatusOK {
+		t.Fatalf("set webhook_url: expected 200, got %d", w.Code)
+	}
+	if stored, _ := h.Keys.Get(t.Context(), created.ID); stored.WebhookURL != "https://hooks.example.com/payments" || stored.Workspace != "payments" {
+		t.Fatalf("webhook_url update touched the wrong fields: %+v", stored)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest(http.MethodPut, "/admin/keys/"+created.ID, `{"webhook_url":"hooks.example.com"}`, adminKey))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("relative webhook_url: expected 400, got %d", w.Code)
+	}
+}
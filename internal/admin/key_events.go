@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/webhook"
+)
+
+// KeyEventPublisher returns a key lifecycle notifier, for
+// KeyExpiryJob.Notify and Handlers.KeyEvents, that publishes each event to
+// gw's hooks and observability exporters. A key without an expiry is
+// published with a null expires_at. The event carries the key's webhook URL
+// for the key events webhook.
+func KeyEventPublisher(gw *aigateway.Gateway) func(ctx context.Context, subject string, key *APIKey) {
+	return func(ctx context.Context, subject string, key *APIKey) {
+		var expiresAt time.Time
+		if key.ExpiresAt != nil {
+			expiresAt = *key.ExpiresAt
+		}
+		gw.PublishKeyEvent(webhook.WithURL(ctx, key.WebhookURL), subject, key.ID, key.Name, expiresAt)
+	}
+}
+
+// notifyKeyEvent hands a key lifecycle event to h.KeyEvents, when set.
+func (h *Handlers) notifyKeyEvent(ctx context.Context, subject string, key *APIKey) {
+	if h.KeyEvents != nil && key != nil {
+		h.KeyEvents(ctx, subject, key)
+	}
+}
@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+)
+
+// KeyExpiryJob keeps expired API keys from accumulating. Each sweep
+// deactivates the keys past their expires_at, deletes them once Retention
+// has passed, and reports keys about to expire so notification integrations
+// can warn their owners ahead of time.
+type KeyExpiryJob struct {
+	Keys Store
+	// Retention is how long an expired key is kept, deactivated, before it
+	// is deleted. Zero keeps expired keys forever.
+	Retention time.Duration
+	// NoticeBefore is how long before its expiry a key is reported as
+	// expiring. Zero sends no notices.
+	NoticeBefore time.Duration
+	// Notify receives aigateway.SubjectKeyExpiring for a key entering the
+	// notice window and aigateway.SubjectKeyExpired for a key the sweep
+	// deactivates. Nil sends nothing.
+	Notify func(ctx context.Context, subject string, key *APIKey)
+
+	mu sync.Mutex
+}
+
+// Run sweeps at once and then every interval until ctx is done.
+func (j *KeyExpiryJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		j.Sweep(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep runs one pass over the store as of now and returns how many keys it
+// deactivated and deleted. A key the store fails to update is logged and
+// retried on the next sweep.
+//
+// An expiring notice is recorded on its key, so each key is reported once
+// per expiry, across restarts and replicas; moving expires_at re-arms it.
+func (j *KeyExpiryJob) Sweep(ctx context.Context, now time.Time) (deactivated, deleted int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, key := range j.Keys.List(ctx) {
+		if key.ExpiresAt == nil {
+			continue
+		}
+		expiresAt := *key.ExpiresAt
+
+		if now.Before(expiresAt) {
+			if j.NoticeBefore > 0 && expiresAt.Sub(now) <= j.NoticeBefore && !noticed(key, expiresAt) && key.Active && key.RevokedAt == nil {
+				if err := j.Keys.SetExpiryNotice(ctx, key.ID, expiresAt); err != nil {
+					logging.Logger.Warn("expiring key notice not recorded", "key_id", key.ID, "error", err)
+					continue
+				}
+				j.notify(ctx, aigateway.SubjectKeyExpiring, key)
+			}
+			continue
+		}
+
+		if key.Active && key.RevokedAt == nil {
+			if err := j.Keys.Deactivate(ctx, key.ID); err != nil {
+				logging.Logger.Warn("expired key not deactivated", "key_id", key.ID, "error", err)
+				continue
+			}
+			deactivated++
+			key.Active = false
+			j.notify(ctx, aigateway.SubjectKeyExpired, key)
+		}
+		if j.Retention > 0 && now.Sub(expiresAt) >= j.Retention {
+			if err := j.Keys.Delete(ctx, key.ID); err != nil {
+				logging.Logger.Warn("expired key not deleted", "key_id", key.ID, "error", err)
+				continue
+			}
+			deleted++
+		}
+	}
+	if deactivated > 0 || deleted > 0 {
+		logging.Logger.Info("expired api keys cleaned up", "deactivated", deactivated, "deleted", deleted)
+	}
+	return deactivated, deleted
+}
+
+// noticed reports whether key's expiring notice was sent for expiresAt.
+func noticed(key *APIKey, expiresAt time.Time) bool {
+	return key.ExpiryNoticeFor != nil && key.ExpiryNoticeFor.Equal(expiresAt)
+}
+
+func (j *KeyExpiryJob) notify(ctx context.Context, subject string, key *APIKey) {
+	if j.Notify != nil {
+		j.Notify(ctx, subject, key)
+	}
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+)
+
+func TestKeyExpiryJob_Sweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewKeyStore()
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	expired, _ := store.Create(ctx, "expired", nil, at(-time.Hour))
+	stale, _ := store.Create(ctx, "stale", nil, at(-48*time.Hour))
+	expiring, _ := store.Create(ctx, "expiring", nil, at(2*24*time.Hour))
+	later, _ := store.Create(ctx, "later", nil, at(30*24*time.Hour))
+	forever, _ := store.Create(ctx, "forever", nil, nil)
+
+	var events []string
+	job := &KeyExpiryJob{
+		Keys:         store,
+		Retention:    24 * time.Hour,
+		NoticeBefore: 7 * 24 * time.Hour,
+		Notify: func(_ context.Context, subject string, key *APIKey) {
+			events = append(events, subject+" "+key.Name)
+		},
+	}
+
+	deactivated, deleted := job.Sweep(ctx, now)
+	if deactivated != 2 || deleted != 1 {
+		t.Fatalf("Sweep = (%d deactivated, %d deleted), want (2, 1)", deactivated, deleted)
+	}
+	if k, ok := store.Get(ctx, expired.ID); !ok || k.Active || k.RevokedAt != nil {
+		t.Fatalf("expired key should be kept, inactive, and not revoked: %+v", k)
+	}
+	if _, ok := store.Get(ctx, stale.ID); ok {
+		t.Fatal("a key expired past the retention period should be deleted")
+	}
+	for _, id := range []string{expiring.ID, later.ID, forever.ID} {
+		if k, _ := store.Get(ctx, id); !k.Active {
+			t.Fatalf("unexpired key %s deactivated", k.Name)
+		}
+	}
+	want := map[string]bool{
+		aigateway.SubjectKeyExpired + " expired":   true,
+		aigateway.SubjectKeyExpired + " stale":     true,
+		aigateway.SubjectKeyExpiring + " expiring": true,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v", events)
+	}
+	for _, e := range events {
+		if !want[e] {
+			t.Fatalf("unexpected event %q in %v", e, events)
+		}
+	}
+
+	// A second sweep repeats nothing; moving the expiry re-arms the notice.
+	events = nil
+	if deactivated, deleted := job.Sweep(ctx, now); deactivated != 0 || deleted != 0 || len(events) != 0 {
+		t.Fatalf("second sweep = (%d, %d), events %v; want nothing", deactivated, deleted, events)
+	}
+	// The notice is recorded on the key, so a restarted job or another
+	// replica's does not repeat it either.
+	restarted := &KeyExpiryJob{Keys: store, NoticeBefore: job.NoticeBefore, Notify: job.Notify}
+	if restarted.Sweep(ctx, now); len(events) != 0 {
+		t.Fatalf("restarted job's sweep events = %v; want nothing", events)
+	}
+	if err := store.SetExpiration(ctx, expiring.ID, at(3*24*time.Hour)); err != nil {
+		t.Fatalf("set expiration: %v", err)
+	}
+	job.Sweep(ctx, now)
+	if len(events) != 1 || events[0] != aigateway.SubjectKeyExpiring+" expiring" {
+		t.Fatalf("events after moving the expiry = %v", events)
+	}
+}
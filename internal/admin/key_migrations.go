@@ -27,12 +27,68 @@ const (
 // executing it.
 //
 // Version 2 replaces the plaintext key column with its SHA-256 hash and a
-// display form. Version 3 erases the pages the rebuild freed.
+// display form. Version 3 erases the pages the rebuild freed. Version 4 adds
+// the rate-limit tier a key is assigned, and version 5 its workspace. Version
+// 6 adds the previous secret a rotation keeps valid for an overlap window,
+// version 7 the bootstrap lock, version 8 the key's own webhook URL, and
+// version 9 the expiry an expiring notice was last sent for.
 func keyStoreSteps(dialect migrations.Dialect) []migrations.Step {
+	timestamp := "DATETIME"
+	if dialect == migrations.Postgres {
+		timestamp = "TIMESTAMPTZ"
+	}
 	return []migrations.Step{
 		{Version: 1, Name: "api_keys_baseline", SQL: baselineDDL(dialect)},
 		{Version: 2, Name: "api_keys_hash", Fn: hashStoredKeys(dialect)},
 		{Version: 3, Name: "api_keys_scrub", NoTx: scrubFreedPages(dialect)},
+		{Version: 4, Name: "api_keys_tier", SQL: "ALTER TABLE api_keys ADD COLUMN tier TEXT NULL"},
+		{Version: 5, Name: "api_keys_workspace", SQL: "ALTER TABLE api_keys ADD COLUMN workspace TEXT NULL"},
+		{Version: 6, Name: "api_keys_previous_secret", Fn: addPreviousSecretColumns(dialect)},
+		{Version: 7, Name: "api_keys_bootstrap_lock", Fn: addBootstrapLock(dialect)},
+		{Version: 8, Name: "api_keys_webhook_url", SQL: "ALTER TABLE api_keys ADD COLUMN webhook_url TEXT NULL"},
+		{Version: 9, Name: "api_keys_expiry_notice", SQL: "ALTER TABLE api_keys ADD COLUMN expiry_notice_for " + timestamp + " NULL"},
+	}
+}
+
+// addBootstrapLock creates the single-row table recording that the store has
+// held a key, and locks a store that already holds some.
+func addBootstrapLock(dialect migrations.Dialect) func(context.Context, *sql.Tx) error {
+	timestamp := "DATETIME"
+	if dialect == migrations.Postgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, stmt := range []string{
+			"CREATE TABLE IF NOT EXISTS api_keys_bootstrap (id INTEGER PRIMARY KEY, locked_at " + timestamp + " NOT NULL)",
+			"INSERT INTO api_keys_bootstrap(id, locked_at) SELECT 1, CURRENT_TIMESTAMP WHERE EXISTS (SELECT 1 FROM api_keys)",
+		} {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("add bootstrap lock: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// addPreviousSecretColumns adds the columns holding a rotated key's previous
+// secret during its overlap window, and the index ValidateKey looks it up by.
+func addPreviousSecretColumns(dialect migrations.Dialect) func(context.Context, *sql.Tx) error {
+	timestamp := "DATETIME"
+	if dialect == migrations.Postgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, stmt := range []string{
+			"ALTER TABLE api_keys ADD COLUMN previous_key_hash TEXT NULL",
+			"ALTER TABLE api_keys ADD COLUMN previous_key_display TEXT NULL",
+			"ALTER TABLE api_keys ADD COLUMN previous_key_expires_at " + timestamp + " NULL",
+			"CREATE INDEX IF NOT EXISTS idx_api_keys_previous_key_hash ON api_keys (previous_key_hash)",
+		} {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("add previous secret columns: %w", err)
+			}
+		}
+		return nil
 	}
 }
 
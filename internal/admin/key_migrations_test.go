@@ -270,7 +270,7 @@ func TestCreatedKeyIsNeverWrittenInClear(t *testing.T) {
 	if err != nil {
 		t.Fatalf("create key: %v", err)
 	}
-	rotated, err := store.RotateKey(context.Background(), created.ID)
+	rotated, err := store.RotateKey(context.Background(), created.ID, 0)
 	if err != nil {
 		t.Fatalf("rotate key: %v", err)
 	}
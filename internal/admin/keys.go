@@ -6,8 +6,12 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
 )
 
 // ErrKeyNotFound is returned by Store implementations when an operation targets
@@ -34,13 +38,62 @@ type APIKey struct {
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
 	UsageCount int64      `json:"usage_count"`
 	Active     bool       `json:"active"`
+	// Tier names the rate-limit tier (Config.RateLimitTiers) whose limits
+	// apply to requests made with this key. Empty means untiered.
+	Tier string `json:"tier,omitempty"`
+	// Workspace groups keys that belong to the same team or tenant. Plugin
+	// match rules can scope a plugin to one or more workspaces.
+	Workspace string `json:"workspace,omitempty"`
+	// WebhookURL is where this key's lifecycle events are also POSTed, so
+	// its owners can follow it without seeing every other key's events.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// ExpiryNoticeFor is the expires_at the last expiring notice was sent
+	// for, so every replica's expiry job skips a key already warned about.
+	ExpiryNoticeFor *time.Time `json:"expiry_notice_for,omitempty"`
+	// PreviousKey is the display form of the secret the last rotation
+	// replaced, present while that secret is still accepted.
+	// PreviousKeyExpiresAt is when it stops being accepted.
+	PreviousKey          string     `json:"previous_key,omitempty"`
+	PreviousKeyExpiresAt *time.Time `json:"previous_key_expires_at,omitempty"`
+}
+
+// MaxKeyRotationOverlap bounds the overlap window a rotation may request.
+const MaxKeyRotationOverlap = 30 * 24 * time.Hour
+
+// KeyRotationOverlapFromEnv reads KEY_ROTATION_OVERLAP, the default overlap
+// window of key rotations, as a Go duration. Unset, it is 0: rotation
+// invalidates the previous secret at once, as it did before overlaps existed,
+// so a rotation prompted by a leaked key leaves nothing valid. It returns 0
+// and an error for an unparsable, negative, or out-of-range value, which
+// startup rejects.
+func KeyRotationOverlapFromEnv() (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv("KEY_ROTATION_OVERLAP"))
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 || d > MaxKeyRotationOverlap {
+		return 0, fmt.Errorf("must be a duration from 0 to %s, got %q", MaxKeyRotationOverlap, raw)
+	}
+	return d, nil
+}
+
+// clearExpiredPreviousKey drops a previous secret whose overlap has ended, so
+// reads never show a credential that no longer validates.
+func clearExpiredPreviousKey(k *APIKey, now time.Time) {
+	if k.PreviousKeyExpiresAt != nil && !now.Before(*k.PreviousKeyExpiresAt) {
+		k.PreviousKey = ""
+		k.PreviousKeyExpiresAt = nil
+	}
 }
 
-// keyRecord pairs a stored key with the hash it is looked up by. The hash is a
-// storage detail and never leaves the store.
+// keyRecord pairs a stored key with the hashes it is looked up by: its
+// current secret's and, during a rotation overlap, its previous secret's. The
+// hashes are a storage detail and never leave the store.
 type keyRecord struct {
-	apiKey *APIKey
-	hash   string
+	apiKey   *APIKey
+	hash     string
+	prevHash string
 }
 
 // KeyStore is an in-memory store for API keys.
@@ -48,6 +101,7 @@ type KeyStore struct {
 	mu     sync.RWMutex
 	byID   map[string]*keyRecord
 	byHash map[string]string // sha256 hex -> ID
+	locked bool              // set by the first Create; see BootstrapLocked
 }
 
 // NewKeyStore creates a new KeyStore.
@@ -96,6 +150,8 @@ func cloneAPIKey(k *APIKey) *APIKey {
 	cp.ExpiresAt = cloneTime(k.ExpiresAt)
 	cp.RotatedAt = cloneTime(k.RotatedAt)
 	cp.LastUsedAt = cloneTime(k.LastUsedAt)
+	cp.PreviousKeyExpiresAt = cloneTime(k.PreviousKeyExpiresAt)
+	clearExpiredPreviousKey(&cp, time.Now())
 	return &cp
 }
 
@@ -140,6 +196,7 @@ func (s *KeyStore) Create(_ context.Context, name string, scopes []string, expir
 	defer s.mu.Unlock()
 	s.byID[id] = &keyRecord{apiKey: stored, hash: hash}
 	s.byHash[hash] = id
+	s.locked = true
 
 	created := cloneAPIKey(stored)
 	created.Key = key
@@ -175,6 +232,13 @@ func (s *KeyStore) IsEmpty(_ context.Context) (bool, error) {
 	return len(s.byID) == 0, nil
 }
 
+// BootstrapLocked reports whether a key has ever been created in the store.
+func (s *KeyStore) BootstrapLocked(_ context.Context) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.locked, nil
+}
+
 // Revoke marks an API key as revoked and inactive.
 func (s *KeyStore) Revoke(_ context.Context, id string) error {
 	s.mu.Lock()
@@ -189,6 +253,18 @@ func (s *KeyStore) Revoke(_ context.Context, id string) error {
 	return nil
 }
 
+// Deactivate marks an API key inactive without revoking it.
+func (s *KeyStore) Deactivate(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	rec.apiKey.Active = false
+	return nil
+}
+
 // Update updates the name and scopes of an API key.
 func (s *KeyStore) Update(_ context.Context, id string, name string, scopes []string) (*APIKey, error) {
 	s.mu.Lock()
@@ -225,6 +301,57 @@ func (s *KeyStore) SetExpiration(_ context.Context, id string, expiresAt *time.T
 	return nil
 }
 
+// SetTier assigns an API key to a rate-limit tier. An empty tier clears it.
+func (s *KeyStore) SetTier(_ context.Context, id, tier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	rec.apiKey.Tier = tier
+	return nil
+}
+
+// SetWorkspace assigns an API key to a workspace. An empty workspace clears it.
+func (s *KeyStore) SetWorkspace(_ context.Context, id, workspace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	rec.apiKey.Workspace = workspace
+	return nil
+}
+
+// SetWebhookURL sets the URL the key's lifecycle events are also sent to. An
+// empty URL clears it.
+func (s *KeyStore) SetWebhookURL(_ context.Context, id, webhookURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	rec.apiKey.WebhookURL = webhookURL
+	return nil
+}
+
+// SetExpiryNotice records that an expiring notice was sent for the key's
+// expiry at expiresAt.
+func (s *KeyStore) SetExpiryNotice(_ context.Context, id string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	t := expiresAt.UTC()
+	rec.apiKey.ExpiryNoticeFor = &t
+	return nil
+}
+
 // Delete removes an API key from the store.
 func (s *KeyStore) Delete(_ context.Context, id string) error {
 	s.mu.Lock()
@@ -234,13 +361,18 @@ func (s *KeyStore) Delete(_ context.Context, id string) error {
 		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
 	}
 	delete(s.byHash, rec.hash)
+	if rec.prevHash != "" {
+		delete(s.byHash, rec.prevHash)
+	}
 	delete(s.byID, id)
 	return nil
 }
 
-// RotateKey generates a new key string for an existing API key. The returned
-// key carries the new secret; the stored copy does not.
-func (s *KeyStore) RotateKey(_ context.Context, id string) (*APIKey, error) {
+// RotateKey generates a new key string for an existing API key. The previous
+// secret stays valid for overlap, or stops validating at once when overlap is
+// zero; a secret an earlier rotation left valid is retired either way. The
+// returned key carries the new secret; the stored copy does not.
+func (s *KeyStore) RotateKey(_ context.Context, id string, overlap time.Duration) (*APIKey, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	rec, ok := s.byID[id]
@@ -253,11 +385,24 @@ func (s *KeyStore) RotateKey(_ context.Context, id string) (*APIKey, error) {
 		return nil, err
 	}
 
-	delete(s.byHash, rec.hash)
+	now := time.Now().UTC()
+	if rec.prevHash != "" {
+		delete(s.byHash, rec.prevHash)
+	}
+	if overlap > 0 {
+		expires := now.Add(overlap)
+		rec.prevHash = rec.hash
+		rec.apiKey.PreviousKey = rec.apiKey.Key
+		rec.apiKey.PreviousKeyExpiresAt = &expires
+	} else {
+		delete(s.byHash, rec.hash)
+		rec.prevHash = ""
+		rec.apiKey.PreviousKey = ""
+		rec.apiKey.PreviousKeyExpiresAt = nil
+	}
 	rec.hash = hashKey(newKey)
 	s.byHash[rec.hash] = id
 	rec.apiKey.Key = displayKey(newKey)
-	now := time.Now().UTC()
 	rec.apiKey.RotatedAt = &now
 
 	rotated := cloneAPIKey(rec.apiKey)
@@ -274,11 +419,21 @@ func (s *KeyStore) ValidateKey(_ context.Context, key string) (*APIKey, bool) {
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	id, ok := s.byHash[hashKey(key)]
+	hash := hashKey(key)
+	id, ok := s.byHash[hash]
 	if !ok {
 		return nil, false
 	}
-	k := s.byID[id].apiKey
+	rec := s.byID[id]
+	k := rec.apiKey
+	previous := hash == rec.prevHash
+	if previous && (k.PreviousKeyExpiresAt == nil || !time.Now().Before(*k.PreviousKeyExpiresAt)) {
+		delete(s.byHash, hash)
+		rec.prevHash = ""
+		k.PreviousKey = ""
+		k.PreviousKeyExpiresAt = nil
+		return nil, false
+	}
 	if !k.Active || k.RevokedAt != nil {
 		return nil, false
 	}
@@ -289,6 +444,9 @@ func (s *KeyStore) ValidateKey(_ context.Context, key string) (*APIKey, bool) {
 	lastUsedAt := now
 	k.LastUsedAt = &lastUsedAt
 	k.UsageCount++
+	if previous {
+		metrics.PreviousKeyRequests.WithLabelValues(k.ID).Inc()
+	}
 	return cloneAPIKey(k), true
 }
 
@@ -5,6 +5,9 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestCreate(t *testing.T) {
@@ -131,6 +134,33 @@ func TestIsEmpty(t *testing.T) {
 	}
 }
 
+func TestBootstrapLocked(t *testing.T) {
+	runBootstrapLockContract(t, NewKeyStore())
+}
+
+// runBootstrapLockContract checks that the first key locks bootstrap and that
+// deleting it does not unlock it.
+func runBootstrapLockContract(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	if locked, err := store.BootstrapLocked(ctx); err != nil || locked {
+		t.Fatalf("BootstrapLocked on a new store = (%v, %v), want (false, nil)", locked, err)
+	}
+	created, err := store.Create(ctx, "first", nil, nil)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	if err := store.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("delete key: %v", err)
+	}
+	if locked, err := store.BootstrapLocked(ctx); err != nil || !locked {
+		t.Fatalf("BootstrapLocked after the first key = (%v, %v), want (true, nil)", locked, err)
+	}
+	if _, err := store.Create(ctx, "second", nil, nil); err != nil {
+		t.Fatalf("create a key on a locked store: %v", err)
+	}
+}
+
 // Delete and RotateKey both retire the old hash; a stale entry would leave the
 // previous secret usable.
 func TestRotateAndDeleteRetireTheOldHash(t *testing.T) {
@@ -140,7 +170,7 @@ func TestRotateAndDeleteRetireTheOldHash(t *testing.T) {
 		t.Fatalf("create key: %v", err)
 	}
 
-	rotated, err := store.RotateKey(context.Background(), created.ID)
+	rotated, err := store.RotateKey(context.Background(), created.ID, 0)
 	if err != nil {
 		t.Fatalf("rotate key: %v", err)
 	}
@@ -380,3 +410,75 @@ func TestValidateKey_ReturnsDefensiveCopy(t *testing.T) {
 		t.Fatalf("stored scope = %q, want %q", stored.Scopes[0], ScopeReadOnly)
 	}
 }
+
+func TestRotateKey_Overlap(t *testing.T) {
+	runRotationOverlapContract(t, NewKeyStore())
+}
+
+// runRotationOverlapContract checks that a rotation with an overlap keeps the
+// previous secret valid, and reported, until the window ends.
+func runRotationOverlapContract(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	created, err := store.Create(ctx, "deploy-key", nil, nil)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	rotated, err := store.RotateKey(ctx, created.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("rotate key: %v", err)
+	}
+	if rotated.PreviousKey != displayKey(created.Key) || rotated.PreviousKeyExpiresAt == nil {
+		t.Fatalf("previous secret not reported: %q, %v", rotated.PreviousKey, rotated.PreviousKeyExpiresAt)
+	}
+	if fetched, _ := store.Get(ctx, created.ID); fetched.PreviousKey != rotated.PreviousKey || fetched.Key != displayKey(rotated.Key) {
+		t.Fatalf("Get shows key %q, previous %q", fetched.Key, fetched.PreviousKey)
+	}
+
+	previousUses := metrics.PreviousKeyRequests.WithLabelValues(created.ID)
+	before := testutil.ToFloat64(previousUses)
+	if _, ok := store.ValidateKey(ctx, created.Key); !ok {
+		t.Fatal("the previous secret stopped validating inside the overlap")
+	}
+	if _, ok := store.ValidateKey(ctx, rotated.Key); !ok {
+		t.Fatal("the new secret does not validate")
+	}
+	if got := testutil.ToFloat64(previousUses) - before; got != 1 {
+		t.Errorf("previous secret requests = %v, want 1", got)
+	}
+
+	// A second rotation retires the first secret whatever its window, and an
+	// overlap that has ended no longer validates.
+	again, err := store.RotateKey(ctx, created.ID, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("rotate key again: %v", err)
+	}
+	if _, ok := store.ValidateKey(ctx, created.Key); ok {
+		t.Fatal("a secret two rotations old still validates")
+	}
+	if _, ok := store.ValidateKey(ctx, rotated.Key); !ok {
+		t.Fatal("the previous secret does not validate")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, ok := store.ValidateKey(ctx, rotated.Key); ok {
+		t.Fatal("the previous secret validates after its overlap")
+	}
+	if fetched, _ := store.Get(ctx, created.ID); fetched.PreviousKey != "" || fetched.PreviousKeyExpiresAt != nil {
+		t.Fatalf("expired previous secret still shown: %q", fetched.PreviousKey)
+	}
+	if _, ok := store.ValidateKey(ctx, again.Key); !ok {
+		t.Fatal("the current secret does not validate")
+	}
+
+	// Without an overlap the previous secret stops at once.
+	last, err := store.RotateKey(ctx, created.ID, 0)
+	if err != nil {
+		t.Fatalf("rotate key without overlap: %v", err)
+	}
+	if _, ok := store.ValidateKey(ctx, again.Key); ok {
+		t.Fatal("the previous secret validates after a rotation without overlap")
+	}
+	if last.PreviousKey != "" {
+		t.Fatalf("rotation without overlap reports previous key %q", last.PreviousKey)
+	}
+}
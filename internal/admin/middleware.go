@@ -6,11 +6,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/internal/webhook"
 )
 
 type contextKey string
@@ -21,6 +25,11 @@ const apiKeyContextKey contextKey = "api_key"
 const (
 	ScopeAdmin    = "admin"
 	ScopeReadOnly = "read_only"
+	// ScopeLogsDecrypt lets a key read request-log payloads in the clear when
+	// REQUEST_LOG_ENCRYPTION_KEY is set. It adds to ScopeAdmin or
+	// ScopeReadOnly rather than implying either, and the master key does not
+	// hold it.
+	ScopeLogsDecrypt = "logs_decrypt"
 )
 
 // APIKeyFromContext retrieves the authenticated API key from the request context.
@@ -62,11 +71,10 @@ func ContextWithAPIKey(ctx context.Context, key *APIKey) context.Context {
 func AuthMiddleware(store Store, masterKey string) func(http.Handler) http.Handler {
 	bootstrapAdminKey := strings.TrimSpace(os.Getenv("ADMIN_BOOTSTRAP_KEY"))
 	bootstrapReadOnlyKey := strings.TrimSpace(os.Getenv("ADMIN_BOOTSTRAP_READ_ONLY_KEY"))
-	bootstrapEnabled := true
-	if raw := strings.TrimSpace(os.Getenv("ADMIN_BOOTSTRAP_ENABLED")); raw != "" {
-		if parsed, err := strconv.ParseBool(raw); err == nil {
-			bootstrapEnabled = parsed
-		}
+	// An invalid setting, which startup rejects, leaves bootstrap off.
+	bootstrapEnabled, err := BootstrapEnabledFromEnv()
+	if err != nil {
+		bootstrapEnabled = false
 	}
 
 	bootstrapAdminAPIKey := &APIKey{
@@ -104,13 +112,18 @@ func AuthMiddleware(store Store, masterKey string) func(http.Handler) http.Handl
 				return
 			}
 
-			// 2. Bootstrap key check (only when store is empty and no master key is configured).
-			if masterKey == "" && bootstrapEnabled && storeIsEmpty(r.Context(), store) {
+			// 2. Bootstrap key check (only until the store's first key and when no master key is configured).
+			if masterKey == "" && bootstrapEnabled && bootstrapAllowed(r.Context(), store) {
 				if bootstrapAdminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(bootstrapAdminKey)) == 1 {
 					next.ServeHTTP(w, r.WithContext(storeKeyInContext(r.Context(), bootstrapAdminAPIKey)))
 					return
 				}
 
+				if token := issuedBootstrapToken(store); token != "" && subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+					next.ServeHTTP(w, r.WithContext(storeKeyInContext(r.Context(), bootstrapAdminAPIKey)))
+					return
+				}
+
 				if bootstrapReadOnlyKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(bootstrapReadOnlyKey)) == 1 {
 					next.ServeHTTP(w, r.WithContext(storeKeyInContext(r.Context(), bootstrapReadOnlyAPIKey)))
 					return
@@ -129,16 +142,13 @@ func AuthMiddleware(store Store, masterKey string) func(http.Handler) http.Handl
 	}
 }
 
-// storeIsEmpty reports whether the key store holds no keys, failing closed when
-// the store cannot answer. Bootstrap credentials exist to seed a fresh
-// deployment and are only valid against a genuinely empty store, so a store
-// outage must not re-open them.
-func storeIsEmpty(ctx context.Context, store Store) bool {
-	empty, err := store.IsEmpty(ctx)
-	if err != nil {
-		return false
-	}
-	return empty
+// bootstrapAllowed reports whether the key store still accepts bootstrap
+// credentials, failing closed when the store cannot answer. Bootstrap
+// credentials exist to seed a fresh deployment and are only valid against a
+// store that has never held a key, so a store outage must not re-open them.
+func bootstrapAllowed(ctx context.Context, store Store) bool {
+	open, err := bootstrapOpen(ctx, store)
+	return err == nil && open
 }
 
 // RequireScope returns a middleware that checks whether the authenticated key
@@ -205,7 +215,46 @@ func storeKeyInContext(ctx context.Context, key *APIKey) context.Context {
 	if key.ID != "" {
 		ctx = authctx.WithKeyID(ctx, key.ID)
 	}
-	return ctx
+	if key.Tier != "" {
+		ctx = authctx.WithTier(ctx, key.Tier)
+	}
+	ctx = authctx.WithIdentity(ctx, authctx.KeyIdentity{
+		Name:      key.Name,
+		Scopes:    slices.Clone(key.Scopes),
+		Workspace: key.Workspace,
+	})
+	// A quota event raised by the request goes to the key's own webhook.
+	return webhook.WithURL(ctx, key.WebhookURL)
+}
+
+// builtinKeyIDs are the IDs of the keys AuthMiddleware synthesizes rather
+// than reads from the store.
+var builtinKeyIDs = []string{"master-key", "bootstrap-admin", "bootstrap-read-only"}
+
+// runAsKey returns a context for sending a request again as the API key
+// keyID rather than as the admin caller of ctx: it carries the key's identity
+// and ctx's trace ID, and is cancelled with ctx. An empty keyID runs the
+// request with no key. It fails with deadletter.ErrKeyUnavailable when the
+// key no longer exists or is revoked or expired.
+func (h *Handlers) runAsKey(ctx context.Context, keyID string) (context.Context, error) {
+	out, cancel := context.WithCancel(logging.WithTraceID(context.Background(), logging.TraceIDFromContext(ctx)))
+	context.AfterFunc(ctx, cancel)
+	if keyID == "" {
+		return out, nil
+	}
+	if slices.Contains(builtinKeyIDs, keyID) {
+		return storeKeyInContext(out, &APIKey{ID: keyID, Name: keyID, Active: true}), nil
+	}
+	var key *APIKey
+	ok := false
+	if h.Keys != nil {
+		key, ok = h.Keys.Get(ctx, keyID)
+	}
+	if !ok || !key.Active || key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		cancel()
+		return nil, deadletter.ErrKeyUnavailable
+	}
+	return storeKeyInContext(out, key), nil
 }
 
 func defaultErrType(status int) string {
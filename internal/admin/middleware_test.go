@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
 )
 
 func TestAuthMiddleware_ValidKey(t *testing.T) {
@@ -26,6 +28,51 @@ func TestAuthMiddleware_ValidKey(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_PropagatesTier(t *testing.T) {
+	store := NewKeyStore()
+	created, _ := store.Create(context.Background(), "tiered", nil, nil)
+	if err := store.SetTier(context.Background(), created.ID, "free"); err != nil {
+		t.Fatalf("SetTier: %v", err)
+	}
+
+	var gotTier, gotID string
+	handler := AuthMiddleware(store, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTier, _ = authctx.Tier(r.Context())
+		gotID, _ = authctx.KeyID(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+created.Key)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotTier != "free" || gotID != created.ID {
+		t.Fatalf("context carries tier %q and key %q, want free and %s", gotTier, gotID, created.ID)
+	}
+}
+
+func TestAuthMiddleware_PropagatesIdentity(t *testing.T) {
+	store := NewKeyStore()
+	created, _ := store.Create(context.Background(), "billing-svc", []string{ScopeReadOnly}, nil)
+	if err := store.SetWorkspace(context.Background(), created.ID, "payments"); err != nil {
+		t.Fatalf("SetWorkspace: %v", err)
+	}
+
+	var got authctx.KeyIdentity
+	handler := AuthMiddleware(store, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = authctx.Identity(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+created.Key)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.Name != "billing-svc" || got.Workspace != "payments" || len(got.Scopes) != 1 || got.Scopes[0] != ScopeReadOnly {
+		t.Fatalf("context carries identity %+v", got)
+	}
+}
+
 func TestAuthMiddleware_NoAuthHeader(t *testing.T) {
 	store := NewKeyStore()
 
@@ -288,3 +335,34 @@ func TestAuthMiddleware_BootstrapRejectedWhenStoreCannotAnswer(t *testing.T) {
 		t.Errorf("got status %d, want %d", rr.Code, http.StatusUnauthorized)
 	}
 }
+
+// The first key ends bootstrap for good: deleting it must not re-open the
+// bootstrap credential.
+func TestAuthMiddleware_BootstrapLockedAfterFirstKey(t *testing.T) {
+	t.Setenv("ADMIN_BOOTSTRAP_KEY", "bootstrap-secret")
+	store := NewKeyStore()
+	handler := AuthMiddleware(store, "")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	status := func() int {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer bootstrap-secret")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if got := status(); got != http.StatusOK {
+		t.Fatalf("fresh store: got status %d, want %d", got, http.StatusOK)
+	}
+	created, err := store.Create(context.Background(), "first-admin", []string{ScopeAdmin}, nil)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	if err := store.Delete(context.Background(), created.ID); err != nil {
+		t.Fatalf("delete key: %v", err)
+	}
+	if got := status(); got != http.StatusUnauthorized {
+		t.Errorf("after the first key: got status %d, want %d", got, http.StatusUnauthorized)
+	}
+}
@@ -14,6 +14,10 @@ const (
 	defaultLogsLimit     = 50
 	maxLogsLimit         = 200
 	maxLogsStatsLimit    = 100
+	// An export pages through the store, so its limit caps the total rows
+	// written rather than one page.
+	defaultLogsExportLimit = 10000
+	maxLogsExportLimit     = 100000
 )
 
 // parseLimit reads the optional "limit" query parameter, returning def when it
@@ -66,3 +70,19 @@ func parseSince(w http.ResponseWriter, r *http.Request) (*time.Time, bool) {
 	}
 	return &parsed, true
 }
+
+// parseDryRun reads the optional "dry_run" query parameter, defaulting to
+// false. A value that is not a boolean writes a 400 response and reports false
+// so the caller returns.
+func parseDryRun(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	raw := r.URL.Query().Get("dry_run")
+	if raw == "" {
+		return false, true
+	}
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid dry_run: must be true or false", "invalid_request_error", "invalid_request")
+		return false, false
+	}
+	return parsed, true
+}
@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
 	"github.com/ferro-labs/ai-gateway/internal/migrations"
 	"github.com/ferro-labs/ai-gateway/internal/sqldb"
 )
@@ -21,12 +22,19 @@ type SQLStore struct {
 	dialect       sqldb.Dialect
 	stmtGetByID   *sql.Stmt
 	stmtGetByHash *sql.Stmt
+	stmtGetByPrev *sql.Stmt
 	stmtRevoke    *sql.Stmt
+	stmtInactive  *sql.Stmt
 	stmtUpdate    *sql.Stmt
 	stmtSetExpiry *sql.Stmt
+	stmtSetTier   *sql.Stmt
+	stmtSetWS     *sql.Stmt
+	stmtSetHook   *sql.Stmt
+	stmtSetNotice *sql.Stmt
 	stmtDelete    *sql.Stmt
 	stmtUsage     *sql.Stmt
 	stmtRotate    *sql.Stmt
+	stmtOverlap   *sql.Stmt
 }
 
 // NewSQLiteStore creates a SQLite-backed key store.
@@ -67,7 +75,7 @@ func (s *SQLStore) init(ctx context.Context) error {
 
 // keyRowSelect lists the columns scanAPIKey expects. key_display stands in for
 // the secret: the store has no way to produce the plaintext.
-const keyRowSelect = `SELECT id, key_display, name, scopes, created_at, revoked_at, expires_at, rotated_at, last_used_at, usage_count, active FROM api_keys`
+const keyRowSelect = `SELECT id, key_display, name, scopes, created_at, revoked_at, expires_at, rotated_at, last_used_at, usage_count, active, tier, workspace, webhook_url, expiry_notice_for, previous_key_display, previous_key_expires_at FROM api_keys`
 
 func (s *SQLStore) prepareStmts(ctx context.Context) error {
 	stmts := []struct {
@@ -76,12 +84,21 @@ func (s *SQLStore) prepareStmts(ctx context.Context) error {
 	}{
 		{&s.stmtGetByID, keyRowSelect + ` WHERE id = ?`},
 		{&s.stmtGetByHash, keyRowSelect + ` WHERE key_hash = ?`},
+		{&s.stmtGetByPrev, keyRowSelect + ` WHERE previous_key_hash = ?`},
 		{&s.stmtRevoke, `UPDATE api_keys SET revoked_at = ?, active = ? WHERE id = ?`},
+		{&s.stmtInactive, `UPDATE api_keys SET active = ? WHERE id = ?`},
 		{&s.stmtUpdate, `UPDATE api_keys SET name = ?, scopes = ? WHERE id = ?`},
 		{&s.stmtSetExpiry, `UPDATE api_keys SET expires_at = ? WHERE id = ?`},
+		{&s.stmtSetTier, `UPDATE api_keys SET tier = ? WHERE id = ?`},
+		{&s.stmtSetWS, `UPDATE api_keys SET workspace = ? WHERE id = ?`},
+		{&s.stmtSetHook, `UPDATE api_keys SET webhook_url = ? WHERE id = ?`},
+		{&s.stmtSetNotice, `UPDATE api_keys SET expiry_notice_for = ? WHERE id = ?`},
 		{&s.stmtDelete, `DELETE FROM api_keys WHERE id = ?`},
 		{&s.stmtUsage, `UPDATE api_keys SET usage_count = usage_count + 1, last_used_at = ? WHERE id = ?`},
-		{&s.stmtRotate, `UPDATE api_keys SET key_hash = ?, key_display = ?, rotated_at = ? WHERE id = ?`},
+		{&s.stmtRotate, `UPDATE api_keys SET key_hash = ?, key_display = ?, rotated_at = ?, previous_key_hash = NULL, previous_key_display = NULL, previous_key_expires_at = NULL WHERE id = ?`},
+		// SET expressions read the row as it was, so the current secret moves
+		// to the previous columns as the new one replaces it.
+		{&s.stmtOverlap, `UPDATE api_keys SET previous_key_hash = key_hash, previous_key_display = key_display, previous_key_expires_at = ?, key_hash = ?, key_display = ?, rotated_at = ? WHERE id = ?`},
 	}
 	for _, s2 := range stmts {
 		// These are long-lived prepared statements cached on the SQLStore for
@@ -102,7 +119,7 @@ func (s *SQLStore) Close() error {
 	if s == nil || s.db == nil {
 		return nil
 	}
-	for _, stmt := range []*sql.Stmt{s.stmtGetByID, s.stmtGetByHash, s.stmtRevoke, s.stmtUpdate, s.stmtSetExpiry, s.stmtDelete, s.stmtUsage, s.stmtRotate} {
+	for _, stmt := range []*sql.Stmt{s.stmtGetByID, s.stmtGetByHash, s.stmtGetByPrev, s.stmtRevoke, s.stmtInactive, s.stmtUpdate, s.stmtSetExpiry, s.stmtSetTier, s.stmtSetWS, s.stmtSetHook, s.stmtSetNotice, s.stmtDelete, s.stmtUsage, s.stmtRotate, s.stmtOverlap} {
 		if stmt != nil {
 			_ = stmt.Close()
 		}
@@ -140,8 +157,22 @@ func (s *SQLStore) Create(ctx context.Context, name string, scopes []string, exp
 INSERT INTO api_keys(id, key_hash, key_display, name, scopes, created_at, revoked_at, expires_at, rotated_at, active, usage_count, last_used_at)
 VALUES(?, ?, ?, ?, ?, ?, NULL, ?, NULL, ?, ?, NULL)`)
 
+	// The first key also locks bootstrap; both land in one transaction so a
+	// key never exists without the lock.
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create key: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
 	//nolint:gosec // G701 false positive: q is a static SQL template (s.bind rewrites placeholders); all values are passed as bound parameters, not interpolated.
-	if _, err := s.db.ExecContext(ctx, q, id, hashKey(key), displayKey(key), name, string(scopesJSON), now, expiresAt, true, 0); err != nil {
+	if _, err := tx.ExecContext(ctx, q, id, hashKey(key), displayKey(key), name, string(scopesJSON), now, expiresAt, true, 0); err != nil {
+		return nil, fmt.Errorf("create key: %w", err)
+	}
+	lock := sqldb.Bind(s.dialect, "INSERT INTO api_keys_bootstrap(id, locked_at) VALUES(1, ?) ON CONFLICT(id) DO NOTHING")
+	if _, err := tx.ExecContext(ctx, lock, now); err != nil {
+		return nil, fmt.Errorf("lock bootstrap: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("create key: %w", err)
 	}
 
@@ -171,6 +202,20 @@ func (s *SQLStore) IsEmpty(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+// BootstrapLocked reports whether a key has ever been created in the store.
+// The lock row outlives the keys, so deleting them all does not clear it.
+func (s *SQLStore) BootstrapLocked(ctx context.Context) (bool, error) {
+	var one int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM api_keys_bootstrap LIMIT 1").Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read bootstrap lock: %w", err)
+	}
+	return true, nil
+}
+
 // Get retrieves an API key by ID from the SQL store.
 func (s *SQLStore) Get(ctx context.Context, id string) (*APIKey, bool) {
 	key, err := s.scanOne(ctx, s.stmtGetByID, id)
@@ -233,6 +278,19 @@ func (s *SQLStore) Revoke(ctx context.Context, id string) error {
 	return nil
 }
 
+// Deactivate marks an API key inactive without recording a revocation.
+func (s *SQLStore) Deactivate(ctx context.Context, id string) error {
+	res, err := s.stmtInactive.ExecContext(ctx, false, id)
+	if err != nil {
+		return fmt.Errorf("deactivate key: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return nil
+}
+
 // Update modifies API key metadata (name/scopes).
 func (s *SQLStore) Update(ctx context.Context, id string, name string, scopes []string) (*APIKey, error) {
 	current, err := s.lookupForMutate(ctx, id)
@@ -277,6 +335,62 @@ func (s *SQLStore) SetExpiration(ctx context.Context, id string, expiresAt *time
 	return nil
 }
 
+// SetTier assigns an API key to a rate-limit tier. An empty tier is stored as
+// NULL.
+func (s *SQLStore) SetTier(ctx context.Context, id, tier string) error {
+	res, err := s.stmtSetTier.ExecContext(ctx, sql.NullString{String: tier, Valid: tier != ""}, id)
+	if err != nil {
+		return fmt.Errorf("set key tier: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return nil
+}
+
+// SetWorkspace assigns an API key to a workspace. An empty workspace is stored
+// as NULL.
+func (s *SQLStore) SetWorkspace(ctx context.Context, id, workspace string) error {
+	res, err := s.stmtSetWS.ExecContext(ctx, sql.NullString{String: workspace, Valid: workspace != ""}, id)
+	if err != nil {
+		return fmt.Errorf("set key workspace: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return nil
+}
+
+// SetWebhookURL sets the URL the key's lifecycle events are also sent to. An
+// empty URL is stored as NULL.
+func (s *SQLStore) SetWebhookURL(ctx context.Context, id, webhookURL string) error {
+	res, err := s.stmtSetHook.ExecContext(ctx, sql.NullString{String: webhookURL, Valid: webhookURL != ""}, id)
+	if err != nil {
+		return fmt.Errorf("set key webhook URL: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return nil
+}
+
+// SetExpiryNotice records that an expiring notice was sent for the key's
+// expiry at expiresAt.
+func (s *SQLStore) SetExpiryNotice(ctx context.Context, id string, expiresAt time.Time) error {
+	res, err := s.stmtSetNotice.ExecContext(ctx, expiresAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("set key expiry notice: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return nil
+}
+
 // Delete removes an API key by ID.
 func (s *SQLStore) Delete(ctx context.Context, id string) error {
 	res, err := s.stmtDelete.ExecContext(ctx, id)
@@ -301,7 +415,18 @@ func (s *SQLStore) ValidateKey(ctx context.Context, key string) (*APIKey, bool)
 	if key == "" {
 		return nil, false
 	}
-	apiKey, err := s.scanOne(ctx, s.stmtGetByHash, hashKey(key))
+	hash := hashKey(key)
+	apiKey, err := s.scanOne(ctx, s.stmtGetByHash, hash)
+	previous := false
+	if errors.Is(err, sql.ErrNoRows) {
+		// A secret replaced by a rotation validates until its overlap ends;
+		// scanAPIKey has already cleared an overlap that is over.
+		apiKey, err = s.scanOne(ctx, s.stmtGetByPrev, hash)
+		if err == nil && apiKey.PreviousKeyExpiresAt == nil {
+			return nil, false
+		}
+		previous = true
+	}
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, false
 	}
@@ -325,19 +450,29 @@ func (s *SQLStore) ValidateKey(ctx context.Context, key string) (*APIKey, bool)
 		apiKey.UsageCount++
 		apiKey.LastUsedAt = &now
 	}
+	if previous {
+		metrics.PreviousKeyRequests.WithLabelValues(apiKey.ID).Inc()
+	}
 	return apiKey, true
 }
 
-// RotateKey rotates the secret value for an existing API key. The returned key
-// carries the new secret, which the read-back cannot recover.
-func (s *SQLStore) RotateKey(ctx context.Context, id string) (*APIKey, error) {
+// RotateKey rotates the secret value for an existing API key. The previous
+// secret stays valid for overlap, or stops validating at once when overlap is
+// zero; a secret an earlier rotation left valid is retired either way. The
+// returned key carries the new secret, which the read-back cannot recover.
+func (s *SQLStore) RotateKey(ctx context.Context, id string, overlap time.Duration) (*APIKey, error) {
 	newKey, err := generateAPIKeyString()
 	if err != nil {
 		return nil, err
 	}
 	now := time.Now().UTC()
 
-	res, err := s.stmtRotate.ExecContext(ctx, hashKey(newKey), displayKey(newKey), now, id)
+	var res sql.Result
+	if overlap > 0 {
+		res, err = s.stmtOverlap.ExecContext(ctx, now.Add(overlap), hashKey(newKey), displayKey(newKey), now, id)
+	} else {
+		res, err = s.stmtRotate.ExecContext(ctx, hashKey(newKey), displayKey(newKey), now, id)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("rotate key: %w", err)
 	}
@@ -379,6 +514,12 @@ func scanAPIKey(scanner interface {
 		expires   sql.NullTime
 		rotated   sql.NullTime
 		lastUsed  sql.NullTime
+		tier      sql.NullString
+		workspace sql.NullString
+		hookURL   sql.NullString
+		noticeFor sql.NullTime
+		prevKey   sql.NullString
+		prevUntil sql.NullTime
 	)
 
 	err := scanner.Scan(
@@ -393,6 +534,12 @@ func scanAPIKey(scanner interface {
 		&lastUsed,
 		&k.UsageCount,
 		&k.Active,
+		&tier,
+		&workspace,
+		&hookURL,
+		&noticeFor,
+		&prevKey,
+		&prevUntil,
 	)
 	if err != nil {
 		return nil, err
@@ -417,6 +564,19 @@ func scanAPIKey(scanner interface {
 		t := lastUsed.Time
 		k.LastUsedAt = &t
 	}
+	k.Tier = tier.String
+	k.Workspace = workspace.String
+	k.WebhookURL = hookURL.String
+	if noticeFor.Valid {
+		t := noticeFor.Time
+		k.ExpiryNoticeFor = &t
+	}
+	if prevUntil.Valid {
+		t := prevUntil.Time
+		k.PreviousKey = prevKey.String
+		k.PreviousKeyExpiresAt = &t
+		clearExpiredPreviousKey(&k, time.Now())
+	}
 	return &k, nil
 }
 
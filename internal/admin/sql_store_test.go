@@ -110,7 +110,60 @@ func runStoreContract(t *testing.T, store Store) {
 		t.Fatalf("expected key to validate after clearing expiration")
 	}
 
-	rotated, err := store.RotateKey(context.Background(), created.ID)
+	if err := store.SetTier(context.Background(), created.ID, "free"); err != nil {
+		t.Fatalf("set tier: %v", err)
+	}
+	if validated, valid := store.ValidateKey(context.Background(), created.Key); !valid || validated.Tier != "free" {
+		t.Fatalf("expected validated key to carry tier free, got %+v", validated)
+	}
+	if err := store.SetTier(context.Background(), created.ID, ""); err != nil {
+		t.Fatalf("clear tier: %v", err)
+	}
+	if fetched, _ := store.Get(context.Background(), created.ID); fetched.Tier != "" {
+		t.Fatalf("expected tier cleared, got %q", fetched.Tier)
+	}
+	if err := store.SetTier(context.Background(), "missing-id", "free"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("set tier on missing key: got %v, want ErrKeyNotFound", err)
+	}
+
+	if err := store.SetWorkspace(context.Background(), created.ID, "payments"); err != nil {
+		t.Fatalf("set workspace: %v", err)
+	}
+	if validated, valid := store.ValidateKey(context.Background(), created.Key); !valid || validated.Workspace != "payments" {
+		t.Fatalf("expected validated key to carry workspace payments, got %+v", validated)
+	}
+	if err := store.SetWorkspace(context.Background(), created.ID, ""); err != nil {
+		t.Fatalf("clear workspace: %v", err)
+	}
+	if fetched, _ := store.Get(context.Background(), created.ID); fetched.Workspace != "" {
+		t.Fatalf("expected workspace cleared, got %q", fetched.Workspace)
+	}
+	if err := store.SetWorkspace(context.Background(), "missing-id", "payments"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("set workspace on missing key: got %v, want ErrKeyNotFound", err)
+	}
+
+	if err := store.SetWebhookURL(context.Background(), created.ID, "https://hooks.example.com/payments"); err != nil {
+		t.Fatalf("set webhook URL: %v", err)
+	}
+	if fetched, _ := store.Get(context.Background(), created.ID); fetched.WebhookURL != "https://hooks.example.com/payments" {
+		t.Fatalf("expected webhook URL stored, got %q", fetched.WebhookURL)
+	}
+	if err := store.SetWebhookURL(context.Background(), created.ID, ""); err != nil {
+		t.Fatalf("clear webhook URL: %v", err)
+	}
+	if fetched, _ := store.Get(context.Background(), created.ID); fetched.WebhookURL != "" {
+		t.Fatalf("expected webhook URL cleared, got %q", fetched.WebhookURL)
+	}
+
+	noticeFor := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := store.SetExpiryNotice(context.Background(), created.ID, noticeFor); err != nil {
+		t.Fatalf("set expiry notice: %v", err)
+	}
+	if fetched, _ := store.Get(context.Background(), created.ID); fetched.ExpiryNoticeFor == nil || !fetched.ExpiryNoticeFor.Equal(noticeFor) {
+		t.Fatalf("expected expiry notice for %v stored, got %v", noticeFor, fetched.ExpiryNoticeFor)
+	}
+
+	rotated, err := store.RotateKey(context.Background(), created.ID, 0)
 	if err != nil {
 		t.Fatalf("rotate key: %v", err)
 	}
@@ -140,6 +193,14 @@ func runStoreContract(t *testing.T, store Store) {
 	}
 }
 
+func TestSQLiteStoreRotationOverlap(t *testing.T) {
+	runRotationOverlapContract(t, newSQLiteTestStore(t))
+}
+
+func TestSQLiteStoreBootstrapLock(t *testing.T) {
+	runBootstrapLockContract(t, newSQLiteTestStore(t))
+}
+
 func TestSQLiteStoreExpiration(t *testing.T) {
 	store := newSQLiteTestStore(t)
 
@@ -19,12 +19,31 @@ type Store interface {
 	// rather than a bare bool so callers can distinguish "no keys" from a
 	// store that could not answer.
 	IsEmpty(ctx context.Context) (bool, error)
+	// BootstrapLocked reports whether the store has ever held a key. The
+	// first Create locks it for good, so bootstrap credentials stay refused
+	// even after every key is deleted.
+	BootstrapLocked(ctx context.Context) (bool, error)
 	Revoke(ctx context.Context, id string) error
+	// Deactivate marks the key inactive without revoking it, as the expiry
+	// job does once a key is past its expires_at.
+	Deactivate(ctx context.Context, id string) error
 	Update(ctx context.Context, id string, name string, scopes []string) (*APIKey, error)
 	SetExpiration(ctx context.Context, id string, expiresAt *time.Time) error
+	// SetTier assigns the key to a rate-limit tier; an empty tier clears it.
+	SetTier(ctx context.Context, id, tier string) error
+	// SetWorkspace assigns the key to a workspace; an empty workspace clears it.
+	SetWorkspace(ctx context.Context, id, workspace string) error
+	// SetWebhookURL sets the URL the key's lifecycle events are also sent
+	// to; an empty URL clears it.
+	SetWebhookURL(ctx context.Context, id, webhookURL string) error
+	// SetExpiryNotice records that an expiring notice was sent for the
+	// key's expiry at expiresAt.
+	SetExpiryNotice(ctx context.Context, id string, expiresAt time.Time) error
 	Delete(ctx context.Context, id string) error
 	ValidateKey(ctx context.Context, key string) (*APIKey, bool)
-	RotateKey(ctx context.Context, id string) (*APIKey, error)
+	// RotateKey replaces the key's secret. The previous secret keeps
+	// validating for overlap; zero invalidates it at once.
+	RotateKey(ctx context.Context, id string, overlap time.Duration) (*APIKey, error)
 	// Ping reports whether the store is reachable. Readiness probes call it to
 	// gate traffic; it must be cheap and return quickly.
 	Ping(ctx context.Context) error
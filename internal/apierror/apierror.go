@@ -82,10 +82,45 @@ func RouteErrorDetails(err error) (status int, errType, code string) {
 		return http.StatusTooManyRequests, errTypeRateLimit, "provider_saturated"
 	}
 
+	// Checked before ErrTierLimitExceeded, which a tier's stream cap also
+	// wraps: the caller should wait for a stream to end, not for its quota.
+	if errors.Is(err, core.ErrStreamLimitExceeded) {
+		return http.StatusTooManyRequests, errTypeRateLimit, "stream_limit_exceeded"
+	}
+
+	if errors.Is(err, core.ErrTierLimitExceeded) {
+		return http.StatusTooManyRequests, errTypeRateLimit, "tier_limit_exceeded"
+	}
+
+	// Serving the request would have sent its data out of the region its key
+	// is bound to. Retrying cannot help, so it is not reported as a 5xx.
+	if errors.Is(err, core.ErrResidencyUnsatisfied) {
+		return http.StatusForbidden, errTypeInvalidRequest, "data_residency_unsatisfied"
+	}
+
+	// The provider answered, but with a response the gateway would not pass
+	// on: a fault upstream of it, like any bad gateway.
+	if errors.Is(err, core.ErrMalformedResponse) {
+		return http.StatusBadGateway, errTypeUpstream, "malformed_provider_response"
+	}
+
+	if errors.Is(err, core.ErrImageTooLarge) {
+		return http.StatusBadRequest, errTypeInvalidRequest, "image_too_large"
+	}
+
+	if errors.Is(err, core.ErrRetryBudgetExhausted) {
+		return http.StatusBadGateway, errTypeUpstream, "retry_budget_exhausted"
+	}
+
 	var unsupportedParam *core.UnsupportedParamError
 	if errors.As(err, &unsupportedParam) {
 		return http.StatusBadRequest, errTypeInvalidRequest, "unsupported_parameter"
 	}
 
+	var imageFetch *core.ImageFetchError
+	if errors.As(err, &imageFetch) {
+		return http.StatusBadRequest, errTypeInvalidRequest, "image_fetch_failed"
+	}
+
 	return status, errType, code
 }
@@ -242,3 +242,72 @@ func TestRouteErrorDetails_DeliberateRateLimitRejectionStays429(t *testing.T) {
 		t.Errorf("type/code = %q/%q, want rate_limit_error/rate_limit_exceeded", errType, code)
 	}
 }
+
+func TestRouteErrorDetails_TierLimitExceeded(t *testing.T) {
+	err := fmt.Errorf("%w: tier %q allows 5 requests per second", core.ErrTierLimitExceeded, "free")
+	status, errType, code := RouteErrorDetails(err)
+	if status != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", status)
+	}
+	if errType != errTypeRateLimit {
+		t.Fatalf("expected rate_limit_error, got %q", errType)
+	}
+	if code != "tier_limit_exceeded" {
+		t.Fatalf("expected tier_limit_exceeded, got %q", code)
+	}
+}
+
+func TestRouteErrorDetails_StreamLimitExceeded(t *testing.T) {
+	// A tier's stream cap wraps both errors; the stream code must win.
+	err := fmt.Errorf("%w: %w: tier %q allows 1 concurrent streams", core.ErrStreamLimitExceeded, core.ErrTierLimitExceeded, "free")
+	status, errType, code := RouteErrorDetails(err)
+	if status != http.StatusTooManyRequests || errType != errTypeRateLimit || code != "stream_limit_exceeded" {
+		t.Fatalf("got %d %q %q, want 429 rate_limit_error stream_limit_exceeded", status, errType, code)
+	}
+}
+
+func TestRouteErrorDetails_MalformedResponse(t *testing.T) {
+	err := fmt.Errorf("openai: %w: no_choices", core.ErrMalformedResponse)
+	status, errType, code := RouteErrorDetails(err)
+	if status != http.StatusBadGateway || errType != errTypeUpstream || code != "malformed_provider_response" {
+		t.Fatalf("got %d %q %q, want 502 upstream_error malformed_provider_response", status, errType, code)
+	}
+}
+
+func TestRouteErrorDetails_RetryBudgetExhausted(t *testing.T) {
+	err := fmt.Errorf("all providers failed: provider anthropic attempt 1: anthropic: %w", core.ErrRetryBudgetExhausted)
+	status, errType, code := RouteErrorDetails(err)
+	if status != http.StatusBadGateway || errType != errTypeUpstream || code != "retry_budget_exhausted" {
+		t.Fatalf("got %d %q %q, want 502 upstream_error retry_budget_exhausted", status, errType, code)
+	}
+}
+
+func TestRouteErrorDetails_ResidencyUnsatisfied(t *testing.T) {
+	err := fmt.Errorf("%w: the key requires region %q", core.ErrResidencyUnsatisfied, "eu")
+	status, errType, code := RouteErrorDetails(err)
+	if status != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", status)
+	}
+	if errType != errTypeInvalidRequest {
+		t.Fatalf("expected invalid_request_error, got %q", errType)
+	}
+	if code != "data_residency_unsatisfied" {
+		t.Fatalf("expected data_residency_unsatisfied, got %q", code)
+	}
+}
+
+func TestRouteErrorDetails_ImageTooLarge(t *testing.T) {
+	err := fmt.Errorf("%w: message 1 image 1 is 9000x9000 pixels", core.ErrImageTooLarge)
+	status, errType, code := RouteErrorDetails(err)
+	if status != http.StatusBadRequest || errType != errTypeInvalidRequest || code != "image_too_large" {
+		t.Fatalf("got %d %q %q, want 400 invalid_request_error image_too_large", status, errType, code)
+	}
+}
+
+func TestRouteErrorDetails_ImageFetchFailed(t *testing.T) {
+	err := fmt.Errorf("route: %w", &core.ImageFetchError{Provider: "ollama", URL: "http://10.0.0.1/cat.png", Reason: "address 10.0.0.1 is not public"})
+	status, errType, code := RouteErrorDetails(err)
+	if status != http.StatusBadRequest || errType != errTypeInvalidRequest || code != "image_fetch_failed" {
+		t.Fatalf("got %d %q %q, want 400 invalid_request_error image_fetch_failed", status, errType, code)
+	}
+}
@@ -4,7 +4,10 @@
 // The auth middleware (internal/admin) stores the key ID here after
 // authentication; the gateway core reads it back to populate
 // plugin.Context.Metadata["api_key"] so that per-key plugins (rate-limit,
-// budget) can scope limits to the authenticated caller.
+// budget) can scope limits to the authenticated caller. The key's rate-limit
+// tier travels alongside it so the gateway can enforce tier limits without
+// reaching back into the key store, as do the key's name, scopes, and
+// workspace, which the plugin manager's match rules are evaluated against.
 //
 // Only the stable APIKey.ID — not the raw bearer secret — is stored here.
 package authctx
@@ -15,13 +18,53 @@ import "context"
 // with other packages that store values in context.
 type contextKey struct{}
 
+// tierContextKey carries the rate-limit tier name of the authenticated key.
+type tierContextKey struct{}
+
+// identityContextKey carries the KeyIdentity of the authenticated key.
+type identityContextKey struct{}
+
+// keyIDRecorderKey carries the *KeyIDRecorder installed by WithKeyIDRecorder.
+type keyIDRecorderKey struct{}
+
+// KeyIdentity describes the authenticated key for the plugin manager's match
+// rules. Like the key ID, none of it is secret.
+type KeyIdentity struct {
+	Name      string
+	Scopes    []string
+	Workspace string
+}
+
 // WithKeyID returns a new context that carries the opaque API-key identifier id.
 // id must not be the raw bearer secret; callers should pass a stable, non-secret
 // identifier such as the database row ID of the authenticated key.
 func WithKeyID(ctx context.Context, id string) context.Context {
+	if rec, ok := ctx.Value(keyIDRecorderKey{}).(*KeyIDRecorder); ok {
+		rec.id = id
+	}
 	return context.WithValue(ctx, contextKey{}, id)
 }
 
+// KeyIDRecorder captures the key ID of a request for middleware that runs
+// outside the auth layer, such as the access log. A value stored on a derived
+// context never reaches the layers above it, so WithKeyID also records the ID
+// in the recorder of an enclosing WithKeyIDRecorder. A recorder belongs to one
+// request and must only be read after the request's handler has returned.
+type KeyIDRecorder struct {
+	id string
+}
+
+// KeyID returns the recorded key ID, or "" when the request was not
+// authenticated with a key.
+func (r *KeyIDRecorder) KeyID() string { return r.id }
+
+// WithKeyIDRecorder returns a context whose descendants record their key ID
+// in the returned recorder.
+func WithKeyIDRecorder(ctx context.Context) (context.Context, *KeyIDRecorder) {
+	rec := &KeyIDRecorder{}
+	return context.WithValue(ctx, keyIDRecorderKey{}, rec), rec
+}
+
 // KeyID returns the opaque API-key identifier stored by WithKeyID, or ("", false)
 // when no identifier is present in ctx.
 func KeyID(ctx context.Context) (string, bool) {
@@ -31,3 +74,33 @@ func KeyID(ctx context.Context) (string, bool) {
 	}
 	return id, true
 }
+
+// WithTier returns a new context that carries the rate-limit tier assigned to
+// the authenticated key. The gateway core reads it back to enforce that tier's
+// limits.
+func WithTier(ctx context.Context, tier string) context.Context {
+	return context.WithValue(ctx, tierContextKey{}, tier)
+}
+
+// Tier returns the tier name stored by WithTier, or ("", false) when the key
+// has no tier.
+func Tier(ctx context.Context) (string, bool) {
+	tier, ok := ctx.Value(tierContextKey{}).(string)
+	if !ok || tier == "" {
+		return "", false
+	}
+	return tier, true
+}
+
+// WithIdentity returns a new context that carries the authenticated key's
+// identity.
+func WithIdentity(ctx context.Context, id KeyIdentity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, id)
+}
+
+// Identity returns the identity stored by WithIdentity, or (KeyIdentity{},
+// false) when none is present in ctx.
+func Identity(ctx context.Context) (KeyIdentity, bool) {
+	id, ok := ctx.Value(identityContextKey{}).(KeyIdentity)
+	return id, ok
+}
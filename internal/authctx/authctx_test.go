@@ -62,3 +62,43 @@ func TestWithKeyID_DoesNotMutateParent(t *testing.T) {
 		t.Errorf("parent context was mutated: KeyID() = (%q, %v), want empty", id, ok)
 	}
 }
+
+func TestTier(t *testing.T) {
+	t.Parallel()
+	if tier, ok := Tier(context.Background()); ok || tier != "" {
+		t.Errorf("Tier() on empty context = (%q, %v), want empty", tier, ok)
+	}
+	if tier, ok := Tier(WithTier(context.Background(), "")); ok || tier != "" {
+		t.Errorf("Tier() with empty tier = (%q, %v), want empty", tier, ok)
+	}
+	ctx := WithKeyID(WithTier(context.Background(), "free"), "key-1")
+	if tier, ok := Tier(ctx); !ok || tier != "free" {
+		t.Errorf("Tier() = (%q, %v), want (free, true)", tier, ok)
+	}
+	if id, ok := KeyID(ctx); !ok || id != "key-1" {
+		t.Errorf("KeyID() = (%q, %v), want (key-1, true)", id, ok)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	if _, ok := Identity(context.Background()); ok {
+		t.Error("Identity() on empty context reported a value")
+	}
+	want := KeyIdentity{Name: "svc", Scopes: []string{"admin"}, Workspace: "payments"}
+	got, ok := Identity(WithIdentity(context.Background(), want))
+	if !ok || got.Name != want.Name || got.Workspace != want.Workspace || len(got.Scopes) != 1 {
+		t.Errorf("Identity() = (%+v, %v), want (%+v, true)", got, ok, want)
+	}
+}
+
+func TestKeyIDRecorder(t *testing.T) {
+	ctx, rec := WithKeyIDRecorder(context.Background())
+	if rec.KeyID() != "" {
+		t.Errorf("KeyID() before auth = %q, want empty", rec.KeyID())
+	}
+	// The key ID is set on a derived context, as the auth middleware does.
+	_ = WithKeyID(WithTier(ctx, "free"), "key-1")
+	if rec.KeyID() != "key-1" {
+		t.Errorf("KeyID() = %q, want key-1", rec.KeyID())
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -15,15 +17,19 @@ import (
 
 	aigateway "github.com/ferro-labs/ai-gateway"
 	"github.com/ferro-labs/ai-gateway/internal/admin"
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
+	"github.com/ferro-labs/ai-gateway/internal/httpclient"
 	"github.com/ferro-labs/ai-gateway/internal/httpserver"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/internal/middleware"
 	gwotel "github.com/ferro-labs/ai-gateway/internal/otel"
 	"github.com/ferro-labs/ai-gateway/internal/ratelimit"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/internal/signing"
 	"github.com/ferro-labs/ai-gateway/internal/version"
+	"github.com/ferro-labs/ai-gateway/internal/webhook"
 	"github.com/ferro-labs/ai-gateway/providers"
-	bedrockpkg "github.com/ferro-labs/ai-gateway/providers/bedrock"
 )
 
 // CheckProductionSafety returns an error if ALLOW_UNAUTHENTICATED_PROXY=true is
@@ -65,9 +71,9 @@ func Serve() {
 		os.Exit(1)
 	}
 
-	gw, srv, cfgManager, keyStore, logReader, otelShutdown := buildServer()
-	listenErr := runUntilShutdown(gw, srv)
-	gracefulShutdown(srv, gw, cfgManager, keyStore, logReader, otelShutdown, listenErr)
+	gw, srv, router, cfgManager, keyStore, logReader, keyWebhook, otelShutdown := buildServer()
+	listenErr := runUntilShutdown(gw, srv, cfgManager, keyStore)
+	gracefulShutdown(srv, gw, router, cfgManager, keyStore, logReader, keyWebhook, otelShutdown, listenErr)
 }
 
 // buildServer runs the startup sequence: it loads configuration, registers
@@ -78,12 +84,22 @@ func Serve() {
 func buildServer() (
 	*aigateway.Gateway,
 	*http.Server,
+	*httpserver.Router,
 	admin.ConfigManager,
 	admin.Store,
 	requestlog.Reader,
+	*webhook.Sender,
 	gwotel.ShutdownFunc,
 ) {
-	cfg := LoadConfig()
+	// Signed config: the config file, imported bundles, GitOps bundles, and
+	// admin config writes must carry a signature from a trusted key.
+	verifier, err := bundleVerifierFromEnv()
+	if err != nil {
+		logging.Logger.Error("invalid bundle signing settings", "error", err)
+		os.Exit(1)
+	}
+
+	cfg := LoadConfig(verifier)
 	registry := RegisterProviders()
 	masterKey := ResolveMasterKey()
 
@@ -108,6 +124,39 @@ func buildServer() (
 
 	gw := BuildGateway(cfg, registry, logWriter)
 
+	// Requests that fail on every target are kept for re-driving when a
+	// dead-letter backend is configured.
+	deadLetterStore, deadLetterBackend, err := CreateDeadLetterStoreFromEnv(context.Background())
+	if err != nil {
+		logging.Logger.Error("failed to initialize dead-letter store", "error", err)
+		os.Exit(1)
+	}
+	if deadLetterStore != nil {
+		queue := deadletter.NewQueue(deadLetterStore)
+		// Dead letters hold prompts, so they are sealed like request-log
+		// bodies.
+		payloads, err := requestLogCipherFromEnv()
+		if err != nil {
+			logging.Logger.Error("invalid REQUEST_LOG_ENCRYPTION_KEY", "error", err)
+			os.Exit(1)
+		}
+		queue.SetPayloadCipher(payloads)
+		gw.SetDeadLetterSink(queue)
+	}
+
+	// Key lifecycle webhook: key events are POSTed to an endpoint, and to the
+	// key's own webhook_url, so other systems can track key state without
+	// polling the admin API.
+	keyWebhook, err := keyWebhookFromEnv()
+	if err != nil {
+		logging.Logger.Error("invalid KEY_EVENTS_WEBHOOK_URL", "error", err)
+		os.Exit(1)
+	}
+	gw.AddHook(keyWebhook.Hook)
+	if strings.TrimSpace(os.Getenv("KEY_EVENTS_WEBHOOK_URL")) != "" {
+		logging.Logger.Info("key lifecycle webhook enabled")
+	}
+
 	// Initialise OpenTelemetry. Init returns a NoOp provider (and a
 	// no-op shutdown) when neither an OTLP endpoint nor any enabled
 	// exporter is configured, so this is free for users who don't opt in.
@@ -131,12 +180,39 @@ func buildServer() (
 		os.Exit(1)
 	}
 
+	if m, ok := cfgManager.(*admin.GatewayConfigManager); ok && verifier != nil {
+		m.SetBundleVerifier(verifier)
+		logging.Logger.Info("config signatures are verified", "strict", verifier.Strict)
+	}
+
+	// GitOps sync: the config follows a bundle kept in version control, and
+	// the admin API refuses config writes.
+	raw, opts, _, err := gitOpsFromEnv()
+	if err != nil {
+		logging.Logger.Error("invalid GITOPS_SYNC_INTERVAL", "error", err)
+		os.Exit(1)
+	}
+	if raw != "" {
+		opts.Signature = verifier != nil
+		source, err := admin.NewGitOpsSource(raw, opts)
+		if err != nil {
+			logging.Logger.Error("invalid GITOPS_SOURCE", "error", err)
+			os.Exit(1)
+		}
+		cfgManager = admin.NewGitOpsSync(cfgManager, source)
+		logging.Logger.Info("gitops sync enabled; admin config writes are disabled", "source", source.String())
+	}
+
 	keyStore, keyStoreBackend, err := CreateKeyStoreFromEnv(context.Background())
 	if err != nil {
 		logging.Logger.Error("failed to initialize API key store", "error", err)
 		os.Exit(1)
 	}
 	LogDeprecatedBootstrapKeys()
+	if err := issueBootstrapToken(context.Background(), keyStore, masterKey); err != nil {
+		logging.Logger.Error("invalid ADMIN_BOOTSTRAP_ENABLED", "error", err)
+		os.Exit(1)
+	}
 
 	var corsOrigins []string
 	if origins := os.Getenv("CORS_ORIGINS"); origins != "" {
@@ -153,9 +229,35 @@ func buildServer() (
 		os.Exit(1)
 	}
 
+	if _, err := admin.KeyRotationOverlapFromEnv(); err != nil {
+		logging.Logger.Error("invalid KEY_ROTATION_OVERLAP", "error", err)
+		os.Exit(1)
+	}
+	if _, _, _, err := keyExpiryFromEnv(); err != nil {
+		logging.Logger.Error("invalid API key expiry settings", "error", err)
+		os.Exit(1)
+	}
+	if _, err := configWatchIntervalFromEnv(); err != nil {
+		logging.Logger.Error("invalid GATEWAY_CONFIG_WATCH_INTERVAL", "error", err)
+		os.Exit(1)
+	}
+	if _, err := secretRefreshIntervalFromEnv(); err != nil {
+		logging.Logger.Error("invalid SECRETS_REFRESH_INTERVAL", "error", err)
+		os.Exit(1)
+	}
+
 	rlStore := NewRateLimitStore()
 
-	r := httpserver.NewRouter(registry, keyStore, corsOrigins, gw, cfgManager, rlStore, logReader, logMaintainer, masterKey, trustedProxies)
+	router := httpserver.NewRouter(registry, keyStore, corsOrigins, gw, cfgManager, rlStore, logReader, logMaintainer, masterKey, trustedProxies)
+	var r http.Handler = router
+	accessLog, err := AccessLogFromEnv()
+	if err != nil {
+		logging.Logger.Error("invalid ACCESS_LOG", "error", err)
+		os.Exit(1)
+	}
+	if accessLog != nil {
+		r = accessLog(r)
+	}
 
 	addr := defaultListenAddr
 	if p := os.Getenv("PORT"); p != "" {
@@ -171,15 +273,17 @@ func buildServer() (
 		"config_store", configStoreBackend,
 		"api_key_store", keyStoreBackend,
 		"request_log_store", logReaderBackend,
+		"dead_letter_store", deadLetterBackend,
 	)
 
-	return gw, srv, cfgManager, keyStore, logReader, otelShutdown
+	return gw, srv, router, cfgManager, keyStore, logReader, keyWebhook, otelShutdown
 }
 
-// runUntilShutdown starts the HTTP server and optional live model discovery,
-// then blocks until an OS signal or a fatal listen error. It returns the listen
-// error observed, if any.
-func runUntilShutdown(gw *aigateway.Gateway, srv *http.Server) error {
+// runUntilShutdown starts the HTTP server, the API key expiry job, optional
+// live model discovery, and the GitOps sync or config file watch when one is
+// configured, then blocks until an OS signal or a fatal listen error. It
+// returns the listen error observed, if any.
+func runUntilShutdown(gw *aigateway.Gateway, srv *http.Server, cfgManager admin.ConfigManager, keyStore admin.Store) error {
 	// Run the server in a goroutine so the main goroutine can block on signal
 	// or a fatal listen error.
 	serveErr := make(chan error, 1)
@@ -198,6 +302,34 @@ func runUntilShutdown(gw *aigateway.Gateway, srv *http.Server) error {
 		}
 	}
 
+	// API key expiry: deactivates expired keys, deletes them after the
+	// retention period, and publishes expiring/expired key hook events.
+	// buildServer has rejected invalid settings.
+	if interval, retention, notice, _ := keyExpiryFromEnv(); interval > 0 {
+		job := &admin.KeyExpiryJob{
+			Keys:         keyStore,
+			Retention:    retention,
+			NoticeBefore: notice,
+			Notify:       admin.KeyEventPublisher(gw),
+		}
+		go job.Run(ctx, interval)
+	}
+
+	if gitOps, ok := cfgManager.(*admin.GitOpsSync); ok {
+		_, _, interval, _ := gitOpsFromEnv()
+		go gitOps.Run(ctx, interval)
+	}
+	startConfigWatch(ctx, cfgManager)
+	startSecretRefresh(ctx, gw)
+
+	// Opt-in provider warm-up: fetches credentials and opens a pooled
+	// connection to each provider in the background, so the first request
+	// does not pay for them. /health reports its progress per provider.
+	if providerWarmupFromEnv() {
+		go gw.WarmProviders(ctx, 0)
+		logging.Logger.Info("provider warm-up started")
+	}
+
 	var listenErr error
 	select {
 	case <-ctx.Done():
@@ -217,9 +349,11 @@ func runUntilShutdown(gw *aigateway.Gateway, srv *http.Server) error {
 func gracefulShutdown(
 	srv *http.Server,
 	gw *aigateway.Gateway,
+	router *httpserver.Router,
 	cfgManager admin.ConfigManager,
 	keyStore admin.Store,
 	logReader requestlog.Reader,
+	keyWebhook *webhook.Sender,
 	otelShutdown gwotel.ShutdownFunc,
 	listenErr error,
 ) {
@@ -234,9 +368,13 @@ func gracefulShutdown(
 
 	if err := httpserver.CloseResources(
 		httpserver.NamedResource{Name: "gateway", Value: gw},
+		// After the gateway, whose hooks may still be queueing events.
+		httpserver.NamedResource{Name: "key webhook", Value: keyWebhook},
+		httpserver.NamedResource{Name: "router", Value: router},
 		httpserver.NamedResource{Name: "config manager", Value: cfgManager},
 		httpserver.NamedResource{Name: "api key store", Value: keyStore},
 		httpserver.NamedResource{Name: "request log store", Value: logReader},
+		httpserver.NamedResource{Name: "dead-letter store", Value: gw.DeadLetterSink()},
 	); err != nil {
 		logging.Logger.Error("shutdown cleanup error", "error", err)
 	}
@@ -274,6 +412,149 @@ func discoveryIntervalFromEnv() (time.Duration, bool) {
 	return d, true
 }
 
+// Defaults of the API key expiry job's environment variables.
+const (
+	defaultKeyExpiryInterval   = time.Hour
+	defaultKeyExpiryNoticeDays = 7
+)
+
+// keyExpiryFromEnv reads the API key expiry job's settings. It is pure: it
+// performs no logging.
+//
+//   - KEY_EXPIRY_CHECK_INTERVAL is how often the job sweeps (default 1h; 0
+//     disables the job).
+//   - KEY_EXPIRED_RETENTION is how long an expired key is kept before it is
+//     deleted (default: kept forever).
+//   - KEY_EXPIRY_NOTICE_DAYS is how many days before expiry a key is
+//     reported as expiring (default 7; 0 sends no notices).
+//
+// An unparsable or negative value is an error, which startup rejects.
+func keyExpiryFromEnv() (interval, retention, notice time.Duration, err error) {
+	interval = defaultKeyExpiryInterval
+	if raw := strings.TrimSpace(os.Getenv("KEY_EXPIRY_CHECK_INTERVAL")); raw != "" {
+		if interval, err = time.ParseDuration(raw); err != nil || interval < 0 {
+			return 0, 0, 0, fmt.Errorf("KEY_EXPIRY_CHECK_INTERVAL must be a non-negative duration, got %q", raw)
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("KEY_EXPIRED_RETENTION")); raw != "" {
+		if retention, err = time.ParseDuration(raw); err != nil || retention < 0 {
+			return 0, 0, 0, fmt.Errorf("KEY_EXPIRED_RETENTION must be a non-negative duration, got %q", raw)
+		}
+	}
+	days := defaultKeyExpiryNoticeDays
+	if raw := strings.TrimSpace(os.Getenv("KEY_EXPIRY_NOTICE_DAYS")); raw != "" {
+		if days, err = strconv.Atoi(raw); err != nil || days < 0 {
+			return 0, 0, 0, fmt.Errorf("KEY_EXPIRY_NOTICE_DAYS must be a non-negative whole number of days, got %q", raw)
+		}
+	}
+	return interval, retention, time.Duration(days) * 24 * time.Hour, nil
+}
+
+// defaultGitOpsInterval is how often the GitOps sync pulls by default.
+const defaultGitOpsInterval = time.Minute
+
+// gitOpsFromEnv reads the GitOps sync's settings. It is pure: it performs no
+// logging.
+//
+//   - GITOPS_SOURCE is the bundle's Git, OCI, or HTTP URL; unset disables
+//     the sync.
+//   - GITOPS_PATH and GITOPS_REF pick the bundle's file and branch or tag
+//     in a Git repository; GITOPS_PATH also names an OCI artifact's layer.
+//   - GITOPS_USERNAME and GITOPS_TOKEN authenticate to an OCI registry, and
+//     GITOPS_TOKEN alone to an HTTP source.
+//   - GITOPS_SYNC_INTERVAL is how often it pulls (default 1m). An
+//     unparsable or non-positive value is an error.
+func gitOpsFromEnv() (source string, opts admin.GitOpsSourceOptions, interval time.Duration, err error) {
+	source = strings.TrimSpace(os.Getenv("GITOPS_SOURCE"))
+	opts = admin.GitOpsSourceOptions{
+		Path:     strings.TrimSpace(os.Getenv("GITOPS_PATH")),
+		Ref:      strings.TrimSpace(os.Getenv("GITOPS_REF")),
+		Username: strings.TrimSpace(os.Getenv("GITOPS_USERNAME")),
+		Token:    strings.TrimSpace(os.Getenv("GITOPS_TOKEN")),
+		Client:   httpclient.New(30 * time.Second),
+	}
+	interval = defaultGitOpsInterval
+	if raw := strings.TrimSpace(os.Getenv("GITOPS_SYNC_INTERVAL")); raw != "" {
+		if interval, err = time.ParseDuration(raw); err != nil || interval <= 0 {
+			return "", admin.GitOpsSourceOptions{}, 0, fmt.Errorf("GITOPS_SYNC_INTERVAL must be a positive duration, got %q", raw)
+		}
+	}
+	return source, opts, interval, nil
+}
+
+// bundleVerifierFromEnv builds the verifier every applied config is checked
+// with: bundles, admin config writes, and the GATEWAY_CONFIG file. It returns
+// nil when no keys are configured. It is pure: it performs no logging.
+//
+//   - BUNDLE_SIGNING_PUBLIC_KEYS holds the trusted keys: minisign public keys
+//     or PEM public keys such as cosign.pub. BUNDLE_SIGNING_PUBLIC_KEYS_FILE
+//     names a file holding them instead.
+//   - BUNDLE_SIGNATURE_MODE is "verify" (default: a signature that is present
+//     must verify) or "strict" (unsigned bundles are refused too).
+func bundleVerifierFromEnv() (*signing.Verifier, error) {
+	spec := strings.TrimSpace(os.Getenv("BUNDLE_SIGNING_PUBLIC_KEYS"))
+	if path := strings.TrimSpace(os.Getenv("BUNDLE_SIGNING_PUBLIC_KEYS_FILE")); path != "" {
+		if spec != "" {
+			return nil, fmt.Errorf("set only one of BUNDLE_SIGNING_PUBLIC_KEYS and BUNDLE_SIGNING_PUBLIC_KEYS_FILE")
+		}
+		raw, err := os.ReadFile(path) //nolint:gosec // G304: the path is operator configuration, not request input
+		if err != nil {
+			return nil, fmt.Errorf("read BUNDLE_SIGNING_PUBLIC_KEYS_FILE: %w", err)
+		}
+		spec = string(raw)
+	}
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("BUNDLE_SIGNATURE_MODE")))
+	if mode != "" && mode != "verify" && mode != "strict" {
+		return nil, fmt.Errorf("BUNDLE_SIGNATURE_MODE must be verify or strict, got %q", mode)
+	}
+	if strings.TrimSpace(spec) == "" {
+		if mode != "" {
+			return nil, fmt.Errorf("BUNDLE_SIGNATURE_MODE=%s needs BUNDLE_SIGNING_PUBLIC_KEYS", mode)
+		}
+		return nil, nil
+	}
+	v, err := signing.NewVerifier(spec, mode == "strict")
+	if err != nil {
+		return nil, fmt.Errorf("BUNDLE_SIGNING_PUBLIC_KEYS: %w", err)
+	}
+	return v, nil
+}
+
+// keyWebhookFromEnv builds the key lifecycle webhook sender. It is pure: it
+// performs no logging.
+//
+//   - KEY_EVENTS_WEBHOOK_URL is the http or https endpoint every gateway.key.*
+//     event is POSTed to. Unset, events go only to the keys that set a
+//     webhook_url of their own.
+//   - KEY_EVENTS_WEBHOOK_SECRET, when set, signs each delivery with an
+//     HMAC-SHA256 in X-Ferro-Signature.
+func keyWebhookFromEnv() (*webhook.Sender, error) {
+	raw := strings.TrimSpace(os.Getenv("KEY_EVENTS_WEBHOOK_URL"))
+	return webhook.New(raw, os.Getenv("KEY_EVENTS_WEBHOOK_SECRET"), "gateway.key.")
+}
+
+// providerWarmupFromEnv reports whether FERRO_PROVIDER_WARMUP enables the
+// startup provider warm-up. It is pure: it performs no logging.
+func providerWarmupFromEnv() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("FERRO_PROVIDER_WARMUP")), "true")
+}
+
+// maxRequestBodyBytesFromEnv reads MAX_REQUEST_BODY_BYTES, the request body
+// size cap applied when the config leaves max_request_bytes unset. It returns
+// 0, meaning aigateway.DefaultMaxRequestBytes, when the var is unset, and an
+// error when it is not a positive integer.
+func maxRequestBodyBytesFromEnv() (int64, error) {
+	raw := strings.TrimSpace(os.Getenv("MAX_REQUEST_BODY_BYTES"))
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("must be a positive number of bytes, got %q", raw)
+	}
+	return v, nil
+}
+
 // ResolveMasterKey returns the master key from the MASTER_KEY env var.
 func ResolveMasterKey() string {
 	return strings.TrimSpace(os.Getenv("MASTER_KEY"))
@@ -281,22 +562,44 @@ func ResolveMasterKey() string {
 
 // LogDeprecatedBootstrapKeys warns about deprecated env vars.
 func LogDeprecatedBootstrapKeys() {
-	if strings.TrimSpace(os.Getenv("ADMIN_BOOTSTRAP_KEY")) != "" {
-		logging.Logger.Warn("ADMIN_BOOTSTRAP_KEY is deprecated -- use MASTER_KEY instead")
-	}
 	if strings.TrimSpace(os.Getenv("ADMIN_BOOTSTRAP_READ_ONLY_KEY")) != "" {
 		logging.Logger.Warn("ADMIN_BOOTSTRAP_READ_ONLY_KEY is deprecated -- use MASTER_KEY instead")
 	}
 }
 
+// issueBootstrapToken prints the one-time bootstrap token when a deployment
+// starts with no admin credential at all: no MASTER_KEY, no
+// ADMIN_BOOTSTRAP_KEY, and a key store that has never held a key. It returns
+// an error, for startup to fail on, when ADMIN_BOOTSTRAP_ENABLED is invalid;
+// a store that cannot be read only skips the token.
+func issueBootstrapToken(ctx context.Context, keyStore admin.Store, masterKey string) error {
+	if _, err := admin.BootstrapEnabledFromEnv(); err != nil {
+		return err
+	}
+	if masterKey != "" {
+		return nil
+	}
+	token, err := admin.IssueBootstrapToken(ctx, keyStore)
+	if err != nil {
+		logging.Logger.Warn("bootstrap token not issued", "error", err)
+		return nil
+	}
+	if token != "" {
+		logging.Logger.Warn("no admin credential is configured -- create the first admin key with this one-time bootstrap token (POST /admin/keys); it stops working once a key exists",
+			"bootstrap_token", token)
+	}
+	return nil
+}
+
 // LoadConfig loads and validates the gateway config from GATEWAY_CONFIG env var.
-// Returns nil if GATEWAY_CONFIG is not set (caller uses default config).
-func LoadConfig() *aigateway.Config {
+// Returns nil if GATEWAY_CONFIG is not set (caller uses default config). A
+// non-nil verifier checks the file's signature first; see loadSignedConfig.
+func LoadConfig(verifier *signing.Verifier) *aigateway.Config {
 	cfgPath := os.Getenv("GATEWAY_CONFIG")
 	if cfgPath == "" {
 		return nil
 	}
-	loaded, err := aigateway.LoadConfig(cfgPath)
+	loaded, err := loadSignedConfig(cfgPath, verifier)
 	if err != nil {
 		logging.Logger.Error("failed to load config", "error", err)
 		os.Exit(1)
@@ -312,6 +615,29 @@ func LoadConfig() *aigateway.Config {
 	return loaded
 }
 
+// configSignatureSuffix names a config file's detached signature, kept
+// beside it as GitOps keeps a bundle's.
+const configSignatureSuffix = ".sig"
+
+// loadSignedConfig reads and parses the config file at path. With a verifier, the signature in path+".sig" must verify
+// over those contents; a missing one is refused only by a strict verifier.
+func loadSignedConfig(path string, verifier *signing.Verifier) (*aigateway.Config, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: GATEWAY_CONFIG is operator configuration, not request input
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	if verifier != nil {
+		sig, err := os.ReadFile(path + configSignatureSuffix) //nolint:gosec // G304: beside the operator's config file
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading config signature: %w", err)
+		}
+		if err := verifier.Verify(data, sig); err != nil {
+			return nil, fmt.Errorf("config signature: %w", err)
+		}
+	}
+	return aigateway.ParseConfig(path, data)
+}
+
 // RegisterProviders auto-registers all providers found via environment variables.
 func RegisterProviders() *providers.Registry {
 	registry := providers.NewRegistry()
@@ -326,12 +652,7 @@ func registerProviderEntries(registry *providers.Registry, entries []providers.P
 			continue // handled below with its dual-key detection
 		}
 
-		cfg := providers.ProviderConfigFromEnv(entry)
-		if cfg == nil {
-			continue // required env var unset — provider not configured, skip silently
-		}
-
-		p, err := entry.Build(cfg)
+		p, err := BuildProviderFromEnv(entry)
 		if err != nil {
 			// Warn-and-skip so one bad credential cannot stop the whole gateway.
 			// The counter is what keeps that from being a silent partial outage:
@@ -340,24 +661,46 @@ func registerProviderEntries(registry *providers.Registry, entries []providers.P
 			metrics.ProviderInitFailures.WithLabelValues(entry.ID).Inc()
 			continue
 		}
+		if p == nil {
+			continue // required env var unset — provider not configured, skip silently
+		}
 		registry.Register(p)
 		logging.Logger.Info("provider registered", "provider", entry.ID)
 	}
 }
 
+// BuildProviderFromEnv builds entry's provider from its environment variables,
+// resolving secret references as startup does. It returns nil and no error
+// when the provider is not configured.
+func BuildProviderFromEnv(entry providers.ProviderEntry) (providers.Provider, error) {
+	cfg := providers.ProviderConfigFromEnv(entry)
+	if cfg == nil {
+		return nil, nil
+	}
+	cfg, err := resolveProviderConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Build(cfg)
+}
+
 func registerBedrockProvider(registry *providers.Registry) {
 	// AWS Bedrock: register if AWS_REGION, AWS_ACCESS_KEY_ID, or
 	// AWS_BEARER_TOKEN_BEDROCK is set.
-	if region := os.Getenv("AWS_REGION"); region != "" ||
-		os.Getenv("AWS_ACCESS_KEY_ID") != "" ||
-		os.Getenv("AWS_BEARER_TOKEN_BEDROCK") != "" {
-		p, err := bedrockpkg.NewWithOptions(bedrockpkg.Options{
-			Region:          os.Getenv("AWS_REGION"),
-			BearerToken:     os.Getenv("AWS_BEARER_TOKEN_BEDROCK"),
-			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
-			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
-			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
-		})
+	// It builds through the provider entry, not the bedrock package, so a
+	// binary built with the nobedrock tag links no AWS SDK.
+	entry, ok := providers.GetProviderEntry(providers.NameBedrock)
+	if !ok {
+		return
+	}
+	if cfg := providers.ProviderConfigFromEnv(entry); cfg != nil {
+		cfg, err := resolveProviderConfig(cfg)
+		if err != nil {
+			logging.Logger.Error("provider init failed", "provider", providers.NameBedrock, "error", err)
+			metrics.ProviderInitFailures.WithLabelValues(providers.NameBedrock).Inc()
+			return
+		}
+		p, err := entry.Build(cfg)
 		if err != nil {
 			// Same warn-and-skip contract as registerProviderEntries: the counter
 			// is the only machine-readable signal that this provider is missing.
@@ -365,7 +708,7 @@ func registerBedrockProvider(registry *providers.Registry) {
 			metrics.ProviderInitFailures.WithLabelValues(providers.NameBedrock).Inc()
 		} else {
 			registry.Register(p)
-			logging.Logger.Info("provider registered", "provider", providers.NameBedrock, "region", p.Region())
+			logging.Logger.Info("provider registered", "provider", providers.NameBedrock, "region", cfg[providers.CfgKeyRegion])
 		}
 	}
 }
@@ -388,6 +731,15 @@ func BuildGateway(cfg *aigateway.Config, registry *providers.Registry, logWriter
 		)
 	}
 
+	if cfg.MaxRequestBytes == 0 {
+		limit, err := maxRequestBodyBytesFromEnv()
+		if err != nil {
+			logging.Logger.Error("invalid MAX_REQUEST_BODY_BYTES", "error", err)
+			os.Exit(1)
+		}
+		cfg.MaxRequestBytes = limit
+	}
+
 	gw, err := aigateway.New(*cfg)
 	if err != nil {
 		logging.Logger.Error("failed to create gateway", "error", err)
@@ -467,6 +819,42 @@ func NewRateLimitStore() *ratelimit.Store {
 	return store
 }
 
+// AccessLogFromEnv returns the HTTP access log middleware configured by
+// ACCESS_LOG, or nil when it is unset. ACCESS_LOG is "stdout", "stderr", or
+// the path of a file to append to; ACCESS_LOG_SAMPLE_RATE (default 1) is the
+// fraction of requests logged, with server errors always logged; a rate
+// outside (0, 1] is an error rather than a silent 100%. The file
+// stays open for the life of the process; each line is written unbuffered,
+// so nothing is lost at exit.
+func AccessLogFromEnv() (func(http.Handler) http.Handler, error) {
+	dest := strings.TrimSpace(os.Getenv("ACCESS_LOG"))
+	if dest == "" {
+		return nil, nil
+	}
+	cfg := middleware.AccessLogConfig{SampleRate: 1}
+	if raw := strings.TrimSpace(os.Getenv("ACCESS_LOG_SAMPLE_RATE")); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 || v > 1 {
+			return nil, fmt.Errorf("ACCESS_LOG_SAMPLE_RATE must be greater than 0 and at most 1, got %q", raw)
+		}
+		cfg.SampleRate = v
+	}
+	switch dest {
+	case "stdout":
+		cfg.Output = os.Stdout
+	case "stderr":
+		cfg.Output = os.Stderr
+	default:
+		f, err := os.OpenFile(filepath.Clean(dest), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open access log: %w", err)
+		}
+		cfg.Output = f
+	}
+	logging.Logger.Info("access log enabled", "output", dest, "sample_rate", cfg.SampleRate)
+	return middleware.AccessLog(cfg), nil
+}
+
 // PrintStartupBanner prints a branded, informative banner to stderr on server start.
 func PrintStartupBanner(addr string, registry *providers.Registry, cfg *aigateway.Config, masterKey, keyStoreBackend, configStoreBackend string) {
 	const (
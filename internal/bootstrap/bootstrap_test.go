@@ -1,10 +1,16 @@
 package bootstrap
 
 import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/admin"
 	"github.com/ferro-labs/ai-gateway/providers"
 )
 
@@ -141,3 +147,215 @@ func TestDiscoveryIntervalFromEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestProviderWarmupFromEnv(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "false": false, "1": false, "true": true, " TRUE ": true} {
+		t.Setenv("FERRO_PROVIDER_WARMUP", value)
+		if got := providerWarmupFromEnv(); got != want {
+			t.Errorf("providerWarmupFromEnv() with %q = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestKeyExpiryFromEnv(t *testing.T) {
+	const day = 24 * time.Hour
+	tests := []struct {
+		interval, retention, notice string
+		wantInterval, wantRetention time.Duration
+		wantNotice                  time.Duration
+		wantErr                     bool
+	}{
+		{wantInterval: time.Hour, wantNotice: 7 * day},
+		{interval: "15m", retention: "720h", notice: "3", wantInterval: 15 * time.Minute, wantRetention: 30 * day, wantNotice: 3 * day},
+		{interval: "0", notice: "0", wantInterval: 0, wantNotice: 0},
+		{interval: "soon", wantErr: true},
+		{retention: "-1h", wantErr: true},
+		{notice: "-2", wantErr: true},
+		{notice: "a week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("KEY_EXPIRY_CHECK_INTERVAL", tt.interval)
+		t.Setenv("KEY_EXPIRED_RETENTION", tt.retention)
+		t.Setenv("KEY_EXPIRY_NOTICE_DAYS", tt.notice)
+		interval, retention, notice, err := keyExpiryFromEnv()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("keyExpiryFromEnv() with %q/%q/%q: err = %v, wantErr %v", tt.interval, tt.retention, tt.notice, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if interval != tt.wantInterval || retention != tt.wantRetention || notice != tt.wantNotice {
+			t.Errorf("keyExpiryFromEnv() with %q/%q/%q = (%v, %v, %v), want (%v, %v, %v)",
+				tt.interval, tt.retention, tt.notice, interval, retention, notice, tt.wantInterval, tt.wantRetention, tt.wantNotice)
+		}
+	}
+}
+
+func TestKeyWebhookFromEnv(t *testing.T) {
+	// Unset, a sender still serves the keys with a webhook URL of their own.
+	t.Setenv("KEY_EVENTS_WEBHOOK_URL", "")
+	s, err := keyWebhookFromEnv()
+	if s == nil || err != nil {
+		t.Fatalf("unset: got (%v, %v)", s, err)
+	}
+	_ = s.Close()
+	t.Setenv("KEY_EVENTS_WEBHOOK_URL", " https://hooks.example.com/ferro ")
+	s, err = keyWebhookFromEnv()
+	if s == nil || err != nil {
+		t.Fatalf("https URL: got (%v, %v)", s, err)
+	}
+	_ = s.Close()
+	t.Setenv("KEY_EVENTS_WEBHOOK_URL", "hooks.example.com")
+	if _, err := keyWebhookFromEnv(); err == nil {
+		t.Fatal("relative URL: expected an error")
+	}
+}
+
+func TestMaxRequestBodyBytesFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "1048576", want: 1 << 20},
+		{value: " 2048 ", want: 2048},
+		{value: "0", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "10MB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("MAX_REQUEST_BODY_BYTES", tt.value)
+		got, err := maxRequestBodyBytesFromEnv()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("maxRequestBodyBytesFromEnv() with %q = (%d, %v), want (%d, error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAccessLogFromEnv(t *testing.T) {
+	t.Setenv("ACCESS_LOG", "")
+	if mw, err := AccessLogFromEnv(); err != nil || mw != nil {
+		t.Fatalf("unset ACCESS_LOG should disable the access log, got (%v, %v)", mw != nil, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	t.Setenv("ACCESS_LOG", path)
+	mw, err := AccessLogFromEnv()
+	if err != nil || mw == nil {
+		t.Fatalf("AccessLogFromEnv() = (%v, %v)", mw != nil, err)
+	}
+	mw(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"path":"/missing"`) {
+		t.Fatalf("access log file = %q, %v", data, err)
+	}
+
+	t.Setenv("ACCESS_LOG", filepath.Join(t.TempDir(), "no-such-dir", "access.log"))
+	if _, err := AccessLogFromEnv(); err == nil {
+		t.Fatal("expected an error for an unwritable access log path")
+	}
+
+	t.Setenv("ACCESS_LOG", "stdout")
+	for _, raw := range []string{"0", "1.5", "-0.1", "half"} {
+		t.Setenv("ACCESS_LOG_SAMPLE_RATE", raw)
+		if _, err := AccessLogFromEnv(); err == nil {
+			t.Errorf("ACCESS_LOG_SAMPLE_RATE=%q: expected an error", raw)
+		}
+	}
+	t.Setenv("ACCESS_LOG_SAMPLE_RATE", "0.25")
+	if mw, err := AccessLogFromEnv(); err != nil || mw == nil {
+		t.Errorf("ACCESS_LOG_SAMPLE_RATE=0.25: got (%v, %v)", mw != nil, err)
+	}
+}
+
+func TestBundleVerifierFromEnv(t *testing.T) {
+	// A minisign public key: "Ed", an 8-byte key ID, and a 32-byte key.
+	key := base64.StdEncoding.EncodeToString(append([]byte("Ed12345678"), make([]byte, 32)...))
+
+	t.Setenv("BUNDLE_SIGNING_PUBLIC_KEYS", "")
+	t.Setenv("BUNDLE_SIGNING_PUBLIC_KEYS_FILE", "")
+	t.Setenv("BUNDLE_SIGNATURE_MODE", "")
+	if v, err := bundleVerifierFromEnv(); v != nil || err != nil {
+		t.Fatalf("unset: bundleVerifierFromEnv() = %v, %v; want nil, nil", v, err)
+	}
+
+	t.Setenv("BUNDLE_SIGNATURE_MODE", "strict")
+	if _, err := bundleVerifierFromEnv(); err == nil {
+		t.Fatal("strict mode without keys: expected an error")
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "minisign.pub")
+	if err := os.WriteFile(keyFile, []byte("untrusted comment: minisign public key\n"+key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUNDLE_SIGNING_PUBLIC_KEYS_FILE", keyFile)
+	v, err := bundleVerifierFromEnv()
+	if err != nil || v == nil || !v.Strict {
+		t.Fatalf("key file, strict: bundleVerifierFromEnv() = %+v, %v", v, err)
+	}
+
+	t.Setenv("BUNDLE_SIGNING_PUBLIC_KEYS", key)
+	if _, err := bundleVerifierFromEnv(); err == nil {
+		t.Fatal("both keys and key file: expected an error")
+	}
+
+	t.Setenv("BUNDLE_SIGNING_PUBLIC_KEYS_FILE", "")
+	t.Setenv("BUNDLE_SIGNATURE_MODE", "sometimes")
+	if _, err := bundleVerifierFromEnv(); err == nil {
+		t.Fatal("unknown mode: expected an error")
+	}
+	t.Setenv("BUNDLE_SIGNATURE_MODE", "")
+	if v, err := bundleVerifierFromEnv(); err != nil || v.Strict {
+		t.Fatalf("default mode: bundleVerifierFromEnv() = %+v, %v; want a lenient verifier", v, err)
+	}
+	t.Setenv("BUNDLE_SIGNING_PUBLIC_KEYS", "not-a-key")
+	if _, err := bundleVerifierFromEnv(); err == nil {
+		t.Fatal("invalid key: expected an error")
+	}
+}
+
+func TestGitOpsFromEnv(t *testing.T) {
+	t.Setenv("GITOPS_SOURCE", " git+https://github.com/acme/gateway-config.git ")
+	t.Setenv("GITOPS_PATH", "prod/ferrogw-config.json")
+	t.Setenv("GITOPS_REF", "main")
+	t.Setenv("GITOPS_SYNC_INTERVAL", "5m")
+	source, opts, interval, err := gitOpsFromEnv()
+	if err != nil || source != "git+https://github.com/acme/gateway-config.git" || opts.Path != "prod/ferrogw-config.json" || opts.Ref != "main" || interval != 5*time.Minute {
+		t.Errorf("gitOpsFromEnv() = %q, %+v, %v, %v", source, opts, interval, err)
+	}
+
+	t.Setenv("GITOPS_SYNC_INTERVAL", "")
+	if _, _, interval, err := gitOpsFromEnv(); err != nil || interval != defaultGitOpsInterval {
+		t.Errorf("GITOPS_SYNC_INTERVAL unset: interval = %v, %v; want %v", interval, err, defaultGitOpsInterval)
+	}
+	for _, raw := range []string{"soon", "0", "-1m", "30"} {
+		t.Setenv("GITOPS_SYNC_INTERVAL", raw)
+		_, _, _, err := gitOpsFromEnv()
+		if err == nil || !strings.Contains(err.Error(), "GITOPS_SYNC_INTERVAL") {
+			t.Errorf("GITOPS_SYNC_INTERVAL=%q: err = %v, want an error naming the variable", raw, err)
+		}
+	}
+}
+
+// An unparsable ADMIN_BOOTSTRAP_ENABLED fails startup, master key or not,
+// instead of issuing a bootstrap token.
+func TestIssueBootstrapToken_InvalidEnabledFailsStartup(t *testing.T) {
+	t.Setenv("ADMIN_BOOTSTRAP_KEY", "")
+	t.Setenv("ADMIN_BOOTSTRAP_ENABLED", "off")
+	store := admin.NewKeyStore()
+	for _, masterKey := range []string{"", "master-secret"} {
+		if err := issueBootstrapToken(t.Context(), store, masterKey); err == nil {
+			t.Errorf("master key %q: ADMIN_BOOTSTRAP_ENABLED=off did not fail startup", masterKey)
+		}
+	}
+	if token, _ := admin.IssueBootstrapToken(t.Context(), store); token != "" {
+		t.Error("a bootstrap token was issued")
+	}
+
+	t.Setenv("ADMIN_BOOTSTRAP_ENABLED", "false")
+	if err := issueBootstrapToken(t.Context(), store, ""); err != nil {
+		t.Errorf("ADMIN_BOOTSTRAP_ENABLED=false: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/admin"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/signing"
+)
+
+// configFileWatcher reloads the gateway config when the GATEWAY_CONFIG file
+// changes. It polls the file's contents instead of subscribing to file
+// events: Kubernetes updates a mounted ConfigMap by swapping a symlink to a
+// new directory, which an event watch on the old file never sees, while
+// re-reading through the path always lands on the current version.
+type configFileWatcher struct {
+	path     string
+	configs  admin.ConfigManager
+	verifier *signing.Verifier // nil when config signatures are not checked
+	last     [sha256.Size]byte
+}
+
+// newConfigFileWatcher returns a watcher for path, taking its current
+// contents as the config already running. With a verifier, a changed file is
+// applied only when its signature verifies, as at startup.
+func newConfigFileWatcher(path string, configs admin.ConfigManager, verifier *signing.Verifier) (*configFileWatcher, error) {
+	w := &configFileWatcher{path: path, configs: configs, verifier: verifier}
+	sum, err := w.fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	w.last = sum
+	return w, nil
+}
+
+// fingerprint hashes the file and, when signatures are checked, its
+// signature, so a signature written after its file is seen as a change.
+func (w *configFileWatcher) fingerprint() ([sha256.Size]byte, error) {
+	h := sha256.New()
+	data, err := os.ReadFile(w.path) //nolint:gosec // G304: GATEWAY_CONFIG is operator configuration, not request input
+	if err != nil {
+		return [sha256.Size]byte{}, fmt.Errorf("read config file: %w", err)
+	}
+	h.Write(data)
+	if w.verifier != nil {
+		// A missing signature hashes as empty; loading reports it.
+		sig, _ := os.ReadFile(w.path + configSignatureSuffix) //nolint:gosec // G304: beside the operator's config file
+		h.Write(sig)
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum, nil
+}
+
+// Run checks the file every interval until ctx is done.
+func (w *configFileWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.check(ctx); err != nil {
+				logging.Logger.Error("config file not reloaded; the running config is unchanged", "path", w.path, "error", err)
+			}
+		}
+	}
+}
+
+// check reloads the config if the file's contents or signature changed since
+// the last check, and reports whether it did. A change that fails to load,
+// verify, or validate is reported once, not on every later check.
+func (w *configFileWatcher) check(ctx context.Context) (bool, error) {
+	sum, err := w.fingerprint()
+	if err != nil {
+		// Mid-swap, or the mount is briefly gone; the next check retries.
+		return false, err
+	}
+	if sum == w.last {
+		return false, nil
+	}
+	w.last = sum
+
+	cfg, err := loadSignedConfig(w.path, w.verifier)
+	if err != nil {
+		return false, err
+	}
+	if err := aigateway.ValidateConfig(*cfg); err != nil {
+		return false, err
+	}
+	if err := w.configs.ReloadConfig(ctx, *cfg); err != nil {
+		return false, err
+	}
+	logging.Logger.Info("config reloaded from file", "path", w.path,
+		"strategy", cfg.Strategy.Mode,
+		"targets", len(cfg.Targets),
+	)
+	return true, nil
+}
+
+// configWatchIntervalFromEnv reads GATEWAY_CONFIG_WATCH_INTERVAL, how often
+// the GATEWAY_CONFIG file is checked for changes. Unset or 0 disables the
+// watch; any other value must be a positive duration, since a typo such as
+// "30" must not silently leave the watch off. It is pure: it performs no
+// logging.
+func configWatchIntervalFromEnv() (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv("GATEWAY_CONFIG_WATCH_INTERVAL"))
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("GATEWAY_CONFIG_WATCH_INTERVAL must be a non-negative duration, got %q", raw)
+	}
+	return d, nil
+}
+
+// startConfigWatch starts reloading the GATEWAY_CONFIG file on change when
+// GATEWAY_CONFIG_WATCH_INTERVAL enables it. buildServer has already rejected
+// an invalid interval.
+func startConfigWatch(ctx context.Context, cfgManager admin.ConfigManager) {
+	interval, _ := configWatchIntervalFromEnv()
+	path := strings.TrimSpace(os.Getenv("GATEWAY_CONFIG"))
+	if interval == 0 || path == "" {
+		return
+	}
+	if _, ok := cfgManager.(*admin.GitOpsSync); ok {
+		logging.Logger.Warn("GATEWAY_CONFIG_WATCH_INTERVAL ignored: GitOps sync manages the config")
+		return
+	}
+	var verifier *signing.Verifier
+	if src, ok := cfgManager.(interface{ BundleVerifier() *signing.Verifier }); ok {
+		verifier = src.BundleVerifier()
+	}
+	w, err := newConfigFileWatcher(path, cfgManager, verifier)
+	if err != nil {
+		logging.Logger.Warn("config file watch not started", "path", path, "error", err)
+		return
+	}
+	go w.Run(ctx, interval)
+	logging.Logger.Info("config file watch enabled", "path", path, "interval", interval.String())
+}
@@ -0,0 +1,149 @@
+package bootstrap
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/admin"
+	"github.com/ferro-labs/ai-gateway/internal/signing"
+)
+
+// TestConfigFileWatcher_ConfigMapSwap lays out a mounted ConfigMap the way
+// Kubernetes does (config.yaml -> ..data/config.yaml, ..data -> a timestamped
+// directory) and updates it by swapping the ..data symlink.
+func TestConfigFileWatcher_ConfigMapSwap(t *testing.T) {
+	mount := t.TempDir()
+	version := 0
+	publish := func(body string) {
+		t.Helper()
+		version++
+		dir := filepath.Join(mount, "..v"+strconv.Itoa(version))
+		if err := os.Mkdir(dir, 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		tmp := filepath.Join(mount, "..data_tmp")
+		if err := os.Symlink(filepath.Base(dir), tmp); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, filepath.Join(mount, "..data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publish("strategy:\n  mode: fallback\ntargets:\n  - virtual_key: test\n")
+	path := filepath.Join(mount, "config.yaml")
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	cm, err := admin.NewGatewayConfigManager(newTestGateway(t), nil)
+	if err != nil {
+		t.Fatalf("NewGatewayConfigManager: %v", err)
+	}
+	w, err := newConfigFileWatcher(path, cm, nil)
+	if err != nil {
+		t.Fatalf("newConfigFileWatcher: %v", err)
+	}
+	if reloaded, err := w.check(t.Context()); reloaded || err != nil {
+		t.Fatalf("unchanged file: reloaded=%v err=%v", reloaded, err)
+	}
+
+	publish("strategy:\n  mode: single\ntargets:\n  - virtual_key: test\n")
+	if reloaded, err := w.check(t.Context()); !reloaded || err != nil {
+		t.Fatalf("swapped file: reloaded=%v err=%v", reloaded, err)
+	}
+	if mode := cm.GetConfig().Strategy.Mode; mode != aigateway.ModeSingle {
+		t.Fatalf("mode = %q, want single after the swap", mode)
+	}
+
+	// An invalid update is reported once and leaves the config running.
+	publish("strategy:\n  mode: single\n")
+	if _, err := w.check(t.Context()); err == nil {
+		t.Fatal("expected an invalid config to be reported")
+	}
+	if _, err := w.check(t.Context()); err != nil {
+		t.Fatalf("the same invalid config was reported again: %v", err)
+	}
+	if mode := cm.GetConfig().Strategy.Mode; mode != aigateway.ModeSingle || len(cm.GetConfig().Targets) != 1 {
+		t.Fatalf("an invalid update changed the config: %+v", cm.GetConfig())
+	}
+}
+
+func TestConfigFileWatcher_Signed(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := signing.NewVerifier(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(path, "strategy:\n  mode: fallback\ntargets:\n  - virtual_key: test\n")
+
+	cm, err := admin.NewGatewayConfigManager(newTestGateway(t), nil)
+	if err != nil {
+		t.Fatalf("NewGatewayConfigManager: %v", err)
+	}
+	w, err := newConfigFileWatcher(path, cm, verifier)
+	if err != nil {
+		t.Fatalf("newConfigFileWatcher: %v", err)
+	}
+
+	next := "strategy:\n  mode: single\ntargets:\n  - virtual_key: test\n"
+	write(path, next)
+	if reloaded, err := w.check(t.Context()); reloaded || !errors.Is(err, signing.ErrUnsigned) {
+		t.Fatalf("unsigned change: reloaded=%v err=%v, want ErrUnsigned", reloaded, err)
+	}
+
+	// The signature lands after its file; writing it is a change too.
+	write(path+configSignatureSuffix, base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(next))))
+	if reloaded, err := w.check(t.Context()); !reloaded || err != nil {
+		t.Fatalf("signed change: reloaded=%v err=%v", reloaded, err)
+	}
+	if mode := cm.GetConfig().Strategy.Mode; mode != aigateway.ModeSingle {
+		t.Fatalf("mode = %q, want single after the signed change", mode)
+	}
+}
+
+func TestConfigWatchIntervalFromEnv(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		"":    0,
+		"10s": 10 * time.Second,
+		"0":   0,
+	} {
+		t.Setenv("GATEWAY_CONFIG_WATCH_INTERVAL", raw)
+		got, err := configWatchIntervalFromEnv()
+		if err != nil || got != want {
+			t.Errorf("GATEWAY_CONFIG_WATCH_INTERVAL=%q: got %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"-5s", "often", "30"} {
+		t.Setenv("GATEWAY_CONFIG_WATCH_INTERVAL", raw)
+		if _, err := configWatchIntervalFromEnv(); err == nil {
+			t.Errorf("GATEWAY_CONFIG_WATCH_INTERVAL=%q: expected an error", raw)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/internal/secrets"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// secretResolver resolves vault:// and awssm:// references in provider
+// variables and store DSNs. It is built from the environment on first use.
+var secretResolver = secrets.FromEnv
+
+// defaultSecretRefreshInterval is how often provider secret references are
+// re-resolved by default.
+const defaultSecretRefreshInterval = 5 * time.Minute
+
+// envSecret reads the environment variable name, resolving it when it holds a
+// secret reference.
+func envSecret(ctx context.Context, name string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if !secrets.IsRef(value) {
+		return value, nil
+	}
+	resolver, err := secretResolver()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	resolved, err := resolver.Resolve(ctx, value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return resolved, nil
+}
+
+// resolveProviderConfig resolves the secret references in a provider config
+// read from the environment, leaving a config without any untouched.
+func resolveProviderConfig(cfg providers.ProviderConfig) (providers.ProviderConfig, error) {
+	if !hasSecretRefs(cfg) {
+		return cfg, nil
+	}
+	resolver, err := secretResolver()
+	if err != nil {
+		return nil, err
+	}
+	return resolveProviderSecrets(context.Background(), resolver, cfg)
+}
+
+// resolveProviderSecrets returns cfg with its secret references resolved.
+func resolveProviderSecrets(ctx context.Context, resolver *secrets.Resolver, cfg providers.ProviderConfig) (providers.ProviderConfig, error) {
+	if !hasSecretRefs(cfg) {
+		return cfg, nil
+	}
+	resolved, err := resolver.Map(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+func hasSecretRefs(cfg providers.ProviderConfig) bool {
+	for _, v := range cfg {
+		if secrets.IsRef(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretRefreshIntervalFromEnv reads SECRETS_REFRESH_INTERVAL, how often
+// provider secret references are re-resolved (default 5m; 0 disables). An
+// unparsable or negative value is an error. It is pure: it performs no
+// logging.
+func secretRefreshIntervalFromEnv() (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv("SECRETS_REFRESH_INTERVAL"))
+	if raw == "" {
+		return defaultSecretRefreshInterval, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("SECRETS_REFRESH_INTERVAL must be a non-negative duration, got %q", raw)
+	}
+	return d, nil
+}
+
+// providerSecretRefresher re-resolves the secret references in provider
+// variables and rebuilds a provider whose resolved credentials changed, so a
+// key rotated in the secret manager takes effect without a restart.
+type providerSecretRefresher struct {
+	resolver *secrets.Resolver
+	gw       *aigateway.Gateway
+	tracked  []trackedProviderSecrets
+}
+
+type trackedProviderSecrets struct {
+	entry    providers.ProviderEntry
+	raw      providers.ProviderConfig
+	resolved providers.ProviderConfig
+}
+
+// newProviderSecretRefresher tracks the entries whose variables hold secret
+// references, resolving them once as the baseline. It returns nil when none
+// do.
+func newProviderSecretRefresher(ctx context.Context, resolver *secrets.Resolver, gw *aigateway.Gateway, entries []providers.ProviderEntry) *providerSecretRefresher {
+	r := &providerSecretRefresher{resolver: resolver, gw: gw}
+	for _, entry := range entries {
+		raw := providers.ProviderConfigFromEnv(entry)
+		if raw == nil || !hasSecretRefs(raw) {
+			continue
+		}
+		resolved, _ := resolver.Map(ctx, raw)
+		r.tracked = append(r.tracked, trackedProviderSecrets{entry: entry, raw: raw, resolved: resolved})
+	}
+	if len(r.tracked) == 0 {
+		return nil
+	}
+	return r
+}
+
+// Run refreshes every interval until ctx is done.
+func (r *providerSecretRefresher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.refresh(ctx)
+		}
+	}
+}
+
+// refresh re-resolves each tracked provider and rebuilds those whose
+// resolved config changed. It returns how many it rebuilt. A provider whose
+// secrets fail to resolve or build keeps serving with its current
+// credentials.
+func (r *providerSecretRefresher) refresh(ctx context.Context) int {
+	rebuilt := 0
+	for i := range r.tracked {
+		t := &r.tracked[i]
+		resolved, err := r.resolver.Map(ctx, t.raw)
+		if err != nil {
+			logging.Logger.Warn("provider secrets not refreshed", "provider", t.entry.ID, "error", err)
+			continue
+		}
+		if maps.Equal(resolved, t.resolved) {
+			continue
+		}
+		p, err := t.entry.Build(resolved)
+		if err != nil {
+			logging.Logger.Warn("provider not rebuilt with refreshed secrets", "provider", t.entry.ID, "error", err)
+			metrics.ProviderInitFailures.WithLabelValues(t.entry.ID).Inc()
+			continue
+		}
+		r.gw.RegisterProvider(p)
+		t.resolved = resolved
+		rebuilt++
+		logging.Logger.Info("provider rebuilt with refreshed secrets", "provider", t.entry.ID)
+	}
+	return rebuilt
+}
+
+// startSecretRefresh starts re-resolving provider secret references when
+// any provider variable holds one and SECRETS_REFRESH_INTERVAL allows it.
+// buildServer has already rejected an invalid interval.
+func startSecretRefresh(ctx context.Context, gw *aigateway.Gateway) {
+	interval, _ := secretRefreshIntervalFromEnv()
+	if interval == 0 {
+		return
+	}
+	resolver, err := secretResolver()
+	if err != nil {
+		return // already reported when the providers registered
+	}
+	if r := newProviderSecretRefresher(ctx, resolver, gw, providers.AllProviders()); r != nil {
+		go r.Run(ctx, interval)
+		logging.Logger.Info("provider secret refresh enabled", "providers", len(r.tracked), "interval", interval.String())
+	}
+}
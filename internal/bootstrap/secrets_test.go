@@ -0,0 +1,185 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/secrets"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+type fakeSecretBackend struct {
+	mu    sync.Mutex
+	value string
+	err   error
+}
+
+func (b *fakeSecretBackend) set(value string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.value, b.err = value, err
+}
+
+func (b *fakeSecretBackend) Get(context.Context, string, string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.value, b.err
+}
+
+func TestProviderSecretRefresher_RebuildsOnRotation(t *testing.T) {
+	t.Setenv("ROTATING_PROVIDER_KEY", "vault://secret/data/rotating#key")
+	t.Setenv("LITERAL_PROVIDER_KEY", "sk-literal")
+
+	backend := &fakeSecretBackend{value: "key-1"}
+	resolver := secrets.NewResolver()
+	resolver.Register(secrets.SchemeVault, backend)
+
+	var built []string
+	entry := func(id, envVar string) providers.ProviderEntry {
+		return providers.ProviderEntry{
+			ID: id,
+			EnvMappings: []providers.EnvMapping{{
+				ConfigKey: providers.CfgKeyAPIKey,
+				EnvVar:    envVar,
+				Required:  true,
+			}},
+			Build: func(cfg providers.ProviderConfig) (providers.Provider, error) {
+				built = append(built, cfg[providers.CfgKeyAPIKey])
+				return bootstrapProvider{name: id, models: []string{id + "-model"}}, nil
+			},
+		}
+	}
+
+	gw := newTestGateway(t)
+	r := newProviderSecretRefresher(t.Context(), resolver, gw, []providers.ProviderEntry{
+		entry("rotating-provider", "ROTATING_PROVIDER_KEY"),
+		entry("literal-provider", "LITERAL_PROVIDER_KEY"),
+	})
+	if r == nil || len(r.tracked) != 1 {
+		t.Fatalf("want only the provider with a secret reference tracked, got %+v", r)
+	}
+
+	if n := r.refresh(t.Context()); n != 0 {
+		t.Fatalf("unchanged secret rebuilt %d providers", n)
+	}
+
+	backend.set("key-2", nil)
+	if n := r.refresh(t.Context()); n != 1 {
+		t.Fatalf("rotated secret rebuilt %d providers, want 1", n)
+	}
+	if _, ok := gw.GetProvider("rotating-provider"); !ok {
+		t.Fatal("rebuilt provider not registered with the gateway")
+	}
+
+	// A failed lookup keeps the current provider rather than dropping it.
+	backend.set("", errors.New("vault sealed"))
+	if n := r.refresh(t.Context()); n != 0 {
+		t.Fatalf("failed lookup rebuilt %d providers", n)
+	}
+	if len(built) != 1 || built[0] != "key-2" {
+		t.Fatalf("built with keys %v, want [key-2]", built)
+	}
+}
+
+func TestRegisterProviderEntriesResolvesSecretRefs(t *testing.T) {
+	t.Setenv("REF_PROVIDER_KEY", "awssm://unreachable")
+	useSecretResolver(t, secrets.NewResolver()) // no awssm backend
+
+	before := initFailureCount(t, "ref-provider")
+	registry := providers.NewRegistry()
+	registerProviderEntries(registry, []providers.ProviderEntry{{
+		ID: "ref-provider",
+		EnvMappings: []providers.EnvMapping{{
+			ConfigKey: providers.CfgKeyAPIKey,
+			EnvVar:    "REF_PROVIDER_KEY",
+			Required:  true,
+		}},
+		Build: func(providers.ProviderConfig) (providers.Provider, error) {
+			t.Fatal("a provider whose secret cannot resolve must not be built with the reference as its key")
+			return nil, nil
+		},
+	}})
+
+	if _, ok := registry.Get("ref-provider"); ok {
+		t.Fatal("provider with an unresolvable secret should be skipped")
+	}
+	if delta := initFailureCount(t, "ref-provider") - before; delta != 1 {
+		t.Fatalf("provider init failure counter delta = %v, want 1", delta)
+	}
+}
+
+func TestCreateConfigManagerFromEnv_VaultDSN(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "config.db")
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/gateway" || r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"data":     map[string]any{"config_dsn": dsn},
+			"metadata": map[string]any{"version": 1},
+		}})
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	useSecretResolver(t, nil)
+
+	t.Setenv("CONFIG_STORE_BACKEND", "sqlite")
+	t.Setenv("CONFIG_STORE_DSN", "vault://secret/data/gateway#config_dsn")
+	manager, backend, err := CreateConfigManagerFromEnv(t.Context(), newTestGateway(t))
+	if err != nil {
+		t.Fatalf("CreateConfigManagerFromEnv: %v", err)
+	}
+	if closer, ok := manager.(interface{ Close() error }); ok {
+		defer func() { _ = closer.Close() }()
+	}
+	if backend != BackendSQLite {
+		t.Fatalf("backend = %q, want sqlite", backend)
+	}
+
+	t.Setenv("CONFIG_STORE_DSN", "vault://secret/data/other#config_dsn")
+	if _, _, err := CreateConfigManagerFromEnv(t.Context(), newTestGateway(t)); err == nil {
+		t.Fatal("want an error for a DSN secret vault refuses")
+	}
+}
+
+func TestSecretRefreshIntervalFromEnv(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		"":    defaultSecretRefreshInterval,
+		"30s": 30 * time.Second,
+		"0":   0,
+	} {
+		t.Setenv("SECRETS_REFRESH_INTERVAL", raw)
+		got, err := secretRefreshIntervalFromEnv()
+		if err != nil || got != want {
+			t.Errorf("SECRETS_REFRESH_INTERVAL=%q: got %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"-1m", "often", "30"} {
+		t.Setenv("SECRETS_REFRESH_INTERVAL", raw)
+		if _, err := secretRefreshIntervalFromEnv(); err == nil {
+			t.Errorf("SECRETS_REFRESH_INTERVAL=%q: expected an error", raw)
+		}
+	}
+}
+
+// useSecretResolver swaps the package resolver for the test: r when set,
+// otherwise one built afresh from the test's environment.
+func useSecretResolver(t *testing.T, r *secrets.Resolver) {
+	t.Helper()
+	prev := secretResolver
+	t.Cleanup(func() { secretResolver = prev })
+	if r != nil {
+		secretResolver = func() (*secrets.Resolver, error) { return r, nil }
+		return
+	}
+	secretResolver = sync.OnceValues(secrets.NewResolverFromEnv)
+}
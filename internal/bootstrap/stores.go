@@ -3,13 +3,22 @@ package bootstrap
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
 	"github.com/ferro-labs/ai-gateway/internal/admin"
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/internal/sqldb"
+	"github.com/ferro-labs/ai-gateway/internal/sqlitefile"
 )
 
 // Backend name constants returned alongside created stores.
@@ -27,7 +36,10 @@ func CreateKeyStoreFromEnv(ctx context.Context) (admin.Store, string, error) {
 		backend = BackendMemory
 	}
 
-	storeDSN := strings.TrimSpace(os.Getenv("API_KEY_STORE_DSN"))
+	storeDSN, err := envSecret(ctx, "API_KEY_STORE_DSN")
+	if err != nil {
+		return nil, "", err
+	}
 
 	switch backend {
 	case BackendMemory, "in-memory", "inmemory":
@@ -49,6 +61,119 @@ func CreateKeyStoreFromEnv(ctx context.Context) (admin.Store, string, error) {
 	}
 }
 
+// StoreStatus is the outcome of checking one persistence store's settings.
+type StoreStatus struct {
+	// Name is the store's environment variable prefix, e.g. API_KEY_STORE.
+	Name string
+	// Backend is the configured backend; "disabled" for an unset request log
+	// store.
+	Backend string
+	// Err is why the store could not be reached, or nil.
+	Err error
+	// NotCreated reports a SQLite file that does not exist yet, in a
+	// directory that does; the gateway creates it on startup.
+	NotCreated bool
+}
+
+// CheckStoresFromEnv reads the API key, config, request log, and dead-letter
+// store settings and, for SQL backends, opens and pings each database without
+// migrating it. SQLite files are opened read-only, so a check never creates
+// or changes one.
+func CheckStoresFromEnv(ctx context.Context) []StoreStatus {
+	stores := []struct{ name, fallback, sqliteDefault string }{
+		{"API_KEY_STORE", BackendMemory, "ferrogw-keys.db"},
+		{"CONFIG_STORE", BackendMemory, "ferrogw-config.db"},
+		{"REQUEST_LOG_STORE", "disabled", "ferrogw-requests.db"},
+		{"DEAD_LETTER_STORE", "disabled", "ferrogw-dead-letters.db"},
+	}
+	out := make([]StoreStatus, 0, len(stores))
+	for _, s := range stores {
+		status := StoreStatus{Name: s.name, Backend: strings.ToLower(strings.TrimSpace(os.Getenv(s.name + "_BACKEND")))}
+		if status.Backend == "" {
+			status.Backend = s.fallback
+		}
+		var dialect sqldb.Dialect
+		switch status.Backend {
+		case BackendMemory, "in-memory", "inmemory", "disabled":
+			out = append(out, status)
+			continue
+		case BackendFile:
+			status.Err = checkFileStore(ctx, s.name+"_DSN")
+			out = append(out, status)
+			continue
+		case BackendSQLite:
+			dialect = sqldb.SQLite
+		case BackendPostgres, backendPostgresSQL:
+			status.Backend, dialect = BackendPostgres, sqldb.Postgres
+		default:
+			status.Err = fmt.Errorf("unsupported backend %q", status.Backend)
+			out = append(out, status)
+			continue
+		}
+		dsn, err := envSecret(ctx, s.name+"_DSN")
+		switch {
+		case err != nil:
+		case dialect == sqldb.SQLite:
+			status.NotCreated, err = checkSQLiteStore(ctx, dsn, s.sqliteDefault)
+		default:
+			var db *sql.DB
+			if db, err = sqldb.Open(ctx, dialect, dsn, s.sqliteDefault); err == nil {
+				_ = db.Close()
+			}
+		}
+		status.Err = err
+		out = append(out, status)
+	}
+	return out
+}
+
+// checkSQLiteStore opens the SQLite database dsn, or defaultDSN when it is
+// blank, read-only and pings it. A file that does not exist yet is not an
+// error when its directory exists, and is reported as not created.
+func checkSQLiteStore(ctx context.Context, dsn, defaultDSN string) (notCreated bool, err error) {
+	if dsn = strings.TrimSpace(dsn); dsn == "" {
+		dsn = defaultDSN
+	}
+	readOnly, path, err := sqlitefile.ReadOnly(dsn)
+	if err != nil {
+		return false, err
+	}
+	if path != "" {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			if _, err := os.Stat(filepath.Dir(path)); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	db, err := sql.Open("sqlite", readOnly)
+	if err != nil {
+		return false, fmt.Errorf("open sqlite database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+	// A ping alone may not touch the file; reading the schema does.
+	if _, err := db.ExecContext(ctx, "SELECT count(*) FROM sqlite_master"); err != nil {
+		return false, fmt.Errorf("read sqlite database: %w", err)
+	}
+	return false, nil
+}
+
+// checkFileStore reports whether the file store the dsnVar env var names can
+// be created: the path is set and its directory exists.
+func checkFileStore(ctx context.Context, dsnVar string) error {
+	path, err := envSecret(ctx, dsnVar)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("%s is required for the file backend", dsnVar)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return nil
+}
+
 // CreateRequestLogReaderFromEnv builds a request log reader from REQUEST_LOG_STORE_BACKEND / REQUEST_LOG_STORE_DSN env vars.
 func CreateRequestLogReaderFromEnv(ctx context.Context) (requestlog.Reader, requestlog.Maintainer, string, error) {
 	backend := strings.ToLower(strings.TrimSpace(os.Getenv("REQUEST_LOG_STORE_BACKEND")))
@@ -56,26 +181,129 @@ func CreateRequestLogReaderFromEnv(ctx context.Context) (requestlog.Reader, requ
 		return nil, nil, "disabled", nil
 	}
 
-	dsn := strings.TrimSpace(os.Getenv("REQUEST_LOG_STORE_DSN"))
+	dsn, err := envSecret(ctx, "REQUEST_LOG_STORE_DSN")
+	if err != nil {
+		return nil, nil, "", err
+	}
+	payloads, err := requestLogCipherFromEnv()
+	if err != nil {
+		return nil, nil, "", err
+	}
 
+	var (
+		writer *requestlog.SQLWriter
+		name   string
+	)
 	switch backend {
 	case BackendSQLite:
-		reader, err := requestlog.NewSQLiteWriter(ctx, dsn)
+		writer, err = requestlog.NewSQLiteWriter(ctx, dsn)
+		name = BackendSQLite
+	case BackendPostgres, backendPostgresSQL:
+		writer, err = requestlog.NewPostgresWriter(ctx, dsn)
+		name = BackendPostgres
+	default:
+		return nil, nil, "", fmt.Errorf("unsupported request log store backend %q", backend)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	writer.SetPayloadCipher(payloads)
+	return writer, writer, name, nil
+}
+
+// requestLogCipherFromEnv builds the request-log payload cipher from
+// REQUEST_LOG_ENCRYPTION_KEY, or from the file REQUEST_LOG_ENCRYPTION_KEY_FILE
+// names (a secret mounted by a KMS or secrets-store driver). It returns nil
+// when neither is set.
+func requestLogCipherFromEnv() (*requestlog.PayloadCipher, error) {
+	spec := strings.TrimSpace(os.Getenv("REQUEST_LOG_ENCRYPTION_KEY"))
+	if path := strings.TrimSpace(os.Getenv("REQUEST_LOG_ENCRYPTION_KEY_FILE")); path != "" {
+		if spec != "" {
+			return nil, fmt.Errorf("set only one of REQUEST_LOG_ENCRYPTION_KEY and REQUEST_LOG_ENCRYPTION_KEY_FILE")
+		}
+		raw, err := os.ReadFile(path) //nolint:gosec // G304: the path is operator configuration, not request input
 		if err != nil {
-			return nil, nil, "", err
+			return nil, fmt.Errorf("read REQUEST_LOG_ENCRYPTION_KEY_FILE: %w", err)
 		}
-		return reader, reader, BackendSQLite, nil
+		spec = strings.TrimSpace(string(raw))
+	}
+	if spec == "" {
+		return nil, nil
+	}
+	return requestlog.ParsePayloadKeys(spec)
+}
+
+// BackendFile is the dead-letter store backend that writes a JSON Lines file.
+const BackendFile = "file"
+
+// CreateDeadLetterStoreFromEnv builds the dead-letter store from
+// DEAD_LETTER_STORE_BACKEND / DEAD_LETTER_STORE_DSN env vars: memory, file
+// (the DSN is the file's path), sqlite, or postgres, keeping the entries
+// DEAD_LETTER_MAX_ENTRIES and DEAD_LETTER_MAX_AGE allow. It returns a nil
+// store when the backend is unset, and failed requests are then not kept.
+func CreateDeadLetterStoreFromEnv(ctx context.Context) (deadletter.Store, string, error) {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("DEAD_LETTER_STORE_BACKEND")))
+	if backend == "" {
+		return nil, "disabled", nil
+	}
+
+	dsn, err := envSecret(ctx, "DEAD_LETTER_STORE_DSN")
+	if err != nil {
+		return nil, "", err
+	}
+	retention, err := deadLetterRetentionFromEnv()
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch backend {
+	case BackendMemory, "in-memory", "inmemory":
+		return deadletter.NewMemoryStore(retention), BackendMemory, nil
+	case BackendFile:
+		store, err := deadletter.NewFileStore(dsn, retention)
+		if err != nil {
+			return nil, "", err
+		}
+		return store, BackendFile, nil
+	case BackendSQLite:
+		store, err := deadletter.NewSQLiteStore(ctx, dsn, retention)
+		if err != nil {
+			return nil, "", err
+		}
+		return store, BackendSQLite, nil
 	case BackendPostgres, backendPostgresSQL:
-		reader, err := requestlog.NewPostgresWriter(ctx, dsn)
+		store, err := deadletter.NewPostgresStore(ctx, dsn, retention)
 		if err != nil {
-			return nil, nil, "", err
+			return nil, "", err
 		}
-		return reader, reader, BackendPostgres, nil
+		return store, BackendPostgres, nil
 	default:
-		return nil, nil, "", fmt.Errorf("unsupported request log store backend %q", backend)
+		return nil, "", fmt.Errorf("unsupported dead-letter store backend %q", backend)
 	}
 }
 
+// deadLetterRetentionFromEnv reads DEAD_LETTER_MAX_ENTRIES, a positive count
+// (default deadletter.DefaultMaxEntries), and DEAD_LETTER_MAX_AGE, a
+// non-negative duration (default 0, no age limit).
+func deadLetterRetentionFromEnv() (deadletter.Retention, error) {
+	var r deadletter.Retention
+	if raw := strings.TrimSpace(os.Getenv("DEAD_LETTER_MAX_ENTRIES")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return r, fmt.Errorf("DEAD_LETTER_MAX_ENTRIES must be a positive integer, got %q", raw)
+		}
+		r.MaxEntries = n
+	}
+	if raw := strings.TrimSpace(os.Getenv("DEAD_LETTER_MAX_AGE")); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return r, fmt.Errorf("DEAD_LETTER_MAX_AGE must be a non-negative duration, got %q", raw)
+		}
+		r.MaxAge = d
+	}
+	return r, nil
+}
+
 // CreateConfigManagerFromEnv builds a config manager from CONFIG_STORE_BACKEND / CONFIG_STORE_DSN env vars.
 func CreateConfigManagerFromEnv(ctx context.Context, gw *aigateway.Gateway) (admin.ConfigManager, string, error) {
 	backend := strings.ToLower(strings.TrimSpace(os.Getenv("CONFIG_STORE_BACKEND")))
@@ -83,7 +311,14 @@ func CreateConfigManagerFromEnv(ctx context.Context, gw *aigateway.Gateway) (adm
 		backend = BackendMemory
 	}
 
-	dsn := strings.TrimSpace(os.Getenv("CONFIG_STORE_DSN"))
+	dsn, err := envSecret(ctx, "CONFIG_STORE_DSN")
+	if err != nil {
+		return nil, "", err
+	}
+	historyLimit, err := configHistoryLimitFromEnv()
+	if err != nil {
+		return nil, "", err
+	}
 
 	switch backend {
 	case BackendMemory, "in-memory", "inmemory":
@@ -97,6 +332,7 @@ func CreateConfigManagerFromEnv(ctx context.Context, gw *aigateway.Gateway) (adm
 		if err != nil {
 			return nil, "", err
 		}
+		store.SetHistoryLimit(historyLimit)
 		manager, err := admin.NewGatewayConfigManager(gw, store)
 		if err != nil {
 			_ = store.Close()
@@ -108,6 +344,7 @@ func CreateConfigManagerFromEnv(ctx context.Context, gw *aigateway.Gateway) (adm
 		if err != nil {
 			return nil, "", err
 		}
+		store.SetHistoryLimit(historyLimit)
 		manager, err := admin.NewGatewayConfigManager(gw, store)
 		if err != nil {
 			_ = store.Close()
@@ -118,3 +355,19 @@ func CreateConfigManagerFromEnv(ctx context.Context, gw *aigateway.Gateway) (adm
 		return nil, "", fmt.Errorf("unsupported config store backend %q", backend)
 	}
 }
+
+// configHistoryLimitFromEnv reads CONFIG_HISTORY_MAX_VERSIONS, how many config
+// versions a SQL config store keeps. "0" keeps every version; unset yields
+// admin.DefaultConfigHistoryLimit. It returns an error for a value that is not
+// a non-negative whole number.
+func configHistoryLimitFromEnv() (int, error) {
+	raw := strings.TrimSpace(os.Getenv("CONFIG_HISTORY_MAX_VERSIONS"))
+	if raw == "" {
+		return admin.DefaultConfigHistoryLimit, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("CONFIG_HISTORY_MAX_VERSIONS must be a non-negative whole number, got %q", raw)
+	}
+	return n, nil
+}
@@ -1,11 +1,16 @@
 package bootstrap
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/admin"
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
 )
 
 func TestCreateKeyStoreFromEnv_DefaultsToMemory(t *testing.T) {
@@ -104,6 +109,42 @@ func TestCreateRequestLogReaderFromEnv_UnsupportedBackend(t *testing.T) {
 	}
 }
 
+func TestCreateRequestLogReaderFromEnv_EncryptionKey(t *testing.T) {
+	t.Setenv("REQUEST_LOG_STORE_BACKEND", "sqlite")
+	t.Setenv("REQUEST_LOG_STORE_DSN", filepath.Join(t.TempDir(), "logs.db"))
+
+	t.Setenv("REQUEST_LOG_ENCRYPTION_KEY", "dG9vLXNob3J0")
+	if _, _, _, err := CreateRequestLogReaderFromEnv(t.Context()); err == nil {
+		t.Fatal("expected error for a key that is not 32 bytes")
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("REQUEST_LOG_ENCRYPTION_KEY_FILE", keyFile)
+	if _, _, _, err := CreateRequestLogReaderFromEnv(t.Context()); err == nil {
+		t.Fatal("expected error when both the key and the key file are set")
+	}
+
+	t.Setenv("REQUEST_LOG_ENCRYPTION_KEY", "")
+	reader, _, _, err := CreateRequestLogReaderFromEnv(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = reader.(*requestlog.SQLWriter).Close() })
+	if err := reader.(requestlog.Writer).Write(t.Context(), requestlog.Entry{Stage: "before_request", Request: []byte(`{"model":"gpt-4o"}`)}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	result, err := reader.List(t.Context(), requestlog.Query{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(result.Data) != 1 || !requestlog.Sealed(result.Data[0].Request) {
+		t.Fatalf("expected the stored request body to be sealed, got %+v", result.Data)
+	}
+}
+
 func TestCreateConfigManagerFromEnv_DefaultsToMemory(t *testing.T) {
 	t.Setenv("CONFIG_STORE_BACKEND", "")
 	gw := newTestGateway(t)
@@ -178,3 +219,48 @@ func newTestGateway(t *testing.T) *aigateway.Gateway {
 	})
 	return gw
 }
+
+func TestConfigHistoryLimitFromEnv(t *testing.T) {
+	for raw, want := range map[string]int{
+		"":   admin.DefaultConfigHistoryLimit,
+		"50": 50,
+		"0":  0,
+	} {
+		t.Setenv("CONFIG_HISTORY_MAX_VERSIONS", raw)
+		if got, err := configHistoryLimitFromEnv(); err != nil || got != want {
+			t.Errorf("CONFIG_HISTORY_MAX_VERSIONS=%q: got %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"-1", "many"} {
+		t.Setenv("CONFIG_HISTORY_MAX_VERSIONS", raw)
+		if _, err := configHistoryLimitFromEnv(); err == nil {
+			t.Errorf("CONFIG_HISTORY_MAX_VERSIONS=%q: want an error", raw)
+		}
+	}
+}
+
+func TestDeadLetterRetentionFromEnv(t *testing.T) {
+	t.Setenv("DEAD_LETTER_MAX_ENTRIES", "")
+	t.Setenv("DEAD_LETTER_MAX_AGE", "")
+	if r, err := deadLetterRetentionFromEnv(); err != nil || r != (deadletter.Retention{}) {
+		t.Fatalf("unset: got %+v, %v; want the defaults", r, err)
+	}
+
+	t.Setenv("DEAD_LETTER_MAX_ENTRIES", "500")
+	t.Setenv("DEAD_LETTER_MAX_AGE", "168h")
+	if r, err := deadLetterRetentionFromEnv(); err != nil || r != (deadletter.Retention{MaxEntries: 500, MaxAge: 168 * time.Hour}) {
+		t.Fatalf("set: got %+v, %v", r, err)
+	}
+
+	for name, raw := range map[string]string{
+		"DEAD_LETTER_MAX_ENTRIES": "0",
+		"DEAD_LETTER_MAX_AGE":     "-1h",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, raw)
+			if _, err := deadLetterRetentionFromEnv(); err == nil {
+				t.Fatalf("%s=%q accepted", name, raw)
+			}
+		})
+	}
+}
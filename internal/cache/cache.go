@@ -2,7 +2,11 @@
 // response-cache plugin. The default in-process implementation is MemoryCache.
 package cache
 
-import "github.com/ferro-labs/ai-gateway/providers"
+import (
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
 
 // Cache defines the interface for response caching.
 type Cache interface {
@@ -12,3 +16,46 @@ type Cache interface {
 	Len() int
 	Clear()
 }
+
+// Admin is the operator surface of a namespaced cache, driven by the
+// /admin/cache endpoints.
+type Admin interface {
+	Stats() Stats
+	Purge(filter PurgeFilter) int
+	SetNamespaceTTL(namespace string, ttl time.Duration)
+}
+
+// PurgeFilter selects the entries Purge removes. Set fields are ANDed; an
+// empty field matches everything.
+type PurgeFilter struct {
+	// Namespace matches entries stored under exactly this namespace.
+	Namespace string
+	// Model matches entries whose cached response reports exactly this model.
+	Model string
+	// KeyPrefix matches entries whose cache key starts with this prefix.
+	KeyPrefix string
+	// User matches entries stored for requests with exactly this end-user
+	// identifier (SetInFor).
+	User string
+}
+
+// Stats is a point-in-time snapshot of a cache.
+type Stats struct {
+	Entries    int
+	Capacity   int
+	DefaultTTL time.Duration
+	Hits       int64
+	Misses     int64
+	// Stale counts lookups that found an expired entry inside the grace
+	// window, whether it was served stale or triggered the refresh.
+	Stale      int64
+	Evictions  int64
+	Namespaces []NamespaceStats
+}
+
+// NamespaceStats describes one namespace's occupancy and effective TTL.
+type NamespaceStats struct {
+	Namespace string
+	Entries   int
+	TTL       time.Duration
+}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"container/list"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,18 +11,42 @@ import (
 )
 
 type memoryEntry struct {
-	key       string
+	id        entryID
+	model     string
+	user      string
 	response  *providers.Response
+	storedAt  time.Time
 	expiresAt time.Time
 }
 
+// entryID identifies one cached response. The namespace is part of the
+// identity, not a prefix of the key, so two tenants issuing the identical
+// request hold separate entries and neither can be served the other's.
+type entryID struct {
+	namespace string
+	key       string
+}
+
 // Memory is a thread-safe in-memory LRU cache with TTL expiration.
+//
+// Entries live in namespaces (the response-cache plugin uses the caller's API
+// key ID). Capacity is shared across namespaces; each namespace may override
+// the default TTL with SetNamespaceTTL.
+//
+// An expired entry is kept for a further Grace so GetStale can still return it
+// while a single caller refreshes it; Get and GetIn never return one.
 type Memory struct {
 	mu        sync.Mutex
 	Capacity  int
 	TTL       time.Duration
-	items     map[string]*list.Element
+	Grace     time.Duration
+	items     map[entryID]*list.Element
 	evictList *list.List
+	nsTTL     map[string]time.Duration
+	hits      int64
+	misses    int64
+	stale     int64
+	evictions int64
 	now       func() time.Time
 }
 
@@ -29,8 +55,9 @@ func NewMemory(capacity int, ttl time.Duration) *Memory {
 	return &Memory{
 		Capacity:  capacity,
 		TTL:       ttl,
-		items:     make(map[string]*list.Element),
+		items:     make(map[entryID]*list.Element),
 		evictList: list.New(),
+		nsTTL:     make(map[string]time.Duration),
 		now:       time.Now,
 	}
 }
@@ -48,35 +75,93 @@ func (m *Memory) SetNowForTest(fn func() time.Time) {
 
 // Get returns the cached response for key, or false if missing or expired.
 func (m *Memory) Get(key string) (*providers.Response, bool) {
+	resp, _, ok := m.GetWithAge(key)
+	return resp, ok
+}
+
+// GetWithAge is Get that also reports how long ago the entry was stored, which
+// the response-cache plugin surfaces to clients as the HTTP Age header.
+func (m *Memory) GetWithAge(key string) (*providers.Response, time.Duration, bool) {
+	return m.GetIn("", key)
+}
+
+// GetIn is GetWithAge scoped to namespace.
+func (m *Memory) GetIn(namespace, key string) (*providers.Response, time.Duration, bool) {
+	resp, age, stale, ok := m.lookup(namespace, key, false)
+	if stale {
+		return nil, 0, false
+	}
+	return resp, age, ok
+}
+
+// GetStale is GetIn that also returns an expired entry still inside the Grace
+// window, reporting stale=true for it. The caller decides whether to serve it
+// or refresh it.
+func (m *Memory) GetStale(namespace, key string) (resp *providers.Response, age time.Duration, stale, ok bool) {
+	return m.lookup(namespace, key, true)
+}
+
+func (m *Memory) lookup(namespace, key string, allowStale bool) (*providers.Response, time.Duration, bool, bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	elem, ok := m.items[key]
+	elem, ok := m.items[entryID{namespace: namespace, key: key}]
 	if !ok {
-		return nil, false
+		m.misses++
+		return nil, 0, false, false
 	}
 
 	entry := elem.Value.(*memoryEntry)
-	if m.now().After(entry.expiresAt) {
+	now := m.now()
+	if now.After(entry.expiresAt) {
+		if !now.After(entry.expiresAt.Add(m.Grace)) {
+			// Expired but still within grace: keep the entry so a concurrent
+			// stale lookup can serve it while one caller refreshes.
+			if allowStale {
+				m.stale++
+				return entry.response, now.Sub(entry.storedAt), true, true
+			}
+			m.misses++
+			return nil, 0, true, false
+		}
 		m.removeElement(elem)
-		return nil, false
+		m.misses++
+		return nil, 0, false, false
 	}
 
 	m.evictList.MoveToFront(elem)
-	return entry.response, true
+	m.hits++
+	return entry.response, now.Sub(entry.storedAt), false, true
 }
 
 // Set stores a response in the cache with the configured TTL.
 func (m *Memory) Set(key string, resp *providers.Response) {
+	m.SetIn("", key, resp)
+}
+
+// SetIn stores a response under namespace with that namespace's TTL. The
+// response's Model is recorded so entries can later be purged by model.
+func (m *Memory) SetIn(namespace, key string, resp *providers.Response) {
+	m.SetInFor(namespace, key, "", resp)
+}
+
+// SetInFor is SetIn that also records the end user the response was generated
+// for, so the user's entries can be purged on an erasure request.
+func (m *Memory) SetInFor(namespace, key, user string, resp *providers.Response) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	now := m.now()
-	if elem, ok := m.items[key]; ok {
+	expiresAt := now.Add(m.ttlLocked(namespace))
+	id := entryID{namespace: namespace, key: key}
+	if elem, ok := m.items[id]; ok {
 		m.evictList.MoveToFront(elem)
 		entry := elem.Value.(*memoryEntry)
+		entry.model = resp.Model
+		entry.user = user
 		entry.response = resp
-		entry.expiresAt = now.Add(m.TTL)
+		entry.storedAt = now
+		entry.expiresAt = expiresAt
 		return
 	}
 
@@ -85,20 +170,37 @@ func (m *Memory) Set(key string, resp *providers.Response) {
 	}
 
 	entry := &memoryEntry{
-		key:       key,
+		id:        id,
+		model:     resp.Model,
+		user:      user,
 		response:  resp,
-		expiresAt: now.Add(m.TTL),
+		storedAt:  now,
+		expiresAt: expiresAt,
 	}
 	elem := m.evictList.PushFront(entry)
-	m.items[key] = elem
+	m.items[id] = elem
 }
 
-// Delete removes an entry from the cache.
+// Delete removes key's entries from the cache, in every namespace.
 func (m *Memory) Delete(key string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if elem, ok := m.items[key]; ok {
+	for elem := m.evictList.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*memoryEntry).id.key == key {
+			m.removeElement(elem)
+		}
+		elem = next
+	}
+}
+
+// DeleteIn removes key's entry in namespace.
+func (m *Memory) DeleteIn(namespace, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[entryID{namespace: namespace, key: key}]; ok {
 		m.removeElement(elem)
 	}
 }
@@ -114,19 +216,120 @@ func (m *Memory) Len() int {
 func (m *Memory) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.items = make(map[string]*list.Element)
+	m.items = make(map[entryID]*list.Element)
 	m.evictList.Init()
 }
 
+// TTLFor returns the TTL applied to new entries in namespace: its override if
+// one is set, otherwise the cache-wide default.
+func (m *Memory) TTLFor(namespace string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ttlLocked(namespace)
+}
+
+// SetNamespaceTTL overrides the TTL for namespace. A non-positive ttl removes
+// the override. Only entries stored afterwards are affected; existing entries
+// keep the expiry they were stored with.
+func (m *Memory) SetNamespaceTTL(namespace string, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ttl <= 0 {
+		delete(m.nsTTL, namespace)
+		return
+	}
+	m.nsTTL[namespace] = ttl
+}
+
+// Purge removes every entry matching filter and returns how many were
+// removed. An empty filter matches every entry.
+func (m *Memory) Purge(filter PurgeFilter) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for elem := m.evictList.Front(); elem != nil; {
+		next := elem.Next()
+		if filter.matches(elem.Value.(*memoryEntry)) {
+			m.removeElement(elem)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// Stats returns a point-in-time snapshot of the cache's size, counters, and
+// per-namespace occupancy.
+func (m *Memory) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[string]int)
+	for elem := m.evictList.Front(); elem != nil; elem = elem.Next() {
+		counts[elem.Value.(*memoryEntry).id.namespace]++
+	}
+	for ns := range m.nsTTL {
+		if _, ok := counts[ns]; !ok {
+			counts[ns] = 0
+		}
+	}
+
+	namespaces := make([]NamespaceStats, 0, len(counts))
+	for ns, n := range counts {
+		namespaces = append(namespaces, NamespaceStats{
+			Namespace: ns,
+			Entries:   n,
+			TTL:       m.ttlLocked(ns),
+		})
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Namespace < namespaces[j].Namespace })
+
+	return Stats{
+		Entries:    m.evictList.Len(),
+		Capacity:   m.Capacity,
+		DefaultTTL: m.TTL,
+		Hits:       m.hits,
+		Misses:     m.misses,
+		Stale:      m.stale,
+		Evictions:  m.evictions,
+		Namespaces: namespaces,
+	}
+}
+
+func (m *Memory) ttlLocked(namespace string) time.Duration {
+	if ttl, ok := m.nsTTL[namespace]; ok {
+		return ttl
+	}
+	return m.TTL
+}
+
 func (m *Memory) removeOldest() {
 	elem := m.evictList.Back()
 	if elem != nil {
 		m.removeElement(elem)
+		m.evictions++
 	}
 }
 
 func (m *Memory) removeElement(elem *list.Element) {
 	m.evictList.Remove(elem)
 	entry := elem.Value.(*memoryEntry)
-	delete(m.items, entry.key)
+	delete(m.items, entry.id)
+}
+
+func (f PurgeFilter) matches(e *memoryEntry) bool {
+	if f.Namespace != "" && e.id.namespace != f.Namespace {
+		return false
+	}
+	if f.Model != "" && e.model != f.Model {
+		return false
+	}
+	if f.KeyPrefix != "" && !strings.HasPrefix(e.id.key, f.KeyPrefix) {
+		return false
+	}
+	if f.User != "" && e.user != f.User {
+		return false
+	}
+	return true
 }
@@ -53,6 +53,30 @@ func TestMemory_GetExpiresEntry(t *testing.T) {
 	}
 }
 
+func TestMemory_GetWithAge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	c := NewMemory(10, time.Minute)
+	c.SetNowForTest(func() time.Time { return now })
+	c.Set("key1", &providers.Response{ID: "resp-1"})
+
+	now = now.Add(7 * time.Second)
+	got, age, ok := c.GetWithAge("key1")
+	if !ok || got.ID != "resp-1" {
+		t.Fatalf("expected cache hit for resp-1, got %v ok=%v", got, ok)
+	}
+	if age != 7*time.Second {
+		t.Errorf("expected age 7s, got %v", age)
+	}
+
+	// Overwriting an entry resets its age.
+	c.Set("key1", &providers.Response{ID: "resp-2"})
+	if _, age, _ = c.GetWithAge("key1"); age != 0 {
+		t.Errorf("expected age reset to 0 after update, got %v", age)
+	}
+}
+
 func TestMemory_LRUEviction(t *testing.T) {
 	t.Parallel()
 
@@ -125,6 +149,31 @@ func TestMemory_Delete(t *testing.T) {
 	}
 }
 
+func TestMemory_DeleteNamespaced(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemory(10, time.Minute)
+	c.SetIn("key-a", "k", &providers.Response{ID: "a"})
+	c.SetIn("key-b", "k", &providers.Response{ID: "b"})
+	c.SetIn("key-b", "other", &providers.Response{ID: "other"})
+
+	c.DeleteIn("key-a", "k")
+	if _, _, ok := c.GetIn("key-a", "k"); ok {
+		t.Error("expected a miss in the namespace deleted from")
+	}
+	if _, _, ok := c.GetIn("key-b", "k"); !ok {
+		t.Error("DeleteIn removed another namespace's entry")
+	}
+
+	c.Delete("k")
+	if _, _, ok := c.GetIn("key-b", "k"); ok {
+		t.Error("Delete left a namespaced entry")
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected len 1, got %d", c.Len())
+	}
+}
+
 func TestMemory_Clear(t *testing.T) {
 	t.Parallel()
 
@@ -153,3 +202,120 @@ func TestMemory_Concurrent(_ *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestMemory_NamespacesIsolateEntries(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemory(10, time.Minute)
+	c.SetIn("tenant-a", "key1", &providers.Response{ID: "a"})
+
+	if _, _, ok := c.GetIn("tenant-b", "key1"); ok {
+		t.Fatal("tenant-b read tenant-a's entry")
+	}
+	if _, ok := c.Get("key1"); ok {
+		t.Fatal("the unnamespaced bucket read tenant-a's entry")
+	}
+	if got, _, ok := c.GetIn("tenant-a", "key1"); !ok || got.ID != "a" {
+		t.Fatalf("expected tenant-a hit, got %v ok=%v", got, ok)
+	}
+}
+
+func TestMemory_NamespaceTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	c := NewMemory(10, time.Minute)
+	c.SetNowForTest(func() time.Time { return now })
+	c.SetNamespaceTTL("short", 5*time.Second)
+	c.SetIn("short", "key1", &providers.Response{ID: "s"})
+	c.SetIn("long", "key1", &providers.Response{ID: "l"})
+
+	now = now.Add(6 * time.Second)
+	if _, _, ok := c.GetIn("short", "key1"); ok {
+		t.Error("expected short namespace entry to expire after its 5s TTL")
+	}
+	if _, _, ok := c.GetIn("long", "key1"); !ok {
+		t.Error("expected default-TTL namespace entry to survive")
+	}
+
+	c.SetNamespaceTTL("short", 0)
+	if got := c.TTLFor("short"); got != time.Minute {
+		t.Errorf("expected cleared override to fall back to 1m, got %v", got)
+	}
+}
+
+func TestMemory_PurgeAndStats(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemory(2, time.Minute)
+	c.SetIn("a", "abc1", &providers.Response{Model: "gpt-4o"})
+	c.SetIn("a", "def2", &providers.Response{Model: "claude"})
+	c.SetIn("b", "abc3", &providers.Response{Model: "gpt-4o"}) // evicts a/abc1
+
+	c.GetIn("a", "def2")
+	c.GetIn("a", "abc1")
+
+	stats := c.Stats()
+	if stats.Entries != 2 || stats.Capacity != 2 || stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if len(stats.Namespaces) != 2 || stats.Namespaces[0].Namespace != "a" || stats.Namespaces[0].Entries != 1 {
+		t.Fatalf("unexpected namespace stats: %+v", stats.Namespaces)
+	}
+
+	if n := c.Purge(PurgeFilter{KeyPrefix: "abc"}); n != 1 {
+		t.Errorf("purge by key prefix removed %d, want 1", n)
+	}
+	if n := c.Purge(PurgeFilter{Model: "claude", Namespace: "b"}); n != 0 {
+		t.Errorf("purge with non-matching namespace removed %d, want 0", n)
+	}
+	if n := c.Purge(PurgeFilter{}); n != 1 {
+		t.Errorf("empty filter removed %d, want 1", n)
+	}
+}
+
+func TestMemory_PurgeByUser(t *testing.T) {
+	t.Parallel()
+
+	c := NewMemory(10, time.Minute)
+	c.SetInFor("a", "k1", "alice", &providers.Response{Model: "gpt-4o"})
+	c.SetInFor("a", "k2", "bob", &providers.Response{Model: "gpt-4o"})
+	c.SetIn("a", "k3", &providers.Response{Model: "gpt-4o"})
+
+	if n := c.Purge(PurgeFilter{User: "alice"}); n != 1 {
+		t.Errorf("purge by user removed %d, want 1", n)
+	}
+	if _, _, ok := c.GetIn("a", "k1"); ok {
+		t.Error("alice's entry should be gone")
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected the other entries to remain, got %d", c.Len())
+	}
+}
+
+func TestMemory_GetStaleWithinGrace(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	c := NewMemory(10, 10*time.Second)
+	c.Grace = 5 * time.Second
+	c.SetNowForTest(func() time.Time { return now })
+	c.SetIn("ns", "key1", &providers.Response{ID: "resp-1"})
+
+	now = now.Add(12 * time.Second)
+	if _, _, ok := c.GetIn("ns", "key1"); ok {
+		t.Error("GetIn must not return an expired entry")
+	}
+	resp, age, stale, ok := c.GetStale("ns", "key1")
+	if !ok || !stale || resp.ID != "resp-1" || age != 12*time.Second {
+		t.Fatalf("GetStale = %v, %v, stale=%v, ok=%v", resp, age, stale, ok)
+	}
+
+	now = now.Add(4 * time.Second) // past expiry + grace
+	if _, _, _, ok := c.GetStale("ns", "key1"); ok {
+		t.Error("expected the entry to be dropped once the grace window passed")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected len 0, got %d", c.Len())
+	}
+}
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
@@ -122,9 +125,16 @@ var keysRotateCmd = &cobra.Command{
 }
 
 func runKeysRotate(cmd *cobra.Command, args []string) error {
+	var body any
+	if overlap, _ := cmd.Flags().GetString("overlap"); overlap != "" {
+		if _, err := time.ParseDuration(overlap); err != nil {
+			return fmt.Errorf("invalid --overlap duration: %w", err)
+		}
+		body = map[string]any{"overlap": overlap}
+	}
 	c := adminClientFromCmd(cmd)
 	var result any
-	if err := c.Post(cmd.Context(), "/admin/keys/"+args[0]+"/rotate", nil, &result); err != nil {
+	if err := c.Post(cmd.Context(), "/admin/keys/"+args[0]+"/rotate", body, &result); err != nil {
 		return err
 	}
 	return printResult(cmd, result)
@@ -223,6 +233,92 @@ func runConfigRollback(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the configuration as an import bundle (secrets redacted)",
+	RunE:  runConfigExport,
+}
+
+func runConfigExport(cmd *cobra.Command, _ []string) error {
+	c := adminClientFromCmd(cmd)
+	var result any
+	if err := c.Get(cmd.Context(), "/admin/config/export", &result); err != nil {
+		return err
+	}
+	return printResult(cmd, result)
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Apply a configuration bundle from config export",
+	RunE:  runConfigImport,
+}
+
+func runConfigImport(cmd *cobra.Command, _ []string) error {
+	filePath, _ := cmd.Flags().GetString("file")
+	if filePath == "" {
+		return fmt.Errorf("--file is required")
+	}
+	raw, err := os.ReadFile(filePath) //nolint:gosec // G304: file path comes from the operator's --file CLI flag, not request input
+	if err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+	if !json.Valid(raw) {
+		return fmt.Errorf("parse bundle file: not valid JSON")
+	}
+	header := http.Header{}
+	if sigPath, _ := cmd.Flags().GetString("signature"); sigPath != "" {
+		sig, err := os.ReadFile(sigPath) //nolint:gosec // G304: file path comes from the operator's --signature CLI flag, not request input
+		if err != nil {
+			return fmt.Errorf("read signature: %w", err)
+		}
+		header.Set(bundleSignatureHeader, encodeSignatureHeader(sig))
+	}
+	path := "/admin/config/import"
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		path += "?dry_run=true"
+	}
+	c := adminClientFromCmd(cmd)
+	var result map[string]any
+	// The bundle is sent as read: re-encoding it would void its signature.
+	if err := c.PostRaw(cmd.Context(), path, raw, header, &result); err != nil {
+		return err
+	}
+	changes := &jsonSlice{
+		headers: []string{"OP", "PATH"},
+		data:    toSlice(result["changes"]),
+		rowFn: func(m map[string]any) []string {
+			return []string{str(m, "op"), str(m, "path")}
+		},
+	}
+	if err := printResult(cmd, changes); err != nil {
+		return err
+	}
+	switch str(result, "status") {
+	case "dry_run":
+		PrintSuccess(cmd.OutOrStdout(), fmt.Sprintf("Dry run: %d change(s), nothing applied.", len(changes.data)))
+	case "unchanged":
+		PrintSuccess(cmd.OutOrStdout(), "Configuration already matches the bundle.")
+	default:
+		PrintSuccess(cmd.OutOrStdout(), "Configuration imported.")
+	}
+	return nil
+}
+
+// bundleSignatureHeader carries a config bundle's signature on import.
+const bundleSignatureHeader = "X-Ferro-Bundle-Signature"
+
+// encodeSignatureHeader fits a signature file into a header value: a cosign
+// signature is already one base64 line, and a multi-line minisign signature
+// file is base64-encoded whole.
+func encodeSignatureHeader(sig []byte) string {
+	sig = bytes.TrimSpace(sig)
+	if bytes.HasPrefix(sig, []byte("untrusted comment:")) {
+		return base64.StdEncoding.EncodeToString(append(sig, '\n'))
+	}
+	return string(sig)
+}
+
 // ── Logs ─────────────────────────────────────────────────────────────────────
 
 var logsCmd = &cobra.Command{
@@ -323,12 +419,16 @@ func init() {
 	keysCreateCmd.Flags().String("name", "", "Human-readable label for the key")
 	keysCreateCmd.Flags().String("scope", "read_only", "Key scope: admin or read_only")
 	keysCreateCmd.Flags().String("expires-in", "", "Expiry duration, e.g. 720h (30 days)")
+	keysRotateCmd.Flags().String("overlap", "", "How long the old key stays valid, e.g. 24h; 0 invalidates it at once (default: the gateway's KEY_ROTATION_OVERLAP)")
 
 	keysCmd.AddCommand(keysListCmd, keysGetCmd, keysCreateCmd, keysRevokeCmd, keysRotateCmd)
 
 	// Config sub-commands.
 	configSetCmd.Flags().String("file", "", "Path to JSON config file")
-	configCmd.AddCommand(configGetCmd, configHistoryCmd, configSetCmd, configRollbackCmd)
+	configImportCmd.Flags().String("file", "", "Path to a bundle written by config export")
+	configImportCmd.Flags().Bool("dry-run", false, "Validate the bundle and list its changes without applying them")
+	configImportCmd.Flags().String("signature", "", "Path to the bundle's minisign or cosign signature, for gateways that verify bundles")
+	configCmd.AddCommand(configGetCmd, configHistoryCmd, configSetCmd, configRollbackCmd, configExportCmd, configImportCmd)
 
 	// Logs sub-commands.
 	logsListCmd.Flags().Int("limit", 50, "Maximum number of log entries to return")
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -255,6 +257,76 @@ func TestRunConfigSet(t *testing.T) {
 	})
 }
 
+func TestRunConfigImport(t *testing.T) {
+	var gotQuery string
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/admin/config/import": func(w http.ResponseWriter, r *http.Request) {
+			gotQuery = r.URL.RawQuery
+			jsonHandler(http.StatusOK, `{"status":"dry_run","changes":[{"path":"aliases.fast","op":"added"}]}`)(w, r)
+		},
+	})
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := os.WriteFile(path, []byte(`{"bundle_version":1,"config":{}}`), 0600); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	cmd, out := newHandlerCmd(t, srv.URL, "table")
+	cmd.Flags().String("file", path, "")
+	cmd.Flags().Bool("dry-run", true, "")
+
+	if err := runConfigImport(cmd, nil); err != nil {
+		t.Fatalf("runConfigImport: %v", err)
+	}
+	if gotQuery != "dry_run=true" {
+		t.Errorf("query = %q, want dry_run=true", gotQuery)
+	}
+	for _, want := range []string{"aliases.fast", "added", "Dry run: 1 change(s)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunConfigImport_Signature(t *testing.T) {
+	// Formatting a signed bundle would void its signature, so the odd
+	// spacing must reach the gateway untouched.
+	bundle := `{ "bundle_version": 1,  "config": {} }`
+	minisig := "untrusted comment: signature\nRWQsig\ntrusted comment: t\nglobal\n"
+	var gotBody, gotSig string
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/admin/config/import": func(w http.ResponseWriter, r *http.Request) {
+			raw, _ := io.ReadAll(r.Body)
+			gotBody, gotSig = string(raw), r.Header.Get(bundleSignatureHeader)
+			jsonHandler(http.StatusOK, `{"status":"unchanged","changes":[]}`)(w, r)
+		},
+	})
+	dir := t.TempDir()
+	bundlePath, sigPath := filepath.Join(dir, "bundle.json"), filepath.Join(dir, "bundle.json.sig")
+	if err := os.WriteFile(bundlePath, []byte(bundle), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ name, file, header string }{
+		{"cosign", "MEUCIQDsig==\n", "MEUCIQDsig=="},
+		{"minisign", minisig, base64.StdEncoding.EncodeToString([]byte(minisig))},
+	} {
+		if err := os.WriteFile(sigPath, []byte(tc.file), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cmd, _ := newHandlerCmd(t, srv.URL, "table")
+		cmd.Flags().String("file", bundlePath, "")
+		cmd.Flags().Bool("dry-run", false, "")
+		cmd.Flags().String("signature", sigPath, "")
+		if err := runConfigImport(cmd, nil); err != nil {
+			t.Fatalf("%s: runConfigImport: %v", tc.name, err)
+		}
+		if gotBody != bundle {
+			t.Errorf("%s: body = %q, want the file's bytes %q", tc.name, gotBody, bundle)
+		}
+		if gotSig != tc.header {
+			t.Errorf("%s: signature header = %q, want %q", tc.name, gotSig, tc.header)
+		}
+	}
+}
+
 func TestRunLogsList(t *testing.T) {
 	srv := stubGateway(t, map[string]http.HandlerFunc{
 		"/admin/logs": jsonHandler(http.StatusOK, `[{"trace_id":"t1","provider":"openai","model":"gpt-4","status":200,"latency_ms":42}]`),
@@ -2,6 +2,7 @@
 package cli
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"slices"
+	"strings"
 	"time"
 )
 
@@ -69,6 +71,12 @@ func (c *AdminClient) Put(ctx context.Context, path string, body, dest any) erro
 	return c.do(ctx, http.MethodPut, path, body, dest)
 }
 
+// PostRaw performs a POST request with a JSON body sent byte for byte, as a
+// signature over it requires, and extra request headers.
+func (c *AdminClient) PostRaw(ctx context.Context, path string, body []byte, header http.Header, dest any) error {
+	return c.send(ctx, http.MethodPost, path, bytes.NewReader(body), header, dest)
+}
+
 // do issues the request and decodes dest. Status codes in tolerate are decoded
 // instead of being turned into an error.
 func (c *AdminClient) do(ctx context.Context, method, path string, body, dest any, tolerate ...int) error {
@@ -80,11 +88,17 @@ func (c *AdminClient) do(ctx context.Context, method, path string, body, dest an
 		}
 		bodyReader = bytes.NewReader(data)
 	}
+	return c.send(ctx, method, path, bodyReader, nil, dest, tolerate...)
+}
 
+func (c *AdminClient) send(ctx context.Context, method, path string, bodyReader io.Reader, header http.Header, dest any, tolerate ...int) error {
 	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bodyReader)
 	if err != nil {
 		return fmt.Errorf("build request: %w", err)
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 	req.Header.Set("Content-Type", "application/json")
 	if c.APIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.APIKey)
@@ -102,15 +116,7 @@ func (c *AdminClient) do(ctx context.Context, method, path string, body, dest an
 	}
 
 	if resp.StatusCode >= 400 && !slices.Contains(tolerate, resp.StatusCode) {
-		var apiErr struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
-			return fmt.Errorf("%s %s: %s", method, path, apiErr.Error.Message)
-		}
-		return fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+		return responseError(method, path, resp.StatusCode, respBody)
 	}
 
 	if dest != nil && len(respBody) > 0 {
@@ -120,3 +126,86 @@ func (c *AdminClient) do(ctx context.Context, method, path string, body, dest an
 	}
 	return nil
 }
+
+// responseError describes a failed admin API call, preferring the message in
+// the gateway's JSON error body over the bare status code.
+func responseError(method, path string, status int, body []byte) error {
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		return fmt.Errorf("%s %s: %s", method, path, apiErr.Error.Message)
+	}
+	return fmt.Errorf("%s %s: HTTP %d", method, path, status)
+}
+
+// maxEventSize bounds one server-sent event line; a request-log entry carrying
+// request and response bodies can be far larger than bufio's default.
+const maxEventSize = 4 << 20
+
+// EventStream reads server-sent events from an open admin API stream.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// Stream opens a server-sent event stream at path. When it returns, the
+// gateway has accepted the request and is subscribed; events are read with
+// Next. The stream is not bound by the client's request timeout, only by ctx.
+func (c *AdminClient) Stream(ctx context.Context, path string) (*EventStream, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	client := *c.HTTPClient
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer func() { _ = resp.Body.Close() }()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, responseError(http.MethodGet, path, resp.StatusCode, respBody)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	return &EventStream{body: resp.Body, scanner: scanner}, nil
+}
+
+// Next blocks until the next event and returns its name and data. Comments
+// such as heartbeats are skipped. At the end of the stream it returns io.EOF.
+func (s *EventStream) Next() (event, data string, err error) {
+	var lines []string
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		switch {
+		case line == "":
+			if event != "" || len(lines) > 0 {
+				return event, strings.Join(lines, "\n"), nil
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			lines = append(lines, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return "", "", err
+	}
+	return "", "", io.EOF
+}
+
+// Close ends the stream.
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
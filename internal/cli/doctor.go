@@ -1,83 +1,222 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 	"time"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/bootstrap"
+	"github.com/ferro-labs/ai-gateway/providers"
 	"github.com/spf13/cobra"
 )
 
-// DoctorCmd runs offline environment and connectivity checks.
+// DoctorCmd runs environment, configuration, and connectivity checks.
 var DoctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Check environment, configuration, and gateway connectivity",
-	RunE:  runDoctor,
+	Long: `Check that this environment is ready to run the gateway and print a
+readiness report: which providers have credentials, whether each provider
+answers a model-list call with them, whether GATEWAY_CONFIG loads, whether the
+key, config, and request log stores are reachable, and whether a gateway is
+running at --gateway-url.
+
+Provider probes list models and are not billed. --offline skips the provider
+and store probes. It exits non-zero when any check fails.`,
+	RunE: runDoctor,
+	// The report explains a failure; usage would bury it.
+	SilenceUsage: true,
+}
+
+// doctorProbeTimeout bounds each provider and store probe.
+const doctorProbeTimeout = 10 * time.Second
+
+// doctorReport prints check results under section headings and counts them
+// for the closing readiness summary.
+type doctorReport struct {
+	out                  io.Writer
+	failed, warned, okay int
+}
+
+func (r *doctorReport) section(title string) {
+	_, _ = fmt.Fprintf(r.out, "\n  %s\n", title)
+}
+
+func (r *doctorReport) ok(format string, args ...any) {
+	r.okay++
+	_, _ = fmt.Fprintf(r.out, "    %s %s\n", Clr(ColorGreen, SymOK), fmt.Sprintf(format, args...))
+}
+
+func (r *doctorReport) warn(format string, args ...any) {
+	r.warned++
+	_, _ = fmt.Fprintf(r.out, "    %s %s\n", Clr(ColorYellow, SymWARN), fmt.Sprintf(format, args...))
+}
+
+func (r *doctorReport) fail(format string, args ...any) {
+	r.failed++
+	_, _ = fmt.Fprintf(r.out, "    %s %s\n", Clr(ColorRed, SymFAIL), fmt.Sprintf(format, args...))
+}
+
+func (r *doctorReport) skip(format string, args ...any) {
+	_, _ = fmt.Fprintf(r.out, "    %s %s\n", Clr(ColorDim, SymDASH), fmt.Sprintf(format, args...))
 }
 
 func runDoctor(cmd *cobra.Command, _ []string) error {
-	out := cmd.OutOrStdout()
-	_, _ = fmt.Fprintln(out, "  Provider API Keys")
-
-	topProviders := []struct {
-		name   string
-		envKey string
-	}{
-		{"openai", "OPENAI_API_KEY"},
-		{"anthropic", "ANTHROPIC_API_KEY"},
-		{"gemini", "GEMINI_API_KEY"},
-		{"groq", "GROQ_API_KEY"},
-		{"mistral", "MISTRAL_API_KEY"},
-	}
-
-	found := 0
-	for _, p := range topProviders {
-		if os.Getenv(p.envKey) != "" {
-			_, _ = fmt.Fprintf(out, "    %s %s\n", Clr(ColorGreen, SymOK), p.name)
-			found++
-		} else {
-			_, _ = fmt.Fprintf(out, "    %s %s\n", Clr(ColorDim, SymDASH), p.name)
-		}
+	offline, _ := cmd.Flags().GetBool("offline")
+	r := &doctorReport{out: cmd.OutOrStdout()}
+
+	configured := doctorProviderKeys(r)
+	if !offline {
+		doctorProviderProbes(cmd.Context(), r, configured)
 	}
+	doctorConfig(r)
 
-	if found == 0 {
-		_, _ = fmt.Fprintf(out, "\n    %s no provider API keys detected\n", Clr(ColorYellow, SymWARN))
+	r.section("Auth")
+	if os.Getenv("MASTER_KEY") != "" {
+		r.ok("MASTER_KEY is set")
 	} else {
-		_, _ = fmt.Fprintf(out, "\n    %d found\n", found)
+		r.warn("MASTER_KEY not set -- run 'ferrogw init' to generate one")
 	}
 
-	// Configuration check.
-	_, _ = fmt.Fprintln(out)
-	_, _ = fmt.Fprintln(out, "  Configuration")
-	cfgPath := os.Getenv("GATEWAY_CONFIG")
-	if cfgPath == "" {
-		_, _ = fmt.Fprintf(out, "    %s GATEWAY_CONFIG not set (using defaults)\n", Clr(ColorDim, SymDASH))
+	if !offline {
+		doctorStores(cmd.Context(), r)
+	}
+	doctorGateway(cmd, r)
+
+	_, _ = fmt.Fprintln(r.out)
+	switch {
+	case r.failed > 0:
+		_, _ = fmt.Fprintf(r.out, "  %s Not ready: %d failed, %d warning(s), %d passed\n", Clr(ColorRed, SymFAIL), r.failed, r.warned, r.okay)
+	case r.warned > 0:
+		_, _ = fmt.Fprintf(r.out, "  %s Ready with %d warning(s), %d passed\n", Clr(ColorYellow, SymWARN), r.warned, r.okay)
+	default:
+		_, _ = fmt.Fprintf(r.out, "  %s Ready: %d passed\n", Clr(ColorGreen, SymOK), r.okay)
+	}
+	_, _ = fmt.Fprintln(r.out)
+	if r.failed > 0 {
+		return fmt.Errorf("not ready: %d check(s) failed", r.failed)
+	}
+	return nil
+}
+
+// doctorProviderKeys reports every provider whose credentials are present in
+// the environment and returns their entries.
+func doctorProviderKeys(r *doctorReport) []providers.ProviderEntry {
+	_, _ = fmt.Fprintln(r.out, "  Provider API Keys")
+	var configured []providers.ProviderEntry
+	for _, entry := range providers.AllProviders() {
+		if providers.ProviderConfigFromEnv(entry) != nil {
+			configured = append(configured, entry)
+			r.ok("%s", entry.ID)
+		}
+	}
+	if len(configured) == 0 {
+		r.warn("no provider API keys detected -- set e.g. OPENAI_API_KEY")
 	} else {
-		cfg, err := aigateway.LoadConfig(cfgPath)
-		if err != nil {
-			_, _ = fmt.Fprintf(out, "    %s %s: %v\n", Clr(ColorRed, SymFAIL), cfgPath, err)
-		} else if err := aigateway.ValidateConfig(*cfg); err != nil {
-			_, _ = fmt.Fprintf(out, "    %s %s: %v\n", Clr(ColorRed, SymFAIL), cfgPath, err)
-		} else {
-			_, _ = fmt.Fprintf(out, "    %s %s (strategy=%s, targets=%d)\n",
-				Clr(ColorGreen, SymOK), cfgPath, cfg.Strategy.Mode, len(cfg.Targets))
+		_, _ = fmt.Fprintf(r.out, "\n    %d found\n", len(configured))
+	}
+	return configured
+}
+
+// doctorProviderProbes builds each configured provider and, where it can list
+// models, calls that endpoint to confirm the credentials are accepted. The
+// probes run concurrently and are reported in provider order.
+func doctorProviderProbes(ctx context.Context, r *doctorReport, entries []providers.ProviderEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	r.section("Provider Connectivity")
+	type probe struct {
+		latency   time.Duration
+		models    int
+		buildErr  error
+		probeErr  error
+		discovery bool
+	}
+	results := make([]probe, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Go(func() {
+			p, err := bootstrap.BuildProviderFromEnv(entry)
+			if err != nil || p == nil {
+				results[i].buildErr = err
+				return
+			}
+			d, ok := p.(providers.DiscoveryProvider)
+			if !ok {
+				return
+			}
+			results[i].discovery = true
+			probeCtx, cancel := context.WithTimeout(ctx, doctorProbeTimeout)
+			defer cancel()
+			start := time.Now()
+			found, err := d.DiscoverModels(probeCtx)
+			results[i].latency, results[i].models, results[i].probeErr = time.Since(start), len(found), err
+		})
+	}
+	wg.Wait()
+
+	for i, entry := range entries {
+		res := results[i]
+		switch {
+		case res.buildErr != nil:
+			r.fail("%s: %v", entry.ID, res.buildErr)
+		case !res.discovery:
+			r.skip("%s: credentials present; no model-list endpoint to probe", entry.ID)
+		case res.probeErr != nil:
+			r.fail("%s: %v", entry.ID, res.probeErr)
+		default:
+			r.ok("%s -- %d models (%dms)", entry.ID, res.models, res.latency.Milliseconds())
 		}
 	}
+}
 
-	// Master key check.
-	_, _ = fmt.Fprintln(out)
-	_, _ = fmt.Fprintln(out, "  Auth")
-	if os.Getenv("MASTER_KEY") != "" {
-		_, _ = fmt.Fprintf(out, "    %s MASTER_KEY is set\n", Clr(ColorGreen, SymOK))
-	} else {
-		_, _ = fmt.Fprintf(out, "    %s MASTER_KEY not set -- run 'ferrogw init' to generate one\n", Clr(ColorYellow, SymWARN))
+// doctorConfig loads and validates the file GATEWAY_CONFIG names.
+func doctorConfig(r *doctorReport) {
+	r.section("Configuration")
+	cfgPath := os.Getenv("GATEWAY_CONFIG")
+	if cfgPath == "" {
+		r.skip("GATEWAY_CONFIG not set (using defaults)")
+		return
+	}
+	cfg, err := aigateway.LoadConfig(cfgPath)
+	if err != nil {
+		r.fail("%s: %v", cfgPath, err)
+		return
+	}
+	if err := aigateway.ValidateConfig(*cfg); err != nil {
+		r.fail("%s: %v", cfgPath, err)
+		return
 	}
+	r.ok("%s (strategy=%s, targets=%d)", cfgPath, cfg.Strategy.Mode, len(cfg.Targets))
+}
 
-	// Connectivity check.
-	_, _ = fmt.Fprintln(out)
-	_, _ = fmt.Fprintln(out, "  Gateway Connectivity")
+// doctorStores reports each persistence store's backend and whether its
+// database answers.
+func doctorStores(ctx context.Context, r *doctorReport) {
+	r.section("Stores")
+	probeCtx, cancel := context.WithTimeout(ctx, doctorProbeTimeout)
+	defer cancel()
+	for _, s := range bootstrap.CheckStoresFromEnv(probeCtx) {
+		switch {
+		case s.Err != nil:
+			r.fail("%s (%s): %v", s.Name, s.Backend, s.Err)
+		case s.NotCreated:
+			r.ok("%s (%s) not created yet; the gateway creates it on startup", s.Name, s.Backend)
+		case s.Backend == bootstrap.BackendSQLite || s.Backend == bootstrap.BackendPostgres || s.Backend == bootstrap.BackendFile:
+			r.ok("%s (%s) reachable", s.Name, s.Backend)
+		default:
+			r.skip("%s: %s (not persisted)", s.Name, s.Backend)
+		}
+	}
+}
 
+// doctorGateway checks the gateway at --gateway-url answers /health.
+func doctorGateway(cmd *cobra.Command, r *doctorReport) {
+	r.section("Gateway Connectivity")
 	c := adminClientFromCmd(cmd)
 	var h struct {
 		Status string `json:"status"`
@@ -89,13 +228,14 @@ func runDoctor(cmd *cobra.Command, _ []string) error {
 	latency := time.Since(start)
 	switch {
 	case err != nil:
-		_, _ = fmt.Fprintf(out, "    %s %s: %v\n", Clr(ColorRed, SymFAIL), c.BaseURL, err)
+		r.fail("%s: %v", c.BaseURL, err)
 	case h.Status != "ok":
-		_, _ = fmt.Fprintf(out, "    %s %s -- %s (%dms)\n", Clr(ColorYellow, SymWARN), c.BaseURL, h.Status, latency.Milliseconds())
+		r.warn("%s -- %s (%dms)", c.BaseURL, h.Status, latency.Milliseconds())
 	default:
-		_, _ = fmt.Fprintf(out, "    %s %s -- healthy (%dms)\n", Clr(ColorGreen, SymOK), c.BaseURL, latency.Milliseconds())
+		r.ok("%s -- healthy (%dms)", c.BaseURL, latency.Milliseconds())
 	}
+}
 
-	_, _ = fmt.Fprintln(out)
-	return nil
+func init() {
+	DoctorCmd.Flags().Bool("offline", false, "Skip the provider and store probes")
 }
@@ -6,27 +6,36 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
 )
 
 func TestRunDoctor(t *testing.T) {
-	// clearProviderKeys blanks every provider env var doctor probes so host
-	// environment leakage does not skew the "N found" count.
+	// clearProviderKeys blanks every provider and store env var doctor reads
+	// so host environment leakage does not skew the "N found" count or start
+	// probes against real services.
 	clearProviderKeys := func(t *testing.T) {
-		for _, k := range []string{
-			"OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GEMINI_API_KEY",
-			"GROQ_API_KEY", "MISTRAL_API_KEY",
-		} {
-			t.Setenv(k, "")
+		for _, entry := range providers.AllProviders() {
+			for _, m := range entry.EnvMappings {
+				t.Setenv(m.EnvVar, "")
+				t.Setenv(m.EnvVar+"_FILE", "")
+			}
+		}
+		for _, store := range []string{"API_KEY_STORE", "CONFIG_STORE", "REQUEST_LOG_STORE"} {
+			t.Setenv(store+"_BACKEND", "")
+			t.Setenv(store+"_DSN", "")
 		}
 	}
 
 	t.Run("reports keys, config, auth and healthy connectivity", func(t *testing.T) {
 		srv := stubGateway(t, map[string]http.HandlerFunc{
-			"/health": jsonHandler(http.StatusOK, `{"status":"ok"}`),
+			"/health":    jsonHandler(http.StatusOK, `{"status":"ok"}`),
+			"/v1/models": jsonHandler(http.StatusOK, `{"data":[{"id":"gpt-4o","object":"model"}]}`),
 		})
 		cmd, out := newHandlerCmd(t, srv.URL, "table")
 		clearProviderKeys(t)
 		t.Setenv("OPENAI_API_KEY", "sk-test")
+		t.Setenv("OPENAI_BASE_URL", srv.URL)
 		t.Setenv("GATEWAY_CONFIG", "")
 		t.Setenv("MASTER_KEY", "master-test")
 
@@ -37,7 +46,8 @@ func TestRunDoctor(t *testing.T) {
 		got := out.String()
 		for _, want := range []string{
 			"Provider API Keys", "openai", "1 found",
-			"MASTER_KEY is set", "healthy",
+			"openai -- 1 models", "MASTER_KEY is set", "healthy",
+			"API_KEY_STORE: memory", "Ready: ",
 		} {
 			if !strings.Contains(got, want) {
 				t.Errorf("output missing %q:\n%s", want, got)
@@ -57,8 +67,8 @@ func TestRunDoctor(t *testing.T) {
 		t.Setenv("GATEWAY_CONFIG", cfgPath)
 		t.Setenv("MASTER_KEY", "")
 
-		if err := runDoctor(cmd, nil); err != nil {
-			t.Fatalf("runDoctor: %v", err)
+		if err := runDoctor(cmd, nil); err == nil || !strings.Contains(err.Error(), "not ready") {
+			t.Fatalf("runDoctor err = %v, want not ready", err)
 		}
 
 		got := out.String()
@@ -66,10 +76,76 @@ func TestRunDoctor(t *testing.T) {
 			"no provider API keys detected",
 			cfgPath,
 			"MASTER_KEY not set",
+			"Not ready: 2 failed",
 		} {
 			if !strings.Contains(got, want) {
 				t.Errorf("output missing %q:\n%s", want, got)
 			}
 		}
 	})
+
+	t.Run("probes providers and stores", func(t *testing.T) {
+		srv := stubGateway(t, map[string]http.HandlerFunc{
+			"/health":    jsonHandler(http.StatusOK, `{"status":"ok"}`),
+			"/v1/models": jsonHandler(http.StatusUnauthorized, `{"error":{"message":"bad key"}}`),
+		})
+		cmd, out := newHandlerCmd(t, srv.URL, "table")
+		clearProviderKeys(t)
+		t.Setenv("OPENAI_API_KEY", "sk-revoked")
+		t.Setenv("OPENAI_BASE_URL", srv.URL)
+		t.Setenv("GATEWAY_CONFIG", "")
+		t.Setenv("MASTER_KEY", "master-test")
+		dir := t.TempDir()
+		keysDB := filepath.Join(dir, "keys.db")
+		if err := os.WriteFile(keysDB, nil, 0o600); err != nil {
+			t.Fatalf("write keys db: %v", err)
+		}
+		t.Setenv("API_KEY_STORE_BACKEND", "sqlite")
+		t.Setenv("API_KEY_STORE_DSN", keysDB)
+		t.Setenv("CONFIG_STORE_BACKEND", "mysql")
+		t.Setenv("REQUEST_LOG_STORE_BACKEND", "sqlite")
+		t.Setenv("REQUEST_LOG_STORE_DSN", filepath.Join(dir, "requests.db"))
+
+		if err := runDoctor(cmd, nil); err == nil {
+			t.Fatal("runDoctor err = nil, want not ready")
+		}
+
+		got := out.String()
+		for _, want := range []string{
+			"openai: openai API error (401): bad key",
+			"API_KEY_STORE (sqlite) reachable",
+			"REQUEST_LOG_STORE (sqlite) not created yet",
+			`CONFIG_STORE (mysql): unsupported backend "mysql"`,
+			"Not ready: 2 failed",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("output missing %q:\n%s", want, got)
+			}
+		}
+		// The checks open SQLite read-only: nothing is created or written.
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("store checks left %d files, want only keys.db: %v", len(entries), entries)
+		}
+	})
+
+	t.Run("offline skips provider and store probes", func(t *testing.T) {
+		srv := stubGateway(t, map[string]http.HandlerFunc{
+			"/health": jsonHandler(http.StatusOK, `{"status":"ok"}`),
+		})
+		cmd, out := newHandlerCmd(t, srv.URL, "table")
+		cmd.Flags().Bool("offline", true, "")
+		clearProviderKeys(t)
+		t.Setenv("OPENAI_API_KEY", "sk-test")
+		t.Setenv("OPENAI_BASE_URL", "http://127.0.0.1:1")
+		t.Setenv("GATEWAY_CONFIG", "")
+		t.Setenv("MASTER_KEY", "master-test")
+
+		if err := runDoctor(cmd, nil); err != nil {
+			t.Fatalf("runDoctor: %v", err)
+		}
+		got := out.String()
+		if strings.Contains(got, "Provider Connectivity") || strings.Contains(got, "Stores") {
+			t.Errorf("offline run probed providers or stores:\n%s", got)
+		}
+	})
 }
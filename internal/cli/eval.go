@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// EvalCmd runs and inspects the eval suites defined in a running gateway.
+var EvalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Run eval suites against models and compare their scores",
+	Long: `Run the eval suites defined in a running gateway's config (eval_suites)
+and compare how models score on them.
+
+  ferrogw eval list
+  ferrogw eval run <suite> --model gpt-4o --model claude-3-5-sonnet
+  ferrogw eval runs <suite> [--model gpt-4o]
+  ferrogw eval show <suite> <run-id>`,
+}
+
+// evalRun mirrors the run summary returned by the admin API.
+type evalRun struct {
+	ID          string    `json:"id" yaml:"id"`
+	Suite       string    `json:"suite" yaml:"suite"`
+	Model       string    `json:"model" yaml:"model"`
+	CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
+	Score       float64   `json:"score" yaml:"score"`
+	Passed      int       `json:"passed" yaml:"passed"`
+	Total       int       `json:"total" yaml:"total"`
+	Errors      int       `json:"errors" yaml:"errors"`
+	TotalTokens int       `json:"total_tokens" yaml:"total_tokens"`
+	Cases       []struct {
+		CaseID string  `json:"case_id" yaml:"case_id"`
+		Output string  `json:"output" yaml:"output"`
+		Score  float64 `json:"score" yaml:"score"`
+		Passed bool    `json:"passed" yaml:"passed"`
+		Reason string  `json:"reason,omitempty" yaml:"reason,omitempty"`
+		Error  string  `json:"error,omitempty" yaml:"error,omitempty"`
+	} `json:"cases,omitempty" yaml:"cases,omitempty"`
+}
+
+var evalListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List eval suites with the latest score of each model",
+	RunE:  runEvalList,
+}
+
+func runEvalList(cmd *cobra.Command, _ []string) error {
+	c := adminClientFromCmd(cmd)
+	var result struct {
+		Data []struct {
+			Name        string    `json:"name" yaml:"name"`
+			Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+			Latest      []evalRun `json:"latest" yaml:"latest"`
+		} `json:"data"`
+	}
+	if err := c.Get(cmd.Context(), "/admin/evals", &result); err != nil {
+		return err
+	}
+
+	pr := printerFromCmd(cmd)
+	if pr.Format != FormatTable {
+		return pr.Print(result.Data)
+	}
+	out := cmd.OutOrStdout()
+	if len(result.Data) == 0 {
+		_, _ = fmt.Fprintln(out, "No eval suites defined.")
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "%-24s %-32s %s\n", "SUITE", "MODEL", "SCORE")
+	for _, s := range result.Data {
+		if len(s.Latest) == 0 {
+			_, _ = fmt.Fprintf(out, "%-24s %-32s %s\n", s.Name, "-", "not run")
+			continue
+		}
+		for _, run := range s.Latest {
+			_, _ = fmt.Fprintf(out, "%-24s %-32s %s\n", s.Name, run.Model, fmtEvalScore(run))
+		}
+	}
+	return nil
+}
+
+var evalRunCmd = &cobra.Command{
+	Use:   "run <suite>",
+	Short: "Run an eval suite against one or more models",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEvalRun,
+}
+
+func runEvalRun(cmd *cobra.Command, args []string) error {
+	models, _ := cmd.Flags().GetStringSlice("model")
+	if len(models) == 0 {
+		return errors.New("at least one --model is required")
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	c := adminClientFromCmd(cmd)
+	// A run waits on every case of every model; the default client timeout
+	// is sized for quick admin calls.
+	if timeout > 0 {
+		c.HTTPClient.Timeout = timeout
+	}
+	var result struct {
+		Data []evalRun `json:"data"`
+	}
+	if err := c.Post(cmd.Context(), "/admin/evals/"+url.PathEscape(args[0])+"/run", map[string]any{"models": models}, &result); err != nil {
+		return err
+	}
+	return printEvalRuns(cmd, result.Data)
+}
+
+var evalRunsCmd = &cobra.Command{
+	Use:   "runs <suite>",
+	Short: "List the stored runs of an eval suite, newest first",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEvalRuns,
+}
+
+func runEvalRuns(cmd *cobra.Command, args []string) error {
+	path := "/admin/evals/" + url.PathEscape(args[0]) + "/runs"
+	if model, _ := cmd.Flags().GetString("model"); model != "" {
+		path += "?model=" + url.QueryEscape(model)
+	}
+	c := adminClientFromCmd(cmd)
+	var result struct {
+		Data []evalRun `json:"data"`
+	}
+	if err := c.Get(cmd.Context(), path, &result); err != nil {
+		return err
+	}
+	return printEvalRuns(cmd, result.Data)
+}
+
+var evalShowCmd = &cobra.Command{
+	Use:   "show <suite> <run-id>",
+	Short: "Show a run's per-case results",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runEvalShow,
+}
+
+func runEvalShow(cmd *cobra.Command, args []string) error {
+	c := adminClientFromCmd(cmd)
+	var run evalRun
+	if err := c.Get(cmd.Context(), "/admin/evals/"+url.PathEscape(args[0])+"/runs/"+url.PathEscape(args[1]), &run); err != nil {
+		return err
+	}
+
+	pr := printerFromCmd(cmd)
+	if pr.Format != FormatTable {
+		return pr.Print(run)
+	}
+	out := cmd.OutOrStdout()
+	_, _ = fmt.Fprintf(out, "%s on %s: %s\n\n", run.Model, run.Suite, fmtEvalScore(run))
+	_, _ = fmt.Fprintf(out, "%-24s %-6s %-6s %s\n", "CASE", "SCORE", "PASSED", "OUTPUT")
+	for _, cr := range run.Cases {
+		passed := boolNo
+		if cr.Passed {
+			passed = boolYes
+		}
+		output := cr.Output
+		if cr.Error != "" {
+			output = "error: " + cr.Error
+		}
+		_, _ = fmt.Fprintf(out, "%-24s %-6.2f %-6s %s\n", cr.CaseID, cr.Score, passed, truncate(output, 60))
+	}
+	return nil
+}
+
+// printEvalRuns prints one row per run, so runs of different models on the
+// same suite line up for comparison.
+func printEvalRuns(cmd *cobra.Command, runs []evalRun) error {
+	pr := printerFromCmd(cmd)
+	if pr.Format != FormatTable {
+		return pr.Print(runs)
+	}
+	out := cmd.OutOrStdout()
+	if len(runs) == 0 {
+		_, _ = fmt.Fprintln(out, "No runs.")
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "%-22s %-32s %-20s %-8s %s\n", "RUN", "MODEL", "CREATED", "TOKENS", "SCORE")
+	for _, run := range runs {
+		_, _ = fmt.Fprintf(out, "%-22s %-32s %-20s %-8d %s\n",
+			run.ID, run.Model, run.CreatedAt.Local().Format("2006-01-02 15:04:05"), run.TotalTokens, fmtEvalScore(run))
+	}
+	return nil
+}
+
+func fmtEvalScore(run evalRun) string {
+	s := fmt.Sprintf("%.2f (%d/%d passed)", run.Score, run.Passed, run.Total)
+	if run.Errors > 0 {
+		s += fmt.Sprintf(", %d errors", run.Errors)
+	}
+	return s
+}
+
+// truncate shortens s to at most n runes on a single line.
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func init() {
+	evalRunCmd.Flags().StringSlice("model", nil, "Model to evaluate (repeatable)")
+	evalRunCmd.Flags().Duration("timeout", 10*time.Minute, "How long to wait for the run to finish")
+	evalRunsCmd.Flags().String("model", "", "Only list runs of this model")
+
+	EvalCmd.AddCommand(evalListCmd, evalRunCmd, evalRunsCmd, evalShowCmd)
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunEvalRun(t *testing.T) {
+	const runBody = `{"data":[
+		{"id":"evr_1","suite":"capitals","model":"gpt-4o","score":1,"passed":2,"total":2,"created_at":"2026-01-02T03:04:05Z"},
+		{"id":"evr_2","suite":"capitals","model":"small","score":0.5,"passed":1,"total":2,"errors":1,"created_at":"2026-01-02T03:04:06Z"}]}`
+
+	t.Run("posts the models and compares their scores", func(t *testing.T) {
+		var got string
+		srv := stubGateway(t, map[string]http.HandlerFunc{
+			"/admin/evals/capitals/run": func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = string(body)
+				jsonHandler(http.StatusOK, runBody)(w, r)
+			},
+		})
+		cmd, out := newHandlerCmd(t, srv.URL, "table")
+		cmd.Flags().StringSlice("model", []string{"gpt-4o", "small"}, "")
+
+		if err := runEvalRun(cmd, []string{"capitals"}); err != nil {
+			t.Fatalf("runEvalRun: %v", err)
+		}
+		if got != `{"models":["gpt-4o","small"]}` {
+			t.Errorf("request body = %s", got)
+		}
+		for _, want := range []string{"evr_1", "1.00 (2/2 passed)", "small", "0.50 (1/2 passed), 1 errors"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("output missing %q:\n%s", want, out.String())
+			}
+		}
+	})
+
+	t.Run("requires a model", func(t *testing.T) {
+		cmd, _ := newHandlerCmd(t, "http://127.0.0.1:0", "table")
+		cmd.Flags().StringSlice("model", nil, "")
+		if err := runEvalRun(cmd, []string{"capitals"}); err == nil {
+			t.Fatal("expected an error without --model")
+		}
+	})
+}
+
+func TestRunEvalList(t *testing.T) {
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/admin/evals": jsonHandler(http.StatusOK, `{"data":[
+			{"name":"capitals","latest":[{"model":"gpt-4o","score":1,"passed":2,"total":2}]},
+			{"name":"fresh","latest":[]}]}`),
+	})
+	cmd, out := newHandlerCmd(t, srv.URL, "table")
+
+	if err := runEvalList(cmd, nil); err != nil {
+		t.Fatalf("runEvalList: %v", err)
+	}
+	for _, want := range []string{"SUITE", "capitals", "gpt-4o", "fresh", "not run"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunEvalShow(t *testing.T) {
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/admin/evals/capitals/runs/evr_1": jsonHandler(http.StatusOK, `{"id":"evr_1","suite":"capitals","model":"gpt-4o","score":0.5,"passed":1,"total":2,
+			"cases":[{"case_id":"1","output":"Paris","score":1,"passed":true},{"case_id":"2","error":"timeout"}]}`),
+	})
+	cmd, out := newHandlerCmd(t, srv.URL, "table")
+
+	if err := runEvalShow(cmd, []string{"capitals", "evr_1"}); err != nil {
+		t.Fatalf("runEvalShow: %v", err)
+	}
+	for _, want := range []string{"gpt-4o on capitals", "Paris", "error: timeout"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// slowCompletion is how long a long, slow completion can legitimately take.
+// A circuit breaker that reopens for probes sooner than this, when no
+// request_timeout says otherwise, flaps on a provider that is merely slow.
+const slowCompletion = 10 * time.Second
+
+// lintConfig returns warnings about settings that pass validation but are
+// unlikely to do what the operator meant. cfg must already be valid.
+func lintConfig(cfg *aigateway.Config) []string {
+	var warnings []string
+	warnings = append(warnings, lintWeights(cfg)...)
+	warnings = append(warnings, lintAliases(cfg)...)
+	warnings = append(warnings, lintCircuitBreakers(cfg)...)
+	warnings = append(warnings, lintPluginStages(cfg)...)
+	warnings = append(warnings, lintConditions(cfg)...)
+	return warnings
+}
+
+// lintWeights flags target weights the strategy ignores or reads differently
+// than they look: a weight of 0 among weighted targets counts as 1, not off,
+// and weights are relative shares, so a near-100 total that misses 100 is
+// usually a percentage typo.
+func lintWeights(cfg *aigateway.Config) []string {
+	var warnings []string
+	weighted := slices.ContainsFunc(cfg.Targets, func(t aigateway.Target) bool { return t.Weight > 0 })
+	if cfg.Strategy.Mode != aigateway.ModeLoadBalance {
+		if weighted {
+			warnings = append(warnings, fmt.Sprintf("target weights are ignored in %s mode; only loadbalance uses them", cfg.Strategy.Mode))
+		}
+		return warnings
+	}
+	var sum float64
+	for _, t := range cfg.Targets {
+		sum += t.Weight
+		if t.Weight == 0 {
+			warnings = append(warnings, fmt.Sprintf("target %q has weight 0, which load balancing counts as 1, not as off; set state: disabled to take it out of rotation", t.VirtualKey))
+		}
+	}
+	if sum != 100 && sum > 50 && sum < 150 {
+		warnings = append(warnings, fmt.Sprintf("loadbalance weights sum to %g, not 100; they are relative shares, so each target gets weight/%g of traffic", sum, sum))
+	}
+	return warnings
+}
+
+// lintAliases flags aliases and canaries whose model no target can serve
+// by its built-in model list. It stays quiet when any target is not a
+// built-in provider, since that target's models cannot be known offline.
+func lintAliases(cfg *aigateway.Config) []string {
+	if len(cfg.Aliases) == 0 {
+		return nil
+	}
+	var targets []providers.Provider
+	for _, t := range cfg.Targets {
+		if !t.InService() {
+			continue
+		}
+		p, ok := placeholderProvider(t.VirtualKey)
+		if !ok {
+			return nil
+		}
+		targets = append(targets, p)
+	}
+	servable := func(model string) bool {
+		return slices.ContainsFunc(targets, func(p providers.Provider) bool { return p.SupportsModel(model) })
+	}
+
+	var warnings []string
+	for _, alias := range slices.Sorted(maps.Keys(cfg.Aliases)) {
+		models := []string{cfg.Aliases[alias]}
+		for _, c := range cfg.Canaries[alias] {
+			models = append(models, c.Model)
+		}
+		for _, model := range models {
+			if !servable(model) {
+				warnings = append(warnings, fmt.Sprintf("alias %q resolves to %q, which no in-service target serves; requests for it will fail unless model discovery finds it", alias, model))
+			}
+		}
+	}
+	return warnings
+}
+
+// placeholderProvider builds the built-in provider named name with
+// placeholder credentials, only to ask it which models it serves.
+func placeholderProvider(name string) (providers.Provider, bool) {
+	entry, ok := providers.GetProviderEntry(name)
+	if !ok {
+		return nil, false
+	}
+	cfg := providers.ProviderConfig{}
+	for _, m := range entry.EnvMappings {
+		if m.Required {
+			cfg[m.ConfigKey] = "lint-placeholder"
+		}
+	}
+	p, err := entry.Build(cfg)
+	if err != nil || p == nil {
+		return nil, false
+	}
+	return p, true
+}
+
+// lintCircuitBreakers flags breakers whose open window is shorter than a
+// request may take, so probes are let through while requests that tripped
+// the breaker are still running, and the breaker flaps.
+func lintCircuitBreakers(cfg *aigateway.Config) []string {
+	floor, what := slowCompletion, "a slow completion ("+slowCompletion.String()+")"
+	if d, err := time.ParseDuration(cfg.RequestTimeout); err == nil && d > 0 {
+		floor, what = d, "request_timeout ("+d.String()+")"
+	}
+	var warnings []string
+	for _, t := range cfg.Targets {
+		cb := t.CircuitBreaker
+		if cb == nil || cb.Timeout == "" {
+			continue
+		}
+		d, err := time.ParseDuration(cb.Timeout)
+		if err != nil || d >= floor {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("target %q circuit_breaker.timeout %s is shorter than %s; the breaker will reopen to probes while failing requests are still in flight", t.VirtualKey, d, what))
+	}
+	return warnings
+}
+
+// lintPluginStages flags enabled plugins at a stage where they cannot do
+// their job, or at no stage the gateway knows.
+func lintPluginStages(cfg *aigateway.Config) []string {
+	var warnings []string
+	for _, pc := range cfg.Plugins {
+		if !pc.Enabled {
+			continue
+		}
+		stage := plugin.Stage(pc.Stage)
+		switch stage {
+		case plugin.StageBeforeRequest, plugin.StageAfterRequest, plugin.StageOnError:
+		default:
+			warnings = append(warnings, fmt.Sprintf("plugin %q has unknown stage %q; the gateway will refuse to load it (use before_request, after_request, or on_error)", pc.Name, pc.Stage))
+			continue
+		}
+		factory, ok := plugin.GetFactory(pc.Name)
+		if !ok {
+			continue
+		}
+		switch typ := factory().Type(); {
+		case (typ == plugin.TypeRateLimit || typ == plugin.TypeAuth) && stage != plugin.StageBeforeRequest:
+			warnings = append(warnings, fmt.Sprintf("plugin %q is a %s plugin at %s; it can only stop requests at before_request", pc.Name, typ, stage))
+		case (typ == plugin.TypeGuardrail || typ == plugin.TypeTransform) && stage == plugin.StageOnError:
+			warnings = append(warnings, fmt.Sprintf("plugin %q is a %s plugin at on_error, where the request has already failed and there is nothing left to check or rewrite", pc.Name, typ))
+		}
+	}
+	return warnings
+}
+
+// lintConditions flags conditional rules that can never match, either on
+// their own or because an earlier rule without a time window matches every
+// request they would.
+func lintConditions(cfg *aigateway.Config) []string {
+	if cfg.Strategy.Mode != aigateway.ModeConditional {
+		return nil
+	}
+	conds := cfg.Strategy.Conditions
+	var warnings []string
+	for j, c := range conds {
+		switch {
+		case c.Expr == "" && c.Key == "" && c.Window == nil:
+			warnings = append(warnings, fmt.Sprintf("condition %d has no key, expr, or window and never matches", j))
+			continue
+		case c.Key != "" && c.Key != "model" && c.Key != "model_prefix":
+			warnings = append(warnings, fmt.Sprintf("condition %d has unknown key %q and never matches (use model or model_prefix)", j, c.Key))
+			continue
+		}
+		for i, earlier := range conds[:j] {
+			if conditionCovers(earlier, c) {
+				warnings = append(warnings, fmt.Sprintf("condition %d is unreachable: condition %d matches every request it would", j, i))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// conditionCovers reports whether a, evaluated first, matches every request
+// b matches. Only rules a can be proven to cover are reported; a windowed a
+// is open only part of the time, so it covers nothing.
+func conditionCovers(a, b aigateway.Condition) bool {
+	if a.Window != nil {
+		return false
+	}
+	if a.Expr != "" {
+		return a.Expr == b.Expr && a.Key == "" && b.Key == ""
+	}
+	if a.Key == "" || b.Key == "" {
+		return false
+	}
+	switch a.Key {
+	case "model":
+		return b.Key == "model" && a.Value == b.Value
+	case "model_prefix":
+		return strings.HasPrefix(b.Value, a.Value) && (b.Key == "model" || b.Key == "model_prefix")
+	}
+	return false
+}
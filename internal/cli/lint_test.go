@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	_ "github.com/ferro-labs/ai-gateway/internal/plugins/ratelimit"
+)
+
+// hasWarning reports whether some warning contains want.
+func hasWarning(warnings []string, want string) bool {
+	for _, w := range warnings {
+		if strings.Contains(w, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLintConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  aigateway.Config
+		want string
+	}{
+		{
+			name: "zero weight among weighted targets",
+			cfg: aigateway.Config{
+				Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeLoadBalance},
+				Targets:  []aigateway.Target{{VirtualKey: "openai", Weight: 70}, {VirtualKey: "anthropic"}},
+			},
+			want: `"anthropic" has weight 0`,
+		},
+		{
+			name: "weights that miss 100",
+			cfg: aigateway.Config{
+				Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeLoadBalance},
+				Targets:  []aigateway.Target{{VirtualKey: "openai", Weight: 70}, {VirtualKey: "anthropic", Weight: 20}},
+			},
+			want: "sum to 90",
+		},
+		{
+			name: "weights outside loadbalance",
+			cfg: aigateway.Config{
+				Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeFallback},
+				Targets:  []aigateway.Target{{VirtualKey: "openai", Weight: 50}},
+			},
+			want: "ignored in fallback mode",
+		},
+		{
+			name: "alias no target serves",
+			cfg: aigateway.Config{
+				Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeFallback},
+				Targets:  []aigateway.Target{{VirtualKey: "anthropic"}},
+				Aliases:  map[string]string{"fast": "gpt-4o-mini"},
+			},
+			want: `alias "fast" resolves to "gpt-4o-mini"`,
+		},
+		{
+			name: "breaker shorter than request timeout",
+			cfg: aigateway.Config{
+				Strategy:       aigateway.StrategyConfig{Mode: aigateway.ModeFallback},
+				RequestTimeout: "60s",
+				Targets: []aigateway.Target{{VirtualKey: "openai",
+					CircuitBreaker: &aigateway.CircuitBreakerConfig{Timeout: "5s"}}},
+			},
+			want: "shorter than request_timeout (1m0s)",
+		},
+		{
+			name: "rate limit after the request",
+			cfg: aigateway.Config{
+				Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeFallback},
+				Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+				Plugins:  []aigateway.PluginConfig{{Name: "rate-limit", Stage: "after_request", Enabled: true}},
+			},
+			want: "can only stop requests at before_request",
+		},
+		{
+			name: "unknown plugin stage",
+			cfg: aigateway.Config{
+				Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeFallback},
+				Targets:  []aigateway.Target{{VirtualKey: "openai"}},
+				Plugins:  []aigateway.PluginConfig{{Name: "rate-limit", Stage: "before", Enabled: true}},
+			},
+			want: `unknown stage "before"`,
+		},
+		{
+			name: "shadowed condition",
+			cfg: aigateway.Config{
+				Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeConditional, Conditions: []aigateway.Condition{
+					{Key: "model_prefix", Value: "gpt-", TargetKey: "openai"},
+					{Key: "model", Value: "gpt-4o", TargetKey: "anthropic"},
+				}},
+				Targets: []aigateway.Target{{VirtualKey: "openai"}, {VirtualKey: "anthropic"}},
+			},
+			want: "condition 1 is unreachable: condition 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lintConfig(&tt.cfg); !hasWarning(got, tt.want) {
+				t.Fatalf("warnings %q, want one containing %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintConfig_CleanConfigHasNoWarnings(t *testing.T) {
+	cfg := aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeLoadBalance},
+		Targets:  []aigateway.Target{{VirtualKey: "openai", Weight: 60}, {VirtualKey: "anthropic", Weight: 40}},
+		Aliases:  map[string]string{"fast": "gpt-4o-mini"},
+		Plugins:  []aigateway.PluginConfig{{Name: "rate-limit", Stage: "before_request", Enabled: true}},
+	}
+	if got := lintConfig(&cfg); len(got) != 0 {
+		t.Fatalf("want no warnings, got %q", got)
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// LogsCmd queries and tails a running gateway's request logs.
+var LogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Query and tail request logs",
+	Long: `Query the request logs of a running gateway, oldest entry first, and
+optionally keep printing new entries as they are written.
+
+  ferrogw logs --since 1h --provider openai
+  ferrogw logs --stage on_error --follow
+  ferrogw logs --model gpt-4o --limit 200 --format json
+
+--since takes a duration back from now (30m, 1h) or an RFC3339 time. With
+--follow, the command prints the matching history and then streams entries
+from /admin/logs/stream until interrupted; in json format each streamed
+entry is one line. Live entries are those written by the gateway process
+the command is connected to.`,
+	Args: cobra.NoArgs,
+	RunE: runLogs,
+}
+
+// logsDefaultLimit is how many past entries are printed when --limit is unset.
+const logsDefaultLimit = 50
+
+func runLogs(cmd *cobra.Command, _ []string) error {
+	filters := url.Values{}
+	for _, name := range []string{"provider", "model", "stage"} {
+		if v, _ := cmd.Flags().GetString(name); v != "" {
+			filters.Set(name, v)
+		}
+	}
+	rawSince, _ := cmd.Flags().GetString("since")
+	since, err := parseLogsSince(rawSince, time.Now())
+	if err != nil {
+		return err
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit <= 0 {
+		return errors.New("--limit must be positive")
+	}
+	follow, _ := cmd.Flags().GetBool("follow")
+
+	c := adminClientFromCmd(cmd)
+	// Subscribe before reading the history so nothing written in between is
+	// lost; entries seen in both are printed once.
+	var stream *EventStream
+	if follow {
+		stream, err = c.Stream(cmd.Context(), "/admin/logs/stream?"+filters.Encode())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = stream.Close() }()
+	}
+
+	history := url.Values{}
+	for k, v := range filters {
+		history[k] = v
+	}
+	history.Set("limit", strconv.Itoa(limit))
+	if !since.IsZero() {
+		history.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	var page struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := c.Get(cmd.Context(), "/admin/logs?"+history.Encode(), &page); err != nil {
+		return err
+	}
+	// The API returns newest first; print in the order entries were written.
+	slices.Reverse(page.Data)
+
+	pr := printerFromCmd(cmd)
+	out := newLogWriter(pr)
+	if !follow {
+		if pr.Format != FormatTable {
+			return pr.Print(page.Data)
+		}
+		if len(page.Data) == 0 {
+			_, _ = fmt.Fprintln(pr.Out, "No log entries found.")
+			return nil
+		}
+	}
+	seen := make(map[string]bool, len(page.Data))
+	for _, e := range page.Data {
+		seen[logEntryKey(e)] = true
+		if err := out.write(e); err != nil {
+			return err
+		}
+	}
+	if !follow {
+		return nil
+	}
+
+	for {
+		event, data, err := stream.Next()
+		if err != nil {
+			// The stream ends when the command is interrupted or the gateway
+			// shuts down; either way there is nothing more to print.
+			if errors.Is(err, io.EOF) || cmd.Context().Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("log stream: %w", err)
+		}
+		if event != "log" {
+			continue
+		}
+		var e map[string]any
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			continue
+		}
+		if key := logEntryKey(e); seen[key] {
+			delete(seen, key)
+			continue
+		}
+		if err := out.write(e); err != nil {
+			return err
+		}
+	}
+}
+
+// parseLogsSince resolves --since to an absolute time: a duration counts
+// back from now, anything else must be RFC3339. Empty means no bound.
+func parseLogsSince(raw string, now time.Time) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("--since %q: duration must be positive", raw)
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--since %q: want a duration such as 1h or an RFC3339 time", raw)
+	}
+	return t, nil
+}
+
+// logEntryKey identifies an entry across the history page and the live
+// stream, which can both carry an entry written while the command started.
+func logEntryKey(e map[string]any) string {
+	return str(e, "trace_id") + "\x00" + str(e, "stage") + "\x00" + str(e, "created_at")
+}
+
+// logWriter prints entries one at a time, so streamed entries appear as they
+// arrive: fixed-width rows in table format, one JSON object per line, or one
+// YAML document per entry.
+type logWriter struct {
+	pr     *Printer
+	yaml   *yaml.Encoder
+	header bool
+}
+
+func newLogWriter(pr *Printer) *logWriter {
+	w := &logWriter{pr: pr}
+	if pr.Format == FormatYAML {
+		w.yaml = yaml.NewEncoder(pr.Out)
+	}
+	return w
+}
+
+const logRowFormat = "%-19s  %-24s  %-12s  %-28s  %7s  %-36s  %s\n"
+
+func (w *logWriter) write(e map[string]any) error {
+	switch w.pr.Format {
+	case FormatJSON:
+		return json.NewEncoder(w.pr.Out).Encode(e)
+	case FormatYAML:
+		return w.yaml.Encode(e)
+	}
+	if !w.header {
+		w.header = true
+		_, _ = fmt.Fprintf(w.pr.Out, logRowFormat, "TIME", "STAGE", "PROVIDER", "MODEL", "TOKENS", "TRACE_ID", "ERROR")
+	}
+	tokens := fmt.Sprintf("%.0f", numVal(e, "total_tokens"))
+	_, err := fmt.Fprintf(w.pr.Out, logRowFormat,
+		logTime(str(e, "created_at")), str(e, "stage"), str(e, "provider"), str(e, "model"),
+		tokens, str(e, "trace_id"), strings.ReplaceAll(str(e, "error_message"), "\n", " "))
+	return err
+}
+
+// logTime renders an entry timestamp in local time to the second, falling
+// back to the raw value when it does not parse.
+func logTime(raw string) string {
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return raw
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func init() {
+	LogsCmd.Flags().String("since", "", "Only entries newer than this: a duration (1h) or an RFC3339 time")
+	LogsCmd.Flags().String("provider", "", "Only entries from this provider")
+	LogsCmd.Flags().String("model", "", "Only entries for this model")
+	LogsCmd.Flags().String("stage", "", "Only entries at this stage, e.g. on_error")
+	LogsCmd.Flags().Int("limit", logsDefaultLimit, "Maximum number of past entries to print")
+	LogsCmd.Flags().BoolP("follow", "f", false, "Keep streaming new entries until interrupted")
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// logsFlags registers LogsCmd's flags on a test command.
+func logsFlags(cmd *cobra.Command) {
+	cmd.Flags().String("since", "", "")
+	cmd.Flags().String("provider", "", "")
+	cmd.Flags().String("model", "", "")
+	cmd.Flags().String("stage", "", "")
+	cmd.Flags().Int("limit", logsDefaultLimit, "")
+	cmd.Flags().Bool("follow", false, "")
+}
+
+const logsPage = `{"data":[
+	{"trace_id":"t2","stage":"on_error","provider":"openai","model":"gpt-4o","total_tokens":0,"error_message":"upstream 500","created_at":"2026-01-02T10:00:02Z"},
+	{"trace_id":"t1","stage":"on_error","provider":"openai","model":"gpt-4o","total_tokens":12,"error_message":"","created_at":"2026-01-02T10:00:01Z"}
+]}`
+
+func TestRunLogs_QueriesFiltersOldestFirst(t *testing.T) {
+	var query map[string][]string
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/admin/logs": func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.Query()
+			jsonHandler(http.StatusOK, logsPage)(w, r)
+		},
+	})
+	cmd, buf := newHandlerCmd(t, srv.URL, FormatTable)
+	logsFlags(cmd)
+	_ = cmd.Flags().Set("provider", "openai")
+	_ = cmd.Flags().Set("stage", "on_error")
+	_ = cmd.Flags().Set("since", "1h")
+
+	if err := runLogs(cmd, nil); err != nil {
+		t.Fatalf("runLogs: %v", err)
+	}
+	if query["provider"][0] != "openai" || query["stage"][0] != "on_error" || query["limit"][0] != "50" {
+		t.Errorf("query = %v", query)
+	}
+	since, err := time.Parse(time.RFC3339, query["since"][0])
+	if err != nil || time.Since(since) < 59*time.Minute || time.Since(since) > 61*time.Minute {
+		t.Errorf("since = %q, want about an hour ago", query["since"][0])
+	}
+	out := buf.String()
+	if !strings.Contains(out, "TRACE_ID") || !strings.Contains(out, "upstream 500") {
+		t.Fatalf("output missing header or error:\n%s", out)
+	}
+	if strings.Index(out, "t1") > strings.Index(out, "t2") {
+		t.Errorf("entries not oldest first:\n%s", out)
+	}
+}
+
+func TestRunLogs_JSON(t *testing.T) {
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/admin/logs": jsonHandler(http.StatusOK, logsPage),
+	})
+	cmd, buf := newHandlerCmd(t, srv.URL, FormatJSON)
+	logsFlags(cmd)
+	if err := runLogs(cmd, nil); err != nil {
+		t.Fatalf("runLogs: %v", err)
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf)
+	}
+	if len(entries) != 2 || entries[0]["trace_id"] != "t1" {
+		t.Errorf("entries = %v", entries)
+	}
+}
+
+func TestRunLogs_Follow(t *testing.T) {
+	var streamQuery string
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/admin/logs": jsonHandler(http.StatusOK, logsPage),
+		"/admin/logs/stream": func(w http.ResponseWriter, r *http.Request) {
+			streamQuery = r.URL.RawQuery
+			w.Header().Set("Content-Type", "text/event-stream")
+			// t2 was written while the command started and is in both the
+			// history and the stream; it must be printed once.
+			_, _ = io.WriteString(w, ": connected\n\n"+
+				"event: log\ndata: "+`{"trace_id":"t2","stage":"on_error","created_at":"2026-01-02T10:00:02Z"}`+"\n\n"+
+				": heartbeat\n\n"+
+				"event: log\ndata: "+`{"trace_id":"t3","stage":"on_error","created_at":"2026-01-02T10:00:03Z"}`+"\n\n")
+		},
+	})
+	cmd, buf := newHandlerCmd(t, srv.URL, FormatJSON)
+	logsFlags(cmd)
+	_ = cmd.Flags().Set("stage", "on_error")
+	_ = cmd.Flags().Set("follow", "true")
+
+	if err := runLogs(cmd, nil); err != nil {
+		t.Fatalf("runLogs: %v", err)
+	}
+	if streamQuery != "stage=on_error" {
+		t.Errorf("stream query = %q", streamQuery)
+	}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %q is not a JSON object: %v", line, err)
+		}
+		ids = append(ids, str(e, "trace_id"))
+	}
+	if strings.Join(ids, ",") != "t1,t2,t3" {
+		t.Errorf("trace ids = %v, want t1,t2,t3", ids)
+	}
+}
+
+func TestParseLogsSince(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		raw     string
+		want    time.Time
+		wantErr bool
+	}{
+		{raw: "", want: time.Time{}},
+		{raw: "90m", want: now.Add(-90 * time.Minute)},
+		{raw: "2026-01-01T00:00:00Z", want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{raw: "-1h", wantErr: true},
+		{raw: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseLogsSince(tt.raw, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLogsSince(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseLogsSince(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
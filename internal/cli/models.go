@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ModelsCmd lists the models a running gateway serves.
+var ModelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List served models with context window, prices, and capabilities",
+	Long: `List the models a running gateway serves (GET /v1/models), with the
+context window, input and output price per 1M tokens, and capability flags
+from the model catalog. A "-" means the catalog has no value for the model.
+
+  ferrogw models
+  ferrogw models --provider openai
+  ferrogw models --grep 'gpt-4o|sonnet' --format json`,
+	Args: cobra.NoArgs,
+	RunE: runModels,
+}
+
+// noValue fills a table cell the catalog has no value for.
+const noValue = "-"
+
+// modelFlags are the capabilities shown in the table, with their short labels,
+// in display order. Others remain in json and yaml output.
+var modelFlags = []struct{ capability, label string }{
+	{"vision", "vision"},
+	{"function_calling", "tools"},
+	{"json_mode", "json"},
+	{"reasoning", "reasoning"},
+	{"prompt_caching", "cache"},
+	{"audio_input", "audio-in"},
+	{"audio_output", "audio-out"},
+}
+
+func runModels(cmd *cobra.Command, _ []string) error {
+	provider, _ := cmd.Flags().GetString("provider")
+	pattern, _ := cmd.Flags().GetString("grep")
+	var match *regexp.Regexp
+	if pattern != "" {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return fmt.Errorf("--grep: %w", err)
+		}
+		match = re
+	}
+
+	c := adminClientFromCmd(cmd)
+	var result struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := c.Get(cmd.Context(), "/v1/models", &result); err != nil {
+		return err
+	}
+	listed := make([]map[string]any, 0, len(result.Data))
+	for _, m := range result.Data {
+		if provider != "" && !strings.EqualFold(str(m, "owned_by"), provider) {
+			continue
+		}
+		if match != nil && !match.MatchString(str(m, "id")) {
+			continue
+		}
+		listed = append(listed, m)
+	}
+
+	pr := printerFromCmd(cmd)
+	if pr.Format == FormatTable && len(listed) == 0 {
+		_, _ = fmt.Fprintln(pr.Out, "No models match.")
+		return nil
+	}
+	return pr.Print(&jsonSlice{
+		headers: []string{"MODEL", "PROVIDER", "MODE", "CONTEXT", "INPUT_$/1M", "OUTPUT_$/1M", "FLAGS"},
+		data:    listed,
+		rowFn: func(m map[string]any) []string {
+			return []string{
+				str(m, "id"), str(m, "owned_by"), dash(str(m, "mode")),
+				modelCount(m, "context_window"),
+				modelPrice(m, "input_per_m_tokens"), modelPrice(m, "output_per_m_tokens"),
+				modelFlagList(m),
+			}
+		},
+	})
+}
+
+// modelCount renders a positive integer field, or "-" when it is absent.
+func modelCount(m map[string]any, key string) string {
+	if v := numVal(m, key); v > 0 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return noValue
+}
+
+// modelPrice renders a price field without trailing zeros, or "-" when the
+// catalog has no price. A price of 0 is a free model and shown as such.
+func modelPrice(m map[string]any, key string) string {
+	if _, ok := m[key]; !ok {
+		return noValue
+	}
+	return strconv.FormatFloat(numVal(m, key), 'f', -1, 64)
+}
+
+// modelFlagList renders the model's capabilities as short comma-separated
+// labels, marking deprecated models.
+func modelFlagList(m map[string]any) string {
+	caps := map[string]bool{}
+	if list, ok := m["capabilities"].([]any); ok {
+		for _, c := range list {
+			if s, ok := c.(string); ok {
+				caps[s] = true
+			}
+		}
+	}
+	var labels []string
+	for _, f := range modelFlags {
+		if caps[f.capability] {
+			labels = append(labels, f.label)
+		}
+	}
+	if b, _ := m["deprecated"].(bool); b {
+		labels = append(labels, "deprecated")
+	}
+	if len(labels) == 0 {
+		return noValue
+	}
+	return strings.Join(labels, ",")
+}
+
+// dash returns s, or noValue when s is empty.
+func dash(s string) string {
+	if s == "" {
+		return noValue
+	}
+	return s
+}
+
+func init() {
+	ModelsCmd.Flags().String("provider", "", "Only models served by this provider")
+	ModelsCmd.Flags().String("grep", "", "Only models whose ID matches this case-insensitive regular expression")
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const modelsList = `{"object":"list","data":[
+	{"id":"gpt-4o","object":"model","owned_by":"openai","mode":"chat","context_window":128000,
+	 "capabilities":["vision","function_calling","streaming"],"input_per_m_tokens":2.5,"output_per_m_tokens":10},
+	{"id":"gpt-4o-mini","object":"model","owned_by":"openai","mode":"chat","context_window":128000,
+	 "input_per_m_tokens":0.15,"output_per_m_tokens":0.6,"deprecated":true},
+	{"id":"claude-3-5-sonnet","object":"model","owned_by":"anthropic"}
+]}`
+
+func TestRunModels_Table(t *testing.T) {
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/v1/models": jsonHandler(http.StatusOK, modelsList),
+	})
+	cmd, buf := newHandlerCmd(t, srv.URL, FormatTable)
+	cmd.Flags().String("provider", "", "")
+	cmd.Flags().String("grep", "", "")
+
+	if err := runModels(cmd, nil); err != nil {
+		t.Fatalf("runModels: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"CONTEXT", "128000", "2.5", "0.15", "vision,tools", "deprecated"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "claude-3-5-sonnet") && strings.Count(line, noValue) < 4 {
+			t.Errorf("uncatalogued model should show %q for missing values: %q", noValue, line)
+		}
+	}
+}
+
+func TestRunModels_Filters(t *testing.T) {
+	srv := stubGateway(t, map[string]http.HandlerFunc{
+		"/v1/models": jsonHandler(http.StatusOK, modelsList),
+	})
+	tests := []struct {
+		name, provider, grep string
+		want                 []string
+	}{
+		{name: "provider", provider: "OpenAI", want: []string{"gpt-4o", "gpt-4o-mini"}},
+		{name: "grep", grep: "MINI|sonnet", want: []string{"gpt-4o-mini", "claude-3-5-sonnet"}},
+		{name: "both", provider: "anthropic", grep: "gpt", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, buf := newHandlerCmd(t, srv.URL, FormatJSON)
+			cmd.Flags().String("provider", tt.provider, "")
+			cmd.Flags().String("grep", tt.grep, "")
+			if err := runModels(cmd, nil); err != nil {
+				t.Fatalf("runModels: %v", err)
+			}
+			var got []map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v\n%s", err, buf)
+			}
+			var ids []string
+			for _, m := range got {
+				ids = append(ids, str(m, "id"))
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunModels_BadGrep(t *testing.T) {
+	cmd, _ := newHandlerCmd(t, "http://127.0.0.1:0", FormatTable)
+	cmd.Flags().String("provider", "", "")
+	cmd.Flags().String("grep", "(", "")
+	if err := runModels(cmd, nil); err == nil || !strings.Contains(err.Error(), "--grep") {
+		t.Fatalf("err = %v, want a --grep error", err)
+	}
+}
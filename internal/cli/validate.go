@@ -12,8 +12,15 @@ import (
 var ValidateCmd = &cobra.Command{
 	Use:   "validate <config-file>",
 	Short: "Validate a gateway configuration file (JSON or YAML)",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runValidate,
+	Long: `Validate a gateway configuration file (JSON or YAML).
+
+Beyond schema validity, validate warns about settings that load but are
+unlikely to do what was meant: load-balance weights that are ignored or
+misleading, aliases no target can serve, circuit breakers that reopen
+faster than a request can finish, plugins at a stage where they cannot act,
+and conditional rules that can never match. Pass --strict to fail on them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runValidate,
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
@@ -27,9 +34,18 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	warnings := lintConfig(cfg)
+	strict, _ := cmd.Flags().GetBool("strict")
+
 	pr := printerFromCmd(cmd)
 	if pr.Format != FormatTable {
-		return pr.Print(cfg)
+		for _, w := range warnings {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s %s\n", SymWARN, w)
+		}
+		if err := pr.Print(cfg); err != nil {
+			return err
+		}
+		return lintResult(warnings, strict)
 	}
 
 	out := cmd.OutOrStdout()
@@ -60,5 +76,24 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	if len(cfg.Aliases) > 0 {
 		_, _ = fmt.Fprintf(out, "  Aliases:   %d\n", len(cfg.Aliases))
 	}
+
+	if len(warnings) > 0 {
+		_, _ = fmt.Fprintf(out, "\n%d warning(s):\n", len(warnings))
+		for _, w := range warnings {
+			_, _ = fmt.Fprintf(out, "  %s %s\n", SymWARN, w)
+		}
+	}
+	return lintResult(warnings, strict)
+}
+
+// lintResult fails validation on lint warnings only under --strict.
+func lintResult(warnings []string, strict bool) error {
+	if strict && len(warnings) > 0 {
+		return fmt.Errorf("%d lint warning(s) with --strict", len(warnings))
+	}
 	return nil
 }
+
+func init() {
+	ValidateCmd.Flags().Bool("strict", false, "Fail when the config has lint warnings")
+}
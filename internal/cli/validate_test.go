@@ -56,6 +56,23 @@ func TestRunValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("lint warnings are reported and fail only under --strict", func(t *testing.T) {
+		path := writeConfig(t, "config.yaml", "strategy:\n  mode: fallback\ntargets:\n  - virtual_key: openai\n    weight: 50\n")
+		cmd, out := newHandlerCmd(t, "", "table")
+		cmd.Flags().Bool("strict", false, "")
+
+		if err := runValidate(cmd, []string{path}); err != nil {
+			t.Fatalf("runValidate: %v", err)
+		}
+		if !strings.Contains(out.String(), "1 warning(s)") {
+			t.Errorf("output missing the lint warning:\n%s", out.String())
+		}
+		_ = cmd.Flags().Set("strict", "true")
+		if err := runValidate(cmd, []string{path}); err == nil {
+			t.Fatal("want an error under --strict")
+		}
+	})
+
 	t.Run("missing file returns a load error", func(t *testing.T) {
 		cmd, _ := newHandlerCmd(t, "", "table")
 
@@ -21,7 +21,7 @@ var pageTemplates = make(map[string]*template.Template)
 func init() {
 	pages := []string{
 		"getting-started", "overview", "keys", "logs",
-		"providers", "config", "analytics", "playground",
+		"providers", "routing", "config", "analytics", "playground",
 	}
 	for _, page := range pages {
 		tmpl, err := template.ParseFS(webassets.Assets,
@@ -0,0 +1,348 @@
+// Package deadletter keeps chat requests that failed on every target they
+// were routed to, so an operator can list them and send them again once the
+// outage is over.
+//
+// The gateway reports each such failure to its aigateway.DeadLetterSink; a
+// Queue is that sink, writing every failure to a Store as an Entry with the
+// request, its error chain, and the provider calls the routing strategy
+// made. Redrive routes a kept request through the gateway again and records
+// the outcome on its entry.
+//
+// With a payload cipher set, each entry's request is sealed like a request-log
+// body before it is stored, and opened only to re-drive it or for a caller
+// allowed to read it.
+package deadletter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Entry statuses.
+const (
+	// StatusPending is an entry not yet re-driven successfully.
+	StatusPending = "pending"
+	// StatusRedriving is an entry a caller has claimed to re-drive.
+	StatusRedriving = "redriving"
+	// StatusRedriven is an entry whose request succeeded when re-driven.
+	StatusRedriven = "redriven"
+)
+
+// claimTTL is how long a claim holds an entry. A re-drive ends well within
+// it; a claim older than that was left by a replica that stopped mid
+// re-drive, and another caller may take the entry over.
+const claimTTL = 15 * time.Minute
+
+// ErrNotFound is returned for an entry the store does not hold.
+var ErrNotFound = errors.New("dead letter not found")
+
+// ErrRedriven is returned when re-driving an entry that already succeeded.
+var ErrRedriven = errors.New("dead letter already re-driven")
+
+// ErrRedriving is returned when re-driving an entry another caller is
+// re-driving.
+var ErrRedriving = errors.New("dead letter is being re-driven")
+
+// ErrKeyUnavailable is returned when re-driving an entry whose API key no
+// longer exists or is no longer valid, so the request cannot be sent as it.
+var ErrKeyUnavailable = errors.New("the API key that sent the request is no longer available")
+
+// Entry is one failed request.
+type Entry struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	KeyID     string    `json:"key_id,omitempty"`
+	// User is the request's end user, kept in the clear beside a sealed
+	// request so an erasure can find the entry.
+	User   string `json:"user,omitempty"`
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+	// Strategy is the routing mode and Attempts the provider calls it made.
+	Strategy string    `json:"strategy,omitempty"`
+	Attempts []Attempt `json:"attempts"`
+	// Errors is the error chain, outermost first.
+	Errors     []string `json:"errors"`
+	StatusCode int      `json:"status_code"`
+	LatencyMs  int64    `json:"latency_ms"`
+	Request    Request  `json:"request,omitzero"`
+	// SealedRequest is Request encrypted when the queue has a payload
+	// cipher; Request is then empty.
+	SealedRequest json.RawMessage `json:"sealed_request,omitempty"`
+
+	Status string `json:"status"`
+	// ClaimedAt is when the entry was claimed to be re-driven, while its
+	// status is StatusRedriving.
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+	// Redrives counts the times the request was sent again; the fields
+	// after it describe the latest.
+	Redrives          int        `json:"redrives"`
+	LastRedriveAt     *time.Time `json:"last_redrive_at,omitempty"`
+	LastRedriveError  string     `json:"last_redrive_error,omitempty"`
+	RedriveResponseID string     `json:"redrive_response_id,omitempty"`
+}
+
+// Sealed reports whether e's request is encrypted.
+func (e Entry) Sealed() bool { return len(e.SealedRequest) > 0 }
+
+// Attempt is one provider call of a failed request.
+type Attempt struct {
+	Target     string `json:"target"`
+	Model      string `json:"model"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error"`
+	LatencyMs  int64  `json:"latency_ms"`
+}
+
+// Request is an entry's request, kept as the request log keeps one, with the
+// fields a providers.Request leaves out of its JSON.
+type Request = requestlog.Request
+
+func newRequest(req providers.Request) Request { return requestlog.NewRequest(req) }
+
+// Query selects entries to list.
+type Query struct {
+	// Status keeps only entries with this status; "" keeps all.
+	Status string
+	// Limit bounds the entries returned; zero or less returns all.
+	Limit int
+}
+
+func (q Query) matches(e Entry) bool {
+	return q.Status == "" || e.Status == q.Status
+}
+
+// claimable reports why e cannot be claimed at now, or nil when it can.
+func claimable(e Entry, now time.Time) error {
+	switch e.Status {
+	case StatusRedriven:
+		return ErrRedriven
+	case StatusRedriving:
+		if e.ClaimedAt != nil && now.Sub(*e.ClaimedAt) < claimTTL {
+			return ErrRedriving
+		}
+	}
+	return nil
+}
+
+// Store keeps entries.
+type Store interface {
+	Add(ctx context.Context, e Entry) error
+	Get(ctx context.Context, id string) (Entry, bool, error)
+	// Claim marks entry id StatusRedriving and returns it, in one step
+	// against the stored state, so of the callers sharing the store only
+	// one re-drives an entry at a time. It fails with ErrNotFound,
+	// ErrRedriven, or ErrRedriving. Update releases the claim.
+	Claim(ctx context.Context, id string) (Entry, error)
+	// List returns the entries q selects, newest first.
+	List(ctx context.Context, q Query) ([]Entry, error)
+	// Update replaces the stored entry with e's ID, returning ErrNotFound
+	// when there is none.
+	Update(ctx context.Context, e Entry) error
+	// Delete removes an entry, reporting whether it existed.
+	Delete(ctx context.Context, id string) (bool, error)
+	Close() error
+}
+
+// Router routes a chat request. *aigateway.Gateway implements it.
+type Router interface {
+	Route(ctx context.Context, req providers.Request) (*providers.Response, error)
+}
+
+// Identify returns a context carrying the identity of the API key keyID, so
+// a request sent again runs under the residency, tier, and plugins of the key
+// that first sent it. keyID is empty for a request sent without one. It
+// fails with ErrKeyUnavailable when the key no longer exists or is revoked.
+type Identify func(ctx context.Context, keyID string) (context.Context, error)
+
+// Queue writes the gateway's dead letters to a store. It implements
+// aigateway.DeadLetterSink.
+type Queue struct {
+	store    Store
+	payloads *requestlog.PayloadCipher
+}
+
+// NewQueue returns a queue keeping entries in store.
+func NewQueue(store Store) *Queue {
+	return &Queue{store: store}
+}
+
+// Store returns the queue's store.
+func (q *Queue) Store() Store { return q.store }
+
+// SetPayloadCipher seals the requests of the entries written from now on
+// with c, the request log's cipher, and opens sealed ones with it. A nil c
+// stores requests in the clear. Call it before the queue is in use.
+func (q *Queue) SetPayloadCipher(c *requestlog.PayloadCipher) { q.payloads = c }
+
+// Open returns e with its request decrypted. An entry that is not sealed is
+// returned unchanged.
+func (q *Queue) Open(e Entry) (Entry, error) {
+	if !e.Sealed() {
+		return e, nil
+	}
+	if q.payloads == nil {
+		return e, requestlog.ErrPayloadKeyUnknown
+	}
+	raw, err := q.payloads.Open(e.SealedRequest)
+	if err != nil {
+		return e, err
+	}
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return e, fmt.Errorf("decode dead-letter request: %w", err)
+	}
+	e.Request, e.SealedRequest = req, nil
+	return e, nil
+}
+
+// seal returns e with its request encrypted.
+func (q *Queue) seal(e Entry) (Entry, error) {
+	raw, err := json.Marshal(e.Request)
+	if err != nil {
+		return e, fmt.Errorf("encode dead-letter request: %w", err)
+	}
+	if e.SealedRequest, err = q.payloads.Seal(raw); err != nil {
+		return e, err
+	}
+	e.Request = Request{}
+	return e, nil
+}
+
+// Close closes the queue's store.
+func (q *Queue) Close() error { return q.store.Close() }
+
+// WriteDeadLetter implements aigateway.DeadLetterSink. A store that fails is
+// logged; the client's answer does not wait on it any longer than the write.
+func (q *Queue) WriteDeadLetter(ctx context.Context, dl aigateway.DeadLetter) {
+	e := Entry{
+		ID:         newID(),
+		CreatedAt:  dl.Time,
+		TraceID:    dl.TraceID,
+		KeyID:      dl.KeyID,
+		User:       dl.Request.User,
+		Model:      dl.Request.Model,
+		Stream:     dl.Request.Stream,
+		Strategy:   dl.Strategy,
+		Attempts:   make([]Attempt, len(dl.Attempts)),
+		Errors:     dl.Errors,
+		StatusCode: dl.StatusCode,
+		LatencyMs:  dl.Latency.Milliseconds(),
+		Request:    newRequest(dl.Request),
+		Status:     StatusPending,
+	}
+	for i, a := range dl.Attempts {
+		e.Attempts[i] = Attempt{Target: a.Target, Model: a.Model, StatusCode: a.StatusCode, Error: a.Error, LatencyMs: a.Latency.Milliseconds()}
+	}
+	if q.payloads != nil {
+		var err error
+		if e, err = q.seal(e); err != nil {
+			logging.FromContext(ctx).Error("failed to seal dead letter", "trace_id", dl.TraceID, "error", err)
+			return
+		}
+	}
+	// The request may have failed on its own deadline; the entry must
+	// still be written.
+	if err := q.store.Add(context.WithoutCancel(ctx), e); err != nil {
+		logging.FromContext(ctx).Error("failed to write dead letter", "trace_id", dl.TraceID, "error", redact.ErrorMessage(err))
+	}
+}
+
+// Redrive routes the request of entry id through r again, non-streaming, and
+// records the outcome on the entry. The request runs as the API key that
+// first sent it, in the context identify returns, so its residency, limits,
+// and per-key plugins apply as they did the first time.
+// A request that fails again is not queued a second time; the entry stays
+// pending with the new error. An entry is claimed in the store before it is
+// re-driven, so concurrent re-drives, on this replica or another sharing the
+// store, cannot send its request twice; the others get ErrRedriving. The
+// returned error reports a missing entry, ErrRedriven, ErrRedriving,
+// ErrKeyUnavailable, or a store failure; the request's own failure is in the
+// entry's LastRedriveError. A sealed request is opened to be sent; the
+// returned entry is as stored.
+func (q *Queue) Redrive(ctx context.Context, r Router, identify Identify, id string) (Entry, *providers.Response, error) {
+	e, err := q.store.Claim(ctx, id)
+	if err != nil {
+		return e, nil, err
+	}
+	// Whatever happens next, the claim is released when the entry is
+	// written back.
+	e.Status, e.ClaimedAt = StatusPending, nil
+	release := func() error { return q.store.Update(context.WithoutCancel(ctx), e) }
+
+	opened, err := q.Open(e)
+	if err != nil {
+		return e, nil, errors.Join(err, release())
+	}
+	routeCtx, err := identify(ctx, e.KeyID)
+	if err != nil {
+		return e, nil, errors.Join(err, release())
+	}
+
+	req := opened.Request.Chat()
+	req.Stream = false
+	req.StreamOptions = nil
+	resp, routeErr := r.Route(aigateway.WithoutDeadLetter(routeCtx), req)
+
+	now := time.Now().UTC()
+	e.Redrives++
+	e.LastRedriveAt = &now
+	if routeErr != nil {
+		e.LastRedriveError = redact.ErrorMessage(routeErr)
+		resp = nil
+	} else {
+		e.Status = StatusRedriven
+		e.LastRedriveError = ""
+		e.RedriveResponseID = resp.ID
+	}
+	if err := release(); err != nil {
+		return e, resp, err
+	}
+	return e, resp, nil
+}
+
+// EraseUser deletes the entries of requests from user (the request's `user`
+// field), sent with the API key keyID, or both together, and returns how many
+// it deleted. It implements admin.UserEraser.
+func (q *Queue) EraseUser(ctx context.Context, user, keyID string) (int, error) {
+	entries, err := q.store.List(ctx, Query{})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		// Entries written before User was kept hold it only in the request.
+		entryUser := e.User
+		if entryUser == "" {
+			entryUser = e.Request.User
+		}
+		if (user != "" && entryUser != user) || (keyID != "" && e.KeyID != keyID) {
+			continue
+		}
+		deleted, err := q.store.Delete(ctx, e.ID)
+		if err != nil {
+			return n, err
+		}
+		if deleted {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func newID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "dl_" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,240 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// fakeRouter answers with err when it is set, and records what it routed
+// and the key it was routed as.
+type fakeRouter struct {
+	err  error
+	reqs []providers.Request
+	keys []string
+}
+
+func (f *fakeRouter) Route(ctx context.Context, req providers.Request) (*providers.Response, error) {
+	f.reqs = append(f.reqs, req)
+	keyID, _ := authctx.KeyID(ctx)
+	f.keys = append(f.keys, keyID)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &providers.Response{ID: "chatcmpl-1", Model: req.Model}, nil
+}
+
+// asKey is an Identify that runs every request as its key.
+func asKey(ctx context.Context, keyID string) (context.Context, error) {
+	return authctx.WithKeyID(ctx, keyID), nil
+}
+
+func queueWithEntry(t *testing.T) (*Queue, string) {
+	t.Helper()
+	q := NewQueue(NewMemoryStore(Retention{}))
+	temp := 0.2
+	q.WriteDeadLetter(t.Context(), aigateway.DeadLetter{
+		TraceID: "trace-1",
+		KeyID:   "key-1",
+		Request: providers.Request{
+			Model:          "gpt-4o",
+			Stream:         true,
+			Temperature:    &temp,
+			Messages:       []providers.Message{{Role: providers.RoleUser, Content: "hi"}},
+			FallbackModels: []string{"claude-3-5-sonnet"},
+		},
+		Strategy:   "fallback",
+		Attempts:   []plugin.Attempt{{Target: "openai", Model: "gpt-4o", StatusCode: 503, Error: "unavailable", Latency: 40 * time.Millisecond}},
+		Errors:     []string{"all targets failed", "unavailable"},
+		StatusCode: 502,
+		Latency:    50 * time.Millisecond,
+		Time:       time.Now().UTC(),
+	})
+	entries, err := q.Store().List(t.Context(), Query{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d (%v)", len(entries), err)
+	}
+	return q, entries[0].ID
+}
+
+func TestQueue_WriteDeadLetter(t *testing.T) {
+	q, id := queueWithEntry(t)
+	e, ok, _ := q.Store().Get(t.Context(), id)
+	if !ok {
+		t.Fatal("entry not found")
+	}
+	if e.Status != StatusPending || e.TraceID != "trace-1" || e.KeyID != "key-1" || e.Model != "gpt-4o" || !e.Stream {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if len(e.Attempts) != 1 || e.Attempts[0].StatusCode != 503 || e.Attempts[0].LatencyMs != 40 {
+		t.Fatalf("attempts not kept: %+v", e.Attempts)
+	}
+	if e.LatencyMs != 50 || e.StatusCode != 502 || len(e.Errors) != 2 {
+		t.Fatalf("failure not kept: %+v", e)
+	}
+}
+
+func TestQueue_Redrive(t *testing.T) {
+	q, id := queueWithEntry(t)
+
+	failing := &fakeRouter{err: errors.New("still down")}
+	e, resp, err := q.Redrive(t.Context(), failing, asKey, id)
+	if err != nil {
+		t.Fatalf("redrive: %v", err)
+	}
+	if resp != nil || e.Status != StatusPending || e.Redrives != 1 || e.LastRedriveError != "still down" || e.LastRedriveAt == nil {
+		t.Fatalf("failed redrive should leave the entry pending with its error: %+v", e)
+	}
+	if failing.keys[0] != "key-1" {
+		t.Fatalf("redrive ran as key %q, want the entry's key-1", failing.keys[0])
+	}
+	req := failing.reqs[0]
+	if req.Stream || len(req.FallbackModels) != 1 || req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Fatalf("redriven request should match the original, non-streaming: %+v", req)
+	}
+
+	ok := &fakeRouter{}
+	e, resp, err = q.Redrive(t.Context(), ok, asKey, id)
+	if err != nil {
+		t.Fatalf("redrive: %v", err)
+	}
+	if resp == nil || e.Status != StatusRedriven || e.Redrives != 2 || e.LastRedriveError != "" || e.RedriveResponseID != "chatcmpl-1" {
+		t.Fatalf("successful redrive should mark the entry: %+v", e)
+	}
+	stored, _, _ := q.Store().Get(t.Context(), id)
+	if stored.Status != StatusRedriven {
+		t.Fatalf("outcome not stored: %+v", stored)
+	}
+
+	if _, _, err := q.Redrive(t.Context(), ok, asKey, id); !errors.Is(err, ErrRedriven) {
+		t.Fatalf("second redrive: got %v, want ErrRedriven", err)
+	}
+	if _, _, err := q.Redrive(t.Context(), ok, asKey, "dl_missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing entry: got %v, want ErrNotFound", err)
+	}
+}
+
+// blockingRouter holds each request until release is closed, signalling
+// entered as it starts.
+type blockingRouter struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b blockingRouter) Route(_ context.Context, req providers.Request) (*providers.Response, error) {
+	b.entered <- struct{}{}
+	<-b.release
+	return &providers.Response{ID: "chatcmpl-1", Model: req.Model}, nil
+}
+
+func TestQueue_RedriveClaimsTheEntry(t *testing.T) {
+	q, id := queueWithEntry(t)
+	r := blockingRouter{entered: make(chan struct{}, 1), release: make(chan struct{})}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := q.Redrive(t.Context(), r, asKey, id)
+		done <- err
+	}()
+	<-r.entered
+	if _, _, err := q.Redrive(t.Context(), &fakeRouter{}, asKey, id); !errors.Is(err, ErrRedriving) {
+		t.Fatalf("concurrent redrive: got %v, want ErrRedriving", err)
+	}
+	close(r.release)
+	if err := <-done; err != nil {
+		t.Fatalf("redrive: %v", err)
+	}
+	if _, _, err := q.Redrive(t.Context(), &fakeRouter{}, asKey, id); !errors.Is(err, ErrRedriven) {
+		t.Fatalf("redrive after the first finished: got %v, want ErrRedriven", err)
+	}
+}
+
+func TestQueue_RedriveKeyUnavailable(t *testing.T) {
+	q, id := queueWithEntry(t)
+	gone := func(context.Context, string) (context.Context, error) { return nil, ErrKeyUnavailable }
+	r := &fakeRouter{}
+	if _, _, err := q.Redrive(t.Context(), r, gone, id); !errors.Is(err, ErrKeyUnavailable) {
+		t.Fatalf("redrive: got %v, want ErrKeyUnavailable", err)
+	}
+	if len(r.reqs) != 0 {
+		t.Fatal("a request whose key is gone was sent")
+	}
+	if e, _, _ := q.Store().Get(t.Context(), id); e.Status != StatusPending {
+		t.Fatalf("entry should be released pending, got %q", e.Status)
+	}
+}
+
+func TestQueue_SealsRequests(t *testing.T) {
+	payloads, err := requestlog.ParsePayloadKeys("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewQueue(NewMemoryStore(Retention{}))
+	q.SetPayloadCipher(payloads)
+	q.WriteDeadLetter(t.Context(), aigateway.DeadLetter{
+		KeyID:   "key-1",
+		Request: providers.Request{Model: "gpt-4o", User: "user-1", Messages: []providers.Message{{Role: providers.RoleUser, Content: "my secret"}}},
+		Time:    time.Now().UTC(),
+	})
+	entries, _ := q.Store().List(t.Context(), Query{})
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	stored := entries[0]
+	data, _ := json.Marshal(stored)
+	if !stored.Sealed() || strings.Contains(string(data), "my secret") || stored.User != "user-1" {
+		t.Fatalf("stored entry should be sealed with its user in the clear: %s", data)
+	}
+
+	opened, err := q.Open(stored)
+	if err != nil || opened.Sealed() || opened.Request.Messages[0].Content != "my secret" {
+		t.Fatalf("Open = %+v, %v", opened, err)
+	}
+	if _, err := NewQueue(q.Store()).Open(stored); !errors.Is(err, requestlog.ErrPayloadKeyUnknown) {
+		t.Fatalf("Open without a cipher: got %v, want ErrPayloadKeyUnknown", err)
+	}
+
+	r := &fakeRouter{}
+	e, _, err := q.Redrive(t.Context(), r, asKey, stored.ID)
+	if err != nil {
+		t.Fatalf("redrive: %v", err)
+	}
+	if r.reqs[0].Messages[0].Content != "my secret" || !e.Sealed() {
+		t.Fatalf("redrive should send the opened request and keep the entry sealed: %+v", e)
+	}
+}
+
+func TestQueue_EraseUser(t *testing.T) {
+	q := NewQueue(NewMemoryStore(Retention{}))
+	for _, dl := range []aigateway.DeadLetter{
+		{KeyID: "key-1", Request: providers.Request{Model: "m", User: "alice"}},
+		{KeyID: "key-1", Request: providers.Request{Model: "m", User: "bob"}},
+		{KeyID: "key-2", Request: providers.Request{Model: "m", User: "alice"}},
+	} {
+		q.WriteDeadLetter(t.Context(), dl)
+	}
+	// An entry written before the user was kept beside the request.
+	_ = q.Store().Add(t.Context(), Entry{ID: "dl_legacy", KeyID: "key-3", Request: newRequest(providers.Request{Model: "m", User: "alice"})})
+
+	if n, err := q.EraseUser(t.Context(), "alice", "key-1"); err != nil || n != 1 {
+		t.Fatalf("erase alice under key-1: %d, %v; want 1", n, err)
+	}
+	if n, err := q.EraseUser(t.Context(), "alice", ""); err != nil || n != 2 {
+		t.Fatalf("erase alice: %d, %v; want 2", n, err)
+	}
+	if n, err := q.EraseUser(t.Context(), "", "key-1"); err != nil || n != 1 {
+		t.Fatalf("erase key-1: %d, %v; want 1", n, err)
+	}
+	if left, _ := q.Store().List(t.Context(), Query{}); len(left) != 0 {
+		t.Fatalf("entries left: %+v", left)
+	}
+}
@@ -0,0 +1,259 @@
+package deadletter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+)
+
+// fileRecord is one line of a FileStore's file: an entry written, or the ID
+// of one deleted.
+type fileRecord struct {
+	Entry   *Entry `json:"entry,omitempty"`
+	Deleted string `json:"deleted,omitempty"`
+}
+
+// FileStore keeps entries in a JSON Lines file, which suits a volume shipped
+// to object storage or read by other tools. Every change is appended as a
+// line; the file is compacted to one line per entry when the store opens and
+// whenever it holds twice as many lines as the retention allows entries.
+// Entries are also held in memory, up to the retention's limit.
+type FileStore struct {
+	mu        sync.RWMutex
+	path      string
+	retention Retention
+	f         *os.File
+	lines     int     // records in the file
+	entries   []Entry // oldest first
+}
+
+// NewFileStore opens the store kept in the file at path, creating it owner-
+// only (0600) if it does not exist, as entries hold request content. It keeps
+// the entries r allows.
+func NewFileStore(path string, r Retention) (*FileStore, error) {
+	if path == "" {
+		return nil, errors.New("dead-letter file path is required")
+	}
+	s := &FileStore{path: path, retention: r}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.entries = r.prune(s.entries, time.Now())
+	if err := s.reopen(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reopen compacts the file and opens the new one for appending. On failure
+// the store keeps appending to the file it had open.
+func (s *FileStore) reopen() error {
+	if err := s.compact(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600) //nolint:gosec // G304: path is the operator-supplied store location.
+	if err != nil {
+		return fmt.Errorf("open dead-letter file: %w", err)
+	}
+	if s.f != nil {
+		_ = s.f.Close()
+	}
+	s.f = f
+	s.lines = len(s.entries)
+	return nil
+}
+
+// load replays the file's records into s.entries.
+func (s *FileStore) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open dead-letter file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec fileRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return fmt.Errorf("dead-letter file line %d: %w", line, err)
+		}
+		s.apply(rec)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read dead-letter file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) apply(rec fileRecord) {
+	switch {
+	case rec.Entry != nil:
+		if i := s.index(rec.Entry.ID); i >= 0 {
+			s.entries[i] = *rec.Entry
+		} else {
+			s.entries = append(s.entries, *rec.Entry)
+		}
+	case rec.Deleted != "":
+		if i := s.index(rec.Deleted); i >= 0 {
+			s.entries = slices.Delete(s.entries, i, i+1)
+		}
+	}
+}
+
+// compact rewrites the file with one line per entry, replacing it only once
+// the new one is complete.
+func (s *FileStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("compact dead-letter file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for i := range s.entries {
+		if err := enc.Encode(fileRecord{Entry: &s.entries[i]}); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("compact dead-letter file: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("compact dead-letter file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("compact dead-letter file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("compact dead-letter file: %w", err)
+	}
+	return nil
+}
+
+// append writes rec to the file. The caller must hold s.mu.
+func (s *FileStore) append(rec fileRecord) error {
+	if s.f == nil {
+		return errors.New("dead-letter file store is closed")
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode dead letter: %w", err)
+	}
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write dead-letter file: %w", err)
+	}
+	s.lines++
+	return nil
+}
+
+// write appends rec to the file and applies it. The caller must hold s.mu.
+func (s *FileStore) write(rec fileRecord) error {
+	if err := s.append(rec); err != nil {
+		return err
+	}
+	s.apply(rec)
+	return nil
+}
+
+// Add implements Store. Entries past the store's retention are dropped. The
+// entry's ID is new, so it is appended without searching the entries held.
+func (s *FileStore) Add(_ context.Context, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(fileRecord{Entry: &e}); err != nil {
+		return err
+	}
+	s.entries = s.retention.prune(append(s.entries, e), time.Now())
+	if s.lines >= 2*s.retention.maxEntries() {
+		// The entry is written; a file left uncompacted only grows.
+		if err := s.reopen(); err != nil {
+			logging.Logger.Warn("dead-letter file not compacted", "error", err)
+		}
+	}
+	return nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, id string) (Entry, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.index(id); i >= 0 {
+		return s.entries[i], true, nil
+	}
+	return Entry{}, false, nil
+}
+
+// Claim implements Store. The claim is written to the file, so an entry
+// claimed when the gateway stopped stays claimed until the claim expires.
+func (s *FileStore) Claim(_ context.Context, id string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return Entry{}, ErrNotFound
+	}
+	e := s.entries[i]
+	now := time.Now().UTC()
+	if err := claimable(e, now); err != nil {
+		return e, err
+	}
+	e.Status, e.ClaimedAt = StatusRedriving, &now
+	return e, s.write(fileRecord{Entry: &e})
+}
+
+// List implements Store.
+func (s *FileStore) List(_ context.Context, q Query) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return newestFirst(s.entries, q), nil
+}
+
+// Update implements Store.
+func (s *FileStore) Update(_ context.Context, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index(e.ID) < 0 {
+		return ErrNotFound
+	}
+	return s.write(fileRecord{Entry: &e})
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index(id) < 0 {
+		return false, nil
+	}
+	return true, s.write(fileRecord{Deleted: id})
+}
+
+// Close implements Store.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+func (s *FileStore) index(id string) int {
+	return slices.IndexFunc(s.entries, func(e Entry) bool { return e.ID == id })
+}
@@ -0,0 +1,228 @@
+package deadletter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/migrations"
+	"github.com/ferro-labs/ai-gateway/internal/sqldb"
+)
+
+// deadLetterLedger is the dead-letter store's own migration ledger, so it can
+// share a database with the gateway's other stores.
+const deadLetterLedger = "dead_letter_schema_migrations"
+
+// SQLStore keeps entries in a dead_letters table in SQLite or Postgres. The
+// entry is stored as JSON beside the columns it is listed and claimed by; the
+// status column is authoritative over the status in the JSON.
+type SQLStore struct {
+	db        *sql.DB
+	dialect   sqldb.Dialect
+	retention Retention
+}
+
+// NewSQLiteStore creates a SQLite-backed store keeping the entries r allows.
+func NewSQLiteStore(ctx context.Context, dsn string, r Retention) (*SQLStore, error) {
+	return newSQLStore(ctx, sqldb.SQLite, dsn, r)
+}
+
+// NewPostgresStore creates a Postgres-backed store keeping the entries r
+// allows.
+func NewPostgresStore(ctx context.Context, dsn string, r Retention) (*SQLStore, error) {
+	return newSQLStore(ctx, sqldb.Postgres, dsn, r)
+}
+
+func newSQLStore(ctx context.Context, dialect sqldb.Dialect, dsn string, r Retention) (*SQLStore, error) {
+	db, err := sqldb.Open(ctx, dialect, dsn, "ferrogw-dead-letters.db")
+	if err != nil {
+		return nil, err
+	}
+	s := &SQLStore{db: db, dialect: dialect, retention: r}
+	if err := migrations.RunNamed(ctx, db, dialect, deadLetterLedger, "", deadLetterSteps(dialect)); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrate %s dead-letter schema: %w", dialect, err)
+	}
+	return s, nil
+}
+
+// deadLetterSteps returns the migration sequence for the dead_letters table.
+func deadLetterSteps(dialect sqldb.Dialect) []migrations.Step {
+	createdAt := "TIMESTAMP"
+	if dialect == sqldb.Postgres {
+		createdAt = "TIMESTAMPTZ"
+	}
+	return []migrations.Step{
+		{Version: 1, Name: "dead_letters", SQL: `
+CREATE TABLE IF NOT EXISTS dead_letters (
+	id TEXT PRIMARY KEY,
+	created_at ` + createdAt + ` NOT NULL,
+	status TEXT NOT NULL,
+	entry_json TEXT NOT NULL
+);`},
+		{Version: 2, Name: "dead_letters_created_at", SQL: "CREATE INDEX IF NOT EXISTS dead_letters_created_at ON dead_letters (created_at)"},
+		{Version: 3, Name: "dead_letters_claimed_at", SQL: "ALTER TABLE dead_letters ADD COLUMN claimed_at " + createdAt},
+	}
+}
+
+func (s *SQLStore) q(query string) string { return sqldb.Bind(s.dialect, query) }
+
+// Add implements Store. Entries past the store's retention are deleted.
+func (s *SQLStore) Add(ctx context.Context, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode dead letter: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, s.q("INSERT INTO dead_letters (id, created_at, status, entry_json) VALUES (?, ?, ?, ?)"),
+		e.ID, e.CreatedAt.UTC(), e.Status, string(data)); err != nil {
+		return fmt.Errorf("insert dead letter: %w", err)
+	}
+	if s.retention.MaxAge > 0 {
+		if _, err := s.db.ExecContext(ctx, s.q("DELETE FROM dead_letters WHERE created_at < ?"),
+			time.Now().UTC().Add(-s.retention.MaxAge)); err != nil {
+			return fmt.Errorf("prune dead letters: %w", err)
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, s.q(`DELETE FROM dead_letters WHERE id NOT IN (
+	SELECT id FROM dead_letters ORDER BY created_at DESC, id DESC LIMIT ?)`), s.retention.maxEntries()); err != nil {
+		return fmt.Errorf("prune dead letters: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store.
+func (s *SQLStore) Get(ctx context.Context, id string) (Entry, bool, error) {
+	e, err := scanEntry(s.db.QueryRowContext(ctx, s.q("SELECT entry_json, status, claimed_at FROM dead_letters WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("get dead letter: %w", err)
+	}
+	return e, true, nil
+}
+
+// Claim implements Store with a conditional UPDATE, which the database
+// applies for one caller only.
+func (s *SQLStore) Claim(ctx context.Context, id string) (Entry, error) {
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx, s.q(`UPDATE dead_letters SET status = ?, claimed_at = ?
+WHERE id = ? AND (status = ? OR (status = ? AND (claimed_at IS NULL OR claimed_at < ?)))`),
+		StatusRedriving, now, id, StatusPending, StatusRedriving, now.Add(-claimTTL))
+	if err != nil {
+		return Entry{}, fmt.Errorf("claim dead letter: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return Entry{}, fmt.Errorf("claim dead letter: %w", err)
+	}
+	e, ok, err := s.Get(ctx, id)
+	switch {
+	case err != nil:
+		return Entry{}, err
+	case !ok:
+		return Entry{}, ErrNotFound
+	case n == 0 && e.Status == StatusRedriven:
+		return e, ErrRedriven
+	case n == 0:
+		return e, ErrRedriving
+	}
+	return e, nil
+}
+
+// rowScanner is a *sql.Row or *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanEntry decodes a row of entry_json, status, and claimed_at.
+func scanEntry(row rowScanner) (Entry, error) {
+	var data, status string
+	var claimedAt sql.NullTime
+	if err := row.Scan(&data, &status, &claimedAt); err != nil {
+		return Entry{}, err
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return Entry{}, fmt.Errorf("decode dead letter: %w", err)
+	}
+	e.Status, e.ClaimedAt = status, nil
+	if claimedAt.Valid {
+		t := claimedAt.Time.UTC()
+		e.ClaimedAt = &t
+	}
+	return e, nil
+}
+
+// List implements Store.
+func (s *SQLStore) List(ctx context.Context, q Query) ([]Entry, error) {
+	query := "SELECT entry_json, status, claimed_at FROM dead_letters"
+	var args []any
+	if q.Status != "" {
+		query += " WHERE status = ?"
+		args = append(args, q.Status)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, s.q(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list dead letters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	result := make([]Entry, 0)
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list dead letters: %w", err)
+		}
+		result = append(result, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list dead letters: %w", err)
+	}
+	return result, nil
+}
+
+// Update implements Store.
+func (s *SQLStore) Update(ctx context.Context, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode dead letter: %w", err)
+	}
+	var claimedAt any
+	if e.ClaimedAt != nil {
+		claimedAt = e.ClaimedAt.UTC()
+	}
+	res, err := s.db.ExecContext(ctx, s.q("UPDATE dead_letters SET status = ?, claimed_at = ?, entry_json = ? WHERE id = ?"), e.Status, claimedAt, string(data), e.ID)
+	if err != nil {
+		return fmt.Errorf("update dead letter: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *SQLStore) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, s.q("DELETE FROM dead_letters WHERE id = ?"), id)
+	if err != nil {
+		return false, fmt.Errorf("delete dead letter: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete dead letter: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Close implements Store.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,144 @@
+package deadletter
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries is how many entries a store keeps by default.
+const DefaultMaxEntries = 1000
+
+// Retention bounds the entries a store keeps. Every store applies it as
+// entries are added, dropping the oldest first.
+type Retention struct {
+	// MaxEntries is the most entries kept; zero or less uses
+	// DefaultMaxEntries.
+	MaxEntries int
+	// MaxAge drops entries created longer ago than it; zero keeps entries
+	// of any age.
+	MaxAge time.Duration
+}
+
+func (r Retention) maxEntries() int {
+	if r.MaxEntries <= 0 {
+		return DefaultMaxEntries
+	}
+	return r.MaxEntries
+}
+
+// expired reports whether an entry created at created is past MaxAge at now.
+func (r Retention) expired(created, now time.Time) bool {
+	return r.MaxAge > 0 && created.Before(now.Add(-r.MaxAge))
+}
+
+// prune returns the entries of oldest, oldest first, that r keeps at now.
+// It drops a prefix, so it costs nothing for the entries kept.
+func (r Retention) prune(oldest []Entry, now time.Time) []Entry {
+	drop := max(0, len(oldest)-r.maxEntries())
+	for drop < len(oldest) && r.expired(oldest[drop].CreatedAt, now) {
+		drop++
+	}
+	return oldest[drop:]
+}
+
+// MemoryStore keeps the most recent entries in memory. Entries do not
+// survive a restart.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	retention Retention
+	entries   []Entry // oldest first
+}
+
+// NewMemoryStore returns a MemoryStore keeping the entries r allows.
+func NewMemoryStore(r Retention) *MemoryStore {
+	return &MemoryStore{retention: r}
+}
+
+// Add implements Store. Entries past the store's retention are dropped.
+func (s *MemoryStore) Add(_ context.Context, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = s.retention.prune(append(s.entries, e), time.Now())
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (Entry, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.index(id); i >= 0 {
+		return s.entries[i], true, nil
+	}
+	return Entry{}, false, nil
+}
+
+// Claim implements Store.
+func (s *MemoryStore) Claim(_ context.Context, id string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return Entry{}, ErrNotFound
+	}
+	now := time.Now().UTC()
+	if err := claimable(s.entries[i], now); err != nil {
+		return s.entries[i], err
+	}
+	s.entries[i].Status, s.entries[i].ClaimedAt = StatusRedriving, &now
+	return s.entries[i], nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, q Query) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return newestFirst(s.entries, q), nil
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(_ context.Context, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(e.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.entries[i] = e
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return false, nil
+	}
+	s.entries = slices.Delete(s.entries, i, i+1)
+	return true, nil
+}
+
+// Close implements Store.
+func (s *MemoryStore) Close() error { return nil }
+
+func (s *MemoryStore) index(id string) int {
+	return slices.IndexFunc(s.entries, func(e Entry) bool { return e.ID == id })
+}
+
+// newestFirst returns the entries of oldest, oldest first, that q selects,
+// newest first.
+func newestFirst(oldest []Entry, q Query) []Entry {
+	result := make([]Entry, 0)
+	for i := len(oldest) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(result) == q.Limit {
+			break
+		}
+		if q.matches(oldest[i]) {
+			result = append(result, oldest[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+package deadletter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func testEntry(id string, created time.Time) Entry {
+	return Entry{
+		ID:        id,
+		CreatedAt: created,
+		Model:     "gpt-4o",
+		Status:    StatusPending,
+		Errors:    []string{"all targets failed"},
+		Request: newRequest(providers.Request{
+			Model:    "gpt-4o",
+			Messages: []providers.Message{{Role: providers.RoleUser, Content: "hi"}},
+		}),
+	}
+}
+
+// testStore runs the behaviour every Store shares.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := t.Context()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"dl_a", "dl_b", "dl_c"} {
+		if err := s.Add(ctx, testEntry(id, base.Add(time.Duration(i)*time.Minute))); err != nil {
+			t.Fatalf("add %s: %v", id, err)
+		}
+	}
+
+	all, err := s.List(ctx, Query{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(all) != 3 || all[0].ID != "dl_c" || all[2].ID != "dl_a" {
+		t.Fatalf("list should be newest first: %+v", all)
+	}
+	if all[0].Request.Messages[0].Content != "hi" {
+		t.Fatalf("request not kept: %+v", all[0].Request)
+	}
+
+	e, ok, err := s.Get(ctx, "dl_b")
+	if err != nil || !ok {
+		t.Fatalf("get: ok=%v err=%v", ok, err)
+	}
+	e.Status = StatusRedriven
+	e.Redrives = 1
+	if err := s.Update(ctx, e); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := s.Update(ctx, testEntry("dl_missing", base)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("update of a missing entry: got %v, want ErrNotFound", err)
+	}
+
+	claimed, err := s.Claim(ctx, "dl_c")
+	if err != nil || claimed.Status != StatusRedriving || claimed.ClaimedAt == nil {
+		t.Fatalf("claim: %+v, %v", claimed, err)
+	}
+	if _, err := s.Claim(ctx, "dl_c"); !errors.Is(err, ErrRedriving) {
+		t.Fatalf("second claim: got %v, want ErrRedriving", err)
+	}
+	if _, err := s.Claim(ctx, "dl_b"); !errors.Is(err, ErrRedriven) {
+		t.Fatalf("claim of a re-driven entry: got %v, want ErrRedriven", err)
+	}
+	if _, err := s.Claim(ctx, "dl_missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("claim of a missing entry: got %v, want ErrNotFound", err)
+	}
+	claimed.Status, claimed.ClaimedAt = StatusPending, nil
+	if err := s.Update(ctx, claimed); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+
+	pending, err := s.List(ctx, Query{Status: StatusPending, Limit: 1})
+	if err != nil {
+		t.Fatalf("list pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "dl_c" {
+		t.Fatalf("pending list with limit 1: %+v", pending)
+	}
+	redriven, err := s.List(ctx, Query{Status: StatusRedriven})
+	if err != nil {
+		t.Fatalf("list redriven: %v", err)
+	}
+	if len(redriven) != 1 || redriven[0].ID != "dl_b" || redriven[0].Redrives != 1 {
+		t.Fatalf("redriven list: %+v", redriven)
+	}
+
+	if deleted, err := s.Delete(ctx, "dl_a"); err != nil || !deleted {
+		t.Fatalf("delete: deleted=%v err=%v", deleted, err)
+	}
+	if deleted, err := s.Delete(ctx, "dl_a"); err != nil || deleted {
+		t.Fatalf("second delete: deleted=%v err=%v", deleted, err)
+	}
+	if _, ok, _ := s.Get(ctx, "dl_a"); ok {
+		t.Fatal("deleted entry still found")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(Retention{}))
+}
+
+func TestMemoryStore_DropsOldest(t *testing.T) {
+	s := NewMemoryStore(Retention{MaxEntries: 2})
+	base := time.Now()
+	for i, id := range []string{"dl_a", "dl_b", "dl_c"} {
+		_ = s.Add(t.Context(), testEntry(id, base.Add(time.Duration(i)*time.Second)))
+	}
+	all, _ := s.List(t.Context(), Query{})
+	if len(all) != 2 || all[1].ID != "dl_b" {
+		t.Fatalf("expected the two newest entries, got %+v", all)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	s, err := NewFileStore(path, Retention{})
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	testStore(t, s)
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := s.Add(t.Context(), testEntry("dl_d", time.Now())); err == nil {
+		t.Fatal("add after close should fail")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("file mode = %o, want 600", perm)
+	}
+
+	reopened, err := NewFileStore(path, Retention{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+	all, err := reopened.List(t.Context(), Query{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != "dl_c" || all[1].ID != "dl_b" || all[1].Status != StatusRedriven {
+		t.Fatalf("reopened store should replay updates and deletes: %+v", all)
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	s, err := NewSQLiteStore(t.Context(), filepath.Join(t.TempDir(), "dead-letters.db"), Retention{})
+	if err != nil {
+		t.Fatalf("new sqlite store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	testStore(t, s)
+}
+
+// Replicas sharing a database claim an entry through it, so only one of
+// them re-drives it.
+func TestSQLiteStore_ClaimIsShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.db")
+	var stores [2]*SQLStore
+	for i := range stores {
+		s, err := NewSQLiteStore(t.Context(), path, Retention{})
+		if err != nil {
+			t.Fatalf("new sqlite store: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		stores[i] = s
+	}
+	if err := stores[0].Add(t.Context(), testEntry("dl_a", time.Now().UTC())); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := stores[0].Claim(t.Context(), "dl_a"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if _, err := stores[1].Claim(t.Context(), "dl_a"); !errors.Is(err, ErrRedriving) {
+		t.Fatalf("claim from the other replica: got %v, want ErrRedriving", err)
+	}
+
+	// A claim left by a replica that stopped expires.
+	stale := time.Now().UTC().Add(-2 * claimTTL)
+	e, _, _ := stores[0].Get(t.Context(), "dl_a")
+	e.ClaimedAt = &stale
+	if err := stores[0].Update(t.Context(), e); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := stores[1].Claim(t.Context(), "dl_a"); err != nil {
+		t.Fatalf("claim over an expired claim: %v", err)
+	}
+}
+
+// testRetention checks that s, opened with retentionUnderTest, drops the
+// oldest entries past the count and every entry past the age.
+func testRetention(t *testing.T, s Store) {
+	t.Helper()
+	ctx := t.Context()
+	created := time.Now().UTC().Add(-time.Minute)
+	add := func(ids ...string) {
+		for _, id := range ids {
+			created = created.Add(time.Second)
+			at := created
+			if id == "dl_old" {
+				at = created.Add(-2 * time.Hour)
+			}
+			if err := s.Add(ctx, testEntry(id, at)); err != nil {
+				t.Fatalf("add %s: %v", id, err)
+			}
+		}
+	}
+	kept := func(want ...string) {
+		all, err := s.List(ctx, Query{})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		var ids []string
+		for _, e := range all {
+			ids = append(ids, e.ID)
+		}
+		if !slices.Equal(ids, want) {
+			t.Fatalf("kept %v, want %v", ids, want)
+		}
+	}
+
+	add("dl_old", "dl_a", "dl_b")
+	kept("dl_b", "dl_a")
+	add("dl_c", "dl_d")
+	kept("dl_d", "dl_c", "dl_b")
+}
+
+var retentionUnderTest = Retention{MaxEntries: 3, MaxAge: time.Hour}
+
+func TestRetention(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testRetention(t, NewMemoryStore(retentionUnderTest))
+	})
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+		s, err := NewFileStore(path, retentionUnderTest)
+		if err != nil {
+			t.Fatalf("new file store: %v", err)
+		}
+		testRetention(t, s)
+		for _, id := range []string{"dl_e", "dl_f"} {
+			_ = s.Add(t.Context(), testEntry(id, time.Now()))
+		}
+		_ = s.Close()
+		// The file was compacted as it outgrew the retention, and reopening
+		// it keeps the same entries.
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if lines := bytes.Count(data, []byte("\n")); lines != 4 {
+			t.Fatalf("file holds %d lines, want 4: compacted at the sixth, then one more", lines)
+		}
+		reopened, err := NewFileStore(path, retentionUnderTest)
+		if err != nil {
+			t.Fatalf("reopen: %v", err)
+		}
+		t.Cleanup(func() { _ = reopened.Close() })
+		if all, _ := reopened.List(t.Context(), Query{}); len(all) != 3 {
+			t.Fatalf("reopened store holds %d entries, want 3", len(all))
+		}
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		s, err := NewSQLiteStore(t.Context(), filepath.Join(t.TempDir(), "dead-letters.db"), retentionUnderTest)
+		if err != nil {
+			t.Fatalf("new sqlite store: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		testRetention(t, s)
+	})
+}
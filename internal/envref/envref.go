@@ -1,5 +1,6 @@
 // Package envref substitutes ${VAR} environment references in configuration
-// values.
+// values, and resolves values that are whole vault:// or awssm:// secret
+// references (see internal/secrets) from their secret manager.
 //
 // Resolution happens at the point a value is USED — when a plugin, exporter, or
 // MCP client is constructed — never when the Config is loaded. That distinction is
@@ -10,17 +11,24 @@
 // means a secret is never written to a database or served over the admin API, while
 // the component still receives the real value.
 //
-// Resolving at use also means a ${VAR} pushed through the admin/GitOps config API —
-// which never passes through LoadConfig — is substituted just the same.
+// Resolving at use also means a ${VAR} or secret reference pushed through the
+// admin/GitOps config API — which never passes through LoadConfig — is resolved
+// just the same.
 package envref
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
+
+	"github.com/ferro-labs/ai-gateway/internal/secrets"
 )
 
+// secretResolver resolves secret references; tests swap it.
+var secretResolver = secrets.FromEnv
+
 // refPattern matches an explicit ${VAR} reference.
 //
 // ONLY the braced form is a reference. A bare "$" is data, not a template: a price
@@ -30,13 +38,17 @@ import (
 // mangles secrets.
 var refPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
 
-// Expand substitutes every ${VAR} in s.
+// Expand substitutes every ${VAR} in s. A value that is a whole secret
+// reference is resolved from its secret manager instead.
 //
 // An undefined variable is an operator error, not a default: Expand returns an error
 // naming it rather than substituting "". Silently blanking a value turns a secret into
 // a baffling upstream auth failure and a guardrail's blocked word into a rule that
 // matches nothing — failures that surface far from their cause.
 func Expand(s string) (string, error) {
+	if secrets.IsRef(s) {
+		return resolveSecret(s)
+	}
 	var missing []string
 	out := refPattern.ReplaceAllStringFunc(s, func(ref string) string {
 		name := refPattern.FindStringSubmatch(ref)[1]
@@ -53,6 +65,16 @@ func Expand(s string) (string, error) {
 	return out, nil
 }
 
+// resolveSecret resolves the secret reference s. Each backend bounds its own
+// requests, so no deadline is added here.
+func resolveSecret(s string) (string, error) {
+	resolver, err := secretResolver()
+	if err != nil {
+		return "", err
+	}
+	return resolver.Resolve(context.Background(), s)
+}
+
 // StringMap returns a copy of m with every value expanded. The input is never
 // mutated: the caller's Config must keep its ${VAR} references.
 func StringMap(m map[string]string) (map[string]string, error) {
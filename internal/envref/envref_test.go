@@ -1,9 +1,13 @@
 package envref
 
 import (
+	"context"
+	"errors"
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/secrets"
 )
 
 func TestExpand(t *testing.T) {
@@ -102,3 +106,38 @@ func TestStringMap_NilVsNonNilEmpty(t *testing.T) {
 		t.Error("StringMap(non-nil empty map) = nil, want a non-nil empty map")
 	}
 }
+
+type fakeSecretBackend map[string]string
+
+func (b fakeSecretBackend) Get(_ context.Context, name, _ string) (string, error) {
+	v, ok := b[name]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return v, nil
+}
+
+func TestAnyMap_SecretRefs(t *testing.T) {
+	r := secrets.NewResolver()
+	r.Register(secrets.SchemeVault, fakeSecretBackend{"secret/data/langsmith": "ls-key"})
+	prev := secretResolver
+	t.Cleanup(func() { secretResolver = prev })
+	secretResolver = func() (*secrets.Resolver, error) { return r, nil }
+
+	in := map[string]any{"api_key": "vault://secret/data/langsmith#key", "url": "https://api.example.com"}
+	out, err := AnyMap(in)
+	if err != nil {
+		t.Fatalf("AnyMap: %v", err)
+	}
+	if out["api_key"] != "ls-key" || out["url"] != "https://api.example.com" {
+		t.Errorf("out = %v, want the secret resolved and the URL untouched", out)
+	}
+	if in["api_key"] != "vault://secret/data/langsmith#key" {
+		t.Errorf("input was mutated: %v", in["api_key"])
+	}
+
+	// No backend for the scheme is an error, not a literal key.
+	if _, err := Expand("awssm://prod/openai"); err == nil {
+		t.Error("expected an awssm:// reference with no backend to fail")
+	}
+}
@@ -0,0 +1,384 @@
+// Package evals runs evaluation suites: it sends a suite's prompts to one or
+// more models through the gateway, scores each answer with the suite's
+// scoring method, and keeps the scored runs so models can be compared on the
+// same prompts.
+//
+// Suites are defined in the gateway config (eval_suites); this package only
+// executes them. Eval requests are routed like any client request, so they
+// pass through plugins, fallbacks, and cost accounting, and appear in the
+// request log.
+package evals
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// defaultLogSample is the number of logged requests a from_logs suite
+// replays when its limit is unset.
+const defaultLogSample = 50
+
+// concurrency bounds the cases of one run that are in flight at once.
+const concurrency = 4
+
+// ErrNoCases is returned for a suite with nothing to run, typically a
+// from_logs suite whose filters match no recorded request content.
+var ErrNoCases = errors.New("eval suite has no cases to run")
+
+// Completer routes a chat completion request. *aigateway.Gateway implements
+// it.
+type Completer interface {
+	Route(ctx context.Context, req providers.Request) (*providers.Response, error)
+}
+
+// Run is the scored result of one suite against one model.
+type Run struct {
+	ID        string    `json:"id"`
+	Suite     string    `json:"suite"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	// Score is the mean case score, from 0 to 1.
+	Score  float64 `json:"score"`
+	Passed int     `json:"passed"`
+	Total  int     `json:"total"`
+	// Errors counts cases whose model call failed; they score 0.
+	Errors      int          `json:"errors"`
+	TotalTokens int          `json:"total_tokens"`
+	LatencyMs   int64        `json:"latency_ms"`
+	Cases       []CaseResult `json:"cases,omitempty"`
+}
+
+// Summary returns r without its per-case results.
+func (r Run) Summary() Run {
+	r.Cases = nil
+	return r
+}
+
+// CaseResult is one scored answer.
+type CaseResult struct {
+	CaseID   string  `json:"case_id"`
+	Prompt   string  `json:"prompt"`
+	Expected string  `json:"expected,omitempty"`
+	Output   string  `json:"output"`
+	Score    float64 `json:"score"`
+	Passed   bool    `json:"passed"`
+	// Reason is the judge's explanation for llm_judge scoring.
+	Reason      string `json:"reason,omitempty"`
+	Error       string `json:"error,omitempty"`
+	LatencyMs   int64  `json:"latency_ms"`
+	TotalTokens int    `json:"total_tokens"`
+}
+
+// Runner executes eval suites and stores their runs.
+type Runner struct {
+	gateway Completer
+	logs    requestlog.Reader
+	store   Store
+}
+
+// NewRunner returns a Runner that routes through gateway and stores runs in
+// store. logs may be nil, in which case from_logs suites cannot run.
+func NewRunner(gateway Completer, logs requestlog.Reader, store Store) *Runner {
+	return &Runner{gateway: gateway, logs: logs, store: store}
+}
+
+// Store returns the store runs are saved to.
+func (r *Runner) Store() Store { return r.store }
+
+// Run scores suite against each model in turn and saves every run. It returns
+// the runs completed before the first failure along with that failure. A
+// model whose calls fail is not a failure of the run: its cases record the
+// error and score 0.
+//
+// decrypt allows cases to be sampled from encrypted request logs; pass it
+// only for a caller that may read them (the logs_decrypt scope). Without it,
+// encrypted traces are skipped like traces recorded without content. The
+// text of a case built from decrypted logs is returned to the caller but
+// saved as RedactedText, so the stored run never holds it in the clear.
+func (r *Runner) Run(ctx context.Context, suite aigateway.EvalSuite, models []string, decrypt bool) ([]Run, error) {
+	scorer, err := newScorer(suite.Scoring, r.gateway)
+	if err != nil {
+		return nil, err
+	}
+	cases, decrypted, err := r.cases(ctx, suite, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, ErrNoCases
+	}
+
+	runs := make([]Run, 0, len(models))
+	for _, model := range models {
+		run, err := r.runModel(ctx, suite.Name, model, cases, scorer)
+		if err != nil {
+			return runs, err
+		}
+		if err := r.store.Save(ctx, redact(run, decrypted)); err != nil {
+			return runs, fmt.Errorf("save eval run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (r *Runner) runModel(ctx context.Context, suite, model string, cases []aigateway.EvalCase, scorer scorer) (Run, error) {
+	id, err := newRunID()
+	if err != nil {
+		return Run{}, err
+	}
+	run := Run{
+		ID:        id,
+		Suite:     suite,
+		Model:     model,
+		CreatedAt: time.Now().UTC(),
+		Total:     len(cases),
+		Cases:     make([]CaseResult, len(cases)),
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, c := range cases {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			run.Cases[i] = r.runCase(ctx, model, c, scorer)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return Run{}, err
+	}
+
+	var sum float64
+	for _, c := range run.Cases {
+		sum += c.Score
+		if c.Passed {
+			run.Passed++
+		}
+		if c.Error != "" {
+			run.Errors++
+		}
+		run.TotalTokens += c.TotalTokens
+		run.LatencyMs += c.LatencyMs
+	}
+	run.Score = sum / float64(len(run.Cases))
+	return run, nil
+}
+
+func (r *Runner) runCase(ctx context.Context, model string, c aigateway.EvalCase, scorer scorer) CaseResult {
+	res := CaseResult{CaseID: c.ID, Prompt: c.Prompt, Expected: c.Expected}
+
+	var messages []providers.Message
+	if c.System != "" {
+		messages = append(messages, providers.Message{Role: providers.RoleSystem, Content: c.System})
+	}
+	messages = append(messages, providers.Message{Role: providers.RoleUser, Content: c.Prompt})
+
+	start := time.Now()
+	resp, err := r.gateway.Route(ctx, providers.Request{Model: model, Messages: messages})
+	res.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Output = firstContent(resp)
+	res.TotalTokens = resp.Usage.TotalTokens
+
+	s, err := scorer.score(ctx, c, res.Output)
+	if err != nil {
+		res.Error = "scoring: " + err.Error()
+		return res
+	}
+	res.Score, res.Passed, res.Reason = s.value, s.passed, s.reason
+	return res
+}
+
+// RedactedText replaces, in stored runs, the prompt, expected answer, output,
+// and judge's reason of a case built from decrypted request logs.
+const RedactedText = "[redacted]"
+
+// redact returns run with the text of the cases at the decrypted indexes
+// replaced by RedactedText. run itself is left unchanged.
+func redact(run Run, decrypted map[int]bool) Run {
+	if len(decrypted) == 0 {
+		return run
+	}
+	run.Cases = slices.Clone(run.Cases)
+	for i := range decrypted {
+		c := &run.Cases[i]
+		c.Prompt, c.Output = RedactedText, RedactedText
+		if c.Expected != "" {
+			c.Expected = RedactedText
+		}
+		if c.Reason != "" {
+			c.Reason = RedactedText
+		}
+	}
+	return run
+}
+
+// cases returns the suite's fixed cases followed by any sampled from the
+// request log, each with an ID, and the indexes of the cases built from
+// decrypted logs.
+func (r *Runner) cases(ctx context.Context, suite aigateway.EvalSuite, decrypt bool) ([]aigateway.EvalCase, map[int]bool, error) {
+	cases := make([]aigateway.EvalCase, 0, len(suite.Cases))
+	for i, c := range suite.Cases {
+		if c.ID == "" {
+			c.ID = strconv.Itoa(i + 1)
+		}
+		cases = append(cases, c)
+	}
+	if suite.FromLogs == nil {
+		return cases, nil, nil
+	}
+	if r.logs == nil {
+		return nil, nil, errors.New("eval suite samples request logs, but request logging is not enabled")
+	}
+	sampled, err := r.sampleLogs(ctx, *suite.FromLogs, decrypt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sample request logs: %w", err)
+	}
+	decrypted := make(map[int]bool)
+	for _, s := range sampled {
+		if s.decrypted {
+			decrypted[len(cases)] = true
+		}
+		cases = append(cases, s.EvalCase)
+	}
+	return cases, decrypted, nil
+}
+
+// logCase is a case sampled from the request log.
+type logCase struct {
+	aigateway.EvalCase
+	// decrypted reports that the case was built from encrypted entries.
+	decrypted bool
+}
+
+// sampleLogs turns recorded requests into cases. It samples answered
+// requests (after_request entries, which carry the serving model and
+// provider) and pairs each with the request recorded for the same trace: the
+// request's last user message is the prompt, its system message the system
+// prompt, and the recorded answer the expected answer. Traces recorded
+// without content, or encrypted when decrypt is false, are skipped.
+func (r *Runner) sampleLogs(ctx context.Context, sample aigateway.EvalLogSample, decrypt bool) ([]logCase, error) {
+	limit := sample.Limit
+	if limit == 0 {
+		limit = defaultLogSample
+	}
+	q := requestlog.Query{
+		Limit:    limit,
+		Stage:    "after_request",
+		Model:    sample.Model,
+		Provider: sample.Provider,
+	}
+	if sample.Since != "" {
+		d, err := time.ParseDuration(sample.Since)
+		if err != nil {
+			return nil, err
+		}
+		since := time.Now().UTC().Add(-d)
+		q.Since = &since
+	}
+	result, err := r.logs.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []logCase
+	for _, entry := range result.Data {
+		sealed := requestlog.Sealed(entry.Response)
+		entry = r.openPayloads(entry, decrypt)
+		var resp providers.Response
+		if len(entry.Response) == 0 || json.Unmarshal(entry.Response, &resp) != nil {
+			continue
+		}
+		req, reqSealed, err := r.loggedRequest(ctx, entry.TraceID, decrypt)
+		if err != nil {
+			return nil, err
+		}
+		if req == nil {
+			continue
+		}
+		c := logCase{
+			EvalCase:  aigateway.EvalCase{ID: entry.TraceID, Expected: firstContent(&resp)},
+			decrypted: sealed || reqSealed,
+		}
+		for _, m := range req.Messages {
+			switch m.Role {
+			case providers.RoleSystem:
+				c.System = m.Content
+			case providers.RoleUser:
+				c.Prompt = m.Content
+			}
+		}
+		if c.Prompt != "" {
+			cases = append(cases, c)
+		}
+	}
+	return cases, nil
+}
+
+// loggedRequest returns the request recorded for a trace, or nil when none
+// was recorded with content, and whether it was recorded encrypted.
+func (r *Runner) loggedRequest(ctx context.Context, traceID string, decrypt bool) (*providers.Request, bool, error) {
+	if traceID == "" {
+		return nil, false, nil
+	}
+	result, err := r.logs.List(ctx, requestlog.Query{TraceID: traceID, Stage: "before_request", Limit: 1})
+	if err != nil {
+		return nil, false, err
+	}
+	for _, entry := range result.Data {
+		sealed := requestlog.Sealed(entry.Request)
+		entry = r.openPayloads(entry, decrypt)
+		var req providers.Request
+		if len(entry.Request) > 0 && json.Unmarshal(entry.Request, &req) == nil {
+			return &req, sealed, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// openPayloads decrypts entry's recorded bodies when the store encrypts them
+// and decrypt allows it. A body left sealed, or that fails to open, does not
+// parse, and the trace is skipped as one recorded without content.
+func (r *Runner) openPayloads(entry requestlog.Entry, decrypt bool) requestlog.Entry {
+	opener, ok := r.logs.(requestlog.PayloadOpener)
+	if !ok || !decrypt {
+		return entry
+	}
+	if opened, err := opener.OpenPayloads(entry); err == nil {
+		return opened
+	}
+	return entry
+}
+
+func firstContent(resp *providers.Response) string {
+	if resp == nil || len(resp.Choices) == 0 {
+		return ""
+	}
+	return resp.Choices[0].Message.Content
+}
+
+func newRunID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating run id: %w", err)
+	}
+	return "evr_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,377 @@
+package evals
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/internal/sqldb"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// fakeGateway answers each model with fn, and records the requests it saw.
+type fakeGateway struct {
+	mu   sync.Mutex
+	reqs []providers.Request
+	fn   func(req providers.Request) (string, error)
+}
+
+func (g *fakeGateway) Route(_ context.Context, req providers.Request) (*providers.Response, error) {
+	g.mu.Lock()
+	g.reqs = append(g.reqs, req)
+	g.mu.Unlock()
+	content, err := g.fn(req)
+	if err != nil {
+		return nil, err
+	}
+	return &providers.Response{
+		Model:   req.Model,
+		Choices: []providers.Choice{{Message: providers.Message{Role: providers.RoleAssistant, Content: content}}},
+		Usage:   providers.Usage{TotalTokens: 10},
+	}, nil
+}
+
+func lastUser(req providers.Request) string {
+	return req.Messages[len(req.Messages)-1].Content
+}
+
+func TestRunner_ExactMatchComparesModels(t *testing.T) {
+	gw := &fakeGateway{fn: func(req providers.Request) (string, error) {
+		switch {
+		case req.Model == "good":
+			return " Paris\n", nil
+		case req.Model == "flaky":
+			return "", errors.New("upstream unavailable")
+		case strings.Contains(lastUser(req), "France"):
+			return "Lyon", nil
+		}
+		return "4", nil
+	}}
+	store := NewMemoryStore(0)
+	runner := NewRunner(gw, nil, store)
+	suite := aigateway.EvalSuite{
+		Name: "capitals",
+		Cases: []aigateway.EvalCase{
+			{Prompt: "Capital of France?", Expected: "Paris"},
+			{ID: "math", System: "Answer tersely.", Prompt: "2+2?", Expected: "Paris"},
+		},
+		Scoring: aigateway.EvalScoring{Method: aigateway.EvalScoringExactMatch},
+	}
+
+	runs, err := runner.Run(context.Background(), suite, []string{"good", "bad", "flaky"}, false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("expected a run per model, got %d", len(runs))
+	}
+	if r := runs[0]; r.Score != 1 || r.Passed != 2 || r.Total != 2 || r.TotalTokens != 20 {
+		t.Fatalf("good model: %+v", r)
+	}
+	if r := runs[1]; r.Score != 0 || r.Passed != 0 || r.Cases[0].Output != "Lyon" {
+		t.Fatalf("bad model: %+v", r)
+	}
+	if r := runs[2]; r.Errors != 2 || r.Cases[0].Error == "" {
+		t.Fatalf("failed calls should be recorded per case: %+v", r)
+	}
+	if runs[0].Cases[0].CaseID != "1" || runs[0].Cases[1].CaseID != "math" {
+		t.Fatalf("case IDs: %q, %q", runs[0].Cases[0].CaseID, runs[0].Cases[1].CaseID)
+	}
+
+	for _, req := range gw.reqs {
+		if lastUser(req) == "2+2?" && req.Messages[0].Role != providers.RoleSystem {
+			t.Fatalf("system prompt not sent: %+v", req.Messages)
+		}
+	}
+
+	stored, _ := store.List(context.Background(), "capitals", "")
+	if len(stored) != 3 || stored[0].Model != "flaky" || stored[0].Cases != nil {
+		t.Fatalf("stored runs should be summaries, newest first: %+v", stored)
+	}
+	full, ok, _ := store.Get(context.Background(), runs[1].ID)
+	if !ok || len(full.Cases) != 2 {
+		t.Fatalf("Get should return case results: %+v", full)
+	}
+}
+
+func TestRunner_Regex(t *testing.T) {
+	gw := &fakeGateway{fn: func(providers.Request) (string, error) { return "The answer is 42.", nil }}
+	runner := NewRunner(gw, nil, NewMemoryStore(0))
+	suite := aigateway.EvalSuite{
+		Name:    "numbers",
+		Cases:   []aigateway.EvalCase{{Prompt: "?"}},
+		Scoring: aigateway.EvalScoring{Method: aigateway.EvalScoringRegex, Pattern: `\b42\b`},
+	}
+	runs, err := runner.Run(context.Background(), suite, []string{"m"}, false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !runs[0].Cases[0].Passed || runs[0].Score != 1 {
+		t.Fatalf("regex should match: %+v", runs[0])
+	}
+}
+
+func TestRunner_LLMJudge(t *testing.T) {
+	gw := &fakeGateway{fn: func(req providers.Request) (string, error) {
+		if req.Model == "judge" {
+			if !strings.Contains(lastUser(req), "Reference answer:\nParis") || !strings.Contains(lastUser(req), "Be strict.") {
+				t.Errorf("judge prompt missing reference or rubric: %s", lastUser(req))
+			}
+			if strings.Contains(lastUser(req), "Paris, France") {
+				return "Correct, if wordy.\nscore: 8", nil
+			}
+			return "Wrong city.\nScore: 1", nil
+		}
+		if req.Model == "a" {
+			return "Paris, France", nil
+		}
+		return "Lyon", nil
+	}}
+	runner := NewRunner(gw, nil, NewMemoryStore(0))
+	suite := aigateway.EvalSuite{
+		Name:    "judged",
+		Cases:   []aigateway.EvalCase{{Prompt: "Capital of France?", Expected: "Paris"}},
+		Scoring: aigateway.EvalScoring{Method: aigateway.EvalScoringLLMJudge, JudgeModel: "judge", Rubric: "Be strict."},
+	}
+	runs, err := runner.Run(context.Background(), suite, []string{"a", "b"}, false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	a, b := runs[0].Cases[0], runs[1].Cases[0]
+	if a.Score != 0.8 || !a.Passed || !strings.Contains(a.Reason, "wordy") {
+		t.Fatalf("model a: %+v", a)
+	}
+	if b.Score != 0.1 || b.Passed {
+		t.Fatalf("model b: %+v", b)
+	}
+}
+
+func TestParseJudgeScore(t *testing.T) {
+	tests := []struct {
+		verdict string
+		want    float64
+		ok      bool
+	}{
+		{"Good.\nscore: 7", 0.7, true},
+		{"**Score**: 10", 1, true},
+		{"Reply as score: N.\nscore: 3", 0.3, true},
+		{"score: 15", 1, true},
+		{"no grade here", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseJudgeScore(tt.verdict)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseJudgeScore(%q) = %v, %v; want %v, %v", tt.verdict, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+type fakeLogReader struct {
+	entries []requestlog.Entry
+}
+
+func (f fakeLogReader) List(_ context.Context, q requestlog.Query) (requestlog.ListResult, error) {
+	var out []requestlog.Entry
+	for _, e := range f.entries {
+		if (q.Stage == "" || e.Stage == q.Stage) && (q.TraceID == "" || e.TraceID == q.TraceID) && (q.Model == "" || e.Model == q.Model) {
+			out = append(out, e)
+		}
+	}
+	return requestlog.ListResult{Data: out, Total: len(out)}, nil
+}
+
+func (fakeLogReader) Stats(context.Context, requestlog.Query) (requestlog.StatsResult, error) {
+	return requestlog.StatsResult{}, nil
+}
+
+func mustJSON(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRunner_FromLogs(t *testing.T) {
+	logged := providers.Request{Model: "gpt-4o", Messages: []providers.Message{
+		{Role: providers.RoleSystem, Content: "Be brief."},
+		{Role: providers.RoleUser, Content: "Capital of Italy?"},
+	}}
+	answer := providers.Response{Choices: []providers.Choice{{Message: providers.Message{Role: providers.RoleAssistant, Content: "Rome"}}}}
+	logs := fakeLogReader{entries: []requestlog.Entry{
+		{TraceID: "t1", Stage: "before_request", Model: "gpt-4o", Request: mustJSON(t, logged)},
+		{TraceID: "t1", Stage: "after_request", Model: "gpt-4o", Response: mustJSON(t, answer)},
+		// Logged without content: nothing to replay.
+		{TraceID: "t2", Stage: "before_request", Model: "gpt-4o"},
+		{TraceID: "t2", Stage: "after_request", Model: "gpt-4o"},
+	}}
+	gw := &fakeGateway{fn: func(req providers.Request) (string, error) {
+		if req.Messages[0].Content != "Be brief." {
+			t.Errorf("system prompt not replayed: %+v", req.Messages)
+		}
+		return "Rome", nil
+	}}
+	suite := aigateway.EvalSuite{
+		Name:     "replay",
+		FromLogs: &aigateway.EvalLogSample{Model: "gpt-4o"},
+		Scoring:  aigateway.EvalScoring{Method: aigateway.EvalScoringExactMatch},
+	}
+
+	runs, err := NewRunner(gw, logs, NewMemoryStore(0)).Run(context.Background(), suite, []string{"cheap-model"}, false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if runs[0].Total != 1 || runs[0].Cases[0].CaseID != "t1" || !runs[0].Cases[0].Passed {
+		t.Fatalf("unexpected replay run: %+v", runs[0])
+	}
+
+	if _, err := NewRunner(gw, nil, NewMemoryStore(0)).Run(context.Background(), suite, []string{"m"}, false); err == nil {
+		t.Fatal("from_logs without a log reader should fail")
+	}
+	suite.FromLogs.Model = "unlogged"
+	if _, err := NewRunner(gw, logs, NewMemoryStore(0)).Run(context.Background(), suite, []string{"m"}, false); !errors.Is(err, ErrNoCases) {
+		t.Fatalf("expected ErrNoCases, got %v", err)
+	}
+}
+
+// sealedLogReader serves entries sealed with its cipher and opens them.
+type sealedLogReader struct {
+	fakeLogReader
+	cipher *requestlog.PayloadCipher
+}
+
+func (s sealedLogReader) OpenPayloads(e requestlog.Entry) (requestlog.Entry, error) {
+	var err error
+	if e.Request, err = s.cipher.Open(e.Request); err != nil {
+		return e, err
+	}
+	e.Response, err = s.cipher.Open(e.Response)
+	return e, err
+}
+
+func TestRunner_FromEncryptedLogs(t *testing.T) {
+	cipher, err := requestlog.ParsePayloadKeys(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(v any) json.RawMessage {
+		sealed, err := cipher.Seal(mustJSON(t, v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	logged := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: providers.RoleUser, Content: "Capital of Italy?"}}}
+	answer := providers.Response{Choices: []providers.Choice{{Message: providers.Message{Role: providers.RoleAssistant, Content: "Rome"}}}}
+	logs := sealedLogReader{cipher: cipher, fakeLogReader: fakeLogReader{entries: []requestlog.Entry{
+		{TraceID: "t1", Stage: "before_request", Model: "gpt-4o", Request: seal(logged)},
+		{TraceID: "t1", Stage: "after_request", Model: "gpt-4o", Response: seal(answer)},
+	}}}
+	gw := &fakeGateway{fn: func(providers.Request) (string, error) { return "Rome", nil }}
+	suite := aigateway.EvalSuite{
+		Name:     "replay",
+		FromLogs: &aigateway.EvalLogSample{Model: "gpt-4o"},
+		Scoring:  aigateway.EvalScoring{Method: aigateway.EvalScoringExactMatch},
+	}
+	store := NewMemoryStore(0)
+	runner := NewRunner(gw, logs, store)
+
+	// A caller that may not decrypt gets no cases from encrypted logs.
+	if _, err := runner.Run(context.Background(), suite, []string{"m"}, false); !errors.Is(err, ErrNoCases) {
+		t.Fatalf("expected ErrNoCases without decrypt, got %v", err)
+	}
+
+	runs, err := runner.Run(context.Background(), suite, []string{"m"}, true)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if c := runs[0].Cases[0]; c.Prompt != "Capital of Italy?" || c.Output != "Rome" || !c.Passed {
+		t.Fatalf("the caller should see the decrypted case: %+v", c)
+	}
+	stored, _, _ := store.Get(context.Background(), runs[0].ID)
+	if c := stored.Cases[0]; c.Prompt != RedactedText || c.Expected != RedactedText || c.Output != RedactedText || !c.Passed {
+		t.Fatalf("the stored case should be redacted: %+v", c)
+	}
+}
+
+func TestMemoryStore_KeepsNewestRuns(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2)
+	for i, model := range []string{"a", "b", "a"} {
+		_ = s.Save(ctx, Run{ID: string(rune('x' + i)), Suite: "s", Model: model})
+	}
+	runs, _ := s.List(ctx, "s", "")
+	if len(runs) != 2 || runs[0].ID != "z" || runs[1].ID != "y" {
+		t.Fatalf("expected the two newest runs, newest first: %+v", runs)
+	}
+	if latest := Latest(runs); len(latest) != 2 || latest[0].Model != "a" {
+		t.Fatalf("Latest: %+v", latest)
+	}
+	if runs, _ := s.List(ctx, "s", "b"); len(runs) != 1 {
+		t.Fatalf("model filter: %+v", runs)
+	}
+	_ = s.DeleteSuite(ctx, "s")
+	if _, ok, _ := s.Get(ctx, "z"); ok {
+		t.Fatal("runs survived DeleteSuite")
+	}
+}
+
+func TestSQLStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqldb.Open(ctx, sqldb.SQLite, filepath.Join(t.TempDir(), "evals.db"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s, err := NewSQLStore(ctx, db, sqldb.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := range DefaultMaxRunsPerSuite + 1 {
+		model := "a"
+		if i == DefaultMaxRunsPerSuite-1 {
+			model = "b"
+		}
+		run := Run{ID: fmt.Sprintf("run-%03d", i), Suite: "s", Model: model, CreatedAt: start.Add(time.Duration(i) * time.Second)}
+		if i == DefaultMaxRunsPerSuite {
+			run.Cases = []CaseResult{{Prompt: "hi"}}
+		}
+		if err := s.Save(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, err := s.List(ctx, "s", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != DefaultMaxRunsPerSuite || runs[0].ID != "run-100" || runs[len(runs)-1].ID != "run-001" {
+		t.Fatalf("expected the newest %d runs, newest first: got %d from %s", DefaultMaxRunsPerSuite, len(runs), runs[0].ID)
+	}
+	if runs[0].Cases != nil {
+		t.Fatal("List should leave out case results")
+	}
+	if runs, _ := s.List(ctx, "s", "b"); len(runs) != 1 || runs[0].ID != "run-099" {
+		t.Fatalf("model filter: %+v", runs)
+	}
+	if run, ok, err := s.Get(ctx, "run-100"); err != nil || !ok || len(run.Cases) != 1 {
+		t.Fatalf("Get: %+v %v %v", run, ok, err)
+	}
+	if err := s.DeleteSuite(ctx, "s"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get(ctx, "run-100"); ok {
+		t.Fatal("runs survived DeleteSuite")
+	}
+}
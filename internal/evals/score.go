@@ -0,0 +1,133 @@
+package evals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// defaultPassScore is the llm_judge pass threshold when the suite sets none.
+const defaultPassScore = 0.7
+
+// judgeInstructions asks for the grade on a line of its own, so it can be
+// read back without a structured-output feature every judge model may lack.
+const judgeInstructions = `You are grading an AI model's answer. Compare the answer with the reference answer, if one is given, and with the grading criteria, if any. Reply with a one-sentence justification, then a final line of the form "score: N", where N is an integer from 0 (wrong) to 10 (fully correct).`
+
+// scoreLine matches the judge's "score: N" line; the last one wins, so a
+// justification that quotes the format cannot decide the grade.
+var scoreLine = regexp.MustCompile(`(?im)^\s*\**score\**\s*[:=]\s*(\d+(?:\.\d+)?)`)
+
+type score struct {
+	value  float64
+	passed bool
+	reason string
+}
+
+type scorer interface {
+	score(ctx context.Context, c aigateway.EvalCase, output string) (score, error)
+}
+
+func newScorer(cfg aigateway.EvalScoring, gateway Completer) (scorer, error) {
+	switch cfg.Method {
+	case aigateway.EvalScoringExactMatch:
+		return exactMatch{}, nil
+	case aigateway.EvalScoringRegex:
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("scoring.pattern: %w", err)
+		}
+		return regexScorer{re: re}, nil
+	case aigateway.EvalScoringLLMJudge:
+		if cfg.JudgeModel == "" {
+			return nil, errors.New("scoring.judge_model is required for llm_judge")
+		}
+		pass := cfg.PassScore
+		if pass == 0 {
+			pass = defaultPassScore
+		}
+		return llmJudge{gateway: gateway, model: cfg.JudgeModel, rubric: cfg.Rubric, pass: pass}, nil
+	default:
+		return nil, fmt.Errorf("unknown scoring method %q", cfg.Method)
+	}
+}
+
+type exactMatch struct{}
+
+func (exactMatch) score(_ context.Context, c aigateway.EvalCase, output string) (score, error) {
+	if strings.TrimSpace(output) == strings.TrimSpace(c.Expected) {
+		return score{value: 1, passed: true}, nil
+	}
+	return score{}, nil
+}
+
+type regexScorer struct {
+	re *regexp.Regexp
+}
+
+func (s regexScorer) score(_ context.Context, _ aigateway.EvalCase, output string) (score, error) {
+	if s.re.MatchString(output) {
+		return score{value: 1, passed: true}, nil
+	}
+	return score{}, nil
+}
+
+type llmJudge struct {
+	gateway Completer
+	model   string
+	rubric  string
+	pass    float64
+}
+
+func (j llmJudge) score(ctx context.Context, c aigateway.EvalCase, output string) (score, error) {
+	var prompt strings.Builder
+	if c.System != "" {
+		fmt.Fprintf(&prompt, "System prompt:\n%s\n\n", c.System)
+	}
+	fmt.Fprintf(&prompt, "Question:\n%s\n\n", c.Prompt)
+	if c.Expected != "" {
+		fmt.Fprintf(&prompt, "Reference answer:\n%s\n\n", c.Expected)
+	}
+	if j.rubric != "" {
+		fmt.Fprintf(&prompt, "Grading criteria:\n%s\n\n", j.rubric)
+	}
+	fmt.Fprintf(&prompt, "Answer to grade:\n%s", output)
+
+	temperature := 0.0
+	resp, err := j.gateway.Route(ctx, providers.Request{
+		Model: j.model,
+		Messages: []providers.Message{
+			{Role: providers.RoleSystem, Content: judgeInstructions},
+			{Role: providers.RoleUser, Content: prompt.String()},
+		},
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return score{}, fmt.Errorf("judge %s: %w", j.model, err)
+	}
+	verdict := firstContent(resp)
+	value, ok := parseJudgeScore(verdict)
+	if !ok {
+		return score{}, fmt.Errorf("judge %s returned no score", j.model)
+	}
+	return score{value: value, passed: value >= j.pass, reason: strings.TrimSpace(verdict)}, nil
+}
+
+// parseJudgeScore reads the last "score: N" line of a verdict and scales it
+// from 0–10 to 0–1.
+func parseJudgeScore(verdict string) (float64, bool) {
+	matches := scoreLine.FindAllStringSubmatch(verdict, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return min(max(n, 0), 10) / 10, true
+}
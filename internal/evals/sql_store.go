@@ -0,0 +1,129 @@
+package evals
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ferro-labs/ai-gateway/internal/migrations"
+	"github.com/ferro-labs/ai-gateway/internal/sqldb"
+)
+
+// evalLedger is the eval store's own migration ledger, so it can share a
+// database with the gateway's other stores.
+const evalLedger = "eval_schema_migrations"
+
+// SQLStore keeps runs in an eval_runs table in SQLite or Postgres, the most
+// recent DefaultMaxRunsPerSuite of each suite. The run is stored as JSON
+// beside the columns it is listed by. The store does not own the database.
+type SQLStore struct {
+	db      *sql.DB
+	dialect sqldb.Dialect
+}
+
+// NewSQLStore migrates the eval_runs table in db and returns a store on it.
+func NewSQLStore(ctx context.Context, db *sql.DB, dialect sqldb.Dialect) (*SQLStore, error) {
+	if err := migrations.RunNamed(ctx, db, dialect, evalLedger, "", evalSteps(dialect)); err != nil {
+		return nil, fmt.Errorf("migrate %s eval schema: %w", dialect, err)
+	}
+	return &SQLStore{db: db, dialect: dialect}, nil
+}
+
+// evalSteps returns the migration sequence for the eval_runs table.
+func evalSteps(dialect sqldb.Dialect) []migrations.Step {
+	createdAt := "TIMESTAMP"
+	if dialect == sqldb.Postgres {
+		createdAt = "TIMESTAMPTZ"
+	}
+	return []migrations.Step{
+		{Version: 1, Name: "eval_runs", SQL: `
+CREATE TABLE IF NOT EXISTS eval_runs (
+	id TEXT PRIMARY KEY,
+	suite TEXT NOT NULL,
+	model TEXT NOT NULL,
+	created_at ` + createdAt + ` NOT NULL,
+	run_json TEXT NOT NULL
+);`},
+		{Version: 2, Name: "eval_runs_suite", SQL: "CREATE INDEX IF NOT EXISTS eval_runs_suite ON eval_runs (suite, created_at)"},
+	}
+}
+
+func (s *SQLStore) q(query string) string { return sqldb.Bind(s.dialect, query) }
+
+// Save implements Store. Once a suite holds the maximum, its oldest runs are
+// deleted.
+func (s *SQLStore) Save(ctx context.Context, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("encode eval run: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, s.q("INSERT INTO eval_runs (id, suite, model, created_at, run_json) VALUES (?, ?, ?, ?, ?)"),
+		run.ID, run.Suite, run.Model, run.CreatedAt.UTC(), string(data)); err != nil {
+		return fmt.Errorf("insert eval run: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, s.q(`DELETE FROM eval_runs WHERE suite = ? AND id NOT IN (
+	SELECT id FROM eval_runs WHERE suite = ? ORDER BY created_at DESC, id DESC LIMIT ?)`),
+		run.Suite, run.Suite, DefaultMaxRunsPerSuite); err != nil {
+		return fmt.Errorf("prune eval runs: %w", err)
+	}
+	return nil
+}
+
+// List implements Store.
+func (s *SQLStore) List(ctx context.Context, suite, model string) ([]Run, error) {
+	query := "SELECT run_json FROM eval_runs WHERE suite = ?"
+	args := []any{suite}
+	if model != "" {
+		query += " AND model = ?"
+		args = append(args, model)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	rows, err := s.db.QueryContext(ctx, s.q(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list eval runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	result := make([]Run, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("list eval runs: %w", err)
+		}
+		var run Run
+		if err := json.Unmarshal([]byte(data), &run); err != nil {
+			return nil, fmt.Errorf("decode eval run: %w", err)
+		}
+		result = append(result, run.Summary())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list eval runs: %w", err)
+	}
+	return result, nil
+}
+
+// Get implements Store.
+func (s *SQLStore) Get(ctx context.Context, id string) (Run, bool, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, s.q("SELECT run_json FROM eval_runs WHERE id = ?"), id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Run{}, false, nil
+	}
+	if err != nil {
+		return Run{}, false, fmt.Errorf("get eval run: %w", err)
+	}
+	var run Run
+	if err := json.Unmarshal([]byte(data), &run); err != nil {
+		return Run{}, false, fmt.Errorf("decode eval run: %w", err)
+	}
+	return run, true, nil
+}
+
+// DeleteSuite implements Store.
+func (s *SQLStore) DeleteSuite(ctx context.Context, suite string) error {
+	if _, err := s.db.ExecContext(ctx, s.q("DELETE FROM eval_runs WHERE suite = ?"), suite); err != nil {
+		return fmt.Errorf("delete eval runs: %w", err)
+	}
+	return nil
+}
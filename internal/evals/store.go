@@ -0,0 +1,103 @@
+package evals
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Store keeps eval runs.
+type Store interface {
+	Save(ctx context.Context, run Run) error
+	// List returns the suite's runs, newest first, optionally only those
+	// for model. Runs are returned without their case results.
+	List(ctx context.Context, suite, model string) ([]Run, error)
+	// Get returns a run with its case results.
+	Get(ctx context.Context, id string) (Run, bool, error)
+	// DeleteSuite removes every run of suite.
+	DeleteSuite(ctx context.Context, suite string) error
+}
+
+// DefaultMaxRunsPerSuite is how many runs a MemoryStore, by default, and a
+// SQLStore keep per suite.
+const DefaultMaxRunsPerSuite = 100
+
+// MemoryStore keeps the most recent runs of each suite in memory. Runs do not
+// survive a restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	maxRuns int
+	runs    map[string][]Run // by suite, oldest first
+}
+
+// NewMemoryStore returns a MemoryStore keeping up to maxRunsPerSuite runs of
+// each suite; zero or less uses DefaultMaxRunsPerSuite.
+func NewMemoryStore(maxRunsPerSuite int) *MemoryStore {
+	if maxRunsPerSuite <= 0 {
+		maxRunsPerSuite = DefaultMaxRunsPerSuite
+	}
+	return &MemoryStore{maxRuns: maxRunsPerSuite, runs: make(map[string][]Run)}
+}
+
+// Save implements Store. Once a suite holds the maximum, its oldest run is
+// dropped.
+func (s *MemoryStore) Save(_ context.Context, run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := append(s.runs[run.Suite], run)
+	if len(runs) > s.maxRuns {
+		runs = slices.Delete(runs, 0, len(runs)-s.maxRuns)
+	}
+	s.runs[run.Suite] = runs
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, suite, model string) ([]Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runs := s.runs[suite]
+	result := make([]Run, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		if model == "" || runs[i].Model == model {
+			result = append(result, runs[i].Summary())
+		}
+	}
+	return result, nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (Run, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, runs := range s.runs {
+		for _, run := range runs {
+			if run.ID == id {
+				return run, true, nil
+			}
+		}
+	}
+	return Run{}, false, nil
+}
+
+// DeleteSuite implements Store.
+func (s *MemoryStore) DeleteSuite(_ context.Context, suite string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.runs, suite)
+	return nil
+}
+
+// Latest reduces runs, newest first as List returns them, to the newest run
+// of each model, in the order the models first appear.
+func Latest(runs []Run) []Run {
+	seen := make(map[string]bool)
+	latest := make([]Run, 0)
+	for _, run := range runs {
+		if !seen[run.Model] {
+			seen[run.Model] = true
+			latest = append(latest, run)
+		}
+	}
+	return latest
+}
@@ -26,6 +26,38 @@ type HookEvent struct {
 	Cost                    models.CostResult
 	Timestamp               time.Time
 	IncludeExtendedCostKeys bool
+	// CacheStatus is the response cache's verdict ("hit" or "miss"), or empty
+	// when no cache plugin handled the request.
+	CacheStatus string
+
+	// KeyID, KeyName, and ExpiresAt describe the API key of a key lifecycle
+	// event; KeyID is empty on request events. A zero ExpiresAt is a key that
+	// never expires. Reason says why a quota event fired.
+	KeyID     string
+	KeyName   string
+	ExpiresAt time.Time
+	Reason    string
+
+	// SLO describes the burn rate of an SLO event; nil on other events.
+	SLO *SLOBurn
+}
+
+// SLOBurn is the state of one SLO indicator when its burn rate crosses the
+// alerting threshold.
+type SLOBurn struct {
+	// Name is the SLO's key in the config, usually a model alias.
+	Name string
+	// Indicator is "latency" or "error".
+	Indicator string
+	// Provider served the request whose outcome crossed the threshold, when
+	// known.
+	Provider   string
+	BurnRate   float64
+	Threshold  float64
+	Attainment float64
+	Objective  float64
+	Window     time.Duration
+	Requests   int
 }
 
 // FailedRequest builds the internal hook payload for a failed request.
@@ -65,8 +97,60 @@ func CompletedRequest(traceID, provider, model string, latency time.Duration, st
 	}
 }
 
+// KeyEvent builds the hook payload for an API key lifecycle event, such as a
+// key nearing or passing its expiry, or being created or revoked.
+func KeyEvent(subject, keyID, keyName string, expiresAt time.Time) HookEvent {
+	return HookEvent{
+		Subject:   subject,
+		KeyID:     keyID,
+		KeyName:   keyName,
+		ExpiresAt: expiresAt,
+		Timestamp: time.Now(),
+	}
+}
+
+// SLOEvent builds the hook payload for an SLO burn-rate threshold crossing.
+func SLOEvent(subject string, burn SLOBurn) HookEvent {
+	return HookEvent{
+		Subject:   subject,
+		Provider:  burn.Provider,
+		Model:     burn.Name,
+		SLO:       &burn,
+		Timestamp: time.Now(),
+	}
+}
+
 // Map materializes the event into the public hook payload shape.
 func (e HookEvent) Map() map[string]any {
+	if s := e.SLO; s != nil {
+		return map[string]any{
+			"slo":                 s.Name,
+			"indicator":           s.Indicator,
+			"provider":            s.Provider,
+			"burn_rate":           s.BurnRate,
+			"burn_rate_threshold": s.Threshold,
+			"attainment":          s.Attainment,
+			"objective":           s.Objective,
+			"window_seconds":      s.Window.Seconds(),
+			"requests":            s.Requests,
+			"timestamp":           e.Timestamp,
+		}
+	}
+	if e.KeyID != "" {
+		m := map[string]any{
+			"key_id":     e.KeyID,
+			"key_name":   e.KeyName,
+			"expires_at": nil,
+			"timestamp":  e.Timestamp,
+		}
+		if !e.ExpiresAt.IsZero() {
+			m["expires_at"] = e.ExpiresAt
+		}
+		if e.Reason != "" {
+			m["reason"] = e.Reason
+		}
+		return m
+	}
 	if e.Error != "" {
 		return map[string]any{
 			"trace_id":   e.TraceID,
@@ -80,7 +164,7 @@ func (e HookEvent) Map() map[string]any {
 		}
 	}
 
-	size := 16
+	size := 17
 	if e.IncludeExtendedCostKeys {
 		size += 3
 	}
@@ -101,6 +185,9 @@ func (e HookEvent) Map() map[string]any {
 	data["cost_reasoning_usd"] = e.Cost.ReasoningUSD
 	data["cost_model_found"] = e.Cost.ModelFound
 	data["timestamp"] = e.Timestamp
+	if e.CacheStatus != "" {
+		data["cache_status"] = e.CacheStatus
+	}
 
 	if e.IncludeExtendedCostKeys {
 		data["cost_image_usd"] = e.Cost.ImageUSD
@@ -25,3 +25,32 @@ func TestHookEventMap_Size(t *testing.T) {
 		t.Fatalf("len(Map()) = %d, want 19", len(got))
 	}
 }
+
+func TestHookEventMap_CacheStatus(t *testing.T) {
+	event := CompletedRequest("trace-123", "openai", "gpt-4o", time.Millisecond, false, 1, 1, models.CostResult{}, false)
+	if _, ok := event.Map()["cache_status"]; ok {
+		t.Fatal("cache_status present without a cache verdict")
+	}
+
+	event.CacheStatus = "hit"
+	if got := event.Map()["cache_status"]; got != "hit" {
+		t.Fatalf("cache_status = %v, want hit", got)
+	}
+}
+
+func TestHookEventMap_KeyEvent(t *testing.T) {
+	expires := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	got := KeyEvent("gateway.key.expiring", "key-1", "ci", expires).Map()
+	if len(got) != 4 || got["key_id"] != "key-1" || got["key_name"] != "ci" || got["expires_at"] != expires {
+		t.Fatalf("Map() = %v", got)
+	}
+}
+
+func TestHookEventMap_KeyEventWithoutExpiry(t *testing.T) {
+	event := KeyEvent("gateway.key.quota_exceeded", "key-1", "ci", time.Time{})
+	event.Reason = "budget exceeded"
+	got := event.Map()
+	if len(got) != 5 || got["expires_at"] != nil || got["reason"] != "budget exceeded" {
+		t.Fatalf("Map() = %v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package experiments
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Comparison scores a candidate answer against the primary's.
+type Comparison struct {
+	// LengthDelta is the candidate's length relative to the primary's, in
+	// characters: 0.25 means a quarter longer, -0.5 half as long.
+	LengthDelta float64 `json:"length_delta"`
+	// Similarity is the cosine similarity of the two answers' embeddings,
+	// nil when no embedder is configured or embedding failed.
+	Similarity *float64 `json:"similarity,omitempty"`
+	// JSON is the structural diff of the two answers, nil unless both are
+	// JSON.
+	JSON *JSONDiff `json:"json,omitempty"`
+}
+
+// JSONDiff compares the shape of two JSON documents: the set of paths each
+// holds and the type of value at every path. Array elements share one path,
+// so a longer list of the same shape is not a difference.
+type JSONDiff struct {
+	// Missing counts paths of the primary the candidate lacks.
+	Missing int `json:"missing"`
+	// Extra counts paths of the candidate the primary lacks.
+	Extra int `json:"extra"`
+	// TypeMismatches counts shared paths whose value types differ.
+	TypeMismatches int `json:"type_mismatches"`
+	// Match is the share of all paths present in both with the same type,
+	// from 0 to 1.
+	Match float64 `json:"match"`
+}
+
+// lengthDelta returns the relative length difference of candidate to
+// primary. An empty primary counts as one character, so the delta stays
+// finite.
+func lengthDelta(primary, candidate string) float64 {
+	p := utf8.RuneCountInString(primary)
+	c := utf8.RuneCountInString(candidate)
+	return float64(c-p) / float64(max(p, 1))
+}
+
+// cosine returns the cosine similarity of a and b, or false when they
+// differ in length or either is all zeros.
+func cosine(a, b []float64) (float64, bool) {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0, false
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0, false
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb)), true
+}
+
+// diffJSON returns the structural diff of two answers, or nil unless both
+// parse as a JSON object or array. A surrounding Markdown code fence is
+// ignored, since models often wrap JSON answers in one.
+func diffJSON(primary, candidate string) *JSONDiff {
+	p, ok := jsonShape(primary)
+	if !ok {
+		return nil
+	}
+	c, ok := jsonShape(candidate)
+	if !ok {
+		return nil
+	}
+	var d JSONDiff
+	same := 0
+	for path, pt := range p {
+		ct, ok := c[path]
+		switch {
+		case !ok:
+			d.Missing++
+		case ct != pt:
+			d.TypeMismatches++
+		default:
+			same++
+		}
+	}
+	for path := range c {
+		if _, ok := p[path]; !ok {
+			d.Extra++
+		}
+	}
+	d.Match = float64(same) / float64(same+d.Missing+d.Extra+d.TypeMismatches)
+	return &d
+}
+
+// jsonShape parses s and maps each path in it to the type of its value.
+func jsonShape(s string) (map[string]string, bool) {
+	s = strings.TrimSpace(s)
+	if rest, ok := strings.CutPrefix(s, "```"); ok {
+		// Drop the fence's language tag line, then the closing fence.
+		_, rest, _ = strings.Cut(rest, "\n")
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	if s == "" || (s[0] != '{' && s[0] != '[') {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, false
+	}
+	shape := make(map[string]string)
+	walkJSON("$", v, shape)
+	return shape, true
+}
+
+func walkJSON(path string, v any, shape map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		shape[path] = "object"
+		for k, child := range v {
+			walkJSON(path+"."+k, child, shape)
+		}
+	case []any:
+		shape[path] = "array"
+		for _, child := range v {
+			walkJSON(path+"[]", child, shape)
+		}
+	case string:
+		shape[path] = "string"
+	case float64:
+		shape[path] = "number"
+	case bool:
+		shape[path] = "boolean"
+	default:
+		shape[path] = "null"
+	}
+}
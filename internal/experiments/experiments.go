@@ -0,0 +1,167 @@
+// Package experiments scores the answer pairs that experiment shadow traffic
+// collects — a candidate model's answer against the primary model's to the
+// same request — and aggregates the scores into a per-experiment report.
+//
+// Experiments are defined in the gateway config (experiments); the gateway
+// sends the shadow requests and hands each pair to a Recorder.
+package experiments
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Embedder embeds text for the similarity score. *aigateway.Gateway
+// implements it.
+type Embedder interface {
+	Embed(ctx context.Context, req providers.EmbeddingRequest) (*providers.EmbeddingResponse, error)
+}
+
+// Report aggregates an experiment's compared pairs. Means are over the
+// pairs that carry the score: similarity needs an embedder, and the JSON
+// match needs both answers to be JSON.
+type Report struct {
+	Experiment string `json:"experiment"`
+	Primary    string `json:"primary"`
+	Candidate  string `json:"candidate"`
+	// Samples counts compared pairs.
+	Samples int `json:"samples"`
+	// Errors counts shadow requests that failed, leaving no pair.
+	Errors              int     `json:"errors"`
+	MeanLengthDelta     float64 `json:"mean_length_delta"`
+	SimilaritySamples   int     `json:"similarity_samples"`
+	MeanSimilarity      float64 `json:"mean_similarity"`
+	JSONSamples         int     `json:"json_samples"`
+	MeanJSONMatch       float64 `json:"mean_json_match"`
+	MeanShadowLatencyMs float64 `json:"mean_shadow_latency_ms"`
+	// Last is the most recent pair's comparison.
+	Last      *Comparison `json:"last,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at,omitzero"`
+}
+
+// Recorder scores experiment samples and keeps a running report for each
+// experiment. Reports are held in memory and do not survive a restart.
+type Recorder struct {
+	embedder Embedder
+
+	mu      sync.Mutex
+	reports map[string]*tally
+}
+
+// tally is a report's running sums.
+type tally struct {
+	primary, candidate string
+
+	samples, errors                  int
+	lengthDelta                      float64
+	similaritySamples, jsonSamples   int
+	similarity, jsonMatch, latencyMs float64
+	last                             *Comparison
+	updated                          time.Time
+}
+
+// NewRecorder returns a Recorder that embeds answers through embedder. A nil
+// embedder skips similarity scores.
+func NewRecorder(embedder Embedder) *Recorder {
+	return &Recorder{embedder: embedder, reports: make(map[string]*tally)}
+}
+
+// Observe scores sample and adds it to its experiment's report. It matches
+// aigateway.ExperimentObserverFunc.
+func (r *Recorder) Observe(ctx context.Context, sample aigateway.ExperimentSample) {
+	e := sample.Experiment
+	var cmp *Comparison
+	if sample.Err == nil {
+		cmp = r.compare(ctx, e, sample.PrimaryOutput, sample.CandidateOutput)
+	} else {
+		logging.FromContext(ctx).Warn("experiment shadow request failed",
+			"experiment", e.ID, "model", sample.Shadowed, "error", sample.Err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.reports[e.ID]
+	if t == nil || t.primary != e.Primary || t.candidate != e.Candidate {
+		// A new experiment, or one whose models changed: earlier pairs
+		// compared different models, so they no longer count.
+		t = &tally{primary: e.Primary, candidate: e.Candidate}
+		r.reports[e.ID] = t
+	}
+	t.updated = time.Now()
+	if cmp == nil {
+		t.errors++
+		return
+	}
+	t.samples++
+	t.lengthDelta += cmp.LengthDelta
+	t.latencyMs += float64(sample.ShadowLatency.Microseconds()) / 1000
+	if cmp.Similarity != nil {
+		t.similaritySamples++
+		t.similarity += *cmp.Similarity
+	}
+	if cmp.JSON != nil {
+		t.jsonSamples++
+		t.jsonMatch += cmp.JSON.Match
+	}
+	t.last = cmp
+}
+
+// compare scores one pair of answers.
+func (r *Recorder) compare(ctx context.Context, e aigateway.Experiment, primary, candidate string) *Comparison {
+	cmp := &Comparison{
+		LengthDelta: lengthDelta(primary, candidate),
+		JSON:        diffJSON(primary, candidate),
+	}
+	if r.embedder == nil || e.Embedder == "" {
+		return cmp
+	}
+	resp, err := r.embedder.Embed(ctx, providers.EmbeddingRequest{
+		Model: e.Embedder,
+		Input: []string{primary, candidate},
+	})
+	if err != nil || len(resp.Data) != 2 {
+		logging.FromContext(ctx).Warn("experiment embedding failed",
+			"experiment", e.ID, "embedder", e.Embedder, "error", err)
+		return cmp
+	}
+	a, b := resp.Data[0], resp.Data[1]
+	if a.Index > b.Index {
+		a, b = b, a
+	}
+	if sim, ok := cosine(a.Embedding, b.Embedding); ok {
+		cmp.Similarity = &sim
+	}
+	return cmp
+}
+
+// Report returns e's report. An experiment with no recorded pairs, or whose
+// models changed since the last one, reports zero samples.
+func (r *Recorder) Report(e aigateway.Experiment) Report {
+	rep := Report{Experiment: e.ID, Primary: e.Primary, Candidate: e.Candidate}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.reports[e.ID]
+	if t == nil || t.primary != e.Primary || t.candidate != e.Candidate {
+		return rep
+	}
+	rep.Samples, rep.Errors = t.samples, t.errors
+	rep.SimilaritySamples, rep.JSONSamples = t.similaritySamples, t.jsonSamples
+	rep.UpdatedAt = t.updated
+	rep.Last = t.last
+	if t.samples > 0 {
+		rep.MeanLengthDelta = t.lengthDelta / float64(t.samples)
+		rep.MeanShadowLatencyMs = t.latencyMs / float64(t.samples)
+	}
+	if t.similaritySamples > 0 {
+		rep.MeanSimilarity = t.similarity / float64(t.similaritySamples)
+	}
+	if t.jsonSamples > 0 {
+		rep.MeanJSONMatch = t.jsonMatch / float64(t.jsonSamples)
+	}
+	return rep
+}
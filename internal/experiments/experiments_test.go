@@ -0,0 +1,72 @@
+package experiments
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestDiffJSON(t *testing.T) {
+	d := diffJSON(`{"name":"a","tags":["x"],"n":1}`, "```json\n{\"name\":\"b\",\"tags\":[],\"n\":\"1\",\"extra\":true}\n```")
+	if d == nil {
+		t.Fatal("want a diff for two JSON answers")
+	}
+	// $.tags[] is missing, $.extra is extra, $.n changed type; $, $.name
+	// and $.tags match.
+	if d.Missing != 1 || d.Extra != 1 || d.TypeMismatches != 1 || d.Match != 0.5 {
+		t.Fatalf("diff = %+v", d)
+	}
+	if diffJSON(`{"a":1}`, "not json") != nil {
+		t.Fatal("want no diff when an answer is not JSON")
+	}
+}
+
+func TestLengthDeltaAndCosine(t *testing.T) {
+	if got := lengthDelta("abcd", "abcde"); got != 0.25 {
+		t.Fatalf("lengthDelta = %v, want 0.25", got)
+	}
+	if sim, ok := cosine([]float64{1, 0}, []float64{1, 1}); !ok || math.Abs(sim-math.Sqrt2/2) > 1e-9 {
+		t.Fatalf("cosine = %v %v", sim, ok)
+	}
+	if _, ok := cosine([]float64{0, 0}, []float64{1, 1}); ok {
+		t.Fatal("want no similarity for a zero vector")
+	}
+}
+
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) Embed(_ context.Context, req providers.EmbeddingRequest) (*providers.EmbeddingResponse, error) {
+	return &providers.EmbeddingResponse{Data: []providers.Embedding{
+		{Index: 1, Embedding: []float64{1, 0}},
+		{Index: 0, Embedding: []float64{1, 0}},
+	}}, nil
+}
+
+func TestRecorder_AggregatesSamples(t *testing.T) {
+	r := NewRecorder(fakeEmbedder{})
+	exp := aigateway.Experiment{ID: "e", Primary: "p", Candidate: "c", Embedder: "emb"}
+	ctx := context.Background()
+	r.Observe(ctx, aigateway.ExperimentSample{Experiment: exp, PrimaryOutput: "abcd", CandidateOutput: "ab"})
+	r.Observe(ctx, aigateway.ExperimentSample{Experiment: exp, PrimaryOutput: "abcd", CandidateOutput: "abcdef"})
+	r.Observe(ctx, aigateway.ExperimentSample{Experiment: exp, Err: errors.New("boom")})
+
+	rep := r.Report(exp)
+	if rep.Samples != 2 || rep.Errors != 1 {
+		t.Fatalf("samples %d errors %d, want 2 and 1", rep.Samples, rep.Errors)
+	}
+	if rep.MeanLengthDelta != 0 {
+		t.Fatalf("mean length delta = %v, want 0 (-0.5 and +0.5)", rep.MeanLengthDelta)
+	}
+	if rep.SimilaritySamples != 2 || rep.MeanSimilarity != 1 {
+		t.Fatalf("similarity %d samples, mean %v; want 2 and 1", rep.SimilaritySamples, rep.MeanSimilarity)
+	}
+
+	exp.Candidate = "c2"
+	if rep := r.Report(exp); rep.Samples != 0 {
+		t.Fatalf("report kept %d samples after the candidate changed", rep.Samples)
+	}
+}
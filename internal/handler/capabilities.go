@@ -12,11 +12,13 @@ import (
 // capability profile for each registered provider, so clients can discover
 // which OpenAI chat parameters a provider forwards, translates, or does not
 // support. Each profile maps a parameter name to "forward", "translate", or
-// "unsupported".
+// "unsupported". extra_params lists, for the providers that accept any, the
+// provider-specific parameters a request may pass through extra_body.
 func Capabilities(reg *providers.Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, _ *http.Request) {
 		names := reg.List()
 		profiles := make(map[string]map[string]string, len(names))
+		extras := make(map[string][]string)
 		for _, name := range names {
 			profile := capabilities.ProfileOf(name)
 			out := make(map[string]string, len(profile))
@@ -24,8 +26,11 @@ func Capabilities(reg *providers.Registry) http.HandlerFunc {
 				out[param] = support.String()
 			}
 			profiles[name] = out
+			if params := capabilities.ExtraParams(name); len(params) > 0 {
+				extras[name] = params
+			}
 		}
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]any{"providers": profiles})
+		_ = json.NewEncoder(w).Encode(map[string]any{"providers": profiles, "extra_params": extras})
 	}
 }
@@ -28,7 +28,8 @@ func TestCapabilities_ReturnsProfiles(t *testing.T) {
 	}
 
 	var payload struct {
-		Providers map[string]map[string]string `json:"providers"`
+		Providers   map[string]map[string]string `json:"providers"`
+		ExtraParams map[string][]string          `json:"extra_params"`
 	}
 	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
 		t.Fatalf("decode response: %v", err)
@@ -65,4 +66,11 @@ func TestCapabilities_ReturnsProfiles(t *testing.T) {
 			t.Errorf("openai profile missing canonical param %q", param)
 		}
 	}
+
+	if _, ok := payload.ExtraParams["gemini"]; ok {
+		t.Error("gemini accepts no extra_body parameters but is listed")
+	}
+	if len(payload.ExtraParams["openai"]) == 0 {
+		t.Error("extra_params missing openai's allowlist")
+	}
 }
@@ -1,74 +1,19 @@
+// Package handler provides HTTP handler functions for the OpenAI-compatible API.
+// The inference endpoints themselves live in internal/openaiapi, shared with
+// the library's aigateway.Handler.
 package handler
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
 	"net/http"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
-	"github.com/ferro-labs/ai-gateway/internal/apierror"
-	"github.com/ferro-labs/ai-gateway/internal/sse"
+	"github.com/ferro-labs/ai-gateway/internal/openaiapi"
 )
 
-// ChatCompletions handles POST /v1/chat/completions.
+// ChatCompletions handles POST /v1/chat/completions, streaming included.
 func ChatCompletions(gw *aigateway.Gateway) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		req, err := DecodeChatCompletionRequest(r.Body)
-		if err != nil {
-			var maxBytesErr *http.MaxBytesError
-			if errors.As(err, &maxBytesErr) {
-				apierror.WriteOpenAI(w, http.StatusRequestEntityTooLarge, "request body too large", "invalid_request_error", "request_too_large")
-				return
-			}
-			apierror.WriteOpenAI(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
-			return
-		}
-		if err := req.Validate(); err != nil {
-			apierror.WriteOpenAI(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
-			return
-		}
-
-		// --- Streaming path ---
-		if req.Stream {
-			if _, ok := gw.FindByModel(req.Model); !ok {
-				apierror.WriteOpenAI(w, http.StatusBadRequest, "no provider supports model: "+req.Model, "invalid_request_error", "model_not_found")
-				return
-			}
-			if _, ok := gw.FindStreamingByModel(req.Model); !ok {
-				apierror.WriteOpenAI(w, http.StatusBadRequest, "provider does not support streaming", "invalid_request_error", "streaming_not_supported")
-				return
-			}
-
-			ch, err := gw.RouteStream(r.Context(), req)
-			if err != nil {
-				status, errType, code := apierror.RouteErrorDetails(err)
-				apierror.WriteOpenAI(w, status, err.Error(), errType, code)
-				return
-			}
-			sse.Write(r.Context(), w, ch)
-			return
-		}
-
-		// --- Non-streaming path ---
-		if _, ok := gw.FindByModel(req.Model); !ok {
-			apierror.WriteOpenAI(w, http.StatusBadRequest, "no provider supports model: "+req.Model, "invalid_request_error", "model_not_found")
-			return
-		}
-
-		resp, err := gw.Route(r.Context(), req)
-		if err != nil {
-			status, errType, code := apierror.RouteErrorDetails(err)
-			apierror.WriteOpenAI(w, status, err.Error(), errType, code)
-			return
-		}
-
-		if resp.OverheadMs > 0 {
-			w.Header().Set("X-Gateway-Overhead-Ms", fmt.Sprintf("%.3f", resp.OverheadMs))
-		}
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(resp)
-	}
+	return openaiapi.ChatCompletions(gw, true)
 }
 
 // Health handles GET /health.
@@ -77,13 +22,14 @@ func Health(gw *aigateway.Gateway) http.HandlerFunc {
 		Name    string `json:"name"`
 		Status  string `json:"status"`
 		Circuit string `json:"circuit"`
+		Warmup  string `json:"warmup,omitempty"`
 		Models  int    `json:"models"`
 	}
 
 	return func(w http.ResponseWriter, _ *http.Request) {
-		circuits := make(map[string]string)
+		readiness := make(map[string]aigateway.ProviderReadiness)
 		for _, pr := range gw.Readiness().Providers {
-			circuits[pr.Name] = pr.Circuit
+			readiness[pr.Name] = pr
 		}
 		var providerStatuses []providerHealth
 		for _, name := range gw.ListProviders() {
@@ -91,7 +37,7 @@ func Health(gw *aigateway.Gateway) http.HandlerFunc {
 			if !ok {
 				continue
 			}
-			circuit := circuits[name]
+			circuit := readiness[name].Circuit
 			if circuit == "" {
 				circuit = "closed"
 			}
@@ -99,6 +45,7 @@ func Health(gw *aigateway.Gateway) http.HandlerFunc {
 				Name:    name,
 				Status:  "available",
 				Circuit: circuit,
+				Warmup:  readiness[name].Warmup,
 				Models:  len(p.Models()),
 			})
 		}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -39,28 +38,3 @@ func TestChatCompletions_BodyTooLarge_Returns413(t *testing.T) {
 		t.Errorf("expected 413, got %d (body: %s)", w.Code, w.Body.String())
 	}
 }
-
-// TestDecodeChatCompletionRequest_BodyTooLarge verifies that DecodeChatCompletionRequest
-// propagates *http.MaxBytesError when the body exceeds the MaxBytesReader limit,
-// allowing callers to map it to 413 via errors.As.
-func TestDecodeChatCompletionRequest_BodyTooLarge(t *testing.T) {
-	// Use valid-JSON-prefixed body: decoder reads the first few bytes, then hits the limit.
-	body := `{"model":"test","messages":[{"role":"user","content":"` + strings.Repeat("x", 500) + `"}]}`
-
-	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/", strings.NewReader(body))
-	w := httptest.NewRecorder()
-
-	// Wrap with a tiny limit so the reader hits it mid-JSON.
-	req.Body = http.MaxBytesReader(w, req.Body, 5)
-
-	_, err := DecodeChatCompletionRequest(req.Body)
-	if err == nil {
-		t.Fatal("expected error from oversized body, got nil")
-	}
-
-	// The handler uses errors.As to detect *http.MaxBytesError.
-	var maxBytesErr *http.MaxBytesError
-	if !errors.As(err, &maxBytesErr) {
-		t.Errorf("errors.As(*http.MaxBytesError) returned false for %T: %v", err, err)
-	}
-}
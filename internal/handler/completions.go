@@ -15,6 +15,8 @@ import (
 	"github.com/ferro-labs/ai-gateway/internal/apierror"
 	"github.com/ferro-labs/ai-gateway/internal/httpclient"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/openaiapi"
+	"github.com/ferro-labs/ai-gateway/internal/scaling"
 	"github.com/ferro-labs/ai-gateway/internal/streamio"
 	"github.com/ferro-labs/ai-gateway/providers"
 )
@@ -96,7 +98,7 @@ func Completions(registry *providers.Registry) http.HandlerFunc {
 
 		var legacyReq LegacyCompletionRequest
 		if err := json.Unmarshal(body, &legacyReq); err != nil {
-			apierror.WriteOpenAI(w, http.StatusBadRequest, "invalid request body: "+err.Error(), "invalid_request_error", "invalid_request")
+			openaiapi.WriteDecodeError(w, err)
 			return
 		}
 		if legacyReq.Model == "" {
@@ -172,7 +174,9 @@ func Completions(registry *providers.Registry) http.HandlerFunc {
 
 			var copyErr error
 			if legacyReq.Stream {
+				closed := scaling.StreamOpened()
 				_, copyErr = streamio.Copy(r.Context(), w, upstreamBody)
+				closed()
 			} else {
 				_, copyErr = io.Copy(w, upstreamBody)
 			}
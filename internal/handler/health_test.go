@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
 	mcpconfig "github.com/ferro-labs/ai-gateway/mcp"
@@ -60,6 +61,47 @@ func TestHealthStatusCodes(t *testing.T) {
 	}
 }
 
+func TestHealthReportsWarmup(t *testing.T) {
+	gw, err := newTestGateway(t, aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "health-provider"}},
+	})
+	if err != nil {
+		t.Fatalf("New gateway: %v", err)
+	}
+	gw.RegisterProvider(healthProvider{})
+
+	warmup := func() string {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/health", nil)
+		w := httptest.NewRecorder()
+		Health(gw).ServeHTTP(w, req)
+		var payload struct {
+			Providers []struct {
+				Warmup *string `json:"warmup"`
+			} `json:"providers"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode health response: %v", err)
+		}
+		if len(payload.Providers) != 1 {
+			t.Fatalf("providers = %d, want 1", len(payload.Providers))
+		}
+		if payload.Providers[0].Warmup == nil {
+			return "<absent>"
+		}
+		return *payload.Providers[0].Warmup
+	}
+
+	if got := warmup(); got != "<absent>" {
+		t.Errorf("warmup before any warm-up = %q, want the field omitted", got)
+	}
+	// healthProvider has no base URL or Warm hook, so it warms trivially.
+	gw.WarmProviders(t.Context(), time.Second)
+	if got := warmup(); got != aigateway.WarmupOK {
+		t.Errorf("warmup = %q, want %q", got, aigateway.WarmupOK)
+	}
+}
+
 func TestLivez(t *testing.T) {
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/livez", nil)
 	w := httptest.NewRecorder()
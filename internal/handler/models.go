@@ -21,6 +21,9 @@ type EnrichedModelInfo struct {
 	Capabilities    []string `json:"capabilities,omitempty"`
 	Status          string   `json:"status,omitempty"`
 	Deprecated      bool     `json:"deprecated,omitempty"`
+	// Token prices in USD per 1M tokens, from the catalog's pricing row.
+	InputPerMTokens  *float64 `json:"input_per_m_tokens,omitempty"`
+	OutputPerMTokens *float64 `json:"output_per_m_tokens,omitempty"`
 }
 
 // enrichFromCatalog builds an EnrichedModelInfo for provider/modelID by
@@ -43,6 +46,10 @@ func enrichFromCatalog(catalog models.Catalog, provider, modelID string) Enriche
 	base.Capabilities = buildCapsList(m.Capabilities)
 	base.Status = m.Lifecycle.Status
 	base.Deprecated = m.IsDeprecated()
+	if priced, ok := catalog.GetForPricing(provider + "/" + modelID); ok {
+		base.InputPerMTokens = priced.Pricing.InputPerMTokens
+		base.OutputPerMTokens = priced.Pricing.OutputPerMTokens
+	}
 	return base
 }
 
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/models"
+)
+
+func TestEnrichFromCatalog_Pricing(t *testing.T) {
+	in, out := 2.5, 10.0
+	catalog := models.Catalog{
+		"openai/gpt-4o": {
+			Provider:      "openai",
+			ModelID:       "gpt-4o",
+			Mode:          models.ModeChat,
+			ContextWindow: 128000,
+			Pricing:       models.Pricing{InputPerMTokens: &in, OutputPerMTokens: &out},
+			Capabilities:  models.Capabilities{Vision: true, Streaming: true},
+		},
+	}
+
+	got := enrichFromCatalog(catalog, "openai", "gpt-4o")
+	if got.ContextWindow != 128000 || len(got.Capabilities) != 2 {
+		t.Errorf("metadata = %+v", got)
+	}
+	if got.InputPerMTokens == nil || *got.InputPerMTokens != in || got.OutputPerMTokens == nil || *got.OutputPerMTokens != out {
+		t.Errorf("prices = %v / %v, want %v / %v", got.InputPerMTokens, got.OutputPerMTokens, in, out)
+	}
+
+	unknown := enrichFromCatalog(catalog, "openai", "not-in-catalog")
+	if unknown.InputPerMTokens != nil || unknown.OutputPerMTokens != nil {
+		t.Errorf("uncatalogued model carries prices: %+v", unknown)
+	}
+}
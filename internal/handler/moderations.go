@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"net/http"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/openaiapi"
+)
+
+// Moderations handles POST /v1/moderations.
+func Moderations(gw *aigateway.Gateway) http.HandlerFunc {
+	return openaiapi.Moderations(gw)
+}
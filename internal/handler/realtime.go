@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/proxy"
+	"github.com/ferro-labs/ai-gateway/internal/realtime"
+	"github.com/ferro-labs/ai-gateway/models"
+)
+
+// errRealtimeRefused records a session the upstream did not upgrade.
+var errRealtimeRefused = errors.New("upstream did not accept the realtime session")
+
+// Realtime handles GET /v1/realtime?model=..., the OpenAI Realtime API's
+// WebSocket endpoint. The session is routed through gw before the upgrade, to
+// the target named by X-Provider or else the one serving the model, so its
+// key's residency requirement and the targets' drain and disable state apply.
+// The connection is tunnelled with the provider's credentials in place of the
+// caller's, and every response.done event's usage is metered through gw as it
+// passes.
+func Realtime(gw *aigateway.Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, "realtime sessions require a WebSocket upgrade", "invalid_request_error", "invalid_request")
+			return
+		}
+		model := r.URL.Query().Get("model")
+		ctx := r.Context()
+		if name := r.Header.Get("X-Provider"); name != "" {
+			ctx = aigateway.WithRequestOptions(ctx, aigateway.RequestOptions{Target: name})
+		} else if model == "" {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, `the model query parameter is required (or name a provider with the X-Provider header)`, "invalid_request_error", "invalid_request")
+			return
+		}
+		p, err := gw.RealtimeProvider(ctx, model)
+		if err != nil {
+			status, errType, code := apierror.RouteErrorDetails(err)
+			apierror.WriteOpenAI(w, status, err.Error(), errType, code)
+			return
+		}
+
+		// Without compression the upstream's frames can be read for usage.
+		r.Header.Del("Sec-WebSocket-Extensions")
+
+		ctx, session := gw.StartRealtimeSession(r.Context(), p.Name(), model)
+		upgraded := false
+		proxy.Forward(w, r.WithContext(ctx), p, func(resp *http.Response) {
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				return
+			}
+			conn, isConn := resp.Body.(io.ReadWriteCloser)
+			if !isConn {
+				return
+			}
+			upgraded = true
+			resp.Body = realtime.Meter(conn, func(u realtime.Usage) {
+				session.RecordUsage(models.Usage{
+					PromptTokens:     u.InputTokens,
+					CompletionTokens: u.OutputTokens,
+					CacheReadTokens:  u.InputDetails.CachedTokens,
+				})
+			})
+		})
+		if !upgraded {
+			session.End(errRealtimeRefused)
+			return
+		}
+		session.End(nil)
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	aigateway "github.com/ferro-labs/ai-gateway"
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/providers/openai"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRealtime_TunnelsAndMetersUsage(t *testing.T) {
+	event := `{"type":"response.done","response":{"usage":{"input_tokens":7,"output_tokens":11,"total_tokens":18}}}`
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-upstream" {
+			t.Errorf("upstream Authorization = %q, want the provider's key", got)
+		}
+		if r.Header.Get("Sec-WebSocket-Extensions") != "" {
+			t.Error("compression extension forwarded upstream")
+		}
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("upstream hijack: %v", err)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		_, _ = buf.Write(append([]byte{0x81, byte(len(event))}, event...))
+		_ = buf.Flush()
+	}))
+	defer upstream.Close()
+
+	p, err := openai.New("sk-upstream", upstream.URL)
+	if err != nil {
+		t.Fatalf("openai.New: %v", err)
+	}
+	gw, err := newTestGateway(t, aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "unused"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	gw.RegisterProvider(p)
+	// The gateway knows the model now its provider is registered.
+	tokensIn := metrics.TokensInput.WithLabelValues(p.Name(), "gpt-realtime")
+	before := testutil.ToFloat64(tokensIn)
+
+	gateway := httptest.NewServer(Realtime(gw))
+	defer gateway.Close()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(t.Context(), "tcp", strings.TrimPrefix(gateway.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial gateway: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	_, _ = conn.Write([]byte("GET /v1/realtime?model=gpt-realtime HTTP/1.1\r\nHost: gateway\r\nAuthorization: Bearer client-key\r\n" +
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Extensions: permessage-deflate\r\n\r\n"))
+
+	br := bufio.NewReader(conn)
+	status, err := br.ReadString('\n')
+	if err != nil || !strings.Contains(status, "101") {
+		t.Fatalf("status = %q, err %v; want 101", strings.TrimSpace(status), err)
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read headers: %v", err)
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+	}
+	frame := make([]byte, 2+len(event))
+	if _, err := io.ReadFull(br, frame); err != nil || string(frame[2:]) != event {
+		t.Fatalf("tunnelled frame = %q, err %v", frame, err)
+	}
+
+	// The session is accounted once the upstream closes the tunnel.
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(tokensIn)-before != 7 {
+		if time.Now().After(deadline) {
+			t.Fatalf("input tokens metered = %v, want 7", testutil.ToFloat64(tokensIn)-before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRealtime_RequiresUpgradeAndModel(t *testing.T) {
+	gw, err := newTestGateway(t, aigateway.Config{
+		Strategy: aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+		Targets:  []aigateway.Target{{VirtualKey: "unused"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	h := Realtime(gw)
+
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/v1/realtime?model=gpt-realtime", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("plain GET: status %d, want 400", w.Code)
+	}
+
+	r := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/v1/realtime", nil)
+	r.Header.Set("Upgrade", "websocket")
+	w = httptest.NewRecorder()
+	h(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("no model: status %d, want 400", w.Code)
+	}
+}
+
+// A session is routed before the upgrade: a target outside the key's region,
+// or draining, is never dialled.
+func TestRealtime_RoutesBeforeUpgrade(t *testing.T) {
+	var dialled atomic.Bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { dialled.Store(true) }))
+	defer upstream.Close()
+	p, err := openai.New("sk-upstream", upstream.URL)
+	if err != nil {
+		t.Fatalf("openai.New: %v", err)
+	}
+	for name, tc := range map[string]struct {
+		target aigateway.Target
+		keyID  string
+		status int
+	}{
+		"outside the region": {aigateway.Target{VirtualKey: p.Name(), Region: "us-east"}, "key-eu", http.StatusForbidden},
+		"draining":           {aigateway.Target{VirtualKey: p.Name(), State: aigateway.TargetStateDraining}, "key-any", http.StatusNotFound},
+	} {
+		gw, err := newTestGateway(t, aigateway.Config{
+			Strategy:  aigateway.StrategyConfig{Mode: aigateway.ModeSingle},
+			Targets:   []aigateway.Target{tc.target},
+			Residency: &aigateway.ResidencyConfig{Keys: map[string]string{"key-eu": "eu"}},
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		gw.RegisterProvider(p)
+
+		for _, header := range []string{"", p.Name()} {
+			r := httptest.NewRequestWithContext(authctx.WithKeyID(t.Context(), tc.keyID), http.MethodGet, "/v1/realtime?model=gpt-realtime", nil)
+			r.Header.Set("Upgrade", "websocket")
+			r.Header.Set("X-Provider", header)
+			w := httptest.NewRecorder()
+			Realtime(gw)(w, r)
+			if w.Code != tc.status {
+				t.Errorf("%s, X-Provider %q: status %d, want %d: %s", name, header, w.Code, tc.status, w.Body.String())
+			}
+		}
+	}
+	if dialled.Load() {
+		t.Error("a refused session reached the upstream")
+	}
+}
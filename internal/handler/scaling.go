@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ferro-labs/ai-gateway/internal/scaling"
+)
+
+// Scaling handles GET /metrics/scaling. It reports this replica's load —
+// requests in flight, requests queued for admission, and open streams — as a
+// flat JSON object, the shape KEDA's metrics-api scaler reads with a
+// valueLocation such as "in_flight". q is nil when no admission queue is
+// configured.
+func Scaling(q scaling.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(scaling.Current(q))
+	}
+}
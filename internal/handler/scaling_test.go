@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScaling_ReturnsFlatJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	Scaling(nil)(w, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics/scaling", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var body map[string]float64
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v (%s)", err, w.Body)
+	}
+	for _, key := range []string{"in_flight", "queue_depth", "streaming_connections", "max_in_flight"} {
+		if _, ok := body[key]; !ok {
+			t.Errorf("missing %q in %s", key, w.Body)
+		}
+	}
+}
@@ -1,16 +1,22 @@
 package httpserver
 
 import (
+	"context"
 	"expvar"
 	"html/template"
+	"io"
 	"io/fs"
 	"net"
 	"net/http"
+	"os"
 
 	aigateway "github.com/ferro-labs/ai-gateway"
 	"github.com/ferro-labs/ai-gateway/internal/admin"
 	"github.com/ferro-labs/ai-gateway/internal/apierror"
 	"github.com/ferro-labs/ai-gateway/internal/dashboard"
+	"github.com/ferro-labs/ai-gateway/internal/deadletter"
+	"github.com/ferro-labs/ai-gateway/internal/evals"
+	"github.com/ferro-labs/ai-gateway/internal/experiments"
 	"github.com/ferro-labs/ai-gateway/internal/handler"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/middleware"
@@ -18,15 +24,34 @@ import (
 	"github.com/ferro-labs/ai-gateway/internal/proxy"
 	"github.com/ferro-labs/ai-gateway/internal/ratelimit"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+	"github.com/ferro-labs/ai-gateway/internal/routingrules"
+	"github.com/ferro-labs/ai-gateway/internal/scaling"
+	"github.com/ferro-labs/ai-gateway/internal/threads"
 	"github.com/ferro-labs/ai-gateway/internal/version"
 	"github.com/ferro-labs/ai-gateway/providers"
 	webassets "github.com/ferro-labs/ai-gateway/web"
 	"github.com/go-chi/chi/v5"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 )
 
 var loginTemplate = template.Must(template.ParseFS(webassets.Assets, "templates/login.html"))
 
+// Router is the gateway's HTTP handler. Close releases the connections of
+// the stores it built, such as the sessions Redis client.
+type Router struct {
+	http.Handler
+	threads threads.Store
+}
+
+// Close closes the thread store when it holds a connection.
+func (r *Router) Close() error {
+	if c, ok := r.threads.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // NewRouter builds the HTTP router for the gateway.
 //
 // trustedProxies lists the CIDR ranges whose X-Forwarded-For / X-Real-IP
@@ -43,7 +68,7 @@ func NewRouter(
 	logMaintainer requestlog.Maintainer,
 	masterKey string,
 	trustedProxies []*net.IPNet,
-) http.Handler {
+) *Router {
 	gw = ensureGateway(gw, registry)
 
 	r := chi.NewRouter()
@@ -104,19 +129,189 @@ func NewRouter(
 	// deprecated chi middleware.RealIP, which honored those headers
 	// unconditionally and could be exploited by a caller that controlled them.
 	app.Use(RealIPMiddleware(resolvedProxies))
-	app.Use(middleware.CORS(corsOrigins...))
+	app.Use(corsMiddleware(gw, corsOrigins))
 	// Optional per-IP rate limiting middleware.
 	if rlStore != nil {
 		app.Use(middleware.RateLimit(rlStore))
 	}
 
-	mountObservabilityRoutes(app, keyStore, masterKey)
+	admission := newAdmissionQueue(gw)
+	mountObservabilityRoutes(app, keyStore, masterKey, admission)
 	mountDashboardRoutes(app)
-	mountAdminRoutes(app, gw, keyStore, cfgManager, logReader, logMaintainer, masterKey)
-	mountOpenAIRoutes(app, gw, registry, keyStore, masterKey)
+	idempotency := newIdempotencyStore(gw)
+	threadStore := newThreadStore(gw)
+	mountAdminRoutes(app, gw, keyStore, cfgManager, logReader, logMaintainer, rlStore, masterKey, userErasers(gw, idempotency, threadStore))
+	mountOpenAIRoutes(app, gw, registry, keyStore, masterKey, admission, idempotency, threadStore)
 	r.Mount("/", app)
 
-	return r
+	return &Router{Handler: r, threads: threadStore}
+}
+
+// corsMiddleware returns the CORS middleware for the config's cors policies,
+// falling back to the flat CORS_ORIGINS allowlist when the config defines none.
+func corsMiddleware(gw *aigateway.Gateway, corsOrigins []string) func(http.Handler) http.Handler {
+	var policies []aigateway.CORSPolicy
+	if gw != nil {
+		policies = gw.GetConfig().CORS
+	}
+	if len(policies) == 0 {
+		return middleware.CORS(corsOrigins...)
+	}
+	if len(corsOrigins) > 0 {
+		logging.Logger.Warn("CORS_ORIGINS is ignored because the config defines cors policies")
+	}
+	converted := make([]middleware.CORSPolicy, 0, len(policies))
+	for _, p := range policies {
+		converted = append(converted, middleware.CORSPolicy{
+			PathPrefix:       p.PathPrefix,
+			AllowedOrigins:   p.AllowedOrigins,
+			AllowedMethods:   p.AllowedMethods,
+			AllowedHeaders:   p.AllowedHeaders,
+			ExposedHeaders:   p.ExposedHeaders,
+			AllowCredentials: p.AllowCredentials,
+			MaxAge:           p.MaxAgeDuration(),
+		})
+	}
+	return middleware.CORSWithPolicies(converted)
+}
+
+// newAdmissionQueue builds the admission queue the config's admission block
+// describes, or returns nil when it has none.
+func newAdmissionQueue(gw *aigateway.Gateway) *middleware.AdmissionQueue {
+	if gw == nil {
+		return nil
+	}
+	adm := gw.GetConfig().Admission
+	if adm == nil {
+		return nil
+	}
+	queueSize := adm.QueueSize
+	if queueSize == 0 {
+		queueSize = adm.MaxInFlight
+	}
+	return middleware.NewAdmissionQueue(adm.MaxInFlight, queueSize, adm.MaxWaitDuration())
+}
+
+// newIdempotencyStore returns the store that replays responses to requests
+// carrying an Idempotency-Key within the configured window, or nil when the
+// window is unset.
+func newIdempotencyStore(gw *aigateway.Gateway) *middleware.IdempotencyStore {
+	if gw == nil {
+		return nil
+	}
+	idem := gw.GetConfig().Idempotency
+	ttl := idem.TTLDuration()
+	if ttl <= 0 {
+		return nil
+	}
+	if idem == nil {
+		return middleware.NewIdempotencyStore(ttl, 0, 0)
+	}
+	return middleware.NewIdempotencyStore(ttl, idem.MaxEntries, idem.MaxBytes)
+}
+
+// userErasers returns the stores besides the request log and the response
+// cache that an admin erasure by user reaches.
+func userErasers(gw *aigateway.Gateway, idempotency *middleware.IdempotencyStore, threadStore threads.Store) map[string]admin.UserEraser {
+	erasers := map[string]admin.UserEraser{
+		// Threads belong to API keys and do not record the end user, so only
+		// an erasure by key_id alone reaches them.
+		"threads": admin.UserEraserFunc(func(ctx context.Context, user, keyID string) (int, error) {
+			if user != "" {
+				return 0, admin.ErrEraseUnsupported
+			}
+			return threadStore.DeleteOwner(ctx, keyID)
+		}),
+	}
+	if idempotency != nil {
+		erasers["idempotency_keys"] = idempotency
+	}
+	if gw != nil {
+		if q, ok := gw.DeadLetterSink().(*deadletter.Queue); ok {
+			erasers["dead_letters"] = q
+		}
+	}
+	return erasers
+}
+
+// newThreadStore builds the thread store the config's sessions block
+// describes: in memory unless it selects the redis store. The Redis client
+// connects lazily, so an unreachable Redis surfaces on the first thread
+// request rather than at startup.
+func newThreadStore(gw *aigateway.Gateway) threads.Store {
+	var sessions *aigateway.SessionsConfig
+	if gw != nil {
+		sessions = gw.GetConfig().Sessions
+	}
+	var limits threads.Limits
+	if sessions != nil {
+		limits = threads.Limits{
+			MessagesPerThread: sessions.MaxMessagesPerThread,
+			RunsPerThread:     sessions.MaxRunsPerThread,
+			ThreadsPerOwner:   sessions.MaxThreadsPerKey,
+		}
+	}
+	memory := func() threads.Store {
+		s := threads.NewMemoryStore(0)
+		s.SetLimits(limits)
+		return s
+	}
+	if sessions == nil || sessions.Store != aigateway.SessionStoreRedis {
+		return memory()
+	}
+	opts, err := redis.ParseURL(sessions.RedisURL)
+	if err != nil {
+		logging.Logger.Error("invalid sessions.redis_url; keeping threads in memory", "error", err)
+		return memory()
+	}
+	prefix := sessions.KeyPrefix
+	if prefix == "" {
+		prefix = aigateway.DefaultSessionKeyPrefix
+	}
+	s := threads.NewRedisStore(redis.NewClient(opts), prefix, sessions.TTLDuration())
+	s.SetLimits(limits)
+	return s
+}
+
+// evalStore keeps eval runs beside the request log when that is a SQL store,
+// so they survive a restart, and in memory otherwise.
+func evalStore(logReader requestlog.Reader) evals.Store {
+	w, ok := logReader.(*requestlog.SQLWriter)
+	if !ok {
+		return evals.NewMemoryStore(0)
+	}
+	db, dialect := w.DB()
+	s, err := evals.NewSQLStore(context.Background(), db, dialect)
+	if err != nil {
+		logging.Logger.Error("eval run store unavailable; keeping runs in memory", "error", err)
+		return evals.NewMemoryStore(0)
+	}
+	return s
+}
+
+// affinityMiddleware returns the replica affinity middleware the config's
+// sessions block describes, or nil when it has none.
+func affinityMiddleware(gw *aigateway.Gateway) func(http.Handler) http.Handler {
+	if gw == nil {
+		return nil
+	}
+	sessions := gw.GetConfig().Sessions
+	if sessions == nil || sessions.Affinity == nil {
+		return nil
+	}
+	cookie, replica := sessions.Affinity.Cookie, sessions.Affinity.ReplicaID
+	if cookie == "" {
+		cookie = aigateway.DefaultAffinityCookie
+	}
+	if replica == "" {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			logging.Logger.Error("cannot name this replica for sessions.affinity; set replica_id", "error", err)
+			return nil
+		}
+		replica = host
+	}
+	return middleware.Affinity(cookie, replica)
 }
 
 // ensureGateway returns gw if non-nil; otherwise builds a default fallback
@@ -185,11 +380,17 @@ func mountProbeRoutes(r chi.Router, gw *aigateway.Gateway, store admin.Store, cf
 
 // mountObservabilityRoutes mounts the auth-gated /metrics, /debug/vars, and
 // pprof routes.
-func mountObservabilityRoutes(r chi.Router, store admin.Store, masterKey string) {
+func mountObservabilityRoutes(r chi.Router, store admin.Store, masterKey string, admission *middleware.AdmissionQueue) {
 	obsAuth := admin.AuthMiddleware(store, masterKey)
+	// A nil *AdmissionQueue must not become a non-nil scaling.Queue.
+	var queue scaling.Queue
+	if admission != nil {
+		queue = admission
+	}
 	r.Group(func(r chi.Router) {
 		r.Use(obsAuth)
 		r.Handle("/metrics", promhttp.Handler())
+		r.Get("/metrics/scaling", handler.Scaling(queue))
 		r.Handle("/debug/vars", expvar.Handler())
 		dashboard.MountPprofRoutes(r)
 	})
@@ -227,6 +428,9 @@ func mountDashboardRoutes(r chi.Router) {
 	r.Get("/dashboard/providers", func(w http.ResponseWriter, _ *http.Request) {
 		renderPage(w, "providers", "Providers")
 	})
+	r.Get("/dashboard/routing", func(w http.ResponseWriter, _ *http.Request) {
+		renderPage(w, "routing", "Routing")
+	})
 	r.Get("/dashboard/config", func(w http.ResponseWriter, _ *http.Request) {
 		renderPage(w, "config", "Config")
 	})
@@ -258,14 +462,39 @@ func mountAdminRoutes(
 	cfgManager admin.ConfigManager,
 	logReader requestlog.Reader,
 	logMaintainer requestlog.Maintainer,
+	rlStore *ratelimit.Store,
 	masterKey string,
+	erasers map[string]admin.UserEraser,
 ) {
+	// Startup has already rejected an invalid KEY_ROTATION_OVERLAP.
+	overlap, _ := admin.KeyRotationOverlapFromEnv()
 	adminHandlers := &admin.Handlers{
 		Keys:      keyStore,
 		Providers: gw,
 		Configs:   cfgManager,
 		Logs:      logReader,
 		LogAdmin:  logMaintainer,
+
+		KeyRotationOverlap: overlap,
+		Erasers:            erasers,
+	}
+	if rlStore != nil {
+		// Assigned only when set: a nil *Store in the interface would not
+		// compare equal to nil.
+		adminHandlers.RateLimits = rlStore
+	}
+	if gw != nil {
+		adminHandlers.Plugins = gw
+		adminHandlers.Routing = gw
+		adminHandlers.Targets = gw
+		adminHandlers.Chat = gw
+		adminHandlers.KeyEvents = admin.KeyEventPublisher(gw)
+		adminHandlers.Evals = evals.NewRunner(gw, logReader, evalStore(logReader))
+		adminHandlers.Experiments = experiments.NewRecorder(gw)
+		gw.SetExperimentObserver(adminHandlers.Experiments.Observe)
+		if q, ok := gw.DeadLetterSink().(*deadletter.Queue); ok {
+			adminHandlers.DeadLetters = q
+		}
 	}
 
 	// Apply the same body-size cap to admin write routes.
@@ -283,7 +512,7 @@ func mountAdminRoutes(
 	})
 }
 
-func mountOpenAIRoutes(r chi.Router, gw *aigateway.Gateway, registry *providers.Registry, store admin.Store, masterKey string) {
+func mountOpenAIRoutes(r chi.Router, gw *aigateway.Gateway, registry *providers.Registry, store admin.Store, masterKey string, admission *middleware.AdmissionQueue, idempotency *middleware.IdempotencyStore, threadStore threads.Store) {
 	auth := middleware.ProxyAuth(store, masterKey)
 
 	// Determine the body-size cap: use the operator's config or the safe default.
@@ -295,22 +524,53 @@ func mountOpenAIRoutes(r chi.Router, gw *aigateway.Gateway, registry *providers.
 	}
 
 	r.Group(func(r chi.Router) {
+		if affinity := affinityMiddleware(gw); affinity != nil {
+			r.Use(affinity)
+		}
 		r.Use(auth)
 		r.Use(middleware.MaxRequestBody(maxBytes))
+		r.Use(middleware.Idempotency(idempotency))
+
+		// Reads answered from the gateway's own state skip the admission
+		// queue.
 		r.Get("/v1/models", handler.Models(gw))
 		r.Get("/v1/capabilities", handler.Capabilities(registry))
-		r.Post("/v1/chat/completions", handler.ChatCompletions(gw))
 
-		// Legacy text completions.
-		r.Post("/v1/completions", handler.Completions(registry))
+		r.Group(func(r chi.Router) {
+			// The admission queue runs after idempotency so replayed
+			// responses never wait for a slot.
+			r.Use(middleware.Admission(admission))
+			r.Use(scaling.Middleware)
+			r.Use(routingrules.Middleware)
+			mountProviderRoutes(r, gw, registry, threadStore)
+		})
+	})
+}
 
-		// Embeddings endpoint.
-		r.Post("/v1/embeddings", handler.Embeddings(gw))
+// mountProviderRoutes registers the /v1 endpoints that can reach a provider,
+// whatever their method, so the admission queue gates all of them.
+func mountProviderRoutes(r chi.Router, gw *aigateway.Gateway, registry *providers.Registry, threadStore threads.Store) {
+	r.Post("/v1/chat/completions", handler.ChatCompletions(gw))
 
-		// Image generation endpoint.
-		r.Post("/v1/images/generations", handler.Images(gw))
+	// Legacy text completions.
+	r.Post("/v1/completions", handler.Completions(registry))
 
-		// Proxy pass-through for unhandled /v1/* endpoints.
-		r.HandleFunc("/v1/*", proxy.Handler(registry))
-	})
+	// Embeddings endpoint.
+	r.Post("/v1/embeddings", handler.Embeddings(gw))
+
+	// Image generation endpoint.
+	r.Post("/v1/images/generations", handler.Images(gw))
+
+	// Moderation endpoint.
+	r.Post("/v1/moderations", handler.Moderations(gw))
+
+	// Realtime WebSocket sessions, metered as they are tunnelled.
+	r.Get("/v1/realtime", handler.Realtime(gw))
+
+	// Assistants-style threads, kept where the config's sessions block
+	// says and run through normal routing.
+	r.Mount("/v1/threads", (&threads.Handlers{Gateway: gw, Store: threadStore}).Routes())
+
+	// Proxy pass-through for unhandled /v1/* endpoints.
+	r.HandleFunc("/v1/*", proxy.Handler(registry, gw.CheckPassthrough))
 }
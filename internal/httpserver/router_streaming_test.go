@@ -44,6 +44,7 @@ func buildProxyTestRouter(t *testing.T, upstreamURL string) http.Handler {
 		t.Fatalf("build openai provider: %v", err)
 	}
 	reg.Register(p)
+	gw.RegisterProvider(p)
 
 	return httpserver.NewRouter(reg, admin.NewKeyStore(), nil, gw, nil, nil, nil, nil, "", nil)
 }
@@ -0,0 +1,211 @@
+// Package jsonstream guarantees that a streamed JSON-mode completion
+// (response_format json_object or json_schema) concatenates to one valid JSON
+// value.
+//
+// Each choice's content is checked byte by byte as it arrives and forwarded as
+// soon as it is known to be a valid prefix, so nothing is buffered beyond the
+// opening markdown fence some models wrap JSON in, which is stripped. Text
+// after the value closes — a closing fence, or prose — is dropped. Content
+// that cannot continue a valid value, or a stream that ends before the value
+// is complete, ends the stream with an ErrInvalidJSON error instead.
+package jsonstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// ErrInvalidJSON is the stream error for JSON-mode output that is not valid
+// JSON. It describes the model's output, not the health of the provider.
+var ErrInvalidJSON = errors.New("model output is not valid JSON")
+
+// Wants reports whether rf asks for JSON output.
+func Wants(rf *providers.ResponseFormat) bool {
+	return rf != nil && (rf.Type == "json_object" || rf.Type == "json_schema")
+}
+
+// choiceState tracks one streamed choice.
+type choiceState struct {
+	scan    scanner
+	started bool   // the value's first byte has been seen
+	pending string // leading text held back while it may be a fence
+	done    bool   // the value is complete; later content is dropped
+}
+
+// Stream forwards src, validating each choice's content as JSON. After a
+// validation failure no further content is forwarded, but src is still read
+// to the end so the final usage chunk is not lost: it rides on the error
+// chunk that closes the stream.
+func Stream(ctx context.Context, src <-chan providers.StreamChunk) <-chan providers.StreamChunk {
+	out := make(chan providers.StreamChunk)
+	go func() {
+		defer close(out)
+		states := make(map[int]*choiceState)
+		var (
+			last   providers.StreamChunk
+			usage  *providers.Usage
+			failed error
+		)
+		for chunk := range src {
+			last = chunk
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if failed != nil || chunk.Error != nil {
+				if chunk.Error != nil && failed == nil {
+					failed = chunk.Error
+				}
+				continue
+			}
+			filtered, err := filter(chunk, states)
+			if err != nil {
+				failed = err
+				continue
+			}
+			if len(filtered.Choices) == 0 && filtered.Usage == nil {
+				continue
+			}
+			select {
+			case out <- filtered:
+			case <-ctx.Done():
+				//nolint:revive // empty-block: consuming the remaining chunks IS the work
+				for range src {
+				}
+				return
+			}
+		}
+		if failed == nil {
+			failed = finish(states)
+		}
+		if failed == nil {
+			return
+		}
+		select {
+		case out <- providers.StreamChunk{
+			ID:      last.ID,
+			Object:  last.Object,
+			Created: last.Created,
+			Model:   last.Model,
+			Usage:   usage,
+			Error:   failed,
+		}:
+		case <-ctx.Done():
+		}
+	}()
+	return out
+}
+
+// filter validates one chunk's content, returning the chunk with only the
+// validated text left in each delta.
+func filter(chunk providers.StreamChunk, states map[int]*choiceState) (providers.StreamChunk, error) {
+	if len(chunk.Choices) == 0 {
+		return chunk, nil
+	}
+	choices := make([]providers.StreamChoice, 0, len(chunk.Choices))
+	for _, c := range chunk.Choices {
+		st := states[c.Index]
+		if st == nil {
+			st = &choiceState{}
+			states[c.Index] = st
+		}
+		hadContent := c.Delta.Content != ""
+		text, err := st.feed(c.Delta.Content)
+		if err != nil {
+			return chunk, fmt.Errorf("%w: choice %d: %v", ErrInvalidJSON, c.Index, err)
+		}
+		c.Delta.Content = text
+		if hadContent && text == "" && c.Delta.Role == "" && c.Delta.ReasoningContent == "" &&
+			len(c.Delta.ToolCalls) == 0 && c.FinishReason == "" {
+			continue // everything this choice carried is held back or dropped
+		}
+		choices = append(choices, c)
+	}
+	chunk.Choices = choices
+	return chunk, nil
+}
+
+// finish checks that every choice that produced content produced a complete
+// value.
+func finish(states map[int]*choiceState) error {
+	for idx, st := range states {
+		if !st.started && strings.TrimSpace(st.pending) == "" {
+			continue // no content, e.g. a tool-call-only choice
+		}
+		if err := st.end(); err != nil {
+			return fmt.Errorf("%w: choice %d: %v", ErrInvalidJSON, idx, err)
+		}
+	}
+	return nil
+}
+
+// feed consumes the next piece of a choice's content and returns the part
+// that may be forwarded.
+func (st *choiceState) feed(text string) (string, error) {
+	if st.done || text == "" {
+		return "", nil
+	}
+	if !st.started {
+		text = st.pending + text
+		st.pending = ""
+		body, held, err := stripFence(text)
+		if err != nil || held {
+			st.pending = text
+			return "", err
+		}
+		if body == "" {
+			return "", nil
+		}
+		text = body
+		st.started = true
+	}
+	for i := 0; i < len(text); i++ {
+		done, err := st.scan.step(text[i])
+		if err != nil {
+			return "", err
+		}
+		if done {
+			st.done = true
+			if st.scan.after {
+				return text[:i], nil
+			}
+			return text[:i+1], nil
+		}
+	}
+	return text, nil
+}
+
+// end reports whether the choice's value was complete.
+func (st *choiceState) end() error {
+	if st.done {
+		return nil
+	}
+	if !st.started {
+		return errors.New("no JSON value")
+	}
+	return st.scan.end()
+}
+
+// stripFence drops leading whitespace and an opening markdown fence line
+// (```json) from the start of a choice's content. held reports that text may
+// still be the start of a fence and must wait for more.
+func stripFence(text string) (body string, held bool, err error) {
+	text = strings.TrimLeft(text, " \t\r\n")
+	if !strings.HasPrefix(text, "`") {
+		return text, false, nil
+	}
+	line, rest, found := strings.Cut(text, "\n")
+	if !strings.HasPrefix("```", line) && !strings.HasPrefix(line, "```") {
+		return "", false, errors.New("unexpected '`' before the JSON value")
+	}
+	if !found {
+		return "", true, nil
+	}
+	if !strings.HasPrefix(line, "```") {
+		return "", false, errors.New("unexpected '`' before the JSON value")
+	}
+	return strings.TrimLeft(rest, " \t\r\n"), false, nil
+}
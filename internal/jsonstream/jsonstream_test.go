@@ -0,0 +1,164 @@
+package jsonstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func feed(chunks ...providers.StreamChunk) <-chan providers.StreamChunk {
+	ch := make(chan providers.StreamChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func content(idx int, text string) providers.StreamChunk {
+	return providers.StreamChunk{ID: "c", Choices: []providers.StreamChoice{{
+		Index: idx,
+		Delta: providers.MessageDelta{Content: text},
+	}}}
+}
+
+// split streams text as one content chunk per piece of size n.
+func split(text string, n int) []providers.StreamChunk {
+	var chunks []providers.StreamChunk
+	for len(text) > n {
+		chunks = append(chunks, content(0, text[:n]))
+		text = text[n:]
+	}
+	return append(chunks, content(0, text))
+}
+
+// collect drains out and returns choice 0's text and the stream error.
+func collect(out <-chan providers.StreamChunk) (string, []providers.StreamChunk, error) {
+	var (
+		b      strings.Builder
+		err    error
+		chunks []providers.StreamChunk
+	)
+	for c := range out {
+		chunks = append(chunks, c)
+		for _, choice := range c.Choices {
+			b.WriteString(choice.Delta.Content)
+		}
+		if c.Error != nil {
+			err = c.Error
+		}
+	}
+	return b.String(), chunks, err
+}
+
+func TestStream_ValidDocuments(t *testing.T) {
+	docs := []string{
+		`{}`,
+		`[]`,
+		`{"a":1,"b":[true,false,null],"c":{"d":"e\"\\é"}}`,
+		` [ -0.5e+10 , 0 , 12.25E-3 , "x" ] `,
+		`"just a string"`,
+		`{"nested":[[{}],[[]]],"n":-12}`,
+	}
+	for _, doc := range docs {
+		for n := 1; n <= len(doc); n++ {
+			got, _, err := collect(Stream(context.Background(), feed(split(doc, n)...)))
+			if err != nil {
+				t.Fatalf("%q in %d-byte chunks: unexpected error %v", doc, n, err)
+			}
+			if got != strings.TrimSpace(doc) {
+				t.Fatalf("%q in %d-byte chunks: got %q", doc, n, got)
+			}
+		}
+	}
+}
+
+func TestStream_StripsFencesAndTrailingText(t *testing.T) {
+	tests := map[string]string{
+		"```json\n{\"a\":1}\n```":           `{"a":1}`,
+		"```\n[1,2]\n```\n":                 `[1,2]`,
+		"  \n```json\n42\n```":              `42`,
+		"{\"ok\":true}\n\nHope this helps!": `{"ok":true}`,
+		"```JSON\r\n{\"x\":\"```\"}\r\n```": `{"x":"` + "```" + `"}`,
+	}
+	for text, want := range tests {
+		for n := 1; n <= len(text); n++ {
+			got, _, err := collect(Stream(context.Background(), feed(split(text, n)...)))
+			if err != nil {
+				t.Fatalf("%q in %d-byte chunks: unexpected error %v", text, n, err)
+			}
+			if got != want {
+				t.Fatalf("%q in %d-byte chunks: got %q, want %q", text, n, got, want)
+			}
+		}
+	}
+}
+
+func TestStream_InvalidOutputEndsWithError(t *testing.T) {
+	usage := &providers.Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12}
+	tests := map[string]string{
+		"truncated":       `{"a":[1,2`,
+		"prose first":     `Here you go: {"a":1}`,
+		"bad literal":     `{"a":tru}`,
+		"missing comma":   `{"a":1 "b":2}`,
+		"trailing comma":  `[1,2,]`,
+		"leading zero":    `[01]`,
+		"bad escape":      `["\x"]`,
+		"lone fence tick": "`{}",
+		"unclosed fence":  "```json",
+	}
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			chunks := append(split(text, 3), providers.StreamChunk{ID: "c", Usage: usage})
+			got, out, err := collect(Stream(context.Background(), feed(chunks...)))
+			if !errors.Is(err, ErrInvalidJSON) {
+				t.Fatalf("want ErrInvalidJSON, got %v (text %q)", err, got)
+			}
+			if last := out[len(out)-1]; last.Usage == nil || last.Usage.TotalTokens != 12 {
+				t.Fatalf("the error chunk should carry the final usage, got %+v", last.Usage)
+			}
+		})
+	}
+}
+
+func TestStream_PassesThroughUpstreamErrorsAndToolCalls(t *testing.T) {
+	upstream := errors.New("upstream reset")
+	_, _, err := collect(Stream(context.Background(), feed(content(0, `{"a":`), providers.StreamChunk{Error: upstream})))
+	if !errors.Is(err, upstream) {
+		t.Fatalf("want the upstream error, got %v", err)
+	}
+
+	toolCall := providers.StreamChunk{Choices: []providers.StreamChoice{{
+		Delta:        providers.MessageDelta{ToolCalls: []providers.ToolCall{{ID: "call_1"}}},
+		FinishReason: "tool_calls",
+	}}}
+	_, out, err := collect(Stream(context.Background(), feed(toolCall)))
+	if err != nil || len(out) != 1 {
+		t.Fatalf("tool-call-only stream: err %v, %d chunks", err, len(out))
+	}
+}
+
+func TestScanner_AgreesWithEncodingJSON(t *testing.T) {
+	inputs := []string{
+		`{"a":1}`, `{"a":}`, `{,}`, `[1,]`, `{"a" 1}`, `-`, `-0`, `1.`, `1.5e`, `1e+5`,
+		`"😀"`, `"\u12"`, "\"tab\there\"", `nul`, `[[[]]`, `{"a":{"b":[]}}`,
+		`{"k":"v",}`, `0.0`, `-01`, `[1 2]`, `"`, `{"a":"b"}}`,
+	}
+	for _, in := range inputs {
+		var st choiceState
+		_, err := st.feed(in)
+		if err == nil {
+			err = st.end()
+		}
+		// Text after a complete value is dropped rather than rejected, so
+		// compare against encoding/json on the value's own extent only.
+		want := json.Valid([]byte(in))
+		if got := err == nil; got != want && !(got && st.done) {
+			t.Errorf("%q: valid = %v, encoding/json says %v (err %v)", in, got, want, err)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package jsonstream
+
+import (
+	"errors"
+	"fmt"
+)
+
+// scanState is where the scanner is within the JSON grammar.
+type scanState int
+
+const (
+	stValue      scanState = iota // expecting a value
+	stValueOrEnd                  // after '[': a value or ']'
+	stKeyOrEnd                    // after '{': a key or '}'
+	stKey                         // after ',' in an object: a key
+	stColon                       // after a key
+	stAfterValue                  // after a value inside an object or array
+	stString                      // inside a string
+	stEscape                      // after '\' in a string
+	stUnicode                     // inside a \uXXXX escape
+	stLiteral                     // inside true, false, or null
+	stNumber                      // inside a number
+)
+
+// numState is where the scanner is within a number:
+// -? (0 | [1-9][0-9]*) (. [0-9]+)? ([eE] [+-]? [0-9]+)?
+type numState int
+
+const (
+	numMinus   numState = iota // after '-'
+	numZero                    // after a leading 0
+	numInt                     // in the integer digits
+	numDot                     // after '.'
+	numFrac                    // in the fraction digits
+	numExp                     // after 'e'
+	numExpSign                 // after the exponent's sign
+	numExpInt                  // in the exponent digits
+)
+
+// scanner checks JSON one byte at a time. The zero value expects a value.
+type scanner struct {
+	state   scanState
+	stack   []byte // open '{' and '[' containers
+	isKey   bool   // the current string is an object key
+	hex     int    // \u digits still to read
+	literal string // the remaining bytes of the current literal
+	num     numState
+	after   bool // the byte that completed the value is not part of it
+}
+
+// step consumes c and reports whether it completed the top-level value.
+func (s *scanner) step(c byte) (bool, error) {
+	switch s.state {
+	case stString:
+		switch {
+		case c == '"':
+			return s.endString(), nil
+		case c == '\\':
+			s.state = stEscape
+		case c < 0x20:
+			return false, errors.New("control character in string")
+		}
+		return false, nil
+	case stEscape:
+		switch c {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			s.state = stString
+		case 'u':
+			s.state, s.hex = stUnicode, 4
+		default:
+			return false, fmt.Errorf("invalid escape '\\%c'", c)
+		}
+		return false, nil
+	case stUnicode:
+		if !isHex(c) {
+			return false, fmt.Errorf("invalid character %q in \\u escape", c)
+		}
+		if s.hex--; s.hex == 0 {
+			s.state = stString
+		}
+		return false, nil
+	case stLiteral:
+		if c != s.literal[0] {
+			return false, fmt.Errorf("invalid character %q in literal", c)
+		}
+		if s.literal = s.literal[1:]; s.literal == "" {
+			return s.endValue(), nil
+		}
+		return false, nil
+	case stNumber:
+		if s.stepNumber(c) {
+			return false, nil
+		}
+		if !s.numberComplete() {
+			return false, fmt.Errorf("invalid character %q in number", c)
+		}
+		if len(s.stack) == 0 {
+			// A top-level number ends at the first byte that is not part
+			// of it.
+			s.after = true
+			return true, nil
+		}
+		s.state = stAfterValue
+		return s.step(c)
+	}
+
+	if isSpace(c) {
+		return false, nil
+	}
+	switch s.state {
+	case stValue, stValueOrEnd:
+		if c == ']' && s.state == stValueOrEnd {
+			return s.closeContainer(), nil
+		}
+		return false, s.beginValue(c)
+	case stKeyOrEnd, stKey:
+		if c == '}' && s.state == stKeyOrEnd {
+			return s.closeContainer(), nil
+		}
+		if c != '"' {
+			return false, fmt.Errorf("invalid character %q, want an object key", c)
+		}
+		s.state, s.isKey = stString, true
+		return false, nil
+	case stColon:
+		if c != ':' {
+			return false, fmt.Errorf("invalid character %q after an object key", c)
+		}
+		s.state = stValue
+		return false, nil
+	case stAfterValue:
+		top := s.stack[len(s.stack)-1]
+		switch {
+		case c == ',' && top == '{':
+			s.state = stKey
+		case c == ',':
+			s.state = stValue
+		case c == '}' && top == '{', c == ']' && top == '[':
+			return s.closeContainer(), nil
+		default:
+			return false, fmt.Errorf("invalid character %q after a value", c)
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid character %q", c)
+}
+
+// end reports whether the input so far is a complete value.
+func (s *scanner) end() error {
+	if s.state == stNumber && len(s.stack) == 0 && s.numberComplete() {
+		return nil
+	}
+	return errors.New("unexpected end of JSON input")
+}
+
+func (s *scanner) beginValue(c byte) error {
+	switch {
+	case c == '{':
+		s.stack = append(s.stack, '{')
+		s.state = stKeyOrEnd
+	case c == '[':
+		s.stack = append(s.stack, '[')
+		s.state = stValueOrEnd
+	case c == '"':
+		s.state, s.isKey = stString, false
+	case c == 't':
+		s.state, s.literal = stLiteral, "rue"
+	case c == 'f':
+		s.state, s.literal = stLiteral, "alse"
+	case c == 'n':
+		s.state, s.literal = stLiteral, "ull"
+	case c == '-':
+		s.state, s.num = stNumber, numMinus
+	case c == '0':
+		s.state, s.num = stNumber, numZero
+	case c >= '1' && c <= '9':
+		s.state, s.num = stNumber, numInt
+	default:
+		return fmt.Errorf("invalid character %q, want a value", c)
+	}
+	return nil
+}
+
+// stepNumber advances the number state, reporting false when c cannot
+// continue the number.
+func (s *scanner) stepNumber(c byte) bool {
+	digit := c >= '0' && c <= '9'
+	switch s.num {
+	case numMinus:
+		switch {
+		case c == '0':
+			s.num = numZero
+		case digit:
+			s.num = numInt
+		default:
+			return false
+		}
+	case numZero, numInt:
+		switch {
+		case digit && s.num == numInt:
+		case c == '.':
+			s.num = numDot
+		case c == 'e' || c == 'E':
+			s.num = numExp
+		default:
+			return false
+		}
+	case numDot, numFrac:
+		switch {
+		case digit:
+			s.num = numFrac
+		case (c == 'e' || c == 'E') && s.num == numFrac:
+			s.num = numExp
+		default:
+			return false
+		}
+	case numExp:
+		switch {
+		case c == '+' || c == '-':
+			s.num = numExpSign
+		case digit:
+			s.num = numExpInt
+		default:
+			return false
+		}
+	case numExpSign, numExpInt:
+		if !digit {
+			return false
+		}
+		s.num = numExpInt
+	}
+	return true
+}
+
+func (s *scanner) numberComplete() bool {
+	return s.num == numZero || s.num == numInt || s.num == numFrac || s.num == numExpInt
+}
+
+func (s *scanner) endString() bool {
+	if s.isKey {
+		s.state, s.isKey = stColon, false
+		return false
+	}
+	return s.endValue()
+}
+
+// endValue moves past a finished value, reporting whether it was the
+// top-level one.
+func (s *scanner) endValue() bool {
+	if len(s.stack) == 0 {
+		return true
+	}
+	s.state = stAfterValue
+	return false
+}
+
+func (s *scanner) closeContainer() bool {
+	s.stack = s.stack[:len(s.stack)-1]
+	return s.endValue()
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\r' || c == '\n' }
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -86,8 +86,14 @@ func Enabled(ctx context.Context, level slog.Level) bool {
 // because it runs above the layer that puts the trace ID on the context.
 const RequestIDHeader = "X-Request-ID"
 
+// TraceIDHeader carries the request's trace ID back to the client under a
+// gateway-specific name, so it survives proxies that rewrite X-Request-ID and
+// can be matched against the trace_id of request log entries.
+const TraceIDHeader = "X-Ferro-Trace-Id"
+
 // Middleware injects a trace ID into every request context and echoes it in
-// the X-Request-ID response header. Resolution precedence:
+// the X-Request-ID and X-Ferro-Trace-Id response headers. Resolution
+// precedence:
 //  1. A trace ID already present on the request context (e.g. seeded by
 //     internal/otel.Middleware after extracting an inbound W3C traceparent).
 //  2. The inbound X-Request-ID header.
@@ -107,6 +113,7 @@ func Middleware(next http.Handler) http.Handler {
 		}
 		ctx := WithTraceID(r.Context(), traceID)
 		w.Header().Set(RequestIDHeader, traceID)
+		w.Header().Set(TraceIDHeader, traceID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -90,6 +90,9 @@ func TestMiddleware_PropagatesProvidedHeader(t *testing.T) {
 	if got := rr.Header().Get("X-Request-ID"); got != traceID {
 		t.Errorf("response X-Request-ID = %q, want %q", got, traceID)
 	}
+	if got := rr.Header().Get("X-Ferro-Trace-Id"); got != traceID {
+		t.Errorf("response X-Ferro-Trace-Id = %q, want %q", got, traceID)
+	}
 }
 
 // TestMiddleware_ReusesContextTraceID asserts the unification protocol:
@@ -14,7 +14,6 @@ import (
 	"github.com/ferro-labs/ai-gateway/observability"
 	"github.com/ferro-labs/ai-gateway/providers/core"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -46,29 +45,30 @@ func mcpTracer() trace.Tracer {
 // on failure. Implementations must be non-blocking.
 type AuditFn func(ctx context.Context, serverName, toolName, status string, latencyMs int, errMsg string)
 
-// Prometheus metrics — registered once at program start.
+// Prometheus metrics — registered once at program start, alongside the
+// gateway metrics so they follow metrics.SetRegisterer.
 var (
-	metricToolCallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	metricToolCallsTotal = metrics.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "ferrogw",
 		Subsystem: "mcp",
 		Name:      "tool_calls_total",
 		Help:      "Total number of MCP tool calls made.",
-	}, []string{"server_name", "tool_name", "status"})
+	}, []string{"server_name", "tool_name", "status"}))
 
-	metricToolCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	metricToolCallDuration = metrics.Register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "ferrogw",
 		Subsystem: "mcp",
 		Name:      "tool_call_duration_seconds",
 		Help:      "Latency of individual MCP tool calls in seconds.",
 		Buckets:   prometheus.DefBuckets,
-	}, []string{"server_name", "tool_name"})
+	}, []string{"server_name", "tool_name"}))
 
-	metricUnknownToolCallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	metricUnknownToolCallsTotal = metrics.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "ferrogw",
 		Subsystem: "mcp",
 		Name:      "unknown_tool_calls_total",
 		Help:      "Tool calls for tools not found in any registered MCP server.",
-	}, []string{"tool_name"})
+	}, []string{"tool_name"}))
 )
 
 // Executor runs the agentic tool-call loop on top of a Registry.
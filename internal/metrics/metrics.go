@@ -1,13 +1,18 @@
 // Package metrics registers the Prometheus metrics used by the gateway.
 // Import this package (via blank import) from the server entry point to
 // register all metrics before the /metrics handler is mounted.
+//
+// The collectors register with prometheus.DefaultRegisterer by default. An
+// application embedding the gateway that already exposes metrics of its own
+// can move them to another registry, under a name prefix, with
+// SetRegisterer.
 package metrics
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 // UnknownModelLabel is the bounded label used for rejected requests whose model is unknown.
@@ -23,163 +28,461 @@ const UnknownToolLabel = "unknown"
 var (
 	// RequestsTotal counts completed requests labelled by provider, model, and
 	// outcome ("success", "error", "rejected").
-	RequestsTotal = promauto.NewCounterVec(
+	RequestsTotal = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_requests_total",
 			Help: "Total number of requests processed by the gateway.",
 		},
 		[]string{"provider", "model", "status"},
-	)
+	))
 
 	// RequestDuration observes end-to-end request latency in seconds.
-	RequestDuration = promauto.NewHistogramVec(
+	RequestDuration = Register(prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "gateway_request_duration_seconds",
 			Help:    "End-to-end request duration in seconds.",
 			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
 		},
 		[]string{"provider", "model"},
-	)
+	))
 
 	// TokensInput counts total prompt tokens sent to providers.
-	TokensInput = promauto.NewCounterVec(
+	TokensInput = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_tokens_input_total",
 			Help: "Total prompt tokens sent to providers.",
 		},
 		[]string{"provider", "model"},
-	)
+	))
 
 	// TokensOutput counts total completion tokens received from providers.
-	TokensOutput = promauto.NewCounterVec(
+	TokensOutput = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_tokens_output_total",
 			Help: "Total completion tokens received from providers.",
 		},
 		[]string{"provider", "model"},
-	)
+	))
 
 	// ProviderErrors counts errors broken down by provider and error type
 	// ("provider_error", "circuit_open", "timeout").
-	ProviderErrors = promauto.NewCounterVec(
+	ProviderErrors = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_provider_errors_total",
 			Help: "Total provider errors by type.",
 		},
 		[]string{"provider", "error_type"},
-	)
+	))
 
 	// ProviderInitFailures counts providers whose factory failed at startup. A
 	// failure is warned-and-skipped so one bad credential cannot stop the
 	// gateway, which makes this counter the only machine-readable signal that a
 	// configured provider is missing. Alert on any non-zero value.
-	ProviderInitFailures = promauto.NewCounterVec(
+	ProviderInitFailures = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_provider_init_failures_total",
 			Help: "Total providers skipped because their factory failed at startup.",
 		},
 		[]string{"provider"},
-	)
+	))
 
 	// CircuitBreakerState tracks per-provider circuit breaker state as a gauge:
 	// 0 = closed, 1 = open, 2 = half_open.
-	CircuitBreakerState = promauto.NewGaugeVec(
+	CircuitBreakerState = Register(prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gateway_circuit_breaker_state",
 			Help: "Circuit breaker state per provider (0=closed 1=open 2=half_open).",
 		},
 		[]string{"provider"},
-	)
+	))
 
 	// MCPServerInitFailures counts MCP servers whose initialize handshake or
 	// tool discovery failed. A failure is logged and skipped so one unreachable
 	// server cannot stop the gateway, which makes this counter the only
 	// machine-readable signal that a configured MCP server never came up.
 	// Alert on any non-zero value.
-	MCPServerInitFailures = promauto.NewCounterVec(
+	MCPServerInitFailures = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_mcp_server_init_failures_total",
 			Help: "Total MCP server initializations that failed.",
 		},
 		[]string{"server_name"},
-	)
+	))
 
 	// MCPServerUp tracks per-MCP-server availability as a gauge:
 	// 0 = not ready (never initialized, or its transport has since died),
 	// 1 = ready and advertising tools. A server that drops from 1 to 0 without
 	// a config change has lost its transport — for stdio, its subprocess died.
-	MCPServerUp = promauto.NewGaugeVec(
+	MCPServerUp = Register(prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gateway_mcp_server_up",
 			Help: "MCP server availability (0=not ready 1=ready).",
 		},
 		[]string{"server_name"},
-	)
+	))
 
 	// RateLimitRejections counts requests rejected by the rate-limit middleware
 	// or plugin, labelled by key_type ("ip", "api_key", "plugin").
-	RateLimitRejections = promauto.NewCounterVec(
+	RateLimitRejections = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_rate_limit_rejections_total",
 			Help: "Total requests rejected by rate limiting.",
 		},
 		[]string{"key_type"},
-	)
+	))
+
+	// RequestBodyTooLargeTotal counts requests whose body ran past the
+	// configured size limit (max_request_bytes / MAX_REQUEST_BODY_BYTES) and
+	// were rejected with 413.
+	RequestBodyTooLargeTotal = Register(prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_request_body_too_large_total",
+			Help: "Total requests rejected for an oversize request body.",
+		},
+	))
+
+	// UpstreamTransientRetriesTotal counts provider requests re-sent by the
+	// transport layer after a connection reset, refusal, or EOF before any
+	// response. These retries do not use a routing strategy's attempts.
+	UpstreamTransientRetriesTotal = Register(prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_upstream_transient_retries_total",
+			Help: "Total upstream requests re-sent after a transient connection error.",
+		},
+	))
+
+	// RateLimitDecisions counts every decision the rate-limit plugin makes,
+	// labelled by limiter ("global", "api_key", "user"), backend ("memory",
+	// "redis"), and decision ("allowed", "denied", "error").
+	RateLimitDecisions = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_rate_limit_decisions_total",
+			Help: "Total rate-limit plugin decisions by limiter, backend, and outcome.",
+		},
+		[]string{"limiter", "backend", "decision"},
+	))
 
 	// RequestCostUSD tracks the estimated cumulative cost of requests in USD,
 	// labelled by provider and model. Uses public pricing tables; actual costs
 	// may differ.
-	RequestCostUSD = promauto.NewCounterVec(
+	RequestCostUSD = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_request_cost_usd_total",
 			Help: "Estimated total cost of requests in USD (based on public pricing tables).",
 		},
 		[]string{"provider", "model"},
-	)
+	))
 
 	// ServerConnectionsCurrent gauges current inbound HTTP connections, labelled
 	// by connection state ("active", "idle").
-	ServerConnectionsCurrent = promauto.NewGaugeVec(
+	ServerConnectionsCurrent = Register(prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gateway_server_connections_current",
 			Help: "Current inbound HTTP connections by state.",
 		},
 		[]string{"state"},
-	)
+	))
 
 	// ServerConnectionTransitionsTotal counts inbound HTTP connection state
 	// transitions, labelled by the same state values emitted by
 	// internal/httpserver's connStateLabel (e.g. "new", "active", "idle",
 	// "hijacked", "closed", "unknown").
-	ServerConnectionTransitionsTotal = promauto.NewCounterVec(
+	ServerConnectionTransitionsTotal = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_server_connection_transitions_total",
 			Help: "Total inbound HTTP connection state transitions.",
 		},
 		[]string{"state"},
-	)
+	))
 
 	// HookEventsDroppedTotal counts hook dispatches dropped because the hook
 	// worker queue was full, labelled by hook subject.
-	HookEventsDroppedTotal = promauto.NewCounterVec(
+	HookEventsDroppedTotal = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_hook_events_dropped_total",
 			Help: "Total hook dispatches dropped because the hook worker queue was full.",
 		},
 		[]string{"subject"},
-	)
+	))
+
+	// RequestLogDroppedTotal counts request log entries the request log store
+	// discarded, labelled by reason ("queue_full" when its write queue was
+	// full, "write_error" when the batch holding them failed to commit).
+	RequestLogDroppedTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_request_log_dropped_total",
+			Help: "Total request log entries dropped by the request log store, by reason.",
+		},
+		[]string{"reason"},
+	))
+
+	// ProviderResponseAnomaliesTotal counts provider responses that broke an
+	// invariant clients rely on, labelled by provider and anomaly (see the
+	// gateway's response validation). Counted whether the response was passed
+	// on or rejected.
+	ProviderResponseAnomaliesTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_provider_response_anomalies_total",
+			Help: "Total malformed provider responses by provider and anomaly.",
+		},
+		[]string{"provider", "anomaly"},
+	))
 
 	// CatalogLoadsTotal counts model catalog load attempts, labelled by source
 	// ("remote", "fallback") and result ("success", "error").
-	CatalogLoadsTotal = promauto.NewCounterVec(
+	CatalogLoadsTotal = Register(prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gateway_catalog_loads_total",
 			Help: "Total model catalog load attempts by source and result.",
 		},
 		[]string{"source", "result"},
-	)
+	))
+
+	// RealtimeSessionsActive tracks the realtime WebSocket sessions currently
+	// proxied to each provider.
+	RealtimeSessionsActive = Register(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_realtime_sessions_active",
+			Help: "Realtime sessions currently proxied, by provider.",
+		},
+		[]string{"provider"},
+	))
+
+	// RealtimeSessionDuration observes how long realtime sessions stay open.
+	RealtimeSessionDuration = Register(prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gateway_realtime_session_duration_seconds",
+			Help:    "Realtime session duration in seconds.",
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+		},
+		[]string{"provider", "model"},
+	))
+
+	// RequestsInFlight gauges the data-plane requests being served, the load
+	// signal /metrics/scaling reports for autoscalers.
+	RequestsInFlight = Register(prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_requests_in_flight",
+			Help: "Data-plane requests currently being served.",
+		},
+	))
+
+	// StreamsOpen gauges the streaming responses currently open.
+	StreamsOpen = Register(prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_streams_open",
+			Help: "Streaming responses currently open.",
+		},
+	))
+
+	// StreamSlotsInUse gauges the streams holding a concurrent-stream slot,
+	// by the rate-limit tier of their key (empty for keys without one, which
+	// hold a slot only when the gateway-wide limit is set).
+	StreamSlotsInUse = Register(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_stream_slots_in_use",
+			Help: "Streams currently holding a concurrent-stream slot, by rate-limit tier.",
+		},
+		[]string{"tier"},
+	))
+
+	// SLOAttainment gauges the share of good requests over each SLO's
+	// rolling window, by SLO name and indicator ("latency" or "error").
+	SLOAttainment = Register(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_slo_attainment",
+			Help: "Share of good requests over the SLO window, by SLO and indicator.",
+		},
+		[]string{"slo", "indicator"},
+	))
+
+	// SLOBurnRate gauges how fast each SLO spends its error budget: 1 spends
+	// exactly the budget over the window, 2 twice as fast.
+	SLOBurnRate = Register(prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_slo_burn_rate",
+			Help: "Error-budget burn rate over the SLO window, by SLO and indicator.",
+		},
+		[]string{"slo", "indicator"},
+	))
+
+	// StreamLimitRejectionsTotal counts streams refused with 429 because
+	// every slot was taken, by the limit that refused them ("key" for a
+	// tier's per-key cap, "gateway" for the gateway-wide one).
+	StreamLimitRejectionsTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_stream_limit_rejections_total",
+			Help: "Total streams rejected by a concurrent-stream limit, by scope.",
+		},
+		[]string{"scope"},
+	))
+
+	// ContextTruncationsTotal counts chat requests shortened to fit their
+	// model's context window, by truncation policy.
+	ContextTruncationsTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_context_truncations_total",
+			Help: "Total requests truncated to fit the model's context window, by policy.",
+		},
+		[]string{"policy"},
+	))
+
+	// ImageLimitActionsTotal counts inline request images over the configured
+	// limits, by what was done about them: downscaled or rejected.
+	ImageLimitActionsTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_image_limit_actions_total",
+			Help: "Total inline request images over the size limits, by action (downscaled, rejected).",
+		},
+		[]string{"action"},
+	))
+
+	// EmbeddingDimensionMismatchesTotal counts embedding responses whose
+	// vectors did not have their model's configured dimension, by provider and
+	// by what was done about it: rejected, padded, or truncated.
+	EmbeddingDimensionMismatchesTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_embedding_dimension_mismatches_total",
+			Help: "Total embedding responses with vectors of the wrong dimension, by provider and action.",
+		},
+		[]string{"provider", "action"},
+	))
+
+	// AdmissionInFlight gauges the requests holding an admission slot.
+	AdmissionInFlight = Register(prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_admission_in_flight",
+			Help: "Requests currently admitted by the admission queue.",
+		},
+	))
+
+	// AdmissionQueueDepth gauges the requests waiting for an admission slot.
+	AdmissionQueueDepth = Register(prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_admission_queue_depth",
+			Help: "Requests currently waiting in the admission queue.",
+		},
+	))
+
+	// AdmissionQueueWait observes how long queued requests waited, labelled
+	// by result ("admitted", "timeout", "cancelled"). Requests admitted
+	// without queueing are not observed.
+	AdmissionQueueWait = Register(prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gateway_admission_queue_wait_seconds",
+			Help:    "Time requests spent in the admission queue in seconds.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"result"},
+	))
+
+	// AdmissionRejectionsTotal counts requests turned away with 503 by the
+	// admission queue, labelled by reason ("queue_full", "timeout").
+	AdmissionRejectionsTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_admission_rejections_total",
+			Help: "Total requests rejected by the admission queue, by reason.",
+		},
+		[]string{"reason"},
+	))
+
+	// PreviousKeyRequests counts requests authenticated with an API key's
+	// previous secret during a rotation overlap, by key ID, so operators can
+	// see which credentials are still in use before they expire.
+	PreviousKeyRequests = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_api_key_previous_secret_requests_total",
+			Help: "Requests authenticated with a rotated API key's previous secret, by key ID.",
+		},
+		[]string{"key_id"},
+	))
+
+	// AliasRequestsTotal counts requests for aliases with canaries by the
+	// model version each resolved to, showing how the alias's traffic is
+	// split. The request metrics, labelled by the serving model, carry each
+	// version's latency and errors.
+	AliasRequestsTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_alias_requests_total",
+			Help: "Requests for aliases with canaries, by alias and resolved model version.",
+		},
+		[]string{"alias", "model"},
+	))
+
+	// ModelFallbacksTotal counts requests served by a model from the
+	// client's fallback list rather than the model it asked for first,
+	// labelled by the requested and the serving model.
+	ModelFallbacksTotal = Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_model_fallbacks_total",
+			Help: "Requests served by a client-supplied fallback model, by requested and serving model.",
+		},
+		[]string{"requested_model", "model"},
+	))
 )
 
+var (
+	registryMu sync.Mutex
+	// registerer is where the collectors are currently registered, already
+	// wrapped with the prefix; nil when they are registered nowhere.
+	registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	collectors []prometheus.Collector
+)
+
+// Register adds c to the gateway's collectors and registers it with the
+// current registerer, panicking on a name collision as promauto does. It is
+// meant for package-level collector declarations, so collectors defined
+// outside this package follow SetRegisterer too.
+func Register[C prometheus.Collector](c C) C {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registerer != nil {
+		registerer.MustRegister(c)
+	}
+	collectors = append(collectors, c)
+	return c
+}
+
+// SetRegisterer moves every gateway collector from its current registerer to
+// reg, prepending prefix to each metric name ("myapp_" turns
+// gateway_requests_total into myapp_gateway_requests_total). A nil reg
+// unregisters them everywhere: they keep counting but are not exported.
+//
+// Recorded values carry over. If a collector cannot be registered with reg,
+// typically because reg already has a metric of that name, SetRegisterer
+// returns the error and leaves every collector where it was.
+func SetRegisterer(reg prometheus.Registerer, prefix string) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	next := reg
+	if reg != nil && prefix != "" {
+		next = prometheus.WrapRegistererWithPrefix(prefix, reg)
+	}
+	if registerer != nil {
+		for _, c := range collectors {
+			registerer.Unregister(c)
+		}
+	}
+	if next != nil {
+		for i, c := range collectors {
+			if err := next.Register(c); err != nil {
+				for _, done := range collectors[:i] {
+					next.Unregister(done)
+				}
+				if registerer != nil {
+					for _, c := range collectors {
+						_ = registerer.Register(c)
+					}
+				}
+				return fmt.Errorf("register gateway metrics: %w", err)
+			}
+		}
+	}
+	registerer = next
+	return nil
+}
+
 // RequestMetricHandles stores cached Prometheus handles for a provider/model
 // pair so hot-path metric updates avoid repeated vector lookups.
 type RequestMetricHandles struct {
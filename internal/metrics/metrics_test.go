@@ -229,3 +229,68 @@ func TestProviderInitFailures_Observable(t *testing.T) {
 		t.Fatalf("init failure delta = %v, want 1", delta)
 	}
 }
+
+// TestSetRegisterer_MovesCollectors moves the collectors to a private registry
+// under a prefix and back, confirming each move takes every collector along and
+// keeps recorded values.
+func TestSetRegisterer_MovesCollectors(t *testing.T) {
+	const provider = "moved-provider"
+	labels := map[string]string{"provider": provider}
+	ProviderInitFailures.WithLabelValues(provider).Inc()
+
+	reg := prometheus.NewRegistry()
+	if err := SetRegisterer(reg, "embed_"); err != nil {
+		t.Fatalf("SetRegisterer: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := SetRegisterer(prometheus.DefaultRegisterer, ""); err != nil {
+			t.Fatalf("restore default registerer: %v", err)
+		}
+	})
+
+	if m := gatherMetric(t, "gateway_provider_init_failures_total", labels); m != nil {
+		t.Error("collector still registered with the default registry after the move")
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather private registry: %v", err)
+	}
+	var found bool
+	for _, mf := range mfs {
+		if mf.GetName() == "embed_gateway_provider_init_failures_total" {
+			for _, m := range mf.GetMetric() {
+				found = found || (labelsMatch(m, labels) && m.GetCounter().GetValue() == 1)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("embed_gateway_provider_init_failures_total not exported by the private registry with its recorded value")
+	}
+
+	if err := SetRegisterer(prometheus.DefaultRegisterer, ""); err != nil {
+		t.Fatalf("move back: %v", err)
+	}
+	if got := gatheredCounter(t, "gateway_provider_init_failures_total", labels); got != 1 {
+		t.Fatalf("value after moving back = %v, want 1", got)
+	}
+}
+
+// TestSetRegisterer_CollisionLeavesCollectors asserts a registry that already
+// has one of the gateway's metric names is refused without moving anything.
+func TestSetRegisterer_CollisionLeavesCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_requests_total",
+		Help: "An application's own metric of the same name.",
+	}))
+	if err := SetRegisterer(reg, ""); err == nil {
+		t.Fatal("SetRegisterer succeeded despite a name collision")
+	}
+
+	const provider = "collision-provider"
+	labels := map[string]string{"provider": provider}
+	ProviderInitFailures.WithLabelValues(provider).Inc()
+	if got := gatheredCounter(t, "gateway_provider_init_failures_total", labels); got != 1 {
+		t.Fatalf("default registry value = %v, want 1: collectors did not stay put", got)
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+)
+
+// AccessLogConfig configures the HTTP access log.
+type AccessLogConfig struct {
+	// Output receives one JSON line per logged request.
+	Output io.Writer
+	// SampleRate is the fraction of requests logged, from 0 to 1. Values
+	// outside (0, 1) log every request. Server errors (5xx) are always
+	// logged regardless of the rate.
+	SampleRate float64
+}
+
+// AccessLog returns middleware that writes an HTTP access log: one JSON line
+// per request with its method, path, status, latency, response bytes, API key
+// ID, and trace ID. It is separate from the request log, which records
+// completions; the access log covers every route, including the admin API
+// and probes.
+//
+// It is meant to wrap the whole router, so it reads the trace ID back off the
+// X-Request-ID response header set by logging.Middleware, and the key ID
+// through an authctx.KeyIDRecorder, both of which are set below it.
+func AccessLog(cfg AccessLogConfig) func(http.Handler) http.Handler {
+	logger := slog.New(slog.NewJSONHandler(cfg.Output, nil))
+	rate := cfg.SampleRate
+	if rate <= 0 || rate > 1 {
+		rate = 1
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx, rec := authctx.WithKeyIDRecorder(r.Context())
+			aw := &accessWriter{ResponseWriter: w}
+			defer func() {
+				status := aw.status
+				if status == 0 {
+					// Nothing was written: net/http sends 200 for a handler
+					// that returns.
+					status = http.StatusOK
+				}
+				if status < http.StatusInternalServerError && rate < 1 && rand.Float64() >= rate { //nolint:gosec // G404: math/rand is fine for access-log sampling, not security-sensitive
+					return
+				}
+				logger.LogAttrs(context.Background(), slog.LevelInfo, "access",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", status),
+					slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+					slog.Int64("bytes", aw.bytes),
+					slog.String("key_id", rec.KeyID()),
+					slog.String("trace_id", aw.Header().Get(logging.RequestIDHeader)),
+				)
+			}()
+			next.ServeHTTP(aw, r.WithContext(ctx))
+		})
+	}
+}
+
+// accessWriter records the status and body size of a response. Like
+// committedWriter, it implements Unwrap so http.NewResponseController still
+// reaches the real writer's Flush, Hijack, and SetWriteDeadline.
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *accessWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
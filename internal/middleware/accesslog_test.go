@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+)
+
+func TestAccessLog_WritesJSONLine(t *testing.T) {
+	var out bytes.Buffer
+	handler := AccessLog(AccessLogConfig{Output: &out})(logging.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Authentication happens below the access log, on a derived context.
+		_ = authctx.WithKeyID(r.Context(), "key-1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions?x=1", nil)
+	req.Header.Set(logging.RequestIDHeader, "trace-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line struct {
+		Msg     string  `json:"msg"`
+		Method  string  `json:"method"`
+		Path    string  `json:"path"`
+		Status  int     `json:"status"`
+		Latency float64 `json:"latency_ms"`
+		Bytes   int64   `json:"bytes"`
+		KeyID   string  `json:"key_id"`
+		TraceID string  `json:"trace_id"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("access log line is not JSON: %v: %s", err, out.String())
+	}
+	if line.Msg != "access" || line.Method != http.MethodPost || line.Path != "/v1/chat/completions" ||
+		line.Status != http.StatusCreated || line.Bytes != 5 || line.KeyID != "key-1" || line.TraceID != "trace-1" {
+		t.Fatalf("unexpected access log line: %+v", line)
+	}
+}
+
+func TestAccessLog_DefaultsStatusTo200(t *testing.T) {
+	var out bytes.Buffer
+	handler := AccessLog(AccessLogConfig{Output: &out})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if !strings.Contains(out.String(), `"status":200`) {
+		t.Fatalf("expected status 200, got %s", out.String())
+	}
+}
+
+func TestAccessLog_SamplingKeepsServerErrors(t *testing.T) {
+	var out bytes.Buffer
+	status := http.StatusOK
+	handler := AccessLog(AccessLogConfig{Output: &out, SampleRate: 1e-9})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	for range 100 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	if out.Len() != 0 {
+		t.Fatalf("a near-zero sample rate should drop successful requests, got %s", out.String())
+	}
+
+	status = http.StatusBadGateway
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(out.String(), `"status":502`) {
+		t.Fatalf("server errors should always be logged, got %q", out.String())
+	}
+}
+
+func TestAccessLog_PreservesFlusher(t *testing.T) {
+	var out bytes.Buffer
+	handler := AccessLog(AccessLogConfig{Output: &out})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush through access log writer: %v", err)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+)
+
+// QueueWaitHeader reports, on admitted requests, how many milliseconds the
+// request waited in the admission queue.
+const QueueWaitHeader = "X-Ferro-Queue-Wait-Ms"
+
+// AdmissionQueue bounds how many requests are served at once. Requests beyond
+// the limit wait, in arrival order, in a bounded queue for a limited time, so
+// that when providers are saturated the gateway sheds load with a prompt 503
+// instead of piling up requests until clients time out.
+type AdmissionQueue struct {
+	slots    chan struct{} // capacity == max in-flight requests
+	maxQueue int64
+	maxWait  time.Duration
+
+	queued atomic.Int64
+}
+
+// NewAdmissionQueue returns a queue admitting maxInFlight requests at once with
+// up to queueSize more waiting at most maxWait each.
+func NewAdmissionQueue(maxInFlight, queueSize int, maxWait time.Duration) *AdmissionQueue {
+	return &AdmissionQueue{
+		slots:    make(chan struct{}, maxInFlight),
+		maxQueue: int64(queueSize),
+		maxWait:  maxWait,
+	}
+}
+
+// Capacity returns the number of requests admitted at once.
+func (q *AdmissionQueue) Capacity() int { return cap(q.slots) }
+
+// InFlight returns the number of requests currently admitted.
+func (q *AdmissionQueue) InFlight() int { return len(q.slots) }
+
+// Queued returns the number of requests currently waiting for a slot.
+func (q *AdmissionQueue) Queued() int { return int(q.queued.Load()) }
+
+// admission outcomes of acquire.
+const (
+	admitted = iota
+	rejectedFull
+	rejectedTimeout
+	cancelled
+)
+
+// acquire takes a slot, waiting up to maxWait for one. It reports how long
+// the request waited alongside the outcome.
+func (q *AdmissionQueue) acquire(ctx context.Context) (int, time.Duration) {
+	select {
+	case q.slots <- struct{}{}:
+		metrics.AdmissionInFlight.Inc()
+		return admitted, 0
+	default:
+	}
+
+	if q.queued.Add(1) > q.maxQueue {
+		q.queued.Add(-1)
+		return rejectedFull, 0
+	}
+	metrics.AdmissionQueueDepth.Inc()
+	start := time.Now()
+	timer := time.NewTimer(q.maxWait)
+	defer func() {
+		timer.Stop()
+		q.queued.Add(-1)
+		metrics.AdmissionQueueDepth.Dec()
+	}()
+
+	outcome, result := admitted, "admitted"
+	select {
+	case q.slots <- struct{}{}:
+		metrics.AdmissionInFlight.Inc()
+	case <-timer.C:
+		outcome, result = rejectedTimeout, "timeout"
+	case <-ctx.Done():
+		outcome, result = cancelled, "cancelled"
+	}
+	waited := time.Since(start)
+	metrics.AdmissionQueueWait.WithLabelValues(result).Observe(waited.Seconds())
+	return outcome, waited
+}
+
+func (q *AdmissionQueue) release() {
+	<-q.slots
+	metrics.AdmissionInFlight.Dec()
+}
+
+// retryAfter is the Retry-After hint for a rejected request: the queue's
+// max wait, rounded up to whole seconds and at least one.
+func (q *AdmissionQueue) retryAfter() string {
+	return strconv.Itoa(max(1, int(math.Ceil(q.maxWait.Seconds()))))
+}
+
+// Admission returns middleware that holds every request it wraps to the
+// queue's in-flight limit for the whole time its handler runs, streams and
+// realtime sessions included. A request that finds the queue full or waits
+// out its max wait gets 503 with a Retry-After header; an admitted request
+// carries X-Ferro-Queue-Wait-Ms. Mount it only on the routes that can reach a
+// provider. A nil queue disables the middleware.
+func Admission(q *AdmissionQueue) func(http.Handler) http.Handler {
+	if q == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			outcome, waited := q.acquire(r.Context())
+			switch outcome {
+			case admitted:
+			case cancelled:
+				// The client is gone; there is no one to answer.
+				return
+			default:
+				reason := "queue_full"
+				if outcome == rejectedTimeout {
+					reason = "timeout"
+				}
+				metrics.AdmissionRejectionsTotal.WithLabelValues(reason).Inc()
+				w.Header().Set("Retry-After", q.retryAfter())
+				apierror.WriteOpenAI(w, http.StatusServiceUnavailable,
+					"the gateway is at capacity, retry later", "server_error", "gateway_overloaded")
+				return
+			}
+			defer q.release()
+			w.Header().Set(QueueWaitHeader, strconv.FormatInt(waited.Milliseconds(), 10))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
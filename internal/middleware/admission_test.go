@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingHandler holds each request until release is closed, signalling
+// entered as it starts.
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func admissionPost(t *testing.T, h http.Handler) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/chat/completions", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestAdmission_QueueFullReturns503WithRetryAfter(t *testing.T) {
+	q := NewAdmissionQueue(1, 0, 2500*time.Millisecond)
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	h := Admission(q)(blockingHandler(entered, release))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		admissionPost(t, h)
+	}()
+	<-entered
+	w := admissionPost(t, h)
+	close(release)
+	<-done
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "3" {
+		t.Fatalf("Retry-After = %q, want the max wait rounded up to 3", got)
+	}
+	if !strings.Contains(w.Body.String(), "gateway_overloaded") {
+		t.Fatalf("body = %s, want code gateway_overloaded", w.Body)
+	}
+}
+
+func TestAdmission_QueuedRequestRunsWhenASlotFrees(t *testing.T) {
+	q := NewAdmissionQueue(1, 1, time.Minute)
+	entered, release := make(chan struct{}, 2), make(chan struct{})
+	h := Admission(q)(blockingHandler(entered, release))
+
+	first := make(chan struct{})
+	go func() {
+		defer close(first)
+		admissionPost(t, h)
+	}()
+	<-entered
+
+	second := make(chan *httptest.ResponseRecorder, 1)
+	go func() { second <- admissionPost(t, h) }()
+	for q.Queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if q.InFlight() != 1 {
+		t.Fatalf("in flight = %d, want 1", q.InFlight())
+	}
+
+	close(release)
+	<-first
+	w := <-second
+	if w.Code != http.StatusOK {
+		t.Fatalf("queued request got %d, want 200", w.Code)
+	}
+	if w.Header().Get(QueueWaitHeader) == "" {
+		t.Fatal("admitted request is missing the queue wait header")
+	}
+	if q.InFlight() != 0 || q.Queued() != 0 {
+		t.Fatalf("in flight %d, queued %d after both finished", q.InFlight(), q.Queued())
+	}
+}
+
+func TestAdmission_WaitTimesOut(t *testing.T) {
+	q := NewAdmissionQueue(1, 1, 20*time.Millisecond)
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	h := Admission(q)(blockingHandler(entered, release))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		admissionPost(t, h)
+	}()
+	<-entered
+	w := admissionPost(t, h)
+	close(release)
+	<-done
+
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("got %d Retry-After %q, want 503 with 1", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestAdmission_GatesGETs(t *testing.T) {
+	// A GET such as /v1/realtime or a proxied read reaches a provider too.
+	q := NewAdmissionQueue(1, 0, time.Second)
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	h := Admission(q)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			entered <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		admissionPost(t, h)
+	}()
+	<-entered
+	r := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/v1/realtime", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	close(release)
+	<-done
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET got %d while the queue was full, want 503", w.Code)
+	}
+}
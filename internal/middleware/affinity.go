@@ -0,0 +1,31 @@
+package middleware
+
+import "net/http"
+
+// ReplicaHeader names the gateway replica that served a response.
+const ReplicaHeader = "X-Ferro-Replica"
+
+// Affinity returns middleware naming replicaID on every response, in the
+// ReplicaHeader header and in the cookie named cookie, so a load balancer
+// doing sticky sessions on either sends the client's later requests to the
+// same replica. The cookie is only set when the request does not already
+// carry this replica's ID; a client pinned elsewhere that lands here, because
+// its replica went away, is re-pinned to this one.
+func Affinity(cookie, replicaID string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(ReplicaHeader, replicaID)
+			if c, err := r.Cookie(cookie); err != nil || c.Value != replicaID {
+				http.SetCookie(w, &http.Cookie{
+					Name:     cookie,
+					Value:    replicaID,
+					Path:     "/",
+					HttpOnly: true,
+					Secure:   r.TLS != nil,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
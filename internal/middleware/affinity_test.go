@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAffinity(t *testing.T) {
+	handler := Affinity("ferro_affinity", "gw-1")(dummyHandler)
+
+	r := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/chat/completions", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Get(ReplicaHeader); got != "gw-1" {
+		t.Errorf("%s = %q, want gw-1", ReplicaHeader, got)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "ferro_affinity" || cookies[0].Value != "gw-1" || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v, want the replica's affinity cookie", cookies)
+	}
+
+	// A client already pinned here is not sent the cookie again.
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Get("Set-Cookie"); got != "" {
+		t.Errorf("Set-Cookie = %q for a pinned client", got)
+	}
+
+	// A client pinned to a replica that went away is re-pinned.
+	r = httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/chat/completions", nil)
+	r.AddCookie(&http.Cookie{Name: "ferro_affinity", Value: "gw-0"})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "gw-1" {
+		t.Errorf("cookies = %+v, want a re-pin to gw-1", cookies)
+	}
+}
@@ -1,20 +1,44 @@
 package middleware
 
 import (
+	"errors"
+	"io"
 	"net/http"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
 )
 
 // MaxRequestBody returns middleware that limits the size of the request body to
 // limit bytes. The body is wrapped with http.MaxBytesReader so that once the
-// limit is reached, any further Read call returns *http.MaxBytesError.
+// limit is reached, any further Read call returns *http.MaxBytesError, and the
+// first such error is counted in gateway_request_body_too_large_total.
 //
 // Handlers that read the body (via io.ReadAll or json.Decoder.Decode) must
 // check for *http.MaxBytesError using errors.As and return HTTP 413.
 func MaxRequestBody(limit int64) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			r.Body = &countedLimitReader{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// countedLimitReader counts the first *http.MaxBytesError its body returns.
+// The error itself is passed through unchanged for the handler to map to 413.
+type countedLimitReader struct {
+	io.ReadCloser
+	counted bool
+}
+
+func (c *countedLimitReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if err != nil && !c.counted {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.counted = true
+			metrics.RequestBodyTooLargeTotal.Inc()
+		}
+	}
+	return n, err
+}
@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // TestMaxRequestBody_UnderLimit verifies that requests within the limit pass through.
@@ -83,3 +86,28 @@ func asMaxBytesError(err error, target **http.MaxBytesError) bool {
 	}
 	return false
 }
+
+// TestMaxRequestBody_CountsRejection verifies an oversize body is counted once,
+// however many reads run past the limit.
+func TestMaxRequestBody_CountsRejection(t *testing.T) {
+	const limit = 10
+
+	inner := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		_, _ = r.Body.Read(make([]byte, 1))
+	})
+	h := MaxRequestBody(limit)(inner)
+
+	before := testutil.ToFloat64(metrics.RequestBodyTooLargeTotal)
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 100)))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got := testutil.ToFloat64(metrics.RequestBodyTooLargeTotal) - before; got != 1 {
+		t.Errorf("gateway_request_body_too_large_total delta = %v, want 1", got)
+	}
+
+	req = httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/", strings.NewReader("small"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got := testutil.ToFloat64(metrics.RequestBodyTooLargeTotal) - before; got != 1 {
+		t.Errorf("a body within the limit was counted: delta = %v, want 1", got)
+	}
+}
@@ -3,29 +3,112 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 )
 
-// CORS returns middleware that sets CORS headers for the given allowed origins.
+// Defaults for a CORSPolicy that leaves the field empty.
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "X-Provider"}
+)
+
+// defaultCORSMaxAge is the preflight cache lifetime used by CORS.
+const defaultCORSMaxAge = 24 * time.Hour
+
+// CORSPolicy is the cross-origin policy for the routes under PathPrefix.
+type CORSPolicy struct {
+	// PathPrefix selects the routes the policy covers, matched on whole path
+	// segments ("/v1" covers /v1 and /v1/chat/completions, not /v1beta).
+	// Empty matches every route.
+	PathPrefix string
+	// AllowedOrigins lists exact origins, or "*" for any origin.
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders default to the gateway's standard
+	// set when empty.
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials sends Access-Control-Allow-Credentials. The allowed
+	// origin is then echoed even for "*", as browsers require.
+	AllowCredentials bool
+	// MaxAge is sent as Access-Control-Max-Age; zero omits the header.
+	MaxAge time.Duration
+}
+
+// compiledCORSPolicy is a CORSPolicy with its headers rendered once.
+type compiledCORSPolicy struct {
+	prefix      string
+	anyOrigin   bool
+	origins     map[string]struct{}
+	methods     string
+	headers     string
+	exposed     string
+	credentials bool
+	maxAge      string
+}
+
+func compileCORSPolicy(p CORSPolicy) compiledCORSPolicy {
+	c := compiledCORSPolicy{
+		prefix:      strings.TrimSuffix(p.PathPrefix, "/"),
+		origins:     make(map[string]struct{}, len(p.AllowedOrigins)),
+		methods:     strings.Join(orDefault(p.AllowedMethods, defaultCORSMethods), ", "),
+		headers:     strings.Join(orDefault(p.AllowedHeaders, defaultCORSHeaders), ", "),
+		exposed:     strings.Join(p.ExposedHeaders, ", "),
+		credentials: p.AllowCredentials,
+	}
+	for _, value := range p.AllowedOrigins {
+		origin := strings.TrimSpace(value)
+		switch origin {
+		case "":
+		case "*":
+			c.anyOrigin = true
+		default:
+			c.origins[origin] = struct{}{}
+		}
+	}
+	if p.MaxAge > 0 {
+		c.maxAge = strconv.FormatInt(int64(p.MaxAge/time.Second), 10)
+	}
+	return c
+}
+
+func orDefault(values, def []string) []string {
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
+
+func (c *compiledCORSPolicy) matches(path string) bool {
+	return c.prefix == "" || path == c.prefix || strings.HasPrefix(path, c.prefix+"/")
+}
+
+// CORS returns middleware that sets CORS headers for the given allowed origins
+// on every route, with the gateway's standard methods, headers, and a 24h
+// preflight cache. It is the CORS_ORIGINS policy; CORSWithPolicies serves the
+// per-route policies of the config's cors section.
 //
 // When no origins are configured the middleware is a no-op: it emits no
 // Access-Control-Allow-Origin header and passes every request (including
 // OPTIONS preflights) straight through to the next handler. Cross-origin
 // requests are therefore blocked by the browser. Set CORS_ORIGINS to an
 // explicit comma-separated allowlist to enable cross-origin access.
+//
+// Unlike a config policy, CORS_ORIGINS has no wildcard: "*" is compared as a
+// literal origin, as it always has been, so it matches no browser origin.
+// Allowing any origin takes a cors policy in the config.
 func CORS(allowedOrigins ...string) func(http.Handler) http.Handler {
-	allowed := make(map[string]struct{}, len(allowedOrigins))
-	for _, value := range allowedOrigins {
-		origin := strings.TrimSpace(value)
-		if origin == "" {
-			continue
-		}
-		allowed[origin] = struct{}{}
+	policy := compileCORSPolicy(CORSPolicy{AllowedOrigins: allowedOrigins, MaxAge: defaultCORSMaxAge})
+	if policy.anyOrigin {
+		logging.Logger.Warn(`CORS_ORIGINS "*" is not a wildcard and matches no origin; use a cors policy in the config to allow any origin`)
+		policy.anyOrigin = false
+		policy.origins["*"] = struct{}{}
 	}
-
-	if len(allowed) == 0 {
+	if !policy.anyOrigin && len(policy.origins) == 0 {
 		logging.Logger.Warn("CORS_ORIGINS is not configured — all cross-origin requests will be blocked. Set CORS_ORIGINS to allow specific origins (e.g. your dashboard).")
 		// Return a pass-through middleware: no CORS headers are emitted.
 		return func(next http.Handler) http.Handler {
@@ -34,23 +117,69 @@ func CORS(allowedOrigins ...string) func(http.Handler) http.Handler {
 			})
 		}
 	}
+	return corsMiddleware([]compiledCORSPolicy{policy})
+}
+
+// CORSWithPolicies returns middleware that applies the first policy whose
+// PathPrefix matches the request path. A request matching no policy, or
+// coming from an origin its policy does not allow, gets no CORS headers and
+// is passed through, so the browser blocks it.
+func CORSWithPolicies(policies []CORSPolicy) func(http.Handler) http.Handler {
+	compiled := make([]compiledCORSPolicy, 0, len(policies))
+	for _, p := range policies {
+		compiled = append(compiled, compileCORSPolicy(p))
+	}
+	return corsMiddleware(compiled)
+}
 
+func corsMiddleware(policies []compiledCORSPolicy) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			requestOrigin := r.Header.Get("Origin")
-			if _, ok := allowed[requestOrigin]; ok {
-				w.Header().Set("Access-Control-Allow-Origin", requestOrigin)
-				w.Header().Set("Vary", "Origin")
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Provider")
-				w.Header().Set("Access-Control-Max-Age", "86400")
-				if r.Method == http.MethodOptions {
+			for i := range policies {
+				p := &policies[i]
+				if !p.matches(r.URL.Path) {
+					continue
+				}
+				if p.apply(w, r) && r.Method == http.MethodOptions {
 					w.WriteHeader(http.StatusNoContent)
 					return
 				}
+				break
 			}
-
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// apply sets the policy's CORS headers when the request's origin is allowed
+// and reports whether it was.
+func (c *compiledCORSPolicy) apply(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	_, listed := c.origins[origin]
+	if len(c.origins) > 0 || c.credentials {
+		// The response depends on the Origin, so caches must key on it.
+		w.Header().Add("Vary", "Origin")
+	}
+	if origin == "" || (!listed && !c.anyOrigin) {
+		return false
+	}
+
+	h := w.Header()
+	if c.anyOrigin && !c.credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	h.Set("Access-Control-Allow-Methods", c.methods)
+	h.Set("Access-Control-Allow-Headers", c.headers)
+	if c.exposed != "" {
+		h.Set("Access-Control-Expose-Headers", c.exposed)
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 var dummyHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -40,6 +41,21 @@ func TestCORS_NoCORSHeaders_WhenOnlyEmptyStrings(t *testing.T) {
 	}
 }
 
+// TestCORS_StarIsNotAWildcard verifies that CORS_ORIGINS=* keeps its old
+// meaning: a literal origin that no browser sends.
+func TestCORS_StarIsNotAWildcard(t *testing.T) {
+	handler := CORS("*")(dummyHandler)
+
+	r := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/v1/chat/completions", nil)
+	r.Header.Set("Origin", "https://attacker.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no Access-Control-Allow-Origin for CORS_ORIGINS=*, got %q", got)
+	}
+}
+
 // TestCORS_NoCORSHeaders_OnAdminPath verifies that the deny-by-default behaviour
 // applies to sensitive /admin/* paths when no origins are configured.
 func TestCORS_NoCORSHeaders_OnAdminPath(t *testing.T) {
@@ -224,3 +240,58 @@ func TestCORS_TrimsWhitespaceFromOrigins(t *testing.T) {
 		t.Fatalf("expected https://example.com, got %q", got)
 	}
 }
+
+// --- per-route policies ---
+
+func TestCORSWithPolicies_FirstMatchingPrefixApplies(t *testing.T) {
+	handler := CORSWithPolicies([]CORSPolicy{
+		{PathPrefix: "/admin", AllowedOrigins: []string{"https://dash.example.com"}, AllowCredentials: true},
+		{PathPrefix: "/v1", AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Ferro-Trace-Id"}, MaxAge: 10 * time.Minute},
+	})(dummyHandler)
+
+	serve := func(method, path, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequestWithContext(t.Context(), method, path, nil)
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/v1/chat/completions", "https://any.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("data plane Allow-Origin = %q, want *", got)
+	}
+	if w.Header().Get("Access-Control-Expose-Headers") != "X-Ferro-Trace-Id" || w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("unexpected data plane headers: %v", w.Header())
+	}
+
+	w = serve(http.MethodOptions, "/admin/keys", "https://dash.example.com")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		w.Header().Get("Access-Control-Allow-Origin") != "https://dash.example.com" {
+		t.Fatalf("admin preflight: %d %v", w.Code, w.Header())
+	}
+
+	if got := serve(http.MethodGet, "/admin/keys", "https://any.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("admin policy should not admit other origins, got %q", got)
+	}
+	// Prefixes match whole path segments, and unmatched routes get nothing.
+	for _, path := range []string{"/v1beta/models", "/dashboard"} {
+		if got := serve(http.MethodGet, path, "https://any.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Fatalf("%s should match no policy, got %q", path, got)
+		}
+	}
+}
+
+func TestCORSWithPolicies_ZeroMaxAgeOmitsHeader(t *testing.T) {
+	handler := CORSWithPolicies([]CORSPolicy{{AllowedOrigins: []string{"https://example.com"}}})(dummyHandler)
+	r := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://example.com" {
+		t.Fatal("expected the origin to be allowed")
+	}
+	if _, ok := w.Header()["Access-Control-Max-Age"]; ok {
+		t.Fatal("expected no Access-Control-Max-Age header")
+	}
+}
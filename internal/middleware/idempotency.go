@@ -0,0 +1,357 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+)
+
+// IdempotencyKeyHeader is the request header that opts a request into replay.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks a response served from the idempotency store
+// rather than by running the request again.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLen bounds the header so a caller cannot make the store
+// hold arbitrarily large keys.
+const maxIdempotencyKeyLen = 255
+
+// Defaults for the IdempotencyStore limits.
+const (
+	DefaultIdempotencyMaxEntries = 10000
+	DefaultIdempotencyMaxBytes   = 64 << 20
+)
+
+// IdempotencyStore keeps the responses to requests sent with an
+// Idempotency-Key header for a fixed window. It is held in memory, so each
+// replica replays only the requests it served itself.
+//
+// The store holds at most maxEntries keys and maxBytes of response bodies.
+// Past either limit it forgets the oldest stored responses early; when every
+// key it holds belongs to a request still running, a new key is refused with
+// 429. A response larger than maxBytes on its own is not stored.
+type IdempotencyStore struct {
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	now        func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	order     *list.List // *idempotencyEntry, oldest claim first
+	bytes     int64      // body bytes of the stored responses
+	nextSweep time.Time
+}
+
+// idempotencyEntry is one key's request fingerprint and, once the request has
+// finished, its response. A nil response means the request is still running.
+type idempotencyEntry struct {
+	hash    [sha256.Size]byte
+	resp    *storedResponse
+	expires time.Time
+	// keyID and user are the API key and the body's end user, for erasure.
+	keyID, user string
+
+	key  string
+	elem *list.Element
+}
+
+type storedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewIdempotencyStore returns a store that keeps responses for ttl, holding
+// up to maxEntries keys and maxBytes of response bodies; zero or less uses
+// DefaultIdempotencyMaxEntries and DefaultIdempotencyMaxBytes.
+func NewIdempotencyStore(ttl time.Duration, maxEntries int, maxBytes int64) *IdempotencyStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultIdempotencyMaxEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultIdempotencyMaxBytes
+	}
+	return &IdempotencyStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		now:        time.Now,
+		entries:    make(map[string]*idempotencyEntry),
+		order:      list.New(),
+	}
+}
+
+// Idempotency returns middleware that replays the stored response when a POST
+// is retried with the same Idempotency-Key and an identical body, so a client
+// retrying a request whose response it never received is not charged twice.
+//
+// Keys are scoped to the authenticated API key, so the middleware must run
+// after the auth middleware. Reusing a key with a different body is rejected
+// with 422, and retrying while the first request is still running with 409.
+// Streaming requests, and responses with a 429 or 5xx status, are not stored:
+// nothing was generated for the latter, so the client should retry them for
+// real. A nil store disables the middleware.
+func Idempotency(store *IdempotencyStore) func(http.Handler) http.Handler {
+	if store == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				apierror.WriteOpenAI(w, http.StatusBadRequest,
+					"Idempotency-Key must be at most 255 characters", "invalid_request_error", "invalid_idempotency_key")
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				// Let the handler report the read error (e.g. 413) as it
+				// would without the header.
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fields := parseRequestFields(body)
+			if fields.Stream {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			keyID, _ := authctx.KeyID(r.Context())
+			scoped := keyID + "\x00" + key
+			hash := fingerprint(r, body)
+			entry, fresh, full := store.begin(scoped, idempotencyEntry{hash: hash, keyID: keyID, user: fields.User})
+			switch {
+			case full:
+				w.Header().Set("Retry-After", "1")
+				apierror.WriteOpenAI(w, http.StatusTooManyRequests,
+					"too many requests with an Idempotency-Key are in progress", "rate_limit_error", "idempotency_store_full")
+				return
+			case fresh:
+			case entry.hash != hash:
+				apierror.WriteOpenAI(w, http.StatusUnprocessableEntity,
+					"Idempotency-Key was already used with a different request", "invalid_request_error", "idempotency_key_reused")
+				return
+			case entry.resp == nil:
+				apierror.WriteOpenAI(w, http.StatusConflict,
+					"a request with this Idempotency-Key is still in progress", "invalid_request_error", "idempotency_key_in_use")
+				return
+			default:
+				entry.resp.replay(w)
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// A panicking handler leaves nothing to replay; release the
+				// key so the client's retry runs.
+				if !completed || rec.status == http.StatusTooManyRequests || rec.status >= 500 {
+					store.release(scoped)
+					return
+				}
+				store.finish(scoped, &storedResponse{
+					status: rec.status,
+					header: w.Header().Clone(),
+					body:   rec.body.Bytes(),
+				})
+			}()
+			next.ServeHTTP(rec, r)
+			completed = true
+		})
+	}
+}
+
+// begin returns the live entry for key, or claims key for a new request,
+// described by claim, and reports fresh. full reports that the store is at
+// its entry limit with nothing it may evict, and nothing was claimed.
+func (s *IdempotencyStore) begin(key string, claim idempotencyEntry) (entry idempotencyEntry, fresh, full bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.After(s.nextSweep) {
+		for _, e := range s.entries {
+			if e.resp != nil && now.After(e.expires) {
+				s.removeLocked(e)
+			}
+		}
+		s.nextSweep = now.Add(min(s.ttl, time.Minute))
+	}
+	if e, ok := s.entries[key]; ok {
+		if e.resp == nil || !now.After(e.expires) {
+			return *e, false, false
+		}
+		s.removeLocked(e)
+	}
+	if len(s.entries) >= s.maxEntries && !s.evictLocked() {
+		return idempotencyEntry{}, false, true
+	}
+	e := &claim
+	e.key = key
+	e.elem = s.order.PushBack(e)
+	s.entries[key] = e
+	return idempotencyEntry{}, true, false
+}
+
+// finish stores resp for the request that claimed key, evicting older
+// responses to keep within maxBytes. A response over maxBytes on its own is
+// not stored, and its key is released.
+func (s *IdempotencyStore) finish(key string, resp *storedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	size := int64(len(resp.body))
+	if size > s.maxBytes {
+		s.removeLocked(e)
+		return
+	}
+	// Running requests hold no bytes, so eviction always makes room.
+	for s.bytes+size > s.maxBytes {
+		s.evictLocked()
+	}
+	e.resp = resp
+	e.expires = s.now().Add(s.ttl)
+	s.bytes += size
+}
+
+// release forgets key so the next request with it runs.
+func (s *IdempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.removeLocked(e)
+	}
+}
+
+// evictLocked forgets the oldest stored response and reports whether there
+// was one; requests still running are never evicted. The caller must hold
+// s.mu.
+func (s *IdempotencyStore) evictLocked() bool {
+	for el := s.order.Front(); el != nil; el = el.Next() {
+		if e := el.Value.(*idempotencyEntry); e.resp != nil {
+			s.removeLocked(e)
+			return true
+		}
+	}
+	return false
+}
+
+// removeLocked forgets e. The caller must hold s.mu.
+func (s *IdempotencyStore) removeLocked(e *idempotencyEntry) {
+	delete(s.entries, e.key)
+	s.order.Remove(e.elem)
+	if e.resp != nil {
+		s.bytes -= int64(len(e.resp.body))
+	}
+}
+
+// EraseUser forgets the stored responses to requests from user (the body's
+// `user` field), from the API key keyID, or both together, for a
+// right-to-erasure request, and returns how many it removed. A request still
+// running loses its key and stores nothing.
+func (s *IdempotencyStore) EraseUser(_ context.Context, user, keyID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, e := range s.entries {
+		if (user == "" || e.user == user) && (keyID == "" || e.keyID == keyID) {
+			s.removeLocked(e)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// replay writes the stored response. Headers the outer middleware already set
+// for this request, such as X-Request-ID, are kept.
+func (resp *storedResponse) replay(w http.ResponseWriter) {
+	h := w.Header()
+	for k, v := range resp.header {
+		if _, ok := h[k]; !ok {
+			h[k] = v
+		}
+	}
+	h.Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.status)
+	_, _ = w.Write(resp.body)
+}
+
+// fingerprint hashes what makes two requests the same: the route and body.
+func fingerprint(r *http.Request, body []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// requestFields are the body fields the middleware reads: whether a streamed
+// response, which cannot be replayed, is asked for, and the end user.
+type requestFields struct {
+	Stream bool
+	User   string
+}
+
+// parseRequestFields reads them from a JSON body; any other body has none. A
+// user that is not a string is ignored rather than hiding the stream flag.
+func parseRequestFields(body []byte) requestFields {
+	var req struct {
+		Stream bool            `json:"stream"`
+		User   json.RawMessage `json:"user"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return requestFields{}
+	}
+	f := requestFields{Stream: req.Stream}
+	_ = json.Unmarshal(req.User, &f.User)
+	return f
+}
+
+// recordingWriter copies the status and body written through it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// errReader returns err from every Read.
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
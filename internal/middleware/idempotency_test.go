@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+)
+
+// countingHandler answers with status and a body numbering each call.
+func countingHandler(calls *atomic.Int32, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintf(w, `{"call":%d}`, n)
+	})
+}
+
+func idempotentPost(ctx context.Context, h http.Handler, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequestWithContext(ctx, http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotency_ReplaysRetryWithSameKeyAndBody(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency(NewIdempotencyStore(time.Hour, 0, 0))(countingHandler(&calls, http.StatusOK))
+
+	first := idempotentPost(t.Context(), h, "k1", `{"model":"gpt-4o"}`)
+	second := idempotentPost(t.Context(), h, "k1", `{"model":"gpt-4o"}`)
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatal("only the replayed response should carry Idempotent-Replayed")
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("replayed Content-Type = %q", second.Header().Get("Content-Type"))
+	}
+}
+
+func TestIdempotency_RejectsKeyReusedWithDifferentBody(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency(NewIdempotencyStore(time.Hour, 0, 0))(countingHandler(&calls, http.StatusOK))
+
+	idempotentPost(t.Context(), h, "k1", `{"model":"gpt-4o"}`)
+	w := idempotentPost(t.Context(), h, "k1", `{"model":"gpt-4o-mini"}`)
+
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "idempotency_key_reused") {
+		t.Fatalf("got %d %s, want 422 idempotency_key_reused", w.Code, w.Body)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestIdempotency_ConcurrentRetryConflicts(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := Idempotency(NewIdempotencyStore(time.Hour, 0, 0))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		idempotentPost(t.Context(), h, "k1", `{}`)
+	}()
+	<-started
+	w := idempotentPost(t.Context(), h, "k1", `{}`)
+	close(release)
+	<-done
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("got %d, want 409 while the first request runs", w.Code)
+	}
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency(NewIdempotencyStore(time.Hour, 0, 0))(countingHandler(&calls, http.StatusBadGateway))
+
+	idempotentPost(t.Context(), h, "k1", `{}`)
+	idempotentPost(t.Context(), h, "k1", `{}`)
+
+	if calls.Load() != 2 {
+		t.Fatalf("handler ran %d times, want a 502 to be retried for real", calls.Load())
+	}
+}
+
+func TestIdempotency_ScopesKeysAndSkipsUnkeyedAndStreaming(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency(NewIdempotencyStore(time.Hour, 0, 0))(countingHandler(&calls, http.StatusOK))
+
+	idempotentPost(t.Context(), h, "", `{}`)
+	idempotentPost(t.Context(), h, "", `{}`)
+	idempotentPost(t.Context(), h, "s", `{"stream":true}`)
+	idempotentPost(t.Context(), h, "s", `{"stream":true}`)
+	idempotentPost(authctx.WithKeyID(t.Context(), "key-a"), h, "k1", `{}`)
+	idempotentPost(authctx.WithKeyID(t.Context(), "key-b"), h, "k1", `{}`)
+
+	if calls.Load() != 6 {
+		t.Fatalf("handler ran %d times, want 6", calls.Load())
+	}
+}
+
+func TestIdempotency_EntriesExpire(t *testing.T) {
+	var calls atomic.Int32
+	store := NewIdempotencyStore(time.Minute, 0, 0)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	h := Idempotency(store)(countingHandler(&calls, http.StatusOK))
+
+	idempotentPost(t.Context(), h, "k1", `{}`)
+	now = now.Add(2 * time.Minute)
+	w := idempotentPost(t.Context(), h, "k1", `{"different":true}`)
+
+	if w.Code != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("got %d after %d calls, want the expired key to be reusable", w.Code, calls.Load())
+	}
+}
+
+func TestIdempotencyStore_EraseUser(t *testing.T) {
+	var calls atomic.Int32
+	store := NewIdempotencyStore(time.Hour, 0, 0)
+	h := Idempotency(store)(countingHandler(&calls, http.StatusOK))
+	keyA := authctx.WithKeyID(t.Context(), "key-a")
+
+	idempotentPost(keyA, h, "k1", `{"user":"alice"}`)
+	idempotentPost(keyA, h, "k2", `{"user":"bob"}`)
+	idempotentPost(authctx.WithKeyID(t.Context(), "key-b"), h, "k3", `{"user":"alice"}`)
+
+	if n, _ := store.EraseUser(t.Context(), "alice", "key-a"); n != 1 {
+		t.Fatalf("erased %d entries for alice under key-a, want 1", n)
+	}
+	if n, _ := store.EraseUser(t.Context(), "alice", ""); n != 1 {
+		t.Fatalf("erased %d remaining entries for alice, want 1", n)
+	}
+	// The erased response is no longer replayed; bob's still is.
+	idempotentPost(keyA, h, "k1", `{"user":"alice"}`)
+	idempotentPost(keyA, h, "k2", `{"user":"bob"}`)
+	if calls.Load() != 4 {
+		t.Fatalf("handler ran %d times, want 4", calls.Load())
+	}
+}
+
+func TestIdempotency_Limits(t *testing.T) {
+	var calls atomic.Int32
+	store := NewIdempotencyStore(time.Hour, 2, 20)
+	h := Idempotency(store)(countingHandler(&calls, http.StatusOK))
+
+	// Each {"call":N} body is 10 bytes: a third key evicts the oldest.
+	for _, key := range []string{"k1", "k2", "k3"} {
+		idempotentPost(t.Context(), h, key, `{}`)
+	}
+	idempotentPost(t.Context(), h, "k3", `{}`)
+	idempotentPost(t.Context(), h, "k1", `{}`)
+	if calls.Load() != 4 {
+		t.Fatalf("handler ran %d times, want k1 evicted and k3 replayed", calls.Load())
+	}
+	if len(store.entries) != 2 || store.bytes != 20 {
+		t.Fatalf("store holds %d entries, %d bytes; want 2, 20", len(store.entries), store.bytes)
+	}
+
+	// A key is refused while every key held is still running.
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	block := Idempotency(NewIdempotencyStore(time.Hour, 1, 0))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		started.Done()
+		<-release
+	}))
+	started.Add(1)
+	done := make(chan struct{})
+	go func() {
+		idempotentPost(t.Context(), block, "a", `{}`)
+		close(done)
+	}()
+	started.Wait()
+	if w := idempotentPost(t.Context(), block, "b", `{}`); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("full store: got %d, want 429", w.Code)
+	}
+	close(release)
+	<-done
+}
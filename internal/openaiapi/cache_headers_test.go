@@ -0,0 +1,49 @@
+package openaiapi
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestWriteCacheHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        *providers.CacheInfo
+		wantStatus  string
+		wantAge     string
+		wantControl string
+	}{
+		{name: "no cache plugin"},
+		{
+			name:       "miss without hint",
+			info:       &providers.CacheInfo{Status: providers.CacheStatusMiss},
+			wantStatus: "miss",
+		},
+		{
+			name:        "hit with hint",
+			info:        &providers.CacheInfo{Status: providers.CacheStatusHit, Age: 42*time.Second + 900*time.Millisecond, Control: "private, max-age=17"},
+			wantStatus:  "hit",
+			wantAge:     "42",
+			wantControl: "private, max-age=17",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeCacheHeaders(rec, tt.info)
+			if got := rec.Header().Get("X-Ferro-Cache"); got != tt.wantStatus {
+				t.Errorf("X-Ferro-Cache = %q, want %q", got, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Age"); got != tt.wantAge {
+				t.Errorf("Age = %q, want %q", got, tt.wantAge)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantControl)
+			}
+		})
+	}
+}
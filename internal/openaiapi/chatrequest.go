@@ -1,10 +1,10 @@
-// Package handler provides HTTP handler functions for the OpenAI-compatible API.
-package handler
+package openaiapi
 
 import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
 
@@ -19,6 +19,7 @@ type routeChatCompletionRequest struct {
 	TopP                *float64                  `json:"top_p,omitempty"`
 	N                   *int                      `json:"n,omitempty"`
 	Seed                *int64                    `json:"seed,omitempty"`
+	TopK                *int                      `json:"top_k,omitempty"`
 	MaxTokens           *int                      `json:"max_tokens,omitempty"`
 	MaxCompletionTokens *int                      `json:"max_completion_tokens,omitempty"`
 	PresencePenalty     *float64                  `json:"presence_penalty,omitempty"`
@@ -40,6 +41,18 @@ type routeChatCompletionRequest struct {
 	User              string              `json:"user,omitempty"`
 	LogitBias         map[string]float64  `json:"logit_bias,omitempty"`
 	ParallelToolCalls *bool               `json:"parallel_tool_calls,omitempty"`
+	ReasoningEffort   string              `json:"reasoning_effort,omitempty"`
+	// Thinking is the normalized extended-thinking option; see
+	// providers.Request.Thinking.
+	Thinking *core.Thinking `json:"thinking,omitempty"`
+	// ExtraBody carries provider-specific parameters, named as in the OpenAI
+	// SDKs' extra_body option; see providers.Request.Extra.
+	ExtraBody map[string]json.RawMessage `json:"extra_body,omitempty"`
+	// Models is an ordered list of candidate models, as OpenRouter and
+	// Portkey accept it; FallbackModels is the gateway's own spelling of the
+	// same list. Both map to providers.Request.FallbackModels.
+	Models         []string `json:"models,omitempty"`
+	FallbackModels []string `json:"fallback_models,omitempty"`
 }
 
 type routeChatMessage struct {
@@ -68,7 +81,7 @@ func putRouteChatCompletionRequest(r *routeChatCompletionRequest) {
 	chatRequestPool.Put(r)
 }
 
-// reset clears all 21 fields before returning to the pool.
+// reset clears all 27 fields before returning to the pool.
 // SECURITY: every field must be listed explicitly. Missing a field
 // leaks one tenant's data to another in the multi-tenant gateway.
 func (r *routeChatCompletionRequest) reset() {
@@ -93,6 +106,12 @@ func (r *routeChatCompletionRequest) reset() {
 	r.User = ""                 // field 19: string
 	r.LogitBias = nil           // field 20: map[string]float64
 	r.ParallelToolCalls = nil   // field 21: *bool
+	r.ReasoningEffort = ""      // field 22: string
+	r.ExtraBody = nil           // field 23: map[string]json.RawMessage
+	r.TopK = nil                // field 24: *int
+	r.Models = nil              // field 25: []string
+	r.FallbackModels = nil      // field 26: []string
+	r.Thinking = nil            // field 27: *core.Thinking
 }
 
 // DecodeChatCompletionRequest decodes the JSON body into a providers.Request.
@@ -119,15 +138,21 @@ func DecodeChatCompletionRequest(r io.Reader) (providers.Request, error) {
 		}
 	}
 
+	thinking, extra := thinkingOption(wire.Thinking, wire.ExtraBody)
+
+	model, fallbacks := candidateModels(wire.Model, wire.Models, wire.FallbackModels)
 	return providers.Request{
-		Model:               wire.Model,
+		Model:               model,
 		Messages:            messages,
 		Temperature:         wire.Temperature,
 		TopP:                wire.TopP,
 		N:                   wire.N,
 		Seed:                wire.Seed,
+		TopK:                wire.TopK,
 		MaxTokens:           wire.MaxTokens,
 		MaxCompletionTokens: wire.MaxCompletionTokens,
+		ReasoningEffort:     wire.ReasoningEffort,
+		Thinking:            thinking,
 		PresencePenalty:     wire.PresencePenalty,
 		FrequencyPenalty:    wire.FrequencyPenalty,
 		Stop:                wire.Stop,
@@ -141,9 +166,60 @@ func DecodeChatCompletionRequest(r io.Reader) (providers.Request, error) {
 		User:                wire.User,
 		LogitBias:           wire.LogitBias,
 		ParallelToolCalls:   wire.ParallelToolCalls,
+		Extra:               extra,
+		FallbackModels:      fallbacks,
 	}, nil
 }
 
+// thinkingOption returns the request's thinking option and its extra_body.
+// Anthropic's thinking parameter sent in extra_body is taken as the option,
+// and removed from extra_body, when the top-level field is absent, so it is
+// capped and mapped for other providers like the option itself. A thinking
+// parameter in another shape stays in extra_body and is forwarded as sent.
+func thinkingOption(thinking *core.Thinking, extra map[string]json.RawMessage) (*core.Thinking, map[string]json.RawMessage) {
+	raw, ok := extra["thinking"]
+	if !ok {
+		return thinking, extra
+	}
+	if thinking == nil {
+		var t core.Thinking
+		if json.Unmarshal(raw, &t) != nil {
+			return nil, extra
+		}
+		thinking = &t
+	}
+	rest := make(map[string]json.RawMessage, len(extra)-1)
+	for k, v := range extra {
+		if k != "thinking" {
+			rest[k] = v
+		}
+	}
+	if len(rest) == 0 {
+		rest = nil
+	}
+	return thinking, rest
+}
+
+// candidateModels returns the model to try first and the ordered fallbacks
+// after it. When model is unset the first entry of models stands in for it;
+// otherwise models, like fallbackModels, only lists fallbacks. Duplicates
+// are dropped, keeping the first occurrence.
+func candidateModels(model string, models, fallbackModels []string) (string, []string) {
+	if len(models) == 0 && len(fallbackModels) == 0 {
+		return model, nil
+	}
+	if model == "" && len(models) > 0 {
+		model, models = models[0], models[1:]
+	}
+	var fallbacks []string
+	for _, m := range slices.Concat(models, fallbackModels) {
+		if m != model && !slices.Contains(fallbacks, m) {
+			fallbacks = append(fallbacks, m)
+		}
+	}
+	return model, fallbacks
+}
+
 func (m routeChatMessage) toProviderMessage() (providers.Message, error) {
 	msg := providers.Message{
 		Role:       m.Role,
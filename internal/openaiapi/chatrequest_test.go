@@ -0,0 +1,179 @@
+package openaiapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// TestDecodeChatCompletionRequest_ParallelToolCalls verifies parallel_tool_calls
+// survives HTTP decode into the request and is forwarded on the wire by the
+// shared marshal.
+func TestDecodeChatCompletionRequest_ParallelToolCalls(t *testing.T) {
+	req, err := DecodeChatCompletionRequest(strings.NewReader(
+		`{"model":"gpt-4","messages":[{"role":"user","content":"hi"}],"parallel_tool_calls":false}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if req.ParallelToolCalls == nil {
+		t.Fatal("parallel_tool_calls not decoded (nil)")
+	}
+	if *req.ParallelToolCalls {
+		t.Errorf("parallel_tool_calls = true, want false")
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(b), `"parallel_tool_calls":false`) {
+		t.Errorf("marshaled request missing parallel_tool_calls: %s", b)
+	}
+}
+
+// TestDecodeChatCompletionRequest_ReasoningEffort verifies reasoning_effort is
+// decoded and forwarded on the wire.
+func TestDecodeChatCompletionRequest_ReasoningEffort(t *testing.T) {
+	req, err := DecodeChatCompletionRequest(strings.NewReader(
+		`{"model":"o3-mini","messages":[{"role":"user","content":"hi"}],"reasoning_effort":"high"}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if req.ReasoningEffort != "high" {
+		t.Fatalf("reasoning_effort = %q, want high", req.ReasoningEffort)
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(b), `"reasoning_effort":"high"`) {
+		t.Errorf("marshaled request missing reasoning_effort: %s", b)
+	}
+}
+
+// TestDecodeChatCompletionRequest_BodyTooLarge verifies that DecodeChatCompletionRequest
+// propagates *http.MaxBytesError when the body exceeds the MaxBytesReader limit,
+// allowing callers to map it to 413 via errors.As.
+func TestDecodeChatCompletionRequest_BodyTooLarge(t *testing.T) {
+	// Use valid-JSON-prefixed body: decoder reads the first few bytes, then hits the limit.
+	body := `{"model":"test","messages":[{"role":"user","content":"` + strings.Repeat("x", 500) + `"}]}`
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	// Wrap with a tiny limit so the reader hits it mid-JSON.
+	req.Body = http.MaxBytesReader(w, req.Body, 5)
+
+	_, err := DecodeChatCompletionRequest(req.Body)
+	if err == nil {
+		t.Fatal("expected error from oversized body, got nil")
+	}
+
+	// The handler uses errors.As to detect *http.MaxBytesError.
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		t.Errorf("errors.As(*http.MaxBytesError) returned false for %T: %v", err, err)
+	}
+}
+
+// TestDecodeChatCompletionRequest_ExtraBody verifies extra_body is decoded
+// into Request.Extra and does not survive into the next pooled decode.
+func TestDecodeChatCompletionRequest_ExtraBody(t *testing.T) {
+	req, err := DecodeChatCompletionRequest(strings.NewReader(
+		`{"model":"m","messages":[{"role":"user","content":"hi"}],"extra_body":{"min_p":0.05,"thinking":{"type":"enabled"}}}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if string(req.Extra["min_p"]) != "0.05" || len(req.Extra) != 1 {
+		t.Fatalf("extra = %v", req.Extra)
+	}
+	if req.Thinking == nil || !req.Thinking.Enabled {
+		t.Fatalf("extra_body thinking not taken as the thinking option: %+v", req.Thinking)
+	}
+
+	next, err := DecodeChatCompletionRequest(strings.NewReader(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if next.Extra != nil {
+		t.Fatalf("extra leaked into the next request: %v", next.Extra)
+	}
+}
+
+// TestDecodeChatCompletionRequest_Thinking verifies the thinking option is
+// decoded in both its forms, that it wins over extra_body's, and that a
+// thinking parameter in another shape stays in extra_body.
+func TestDecodeChatCompletionRequest_Thinking(t *testing.T) {
+	req, err := DecodeChatCompletionRequest(strings.NewReader(
+		`{"model":"m","messages":[{"role":"user","content":"hi"}],"thinking":{"enabled":true,"budget_tokens":4096},` +
+			`"extra_body":{"thinking":{"type":"disabled"}}}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if req.Thinking == nil || *req.Thinking != (core.Thinking{Enabled: true, BudgetTokens: 4096}) || req.Extra != nil {
+		t.Fatalf("thinking = %+v, extra = %v", req.Thinking, req.Extra)
+	}
+
+	req, err = DecodeChatCompletionRequest(strings.NewReader(
+		`{"model":"m","messages":[{"role":"user","content":"hi"}],"extra_body":{"thinking":{"type":"adaptive"}}}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if req.Thinking != nil || string(req.Extra["thinking"]) != `{"type":"adaptive"}` {
+		t.Fatalf("thinking = %+v, extra = %v", req.Thinking, req.Extra)
+	}
+
+	if _, err := DecodeChatCompletionRequest(strings.NewReader(
+		`{"model":"m","messages":[{"role":"user","content":"hi"}],"thinking":{"budget_tokens":10}}`)); err == nil {
+		t.Fatal("decoded a thinking option that neither enables nor disables thinking")
+	}
+}
+
+// TestDecodeChatCompletionRequest_TopK verifies top_k is decoded as a
+// first-class parameter.
+func TestDecodeChatCompletionRequest_TopK(t *testing.T) {
+	req, err := DecodeChatCompletionRequest(strings.NewReader(
+		`{"model":"m","messages":[{"role":"user","content":"hi"}],"top_k":40}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if req.TopK == nil || *req.TopK != 40 {
+		t.Fatalf("top_k = %v, want 40", req.TopK)
+	}
+}
+
+// TestDecodeChatCompletionRequest_ModelList verifies a models list supplies
+// the first model when model is unset and fallbacks otherwise, merged with
+// fallback_models and deduplicated.
+func TestDecodeChatCompletionRequest_ModelList(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		model     string
+		fallbacks []string
+	}{
+		{"models only", `"models":["a","b","c"]`, "a", []string{"b", "c"}},
+		{"model and models", `"model":"a","models":["b","a"]`, "a", []string{"b"}},
+		{"fallback_models", `"model":"a","fallback_models":["b"]`, "a", []string{"b"}},
+		{"both lists", `"models":["a","b"],"fallback_models":["b","c"]`, "a", []string{"b", "c"}},
+		{"neither", `"model":"a"`, "a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := DecodeChatCompletionRequest(strings.NewReader(
+				`{` + tt.body + `,"messages":[{"role":"user","content":"hi"}]}`))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if req.Model != tt.model || !slices.Equal(req.FallbackModels, tt.fallbacks) {
+				t.Fatalf("got %q %v, want %q %v", req.Model, req.FallbackModels, tt.model, tt.fallbacks)
+			}
+		})
+	}
+}
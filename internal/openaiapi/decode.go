@@ -0,0 +1,62 @@
+package openaiapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+)
+
+// decodeJSONBody decodes the JSON request body into dst. On failure it writes
+// an OpenAI-format error response and returns false; callers should stop
+// processing when it returns false. A body that exceeds the configured size
+// limit yields 413; any other decode failure yields 400.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		WriteDecodeError(w, err)
+		return false
+	}
+	return true
+}
+
+// WriteDecodeError writes the OpenAI-format error for a failed request body
+// decode: 413 when the body ran past the size limit, 400 otherwise.
+func WriteDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		apierror.WriteOpenAI(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body too large: the limit is %d bytes", maxBytesErr.Limit),
+			"invalid_request_error", "request_too_large")
+		return
+	}
+	apierror.WriteOpenAI(w, http.StatusBadRequest, decodeErrorMessage(err), "invalid_request_error", "invalid_request")
+}
+
+// decodeErrorMessage describes a request body decode failure for the client.
+// The body is decoded as it streams in, so the errors encoding/json reports
+// mid-stream — a bare EOF for an empty or cut-off body, a syntax error with
+// no position — are rewritten to say what was wrong and where.
+func decodeErrorMessage(err error) string {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		return "invalid request body: the body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "invalid request body: the JSON ends before it is complete"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("invalid request body: malformed JSON at byte %d: %s", syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("invalid request body: expected a JSON %s, got %s", typeErr.Type, typeErr.Value)
+		}
+		return fmt.Sprintf("invalid request body: field %q must be %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	default:
+		return "invalid request body: " + err.Error()
+	}
+}
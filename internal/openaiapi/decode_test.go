@@ -1,4 +1,4 @@
-package handler
+package openaiapi
 
 import (
 	"encoding/json"
@@ -119,3 +119,32 @@ func TestDecodeJSONBody(t *testing.T) {
 		})
 	}
 }
+
+// TestDecodeErrorMessage covers the client-facing messages for the decode
+// failures encoding/json reports mid-stream.
+func TestDecodeErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "empty body", body: ``, want: "the body is empty"},
+		{name: "truncated body", body: `{"model":`, want: "the JSON ends before it is complete"},
+		{name: "syntax error", body: `{"model" "x"}`, want: "malformed JSON at byte 10"},
+		{name: "wrong field type", body: `{"model": 42}`, want: `field "model" must be string, got number`},
+		{name: "wrong top-level type", body: `[1]`, want: "expected a JSON openaiapi.decodeTarget, got array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst decodeTarget
+			err := json.NewDecoder(strings.NewReader(tt.body)).Decode(&dst)
+			if err == nil {
+				t.Fatal("Decode succeeded, want an error")
+			}
+			got := decodeErrorMessage(err)
+			if !strings.HasPrefix(got, "invalid request body: ") || !strings.Contains(got, tt.want) {
+				t.Errorf("decodeErrorMessage() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package openaiapi
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// maxPooledResponseBuf caps the encode buffers kept for reuse, so one huge
+// response does not pin its buffer for the life of the process.
+const maxPooledResponseBuf = 1 << 20
+
+// responseBufPool holds encode buffers for chat completion bodies.
+var responseBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// writeChatResponse writes resp as the JSON response body, encoded with
+// Response.AppendJSON into a pooled buffer and sent in one write with its
+// Content-Length. The body matches what json.Encoder wrote, trailing newline
+// included. The caller sets Content-Type and any other headers first.
+func writeChatResponse(w http.ResponseWriter, resp *providers.Response) {
+	bp := responseBufPool.Get().(*[]byte)
+	buf, err := resp.AppendJSON((*bp)[:0])
+	if err != nil {
+		responseBufPool.Put(bp)
+		apierror.WriteOpenAI(w, http.StatusInternalServerError, "failed to encode response", "server_error", "internal_error")
+		return
+	}
+	buf = append(buf, '\n')
+	w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	_, _ = w.Write(buf)
+	if cap(buf) <= maxPooledResponseBuf {
+		*bp = buf[:0]
+		responseBufPool.Put(bp)
+	}
+}
@@ -0,0 +1,70 @@
+package openaiapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func benchResponse() *providers.Response {
+	return &providers.Response{
+		ID:       "chatcmpl-bench",
+		Object:   "chat.completion",
+		Created:  1710000000,
+		Model:    "test-model",
+		Provider: "test",
+		Choices: []providers.Choice{{
+			Message: providers.Message{
+				Role:    "assistant",
+				Content: "Deployment completed successfully. One provider showed elevated p95 latency, but error rate remained stable.",
+			},
+			FinishReason: "stop",
+		}},
+		Usage: providers.Usage{PromptTokens: 128, CompletionTokens: 32, TotalTokens: 160},
+	}
+}
+
+func TestWriteChatResponse_MatchesEncoder(t *testing.T) {
+	resp := benchResponse()
+	var want bytes.Buffer
+	if err := json.NewEncoder(&want).Encode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	writeChatResponse(rec, resp)
+	if rec.Body.String() != want.String() {
+		t.Fatalf("body = %s, want %s", rec.Body.String(), want.String())
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(want.Len()) {
+		t.Fatalf("Content-Length = %s, want %d", got, want.Len())
+	}
+}
+
+func BenchmarkWriteChatResponse(b *testing.B) {
+	resp := benchResponse()
+	rec := httptest.NewRecorder()
+	rec.Body.Grow(1024)
+	b.ReportAllocs()
+	for b.Loop() {
+		rec.Body.Reset()
+		writeChatResponse(rec, resp)
+	}
+}
+
+// BenchmarkWriteChatResponse_Encoder is the json.Encoder write the handler
+// used before writeChatResponse, for comparison.
+func BenchmarkWriteChatResponse_Encoder(b *testing.B) {
+	resp := benchResponse()
+	rec := httptest.NewRecorder()
+	rec.Body.Grow(1024)
+	b.ReportAllocs()
+	for b.Loop() {
+		rec.Body.Reset()
+		_ = json.NewEncoder(rec).Encode(resp)
+	}
+}
@@ -0,0 +1,221 @@
+// Package openaiapi serves the OpenAI-compatible inference endpoints — chat
+// completions, embeddings, image generation, and moderation — over any
+// Gateway. It holds
+// the request decoding, validation, and response encoding shared by ferrogw's
+// router and the library's aigateway.Handler, so both behave identically. It
+// does not import the root package, which is what lets the root package
+// import it.
+package openaiapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/pluginmeta"
+	"github.com/ferro-labs/ai-gateway/internal/sse"
+	"github.com/ferro-labs/ai-gateway/internal/truncation"
+	"github.com/ferro-labs/ai-gateway/internal/upstreamhdr"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Gateway is the part of *aigateway.Gateway the handlers use.
+type Gateway interface {
+	FindByModel(model string) (providers.Provider, bool)
+	FindStreamingByModel(model string) (providers.StreamProvider, bool)
+	Route(ctx context.Context, req providers.Request) (*providers.Response, error)
+	RouteStream(ctx context.Context, req providers.Request) (<-chan providers.StreamChunk, error)
+	Embed(ctx context.Context, req providers.EmbeddingRequest) (*providers.EmbeddingResponse, error)
+	GenerateImage(ctx context.Context, req providers.ImageRequest) (*providers.ImageResponse, error)
+	Moderate(ctx context.Context, req providers.ModerationRequest) (*providers.ModerationResponse, error)
+}
+
+// ChatCompletions handles POST /v1/chat/completions. When streaming is false,
+// a request with "stream": true is refused with 400 rather than answered as
+// SSE.
+func ChatCompletions(gw Gateway, streaming bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, truncated := truncation.WithRecorder(r.Context())
+		ctx, upstream := upstreamhdr.WithRecorder(ctx)
+		ctx, pluginHeaders := pluginmeta.WithRecorder(ctx)
+		req, err := DecodeChatCompletionRequest(r.Body)
+		if err != nil {
+			WriteDecodeError(w, err)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_request")
+			return
+		}
+
+		// --- Streaming path ---
+		if req.Stream {
+			if !streaming {
+				apierror.WriteOpenAI(w, http.StatusBadRequest, "streaming is not enabled on this endpoint", "invalid_request_error", "streaming_not_supported")
+				return
+			}
+			if _, ok := gw.FindByModel(req.Model); !ok {
+				apierror.WriteOpenAI(w, http.StatusBadRequest, "no provider supports model: "+req.Model, "invalid_request_error", "model_not_found")
+				return
+			}
+			if _, ok := gw.FindStreamingByModel(req.Model); !ok {
+				apierror.WriteOpenAI(w, http.StatusBadRequest, "provider does not support streaming", "invalid_request_error", "streaming_not_supported")
+				return
+			}
+
+			ch, err := gw.RouteStream(ctx, req)
+			upstream.WriteHeaders(w.Header())
+			pluginHeaders.WriteHeaders(w.Header())
+			if err != nil {
+				status, errType, code := apierror.RouteErrorDetails(err)
+				apierror.WriteOpenAI(w, status, err.Error(), errType, code)
+				return
+			}
+			writeTruncationHeader(w, truncated)
+			sse.Write(r.Context(), w, ch)
+			return
+		}
+
+		// --- Non-streaming path ---
+		if _, ok := gw.FindByModel(req.Model); !ok {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, "no provider supports model: "+req.Model, "invalid_request_error", "model_not_found")
+			return
+		}
+
+		resp, err := gw.Route(ctx, req)
+		upstream.WriteHeaders(w.Header())
+		pluginHeaders.WriteHeaders(w.Header())
+		if err != nil {
+			status, errType, code := apierror.RouteErrorDetails(err)
+			apierror.WriteOpenAI(w, status, err.Error(), errType, code)
+			return
+		}
+
+		writeTruncationHeader(w, truncated)
+		if resp.OverheadMs > 0 {
+			w.Header().Set("X-Gateway-Overhead-Ms", fmt.Sprintf("%.3f", resp.OverheadMs))
+		}
+		writeCacheHeaders(w, resp.Cache)
+		w.Header().Set("Content-Type", "application/json")
+		writeChatResponse(w, resp)
+	}
+}
+
+// writeCacheHeaders surfaces the response cache's verdict to the client:
+// X-Ferro-Cache is hit, stale, or miss, X-Ferro-Cache-Key names the entry, Age
+// is the served entry's age in whole seconds (per RFC 9111), and
+// Cache-Control carries the plugin's optional freshness hint. Nothing is
+// written when no cache plugin handled the request.
+func writeCacheHeaders(w http.ResponseWriter, info *providers.CacheInfo) {
+	if info == nil {
+		return
+	}
+	w.Header().Set("X-Ferro-Cache", info.Status)
+	if info.Key != "" {
+		w.Header().Set("X-Ferro-Cache-Key", info.Key)
+	}
+	if info.Status != providers.CacheStatusMiss {
+		w.Header().Set("Age", strconv.FormatInt(int64(info.Age/time.Second), 10))
+	}
+	if info.Control != "" {
+		w.Header().Set("Cache-Control", info.Control)
+	}
+}
+
+// writeTruncationHeader tells the client its messages were shortened to fit
+// the model's context window, and how.
+func writeTruncationHeader(w http.ResponseWriter, rec *truncation.Recorder) {
+	if info, ok := rec.Info(); ok {
+		w.Header().Set(truncation.Header, info.HeaderValue())
+	}
+}
+
+// Embeddings handles POST /v1/embeddings.
+// It routes embedding requests to the first registered EmbeddingProvider that
+// supports the requested model.
+func Embeddings(gw Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req providers.EmbeddingRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Model == "" {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, "model is required", "invalid_request_error", "invalid_request")
+			return
+		}
+		if req.Input == nil {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, "input is required", "invalid_request_error", "invalid_request")
+			return
+		}
+
+		resp, err := gw.Embed(r.Context(), req)
+		if err != nil {
+			status, errType, code := apierror.RouteErrorDetails(err)
+			apierror.WriteOpenAI(w, status, err.Error(), errType, code)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// Images handles POST /v1/images/generations.
+// It routes image generation requests to the first registered ImageProvider that
+// supports the requested model.
+func Images(gw Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req providers.ImageRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Model == "" {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, "model is required", "invalid_request_error", "invalid_request")
+			return
+		}
+		if req.Prompt == "" {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, "prompt is required", "invalid_request_error", "invalid_request")
+			return
+		}
+
+		resp, err := gw.GenerateImage(r.Context(), req)
+		if err != nil {
+			status, errType, code := apierror.RouteErrorDetails(err)
+			apierror.WriteOpenAI(w, status, err.Error(), errType, code)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// Moderations handles POST /v1/moderations.
+// It routes moderation requests to a registered ModerationProvider; a request
+// without a model uses providers.DefaultModerationModel.
+func Moderations(gw Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req providers.ModerationRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Input == nil {
+			apierror.WriteOpenAI(w, http.StatusBadRequest, "input is required", "invalid_request_error", "invalid_request")
+			return
+		}
+
+		resp, err := gw.Moderate(r.Context(), req)
+		if err != nil {
+			status, errType, code := apierror.RouteErrorDetails(err)
+			apierror.WriteOpenAI(w, status, err.Error(), errType, code)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
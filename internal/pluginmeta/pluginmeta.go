@@ -0,0 +1,52 @@
+// Package pluginmeta carries the response headers plugins declare for the
+// client from the gateway core to the HTTP layer. The gateway filters them
+// through the operator's allowlist before recording them, so everything a
+// recorder holds is cleared for the client.
+package pluginmeta
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Recorder holds the allowlisted plugin headers of one request for the HTTP
+// layer, which must set its response headers before the body and so cannot
+// wait for the response. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	headers map[string]string
+}
+
+// WriteHeaders sets the recorded headers on h.
+func (r *Recorder) WriteHeaders(h http.Header) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, v := range r.headers {
+		h.Set(name, v)
+	}
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose request records its plugin headers in
+// the returned recorder.
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	rec := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, rec), rec
+}
+
+// Record stores headers in ctx's recorder, if it has one, replacing any
+// recorded before.
+func Record(ctx context.Context, headers map[string]string) {
+	rec, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok || len(headers) == 0 {
+		return
+	}
+	rec.mu.Lock()
+	rec.headers = headers
+	rec.mu.Unlock()
+}
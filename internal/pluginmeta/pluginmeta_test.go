@@ -0,0 +1,25 @@
+package pluginmeta
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx, rec := WithRecorder(context.Background())
+	Record(ctx, map[string]string{"X-Ferro-Guardrail": "word-filter=pass"})
+	h := http.Header{}
+	rec.WriteHeaders(h)
+	if got := h.Get("X-Ferro-Guardrail"); got != "word-filter=pass" {
+		t.Errorf("X-Ferro-Guardrail = %q, want word-filter=pass", got)
+	}
+
+	// Without a recorder, recording does nothing; a nil recorder writes nothing.
+	Record(context.Background(), map[string]string{"X-Ferro-Guardrail": "x"})
+	var none *Recorder
+	none.WriteHeaders(h)
+	if len(h) != 1 {
+		t.Errorf("headers = %v, want only the recorded one", h)
+	}
+}
@@ -50,6 +50,14 @@
 // The API key is read from pctx.Metadata["api_key"]. Requests without a key
 // are not subject to per-key spend tracking (they will not be rejected by
 // this plugin).
+//
+// # Quota events
+//
+// The completion whose cost takes a key's committed spend to the limit is
+// reported to the gateway's plugin.QuotaNotifier, which publishes
+// gateway.key.quota_exceeded. It is reported once per crossing: the requests
+// rejected afterwards are not, and a key reset with [ResetStoreKey] is
+// reported again when it next reaches the limit.
 package budget
 
 import (
@@ -102,12 +110,15 @@ func (s *spendStore) evictMinLocked(newKey string) {
 
 // add records usd worth of committed spend for key as a single atomic
 // read-modify-write under the store mutex. Concurrent completions for the
-// same key therefore never lose an increment (no lost-update race).
-func (s *spendStore) add(key string, usd float64) {
+// same key therefore never lose an increment (no lost-update race). It
+// returns the key's committed spend before and after the increment.
+func (s *spendStore) add(key string, usd float64) (before, after float64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.evictMinLocked(key)
-	s.spend[key] += usd
+	before = s.spend[key]
+	s.spend[key] = before + usd
+	return before, s.spend[key]
 }
 
 func (s *spendStore) get(key string) float64 {
@@ -169,6 +180,7 @@ type Plugin struct {
 	inputPerMTokens  float64
 	outputPerMTokens float64
 	store            *spendStore
+	notifier         plugin.QuotaNotifier
 }
 
 // Name returns the plugin identifier.
@@ -177,6 +189,10 @@ func (p *Plugin) Name() string { return "budget" }
 // Type returns the plugin lifecycle hook type.
 func (p *Plugin) Type() plugin.PluginType { return plugin.TypeRateLimit }
 
+// SetQuotaNotifier receives the gateway's QuotaNotifier, told when a key's
+// spend reaches the limit.
+func (p *Plugin) SetQuotaNotifier(n plugin.QuotaNotifier) { p.notifier = n }
+
 // Init reads the plugin configuration.
 func (p *Plugin) Init(config map[string]any) error {
 	p.storeID = "default"
@@ -238,7 +254,7 @@ func (p *Plugin) Init(config map[string]any) error {
 //
 // When pctx.Response is non-nil (after_request stage), it calculates the cost
 // of the completed request from token usage and adds it to the store.
-func (p *Plugin) Execute(_ context.Context, pctx *plugin.Context) error {
+func (p *Plugin) Execute(ctx context.Context, pctx *plugin.Context) error {
 	key, ok := pctx.Metadata["api_key"].(string)
 	if !ok || key == "" {
 		// No API key in context — skip per-key budget tracking.
@@ -251,7 +267,7 @@ func (p *Plugin) Execute(_ context.Context, pctx *plugin.Context) error {
 	}
 
 	// after_request stage: record cost from the completed request's usage.
-	p.recordCost(pctx, key)
+	p.recordCost(ctx, pctx, key)
 	return nil
 }
 
@@ -318,15 +334,20 @@ func (p *Plugin) checkBudget(pctx *plugin.Context, key string) error {
 
 // recordCost calculates the actual USD cost from token usage and adds it to
 // the store via a single atomic read-modify-write, so concurrent completions
-// for the same key never lose an increment.
-func (p *Plugin) recordCost(pctx *plugin.Context, key string) {
+// for the same key never lose an increment. Exactly one completion therefore
+// sees the spend cross the limit, and it reports the key to the notifier.
+func (p *Plugin) recordCost(ctx context.Context, pctx *plugin.Context, key string) {
 	usage, ok := usageFromContext(pctx)
 	if !ok {
 		return
 	}
 	actual := (float64(usage.PromptTokens)/1_000_000.0)*p.inputPerMTokens +
 		(float64(usage.CompletionTokens)/1_000_000.0)*p.outputPerMTokens
-	if actual > 0 {
-		p.store.add(key, actual)
+	if actual <= 0 {
+		return
+	}
+	before, after := p.store.add(key, actual)
+	if p.notifier != nil && p.spendLimitUSD > 0 && before < p.spendLimitUSD && after >= p.spendLimitUSD {
+		p.notifier.QuotaExceeded(ctx, key, fmt.Sprintf("budget exceeded: spent $%.4f of $%.2f limit", after, p.spendLimitUSD))
 	}
 }
@@ -127,6 +127,50 @@ func TestBudget_RecordAndExceed(t *testing.T) {
 	}
 }
 
+// quotaRecorder is a plugin.QuotaNotifier that keeps the keys it is told of.
+type quotaRecorder struct {
+	keys []string
+}
+
+func (r *quotaRecorder) QuotaExceeded(_ context.Context, keyID, _ string) {
+	r.keys = append(r.keys, keyID)
+}
+
+func TestBudget_QuotaExceededOncePerCrossing(t *testing.T) {
+	p := makePlugin(t, map[string]any{
+		"store_id":            "test-quota-notify",
+		"spend_limit_usd":     0.001,
+		"input_per_m_tokens":  3.0,
+		"output_per_m_tokens": 15.0,
+	})
+	notified := &quotaRecorder{}
+	p.SetQuotaNotifier(notified)
+	apiKey := "key-quota"
+	record := func(prompt, completion int) {
+		pctx := pctxWithKey(apiKey)
+		pctx.Response = &providers.Response{Usage: providers.Usage{PromptTokens: prompt, CompletionTokens: completion}}
+		if err := p.Execute(context.Background(), pctx); err != nil {
+			t.Fatalf("after_request: %v", err)
+		}
+	}
+
+	record(100, 0) // $0.0003: under the limit
+	if len(notified.keys) != 0 {
+		t.Fatalf("notified under the limit: %v", notified.keys)
+	}
+	record(100, 50) // $0.00135: crosses it
+	record(100, 50) // already over: not reported again
+	if len(notified.keys) != 1 || notified.keys[0] != apiKey {
+		t.Fatalf("notified = %v, want the key once", notified.keys)
+	}
+
+	ResetStoreKey("test-quota-notify", apiKey)
+	record(100, 50)
+	if len(notified.keys) != 2 {
+		t.Fatalf("a reset key should be reported when it reaches the limit again: %v", notified.keys)
+	}
+}
+
 func TestBudget_RecordsUsageFromMetadata_NonChatSurface(t *testing.T) {
 	// Non-chat surfaces (embeddings) carry no chat Response; token usage arrives
 	// through Metadata["usage"]. Budget must gate and record cost from it exactly
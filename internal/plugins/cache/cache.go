@@ -3,14 +3,33 @@
 // and latency for repeated requests. Register it with a blank import:
 //
 //	_ "github.com/ferro-labs/ai-gateway/internal/plugins/cache"
+//
+// Every response the plugin handles carries a providers.CacheInfo verdict
+// (hit or miss), which the HTTP layer surfaces as the X-Ferro-Cache and Age
+// headers, and the verdict is recorded in plugin metadata under "cache_status"
+// so logging plugins and hook consumers can compute hit rates.
+//
+// Entries are namespaced by the caller's API key ID (Metadata["api_key"]), so
+// two tenants sending the identical request never share a cached completion.
+// Requests without a key share the unnamespaced bucket. Operators inspect and
+// purge the cache, and override per-namespace TTLs, through /admin/cache.
+//
+// Two options protect the provider when a hot entry expires. With
+// stale_while_revalidate set, an expired entry is kept for that many seconds:
+// the first request after expiry refreshes it while concurrent requests are
+// served the stale copy (X-Ferro-Cache: stale). With coalesce enabled,
+// identical requests that miss outright wait for the first one's response
+// instead of each calling the provider.
 package cache
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"hash"
 	"sort"
 	"strconv"
@@ -32,8 +51,38 @@ func init() {
 // It aliases Memory from the internal cache package.
 type ResponseCache struct {
 	*internalCache.Memory
+	// cacheControl, when true, stamps a Cache-Control hint on every response
+	// the plugin handles so clients can reuse it for its remaining freshness.
+	cacheControl bool
+	// coalesce makes identical concurrent misses wait for one provider call.
+	coalesce bool
+	// coalesceWait bounds how long a coalesced request waits for the leader.
+	coalesceWait time.Duration
+	flights      *flightGroup
+}
+
+// refreshLease marks the request that leads a refresh of one entry. It rides
+// in plugin metadata from before_request to the stage that releases it.
+type refreshLease struct {
+	namespace string
+	key       string
+	flight    *flight
 }
 
+// refreshLeaseKey is the Metadata key holding the request's *refreshLease.
+const refreshLeaseKey = "cache_refresh"
+
+// requestKeyKey is the Metadata key holding the cache key computed before the
+// request. The gateway rewrites the request after the before stage (a
+// fallback model replaces the requested one, truncation drops turns), so the
+// after stage stores under this key rather than one computed from the
+// rewritten request.
+const requestKeyKey = "cache_request_key"
+
+// defaultCoalesceWait is how long a coalesced request waits for the leader
+// before calling the provider itself.
+const defaultCoalesceWait = 10 * time.Second
+
 // Name returns the plugin identifier.
 func (c *ResponseCache) Name() string {
 	return "response-cache"
@@ -64,43 +113,189 @@ func (c *ResponseCache) Init(config map[string]any) error {
 		capacity = int(v)
 	}
 	c.Memory = internalCache.NewMemory(capacity, ttl)
+	c.cacheControl, _ = config["cache_control"].(bool)
+	c.coalesce, _ = config["coalesce"].(bool)
+
+	switch v := config["stale_while_revalidate"].(type) {
+	case int:
+		c.Grace = time.Duration(v) * time.Second
+	case float64:
+		c.Grace = time.Duration(v * float64(time.Second))
+	}
+	if c.Grace < 0 {
+		return fmt.Errorf("stale_while_revalidate must not be negative")
+	}
+
+	c.coalesceWait = defaultCoalesceWait
+	switch v := config["coalesce_timeout"].(type) {
+	case int:
+		c.coalesceWait = time.Duration(v) * time.Second
+	case float64:
+		c.coalesceWait = time.Duration(v * float64(time.Second))
+	}
+	if c.coalesceWait <= 0 {
+		return fmt.Errorf("coalesce_timeout must be positive")
+	}
+	c.flights = newFlightGroup(c.coalesceWait)
+
+	// namespace_ttls maps a namespace (API key ID) to its TTL in seconds.
+	if raw, ok := config["namespace_ttls"].(map[string]any); ok {
+		for ns, v := range raw {
+			var secs int
+			switch n := v.(type) {
+			case int:
+				secs = n
+			case float64:
+				secs = int(n)
+			default:
+				return fmt.Errorf("namespace_ttls[%q] must be a number of seconds", ns)
+			}
+			if secs <= 0 {
+				return fmt.Errorf("namespace_ttls[%q] must be positive", ns)
+			}
+			c.SetNamespaceTTL(ns, time.Duration(secs)*time.Second)
+		}
+	}
 	return nil
 }
 
 // Execute checks for a cache hit (before request) or stores the response (after request) and does maintenance as per LRU policy.
-func (c *ResponseCache) Execute(_ context.Context, pctx *plugin.Context) error {
+func (c *ResponseCache) Execute(ctx context.Context, pctx *plugin.Context) error {
 	if pctx.Request == nil {
 		return nil
 	}
 
-	key := cacheKey(pctx.Request)
+	if pctx.Error != nil && pctx.Response == nil {
+		// on_error: a leader that failed hands the refresh to the next caller.
+		c.releaseLease(pctx)
+		return nil
+	}
+
+	namespace, _ := pctx.Metadata["api_key"].(string)
 
 	if pctx.Response == nil {
 		// before_request: lookup
-		if resp, ok := c.Get(key); ok {
-			pctx.Response = cloneResponse(resp)
-			pctx.Skip = true
-			pctx.Metadata["cache_hit"] = true
-		}
+		key := cacheKey(pctx.Request)
+		pctx.Metadata[requestKeyKey] = key
+		c.lookup(ctx, pctx, namespace, key)
 		return nil
 	}
+	key, ok := pctx.Metadata[requestKeyKey].(string)
+	if !ok {
+		key = cacheKey(pctx.Request)
+	}
 
 	// after_request: store
 	if pctx.Metadata["cache_hit"] == true {
 		return nil
 	}
 
-	if c.Capacity <= 0 {
-		return nil
+	// A streamed response cut at the capture limit is not the whole answer.
+	truncated, _ := pctx.Metadata["response_truncated"].(bool)
+	if c.Capacity > 0 && !truncated {
+		// Store a private copy: the caller's resp keeps being mutated after this
+		// call returns (e.g. Route/RouteStream stamp OverheadMs post-RunAfter), so
+		// the cache must not hold onto the same pointer.
+		c.SetInFor(namespace, key, pctx.Request.User, cloneResponse(pctx.Response))
+	}
+	c.releaseLease(pctx)
+	pctx.Response.Cache = &providers.CacheInfo{
+		Status:  providers.CacheStatusMiss,
+		Key:     key,
+		Control: c.cacheControlHeader(c.TTLFor(namespace)),
 	}
-
-	// Store a private copy: the caller's resp keeps being mutated after this
-	// call returns (e.g. Route/RouteStream stamp OverheadMs post-RunAfter), so
-	// the cache must not hold onto the same pointer.
-	c.Set(key, cloneResponse(pctx.Response))
 	return nil
 }
 
+// lookup serves a fresh entry, or a stale one while another request
+// refreshes it, or lets the request through to the provider as a miss. With
+// coalescing on, a miss whose refresh is already in flight waits for it.
+func (c *ResponseCache) lookup(ctx context.Context, pctx *plugin.Context, namespace, key string) {
+	resp, age, stale, ok := c.GetStale(namespace, key)
+	if ok && !stale {
+		c.serve(pctx, resp, age, namespace, key, providers.CacheStatusHit)
+		return
+	}
+
+	if ok || c.coalesce {
+		f, leader := c.flights.claim(namespace, key)
+		if leader {
+			c.takeLease(ctx, pctx, namespace, key, f)
+		} else if ok {
+			c.serve(pctx, resp, age, namespace, key, providers.CacheStatusStale)
+			return
+		} else if c.awaitFlight(ctx, f) {
+			if resp, age, ok := c.GetIn(namespace, key); ok {
+				c.serve(pctx, resp, age, namespace, key, providers.CacheStatusHit)
+				return
+			}
+		}
+	}
+	pctx.Metadata["cache_status"] = providers.CacheStatusMiss
+}
+
+func (c *ResponseCache) serve(pctx *plugin.Context, resp *providers.Response, age time.Duration, namespace, key, status string) {
+	hit := cloneResponse(resp)
+	hit.Cache = &providers.CacheInfo{
+		Status:  status,
+		Age:     age,
+		Key:     key,
+		Control: c.cacheControlHeader(c.TTLFor(namespace) - age),
+	}
+	pctx.Response = hit
+	pctx.Skip = true
+	pctx.Metadata["cache_hit"] = true
+	pctx.Metadata["cache_status"] = status
+	pctx.Mutation = "served a cached response (" + status + ")"
+}
+
+// takeLease makes this request the entry's refresher. The lease is released
+// when the response is stored or the request fails, and at the latest when
+// the request's context ends, so a leader that never reaches a later stage
+// cannot hold the key.
+func (c *ResponseCache) takeLease(ctx context.Context, pctx *plugin.Context, namespace, key string, f *flight) {
+	pctx.Metadata[refreshLeaseKey] = &refreshLease{namespace: namespace, key: key, flight: f}
+	context.AfterFunc(ctx, func() { c.flights.release(namespace, key, f) })
+}
+
+func (c *ResponseCache) releaseLease(pctx *plugin.Context) {
+	lease, ok := pctx.Metadata[refreshLeaseKey].(*refreshLease)
+	if !ok {
+		return
+	}
+	delete(pctx.Metadata, refreshLeaseKey)
+	c.flights.release(lease.namespace, lease.key, lease.flight)
+}
+
+// awaitFlight waits for the leader's refresh, reporting false if the wait
+// times out or the caller goes away first.
+func (c *ResponseCache) awaitFlight(ctx context.Context, f *flight) bool {
+	timer := time.NewTimer(c.coalesceWait)
+	defer timer.Stop()
+	select {
+	case <-f.done:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// cacheControlHeader renders the Cache-Control hint for a response that stays
+// fresh for another remaining, or "" when hints are disabled. The response is
+// specific to the caller's request, so it is marked private: a shared proxy
+// must never serve one tenant's completion to another.
+func (c *ResponseCache) cacheControlHeader(remaining time.Duration) string {
+	if !c.cacheControl {
+		return ""
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return "private, max-age=" + strconv.Itoa(int(remaining/time.Second))
+}
+
 // Close releases plugin resources.
 func (c *ResponseCache) Close() error { return nil }
 
@@ -108,9 +303,11 @@ func (c *ResponseCache) Close() error { return nil }
 // top-level struct. Route/RouteStream stamp Object/Created/OverheadMs on the
 // response returned from a cache hit; Choices/Metadata/Usage are never
 // mutated post-hit, so a shallow copy is sufficient to remove the race on a
-// cache entry shared across concurrent callers.
+// cache entry shared across concurrent callers. The per-request cache verdict
+// is dropped so a stored entry never carries a stale one.
 func cloneResponse(resp *providers.Response) *providers.Response {
 	clone := *resp
+	clone.Cache = nil
 	return &clone
 }
 
@@ -118,6 +315,12 @@ func cacheKey(req *providers.Request) string {
 	h := sha256.New()
 	writeCacheKeyString(h, "model")
 	writeCacheKeyString(h, req.Model)
+	if len(req.FallbackModels) > 0 {
+		// A request with fallback models may be answered by any of them, so
+		// its entries are kept apart from those of the bare model. Only
+		// written when set, so keys of requests without them are unchanged.
+		writeCacheKeyStringSlice(h, "fallback_models", req.FallbackModels)
+	}
 	writeCacheKeyString(h, "messages")
 	writeCacheKeyInt(h, len(req.Messages))
 	for _, m := range req.Messages {
@@ -127,10 +330,20 @@ func cacheKey(req *providers.Request) string {
 	}
 	writeCacheKeyOptionalFloat64(h, "temperature", req.Temperature)
 	writeCacheKeyOptionalFloat64(h, "top_p", req.TopP)
+	if req.TopK != nil {
+		// Only written when set, so keys of requests without it are unchanged.
+		writeCacheKeyOptionalInt(h, "top_k", req.TopK)
+	}
 	writeCacheKeyOptionalInt(h, "n", req.N)
 	writeCacheKeyOptionalInt64(h, "seed", req.Seed)
 	writeCacheKeyOptionalInt(h, "max_tokens", req.MaxTokens)
 	writeCacheKeyOptionalInt(h, "max_completion_tokens", req.MaxCompletionTokens)
+	writeCacheKeyString(h, "reasoning_effort")
+	writeCacheKeyString(h, req.ReasoningEffort)
+	if req.Thinking != nil {
+		// Only written when set, so keys of requests without it are unchanged.
+		writeCacheKeyJSON(h, "thinking", req.Thinking)
+	}
 	writeCacheKeyOptionalFloat64(h, "presence_penalty", req.PresencePenalty)
 	writeCacheKeyOptionalFloat64(h, "frequency_penalty", req.FrequencyPenalty)
 	writeCacheKeyStringSlice(h, "stop", req.Stop)
@@ -143,6 +356,10 @@ func cacheKey(req *providers.Request) string {
 	writeCacheKeyString(h, "user")
 	writeCacheKeyString(h, req.User)
 	writeCacheKeyFloatMap(h, "logit_bias", req.LogitBias)
+	if len(req.Extra) > 0 {
+		// Only written when set, so keys of requests without it are unchanged.
+		writeCacheKeyRawMap(h, "extra", req.Extra)
+	}
 
 	return hex.EncodeToString(h.Sum(nil))
 }
@@ -205,6 +422,28 @@ func writeCacheKeyFloatMap(h hash.Hash, label string, values map[string]float64)
 	}
 }
 
+// writeCacheKeyRawMap writes values in key order, each compacted so
+// whitespace differences in the client's JSON do not split entries.
+func writeCacheKeyRawMap(h hash.Hash, label string, values map[string]json.RawMessage) {
+	writeCacheKeyString(h, label)
+	writeCacheKeyInt(h, len(values))
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, key := range keys {
+		writeCacheKeyString(h, key)
+		buf.Reset()
+		if err := json.Compact(&buf, values[key]); err != nil {
+			writeCacheKeyString(h, string(values[key]))
+			continue
+		}
+		writeCacheKeyString(h, buf.String())
+	}
+}
+
 func writeCacheKeyJSON(h hash.Hash, label string, v any) {
 	writeCacheKeyString(h, label)
 	b, err := json.Marshal(v)
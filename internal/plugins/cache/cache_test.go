@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"testing"
@@ -119,6 +120,64 @@ func TestResponseCache_CacheHitAfterStore(t *testing.T) {
 	}
 }
 
+// TestResponseCache_StoresUnderRequestKey verifies the after stage stores
+// under the key the before stage looked up, though the gateway served the
+// request with a fallback model and truncated its turns in between.
+func TestResponseCache_StoresUnderRequestKey(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{})
+	newReq := func() *providers.Request {
+		req := testRequest("gpt-4", "hello")
+		req.FallbackModels = []string{"claude-3-5-sonnet"}
+		return req
+	}
+
+	req := newReq()
+	pctx := plugin.NewContext(req)
+	if err := c.Execute(context.Background(), pctx); err != nil || pctx.Skip {
+		t.Fatalf("lookup: err %v, skip %v; want a miss", err, pctx.Skip)
+	}
+	req.Model, req.FallbackModels, req.Messages = "claude-3-5-sonnet", nil, nil
+	pctx.Response = testResponse()
+	if err := c.Execute(context.Background(), pctx); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+
+	lookup := plugin.NewContext(newReq())
+	if err := c.Execute(context.Background(), lookup); err != nil || !lookup.Skip {
+		t.Fatalf("repeat request: err %v, skip %v; want a hit", err, lookup.Skip)
+	}
+	bare := plugin.NewContext(testRequest("gpt-4", "hello"))
+	if err := c.Execute(context.Background(), bare); err != nil || bare.Skip {
+		t.Fatalf("request without fallbacks: err %v, skip %v; want a miss", err, bare.Skip)
+	}
+}
+
+// TestResponseCache_SkipsTruncatedResponse verifies a streamed response the
+// gateway cut at its capture limit is not stored.
+func TestResponseCache_SkipsTruncatedResponse(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{})
+	req := testRequest("gpt-4", "hello")
+
+	storePctx := plugin.NewContext(req)
+	storePctx.Response = testResponse()
+	storePctx.Metadata["response_truncated"] = true
+	if err := c.Execute(context.Background(), storePctx); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+
+	lookupPctx := plugin.NewContext(req)
+	if err := c.Execute(context.Background(), lookupPctx); err != nil {
+		t.Fatalf("Execute (lookup) error: %v", err)
+	}
+	if lookupPctx.Skip || lookupPctx.Response != nil {
+		t.Error("a truncated response was served from the cache")
+	}
+}
+
 // TestResponseCache_ConcurrentCacheHits_NoDataRace reproduces the scenario where
 // Route/RouteStream stamp Object/Created/OverheadMs on a cache-hit response
 // (see gateway_route.go and gateway_stream.go). Before the fix, every hit
@@ -471,6 +530,9 @@ func TestResponseCache_CacheHitMetadata(t *testing.T) {
 	if !ok || !hit {
 		t.Errorf("expected cache_hit=true in metadata, got %v", lookupPctx.Metadata["cache_hit"])
 	}
+	if lookupPctx.Mutation != "served a cached response (hit)" {
+		t.Errorf("expected a mutation note for the served response, got %q", lookupPctx.Mutation)
+	}
 }
 
 // --- logprobs cache-key coverage (issue #152) ---
@@ -549,6 +611,19 @@ func TestCacheKey_OutputParamsProduceDistinctKeys(t *testing.T) {
 				req.TopP = &v
 			},
 		},
+		{
+			name: "top_k",
+			mutate: func(req *providers.Request) {
+				v := 40
+				req.TopK = &v
+			},
+		},
+		{
+			name: "extra",
+			mutate: func(req *providers.Request) {
+				req.Extra = map[string]json.RawMessage{"repetition_penalty": json.RawMessage(`1.1`)}
+			},
+		},
 		{
 			name: "seed",
 			mutate: func(req *providers.Request) {
@@ -569,6 +644,12 @@ func TestCacheKey_OutputParamsProduceDistinctKeys(t *testing.T) {
 				req.Stop = []string{"DONE"}
 			},
 		},
+		{
+			name: "fallback_models",
+			mutate: func(req *providers.Request) {
+				req.FallbackModels = []string{"claude-3-5-sonnet"}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -632,6 +713,87 @@ func TestResponseCache_LogProbsCacheMissWithoutLogprobs(t *testing.T) {
 	}
 }
 
+// TestResponseCache_TopKCacheMiss verifies that a cached response is not
+// served to a request for the same model/messages with a different top_k.
+func TestResponseCache_TopKCacheMiss(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{})
+	withTopK := func(k int) *providers.Request {
+		return &providers.Request{
+			Model:    "gpt-4",
+			Messages: []providers.Message{{Role: "user", Content: "what is 2+2"}},
+			TopK:     &k,
+		}
+	}
+
+	storePctx := plugin.NewContext(withTopK(10))
+	storePctx.Response = testResponse()
+	if err := c.Execute(context.Background(), storePctx); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+
+	for name, req := range map[string]*providers.Request{
+		"top_k=40":    withTopK(40),
+		"top_k unset": testRequest("gpt-4", "what is 2+2"),
+	} {
+		lookupPctx := plugin.NewContext(req)
+		if err := c.Execute(context.Background(), lookupPctx); err != nil {
+			t.Fatalf("Execute (lookup %s) error: %v", name, err)
+		}
+		if lookupPctx.Skip {
+			t.Errorf("%s request must not hit a top_k=10 cache entry", name)
+		}
+	}
+}
+
+// TestResponseCache_ExtraCacheKey verifies that extra_body parameters split
+// cache entries by value, while key order and whitespace do not.
+func TestResponseCache_ExtraCacheKey(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{})
+	withExtra := func(extra map[string]json.RawMessage) *providers.Request {
+		req := testRequest("gpt-4", "what is 2+2")
+		req.Extra = extra
+		return req
+	}
+
+	storePctx := plugin.NewContext(withExtra(map[string]json.RawMessage{
+		"repetition_penalty": json.RawMessage(`1.1`),
+		"min_p":              json.RawMessage(`{"value": 0.05}`),
+	}))
+	storePctx.Response = testResponse()
+	if err := c.Execute(context.Background(), storePctx); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+
+	lookups := []struct {
+		name string
+		req  *providers.Request
+		hit  bool
+	}{
+		{"same extra", withExtra(map[string]json.RawMessage{
+			"min_p":              json.RawMessage(`{"value":0.05}`),
+			"repetition_penalty": json.RawMessage(`1.1`),
+		}), true},
+		{"different value", withExtra(map[string]json.RawMessage{
+			"repetition_penalty": json.RawMessage(`1.3`),
+			"min_p":              json.RawMessage(`{"value": 0.05}`),
+		}), false},
+		{"no extra", testRequest("gpt-4", "what is 2+2"), false},
+	}
+	for _, tt := range lookups {
+		lookupPctx := plugin.NewContext(tt.req)
+		if err := c.Execute(context.Background(), lookupPctx); err != nil {
+			t.Fatalf("Execute (lookup %s) error: %v", tt.name, err)
+		}
+		if lookupPctx.Skip != tt.hit {
+			t.Errorf("%s: cache hit = %v, want %v", tt.name, lookupPctx.Skip, tt.hit)
+		}
+	}
+}
+
 // TestResponseCache_LogProbsCacheHit verifies that a cached response from a
 // logprobs=true request is served to an identical logprobs=true request.
 func TestResponseCache_LogProbsCacheHit(t *testing.T) {
@@ -692,3 +854,267 @@ func TestResponseCache_MaxEntriesMany(t *testing.T) {
 		t.Fatalf("expected %d entries after overflow, got %d", maxEntries, c.Len())
 	}
 }
+
+func TestResponseCache_CacheInfoMissThenHit(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{"max_age": 60, "cache_control": true})
+	req := testRequest("gpt-4", "hello")
+
+	missPctx := plugin.NewContext(req)
+	if err := c.Execute(context.Background(), missPctx); err != nil {
+		t.Fatalf("Execute (lookup) error: %v", err)
+	}
+	if got := missPctx.Metadata["cache_status"]; got != providers.CacheStatusMiss {
+		t.Errorf("expected cache_status=miss on lookup miss, got %v", got)
+	}
+
+	missPctx.Response = testResponse()
+	if err := c.Execute(context.Background(), missPctx); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+	info := missPctx.Response.Cache
+	if info == nil || info.Status != providers.CacheStatusMiss {
+		t.Fatalf("expected miss CacheInfo on stored response, got %+v", info)
+	}
+	if info.Control != "private, max-age=60" {
+		t.Errorf("unexpected miss Cache-Control %q", info.Control)
+	}
+
+	hitPctx := plugin.NewContext(req)
+	if err := c.Execute(context.Background(), hitPctx); err != nil {
+		t.Fatalf("Execute (hit) error: %v", err)
+	}
+	info = hitPctx.Response.Cache
+	if info == nil || info.Status != providers.CacheStatusHit {
+		t.Fatalf("expected hit CacheInfo, got %+v", info)
+	}
+	if info.Age < 0 || info.Age > time.Second {
+		t.Errorf("unexpected hit age %v", info.Age)
+	}
+	if info.Control != "private, max-age=60" && info.Control != "private, max-age=59" {
+		t.Errorf("unexpected hit Cache-Control %q", info.Control)
+	}
+	if got := hitPctx.Metadata["cache_status"]; got != providers.CacheStatusHit {
+		t.Errorf("expected cache_status=hit, got %v", got)
+	}
+}
+
+func TestResponseCache_CacheControlDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{})
+	pctx := plugin.NewContext(testRequest("gpt-4", "hello"))
+	pctx.Response = testResponse()
+	if err := c.Execute(context.Background(), pctx); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+	if pctx.Response.Cache == nil || pctx.Response.Cache.Control != "" {
+		t.Errorf("expected empty Cache-Control hint by default, got %+v", pctx.Response.Cache)
+	}
+}
+
+func TestResponseCache_NamespacedByAPIKey(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{})
+	req := testRequest("gpt-4", "hello")
+
+	storePctx := plugin.NewContext(req)
+	storePctx.Metadata["api_key"] = "key-a"
+	storePctx.Response = testResponse()
+	if err := c.Execute(context.Background(), storePctx); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+
+	other := plugin.NewContext(req)
+	other.Metadata["api_key"] = "key-b"
+	if err := c.Execute(context.Background(), other); err != nil {
+		t.Fatalf("Execute (other tenant) error: %v", err)
+	}
+	if other.Skip {
+		t.Fatal("a different API key was served another tenant's cached response")
+	}
+
+	same := plugin.NewContext(req)
+	same.Metadata["api_key"] = "key-a"
+	if err := c.Execute(context.Background(), same); err != nil {
+		t.Fatalf("Execute (same tenant) error: %v", err)
+	}
+	if !same.Skip {
+		t.Fatal("expected a hit for the API key that stored the entry")
+	}
+}
+
+func TestResponseCache_NamespaceTTLsConfig(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{"namespace_ttls": map[string]any{"key-a": float64(30)}})
+	if got := c.TTLFor("key-a"); got != 30*time.Second {
+		t.Errorf("TTLFor(key-a) = %v, want 30s", got)
+	}
+
+	bad := &ResponseCache{}
+	if err := bad.Init(map[string]any{"namespace_ttls": map[string]any{"key-a": "soon"}}); err == nil {
+		t.Error("expected a non-numeric namespace TTL to fail Init")
+	}
+}
+
+func TestResponseCache_StaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	c := initCache(t, map[string]any{"max_age": 10, "stale_while_revalidate": 30})
+	c.SetNowForTest(func() time.Time { return now })
+	req := testRequest("gpt-4", "hello")
+
+	store := plugin.NewContext(req)
+	store.Response = testResponse()
+	if err := c.Execute(context.Background(), store); err != nil {
+		t.Fatalf("Execute (store) error: %v", err)
+	}
+
+	now = now.Add(15 * time.Second) // expired, inside the grace window
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	refresher := plugin.NewContext(req)
+	if err := c.Execute(ctx, refresher); err != nil {
+		t.Fatalf("Execute (refresher) error: %v", err)
+	}
+	if refresher.Skip {
+		t.Fatal("the first request after expiry must go to the provider to refresh")
+	}
+
+	waiter := plugin.NewContext(req)
+	if err := c.Execute(context.Background(), waiter); err != nil {
+		t.Fatalf("Execute (concurrent) error: %v", err)
+	}
+	if !waiter.Skip || waiter.Response.Cache.Status != providers.CacheStatusStale {
+		t.Fatalf("expected concurrent request to be served stale, got skip=%v cache=%+v", waiter.Skip, waiter.Response)
+	}
+	if waiter.Response.Cache.Age != 15*time.Second {
+		t.Errorf("stale age = %v, want 15s", waiter.Response.Cache.Age)
+	}
+
+	refresher.Response = testResponse()
+	if err := c.Execute(ctx, refresher); err != nil {
+		t.Fatalf("Execute (refresh store) error: %v", err)
+	}
+	fresh := plugin.NewContext(req)
+	if err := c.Execute(context.Background(), fresh); err != nil {
+		t.Fatalf("Execute (after refresh) error: %v", err)
+	}
+	if !fresh.Skip || fresh.Response.Cache.Status != providers.CacheStatusHit {
+		t.Fatalf("expected a fresh hit after refresh, got %+v", fresh.Response)
+	}
+}
+
+func TestResponseCache_StaleLeaseReleasedOnError(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	c := initCache(t, map[string]any{"max_age": 10, "stale_while_revalidate": 30})
+	c.SetNowForTest(func() time.Time { return now })
+	req := testRequest("gpt-4", "hello")
+
+	store := plugin.NewContext(req)
+	store.Response = testResponse()
+	_ = c.Execute(context.Background(), store)
+	now = now.Add(15 * time.Second)
+
+	failed := plugin.NewContext(req)
+	_ = c.Execute(context.Background(), failed)
+	failed.Error = fmt.Errorf("provider down")
+	_ = c.Execute(context.Background(), failed)
+
+	next := plugin.NewContext(req)
+	_ = c.Execute(context.Background(), next)
+	if next.Skip {
+		t.Fatal("after the refresher failed, the next request should take over the refresh")
+	}
+}
+
+func TestResponseCache_CoalescesConcurrentMisses(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{"coalesce": true})
+	req := testRequest("gpt-4", "hello")
+
+	leader := plugin.NewContext(req)
+	if err := c.Execute(context.Background(), leader); err != nil {
+		t.Fatalf("Execute (leader) error: %v", err)
+	}
+	if leader.Skip {
+		t.Fatal("leader must reach the provider")
+	}
+
+	const followers = 8
+	var wg sync.WaitGroup
+	results := make([]*plugin.Context, followers)
+	for i := range followers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pctx := plugin.NewContext(req)
+			_ = c.Execute(context.Background(), pctx)
+			results[i] = pctx
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	leader.Response = testResponse()
+	if err := c.Execute(context.Background(), leader); err != nil {
+		t.Fatalf("Execute (leader store) error: %v", err)
+	}
+	wg.Wait()
+
+	for i, pctx := range results {
+		if !pctx.Skip || pctx.Response.Cache.Status != providers.CacheStatusHit {
+			t.Errorf("follower %d was not served the leader's response", i)
+		}
+	}
+}
+
+func TestResponseCache_CoalesceWaitTimesOut(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{"coalesce": true, "coalesce_timeout": 0.05})
+	req := testRequest("gpt-4", "hello")
+
+	leader := plugin.NewContext(req)
+	_ = c.Execute(context.Background(), leader)
+
+	start := time.Now()
+	follower := plugin.NewContext(req)
+	_ = c.Execute(context.Background(), follower)
+	if follower.Skip {
+		t.Fatal("follower with no leader response should fall through to the provider")
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Errorf("follower waited %v, expected about the 50ms coalesce timeout", waited)
+	}
+}
+
+func TestResponseCache_LeaseReleasedWhenLeaderContextEnds(t *testing.T) {
+	t.Parallel()
+
+	c := initCache(t, map[string]any{"coalesce": true})
+	req := testRequest("gpt-4", "hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := plugin.NewContext(req)
+	_ = c.Execute(ctx, leader)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = c.Execute(context.Background(), plugin.NewContext(req))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("follower still waiting after the leader's context ended")
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// flight is one in-progress provider call that will populate a cache entry.
+// done closes when the leader stores the response, fails, or goes away.
+type flight struct {
+	done    chan struct{}
+	started time.Time
+	once    sync.Once
+}
+
+func (f *flight) finish() {
+	f.once.Do(func() { close(f.done) })
+}
+
+type flightKey struct {
+	namespace string
+	key       string
+}
+
+// flightGroup tracks which cache entries have a refresh in flight so that,
+// of many identical concurrent requests, only one reaches the provider.
+//
+// A flight the leader never finishes — a caller whose context is never
+// cancelled and whose response never reaches after_request — would otherwise
+// block the key forever, so a flight older than maxAge is treated as
+// abandoned and the next caller takes over.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[flightKey]*flight
+	maxAge  time.Duration
+	now     func() time.Time
+}
+
+func newFlightGroup(maxAge time.Duration) *flightGroup {
+	return &flightGroup{
+		flights: make(map[flightKey]*flight),
+		maxAge:  maxAge,
+		now:     time.Now,
+	}
+}
+
+// claim returns the flight for the entry and whether the caller leads it. A
+// leader must eventually call release.
+func (g *flightGroup) claim(namespace, key string) (*flight, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	k := flightKey{namespace: namespace, key: key}
+	now := g.now()
+	if f, ok := g.flights[k]; ok && now.Sub(f.started) < g.maxAge {
+		return f, false
+	} else if ok {
+		f.finish()
+	}
+	f := &flight{done: make(chan struct{}), started: now}
+	g.flights[k] = f
+	return f, true
+}
+
+// release finishes f and forgets it, unless a newer flight has already taken
+// its place. Safe to call more than once.
+func (g *flightGroup) release(namespace, key string, f *flight) {
+	g.mu.Lock()
+	k := flightKey{namespace: namespace, key: key}
+	if g.flights[k] == f {
+		delete(g.flights, k)
+	}
+	g.mu.Unlock()
+	f.finish()
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlightGroup_ClaimAndRelease(t *testing.T) {
+	t.Parallel()
+
+	g := newFlightGroup(time.Minute)
+	f, leader := g.claim("ns", "k")
+	if !leader {
+		t.Fatal("first claim should lead")
+	}
+	if other, leader := g.claim("ns", "k"); leader || other != f {
+		t.Fatal("second claim should join the existing flight")
+	}
+	if _, leader := g.claim("other-ns", "k"); !leader {
+		t.Fatal("a different namespace must not share the flight")
+	}
+
+	g.release("ns", "k", f)
+	g.release("ns", "k", f) // idempotent
+	select {
+	case <-f.done:
+	default:
+		t.Fatal("release did not finish the flight")
+	}
+	if _, leader := g.claim("ns", "k"); !leader {
+		t.Fatal("claim after release should lead a new flight")
+	}
+}
+
+func TestFlightGroup_AbandonedFlightIsTakenOver(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	g := newFlightGroup(10 * time.Second)
+	g.now = func() time.Time { return now }
+
+	stale, _ := g.claim("ns", "k")
+	now = now.Add(11 * time.Second)
+	fresh, leader := g.claim("ns", "k")
+	if !leader || fresh == stale {
+		t.Fatal("a flight older than maxAge should be replaced")
+	}
+	select {
+	case <-stale.done:
+	default:
+		t.Fatal("the abandoned flight's waiters were not woken")
+	}
+
+	// The abandoned leader's late release must not evict the new flight.
+	g.release("ns", "k", stale)
+	if f, leader := g.claim("ns", "k"); leader || f != fresh {
+		t.Fatal("late release of an abandoned flight removed its replacement")
+	}
+}
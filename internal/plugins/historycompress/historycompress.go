@@ -0,0 +1,344 @@
+// Package historycompress provides a transform plugin that keeps long chats
+// cheap: once a conversation's estimated prompt passes a token threshold, its
+// older turns are replaced with a summary written by a configured (cheap)
+// model, and only the most recent turns are sent verbatim. Register it with a
+// blank import:
+//
+//	_ "github.com/ferro-labs/ai-gateway/internal/plugins/historycompress"
+//
+// # Sessions
+//
+// Clients resend the whole history on every turn, so the plugin remembers each
+// conversation's summary and extends it instead of summarizing from scratch.
+// A conversation is identified by the caller's API key and its opening
+// messages (the system prompt and first turn); a cached summary is reused only
+// while the turns it covers are unchanged, so an edited history is summarized
+// anew. Summaries are held in memory, per replica.
+//
+// # Configuration
+//
+//	name: history-compress
+//	type: transform
+//	stage: before_request
+//	config:
+//	  summary_model: gpt-4o-mini   # required; the model that writes summaries
+//	  threshold_tokens: 8000       # compress once the prompt exceeds this
+//	  keep_last: 6                 # recent messages always sent verbatim
+//	  summary_max_tokens: 512      # length bound given to the summary model
+//	  max_sessions: 10000          # sessions remembered; least recent evicted
+//	  session_ttl: 3600            # seconds a session's summary is kept
+//
+// A failed summary call leaves the request as it was: compression saves cost,
+// it never decides whether a request is served.
+package historycompress
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/plugins/plugincfg"
+	"github.com/ferro-labs/ai-gateway/internal/tokens"
+	"github.com/ferro-labs/ai-gateway/internal/truncation"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func init() {
+	plugin.RegisterFactory("history-compress", func() plugin.Plugin {
+		return &Compressor{}
+	})
+}
+
+// Configuration defaults.
+const (
+	defaultThresholdTokens  = 8000
+	defaultKeepLast         = 6
+	defaultSummaryMaxTokens = 512
+	defaultMaxSessions      = 10000
+	defaultSessionTTL       = time.Hour
+)
+
+// Compressor replaces a long conversation's older turns with a summary.
+type Compressor struct {
+	completer        plugin.Completer
+	summaryModel     string
+	threshold        int
+	keepLast         int
+	summaryMaxTokens int
+	sessions         *sessionCache
+}
+
+// Name returns the plugin identifier.
+func (c *Compressor) Name() string { return "history-compress" }
+
+// Type returns the plugin lifecycle hook type.
+func (c *Compressor) Type() plugin.PluginType { return plugin.TypeTransform }
+
+// SetCompleter receives the gateway's Completer, which calls the summary
+// model.
+func (c *Compressor) SetCompleter(completer plugin.Completer) { c.completer = completer }
+
+// Init configures the plugin from the provided options map.
+func (c *Compressor) Init(config map[string]any) error {
+	if c.completer == nil {
+		return errors.New("history-compress: no gateway to call the summary model through")
+	}
+	c.summaryModel, _ = config["summary_model"].(string)
+	if strings.TrimSpace(c.summaryModel) == "" {
+		return errors.New("history-compress: summary_model is required")
+	}
+	ints := []struct {
+		key string
+		dst *int
+		def int
+	}{
+		{"threshold_tokens", &c.threshold, defaultThresholdTokens},
+		{"keep_last", &c.keepLast, defaultKeepLast},
+		{"summary_max_tokens", &c.summaryMaxTokens, defaultSummaryMaxTokens},
+	}
+	for _, opt := range ints {
+		v, err := intOption(config, opt.key, opt.def)
+		if err != nil {
+			return err
+		}
+		if v <= 0 {
+			return fmt.Errorf("history-compress: %s must be positive", opt.key)
+		}
+		*opt.dst = v
+	}
+	maxSessions, err := intOption(config, "max_sessions", defaultMaxSessions)
+	if err != nil {
+		return err
+	}
+	ttlSeconds, err := intOption(config, "session_ttl", int(defaultSessionTTL/time.Second))
+	if err != nil {
+		return err
+	}
+	if maxSessions <= 0 || ttlSeconds <= 0 {
+		return errors.New("history-compress: max_sessions and session_ttl must be positive")
+	}
+	c.sessions = newSessionCache(maxSessions, time.Duration(ttlSeconds)*time.Second)
+	return nil
+}
+
+func intOption(config map[string]any, key string, def int) (int, error) {
+	v, ok := config[key]
+	if !ok {
+		return def, nil
+	}
+	f, err := plugincfg.ToFloat64(v)
+	if err != nil {
+		return 0, fmt.Errorf("history-compress: %s %w", key, err)
+	}
+	return int(f), nil
+}
+
+// Execute runs the plugin logic for the current request context.
+func (c *Compressor) Execute(ctx context.Context, pctx *plugin.Context) error {
+	req := pctx.Request
+	if req == nil || tokens.Prompt(*req) <= c.threshold {
+		return nil
+	}
+	cut := c.cutIndex(req.Messages)
+	if cut <= 0 {
+		return nil
+	}
+
+	keyID, _ := pctx.Metadata["api_key"].(string)
+	session := sessionID(keyID, req.Messages)
+	older := req.Messages[:cut]
+	summary, covered, ok := c.sessions.get(session, older)
+	if !ok || covered < cut {
+		var err error
+		summary, err = c.summarize(ctx, summary, older[covered:])
+		if err != nil {
+			logging.FromContext(ctx).Warn("history-compress: summary failed; sending the full history",
+				"summary_model", c.summaryModel, "error", err)
+			return nil
+		}
+		c.sessions.put(session, older, summary)
+	}
+
+	out := make([]providers.Message, 0, len(req.Messages)-cut+2)
+	for _, m := range older {
+		if truncation.Pinned(m) {
+			out = append(out, m)
+		}
+	}
+	out = append(out, providers.Message{Role: "system", Content: truncation.SummaryPrefix + summary})
+	req.Messages = append(out, req.Messages[cut:]...)
+	pctx.Metadata["history_compressed"] = cut
+	pctx.Mutation = fmt.Sprintf("summarized %d older messages", cut)
+	if logging.Enabled(ctx, slog.LevelDebug) {
+		logging.FromContext(ctx).Debug("history-compress: older turns summarized",
+			"summarized_messages", cut, "cached", ok && covered == cut)
+	}
+	return nil
+}
+
+// cutIndex returns how many leading messages to summarize: all but the last
+// keepLast conversation messages, moved earlier so the verbatim part never
+// opens with a tool result whose call would be summarized away. It returns 0
+// when there is nothing to summarize.
+func (c *Compressor) cutIndex(msgs []providers.Message) int {
+	kept := 0
+	cut := len(msgs)
+	for cut > 0 && kept < c.keepLast {
+		cut--
+		if !truncation.Pinned(msgs[cut]) {
+			kept++
+		}
+	}
+	for cut > 0 && msgs[cut].Role == "tool" {
+		cut--
+	}
+	for i := range cut {
+		if !truncation.Pinned(msgs[i]) {
+			return cut
+		}
+	}
+	return 0
+}
+
+// summarize asks the summary model to extend previous with turns.
+func (c *Compressor) summarize(ctx context.Context, previous string, turns []providers.Message) (string, error) {
+	resp, err := c.completer.Complete(ctx, truncation.SummaryRequest(c.summaryModel, c.summaryMaxTokens, previous, turns))
+	if err != nil {
+		return "", err
+	}
+	summary := truncation.SummaryText(resp)
+	if summary == "" {
+		return "", errors.New("summary model returned no text")
+	}
+	return summary, nil
+}
+
+// Close releases the plugin's session summaries.
+func (c *Compressor) Close() error {
+	if c.sessions != nil {
+		c.sessions.clear()
+	}
+	return nil
+}
+
+// sessionID identifies a conversation by the caller and its opening: every
+// message up to and including the first conversation turn.
+func sessionID(keyID string, msgs []providers.Message) string {
+	h := sha256.New()
+	h.Write([]byte(keyID))
+	for _, m := range msgs {
+		writeMessage(h, m)
+		if !truncation.Pinned(m) {
+			break
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// historyHash fingerprints msgs, so a cached summary is reused only for the
+// turns it was written from.
+func historyHash(msgs []providers.Message) string {
+	h := sha256.New()
+	for _, m := range msgs {
+		writeMessage(h, m)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeMessage(h interface{ Write([]byte) (int, error) }, m providers.Message) {
+	_, _ = fmt.Fprintf(h, "%d:%s|%d:%s|%d:%s\x00", len(m.Role), m.Role, len(m.Content), m.Content, len(m.ToolCallID), m.ToolCallID)
+	for _, tc := range m.ToolCalls {
+		_, _ = fmt.Fprintf(h, "%s(%s)\x00", tc.Function.Name, tc.Function.Arguments)
+	}
+}
+
+// session is one conversation's summary of its first covered messages.
+type session struct {
+	id        string
+	summary   string
+	covered   int
+	hash      string // historyHash of the covered messages
+	expiresAt time.Time
+}
+
+// sessionCache is an LRU of session summaries with a TTL.
+type sessionCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List // front is most recently used
+	now      func() time.Time
+}
+
+func newSessionCache(capacity int, ttl time.Duration) *sessionCache {
+	return &sessionCache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// get returns the session's summary and how many of older's messages it
+// covers, when its covered messages are still older's opening. ok is false
+// when there is no usable summary.
+func (s *sessionCache) get(id string, older []providers.Message) (summary string, covered int, ok bool) {
+	s.mu.Lock()
+	el, found := s.items[id]
+	if !found {
+		s.mu.Unlock()
+		return "", 0, false
+	}
+	e := el.Value.(*session)
+	if s.now().After(e.expiresAt) {
+		s.order.Remove(el)
+		delete(s.items, id)
+		s.mu.Unlock()
+		return "", 0, false
+	}
+	s.order.MoveToFront(el)
+	summary, covered, hash := e.summary, e.covered, e.hash
+	s.mu.Unlock()
+
+	if covered > len(older) || historyHash(older[:covered]) != hash {
+		return "", 0, false
+	}
+	return summary, covered, true
+}
+
+// put stores summary as the session's summary of older.
+func (s *sessionCache) put(id string, older []providers.Message, summary string) {
+	e := &session{id: id, summary: summary, covered: len(older), hash: historyHash(older)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.expiresAt = s.now().Add(s.ttl)
+	if el, ok := s.items[id]; ok {
+		el.Value = e
+		s.order.MoveToFront(el)
+		return
+	}
+	s.items[id] = s.order.PushFront(e)
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*session).id)
+	}
+}
+
+func (s *sessionCache) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.items)
+	s.order.Init()
+}
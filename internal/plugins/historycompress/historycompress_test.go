@@ -0,0 +1,150 @@
+package historycompress
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/truncation"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// fakeCompleter answers every summary request with a numbered summary and
+// keeps the transcripts it was sent.
+type fakeCompleter struct {
+	transcripts []string
+	err         error
+}
+
+func (f *fakeCompleter) Complete(_ context.Context, req providers.Request) (*providers.Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.transcripts = append(f.transcripts, req.Messages[1].Content)
+	summary := "summary " + string(rune('0'+len(f.transcripts)))
+	return &providers.Response{Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: summary}}}}, nil
+}
+
+func newCompressor(t *testing.T, f *fakeCompleter) *Compressor {
+	t.Helper()
+	c := &Compressor{}
+	c.SetCompleter(f)
+	if err := c.Init(map[string]any{"summary_model": "cheap", "threshold_tokens": 50, "keep_last": 2}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	return c
+}
+
+// chat is a system prompt and n turns of about 20 tokens each.
+func chat(n int) []providers.Message {
+	msgs := []providers.Message{{Role: "system", Content: "Be terse."}}
+	for i := range n {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		msgs = append(msgs, providers.Message{Role: role, Content: "turn " + string(rune('a'+i)) + strings.Repeat(" word", 15)})
+	}
+	return msgs
+}
+
+func run(t *testing.T, c *Compressor, msgs []providers.Message) []providers.Message {
+	t.Helper()
+	req := &providers.Request{Model: "gpt-4o", Messages: append([]providers.Message(nil), msgs...)}
+	pctx := plugin.NewContext(req)
+	pctx.Metadata["api_key"] = "key-a"
+	if err := c.Execute(context.Background(), pctx); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	// The history is cut exactly when the plugin reports a mutation.
+	if cut := len(req.Messages) != len(msgs); cut != (pctx.Mutation != "") {
+		t.Fatalf("history cut = %v but mutation = %q", cut, pctx.Mutation)
+	}
+	return req.Messages
+}
+
+func TestCompressor_BelowThreshold(t *testing.T) {
+	f := &fakeCompleter{}
+	c := newCompressor(t, f)
+	msgs := chat(1)
+	if got := run(t, c, msgs); len(got) != len(msgs) || len(f.transcripts) != 0 {
+		t.Fatalf("short chat was compressed: %d messages, %d summaries", len(got), len(f.transcripts))
+	}
+}
+
+func TestCompressor_SummarizesAndExtendsPerSession(t *testing.T) {
+	f := &fakeCompleter{}
+	c := newCompressor(t, f)
+
+	got := run(t, c, chat(6))
+	// system, summary, then the last two turns verbatim.
+	if len(got) != 4 || got[0].Content != "Be terse." || got[1].Content != truncation.SummaryPrefix+"summary 1" ||
+		!strings.HasPrefix(got[2].Content, "turn e") || !strings.HasPrefix(got[3].Content, "turn f") {
+		t.Fatalf("compressed chat = %+v", got)
+	}
+
+	// The same history again reuses the summary.
+	run(t, c, chat(6))
+	if len(f.transcripts) != 1 {
+		t.Fatalf("summary calls = %d, want the cached summary reused", len(f.transcripts))
+	}
+
+	// Two more turns extend the summary with only the newly older turns.
+	got = run(t, c, chat(8))
+	if len(f.transcripts) != 2 || got[1].Content != truncation.SummaryPrefix+"summary 2" {
+		t.Fatalf("extended chat: %d summary calls, summary %q", len(f.transcripts), got[1].Content)
+	}
+	ext := f.transcripts[1]
+	if !strings.HasPrefix(ext, "Summary so far: summary 1") || strings.Contains(ext, "turn a") || !strings.Contains(ext, "turn f") {
+		t.Errorf("extension transcript = %q", ext)
+	}
+
+	// An edited history is summarized from scratch.
+	edited := chat(8)
+	edited[3].Content = "something else" + strings.Repeat(" word", 15)
+	run(t, c, edited)
+	if len(f.transcripts) != 3 || strings.HasPrefix(f.transcripts[2], "Summary so far") {
+		t.Errorf("edited history reused a stale summary: %q", f.transcripts[len(f.transcripts)-1])
+	}
+}
+
+func TestCompressor_KeepsToolResultWithItsCall(t *testing.T) {
+	c := newCompressor(t, &fakeCompleter{})
+	msgs := append(chat(4),
+		providers.Message{Role: "assistant", ToolCalls: []providers.ToolCall{{ID: "c1"}}},
+		providers.Message{Role: "tool", ToolCallID: "c1", Content: "sunny"},
+		providers.Message{Role: "user", Content: "thanks"},
+	)
+	got := run(t, c, msgs)
+	if got[2].Role != "assistant" || len(got[2].ToolCalls) != 1 {
+		t.Fatalf("verbatim turns must open with the tool call: %+v", got[2:])
+	}
+}
+
+func TestCompressor_SummaryFailureSendsHistory(t *testing.T) {
+	c := newCompressor(t, &fakeCompleter{err: errors.New("down")})
+	msgs := chat(6)
+	if got := run(t, c, msgs); len(got) != len(msgs) {
+		t.Fatalf("request changed after a failed summary: %d messages", len(got))
+	}
+}
+
+func TestCompressor_InitErrors(t *testing.T) {
+	if err := (&Compressor{}).Init(map[string]any{"summary_model": "cheap"}); err == nil {
+		t.Error("Init succeeded without a completer")
+	}
+	for name, cfg := range map[string]map[string]any{
+		"no model":      {},
+		"zero keep":     {"summary_model": "cheap", "keep_last": 0},
+		"bad threshold": {"summary_model": "cheap", "threshold_tokens": "lots"},
+		"zero sessions": {"summary_model": "cheap", "max_sessions": 0},
+	} {
+		c := &Compressor{}
+		c.SetCompleter(&fakeCompleter{})
+		if err := c.Init(cfg); err == nil {
+			t.Errorf("%s: Init accepted %v", name, cfg)
+		}
+	}
+}
@@ -6,13 +6,17 @@ package logger
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/redact"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
 	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
 )
 
 func init() {
@@ -24,10 +28,12 @@ func init() {
 // RequestLogger is a logging plugin that emits structured log entries
 // for every request and response flowing through the gateway.
 type RequestLogger struct {
-	logLevel slog.Level
-	writer   requestlog.Writer
-	shared   requestlog.Writer
-	redactor *redact.Redactor
+	logLevel      slog.Level
+	writer        requestlog.Writer
+	shared        requestlog.Writer
+	redactor      *redact.Redactor
+	recordContent bool
+	sampling      *contentSampling
 }
 
 // Name returns the plugin identifier.
@@ -50,10 +56,26 @@ func (l *RequestLogger) SetRequestLogWriter(w requestlog.Writer) {
 // credentials are process configuration, set once via
 // REQUEST_LOG_STORE_BACKEND / REQUEST_LOG_STORE_DSN — and are ignored with a
 // warning so an operator running an old config learns where the setting moved.
+//
+// `record_content` additionally persists the request and response bodies
+// (messages, parameters, choices), passed through the redactor first, so a
+// request's full transcript can be read back from the admin API. It is off by
+// default: prompts and completions are often more sensitive than the metadata.
+// `content_sampling` records them for a sample of requests only (see
+// contentSampling).
 func (l *RequestLogger) Init(config map[string]any) error {
 	l.logLevel = slog.LevelInfo
 	l.writer = requestlog.NoopWriter{}
 	l.redactor = redact.DefaultRedactor()
+	l.recordContent, _ = config["record_content"].(bool)
+	sampling, err := parseContentSampling(config)
+	if err != nil {
+		return err
+	}
+	if sampling != nil && !l.recordContent {
+		return errors.New("request-logger: content_sampling requires record_content")
+	}
+	l.sampling = sampling
 	if level, ok := config["level"].(string); ok {
 		switch level {
 		case "debug":
@@ -96,17 +118,33 @@ func (l *RequestLogger) Execute(ctx context.Context, pctx *plugin.Context) error
 			"stream", pctx.Request.Stream,
 			"timestamp", now.Format(time.RFC3339),
 		)
+		keyID, workspace := keyFields(ctx)
+		record := l.recordsContent(ctx, plugin.StageBeforeRequest)
 		_ = l.writer.Write(ctx, requestlog.Entry{
-			TraceID:   logging.TraceIDFromContext(ctx),
-			Stage:     string(plugin.StageBeforeRequest),
-			Model:     pctx.Request.Model,
-			CreatedAt: now,
+			TraceID:         logging.TraceIDFromContext(ctx),
+			Stage:           string(plugin.StageBeforeRequest),
+			Model:           pctx.Request.Model,
+			Request:         l.requestContent(pctx.Request, record),
+			PluginDecisions: l.decisions(pctx),
+			KeyID:           keyID,
+			Workspace:       workspace,
+			User:            endUser(pctx),
+			CreatedAt:       now,
 		})
 	}
 
 	if pctx.Response != nil {
 		// after_request stage
 		now := time.Now().UTC()
+		// The response cache records its verdict in Metadata during
+		// before_request, so it is available regardless of plugin order.
+		cacheStatus, _ := pctx.Metadata["cache_status"].(string)
+		// The gateway prices the response before the after_request stage.
+		cost, _ := pctx.Metadata["cost_usd"].(float64)
+		// A streamed response is recorded as the gateway assembled it, which
+		// stops at its capture limit.
+		truncated, _ := pctx.Metadata["response_truncated"].(bool)
+		upstream, _ := pctx.Metadata["upstream_headers"].(map[string]string)
 		log.Log(ctx, l.logLevel, "gateway response",
 			"model", pctx.Response.Model,
 			"provider", pctx.Response.Provider,
@@ -114,8 +152,13 @@ func (l *RequestLogger) Execute(ctx context.Context, pctx *plugin.Context) error
 			"completion_tokens", pctx.Response.Usage.CompletionTokens,
 			"total_tokens", pctx.Response.Usage.TotalTokens,
 			"choices", len(pctx.Response.Choices),
+			"cache", cacheStatus,
+			"response_truncated", truncated,
+			"upstream_headers", upstream,
 			"timestamp", now.Format(time.RFC3339),
 		)
+		keyID, workspace := keyFields(ctx)
+		record := l.recordsContent(ctx, plugin.StageAfterRequest)
 		_ = l.writer.Write(ctx, requestlog.Entry{
 			TraceID:          logging.TraceIDFromContext(ctx),
 			Stage:            string(plugin.StageAfterRequest),
@@ -124,6 +167,15 @@ func (l *RequestLogger) Execute(ctx context.Context, pctx *plugin.Context) error
 			PromptTokens:     pctx.Response.Usage.PromptTokens,
 			CompletionTokens: pctx.Response.Usage.CompletionTokens,
 			TotalTokens:      pctx.Response.Usage.TotalTokens,
+			CacheStatus:      cacheStatus,
+			CostUSD:          cost,
+			Request:          l.sampledRequest(ctx, pctx, record),
+			Response:         l.content(pctx.Response, record),
+			PluginDecisions:  l.decisions(pctx),
+			KeyID:            keyID,
+			Workspace:        workspace,
+			User:             endUser(pctx),
+			UpstreamHeaders:  upstreamHeaders(upstream),
 			CreatedAt:        now,
 		})
 	}
@@ -137,23 +189,165 @@ func (l *RequestLogger) Execute(ctx context.Context, pctx *plugin.Context) error
 			model = pctx.Request.Model
 		}
 		errMsg := l.redactor.Redact(pctx.Error.Error())
+		// The provider's rate-limit headers tell a throttled provider from a
+		// failing one.
+		upstream, _ := pctx.Metadata["upstream_headers"].(map[string]string)
 		log.Log(ctx, slog.LevelError, "gateway error",
 			"model", model,
 			"error", errMsg,
+			"upstream_headers", upstream,
 			"timestamp", now.Format(time.RFC3339),
 		)
+		keyID, workspace := keyFields(ctx)
+		record := l.recordsContent(ctx, plugin.StageOnError)
 		_ = l.writer.Write(ctx, requestlog.Entry{
-			TraceID:      logging.TraceIDFromContext(ctx),
-			Stage:        string(plugin.StageOnError),
-			Model:        model,
-			ErrorMessage: errMsg,
-			CreatedAt:    now,
+			TraceID:         logging.TraceIDFromContext(ctx),
+			Stage:           string(plugin.StageOnError),
+			Model:           model,
+			ErrorMessage:    errMsg,
+			Request:         l.sampledRequest(ctx, pctx, record),
+			PluginDecisions: l.decisions(pctx),
+			KeyID:           keyID,
+			Workspace:       workspace,
+			User:            endUser(pctx),
+			UpstreamHeaders: upstreamHeaders(upstream),
+			CreatedAt:       now,
 		})
 	}
 
 	return nil
 }
 
+// ObserveRejection records a request a before_request plugin rejected, as a
+// requestlog.StageRejected entry naming the plugin and its reason. The
+// plugin manager calls it whether or not the logger itself ran before the
+// rejection.
+func (l *RequestLogger) ObserveRejection(ctx context.Context, pctx *plugin.Context, rejection *plugin.RejectionError) {
+	now := time.Now().UTC()
+	model := ""
+	if pctx.Request != nil {
+		model = pctx.Request.Model
+	}
+	reason := l.redactor.Redact(rejection.Reason)
+	logging.FromContext(ctx).Log(ctx, l.logLevel, "gateway request rejected",
+		"model", model,
+		"plugin", rejection.Plugin,
+		"reason", reason,
+		"timestamp", now.Format(time.RFC3339),
+	)
+	keyID, workspace := keyFields(ctx)
+	_ = l.writer.Write(ctx, requestlog.Entry{
+		TraceID:         logging.TraceIDFromContext(ctx),
+		Stage:           requestlog.StageRejected,
+		Model:           model,
+		Request:         l.requestContent(pctx.Request, l.recordsContent(ctx, requestlog.StageRejected)),
+		PluginDecisions: l.decisions(pctx),
+		Plugin:          rejection.Plugin,
+		Decision:        plugin.DecisionReject,
+		Reason:          reason,
+		KeyID:           keyID,
+		Workspace:       workspace,
+		User:            endUser(pctx),
+		CreatedAt:       now,
+	})
+}
+
+// keyFields returns the ID and workspace of the API key the request
+// authenticated with, empty when it used none.
+func keyFields(ctx context.Context) (keyID, workspace string) {
+	keyID, _ = authctx.KeyID(ctx)
+	if id, ok := authctx.Identity(ctx); ok {
+		workspace = id.Workspace
+	}
+	return keyID, workspace
+}
+
+// endUser returns the request's end-user identifier, empty when it set none.
+func endUser(pctx *plugin.Context) string {
+	if pctx.Request == nil {
+		return ""
+	}
+	return pctx.Request.User
+}
+
+// recordsContent reports whether the entry of stage records the request's
+// bodies: always when content recording is on without sampling, and when it
+// is sampled otherwise. The sample is drawn on the entry that ends the
+// request, by its outcome.
+func (l *RequestLogger) recordsContent(ctx context.Context, stage plugin.Stage) bool {
+	s := l.sampling
+	switch {
+	case !l.recordContent:
+		return false
+	case s == nil || s.flagged(ctx):
+		return true
+	case stage == plugin.StageBeforeRequest:
+		return false
+	case stage == plugin.StageAfterRequest:
+		return s.sample(s.success)
+	default:
+		return s.sample(s.error)
+	}
+}
+
+// sampledRequest returns the request body for an entry that ends a sampled
+// request. Without sampling, and for a flagged key, the before_request entry
+// already carries it.
+func (l *RequestLogger) sampledRequest(ctx context.Context, pctx *plugin.Context, record bool) json.RawMessage {
+	if l.sampling == nil || pctx.Request == nil || l.sampling.flagged(ctx) {
+		return nil
+	}
+	return l.requestContent(pctx.Request, record)
+}
+
+// requestContent returns req as redacted JSON when record is set, with the
+// fields a providers.Request leaves out of its JSON, so a replay from the log
+// sends what the client did.
+func (l *RequestLogger) requestContent(req *providers.Request, record bool) json.RawMessage {
+	if req == nil {
+		return l.content(nil, record)
+	}
+	return l.content(requestlog.NewRequest(*req), record)
+}
+
+// content returns v as redacted JSON when record is set.
+func (l *RequestLogger) content(v any, record bool) json.RawMessage {
+	if !record {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return json.RawMessage(l.redactor.Redact(string(b)))
+}
+
+// decisions returns the plugin outcomes recorded so far as redacted JSON; an
+// error reason can quote upstream text just as an error message can.
+func (l *RequestLogger) decisions(pctx *plugin.Context) json.RawMessage {
+	if len(pctx.Decisions) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(pctx.Decisions)
+	if err != nil {
+		return nil
+	}
+	return json.RawMessage(l.redactor.Redact(string(b)))
+}
+
+// upstreamHeaders renders the provider response headers the gateway recorded
+// for an entry, or nil when there are none.
+func upstreamHeaders(headers map[string]string) json.RawMessage {
+	if len(headers) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(headers)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
 // Close is a no-op. The request-log store the plugin writes to is owned by the
 // gateway, which closes it on shutdown; closing it here would break the admin
 // log reader that shares the same store.
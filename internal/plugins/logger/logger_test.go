@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/requestlog"
 	"github.com/ferro-labs/ai-gateway/plugin"
@@ -147,6 +148,31 @@ func TestRequestLogger_Init_PersistsToInjectedWriter(t *testing.T) {
 	}
 }
 
+// Entries carry the authenticated key's ID and workspace for usage reports,
+// and the request's end user for erasure.
+func TestRequestLogger_RecordsKeyAndWorkspace(t *testing.T) {
+	rec := &recordingWriter{}
+	l := &RequestLogger{}
+	l.SetRequestLogWriter(rec)
+	if err := l.Init(map[string]any{"persist": true}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	ctx := authctx.WithKeyID(context.Background(), "key-1")
+	ctx = authctx.WithIdentity(ctx, authctx.KeyIdentity{Workspace: "team-a"})
+	pctx := plugin.NewContext(&providers.Request{Model: "gpt-4", User: "user-42"})
+	pctx.Response = &providers.Response{Model: "gpt-4", Provider: "openai"}
+	if err := l.Execute(ctx, pctx); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(rec.entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(rec.entries))
+	}
+	if e := rec.entries[0]; e.KeyID != "key-1" || e.Workspace != "team-a" || e.User != "user-42" {
+		t.Fatalf("entry key = %q, workspace = %q, user = %q", e.KeyID, e.Workspace, e.User)
+	}
+}
+
 // persist:false records nothing even when a store is injected — the operator
 // wants stdout logging only.
 func TestRequestLogger_Init_PersistFalseDoesNotWrite(t *testing.T) {
@@ -265,3 +291,150 @@ func TestRequestLogger_ExecuteErrorRedactsKeyInEntry(t *testing.T) {
 		t.Errorf("ErrorMessage missing REDACTED marker; got %q", entry.ErrorMessage)
 	}
 }
+
+// The on_error entry carries the provider's rate-limit headers the gateway
+// recorded, so throttling can be told from a failure.
+func TestRequestLogger_ExecuteErrorRecordsUpstreamHeaders(t *testing.T) {
+	l := &RequestLogger{}
+	if err := l.Init(map[string]any{}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	rec := &recordingWriter{}
+	l.writer = rec
+
+	pctx := plugin.NewContext(nil)
+	pctx.Error = errors.New("openai API error (429): rate limited")
+	pctx.Metadata["upstream_headers"] = map[string]string{"x-ratelimit-remaining-tokens": "0"}
+	if err := l.Execute(context.Background(), pctx); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	if len(rec.entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(rec.entries))
+	}
+	if got := string(rec.entries[0].UpstreamHeaders); got != `{"x-ratelimit-remaining-tokens":"0"}` {
+		t.Errorf("UpstreamHeaders = %s", got)
+	}
+}
+
+// The after_request entry carries the response cache's verdict from Metadata.
+func TestRequestLogger_ExecuteResponseRecordsCacheStatus(t *testing.T) {
+	l := &RequestLogger{}
+	if err := l.Init(map[string]any{}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	rec := &recordingWriter{}
+	l.writer = rec
+
+	pctx := plugin.NewContext(&providers.Request{Model: "gpt-4"})
+	pctx.Response = &providers.Response{Model: "gpt-4", Provider: "openai"}
+	pctx.Metadata["cache_status"] = providers.CacheStatusHit
+
+	if err := l.Execute(context.Background(), pctx); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(rec.entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(rec.entries))
+	}
+	if got := rec.entries[0].CacheStatus; got != providers.CacheStatusHit {
+		t.Errorf("CacheStatus = %q, want %q", got, providers.CacheStatusHit)
+	}
+}
+
+// With record_content set, entries carry the redacted request and response
+// bodies; every entry carries the cost and the plugin decisions so far.
+func TestRequestLogger_RecordsTranscript(t *testing.T) {
+	for _, recordContent := range []bool{false, true} {
+		l := &RequestLogger{}
+		if err := l.Init(map[string]any{"record_content": recordContent}); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		rec := &recordingWriter{}
+		l.writer = rec
+
+		pctx := plugin.NewContext(&providers.Request{
+			Model:    "gpt-4",
+			Messages: []providers.Message{{Role: "user", Content: "mail me at jane@example.com"}},
+		})
+		pctx.Decisions = []plugin.Decision{{Plugin: "word-filter", Stage: "before_request", Outcome: plugin.DecisionAllow}}
+		if err := l.Execute(context.Background(), pctx); err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		pctx.Response = &providers.Response{Model: "gpt-4", Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "done"}}}}
+		pctx.Metadata["cost_usd"] = 0.002
+		if err := l.Execute(context.Background(), pctx); err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+
+		if len(rec.entries) != 2 {
+			t.Fatalf("entries = %d, want 2", len(rec.entries))
+		}
+		before, after := rec.entries[0], rec.entries[1]
+		if !strings.Contains(string(before.PluginDecisions), `"word-filter"`) {
+			t.Errorf("plugin decisions = %s, want word-filter", before.PluginDecisions)
+		}
+		if after.CostUSD != 0.002 {
+			t.Errorf("CostUSD = %v, want 0.002", after.CostUSD)
+		}
+		if !recordContent {
+			if before.Request != nil || after.Response != nil {
+				t.Errorf("content recorded without record_content: %s / %s", before.Request, after.Response)
+			}
+			continue
+		}
+		if strings.Contains(string(before.Request), "jane@example.com") || !strings.Contains(string(before.Request), "REDACTED") {
+			t.Errorf("request body not redacted: %s", before.Request)
+		}
+		if !strings.Contains(string(after.Response), `"content":"done"`) {
+			t.Errorf("response body = %s, want the choice content", after.Response)
+		}
+	}
+}
+
+// A rejection by another plugin is recorded as a before_request_rejected
+// entry naming the plugin and its redacted reason.
+func TestRequestLogger_ObserveRejection(t *testing.T) {
+	l := &RequestLogger{}
+	if err := l.Init(map[string]any{}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	rec := &recordingWriter{}
+	l.writer = rec
+
+	m := plugin.NewManager()
+	_ = m.Register(plugin.StageBeforeRequest, rejectingPlugin{})
+	_ = m.Register(plugin.StageBeforeRequest, l)
+
+	fakeKey := "sk-" + strings.Repeat("z", 40)
+	pctx := plugin.NewContext(&providers.Request{Model: "gpt-4"})
+	pctx.Metadata["reason"] = "prompt contains " + fakeKey
+	if err := m.RunBefore(context.Background(), pctx); err == nil {
+		t.Fatal("expected the request to be rejected")
+	}
+
+	if len(rec.entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(rec.entries))
+	}
+	e := rec.entries[0]
+	if e.Stage != requestlog.StageRejected || e.Plugin != "word-filter" || e.Decision != plugin.DecisionReject || e.Model != "gpt-4" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if strings.Contains(e.Reason, fakeKey) || !strings.Contains(e.Reason, "prompt contains") {
+		t.Errorf("reason not redacted: %q", e.Reason)
+	}
+	if !strings.Contains(string(e.PluginDecisions), `"reject"`) {
+		t.Errorf("plugin decisions = %s, want the rejection", e.PluginDecisions)
+	}
+}
+
+type rejectingPlugin struct{}
+
+func (rejectingPlugin) Name() string              { return "word-filter" }
+func (rejectingPlugin) Type() plugin.PluginType   { return plugin.TypeGuardrail }
+func (rejectingPlugin) Init(map[string]any) error { return nil }
+func (rejectingPlugin) Close() error              { return nil }
+func (rejectingPlugin) Execute(_ context.Context, pctx *plugin.Context) error {
+	pctx.Reject = true
+	pctx.Reason, _ = pctx.Metadata["reason"].(string)
+	return nil
+}
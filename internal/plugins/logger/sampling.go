@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/ferro-labs/ai-gateway/internal/plugins/plugincfg"
+)
+
+// contentSampling decides which requests' bodies are recorded when
+// record_content is on, so transcripts can be kept for a fraction of traffic
+// instead of all of it:
+//
+//	record_content: true
+//	content_sampling:
+//	  success: 0.01          # fraction of successful requests recorded
+//	  error: 1.0             # fraction of failed or rejected requests recorded
+//	  keys: [key_abc123]     # API key IDs whose every request is recorded
+//	  workspaces: [support]  # workspaces whose every request is recorded
+//
+// Both rates default to 1. A request's outcome is only known once it ends,
+// so a sampled request's body is recorded on its after_request, on_error, or
+// before_request_rejected entry; only flagged keys and workspaces also get it
+// on their before_request entry.
+type contentSampling struct {
+	success    float64
+	error      float64
+	keys       map[string]bool
+	workspaces map[string]bool
+	random     func() float64
+}
+
+// parseContentSampling reads the content_sampling option; it returns nil when
+// the option is absent.
+func parseContentSampling(config map[string]any) (*contentSampling, error) {
+	raw, ok := config["content_sampling"]
+	if !ok {
+		return nil, nil
+	}
+	opts, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("request-logger: content_sampling must be a map")
+	}
+	s := &contentSampling{success: 1, error: 1, random: rand.Float64}
+	for _, rate := range []struct {
+		key string
+		dst *float64
+	}{
+		{"success", &s.success},
+		{"error", &s.error},
+	} {
+		v, ok := opts[rate.key]
+		if !ok {
+			continue
+		}
+		f, err := plugincfg.ToFloat64(v)
+		if err != nil {
+			return nil, fmt.Errorf("request-logger: content_sampling.%s %w", rate.key, err)
+		}
+		if f < 0 || f > 1 {
+			return nil, fmt.Errorf("request-logger: content_sampling.%s must be between 0 and 1, got %v", rate.key, f)
+		}
+		*rate.dst = f
+	}
+	s.keys = stringSet(opts["keys"])
+	s.workspaces = stringSet(opts["workspaces"])
+	return s, nil
+}
+
+// flagged reports whether every request of ctx's key is recorded.
+func (s *contentSampling) flagged(ctx context.Context) bool {
+	keyID, workspace := keyFields(ctx)
+	return (keyID != "" && s.keys[keyID]) || (workspace != "" && s.workspaces[workspace])
+}
+
+// sample draws against rate.
+func (s *contentSampling) sample(rate float64) bool {
+	return rate >= 1 || (rate > 0 && s.random() < rate)
+}
+
+func stringSet(v any) map[string]bool {
+	set := make(map[string]bool)
+	switch list := v.(type) {
+	case []any:
+		for _, item := range list {
+			if s, ok := item.(string); ok && s != "" {
+				set[s] = true
+			}
+		}
+	case []string:
+		for _, s := range list {
+			if s != "" {
+				set[s] = true
+			}
+		}
+	}
+	return set
+}
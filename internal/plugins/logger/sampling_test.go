@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestRequestLogger_Init_ContentSampling(t *testing.T) {
+	for name, config := range map[string]map[string]any{
+		"without record_content": {"content_sampling": map[string]any{"success": 0.5}},
+		"rate above one":         {"record_content": true, "content_sampling": map[string]any{"success": 2}},
+		"negative rate":          {"record_content": true, "content_sampling": map[string]any{"error": -0.1}},
+		"not a map":              {"record_content": true, "content_sampling": "1%"},
+	} {
+		l := &RequestLogger{}
+		if err := l.Init(config); err == nil {
+			t.Errorf("%s: Init accepted %v", name, config)
+		}
+	}
+}
+
+// With sampling, a request's bodies are recorded on the entry that ends it,
+// by its outcome, and on every entry of a flagged key.
+func TestRequestLogger_ContentSampling(t *testing.T) {
+	l := &RequestLogger{}
+	err := l.Init(map[string]any{
+		"record_content": true,
+		"content_sampling": map[string]any{
+			"success": 0.25,
+			"error":   1,
+			"keys":    []any{"key-flagged"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	draw := 0.5
+	l.sampling.random = func() float64 { return draw }
+	rec := &recordingWriter{}
+	l.writer = rec
+
+	run := func(ctx context.Context, fail bool) {
+		t.Helper()
+		rec.entries = nil
+		pctx := plugin.NewContext(&providers.Request{Model: "gpt-4", Messages: []providers.Message{{Role: "user", Content: "hi"}}})
+		if err := l.Execute(ctx, pctx); err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		if fail {
+			pctx.Error = errors.New("provider down")
+		} else {
+			pctx.Response = &providers.Response{Model: "gpt-4", Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "hello"}}}}
+		}
+		if err := l.Execute(ctx, pctx); err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		if len(rec.entries) != 2 {
+			t.Fatalf("entries = %d, want 2", len(rec.entries))
+		}
+	}
+
+	// A success outside the sample records no bodies.
+	run(context.Background(), false)
+	if before, after := rec.entries[0], rec.entries[1]; before.Request != nil || after.Request != nil || after.Response != nil {
+		t.Errorf("unsampled success recorded content: %+v", rec.entries)
+	}
+
+	// A success inside it records both on the after_request entry.
+	draw = 0.1
+	run(context.Background(), false)
+	if before, after := rec.entries[0], rec.entries[1]; before.Request != nil || after.Request == nil || after.Response == nil {
+		t.Errorf("sampled success: before request %s, after %s / %s", before.Request, after.Request, after.Response)
+	}
+
+	// Every error is recorded, with its request.
+	draw = 0.9
+	run(context.Background(), true)
+	if failed := rec.entries[1]; failed.Request == nil {
+		t.Error("failed request recorded without its body")
+	}
+
+	// A flagged key is always recorded, the request on before_request.
+	run(authctx.WithKeyID(context.Background(), "key-flagged"), false)
+	if before, after := rec.entries[0], rec.entries[1]; before.Request == nil || after.Request != nil || after.Response == nil {
+		t.Errorf("flagged key: before request %s, after %s / %s", before.Request, after.Request, after.Response)
+	}
+}
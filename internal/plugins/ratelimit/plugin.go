@@ -1,6 +1,6 @@
 // Package ratelimit provides a gateway plugin that enforces per-request rate
-// limits using an in-memory token bucket.  Configure it at the before_request
-// stage so that over-budget requests are rejected before they hit the provider.
+// limits, in memory or in Redis.  Configure it at the before_request stage so
+// that over-budget requests are rejected before they hit the provider.
 //
 // Supported config keys:
 //   - requests_per_second (float64|int, default 100): global request rate.
@@ -12,15 +12,42 @@
 //   - user_rpm (float64|int, optional): per-user rate limit in requests/minute.
 //     The user ID is read from pctx.Request.User. Requests with an empty User
 //     field are not individually limited by this option.
+//   - backend (string, default "memory"): where limiter state lives. "memory"
+//     keeps a token bucket per gateway instance, so N replicas admit N times
+//     the configured rate. "redis" keeps one shared limit per key for every
+//     replica pointed at the same Redis.
+//   - redis_url (string, required for backend redis): e.g.
+//     "redis://:${REDIS_PASSWORD}@redis:6379/0".
+//   - algorithm (string, backend redis only, default "gcra"): "gcra" matches
+//     the token bucket's rate/burst behaviour with one key per limiter;
+//     "sliding_window" admits at most burst requests in any trailing
+//     burst/rate seconds, exactly, at the cost of one entry per admitted
+//     request.
+//   - key_prefix (string, backend redis only, default "ferro:ratelimit:"):
+//     prefix for the Redis keys. Give each rate-limit entry that shares a
+//     Redis its own prefix, or their limits will draw on the same counters.
+//
+// With backend redis, a request Redis cannot decide on fails closed: the plugin
+// returns an error and the gateway answers 500, never 429, since nobody was
+// actually rate-limited. Every decision is counted in
+// gateway_rate_limit_decisions_total.
+//
+// With backend memory, the buckets can be listed and reset through
+// GET and DELETE /admin/ratelimits.
 package ratelimit
 
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
 	"github.com/ferro-labs/ai-gateway/internal/plugins/plugincfg"
 	internalrl "github.com/ferro-labs/ai-gateway/internal/ratelimit"
 	"github.com/ferro-labs/ai-gateway/plugin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
 )
 
 // defaultMaxKeys is the default maximum number of keys tracked in per-key and
@@ -28,13 +55,21 @@ import (
 // accessed entry is evicted to prevent unbounded memory growth.
 const defaultMaxKeys = 100_000
 
+// Backends accepted by the backend config key.
+const (
+	backendMemory = "memory"
+	backendRedis  = "redis"
+)
+
+const defaultKeyPrefix = "ferro:ratelimit:"
+
 func init() {
 	plugin.RegisterFactory("rate-limit", func() plugin.Plugin {
 		return &Plugin{}
 	})
 }
 
-// Plugin enforces token-bucket rate limits on incoming requests.
+// Plugin enforces rate limits on incoming requests.
 //
 // Three limiters are layered — a request is rejected as soon as any one of
 // them denies it:
@@ -42,9 +77,89 @@ func init() {
 //  2. Per-API-key limiter (key_rpm) — keyed on Metadata["api_key"]
 //  3. Per-user limiter (user_rpm) — keyed on Request.User
 type Plugin struct {
-	limiter   *internalrl.Limiter // global
-	keyStore  *internalrl.Store   // per API key (nil when key_rpm unset)
-	userStore *internalrl.Store   // per user   (nil when user_rpm unset)
+	limiter   *limiter // global
+	keyStore  *limiter // per API key (nil when key_rpm unset)
+	userStore *limiter // per user   (nil when user_rpm unset)
+
+	client    *redis.Client // nil with the memory backend
+	closeOnce sync.Once
+}
+
+// allower is a limiter's state, wherever it is kept.
+type allower interface {
+	Allow(ctx context.Context, key string) (bool, error)
+}
+
+// memoryGlobal adapts the single in-memory bucket; the key is ignored.
+type memoryGlobal struct{ l *internalrl.Limiter }
+
+func (m memoryGlobal) Allow(context.Context, string) (bool, error) { return m.l.Allow(), nil }
+
+// memoryKeyed adapts a per-key in-memory store.
+type memoryKeyed struct{ s *internalrl.Store }
+
+func (m memoryKeyed) Allow(_ context.Context, key string) (bool, error) { return m.s.Allow(key), nil }
+
+// limiter is one layer of the plugin with its decision counters resolved up
+// front, so Execute does no label lookups.
+type limiter struct {
+	allower
+	name      string
+	keyPrefix string // namespaces this layer's keys in a shared backend
+	// inspect is the layer's in-memory state, nil with the redis backend.
+	inspect internalrl.Inspectable
+	allowed prometheus.Counter
+	denied  prometheus.Counter
+	errored prometheus.Counter
+}
+
+func newLimiter(a allower, name, backend string) *limiter {
+	l := &limiter{
+		allower:   a,
+		name:      name,
+		keyPrefix: name + ":",
+		allowed:   metrics.RateLimitDecisions.WithLabelValues(name, backend, "allowed"),
+		denied:    metrics.RateLimitDecisions.WithLabelValues(name, backend, "denied"),
+		errored:   metrics.RateLimitDecisions.WithLabelValues(name, backend, "error"),
+	}
+	switch m := a.(type) {
+	case memoryGlobal:
+		l.inspect = m.l
+	case memoryKeyed:
+		l.inspect = m.s
+	}
+	return l
+}
+
+// States implements internalrl.Inspectable, reporting keys without the
+// layer's prefix.
+func (l *limiter) States() []internalrl.BucketState {
+	states := l.inspect.States()
+	for i := range states {
+		states[i].Key = strings.TrimPrefix(states[i].Key, l.keyPrefix)
+	}
+	return states
+}
+
+// Reset implements internalrl.Inspectable.
+func (l *limiter) Reset(key string) bool {
+	if key != "" {
+		key = l.keyPrefix + key
+	}
+	return l.inspect.Reset(key)
+}
+
+func (l *limiter) allow(ctx context.Context, key string) (bool, error) {
+	ok, err := l.Allow(ctx, l.keyPrefix+key)
+	switch {
+	case err != nil:
+		l.errored.Inc()
+	case ok:
+		l.allowed.Inc()
+	default:
+		l.denied.Inc()
+	}
+	return ok, err
 }
 
 // Name returns the plugin identifier.
@@ -72,8 +187,8 @@ func (p *Plugin) Init(config map[string]any) error {
 		}
 		burst = f
 	}
-	p.limiter = internalrl.New(rps, burst)
 
+	var keyRPM, userRPM float64
 	if v, ok := config["key_rpm"]; ok {
 		rpm, err := plugincfg.ToFloat64(v)
 		if err != nil {
@@ -82,11 +197,8 @@ func (p *Plugin) Init(config map[string]any) error {
 		if rpm <= 0 {
 			return fmt.Errorf("rate-limit: key_rpm must be > 0")
 		}
-		// burst=rpm lets a key spend up to a full minute's worth of tokens
-		// when idle, matching typical RPM semantics.
-		p.keyStore = internalrl.NewStoreWithMax(rpm/60.0, rpm, defaultMaxKeys)
+		keyRPM = rpm
 	}
-
 	if v, ok := config["user_rpm"]; ok {
 		rpm, err := plugincfg.ToFloat64(v)
 		if err != nil {
@@ -95,39 +207,104 @@ func (p *Plugin) Init(config map[string]any) error {
 		if rpm <= 0 {
 			return fmt.Errorf("rate-limit: user_rpm must be > 0")
 		}
-		// burst=rpm lets a user spend up to a full minute's worth of tokens
-		// when idle, matching typical RPM semantics.
-		p.userStore = internalrl.NewStoreWithMax(rpm/60.0, rpm, defaultMaxKeys)
+		userRPM = rpm
+	}
+
+	backend := backendMemory
+	if v, ok := config["backend"].(string); ok && v != "" {
+		backend = v
+	}
+	algorithm, _ := config["algorithm"].(string)
+
+	switch backend {
+	case backendMemory:
+		if algorithm != "" && algorithm != "token_bucket" {
+			return fmt.Errorf("rate-limit: algorithm %q requires backend redis", algorithm)
+		}
+		p.limiter = newLimiter(memoryGlobal{internalrl.New(rps, burst)}, "global", backend)
+		// burst=rpm lets a key or user spend up to a full minute's worth of
+		// tokens when idle, matching typical RPM semantics.
+		if keyRPM > 0 {
+			p.keyStore = newLimiter(memoryKeyed{internalrl.NewStoreWithMax(keyRPM/60.0, keyRPM, defaultMaxKeys)}, "api_key", backend)
+		}
+		if userRPM > 0 {
+			p.userStore = newLimiter(memoryKeyed{internalrl.NewStoreWithMax(userRPM/60.0, userRPM, defaultMaxKeys)}, "user", backend)
+		}
+		return nil
+	case backendRedis:
+		return p.initRedis(config, algorithm, rps, burst, keyRPM, userRPM)
+	default:
+		return fmt.Errorf("rate-limit: unknown backend %q (want %q or %q)", backend, backendMemory, backendRedis)
+	}
+}
+
+// initRedis builds the limiters on a shared Redis. The connection is opened
+// lazily by the client, so an unreachable Redis surfaces on the first request
+// rather than failing the config load.
+func (p *Plugin) initRedis(config map[string]any, algorithm string, rps, burst, keyRPM, userRPM float64) error {
+	url, _ := config["redis_url"].(string)
+	if url == "" {
+		return fmt.Errorf("rate-limit: redis_url is required with backend redis")
+	}
+	if algorithm == "" {
+		algorithm = internalrl.AlgorithmGCRA
 	}
+	prefix := defaultKeyPrefix
+	if v, ok := config["key_prefix"].(string); ok && v != "" {
+		prefix = v
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return fmt.Errorf("rate-limit: redis_url: %w", err)
+	}
+	client := redis.NewClient(opts)
 
+	build := func(name string, rate, burst float64) (*limiter, error) {
+		l, err := internalrl.NewRedis(client, prefix, algorithm, rate, burst)
+		if err != nil {
+			return nil, fmt.Errorf("rate-limit: %s limiter: %w", name, err)
+		}
+		return newLimiter(l, name, backendRedis), nil
+	}
+	if p.limiter, err = build("global", rps, burst); err != nil {
+		_ = client.Close()
+		return err
+	}
+	if keyRPM > 0 {
+		if p.keyStore, err = build("api_key", keyRPM/60.0, keyRPM); err != nil {
+			_ = client.Close()
+			return err
+		}
+	}
+	if userRPM > 0 {
+		if p.userStore, err = build("user", userRPM/60.0, userRPM); err != nil {
+			_ = client.Close()
+			return err
+		}
+	}
+	p.client = client
 	return nil
 }
 
 // Execute rejects the request if any configured rate limit is exceeded.
 // Checks are applied in order: global → per-key → per-user.
-func (p *Plugin) Execute(_ context.Context, pctx *plugin.Context) error {
-	if !p.limiter.Allow() {
-		pctx.Reject = true
-		pctx.Reason = "rate limit exceeded"
-		return nil
+func (p *Plugin) Execute(ctx context.Context, pctx *plugin.Context) error {
+	if denied, err := check(ctx, pctx, p.limiter, "", "rate limit exceeded"); denied || err != nil {
+		return err
 	}
 
 	if p.keyStore != nil {
 		if key, ok := pctx.Metadata["api_key"].(string); ok && key != "" {
-			if !p.keyStore.Allow(key) {
-				pctx.Reject = true
-				pctx.Reason = "per-key rate limit exceeded"
-				return nil
+			if denied, err := check(ctx, pctx, p.keyStore, key, "per-key rate limit exceeded"); denied || err != nil {
+				return err
 			}
 		}
 	}
 
 	if p.userStore != nil && pctx.Request != nil {
 		if userID := pctx.Request.User; userID != "" {
-			if !p.userStore.Allow(userID) {
-				pctx.Reject = true
-				pctx.Reason = "per-user rate limit exceeded"
-				return nil
+			if denied, err := check(ctx, pctx, p.userStore, userID, "per-user rate limit exceeded"); denied || err != nil {
+				return err
 			}
 		}
 	}
@@ -135,5 +312,41 @@ func (p *Plugin) Execute(_ context.Context, pctx *plugin.Context) error {
 	return nil
 }
 
-// Close releases plugin resources.
-func (p *Plugin) Close() error { return nil }
+// check consults l for key and rejects the request with reason when l refuses
+// it. An error means l could not decide and the request is left untouched.
+func check(ctx context.Context, pctx *plugin.Context, l *limiter, key, reason string) (denied bool, err error) {
+	ok, err := l.allow(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		pctx.Reject = true
+		pctx.Reason = reason
+		return true, nil
+	}
+	return false, nil
+}
+
+// Limiters returns the plugin's in-memory limiter layers by name ("global",
+// "api_key", "user") for the admin API. Layers kept in Redis are shared with
+// other replicas and are not listed.
+func (p *Plugin) Limiters() map[string]internalrl.Inspectable {
+	layers := make(map[string]internalrl.Inspectable)
+	for _, l := range []*limiter{p.limiter, p.keyStore, p.userStore} {
+		if l != nil && l.inspect != nil {
+			layers[l.name] = l
+		}
+	}
+	return layers
+}
+
+// Close releases plugin resources. It is safe to call more than once.
+func (p *Plugin) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		if p.client != nil {
+			err = p.client.Close()
+		}
+	})
+	return err
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"testing"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
 	"github.com/ferro-labs/ai-gateway/plugin"
 	"github.com/ferro-labs/ai-gateway/providers/core"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func newPlugin(t *testing.T, cfg map[string]any) *Plugin {
@@ -205,3 +208,103 @@ func TestPlugin_Execute_NoKeyOrUser_SkipsStores(t *testing.T) {
 		t.Errorf("expected allow for empty key/user, got Reject (reason: %q)", pctx.Reason)
 	}
 }
+
+func newRedisPlugin(t *testing.T, addr string, cfg map[string]any) *Plugin {
+	t.Helper()
+	cfg["backend"] = "redis"
+	cfg["redis_url"] = "redis://" + addr
+	p := newPlugin(t, cfg)
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func TestPlugin_Init_Backends(t *testing.T) {
+	for name, cfg := range map[string]map[string]any{
+		"unknown backend":         {"backend": "memcached"},
+		"redis without url":       {"backend": "redis"},
+		"redis bad url":           {"backend": "redis", "redis_url": "http://nope"},
+		"redis unknown algorithm": {"backend": "redis", "redis_url": "redis://127.0.0.1:1", "algorithm": "leaky"},
+		"memory with gcra":        {"algorithm": "gcra"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := &Plugin{}
+			if p.Init(cfg) == nil {
+				t.Error("expected Init to fail")
+			}
+		})
+	}
+}
+
+func TestPlugin_Execute_RedisSharedAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := func() map[string]any {
+		return map[string]any{"requests_per_second": 1000.0, "key_rpm": 2.0, "algorithm": "sliding_window"}
+	}
+	replicas := []*Plugin{newRedisPlugin(t, mr.Addr(), cfg()), newRedisPlugin(t, mr.Addr(), cfg())}
+	deniedBefore := counterValue(t, "api_key", "redis", "denied")
+
+	var admitted int
+	for i := range 4 {
+		pctx := &plugin.Context{Request: &core.Request{}, Metadata: map[string]any{"api_key": "client-key"}}
+		if err := replicas[i%2].Execute(context.Background(), pctx); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if !pctx.Reject {
+			admitted++
+		} else if pctx.Reason != "per-key rate limit exceeded" {
+			t.Fatalf("unexpected reason %q", pctx.Reason)
+		}
+	}
+	if admitted != 2 {
+		t.Fatalf("two replicas admitted %d requests for a 2 rpm key, want 2", admitted)
+	}
+	if got := counterValue(t, "api_key", "redis", "denied") - deniedBefore; got != 2 {
+		t.Fatalf("denied counter advanced by %v, want 2", got)
+	}
+}
+
+func TestPlugin_Execute_RedisUnavailableFailsClosed(t *testing.T) {
+	mr := miniredis.RunT(t)
+	p := newRedisPlugin(t, mr.Addr(), map[string]any{"requests_per_second": 10.0})
+	mr.Close()
+
+	pctx := &plugin.Context{Request: &core.Request{}, Metadata: map[string]any{}}
+	if err := p.Execute(context.Background(), pctx); err == nil {
+		t.Fatal("expected an error when Redis is unreachable")
+	}
+	if pctx.Reject {
+		t.Fatal("an undecided request must not be reported as rate-limited")
+	}
+}
+
+func counterValue(t *testing.T, labels ...string) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := metrics.RateLimitDecisions.WithLabelValues(labels...).Write(m); err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestPlugin_Limiters(t *testing.T) {
+	p := newPlugin(t, map[string]any{"key_rpm": 1.0})
+	pctx := &plugin.Context{Request: &core.Request{}, Metadata: map[string]any{"api_key": "key-1"}}
+	_ = p.Execute(context.Background(), pctx)
+
+	layers := p.Limiters()
+	if len(layers) != 2 || layers["global"] == nil || layers["api_key"] == nil {
+		t.Fatalf("layers = %v, want global and api_key", layers)
+	}
+	states := layers["api_key"].States()
+	if len(states) != 1 || states[0].Key != "key-1" {
+		t.Fatalf("api_key states = %+v, want the unprefixed key", states)
+	}
+	if !layers["api_key"].Reset("key-1") || len(layers["api_key"].States()) != 0 {
+		t.Fatal("expected Reset to forget key-1")
+	}
+
+	redisBacked := newPlugin(t, map[string]any{"backend": "redis", "redis_url": "redis://" + miniredis.RunT(t).Addr()})
+	if layers := redisBacked.Limiters(); len(layers) != 0 {
+		t.Fatalf("redis layers = %v, want none", layers)
+	}
+}
@@ -12,6 +12,11 @@ import (
 	"github.com/ferro-labs/ai-gateway/plugin"
 )
 
+// GuardrailHeader reports the filter's verdict to the client, as
+// "word-filter=pass" or "word-filter=block", when the gateway's
+// expose_plugin_metadata allows it.
+const GuardrailHeader = "X-Ferro-Guardrail"
+
 func init() {
 	plugin.RegisterFactory("word-filter", func() plugin.Plugin {
 		return &WordFilter{}
@@ -87,10 +92,12 @@ func (w *WordFilter) Execute(ctx context.Context, pctx *plugin.Context) error {
 					"matched_word", word)
 				pctx.Reject = true
 				pctx.Reason = "request blocked by content policy"
+				pctx.SetResponseHeader(GuardrailHeader, "word-filter=block")
 				return nil
 			}
 		}
 	}
+	pctx.SetResponseHeader(GuardrailHeader, "word-filter=pass")
 	return nil
 }
 
@@ -51,6 +51,9 @@ func TestWordFilter_BlocksMessage(t *testing.T) {
 	if !pctx.Reject {
 		t.Error("expected request to be rejected")
 	}
+	if got := pctx.ResponseHeaders[GuardrailHeader]; got != "word-filter=block" {
+		t.Errorf("%s = %q, want word-filter=block", GuardrailHeader, got)
+	}
 	if pctx.Reason != "request blocked by content policy" {
 		t.Errorf("unexpected reason: %q", pctx.Reason)
 	}
@@ -71,6 +74,9 @@ func TestWordFilter_AllowsCleanMessage(t *testing.T) {
 	if pctx.Reject {
 		t.Error("expected request to be allowed")
 	}
+	if got := pctx.ResponseHeaders[GuardrailHeader]; got != "word-filter=pass" {
+		t.Errorf("%s = %q, want word-filter=pass", GuardrailHeader, got)
+	}
 }
 
 func TestWordFilter_CaseInsensitive(t *testing.T) {
@@ -29,6 +29,11 @@ import (
 // pass-through endpoints (e.g. /v1/responses, /v1/audio/*, /v1/realtime).
 const proxyFlushInterval = -1 * time.Nanosecond
 
+// AllowFunc reports whether a pass-through request may be forwarded to the
+// provider name, returning the reason it may not. Its error is reported with
+// apierror.RouteErrorDetails, so a typed gateway error keeps its status.
+type AllowFunc func(ctx context.Context, name string) error
+
 // Handler returns an http.HandlerFunc that transparently forwards
 // any /v1/* request to the matching upstream provider.
 //
@@ -41,11 +46,18 @@ const proxyFlushInterval = -1 * time.Nanosecond
 //  1. X-Provider request header (e.g. "X-Provider: openai")
 //  2. "model" field in the JSON request body
 //
-// If neither resolves a provider, a 400 is returned with instructions.
-func Handler(registry *providers.Registry) http.HandlerFunc {
+// If neither resolves a provider, a 400 is returned with instructions. allow,
+// when non-nil, restricts the providers a request may reach: a model
+// resolves to the first provider serving it that allow accepts.
+func Handler(registry *providers.Registry, allow AllowFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		p, ok := ResolveProvider(r, registry)
-		if !ok {
+		p, err := ResolveAllowedProvider(r, registry, allow)
+		if err != nil {
+			status, errType, code := apierror.RouteErrorDetails(err)
+			apierror.WriteOpenAI(w, status, err.Error(), errType, code)
+			return
+		}
+		if p == nil {
 			apierror.WriteOpenAI(w, http.StatusBadRequest,
 				`no provider resolved; set the X-Provider header (e.g. "X-Provider: openai") or include a "model" field in the request body`,
 				"invalid_request_error",
@@ -53,113 +65,125 @@ func Handler(registry *providers.Registry) http.HandlerFunc {
 			)
 			return
 		}
+		Forward(w, r, p, nil)
+	}
+}
 
-		pp, canProxy := p.(providers.ProxiableProvider)
-		if !canProxy {
-			apierror.WriteOpenAI(w, http.StatusNotImplemented,
-				"provider "+p.Name()+" does not support proxy pass-through",
-				"invalid_request_error",
-				"proxy_not_supported",
-			)
-			return
-		}
-
-		// Non-OpenAI-wire providers (Anthropic, Gemini, Bedrock, Cohere, Vertex,
-		// Azure) cannot serve a transparently-forwarded OpenAI-shaped request at
-		// their base URL. Refuse with 501 instead of forwarding a request their
-		// upstream cannot parse; they remain available via their native
-		// translated endpoints. See core.NonOpenAIWireProvider.
-		if _, nativeOnly := p.(providers.NonOpenAIWireProvider); nativeOnly {
-			apierror.WriteOpenAI(w, http.StatusNotImplemented,
-				"provider "+p.Name()+" is not available for OpenAI-compatible pass-through; use its native chat, embeddings, or images endpoints",
-				"invalid_request_error",
-				"proxy_not_supported",
-			)
-			return
-		}
+// Forward proxies r to p's upstream, injecting p's authentication headers, and
+// writes the upstream's answer to w. It refuses providers that cannot be
+// proxied. onResponse, when non-nil, sees the upstream response before it is
+// forwarded; for an upgraded connection (a 101, e.g. /v1/realtime) it may
+// replace resp.Body, which must stay an io.ReadWriteCloser. For an upgraded
+// connection Forward returns once the tunnel closes.
+func Forward(w http.ResponseWriter, r *http.Request, p providers.Provider, onResponse func(resp *http.Response)) {
+	pp, canProxy := p.(providers.ProxiableProvider)
+	if !canProxy {
+		apierror.WriteOpenAI(w, http.StatusNotImplemented,
+			"provider "+p.Name()+" does not support proxy pass-through",
+			"invalid_request_error",
+			"proxy_not_supported",
+		)
+		return
+	}
 
-		providerName := p.Name()
+	// Non-OpenAI-wire providers (Anthropic, Gemini, Bedrock, Cohere, Vertex,
+	// Azure) cannot serve a transparently-forwarded OpenAI-shaped request at
+	// their base URL. Refuse with 501 instead of forwarding a request their
+	// upstream cannot parse; they remain available via their native
+	// translated endpoints. See core.NonOpenAIWireProvider.
+	if _, nativeOnly := p.(providers.NonOpenAIWireProvider); nativeOnly {
+		apierror.WriteOpenAI(w, http.StatusNotImplemented,
+			"provider "+p.Name()+" is not available for OpenAI-compatible pass-through; use its native chat, embeddings, or images endpoints",
+			"invalid_request_error",
+			"proxy_not_supported",
+		)
+		return
+	}
 
-		target, err := url.Parse(pp.BaseURL())
-		if err != nil {
-			//nolint:gosec // G706: providerName comes from the configured registry, not raw user input.
-			slog.Error("invalid provider base URL", "provider", providerName, "error", err)
-			apierror.WriteOpenAI(w, http.StatusInternalServerError, "upstream provider is unavailable", "server_error", "internal_error")
-			return
-		}
+	providerName := p.Name()
 
-		// The proxy is mounted at /v1/*, so every inbound path already carries
-		// the OpenAI /v1 prefix. Strip a trailing /v1 from the provider base
-		// path so a base URL that itself ends in /v1 (e.g. https://api.x.ai/v1)
-		// does not double the segment (…/v1 + /v1/responses -> /v1/v1/responses)
-		// and 404 upstream.
-		target.Path = strings.TrimSuffix(strings.TrimSuffix(target.Path, "/v1/"), "/v1")
-		target.RawPath = ""
-
-		authHeaders := pp.AuthHeaders()
-
-		// Use the raw SSE-tuned transport (no ResponseHeaderTimeout) so slow or
-		// streaming pass-through endpoints are not cut off at 30s while waiting
-		// for the upstream's first response header. The raw transport (not the
-		// otelhttp-wrapped client RoundTripper) keeps this a transparent proxy:
-		// no traceparent/tracestate injected into upstream requests and no extra
-		// OTel CLIENT span per proxied call.
-		//
-		// Providers requiring per-request signing (e.g. AWS SigV4) wrap that
-		// transport so the fully-formed outbound request is signed; a signing
-		// failure surfaces via ErrorHandler rather than as an unsigned forward.
-		var transport http.RoundTripper = httpclient.SharedStreamingTransport()
-		if signer, ok := p.(providers.RequestSigner); ok {
-			transport = signingRoundTripper{base: transport, signer: signer}
-		}
+	target, err := url.Parse(pp.BaseURL())
+	if err != nil {
+		//nolint:gosec // G706: providerName comes from the configured registry, not raw user input.
+		slog.Error("invalid provider base URL", "provider", providerName, "error", err)
+		apierror.WriteOpenAI(w, http.StatusInternalServerError, "upstream provider is unavailable", "server_error", "internal_error")
+		return
+	}
 
-		// WrapResponseWriter clears http.Server's WriteTimeout after the first
-		// write so long streams are not truncated. Cancelling this context on an
-		// idle upstream is what replaces the bound that removal gives up.
-		upstreamCtx, cancelUpstream := context.WithCancel(r.Context())
-		defer cancelUpstream()
-		r = r.WithContext(upstreamCtx)
-
-		proxy := &httputil.ReverseProxy{
-			Transport:     transport,
-			FlushInterval: proxyFlushInterval,
-			Rewrite: func(pr *httputil.ProxyRequest) {
-				pr.SetURL(target)
-				pr.Out.Header.Del("X-Provider")
-				pr.Out.Header.Del("Authorization")
-				for k, v := range authHeaders {
-					pr.Out.Header.Set(k, v)
-				}
-				pr.SetXForwarded()
-			},
-			ModifyResponse: func(resp *http.Response) error {
-				resp.Header.Set("X-Gateway-Provider", providerName)
-				// A 101 hands resp.Body to handleUpgradeResponse, which requires an
-				// io.ReadWriteCloser, and a tunnelled connection (e.g. /v1/realtime)
-				// is legitimately idle. Bound only ordinary response bodies.
-				if resp.StatusCode != http.StatusSwitchingProtocols {
-					resp.Body = streamio.NewIdleReadCloser(resp.Body, streamio.IdleTimeout(), cancelUpstream)
-				}
-				return nil
-			},
-			ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
-				var maxBytesErr *http.MaxBytesError
-				if errors.As(err, &maxBytesErr) {
-					apierror.WriteOpenAI(w, http.StatusRequestEntityTooLarge, "request body too large", "invalid_request_error", "request_too_large")
-					return
-				}
-				//nolint:gosec // G706: providerName comes from the configured registry, not raw user input.
-				slog.Error("proxy upstream error", "provider", providerName, "error", err)
-				apierror.WriteOpenAI(w, http.StatusBadGateway,
-					"upstream connection failed",
-					"server_error",
-					"upstream_error",
-				)
-			},
-		}
+	// The proxy is mounted at /v1/*, so every inbound path already carries
+	// the OpenAI /v1 prefix. Strip a trailing /v1 from the provider base
+	// path so a base URL that itself ends in /v1 (e.g. https://api.x.ai/v1)
+	// does not double the segment (…/v1 + /v1/responses -> /v1/v1/responses)
+	// and 404 upstream.
+	target.Path = strings.TrimSuffix(strings.TrimSuffix(target.Path, "/v1/"), "/v1")
+	target.RawPath = ""
+
+	authHeaders := pp.AuthHeaders()
+
+	// Use the raw SSE-tuned transport (no ResponseHeaderTimeout) so slow or
+	// streaming pass-through endpoints are not cut off at 30s while waiting
+	// for the upstream's first response header. The raw transport (not the
+	// otelhttp-wrapped client RoundTripper) keeps this a transparent proxy:
+	// no traceparent/tracestate injected into upstream requests and no extra
+	// OTel CLIENT span per proxied call.
+	//
+	// Providers requiring per-request signing (e.g. AWS SigV4) wrap that
+	// transport so the fully-formed outbound request is signed; a signing
+	// failure surfaces via ErrorHandler rather than as an unsigned forward.
+	var transport http.RoundTripper = httpclient.SharedStreamingTransport()
+	if signer, ok := p.(providers.RequestSigner); ok {
+		transport = signingRoundTripper{base: transport, signer: signer}
+	}
 
-		proxy.ServeHTTP(streamio.WrapResponseWriter(w), r)
+	// WrapResponseWriter clears http.Server's WriteTimeout after the first
+	// write so long streams are not truncated. Cancelling this context on an
+	// idle upstream is what replaces the bound that removal gives up.
+	upstreamCtx, cancelUpstream := context.WithCancel(r.Context())
+	defer cancelUpstream()
+	r = r.WithContext(upstreamCtx)
+
+	proxy := &httputil.ReverseProxy{
+		Transport:     transport,
+		FlushInterval: proxyFlushInterval,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.Header.Del("X-Provider")
+			pr.Out.Header.Del("Authorization")
+			for k, v := range authHeaders {
+				pr.Out.Header.Set(k, v)
+			}
+			pr.SetXForwarded()
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if onResponse != nil {
+				onResponse(resp)
+			}
+			resp.Header.Set("X-Gateway-Provider", providerName)
+			// A 101 hands resp.Body to handleUpgradeResponse, which requires an
+			// io.ReadWriteCloser, and a tunnelled connection (e.g. /v1/realtime)
+			// is legitimately idle. Bound only ordinary response bodies.
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				resp.Body = streamio.NewIdleReadCloser(resp.Body, streamio.IdleTimeout(), cancelUpstream)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				apierror.WriteOpenAI(w, http.StatusRequestEntityTooLarge, "request body too large", "invalid_request_error", "request_too_large")
+				return
+			}
+			//nolint:gosec // G706: providerName comes from the configured registry, not raw user input.
+			slog.Error("proxy upstream error", "provider", providerName, "error", err)
+			apierror.WriteOpenAI(w, http.StatusBadGateway,
+				"upstream connection failed",
+				"server_error",
+				"upstream_error",
+			)
+		},
 	}
+
+	proxy.ServeHTTP(streamio.WrapResponseWriter(w), r)
 }
 
 // signingRoundTripper signs each outbound proxied request via a provider's
@@ -199,6 +223,48 @@ func ResolveProvider(r *http.Request, registry *providers.Registry) (providers.P
 	return registry.FindByModel(model)
 }
 
+// ResolveAllowedProvider is ResolveProvider restricted to the providers allow
+// accepts. A model served only by providers allow refuses returns the first
+// refusal; nil with no error means no provider resolved.
+func ResolveAllowedProvider(r *http.Request, registry *providers.Registry, allow AllowFunc) (providers.Provider, error) {
+	if allow == nil {
+		p, _ := ResolveProvider(r, registry)
+		return p, nil
+	}
+	if name := r.Header.Get("X-Provider"); name != "" {
+		p, ok := registry.Get(name)
+		if !ok {
+			return nil, nil
+		}
+		if err := allow(r.Context(), name); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil, nil
+	}
+	model, err := ExtractTopLevelModel(r)
+	if err != nil || model == "" {
+		return nil, nil
+	}
+	var refused error
+	for _, name := range registry.List() {
+		p, ok := registry.Get(name)
+		if !ok || !p.SupportsModel(model) {
+			continue
+		}
+		err := allow(r.Context(), name)
+		if err == nil {
+			return p, nil
+		}
+		if refused == nil {
+			refused = err
+		}
+	}
+	return nil, refused
+}
+
 // ExtractTopLevelModel peeks at the JSON body to find the top-level "model"
 // field, then restores the body so it can be read again by downstream handlers.
 func ExtractTopLevelModel(r *http.Request) (string, error) {
@@ -166,7 +166,7 @@ func TestProxyHandler_ForwardsRequest(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", strings.NewReader(`{}`))
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -191,7 +191,7 @@ func TestProxyHandler_InjectsAuthHeader(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", nil)
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -214,7 +214,7 @@ func TestProxyHandler_RemovesGatewayHeaders(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", nil)
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -235,7 +235,7 @@ func TestProxyHandler_AddsGatewayProviderHeader(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", nil)
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -258,7 +258,7 @@ func TestProxyHandler_RebuildsForwardedHeaders(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", nil)
 	req.RemoteAddr = "203.0.113.10:1234"
@@ -285,7 +285,7 @@ func TestProxyHandler_PassthroughNon200(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", nil)
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -300,7 +300,7 @@ func TestProxyHandler_PassthroughNon200(t *testing.T) {
 
 func TestProxyHandler_NoProvider_Returns400(t *testing.T) {
 	reg := providers.NewRegistry() // empty registry
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/audio/transcriptions", nil)
 	w := httptest.NewRecorder()
@@ -318,6 +318,32 @@ func TestProxyHandler_NoProvider_Returns400(t *testing.T) {
 	}
 }
 
+func TestProxyHandler_AllowRefusesProvider(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("a refused provider was forwarded to")
+	}))
+	defer upstream.Close()
+	refuse := func(context.Context, string) error { return providers.ErrResidencyUnsatisfied }
+	handler := Handler(buildTestRegistry(t, upstream.URL), refuse)
+
+	for name, build := range map[string]func() *http.Request{
+		"by header": func() *http.Request {
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", nil)
+			req.Header.Set("X-Provider", providerOpenAI)
+			return req
+		},
+		"by model": func() *http.Request {
+			return httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"gpt-4o"}`))
+		},
+	} {
+		w := httptest.NewRecorder()
+		handler(w, build())
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
+
 // TestProxyHandler_DoesNotDoubleV1Prefix guards against the pass-through proxy
 // doubling the /v1 path segment for providers whose base URL already ends in
 // /v1 (e.g. xai, openrouter, cerebras). The proxy is mounted at /v1/*, so the
@@ -333,7 +359,7 @@ func TestProxyHandler_DoesNotDoubleV1Prefix(t *testing.T) {
 
 	// Provider whose base URL already ends in /v1.
 	reg := buildTestRegistry(t, upstream.URL+"/v1")
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{}`))
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -360,7 +386,7 @@ func TestProxyHandler_PreservesV1PrefixForRootBase(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL) // base has no /v1
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{}`))
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -384,7 +410,7 @@ func TestProxyHandler_GatesNonOpenAIWireProvider(t *testing.T) {
 	}
 	reg := providers.NewRegistry()
 	reg.Register(g)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{}`))
 	req.Header.Set("X-Provider", g.Name())
@@ -443,7 +469,7 @@ func TestProxyHandler_InvokesRequestSigner(t *testing.T) {
 	signed := false
 	reg := providers.NewRegistry()
 	reg.Register(stubSigningProvider{baseURL: upstream.URL, signed: &signed})
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{}`))
 	req.Header.Set("X-Provider", "stub-signer")
@@ -473,7 +499,7 @@ func TestProxyHandler_RequestSignerFailure_Returns502(t *testing.T) {
 
 	reg := providers.NewRegistry()
 	reg.Register(stubSigningProvider{baseURL: upstream.URL, signErr: errors.New("sign failed")})
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{}`))
 	req.Header.Set("X-Provider", "stub-signer")
@@ -571,7 +597,7 @@ func TestProxyHandler_ErrorHandler_GenericJSON(t *testing.T) {
 	dead.Close()
 
 	reg := buildTestRegistry(t, deadURL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/files", nil)
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -620,7 +646,7 @@ func TestProxyHandler_StreamingResponseUsesWriteDeadlines(t *testing.T) {
 	defer upstream.Close()
 
 	reg := buildTestRegistry(t, upstream.URL)
-	handler := Handler(reg)
+	handler := Handler(reg, nil)
 
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"gpt-4o","stream":true}`))
 	req.Header.Set("X-Provider", providerOpenAI)
@@ -669,7 +695,7 @@ func TestProxyHandler_StalledUpstreamIsCutByIdleTimeout(t *testing.T) {
 	}))
 	defer upstream.Close()
 
-	handler := Handler(buildTestRegistry(t, upstream.URL))
+	handler := Handler(buildTestRegistry(t, upstream.URL), nil)
 	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"gpt-4o","stream":true}`))
 	req.Header.Set("X-Provider", providerOpenAI)
 	req.ContentLength = int64(len(`{"model":"gpt-4o","stream":true}`))
@@ -716,7 +742,7 @@ func TestProxyHandler_SwitchingProtocolsUpgradeStillWorks(t *testing.T) {
 	}))
 	defer upstream.Close()
 
-	gateway := httptest.NewServer(Handler(buildTestRegistry(t, upstream.URL)))
+	gateway := httptest.NewServer(Handler(buildTestRegistry(t, upstream.URL), nil))
 	defer gateway.Close()
 
 	var dialer net.Dialer
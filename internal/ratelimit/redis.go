@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Algorithm names accepted by NewRedis.
+const (
+	// AlgorithmGCRA is the generic cell rate algorithm: one timestamp per
+	// key, and the same rate/burst behaviour as the in-memory token bucket.
+	AlgorithmGCRA = "gcra"
+	// AlgorithmSlidingWindow keeps a log of admitted requests per key and
+	// admits a request only while fewer than burst fall within the trailing
+	// burst/rate seconds. Exact, but its memory grows with the limit.
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// Both scripts take the clock from Redis TIME rather than from the caller, so
+// replicas with skewed clocks still agree on every key's state. Timestamps are
+// microseconds, written with %.0f because Lua's default number formatting
+// would round them to 14 significant digits.
+
+// gcraScript admits a request when the key's theoretical arrival time (TAT),
+// advanced by one emission interval, stays within the burst capacity of now.
+//
+//	KEYS[1] limiter key
+//	ARGV[1] emission interval in microseconds (1/rate)
+//	ARGV[2] capacity in microseconds (interval * burst)
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+if new_tat - now > capacity then
+	return 0
+end
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return 1
+`)
+
+// slidingWindowScript admits a request when fewer than limit requests were
+// admitted in the trailing window.
+//
+//	KEYS[1] limiter key
+//	ARGV[1] window in microseconds
+//	ARGV[2] limit
+//	ARGV[3] unique member suffix, so simultaneous requests are not collapsed
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', string.format('%.0f', now - window))
+if redis.call('ZCARD', KEYS[1]) >= limit then
+	return 0
+end
+local score = string.format('%.0f', now)
+redis.call('ZADD', KEYS[1], score, score .. '-' .. ARGV[3])
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+return 1
+`)
+
+// RedisLimiter is a rate limiter whose state lives in Redis, so every gateway
+// replica pointed at the same Redis enforces one shared limit per key. Each
+// decision is a single Lua script call, atomic on the Redis side.
+type RedisLimiter struct {
+	client    redis.Scripter
+	prefix    string
+	algorithm string
+	script    *redis.Script
+	args      []any
+	member    string        // per-limiter prefix for sliding-window entries
+	seq       atomic.Uint64 // makes sliding-window entries unique per call
+}
+
+// NewRedis creates a limiter allowing ratePerSecond requests/s per key with a
+// burst capacity, stored under keys beginning with prefix. If burst <= 0 it
+// defaults to ratePerSecond, as in New; a burst below one is raised to one so
+// the limiter can admit anything at all.
+func NewRedis(client redis.Scripter, prefix, algorithm string, ratePerSecond, burst float64) (*RedisLimiter, error) {
+	if ratePerSecond <= 0 {
+		return nil, fmt.Errorf("rate must be > 0")
+	}
+	if burst <= 0 {
+		burst = ratePerSecond
+	}
+	burst = math.Max(1, math.Floor(burst))
+	interval := 1e6 / ratePerSecond // microseconds
+
+	l := &RedisLimiter{client: client, prefix: prefix, algorithm: algorithm}
+	switch algorithm {
+	case AlgorithmGCRA:
+		l.script = gcraScript
+		l.args = []any{formatMicros(interval), formatMicros(interval * burst)}
+	case AlgorithmSlidingWindow:
+		l.script = slidingWindowScript
+		l.args = []any{formatMicros(interval * burst), strconv.FormatFloat(burst, 'f', 0, 64)}
+		var b [8]byte
+		_, _ = rand.Read(b[:])
+		l.member = hex.EncodeToString(b[:])
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q (want %q or %q)", algorithm, AlgorithmGCRA, AlgorithmSlidingWindow)
+	}
+	return l, nil
+}
+
+// Allow consumes one request from key's allowance and reports whether it is
+// permitted. An error means Redis could not be consulted and no decision was
+// made; the caller chooses whether that admits or refuses the request.
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	args := l.args
+	if l.algorithm == AlgorithmSlidingWindow {
+		args = append(args[:len(args):len(args)], l.member+strconv.FormatUint(l.seq.Add(1), 36))
+	}
+	n, err := l.script.Run(ctx, l.client, []string{l.prefix + key}, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis rate limiter: %w", err)
+	}
+	return n == 1, nil
+}
+
+func formatMicros(us float64) string {
+	return strconv.FormatFloat(math.Max(1, math.Round(us)), 'f', 0, 64)
+}
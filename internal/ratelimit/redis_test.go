@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newMiniredis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Unix(1_700_000_000, 0))
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func allowN(t *testing.T, l *RedisLimiter, key string, n int) int {
+	t.Helper()
+	allowed := 0
+	for range n {
+		ok, err := l.Allow(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if ok {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestRedisLimiter(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmGCRA, AlgorithmSlidingWindow} {
+		t.Run(algorithm, func(t *testing.T) {
+			mr, client := newMiniredis(t)
+			l, err := NewRedis(client, "test:", algorithm, 2, 5)
+			if err != nil {
+				t.Fatalf("NewRedis: %v", err)
+			}
+
+			if got := allowN(t, l, "a", 10); got != 5 {
+				t.Fatalf("burst admitted %d, want 5", got)
+			}
+			if got := allowN(t, l, "b", 1); got != 1 {
+				t.Fatal("another key must have its own allowance")
+			}
+
+			// A replica sharing the Redis sees the same exhausted allowance.
+			replica, _ := NewRedis(client, "test:", algorithm, 2, 5)
+			if got := allowN(t, replica, "a", 1); got != 0 {
+				t.Fatal("second limiter on the same Redis must share the limit")
+			}
+
+			// The window (burst/rate) passes and the full burst is available again.
+			mr.SetTime(time.Unix(1_700_000_000, 0).Add(2600 * time.Millisecond))
+			if got := allowN(t, l, "a", 10); got != 5 {
+				t.Fatalf("after the window admitted %d, want 5", got)
+			}
+		})
+	}
+}
+
+func TestRedisLimiter_GCRARefillsAtRate(t *testing.T) {
+	mr, client := newMiniredis(t)
+	l, _ := NewRedis(client, "test:", AlgorithmGCRA, 2, 2)
+	start := time.Unix(1_700_000_000, 0)
+
+	if got := allowN(t, l, "a", 3); got != 2 {
+		t.Fatalf("burst admitted %d, want 2", got)
+	}
+	// Half a second at 2/s earns exactly one request.
+	mr.SetTime(start.Add(500 * time.Millisecond))
+	if got := allowN(t, l, "a", 3); got != 1 {
+		t.Fatalf("after 500ms admitted %d, want 1", got)
+	}
+}
+
+func TestRedisLimiter_SlidingWindowIsExact(t *testing.T) {
+	mr, client := newMiniredis(t)
+	l, _ := NewRedis(client, "test:", AlgorithmSlidingWindow, 1, 3) // 3 per 3s
+	start := time.Unix(1_700_000_000, 0)
+
+	allowN(t, l, "a", 1)
+	mr.SetTime(start.Add(2 * time.Second))
+	if got := allowN(t, l, "a", 5); got != 2 {
+		t.Fatalf("admitted %d, want 2", got)
+	}
+	// Only the first request has left the window.
+	mr.SetTime(start.Add(3100 * time.Millisecond))
+	if got := allowN(t, l, "a", 5); got != 1 {
+		t.Fatalf("admitted %d after the oldest expired, want 1", got)
+	}
+}
+
+func TestNewRedis_Validation(t *testing.T) {
+	_, client := newMiniredis(t)
+	if _, err := NewRedis(client, "test:", "leaky", 1, 1); err == nil {
+		t.Error("expected an unknown algorithm to be rejected")
+	}
+	if _, err := NewRedis(client, "test:", AlgorithmGCRA, 0, 1); err == nil {
+		t.Error("expected a zero rate to be rejected")
+	}
+}
+
+func TestRedisLimiter_ErrorWhenUnavailable(t *testing.T) {
+	mr, client := newMiniredis(t)
+	l, _ := NewRedis(client, "test:", AlgorithmGCRA, 1, 1)
+	mr.Close()
+	if _, err := l.Allow(context.Background(), "a"); err == nil {
+		t.Fatal("expected an error when Redis is unreachable")
+	}
+}
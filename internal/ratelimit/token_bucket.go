@@ -1,9 +1,12 @@
-// Package ratelimit provides a simple in-memory token-bucket rate limiter.
-// It is used both as a standalone HTTP middleware (rate-limit by IP or API key)
-// and by the rate-limit plugin (per-provider limiting).
+// Package ratelimit provides a simple in-memory token-bucket rate limiter and a
+// Redis-backed limiter shared across gateway replicas. The in-memory limiter is
+// used both as a standalone HTTP middleware (rate-limit by IP or API key) and
+// by the rate-limit plugin; the Redis limiter backs the plugin when it is
+// configured with backend: redis.
 package ratelimit
 
 import (
+	"sort"
 	"sync"
 	"time"
 )
@@ -16,6 +19,33 @@ type Limiter struct {
 	tokens     float64 // current token count
 	lastRefill time.Time
 	now        func() time.Time
+
+	// rejections and lastRejection describe the requests refused since the
+	// bucket was created or last reset, for the admin API.
+	rejections    int64
+	lastRejection time.Time
+}
+
+// BucketState is a point-in-time view of one token bucket, as reported by the
+// admin API.
+type BucketState struct {
+	// Key is the bucket's key within its Store; empty for a lone Limiter.
+	Key           string     `json:"key,omitempty"`
+	Tokens        float64    `json:"tokens"`
+	Burst         float64    `json:"burst"`
+	RatePerSecond float64    `json:"rate_per_second"`
+	Rejections    int64      `json:"rejections"`
+	LastRejection *time.Time `json:"last_rejection,omitempty"`
+}
+
+// Inspectable is a set of token buckets the admin API can list and reset.
+// Both Limiter and Store implement it.
+type Inspectable interface {
+	// States returns the state of every bucket.
+	States() []BucketState
+	// Reset refills the bucket for key, or every bucket when key is empty,
+	// and reports whether anything was reset.
+	Reset(key string) bool
 }
 
 // New creates a Limiter allowing ratePerSecond requests/s with a burst capacity.
@@ -66,9 +96,50 @@ func (l *Limiter) Allow() bool {
 		l.tokens--
 		return true
 	}
+	l.rejections++
+	l.lastRejection = now
 	return false
 }
 
+// State returns the bucket's current state. Tokens includes the refill
+// accrued since the last request without consuming anything.
+func (l *Limiter) State() BucketState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tokens := l.tokens + l.now().Sub(l.lastRefill).Seconds()*l.rate
+	if tokens > l.burst {
+		tokens = l.burst
+	}
+	state := BucketState{
+		Tokens:        tokens,
+		Burst:         l.burst,
+		RatePerSecond: l.rate,
+		Rejections:    l.rejections,
+	}
+	if !l.lastRejection.IsZero() {
+		last := l.lastRejection
+		state.LastRejection = &last
+	}
+	return state
+}
+
+// States implements Inspectable with the limiter's single bucket.
+func (l *Limiter) States() []BucketState {
+	return []BucketState{l.State()}
+}
+
+// Reset implements Inspectable. A Limiter has one bucket, so key is ignored:
+// the bucket is refilled to its burst and its rejection count cleared.
+func (l *Limiter) Reset(string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = l.burst
+	l.lastRefill = l.now()
+	l.rejections = 0
+	l.lastRejection = time.Time{}
+	return true
+}
+
 // Store maintains per-key Limiter instances with an optional max-size cap.
 // When maxKeys > 0, inserting a new key that would exceed the cap evicts the
 // least recently accessed entry, preventing unbounded memory growth.
@@ -163,3 +234,38 @@ func (s *Store) Allow(key string) bool {
 	s.lastSeen.Store(key, now)
 	return l.Allow()
 }
+
+// States implements Inspectable, returning every tracked key's bucket sorted
+// by key.
+func (s *Store) States() []BucketState {
+	s.mu.RLock()
+	states := make([]BucketState, 0, len(s.limiters))
+	for key, l := range s.limiters {
+		state := l.State()
+		state.Key = key
+		states = append(states, state)
+	}
+	s.mu.RUnlock()
+	sort.Slice(states, func(i, j int) bool { return states[i].Key < states[j].Key })
+	return states
+}
+
+// Reset implements Inspectable by forgetting key's bucket, so its next request
+// starts from a full one. An empty key forgets every bucket. It reports false
+// when key is not tracked.
+func (s *Store) Reset(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key == "" {
+		reset := len(s.limiters) > 0
+		clear(s.limiters)
+		s.lastSeen.Clear()
+		return reset
+	}
+	if _, ok := s.limiters[key]; !ok {
+		return false
+	}
+	delete(s.limiters, key)
+	s.lastSeen.Delete(key)
+	return true
+}
@@ -122,3 +122,61 @@ func TestNewStoreWithMax_ZeroIsUnlimited(t *testing.T) {
 		t.Fatalf("expected 50 limiters, got %d", n)
 	}
 }
+
+func TestLimiter_StateAndReset(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(100, 0)
+	l := New(1, 2)
+	l.SetNowForTest(func() time.Time { return now })
+	l.Allow()
+	l.Allow()
+	l.Allow()
+
+	state := l.State()
+	if state.Tokens != 0 || state.Burst != 2 || state.RatePerSecond != 1 || state.Rejections != 1 {
+		t.Fatalf("state = %+v", state)
+	}
+	if state.LastRejection == nil || !state.LastRejection.Equal(now) {
+		t.Fatalf("LastRejection = %v, want %v", state.LastRejection, now)
+	}
+
+	// Reading the state reports the refill without consuming it.
+	now = now.Add(500 * time.Millisecond)
+	if got := l.State().Tokens; got != 0.5 {
+		t.Fatalf("Tokens after 500ms = %v, want 0.5", got)
+	}
+
+	l.Reset("")
+	if state := l.State(); state.Tokens != 2 || state.Rejections != 0 || state.LastRejection != nil {
+		t.Fatalf("state after reset = %+v", state)
+	}
+}
+
+func TestStore_StatesAndReset(t *testing.T) {
+	t.Parallel()
+
+	s := NewStore(1, 1)
+	s.Allow("b")
+	s.Allow("b")
+	s.Allow("a")
+
+	states := s.States()
+	if len(states) != 2 || states[0].Key != "a" || states[1].Key != "b" || states[1].Rejections != 1 {
+		t.Fatalf("states = %+v", states)
+	}
+
+	if !s.Reset("b") {
+		t.Fatal("Reset(b) = false, want true")
+	}
+	if s.Reset("b") {
+		t.Fatal("Reset of an untracked key = true, want false")
+	}
+	if !s.Allow("b") {
+		t.Fatal("expected a reset key to start from a full bucket")
+	}
+
+	if !s.Reset("") || len(s.States()) != 0 {
+		t.Fatalf("Reset(\"\") left %d buckets", len(s.States()))
+	}
+}
@@ -0,0 +1,203 @@
+// Package realtime meters proxied OpenAI Realtime API sessions. A session is
+// a WebSocket tunnel the gateway forwards byte for byte; Meter decodes the
+// server's side of it as it passes through and reports the token usage each
+// response.done event carries, so realtime traffic is accounted like any
+// other request without the gateway terminating the WebSocket itself.
+//
+// Frames are read uncompressed: the proxy drops the client's
+// Sec-WebSocket-Extensions header so permessage-deflate is never negotiated.
+package realtime
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxMessageBytes caps the text message Meter buffers to look for usage.
+// Larger messages (audio deltas, mostly) are forwarded but not decoded.
+const maxMessageBytes = 1 << 20
+
+// WebSocket opcodes (RFC 6455 §5.2).
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opClose        = 0x8
+)
+
+// Usage is the token usage of one realtime response.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+	InputDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+		TextTokens   int `json:"text_tokens"`
+		AudioTokens  int `json:"audio_tokens"`
+	} `json:"input_token_details"`
+	OutputDetails struct {
+		TextTokens  int `json:"text_tokens"`
+		AudioTokens int `json:"audio_tokens"`
+	} `json:"output_token_details"`
+}
+
+// Meter wraps the upstream side of an upgraded connection. Reads pass through
+// unchanged while their bytes are decoded as server WebSocket frames;
+// onUsage is called with the usage of every response.done event. Writes go
+// straight to conn; Close closes conn once every event read so far has been
+// reported. A stream Meter cannot decode is still forwarded;
+// it just stops being metered.
+func Meter(conn io.ReadWriteCloser, onUsage func(Usage)) io.ReadWriteCloser {
+	m := &meter{ReadWriteCloser: conn, onUsage: onUsage, done: make(chan struct{})}
+	pr, pw := io.Pipe()
+	m.pw = pw
+	go m.decode(pr)
+	return m
+}
+
+type meter struct {
+	io.ReadWriteCloser
+	onUsage func(Usage)
+
+	pw       *io.PipeWriter
+	done     chan struct{} // closed when decode returns
+	mu       sync.Mutex
+	detached bool // the decoder has stopped; reads are no longer copied to it
+}
+
+func (m *meter) Read(p []byte) (int, error) {
+	n, err := m.ReadWriteCloser.Read(p)
+	if n > 0 {
+		m.mu.Lock()
+		if !m.detached {
+			if _, werr := m.pw.Write(p[:n]); werr != nil {
+				m.detached = true
+			}
+		}
+		m.mu.Unlock()
+	}
+	if err != nil {
+		_ = m.pw.CloseWithError(err)
+	}
+	return n, err
+}
+
+func (m *meter) Close() error {
+	_ = m.pw.Close()
+	<-m.done
+	return m.ReadWriteCloser.Close()
+}
+
+// decode reads frames from r until it ends or cannot be decoded, then closes
+// r so the copy in Read stops feeding it.
+func (m *meter) decode(r *io.PipeReader) {
+	defer close(m.done)
+	defer r.Close()
+	var msg []byte
+	var msgOp byte
+	oversized := false
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		switch f.op {
+		case opText:
+			msg, msgOp, oversized = msg[:0], f.op, false
+		case opContinuation:
+		case opClose:
+			return
+		default:
+			// Binary, ping, and pong frames carry no usage. Control frames can
+			// arrive between the fragments of a message, so msg is kept.
+			if f.op < 0x8 {
+				msgOp = f.op
+			}
+			continue
+		}
+		if msgOp != opText {
+			continue
+		}
+		if !f.skipped && len(msg)+len(f.payload) <= maxMessageBytes {
+			msg = append(msg, f.payload...)
+		} else {
+			oversized = true
+		}
+		if f.fin && !oversized {
+			m.inspect(msg)
+		}
+	}
+}
+
+// inspect reports the usage of a response.done event.
+func (m *meter) inspect(msg []byte) {
+	var event struct {
+		Type     string `json:"type"`
+		Response struct {
+			Usage *Usage `json:"usage"`
+		} `json:"response"`
+	}
+	if json.Unmarshal(msg, &event) != nil || event.Type != "response.done" || event.Response.Usage == nil {
+		return
+	}
+	m.onUsage(*event.Response.Usage)
+}
+
+// frame is one WebSocket frame. skipped is set, and payload nil, for a
+// payload too large to be worth decoding.
+type frame struct {
+	fin     bool
+	op      byte
+	payload []byte
+	skipped bool
+}
+
+// readFrame reads one frame. Server frames are unmasked, but a masked one is
+// unmasked all the same.
+func readFrame(r io.Reader) (frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return frame{}, err
+	}
+	f := frame{fin: head[0]&0x80 != 0, op: head[0] & 0x0f}
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return frame{}, err
+		}
+	}
+	if length > maxMessageBytes {
+		f.skipped = true
+		if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil { //nolint:gosec // G115: a length past MaxInt64 fails when the stream ends first.
+			return frame{}, err
+		}
+		return f, nil
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.payload); err != nil {
+		return frame{}, err
+	}
+	if masked {
+		for i := range f.payload {
+			f.payload[i] ^= mask[i%4]
+		}
+	}
+	return f, nil
+}
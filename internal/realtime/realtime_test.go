@@ -0,0 +1,85 @@
+package realtime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+// serverFrame encodes an unmasked frame.
+func serverFrame(fin bool, op byte, payload []byte) []byte {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	var out []byte
+	switch n := len(payload); {
+	case n < 126:
+		out = []byte{b0, byte(n)}
+	case n <= 0xffff:
+		out = []byte{b0, 126, 0, 0}
+		binary.BigEndian.PutUint16(out[2:], uint16(n))
+	default:
+		out = make([]byte, 10)
+		out[0], out[1] = b0, 127
+		binary.BigEndian.PutUint64(out[2:], uint64(n))
+	}
+	return append(out, payload...)
+}
+
+type conn struct {
+	io.Reader
+	closed bool
+}
+
+func (c *conn) Write(p []byte) (int, error) { return len(p), nil }
+func (c *conn) Close() error                { c.closed = true; return nil }
+
+func TestMeter(t *testing.T) {
+	done := `{"type":"response.done","response":{"usage":{"input_tokens":12,"output_tokens":30,"total_tokens":42,"input_token_details":{"cached_tokens":4}}}}`
+	var stream []byte
+	stream = append(stream, serverFrame(true, opText, []byte(`{"type":"session.created"}`))...)
+	stream = append(stream, serverFrame(true, 0x2, bytes.Repeat([]byte{0xff}, 300))...)
+	// A fragmented response.done with a ping between its fragments.
+	stream = append(stream, serverFrame(false, opText, []byte(done[:20]))...)
+	stream = append(stream, serverFrame(true, 0x9, nil)...)
+	stream = append(stream, serverFrame(true, opContinuation, []byte(done[20:]))...)
+	// Too large to decode; it must be skipped, not counted.
+	big := `{"type":"response.done","response":{"usage":{"input_tokens":1}},"pad":"` + strings.Repeat("x", maxMessageBytes) + `"}`
+	stream = append(stream, serverFrame(true, opText, []byte(big))...)
+	stream = append(stream, serverFrame(true, opText, []byte(done))...)
+
+	var got []Usage
+	c := &conn{Reader: bytes.NewReader(stream)}
+	m := Meter(c, func(u Usage) { got = append(got, u) })
+
+	forwarded, err := io.ReadAll(m)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(forwarded, stream) {
+		t.Fatal("metered stream differs from the upstream's")
+	}
+	if err := m.Close(); err != nil || !c.closed {
+		t.Fatalf("Close: err %v, underlying closed %v", err, c.closed)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("reported %d usages, want 2: %+v", len(got), got)
+	}
+	if u := got[0]; u.InputTokens != 12 || u.OutputTokens != 30 || u.InputDetails.CachedTokens != 4 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestMeter_UndecodableStreamStillForwarded(t *testing.T) {
+	stream := []byte("not a websocket stream at all, just bytes")
+	m := Meter(&conn{Reader: bytes.NewReader(stream)}, func(Usage) { t.Error("usage reported for garbage") })
+	forwarded, err := io.ReadAll(m)
+	if err != nil || !bytes.Equal(forwarded, stream) {
+		t.Fatalf("forwarded %q, err %v", forwarded, err)
+	}
+	_ = m.Close()
+}
@@ -0,0 +1,314 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Export sources.
+const (
+	// SourceOpenAI is the OpenAI usage CSV: the completions usage export of
+	// the usage dashboard (start_time_iso, model, num_model_requests,
+	// input_tokens, output_tokens) or the legacy activity export (timestamp,
+	// snapshot_id, n_requests, n_context_tokens_total,
+	// n_generated_tokens_total).
+	SourceOpenAI = "openai"
+	// SourceAnthropic is the Anthropic console usage or cost CSV
+	// (usage_date_utc, model, the input token columns by cache use,
+	// output_tokens, cost_usd).
+	SourceAnthropic = "anthropic"
+)
+
+// Sources lists the export sources Parse reads.
+var Sources = []string{SourceOpenAI, SourceAnthropic}
+
+// Usage is one UTC day and model of usage.
+type Usage struct {
+	Day              string  `json:"day,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// Export is a parsed provider export, one row per day and model, ordered by
+// both.
+type Export struct {
+	Source string
+	Rows   []Usage
+	// Compared lists the quantities the export carries, as Quantity names;
+	// only these are reconciled.
+	Compared []string
+}
+
+// Reconciled quantities.
+const (
+	QuantityRequests         = "requests"
+	QuantityPromptTokens     = "prompt_tokens"
+	QuantityCompletionTokens = "completion_tokens"
+	QuantityCost             = "cost_usd"
+)
+
+// exportFormat names the columns of one source's export; each quantity is
+// read from the first of its columns the export has.
+type exportFormat struct {
+	day, model, requests, completion, cost []string
+	// prompt lists groups of columns instead: the first group the export
+	// has any column of is summed, as Anthropic splits input tokens by cache
+	// use.
+	prompt [][]string
+}
+
+var formats = map[string]exportFormat{
+	SourceOpenAI: {
+		day:        []string{"start_time_iso", "start_time", "timestamp", "date"},
+		model:      []string{"model", "snapshot_id"},
+		requests:   []string{"num_model_requests", "n_requests"},
+		prompt:     [][]string{{"input_tokens"}, {"n_context_tokens_total"}},
+		completion: []string{"output_tokens", "n_generated_tokens_total"},
+		cost:       []string{"cost_usd", "amount_value"},
+	},
+	SourceAnthropic: {
+		day:        []string{"usage_date_utc", "date"},
+		model:      []string{"model"},
+		prompt:     [][]string{{"uncached_input_tokens", "cache_read_input_tokens", "cache_creation_input_tokens"}, {"input_tokens"}},
+		completion: []string{"output_tokens"},
+		cost:       []string{"cost_usd", "cost"},
+	},
+}
+
+// DetectSource names the source of an export from its header: Anthropic's
+// has usage_date_utc or uncached_input_tokens, anything else is read as
+// OpenAI's.
+func DetectSource(header []string) string {
+	for _, h := range header {
+		if n := normalizeColumn(h); n == "usage_date_utc" || n == "uncached_input_tokens" {
+			return SourceAnthropic
+		}
+	}
+	return SourceOpenAI
+}
+
+// Parse reads a provider usage export in CSV. An empty source is detected
+// from the header. Rows for the same day and model are summed.
+func Parse(r io.Reader, source string) (*Export, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("export is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read export header: %w", err)
+	}
+	if source == "" {
+		source = DetectSource(header)
+	}
+	f, ok := formats[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown source %q: must be one of %s", source, strings.Join(Sources, ", "))
+	}
+
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		if _, seen := index[normalizeColumn(h)]; !seen {
+			index[normalizeColumn(h)] = i
+		}
+	}
+	first := func(names []string) int {
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	// columns returns the columns a quantity is read from: the first of
+	// names present, or with all set every one.
+	columns := func(names []string, all bool) []int {
+		var cols []int
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				cols = append(cols, i)
+				if !all {
+					break
+				}
+			}
+		}
+		return cols
+	}
+
+	dayCol, modelCol := first(f.day), first(f.model)
+	if dayCol < 0 || modelCol < 0 {
+		return nil, fmt.Errorf("%s export needs a date column (%s) and a model column (%s)",
+			source, strings.Join(f.day, ", "), strings.Join(f.model, ", "))
+	}
+	requestCols := columns(f.requests, false)
+	var promptCols []int
+	for _, group := range f.prompt {
+		if promptCols = columns(group, true); len(promptCols) > 0 {
+			break
+		}
+	}
+	completionCols := columns(f.completion, false)
+	costCols := columns(f.cost, false)
+
+	exp := &Export{Source: source}
+	if len(requestCols) > 0 {
+		exp.Compared = append(exp.Compared, QuantityRequests)
+	}
+	if len(promptCols) > 0 {
+		exp.Compared = append(exp.Compared, QuantityPromptTokens)
+	}
+	if len(completionCols) > 0 {
+		exp.Compared = append(exp.Compared, QuantityCompletionTokens)
+	}
+	if len(costCols) > 0 {
+		exp.Compared = append(exp.Compared, QuantityCost)
+	}
+	if len(exp.Compared) == 0 {
+		return nil, fmt.Errorf("%s export has no request, token, or cost column", source)
+	}
+
+	byKey := make(map[[2]string]*Usage)
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read export line %d: %w", line, err)
+		}
+		field := func(i int) string {
+			if i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if slices.IndexFunc(record, func(s string) bool { return strings.TrimSpace(s) != "" }) < 0 {
+			continue
+		}
+		day, err := parseDay(field(dayCol))
+		if err != nil {
+			return nil, fmt.Errorf("export line %d: %w", line, err)
+		}
+		model := field(modelCol)
+		sum := func(cols []int) (float64, error) {
+			var total float64
+			for _, c := range cols {
+				v, err := parseNumber(field(c))
+				if err != nil {
+					return 0, fmt.Errorf("export line %d, column %q: %w", line, header[c], err)
+				}
+				total += v
+			}
+			return total, nil
+		}
+		requests, err := sum(requestCols)
+		if err != nil {
+			return nil, err
+		}
+		prompt, err := sum(promptCols)
+		if err != nil {
+			return nil, err
+		}
+		completion, err := sum(completionCols)
+		if err != nil {
+			return nil, err
+		}
+		cost, err := sum(costCols)
+		if err != nil {
+			return nil, err
+		}
+
+		key := [2]string{day, model}
+		u := byKey[key]
+		if u == nil {
+			u = &Usage{Day: day, Model: model}
+			byKey[key] = u
+		}
+		u.Requests += int64(math.Round(requests))
+		u.PromptTokens += int64(math.Round(prompt))
+		u.CompletionTokens += int64(math.Round(completion))
+		u.CostUSD += cost
+	}
+
+	exp.Rows = make([]Usage, 0, len(byKey))
+	for _, u := range byKey {
+		exp.Rows = append(exp.Rows, *u)
+	}
+	slices.SortFunc(exp.Rows, func(a, b Usage) int {
+		if c := strings.Compare(a.Day, b.Day); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Model, b.Model)
+	})
+	return exp, nil
+}
+
+// normalizeColumn lowercases a header and joins its words with underscores,
+// so "Usage Date (UTC)" reads as usage_date_utc.
+func normalizeColumn(h string) string {
+	h = strings.TrimPrefix(h, "\ufeff")
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(h) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	return b.String()
+}
+
+// dayLayouts are the date formats exports use, tried in order after Unix
+// seconds.
+var dayLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseDay returns the UTC day (YYYY-MM-DD) of an export's date cell.
+func parseDay(s string) (string, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC().Format(time.DateOnly), nil
+	}
+	for _, layout := range dayLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(time.DateOnly), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", s)
+}
+
+// parseNumber reads a numeric cell, allowing a currency sign and thousands
+// separators. An empty cell is zero.
+func parseNumber(s string) (float64, error) {
+	s = strings.NewReplacer(",", "", "$", "").Replace(s)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,175 @@
+// Package reconcile checks a provider's usage export, the figures its invoice
+// is built from, against the usage the gateway recorded for that provider.
+//
+// Parse reads the export into one row per UTC day and model; Compare matches
+// those rows to the request log's daily usage and reports each day and model
+// whose figures differ by more than a tolerance, or that only one side has.
+package reconcile
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+)
+
+// Row statuses.
+const (
+	// StatusMatch is a day and model whose figures agree within tolerance.
+	StatusMatch = "match"
+	// StatusMismatch is a day and model whose figures differ.
+	StatusMismatch = "mismatch"
+	// StatusMissingInGateway is usage the provider billed that the gateway
+	// did not record: requests made around the gateway, or while its
+	// request log was off.
+	StatusMissingInGateway = "missing_in_gateway"
+	// StatusMissingInProvider is usage the gateway recorded that the export
+	// does not have.
+	StatusMissingInProvider = "missing_in_provider"
+)
+
+// DefaultTolerance is the relative difference below which figures match.
+const DefaultTolerance = 0.01
+
+// Row is one day and model of a reconciliation.
+type Row struct {
+	Day      string `json:"day"`
+	Model    string `json:"model"`
+	Status   string `json:"status"`
+	Provider Usage  `json:"provider"`
+	Gateway  Usage  `json:"gateway"`
+	// Discrepancies names the quantities that differ beyond tolerance.
+	Discrepancies []string `json:"discrepancies,omitempty"`
+}
+
+// Summary counts a reconciliation's rows by status and totals both sides.
+type Summary struct {
+	Rows              int   `json:"rows"`
+	Matched           int   `json:"matched"`
+	Mismatched        int   `json:"mismatched"`
+	MissingInGateway  int   `json:"missing_in_gateway"`
+	MissingInProvider int   `json:"missing_in_provider"`
+	ProviderTotals    Usage `json:"provider_totals"`
+	GatewayTotals     Usage `json:"gateway_totals"`
+}
+
+// GatewayUsage turns the request log's daily usage rows for provider into
+// usage by day and model. A provider bills neither responses served from the
+// response cache nor failed requests, so neither counts.
+func GatewayUsage(rows []requestlog.UsageRow, provider string) []Usage {
+	byKey := make(map[[2]string]*Usage)
+	for _, r := range rows {
+		if r.Provider != provider {
+			continue
+		}
+		key := [2]string{r.Day, r.Model}
+		u := byKey[key]
+		if u == nil {
+			u = &Usage{Day: r.Day, Model: r.Model}
+			byKey[key] = u
+		}
+		u.Requests += r.Requests - r.CachedRequests - r.Errors
+		u.PromptTokens += r.PromptTokens
+		u.CompletionTokens += r.CompletionTokens
+		u.CostUSD += r.CostUSD
+	}
+	out := make([]Usage, 0, len(byKey))
+	for _, u := range byKey {
+		out = append(out, *u)
+	}
+	return out
+}
+
+// Compare reconciles exp against the gateway's usage, comparing the
+// quantities exp carries. Rows are ordered by day and model.
+func Compare(exp *Export, gateway []Usage, tolerance float64) ([]Row, Summary) {
+	byKey := make(map[[2]string]*Row)
+	row := func(day, model string) *Row {
+		key := [2]string{day, model}
+		if byKey[key] == nil {
+			byKey[key] = &Row{Day: day, Model: model}
+		}
+		return byKey[key]
+	}
+	provided := make(map[[2]string]bool, len(exp.Rows))
+	for _, u := range exp.Rows {
+		row(u.Day, u.Model).Provider = u
+		provided[[2]string{u.Day, u.Model}] = true
+	}
+	recorded := make(map[[2]string]bool, len(gateway))
+	for _, u := range gateway {
+		row(u.Day, u.Model).Gateway = u
+		recorded[[2]string{u.Day, u.Model}] = true
+	}
+
+	var s Summary
+	rows := make([]Row, 0, len(byKey))
+	for key, r := range byKey {
+		switch {
+		case !recorded[key]:
+			r.Status = StatusMissingInGateway
+			s.MissingInGateway++
+		case !provided[key]:
+			r.Status = StatusMissingInProvider
+			s.MissingInProvider++
+		default:
+			r.Discrepancies = discrepancies(exp.Compared, r.Provider, r.Gateway, tolerance)
+			if len(r.Discrepancies) == 0 {
+				r.Status = StatusMatch
+				s.Matched++
+			} else {
+				r.Status = StatusMismatch
+				s.Mismatched++
+			}
+		}
+		add(&s.ProviderTotals, r.Provider)
+		add(&s.GatewayTotals, r.Gateway)
+		rows = append(rows, *r)
+	}
+	s.Rows = len(rows)
+	slices.SortFunc(rows, func(a, b Row) int {
+		if c := strings.Compare(a.Day, b.Day); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Model, b.Model)
+	})
+	return rows, s
+}
+
+func discrepancies(compared []string, provider, gateway Usage, tolerance float64) []string {
+	var out []string
+	for _, q := range compared {
+		var p, g float64
+		switch q {
+		case QuantityRequests:
+			p, g = float64(provider.Requests), float64(gateway.Requests)
+		case QuantityPromptTokens:
+			p, g = float64(provider.PromptTokens), float64(gateway.PromptTokens)
+		case QuantityCompletionTokens:
+			p, g = float64(provider.CompletionTokens), float64(gateway.CompletionTokens)
+		case QuantityCost:
+			p, g = provider.CostUSD, gateway.CostUSD
+		}
+		if relativeDifference(p, g) > tolerance {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+// relativeDifference is |a-b| over the larger of the two; zero when both are.
+func relativeDifference(a, b float64) float64 {
+	larger := math.Max(math.Abs(a), math.Abs(b))
+	if larger == 0 {
+		return 0
+	}
+	return math.Abs(a-b) / larger
+}
+
+func add(total *Usage, u Usage) {
+	total.Requests += u.Requests
+	total.PromptTokens += u.PromptTokens
+	total.CompletionTokens += u.CompletionTokens
+	total.CostUSD += u.CostUSD
+}
@@ -0,0 +1,129 @@
+package reconcile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/requestlog"
+)
+
+const openAIExport = `start_time,end_time,start_time_iso,end_time_iso,project_id,num_model_requests,user_id,api_key_id,model,batch,input_tokens,input_cached_tokens,output_tokens
+1735689600,1735776000,2025-01-01T00:00:00+00:00,2025-01-02T00:00:00+00:00,proj_1,10,,key_1,gpt-4o-2024-08-06,False,1000,200,300
+1735689600,1735776000,2025-01-01T00:00:00+00:00,2025-01-02T00:00:00+00:00,proj_2,5,,key_2,gpt-4o-2024-08-06,False,500,0,100
+1735776000,1735862400,2025-01-02T00:00:00+00:00,2025-01-03T00:00:00+00:00,proj_1,2,,key_1,gpt-4o-mini,False,40,0,20
+`
+
+const anthropicExport = "\ufeffUsage Date (UTC),Model,Workspace,API Key,Uncached Input Tokens,Cache Read Input Tokens,Cache Creation Input Tokens,Output Tokens,Cost (USD)\n" +
+	"2025-01-01,claude-sonnet-4,Default,key-a,\"1,000\",500,100,400,$1.25\n" +
+	"2025-01-01,claude-sonnet-4,Default,key-b,100,0,0,50,0.10\n"
+
+func TestParse_OpenAI(t *testing.T) {
+	exp, err := Parse(strings.NewReader(openAIExport), "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if exp.Source != SourceOpenAI {
+		t.Fatalf("source = %q", exp.Source)
+	}
+	want := []Usage{
+		{Day: "2025-01-01", Model: "gpt-4o-2024-08-06", Requests: 15, PromptTokens: 1500, CompletionTokens: 400},
+		{Day: "2025-01-02", Model: "gpt-4o-mini", Requests: 2, PromptTokens: 40, CompletionTokens: 20},
+	}
+	if !reflect.DeepEqual(exp.Rows, want) {
+		t.Fatalf("rows = %+v\nwant %+v", exp.Rows, want)
+	}
+	if !reflect.DeepEqual(exp.Compared, []string{QuantityRequests, QuantityPromptTokens, QuantityCompletionTokens}) {
+		t.Fatalf("compared = %v", exp.Compared)
+	}
+}
+
+func TestParse_OpenAILegacy(t *testing.T) {
+	exp, err := Parse(strings.NewReader("timestamp,n_requests,operation,snapshot_id,n_context_tokens_total,n_generated_tokens_total\n1735700000,3,completion,gpt-4,90,30\n"), SourceOpenAI)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []Usage{{Day: "2025-01-01", Model: "gpt-4", Requests: 3, PromptTokens: 90, CompletionTokens: 30}}
+	if !reflect.DeepEqual(exp.Rows, want) {
+		t.Fatalf("rows = %+v", exp.Rows)
+	}
+}
+
+func TestParse_Anthropic(t *testing.T) {
+	exp, err := Parse(strings.NewReader(anthropicExport), "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if exp.Source != SourceAnthropic {
+		t.Fatalf("source = %q", exp.Source)
+	}
+	if len(exp.Rows) != 1 {
+		t.Fatalf("rows = %+v", exp.Rows)
+	}
+	got := exp.Rows[0]
+	if got.PromptTokens != 1700 || got.CompletionTokens != 450 || got.Requests != 0 || got.CostUSD < 1.349 || got.CostUSD > 1.351 {
+		t.Fatalf("row = %+v, want input tokens summed over cache use", got)
+	}
+	if !reflect.DeepEqual(exp.Compared, []string{QuantityPromptTokens, QuantityCompletionTokens, QuantityCost}) {
+		t.Fatalf("compared = %v", exp.Compared)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for name, tc := range map[string]struct{ body, source string }{
+		"empty":          {"", ""},
+		"unknown source": {openAIExport, "mistral"},
+		"no model":       {"date,input_tokens\n2025-01-01,4\n", SourceOpenAI},
+		"no quantities":  {"date,model\n2025-01-01,gpt-4o\n", SourceOpenAI},
+		"bad date":       {"date,model,input_tokens\nyesterday,gpt-4o,4\n", SourceOpenAI},
+		"bad number":     {"date,model,input_tokens\n2025-01-01,gpt-4o,many\n", SourceOpenAI},
+	} {
+		if _, err := Parse(strings.NewReader(tc.body), tc.source); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	exp := &Export{
+		Source:   SourceOpenAI,
+		Compared: []string{QuantityRequests, QuantityPromptTokens, QuantityCompletionTokens},
+		Rows: []Usage{
+			{Day: "2025-01-01", Model: "gpt-4o", Requests: 100, PromptTokens: 10000, CompletionTokens: 2000},
+			{Day: "2025-01-01", Model: "gpt-4o-mini", Requests: 10, PromptTokens: 1000, CompletionTokens: 100},
+			{Day: "2025-01-02", Model: "gpt-4o", Requests: 7, PromptTokens: 700, CompletionTokens: 70},
+		},
+	}
+	gateway := GatewayUsage([]requestlog.UsageRow{
+		// Within 1%, once the cached request is left out.
+		{Day: "2025-01-01", Provider: "openai", Model: "gpt-4o", Requests: 100, CachedRequests: 1, PromptTokens: 9950, CompletionTokens: 2000, CostUSD: 5},
+		{Day: "2025-01-01", Provider: "openai", Model: "gpt-4o-mini", Requests: 8, PromptTokens: 800, CompletionTokens: 100},
+		{Day: "2025-01-03", Provider: "openai", Model: "gpt-4o", Requests: 1, PromptTokens: 10, CompletionTokens: 1},
+		// Another provider's usage is not the export's.
+		{Day: "2025-01-02", Provider: "azure-openai", Model: "gpt-4o", Requests: 7, PromptTokens: 700, CompletionTokens: 70},
+	}, "openai")
+
+	rows, summary := Compare(exp, gateway, DefaultTolerance)
+	statuses := make([]string, len(rows))
+	for i, r := range rows {
+		statuses[i] = r.Day + " " + r.Model + " " + r.Status
+	}
+	want := []string{
+		"2025-01-01 gpt-4o match",
+		"2025-01-01 gpt-4o-mini mismatch",
+		"2025-01-02 gpt-4o missing_in_gateway",
+		"2025-01-03 gpt-4o missing_in_provider",
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("statuses = %v\nwant %v", statuses, want)
+	}
+	if !reflect.DeepEqual(rows[1].Discrepancies, []string{QuantityRequests, QuantityPromptTokens}) {
+		t.Fatalf("discrepancies = %v", rows[1].Discrepancies)
+	}
+	if summary.Rows != 4 || summary.Matched != 1 || summary.Mismatched != 1 || summary.MissingInGateway != 1 || summary.MissingInProvider != 1 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	if summary.ProviderTotals.Requests != 117 || summary.GatewayTotals.Requests != 108 {
+		t.Fatalf("totals = %+v / %+v", summary.ProviderTotals, summary.GatewayTotals)
+	}
+}
@@ -0,0 +1,201 @@
+package requestlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/internal/sqldb"
+	"github.com/lib/pq"
+)
+
+const (
+	// defaultBatchQueueSize bounds the entries waiting for the writer
+	// goroutine. Entries beyond it are dropped rather than blocking requests.
+	defaultBatchQueueSize = 4096
+	// maxBatchSize caps the entries committed in one transaction, so a long
+	// backlog is written in bounded steps rather than one huge transaction.
+	maxBatchSize = 256
+)
+
+// ErrQueueFull is returned by Write when the writer's queue is full and the
+// entry was dropped.
+var ErrQueueFull = errors.New("request log write queue is full")
+
+// batchItem is one unit of work for the writer goroutine: an entry to insert,
+// or, when flushed is set, a marker closed once everything queued before it
+// is committed.
+type batchItem struct {
+	entry   Entry
+	flushed chan struct{}
+}
+
+// batcher moves request log writes off the request path. A write per request
+// per stage, each in its own transaction, makes requests queue on SQLite's
+// file lock and on fsync, and costs Postgres a round trip each. Instead Write
+// enqueues and returns, and a single goroutine commits whatever has queued up
+// in one transaction.
+type batcher struct {
+	// mu guards closed against a send racing close(queue).
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan batchItem
+	stopped chan struct{}
+}
+
+func (w *SQLWriter) startBatcher(size int) {
+	if size <= 0 {
+		size = defaultBatchQueueSize
+	}
+	w.batch = &batcher{
+		queue:   make(chan batchItem, size),
+		stopped: make(chan struct{}),
+	}
+	go w.runBatcher(w.batch)
+}
+
+// enqueue queues entry without blocking, dropping it with a metric when the
+// queue is full.
+func (b *batcher) enqueue(entry Entry) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return errors.New("request log writer is closed")
+	}
+	select {
+	case b.queue <- batchItem{entry: entry}:
+		return nil
+	default:
+		metrics.RequestLogDroppedTotal.WithLabelValues("queue_full").Inc()
+		return ErrQueueFull
+	}
+}
+
+// flush waits until every entry queued before the call is committed, so reads
+// observe the caller's own writes. Unlike enqueue it blocks for queue space.
+func (b *batcher) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return nil
+	}
+	select {
+	case b.queue <- batchItem{flushed: done}:
+		b.mu.RUnlock()
+	case <-ctx.Done():
+		b.mu.RUnlock()
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting entries and waits for the queued ones to commit.
+func (b *batcher) close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+	<-b.stopped
+}
+
+// runBatcher commits b's queued entries until the queue is closed and drained.
+func (w *SQLWriter) runBatcher(b *batcher) {
+	defer close(b.stopped)
+	items := make([]batchItem, 0, maxBatchSize)
+	for item := range b.queue {
+		items = append(items[:0], item)
+	fill:
+		for len(items) < maxBatchSize {
+			select {
+			case next, ok := <-b.queue:
+				if !ok {
+					break fill
+				}
+				items = append(items, next)
+			default:
+				break fill
+			}
+		}
+		w.commitBatch(items)
+	}
+}
+
+// commitBatch inserts the batch's entries in one transaction, then releases
+// its flush markers. A failed batch is dropped and counted, not retried: the
+// request it describes has long been answered.
+func (w *SQLWriter) commitBatch(items []batchItem) {
+	entries := 0
+	for _, item := range items {
+		if item.flushed == nil {
+			entries++
+		}
+	}
+	if entries > 0 {
+		if err := w.insertBatch(items); err != nil {
+			metrics.RequestLogDroppedTotal.WithLabelValues("write_error").Add(float64(entries))
+			slog.Warn("request log batch write failed; entries dropped", "entries", entries, "error", err)
+		}
+	}
+	for _, item := range items {
+		if item.flushed != nil {
+			close(item.flushed)
+		}
+	}
+}
+
+func (w *SQLWriter) insertBatch(items []batchItem) error {
+	// The writer goroutine outlives the requests whose entries it writes, so
+	// it runs detached from any request context.
+	ctx := context.Background()
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin request log batch: %w", err)
+	}
+	// Postgres loads the batch with COPY, which streams the rows in one
+	// statement rather than parsing an INSERT per row.
+	query := insertEntrySQL
+	if w.dialect == sqldb.Postgres {
+		query = pq.CopyIn("request_logs", entryColumns...)
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("prepare request log batch: %w", err)
+	}
+	for _, item := range items {
+		if item.flushed != nil {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, entryArgs(item.entry)...); err != nil {
+			_ = stmt.Close()
+			_ = tx.Rollback()
+			return fmt.Errorf("write request log: %w", err)
+		}
+	}
+	if w.dialect == sqldb.Postgres {
+		// An Exec with no arguments flushes the COPY.
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			_ = stmt.Close()
+			_ = tx.Rollback()
+			return fmt.Errorf("write request log: %w", err)
+		}
+	}
+	_ = stmt.Close()
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit request log batch: %w", err)
+	}
+	return nil
+}
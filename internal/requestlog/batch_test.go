@@ -0,0 +1,90 @@
+package requestlog
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSQLiteWriter_WALAndFilterIndexes(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "wal.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	var mode string
+	if err := w.db.QueryRowContext(t.Context(), "PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatalf("read journal mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+	for _, idx := range filterIndexes {
+		if !sqliteObjectExists(t, w.db, idx.name) {
+			t.Errorf("expected index %q to exist", idx.name)
+		}
+	}
+}
+
+// TestSQLiteWriter_BatchedWritesCommitOnClose confirms queued writes are
+// committed when the writer closes, and that reads wait for them.
+func TestSQLiteWriter_BatchedWritesCommitOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.db")
+	w, err := NewSQLiteWriter(t.Context(), path)
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	for range 600 {
+		if err := w.Write(t.Context(), Entry{TraceID: "t", Stage: "after_request"}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := w.Write(t.Context(), Entry{Stage: "after_request"}); err == nil {
+		t.Fatal("expected Write after Close to fail")
+	}
+
+	w, err = NewSQLiteWriter(t.Context(), path)
+	if err != nil {
+		t.Fatalf("reopen sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	res, err := w.List(t.Context(), Query{Limit: 1})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if res.Total != 600 {
+		t.Fatalf("total = %d, want 600", res.Total)
+	}
+}
+
+func TestSQLiteWriter_DropsWhenQueueFull(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "full.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	// Swap in a one-slot queue nothing drains, so the second write overflows.
+	running := w.batch
+	w.batch = &batcher{queue: make(chan batchItem, 1), stopped: make(chan struct{})}
+	dropped := metrics.RequestLogDroppedTotal.WithLabelValues("queue_full")
+	before := testutil.ToFloat64(dropped)
+
+	if err := w.Write(t.Context(), Entry{Stage: "after_request"}); err != nil {
+		t.Fatalf("first Write() error = %v", err)
+	}
+	if err := w.Write(t.Context(), Entry{Stage: "after_request"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("second Write() error = %v, want ErrQueueFull", err)
+	}
+	if got := testutil.ToFloat64(dropped) - before; got != 1 {
+		t.Fatalf("dropped counter rose by %v, want 1", got)
+	}
+	w.batch = running
+}
@@ -0,0 +1,205 @@
+package requestlog
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Recorded request and response bodies hold user content, so a database dump
+// or backup of request_logs leaks every captured prompt. A PayloadCipher
+// encrypts them before they are queued: each body is sealed with a fresh
+// AES-256-GCM data key, and the data key is itself sealed with the operator's
+// key encryption key (KEK). The stored value is a JSON string,
+//
+//	"fgwenc:v1:<kek id>:<sealed data key>:<sealed body>"
+//
+// so it stays valid JSON in the columns and through the admin API, which
+// returns it as-is unless the caller may decrypt it. The KEK id is the first
+// eight hex digits of the key's SHA-256, so a rotated-out key can still open
+// the entries it sealed while it was current.
+
+// sealedPrefix starts every sealed payload.
+const sealedPrefix = "fgwenc:v1:"
+
+// payloadKeySize is the KEK size: AES-256.
+const payloadKeySize = 32
+
+// ErrPayloadKeyUnknown is returned by Open for a payload sealed under a KEK
+// the cipher was not given.
+var ErrPayloadKeyUnknown = errors.New("request log payload was encrypted with an unknown key")
+
+// PayloadOpener is implemented by stores that encrypt recorded payloads, for
+// the admin API to decrypt them for callers allowed to read them.
+type PayloadOpener interface {
+	// OpenPayloads returns entry with its Request and Response decrypted.
+	// Payloads that are not sealed are returned unchanged.
+	OpenPayloads(entry Entry) (Entry, error)
+}
+
+// PayloadCipher seals and opens recorded payloads. It is safe for concurrent
+// use.
+type PayloadCipher struct {
+	activeID string
+	keks     map[string]cipher.AEAD
+}
+
+// ParsePayloadKeys builds a PayloadCipher from a comma-separated list of
+// base64-encoded 32-byte keys. The first key seals new payloads; every key
+// opens the payloads it sealed, so a rotated-out key stays listed until its
+// entries have aged out.
+func ParsePayloadKeys(spec string) (*PayloadCipher, error) {
+	c := &PayloadCipher{keks: make(map[string]cipher.AEAD)}
+	for i, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return nil, fmt.Errorf("request log encryption key %d: not valid base64", i+1)
+		}
+		if len(key) != payloadKeySize {
+			return nil, fmt.Errorf("request log encryption key %d: must decode to %d bytes, got %d", i+1, payloadKeySize, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		id := hex.EncodeToString(sum[:4])
+		if c.activeID == "" {
+			c.activeID = id
+		}
+		c.keks[id] = aead
+	}
+	if c.activeID == "" {
+		return nil, errors.New("request log encryption key is empty")
+	}
+	return c, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Sealed reports whether raw is a sealed payload.
+func Sealed(raw json.RawMessage) bool {
+	return len(raw) > len(sealedPrefix) && raw[0] == '"' && strings.HasPrefix(string(raw[1:]), sealedPrefix)
+}
+
+// Seal encrypts raw. An empty or already sealed payload is returned unchanged.
+func (c *PayloadCipher) Seal(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || Sealed(raw) {
+		return raw, nil
+	}
+	dataKey := make([]byte, payloadKeySize)
+	_, _ = rand.Read(dataKey)
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey := seal(c.keks[c.activeID], dataKey, []byte(c.activeID))
+	body := seal(data, raw, nil)
+
+	enc := base64.RawURLEncoding
+	sealed := sealedPrefix + c.activeID + ":" + enc.EncodeToString(wrappedKey) + ":" + enc.EncodeToString(body)
+	return json.Marshal(sealed)
+}
+
+// Open decrypts a payload sealed by Seal. A payload that is not sealed is
+// returned unchanged.
+func (c *PayloadCipher) Open(raw json.RawMessage) (json.RawMessage, error) {
+	if !Sealed(raw) {
+		return raw, nil
+	}
+	var sealed string
+	if err := json.Unmarshal(raw, &sealed); err != nil {
+		return nil, fmt.Errorf("decode sealed request log payload: %w", err)
+	}
+	parts := strings.Split(strings.TrimPrefix(sealed, sealedPrefix), ":")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed sealed request log payload")
+	}
+	kek, ok := c.keks[parts[0]]
+	if !ok {
+		return nil, ErrPayloadKeyUnknown
+	}
+	enc := base64.RawURLEncoding
+	wrappedKey, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed sealed request log payload")
+	}
+	body, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed sealed request log payload")
+	}
+	dataKey, err := open(kek, wrappedKey, []byte(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := open(data, body, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(plain), nil
+}
+
+// sealEntry encrypts entry's Request and Response.
+func (c *PayloadCipher) sealEntry(entry Entry) (Entry, error) {
+	var err error
+	if entry.Request, err = c.Seal(entry.Request); err != nil {
+		return entry, err
+	}
+	if entry.Response, err = c.Seal(entry.Response); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// openEntry decrypts entry's Request and Response.
+func (c *PayloadCipher) openEntry(entry Entry) (Entry, error) {
+	var err error
+	if entry.Request, err = c.Open(entry.Request); err != nil {
+		return entry, err
+	}
+	if entry.Response, err = c.Open(entry.Response); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// seal encrypts plaintext under aead with a random nonce, which it prepends.
+// crypto/rand.Read never fails, so neither does seal.
+func seal(aead cipher.AEAD, plaintext, additional []byte) []byte {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	_, _ = rand.Read(nonce)
+	return aead.Seal(nonce, nonce, plaintext, additional)
+}
+
+// open reverses seal.
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed sealed request log payload")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additional)
+	if err != nil {
+		return nil, errors.New("request log payload failed authentication")
+	}
+	return plain, nil
+}
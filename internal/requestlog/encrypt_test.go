@@ -0,0 +1,129 @@
+package requestlog
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testPayloadKey  = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testPayloadKey2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestParsePayloadKeys_Invalid(t *testing.T) {
+	for _, spec := range []string{"", " , ", "not base64!", "c2hvcnQ="} {
+		if _, err := ParsePayloadKeys(spec); err == nil {
+			t.Errorf("ParsePayloadKeys(%q): expected error", spec)
+		}
+	}
+}
+
+func TestPayloadCipher_RoundTrip(t *testing.T) {
+	c, err := ParsePayloadKeys(testPayloadKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := json.RawMessage(`{"messages":[{"role":"user","content":"hello"}]}`)
+	sealed, err := c.Seal(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Sealed(sealed) || strings.Contains(string(sealed), "hello") {
+		t.Fatalf("expected a sealed payload without the plaintext, got %s", sealed)
+	}
+	if !json.Valid(sealed) {
+		t.Fatalf("sealed payload is not valid JSON: %s", sealed)
+	}
+	again, _ := c.Seal(plain)
+	if string(again) == string(sealed) {
+		t.Fatal("sealing the same payload twice produced the same ciphertext")
+	}
+	opened, err := c.Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(opened) != string(plain) {
+		t.Fatalf("round trip: got %s, want %s", opened, plain)
+	}
+	if got, _ := c.Open(plain); string(got) != string(plain) {
+		t.Fatalf("opening a plaintext payload should return it unchanged, got %s", got)
+	}
+}
+
+func TestPayloadCipher_Rotation(t *testing.T) {
+	old, _ := ParsePayloadKeys(testPayloadKey)
+	sealed, _ := old.Seal(json.RawMessage(`{"a":1}`))
+
+	rotated, err := ParsePayloadKeys(testPayloadKey2 + "," + testPayloadKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := rotated.Open(sealed); err != nil || string(got) != `{"a":1}` {
+		t.Fatalf("rotated cipher should open old payloads: %s, %v", got, err)
+	}
+	fresh, _ := rotated.Seal(json.RawMessage(`{"a":2}`))
+	if _, err := old.Open(fresh); !errors.Is(err, ErrPayloadKeyUnknown) {
+		t.Fatalf("expected ErrPayloadKeyUnknown for a payload sealed under the new key, got %v", err)
+	}
+}
+
+func TestPayloadCipher_Tampered(t *testing.T) {
+	c, _ := ParsePayloadKeys(testPayloadKey)
+	sealed, _ := c.Seal(json.RawMessage(`{"a":1}`))
+	tampered := []byte(string(sealed))
+	tampered[len(tampered)-3] ^= 1
+	if _, err := c.Open(tampered); err == nil {
+		t.Fatal("expected a tampered payload to fail to open")
+	}
+}
+
+func TestSQLWriter_SealsPayloads(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "requests.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	c, _ := ParsePayloadKeys(testPayloadKey)
+	w.SetPayloadCipher(c)
+
+	live, cancel := w.Subscribe(Query{}, 1)
+	defer cancel()
+	entry := Entry{
+		TraceID:  "t1",
+		Stage:    "after_request",
+		Request:  json.RawMessage(`{"model":"gpt-4o"}`),
+		Response: json.RawMessage(`{"id":"r1"}`),
+	}
+	if err := w.Write(t.Context(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if published := <-live; !Sealed(published.Request) || !Sealed(published.Response) {
+		t.Fatalf("live subscribers should receive sealed payloads, got %+v", published)
+	}
+
+	var stored string
+	if err := w.flush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.db.QueryRowContext(t.Context(), "SELECT request_body FROM request_logs").Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stored, "gpt-4o") {
+		t.Fatalf("request body stored in the clear: %s", stored)
+	}
+
+	result, err := w.List(t.Context(), Query{TraceID: "t1"})
+	if err != nil || len(result.Data) != 1 {
+		t.Fatalf("list: %v, %+v", err, result)
+	}
+	opened, err := w.OpenPayloads(result.Data[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(opened.Request) != `{"model":"gpt-4o"}` || string(opened.Response) != `{"id":"r1"}` {
+		t.Fatalf("unexpected opened payloads: %s %s", opened.Request, opened.Response)
+	}
+}
@@ -0,0 +1,76 @@
+package requestlog
+
+import "sync"
+
+// Subscriber is implemented by stores that push entries to live subscribers
+// as they are written, for the admin API's log tail. Only entries written by
+// this process are pushed; replicas sharing a database each see their own.
+type Subscriber interface {
+	// Subscribe returns a channel receiving each entry written from now on
+	// that matches q's TraceID, Stage, Model, and Provider filters, and a
+	// cancel function that must be called to release it. buffer bounds the
+	// entries queued for a slow reader; entries beyond it are dropped rather
+	// than blocking writers.
+	Subscribe(q Query, buffer int) (<-chan Entry, func())
+}
+
+// Feed fans written entries out to subscribers. The zero value is ready to
+// use.
+type Feed struct {
+	mu   sync.Mutex
+	subs map[*feedSub]struct{}
+}
+
+type feedSub struct {
+	query Query
+	ch    chan Entry
+}
+
+// Subscribe implements Subscriber.
+func (f *Feed) Subscribe(q Query, buffer int) (<-chan Entry, func()) {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	sub := &feedSub{query: q, ch: make(chan Entry, buffer)}
+	f.mu.Lock()
+	if f.subs == nil {
+		f.subs = make(map[*feedSub]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	f.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			delete(f.subs, sub)
+			f.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish delivers entry to every subscriber whose filters match it, without
+// blocking on any of them.
+func (f *Feed) Publish(entry Entry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		if !sub.query.Matches(entry) {
+			continue
+		}
+		select {
+		case sub.ch <- entry:
+		default:
+		}
+	}
+}
+
+// Matches reports whether entry passes the query's TraceID, Stage, Model, and
+// Provider filters. Time bounds and paging are ignored.
+func (q Query) Matches(entry Entry) bool {
+	return (q.TraceID == "" || entry.TraceID == q.TraceID) &&
+		(q.Stage == "" || entry.Stage == q.Stage) &&
+		(q.Model == "" || entry.Model == q.Model) &&
+		(q.Provider == "" || entry.Provider == q.Provider)
+}
@@ -20,18 +20,104 @@ const requestLogLedger = "request_log_schema_migrations"
 // created_at range, and Stats' since filter.
 const createdAtIndex = "idx_request_logs_created_at"
 
+// traceIDIndex serves the per-trace lookup behind a request transcript.
+const traceIDIndex = "idx_request_logs_trace_id"
+
+// endUserIndex serves erasure deletes by end user.
+const endUserIndex = "idx_request_logs_end_user"
+
+// filterIndexes serve the provider, model, and stage filters of List, Stats,
+// and Delete. Together with createdAtIndex they cover
+// every column the admin log views filter on.
+var filterIndexes = []struct{ name, column string }{
+	{"idx_request_logs_provider", "provider"},
+	{"idx_request_logs_model", "model"},
+	{"idx_request_logs_stage", "stage"},
+}
+
 // requestLogSteps returns the migration sequence for the request_logs database.
 //
 // Version 1 is the pre-runner schema. Databases created before the runner
 // existed already have this shape, so Run adopts it as a baseline rather than
 // executing it. Version 2 builds the created_at index; it runs outside a
 // transaction because the Postgres path uses CREATE INDEX CONCURRENTLY.
+// Version 3 adds the nullable cache_status column; rows written before it read
+// back with an empty status. Version 4 adds the nullable transcript columns
+// (request and response bodies, cost, plugin decisions), and version 5 indexes
+// trace_id the same way version 2 indexes created_at. Version 6 adds the
+// nullable guardrail decision columns, and version 7 the nullable API key and
+// workspace columns usage reports group by. Version 8 indexes provider, model,
+// and stage, also concurrently on Postgres. Version 9 partitions the Postgres
+// table by month (see partitionRequestLogs) and does nothing on SQLite.
+// Version 10 adds the nullable end_user column, and version 11 indexes it for
+// erasure by user, partition by partition on Postgres (see
+// ensurePartitionedIndex). Version 12 adds the nullable upstream_headers
+// column.
 func requestLogSteps(dialect sqldb.Dialect) []migrations.Step {
 	return []migrations.Step{
 		{Version: 1, Name: "request_logs_baseline", SQL: requestLogBaselineDDL(dialect)},
 		{Version: 2, Name: "request_logs_created_at_index", NoTx: func(ctx context.Context, db *sql.DB) error {
-			return ensureCreatedAtIndex(ctx, db, dialect)
+			return ensureIndex(ctx, db, dialect, createdAtIndex, "created_at")
+		}},
+		{Version: 3, Name: "request_logs_cache_status", SQL: `ALTER TABLE request_logs ADD COLUMN cache_status TEXT;`},
+		{Version: 4, Name: "request_logs_transcript", Fn: func(ctx context.Context, tx *sql.Tx) error {
+			for _, ddl := range []string{
+				"ALTER TABLE request_logs ADD COLUMN request_body TEXT",
+				"ALTER TABLE request_logs ADD COLUMN response_body TEXT",
+				"ALTER TABLE request_logs ADD COLUMN cost_usd DOUBLE PRECISION",
+				"ALTER TABLE request_logs ADD COLUMN plugin_decisions TEXT",
+			} {
+				if _, err := tx.ExecContext(ctx, ddl); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{Version: 5, Name: "request_logs_trace_id_index", NoTx: func(ctx context.Context, db *sql.DB) error {
+			return ensureIndex(ctx, db, dialect, traceIDIndex, "trace_id")
+		}},
+		{Version: 6, Name: "request_logs_guardrail_decision", Fn: func(ctx context.Context, tx *sql.Tx) error {
+			for _, ddl := range []string{
+				"ALTER TABLE request_logs ADD COLUMN plugin_name TEXT",
+				"ALTER TABLE request_logs ADD COLUMN decision TEXT",
+				"ALTER TABLE request_logs ADD COLUMN decision_reason TEXT",
+			} {
+				if _, err := tx.ExecContext(ctx, ddl); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{Version: 7, Name: "request_logs_key_workspace", Fn: func(ctx context.Context, tx *sql.Tx) error {
+			for _, ddl := range []string{
+				"ALTER TABLE request_logs ADD COLUMN key_id TEXT",
+				"ALTER TABLE request_logs ADD COLUMN workspace TEXT",
+			} {
+				if _, err := tx.ExecContext(ctx, ddl); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{Version: 8, Name: "request_logs_filter_indexes", NoTx: func(ctx context.Context, db *sql.DB) error {
+			// Every build is attempted even after one defers, so a single
+			// failure does not leave the others missing until the next start.
+			var errs []error
+			for _, idx := range filterIndexes {
+				if err := ensureIndex(ctx, db, dialect, idx.name, idx.column); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		}},
+		{Version: 9, Name: "request_logs_partitioned", Fn: func(ctx context.Context, tx *sql.Tx) error {
+			return partitionRequestLogs(ctx, tx, dialect)
+		}},
+		{Version: 10, Name: "request_logs_end_user", SQL: `ALTER TABLE request_logs ADD COLUMN end_user TEXT;`},
+		{Version: 11, Name: "request_logs_end_user_index", NoTx: func(ctx context.Context, db *sql.DB) error {
+			return ensurePartitionedIndex(ctx, db, dialect, endUserIndex, "end_user")
 		}},
+		{Version: 12, Name: "request_logs_upstream_headers", SQL: `ALTER TABLE request_logs ADD COLUMN upstream_headers TEXT;`},
 	}
 }
 
@@ -66,7 +152,7 @@ CREATE TABLE IF NOT EXISTS request_logs (
 );`
 }
 
-// ensureCreatedAtIndex builds the created_at index if it is missing.
+// ensureIndex builds the named single-column index if it is missing.
 //
 // request_logs takes a write per request per stage. On Postgres a plain
 // CREATE INDEX holds a lock that blocks those writes for the length of the
@@ -78,19 +164,19 @@ CREATE TABLE IF NOT EXISTS request_logs (
 // build is non-fatal: the step returns migrations.ErrDeferStep so the runner
 // keeps startup alive but does not record the version, and the next start
 // retries the build. Only a valid index records the step as done.
-func ensureCreatedAtIndex(ctx context.Context, db *sql.DB, dialect sqldb.Dialect) error {
+func ensureIndex(ctx context.Context, db *sql.DB, dialect sqldb.Dialect, name, column string) error {
 	if dialect != sqldb.Postgres {
-		// createdAtIndex is a package constant, not input; identifiers cannot be
-		// bound as parameters.
-		if _, err := db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS "+createdAtIndex+" ON request_logs (created_at)"); err != nil {
+		// name and column are package constants, not input; identifiers cannot
+		// be bound as parameters.
+		if _, err := db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS "+name+" ON request_logs ("+column+")"); err != nil {
 			slog.Warn("request log index build failed; queries will scan until the next start retries it",
-				"index", createdAtIndex, "error", err)
+				"index", name, "error", err)
 			return fmt.Errorf("build request log index: %w", migrations.ErrDeferStep)
 		}
 		return nil
 	}
 
-	switch postgresIndexState(ctx, db) {
+	switch postgresIndexState(ctx, db, name) {
 	case indexValid:
 		return nil
 	case indexInvalid:
@@ -105,15 +191,15 @@ func ensureCreatedAtIndex(ctx context.Context, db *sql.DB, dialect sqldb.Dialect
 		// Deferring keeps the step unrecorded, so once the operator rebuilds it
 		// the next start records it.
 		slog.Warn("request log index is invalid from an interrupted build; run REINDEX INDEX CONCURRENTLY to rebuild it",
-			"index", createdAtIndex)
+			"index", name)
 		return fmt.Errorf("request log index is invalid: %w", migrations.ErrDeferStep)
 	default: // indexAbsent
-		if _, err := db.ExecContext(ctx, "CREATE INDEX CONCURRENTLY IF NOT EXISTS "+createdAtIndex+" ON request_logs (created_at)"); err != nil {
+		if _, err := db.ExecContext(ctx, "CREATE INDEX CONCURRENTLY IF NOT EXISTS "+name+" ON request_logs ("+column+")"); err != nil {
 			// A failed concurrent build usually leaves an invalid index behind,
 			// which the next start reports as indexInvalid (and points at
 			// REINDEX) rather than silently rebuilding. Until then queries scan.
 			slog.Warn("request log index build failed; queries will scan until it is rebuilt with REINDEX INDEX CONCURRENTLY",
-				"index", createdAtIndex, "error", err)
+				"index", name, "error", err)
 			return fmt.Errorf("request log index build failed: %w", migrations.ErrDeferStep)
 		}
 		return nil
@@ -128,23 +214,23 @@ const (
 	indexInvalid
 )
 
-// postgresIndexState reports whether the created_at index is absent, present
-// and usable, or present but invalid.
+// postgresIndexState reports whether the named index is absent, present and
+// usable, or present but invalid.
 //
 // to_regclass resolves the name through search_path, so the probe inspects the
 // index the writer would actually use rather than a same-named index in another
 // schema. It returns NULL — and the join no rows — when the index does not
 // exist. A probe failure is treated as absent so a transient error at most
 // triggers a redundant IF NOT EXISTS build.
-func postgresIndexState(ctx context.Context, db *sql.DB) indexState {
+func postgresIndexState(ctx context.Context, db *sql.DB, name string) indexState {
 	const probe = `SELECT i.indisvalid FROM pg_index i WHERE i.indexrelid = to_regclass($1)`
 
 	var valid bool
-	switch err := db.QueryRowContext(ctx, probe, createdAtIndex).Scan(&valid); {
+	switch err := db.QueryRowContext(ctx, probe, name).Scan(&valid); {
 	case errors.Is(err, sql.ErrNoRows):
 		return indexAbsent
 	case err != nil:
-		slog.Warn("could not probe request log index; assuming it is absent", "index", createdAtIndex, "error", err)
+		slog.Warn("could not probe request log index; assuming it is absent", "index", name, "error", err)
 		return indexAbsent
 	case valid:
 		return indexValid
@@ -0,0 +1,286 @@
+package requestlog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/migrations"
+	"github.com/ferro-labs/ai-gateway/internal/sqldb"
+)
+
+// On Postgres, request_logs is partitioned by month of created_at, so
+// retention deletes and time-bounded queries touch only the partitions in
+// range. Each month's partition is created ahead of time by the writer;
+// entries outside every monthly partition land in the default partition and
+// are moved out when their month's partition is created.
+
+// defaultPartition is the catch-all partition for entries whose month has no
+// partition yet.
+const defaultPartition = "request_logs_default"
+
+// partitionInterval is how often a Postgres writer checks that the current
+// and next month's partitions exist.
+const partitionInterval = 6 * time.Hour
+
+// partitionMonth returns the first instant of t's month in UTC.
+func partitionMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// partitionName returns the name of the partition holding month's entries,
+// for example request_logs_2025_01.
+func partitionName(month time.Time) string {
+	return fmt.Sprintf("request_logs_%04d_%02d", month.Year(), int(month.Month()))
+}
+
+// partitionBounds renders month's [from, to) range as timestamptz literals.
+// Partition bounds cannot be bound as parameters.
+func partitionBounds(month time.Time) (from, to string) {
+	const layout = "2006-01-02 15:04:05+00"
+	return "'" + month.Format(layout) + "'", "'" + month.AddDate(0, 1, 0).Format(layout) + "'"
+}
+
+// partitionedRequestLogsDDL is the partitioned request_logs table. The primary
+// key must include the partition column, so it is (id, created_at).
+const partitionedRequestLogsDDL = `CREATE TABLE request_logs (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+	trace_id TEXT,
+	stage TEXT NOT NULL,
+	model TEXT,
+	provider TEXT,
+	prompt_tokens INTEGER NOT NULL,
+	completion_tokens INTEGER NOT NULL,
+	total_tokens INTEGER NOT NULL,
+	error_message TEXT,
+	created_at TIMESTAMPTZ NOT NULL,
+	cache_status TEXT,
+	request_body TEXT,
+	response_body TEXT,
+	cost_usd DOUBLE PRECISION,
+	plugin_decisions TEXT,
+	plugin_name TEXT,
+	decision TEXT,
+	decision_reason TEXT,
+	key_id TEXT,
+	workspace TEXT,
+	PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at)`
+
+// partitionedColumns are the columns partitionedRequestLogsDDL creates, which
+// partitionRequestLogs copies. Columns added by later migration steps are not
+// listed: they do not exist yet when it runs.
+var partitionedColumns = []string{
+	"id", "trace_id", "stage", "model", "provider", "prompt_tokens", "completion_tokens", "total_tokens",
+	"error_message", "created_at", "cache_status", "request_body", "response_body", "cost_usd",
+	"plugin_decisions", "plugin_name", "decision", "decision_reason", "key_id", "workspace",
+}
+
+// partitionRequestLogs converts a Postgres request_logs table to the
+// partitioned layout, copying its rows into monthly partitions, and rebuilds
+// its indexes on the new parent, where they cascade to every partition. It is
+// a no-op on SQLite.
+//
+// The copy runs inside the migration transaction, so a large table is locked
+// for its length; the step runs once, on the first start of this version.
+func partitionRequestLogs(ctx context.Context, tx *sql.Tx, dialect sqldb.Dialect) error {
+	if dialect != sqldb.Postgres {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "ALTER TABLE request_logs RENAME TO request_logs_unpartitioned"); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, partitionedRequestLogsDDL); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "CREATE TABLE "+defaultPartition+" PARTITION OF request_logs DEFAULT"); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT date_trunc('month', created_at AT TIME ZONE 'UTC') FROM request_logs_unpartitioned`)
+	if err != nil {
+		return err
+	}
+	var months []time.Time
+	for rows.Next() {
+		var month time.Time
+		if err := rows.Scan(&month); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		months = append(months, partitionMonth(month))
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	current := partitionMonth(time.Now())
+	months = append(months, current, current.AddDate(0, 1, 0))
+	for _, month := range months {
+		if err := createPartition(ctx, tx, month); err != nil {
+			return err
+		}
+	}
+
+	columns := strings.Join(partitionedColumns, ", ")
+	for _, stmt := range []string{
+		"INSERT INTO request_logs (" + columns + ") SELECT " + columns + " FROM request_logs_unpartitioned",
+		"SELECT setval(pg_get_serial_sequence('request_logs', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM request_logs",
+		"DROP TABLE request_logs_unpartitioned",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	// The old indexes went with the old table. Concurrent builds are not
+	// supported on a partitioned parent, and the new table is still private to
+	// this transaction, so plain builds are used.
+	indexes := append([]struct{ name, column string }{
+		{createdAtIndex, "created_at"},
+		{traceIDIndex, "trace_id"},
+	}, filterIndexes...)
+	for _, idx := range indexes {
+		if _, err := tx.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS "+idx.name+" ON request_logs ("+idx.column+")"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensurePartitionedIndex builds the named single-column index without
+// blocking writes once request_logs is partitioned. CREATE INDEX CONCURRENTLY
+// is not supported on a partitioned table, so on Postgres the parent's index is
+// created ON ONLY the parent, where it starts out invalid; each partition is
+// then indexed concurrently and its index attached, and the parent's turns
+// valid once every partition's is. Partitions created afterwards get the index
+// as they are attached. A failure defers the step like ensureIndex. On SQLite
+// it is ensureIndex.
+func ensurePartitionedIndex(ctx context.Context, db *sql.DB, dialect sqldb.Dialect, name, column string) error {
+	if dialect != sqldb.Postgres {
+		return ensureIndex(ctx, db, dialect, name, column)
+	}
+	if postgresIndexState(ctx, db, name) == indexValid {
+		return nil
+	}
+	fail := func(err error) error {
+		slog.Warn("request log index build failed; queries will scan until the next start retries it",
+			"index", name, "error", err)
+		return fmt.Errorf("build request log index: %w", migrations.ErrDeferStep)
+	}
+	// name, column, and the partition names are package constants or derived
+	// from them, not input; identifiers cannot be bound as parameters.
+	if _, err := db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS "+name+" ON ONLY request_logs ("+column+")"); err != nil {
+		return fail(err)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = 'request_logs'::regclass`)
+	if err != nil {
+		return fail(err)
+	}
+	var partitions []string
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			_ = rows.Close()
+			return fail(err)
+		}
+		partitions = append(partitions, partition)
+	}
+	if err := rows.Close(); err != nil {
+		return fail(err)
+	}
+	for _, partition := range partitions {
+		child := partition + "_" + column + "_idx"
+		for _, stmt := range []string{
+			"CREATE INDEX CONCURRENTLY IF NOT EXISTS " + child + " ON " + partition + " (" + column + ")",
+			// Attaching an index that is already attached does nothing.
+			"ALTER INDEX " + name + " ATTACH PARTITION " + child,
+		} {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	return nil
+}
+
+// sqlExecer is the subset of *sql.DB and *sql.Tx createPartition needs.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// createPartition creates month's partition unless it exists. Entries for the
+// month already in the default partition are moved into the new table before
+// it is attached, since attaching a range the default partition still holds
+// rows for fails.
+func createPartition(ctx context.Context, db sqlExecer, month time.Time) error {
+	name := partitionName(month)
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+		return fmt.Errorf("probe request log partition %s: %w", name, err)
+	}
+	if exists {
+		return nil
+	}
+
+	from, to := partitionBounds(month)
+	// name and the bounds are derived from a time.Time, not input; identifiers
+	// and partition bounds cannot be bound as parameters.
+	for _, stmt := range []string{
+		"CREATE TABLE " + name + " (LIKE request_logs INCLUDING DEFAULTS)",
+		"WITH moved AS (DELETE FROM " + defaultPartition + " WHERE created_at >= " + from + " AND created_at < " + to + " RETURNING *) INSERT INTO " + name + " SELECT * FROM moved",
+		"ALTER TABLE request_logs ATTACH PARTITION " + name + " FOR VALUES FROM (" + from + ") TO (" + to + ")",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("create request log partition %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ensurePartitions creates the partitions for now's month and the next, so
+// entries never wait on a partition at a month boundary. Each runs in its own
+// transaction; a failure, such as another instance creating the same
+// partition concurrently, is logged and retried on the next pass.
+func (w *SQLWriter) ensurePartitions(ctx context.Context, now time.Time) {
+	current := partitionMonth(now)
+	for _, month := range []time.Time{current, current.AddDate(0, 1, 0)} {
+		if err := w.createPartitionTx(ctx, month); err != nil {
+			slog.Warn("request log partition maintenance failed; entries for the month use the default partition until it is retried",
+				"partition", partitionName(month), "error", err)
+		}
+	}
+}
+
+func (w *SQLWriter) createPartitionTx(ctx context.Context, month time.Time) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := createPartition(ctx, tx, month); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// maintainPartitions runs ensurePartitions every partitionInterval until stop
+// is closed.
+func (w *SQLWriter) maintainPartitions(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(partitionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			w.ensurePartitions(ctx, now)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package requestlog
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPartitionNaming(t *testing.T) {
+	// 2024-12-31 20:00 at UTC-5 is already January in UTC.
+	at := time.Date(2024, 12, 31, 20, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	month := partitionMonth(at)
+	if want := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC); !month.Equal(want) {
+		t.Fatalf("partitionMonth = %v, want %v", month, want)
+	}
+	if got := partitionName(month); got != "request_logs_2025_01" {
+		t.Errorf("partitionName = %q", got)
+	}
+	from, to := partitionBounds(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC))
+	if from != "'2025-12-01 00:00:00+00'" || to != "'2026-01-01 00:00:00+00'" {
+		t.Errorf("partitionBounds = %s, %s", from, to)
+	}
+}
+
+func TestPostgresWriter_Partitions(t *testing.T) {
+	dsn := os.Getenv("FERROGW_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("set FERROGW_TEST_POSTGRES_DSN to run Postgres requestlog integration tests")
+	}
+
+	w, err := NewPostgresWriter(t.Context(), dsn)
+	if err != nil {
+		t.Fatalf("new postgres writer: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = w.db.ExecContext(context.Background(), "DELETE FROM request_logs")
+		_ = w.Close()
+	})
+
+	// An entry far in the future lands in the default partition, and moves to
+	// its month's partition once that is created.
+	future := time.Date(2099, 3, 15, 0, 0, 0, 0, time.UTC)
+	if err := w.Write(t.Context(), Entry{TraceID: "future", Stage: "after_request", CreatedAt: future}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.flush(t.Context()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	t.Cleanup(func() { _, _ = w.db.ExecContext(context.Background(), "DROP TABLE IF EXISTS "+partitionName(future)) })
+	if err := w.createPartitionTx(t.Context(), partitionMonth(future)); err != nil {
+		t.Fatalf("create partition: %v", err)
+	}
+
+	var n int
+	if err := w.db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM "+partitionName(future)).Scan(&n); err != nil {
+		t.Fatalf("count partition rows: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("partition holds %d rows, want 1", n)
+	}
+	if err := w.db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM "+partitionName(partitionMonth(time.Now()))).Scan(&n); err != nil {
+		t.Fatalf("expected the current month's partition: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package requestlog
+
+import (
+	"encoding/json"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Request is a chat request as a log entry records it. The fields a
+// providers.Request keeps out of its JSON are kept beside it, so a request
+// sent again from the log asks for what the original did.
+type Request struct {
+	providers.Request
+	Extra          map[string]json.RawMessage `json:"extra,omitempty"`
+	Thinking       *providers.Thinking        `json:"thinking,omitempty"`
+	FallbackModels []string                   `json:"fallback_models,omitempty"`
+}
+
+// NewRequest returns req as it is recorded.
+func NewRequest(req providers.Request) Request {
+	return Request{Request: req, Extra: req.Extra, Thinking: req.Thinking, FallbackModels: req.FallbackModels}
+}
+
+// Chat returns the request to route.
+func (r Request) Chat() providers.Request {
+	req := r.Request
+	req.Extra, req.Thinking, req.FallbackModels = r.Extra, r.Thinking, r.FallbackModels
+	return req
+}
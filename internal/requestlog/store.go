@@ -4,6 +4,7 @@ package requestlog
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -29,17 +30,86 @@ type Entry struct {
 	CompletionTokens int       `json:"completion_tokens" yaml:"completion_tokens"`
 	TotalTokens      int       `json:"total_tokens" yaml:"total_tokens"`
 	ErrorMessage     string    `json:"error_message" yaml:"error_message"`
+	CacheStatus      string    `json:"cache_status,omitempty" yaml:"cache_status,omitempty"`
 	CreatedAt        time.Time `json:"created_at" yaml:"created_at"`
+
+	// CostUSD is the priced cost of the response, on after_request entries.
+	CostUSD float64 `json:"cost_usd,omitempty" yaml:"cost_usd,omitempty"`
+	// Request and Response are the recorded request and response bodies,
+	// present only when the request logger is configured to record content.
+	Request  json.RawMessage `json:"request,omitempty" yaml:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty" yaml:"response,omitempty"`
+	// PluginDecisions lists the outcome of each plugin that ran before the
+	// entry was written, as a JSON array.
+	PluginDecisions json.RawMessage `json:"plugin_decisions,omitempty" yaml:"plugin_decisions,omitempty"`
+	// Plugin, Decision, and Reason record a guardrail decision on the entry
+	// itself: on a StageRejected entry, the plugin that rejected the request,
+	// "reject", and its reason.
+	Plugin   string `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Decision string `json:"decision,omitempty" yaml:"decision,omitempty"`
+	Reason   string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// KeyID and Workspace identify the API key the request authenticated
+	// with, and the workspace it belongs to, for usage reports.
+	KeyID     string `json:"key_id,omitempty" yaml:"key_id,omitempty"`
+	Workspace string `json:"workspace,omitempty" yaml:"workspace,omitempty"`
+	// User is the request's end-user identifier (its `user` field), kept so
+	// an erasure request can find the user's entries.
+	User string `json:"user,omitempty" yaml:"user,omitempty"`
+	// UpstreamHeaders holds the rate-limit and request-ID headers of the
+	// provider's last response, as a JSON object keyed by lower-case header
+	// name, on after_request and on_error entries.
+	UpstreamHeaders json.RawMessage `json:"upstream_headers,omitempty" yaml:"upstream_headers,omitempty"`
 }
 
+// StageRejected is the stage of the entry written when a before_request
+// plugin rejects a request, so guardrail activity can be audited.
+const StageRejected = "before_request_rejected"
+
 // Query defines request log listing filters.
 type Query struct {
 	Limit    int
 	Offset   int
+	TraceID  string
 	Stage    string
 	Model    string
 	Provider string
 	Since    *time.Time
+	// Until excludes entries created after it. Paging a large export pins it
+	// at the start so entries written meanwhile cannot shift the pages.
+	Until *time.Time
+}
+
+// where renders the query's filters as a SQL WHERE clause with ? placeholders
+// and the matching args. It returns "" when no filter is set.
+func (q Query) where() (string, []any) {
+	var clauses []string
+	var args []any
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"trace_id", q.TraceID},
+		{"stage", q.Stage},
+		{"model", q.Model},
+		{"provider", q.Provider},
+	} {
+		if f.value != "" {
+			clauses = append(clauses, f.column+" = ?")
+			args = append(args, f.value)
+		}
+	}
+	if q.Since != nil {
+		clauses = append(clauses, "created_at >= ?")
+		args = append(args, q.Since.UTC())
+	}
+	if q.Until != nil {
+		clauses = append(clauses, "created_at <= ?")
+		args = append(args, q.Until.UTC())
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
 }
 
 // MaintenanceQuery defines filters for request log cleanup operations.
@@ -48,6 +118,10 @@ type MaintenanceQuery struct {
 	Stage    string
 	Model    string
 	Provider string
+	// User and KeyID select one end user's or one API key's entries, for
+	// right-to-erasure requests.
+	User  string
+	KeyID string
 }
 
 // ListResult is a paginated request log query response.
@@ -99,23 +173,64 @@ func (NoopWriter) Write(_ context.Context, _ Entry) error { return nil }
 type SQLWriter struct {
 	db      *sql.DB
 	dialect sqldb.Dialect
+	feed    Feed
+	// payloads seals recorded request and response bodies before they are
+	// queued; nil stores them in the clear.
+	payloads *PayloadCipher
+	// batch queues writes for a single writer goroutine.
+	batch *batcher
+	// stopPartitions and partitionsDone stop and await the Postgres
+	// partition maintenance goroutine; both are nil on SQLite.
+	stopPartitions chan struct{}
+	partitionsDone chan struct{}
 }
 
 // NewSQLiteWriter creates a SQLite-backed request log writer.
+//
+// The database runs in WAL mode, so readers do not block the writer, and
+// writes are batched: Write queues the entry and returns, and a single
+// goroutine commits the queue in transactions of up to 256 entries. When the
+// queue (4096 entries) is full, Write drops the entry, counts it in
+// gateway_request_log_dropped_total, and returns ErrQueueFull. Reads through
+// the writer first wait for the queue to drain, and Close commits it.
 func NewSQLiteWriter(ctx context.Context, dsn string) (*SQLWriter, error) {
 	db, err := sqldb.Open(ctx, sqldb.SQLite, dsn, "ferrogw-requests.db")
 	if err != nil {
 		return nil, err
 	}
 	w := &SQLWriter{db: db, dialect: sqldb.SQLite}
+	if err := enableWAL(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	if err := w.init(ctx); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
+	w.startBatcher(defaultBatchQueueSize)
 	return w, nil
 }
 
+// enableWAL switches the SQLite database to write-ahead logging. WAL lets
+// readers proceed during a write, and with synchronous=NORMAL a commit no
+// longer waits on fsync; a power loss can then drop the last commits but not
+// corrupt the file, which suits request logs. In-memory databases keep their
+// own journal mode.
+func enableWAL(ctx context.Context, db *sql.DB) error {
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA synchronous=NORMAL"} {
+		if _, err := db.ExecContext(ctx, pragma); err != nil {
+			return fmt.Errorf("configure sqlite request log (%s): %w", pragma, err)
+		}
+	}
+	return nil
+}
+
 // NewPostgresWriter creates a Postgres-backed request log writer.
+//
+// Writes are queued and batched as for SQLite, each batch loaded with COPY.
+// request_logs is partitioned by month of created_at; the writer creates the
+// current and next month's partitions at startup and checks them every six
+// hours while it is open.
 func NewPostgresWriter(ctx context.Context, dsn string) (*SQLWriter, error) {
 	db, err := sqldb.Open(ctx, sqldb.Postgres, dsn, "")
 	if err != nil {
@@ -126,6 +241,11 @@ func NewPostgresWriter(ctx context.Context, dsn string) (*SQLWriter, error) {
 		_ = db.Close()
 		return nil, err
 	}
+	w.ensurePartitions(ctx, time.Now())
+	w.stopPartitions = make(chan struct{})
+	w.partitionsDone = make(chan struct{})
+	go w.maintainPartitions(w.stopPartitions, w.partitionsDone)
+	w.startBatcher(defaultBatchQueueSize)
 	return w, nil
 }
 
@@ -136,16 +256,64 @@ func (w *SQLWriter) init(ctx context.Context) error {
 	return nil
 }
 
-func (w *SQLWriter) Write(ctx context.Context, entry Entry) error {
+// entryColumns are the request_logs columns an entry is written to, in
+// entryArgs order.
+var entryColumns = []string{
+	"trace_id", "stage", "model", "provider", "prompt_tokens", "completion_tokens", "total_tokens",
+	"error_message", "cache_status", "cost_usd", "request_body", "response_body", "plugin_decisions",
+	"plugin_name", "decision", "decision_reason", "key_id", "workspace", "end_user", "created_at",
+	"upstream_headers",
+}
+
+// insertEntrySQL inserts one entry on SQLite.
+var insertEntrySQL = "INSERT INTO request_logs(" + strings.Join(entryColumns, ", ") +
+	") VALUES(?" + strings.Repeat(", ?", len(entryColumns)-1) + ")"
+
+// SetPayloadCipher encrypts the request and response bodies of entries
+// written from now on with c; nil stops encrypting. Call it before the writer
+// is handed to logging plugins.
+func (w *SQLWriter) SetPayloadCipher(c *PayloadCipher) {
+	w.payloads = c
+}
+
+// OpenPayloads implements PayloadOpener. Without a cipher, entries are
+// returned unchanged.
+func (w *SQLWriter) OpenPayloads(entry Entry) (Entry, error) {
+	if w.payloads == nil {
+		return entry, nil
+	}
+	return w.payloads.openEntry(entry)
+}
+
+// DB returns the writer's database and dialect, so that other stores can keep
+// their tables beside the request log. The writer still owns and closes it.
+func (w *SQLWriter) DB() (*sql.DB, sqldb.Dialect) {
+	return w.db, w.dialect
+}
+
+// Write queues entry for the writer goroutine (see NewSQLiteWriter) and
+// returns. Live subscribers receive it as soon as it is queued, with its
+// payloads already sealed when a cipher is set.
+func (w *SQLWriter) Write(_ context.Context, entry Entry) error {
 	if entry.CreatedAt.IsZero() {
 		entry.CreatedAt = time.Now().UTC()
 	}
+	if w.payloads != nil {
+		sealed, err := w.payloads.sealEntry(entry)
+		if err != nil {
+			return err
+		}
+		entry = sealed
+	}
+	if err := w.batch.enqueue(entry); err != nil {
+		return err
+	}
+	w.feed.Publish(entry)
+	return nil
+}
 
-	query := sqldb.Bind(w.dialect, `INSERT INTO request_logs(trace_id, stage, model, provider, prompt_tokens, completion_tokens, total_tokens, error_message, created_at)
-	VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`)
-
-	// #nosec G701 -- query is a fixed literal routed through sqldb.Bind; every value is a bound parameter.
-	_, err := w.db.ExecContext(ctx, query,
+func entryArgs(entry Entry) []any {
+	return []any{
 		entry.TraceID,
 		entry.Stage,
 		entry.Model,
@@ -154,16 +322,37 @@ func (w *SQLWriter) Write(ctx context.Context, entry Entry) error {
 		entry.CompletionTokens,
 		entry.TotalTokens,
 		entry.ErrorMessage,
+		entry.CacheStatus,
+		entry.CostUSD,
+		nullableJSON(entry.Request),
+		nullableJSON(entry.Response),
+		nullableJSON(entry.PluginDecisions),
+		entry.Plugin,
+		entry.Decision,
+		entry.Reason,
+		entry.KeyID,
+		entry.Workspace,
+		entry.User,
 		entry.CreatedAt,
-	)
-	if err != nil {
-		return fmt.Errorf("write request log: %w", err)
+		nullableJSON(entry.UpstreamHeaders),
 	}
-	return nil
+}
+
+// flush waits for queued writes to commit, so a read sees them.
+func (w *SQLWriter) flush(ctx context.Context) error {
+	return w.batch.flush(ctx)
+}
+
+// Subscribe implements Subscriber for entries written through w.
+func (w *SQLWriter) Subscribe(q Query, buffer int) (<-chan Entry, func()) {
+	return w.feed.Subscribe(q, buffer)
 }
 
 // List returns paginated request log entries with optional filters.
 func (w *SQLWriter) List(ctx context.Context, query Query) (ListResult, error) {
+	if err := w.flush(ctx); err != nil {
+		return ListResult{}, err
+	}
 	if query.Limit <= 0 {
 		query.Limit = defaultListLimit
 	}
@@ -174,30 +363,7 @@ func (w *SQLWriter) List(ctx context.Context, query Query) (ListResult, error) {
 		query.Offset = 0
 	}
 
-	whereClauses := make([]string, 0)
-	args := make([]any, 0)
-
-	if query.Stage != "" {
-		whereClauses = append(whereClauses, "stage = ?")
-		args = append(args, query.Stage)
-	}
-	if query.Model != "" {
-		whereClauses = append(whereClauses, "model = ?")
-		args = append(args, query.Model)
-	}
-	if query.Provider != "" {
-		whereClauses = append(whereClauses, "provider = ?")
-		args = append(args, query.Provider)
-	}
-	if query.Since != nil {
-		whereClauses = append(whereClauses, "created_at >= ?")
-		args = append(args, query.Since.UTC())
-	}
-
-	whereSQL := ""
-	if len(whereClauses) > 0 {
-		whereSQL = " WHERE " + strings.Join(whereClauses, " AND ")
-	}
+	whereSQL, args := query.where()
 
 	// #nosec G202 G701 -- whereSQL is built only from fixed predicates; every value is a bound placeholder.
 	countQuery := sqldb.Bind(w.dialect, "SELECT COUNT(*) FROM request_logs"+whereSQL)
@@ -209,7 +375,7 @@ func (w *SQLWriter) List(ctx context.Context, query Query) (ListResult, error) {
 	}
 
 	// #nosec G202 -- whereSQL is built only from fixed predicates and bound placeholders.
-	listQuery := sqldb.Bind(w.dialect, "SELECT trace_id, stage, model, provider, prompt_tokens, completion_tokens, total_tokens, error_message, created_at FROM request_logs"+whereSQL+" ORDER BY created_at DESC LIMIT ? OFFSET ?")
+	listQuery := sqldb.Bind(w.dialect, "SELECT trace_id, stage, model, provider, prompt_tokens, completion_tokens, total_tokens, error_message, cache_status, cost_usd, request_body, response_body, plugin_decisions, plugin_name, decision, decision_reason, key_id, workspace, end_user, created_at, upstream_headers FROM request_logs"+whereSQL+" ORDER BY created_at DESC LIMIT ? OFFSET ?")
 	listArgs := make([]any, 0, len(args)+2)
 	listArgs = append(listArgs, args...)
 	listArgs = append(listArgs, query.Limit, query.Offset)
@@ -231,8 +397,20 @@ func (w *SQLWriter) List(ctx context.Context, query Query) (ListResult, error) {
 			model    sql.NullString
 			provider sql.NullString
 			errMsg   sql.NullString
+			cache    sql.NullString
+			cost     sql.NullFloat64
+			reqBody  sql.NullString
+			respBody sql.NullString
+			plugins  sql.NullString
+			plugin   sql.NullString
+			decision sql.NullString
+			reason   sql.NullString
+			keyID    sql.NullString
+			ws       sql.NullString
+			user     sql.NullString
+			upstream sql.NullString
 		)
-		if err := rows.Scan(&traceID, &e.Stage, &model, &provider, &e.PromptTokens, &e.CompletionTokens, &e.TotalTokens, &errMsg, &e.CreatedAt); err != nil {
+		if err := rows.Scan(&traceID, &e.Stage, &model, &provider, &e.PromptTokens, &e.CompletionTokens, &e.TotalTokens, &errMsg, &cache, &cost, &reqBody, &respBody, &plugins, &plugin, &decision, &reason, &keyID, &ws, &user, &e.CreatedAt, &upstream); err != nil {
 			return ListResult{}, fmt.Errorf("scan request log row: %w", err)
 		}
 		if traceID.Valid {
@@ -247,6 +425,20 @@ func (w *SQLWriter) List(ctx context.Context, query Query) (ListResult, error) {
 		if errMsg.Valid {
 			e.ErrorMessage = errMsg.String
 		}
+		if cache.Valid {
+			e.CacheStatus = cache.String
+		}
+		e.CostUSD = cost.Float64
+		e.Request = rawJSON(reqBody)
+		e.Response = rawJSON(respBody)
+		e.PluginDecisions = rawJSON(plugins)
+		e.Plugin = plugin.String
+		e.Decision = decision.String
+		e.Reason = reason.String
+		e.KeyID = keyID.String
+		e.Workspace = ws.String
+		e.User = user.String
+		e.UpstreamHeaders = rawJSON(upstream)
 		entries = append(entries, e)
 	}
 
@@ -282,35 +474,16 @@ SELECT 'model', COALESCE(NULLIF(model, ''), 'unknown'), COUNT(*),
 FROM request_logs%[1]s
 GROUP BY COALESCE(NULLIF(model, ''), 'unknown')`
 
-// Stats aggregates request logs matching the query filters (Stage, Model,
-// Provider, Since) entirely in SQL. Limit and Offset are ignored. Returned maps
-// are always non-nil. TotalEntries/ErrorEntries/TotalTokens are derived from the
-// stage rows, which partition every matching row exactly once.
+// Stats aggregates request logs matching the query filters (TraceID, Stage,
+// Model, Provider, Since, Until) entirely in SQL. Limit and Offset are
+// ignored. Returned maps are always non-nil. TotalEntries/ErrorEntries/
+// TotalTokens are derived from the stage rows, which partition every matching
+// row exactly once.
 func (w *SQLWriter) Stats(ctx context.Context, query Query) (StatsResult, error) {
-	whereClauses := make([]string, 0)
-	args := make([]any, 0)
-
-	if query.Stage != "" {
-		whereClauses = append(whereClauses, "stage = ?")
-		args = append(args, query.Stage)
-	}
-	if query.Model != "" {
-		whereClauses = append(whereClauses, "model = ?")
-		args = append(args, query.Model)
-	}
-	if query.Provider != "" {
-		whereClauses = append(whereClauses, "provider = ?")
-		args = append(args, query.Provider)
-	}
-	if query.Since != nil {
-		whereClauses = append(whereClauses, "created_at >= ?")
-		args = append(args, query.Since.UTC())
-	}
-
-	whereSQL := ""
-	if len(whereClauses) > 0 {
-		whereSQL = " WHERE " + strings.Join(whereClauses, " AND ")
+	if err := w.flush(ctx); err != nil {
+		return StatsResult{}, err
 	}
+	whereSQL, args := query.where()
 
 	// #nosec G201 -- dimension/column names are fixed literals; whereSQL contains only bound placeholders.
 	statsQuery := fmt.Sprintf(statsQueryTemplate, whereSQL)
@@ -368,15 +541,40 @@ func (w *SQLWriter) Stats(ctx context.Context, query Query) (StatsResult, error)
 	return result, nil
 }
 
-// Delete removes request log entries matching maintenance filters.
+// Delete removes request log entries matching maintenance filters. At least
+// one of Before, User, or KeyID is required, so a filter cannot be omitted
+// into deleting everything.
 func (w *SQLWriter) Delete(ctx context.Context, query MaintenanceQuery) (int, error) {
-	if query.Before == nil {
-		return 0, fmt.Errorf("before is required")
+	if query.Before == nil && query.User == "" && query.KeyID == "" {
+		return 0, fmt.Errorf("before is required unless user or key_id is set")
+	}
+	if err := w.flush(ctx); err != nil {
+		return 0, err
 	}
 
-	whereClauses := []string{"created_at < ?"}
-	args := []any{query.Before.UTC()}
+	var whereClauses []string
+	var args []any
 
+	if query.Before != nil {
+		whereClauses = append(whereClauses, "created_at < ?")
+		args = append(args, query.Before.UTC())
+	}
+	if query.User != "" {
+		// Entries written before the end_user column existed hold NULL
+		// there. They are matched by the user field of the request body
+		// recorded for their trace, so a trace recorded without content, or
+		// with its body encrypted, cannot be matched.
+		find := "instr(request_body, ?) > 0"
+		if w.dialect == sqldb.Postgres {
+			find = "strpos(request_body, ?) > 0"
+		}
+		whereClauses = append(whereClauses, "(end_user = ? OR (end_user IS NULL AND trace_id IN (SELECT trace_id FROM request_logs WHERE end_user IS NULL AND "+find+")))")
+		args = append(args, query.User, userBodyField(query.User))
+	}
+	if query.KeyID != "" {
+		whereClauses = append(whereClauses, "key_id = ?")
+		args = append(args, query.KeyID)
+	}
 	if query.Stage != "" {
 		whereClauses = append(whereClauses, "stage = ?")
 		args = append(args, query.Stage)
@@ -406,10 +604,38 @@ func (w *SQLWriter) Delete(ctx context.Context, query MaintenanceQuery) (int, er
 	return int(affected), nil
 }
 
-// Close closes the underlying SQL connection.
+// userBodyField returns the user field as it appears in a recorded request
+// body, which is compact JSON.
+func userBodyField(user string) string {
+	b, _ := json.Marshal(user)
+	return `"user":` + string(b)
+}
+
+// Close commits any queued writes and closes the underlying SQL connection.
 func (w *SQLWriter) Close() error {
 	if w == nil || w.db == nil {
 		return nil
 	}
+	if w.stopPartitions != nil {
+		close(w.stopPartitions)
+		<-w.partitionsDone
+		w.stopPartitions = nil
+	}
+	w.batch.close()
 	return w.db.Close()
 }
+
+// nullableJSON stores an absent JSON body as NULL rather than an empty string.
+func nullableJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
@@ -3,6 +3,7 @@ package requestlog
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
@@ -36,7 +37,7 @@ func TestSQLiteWriter_MigrationSchema(t *testing.T) {
 	}
 	t.Cleanup(func() { _ = w.Close() })
 
-	for _, name := range []string{requestLogLedger, "request_logs", createdAtIndex} {
+	for _, name := range []string{requestLogLedger, "request_logs", createdAtIndex, traceIDIndex} {
 		if !sqliteObjectExists(t, w.db, name) {
 			t.Errorf("expected %q to exist after construction", name)
 		}
@@ -358,6 +359,52 @@ func TestDeleteRequiresBefore(t *testing.T) {
 	}
 }
 
+func TestSQLiteWriter_DeleteByUser(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "requests.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	now := time.Now().UTC()
+	for _, e := range []Entry{
+		{TraceID: "t1", Stage: "before_request", User: "alice", KeyID: "key-a", CreatedAt: now},
+		{TraceID: "t1", Stage: "after_request", User: "alice", KeyID: "key-a", CreatedAt: now},
+		{TraceID: "t2", Stage: "after_request", User: "bob", KeyID: "key-a", CreatedAt: now},
+		{TraceID: "t3", Stage: "after_request", KeyID: "key-b", CreatedAt: now},
+		// Written before end_user existed: found through the request body.
+		{TraceID: "t4", Stage: "before_request", Request: json.RawMessage(`{"model":"m","user":"alice"}`), CreatedAt: now},
+		{TraceID: "t4", Stage: "after_request", CreatedAt: now},
+		{TraceID: "t5", Stage: "before_request", Request: json.RawMessage(`{"model":"m","user":"alice2"}`), KeyID: "key-b", CreatedAt: now},
+	} {
+		if err := w.Write(t.Context(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.flush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.db.ExecContext(t.Context(), "UPDATE request_logs SET end_user = NULL WHERE trace_id IN ('t4', 't5')"); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := w.Delete(t.Context(), MaintenanceQuery{User: "alice"})
+	if err != nil || deleted != 4 {
+		t.Fatalf("delete by user: deleted=%d err=%v, want 4", deleted, err)
+	}
+	deleted, err = w.Delete(t.Context(), MaintenanceQuery{KeyID: "key-a"})
+	if err != nil || deleted != 1 {
+		t.Fatalf("delete by key: deleted=%d err=%v, want 1", deleted, err)
+	}
+	result, err := w.List(t.Context(), Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data) != 2 || result.Data[0].TraceID == "t4" || result.Data[1].TraceID == "t4" {
+		t.Fatalf("unexpected remaining entries: %+v", result.Data)
+	}
+}
+
 func TestNewPostgresWriterRequiresDSN(t *testing.T) {
 	_, err := NewPostgresWriter(t.Context(), "   ")
 	if err == nil || !strings.Contains(err.Error(), "postgres dsn is required") {
@@ -536,3 +583,173 @@ func TestSQLiteWriter_CreatedAtIndexExists(t *testing.T) {
 		t.Fatalf("unexpected index name: %s", name)
 	}
 }
+
+// TestSQLiteWriter_CacheStatusRoundTrip confirms the cache_status column added
+// in migration 3 is written and listed, and that rows without one read back
+// empty.
+func TestSQLiteWriter_CacheStatusRoundTrip(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	ctx := context.Background()
+	base := time.Now().UTC()
+	if err := w.Write(ctx, Entry{TraceID: "hit", Stage: "after_request", CacheStatus: "hit", CreatedAt: base}); err != nil {
+		t.Fatalf("write hit entry: %v", err)
+	}
+	if err := w.Write(ctx, Entry{TraceID: "plain", Stage: "after_request", CreatedAt: base.Add(-time.Second)}); err != nil {
+		t.Fatalf("write plain entry: %v", err)
+	}
+
+	result, err := w.List(ctx, Query{Limit: 10})
+	if err != nil {
+		t.Fatalf("list logs: %v", err)
+	}
+	if len(result.Data) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(result.Data))
+	}
+	if got := result.Data[0].CacheStatus; got != "hit" {
+		t.Errorf("hit entry CacheStatus = %q, want %q", got, "hit")
+	}
+	if got := result.Data[1].CacheStatus; got != "" {
+		t.Errorf("plain entry CacheStatus = %q, want empty", got)
+	}
+}
+
+// TestSQLiteWriter_TranscriptRoundTrip confirms the transcript columns added in
+// migration 4 are written and listed, and that the trace and until filters
+// select a single request's entries.
+func TestSQLiteWriter_TranscriptRoundTrip(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "transcript.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	ctx := context.Background()
+	base := time.Now().UTC()
+	entries := []Entry{
+		{TraceID: "t1", Stage: "before_request", Request: []byte(`{"model":"gpt-4o"}`), CreatedAt: base},
+		{TraceID: "t1", Stage: "after_request", CostUSD: 0.25, Response: []byte(`{"id":"r"}`), PluginDecisions: []byte(`[{"plugin":"word-filter","outcome":"ok"}]`), CreatedAt: base.Add(time.Second)},
+		{TraceID: "t2", Stage: "before_request", CreatedAt: base.Add(2 * time.Second)},
+	}
+	for _, e := range entries {
+		if err := w.Write(ctx, e); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+
+	result, err := w.List(ctx, Query{TraceID: "t1", Limit: 10})
+	if err != nil {
+		t.Fatalf("list logs: %v", err)
+	}
+	if result.Total != 2 || len(result.Data) != 2 {
+		t.Fatalf("trace filter returned %d/%d entries, want 2", len(result.Data), result.Total)
+	}
+	after, before := result.Data[0], result.Data[1]
+	if after.CostUSD != 0.25 || string(after.Response) != `{"id":"r"}` || !strings.Contains(string(after.PluginDecisions), "word-filter") {
+		t.Errorf("after_request entry = %+v", after)
+	}
+	if string(before.Request) != `{"model":"gpt-4o"}` || before.Response != nil || before.PluginDecisions != nil {
+		t.Errorf("before_request entry = %+v", before)
+	}
+
+	until := base.Add(time.Second)
+	result, err = w.List(ctx, Query{Until: &until, Limit: 10})
+	if err != nil {
+		t.Fatalf("list logs: %v", err)
+	}
+	if result.Total != 2 {
+		t.Fatalf("until filter matched %d entries, want 2", result.Total)
+	}
+}
+
+func TestSQLiteWriter_GuardrailDecisionRoundTrip(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "guardrail.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	ctx := context.Background()
+	if err := w.Write(ctx, Entry{TraceID: "t1", Stage: StageRejected, Model: "gpt-4o", Plugin: "word-filter", Decision: "reject", Reason: "blocked word: secret"}); err != nil {
+		t.Fatalf("write entry: %v", err)
+	}
+	if err := w.Write(ctx, Entry{TraceID: "t2", Stage: "before_request"}); err != nil {
+		t.Fatalf("write entry: %v", err)
+	}
+
+	result, err := w.List(ctx, Query{Stage: StageRejected, Limit: 10})
+	if err != nil {
+		t.Fatalf("list logs: %v", err)
+	}
+	if len(result.Data) != 1 {
+		t.Fatalf("stage filter returned %d entries, want 1", len(result.Data))
+	}
+	if e := result.Data[0]; e.Plugin != "word-filter" || e.Decision != "reject" || e.Reason != "blocked word: secret" {
+		t.Errorf("rejected entry = %+v", e)
+	}
+}
+
+func TestSQLiteWriter_UpstreamHeadersRoundTrip(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "upstream.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	ctx := context.Background()
+	headers := json.RawMessage(`{"x-ratelimit-remaining-tokens":"0","x-request-id":"req_1"}`)
+	if err := w.Write(ctx, Entry{TraceID: "t1", Stage: "on_error", UpstreamHeaders: headers}); err != nil {
+		t.Fatalf("write entry: %v", err)
+	}
+	if err := w.Write(ctx, Entry{TraceID: "t2", Stage: "before_request"}); err != nil {
+		t.Fatalf("write entry: %v", err)
+	}
+
+	result, err := w.List(ctx, Query{Limit: 10})
+	if err != nil {
+		t.Fatalf("list logs: %v", err)
+	}
+	got := map[string]string{}
+	for _, e := range result.Data {
+		got[e.TraceID] = string(e.UpstreamHeaders)
+	}
+	if got["t1"] != string(headers) || got["t2"] != "" {
+		t.Errorf("upstream headers = %v", got)
+	}
+}
+
+func TestSQLiteWriter_SubscribeReceivesMatchingWrites(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "requests.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteWriter() error = %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	entries, cancel := w.Subscribe(Query{Model: "gpt-4o"}, 1)
+	for _, e := range []Entry{
+		{TraceID: "t1", Stage: "before_request", Model: "claude"},
+		{TraceID: "t2", Stage: "before_request", Model: "gpt-4o"},
+		// The buffer holds one entry; this one is dropped, not blocked on.
+		{TraceID: "t3", Stage: "before_request", Model: "gpt-4o"},
+	} {
+		if err := w.Write(t.Context(), e); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if got := <-entries; got.TraceID != "t2" || got.CreatedAt.IsZero() {
+		t.Fatalf("received %+v, want t2 with its write time", got)
+	}
+	cancel()
+	if _, open := <-entries; open {
+		t.Fatal("expected the channel to close on cancel")
+	}
+	cancel() // idempotent
+	if err := w.Write(t.Context(), Entry{TraceID: "t4", Model: "gpt-4o"}); err != nil {
+		t.Fatalf("Write() after cancel error = %v", err)
+	}
+}
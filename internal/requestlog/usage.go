@@ -0,0 +1,162 @@
+package requestlog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/sqldb"
+)
+
+// UsageDimension is a column a usage report can group by.
+type UsageDimension string
+
+// Usage report dimensions.
+const (
+	UsageByProvider  UsageDimension = "provider"
+	UsageByModel     UsageDimension = "model"
+	UsageByKey       UsageDimension = "key"
+	UsageByWorkspace UsageDimension = "workspace"
+)
+
+// UsageDimensions lists every dimension in report column order.
+var UsageDimensions = []UsageDimension{UsageByProvider, UsageByModel, UsageByKey, UsageByWorkspace}
+
+// usageColumns maps each dimension to its request_logs column.
+var usageColumns = map[UsageDimension]string{
+	UsageByProvider:  "provider",
+	UsageByModel:     "model",
+	UsageByKey:       "key_id",
+	UsageByWorkspace: "workspace",
+}
+
+// UsageQuery selects the entries a usage report covers: those created in
+// [Since, Until), grouped by GroupBy. An empty GroupBy groups by every
+// dimension. Daily also groups them by UTC calendar day, first.
+type UsageQuery struct {
+	Since   time.Time
+	Until   time.Time
+	GroupBy []UsageDimension
+	Daily   bool
+}
+
+// UsageRow is one group of a usage report. Dimensions the report does not
+// group by are empty.
+//
+// Requests counts completed and failed requests; rejected requests never
+// reached a provider and are left out. A response served from the response
+// cache counts as a request and in CachedRequests, but adds no tokens or cost,
+// since the provider was not billed for it. Failed requests carry no provider
+// or token counts.
+type UsageRow struct {
+	// Day is the UTC day (YYYY-MM-DD) of a Daily report's row.
+	Day              string  `json:"day,omitempty"`
+	Provider         string  `json:"provider,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	KeyID            string  `json:"key_id,omitempty"`
+	Workspace        string  `json:"workspace,omitempty"`
+	Requests         int64   `json:"requests"`
+	Errors           int64   `json:"errors"`
+	CachedRequests   int64   `json:"cached_requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// UsageReporter is implemented by stores that can aggregate usage reports.
+type UsageReporter interface {
+	Usage(ctx context.Context, query UsageQuery) ([]UsageRow, error)
+}
+
+// usageQueryTemplate aggregates the after_request and on_error rows in the
+// time range. %[1]s is the select list for the dimensions and %[2]s the GROUP
+// BY / ORDER BY list, both built from usageColumns.
+const usageQueryTemplate = `SELECT %[1]s,
+       COUNT(*),
+       SUM(CASE WHEN stage = 'on_error' THEN 1 ELSE 0 END),
+       SUM(CASE WHEN cache_status IN ('hit', 'stale') THEN 1 ELSE 0 END),
+       COALESCE(SUM(CASE WHEN cache_status IN ('hit', 'stale') THEN 0 ELSE prompt_tokens END), 0),
+       COALESCE(SUM(CASE WHEN cache_status IN ('hit', 'stale') THEN 0 ELSE completion_tokens END), 0),
+       COALESCE(SUM(CASE WHEN cache_status IN ('hit', 'stale') THEN 0 ELSE total_tokens END), 0),
+       COALESCE(SUM(CASE WHEN cache_status IN ('hit', 'stale') THEN 0 ELSE cost_usd END), 0)
+FROM request_logs
+WHERE stage IN ('after_request', 'on_error') AND created_at >= ? AND created_at < ?
+%[2]s`
+
+// Usage aggregates the usage report described by query, ordered by the
+// grouped dimensions.
+func (w *SQLWriter) Usage(ctx context.Context, query UsageQuery) ([]UsageRow, error) {
+	if err := w.flush(ctx); err != nil {
+		return nil, err
+	}
+	groupBy := query.GroupBy
+	if len(groupBy) == 0 {
+		groupBy = UsageDimensions
+	}
+	grouped := make(map[UsageDimension]bool, len(groupBy))
+	for _, d := range groupBy {
+		if _, ok := usageColumns[d]; !ok {
+			return nil, fmt.Errorf("unknown usage dimension %q", d)
+		}
+		grouped[d] = true
+	}
+
+	selects := make([]string, 0, len(UsageDimensions)+1)
+	var groups []string
+	if query.Daily {
+		day := usageDay(w.dialect)
+		selects = append(selects, day)
+		groups = append(groups, day)
+	} else {
+		selects = append(selects, "''")
+	}
+	for _, d := range UsageDimensions {
+		if !grouped[d] {
+			selects = append(selects, "''")
+			continue
+		}
+		expr := "COALESCE(" + usageColumns[d] + ", '')"
+		selects = append(selects, expr)
+		groups = append(groups, expr)
+	}
+	list := strings.Join(groups, ", ")
+	groupSQL := "GROUP BY " + list + " ORDER BY " + list
+
+	// #nosec G201 -- the select and group lists are built only from usageColumns and usageDay literals.
+	stmt := sqldb.Bind(w.dialect, fmt.Sprintf(usageQueryTemplate, strings.Join(selects, ", "), groupSQL))
+	// #nosec G701 -- stmt is assembled from fixed literals and bound placeholders.
+	rows, err := w.db.QueryContext(ctx, stmt, query.Since.UTC(), query.Until.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("aggregate usage report: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := make([]UsageRow, 0)
+	for rows.Next() {
+		var r UsageRow
+		if err := rows.Scan(&r.Day, &r.Provider, &r.Model, &r.KeyID, &r.Workspace,
+			&r.Requests, &r.Errors, &r.CachedRequests,
+			&r.PromptTokens, &r.CompletionTokens, &r.TotalTokens, &r.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan usage report row: %w", err)
+		}
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate usage report: %w", err)
+	}
+	return result, nil
+}
+
+// usageDay is the expression of an entry's UTC day. SQLite keeps created_at
+// as text beginning with the date, in UTC as entries are written, which the
+// range filter relies on too.
+func usageDay(dialect sqldb.Dialect) string {
+	if dialect == sqldb.Postgres {
+		return "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')"
+	}
+	return "substr(created_at, 1, 10)"
+}
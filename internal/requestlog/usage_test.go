@@ -0,0 +1,93 @@
+package requestlog
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSQLiteWriter_Usage(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	ctx := context.Background()
+	jan := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	for _, e := range []Entry{
+		{Stage: "after_request", Provider: "openai", Model: "gpt-4o", KeyID: "k1", Workspace: "team-a", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, CostUSD: 0.25, CreatedAt: jan},
+		{Stage: "after_request", Provider: "openai", Model: "gpt-4o", KeyID: "k1", Workspace: "team-a", PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30, CostUSD: 0.5, CreatedAt: jan},
+		// A cache hit is a request, but the provider never billed it.
+		{Stage: "after_request", Provider: "openai", Model: "gpt-4o", KeyID: "k1", Workspace: "team-a", TotalTokens: 30, CostUSD: 0.5, CacheStatus: "hit", CreatedAt: jan},
+		{Stage: "on_error", Model: "gpt-4o", KeyID: "k2", ErrorMessage: "boom", CreatedAt: jan},
+		// Not usage: a before_request entry, a rejection, and February.
+		{Stage: "before_request", Model: "gpt-4o", KeyID: "k1", CreatedAt: jan},
+		{Stage: StageRejected, Model: "gpt-4o", KeyID: "k1", CreatedAt: jan},
+		{Stage: "after_request", Provider: "openai", Model: "gpt-4o", KeyID: "k1", TotalTokens: 99, CreatedAt: jan.AddDate(0, 1, 0)},
+	} {
+		if err := w.Write(ctx, e); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := w.Usage(ctx, UsageQuery{Since: since, Until: since.AddDate(0, 1, 0)})
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	want := []UsageRow{
+		{Model: "gpt-4o", KeyID: "k2", Requests: 1, Errors: 1},
+		{Provider: "openai", Model: "gpt-4o", KeyID: "k1", Workspace: "team-a", Requests: 3, CachedRequests: 1, PromptTokens: 30, CompletionTokens: 15, TotalTokens: 45, CostUSD: 0.75},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %+v\nwant %+v", rows, want)
+	}
+
+	rows, err = w.Usage(ctx, UsageQuery{Since: since, Until: since.AddDate(0, 1, 0), GroupBy: []UsageDimension{UsageByModel}})
+	if err != nil {
+		t.Fatalf("usage by model: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Model != "gpt-4o" || rows[0].KeyID != "" || rows[0].Requests != 4 || rows[0].Errors != 1 {
+		t.Fatalf("rows by model = %+v", rows)
+	}
+
+	rows, err = w.Usage(ctx, UsageQuery{Since: since, Until: since.AddDate(0, 2, 0), GroupBy: []UsageDimension{UsageByProvider}, Daily: true})
+	if err != nil {
+		t.Fatalf("daily usage: %v", err)
+	}
+	want = []UsageRow{
+		{Day: "2025-01-15", Requests: 1, Errors: 1},
+		{Day: "2025-01-15", Provider: "openai", Requests: 3, CachedRequests: 1, PromptTokens: 30, CompletionTokens: 15, TotalTokens: 45, CostUSD: 0.75},
+		{Day: "2025-02-15", Provider: "openai", Requests: 1, TotalTokens: 99},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("daily rows = %+v\nwant %+v", rows, want)
+	}
+
+	if _, err := w.Usage(ctx, UsageQuery{GroupBy: []UsageDimension{"region"}}); err == nil {
+		t.Fatal("expected an unknown dimension to be rejected")
+	}
+}
+
+func TestSQLiteWriter_KeyAndWorkspaceRoundTrip(t *testing.T) {
+	w, err := NewSQLiteWriter(t.Context(), filepath.Join(t.TempDir(), "keys.db"))
+	if err != nil {
+		t.Fatalf("new sqlite writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+
+	ctx := context.Background()
+	if err := w.Write(ctx, Entry{TraceID: "t1", Stage: "after_request", KeyID: "k1", Workspace: "team-a"}); err != nil {
+		t.Fatalf("write entry: %v", err)
+	}
+	result, err := w.List(ctx, Query{Limit: 10})
+	if err != nil {
+		t.Fatalf("list logs: %v", err)
+	}
+	if e := result.Data[0]; e.KeyID != "k1" || e.Workspace != "team-a" {
+		t.Fatalf("entry = %+v", e)
+	}
+}
@@ -0,0 +1,169 @@
+// Package routingrules compiles and evaluates the CEL expressions and time
+// windows of conditional routing rules.
+//
+// An expression is compiled once, when the config is loaded, and must return
+// a bool. It is evaluated per request against these variables:
+//
+//	model          string               the requested model
+//	tokens         int                  estimated prompt tokens
+//	request_bytes  int                  request body size; 0 when unknown
+//	headers        map(string, string)  request headers, names lowercased
+//	key            map(string, dyn)     the API key: id, name, workspace, tier, scopes
+//	now            timestamp            evaluation time
+//
+// For example:
+//
+//	tokens > 8000 && headers["x-priority"] == "batch"
+//	key.workspace == "research" || now.getHours("America/New_York") >= 22
+package routingrules
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/tokens"
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/google/cel-go/cel"
+)
+
+// costLimit bounds the work a single evaluation may do, so a rule that loops
+// over a large header map or list cannot stall the request path.
+const costLimit = 100_000
+
+var (
+	envOnce sync.Once
+	env     *cel.Env
+	envErr  error
+)
+
+func celEnv() (*cel.Env, error) {
+	envOnce.Do(func() {
+		env, envErr = cel.NewEnv(
+			cel.Variable("model", cel.StringType),
+			cel.Variable("tokens", cel.IntType),
+			cel.Variable("request_bytes", cel.IntType),
+			cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("key", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("now", cel.TimestampType),
+		)
+	})
+	return env, envErr
+}
+
+// Rule is a compiled routing expression. It is safe for concurrent use.
+type Rule struct {
+	expr    string
+	program cel.Program
+}
+
+// Compile parses and type-checks expr, which must evaluate to a bool.
+func Compile(expr string) (*Rule, error) {
+	e, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := e.Compile(expr)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("invalid routing expression %q: %w", expr, iss.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("routing expression %q returns %s, want bool", expr, ast.OutputType())
+	}
+	program, err := e.Program(ast, cel.EvalOptions(cel.OptOptimize), cel.CostLimit(costLimit))
+	if err != nil {
+		return nil, fmt.Errorf("invalid routing expression %q: %w", expr, err)
+	}
+	return &Rule{expr: expr, program: program}, nil
+}
+
+// String returns the rule's source expression.
+func (r *Rule) String() string { return r.expr }
+
+// Match evaluates the rule for req. An evaluation error, such as a missing
+// header map entry, counts as no match.
+func (r *Rule) Match(ctx context.Context, req providers.Request) bool {
+	out, _, err := r.program.ContextEval(ctx, activation(ctx, req))
+	if err != nil {
+		return false
+	}
+	matched, ok := out.Value().(bool)
+	return ok && matched
+}
+
+// activation binds the rule variables for req. tokens, headers, and key are
+// computed only when a rule reads them.
+func activation(ctx context.Context, req providers.Request) map[string]any {
+	info, _ := ctx.Value(requestInfoKey{}).(requestInfo)
+	return map[string]any{
+		"model":         req.Model,
+		"tokens":        func() any { return int64(tokens.Prompt(req)) },
+		"request_bytes": info.size,
+		"headers":       func() any { return headerMap(info.header) },
+		"key":           func() any { return keyMap(ctx) },
+		"now":           time.Now(),
+	}
+}
+
+func headerMap(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for name, values := range h {
+		name = strings.ToLower(name)
+		// The caller's credentials are never exposed to rules.
+		if len(values) == 0 || name == "authorization" || name == "x-api-key" {
+			continue
+		}
+		m[name] = values[0]
+	}
+	return m
+}
+
+func keyMap(ctx context.Context) map[string]any {
+	id, _ := authctx.KeyID(ctx)
+	tier, _ := authctx.Tier(ctx)
+	identity, _ := authctx.Identity(ctx)
+	scopes := identity.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return map[string]any{
+		"id":        id,
+		"name":      identity.Name,
+		"workspace": identity.Workspace,
+		"tier":      tier,
+		"scopes":    scopes,
+	}
+}
+
+type requestInfoKey struct{}
+
+// requestInfo is the HTTP request metadata rules can read.
+type requestInfo struct {
+	header http.Header
+	size   int64
+}
+
+// WithRequest returns a context carrying r's headers and body size for rules
+// evaluated while serving it. A body of unknown length counts as 0 bytes.
+func WithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, requestInfo{header: r.Header, size: max(r.ContentLength, 0)})
+}
+
+// HeaderValue returns the named header of the request ctx is serving, or ""
+// when it has none or ctx carries no request.
+func HeaderValue(ctx context.Context, name string) string {
+	info, _ := ctx.Value(requestInfoKey{}).(requestInfo)
+	return info.header.Get(name)
+}
+
+// Middleware attaches each request's headers and body size to its context
+// for the routing rules evaluated while serving it.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithRequest(r.Context(), r)))
+	})
+}
@@ -0,0 +1,80 @@
+package routingrules
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestCompile_Rejects(t *testing.T) {
+	for _, expr := range []string{
+		`model ==`,              // syntax error
+		`model`,                 // not a bool
+		`unknown == "x"`,        // undeclared variable
+		`tokens > "many"`,       // type error
+		`headers["x-a"] == 1`,   // type error
+		`key.scopes.size() + 1`, // not a bool
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestRule_Match(t *testing.T) {
+	httpReq := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(strings.Repeat("x", 2048)))
+	httpReq.Header.Set("X-Priority", "batch")
+	httpReq.Header.Set("Authorization", "Bearer secret")
+	ctx := WithRequest(context.Background(), httpReq)
+	ctx = authctx.WithKeyID(ctx, "key-1")
+	ctx = authctx.WithTier(ctx, "pro")
+	ctx = authctx.WithIdentity(ctx, authctx.KeyIdentity{Name: "ci", Scopes: []string{"read_only"}, Workspace: "research"})
+
+	req := providers.Request{
+		Model:    "gpt-4o",
+		Messages: []providers.Message{{Role: "user", Content: strings.Repeat("word ", 400)}},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`model == "gpt-4o"`, true},
+		{`model.startsWith("claude-")`, false},
+		{`tokens > 300`, true},
+		{`tokens > 100000`, false},
+		{`request_bytes == 2048`, true},
+		{`headers["x-priority"] == "batch"`, true},
+		{`"authorization" in headers`, false},
+		{`key.id == "key-1" && key.tier == "pro"`, true},
+		{`key.workspace == "research" && "read_only" in key.scopes`, true},
+		{`key.name == "other"`, false},
+		{`now.getFullYear() >= 2024`, true},
+		// A missing map entry is an evaluation error, which never matches.
+		{`headers["x-missing"] == "1"`, false},
+	}
+	for _, tt := range tests {
+		rule, err := Compile(tt.expr)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tt.expr, err)
+		}
+		if got := rule.Match(ctx, req); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestRule_MatchWithoutRequestMetadata(t *testing.T) {
+	rule, err := Compile(`request_bytes == 0 && size(headers) == 0 && key.id == "" && key.scopes.size() == 0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rule.Match(context.Background(), providers.Request{Model: "gpt-4o"}) {
+		t.Error("rule over an unauthenticated request with no HTTP metadata did not match")
+	}
+}
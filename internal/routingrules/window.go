@@ -0,0 +1,90 @@
+package routingrules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a recurring weekly time window, such as weekdays 09:00–18:00 in
+// America/New_York. It is safe for concurrent use.
+type Window struct {
+	loc *time.Location
+	// days[d] reports whether the window opens on weekday d.
+	days       [7]bool
+	start, end time.Duration // offsets from local midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWindow builds a window open from start to end, both "HH:MM" local
+// times in the IANA timezone (UTC when empty), on each of days ("mon" through
+// "sun"; every day when empty). end may be "24:00". An end earlier than start
+// closes the window the next morning, so a window that opens on Friday at
+// 22:00 and ends at 06:00 is open until Saturday 06:00.
+func ParseWindow(timezone string, days []string, start, end string) (*Window, error) {
+	w := &Window{loc: time.UTC}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		w.loc = loc
+	}
+	if len(days) == 0 {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, day := range days {
+		d, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q: want one of mon, tue, wed, thu, fri, sat, sun", day)
+		}
+		w.days[d] = true
+	}
+	var err error
+	if w.start, err = parseClock(start); err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+	if w.start == 24*time.Hour {
+		return nil, fmt.Errorf("invalid start: 24:00 is only valid as an end")
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return nil, fmt.Errorf("invalid end: %w", err)
+	}
+	if w.start == w.end {
+		return nil, fmt.Errorf("start and end are both %s; use 00:00 to 24:00 for a whole day", start)
+	}
+	return w, nil
+}
+
+// parseClock parses "HH:MM" from 00:00 to 24:00.
+func parseClock(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an HH:MM time", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether the window is open at t.
+func (w *Window) Contains(t time.Time) bool {
+	t = t.In(w.loc)
+	// Wall-clock time, not time elapsed since midnight, which differs on the
+	// days daylight saving time changes.
+	hour, minute, second := t.Clock()
+	offset := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+	today := t.Weekday()
+	if w.start < w.end {
+		return w.days[today] && offset >= w.start && offset < w.end
+	}
+	// Overnight: open from start until midnight on a listed day, and from
+	// midnight until end on the day after one.
+	yesterday := (today + 6) % 7
+	return (w.days[today] && offset >= w.start) || (w.days[yesterday] && offset < w.end)
+}
@@ -0,0 +1,76 @@
+package routingrules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindow_Rejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		timezone   string
+		days       []string
+		start, end string
+	}{
+		{name: "unknown timezone", timezone: "Mars/Olympus_Mons", start: "09:00", end: "17:00"},
+		{name: "unknown day", days: []string{"funday"}, start: "09:00", end: "17:00"},
+		{name: "malformed start", start: "9am", end: "17:00"},
+		{name: "out of range end", start: "09:00", end: "25:00"},
+		{name: "start at 24:00", start: "24:00", end: "06:00"},
+		{name: "empty window", start: "09:00", end: "09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseWindow(tt.timezone, tt.days, tt.start, tt.end); err == nil {
+				t.Error("ParseWindow succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+	business, err := ParseWindow("America/New_York", []string{"mon", "tue", "wed", "thu", "fri"}, "09:00", "18:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	night, err := ParseWindow("", []string{"fri"}, "22:00", "06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	allDay, err := ParseWindow("UTC", []string{"Sun"}, "00:00", "24:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		window *Window
+		at     time.Time
+		want   bool
+	}{
+		{"business hours open", business, time.Date(2025, 3, 12, 9, 0, 0, 0, ny), true},
+		{"business hours last minute", business, time.Date(2025, 3, 12, 17, 59, 59, 0, ny), true},
+		{"business hours end is exclusive", business, time.Date(2025, 3, 12, 18, 0, 0, 0, ny), false},
+		{"business hours weekend", business, time.Date(2025, 3, 15, 12, 0, 0, 0, ny), false},
+		// 14:00 UTC is 10:00 in New York in March (EDT).
+		{"business hours from UTC", business, time.Date(2025, 3, 12, 14, 0, 0, 0, time.UTC), true},
+		{"overnight opening day", night, time.Date(2025, 3, 14, 23, 0, 0, 0, time.UTC), true},
+		{"overnight next morning", night, time.Date(2025, 3, 15, 5, 59, 0, 0, time.UTC), true},
+		{"overnight closed after end", night, time.Date(2025, 3, 15, 6, 0, 0, 0, time.UTC), false},
+		{"overnight closed before start", night, time.Date(2025, 3, 14, 21, 59, 0, 0, time.UTC), false},
+		{"overnight not the day before", night, time.Date(2025, 3, 14, 2, 0, 0, 0, time.UTC), false},
+		{"whole day", allDay, time.Date(2025, 3, 16, 23, 59, 0, 0, time.UTC), true},
+		{"whole day other day", allDay, time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.at); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package scaling tracks the load signals an autoscaler such as KEDA or an
+// HPA external metric scales gateway replicas on: requests in flight, requests
+// queued for admission, and open streams. Unlike CPU, these rise as soon as
+// providers slow down and requests start to pile up in the gateway.
+//
+// The counters are process-wide, like the Prometheus metrics that mirror them
+// (gateway_requests_in_flight, gateway_streams_open).
+package scaling
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+)
+
+var (
+	inFlight atomic.Int64
+	streams  atomic.Int64
+)
+
+// Queue is the admission queue's view the snapshot reads; it is satisfied by
+// *middleware.AdmissionQueue.
+type Queue interface {
+	Capacity() int
+	Queued() int
+}
+
+// Snapshot is the load of this replica at one instant.
+type Snapshot struct {
+	// InFlight is the number of data-plane requests being served.
+	InFlight int64 `json:"in_flight"`
+	// QueueDepth is the number of requests waiting for admission.
+	QueueDepth int `json:"queue_depth"`
+	// StreamingConnections is the number of streaming responses open.
+	StreamingConnections int64 `json:"streaming_connections"`
+	// MaxInFlight is the admission limit, or 0 when admission is unbounded.
+	MaxInFlight int `json:"max_in_flight"`
+}
+
+// Current returns the replica's load. q may be nil when no admission queue is
+// configured.
+func Current(q Queue) Snapshot {
+	s := Snapshot{
+		InFlight:             inFlight.Load(),
+		StreamingConnections: streams.Load(),
+	}
+	if q != nil {
+		s.QueueDepth = q.Queued()
+		s.MaxInFlight = q.Capacity()
+	}
+	return s
+}
+
+// Middleware counts every request it wraps as in flight while its handler
+// runs. Mounted after the admission queue, on the same routes, it counts
+// admitted requests only.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Add(1)
+		metrics.RequestsInFlight.Inc()
+		defer func() {
+			inFlight.Add(-1)
+			metrics.RequestsInFlight.Dec()
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// StreamOpened counts a streaming response as open until the returned
+// function is called.
+func StreamOpened() (closed func()) {
+	streams.Add(1)
+	metrics.StreamsOpen.Inc()
+	return func() {
+		streams.Add(-1)
+		metrics.StreamsOpen.Dec()
+	}
+}
@@ -0,0 +1,45 @@
+package scaling
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeQueue struct{ capacity, queued int }
+
+func (q fakeQueue) Capacity() int { return q.capacity }
+func (q fakeQueue) Queued() int   { return q.queued }
+
+func TestMiddleware_CountsRequestsWhileRunning(t *testing.T) {
+	var during Snapshot
+	h := Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		during = Current(nil)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/chat/completions", nil))
+	if during.InFlight != 1 {
+		t.Fatalf("in flight during a POST = %d, want 1", during.InFlight)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/v1/realtime", nil))
+	if during.InFlight != 1 {
+		t.Fatalf("in flight during a GET = %d, want 1", during.InFlight)
+	}
+	if got := Current(nil).InFlight; got != 0 {
+		t.Fatalf("in flight after the requests = %d, want 0", got)
+	}
+}
+
+func TestCurrent_ReportsStreamsAndQueue(t *testing.T) {
+	closed := StreamOpened()
+	got := Current(fakeQueue{capacity: 50, queued: 7})
+	closed()
+
+	want := Snapshot{QueueDepth: 7, StreamingConnections: 1, MaxInFlight: 50}
+	if got != want {
+		t.Fatalf("Current = %+v, want %+v", got, want)
+	}
+	if n := Current(nil).StreamingConnections; n != 0 {
+		t.Fatalf("streams after close = %d, want 0", n)
+	}
+}
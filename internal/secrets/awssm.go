@@ -0,0 +1,139 @@
+//go:build !nobedrock
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// AWS Secrets Manager is reached through its JSON API with SigV4 signing from
+// the AWS SDK the Bedrock provider already links, so building with the
+// nobedrock tag replaces this file with awssm_stub.go.
+
+func registerAWS(r *Resolver) {
+	r.Register(SchemeAWSSM, &AWSSecretsManager{
+		Endpoint: strings.TrimSpace(os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER")),
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	})
+}
+
+// AWSSecretsManager reads secrets from AWS Secrets Manager. A reference names
+// the secret by name or ARN, and a field picks one key of a JSON secret:
+// awssm://prod/openai#api_key.
+type AWSSecretsManager struct {
+	// Region and Credentials default to the standard AWS configuration
+	// chain (AWS_REGION, AWS_ACCESS_KEY_ID, shared config, instance roles).
+	Region      string
+	Credentials aws.CredentialsProvider
+	// Endpoint overrides https://secretsmanager.<region>.amazonaws.com.
+	Endpoint string
+	Client   *http.Client
+
+	mu     sync.Mutex
+	loaded bool
+}
+
+// Get fetches the secret's current value.
+func (a *AWSSecretsManager) Get(ctx context.Context, name, field string) (string, error) {
+	region, creds, err := a.config(ctx)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", err
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	credentials, err := creds.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("aws credentials: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	if err := v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(sum[:]), "secretsmanager", region, time.Now()); err != nil {
+		return "", fmt.Errorf("sign request: %w", err)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body struct {
+		SecretString *string `json:"SecretString"`
+		Message      string  `json:"message"`
+		Type         string  `json:"__type"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode secrets manager response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp, strings.TrimSpace(body.Type+" "+body.Message))
+	}
+	if body.SecretString == nil {
+		return "", fmt.Errorf("the secret has no string value")
+	}
+	if field == "" {
+		return *body.SecretString, nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(*body.SecretString), &data); err != nil {
+		return "", fmt.Errorf("#%s names a field, but the secret is not a JSON object", field)
+	}
+	return pickField(data, field)
+}
+
+// config fills in the region and credentials from the AWS configuration
+// chain the first time they are needed.
+func (a *AWSSecretsManager) config(ctx context.Context) (string, aws.CredentialsProvider, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.loaded && (a.Region == "" || a.Credentials == nil) {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("load aws config: %w", err)
+		}
+		if a.Region == "" {
+			a.Region = cfg.Region
+		}
+		if a.Credentials == nil {
+			a.Credentials = cfg.Credentials
+		}
+		a.loaded = true
+	}
+	if a.Region == "" {
+		return "", nil, fmt.Errorf("no AWS region configured; set AWS_REGION")
+	}
+	if a.Credentials == nil {
+		return "", nil, fmt.Errorf("no AWS credentials configured")
+	}
+	return a.Region, a.Credentials, nil
+}
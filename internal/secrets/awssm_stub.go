@@ -0,0 +1,24 @@
+//go:build nobedrock
+
+package secrets
+
+import (
+	"context"
+	"errors"
+)
+
+// errAWSExcluded is returned for awssm:// references in a binary built
+// without the AWS SDK.
+var errAWSExcluded = errors.New("aws secrets manager: not included in this build (built with the nobedrock tag)")
+
+type excludedAWS struct{}
+
+func (excludedAWS) Get(context.Context, string, string) (string, error) {
+	return "", errAWSExcluded
+}
+
+// registerAWS registers a backend that reports why awssm:// references
+// cannot resolve, rather than leaving them unconfigured.
+func registerAWS(r *Resolver) {
+	r.Register(SchemeAWSSM, excludedAWS{})
+}
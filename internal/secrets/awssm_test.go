@@ -0,0 +1,58 @@
+//go:build !nobedrock
+
+package secrets
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestAWSSecretsManager(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDTEST/") || !strings.Contains(auth, "/eu-west-1/secretsmanager/") ||
+			r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var req struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.SecretId {
+		case "prod/openai":
+			_, _ = w.Write([]byte(`{"SecretString":"sk-plain"}`))
+		case "prod/db":
+			_, _ = w.Write([]byte(`{"SecretString":"{\"dsn\":\"postgres://gw@db/gw\"}"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer srv.Close()
+
+	r := NewResolver()
+	r.Register(SchemeAWSSM, &AWSSecretsManager{
+		Region:      "eu-west-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDTEST", "secret", ""),
+		Endpoint:    srv.URL,
+	})
+	for ref, want := range map[string]string{
+		"awssm://prod/openai": "sk-plain",
+		"awssm://prod/db#dsn": "postgres://gw@db/gw",
+	} {
+		if got, err := r.Resolve(t.Context(), ref); err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	_, err := r.Resolve(t.Context(), "awssm://prod/missing")
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("missing secret: err = %v, want ResourceNotFoundException", err)
+	}
+	if _, err := r.Resolve(t.Context(), "awssm://prod/openai#key"); err == nil {
+		t.Error("expected a field of a non-JSON secret to fail")
+	}
+}
@@ -0,0 +1,190 @@
+// Package secrets resolves references to secrets held in an external secret
+// manager, so provider keys and store DSNs never sit in plaintext in the
+// environment or a config file. A reference is a whole value of the form
+//
+//	vault://<path>#<field>    a HashiCorp Vault secret, KV v1 or v2
+//	awssm://<name>[#<field>]  an AWS Secrets Manager secret
+//
+// Any other value is a literal and resolves to itself.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Reference schemes.
+const (
+	SchemeVault = "vault"
+	SchemeAWSSM = "awssm"
+)
+
+// knownSchemes are the schemes IsRef recognizes, whether or not a backend for
+// them is configured: a reference with no backend must fail rather than pass
+// through as a literal key.
+var knownSchemes = []string{SchemeVault, SchemeAWSSM}
+
+// Backend fetches secrets for one reference scheme.
+type Backend interface {
+	// Get returns the secret name refers to, or its field when field is set.
+	Get(ctx context.Context, name, field string) (string, error)
+}
+
+// Resolver resolves references through the backends registered for their
+// schemes. It is safe for concurrent use.
+type Resolver struct {
+	mu       sync.RWMutex
+	backends map[string]Backend
+}
+
+// NewResolver returns a Resolver with no backends.
+func NewResolver() *Resolver {
+	return &Resolver{backends: make(map[string]Backend)}
+}
+
+// NewResolverFromEnv returns a Resolver with the backends the environment
+// configures: Vault when VAULT_ADDR is set, and AWS Secrets Manager, which
+// uses the standard AWS credential chain and region settings.
+func NewResolverFromEnv() (*Resolver, error) {
+	r := NewResolver()
+	v, err := VaultFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		r.Register(SchemeVault, v)
+	}
+	registerAWS(r)
+	return r, nil
+}
+
+// FromEnv returns the Resolver NewResolverFromEnv builds, built once on first
+// use and shared by every caller.
+var FromEnv = sync.OnceValues(NewResolverFromEnv)
+
+// Register sets the backend for scheme.
+func (r *Resolver) Register(scheme string, b Backend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[scheme] = b
+}
+
+// IsRef reports whether s is a secret reference.
+func IsRef(s string) bool {
+	_, _, _, ok := parseRef(s)
+	return ok
+}
+
+// Resolve returns the secret s refers to, or s itself when it is a literal.
+func (r *Resolver) Resolve(ctx context.Context, s string) (string, error) {
+	scheme, name, field, ok := parseRef(s)
+	if !ok {
+		return s, nil
+	}
+	r.mu.RLock()
+	b := r.backends[scheme]
+	r.mu.RUnlock()
+	if b == nil {
+		return "", fmt.Errorf("%s:// secret reference, but no %s backend is configured", scheme, scheme)
+	}
+	val, err := b.Get(ctx, name, field)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s://%s: %w", scheme, name, err)
+	}
+	return val, nil
+}
+
+// Map returns a copy of m with every value resolved, or the first error.
+func (r *Resolver) Map(ctx context.Context, m map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(m))
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		val, err := r.Resolve(ctx, m[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = val
+	}
+	return out, nil
+}
+
+func parseRef(s string) (scheme, name, field string, ok bool) {
+	scheme, rest, found := strings.Cut(s, "://")
+	if !found || rest == "" {
+		return "", "", "", false
+	}
+	known := false
+	for _, k := range knownSchemes {
+		known = known || scheme == k
+	}
+	if !known {
+		return "", "", "", false
+	}
+	name, field, _ = strings.Cut(rest, "#")
+	return scheme, name, field, name != ""
+}
+
+// pickField returns field of a secret's key/value data. With no field named,
+// a secret holding exactly one value returns it.
+func pickField(data map[string]any, field string) (string, error) {
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("the secret holds %d values; name one with #field", len(data))
+		}
+		for k := range data {
+			field = k
+		}
+	}
+	v, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("the secret has no field %q", field)
+	}
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case nil:
+		return "", fmt.Errorf("field %q is empty", field)
+	default:
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(raw), nil
+	}
+}
+
+// EnvOrFile returns the environment variable name or, when it is unset, the
+// contents of the file name_FILE points to, such as a mounted Kubernetes
+// Secret. Either value has surrounding whitespace trimmed.
+func EnvOrFile(name string) (string, error) {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v, nil
+	}
+	path := strings.TrimSpace(os.Getenv(name + "_FILE"))
+	if path == "" {
+		return "", nil
+	}
+	raw, err := os.ReadFile(path) //nolint:gosec // G304: the path is operator configuration, not request input
+	if err != nil {
+		return "", fmt.Errorf("read %s_FILE: %w", name, err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// statusError describes a failed secret-manager response.
+func statusError(resp *http.Response, detail string) error {
+	if detail != "" {
+		return fmt.Errorf("%s: %s", resp.Status, detail)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeBackend map[string]string
+
+func (f fakeBackend) Get(_ context.Context, name, field string) (string, error) {
+	v, ok := f[name+"#"+field]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return v, nil
+}
+
+func TestResolver(t *testing.T) {
+	r := NewResolver()
+	r.Register(SchemeVault, fakeBackend{"secret/data/openai#api_key": "sk-vault"})
+
+	for in, want := range map[string]string{
+		"sk-literal":                         "sk-literal",
+		"vault://secret/data/openai#api_key": "sk-vault",
+		"postgres://user@db/gateway":         "postgres://user@db/gateway",
+		"https://example.com/#anchor":        "https://example.com/#anchor",
+		"vault:/not-a-ref":                   "vault:/not-a-ref",
+		"":                                   "",
+	} {
+		got, err := r.Resolve(t.Context(), in)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	if _, err := r.Resolve(t.Context(), "vault://secret/data/missing#x"); err == nil {
+		t.Error("expected a missing secret to fail")
+	}
+	if _, err := r.Resolve(t.Context(), "awssm://prod/openai"); err == nil {
+		t.Error("expected a reference with no backend to fail rather than pass through")
+	}
+
+	got, err := r.Map(t.Context(), map[string]string{"api_key": "vault://secret/data/openai#api_key", "base_url": "https://api.example.com"})
+	if err != nil || got["api_key"] != "sk-vault" || got["base_url"] != "https://api.example.com" {
+		t.Errorf("Map = %v, %v", got, err)
+	}
+}
+
+func TestPickField(t *testing.T) {
+	if v, err := pickField(map[string]any{"only": "one"}, ""); err != nil || v != "one" {
+		t.Errorf("single value: %q, %v", v, err)
+	}
+	if _, err := pickField(map[string]any{"a": "1", "b": "2"}, ""); err == nil {
+		t.Error("expected an ambiguous secret to need a field")
+	}
+	if v, err := pickField(map[string]any{"port": float64(5432)}, "port"); err != nil || v != "5432" {
+		t.Errorf("number field: %q, %v", v, err)
+	}
+	if _, err := pickField(map[string]any{"a": "1"}, "b"); err == nil {
+		t.Error("expected a missing field to fail")
+	}
+}
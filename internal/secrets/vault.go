@@ -0,0 +1,84 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Vault reads secrets from HashiCorp Vault's HTTP API. A reference's path is
+// the API path under /v1, so a KV v2 secret is vault://secret/data/openai#key
+// and a KV v1 secret vault://secret/openai#key.
+type Vault struct {
+	Addr      string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+// VaultFromEnv returns a Vault configured by VAULT_ADDR, VAULT_TOKEN (or
+// VAULT_TOKEN_FILE), and VAULT_NAMESPACE, or nil when VAULT_ADDR is unset.
+func VaultFromEnv() (*Vault, error) {
+	addr := strings.TrimSpace(os.Getenv("VAULT_ADDR"))
+	if addr == "" {
+		return nil, nil
+	}
+	token, err := EnvOrFile("VAULT_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{
+		Addr:      strings.TrimRight(addr, "/"),
+		Token:     token,
+		Namespace: strings.TrimSpace(os.Getenv("VAULT_NAMESPACE")),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Get reads the secret at path and returns its field.
+func (v *Vault) Get(ctx context.Context, path, field string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.Addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if v.Token != "" {
+		req.Header.Set("X-Vault-Token", v.Token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		Data   map[string]any `json:"data"`
+		Errors []string       `json:"errors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp, strings.Join(body.Errors, "; "))
+	}
+	data := body.Data
+	// KV v2 nests the secret's values under data.data, beside its metadata.
+	if inner, ok := data["data"].(map[string]any); ok {
+		if _, v2 := data["metadata"]; v2 {
+			data = inner
+		}
+	}
+	return pickField(data, field)
+}
@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/openai":
+			_, _ = w.Write([]byte(`{"data":{"data":{"api_key":"sk-v2","org":"acme"},"metadata":{"version":3}}}`))
+		case "/v1/kv/openai":
+			_, _ = w.Write([]byte(`{"data":{"api_key":"sk-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("VAULT_ADDR", srv.URL+"/")
+	t.Setenv("VAULT_TOKEN", "vault-token")
+	t.Setenv("VAULT_NAMESPACE", "team")
+	r, err := NewResolverFromEnv()
+	if err != nil {
+		t.Fatalf("NewResolverFromEnv: %v", err)
+	}
+
+	for ref, want := range map[string]string{
+		"vault://secret/data/openai#api_key": "sk-v2",
+		"vault://secret/data/openai#org":     "acme",
+		"vault://kv/openai":                  "sk-v1",
+	} {
+		if got, err := r.Resolve(t.Context(), ref); err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	for _, ref := range []string{"vault://secret/data/openai", "vault://secret/data/missing#api_key"} {
+		if _, err := r.Resolve(t.Context(), ref); err == nil {
+			t.Errorf("Resolve(%q): expected an error", ref)
+		}
+	}
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	r, _ = NewResolverFromEnv()
+	if _, err := r.Resolve(t.Context(), "vault://kv/openai"); err == nil {
+		t.Error("expected a rejected token to fail")
+	}
+}
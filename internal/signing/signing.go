@@ -0,0 +1,205 @@
+// Package signing verifies detached signatures on artifacts the gateway loads
+// from outside, such as config bundles. It reads the two formats operators
+// already sign with: minisign signature files, and the base64 signatures
+// `cosign sign-blob --key` writes for an ECDSA, RSA, or Ed25519 key. Public
+// keys are minisign keys (the base64 line of a minisign .pub file) or
+// PEM-encoded PKIX keys such as cosign.pub.
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ErrUnsigned reports an artifact without a signature to a verifier that
+// requires one.
+var ErrUnsigned = errors.New("artifact is not signed")
+
+// ErrBadSignature reports a signature that no trusted key verifies.
+var ErrBadSignature = errors.New("signature does not verify with any trusted key")
+
+// minisignUntrusted opens every minisign key and signature file.
+const minisignUntrusted = "untrusted comment:"
+
+const minisignTrusted = "trusted comment:"
+
+// Verifier checks detached signatures against a set of trusted public keys.
+type Verifier struct {
+	minisign []minisignKey
+	pkix     []crypto.PublicKey
+	// Strict refuses unsigned artifacts. Otherwise an unsigned artifact is
+	// accepted and only a signature that is present must verify.
+	Strict bool
+}
+
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// NewVerifier parses the trusted public keys in spec: PEM blocks and minisign
+// keys, the latter one per line or separated by commas. Minisign comment
+// lines are ignored. It fails when spec holds no key.
+func NewVerifier(spec string, strict bool) (*Verifier, error) {
+	v := &Verifier{Strict: strict}
+	rest := []byte(spec)
+	var text strings.Builder
+	for {
+		i := bytes.Index(rest, []byte("-----BEGIN "))
+		if i < 0 {
+			text.Write(rest)
+			break
+		}
+		// Keep what precedes the block: it may hold minisign keys.
+		text.Write(rest[:i])
+		block, after := pem.Decode(rest[i:])
+		if block == nil {
+			return nil, errors.New("malformed PEM block")
+		}
+		rest = after
+		if block.Type != "PUBLIC KEY" {
+			return nil, fmt.Errorf("unsupported PEM block %q: want PUBLIC KEY", block.Type)
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse PEM public key: %w", err)
+		}
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+		v.pkix = append(v.pkix, key)
+	}
+	for _, field := range strings.FieldsFunc(text.String(), func(r rune) bool { return r == '\n' || r == ',' }) {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, minisignUntrusted) {
+			continue
+		}
+		key, err := parseMinisignKey(field)
+		if err != nil {
+			return nil, err
+		}
+		v.minisign = append(v.minisign, key)
+	}
+	if len(v.minisign) == 0 && len(v.pkix) == 0 {
+		return nil, errors.New("no public keys found")
+	}
+	return v, nil
+}
+
+func parseMinisignKey(s string) (minisignKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return minisignKey{}, fmt.Errorf("invalid minisign public key %q", s)
+	}
+	var k minisignKey
+	copy(k.id[:], raw[2:10])
+	k.key = ed25519.PublicKey(raw[10:])
+	return k, nil
+}
+
+// Verify checks signature over data. signature is the content of a minisign
+// .minisig file or a cosign base64 signature; empty means the artifact is
+// unsigned, which is an error only for a strict verifier.
+func (v *Verifier) Verify(data, signature []byte) error {
+	signature = bytes.TrimSpace(signature)
+	if len(signature) == 0 {
+		if v.Strict {
+			return ErrUnsigned
+		}
+		return nil
+	}
+	if bytes.HasPrefix(signature, []byte(minisignUntrusted)) {
+		return v.verifyMinisign(data, string(signature))
+	}
+	return v.verifyPKIX(data, string(signature))
+}
+
+// verifyMinisign checks both the signature over data and the global
+// signature binding its trusted comment.
+func (v *Verifier) verifyMinisign(data []byte, file string) error {
+	lines := strings.Split(strings.ReplaceAll(file, "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], minisignTrusted) {
+		return errors.New("malformed minisign signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("malformed minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return errors.New("malformed minisign global signature")
+	}
+	msg := data
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		sum := blake2b.Sum512(data)
+		msg = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", sig[:2])
+	}
+	trusted := strings.TrimPrefix(lines[2], minisignTrusted+" ")
+	for _, k := range v.minisign {
+		if !bytes.Equal(k.id[:], sig[2:10]) {
+			continue
+		}
+		if !ed25519.Verify(k.key, msg, sig[10:]) {
+			return ErrBadSignature
+		}
+		if !ed25519.Verify(k.key, append(sig[10:len(sig):len(sig)], trusted...), global) {
+			return errors.New("minisign trusted comment does not verify")
+		}
+		return nil
+	}
+	return ErrBadSignature
+}
+
+func (v *Verifier) verifyPKIX(data []byte, encoded string) error {
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.New("malformed signature: want a minisign signature file or a base64 signature")
+	}
+	digest := sha256.Sum256(data)
+	for _, key := range v.pkix {
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(k, digest[:], sig) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil {
+				return nil
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, data, sig) {
+				return nil
+			}
+		}
+	}
+	return ErrBadSignature
+}
+
+// DecodeHeader reads a signature sent in an HTTP header, where a multi-line
+// minisign signature file travels base64-encoded and a cosign signature is
+// already base64.
+func DecodeHeader(value string) []byte {
+	value = strings.TrimSpace(value)
+	if raw, err := base64.StdEncoding.DecodeString(value); err == nil && bytes.HasPrefix(raw, []byte(minisignUntrusted)) {
+		return raw
+	}
+	return []byte(value)
+}
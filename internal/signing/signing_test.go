@@ -0,0 +1,159 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignPair builds a minisign public key line and a signer producing
+// minisign signature files with it, prehashed or legacy.
+func minisignPair(t *testing.T) (pub string, sign func(data []byte, prehash bool) string) {
+	t.Helper()
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	pub = base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), pk...))
+	sign = func(data []byte, prehash bool) string {
+		alg, msg := "Ed", data
+		if prehash {
+			sum := blake2b.Sum512(data)
+			alg, msg = "ED", sum[:]
+		}
+		sig := ed25519.Sign(sk, msg)
+		trusted := "timestamp:1700000000\tfile:bundle.json"
+		global := ed25519.Sign(sk, append(append([]byte{}, sig...), trusted...))
+		return "untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte(alg), id...), sig...)) + "\n" +
+			"trusted comment: " + trusted + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n"
+	}
+	return pub, sign
+}
+
+func TestVerifier_Minisign(t *testing.T) {
+	pub, sign := minisignPair(t)
+	v, err := NewVerifier("untrusted comment: minisign public key\n"+pub+"\n", true)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	data := []byte(`{"bundle_version":1}`)
+
+	for _, prehash := range []bool{true, false} {
+		if err := v.Verify(data, []byte(sign(data, prehash))); err != nil {
+			t.Errorf("prehash=%v: Verify = %v", prehash, err)
+		}
+	}
+	if err := v.Verify([]byte(`{"bundle_version":2}`), []byte(sign(data, true))); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered data: Verify = %v, want ErrBadSignature", err)
+	}
+
+	otherPub, _ := minisignPair(t)
+	other, err := NewVerifier(otherPub, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Verify(data, []byte(sign(data, true))); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("untrusted key: Verify = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestVerifier_MinisignTrustedCommentTampered(t *testing.T) {
+	pub, sign := minisignPair(t)
+	v, err := NewVerifier(pub, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("bundle")
+	tampered := strings.Replace(sign(data, true), "file:bundle.json", "file:other.json", 1)
+	if err := v.Verify(data, []byte(tampered)); err == nil {
+		t.Error("Verify accepted a signature whose trusted comment was altered")
+	}
+}
+
+func TestVerifier_CosignECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minisignPub, _ := minisignPair(t)
+	// A PEM key and a minisign key in one spec are both trusted.
+	spec := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})) + minisignPub
+	v, err := NewVerifier(spec, false)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	if len(v.pkix) != 1 || len(v.minisign) != 1 {
+		t.Fatalf("parsed %d PEM and %d minisign keys, want 1 and 1", len(v.pkix), len(v.minisign))
+	}
+
+	data := []byte(`{"bundle_version":1}`)
+	digest := sha256.Sum256(data)
+	raw, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(raw)
+	if err := v.Verify(data, []byte(sig+"\n")); err != nil {
+		t.Errorf("Verify = %v", err)
+	}
+	if err := v.Verify(append(data, ' '), []byte(sig)); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered data: Verify = %v, want ErrBadSignature", err)
+	}
+	if err := v.Verify(data, []byte("not base64!")); err == nil {
+		t.Error("Verify accepted a malformed signature")
+	}
+}
+
+func TestVerifier_Unsigned(t *testing.T) {
+	pub, _ := minisignPair(t)
+	lenient, _ := NewVerifier(pub, false)
+	if err := lenient.Verify([]byte("data"), nil); err != nil {
+		t.Errorf("lenient: Verify(unsigned) = %v, want nil", err)
+	}
+	strict, _ := NewVerifier(pub, true)
+	if err := strict.Verify([]byte("data"), []byte("  \n")); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("strict: Verify(unsigned) = %v, want ErrUnsigned", err)
+	}
+}
+
+func TestNewVerifier_Errors(t *testing.T) {
+	for name, spec := range map[string]string{
+		"empty":          " \n",
+		"comment only":   "untrusted comment: nothing here\n",
+		"bad minisign":   "RWQnotakey",
+		"bad PEM":        "-----BEGIN PUBLIC KEY-----\nAAAA\n",
+		"private key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1}})),
+		"garbage in PEM": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1, 2, 3}})),
+	} {
+		if _, err := NewVerifier(spec, false); err == nil {
+			t.Errorf("%s: NewVerifier accepted %q", name, spec)
+		}
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	minisig := "untrusted comment: x\nAAAA\ntrusted comment: y\nBBBB\n"
+	if got := string(DecodeHeader(base64.StdEncoding.EncodeToString([]byte(minisig)))); got != minisig {
+		t.Errorf("minisign header decoded to %q", got)
+	}
+	if got := string(DecodeHeader(" MEUCIQD= ")); got != "MEUCIQD=" {
+		t.Errorf("cosign header decoded to %q, want it unchanged", got)
+	}
+}
@@ -48,6 +48,25 @@ func Secure(dsn string) error {
 	return nil
 }
 
+// ReadOnly returns dsn changed to open its database read-only, so opening it
+// never creates or writes the file, and the path of that file. An in-memory
+// DSN is returned unchanged, with an empty path.
+func ReadOnly(dsn string) (readOnlyDSN, path string, err error) {
+	path, err = filePath(dsn)
+	if err != nil || path == "" {
+		return dsn, "", err
+	}
+	base, query, _ := strings.Cut(dsn, "?")
+	// SQLite reads mode from URI filenames only.
+	if !strings.HasPrefix(base, "file:") {
+		base = "file:" + (&url.URL{Path: base}).EscapedPath()
+	}
+	if query != "" {
+		query += "&"
+	}
+	return base + "?" + query + "mode=ro", path, nil
+}
+
 // filePath extracts the on-disk file path from a SQLite DSN. SQLite decodes
 // percent escapes in file: URIs before opening the file, so this helper must
 // resolve the same path before creating and restricting it.
@@ -209,3 +209,18 @@ func TestSecure_InvalidFileURI(t *testing.T) {
 		t.Fatal("expected an invalid percent escape to fail")
 	}
 }
+
+func TestReadOnly(t *testing.T) {
+	for dsn, want := range map[string][2]string{
+		"/data/keys.db":                         {"file:/data/keys.db?mode=ro", "/data/keys.db"},
+		"/data/keys.db?_pragma=busy_timeout(5)": {"file:/data/keys.db?_pragma=busy_timeout(5)&mode=ro", "/data/keys.db"},
+		"file:/data/keys.db?cache=shared":       {"file:/data/keys.db?cache=shared&mode=ro", "/data/keys.db"},
+		"/data/my keys.db":                      {"file:/data/my%20keys.db?mode=ro", "/data/my keys.db"},
+		":memory:":                              {":memory:", ""},
+	} {
+		got, path, err := ReadOnly(dsn)
+		if err != nil || got != want[0] || path != want[1] {
+			t.Errorf("ReadOnly(%q) = %q, %q, %v; want %q, %q", dsn, got, path, err, want[0], want[1])
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/internal/redact"
+	"github.com/ferro-labs/ai-gateway/internal/scaling"
 	"github.com/ferro-labs/ai-gateway/internal/streamio"
 	"github.com/ferro-labs/ai-gateway/providers"
 )
@@ -27,17 +28,29 @@ func SetIdleTimeoutForTest(d time.Duration) func() {
 	return func() { idleTimeout = prev }
 }
 
-// Write streams SSE chunks from ch to the response writer.
+// Write streams SSE chunks from ch to the response writer. When ctx carries a
+// trace ID, the first event is sent with it as its id.
 func Write(ctx context.Context, w http.ResponseWriter, ch <-chan providers.StreamChunk) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
+	defer scaling.StreamOpened()()
+
 	controller := http.NewResponseController(w)
 	_ = streamio.ClearWriteDeadline(controller)
 
 	bw := bufio.NewWriterSize(w, 4096)
 	enc := json.NewEncoder(bw)
+	// scratch holds each chunk's encoding; it grows to the largest chunk and
+	// is reused for the rest of the stream.
+	scratch := make([]byte, 0, 512)
+	// The trace ID also leads the first event as its SSE id, for clients such
+	// as EventSource that cannot read response headers. It sits in the buffer
+	// until that event is flushed.
+	if traceID := logging.TraceIDFromContext(ctx); traceID != "" {
+		_, _ = bw.WriteString("id: " + traceID + "\n")
+	}
 	now := time.Now().Unix()
 	idleTimer := time.NewTimer(idleTimeout)
 	defer idleTimer.Stop()
@@ -97,7 +110,7 @@ func Write(ctx context.Context, w http.ResponseWriter, ch <-chan providers.Strea
 			idleTimer.Reset(idleTimeout)
 
 			if err := writeAndFlush(ctx, controller, bw, func() error {
-				return writeChunk(bw, enc, &chunk)
+				return writeChunk(bw, &scratch, &chunk)
 			}); err != nil {
 				if !errors.Is(err, context.Canceled) {
 					logging.FromContext(ctx).Debug("stream response write failed", "error", err)
@@ -112,18 +125,17 @@ func writeAndFlush(ctx context.Context, controller *http.ResponseController, bw
 	return streamio.WriteAndFlush(ctx, controller, bw.Flush, writeFn)
 }
 
-// writeChunk writes a single stream chunk as an SSE event using a
-// concrete-typed argument. This is the highest-frequency write in the gateway
-// (once per token delta); taking *providers.StreamChunk instead of the any
-// parameter of writeEvent avoids heap-boxing the chunk on every write.
-func writeChunk(bw *bufio.Writer, enc *json.Encoder, chunk *providers.StreamChunk) error {
-	if _, err := bw.WriteString("data: "); err != nil {
-		return err
-	}
-	if err := enc.Encode(chunk); err != nil {
-		return err
-	}
-	return bw.WriteByte('\n')
+// writeChunk writes a single stream chunk as an SSE event. This is the
+// highest-frequency write in the gateway (once per token delta), so the chunk
+// is encoded with its hand-written AppendJSON into the reused scratch buffer
+// rather than through encoding/json's reflection. The bytes are identical.
+func writeChunk(bw *bufio.Writer, scratch *[]byte, chunk *providers.StreamChunk) error {
+	buf := append((*scratch)[:0], "data: "...)
+	buf = chunk.AppendJSON(buf)
+	buf = append(buf, "\n\n"...)
+	*scratch = buf
+	_, err := bw.Write(buf)
+	return err
 }
 
 // writeEvent writes an arbitrary payload as an SSE event. It is used for
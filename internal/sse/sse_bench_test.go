@@ -9,34 +9,57 @@ import (
 	"github.com/ferro-labs/ai-gateway/providers"
 )
 
+// benchChunk is a typical token-delta chunk.
+var benchChunk = providers.StreamChunk{
+	ID:      "chatcmpl-bench",
+	Object:  "chat.completion.chunk",
+	Created: 1700000000,
+	Model:   "gpt-4o-mini",
+	Choices: []providers.StreamChoice{{
+		Index: 0,
+		Delta: providers.MessageDelta{Role: "assistant", Content: "token"},
+	}},
+}
+
 // BenchmarkSSEWrite_1000Chunks measures the per-chunk write path that runs once
-// per token delta. writeChunk takes a concrete *providers.StreamChunk so the
-// chunk is not heap-boxed into an `any` on every write; allocs/op should stay
-// flat instead of scaling with the chunk count.
+// per token delta. writeChunk encodes into a reused scratch buffer, so
+// allocs/op should stay at zero instead of scaling with the chunk count.
 func BenchmarkSSEWrite_1000Chunks(b *testing.B) {
 	const chunks = 1000
 
 	bw := bufio.NewWriterSize(io.Discard, 4096)
-	enc := json.NewEncoder(bw)
-	chunk := providers.StreamChunk{
-		ID:      "chatcmpl-bench",
-		Object:  "chat.completion.chunk",
-		Created: 1700000000,
-		Model:   "gpt-4o-mini",
-		Choices: []providers.StreamChoice{{
-			Index: 0,
-			Delta: providers.MessageDelta{Role: "assistant", Content: "token"},
-		}},
-	}
+	scratch := make([]byte, 0, 512)
+	chunk := benchChunk
 
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		for j := 0; j < chunks; j++ {
-			if err := writeChunk(bw, enc, &chunk); err != nil {
+			if err := writeChunk(bw, &scratch, &chunk); err != nil {
 				b.Fatalf("writeChunk error: %v", err)
 			}
 		}
 		bw.Reset(io.Discard)
 	}
 }
+
+// BenchmarkSSEWrite_1000Chunks_EncodingJSON is the same loop through a
+// json.Encoder, as writeChunk encoded before AppendJSON, for comparison.
+func BenchmarkSSEWrite_1000Chunks_EncodingJSON(b *testing.B) {
+	const chunks = 1000
+
+	bw := bufio.NewWriterSize(io.Discard, 4096)
+	enc := json.NewEncoder(bw)
+	chunk := benchChunk
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < chunks; j++ {
+			if err := writeEvent(bw, enc, &chunk); err != nil {
+				b.Fatalf("writeEvent error: %v", err)
+			}
+		}
+		bw.Reset(io.Discard)
+	}
+}
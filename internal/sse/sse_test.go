@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ferro-labs/ai-gateway/internal/logging"
 	"github.com/ferro-labs/ai-gateway/providers"
 )
 
@@ -32,6 +33,24 @@ func TestWrite_WritesDoneSentinel(t *testing.T) {
 	}
 }
 
+func TestWrite_FirstEventCarriesTraceID(t *testing.T) {
+	ch := make(chan providers.StreamChunk, 2)
+	ch <- providers.StreamChunk{ID: "stream-1"}
+	ch <- providers.StreamChunk{ID: "stream-1"}
+	close(ch)
+
+	w := httptest.NewRecorder()
+	Write(logging.WithTraceID(context.Background(), "0af7651916cd43dd8448eb211c80319c"), w, ch)
+
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "id: 0af7651916cd43dd8448eb211c80319c\ndata: {") {
+		t.Fatalf("first event should carry the trace ID, got: %s", body)
+	}
+	if strings.Count(body, "id: ") != 1 {
+		t.Fatalf("only the first event should carry an id, got: %s", body)
+	}
+}
+
 func TestWrite_StopsWhenContextCanceled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -0,0 +1,194 @@
+// Package stopseq enforces stop sequences on the gateway side, for providers
+// that cannot take them upstream or cap how many they accept.
+//
+// Output is cut at the first occurrence of any stop sequence, the sequence
+// itself excluded, and the choice's finish_reason becomes "stop" — what an
+// OpenAI model returns when it stops natively. In a stream the cut may fall
+// across chunk boundaries, so text that could still turn out to be the start
+// of a stop sequence is held back until the next chunk settles it.
+package stopseq
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// Matcher finds stop sequences in generated text. The zero value matches
+// nothing.
+type Matcher struct {
+	stops []string
+}
+
+// New returns a Matcher for stops, ignoring empty sequences.
+func New(stops []string) Matcher {
+	var m Matcher
+	for _, s := range stops {
+		if s != "" {
+			m.stops = append(m.stops, s)
+		}
+	}
+	return m
+}
+
+// Empty reports whether m has no stop sequences.
+func (m Matcher) Empty() bool { return len(m.stops) == 0 }
+
+// Index returns the byte offset of the earliest stop sequence in text, or -1.
+func (m Matcher) Index(text string) int {
+	first := -1
+	for _, s := range m.stops {
+		if i := strings.Index(text, s); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	return first
+}
+
+// partial returns the length of the longest suffix of text that is a proper
+// prefix of some stop sequence: the bytes that must be held back because the
+// next chunk could complete a match.
+func (m Matcher) partial(text string) int {
+	held := 0
+	for _, s := range m.stops {
+		for k := min(len(s)-1, len(text)); k > held; k-- {
+			if strings.HasSuffix(text, s[:k]) {
+				held = k
+				break
+			}
+		}
+	}
+	return held
+}
+
+// Truncate cuts each choice of resp at its first stop sequence and marks the
+// choice finished by "stop". Choices without a match are left untouched.
+func (m Matcher) Truncate(resp *providers.Response) {
+	if resp == nil || m.Empty() {
+		return
+	}
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		if at := m.Index(choice.Message.Content); at >= 0 {
+			choice.Message.Content = choice.Message.Content[:at]
+			choice.FinishReason = core.FinishReasonStop
+		}
+	}
+}
+
+// choiceState tracks one streamed choice.
+type choiceState struct {
+	pending string // held-back text that may begin a stop sequence
+	stopped bool
+}
+
+// Stream forwards src, truncating each choice at its first stop sequence.
+// Once a choice stops, its later content is discarded, but src is still read
+// to the end so the provider's final usage chunk reaches the caller and the
+// request is billed for what the model actually generated.
+func (m Matcher) Stream(ctx context.Context, src <-chan providers.StreamChunk) <-chan providers.StreamChunk {
+	out := make(chan providers.StreamChunk)
+	go func() {
+		defer close(out)
+		states := make(map[int]*choiceState)
+		var last providers.StreamChunk
+		for chunk := range src {
+			last = chunk
+			filtered, ok := m.filter(chunk, states)
+			if !ok {
+				continue
+			}
+			select {
+			case out <- filtered:
+			case <-ctx.Done():
+				// Keep draining so the provider's sender can finish and
+				// close its channel instead of blocking forever.
+				//nolint:revive // empty-block: consuming the remaining chunks IS the work
+				for range src {
+				}
+				return
+			}
+		}
+		// A stream that ended without a finish_reason may still hold text
+		// back; it never turned into a stop sequence, so it belongs to the
+		// output.
+		if flush, ok := flushPending(last, states); ok {
+			select {
+			case out <- flush:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out
+}
+
+// filter applies the matcher to one chunk. It reports false when nothing is
+// left worth sending.
+func (m Matcher) filter(chunk providers.StreamChunk, states map[int]*choiceState) (providers.StreamChunk, bool) {
+	if chunk.Error != nil || len(chunk.Choices) == 0 {
+		return chunk, true
+	}
+	choices := make([]providers.StreamChoice, 0, len(chunk.Choices))
+	for _, c := range chunk.Choices {
+		st := states[c.Index]
+		if st == nil {
+			st = &choiceState{}
+			states[c.Index] = st
+		}
+		if st.stopped {
+			continue
+		}
+		hadContent := c.Delta.Content != ""
+		text := st.pending + c.Delta.Content
+		st.pending = ""
+		switch at := m.Index(text); {
+		case at >= 0:
+			c.Delta.Content = text[:at]
+			c.FinishReason = core.FinishReasonStop
+			st.stopped = true
+		case c.FinishReason != "":
+			c.Delta.Content = text
+		default:
+			held := m.partial(text)
+			c.Delta.Content = text[:len(text)-held]
+			st.pending = text[len(text)-held:]
+		}
+		if hadContent && c.Delta.Content == "" && c.Delta.Role == "" && c.Delta.ReasoningContent == "" &&
+			len(c.Delta.ToolCalls) == 0 && c.FinishReason == "" {
+			continue // everything this choice carried is being held back
+		}
+		choices = append(choices, c)
+	}
+	if len(choices) == 0 && chunk.Usage == nil {
+		return chunk, false
+	}
+	chunk.Choices = choices
+	return chunk, true
+}
+
+// flushPending builds a chunk releasing the text still held back for choices
+// that neither stopped nor finished.
+func flushPending(last providers.StreamChunk, states map[int]*choiceState) (providers.StreamChunk, bool) {
+	var choices []providers.StreamChoice
+	for idx, st := range states {
+		if st.stopped || st.pending == "" {
+			continue
+		}
+		choices = append(choices, providers.StreamChoice{
+			Index: idx,
+			Delta: providers.MessageDelta{Content: st.pending},
+		})
+	}
+	if len(choices) == 0 {
+		return providers.StreamChunk{}, false
+	}
+	return providers.StreamChunk{
+		ID:      last.ID,
+		Object:  last.Object,
+		Created: last.Created,
+		Model:   last.Model,
+		Choices: choices,
+	}, true
+}
@@ -0,0 +1,147 @@
+package stopseq
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func feed(chunks ...providers.StreamChunk) <-chan providers.StreamChunk {
+	ch := make(chan providers.StreamChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func content(idx int, text string) providers.StreamChunk {
+	return providers.StreamChunk{ID: "c", Choices: []providers.StreamChoice{{
+		Index: idx,
+		Delta: providers.MessageDelta{Content: text},
+	}}}
+}
+
+// collect drains out and returns each choice's text and final finish reason.
+func collect(out <-chan providers.StreamChunk) (map[int]string, map[int]string, []providers.StreamChunk) {
+	text := make(map[int]*strings.Builder)
+	finish := make(map[int]string)
+	var chunks []providers.StreamChunk
+	for c := range out {
+		chunks = append(chunks, c)
+		for _, choice := range c.Choices {
+			if text[choice.Index] == nil {
+				text[choice.Index] = &strings.Builder{}
+			}
+			text[choice.Index].WriteString(choice.Delta.Content)
+			if choice.FinishReason != "" {
+				finish[choice.Index] = choice.FinishReason
+			}
+		}
+	}
+	out2 := make(map[int]string, len(text))
+	for i, b := range text {
+		out2[i] = b.String()
+	}
+	return out2, finish, chunks
+}
+
+func TestIndex(t *testing.T) {
+	m := New([]string{"END", "", "\n\n"})
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"no match", -1},
+		{"a END b", 2},
+		{"x\n\ny END", 1},
+	}
+	for _, tt := range tests {
+		if got := m.Index(tt.text); got != tt.want {
+			t.Errorf("Index(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+	if !New([]string{""}).Empty() {
+		t.Error("a matcher of empty sequences should be empty")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	resp := &providers.Response{Choices: []providers.Choice{
+		{Message: providers.Message{Content: "one. STOP two."}, FinishReason: "length"},
+		{Index: 1, Message: providers.Message{Content: "untouched"}, FinishReason: "length"},
+	}}
+	New([]string{"STOP"}).Truncate(resp)
+	if got := resp.Choices[0]; got.Message.Content != "one. " || got.FinishReason != "stop" {
+		t.Fatalf("choice 0 = %q/%q, want truncated with finish stop", got.Message.Content, got.FinishReason)
+	}
+	if got := resp.Choices[1]; got.Message.Content != "untouched" || got.FinishReason != "length" {
+		t.Fatalf("choice 1 changed: %+v", got)
+	}
+}
+
+func TestStream_MatchAcrossChunks(t *testing.T) {
+	m := New([]string{"<|end|>"})
+	usage := providers.StreamChunk{ID: "c", Usage: &providers.Usage{TotalTokens: 9}}
+	out := m.Stream(context.Background(), feed(
+		content(0, "hello <|e"),
+		content(0, "nd|> and more"),
+		content(0, " after"),
+		providers.StreamChunk{ID: "c", Choices: []providers.StreamChoice{{FinishReason: "length"}}},
+		usage,
+	))
+
+	text, finish, chunks := collect(out)
+	if text[0] != "hello " {
+		t.Fatalf("text = %q, want %q", text[0], "hello ")
+	}
+	if finish[0] != "stop" {
+		t.Fatalf("finish_reason = %q, want stop", finish[0])
+	}
+	if last := chunks[len(chunks)-1]; last.Usage == nil || last.Usage.TotalTokens != 9 {
+		t.Fatalf("usage chunk not forwarded after the stop: %+v", last)
+	}
+}
+
+func TestStream_HeldTextReleasedWhenNoMatch(t *testing.T) {
+	m := New([]string{"STOP"})
+	out := m.Stream(context.Background(), feed(
+		content(0, "keep ST"),
+		content(0, "ILL going S"),
+		providers.StreamChunk{ID: "c", Choices: []providers.StreamChoice{{FinishReason: "stop"}}},
+	))
+	text, finish, _ := collect(out)
+	if text[0] != "keep STILL going S" || finish[0] != "stop" {
+		t.Fatalf("text %q finish %q, want the full text released", text[0], finish[0])
+	}
+
+	// Without a finish_reason the held tail is flushed when the stream ends.
+	out = m.Stream(context.Background(), feed(content(0, "abc ST")))
+	text, _, _ = collect(out)
+	if text[0] != "abc ST" {
+		t.Fatalf("text = %q, want the held tail flushed at end of stream", text[0])
+	}
+}
+
+func TestStream_ChoicesStopIndependently(t *testing.T) {
+	m := New([]string{"."})
+	out := m.Stream(context.Background(), feed(
+		providers.StreamChunk{Choices: []providers.StreamChoice{
+			{Index: 0, Delta: providers.MessageDelta{Content: "first. rest"}},
+			{Index: 1, Delta: providers.MessageDelta{Content: "second"}},
+		}},
+		providers.StreamChunk{Choices: []providers.StreamChoice{
+			{Index: 0, Delta: providers.MessageDelta{Content: "dropped"}},
+			{Index: 1, Delta: providers.MessageDelta{Content: " too. x"}},
+		}},
+	))
+	text, finish, _ := collect(out)
+	if text[0] != "first" || text[1] != "second too" {
+		t.Fatalf("text = %q, want each choice cut at its own stop", text)
+	}
+	if finish[0] != "stop" || finish[1] != "stop" {
+		t.Fatalf("finish = %v, want stop for both", finish)
+	}
+}
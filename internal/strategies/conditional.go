@@ -12,6 +12,10 @@ type ConditionRule struct {
 	Key    string // "model", "model_prefix"
 	Value  string
 	Target Target
+	// Match, when set, must also hold for the rule to match; with an empty Key
+	// it is the whole condition. It receives the request context so it can
+	// read request metadata such as headers and the authenticated key.
+	Match func(ctx context.Context, req providers.Request) bool
 }
 
 // Conditional routes requests based on matching conditions.
@@ -48,7 +52,7 @@ func (c *Conditional) WithRoutingTargets(targets []Target) *Conditional {
 
 // Execute routes the request to the provider whose SupportedModels includes the requested model.
 func (c *Conditional) Execute(ctx context.Context, req providers.Request) (*providers.Response, error) {
-	target := c.matchTarget(req)
+	target := c.matchTarget(ctx, req)
 	return dispatch(ctx, c.lookup, target, req, "provider not found")
 }
 
@@ -56,9 +60,15 @@ func (c *Conditional) Execute(ctx context.Context, req providers.Request) (*prov
 // configured target as a fallback. With no match it returns the targets in
 // declared order (targets[0] is the fallback used by Execute).
 func (c *Conditional) SelectTargets(req providers.Request) ([]string, error) {
+	return c.SelectTargetsContext(context.Background(), req)
+}
+
+// SelectTargetsContext is SelectTargets with the request context, which rules
+// with a Match function need.
+func (c *Conditional) SelectTargetsContext(ctx context.Context, req providers.Request) ([]string, error) {
 	keys := make([]string, 0, len(c.targets))
 	for _, rule := range c.rules {
-		if c.matches(rule, req) {
+		if c.matches(ctx, rule, req) {
 			keys = appendUniqueKey(keys, rule.Target.VirtualKey)
 			break
 		}
@@ -66,17 +76,22 @@ func (c *Conditional) SelectTargets(req providers.Request) ([]string, error) {
 	return appendRemainingTargetKeys(keys, c.targets), nil
 }
 
-func (c *Conditional) matchTarget(req providers.Request) Target {
+func (c *Conditional) matchTarget(ctx context.Context, req providers.Request) Target {
 	for _, rule := range c.rules {
-		if c.matches(rule, req) {
+		if c.matches(ctx, rule, req) {
 			return rule.Target
 		}
 	}
 	return c.fallback
 }
 
-func (c *Conditional) matches(rule ConditionRule, req providers.Request) bool {
+func (c *Conditional) matches(ctx context.Context, rule ConditionRule, req providers.Request) bool {
+	if rule.Match != nil && !rule.Match(ctx, req) {
+		return false
+	}
 	switch rule.Key {
+	case "":
+		return rule.Match != nil
 	case "model":
 		return req.Model == rule.Value
 	case "model_prefix":
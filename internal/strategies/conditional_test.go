@@ -139,3 +139,80 @@ func TestConditional_NoRulesUsesFallback(t *testing.T) {
 		t.Errorf("expected fallback, got %s", resp.ID)
 	}
 }
+
+type ctxKey struct{}
+
+func TestConditional_MatchFuncSeesContext(t *testing.T) {
+	openai := &mockProvider{name: "openai", models: []string{"gpt-4o"}, resp: &providers.Response{ID: "openai-resp"}}
+	batch := &mockProvider{name: "batch", models: []string{"gpt-4o"}, resp: &providers.Response{ID: "batch-resp"}}
+
+	rules := []ConditionRule{{
+		Match: func(ctx context.Context, _ providers.Request) bool {
+			return ctx.Value(ctxKey{}) == "batch"
+		},
+		Target: Target{VirtualKey: "batch"},
+	}}
+	c := NewConditional(rules, Target{VirtualKey: "openai"}, newLookup(openai, batch)).
+		WithRoutingTargets([]Target{{VirtualKey: "openai"}, {VirtualKey: "batch"}})
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	batchCtx := context.WithValue(context.Background(), ctxKey{}, "batch")
+
+	resp, err := c.Execute(batchCtx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "batch-resp" {
+		t.Errorf("Execute with a matching context routed to %s", resp.ID)
+	}
+	resp, err = c.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "openai-resp" {
+		t.Errorf("Execute without a matching context routed to %s", resp.ID)
+	}
+
+	keys, err := SelectTargetsContext(batchCtx, c, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "batch" {
+		t.Errorf("SelectTargetsContext = %v, want batch first", keys)
+	}
+	keys, _ = c.SelectTargets(req)
+	if len(keys) != 2 || keys[0] != "openai" {
+		t.Errorf("SelectTargets = %v, want openai first", keys)
+	}
+}
+
+func TestConditional_MatchAndKeyMustBothHold(t *testing.T) {
+	openai := &mockProvider{name: "openai", models: []string{"gpt-4o", "gpt-4o-mini"}, resp: &providers.Response{ID: "openai-resp"}}
+	batch := &mockProvider{name: "batch", models: []string{"gpt-4o", "gpt-4o-mini"}, resp: &providers.Response{ID: "batch-resp"}}
+
+	open := true
+	rules := []ConditionRule{{
+		Key: "model", Value: "gpt-4o-mini",
+		Match:  func(context.Context, providers.Request) bool { return open },
+		Target: Target{VirtualKey: "batch"},
+	}}
+	c := NewConditional(rules, Target{VirtualKey: "openai"}, newLookup(openai, batch))
+
+	for _, tt := range []struct {
+		model string
+		open  bool
+		want  string
+	}{
+		{"gpt-4o-mini", true, "batch-resp"},
+		{"gpt-4o-mini", false, "openai-resp"},
+		{"gpt-4o", true, "openai-resp"},
+	} {
+		open = tt.open
+		resp, err := c.Execute(context.Background(), providers.Request{Model: tt.model, Messages: []providers.Message{{Role: "user", Content: "hi"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.ID != tt.want {
+			t.Errorf("model %s, match %v: routed to %s, want %s", tt.model, tt.open, resp.ID, tt.want)
+		}
+	}
+}
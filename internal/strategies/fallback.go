@@ -110,15 +110,16 @@ func defaultRetryableStatus(code int) bool {
 }
 
 // shouldRetry returns true if the error is eligible for another attempt against
-// the same target. Cancellation, deadline expiry, and open-circuit sentinels are
-// never retryable. With no configured onStatusCodes the default policy applies
+// the same target. Cancellation, deadline expiry, open-circuit, and spent
+// retry budget sentinels are never retryable. With no configured onStatusCodes the default policy applies
 // (transport errors plus 408/429/5xx); when onStatusCodes is set, only those
 // codes are retried. A transport error carries no status code and is always
 // retryable — it is exactly the transient case retries exist for.
 func shouldRetry(err error, onStatusCodes []int) bool {
 	if errors.Is(err, context.Canceled) ||
 		errors.Is(err, context.DeadlineExceeded) ||
-		errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		errors.Is(err, circuitbreaker.ErrCircuitOpen) ||
+		errors.Is(err, providers.ErrRetryBudgetExhausted) {
 		return false
 	}
 	code := providers.ParseStatusCode(err)
@@ -247,6 +248,12 @@ func (f *Fallback) Execute(ctx context.Context, req providers.Request) (*provide
 			attemptErr = err
 			lastErr = fmt.Errorf("provider %s attempt %d: %w", target.VirtualKey, attempt+1, err)
 
+			// A spent retry budget refuses every further attempt, on this
+			// target and the ones after it.
+			if errors.Is(err, providers.ErrRetryBudgetExhausted) {
+				return nil, fmt.Errorf("all providers failed: %w", lastErr)
+			}
+
 			// Stop retrying this target when the failure is not retryable.
 			if !shouldRetry(err, retry.onStatusCodes) {
 				logging.Logger.Debug("skipping retries for provider: error not retryable",
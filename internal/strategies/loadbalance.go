@@ -11,8 +11,14 @@ import (
 type LoadBalance struct {
 	targets []Target
 	lookup  ProviderLookup
+	scale   WeightScale
 }
 
+// WeightScale returns a multiplier in (0, 1] applied to a target's configured
+// weight at selection time, letting the caller shrink a target's share while
+// it is unhealthy without rebuilding the strategy.
+type WeightScale func(virtualKey string) float64
+
 // NewLoadBalance creates a new load balance strategy.
 func NewLoadBalance(targets []Target, lookup ProviderLookup) *LoadBalance {
 	return &LoadBalance{
@@ -21,6 +27,23 @@ func NewLoadBalance(targets []Target, lookup ProviderLookup) *LoadBalance {
 	}
 }
 
+// WithWeightScale sets the multiplier applied to each target's weight on every
+// selection. A nil scale leaves the configured weights unchanged.
+func (lb *LoadBalance) WithWeightScale(scale WeightScale) *LoadBalance {
+	lb.scale = scale
+	return lb
+}
+
+// weight is t's selection weight: its effective configured weight times the
+// current scale.
+func (lb *LoadBalance) weight(t Target) float64 {
+	w := effectiveWeight(t.Weight)
+	if lb.scale != nil {
+		w *= lb.scale(t.VirtualKey)
+	}
+	return w
+}
+
 // Execute selects a provider by weighted random selection and sends the request.
 // Only targets whose provider supports the requested model are considered.
 func (lb *LoadBalance) Execute(ctx context.Context, req providers.Request) (*providers.Response, error) {
@@ -60,9 +83,7 @@ func (lb *LoadBalance) compatibleTargets(model string) []Target {
 // selection. weightedPick draws from the top-level math/rand source, which is
 // safe for concurrent use, so no additional locking is required here.
 func (lb *LoadBalance) selectFromTargets(targets []Target) (Target, error) {
-	t, ok := weightedPick(targets, func(t Target) float64 {
-		return effectiveWeight(t.Weight)
-	})
+	t, ok := weightedPick(targets, lb.weight)
 	if !ok {
 		return Target{}, fmt.Errorf("no targets available")
 	}
@@ -79,7 +100,7 @@ func (lb *LoadBalance) SelectTargets(req providers.Request) ([]string, error) {
 	if len(compatible) == 0 {
 		return nil, nil
 	}
-	startIdx := weightedStartIndex(compatible)
+	startIdx := weightedStartIndex(compatible, lb.weight)
 	keys := make([]string, 0, len(compatible))
 	for i := 0; i < len(compatible); i++ {
 		keys = append(keys, compatible[(startIdx+i)%len(compatible)].VirtualKey)
@@ -166,3 +166,37 @@ func TestLoadBalance_UnresolvableSelectedTargetReturnsError(t *testing.T) {
 		t.Fatal("expected error when selected provider is no longer resolvable")
 	}
 }
+
+func TestLoadBalance_WeightScale(t *testing.T) {
+	ma := &mockProvider{name: "a", models: []string{"gpt-4o"}, resp: &providers.Response{ID: "a"}}
+	mb := &mockProvider{name: "b", models: []string{"gpt-4o"}, resp: &providers.Response{ID: "b"}}
+
+	// Equal weights, but "a" is scaled to 1/16 of its share.
+	lb := NewLoadBalance(
+		[]Target{{VirtualKey: "a"}, {VirtualKey: "b"}},
+		newLookup(ma, mb),
+	).WithWeightScale(func(key string) float64 {
+		if key == "a" {
+			return 1.0 / 16
+		}
+		return 1
+	})
+
+	req := providers.Request{Model: "gpt-4o", Messages: []providers.Message{{Role: "user", Content: "hi"}}}
+	first := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		if _, err := lb.Execute(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+		keys, _ := lb.SelectTargets(req)
+		first[keys[0]]++
+	}
+
+	// Expected share for "a" is 1/17 (~59 of 1000) on both paths.
+	if ma.calls == 0 || ma.calls > 150 {
+		t.Errorf("Execute sent %d of 1000 calls to the scaled-down target, want ~59", ma.calls)
+	}
+	if first["a"] == 0 || first["a"] > 150 {
+		t.Errorf("SelectTargets put the scaled-down target first %d of 1000 times, want ~59", first["a"])
+	}
+}
@@ -31,6 +31,21 @@ type Strategy interface {
 	SelectTargets(req providers.Request) ([]string, error)
 }
 
+// ContextSelector is implemented by strategies whose target order depends on
+// the request context as well as the request.
+type ContextSelector interface {
+	SelectTargetsContext(ctx context.Context, req providers.Request) ([]string, error)
+}
+
+// SelectTargetsContext returns s's target order for req, passing ctx to
+// strategies that implement ContextSelector.
+func SelectTargetsContext(ctx context.Context, s Strategy, req providers.Request) ([]string, error) {
+	if cs, ok := s.(ContextSelector); ok {
+		return cs.SelectTargetsContext(ctx, req)
+	}
+	return s.SelectTargets(req)
+}
+
 // ProviderLookup resolves a provider name to a Provider instance.
 type ProviderLookup func(name string) (providers.Provider, bool)
 
@@ -45,16 +45,16 @@ func appendRemainingTargetKeys(keys []string, targets []Target) []string {
 }
 
 // weightedStartIndex picks a starting index into targets by weighted random
-// selection (zero/negative weight counts as 1). The load-balance ordering
-// rotates the target list from this index so the first attempted target is
-// weight-biased while the rest remain available as fallbacks.
-func weightedStartIndex(targets []Target) int {
+// selection using weight. The load-balance ordering rotates the target list
+// from this index so the first attempted target is weight-biased while the
+// rest remain available as fallbacks.
+func weightedStartIndex(targets []Target, weight func(Target) float64) int {
 	if len(targets) == 0 {
 		return 0
 	}
 	totalWeight := 0.0
 	for _, t := range targets {
-		totalWeight += effectiveWeight(t.Weight)
+		totalWeight += weight(t)
 	}
 	if totalWeight <= 0 {
 		return 0
@@ -62,7 +62,7 @@ func weightedStartIndex(targets []Target) int {
 	r := rand.Float64() * totalWeight //nolint:gosec // G404: math/rand is fine for load-balancing weight selection, not security-sensitive
 	cumulative := 0.0
 	for i, t := range targets {
-		cumulative += effectiveWeight(t.Weight)
+		cumulative += weight(t)
 		if r < cumulative {
 			return i
 		}
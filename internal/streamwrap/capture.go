@@ -0,0 +1,162 @@
+package streamwrap
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// DefaultMaxCaptureBytes is the capture limit Meter applies when
+// MeterMeta.MaxCaptureBytes is 0: 1 MiB of text, far more than any
+// realistic completion.
+const DefaultMaxCaptureBytes = 1 << 20
+
+// capture assembles the Response a stream adds up to, for the after-request
+// plugins (request logs, cache), usage estimates, and events, as Meter
+// forwards the stream's chunks. It holds each choice's text in one builder
+// per field, so the stream is buffered once and appending stays linear, and
+// keeps at most limit bytes of content, reasoning, and tool-call arguments
+// in all; text past the limit is dropped and the capture marked truncated.
+// The chunks sent to the client are never affected.
+type capture struct {
+	resp      providers.Response
+	choices   []*choiceCapture
+	limit     int
+	size      int
+	truncated bool
+}
+
+type choiceCapture struct {
+	role         string
+	content      strings.Builder
+	reasoning    strings.Builder
+	calls        []*toolCallCapture
+	finishReason string
+}
+
+type toolCallCapture struct {
+	call providers.ToolCall
+	args strings.Builder
+}
+
+// newCapture returns a capture of a stream served by provider for model.
+// limit <= 0 keeps everything.
+func newCapture(provider, model string, limit int) *capture {
+	return &capture{
+		resp: providers.Response{
+			Object:   "chat.completion",
+			Provider: provider,
+			Model:    model,
+		},
+		limit: limit,
+	}
+}
+
+// add folds one chunk into the capture. Tool-call deltas are merged by their
+// index into whole calls, as the client reassembles them.
+func (c *capture) add(chunk providers.StreamChunk) {
+	if chunk.ID != "" && c.resp.ID == "" {
+		c.resp.ID = chunk.ID
+	}
+	if chunk.Created != 0 && c.resp.Created == 0 {
+		c.resp.Created = chunk.Created
+	}
+	if chunk.Model != "" {
+		c.resp.Model = chunk.Model
+	}
+	if chunk.SystemFingerprint != "" {
+		c.resp.SystemFingerprint = chunk.SystemFingerprint
+	}
+	for _, sc := range chunk.Choices {
+		if sc.Index < 0 {
+			continue
+		}
+		for len(c.choices) <= sc.Index {
+			c.choices = append(c.choices, &choiceCapture{role: "assistant"})
+		}
+		choice := c.choices[sc.Index]
+		if sc.Delta.Role != "" {
+			choice.role = sc.Delta.Role
+		}
+		c.write(&choice.content, sc.Delta.Content)
+		c.write(&choice.reasoning, sc.Delta.ReasoningContent)
+		for _, delta := range sc.Delta.ToolCalls {
+			c.addToolCall(choice, delta)
+		}
+		if sc.FinishReason != "" {
+			choice.finishReason = sc.FinishReason
+		}
+	}
+}
+
+// addToolCall folds one tool-call delta into choice: a delta continues the
+// call with the same index, and one without an index starts a new call.
+func (c *capture) addToolCall(choice *choiceCapture, delta providers.ToolCall) {
+	var tc *toolCallCapture
+	if delta.Index != nil {
+		for _, existing := range choice.calls {
+			if existing.call.Index != nil && *existing.call.Index == *delta.Index {
+				tc = existing
+				break
+			}
+		}
+	}
+	if tc == nil {
+		tc = &toolCallCapture{call: delta}
+		tc.call.Function.Arguments = ""
+		choice.calls = append(choice.calls, tc)
+	} else {
+		if delta.ID != "" {
+			tc.call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			tc.call.Type = delta.Type
+		}
+		tc.call.Function.Name += delta.Function.Name
+	}
+	c.write(&tc.args, delta.Function.Arguments)
+}
+
+// write appends s to b within the capture limit, cutting it at a rune
+// boundary when it does not fit. Once the capture is truncated nothing more
+// is kept, so the captured text never skips ahead.
+func (c *capture) write(b *strings.Builder, s string) {
+	if s == "" || c.truncated {
+		return
+	}
+	if c.limit > 0 {
+		room := c.limit - c.size
+		if len(s) > room {
+			c.truncated = true
+			for room > 0 && !utf8.RuneStart(s[room]) {
+				room--
+			}
+			if room <= 0 {
+				return
+			}
+			s = s[:room]
+		}
+	}
+	b.WriteString(s)
+	c.size += len(s)
+}
+
+// response returns the assembled response.
+func (c *capture) response() providers.Response {
+	resp := c.resp
+	for i, choice := range c.choices {
+		msg := providers.Message{
+			Role:             choice.role,
+			Content:          choice.content.String(),
+			ReasoningContent: choice.reasoning.String(),
+		}
+		for _, tc := range choice.calls {
+			call := tc.call
+			call.Function.Arguments = tc.args.String()
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		resp.Choices = append(resp.Choices, providers.Choice{Index: i, Message: msg, FinishReason: choice.finishReason})
+	}
+	return resp
+}
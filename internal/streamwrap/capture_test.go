@@ -0,0 +1,91 @@
+package streamwrap
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestCapture_MergesToolCallDeltas(t *testing.T) {
+	c := newCapture("openai", "gpt-4o", 0)
+	idx0, idx1 := 0, 1
+	for _, chunk := range []providers.StreamChunk{
+		{ID: "a", Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{ToolCalls: []providers.ToolCall{
+			{Index: &idx0, ID: "call_1", Type: "function", Function: providers.FunctionCall{Name: "lookup", Arguments: `{"ci`}},
+		}}}}},
+		{Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{ToolCalls: []providers.ToolCall{
+			{Index: &idx1, ID: "call_2", Type: "function", Function: providers.FunctionCall{Name: "time"}},
+			{Index: &idx0, Function: providers.FunctionCall{Arguments: `ty":"Oslo"}`}},
+		}}}}},
+		{Choices: []providers.StreamChoice{{FinishReason: "tool_calls"}}},
+	} {
+		c.add(chunk)
+	}
+
+	resp := c.response()
+	calls := resp.Choices[0].Message.ToolCalls
+	if resp.ID != "a" || len(calls) != 2 || resp.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("response = %+v", resp)
+	}
+	if calls[0].ID != "call_1" || calls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Errorf("first call = %+v, want its arguments merged", calls[0])
+	}
+	if calls[1].ID != "call_2" || calls[1].Function.Name != "time" {
+		t.Errorf("second call = %+v", calls[1])
+	}
+}
+
+func TestCapture_Limit(t *testing.T) {
+	c := newCapture("openai", "gpt-4o", 8)
+	c.add(providers.StreamChunk{Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: "hello "}}}})
+	c.add(providers.StreamChunk{Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: "wörld!"}}}})
+	c.add(providers.StreamChunk{Choices: []providers.StreamChoice{{Delta: providers.MessageDelta{Content: "more"}}}})
+
+	// The two-byte "ö" straddles the limit, so the cut falls before it.
+	if got := c.response().Choices[0].Message.Content; got != "hello w" || !c.truncated {
+		t.Fatalf("content = %q, truncated = %v; want %q", got, c.truncated, "hello w")
+	}
+}
+
+// TestMeter_CaptureLimitLeavesClientStreamWhole verifies the capture limit
+// bounds only the response the after-request stage sees.
+func TestMeter_CaptureLimitLeavesClientStreamWhole(t *testing.T) {
+	text := strings.Repeat("x", 100)
+	var chunks []providers.StreamChunk
+	for range 5 {
+		chunks = append(chunks, providers.StreamChunk{ID: "1", Choices: []providers.StreamChoice{{
+			Delta: providers.MessageDelta{Content: text},
+		}}})
+	}
+
+	var seen *providers.Response
+	var seenTruncated bool
+	out := Meter(context.Background(), feed(chunks...), time.Now(), MeterMeta{
+		Provider:        "openai",
+		Model:           "gpt-4o",
+		MetricModel:     "gpt-4o",
+		Catalog:         models.Catalog{},
+		MaxCaptureBytes: 250,
+		CompletionFn: func(_ context.Context, resp *providers.Response, truncated bool) error {
+			seen, seenTruncated = resp, truncated
+			return nil
+		},
+	})
+
+	var forwarded strings.Builder
+	for c := range out {
+		for _, ch := range c.Choices {
+			forwarded.WriteString(ch.Delta.Content)
+		}
+	}
+	if forwarded.Len() != 500 {
+		t.Fatalf("client received %d bytes, want all 500", forwarded.Len())
+	}
+	if seen == nil || len(seen.Choices[0].Message.Content) != 250 || !seenTruncated {
+		t.Fatalf("after-request stage saw %+v (truncated %v), want 250 bytes marked truncated", seen, seenTruncated)
+	}
+}
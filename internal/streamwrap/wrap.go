@@ -27,11 +27,13 @@ package streamwrap
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/ferro-labs/ai-gateway/internal/circuitbreaker"
 	"github.com/ferro-labs/ai-gateway/internal/events"
 	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/ferro-labs/ai-gateway/internal/tokens"
 	"github.com/ferro-labs/ai-gateway/models"
 	"github.com/ferro-labs/ai-gateway/providers"
 )
@@ -72,8 +74,10 @@ type MeterMeta struct {
 	// stays decoupled from the public observability package.
 	SpanFinisher SpanFinisher
 	// CompletionFn, if non-nil, is invoked once after the upstream stream closes
-	// successfully and before success metrics/events are emitted.
-	CompletionFn func(ctx context.Context, resp *providers.Response) error
+	// successfully and before success metrics/events are emitted, with the
+	// response the stream added up to. truncated reports that the response's
+	// text was cut at MaxCaptureBytes.
+	CompletionFn func(ctx context.Context, resp *providers.Response, truncated bool) error
 	// ErrorFn, if non-nil, is invoked once when the upstream stream fails or
 	// the downstream client cancels before the stream completes.
 	ErrorFn func(ctx context.Context, err error)
@@ -94,6 +98,32 @@ type MeterMeta struct {
 	// every caller that predates this field. Callers should set it to
 	// `req.ClientStreamOptions != nil && !req.ClientStreamOptions.IncludeUsage`.
 	SuppressUsageForClient bool
+	// IncludeUsageForClient, when true, means the client asked for the usage
+	// chunk (stream_options.include_usage=true). If the stream completes
+	// without having forwarded any usage, Meter sends one final usage-only
+	// chunk — choices empty, as OpenAI sends it — before closing out.
+	IncludeUsageForClient bool
+	// PromptTokensEstimate is the estimated prompt size. When a stream
+	// completes without the provider reporting usage, Meter estimates it from
+	// this and the streamed completion, marked Estimated, for both accounting
+	// and the client's usage chunk.
+	PromptTokensEstimate int
+	// StripReasoningForClient, when true, clears ReasoningContent on the
+	// copy of each chunk forwarded to out, and skips a chunk entirely when
+	// reasoning was all it carried. Like SuppressUsageForClient it never
+	// affects the response CompletionFn and PublishFn see.
+	StripReasoningForClient bool
+	// ModelForClient, when set, replaces Model on the copy of each chunk
+	// forwarded to out, such as with the alias the client asked for. Like
+	// StripReasoningForClient it never affects the response CompletionFn and
+	// PublishFn see.
+	ModelForClient string
+	// MaxCaptureBytes bounds the text Meter keeps to assemble the response
+	// CompletionFn and PublishFn see: content, reasoning, and tool-call
+	// arguments past it are dropped from that response, never from the
+	// chunks forwarded to out. 0 means DefaultMaxCaptureBytes; a negative
+	// value keeps everything.
+	MaxCaptureBytes int
 }
 
 // metricLabelModel returns the bounded Prometheus label for this request.
@@ -153,6 +183,10 @@ func (f SpanFinisherFunc) Finish(o StreamOutcome) { f(o) }
 // provider stream directly and stopping early would deadlock that provider
 // goroutine.
 //
+// Each chunk is also folded into the response the stream adds up to, for
+// CompletionFn and the completion event, once it has been forwarded, so the
+// assembly never delays the client; MaxCaptureBytes bounds its memory.
+//
 // start should be the time.Now() captured immediately before the upstream
 // CompleteStream call so that latency includes provider connection time.
 func Meter(ctx context.Context, src <-chan providers.StreamChunk, start time.Time, meta MeterMeta) <-chan providers.StreamChunk {
@@ -162,15 +196,16 @@ func Meter(ctx context.Context, src <-chan providers.StreamChunk, start time.Tim
 		defer close(out)
 
 		var usage providers.Usage
+		var usageForwarded bool
 		var streamErr error
 		var firstChunkAt time.Time
 		var lastChunkAt time.Time
 		clientCanceled := false
-		resp := providers.Response{
-			Object:   "chat.completion",
-			Provider: meta.Provider,
-			Model:    meta.Model,
+		limit := meta.MaxCaptureBytes
+		if limit == 0 {
+			limit = DefaultMaxCaptureBytes
 		}
+		captured := newCapture(meta.Provider, meta.Model, limit)
 
 	loop:
 		for {
@@ -201,7 +236,6 @@ func Meter(ctx context.Context, src <-chan providers.StreamChunk, start time.Tim
 				if chunk.Usage != nil && (chunk.Usage.TotalTokens > 0 || chunk.Usage.PromptTokens > 0) {
 					usage = *chunk.Usage
 				}
-				applyChunkToResponse(&resp, chunk)
 				if chunk.Error != nil {
 					streamErr = chunk.Error
 				}
@@ -217,10 +251,27 @@ func Meter(ctx context.Context, src <-chan providers.StreamChunk, start time.Tim
 				if meta.SuppressUsageForClient && forward.Usage != nil {
 					forward.Usage = nil
 				}
-				select {
-				case out <- forward:
-				case <-ctx.Done():
-					clientCanceled = true
+				keep := true
+				if meta.StripReasoningForClient {
+					forward, keep = stripReasoning(forward)
+				}
+				if meta.ModelForClient != "" && forward.Model != "" {
+					forward.Model = meta.ModelForClient
+				}
+				if keep {
+					if forward.Usage != nil && forward.Usage.TotalTokens+forward.Usage.PromptTokens+forward.Usage.CompletionTokens > 0 {
+						usageForwarded = true
+					}
+					select {
+					case out <- forward:
+					case <-ctx.Done():
+						clientCanceled = true
+					}
+				}
+				// The chunk is captured only once the client has it, so
+				// assembling the response never delays delivery.
+				captured.add(chunk)
+				if clientCanceled {
 					streamErr = drainSrc(ctx, src, streamErr)
 					break loop
 				}
@@ -253,13 +304,23 @@ func Meter(ctx context.Context, src <-chan providers.StreamChunk, start time.Tim
 			meta.LatencyRecorder(meta.Provider, latency)
 		}
 
-		resp.Usage = usage
-		if resp.Usage.TotalTokens == 0 {
-			resp.Usage.TotalTokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+		resp := captured.response()
+		if usage.TotalTokens == 0 {
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
+		if tokens.Missing(usage) {
+			usage = tokens.Estimate(meta.PromptTokensEstimate, &resp)
 		}
-		if handleCompletionFn(ctx, meta, usage, ttftMs, ttltMs, &resp, out) {
+		if usage.ReasoningTokens == 0 {
+			usage.ReasoningTokens = tokens.Reasoning(&resp, usage.CompletionTokens)
+		}
+		resp.Usage = usage
+		if handleCompletionFn(ctx, meta, usage, ttftMs, ttltMs, &resp, captured.truncated, out) {
 			return
 		}
+		if meta.IncludeUsageForClient && !usageForwarded {
+			sendUsageChunk(ctx, &resp, meta.ModelForClient, out)
+		}
 
 		// Success path: emit the same metrics as Gateway.Route().
 		finishStreamOnSuccess(ctx, meta, usage, ttftMs, ttltMs, latency)
@@ -346,12 +407,13 @@ func handleCompletionFn(
 	usage providers.Usage,
 	ttftMs, ttltMs float64,
 	resp *providers.Response,
+	truncated bool,
 	out chan<- providers.StreamChunk,
 ) bool {
 	if meta.CompletionFn == nil {
 		return false
 	}
-	err := meta.CompletionFn(ctx, resp)
+	err := meta.CompletionFn(ctx, resp, truncated)
 	if err == nil {
 		return false
 	}
@@ -378,6 +440,28 @@ func handleCompletionFn(
 	return true
 }
 
+// sendUsageChunk sends the client the final usage chunk the provider's stream
+// lacked, reporting model when it is set.
+func sendUsageChunk(ctx context.Context, resp *providers.Response, model string, out chan<- providers.StreamChunk) {
+	usage := resp.Usage
+	if model == "" {
+		model = resp.Model
+	}
+	select {
+	case out <- providers.StreamChunk{
+		ID:      resp.ID,
+		Object:  "chat.completion.chunk",
+		Created: resp.Created,
+		Model:   model,
+		Choices: []providers.StreamChoice{},
+		Usage:   &usage,
+
+		SystemFingerprint: resp.SystemFingerprint,
+	}:
+	case <-ctx.Done():
+	}
+}
+
 // finishStreamOnSuccess emits success metrics, publishes the completion event,
 // finalises the observability span, and records a successful circuit-breaker
 // outcome. It mirrors what Gateway.Route() does for non-streaming requests.
@@ -439,39 +523,21 @@ func finishStreamOnSuccess(
 	}
 }
 
-func applyChunkToResponse(resp *providers.Response, chunk providers.StreamChunk) {
-	if chunk.ID != "" && resp.ID == "" {
-		resp.ID = chunk.ID
-	}
-	if chunk.Created != 0 && resp.Created == 0 {
-		resp.Created = chunk.Created
+// stripReasoning returns chunk with ReasoningContent cleared on a copy of its
+// choices, and whether anything is left worth forwarding. A chunk that carried
+// only reasoning deltas is not.
+func stripReasoning(chunk providers.StreamChunk) (providers.StreamChunk, bool) {
+	if !slices.ContainsFunc(chunk.Choices, func(c providers.StreamChoice) bool { return c.Delta.ReasoningContent != "" }) {
+		return chunk, true
 	}
-	if chunk.Model != "" {
-		resp.Model = chunk.Model
-	}
-	for _, streamChoice := range chunk.Choices {
-		idx := streamChoice.Index
-		if idx < 0 {
-			continue
-		}
-		for len(resp.Choices) <= idx {
-			resp.Choices = append(resp.Choices, providers.Choice{
-				Index: len(resp.Choices),
-				Message: providers.Message{
-					Role: "assistant",
-				},
-			})
-		}
-		choice := &resp.Choices[idx]
-		if streamChoice.Delta.Role != "" {
-			choice.Message.Role = streamChoice.Delta.Role
-		}
-		choice.Message.Content += streamChoice.Delta.Content
-		if len(streamChoice.Delta.ToolCalls) > 0 {
-			choice.Message.ToolCalls = append(choice.Message.ToolCalls, streamChoice.Delta.ToolCalls...)
-		}
-		if streamChoice.FinishReason != "" {
-			choice.FinishReason = streamChoice.FinishReason
+	chunk.Choices = slices.Clone(chunk.Choices)
+	keep := chunk.Usage != nil || chunk.Error != nil
+	for i := range chunk.Choices {
+		c := &chunk.Choices[i]
+		c.Delta.ReasoningContent = ""
+		if c.Delta.Role != "" || c.Delta.Content != "" || len(c.Delta.ToolCalls) > 0 || c.FinishReason != "" {
+			keep = true
 		}
 	}
+	return chunk, keep
 }
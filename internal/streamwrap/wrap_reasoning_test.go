@@ -0,0 +1,100 @@
+package streamwrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/models"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// TestMeter_StripReasoningForClient verifies reasoning deltas are accumulated
+// for the after-request stage but kept from the client, and that a chunk
+// carrying nothing but reasoning is not forwarded at all.
+func TestMeter_StripReasoningForClient(t *testing.T) {
+	src := feed(
+		providers.StreamChunk{ID: "1", Choices: []providers.StreamChoice{{
+			Delta: providers.MessageDelta{ReasoningContent: "thinking "},
+		}}},
+		providers.StreamChunk{ID: "2", Choices: []providers.StreamChoice{{
+			Delta: providers.MessageDelta{ReasoningContent: "hard", Content: "42"},
+		}}},
+		providers.StreamChunk{ID: "3", Choices: []providers.StreamChoice{{
+			FinishReason: "stop",
+		}}},
+	)
+
+	var seen *providers.Response
+	out := Meter(context.Background(), src, time.Now(), MeterMeta{
+		Provider:                "deepseek",
+		Model:                   "deepseek-reasoner",
+		MetricModel:             "deepseek-reasoner",
+		Catalog:                 models.Catalog{},
+		StripReasoningForClient: true,
+		CompletionFn: func(_ context.Context, resp *providers.Response, _ bool) error {
+			seen = resp
+			return nil
+		},
+	})
+
+	var forwarded []providers.StreamChunk
+	for c := range out {
+		forwarded = append(forwarded, c)
+	}
+
+	if len(forwarded) != 2 || forwarded[0].ID != "2" {
+		t.Fatalf("forwarded %+v, want chunks 2 and 3 only", forwarded)
+	}
+	if d := forwarded[0].Choices[0].Delta; d.ReasoningContent != "" || d.Content != "42" {
+		t.Fatalf("forwarded delta = %+v, want content without reasoning", d)
+	}
+	if seen == nil || seen.Choices[0].Message.ReasoningContent != "thinking hard" {
+		t.Fatalf("after-request stage saw %+v, want accumulated reasoning", seen)
+	}
+}
+
+// TestMeter_ModelForClient verifies forwarded chunks, including the usage
+// chunk Meter synthesizes, report ModelForClient while the after-request
+// stage sees the provider's model.
+func TestMeter_ModelForClient(t *testing.T) {
+	src := feed(
+		providers.StreamChunk{ID: "1", Model: "gpt-4o-mini-2024-07-18", Choices: []providers.StreamChoice{{
+			Delta: providers.MessageDelta{Content: "42"},
+		}}},
+		providers.StreamChunk{ID: "1", Model: "gpt-4o-mini-2024-07-18", Choices: []providers.StreamChoice{{
+			FinishReason: "stop",
+		}}},
+	)
+
+	var seen *providers.Response
+	out := Meter(context.Background(), src, time.Now(), MeterMeta{
+		Provider:              "openai",
+		Model:                 "gpt-4o-mini",
+		MetricModel:           "gpt-4o-mini",
+		Catalog:               models.Catalog{},
+		IncludeUsageForClient: true,
+		ModelForClient:        "fast",
+		CompletionFn: func(_ context.Context, resp *providers.Response, _ bool) error {
+			seen = resp
+			return nil
+		},
+	})
+
+	var forwarded []providers.StreamChunk
+	for c := range out {
+		forwarded = append(forwarded, c)
+	}
+
+	if len(forwarded) != 3 || forwarded[2].Usage == nil {
+		t.Fatalf("forwarded %+v, want two chunks and a usage chunk", forwarded)
+	}
+	for _, c := range forwarded {
+		if c.Model != "fast" {
+			t.Errorf("forwarded chunk model = %q, want fast", c.Model)
+		}
+	}
+	if seen == nil || seen.Model != "gpt-4o-mini-2024-07-18" {
+		t.Fatalf("after-request stage saw %+v, want the provider's model", seen)
+	}
+}
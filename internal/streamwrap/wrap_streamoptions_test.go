@@ -39,7 +39,7 @@ func TestMeter_SuppressUsageForClient_AccountingSeesRealUsage(t *testing.T) {
 		MetricModel:            "gpt-4o",
 		Catalog:                models.Catalog{},
 		SuppressUsageForClient: true, // client sent include_usage:false
-		CompletionFn: func(_ context.Context, resp *providers.Response) error {
+		CompletionFn: func(_ context.Context, resp *providers.Response, _ bool) error {
 			completionFnCalled = true
 			pluginSawUsage = resp.Usage
 			return nil
@@ -143,3 +143,106 @@ func TestMeter_SuppressUsageForClient_ZeroValueKeepsUsage(t *testing.T) {
 		t.Errorf("TotalTokens = %d, want 4", forwarded[0].Usage.TotalTokens)
 	}
 }
+
+// TestMeter_IncludeUsageForClient_EstimatesMissingUsage covers a provider
+// that streams no usage: a client that asked for include_usage still gets a
+// final usage-only chunk, estimated from the prompt and the streamed text, and
+// accounting sees the same estimate.
+func TestMeter_IncludeUsageForClient_EstimatesMissingUsage(t *testing.T) {
+	src := feed(
+		providers.StreamChunk{ID: "c1", Model: "m", Choices: []providers.StreamChoice{{
+			Delta: providers.MessageDelta{Content: "hello world"},
+		}}},
+		providers.StreamChunk{ID: "c1", Choices: []providers.StreamChoice{{FinishReason: "stop"}}},
+	)
+
+	var pluginSawUsage providers.Usage
+	out := Meter(context.Background(), src, time.Now(), MeterMeta{
+		Provider:              "ollama",
+		Model:                 "m",
+		MetricModel:           "m",
+		Catalog:               models.Catalog{},
+		IncludeUsageForClient: true,
+		PromptTokensEstimate:  20,
+		CompletionFn: func(_ context.Context, resp *providers.Response, _ bool) error {
+			pluginSawUsage = resp.Usage
+			return nil
+		},
+	})
+
+	var forwarded []providers.StreamChunk
+	for c := range out {
+		forwarded = append(forwarded, c)
+	}
+
+	if len(forwarded) != 3 {
+		t.Fatalf("forwarded %d chunks, want 2 and a usage chunk", len(forwarded))
+	}
+	last := forwarded[2]
+	if last.Usage == nil || last.Choices == nil || len(last.Choices) != 0 {
+		t.Fatalf("last chunk = %+v, want usage with an empty choices array", last)
+	}
+	if last.ID != "c1" || last.Model != "m" {
+		t.Errorf("usage chunk id/model = %q/%q, want c1/m", last.ID, last.Model)
+	}
+	if u := *last.Usage; u.PromptTokens != 20 || u.CompletionTokens != 4 || u.TotalTokens != 24 || !u.Estimated {
+		t.Errorf("usage = %+v, want {Prompt:20 Completion:4 Total:24 Estimated:true}", u)
+	}
+	if pluginSawUsage != *last.Usage {
+		t.Errorf("plugin stage saw usage %+v, want the estimate the client got", pluginSawUsage)
+	}
+}
+
+// TestMeter_IncludeUsageForClient_NoDuplicate verifies that a provider's own
+// usage chunk is forwarded as-is and not followed by a second one.
+func TestMeter_IncludeUsageForClient_NoDuplicate(t *testing.T) {
+	src := feed(
+		providers.StreamChunk{ID: "1", Choices: []providers.StreamChoice{{
+			Delta: providers.MessageDelta{Content: "hi"},
+		}}},
+		providers.StreamChunk{ID: "1", Usage: &providers.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}},
+	)
+
+	out := Meter(context.Background(), src, time.Now(), MeterMeta{
+		Provider:              "openai",
+		Model:                 "gpt-4o",
+		MetricModel:           "gpt-4o",
+		Catalog:               models.Catalog{},
+		IncludeUsageForClient: true,
+		PromptTokensEstimate:  99,
+	})
+
+	var withUsage int
+	for c := range out {
+		if c.Usage != nil {
+			withUsage++
+			if c.Usage.TotalTokens != 6 {
+				t.Errorf("usage = %+v, want the provider's", c.Usage)
+			}
+		}
+	}
+	if withUsage != 1 {
+		t.Fatalf("got %d usage chunks, want exactly 1", withUsage)
+	}
+}
+
+// TestMeter_IncludeUsageForClient_Unset verifies no usage chunk is added for
+// a client that did not ask for one.
+func TestMeter_IncludeUsageForClient_Unset(t *testing.T) {
+	src := feed(providers.StreamChunk{ID: "1", Choices: []providers.StreamChoice{{
+		Delta: providers.MessageDelta{Content: "hi"},
+	}}})
+
+	out := Meter(context.Background(), src, time.Now(), MeterMeta{
+		Provider:    "ollama",
+		Model:       "m",
+		MetricModel: "m",
+		Catalog:     models.Catalog{},
+	})
+
+	for c := range out {
+		if c.Usage != nil {
+			t.Fatalf("unexpected usage chunk %+v", c)
+		}
+	}
+}
@@ -337,7 +337,7 @@ func TestMeter_CallsCircuitBreakerOutcome_OnAfterPluginError(t *testing.T) {
 		Model:       "gpt-4o",
 		MetricModel: "gpt-4o",
 		Catalog:     models.Catalog{},
-		CompletionFn: func(context.Context, *providers.Response) error {
+		CompletionFn: func(context.Context, *providers.Response, bool) error {
 			return pluginErr
 		},
 		CircuitBreakerOutcome: func(err error) {
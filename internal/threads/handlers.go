@@ -0,0 +1,293 @@
+package threads
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/internal/openaiapi"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// Handlers serves the threads API over a gateway and a store.
+type Handlers struct {
+	Gateway Completer
+	Store   Store
+}
+
+// Routes returns the threads endpoints, to be mounted at /v1/threads.
+func (h *Handlers) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/", h.createThread)
+	r.Get("/{thread_id}", h.getThread)
+	r.Delete("/{thread_id}", h.deleteThread)
+	r.Post("/{thread_id}/messages", h.createMessage)
+	r.Get("/{thread_id}/messages", h.listMessages)
+	r.Post("/{thread_id}/runs", h.createRun)
+	r.Get("/{thread_id}/runs", h.listRuns)
+	r.Get("/{thread_id}/runs/{run_id}", h.getRun)
+	return r
+}
+
+// owner is the API key the request authenticated with; threads are visible
+// only to the key that created them.
+func owner(r *http.Request) string {
+	id, _ := authctx.KeyID(r.Context())
+	return id
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeInvalid(w http.ResponseWriter, msg string) {
+	apierror.WriteOpenAI(w, http.StatusBadRequest, msg, "invalid_request_error", "invalid_request")
+}
+
+func writeNotFound(w http.ResponseWriter, what string) {
+	apierror.WriteOpenAI(w, http.StatusNotFound, what+" not found", "invalid_request_error", "not_found")
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrThreadNotFound):
+		writeNotFound(w, "thread")
+	case errors.Is(err, ErrRunActive), errors.Is(err, ErrLimitExceeded):
+		writeInvalid(w, err.Error())
+	default:
+		apierror.WriteOpenAI(w, http.StatusInternalServerError, err.Error(), "server_error", "internal_error")
+	}
+}
+
+func (h *Handlers) createThread(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Messages []messageInput    `json:"messages"`
+		Metadata map[string]string `json:"metadata"`
+	}
+	// An empty body creates an empty thread.
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			openaiapi.WriteDecodeError(w, err)
+			return
+		}
+	}
+	id, err := newID("thread_")
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	now := time.Now().Unix()
+	msgs := make([]Message, 0, len(body.Messages))
+	for _, in := range body.Messages {
+		msg, err := in.toMessage(id, now)
+		if err != nil {
+			writeInvalid(w, err.Error())
+			return
+		}
+		msgs = append(msgs, msg)
+	}
+	thread := Thread{
+		ID:        id,
+		Object:    "thread",
+		CreatedAt: now,
+		Metadata:  metadataOrEmpty(body.Metadata),
+		Owner:     owner(r),
+	}
+	if err := h.Store.CreateThread(r.Context(), thread, msgs); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+func (h *Handlers) getThread(w http.ResponseWriter, r *http.Request) {
+	thread, ok, err := h.Store.GetThread(r.Context(), owner(r), chi.URLParam(r, "thread_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !ok {
+		writeNotFound(w, "thread")
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+func (h *Handlers) deleteThread(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "thread_id")
+	ok, err := h.Store.DeleteThread(r.Context(), owner(r), id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !ok {
+		writeNotFound(w, "thread")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "object": "thread.deleted", "deleted": true})
+}
+
+func (h *Handlers) createMessage(w http.ResponseWriter, r *http.Request) {
+	var in messageInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		openaiapi.WriteDecodeError(w, err)
+		return
+	}
+	msg, err := in.toMessage(chi.URLParam(r, "thread_id"), time.Now().Unix())
+	if err != nil {
+		writeInvalid(w, err.Error())
+		return
+	}
+	if err := h.Store.AddMessage(r.Context(), owner(r), msg); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, msg)
+}
+
+func (h *Handlers) listMessages(w http.ResponseWriter, r *http.Request) {
+	msgs, err := h.Store.Messages(r.Context(), owner(r), chi.URLParam(r, "thread_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeList(w, r, msgs, func(m Message) string { return m.ID })
+}
+
+func (h *Handlers) createRun(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		AssistantID            string            `json:"assistant_id"`
+		Model                  string            `json:"model"`
+		Instructions           string            `json:"instructions"`
+		AdditionalInstructions string            `json:"additional_instructions"`
+		Temperature            *float64          `json:"temperature"`
+		Metadata               map[string]string `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		openaiapi.WriteDecodeError(w, err)
+		return
+	}
+	// There are no stored assistants: a run without a model is routed to its
+	// assistant ID, which an alias can map to a real model.
+	model := body.Model
+	if model == "" {
+		model = body.AssistantID
+	}
+	if model == "" {
+		writeInvalid(w, "model or assistant_id is required")
+		return
+	}
+	instructions := body.Instructions
+	if body.AdditionalInstructions != "" {
+		if instructions != "" {
+			instructions += "\n\n"
+		}
+		instructions += body.AdditionalInstructions
+	}
+
+	id, err := newID("run_")
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	now := time.Now().Unix()
+	run := Run{
+		ID:           id,
+		Object:       "thread.run",
+		CreatedAt:    now,
+		ThreadID:     chi.URLParam(r, "thread_id"),
+		AssistantID:  body.AssistantID,
+		Status:       StatusInProgress,
+		Model:        model,
+		Instructions: instructions,
+		StartedAt:    &now,
+		Metadata:     metadataOrEmpty(body.Metadata),
+		temperature:  body.Temperature,
+	}
+	msgs, err := h.Store.StartRun(r.Context(), owner(r), run)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	run, reply := execute(r.Context(), h.Gateway, run, msgs)
+	if err := h.Store.FinishRun(r.Context(), run, reply); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (h *Handlers) listRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.Store.Runs(r.Context(), owner(r), chi.URLParam(r, "thread_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeList(w, r, runs, func(run Run) string { return run.ID })
+}
+
+func (h *Handlers) getRun(w http.ResponseWriter, r *http.Request) {
+	run, ok, err := h.Store.GetRun(r.Context(), owner(r), chi.URLParam(r, "thread_id"), chi.URLParam(r, "run_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !ok {
+		writeNotFound(w, "run")
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// writeList writes items, oldest first, as an OpenAI list page honoring the
+// limit, order (desc by default), and after query parameters.
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T, id func(T) string) {
+	q := r.URL.Query()
+	limit := defaultListLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			writeInvalid(w, "limit must be between 1 and 100")
+			return
+		}
+		limit = n
+	}
+	switch q.Get("order") {
+	case "", "desc":
+		slices.Reverse(items)
+	case "asc":
+	default:
+		writeInvalid(w, "order must be asc or desc")
+		return
+	}
+	if after := q.Get("after"); after != "" {
+		i := slices.IndexFunc(items, func(item T) bool { return id(item) == after })
+		items = items[i+1:] // i is -1 for an unknown cursor, which lists from the start
+	}
+	if items == nil {
+		items = []T{}
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	page := map[string]any{"object": "list", "data": items, "first_id": nil, "last_id": nil, "has_more": hasMore}
+	if len(items) > 0 {
+		page["first_id"] = id(items[0])
+		page["last_id"] = id(items[len(items)-1])
+	}
+	writeJSON(w, http.StatusOK, page)
+}
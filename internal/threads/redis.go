@@ -0,0 +1,488 @@
+package threads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisTTL is how long a RedisStore keeps a thread after its last
+// change when NewRedisStore is given no TTL.
+const DefaultRedisTTL = 30 * 24 * time.Hour
+
+// RedisStore keeps threads in Redis, so every gateway replica pointed at the
+// same Redis serves the same threads and a client's next call may land on any
+// of them. A thread expires once it has gone unchanged for the store's TTL.
+//
+// Each thread lives under five keys sharing a {id} hash tag, so they map to
+// one Redis Cluster slot and the scripts below can touch them together:
+//
+//	<prefix>thread:{id}          hash: owner, thread JSON
+//	<prefix>thread:{id}:messages list of message JSON, oldest first
+//	<prefix>thread:{id}:runs     hash: run ID to run JSON
+//	<prefix>thread:{id}:run_ids  list of run IDs, oldest first
+//	<prefix>thread:{id}:active   ID of the run in progress, if any
+//
+// The threads of each owner are indexed under <prefix>owner:{owner}, a sorted
+// set of thread IDs scored by when each expires, which bounds how many an
+// owner may create. The index lives in another slot than the threads, so it
+// is kept up to date on a best-effort basis; a stale entry ages out with its
+// score.
+type RedisStore struct {
+	client redis.Cmdable
+	prefix string
+	ttl    time.Duration
+	limits Limits
+}
+
+// NewRedisStore returns a store keeping threads under keys beginning with
+// prefix, each expiring ttl after its thread last changed; zero or less uses
+// DefaultRedisTTL.
+func NewRedisStore(client redis.Cmdable, prefix string, ttl time.Duration) *RedisStore {
+	if ttl <= 0 {
+		ttl = DefaultRedisTTL
+	}
+	return &RedisStore{client: client, prefix: prefix, ttl: ttl, limits: Limits{}.withDefaults()}
+}
+
+// SetLimits replaces the store's limits. Threads already past a new limit
+// are kept but cannot grow. It is not safe to call while the store is in use.
+func (s *RedisStore) SetLimits(l Limits) {
+	s.limits = l.withDefaults()
+}
+
+// Indexes into threadKeys' result, and so into a script's KEYS (one-based in
+// Lua).
+const (
+	keyThread = iota
+	keyMessages
+	keyRuns
+	keyRunIDs
+)
+
+func (s *RedisStore) threadKeys(id string) []string {
+	base := s.prefix + "thread:{" + id + "}"
+	return []string{base, base + ":messages", base + ":runs", base + ":run_ids", base + ":active"}
+}
+
+// Close closes the store's client when it can be closed.
+func (s *RedisStore) Close() error {
+	if c, ok := s.client.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *RedisStore) ownerKey(owner string) string {
+	return s.prefix + "owner:{" + owner + "}"
+}
+
+func (s *RedisStore) ttlMillis() string {
+	return strconv.FormatInt(s.ttl.Milliseconds(), 10)
+}
+
+// touchLua refreshes the expiry of every key of the thread; ARGV[1] is the TTL
+// in milliseconds. PEXPIRE skips keys that do not exist yet.
+const touchLua = `
+local function touch(ttl)
+	for _, k in ipairs(KEYS) do
+		redis.call('PEXPIRE', k, ttl)
+	end
+end
+`
+
+// deleteScript removes the thread when ARGV[1] owns it.
+//
+//	ARGV[1] owner
+var deleteScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', unpack(KEYS))
+return 1
+`)
+
+// reserveScript adds thread ARGV[4] to the owner index KEYS[1] unless the
+// owner already has ARGV[3] threads that have not expired, returning 1 when
+// it did. The index expires with its latest thread.
+//
+//	ARGV[1] now, in milliseconds since the epoch
+//	ARGV[2] the thread's expiry, likewise
+//	ARGV[3] most threads per owner
+//	ARGV[4] thread ID
+var reserveScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+redis.call('PEXPIREAT', KEYS[1], ARGV[2])
+return 1
+`)
+
+// addMessageScript appends a message to ARGV[2]'s thread, returning 1; 0
+// means the thread is missing and -1 that it is full.
+//
+//	ARGV[1] TTL in milliseconds
+//	ARGV[2] owner
+//	ARGV[3] message JSON
+//	ARGV[4] most messages per thread
+var addMessageScript = redis.NewScript(touchLua + `
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[2] then
+	return 0
+end
+if redis.call('LLEN', KEYS[2]) >= tonumber(ARGV[4]) then
+	return -1
+end
+redis.call('RPUSH', KEYS[2], ARGV[3])
+touch(ARGV[1])
+return 1
+`)
+
+// startRunScript records a run on ARGV[2]'s thread unless another is in
+// progress, returning {1, messages...}; {0} means the thread is missing, {-1}
+// that a run is active, {-2} that the thread has its most runs and {-3} that
+// it has no room for the run's reply. The active marker expires with the
+// thread, so a replica dying mid-run does not block the thread for longer.
+//
+//	ARGV[1] TTL in milliseconds
+//	ARGV[2] owner
+//	ARGV[3] run ID
+//	ARGV[4] run JSON
+//	ARGV[5] most runs per thread
+//	ARGV[6] most messages per thread
+var startRunScript = redis.NewScript(touchLua + `
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[2] then
+	return {0}
+end
+if redis.call('EXISTS', KEYS[5]) == 1 then
+	return {-1}
+end
+if redis.call('LLEN', KEYS[4]) >= tonumber(ARGV[5]) then
+	return {-2}
+end
+if redis.call('LLEN', KEYS[2]) >= tonumber(ARGV[6]) then
+	return {-3}
+end
+redis.call('SET', KEYS[5], ARGV[3], 'PX', ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[3], ARGV[4])
+redis.call('RPUSH', KEYS[4], ARGV[3])
+touch(ARGV[1])
+local out = redis.call('LRANGE', KEYS[2], 0, -1)
+table.insert(out, 1, 1)
+return out
+`)
+
+// finishRunScript replaces a run and appends its reply, if any, unless the
+// thread has been deleted meanwhile.
+//
+//	ARGV[1] TTL in milliseconds
+//	ARGV[2] run ID
+//	ARGV[3] run JSON
+//	ARGV[4] reply message JSON, or empty
+var finishRunScript = redis.NewScript(touchLua + `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[3], ARGV[2], ARGV[3])
+if ARGV[4] ~= '' then
+	redis.call('RPUSH', KEYS[2], ARGV[4])
+end
+if redis.call('GET', KEYS[5]) == ARGV[2] then
+	redis.call('DEL', KEYS[5])
+end
+touch(ARGV[1])
+return 1
+`)
+
+// CreateThread implements Store.
+func (s *RedisStore) CreateThread(ctx context.Context, thread Thread, msgs []Message) error {
+	if len(msgs) > s.limits.MessagesPerThread {
+		return s.limits.tooManyMessages()
+	}
+	data, err := json.Marshal(thread)
+	if err != nil {
+		return err
+	}
+	encoded := make([]any, len(msgs))
+	for i, m := range msgs {
+		if encoded[i], err = marshalString(m); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	reserved, err := reserveScript.Run(ctx, s.client, []string{s.ownerKey(thread.Owner)},
+		now.UnixMilli(), now.Add(s.ttl).UnixMilli(), s.limits.ThreadsPerOwner, thread.ID).Int()
+	if err != nil {
+		return fmt.Errorf("threads: create %s: %w", thread.ID, err)
+	}
+	if reserved == 0 {
+		return s.limits.tooManyThreads()
+	}
+	keys := s.threadKeys(thread.ID)
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, keys...)
+		p.HSet(ctx, keys[keyThread], "owner", thread.Owner, "thread", data)
+		p.PExpire(ctx, keys[keyThread], s.ttl)
+		if len(encoded) > 0 {
+			p.RPush(ctx, keys[keyMessages], encoded...)
+			p.PExpire(ctx, keys[keyMessages], s.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = s.client.ZRem(ctx, s.ownerKey(thread.Owner), thread.ID).Err()
+		return fmt.Errorf("threads: create %s: %w", thread.ID, err)
+	}
+	return nil
+}
+
+// touchOwner moves thread id's expiry in its owner's index along with the
+// thread's. The index is best-effort, so a failure is not reported.
+func (s *RedisStore) touchOwner(ctx context.Context, owner, id string) {
+	expiry := time.Now().Add(s.ttl)
+	key := s.ownerKey(owner)
+	_, _ = s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZAddXX(ctx, key, redis.Z{Score: float64(expiry.UnixMilli()), Member: id})
+		p.PExpireAt(ctx, key, expiry)
+		return nil
+	})
+}
+
+// GetThread implements Store.
+func (s *RedisStore) GetThread(ctx context.Context, owner, id string) (Thread, bool, error) {
+	vals, err := s.client.HMGet(ctx, s.threadKeys(id)[keyThread], "owner", "thread").Result()
+	if err != nil {
+		return Thread{}, false, fmt.Errorf("threads: get %s: %w", id, err)
+	}
+	if o, _ := vals[0].(string); o != owner || vals[1] == nil {
+		return Thread{}, false, nil
+	}
+	var thread Thread
+	if err := json.Unmarshal([]byte(vals[1].(string)), &thread); err != nil {
+		return Thread{}, false, fmt.Errorf("threads: decode %s: %w", id, err)
+	}
+	thread.Owner = owner
+	return thread, true, nil
+}
+
+// DeleteThread implements Store.
+func (s *RedisStore) DeleteThread(ctx context.Context, owner, id string) (bool, error) {
+	n, err := deleteScript.Run(ctx, s.client, s.threadKeys(id), owner).Int()
+	if err != nil {
+		return false, fmt.Errorf("threads: delete %s: %w", id, err)
+	}
+	if n == 1 {
+		_ = s.client.ZRem(ctx, s.ownerKey(owner), id).Err()
+	}
+	return n == 1, nil
+}
+
+// DeleteOwner implements Store. Threads are not indexed by owner, so it scans
+// the store's thread keys, on every master of a Redis Cluster.
+func (s *RedisStore) DeleteOwner(ctx context.Context, owner string) (int, error) {
+	if err := s.client.Del(ctx, s.ownerKey(owner)).Err(); err != nil {
+		return 0, fmt.Errorf("threads: delete index of %s: %w", owner, err)
+	}
+	cluster, ok := s.client.(*redis.ClusterClient)
+	if !ok {
+		return s.deleteOwnerOn(ctx, s.client, owner)
+	}
+	var mu sync.Mutex
+	total := 0
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		n, err := s.deleteOwnerOn(ctx, node, owner)
+		mu.Lock()
+		total += n
+		mu.Unlock()
+		return err
+	})
+	return total, err
+}
+
+// deleteOwnerOn deletes owner's threads among the thread keys client holds.
+// The scan pattern ends in "}", so it matches each thread's main key only.
+func (s *RedisStore) deleteOwnerOn(ctx context.Context, client redis.Cmdable, owner string) (int, error) {
+	n := 0
+	iter := client.Scan(ctx, 0, s.prefix+"thread:{*}", 1000).Iterator()
+	for iter.Next(ctx) {
+		id := strings.TrimSuffix(strings.TrimPrefix(iter.Val(), s.prefix+"thread:{"), "}")
+		deleted, err := deleteScript.Run(ctx, client, s.threadKeys(id), owner).Int()
+		if err != nil {
+			return n, fmt.Errorf("threads: delete %s: %w", id, err)
+		}
+		n += deleted
+	}
+	if err := iter.Err(); err != nil {
+		return n, fmt.Errorf("threads: scan: %w", err)
+	}
+	return n, nil
+}
+
+// AddMessage implements Store.
+func (s *RedisStore) AddMessage(ctx context.Context, owner string, msg Message) error {
+	data, err := marshalString(msg)
+	if err != nil {
+		return err
+	}
+	n, err := addMessageScript.Run(ctx, s.client, s.threadKeys(msg.ThreadID), s.ttlMillis(), owner, data, s.limits.MessagesPerThread).Int()
+	if err != nil {
+		return fmt.Errorf("threads: add message to %s: %w", msg.ThreadID, err)
+	}
+	switch n {
+	case 0:
+		return ErrThreadNotFound
+	case -1:
+		return s.limits.tooManyMessages()
+	}
+	s.touchOwner(ctx, owner, msg.ThreadID)
+	return nil
+}
+
+// Messages implements Store.
+func (s *RedisStore) Messages(ctx context.Context, owner, threadID string) ([]Message, error) {
+	keys := s.threadKeys(threadID)
+	var (
+		ownerCmd *redis.StringCmd
+		msgsCmd  *redis.StringSliceCmd
+	)
+	_, err := s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		ownerCmd = p.HGet(ctx, keys[keyThread], "owner")
+		msgsCmd = p.LRange(ctx, keys[keyMessages], 0, -1)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("threads: messages of %s: %w", threadID, err)
+	}
+	if ownerCmd.Err() != nil || ownerCmd.Val() != owner {
+		return nil, ErrThreadNotFound
+	}
+	return decodeAll[Message](msgsCmd.Val())
+}
+
+// StartRun implements Store.
+func (s *RedisStore) StartRun(ctx context.Context, owner string, run Run) ([]Message, error) {
+	data, err := marshalString(run)
+	if err != nil {
+		return nil, err
+	}
+	res, err := startRunScript.Run(ctx, s.client, s.threadKeys(run.ThreadID), s.ttlMillis(), owner, run.ID, data,
+		s.limits.RunsPerThread, s.limits.MessagesPerThread).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("threads: start run on %s: %w", run.ThreadID, err)
+	}
+	switch res[0] {
+	case int64(0):
+		return nil, ErrThreadNotFound
+	case int64(-1):
+		return nil, ErrRunActive
+	case int64(-2):
+		return nil, s.limits.tooManyRuns()
+	case int64(-3):
+		return nil, s.limits.tooManyMessages()
+	}
+	s.touchOwner(ctx, owner, run.ThreadID)
+	raw := make([]string, 0, len(res)-1)
+	for _, v := range res[1:] {
+		str, _ := v.(string)
+		raw = append(raw, str)
+	}
+	return decodeAll[Message](raw)
+}
+
+// FinishRun implements Store. A thread deleted while its run was in progress
+// is not recreated.
+func (s *RedisStore) FinishRun(ctx context.Context, run Run, reply *Message) error {
+	data, err := marshalString(run)
+	if err != nil {
+		return err
+	}
+	var replyData string
+	if reply != nil {
+		if replyData, err = marshalString(*reply); err != nil {
+			return err
+		}
+	}
+	if err := finishRunScript.Run(ctx, s.client, s.threadKeys(run.ThreadID), s.ttlMillis(), run.ID, data, replyData).Err(); err != nil {
+		return fmt.Errorf("threads: finish run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// GetRun implements Store.
+func (s *RedisStore) GetRun(ctx context.Context, owner, threadID, runID string) (Run, bool, error) {
+	keys := s.threadKeys(threadID)
+	var ownerCmd, runCmd *redis.StringCmd
+	_, err := s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		ownerCmd = p.HGet(ctx, keys[keyThread], "owner")
+		runCmd = p.HGet(ctx, keys[keyRuns], runID)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return Run{}, false, fmt.Errorf("threads: get run %s: %w", runID, err)
+	}
+	if ownerCmd.Err() != nil || ownerCmd.Val() != owner || runCmd.Err() != nil {
+		return Run{}, false, nil
+	}
+	var run Run
+	if err := json.Unmarshal([]byte(runCmd.Val()), &run); err != nil {
+		return Run{}, false, fmt.Errorf("threads: decode run %s: %w", runID, err)
+	}
+	return run, true, nil
+}
+
+// Runs implements Store.
+func (s *RedisStore) Runs(ctx context.Context, owner, threadID string) ([]Run, error) {
+	keys := s.threadKeys(threadID)
+	var (
+		ownerCmd *redis.StringCmd
+		idsCmd   *redis.StringSliceCmd
+		runsCmd  *redis.MapStringStringCmd
+	)
+	_, err := s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		ownerCmd = p.HGet(ctx, keys[keyThread], "owner")
+		idsCmd = p.LRange(ctx, keys[keyRunIDs], 0, -1)
+		runsCmd = p.HGetAll(ctx, keys[keyRuns])
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("threads: runs of %s: %w", threadID, err)
+	}
+	if ownerCmd.Err() != nil || ownerCmd.Val() != owner {
+		return nil, ErrThreadNotFound
+	}
+	byID := runsCmd.Val()
+	raw := make([]string, 0, len(idsCmd.Val()))
+	for _, id := range idsCmd.Val() {
+		if r, ok := byID[id]; ok {
+			raw = append(raw, r)
+		}
+	}
+	return decodeAll[Run](raw)
+}
+
+func marshalString(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("threads: encode: %w", err)
+	}
+	return string(b), nil
+}
+
+func decodeAll[T any](raw []string) ([]T, error) {
+	out := make([]T, len(raw))
+	for i, r := range raw {
+		if err := json.Unmarshal([]byte(r), &out[i]); err != nil {
+			return nil, fmt.Errorf("threads: decode: %w", err)
+		}
+	}
+	return out, nil
+}
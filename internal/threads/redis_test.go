@@ -0,0 +1,157 @@
+package threads
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisStore(t *testing.T) (*miniredis.Miniredis, *RedisStore) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, NewRedisStore(client, "test:", time.Hour)
+}
+
+func TestRedisStore_SharedAcrossReplicas(t *testing.T) {
+	_, store := newRedisStore(t)
+	gw := &fakeCompleter{}
+	a := (&Handlers{Gateway: gw, Store: store}).Routes()
+	b := (&Handlers{Gateway: gw, Store: store}).Routes()
+
+	var thread Thread
+	if code := call(t, a, "k1", http.MethodPost, "/", `{"messages":[{"role":"user","content":"2+2?"}],"metadata":{"user":"u1"}}`, &thread); code != http.StatusOK {
+		t.Fatalf("create thread: status %d", code)
+	}
+	base := "/" + thread.ID
+
+	var got Thread
+	if code := call(t, b, "k1", http.MethodGet, base, "", &got); code != http.StatusOK || got.Metadata["user"] != "u1" {
+		t.Fatalf("get on the other replica: status %d, thread %+v", code, got)
+	}
+	var run Run
+	if code := call(t, b, "k1", http.MethodPost, base+"/runs", `{"model":"gpt-4o"}`, &run); code != http.StatusOK || run.Status != StatusCompleted {
+		t.Fatalf("run on the other replica: status %d, run %+v", code, run)
+	}
+	if len(gw.got.Messages) != 1 || gw.got.Messages[0].Content != "2+2?" {
+		t.Errorf("routed messages = %+v, want the thread's", gw.got.Messages)
+	}
+
+	var page struct {
+		Data []Message `json:"data"`
+	}
+	if code := call(t, a, "k1", http.MethodGet, base+"/messages?order=asc", "", &page); code != http.StatusOK {
+		t.Fatalf("list messages: status %d", code)
+	}
+	if len(page.Data) != 2 || page.Data[1].Role != "assistant" || page.Data[1].Content[0].Text.Value != "4" {
+		t.Fatalf("messages = %+v, want the question and the run's reply", page.Data)
+	}
+	var runs struct {
+		Data []Run `json:"data"`
+	}
+	if code := call(t, a, "k1", http.MethodGet, base+"/runs", "", &runs); code != http.StatusOK || len(runs.Data) != 1 || runs.Data[0].ID != run.ID {
+		t.Fatalf("list runs: status %d, runs %+v", code, runs.Data)
+	}
+
+	if code := call(t, a, "k2", http.MethodGet, base, "", nil); code != http.StatusNotFound {
+		t.Errorf("other key get: status %d, want 404", code)
+	}
+	if code := call(t, b, "k2", http.MethodDelete, base, "", nil); code != http.StatusNotFound {
+		t.Errorf("other key delete: status %d, want 404", code)
+	}
+	if code := call(t, b, "k1", http.MethodDelete, base, "", nil); code != http.StatusOK {
+		t.Errorf("owner delete: status %d", code)
+	}
+	if code := call(t, a, "k1", http.MethodGet, base, "", nil); code != http.StatusNotFound {
+		t.Errorf("get after delete: status %d, want 404", code)
+	}
+}
+
+func TestRedisStore_Runs(t *testing.T) {
+	ctx := t.Context()
+	_, s := newRedisStore(t)
+	if err := s.CreateThread(ctx, Thread{ID: "t1", Owner: "k1"}, nil); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+
+	if _, err := s.StartRun(ctx, "k2", Run{ID: "r1", ThreadID: "t1"}); !errors.Is(err, ErrThreadNotFound) {
+		t.Errorf("StartRun by another key err = %v, want ErrThreadNotFound", err)
+	}
+	if _, err := s.StartRun(ctx, "k1", Run{ID: "r1", ThreadID: "t1", Status: StatusInProgress}); err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if _, err := s.StartRun(ctx, "k1", Run{ID: "r2", ThreadID: "t1", Status: StatusInProgress}); !errors.Is(err, ErrRunActive) {
+		t.Errorf("second StartRun err = %v, want ErrRunActive", err)
+	}
+	if err := s.FinishRun(ctx, Run{ID: "r1", ThreadID: "t1", Status: StatusCompleted}, nil); err != nil {
+		t.Fatalf("FinishRun: %v", err)
+	}
+	if _, err := s.StartRun(ctx, "k1", Run{ID: "r2", ThreadID: "t1", Status: StatusInProgress}); err != nil {
+		t.Errorf("StartRun after finish: %v", err)
+	}
+	if run, ok, err := s.GetRun(ctx, "k1", "t1", "r1"); err != nil || !ok || run.Status != StatusCompleted {
+		t.Errorf("GetRun = %+v, %v, %v; want the finished run", run, ok, err)
+	}
+
+	// A run finishing after its thread was deleted does not bring it back.
+	if _, err := s.DeleteThread(ctx, "k1", "t1"); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if err := s.FinishRun(ctx, Run{ID: "r2", ThreadID: "t1", Status: StatusCompleted}, &Message{ID: "m1", ThreadID: "t1"}); err != nil {
+		t.Fatalf("FinishRun: %v", err)
+	}
+	if _, err := s.Messages(ctx, "k1", "t1"); !errors.Is(err, ErrThreadNotFound) {
+		t.Errorf("Messages after delete err = %v, want ErrThreadNotFound", err)
+	}
+}
+
+func TestRedisStore_ExpiresIdleThreads(t *testing.T) {
+	ctx := t.Context()
+	mr, s := newRedisStore(t)
+	if err := s.CreateThread(ctx, Thread{ID: "t1", Owner: "k1"}, []Message{{ID: "m1", ThreadID: "t1"}}); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+
+	// A change restarts the TTL of the whole thread.
+	mr.FastForward(50 * time.Minute)
+	if err := s.AddMessage(ctx, "k1", Message{ID: "m2", ThreadID: "t1"}); err != nil {
+		t.Fatalf("AddMessage: %v", err)
+	}
+	mr.FastForward(50 * time.Minute)
+	if msgs, err := s.Messages(ctx, "k1", "t1"); err != nil || len(msgs) != 2 {
+		t.Fatalf("Messages = %+v, %v; want both past the first TTL", msgs, err)
+	}
+
+	mr.FastForward(time.Hour)
+	if _, ok, _ := s.GetThread(ctx, "k1", "t1"); ok {
+		t.Error("idle thread outlived its TTL")
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Errorf("keys left after expiry: %v", keys)
+	}
+}
+
+func TestRedisStore_DeleteOwner(t *testing.T) {
+	_, s := newRedisStore(t)
+	testDeleteOwner(t, s)
+}
+
+func TestRedisStore_Limits(t *testing.T) {
+	_, store := newRedisStore(t)
+	testLimits(t, store)
+}
+
+func TestRedisStore_CloseClosesClient(t *testing.T) {
+	_, store := newRedisStore(t)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, _, err := store.GetThread(t.Context(), "k1", "t1"); !errors.Is(err, redis.ErrClosed) {
+		t.Errorf("GetThread after Close err = %v, want redis.ErrClosed", err)
+	}
+}
@@ -0,0 +1,296 @@
+package threads
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Store keeps threads with their messages and runs. Methods taking an owner
+// treat a thread belonging to another owner as missing.
+type Store interface {
+	CreateThread(ctx context.Context, thread Thread, msgs []Message) error
+	GetThread(ctx context.Context, owner, id string) (Thread, bool, error)
+	// DeleteThread removes a thread with its messages and runs. It reports
+	// whether the thread existed.
+	DeleteThread(ctx context.Context, owner, id string) (bool, error)
+	AddMessage(ctx context.Context, owner string, msg Message) error
+	// Messages returns the thread's messages, oldest first.
+	Messages(ctx context.Context, owner, threadID string) ([]Message, error)
+	// StartRun stores run and returns the thread's messages to run over. It
+	// fails with ErrRunActive while another run on the thread is in progress.
+	StartRun(ctx context.Context, owner string, run Run) ([]Message, error)
+	// FinishRun replaces the stored run with run and appends reply, if any,
+	// to its thread.
+	FinishRun(ctx context.Context, run Run, reply *Message) error
+	GetRun(ctx context.Context, owner, threadID, runID string) (Run, bool, error)
+	// Runs returns the thread's runs, oldest first.
+	Runs(ctx context.Context, owner, threadID string) ([]Run, error)
+	// DeleteOwner removes every thread of owner, for a right-to-erasure
+	// request, and returns how many there were.
+	DeleteOwner(ctx context.Context, owner string) (int, error)
+}
+
+// DefaultMaxThreads is how many threads a MemoryStore keeps.
+const DefaultMaxThreads = 10000
+
+// Defaults for the zero fields of Limits.
+const (
+	DefaultMaxMessagesPerThread = 1000
+	DefaultMaxRunsPerThread     = 1000
+	DefaultMaxThreadsPerOwner   = 1000
+)
+
+// Limits bounds how much one API key can keep in a store. A zero or negative
+// field uses its default.
+type Limits struct {
+	// MessagesPerThread caps a thread's messages, including the replies its
+	// runs append.
+	MessagesPerThread int
+	RunsPerThread     int
+	ThreadsPerOwner   int
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MessagesPerThread <= 0 {
+		l.MessagesPerThread = DefaultMaxMessagesPerThread
+	}
+	if l.RunsPerThread <= 0 {
+		l.RunsPerThread = DefaultMaxRunsPerThread
+	}
+	if l.ThreadsPerOwner <= 0 {
+		l.ThreadsPerOwner = DefaultMaxThreadsPerOwner
+	}
+	return l
+}
+
+// Errors for each limit, wrapping ErrLimitExceeded.
+func (l Limits) tooManyMessages() error {
+	return fmt.Errorf("%w: a thread holds at most %d messages", ErrLimitExceeded, l.MessagesPerThread)
+}
+
+func (l Limits) tooManyRuns() error {
+	return fmt.Errorf("%w: a thread holds at most %d runs", ErrLimitExceeded, l.RunsPerThread)
+}
+
+func (l Limits) tooManyThreads() error {
+	return fmt.Errorf("%w: an API key holds at most %d threads", ErrLimitExceeded, l.ThreadsPerOwner)
+}
+
+// MemoryStore keeps the most recently created threads in memory. Threads do
+// not survive a restart.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxThreads int
+	limits     Limits
+	order      []string // thread IDs, oldest first
+	threads    map[string]*storedThread
+	owned      map[string]int // owner to thread count
+}
+
+type storedThread struct {
+	thread   Thread
+	messages []Message
+	runs     []Run
+}
+
+// NewMemoryStore returns a MemoryStore keeping up to maxThreads threads; zero
+// or less uses DefaultMaxThreads.
+func NewMemoryStore(maxThreads int) *MemoryStore {
+	if maxThreads <= 0 {
+		maxThreads = DefaultMaxThreads
+	}
+	return &MemoryStore{
+		maxThreads: maxThreads,
+		limits:     Limits{}.withDefaults(),
+		threads:    make(map[string]*storedThread),
+		owned:      make(map[string]int),
+	}
+}
+
+// SetLimits replaces the store's limits. Threads already past a new limit
+// are kept but cannot grow.
+func (s *MemoryStore) SetLimits(l Limits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = l.withDefaults()
+}
+
+// removeLocked drops thread id from the threads map and its owner's count,
+// leaving s.order to the caller. The caller must hold s.mu.
+func (s *MemoryStore) removeLocked(id string) {
+	st, ok := s.threads[id]
+	if !ok {
+		return
+	}
+	delete(s.threads, id)
+	if s.owned[st.thread.Owner]--; s.owned[st.thread.Owner] <= 0 {
+		delete(s.owned, st.thread.Owner)
+	}
+}
+
+// lookupLocked returns owner's thread id. The caller must hold s.mu.
+func (s *MemoryStore) lookupLocked(owner, id string) (*storedThread, bool) {
+	st, ok := s.threads[id]
+	if !ok || st.thread.Owner != owner {
+		return nil, false
+	}
+	return st, true
+}
+
+// CreateThread implements Store. Once the store holds the maximum, its oldest
+// thread is dropped.
+func (s *MemoryStore) CreateThread(_ context.Context, thread Thread, msgs []Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(msgs) > s.limits.MessagesPerThread {
+		return s.limits.tooManyMessages()
+	}
+	if s.owned[thread.Owner] >= s.limits.ThreadsPerOwner {
+		return s.limits.tooManyThreads()
+	}
+	s.threads[thread.ID] = &storedThread{thread: thread, messages: slices.Clone(msgs)}
+	s.owned[thread.Owner]++
+	s.order = append(s.order, thread.ID)
+	for len(s.threads) > s.maxThreads {
+		s.removeLocked(s.order[0])
+		s.order = s.order[1:]
+	}
+	return nil
+}
+
+// GetThread implements Store.
+func (s *MemoryStore) GetThread(_ context.Context, owner, id string) (Thread, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.lookupLocked(owner, id)
+	if !ok {
+		return Thread{}, false, nil
+	}
+	return st.thread, true, nil
+}
+
+// DeleteThread implements Store.
+func (s *MemoryStore) DeleteThread(_ context.Context, owner, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookupLocked(owner, id); !ok {
+		return false, nil
+	}
+	s.removeLocked(id)
+	s.order = slices.DeleteFunc(s.order, func(t string) bool { return t == id })
+	return true, nil
+}
+
+// DeleteOwner implements Store.
+func (s *MemoryStore) DeleteOwner(_ context.Context, owner string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	s.order = slices.DeleteFunc(s.order, func(id string) bool {
+		if s.threads[id].thread.Owner != owner {
+			return false
+		}
+		s.removeLocked(id)
+		n++
+		return true
+	})
+	return n, nil
+}
+
+// AddMessage implements Store.
+func (s *MemoryStore) AddMessage(_ context.Context, owner string, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.lookupLocked(owner, msg.ThreadID)
+	if !ok {
+		return ErrThreadNotFound
+	}
+	if len(st.messages) >= s.limits.MessagesPerThread {
+		return s.limits.tooManyMessages()
+	}
+	st.messages = append(st.messages, msg)
+	return nil
+}
+
+// Messages implements Store.
+func (s *MemoryStore) Messages(_ context.Context, owner, threadID string) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.lookupLocked(owner, threadID)
+	if !ok {
+		return nil, ErrThreadNotFound
+	}
+	return slices.Clone(st.messages), nil
+}
+
+// StartRun implements Store. A run needs room on its thread for its reply.
+func (s *MemoryStore) StartRun(_ context.Context, owner string, run Run) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.lookupLocked(owner, run.ThreadID)
+	if !ok {
+		return nil, ErrThreadNotFound
+	}
+	for _, r := range st.runs {
+		if r.Status == StatusInProgress {
+			return nil, ErrRunActive
+		}
+	}
+	if len(st.runs) >= s.limits.RunsPerThread {
+		return nil, s.limits.tooManyRuns()
+	}
+	if len(st.messages) >= s.limits.MessagesPerThread {
+		return nil, s.limits.tooManyMessages()
+	}
+	st.runs = append(st.runs, run)
+	return slices.Clone(st.messages), nil
+}
+
+// FinishRun implements Store. A thread deleted while its run was in progress
+// is not recreated.
+func (s *MemoryStore) FinishRun(_ context.Context, run Run, reply *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.threads[run.ThreadID]
+	if !ok {
+		return nil
+	}
+	for i := range st.runs {
+		if st.runs[i].ID == run.ID {
+			st.runs[i] = run
+		}
+	}
+	if reply != nil {
+		st.messages = append(st.messages, *reply)
+	}
+	return nil
+}
+
+// GetRun implements Store.
+func (s *MemoryStore) GetRun(_ context.Context, owner, threadID, runID string) (Run, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.lookupLocked(owner, threadID)
+	if !ok {
+		return Run{}, false, nil
+	}
+	for _, r := range st.runs {
+		if r.ID == runID {
+			return r, true, nil
+		}
+	}
+	return Run{}, false, nil
+}
+
+// Runs implements Store.
+func (s *MemoryStore) Runs(_ context.Context, owner, threadID string) ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.lookupLocked(owner, threadID)
+	if !ok {
+		return nil, ErrThreadNotFound
+	}
+	return slices.Clone(st.runs), nil
+}
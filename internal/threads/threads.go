@@ -0,0 +1,262 @@
+// Package threads serves a minimal OpenAI Assistants-style facade — threads,
+// their messages, and runs — so clients written against the Assistants API
+// can move to the gateway without rewriting their conversation handling.
+//
+// It is deliberately thin: there are no stored assistants, tools, files, or
+// streaming runs. A run replays the thread as a chat completion through the
+// gateway's normal routing, so it passes through plugins, fallbacks, and cost
+// accounting like any client request, and appends the answer to the thread.
+// Runs complete before the create call returns, so a client polling for a
+// terminal status sees one on its first poll.
+package threads
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/apierror"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Run statuses. Runs execute synchronously, so a stored run is only briefly
+// in progress.
+const (
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+var (
+	// ErrThreadNotFound is returned for a thread that does not exist or
+	// belongs to another API key.
+	ErrThreadNotFound = errors.New("thread not found")
+	// ErrRunActive is returned when a run is started on a thread that already
+	// has one in progress.
+	ErrRunActive = errors.New("thread already has an active run")
+	// ErrLimitExceeded is returned when a change would take a thread or an
+	// API key past one of the store's Limits.
+	ErrLimitExceeded = errors.New("thread limit exceeded")
+)
+
+// Completer routes a chat completion request. *aigateway.Gateway implements
+// it.
+type Completer interface {
+	Route(ctx context.Context, req providers.Request) (*providers.Response, error)
+}
+
+// Thread is a conversation. Owner is the API key that created it; other keys
+// cannot see it.
+type Thread struct {
+	ID        string            `json:"id"`
+	Object    string            `json:"object"` // always "thread"
+	CreatedAt int64             `json:"created_at"`
+	Metadata  map[string]string `json:"metadata"`
+	Owner     string            `json:"-"`
+}
+
+// Message is one entry of a thread.
+type Message struct {
+	ID          string            `json:"id"`
+	Object      string            `json:"object"` // always "thread.message"
+	CreatedAt   int64             `json:"created_at"`
+	ThreadID    string            `json:"thread_id"`
+	Role        string            `json:"role"`
+	Content     []Content         `json:"content"`
+	AssistantID *string           `json:"assistant_id"`
+	RunID       *string           `json:"run_id"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// Content is one part of a message. Only text parts are supported.
+type Content struct {
+	Type string `json:"type"` // always "text"
+	Text Text   `json:"text"`
+}
+
+// Text is the body of a text content part.
+type Text struct {
+	Value       string `json:"value"`
+	Annotations []any  `json:"annotations"`
+}
+
+// text returns the message's text parts joined by blank lines.
+func (m Message) text() string {
+	parts := make([]string, 0, len(m.Content))
+	for _, c := range m.Content {
+		parts = append(parts, c.Text.Value)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Run is one completion over a thread.
+type Run struct {
+	ID           string            `json:"id"`
+	Object       string            `json:"object"` // always "thread.run"
+	CreatedAt    int64             `json:"created_at"`
+	ThreadID     string            `json:"thread_id"`
+	AssistantID  string            `json:"assistant_id"`
+	Status       string            `json:"status"`
+	Model        string            `json:"model"`
+	Instructions string            `json:"instructions"`
+	StartedAt    *int64            `json:"started_at"`
+	CompletedAt  *int64            `json:"completed_at"`
+	FailedAt     *int64            `json:"failed_at"`
+	LastError    *RunError         `json:"last_error"`
+	Usage        *RunUsage         `json:"usage"`
+	Metadata     map[string]string `json:"metadata"`
+
+	temperature *float64
+}
+
+// RunError is why a run failed.
+type RunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RunUsage is the token usage of a finished run.
+type RunUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// messageInput is the body of a message creation request, also used for the
+// messages of a thread creation request. Content is a string or an array of
+// text parts.
+type messageInput struct {
+	Role     string            `json:"role"`
+	Content  json.RawMessage   `json:"content"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// toMessage validates in and builds the message it describes.
+func (in messageInput) toMessage(threadID string, now int64) (Message, error) {
+	if in.Role != "user" && in.Role != "assistant" {
+		return Message{}, fmt.Errorf("role must be user or assistant, got %q", in.Role)
+	}
+	text, err := contentText(in.Content)
+	if err != nil {
+		return Message{}, err
+	}
+	id, err := newID("msg_")
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{
+		ID:        id,
+		Object:    "thread.message",
+		CreatedAt: now,
+		ThreadID:  threadID,
+		Role:      in.Role,
+		Content:   []Content{textContent(text)},
+		Metadata:  metadataOrEmpty(in.Metadata),
+	}, nil
+}
+
+// contentText reads a message's content, a string or an array of
+// {"type":"text","text":"..."} parts, as one text.
+func contentText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", errors.New("content is required")
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", errors.New("content must be a string or an array of text parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.Type != "text" {
+			return "", fmt.Errorf("content part type %q is not supported", p.Type)
+		}
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+func textContent(s string) Content {
+	return Content{Type: "text", Text: Text{Value: s, Annotations: []any{}}}
+}
+
+func metadataOrEmpty(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+// execute routes run over msgs and returns the finished run and, when it
+// completed, the assistant's reply to append to the thread.
+func execute(ctx context.Context, gw Completer, run Run, msgs []Message) (Run, *Message) {
+	req := providers.Request{Model: run.Model, Temperature: run.temperature}
+	if run.Instructions != "" {
+		req.Messages = append(req.Messages, providers.Message{Role: "system", Content: run.Instructions})
+	}
+	for _, m := range msgs {
+		req.Messages = append(req.Messages, providers.Message{Role: m.Role, Content: m.text()})
+	}
+
+	resp, err := gw.Route(ctx, req)
+	now := time.Now().Unix()
+	if err == nil && len(resp.Choices) == 0 {
+		err = errors.New("the model returned no answer")
+	}
+	if err != nil {
+		_, _, code := apierror.RouteErrorDetails(err)
+		run.Status = StatusFailed
+		run.FailedAt = &now
+		run.LastError = &RunError{Code: code, Message: err.Error()}
+		return run, nil
+	}
+
+	run.Status = StatusCompleted
+	run.CompletedAt = &now
+	run.Usage = &RunUsage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}
+	id, err := newID("msg_")
+	if err != nil {
+		run.Status, run.CompletedAt, run.FailedAt = StatusFailed, nil, &now
+		run.LastError = &RunError{Code: "server_error", Message: err.Error()}
+		return run, nil
+	}
+	runID := run.ID
+	var assistantID *string
+	if run.AssistantID != "" {
+		assistantID = &run.AssistantID
+	}
+	return run, &Message{
+		ID:          id,
+		Object:      "thread.message",
+		CreatedAt:   now,
+		ThreadID:    run.ThreadID,
+		Role:        "assistant",
+		Content:     []Content{textContent(resp.Choices[0].Message.Content)},
+		AssistantID: assistantID,
+		RunID:       &runID,
+		Metadata:    map[string]string{},
+	}
+}
+
+func newID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating id: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
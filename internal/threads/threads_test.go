@@ -0,0 +1,244 @@
+package threads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+type fakeCompleter struct {
+	got providers.Request
+	err error
+}
+
+func (f *fakeCompleter) Route(_ context.Context, req providers.Request) (*providers.Response, error) {
+	f.got = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &providers.Response{
+		Choices: []providers.Choice{{Message: providers.Message{Role: "assistant", Content: "4"}}},
+		Usage:   providers.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6},
+	}, nil
+}
+
+// call serves one request as the API key keyID and decodes the response body
+// into out, when given.
+func call(t *testing.T, h http.Handler, keyID, method, path, body string, out any) int {
+	t.Helper()
+	r := httptest.NewRequestWithContext(authctx.WithKeyID(t.Context(), keyID), method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if out != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %s %s: %v (body %s)", method, path, err, w.Body)
+		}
+	}
+	return w.Code
+}
+
+func TestThreads_RunAppendsReply(t *testing.T) {
+	gw := &fakeCompleter{}
+	h := (&Handlers{Gateway: gw, Store: NewMemoryStore(0)}).Routes()
+
+	var thread Thread
+	if code := call(t, h, "k1", http.MethodPost, "/", `{"messages":[{"role":"user","content":"2+2?"}]}`, &thread); code != http.StatusOK {
+		t.Fatalf("create thread: status %d", code)
+	}
+	base := "/" + thread.ID
+	if code := call(t, h, "k1", http.MethodPost, base+"/messages", `{"role":"user","content":[{"type":"text","text":"Be terse."}]}`, nil); code != http.StatusOK {
+		t.Fatalf("create message: status %d", code)
+	}
+
+	var run Run
+	if code := call(t, h, "k1", http.MethodPost, base+"/runs", `{"assistant_id":"asst_math","instructions":"You are a calculator."}`, &run); code != http.StatusOK {
+		t.Fatalf("create run: status %d", code)
+	}
+	if run.Status != StatusCompleted || run.Usage == nil || run.Usage.TotalTokens != 6 {
+		t.Fatalf("run = %+v, want completed with usage", run)
+	}
+	if gw.got.Model != "asst_math" {
+		t.Errorf("routed model = %q, want the assistant ID", gw.got.Model)
+	}
+	if len(gw.got.Messages) != 3 || gw.got.Messages[0].Role != "system" || gw.got.Messages[2].Content != "Be terse." {
+		t.Errorf("routed messages = %+v", gw.got.Messages)
+	}
+
+	var page struct {
+		Data    []Message `json:"data"`
+		FirstID string    `json:"first_id"`
+		HasMore bool      `json:"has_more"`
+	}
+	if code := call(t, h, "k1", http.MethodGet, base+"/messages?limit=2", "", &page); code != http.StatusOK {
+		t.Fatalf("list messages: status %d", code)
+	}
+	if len(page.Data) != 2 || !page.HasMore || page.FirstID != page.Data[0].ID {
+		t.Fatalf("page = %+v, want the 2 newest of 3", page)
+	}
+	reply := page.Data[0]
+	if reply.Role != "assistant" || reply.Content[0].Text.Value != "4" || reply.RunID == nil || *reply.RunID != run.ID {
+		t.Errorf("newest message = %+v, want the run's reply", reply)
+	}
+
+	var got Run
+	if code := call(t, h, "k1", http.MethodGet, base+"/runs/"+run.ID, "", &got); code != http.StatusOK || got.Status != StatusCompleted {
+		t.Errorf("get run: status %d, run %+v", code, got)
+	}
+}
+
+func TestThreads_FailedRun(t *testing.T) {
+	h := (&Handlers{Gateway: &fakeCompleter{err: errors.New("upstream down")}, Store: NewMemoryStore(0)}).Routes()
+
+	var thread Thread
+	call(t, h, "", http.MethodPost, "/", "", &thread)
+	var run Run
+	if code := call(t, h, "", http.MethodPost, "/"+thread.ID+"/runs", `{"model":"gpt-4o"}`, &run); code != http.StatusOK {
+		t.Fatalf("create run: status %d", code)
+	}
+	if run.Status != StatusFailed || run.LastError == nil || run.FailedAt == nil {
+		t.Fatalf("run = %+v, want failed with last_error", run)
+	}
+
+	if code := call(t, h, "", http.MethodPost, "/"+thread.ID+"/runs", `{}`, nil); code != http.StatusBadRequest {
+		t.Errorf("run without model: status %d, want 400", code)
+	}
+}
+
+func TestThreads_ScopedToKey(t *testing.T) {
+	h := (&Handlers{Gateway: &fakeCompleter{}, Store: NewMemoryStore(0)}).Routes()
+
+	var thread Thread
+	call(t, h, "k1", http.MethodPost, "/", `{}`, &thread)
+	if code := call(t, h, "k2", http.MethodGet, "/"+thread.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("other key get: status %d, want 404", code)
+	}
+	if code := call(t, h, "k2", http.MethodPost, "/"+thread.ID+"/messages", `{"role":"user","content":"hi"}`, nil); code != http.StatusNotFound {
+		t.Errorf("other key message: status %d, want 404", code)
+	}
+	if code := call(t, h, "k1", http.MethodDelete, "/"+thread.ID, "", nil); code != http.StatusOK {
+		t.Errorf("owner delete: status %d", code)
+	}
+	if code := call(t, h, "k1", http.MethodGet, "/"+thread.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("get after delete: status %d, want 404", code)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := t.Context()
+	s := NewMemoryStore(2)
+	for _, id := range []string{"t1", "t2", "t3"} {
+		_ = s.CreateThread(ctx, Thread{ID: id}, nil)
+	}
+	if _, ok, _ := s.GetThread(ctx, "", "t1"); ok {
+		t.Error("oldest thread kept past the maximum")
+	}
+
+	if _, err := s.StartRun(ctx, "", Run{ID: "r1", ThreadID: "t3", Status: StatusInProgress}); err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if _, err := s.StartRun(ctx, "", Run{ID: "r2", ThreadID: "t3", Status: StatusInProgress}); !errors.Is(err, ErrRunActive) {
+		t.Errorf("second StartRun err = %v, want ErrRunActive", err)
+	}
+	_ = s.FinishRun(ctx, Run{ID: "r1", ThreadID: "t3", Status: StatusCompleted}, nil)
+	if _, err := s.StartRun(ctx, "", Run{ID: "r2", ThreadID: "t3", Status: StatusInProgress}); err != nil {
+		t.Errorf("StartRun after finish: %v", err)
+	}
+}
+
+// testDeleteOwner checks that DeleteOwner removes one key's threads only.
+func testDeleteOwner(t *testing.T, s Store) {
+	t.Helper()
+	ctx := t.Context()
+	for id, owner := range map[string]string{"t1": "k1", "t2": "k1", "t3": "k2"} {
+		if err := s.CreateThread(ctx, Thread{ID: id, Owner: owner}, []Message{{ID: "m-" + id, ThreadID: id}}); err != nil {
+			t.Fatalf("CreateThread: %v", err)
+		}
+	}
+	if n, err := s.DeleteOwner(ctx, "k1"); err != nil || n != 2 {
+		t.Fatalf("DeleteOwner = %d, %v; want 2", n, err)
+	}
+	if _, err := s.Messages(ctx, "k1", "t1"); !errors.Is(err, ErrThreadNotFound) {
+		t.Errorf("Messages after DeleteOwner err = %v, want ErrThreadNotFound", err)
+	}
+	if _, ok, _ := s.GetThread(ctx, "k2", "t3"); !ok {
+		t.Error("DeleteOwner removed another key's thread")
+	}
+}
+
+func TestMemoryStore_DeleteOwner(t *testing.T) {
+	testDeleteOwner(t, NewMemoryStore(0))
+}
+
+// testLimits checks that a store enforces its Limits.
+func testLimits(t *testing.T, s interface {
+	Store
+	SetLimits(Limits)
+}) {
+	t.Helper()
+	ctx := t.Context()
+	s.SetLimits(Limits{MessagesPerThread: 2, RunsPerThread: 1, ThreadsPerOwner: 2})
+	msg := func(thread string) Message { return Message{ID: "m", ThreadID: thread} }
+
+	if err := s.CreateThread(ctx, Thread{ID: "t0", Owner: "k1"}, []Message{msg("t0"), msg("t0"), msg("t0")}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("create with 3 messages err = %v, want ErrLimitExceeded", err)
+	}
+	for _, id := range []string{"t1", "t2"} {
+		if err := s.CreateThread(ctx, Thread{ID: id, Owner: "k1"}, []Message{msg(id)}); err != nil {
+			t.Fatalf("CreateThread %s: %v", id, err)
+		}
+	}
+	if err := s.CreateThread(ctx, Thread{ID: "t3", Owner: "k1"}, nil); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("third thread of a key err = %v, want ErrLimitExceeded", err)
+	}
+	if err := s.CreateThread(ctx, Thread{ID: "t3", Owner: "k2"}, nil); err != nil {
+		t.Errorf("another key's thread: %v", err)
+	}
+
+	if err := s.AddMessage(ctx, "k1", msg("t1")); err != nil {
+		t.Fatalf("AddMessage: %v", err)
+	}
+	if err := s.AddMessage(ctx, "k1", msg("t1")); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("third message err = %v, want ErrLimitExceeded", err)
+	}
+	if _, err := s.StartRun(ctx, "k1", Run{ID: "r1", ThreadID: "t1", Status: StatusInProgress}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("run with no room for its reply err = %v, want ErrLimitExceeded", err)
+	}
+
+	if _, err := s.StartRun(ctx, "k1", Run{ID: "r2", ThreadID: "t2", Status: StatusInProgress}); err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	_ = s.FinishRun(ctx, Run{ID: "r2", ThreadID: "t2", Status: StatusCompleted}, nil)
+	if _, err := s.StartRun(ctx, "k1", Run{ID: "r3", ThreadID: "t2", Status: StatusInProgress}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("second run err = %v, want ErrLimitExceeded", err)
+	}
+
+	if _, err := s.DeleteThread(ctx, "k1", "t2"); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if err := s.CreateThread(ctx, Thread{ID: "t4", Owner: "k1"}, nil); err != nil {
+		t.Errorf("create after a delete freed a slot: %v", err)
+	}
+}
+
+func TestMemoryStore_Limits(t *testing.T) {
+	testLimits(t, NewMemoryStore(0))
+}
+
+func TestThreads_LimitIsBadRequest(t *testing.T) {
+	store := NewMemoryStore(0)
+	store.SetLimits(Limits{ThreadsPerOwner: 1})
+	h := (&Handlers{Gateway: &fakeCompleter{}, Store: store}).Routes()
+	if code := call(t, h, "k1", http.MethodPost, "/", "", nil); code != http.StatusOK {
+		t.Fatalf("first thread: status %d", code)
+	}
+	if code := call(t, h, "k1", http.MethodPost, "/", "", nil); code != http.StatusBadRequest {
+		t.Errorf("thread past the cap: status %d, want 400", code)
+	}
+}
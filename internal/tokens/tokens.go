@@ -0,0 +1,115 @@
+// Package tokens estimates token counts for text and chat requests when a
+// provider does not report usage itself.
+//
+// The estimate approximates the BPE vocabularies of current chat models
+// without shipping one: a run of ASCII letters and digits costs roughly one
+// token per four bytes, and every other non-space character (punctuation,
+// symbols, and most non-Latin script) about one token each. It is close enough
+// to show a client what a request cost, not to bill it.
+package tokens
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Framing overheads in OpenAI's chat format: every message is wrapped in a few
+// role and separator tokens, and the reply is primed with a few more. An image
+// is charged at OpenAI's low-detail rate, the floor for any image input.
+const (
+	perMessage = 4
+	perReply   = 3
+	perImage   = 85
+)
+
+// Count estimates the number of tokens in text.
+func Count(text string) int {
+	n := 0
+	word := 0 // bytes in the current ASCII alphanumeric run
+	for _, r := range text {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			word++
+			continue
+		}
+		n += (word + 3) / 4
+		word = 0
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n + (word+3)/4
+}
+
+// Prompt estimates the prompt tokens of a chat request: its messages, their
+// framing, and the tool definitions the model is shown.
+func Prompt(req providers.Request) int {
+	n := perReply
+	for _, msg := range req.Messages {
+		n += perMessage + Count(msg.Role) + Count(msg.Name) + Count(msg.Content)
+		for _, part := range msg.ContentParts {
+			// Content holds the plain-text form of a multipart message, so
+			// only image parts add to it.
+			if part.Type == "image_url" {
+				n += perImage
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			n += Count(call.Function.Name) + Count(call.Function.Arguments)
+		}
+	}
+	for _, tool := range req.Tools {
+		n += perMessage + Count(tool.Function.Name) + Count(tool.Function.Description) + Count(string(tool.Function.Parameters))
+	}
+	return n
+}
+
+// Missing reports whether u carries no token counts at all, as when a
+// provider or stream omits usage.
+func Missing(u providers.Usage) bool {
+	return u.PromptTokens == 0 && u.CompletionTokens == 0 && u.TotalTokens == 0
+}
+
+// Estimate returns usage estimated for resp, given the request's estimated
+// prompt size (see Prompt), marked Estimated.
+func Estimate(promptTokens int, resp *providers.Response) providers.Usage {
+	completion := Completion(resp)
+	return providers.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completion,
+		TotalTokens:      promptTokens + completion,
+		Estimated:        true,
+	}
+}
+
+// Completion estimates the completion tokens of a response from its choices'
+// content and tool calls.
+func Completion(resp *providers.Response) int {
+	if resp == nil {
+		return 0
+	}
+	n := 0
+	for _, choice := range resp.Choices {
+		n += Count(choice.Message.Content) + Count(choice.Message.ReasoningContent)
+		for _, call := range choice.Message.ToolCalls {
+			n += Count(call.Function.Name) + Count(call.Function.Arguments)
+		}
+	}
+	return n
+}
+
+// Reasoning estimates the reasoning tokens of a response from its choices'
+// reasoning content, for providers such as Anthropic that bill thinking as
+// completion tokens without reporting how many there were. The estimate never
+// exceeds completion, the completion tokens that include them.
+func Reasoning(resp *providers.Response, completion int) int {
+	if resp == nil {
+		return 0
+	}
+	n := 0
+	for _, choice := range resp.Choices {
+		n += Count(choice.Message.ReasoningContent)
+	}
+	return min(n, completion)
+}
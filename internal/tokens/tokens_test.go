@@ -0,0 +1,84 @@
+package tokens
+
+import (
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"hi", 1},
+		{"hello", 2},
+		{"hello world", 4},
+		{"hello, world!", 6},
+		{"日本語", 3},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	req := providers.Request{Messages: []providers.Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "hi"},
+	}}
+	// 3 reply priming + (4 + system 2 + "be brief" 3) + (4 + user 1 + "hi" 1)
+	if got := Prompt(req); got != 18 {
+		t.Fatalf("Prompt = %d, want 18", got)
+	}
+
+	req.Messages[1].ContentParts = []providers.ContentPart{{Type: "image_url"}}
+	if got := Prompt(req); got != 18+perImage {
+		t.Fatalf("Prompt with image = %d, want %d", got, 18+perImage)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	if got := Completion(nil); got != 0 {
+		t.Fatalf("Completion(nil) = %d, want 0", got)
+	}
+	resp := &providers.Response{Choices: []providers.Choice{{
+		Message: providers.Message{Content: "hello world"},
+	}}}
+	if got := Completion(resp); got != 4 {
+		t.Fatalf("Completion = %d, want 4", got)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	if !Missing(providers.Usage{}) || Missing(providers.Usage{CompletionTokens: 1}) {
+		t.Fatal("Missing should hold only for usage with no counts")
+	}
+	resp := &providers.Response{Choices: []providers.Choice{{
+		Message: providers.Message{Content: "hello world"},
+	}}}
+	got := Estimate(10, resp)
+	want := providers.Usage{PromptTokens: 10, CompletionTokens: 4, TotalTokens: 14, Estimated: true}
+	if got != want {
+		t.Fatalf("Estimate = %+v, want %+v", got, want)
+	}
+}
+
+func TestReasoning(t *testing.T) {
+	resp := &providers.Response{Choices: []providers.Choice{{
+		Message: providers.Message{Content: "yes", ReasoningContent: "hello world"},
+	}}}
+	if got := Reasoning(resp, 100); got != 4 {
+		t.Fatalf("Reasoning = %d, want 4", got)
+	}
+	if got := Reasoning(resp, 2); got != 2 {
+		t.Fatalf("Reasoning = %d, want it capped at the completion tokens", got)
+	}
+	if got := Reasoning(nil, 100); got != 0 {
+		t.Fatalf("Reasoning(nil) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,122 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+)
+
+// transientRetryBaseDelay is the backoff before the first transient retry;
+// each further retry doubles it. The wait is jittered over [base/2, base).
+const transientRetryBaseDelay = 50 * time.Millisecond
+
+// transientRetry re-sends a request whose connection failed before any
+// response arrived. These are the failures of a stale pooled connection or a
+// restarting load balancer; they say nothing about the provider, so surfacing
+// them would burn one of the routing strategy's fallback attempts on a
+// request a second connection serves. Timeouts, cancellations, and every HTTP
+// response (whatever its status) are returned as they are.
+//
+// A failure after the request may have reached the upstream (reset, broken
+// pipe, EOF) is retried only for an idempotent request: re-sending a chat
+// POST the provider already started would bill a second generation. A
+// non-idempotent request is re-sent only when the connection was never made
+// (refused, or failed while dialing), so it cannot have been received. The
+// case of a reused connection closed before anything was written is left to
+// net/http, which retries it itself.
+//
+// A request is re-sent only when its body can be replayed (GetBody is set, as
+// http.NewRequest does for in-memory bodies), at most retries times.
+type transientRetry struct {
+	next    http.RoundTripper
+	retries int
+}
+
+func (t transientRetry) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	for attempt := 0; err != nil && attempt < t.retries && retryable(req, err); attempt++ {
+		ctx := req.Context()
+		timer := time.NewTimer(transientBackoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+
+		retry := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, err
+			}
+			retry.Body = body
+		}
+		metrics.UpstreamTransientRetriesTotal.Inc()
+		if logging.Enabled(ctx, slog.LevelDebug) {
+			logging.FromContext(ctx).Debug("transport: retrying after a transient connection error",
+				"host", req.URL.Host, "attempt", attempt+1, "error", err)
+		}
+		resp, err = t.next.RoundTrip(retry)
+	}
+	return resp, err
+}
+
+// retryable reports whether req may be re-sent after err.
+func retryable(req *http.Request, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if notSent(err) {
+		return true
+	}
+	return idempotent(req) && transientError(err)
+}
+
+// notSent reports whether err is a failure to connect, before any of the
+// request was written.
+func notSent(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial" && transientError(err)
+}
+
+// idempotent reports whether sending req twice has the effect of sending it
+// once: its method says so, or it carries an idempotency key, as net/http
+// judges a request it may retry.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// transientError reports whether err is a connection failure that a fresh
+// connection is likely to get past.
+func transientError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// transientBackoff returns the jittered wait before retry number attempt+1.
+func transientBackoff(attempt int) time.Duration {
+	d := transientRetryBaseDelay << attempt
+	return d/2 + rand.N(d/2)
+}
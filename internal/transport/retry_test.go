@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestClient_DroppedConnection(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			// Drop the connection without a response, as a restarting
+			// load balancer does.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	client := NewDefault().DefaultClient()
+
+	// The upstream read the POST before dropping it; re-sending could run
+	// it twice.
+	if resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"model":"m"}`)); err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("a dropped POST was re-sent")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server saw %d POSTs, want 1", n)
+	}
+
+	calls.Store(0)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server saw %d GETs, want 2", n)
+	}
+}
+
+func TestTransientRetry(t *testing.T) {
+	reset := &syscallErr{syscall.ECONNRESET}
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: &syscallErr{syscall.ECONNREFUSED}}
+	dialReset := &net.OpError{Op: "dial", Net: "tcp", Err: reset}
+	tests := []struct {
+		name      string
+		method    string
+		errs      []error
+		body      io.Reader
+		wantCalls int
+		wantErr   bool
+	}{
+		{"reset then success", http.MethodGet, []error{reset}, nil, 2, false},
+		{"EOF twice then success", http.MethodGet, []error{io.EOF, io.ErrUnexpectedEOF}, nil, 3, false},
+		{"gives up after the retries", http.MethodGet, []error{reset, reset, reset, reset}, nil, 3, true},
+		{"timeout is not retried", http.MethodGet, []error{context.DeadlineExceeded}, nil, 1, true},
+		{"other errors are not retried", http.MethodGet, []error{errors.New("tls: bad certificate")}, nil, 1, true},
+		{"POST reset is not retried", http.MethodPost, []error{reset}, strings.NewReader("x"), 1, true},
+		{"POST EOF is not retried", http.MethodPost, []error{io.EOF}, strings.NewReader("x"), 1, true},
+		{"POST refused is retried", http.MethodPost, []error{refused}, strings.NewReader("x"), 2, false},
+		{"POST reset while dialing is retried", http.MethodPost, []error{dialReset}, strings.NewReader("x"), 2, false},
+		{"unreplayable body is not retried", http.MethodPost, []error{refused}, io.MultiReader(strings.NewReader("x")), 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			rt := transientRetry{retries: 2, next: roundTripFunc(func(*http.Request) (*http.Response, error) {
+				calls++
+				if calls <= len(tt.errs) {
+					return nil, tt.errs[calls-1]
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			})}
+			req, _ := http.NewRequest(tt.method, "http://upstream.test", tt.body)
+			_, err := rt.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTransientRetry_IdempotencyKey(t *testing.T) {
+	calls := 0
+	rt := transientRetry{retries: 2, next: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, io.EOF
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})}
+	req, _ := http.NewRequest(http.MethodPost, "http://upstream.test", strings.NewReader("x"))
+	req.Header.Set("Idempotency-Key", "k1")
+	if _, err := rt.RoundTrip(req); err != nil || calls != 2 {
+		t.Errorf("err = %v, calls = %d; want a POST with an idempotency key retried", err, calls)
+	}
+}
+
+func TestTransientRetry_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	rt := transientRetry{retries: 2, next: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		cancel()
+		return nil, io.EOF
+	})}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://upstream.test", nil)
+	if _, err := rt.RoundTrip(req); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want the original EOF", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 after cancellation", calls)
+	}
+}
+
+// syscallErr wraps an errno the way net.OpError does.
+type syscallErr struct{ errno syscall.Errno }
+
+func (e *syscallErr) Error() string { return "read: " + e.errno.Error() }
+func (e *syscallErr) Unwrap() error { return e.errno }
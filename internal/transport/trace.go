@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+)
+
+// traceHeaders forwards the gateway trace ID on outbound provider calls so
+// upstream logs can be correlated with the gateway's request log:
+//   - X-Request-ID carries the trace ID as-is.
+//   - traceparent carries it as a W3C trace context when the trace ID is a
+//     valid 32-hex W3C trace ID, with a fresh span ID.
+//
+// It sits inside the otelhttp wrapper, so when OTel tracing is enabled the
+// traceparent injected for the CLIENT span is kept, and headers a provider
+// set itself are never overwritten. Requests without a trace ID on their
+// context pass through untouched.
+type traceHeaders struct {
+	next http.RoundTripper
+}
+
+func (t traceHeaders) RoundTrip(req *http.Request) (*http.Response, error) {
+	traceID := logging.TraceIDFromContext(req.Context())
+	if traceID == "" {
+		return t.next.RoundTrip(req)
+	}
+	setID := req.Header.Get(logging.RequestIDHeader) == ""
+	setParent := req.Header.Get("traceparent") == "" && validW3CTraceID(traceID)
+	if !setID && !setParent {
+		return t.next.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the caller's request.
+	req = req.Clone(req.Context())
+	if setID {
+		req.Header.Set(logging.RequestIDHeader, traceID)
+	}
+	if setParent {
+		if span, err := newSpanID(); err == nil {
+			// Flags 00: the gateway did not record a sampled span for it.
+			req.Header.Set("traceparent", "00-"+traceID+"-"+span+"-00")
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// validW3CTraceID reports whether id is 32 lowercase hex digits, not all zero.
+// Trace IDs taken from an inbound X-Request-ID can be anything.
+func validW3CTraceID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	zero := true
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			zero = false
+		default:
+			return false
+		}
+	}
+	return !zero
+}
+
+func newSpanID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
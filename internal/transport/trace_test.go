@@ -0,0 +1,82 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/upstreamhdr"
+)
+
+func TestClient_ForwardsTraceID(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client := NewDefault().DefaultClient()
+	send := func(ctx context.Context, header http.Header) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	const traceID = "0af7651916cd43dd8448eb211c80319c"
+	send(logging.WithTraceID(context.Background(), traceID), nil)
+	if got.Get("X-Request-ID") != traceID {
+		t.Errorf("X-Request-ID = %q, want %q", got.Get("X-Request-ID"), traceID)
+	}
+	if parent := got.Get("traceparent"); !strings.HasPrefix(parent, "00-"+traceID+"-") || len(parent) != 55 {
+		t.Errorf("traceparent = %q", parent)
+	}
+
+	// A caller-set header wins; a non-W3C trace ID gets no traceparent.
+	send(logging.WithTraceID(context.Background(), "client-chosen-id"), http.Header{"X-Request-Id": {"provider-id"}})
+	if got.Get("X-Request-ID") != "provider-id" || got.Get("traceparent") != "" {
+		t.Errorf("headers = %v", got)
+	}
+
+	send(context.Background(), nil)
+	if got.Get("X-Request-ID") != "" || got.Get("traceparent") != "" {
+		t.Errorf("no trace ID should add no headers, got %v", got)
+	}
+}
+
+func TestClient_RecordsUpstreamHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "0")
+		w.Header().Set("X-Request-Id", "req_upstream")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, rec := upstreamhdr.WithRecorder(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewDefault().DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	got := rec.Headers()
+	if got["x-ratelimit-remaining-requests"] != "0" || got["x-request-id"] != "req_upstream" {
+		t.Errorf("recorded headers = %v", got)
+	}
+}
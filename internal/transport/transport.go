@@ -34,6 +34,10 @@ type Config struct {
 	ForceHTTP2            bool
 	DisableCompression    bool
 	StreamingIdleTimeout  time.Duration
+	// TransientRetries is how many times a request is re-sent after a
+	// connection failure before any response that cannot have run it twice
+	// (see transientRetry). 0 disables the retry.
+	TransientRetries int
 }
 
 // DefaultConfig returns production-optimized defaults.
@@ -50,6 +54,7 @@ func DefaultConfig() Config {
 		ForceHTTP2:            true,
 		DisableCompression:    false,
 		StreamingIdleTimeout:  5 * time.Minute,
+		TransientRetries:      2,
 	}
 }
 
@@ -155,6 +160,11 @@ func (m *Manager) CloseIdleConnections() {
 //   - traceparent injected into request headers
 //   - a CLIENT span emitted by the OTel SDK
 //
+// Inside it, traceHeaders forwards the gateway trace ID as X-Request-ID (and
+// as traceparent when OTel did not inject one), upstreamHeaders records the
+// provider's rate-limit and request-ID response headers, and transientRetry
+// re-sends requests whose connection failed before any response.
+//
 // The wrapper is applied regardless of whether OTel tracing is enabled.
 // When no real TracerProvider is configured the global no-op tracer and
 // no-op propagator are used, so no spans are exported; however there is
@@ -190,7 +200,9 @@ func (m *Manager) buildClient(cfg Config, streaming bool) (*http.Client, *http.T
 	}
 
 	return &http.Client{
-		Transport: otelhttp.NewTransport(t),
+		Transport: otelhttp.NewTransport(traceHeaders{next: upstreamHeaders{
+			next: transientRetry{next: t, retries: cfg.TransientRetries},
+		}}),
 		// No global Timeout — use context.WithTimeout per request.
 		// LLM streaming responses can legitimately take 60-120s.
 	}, t
@@ -0,0 +1,22 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/ferro-labs/ai-gateway/internal/upstreamhdr"
+)
+
+// upstreamHeaders records the rate-limit and request-ID headers of every
+// provider response, error statuses included, in the upstreamhdr.Recorder on
+// the request's context. Requests without a recorder pass through untouched.
+type upstreamHeaders struct {
+	next http.RoundTripper
+}
+
+func (t upstreamHeaders) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if resp != nil {
+		upstreamhdr.FromContext(req.Context()).Record(resp.Header)
+	}
+	return resp, err
+}
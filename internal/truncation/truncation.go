@@ -0,0 +1,197 @@
+// Package truncation shortens chat requests whose prompt would not fit the
+// target model's context window, dropping the oldest conversation turns
+// instead of letting the provider refuse the request. The gateway core picks
+// the policy and the token budget; this package decides which messages go and
+// records what it did for the HTTP layer to report.
+//
+// The summary request and the messages that are never summarized are shared
+// with the history-compress plugin, so both write summaries the same way.
+package truncation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// Policy names.
+const (
+	// DropOldest drops the oldest conversation turns until the prompt fits.
+	DropOldest = "drop_oldest"
+	// KeepLast keeps the system messages and the last N turns, then drops
+	// further turns if the prompt still does not fit.
+	KeepLast = "keep_last"
+	// Summarize drops turns like DropOldest and replaces them with a summary
+	// written by a cheaper model.
+	Summarize = "summarize"
+	// None turns truncation off, for a key that must not inherit its
+	// workspace's policy.
+	None = "none"
+)
+
+// Header reports a truncated request to the client, e.g.
+// "drop_oldest; dropped=4". It is absent when the request was sent whole.
+const Header = "X-Ferro-Context-Truncated"
+
+// Info describes one truncation.
+type Info struct {
+	// Policy is the policy that was applied.
+	Policy string
+	// Dropped is how many messages were removed from the request.
+	Dropped int
+	// Summarized reports whether the dropped messages were replaced with a
+	// summary. A summarize policy whose summary call failed falls back to
+	// dropping them.
+	Summarized bool
+}
+
+// HeaderValue renders i for Header.
+func (i Info) HeaderValue() string {
+	v := fmt.Sprintf("%s; dropped=%d", i.Policy, i.Dropped)
+	if i.Policy == Summarize && !i.Summarized {
+		v += "; summary=failed"
+	}
+	return v
+}
+
+// Fit removes the oldest conversation turns from msgs until estimate reports
+// at most limit tokens. System and developer messages are always kept, as is
+// the last message; keepLast > 0 keeps at most that many other messages to
+// begin with. Once anything is dropped, the kept conversation starts on a
+// user message, as providers that alternate turns require, so a tool result
+// is never kept without the assistant message that called it either.
+// estimate must be additive over messages, as tokens.Prompt is.
+//
+// It returns the kept messages in their original order and the dropped ones.
+// ok is false when the prompt does not fit even after dropping everything
+// Fit may drop.
+func Fit(msgs []providers.Message, keepLast, limit int, estimate func([]providers.Message) int) (kept, dropped []providers.Message, ok bool) {
+	var conv []int // indexes of the droppable messages
+	for i, m := range msgs {
+		if !Pinned(m) {
+			conv = append(conv, i)
+		}
+	}
+	base := estimate(nil)
+	cost := func(m providers.Message) int { return estimate([]providers.Message{m}) - base }
+	total := estimate(msgs)
+
+	start := 0 // conv[:start] are dropped
+	drop := func() {
+		total -= cost(msgs[conv[start]])
+		start++
+	}
+	if keepLast > 0 && len(conv) > keepLast {
+		for start < len(conv)-keepLast {
+			drop()
+		}
+	}
+	for {
+		// The kept turns start on a user message: the reply and tool results
+		// of a dropped one go with it. The last message is the one the model
+		// answers; it always stays.
+		for start > 0 && start < len(conv)-1 && msgs[conv[start]].Role != "user" {
+			drop()
+		}
+		if total <= limit || start >= len(conv)-1 {
+			break
+		}
+		drop()
+	}
+	if start == 0 {
+		return msgs, nil, total <= limit
+	}
+
+	first := len(msgs)
+	if start < len(conv) {
+		first = conv[start]
+	}
+	kept = make([]providers.Message, 0, len(msgs)-start)
+	for i, m := range msgs {
+		if i >= first || Pinned(m) {
+			kept = append(kept, m)
+		} else {
+			dropped = append(dropped, m)
+		}
+	}
+	return kept, dropped, total <= limit
+}
+
+// Pinned reports whether m is an instruction: one Fit never drops and a
+// summary never covers.
+func Pinned(m providers.Message) bool {
+	return m.Role == "system" || m.Role == "developer"
+}
+
+// summaryPrompt instructs the summary model. The previous summary, when there
+// is one, opens the transcript so the new summary extends it.
+const summaryPrompt = "Summarize the conversation below for an assistant that will continue it without seeing it. " +
+	"Keep facts, decisions, names, numbers, and open questions; drop pleasantries. Reply with the summary only."
+
+// SummaryPrefix opens the system message that carries a summary.
+const SummaryPrefix = "Summary of earlier turns of this conversation:\n"
+
+// SummaryRequest returns the request that asks model to summarize msgs in at
+// most maxTokens, extending previous when it is not empty. Pinned and empty
+// messages are left out of the transcript.
+func SummaryRequest(model string, maxTokens int, previous string, msgs []providers.Message) providers.Request {
+	var transcript strings.Builder
+	if previous != "" {
+		fmt.Fprintf(&transcript, "Summary so far: %s\n\n", previous)
+	}
+	for _, m := range msgs {
+		if Pinned(m) || m.Content == "" {
+			continue
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n\n", m.Role, m.Content)
+	}
+	return providers.Request{
+		Model:     model,
+		MaxTokens: &maxTokens,
+		Messages: []providers.Message{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: transcript.String()},
+		},
+	}
+}
+
+// SummaryText returns the summary resp carries, or "" when it has none.
+func SummaryText(resp *providers.Response) string {
+	if resp == nil || len(resp.Choices) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content)
+}
+
+// Recorder captures the truncation of a request for the HTTP layer, which
+// must set its response header before the body and so cannot wait for the
+// response. A recorder belongs to one request.
+type Recorder struct {
+	info *Info
+}
+
+// Info returns the recorded truncation, if the request was truncated.
+func (r *Recorder) Info() (Info, bool) {
+	if r == nil || r.info == nil {
+		return Info{}, false
+	}
+	return *r.info, true
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose request records its truncation in the
+// returned recorder.
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	rec := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, rec), rec
+}
+
+// Record stores info in ctx's recorder, if it has one.
+func Record(ctx context.Context, info Info) {
+	if rec, ok := ctx.Value(recorderKey{}).(*Recorder); ok {
+		rec.info = &info
+	}
+}
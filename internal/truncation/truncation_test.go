@@ -0,0 +1,143 @@
+package truncation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+// wordCount estimates one token per word, plus one for the request.
+func wordCount(msgs []providers.Message) int {
+	n := 1
+	for _, m := range msgs {
+		n += len(strings.Fields(m.Content))
+	}
+	return n
+}
+
+func roles(msgs []providers.Message) string {
+	var out []string
+	for _, m := range msgs {
+		out = append(out, m.Role+":"+m.Content)
+	}
+	return strings.Join(out, " ")
+}
+
+func TestFit(t *testing.T) {
+	sys := providers.Message{Role: "system", Content: "be brief"}
+	u := func(s string) providers.Message { return providers.Message{Role: "user", Content: s} }
+	a := func(s string) providers.Message { return providers.Message{Role: "assistant", Content: s} }
+	call := providers.Message{Role: "assistant", ToolCalls: []providers.ToolCall{{ID: "c1"}}}
+	result := providers.Message{Role: "tool", ToolCallID: "c1", Content: "sunny"}
+
+	tests := []struct {
+		name        string
+		msgs        []providers.Message
+		keepLast    int
+		limit       int
+		wantKept    string
+		wantDropped int
+		wantOK      bool
+	}{
+		{
+			name:     "fits",
+			msgs:     []providers.Message{sys, u("hi")},
+			limit:    10,
+			wantKept: "system:be brief user:hi",
+			wantOK:   true,
+		},
+		{
+			name:        "drops oldest, keeps system",
+			msgs:        []providers.Message{sys, u("one two three"), a("four five"), u("six"), a("seven"), u("eight")},
+			limit:       9,
+			wantKept:    "system:be brief user:six assistant:seven user:eight",
+			wantDropped: 2,
+			wantOK:      true,
+		},
+		{
+			name:        "kept turns start on a user message",
+			msgs:        []providers.Message{sys, u("one two three"), a("four five"), u("six")},
+			limit:       6,
+			wantKept:    "system:be brief user:six",
+			wantDropped: 2,
+			wantOK:      true,
+		},
+		{
+			name:        "tool result goes with its call",
+			msgs:        []providers.Message{sys, u("weather?"), call, result, u("thanks")},
+			limit:       4,
+			wantKept:    "system:be brief user:thanks",
+			wantDropped: 3,
+			wantOK:      true,
+		},
+		{
+			name:        "keep last",
+			msgs:        []providers.Message{u("a"), a("b"), u("c"), a("d"), u("e")},
+			keepLast:    3,
+			limit:       100,
+			wantKept:    "user:c assistant:d user:e",
+			wantDropped: 2,
+			wantOK:      true,
+		},
+		{
+			name:        "last message alone is too long",
+			msgs:        []providers.Message{u("a"), u("one two three four five")},
+			limit:       3,
+			wantKept:    "user:one two three four five",
+			wantDropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped, ok := Fit(tt.msgs, tt.keepLast, tt.limit, wordCount)
+			if got := roles(kept); got != tt.wantKept {
+				t.Errorf("kept = %q, want %q", got, tt.wantKept)
+			}
+			if len(dropped) != tt.wantDropped || ok != tt.wantOK {
+				t.Errorf("dropped %d, ok %v; want %d, %v", len(dropped), ok, tt.wantDropped, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	Record(context.Background(), Info{Policy: DropOldest}) // no recorder: no-op
+
+	ctx, rec := WithRecorder(context.Background())
+	if _, ok := rec.Info(); ok {
+		t.Fatal("fresh recorder reports a truncation")
+	}
+	Record(ctx, Info{Policy: Summarize, Dropped: 3})
+	info, ok := rec.Info()
+	if !ok || info.HeaderValue() != "summarize; dropped=3; summary=failed" {
+		t.Fatalf("Info() = %+v, %v", info, ok)
+	}
+	info.Summarized = true
+	if got := info.HeaderValue(); got != "summarize; dropped=3" {
+		t.Errorf("HeaderValue() = %q", got)
+	}
+}
+
+func TestSummaryRequest(t *testing.T) {
+	req := SummaryRequest("cheap", 256, "earlier", []providers.Message{
+		{Role: "system", Content: "Be terse."},
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: ""},
+		{Role: "assistant", Content: "hello"},
+	})
+	if req.Model != "cheap" || req.MaxTokens == nil || *req.MaxTokens != 256 || len(req.Messages) != 2 {
+		t.Fatalf("request = %+v", req)
+	}
+	if got, want := req.Messages[1].Content, "Summary so far: earlier\n\nuser: hi\n\nassistant: hello\n\n"; got != want {
+		t.Fatalf("transcript = %q, want %q", got, want)
+	}
+	resp := &providers.Response{Choices: []providers.Choice{{Message: providers.Message{Content: "  short  "}}}}
+	if got := SummaryText(resp); got != "short" {
+		t.Fatalf("SummaryText = %q", got)
+	}
+	if got := SummaryText(&providers.Response{}); got != "" {
+		t.Fatalf("SummaryText of an empty response = %q", got)
+	}
+}
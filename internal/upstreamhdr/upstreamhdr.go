@@ -0,0 +1,164 @@
+// Package upstreamhdr captures the provider response headers worth keeping
+// past the provider call — rate-limit state and request IDs — so throttling
+// upstream can be correlated with the gateway's own errors. The shared
+// transport records them on the request's context; the gateway copies them
+// into the request log, and the HTTP layer forwards them to the client as
+// X-Ferro-Upstream-* headers when the gateway exposes them.
+package upstreamhdr
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HeaderPrefix prefixes each captured header sent to the client, in place of
+// a leading "x-": x-ratelimit-remaining-tokens is sent as
+// X-Ferro-Upstream-Ratelimit-Remaining-Tokens.
+const HeaderPrefix = "X-Ferro-Upstream-"
+
+// capturedPrefixes and capturedNames select the headers kept, in lower case:
+// the OpenAI-style x-ratelimit-* family (OpenAI, Azure, Groq, Mistral, …),
+// Anthropic's anthropic-ratelimit-* family, Retry-After, and the request IDs
+// providers quote in support tickets.
+var (
+	capturedPrefixes = []string{"x-ratelimit-", "anthropic-ratelimit-"}
+	capturedNames    = map[string]bool{
+		"retry-after":      true,
+		"x-request-id":     true,
+		"request-id":       true,
+		"x-amzn-requestid": true,
+		"apim-request-id":  true,
+	}
+)
+
+// Bounds on what one response can make the gateway keep.
+const (
+	maxHeaders     = 32
+	maxValueLength = 256
+)
+
+// Captured reports whether the header name is one the recorder keeps.
+func Captured(name string) bool {
+	name = strings.ToLower(name)
+	if capturedNames[name] {
+		return true
+	}
+	for _, p := range capturedPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Recorder holds the captured headers of the last provider response of one
+// request: after a fallback, those of the provider that answered last. It is
+// safe for concurrent use, as hedged attempts may record at once.
+type Recorder struct {
+	mu      sync.Mutex
+	headers map[string]string
+	expose  bool
+}
+
+// Record replaces the recorded headers with the captured ones of h.
+func (r *Recorder) Record(h http.Header) {
+	if r == nil {
+		return
+	}
+	var kept map[string]string
+	for name, values := range h {
+		if len(values) == 0 || !Captured(name) {
+			continue
+		}
+		if kept == nil {
+			kept = make(map[string]string)
+		}
+		if len(kept) == maxHeaders {
+			break
+		}
+		v := values[0]
+		if len(v) > maxValueLength {
+			v = v[:maxValueLength]
+		}
+		kept[strings.ToLower(name)] = v
+	}
+	r.mu.Lock()
+	r.headers = kept
+	r.mu.Unlock()
+}
+
+// Headers returns a copy of the recorded headers keyed by lower-case name, or
+// nil when there are none.
+func (r *Recorder) Headers() map[string]string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.headers) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(r.headers))
+	for k, v := range r.headers {
+		out[k] = v
+	}
+	return out
+}
+
+// SetExposed sets whether the headers are forwarded to the client.
+func (r *Recorder) SetExposed(expose bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.expose = expose
+	r.mu.Unlock()
+}
+
+// WriteHeaders sets the recorded headers on h as X-Ferro-Upstream-* headers,
+// when the gateway exposes them.
+func (r *Recorder) WriteHeaders(h http.Header) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.expose {
+		return
+	}
+	for name, v := range r.headers {
+		h.Set(HeaderPrefix+strings.TrimPrefix(name, "x-"), v)
+	}
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose provider calls record their headers in
+// the returned recorder. A context that already carries a recorder keeps it,
+// so the HTTP layer and the gateway share one.
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	if rec, ok := ctx.Value(recorderKey{}).(*Recorder); ok && rec != nil {
+		return ctx, rec
+	}
+	rec := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, rec), rec
+}
+
+// WithoutRecorder returns a context whose provider calls record nothing, for
+// background calls (such as experiment shadows) that must not overwrite the
+// headers of the request they were derived from.
+func WithoutRecorder(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(recorderKey{}).(*Recorder); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, recorderKey{}, (*Recorder)(nil))
+}
+
+// FromContext returns ctx's recorder, or nil when it has none. A nil
+// recorder's methods do nothing.
+func FromContext(ctx context.Context) *Recorder {
+	rec, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return rec
+}
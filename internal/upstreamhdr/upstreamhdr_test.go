@@ -0,0 +1,95 @@
+package upstreamhdr
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_RecordsSelectedHeaders(t *testing.T) {
+	_, rec := WithRecorder(context.Background())
+	rec.Record(http.Header{
+		"X-Ratelimit-Remaining-Tokens":           {"1200"},
+		"Anthropic-Ratelimit-Requests-Remaining": {"9"},
+		"Request-Id":                             {"req_abc"},
+		"Retry-After":                            {"3"},
+		"Content-Type":                           {"application/json"},
+		"Set-Cookie":                             {"session=secret"},
+		"X-Ratelimit-Reset-Tokens":               {strings.Repeat("9", 1000)},
+	})
+
+	got := rec.Headers()
+	want := map[string]string{
+		"x-ratelimit-remaining-tokens":           "1200",
+		"anthropic-ratelimit-requests-remaining": "9",
+		"request-id":                             "req_abc",
+		"retry-after":                            "3",
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("%s = %q, want %q", name, got[name], v)
+		}
+	}
+	if _, ok := got["content-type"]; ok {
+		t.Error("content-type was recorded")
+	}
+	if _, ok := got["set-cookie"]; ok {
+		t.Error("set-cookie was recorded")
+	}
+	if n := len(got["x-ratelimit-reset-tokens"]); n != maxValueLength {
+		t.Errorf("long value kept %d bytes, want %d", n, maxValueLength)
+	}
+
+	// A later response replaces the record, even with nothing to keep.
+	rec.Record(http.Header{"Content-Type": {"text/plain"}})
+	if h := rec.Headers(); h != nil {
+		t.Errorf("headers after a bare response = %v, want nil", h)
+	}
+}
+
+func TestRecorder_WriteHeaders(t *testing.T) {
+	_, rec := WithRecorder(context.Background())
+	rec.Record(http.Header{
+		"X-Ratelimit-Remaining-Tokens": {"0"},
+		"Request-Id":                   {"req_abc"},
+	})
+
+	hidden := http.Header{}
+	rec.WriteHeaders(hidden)
+	if len(hidden) != 0 {
+		t.Errorf("headers written while not exposed: %v", hidden)
+	}
+
+	rec.SetExposed(true)
+	out := http.Header{}
+	rec.WriteHeaders(out)
+	if got := out.Get("X-Ferro-Upstream-Ratelimit-Remaining-Tokens"); got != "0" {
+		t.Errorf("X-Ferro-Upstream-Ratelimit-Remaining-Tokens = %q, want 0", got)
+	}
+	if got := out.Get("X-Ferro-Upstream-Request-Id"); got != "req_abc" {
+		t.Errorf("X-Ferro-Upstream-Request-Id = %q, want req_abc", got)
+	}
+}
+
+func TestWithRecorder_SharesAndDetaches(t *testing.T) {
+	ctx, rec := WithRecorder(context.Background())
+	if inner, again := WithRecorder(ctx); again != rec || inner != ctx {
+		t.Error("WithRecorder replaced the context's recorder")
+	}
+	if FromContext(ctx) != rec {
+		t.Error("FromContext did not return the recorder")
+	}
+
+	detached := WithoutRecorder(ctx)
+	if FromContext(detached) != nil {
+		t.Error("WithoutRecorder left the recorder reachable")
+	}
+	FromContext(detached).Record(http.Header{"Request-Id": {"shadow"}})
+	if rec.Headers() != nil {
+		t.Error("a detached call recorded into the request's recorder")
+	}
+	if _, fresh := WithRecorder(detached); fresh == rec || fresh == nil {
+		t.Error("WithRecorder on a detached context did not start a new recorder")
+	}
+}
@@ -0,0 +1,270 @@
+// Package webhook delivers gateway hook events to an HTTP endpoint, so that
+// systems outside the gateway, such as a platform team's key inventory, learn
+// of them as they happen instead of polling the admin API.
+//
+// Each event is POSTed as JSON:
+//
+//	{"id": "<delivery id>", "subject": "gateway.key.revoked", "data": {...}}
+//
+// with its subject in X-Ferro-Event and its ID in X-Ferro-Delivery; a
+// receiver deduplicates retried deliveries by the ID. With a secret set, the
+// request is signed: X-Ferro-Signature is "sha256=" and the hex HMAC-SHA256,
+// keyed by the secret, of X-Ferro-Timestamp (Unix seconds), a dot, and the
+// body. A receiver recomputes it and rejects a stale timestamp.
+//
+// An event about an API key is also sent to the key's own webhook URL, when
+// the publisher set one with WithURL, so a team can follow its keys without
+// seeing every other team's.
+//
+// Delivery is best effort and never holds up the gateway: events are queued
+// and sent by the Sender's own goroutine. A network error, 429, or 5xx is
+// retried a few times with a short backoff; then the event is logged and
+// dropped, as is an event that finds the queue full.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/internal/httpclient"
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+)
+
+// Delivery headers.
+const (
+	HeaderEvent     = "X-Ferro-Event"
+	HeaderDelivery  = "X-Ferro-Delivery"
+	HeaderTimestamp = "X-Ferro-Timestamp"
+	HeaderSignature = "X-Ferro-Signature"
+)
+
+const (
+	// requestTimeout bounds one delivery attempt.
+	requestTimeout = 5 * time.Second
+	// maxAttempts is how many times an event is sent before it is dropped.
+	maxAttempts = 3
+	// queueSize is how many deliveries may wait for the sender.
+	queueSize = 256
+	// closeTimeout bounds how long Close waits for queued deliveries.
+	closeTimeout = 10 * time.Second
+)
+
+// Sender posts hook events whose subject has its prefix to its URL and to the
+// URL of the key they are about.
+type Sender struct {
+	url     string
+	secret  []byte
+	prefix  string
+	client  *http.Client
+	backoff time.Duration // before the second attempt; doubled after
+
+	mu      sync.Mutex
+	closed  bool
+	queue   chan job
+	stopped chan struct{}
+	ctx     context.Context // cancelled when Close gives up waiting
+	cancel  context.CancelFunc
+}
+
+// job is one queued delivery.
+type job struct {
+	url  string
+	d    delivery
+	body []byte
+}
+
+// New returns a Sender that posts the events whose subject starts with
+// subjectPrefix ("" for every event) to rawURL, an http or https URL, signing
+// them with secret when it is not empty. An empty rawURL sends events only to
+// the URLs set with WithURL. Close stops the Sender.
+func New(rawURL, secret, subjectPrefix string) (*Sender, error) {
+	if rawURL != "" {
+		if err := ValidateURL(rawURL); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Sender{
+		url:     rawURL,
+		secret:  []byte(secret),
+		prefix:  subjectPrefix,
+		client:  httpclient.New(requestTimeout),
+		backoff: 500 * time.Millisecond,
+		queue:   make(chan job, queueSize),
+		stopped: make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	go s.run()
+	return s, nil
+}
+
+// ValidateURL reports whether rawURL is an absolute http or https URL.
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook URL must be an absolute http or https URL, got %q", rawURL)
+	}
+	return nil
+}
+
+// urlContextKey carries the webhook URL set by WithURL.
+type urlContextKey struct{}
+
+// WithURL returns a context whose events are also sent to rawURL, the
+// webhook URL of the key the event is about. An empty rawURL clears a URL set
+// further up ctx.
+func WithURL(ctx context.Context, rawURL string) context.Context {
+	return context.WithValue(ctx, urlContextKey{}, rawURL)
+}
+
+// Close stops taking events and waits a bounded time for the queued ones to
+// be sent.
+func (s *Sender) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	timer := time.NewTimer(closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.stopped:
+		return nil
+	case <-timer.C:
+		s.cancel()
+		<-s.stopped
+		return fmt.Errorf("webhook deliveries still queued after %s were dropped", closeTimeout)
+	}
+}
+
+func (s *Sender) run() {
+	defer close(s.stopped)
+	defer s.cancel()
+	for j := range s.queue {
+		if s.ctx.Err() == nil {
+			s.deliver(j)
+		}
+	}
+}
+
+// delivery is the JSON body of one webhook request.
+type delivery struct {
+	ID      string         `json:"id"`
+	Subject string         `json:"subject"`
+	Data    map[string]any `json:"data"`
+}
+
+// Hook is the Sender's aigateway.EventHookFunc: register it with
+// Gateway.AddHook. It queues the event and returns at once.
+func (s *Sender) Hook(ctx context.Context, subject string, data map[string]any) {
+	if !strings.HasPrefix(subject, s.prefix) {
+		return
+	}
+	keyURL, _ := ctx.Value(urlContextKey{}).(string)
+	if s.url == "" && keyURL == "" {
+		return
+	}
+	d := delivery{ID: newDeliveryID(), Subject: subject, Data: data}
+	body, err := json.Marshal(d)
+	if err != nil {
+		logging.Logger.Warn("webhook event not encoded", "subject", subject, "error", err)
+		return
+	}
+	if s.url != "" {
+		s.enqueue(job{url: s.url, d: d, body: body})
+	}
+	if keyURL != "" && keyURL != s.url {
+		s.enqueue(job{url: keyURL, d: d, body: body})
+	}
+}
+
+func (s *Sender) enqueue(j job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- j:
+	default:
+		logging.Logger.Warn("webhook queue full, event dropped", "subject", j.d.Subject, "delivery", j.d.ID)
+	}
+}
+
+// deliver sends j, retrying a transient failure.
+func (s *Sender) deliver(j job) {
+	wait := s.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := s.send(s.ctx, j)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == maxAttempts {
+			logging.Logger.Warn("webhook delivery failed", "subject", j.d.Subject, "delivery", j.d.ID, "attempts", attempt, "error", err)
+			return
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// send makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (s *Sender) send(ctx context.Context, j job) (retry bool, err error) {
+	d, body := j.d, j.body
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Subject)
+	req.Header.Set(HeaderDelivery, d.ID)
+	if len(s.secret) > 0 {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, ts)
+		req.Header.Set(HeaderSignature, Sign(s.secret, ts, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("receiver answered %d", resp.StatusCode)
+}
+
+// Sign returns the X-Ferro-Signature value for body sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a webhook endpoint that answers with the queued statuses, then
+// 200, and keeps what it was sent.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.bodies = append(rc.bodies, body)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newSender(t *testing.T, rc *receiver, secret string) *Sender {
+	t.Helper()
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+	s, err := New(srv.URL, secret, "gateway.key.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.backoff = 0
+	return s
+}
+
+func closeSender(t *testing.T, s *Sender) {
+	t.Helper()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestHook_SignsAndFilters(t *testing.T) {
+	rc := &receiver{}
+	s := newSender(t, rc, "s3cret")
+
+	s.Hook(context.Background(), "gateway.request.completed", map[string]any{"trace_id": "t1"})
+	s.Hook(context.Background(), "gateway.key.revoked", map[string]any{"key_id": "key-1"})
+	closeSender(t, s)
+
+	if len(rc.bodies) != 1 {
+		t.Fatalf("deliveries = %d, want only the key event", len(rc.bodies))
+	}
+	var got delivery
+	if err := json.Unmarshal(rc.bodies[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	h := rc.headers[0]
+	if got.Subject != "gateway.key.revoked" || got.Data["key_id"] != "key-1" || got.ID == "" {
+		t.Fatalf("body = %s", rc.bodies[0])
+	}
+	if h.Get(HeaderEvent) != got.Subject || h.Get(HeaderDelivery) != got.ID {
+		t.Fatalf("headers = %v", h)
+	}
+	if want := Sign([]byte("s3cret"), h.Get(HeaderTimestamp), rc.bodies[0]); h.Get(HeaderSignature) != want {
+		t.Fatalf("signature = %q, want %q", h.Get(HeaderSignature), want)
+	}
+}
+
+func TestHook_Unsigned(t *testing.T) {
+	rc := &receiver{}
+	s := newSender(t, rc, "")
+	s.Hook(context.Background(), "gateway.key.created", map[string]any{})
+	closeSender(t, s)
+	if len(rc.headers) != 1 || rc.headers[0].Get(HeaderSignature) != "" {
+		t.Fatalf("an unsigned sender should send no signature: %v", rc.headers)
+	}
+}
+
+func TestHook_Retries(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	s := newSender(t, rc, "")
+	s.Hook(context.Background(), "gateway.key.created", map[string]any{})
+	closeSender(t, s)
+	if len(rc.bodies) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(rc.bodies))
+	}
+	if rc.headers[0].Get(HeaderDelivery) != rc.headers[2].Get(HeaderDelivery) {
+		t.Fatal("a retry should keep the delivery ID")
+	}
+
+	rc = &receiver{statuses: []int{http.StatusBadRequest}}
+	s = newSender(t, rc, "")
+	s.Hook(context.Background(), "gateway.key.created", map[string]any{})
+	closeSender(t, s)
+	if len(rc.bodies) != 1 {
+		t.Fatalf("a 4xx should not be retried: %d attempts", len(rc.bodies))
+	}
+}
+
+// An event about a key with its own URL goes there as well as to the
+// sender's URL; one without goes only to the sender's.
+func TestHook_KeyURL(t *testing.T) {
+	shared, own := &receiver{}, &receiver{}
+	ownSrv := httptest.NewServer(own)
+	defer ownSrv.Close()
+	s := newSender(t, shared, "")
+
+	s.Hook(WithURL(context.Background(), ownSrv.URL), "gateway.key.created", map[string]any{"key_id": "key-1"})
+	s.Hook(context.Background(), "gateway.key.created", map[string]any{"key_id": "key-2"})
+	closeSender(t, s)
+	if len(shared.bodies) != 2 || len(own.bodies) != 1 {
+		t.Fatalf("deliveries: shared %d, key's own %d; want 2 and 1", len(shared.bodies), len(own.bodies))
+	}
+
+	// Without a URL of its own the sender only serves keys that have one.
+	own = &receiver{}
+	ownSrv2 := httptest.NewServer(own)
+	defer ownSrv2.Close()
+	s, err := New("", "", "gateway.key.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Hook(WithURL(context.Background(), ownSrv2.URL), "gateway.key.revoked", map[string]any{})
+	s.Hook(context.Background(), "gateway.key.revoked", map[string]any{})
+	closeSender(t, s)
+	if len(own.bodies) != 1 {
+		t.Fatalf("key's own deliveries = %d, want 1", len(own.bodies))
+	}
+}
+
+// Hook queues the event; a slow receiver does not hold up the publisher.
+func TestHook_DoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { <-release }))
+	defer srv.Close()
+	defer close(release)
+	s, err := New(srv.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for range queueSize + 10 {
+		s.Hook(context.Background(), "gateway.key.created", map[string]any{})
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Hook took %v with a stalled receiver", d)
+	}
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	for _, u := range []string{"hooks.example.com/key", "ftp://hooks.example.com", "https://"} {
+		if _, err := New(u, "", ""); err == nil {
+			t.Errorf("%q: expected an error", u)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package aigateway
+
+import (
+	"github.com/ferro-labs/ai-gateway/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SetMetricsRegisterer moves the gateway's Prometheus metrics out of
+// prometheus.DefaultRegisterer, where they are registered at start-up, into
+// reg, with prefix prepended to every metric name. It is for applications
+// that embed the gateway and already expose metrics of their own:
+//
+//	reg := prometheus.NewRegistry()
+//	if err := aigateway.SetMetricsRegisterer(reg, "myapp_"); err != nil { ... }
+//
+// A nil reg stops exporting them. The metrics are process-wide, so this
+// applies to every Gateway; call it before serving traffic. It fails, leaving
+// the metrics where they were, when reg already has a metric of the same name.
+func SetMetricsRegisterer(reg prometheus.Registerer, prefix string) error {
+	return metrics.SetRegisterer(reg, prefix)
+}
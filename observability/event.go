@@ -72,6 +72,9 @@ type Event struct {
 	Cost CostBreakdown
 	// Timestamp records when the event was constructed.
 	Timestamp time.Time
+	// CacheStatus is the response cache's verdict for the request ("hit" or
+	// "miss"), or empty when no cache plugin handled it.
+	CacheStatus string
 	// Attributes carries additional ferro.* and gen_ai.* attributes that
 	// don't fit into the typed fields above. Implementations MAY pass
 	// this through to the backing system verbatim.
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"reflect"
 	"runtime/debug"
+	"slices"
 	"sync"
 
 	"github.com/ferro-labs/ai-gateway/observability"
@@ -71,11 +72,18 @@ func rejectionReason(pctx *Context, err error) string {
 	return defaultRejectionReason
 }
 
+// registration is a plugin registered at one stage, with the compiled form of
+// its match rules. A nil match runs the plugin for every request.
+type registration struct {
+	plugin Plugin
+	match  *matcher
+}
+
 // Manager manages plugin lifecycle and execution.
 type Manager struct {
-	before      []Plugin
-	after       []Plugin
-	onErr       []Plugin
+	before      []registration
+	after       []registration
+	onErr       []registration
 	mu          sync.RWMutex
 	lifecycleMu sync.Mutex
 	lifecycle   *sync.Cond
@@ -115,34 +123,90 @@ func (m *Manager) Acquire() func() {
 	}
 }
 
-// Register registers a plugin at the given stage.
+// Register registers a plugin at the given stage. It runs for every request.
 func (m *Manager) Register(stage Stage, p Plugin) error {
+	return m.RegisterMatched(stage, p, nil)
+}
+
+// RegisterMatched registers a plugin at the given stage that runs only for
+// requests meeting match. A nil or empty match behaves like Register.
+func (m *Manager) RegisterMatched(stage Stage, p Plugin, match *Match) error {
+	if err := match.Validate(); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.Name(), err)
+	}
+	reg := registration{plugin: p, match: compileMatch(match)}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	switch stage {
 	case StageBeforeRequest:
-		m.before = append(m.before, p)
+		m.before = append(m.before, reg)
 	case StageAfterRequest:
-		m.after = append(m.after, p)
+		m.after = append(m.after, reg)
 	case StageOnError:
-		m.onErr = append(m.onErr, p)
+		m.onErr = append(m.onErr, reg)
 	default:
 		return fmt.Errorf("unknown plugin stage: %s", stage)
 	}
-	slog.Default().Info("plugin registered", "name", p.Name(), "type", p.Type(), "stage", stage)
+	slog.Default().Info("plugin registered", "name", p.Name(), "type", p.Type(), "stage", stage, "scoped", reg.match != nil)
 	return nil
 }
 
+// skipPluginsKey carries the names of the plugins a request opted out of.
+type skipPluginsKey struct{}
+
+// WithoutPlugins returns ctx marked so the named plugins do not run, at any
+// stage, for the request it carries. It is for trusted callers, such as an
+// embedder's admin tooling, and is never set from client input.
+func WithoutPlugins(ctx context.Context, names ...string) context.Context {
+	if len(names) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, skipPluginsKey{}, names)
+}
+
+// skippedPlugins returns the plugin names ctx opted out of.
+func skippedPlugins(ctx context.Context) []string {
+	names, _ := ctx.Value(skipPluginsKey{}).([]string)
+	return names
+}
+
+// applies reports whether reg runs for the request. The subject is resolved on
+// the first scoped plugin of a stage and reused for the rest of it, so a stage
+// with no scoped plugins pays nothing.
+func (reg registration) applies(ctx context.Context, pctx *Context, s **subject, skip []string) bool {
+	if len(skip) > 0 && slices.Contains(skip, reg.plugin.Name()) {
+		return false
+	}
+	if reg.match == nil {
+		return true
+	}
+	if *s == nil {
+		*s = resolveSubject(ctx, pctx)
+	}
+	return reg.match.matches(*s)
+}
+
 // RunBefore executes all before-request plugins. Fail-closed plugin errors or
 // rejections abort the request; fail-open plugin failures are logged and ignored.
 func (m *Manager) RunBefore(ctx context.Context, pctx *Context) error {
 	m.mu.RLock()
 	plugins := m.before
 	m.mu.RUnlock()
-	for _, p := range plugins {
+	var s *subject
+	skip := skippedPlugins(ctx)
+	for _, reg := range plugins {
+		if !reg.applies(ctx, pctx, &s, skip) {
+			continue
+		}
+		p := reg.plugin
 		err := m.executePlugin(ctx, p, pctx, string(StageBeforeRequest))
 		if failureErr := handlePluginFailure(p, StageBeforeRequest, pctx, err); failureErr != nil {
+			var rejection *RejectionError
+			if errors.As(failureErr, &rejection) {
+				m.notifyRejection(ctx, pctx, rejection, &s)
+			}
 			return failureErr
 		}
 		if pctx.Skip {
@@ -158,7 +222,13 @@ func (m *Manager) RunAfter(ctx context.Context, pctx *Context) error {
 	m.mu.RLock()
 	plugins := m.after
 	m.mu.RUnlock()
-	for _, p := range plugins {
+	var s *subject
+	skip := skippedPlugins(ctx)
+	for _, reg := range plugins {
+		if !reg.applies(ctx, pctx, &s, skip) {
+			continue
+		}
+		p := reg.plugin
 		err := m.executePlugin(ctx, p, pctx, string(StageAfterRequest))
 		if failureErr := handlePluginFailure(p, StageAfterRequest, pctx, err); failureErr != nil {
 			return failureErr
@@ -175,9 +245,14 @@ func (m *Manager) RunOnError(ctx context.Context, pctx *Context) {
 	m.mu.RLock()
 	plugins := m.onErr
 	m.mu.RUnlock()
-	for _, p := range plugins {
-		if err := m.executePlugin(ctx, p, pctx, string(StageOnError)); err != nil {
-			slog.Default().Warn("on-error plugin error", "plugin", p.Name(), "error", err)
+	var s *subject
+	skip := skippedPlugins(ctx)
+	for _, reg := range plugins {
+		if !reg.applies(ctx, pctx, &s, skip) {
+			continue
+		}
+		if err := m.executePlugin(ctx, reg.plugin, pctx, string(StageOnError)); err != nil {
+			slog.Default().Warn("on-error plugin error", "plugin", reg.plugin.Name(), "error", err)
 		}
 	}
 }
@@ -189,6 +264,13 @@ func (m *Manager) RunOnError(ctx context.Context, pctx *Context) {
 // privacy level. With the NoOp provider — or when no root span is set — the
 // child is a no-op and adds effectively zero overhead.
 func (m *Manager) executePlugin(ctx context.Context, p Plugin, pctx *Context, stage string) (err error) {
+	// Reject is sticky: once an earlier plugin set it, it stays set for the
+	// on_error plugins that follow. Only a plugin that sets it rejected.
+	rejectedBefore := pctx.Reject
+	rejected := func() bool { return pctx.Reject && !rejectedBefore }
+	// Registered first so it runs last, after a panic has become err.
+	defer func() { recordDecision(pctx, p.Name(), stage, rejected(), err) }()
+
 	var span observability.Span
 	if pctx.Span != nil {
 		ctx, span = pctx.Span.StartChild(ctx, "plugin."+stage+"."+p.Name(), observability.SpanKindInternal)
@@ -219,7 +301,7 @@ func (m *Manager) executePlugin(ctx context.Context, p Plugin, pctx *Context, st
 
 	if span != nil {
 		switch {
-		case pctx.Reject:
+		case rejected():
 			span.SetAttribute(observability.AttrFerroPluginOutcome, "rejected")
 			if pctx.Reason != "" {
 				span.SetAttribute(observability.AttrFerroPluginReason, pctx.Reason)
@@ -234,6 +316,55 @@ func (m *Manager) executePlugin(ctx context.Context, p Plugin, pctx *Context, st
 	return err
 }
 
+// recordDecision appends the outcome of one plugin run to pctx.Decisions and
+// consumes the plugin's Mutation note. rejected reports whether this run, not
+// an earlier one, set pctx.Reject.
+func recordDecision(pctx *Context, name, stage string, rejected bool, err error) {
+	d := Decision{Plugin: name, Stage: stage, Outcome: DecisionAllow}
+	switch {
+	case rejected:
+		d.Outcome = DecisionReject
+		d.Reason = rejectionReason(pctx, err)
+	case err != nil:
+		d.Outcome = DecisionError
+		d.Reason = err.Error()
+	case pctx.Mutation != "":
+		d.Outcome = DecisionMutate
+		d.Reason = pctx.Mutation
+	}
+	pctx.Mutation = ""
+	pctx.Decisions = append(pctx.Decisions, d)
+}
+
+// notifyRejection hands a before_request rejection to every applicable
+// RejectionObserver. An observer that panics is logged and skipped: recording
+// a rejection must not change how the request is answered.
+func (m *Manager) notifyRejection(ctx context.Context, pctx *Context, rejection *RejectionError, s **subject) {
+	m.mu.RLock()
+	stages := [][]registration{m.before, m.after, m.onErr}
+	m.mu.RUnlock()
+
+	notified := make(map[string]bool)
+	skip := skippedPlugins(ctx)
+	for _, regs := range stages {
+		for _, reg := range regs {
+			observer, ok := reg.plugin.(RejectionObserver)
+			if !ok || notified[reg.plugin.Name()] || !reg.applies(ctx, pctx, s, skip) {
+				continue
+			}
+			notified[reg.plugin.Name()] = true
+			func() {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						slog.Default().Error("rejection observer panicked", "plugin", reg.plugin.Name(), "panic", recovered)
+					}
+				}()
+				observer.ObserveRejection(ctx, pctx, rejection)
+			}()
+		}
+	}
+}
+
 // Close starts closing the manager, releases each registered plugin instance
 // once, and clears the manager. If requests are still using this manager, Close
 // returns immediately and cleanup runs after the active users drain.
@@ -270,10 +401,7 @@ func (m *Manager) closeWhenDrained() {
 
 func (m *Manager) closePlugins() error {
 	m.mu.Lock()
-	plugins := make([]Plugin, 0, len(m.before)+len(m.after)+len(m.onErr))
-	plugins = append(plugins, m.before...)
-	plugins = append(plugins, m.after...)
-	plugins = append(plugins, m.onErr...)
+	plugins := m.allLocked()
 	m.before = nil
 	m.after = nil
 	m.onErr = nil
@@ -318,6 +446,28 @@ type pluginInstanceKey struct {
 	ptr uintptr
 }
 
+// Plugins returns every registered plugin instance once, in before, after,
+// on-error registration order. A plugin registered at several stages appears
+// a single time.
+func (m *Manager) Plugins() []Plugin {
+	m.mu.RLock()
+	all := m.allLocked()
+	m.mu.RUnlock()
+	return uniquePluginInstances(all)
+}
+
+// allLocked lists every registered plugin in before, after, on-error order.
+// Caller must hold m.mu.
+func (m *Manager) allLocked() []Plugin {
+	all := make([]Plugin, 0, len(m.before)+len(m.after)+len(m.onErr))
+	for _, stage := range [][]registration{m.before, m.after, m.onErr} {
+		for _, reg := range stage {
+			all = append(all, reg.plugin)
+		}
+	}
+	return all
+}
+
 // HasPlugins returns true if any plugins are registered.
 func (m *Manager) HasPlugins() bool {
 	m.mu.RLock()
@@ -0,0 +1,205 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+)
+
+// Match scopes a plugin to part of the traffic. Each non-empty field is a
+// condition, and a request must meet every condition for the plugin to run; a
+// condition is met when any one of its values matches. A nil or empty Match
+// applies the plugin to every request, which is the default.
+//
+// The key, scope, and workspace conditions describe the authenticated API key,
+// so a request made without one never meets them.
+type Match struct {
+	// Models are model names the request must target. A "*" in a pattern
+	// matches any run of characters, slashes included: "gpt-4*" or
+	// "*/claude-*".
+	Models []string `json:"models,omitempty" yaml:"models,omitempty"`
+	// Keys are API key names or IDs.
+	Keys []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+	// Scopes are API key scopes; a key holding any of them matches.
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// Workspaces are the workspaces an API key may belong to.
+	Workspaces []string `json:"workspaces,omitempty" yaml:"workspaces,omitempty"`
+}
+
+// IsZero reports whether m places no condition on the traffic.
+func (m *Match) IsZero() bool {
+	return m == nil || len(m.Models)+len(m.Keys)+len(m.Scopes)+len(m.Workspaces) == 0
+}
+
+// Validate rejects empty values, which could never match anything and almost
+// always mean a templating mistake in the config.
+func (m *Match) Validate() error {
+	if m == nil {
+		return nil
+	}
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"models", m.Models},
+		{"keys", m.Keys},
+		{"scopes", m.Scopes},
+		{"workspaces", m.Workspaces},
+	} {
+		for _, v := range field.values {
+			if strings.TrimSpace(v) == "" {
+				return errors.New("match." + field.name + " contains an empty value")
+			}
+		}
+	}
+	return nil
+}
+
+// matcher is a Match compiled at registration, so the per-request check is a
+// few map lookups rather than a scan of the raw rule lists.
+type matcher struct {
+	models     map[string]struct{}
+	modelGlobs [][]string // each pattern split on "*"
+	keys       map[string]struct{}
+	scopes     map[string]struct{}
+	workspaces map[string]struct{}
+	needsKey   bool
+}
+
+// compileMatch returns nil for a Match that places no condition, letting the
+// manager skip the check entirely for unscoped plugins.
+func compileMatch(m *Match) *matcher {
+	if m.IsZero() {
+		return nil
+	}
+	c := &matcher{
+		keys:       toSet(m.Keys),
+		scopes:     toSet(m.Scopes),
+		workspaces: toSet(m.Workspaces),
+	}
+	c.needsKey = c.keys != nil || c.scopes != nil || c.workspaces != nil
+	for _, model := range m.Models {
+		if strings.Contains(model, "*") {
+			c.modelGlobs = append(c.modelGlobs, strings.Split(model, "*"))
+			continue
+		}
+		if c.models == nil {
+			c.models = make(map[string]struct{})
+		}
+		c.models[model] = struct{}{}
+	}
+	return c
+}
+
+func toSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func (c *matcher) hasModelRule() bool {
+	return c.models != nil || c.modelGlobs != nil
+}
+
+// matches reports whether the request described by s meets every condition.
+func (c *matcher) matches(s *subject) bool {
+	if c.hasModelRule() && !c.matchModel(s.model) {
+		return false
+	}
+	if !c.needsKey {
+		return true
+	}
+	if !s.hasKey {
+		return false
+	}
+	if c.keys != nil && !inSet(c.keys, s.keyName) && !inSet(c.keys, s.keyID) {
+		return false
+	}
+	if c.workspaces != nil && !inSet(c.workspaces, s.workspace) {
+		return false
+	}
+	if c.scopes != nil {
+		for _, scope := range s.scopes {
+			if inSet(c.scopes, scope) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (c *matcher) matchModel(model string) bool {
+	if model == "" {
+		return false
+	}
+	if _, ok := c.models[model]; ok {
+		return true
+	}
+	for _, parts := range c.modelGlobs {
+		if globMatch(parts, model) {
+			return true
+		}
+	}
+	return false
+}
+
+func inSet(set map[string]struct{}, v string) bool {
+	if v == "" {
+		return false
+	}
+	_, ok := set[v]
+	return ok
+}
+
+// globMatch reports whether s matches a pattern already split on "*": it must
+// start with the first part, end with the last, and contain the rest in order
+// between them.
+func globMatch(parts []string, s string) bool {
+	first, last := parts[0], parts[len(parts)-1]
+	if len(s) < len(first)+len(last) || !strings.HasPrefix(s, first) || !strings.HasSuffix(s, last) {
+		return false
+	}
+	s = s[len(first) : len(s)-len(last)]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return true
+}
+
+// subject is what match rules are evaluated against. A stage run resolves it
+// at most once, and only when it reaches a scoped plugin.
+type subject struct {
+	model     string
+	hasKey    bool
+	keyID     string
+	keyName   string
+	scopes    []string
+	workspace string
+}
+
+func resolveSubject(ctx context.Context, pctx *Context) *subject {
+	s := &subject{}
+	if pctx.Request != nil {
+		s.model = pctx.Request.Model
+	}
+	s.keyID, s.hasKey = authctx.KeyID(ctx)
+	if id, ok := authctx.Identity(ctx); ok {
+		s.hasKey = true
+		s.keyName = id.Name
+		s.scopes = id.Scopes
+		s.workspace = id.Workspace
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/internal/authctx"
+	"github.com/ferro-labs/ai-gateway/providers"
+)
+
+func TestGlobMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"gpt-4*", "gpt-4o", true},
+		{"gpt-4*", "gpt-4", true},
+		{"gpt-4*", "gpt-3.5-turbo", false},
+		{"*/claude-*", "openrouter/anthropic/claude-sonnet-4-6", true},
+		{"*mini*", "gpt-4o-mini-2024", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "acb", false},
+		{"ab*ba", "aba", false},
+		{"*", "anything", true},
+	} {
+		c := compileMatch(&Match{Models: []string{tt.pattern}})
+		if got := c.matchModel(tt.s); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	keyed := &subject{model: "gpt-4o", hasKey: true, keyID: "id-1", keyName: "billing-svc", scopes: []string{"read_only"}, workspace: "payments"}
+	anonymous := &subject{model: "gpt-4o"}
+
+	for _, tt := range []struct {
+		name  string
+		match Match
+		s     *subject
+		want  bool
+	}{
+		{"exact model", Match{Models: []string{"gpt-4o"}}, anonymous, true},
+		{"other model", Match{Models: []string{"claude-*"}}, anonymous, false},
+		{"key by name", Match{Keys: []string{"billing-svc"}}, keyed, true},
+		{"key by id", Match{Keys: []string{"id-1"}}, keyed, true},
+		{"key rule without a key", Match{Keys: []string{"billing-svc"}}, anonymous, false},
+		{"any scope", Match{Scopes: []string{"admin", "read_only"}}, keyed, true},
+		{"missing scope", Match{Scopes: []string{"admin"}}, keyed, false},
+		{"workspace", Match{Workspaces: []string{"payments"}}, keyed, true},
+		{"all conditions", Match{Models: []string{"gpt-*"}, Workspaces: []string{"payments"}, Scopes: []string{"read_only"}}, keyed, true},
+		{"one condition fails", Match{Models: []string{"claude-*"}, Workspaces: []string{"payments"}}, keyed, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compileMatch(&tt.match).matches(tt.s); got != tt.want {
+				t.Fatalf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch_ZeroAndValidate(t *testing.T) {
+	if compileMatch(nil) != nil || compileMatch(&Match{}) != nil {
+		t.Fatal("an empty match must compile to nil so unscoped plugins skip the check")
+	}
+	if err := (&Match{Keys: []string{" "}}).Validate(); err == nil {
+		t.Fatal("expected a blank value to be rejected")
+	}
+	var nilMatch *Match
+	if err := nilMatch.Validate(); err != nil {
+		t.Fatalf("nil match: %v", err)
+	}
+}
+
+func TestManager_RegisterMatched(t *testing.T) {
+	m := NewManager()
+	var scopedRuns, globalRuns int
+	scoped := &mockPlugin{name: "scoped", typ: TypeGuardrail, execFn: func(context.Context, *Context) error {
+		scopedRuns++
+		return nil
+	}}
+	global := &mockPlugin{name: "global", typ: TypeGuardrail, execFn: func(context.Context, *Context) error {
+		globalRuns++
+		return nil
+	}}
+	if err := m.RegisterMatched(StageBeforeRequest, scoped, &Match{Models: []string{"gpt-4*"}, Workspaces: []string{"payments"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register(StageBeforeRequest, global); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RegisterMatched(StageBeforeRequest, global, &Match{Keys: []string{""}}); err == nil {
+		t.Fatal("expected an invalid match to be refused")
+	}
+
+	run := func(ctx context.Context, model string) {
+		t.Helper()
+		pctx := NewContext(&providers.Request{Model: model})
+		defer PutContext(pctx)
+		if err := m.RunBefore(ctx, pctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	payments := authctx.WithIdentity(authctx.WithKeyID(context.Background(), "id-1"), authctx.KeyIdentity{Workspace: "payments"})
+
+	run(payments, "gpt-4o")
+	run(payments, "claude-sonnet-4-6")
+	run(context.Background(), "gpt-4o")
+
+	if scopedRuns != 1 {
+		t.Fatalf("scoped plugin ran %d times, want 1", scopedRuns)
+	}
+	if globalRuns != 3 {
+		t.Fatalf("unscoped plugin ran %d times, want 3", globalRuns)
+	}
+}
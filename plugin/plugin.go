@@ -35,7 +35,10 @@ package plugin
 
 import (
 	"context"
+	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ferro-labs/ai-gateway/observability"
 	"github.com/ferro-labs/ai-gateway/providers"
@@ -78,6 +81,34 @@ const (
 	TypeRateLimit PluginType = "ratelimit"
 )
 
+// Completer runs a chat completion on the provider that serves req.Model,
+// outside the request pipeline: no routing strategy, plugin, or limit applies.
+type Completer interface {
+	Complete(ctx context.Context, req providers.Request) (*providers.Response, error)
+}
+
+// CompleterReceiver is implemented by plugins that call a model of their own,
+// such as to summarize a conversation. The gateway supplies its Completer
+// before Init.
+type CompleterReceiver interface {
+	SetCompleter(Completer)
+}
+
+// QuotaNotifier receives a plugin's report that an API key, named by its ID
+// as in Context.Metadata["api_key"], has run out of a quota the plugin
+// enforces. The gateway publishes it as a key lifecycle event.
+type QuotaNotifier interface {
+	QuotaExceeded(ctx context.Context, keyID, reason string)
+}
+
+// QuotaNotifierReceiver is implemented by plugins that enforce a per-key
+// quota, such as a spend limit. The gateway supplies its QuotaNotifier before
+// Init. A plugin reports each key once per exhaustion, not once per refused
+// request.
+type QuotaNotifierReceiver interface {
+	SetQuotaNotifier(QuotaNotifier)
+}
+
 // Stage defines when a plugin runs in the request lifecycle.
 type Stage string
 
@@ -94,7 +125,9 @@ type Context struct {
 	Response *providers.Response
 	// Metadata carries key/value data shared between plugins and stages (for
 	// example "api_key" or "cache_hit"). Writing Metadata never alters pipeline
-	// control flow; it only passes information along.
+	// control flow; it only passes information along. A streamed Response
+	// whose text was cut at the gateway's capture limit is marked
+	// "response_truncated".
 	Metadata map[string]any
 	// Error holds the provider or pipeline error surfaced to the after_request
 	// and on_error stages so plugins can observe it. Setting it does not by
@@ -119,6 +152,124 @@ type Context struct {
 	// observability seam); when nil no plugin spans are emitted. Setting it
 	// never alters pipeline control flow.
 	Span observability.Span
+	// Decisions records, in order, the outcome of every plugin that has run
+	// for the request so far. The manager appends to it; plugins read it
+	// (the request logger persists it with the request).
+	Decisions []Decision
+	// Mutation, set by a plugin that rewrote the request or response,
+	// describes the change: history-compress reports "summarized 6 older
+	// messages", the response cache "served a cached response (hit)". The
+	// manager records it as the plugin's mutate decision and clears it before
+	// the next plugin runs. It never alters pipeline control flow.
+	Mutation string
+	// ResponseHeaders and ResponseFields hold what plugins declared for the
+	// client through SetResponseHeader and SetResponseField. The gateway
+	// forwards only the names its expose_plugin_metadata allowlist names;
+	// the rest never leave it.
+	ResponseHeaders map[string]string
+	ResponseFields  map[string]any
+	// Diagnostics describes the failure the on_error stage runs for, so an
+	// alerting or dead-letter plugin can tell which providers were tried and
+	// how each failed. The gateway sets it just before on_error plugins run;
+	// it is nil at every other stage.
+	Diagnostics *ErrorDiagnostics
+}
+
+// ErrorDiagnostics is what the gateway knows about a failed request. Error
+// text and provider bodies are redacted of recognised credentials, the same
+// best-effort redaction applied to the request log.
+type ErrorDiagnostics struct {
+	// Attempts lists the provider calls the request made, in order, including
+	// retries and fallbacks. It is empty when the request failed before
+	// reaching a provider, for example when no target serves its model.
+	Attempts []Attempt
+	// StatusCode is the HTTP status the client is answered with.
+	StatusCode int
+	// ProviderBody is the error body of the last provider response that
+	// failed, or "" when no provider answered with an error status.
+	ProviderBody string
+	// Latency is the time from the request's arrival to its failure.
+	Latency time.Duration
+}
+
+// Attempt is one provider call of a request.
+type Attempt struct {
+	// Target is the provider the call went to, and Model the model it asked
+	// for.
+	Target string
+	Model  string
+	// StatusCode is the provider's HTTP status for a failed call, or 0 when
+	// the call succeeded or failed without a response, such as on a timeout.
+	StatusCode int
+	// Error is the call's error message, or "" when it succeeded. A stream
+	// counts as succeeded once it started.
+	Error   string
+	Latency time.Duration
+}
+
+// SetResponseHeader declares a response header for the client, such as
+// "X-Ferro-Guardrail: word-filter=pass". Values several plugins set for one
+// header are joined with ", ", and a value already present is not repeated.
+// Headers reach the client only when allowlisted; a streamed response carries
+// those set before the stream starts, by before_request plugins.
+func (c *Context) SetResponseHeader(name, value string) {
+	name = http.CanonicalHeaderKey(name)
+	if c.ResponseHeaders == nil {
+		c.ResponseHeaders = make(map[string]string, 2)
+	}
+	existing, ok := c.ResponseHeaders[name]
+	if !ok || existing == "" {
+		c.ResponseHeaders[name] = value
+		return
+	}
+	for rest := existing; rest != ""; {
+		var v string
+		v, rest, _ = strings.Cut(rest, ", ")
+		if v == value {
+			return
+		}
+	}
+	c.ResponseHeaders[name] = existing + ", " + value
+}
+
+// SetResponseField declares a field for the plugin_metadata object of the
+// JSON response. Fields reach the client only when allowlisted, and only on
+// non-streaming responses.
+func (c *Context) SetResponseField(name string, value any) {
+	if c.ResponseFields == nil {
+		c.ResponseFields = make(map[string]any, 2)
+	}
+	c.ResponseFields[name] = value
+}
+
+// Decision outcomes.
+const (
+	DecisionAllow  = "allow"
+	DecisionReject = "reject"
+	DecisionMutate = "mutate"
+	DecisionError  = "error"
+)
+
+// Decision is the outcome of one plugin run.
+type Decision struct {
+	Plugin string `json:"plugin"`
+	Stage  string `json:"stage"`
+	// Outcome is DecisionAllow, DecisionReject, DecisionMutate, or
+	// DecisionError.
+	Outcome string `json:"outcome"`
+	// Reason is the rejection reason, the mutation description, or the
+	// error message.
+	Reason string `json:"reason,omitempty"`
+}
+
+// RejectionObserver is implemented by plugins that record requests rejected
+// by other plugins, such as the request logger. When a before_request plugin
+// rejects a request, the manager calls ObserveRejection on every registered
+// plugin that implements it and applies to the request, whichever stage it is
+// registered at and whether or not it ran. A plugin registered at several
+// stages is called once.
+type RejectionObserver interface {
+	ObserveRejection(ctx context.Context, pctx *Context, rejection *RejectionError)
 }
 
 // pluginContextPool recycles Context objects to reduce GC pressure.
@@ -149,17 +300,22 @@ func PutContext(c *Context) {
 	pluginContextPool.Put(c)
 }
 
-// reset clears all 8 fields before returning to the pool.
-// Metadata map entries are deleted but the map itself is kept
-// to preserve its bucket array capacity for the next request.
+// reset clears all 13 fields before returning to the pool.
+// Map entries are deleted but the maps themselves are kept
+// to preserve their bucket array capacity for the next request.
 // SECURITY: every field must be listed explicitly.
 func (c *Context) reset() {
-	c.Request = nil   // field 1: *providers.Request
-	c.Response = nil  // field 2: *providers.Response
-	clear(c.Metadata) // field 3: map[string]interface{} — clear entries, keep capacity
-	c.Error = nil     // field 4: error
-	c.Skip = false    // field 5: bool
-	c.Reject = false  // field 6: bool
-	c.Reason = ""     // field 7: string
-	c.Span = nil      // field 8: observability.Span
+	c.Request = nil          // field 1: *providers.Request
+	c.Response = nil         // field 2: *providers.Response
+	clear(c.Metadata)        // field 3: map[string]interface{} — clear entries, keep capacity
+	c.Error = nil            // field 4: error
+	c.Skip = false           // field 5: bool
+	c.Reject = false         // field 6: bool
+	c.Reason = ""            // field 7: string
+	c.Span = nil             // field 8: observability.Span
+	c.Decisions = nil        // field 9: []Decision
+	c.Mutation = ""          // field 10: string
+	clear(c.ResponseHeaders) // field 11: map[string]string
+	clear(c.ResponseFields)  // field 12: map[string]any
+	c.Diagnostics = nil      // field 13: *ErrorDiagnostics
 }
@@ -62,6 +62,30 @@ func TestManager_Register(t *testing.T) {
 	}
 }
 
+func TestManager_WithoutPlugins(t *testing.T) {
+	m := NewManager()
+	var ran []string
+	for _, name := range []string{"keep", "drop"} {
+		_ = m.Register(StageBeforeRequest, &mockPlugin{
+			name: name,
+			typ:  TypeGuardrail,
+			execFn: func(_ context.Context, _ *Context) error {
+				ran = append(ran, name)
+				return nil
+			},
+		})
+	}
+
+	pctx := NewContext(&providers.Request{Model: "gpt-4o"})
+	defer PutContext(pctx)
+	if err := m.RunBefore(WithoutPlugins(context.Background(), "drop"), pctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 1 || ran[0] != "keep" {
+		t.Fatalf("ran %v, want only [keep]", ran)
+	}
+}
+
 func TestManager_RunBefore(t *testing.T) {
 	m := NewManager()
 	called := false
@@ -213,6 +237,148 @@ func TestManager_RunBefore_FailOpenPluginFailureDoesNotAbort(t *testing.T) {
 	}
 }
 
+func TestManager_RecordsDecisions(t *testing.T) {
+	m := NewManager()
+	_ = m.Register(StageBeforeRequest, &mockPlugin{name: "allow", typ: TypeGuardrail})
+	_ = m.Register(StageBeforeRequest, &mockPlugin{
+		name: "flaky-log",
+		typ:  TypeLogging,
+		execFn: func(context.Context, *Context) error {
+			panic("sink down")
+		},
+	})
+	_ = m.Register(StageBeforeRequest, &mockPlugin{
+		name: "blocker",
+		typ:  TypeGuardrail,
+		execFn: func(_ context.Context, pctx *Context) error {
+			pctx.Reject = true
+			pctx.Reason = "blocked"
+			return nil
+		},
+	})
+
+	pctx := NewContext(&providers.Request{Model: "gpt-4o"})
+	defer PutContext(pctx)
+	if err := m.RunBefore(context.Background(), pctx); err == nil {
+		t.Fatal("expected rejection error")
+	}
+
+	want := []Decision{
+		{Plugin: "allow", Stage: "before_request", Outcome: DecisionAllow},
+		{Plugin: "flaky-log", Stage: "before_request", Outcome: DecisionError, Reason: "plugin flaky-log panicked at before_request"},
+		{Plugin: "blocker", Stage: "before_request", Outcome: DecisionReject, Reason: "blocked"},
+	}
+	if len(pctx.Decisions) != len(want) {
+		t.Fatalf("decisions = %+v, want %+v", pctx.Decisions, want)
+	}
+	for i := range want {
+		if pctx.Decisions[i] != want[i] {
+			t.Errorf("decision %d = %+v, want %+v", i, pctx.Decisions[i], want[i])
+		}
+	}
+}
+
+func TestManager_RecordsMutation(t *testing.T) {
+	m := NewManager()
+	_ = m.Register(StageBeforeRequest, &mockPlugin{
+		name: "pii-redactor",
+		typ:  TypeTransform,
+		execFn: func(_ context.Context, pctx *Context) error {
+			pctx.Request.Messages[0].Content = "[redacted]"
+			pctx.Mutation = "redacted 1 email address"
+			return nil
+		},
+	})
+	_ = m.Register(StageBeforeRequest, &mockPlugin{name: "after", typ: TypeGuardrail})
+
+	pctx := NewContext(&providers.Request{Messages: []providers.Message{{Role: "user", Content: "a@b.co"}}})
+	defer PutContext(pctx)
+	if err := m.RunBefore(context.Background(), pctx); err != nil {
+		t.Fatalf("RunBefore: %v", err)
+	}
+	if d := pctx.Decisions[0]; d.Outcome != DecisionMutate || d.Reason != "redacted 1 email address" {
+		t.Fatalf("mutation not recorded: %+v", d)
+	}
+	if d := pctx.Decisions[1]; d.Outcome != DecisionAllow {
+		t.Fatalf("mutation note leaked into the next plugin's decision: %+v", d)
+	}
+	if pctx.Mutation != "" {
+		t.Fatal("Mutation should be consumed by the manager")
+	}
+}
+
+// A rejection stays on the context for the on_error plugins that follow, but
+// it is not theirs.
+func TestManager_RecordsRejectionOnlyForTheRejectingPlugin(t *testing.T) {
+	m := NewManager()
+	_ = m.Register(StageAfterRequest, &mockPlugin{
+		name: "output-guard",
+		typ:  TypeGuardrail,
+		execFn: func(_ context.Context, pctx *Context) error {
+			pctx.Reject = true
+			pctx.Reason = "unsafe output"
+			return nil
+		},
+	})
+	_ = m.Register(StageOnError, &mockPlugin{name: "alerter", typ: TypeLogging})
+
+	pctx := NewContext(&providers.Request{Model: "gpt-4o"})
+	defer PutContext(pctx)
+	if err := m.RunAfter(context.Background(), pctx); err == nil {
+		t.Fatal("expected rejection error")
+	}
+	m.RunOnError(context.Background(), pctx)
+
+	want := []Decision{
+		{Plugin: "output-guard", Stage: "after_request", Outcome: DecisionReject, Reason: "unsafe output"},
+		{Plugin: "alerter", Stage: "on_error", Outcome: DecisionAllow},
+	}
+	if len(pctx.Decisions) != len(want) || pctx.Decisions[0] != want[0] || pctx.Decisions[1] != want[1] {
+		t.Fatalf("decisions = %+v, want %+v", pctx.Decisions, want)
+	}
+}
+
+type observingPlugin struct {
+	mockPlugin
+	seen []*RejectionError
+}
+
+func (o *observingPlugin) ObserveRejection(_ context.Context, _ *Context, rejection *RejectionError) {
+	o.seen = append(o.seen, rejection)
+}
+
+func TestManager_NotifiesRejectionObservers(t *testing.T) {
+	m := NewManager()
+	observer := &observingPlugin{mockPlugin: mockPlugin{name: "audit", typ: TypeLogging}}
+	_ = m.Register(StageBeforeRequest, &mockPlugin{
+		name: "blocker",
+		typ:  TypeGuardrail,
+		execFn: func(_ context.Context, pctx *Context) error {
+			pctx.Reject = true
+			pctx.Reason = "blocked word"
+			return nil
+		},
+	})
+	// Registered after the blocker and at two stages: it never runs, but is
+	// told about the rejection exactly once.
+	_ = m.Register(StageBeforeRequest, observer)
+	_ = m.Register(StageAfterRequest, observer)
+	scoped := &observingPlugin{mockPlugin: mockPlugin{name: "scoped", typ: TypeLogging}}
+	_ = m.RegisterMatched(StageAfterRequest, scoped, &Match{Models: []string{"other-model"}})
+
+	pctx := NewContext(&providers.Request{Model: "gpt-4o"})
+	defer PutContext(pctx)
+	if err := m.RunBefore(context.Background(), pctx); err == nil {
+		t.Fatal("expected rejection error")
+	}
+	if len(observer.seen) != 1 || observer.seen[0].Plugin != "blocker" || observer.seen[0].Reason != "blocked word" {
+		t.Fatalf("observer calls = %+v", observer.seen)
+	}
+	if len(scoped.seen) != 0 {
+		t.Fatal("an observer whose match rules exclude the request was notified")
+	}
+}
+
 func TestManager_RunAfter(t *testing.T) {
 	m := NewManager()
 	called := false
@@ -462,6 +628,20 @@ func TestManager_CloseReturnsWhileActiveAndClosesAfterRelease(t *testing.T) {
 	}
 }
 
+func TestManager_PluginsListsEachInstanceOnce(t *testing.T) {
+	m := NewManager()
+	shared := &mockPlugin{name: "shared", typ: TypeTransform}
+	other := &mockPlugin{name: "other", typ: TypeLogging}
+	_ = m.Register(StageBeforeRequest, shared)
+	_ = m.Register(StageAfterRequest, shared)
+	_ = m.Register(StageOnError, other)
+
+	got := m.Plugins()
+	if len(got) != 2 || got[0] != Plugin(shared) || got[1] != Plugin(other) {
+		t.Fatalf("Plugins() = %v, want [shared other]", got)
+	}
+}
+
 func TestManager_NoPlugins(t *testing.T) {
 	m := NewManager()
 	if m.HasPlugins() {
@@ -549,3 +729,19 @@ func TestManager_RunBefore_SnapshotIsolation(t *testing.T) {
 		t.Error("subsequent RunBefore must execute the newly registered second plugin")
 	}
 }
+
+func TestContext_SetResponseHeader(t *testing.T) {
+	pctx := NewContext(nil)
+	pctx.SetResponseHeader("x-ferro-guardrail", "word-filter=pass")
+	pctx.SetResponseHeader("X-Ferro-Guardrail", "pii=pass")
+	pctx.SetResponseHeader("X-Ferro-Guardrail", "word-filter=pass")
+	if got := pctx.ResponseHeaders["X-Ferro-Guardrail"]; got != "word-filter=pass, pii=pass" {
+		t.Errorf("X-Ferro-Guardrail = %q, want both verdicts once", got)
+	}
+	pctx.SetResponseField("guardrail", "pass")
+
+	PutContext(pctx)
+	if len(pctx.ResponseHeaders) != 0 || len(pctx.ResponseFields) != 0 {
+		t.Error("a pooled context kept the previous request's response metadata")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"strings"
@@ -118,9 +119,15 @@ type anthropicRequest struct {
 	ToolChoice    any                     `json:"tool_choice,omitempty"`
 	Temperature   *float64                `json:"temperature,omitempty"`
 	TopP          *float64                `json:"top_p,omitempty"`
+	TopK          *int                    `json:"top_k,omitempty"`
 	StopSequences []string                `json:"stop_sequences,omitempty"`
 	Metadata      *anthropicMetadata      `json:"metadata,omitempty"`
+	Thinking      *anthropicwire.Thinking `json:"thinking,omitempty"`
 	Stream        bool                    `json:"stream,omitempty"`
+
+	// Extra holds the allowlisted extra_body parameters (thinking, top_k,
+	// service_tier), merged into the body by newMessagesRequest.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // anthropicMetadata carries the optional request metadata; user_id maps the
@@ -232,31 +239,46 @@ func buildAnthropicRequest(ctx context.Context, req core.Request, stream bool) a
 		maxTokens = *req.MaxTokens
 	}
 
+	thinking, maxTokens := anthropicwire.MapThinking(ctx, Name, req, maxTokens, defaultMaxTokens)
+
 	var metadata *anthropicMetadata
 	if req.User != "" {
 		metadata = &anthropicMetadata{UserID: req.User}
 	}
 
-	return anthropicRequest{
+	aReq := anthropicRequest{
 		Model:         req.Model,
 		MaxTokens:     maxTokens,
 		Messages:      messages,
 		Temperature:   anthropicwire.ClampTemperature(ctx, Name, req.Model, req.Temperature),
 		TopP:          req.TopP,
+		TopK:          req.TopK,
 		StopSequences: req.Stop,
 		System:        system,
 		Tools:         anthropicwire.MapTools(req.Tools),
 		ToolChoice:    anthropicwire.MapToolChoice(req.ToolChoice, req.Tools),
 		Metadata:      metadata,
+		Thinking:      thinking,
 		Stream:        stream,
+		Extra:         req.Extra,
+	}
+	if thinking != nil {
+		if _, ok := req.Extra["thinking"]; ok {
+			aReq.Extra = maps.Clone(req.Extra)
+			delete(aReq.Extra, "thinking")
+		}
+	}
+	if thinking.Enabled() {
+		aReq.Temperature, aReq.TopP, aReq.TopK = nil, nil, nil
 	}
+	return aReq
 }
 
 // newMessagesRequest sends a POST to the Anthropic /v1/messages endpoint with the
 // standard authentication and version headers. The returned release frees the
 // pooled request body and must be called by the caller.
 func (p *Provider) newMessagesRequest(ctx context.Context, aReq anthropicRequest) (*http.Response, func(), error) {
-	bodyReader, _, release, err := core.JSONBodyReader(aReq)
+	bodyReader, _, release, err := core.JSONBodyReaderExtra(aReq, aReq.Extra)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -324,9 +346,10 @@ func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Respon
 			{
 				Index: 0,
 				Message: core.Message{
-					Role:      aResp.Role,
-					Content:   content,
-					ToolCalls: toolCalls,
+					Role:             aResp.Role,
+					Content:          content,
+					ReasoningContent: anthropicwire.DecodeThinking(aResp.Content),
+					ToolCalls:        toolCalls,
 				},
 				FinishReason: core.NormalizeFinishReason(aResp.StopReason),
 			},
@@ -15,7 +15,7 @@ func f64(f float64) *float64 { return &f }
 func i64(i int64) *int64     { return &i }
 
 // TestComplete_MapsSupportedParams_DropsRest verifies #140 native wiring:
-// top_p and stop map to the Anthropic field names, and params the Messages API
+// top_p, top_k, and stop map to the Anthropic field names, and params the Messages API
 // cannot express are not forwarded.
 func TestComplete_MapsSupportedParams_DropsRest(t *testing.T) {
 	var captured map[string]json.RawMessage
@@ -32,17 +32,19 @@ func TestComplete_MapsSupportedParams_DropsRest(t *testing.T) {
 		t.Fatalf("New: %v", err)
 	}
 
+	topK := 40
 	_, _ = p.Complete(context.Background(), core.Request{
 		Model:           "claude-3-5-sonnet",
 		Messages:        []core.Message{{Role: "user", Content: "hi"}},
 		TopP:            f64(0.9),
+		TopK:            &topK,
 		Stop:            []string{"END"},
 		PresencePenalty: f64(0.5), // unsupported → dropped
 		Seed:            i64(42),  // unsupported → dropped
 		LogitBias:       map[string]float64{"1": -1},
 	})
 
-	for _, k := range []string{"top_p", "stop_sequences"} {
+	for _, k := range []string{"top_p", "top_k", "stop_sequences"} {
 		if _, ok := captured[k]; !ok {
 			t.Errorf("expected %q forwarded; keys=%v", k, mapKeys(captured))
 		}
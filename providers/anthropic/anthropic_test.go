@@ -407,6 +407,33 @@ func TestComplete_MapsUserToMetadata(t *testing.T) {
 	}
 }
 
+// TestComplete_MapsThinking verifies the thinking option becomes Anthropic's
+// thinking parameter, replacing one sent in extra_body, and that the sampling
+// parameters Anthropic rejects alongside thinking are dropped.
+func TestComplete_MapsThinking(t *testing.T) {
+	body := captureBody(t, core.Request{
+		Model:       "claude-sonnet-4-6",
+		Messages:    []core.Message{{Role: core.RoleUser, Content: "hi"}},
+		MaxTokens:   intPtr(2000),
+		Temperature: floatPtr(0.3),
+		TopK:        intPtr(40),
+		Thinking:    &core.Thinking{Enabled: true, BudgetTokens: 4000},
+		Extra:       map[string]json.RawMessage{"thinking": json.RawMessage(`{"type":"disabled"}`)},
+	})
+
+	if got := string(body["thinking"]); got != `{"type":"enabled","budget_tokens":4000}` {
+		t.Errorf("thinking = %s", got)
+	}
+	if got := string(body["max_tokens"]); got != "5024" {
+		t.Errorf("max_tokens = %s, want the budget plus the default reply length", got)
+	}
+	for _, name := range []string{"temperature", "top_k", "top_p"} {
+		if _, ok := body[name]; ok {
+			t.Errorf("%s sent alongside thinking", name)
+		}
+	}
+}
+
 type wireImageBlock struct {
 	Type   string `json:"type"`
 	Source struct {
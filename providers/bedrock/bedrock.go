@@ -67,6 +67,8 @@ type Provider struct {
 	client      bedrockRuntimeClient
 	region      string
 	bearerToken string
+	// credentials resolves the SigV4 credentials; nil in bearer-token mode.
+	credentials aws.CredentialsProvider
 }
 
 // Compile-time interface assertions.
@@ -77,6 +79,7 @@ var (
 	_ core.ImageProvider         = (*Provider)(nil)
 	_ core.ProxiableProvider     = (*Provider)(nil)
 	_ core.NonOpenAIWireProvider = (*Provider)(nil)
+	_ core.Warmer                = (*Provider)(nil)
 )
 
 // New creates a new AWS Bedrock provider.
@@ -142,12 +145,16 @@ func NewWithOptions(opts Options) (*Provider, error) {
 	cfg.HTTPClient = providerhttp.ForProvider(Name)
 
 	client := realBedrockClient{bedrockruntime.NewFromConfig(cfg, clientOpts...)}
-	return &Provider{
+	p := &Provider{
 		name:        Name,
 		client:      client,
 		region:      region,
 		bearerToken: bearerToken,
-	}, nil
+	}
+	if bearerToken == "" {
+		p.credentials = cfg.Credentials
+	}
+	return p, nil
 }
 
 // Name implements core.Provider.
@@ -161,6 +168,20 @@ func (p *Provider) BaseURL() string {
 	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", p.region)
 }
 
+// Warm implements core.Warmer. Loading the AWS config only sets up the
+// default credential chain; the credentials themselves (from the environment,
+// a profile, SSO, or the instance metadata service) are fetched on the first
+// signed request. Warm fetches them ahead of it.
+func (p *Provider) Warm(ctx context.Context) error {
+	if p.credentials == nil {
+		return nil
+	}
+	if _, err := p.credentials.Retrieve(ctx); err != nil {
+		return fmt.Errorf("bedrock: retrieve AWS credentials: %w", err)
+	}
+	return nil
+}
+
 // NonOpenAIWire marks Bedrock as ineligible for transparent OpenAI-wire proxy
 // pass-through: its upstream is the AWS Bedrock API (SigV4-signed and not
 // OpenAI-shaped). It remains fully usable via its native translated endpoints,
@@ -21,6 +21,7 @@ type bedrockAnthropicRequest struct {
 	TopP             *float64                `json:"top_p,omitempty"`
 	StopSequences    []string                `json:"stop_sequences,omitempty"`
 	System           string                  `json:"system,omitempty"`
+	Thinking         *anthropicwire.Thinking `json:"thinking,omitempty"`
 }
 
 // bedrockAnthropicDefaultMaxTokens is the max_tokens applied when a request does
@@ -53,7 +54,8 @@ func buildBedrockAnthropicRequest(ctx context.Context, req core.Request) (bedroc
 	// Note: the native Anthropic provider maps the OpenAI "user" field to
 	// metadata.user_id, but AWS Bedrock's InvokeModel Anthropic schema does not
 	// document a metadata field, so it is intentionally not forwarded here.
-	return bedrockAnthropicRequest{
+	thinking, maxTokens := anthropicwire.MapThinking(ctx, Name, req, maxTokens, bedrockAnthropicDefaultMaxTokens)
+	aReq := bedrockAnthropicRequest{
 		AnthropicVersion: "bedrock-2023-05-31",
 		MaxTokens:        maxTokens,
 		Messages:         messages,
@@ -63,7 +65,12 @@ func buildBedrockAnthropicRequest(ctx context.Context, req core.Request) (bedroc
 		TopP:             req.TopP,
 		StopSequences:    req.Stop,
 		System:           system,
-	}, nil
+		Thinking:         thinking,
+	}
+	if thinking.Enabled() {
+		aReq.Temperature, aReq.TopP = nil, nil
+	}
+	return aReq, nil
 }
 
 // bedrockAnthropicContent renders a non-system message's content for Bedrock's
@@ -167,8 +174,13 @@ func (p *Provider) completeAnthropic(ctx context.Context, req core.Request) (*co
 		Model:    req.Model,
 		Provider: p.name,
 		Choices: []core.Choice{{
-			Index:        0,
-			Message:      core.Message{Role: core.RoleAssistant, Content: text, ToolCalls: toolCalls},
+			Index: 0,
+			Message: core.Message{
+				Role:             core.RoleAssistant,
+				Content:          text,
+				ReasoningContent: anthropicwire.DecodeThinking(anthropicResp.Content),
+				ToolCalls:        toolCalls,
+			},
 			FinishReason: core.NormalizeFinishReason(anthropicResp.StopReason),
 		}},
 		Usage: core.Usage{
@@ -206,6 +206,12 @@ func (p *Provider) embedCohere(ctx context.Context, req core.EmbeddingRequest, m
 	}, nil
 }
 
+// SupportsEmbeddingDimensions reports whether model honours a requested
+// output dimension: only Titan Text Embeddings V2 does.
+func (p *Provider) SupportsEmbeddingDimensions(model string) bool {
+	return strings.HasPrefix(bedrockModelRoutingID(model), "amazon.titan-embed-text-v2")
+}
+
 func isBedrockTitanTextEmbeddingModel(model string) bool {
 	return strings.HasPrefix(model, "amazon.titan-embed-text-")
 }
@@ -50,6 +50,26 @@ func TestNewBedrockWithOptions_StaticCredentials(t *testing.T) {
 	}
 }
 
+func TestBedrockWarm(t *testing.T) {
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	static, err := NewWithOptions(Options{AccessKeyID: "test-access-key", SecretAccessKey: "test-secret-key"})
+	if err != nil {
+		t.Fatalf("NewBedrockWithOptions() error: %v", err)
+	}
+	if err := static.Warm(t.Context()); err != nil {
+		t.Errorf("Warm() with static credentials error: %v", err)
+	}
+
+	bearer, err := NewWithOptions(Options{BearerToken: "test-bearer-token"})
+	if err != nil {
+		t.Fatalf("NewBedrockWithOptions() error: %v", err)
+	}
+	if err := bearer.Warm(t.Context()); err != nil {
+		t.Errorf("Warm() in bearer-token mode error: %v", err)
+	}
+}
+
 func TestNewBedrockWithOptions_BearerTokenAuthHeaders(t *testing.T) {
 	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
 
@@ -217,6 +237,20 @@ func TestBedrockProvider_SupportedEmbeddingModels(t *testing.T) {
 	}
 }
 
+func TestBedrockProvider_SupportsEmbeddingDimensions(t *testing.T) {
+	p := &Provider{name: Name}
+	for model, want := range map[string]bool{
+		"amazon.titan-embed-text-v2:0":    true,
+		"us.amazon.titan-embed-text-v2:0": true,
+		"amazon.titan-embed-text-v1":      false,
+		"cohere.embed-english-v3":         false,
+	} {
+		if got := p.SupportsEmbeddingDimensions(model); got != want {
+			t.Errorf("SupportsEmbeddingDimensions(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
 func TestBedrockProvider_SupportsModel_CrossRegionInferenceProfiles(t *testing.T) {
 	p := &Provider{name: Name}
 
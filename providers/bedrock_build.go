@@ -0,0 +1,21 @@
+//go:build !nobedrock
+
+package providers
+
+import bedrockpkg "github.com/ferro-labs/ai-gateway/providers/bedrock"
+
+// Bedrock is the only provider built on a vendor SDK: the AWS SDK, for SigV4
+// signing and the AWS credential chain. Building with the nobedrock tag
+// replaces this file with bedrock_stub.go, dropping the provider and the SDK
+// from the binary for embedders and deployments that do not use Bedrock.
+
+// buildBedrock constructs the Bedrock provider.
+func buildBedrock(cfg ProviderConfig) (Provider, error) {
+	return bedrockpkg.NewWithOptions(bedrockpkg.Options{
+		Region:          cfg[CfgKeyRegion],
+		BearerToken:     cfg[CfgKeyAPIKey],
+		AccessKeyID:     cfg[CfgKeyAccessKeyID],
+		SecretAccessKey: cfg[CfgKeySecretAccessKey],
+		SessionToken:    cfg[CfgKeySessionToken],
+	})
+}
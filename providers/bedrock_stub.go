@@ -0,0 +1,16 @@
+//go:build nobedrock
+
+package providers
+
+import "errors"
+
+// errBedrockExcluded is returned when Bedrock is configured in a binary built
+// without it.
+var errBedrockExcluded = errors.New("bedrock: not included in this build (built with the nobedrock tag)")
+
+// buildBedrock fails: this build leaves the Bedrock provider out. The entry
+// stays registered so a configured Bedrock reports why it is missing instead
+// of being silently ignored.
+func buildBedrock(ProviderConfig) (Provider, error) {
+	return nil, errBedrockExcluded
+}
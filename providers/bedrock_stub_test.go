@@ -0,0 +1,18 @@
+//go:build nobedrock
+
+package providers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuildBedrock_Excluded(t *testing.T) {
+	entry, ok := GetProviderEntry(NameBedrock)
+	if !ok {
+		t.Fatal("bedrock entry missing from a nobedrock build")
+	}
+	if _, err := entry.Build(ProviderConfig{CfgKeyRegion: "us-east-1"}); !errors.Is(err, errBedrockExcluded) {
+		t.Fatalf("Build error = %v, want errBedrockExcluded", err)
+	}
+}
@@ -2,6 +2,7 @@ package capabilities_test
 
 import (
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -36,6 +37,86 @@ func TestMatrixKeysAreRealProviderIDs(t *testing.T) {
 	}
 }
 
+// The seeded set has the same failure mode: a misspelled ID marks no provider
+// as deterministic, and seeded requests to the real one are warned about or
+// rejected for no reason.
+func TestSeededKeysAreRealProviderIDs(t *testing.T) {
+	known := make(map[string]bool)
+	for _, entry := range providers.AllProviders() {
+		known[entry.ID] = true
+	}
+
+	for id := range capabilities.Seeded {
+		if !known[id] {
+			t.Errorf("seeded set declares provider %q, which is not a built-in provider ID", id)
+		}
+		if capabilities.SupportOf(id, "seed") == capabilities.Unsupported {
+			t.Errorf("provider %q is declared to honour seed but cannot express it", id)
+		}
+	}
+}
+
+// A misspelled ID in the top_k set strips top_k from every request to the real
+// provider.
+func TestTopKKeysAreRealProviderIDs(t *testing.T) {
+	known := make(map[string]bool)
+	for _, entry := range providers.AllProviders() {
+		known[entry.ID] = true
+	}
+
+	for id := range capabilities.TopK {
+		if !known[id] {
+			t.Errorf("top_k set declares provider %q, which is not a built-in provider ID", id)
+		}
+	}
+}
+
+// A stop cap keyed by a misspelled ID leaves the real provider uncapped, and
+// requests over its limit fail upstream instead of being emulated.
+func TestStopLimitKeysAreRealProviderIDs(t *testing.T) {
+	known := make(map[string]bool)
+	for _, entry := range providers.AllProviders() {
+		known[entry.ID] = true
+	}
+
+	for id, limit := range capabilities.StopLimits {
+		if !known[id] {
+			t.Errorf("stop limit declared for %q, which is not a built-in provider ID", id)
+		}
+		if limit <= 0 {
+			t.Errorf("stop limit for %q is %d; omit the entry instead", id, limit)
+		}
+	}
+}
+
+// An extra_body allowlist keyed by a misspelled ID leaves the real provider
+// accepting no extras. An allowlisted name that is also a standard parameter
+// would let a client set that parameter twice, once past the matrix.
+func TestExtraParamKeysAreRealProviderIDs(t *testing.T) {
+	known := make(map[string]bool)
+	for _, entry := range providers.AllProviders() {
+		known[entry.ID] = true
+	}
+	standard := make(map[string]bool, len(capabilities.AllParams))
+	for _, param := range capabilities.AllParams {
+		standard[param] = true
+	}
+
+	for id, params := range capabilities.ExtraParamsByProvider {
+		if !known[id] {
+			t.Errorf("extra_body allowlist declared for %q, which is not a built-in provider ID", id)
+		}
+		if !slices.IsSorted(params) {
+			t.Errorf("extra_body allowlist for %q is not sorted: %v", id, params)
+		}
+		for _, param := range params {
+			if standard[param] {
+				t.Errorf("provider %q allowlists %q through extra_body, but it is a standard parameter", id, param)
+			}
+		}
+	}
+}
+
 // A parameter name the gateway does not model can never be enforced or reported,
 // so an Unsupported entry for it is inert: the parameter it was meant to catch
 // still reaches the provider.
@@ -8,3 +8,15 @@ package capabilities
 // test importing providers would be an import cycle. This file imports nothing,
 // so it bridges the two without creating one.
 var Matrix = matrix
+
+// Seeded exposes the unexported seeded set to the same drift guard.
+var Seeded = seeded
+
+// StopLimits exposes the unexported stop-sequence caps likewise.
+var StopLimits = stopLimits
+
+// ExtraParamsByProvider exposes the unexported extra_body allowlists likewise.
+var ExtraParamsByProvider = extraParams
+
+// TopK exposes the unexported top_k set likewise.
+var TopK = topK
@@ -10,6 +10,8 @@
 // they mirror real provider behaviour rather than inventing support.
 package capabilities
 
+import "slices"
+
 // Support classifies how a provider handles a given OpenAI chat parameter.
 type Support int
 
@@ -47,10 +49,12 @@ type Profile map[string]Support
 var AllParams = []string{
 	"temperature",
 	"top_p",
+	"top_k",
 	"n",
 	"seed",
 	"max_tokens",
 	"max_completion_tokens",
+	"reasoning_effort",
 	"presence_penalty",
 	"frequency_penalty",
 	"stop",
@@ -92,7 +96,7 @@ func unsupported(params ...string) Profile {
 // (streaming is handled natively) and default to Forward.
 var matrix = map[string]Profile{
 	"anthropic": unsupported(
-		"n", "seed", "max_completion_tokens", "presence_penalty",
+		"n", "seed", "max_completion_tokens", "reasoning_effort", "presence_penalty",
 		"frequency_penalty", "response_format", "logprobs", "top_logprobs", "logit_bias",
 	),
 	// Bedrock parameter support is model-dependent (Anthropic, Titan, Nova, and
@@ -101,17 +105,17 @@ var matrix = map[string]Profile{
 	// (temperature, top_p, max_tokens), so anything outside that is Unsupported
 	// at the provider level.
 	"bedrock": unsupported(
-		"n", "seed", "max_completion_tokens", "presence_penalty", "frequency_penalty",
+		"n", "seed", "max_completion_tokens", "reasoning_effort", "presence_penalty", "frequency_penalty",
 		"stop", "tools", "tool_choice", "response_format", "logprobs", "top_logprobs",
 		"user", "logit_bias",
 	),
 	"cohere": unsupported(
-		"n", "max_completion_tokens", "response_format",
+		"n", "max_completion_tokens", "reasoning_effort", "response_format",
 		"logprobs", "top_logprobs", "user", "logit_bias",
 	),
 	"gemini": geminiProfile(),
 	"replicate": unsupported(
-		"n", "max_completion_tokens", "tools", "tool_choice",
+		"n", "max_completion_tokens", "reasoning_effort", "tools", "tool_choice",
 		"response_format", "logprobs", "top_logprobs", "user", "logit_bias",
 	),
 }
@@ -120,20 +124,120 @@ var matrix = map[string]Profile{
 // Translate: providers/gemini/gemini.go maps the json_object and json_schema
 // response formats onto Gemini's native responseMimeType.
 func geminiProfile() Profile {
-	p := unsupported("max_completion_tokens", "logprobs", "top_logprobs", "user", "logit_bias")
+	p := unsupported("max_completion_tokens", "reasoning_effort", "logprobs", "top_logprobs", "user", "logit_bias")
 	p["response_format"] = Translate
 	return p
 }
 
+// seeded lists the providers whose sampling honours seed: a repeated request
+// with the same seed (and, where reported, the same system_fingerprint) returns
+// the same completion, as far as the provider guarantees it. Forwarding seed is
+// not enough to be listed — many OpenAI-compatible APIs accept the field and
+// ignore it. Self-hosted vLLM and other OpenAI-compatible servers are reached
+// through the openai provider with a custom base URL and inherit its entry.
+var seeded = map[string]bool{
+	"ai21":         true,
+	"azure-openai": true,
+	"cohere":       true,
+	"deepinfra":    true,
+	"fireworks":    true,
+	"gemini":       true,
+	"groq":         true,
+	"mistral":      true,
+	"ollama":       true,
+	"openai":       true,
+	"replicate":    true,
+	"together":     true,
+	"vertex-ai":    true,
+}
+
+// HonorsSeed reports whether providerID produces reproducible output for a
+// seeded request. Unknown providers are assumed not to.
+func HonorsSeed(providerID string) bool {
+	return seeded[providerID]
+}
+
+// topK lists the providers that accept top_k. Unlike the OpenAI parameters,
+// top_k is unsupported by default: the OpenAI API and most of its compatible
+// servers reject a request carrying it, so SupportOf reports it Unsupported
+// for every provider not listed here. Ollama's OpenAI-compatible endpoint
+// ignores the field, so the Ollama provider sends a request carrying it to its
+// native chat, which takes it as an option.
+// Bedrock is absent because only its Anthropic models have an equivalent.
+var topK = map[string]bool{
+	"anthropic":  true,
+	"cohere":     true,
+	"deepinfra":  true,
+	"fireworks":  true,
+	"gemini":     true,
+	"novita":     true,
+	"ollama":     true,
+	"openrouter": true,
+	"perplexity": true,
+	"replicate":  true,
+	"sambanova":  true,
+	"together":   true,
+}
+
+// stopLimits records providers that accept stop but reject a request carrying
+// more sequences than their cap.
+var stopLimits = map[string]int{
+	"azure-openai": 4,
+	"gemini":       5,
+	"openai":       4,
+	"vertex-ai":    5,
+}
+
+// StopLimit returns the most stop sequences providerID accepts in one request,
+// or 0 when it sets no cap of its own.
+func StopLimit(providerID string) int {
+	return stopLimits[providerID]
+}
+
+// extraParams lists, per provider, the provider-specific parameters a client
+// may pass through extra_body (core.Request.Extra). They are merged into the
+// top level of the upstream request body verbatim, so only providers whose API
+// documents the field are listed; a provider without an entry accepts no
+// extras, and extras bound for it are handled by the compatibility mode like
+// any other unsupported parameter.
+var extraParams = map[string][]string{
+	"anthropic":    {"service_tier", "thinking"},
+	"azure-openai": {"audio", "metadata", "modalities", "prediction", "service_tier", "store", "verbosity", "web_search_options"},
+	"deepinfra":    {"min_p", "repetition_penalty"},
+	"fireworks":    {"min_p", "repetition_penalty"},
+	"groq":         {"service_tier"},
+	"mistral":      {"prediction", "safe_prompt"},
+	"openai":       {"audio", "metadata", "modalities", "prediction", "service_tier", "store", "verbosity", "web_search_options"},
+	"openrouter":   {"min_p", "models", "provider", "repetition_penalty", "route", "top_a", "transforms"},
+	"perplexity":   {"return_images", "return_related_questions", "search_domain_filter", "search_recency_filter"},
+	"together":     {"min_p", "repetition_penalty"},
+	"xai":          {"search_parameters"},
+}
+
+// ExtraParams returns the extra_body parameters providerID accepts, sorted by
+// name, for the /v1/capabilities response. The slice must not be modified.
+func ExtraParams(providerID string) []string {
+	return extraParams[providerID]
+}
+
+// AcceptsExtra reports whether providerID accepts param through extra_body.
+func AcceptsExtra(providerID, param string) bool {
+	return slices.Contains(extraParams[providerID], param)
+}
+
 // SupportOf returns the declared Support for a provider/parameter pair. Unknown
 // providers and unknown/future parameters default to Forward so the matrix never
-// breaks on inputs it does not model.
+// breaks on inputs it does not model. top_k is the exception: it is Unsupported
+// for any provider not in the topK set.
 func SupportOf(providerID, param string) Support {
 	if p, ok := matrix[providerID]; ok {
 		if s, ok := p[param]; ok {
 			return s
 		}
 	}
+	if param == "top_k" && !topK[providerID] {
+		return Unsupported
+	}
 	return Forward
 }
 
@@ -142,6 +246,8 @@ func SupportOf(providerID, param string) Support {
 // request hot path (e.g. the shared openaicompat builder) to skip a per-param
 // AllParams scan for the common case of a provider with no entry: such a
 // provider forwards everything by definition, so there is nothing to find.
+// top_k, Unsupported outside the topK set even without an entry, is stripped
+// by the gateway before the provider is called.
 func HasProfile(providerID string) bool {
 	_, ok := matrix[providerID]
 	return ok
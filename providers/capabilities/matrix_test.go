@@ -35,6 +35,7 @@ func TestSupportOf_DerivedMatrix(t *testing.T) {
 		{"anthropic supported temperature", "anthropic", "temperature", Forward},
 		{"anthropic supported user", "anthropic", "user", Forward},
 		{"anthropic drops seed", "anthropic", "seed", Unsupported},
+		{"anthropic drops reasoning_effort", "anthropic", "reasoning_effort", Unsupported},
 		{"anthropic drops response_format", "anthropic", "response_format", Unsupported},
 		{"anthropic drops logit_bias", "anthropic", "logit_bias", Unsupported},
 
@@ -74,6 +75,14 @@ func TestSupportOf_DerivedMatrix(t *testing.T) {
 		{"anthropic forwards stream", "anthropic", "stream", Forward},
 		{"gemini forwards parallel_tool_calls", "gemini", "parallel_tool_calls", Forward},
 
+		// top_k is Unsupported unless the provider is in the topK set.
+		{"anthropic forwards top_k", "anthropic", "top_k", Forward},
+		{"together forwards top_k", "together", "top_k", Forward},
+		{"ollama forwards top_k", "ollama", "top_k", Forward},
+		{"openai drops top_k", "openai", "top_k", Unsupported},
+		{"bedrock drops top_k", "bedrock", "top_k", Unsupported},
+		{"unknown provider drops top_k", "does-not-exist", "top_k", Unsupported},
+
 		// Unknown provider / unknown param default to Forward.
 		{"unknown provider defaults forward", "does-not-exist", "seed", Forward},
 		{"unknown param defaults forward", "anthropic", "made_up_param", Forward},
@@ -108,6 +108,7 @@ type cohereRequest struct {
 	Temperature      *float64               `json:"temperature,omitempty"`
 	MaxTokens        *int                   `json:"max_tokens,omitempty"`
 	P                *float64               `json:"p,omitempty"`
+	K                *int                   `json:"k,omitempty"`
 	Seed             *int64                 `json:"seed,omitempty"`
 	PresencePenalty  *float64               `json:"presence_penalty,omitempty"`
 	FrequencyPenalty *float64               `json:"frequency_penalty,omitempty"`
@@ -257,6 +258,7 @@ func cohereAPIError(prefix string, resp *http.Response, body []byte) error {
 		StatusCode: resp.StatusCode,
 		Message:    fmt.Sprintf("%s (%d): %s", prefix, resp.StatusCode, msg),
 		RetryAfter: core.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		Body:       core.ErrorBody(body),
 	}
 }
 
@@ -274,6 +276,7 @@ func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Respon
 		Temperature:      req.Temperature,
 		MaxTokens:        req.MaxTokens,
 		P:                req.TopP,
+		K:                req.TopK,
 		Seed:             req.Seed,
 		PresencePenalty:  req.PresencePenalty,
 		FrequencyPenalty: req.FrequencyPenalty,
@@ -430,6 +433,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req core.Request) (<-chan
 		Temperature:      req.Temperature,
 		MaxTokens:        req.MaxTokens,
 		P:                req.TopP,
+		K:                req.TopK,
 		Seed:             req.Seed,
 		PresencePenalty:  req.PresencePenalty,
 		FrequencyPenalty: req.FrequencyPenalty,
@@ -615,6 +619,10 @@ type cohereEmbedResponse struct {
 	} `json:"meta"`
 }
 
+// SupportsEmbeddingDimensions reports false: Cohere's embed models have a
+// fixed output dimension.
+func (p *Provider) SupportsEmbeddingDimensions(string) bool { return false }
+
 // Embed sends an embedding request to Cohere's /v1/embed endpoint.
 func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.EmbeddingResponse, error) {
 	var texts []string
@@ -15,7 +15,7 @@ func f64(f float64) *float64 { return &f }
 func i64(i int64) *int64     { return &i }
 
 // TestComplete_MapsSupportedParams_DropsRest verifies #140 native wiring for
-// Cohere v2: top_p maps to "p", stop to "stop_sequences", and seed/penalties
+// Cohere v2: top_p maps to "p", top_k to "k", stop to "stop_sequences", and seed/penalties
 // are forwarded while unsupported params are not.
 func TestComplete_MapsSupportedParams_DropsRest(t *testing.T) {
 	var captured map[string]json.RawMessage
@@ -32,10 +32,12 @@ func TestComplete_MapsSupportedParams_DropsRest(t *testing.T) {
 		t.Fatalf("New: %v", err)
 	}
 
+	topK := 40
 	_, _ = p.Complete(context.Background(), core.Request{
 		Model:            "command-r",
 		Messages:         []core.Message{{Role: "user", Content: "hi"}},
 		TopP:             f64(0.9),
+		TopK:             &topK,
 		Seed:             i64(42),
 		PresencePenalty:  f64(0.5),
 		FrequencyPenalty: f64(0.25),
@@ -44,13 +46,13 @@ func TestComplete_MapsSupportedParams_DropsRest(t *testing.T) {
 		LogitBias:        map[string]float64{"1": -1}, // unsupported → dropped
 	})
 
-	for _, k := range []string{"p", "stop_sequences", "seed", "presence_penalty", "frequency_penalty"} {
+	for _, k := range []string{"p", "k", "stop_sequences", "seed", "presence_penalty", "frequency_penalty"} {
 		if _, ok := captured[k]; !ok {
 			t.Errorf("expected %q forwarded; keys=%v", k, mapKeys(captured))
 		}
 	}
-	// Cohere uses "p", not "top_p"; logit_bias is unsupported.
-	for _, k := range []string{"top_p", "logit_bias"} {
+	// Cohere uses "p" and "k", not "top_p" and "top_k"; logit_bias is unsupported.
+	for _, k := range []string{"top_p", "top_k", "logit_bias"} {
 		if _, ok := captured[k]; ok {
 			t.Errorf("param %q should NOT be forwarded to Cohere", k)
 		}
@@ -32,11 +32,59 @@ type HTTPStatusError struct {
 	// to its own computed backoff, so a 429/503 is retried when the provider says
 	// it is ready rather than on a guess.
 	RetryAfter time.Duration
+	// Body is the raw upstream response body, cut to MaxErrorBodyBytes. It is
+	// kept for debugging and only surfaced when the gateway's
+	// expose_provider_errors is set (see ProviderBodyError).
+	Body string
 }
 
 // Error implements error.
 func (e *HTTPStatusError) Error() string { return e.Message }
 
+// MaxErrorBodyBytes caps the raw body an HTTPStatusError keeps.
+const MaxErrorBodyBytes = 4 << 10
+
+// ErrorBody returns body as kept in HTTPStatusError.Body: cut to
+// MaxErrorBodyBytes and trimmed of surrounding whitespace.
+func ErrorBody(body []byte) string {
+	if len(body) > MaxErrorBodyBytes {
+		body = body[:MaxErrorBodyBytes]
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// ProviderBodyError adds the raw upstream body of a provider error to its
+// message, for the gateway's expose_provider_errors debugging mode. The body
+// rides the message so it reaches both the client's error response and the
+// request log, each of which redacts credentials from it. It unwraps to the
+// original error, so status classification is unchanged.
+type ProviderBodyError struct {
+	Err  error
+	Body string
+}
+
+// Error implements error.
+func (e *ProviderBodyError) Error() string {
+	return e.Err.Error() + " [provider response body: " + e.Body + "]"
+}
+
+// Unwrap returns the original provider error.
+func (e *ProviderBodyError) Unwrap() error { return e.Err }
+
+// WithProviderBody wraps err in a ProviderBodyError when it carries an upstream
+// body that says more than its message already does, and returns err unchanged
+// otherwise.
+func WithProviderBody(err error) error {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.Body == "" {
+		return err
+	}
+	if strings.HasSuffix(statusErr.Message, ": "+statusErr.Body) {
+		return err // the body was plain text and is already the message
+	}
+	return &ProviderBodyError{Err: err, Body: statusErr.Body}
+}
+
 // maxRetryAfterSeconds is the largest delta-seconds value a time.Duration can
 // hold. Beyond it the multiply by time.Second wraps past MaxInt64 into a
 // negative duration, which the fallback strategy would honour as "retry now".
@@ -107,6 +155,7 @@ func APIError(label string, status int, body []byte) error {
 	return &HTTPStatusError{
 		StatusCode: status,
 		Message:    fmt.Sprintf("%s API error (%d): %s", label, status, msg),
+		Body:       ErrorBody(body),
 	}
 }
 
@@ -134,6 +183,28 @@ func (e *UnsupportedParamError) Error() string {
 // HTTPStatus reports the HTTP status this error maps to (400 Bad Request).
 func (e *UnsupportedParamError) HTTPStatus() int { return http.StatusBadRequest }
 
+// ImageFetchError reports an image URL the gateway was configured to fetch
+// for a provider but could not: the URL is not allowed, the fetch failed, or
+// the image is over the configured limits. The upstream was not called, so it
+// never counts against the provider.
+type ImageFetchError struct {
+	// Provider is the target the image was fetched for.
+	Provider string
+	// URL is the image URL without its query string, which may carry
+	// credentials.
+	URL string
+	// Reason says why the image could not be used.
+	Reason string
+}
+
+// Error implements error.
+func (e *ImageFetchError) Error() string {
+	return fmt.Sprintf("cannot fetch image %s for provider %q: %s", e.URL, e.Provider, e.Reason)
+}
+
+// HTTPStatus reports the HTTP status this error maps to (400 Bad Request).
+func (e *ImageFetchError) HTTPStatus() int { return http.StatusBadRequest }
+
 // NewUnsupportedParamError builds the reject-mode error naming the request
 // parameters the provider cannot express.
 func NewUnsupportedParamError(provider string, params []string) error {
@@ -141,3 +141,34 @@ func TestAPIError_TypedStatusSurvivesWrapping(t *testing.T) {
 		t.Errorf("StatusCode = %d, want 503", statusErr.StatusCode)
 	}
 }
+
+func TestWithProviderBody(t *testing.T) {
+	body := `{"error":{"message":"invalid model","param":"model","code":"model_not_found"}}`
+	err := fmt.Errorf("attempt 1: %w", APIError("openai", 404, []byte(body)))
+
+	exposed := WithProviderBody(err)
+	if !strings.Contains(exposed.Error(), `"param":"model"`) {
+		t.Fatalf("exposed error %q is missing the raw body", exposed)
+	}
+	if ParseStatusCode(exposed) != 404 {
+		t.Fatalf("ParseStatusCode(exposed) = %d, want 404", ParseStatusCode(exposed))
+	}
+
+	// A plain-text body is already the message; repeating it adds nothing.
+	plain := APIError("groq", 400, []byte("bad request"))
+	if got := WithProviderBody(plain); got != plain {
+		t.Fatalf("plain-text body was wrapped: %v", got)
+	}
+	other := errors.New("dial tcp: connection refused")
+	if got := WithProviderBody(other); got != other {
+		t.Fatalf("non-status error was wrapped: %v", got)
+	}
+}
+
+func TestAPIError_BodyIsCapped(t *testing.T) {
+	err := APIError("openai", 400, []byte(strings.Repeat("x", 2*MaxErrorBodyBytes)))
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || len(statusErr.Body) != MaxErrorBodyBytes {
+		t.Fatalf("body length = %d, want %d", len(statusErr.Body), MaxErrorBodyBytes)
+	}
+}
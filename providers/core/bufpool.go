@@ -3,7 +3,10 @@ package core
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
 	"sync"
 )
 
@@ -52,6 +55,14 @@ func MarshalJSON(v any) ([]byte, error) {
 // return the buffer to the pool. This avoids the extra copy that MarshalJSON
 // performs, making it ideal for building HTTP request bodies.
 func JSONBodyReader(v any) (body io.Reader, contentLen int, release func(), err error) {
+	return JSONBodyReaderExtra(v, nil)
+}
+
+// JSONBodyReaderExtra is JSONBodyReader for a chat request body carrying
+// Request.Extra: v must encode to a JSON object, and each extra parameter is
+// appended to it as a top-level field, in name order. Callers make sure no
+// extra parameter repeats one of v's own fields.
+func JSONBodyReaderExtra(v any, extra map[string]json.RawMessage) (body io.Reader, contentLen int, release func(), err error) {
 	buf := bufPool.Get().(*bytes.Buffer)
 	buf.Reset()
 
@@ -68,6 +79,13 @@ func JSONBodyReader(v any) (body io.Reader, contentLen int, release func(), err
 	if len(b) > 0 && b[len(b)-1] == '\n' {
 		buf.Truncate(buf.Len() - 1)
 	}
+	if len(extra) > 0 {
+		if err := appendFields(buf, extra); err != nil {
+			buf.Reset()
+			bufPool.Put(buf)
+			return nil, 0, nil, err
+		}
+	}
 
 	reader := bytes.NewReader(buf.Bytes())
 	n := reader.Len()
@@ -77,3 +95,32 @@ func JSONBodyReader(v any) (body io.Reader, contentLen int, release func(), err
 	}
 	return reader, n, rel, nil
 }
+
+// appendFields adds fields to the JSON object encoded in buf.
+func appendFields(buf *bytes.Buffer, fields map[string]json.RawMessage) error {
+	b := buf.Bytes()
+	if len(b) < 2 || b[len(b)-1] != '}' {
+		return errors.New("extra parameters need a JSON object body")
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	buf.Truncate(buf.Len() - 1)
+	for _, name := range names {
+		value := fields[name]
+		if !json.Valid(value) {
+			return fmt.Errorf("extra parameter %q is not valid JSON", name)
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
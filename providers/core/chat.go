@@ -3,6 +3,12 @@ package core
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 // ContentPart is a single element of a multipart message content array.
@@ -68,7 +74,8 @@ type Message struct {
 	ToolCalls    []ToolCall    `json:"-"` // tool calls issued by the model
 	ToolCallID   string        `json:"-"` // for role="tool" result messages
 	// ReasoningContent is the model's chain-of-thought, surfaced by reasoning
-	// models (e.g. deepseek-reasoner). Empty for models that don't emit it.
+	// models (e.g. deepseek-reasoner, Anthropic extended thinking, Gemini
+	// thought summaries). Empty for models that don't emit it.
 	ReasoningContent string `json:"-"`
 }
 
@@ -82,6 +89,9 @@ type messageWire struct {
 	ToolCalls        []ToolCall      `json:"tool_calls,omitempty"`
 	ToolCallID       string          `json:"tool_call_id,omitempty"`
 	ReasoningContent string          `json:"reasoning_content,omitempty"`
+	// Reasoning is the name OpenRouter and Groq give the same text. It is
+	// only read, folded into ReasoningContent, and never written.
+	Reasoning string `json:"reasoning,omitempty"`
 }
 
 // MarshalJSON encodes a Message to JSON.  Content is written as a string unless
@@ -122,6 +132,9 @@ func (m *Message) UnmarshalJSON(b []byte) error {
 	m.ToolCalls = w.ToolCalls
 	m.ToolCallID = w.ToolCallID
 	m.ReasoningContent = w.ReasoningContent
+	if m.ReasoningContent == "" {
+		m.ReasoningContent = w.Reasoning
+	}
 
 	if len(w.Content) == 0 || string(w.Content) == "null" {
 		return nil
@@ -160,11 +173,23 @@ type Request struct {
 	TopP        *float64 `json:"top_p,omitempty"`
 	N           *int     `json:"n,omitempty"`
 	Seed        *int64   `json:"seed,omitempty"`
+	// TopK limits sampling to the k most likely tokens. It is not part of the
+	// OpenAI schema; providers that lack it have it stripped before the
+	// request is sent (see capabilities.SupportOf).
+	TopK *int `json:"top_k,omitempty"`
 
 	// Output limits
 	MaxTokens           *int `json:"max_tokens,omitempty"`
 	MaxCompletionTokens *int `json:"max_completion_tokens,omitempty"`
 
+	// Reasoning: how much effort a reasoning model spends thinking before it
+	// answers ("minimal", "low", "medium", "high"). See AdaptReasoningParams.
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+	// Thinking is the normalized extended-thinking option. Providers map it
+	// to their own parameter, so it is never marshaled with the request
+	// itself (json:"-").
+	Thinking *Thinking `json:"-"`
+
 	// Penalties
 	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
 	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
@@ -208,8 +233,26 @@ type Request struct {
 	// Misc
 	User      string             `json:"user,omitempty"`
 	LogitBias map[string]float64 `json:"logit_bias,omitempty"`
+
+	// Extra carries provider-specific parameters the client sent in
+	// extra_body (e.g. repetition_penalty, Anthropic's thinking). The
+	// gateway forwards each one only to providers whose allowlist names it
+	// (capabilities.AcceptsExtra); the providers that accept any merge them
+	// into the top level of their upstream body via JSONBodyReaderExtra.
+	// Never marshaled with the request itself (json:"-").
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// FallbackModels lists models to try, in order, when Model fails. The
+	// gateway routes each one as a request of its own, so a fallback model
+	// may be served by a different provider than Model. Never marshaled
+	// with the request itself (json:"-").
+	FallbackModels []string `json:"-"`
 }
 
+// MaxFallbackModels bounds Request.FallbackModels, so one request cannot
+// fan out into an unbounded number of upstream attempts.
+const MaxFallbackModels = 8
+
 // StreamOptions carries the OpenAI stream_options object. IncludeUsage requests a
 // terminal usage chunk on the stream so cost and metrics tracking work.
 //
@@ -261,6 +304,9 @@ func (r Request) Validate() error {
 	if r.TopP != nil && (*r.TopP < 0 || *r.TopP > 1) {
 		return errors.New("top_p must be between 0 and 1")
 	}
+	if r.TopK != nil && *r.TopK <= 0 {
+		return errors.New("top_k must be positive")
+	}
 	if r.MaxTokens != nil && *r.MaxTokens <= 0 {
 		return errors.New("max_tokens must be positive")
 	}
@@ -273,9 +319,34 @@ func (r Request) Validate() error {
 	if r.FrequencyPenalty != nil && (*r.FrequencyPenalty < -2 || *r.FrequencyPenalty > 2) {
 		return errors.New("frequency_penalty must be between -2 and 2")
 	}
+	if len(r.FallbackModels) > MaxFallbackModels {
+		return fmt.Errorf("at most %d fallback models are allowed", MaxFallbackModels)
+	}
+	if slices.Contains(r.FallbackModels, "") {
+		return errors.New("fallback models must not be empty")
+	}
+	for name := range r.Extra {
+		if requestFields()[name] {
+			return fmt.Errorf("extra_body.%s shadows a standard parameter; set it at the top level", name)
+		}
+	}
 	return nil
 }
 
+// requestFields is the set of JSON field names Request itself carries, which
+// extra_body must not override.
+var requestFields = sync.OnceValue(func() map[string]bool {
+	t := reflect.TypeFor[Request]()
+	fields := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+})
+
 // Response represents a chat completion response normalised across providers.
 type Response struct {
 	ID       string   `json:"id"`
@@ -286,6 +357,12 @@ type Response struct {
 	Choices  []Choice `json:"choices"`
 	Usage    Usage    `json:"usage"`
 
+	// SystemFingerprint identifies the backend configuration that served the
+	// request, as reported by providers that support seeded sampling. Two
+	// responses to the same seed are only expected to match when their
+	// fingerprints do.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
 	// Metadata carries provider-specific top-level response fields (e.g.
 	// Perplexity's citations/search_results) captured on request via
 	// ChatParams.ExtraResponseFields. Serialized under "provider_metadata" (not
@@ -293,10 +370,42 @@ type Response struct {
 	// requested.
 	Metadata map[string]any `json:"provider_metadata,omitempty"`
 
+	// PluginMetadata carries the fields gateway plugins declared for the
+	// client, limited to those the gateway's expose_plugin_metadata allows.
+	// Nil unless a plugin set an allowed field.
+	PluginMetadata map[string]any `json:"plugin_metadata,omitempty"`
+
 	// OverheadMs is the gateway processing overhead in milliseconds
 	// (total latency minus provider call duration). Excluded from JSON
 	// responses; exposed via the X-Gateway-Overhead-Ms response header.
 	OverheadMs float64 `json:"-"`
+
+	// Cache describes how the response cache handled this request. Nil when
+	// no cache plugin ran. Excluded from JSON responses; exposed via the
+	// X-Ferro-Cache, Age, and Cache-Control response headers.
+	Cache *CacheInfo `json:"-"`
+}
+
+// Cache status values reported in CacheInfo.Status.
+const (
+	CacheStatusHit  = "hit"
+	CacheStatusMiss = "miss"
+	// CacheStatusStale marks an expired entry served from the
+	// stale-while-revalidate grace window while another request refreshes it.
+	CacheStatusStale = "stale"
+)
+
+// CacheInfo is the response cache's verdict for one request.
+type CacheInfo struct {
+	// Status is CacheStatusHit, CacheStatusStale, or CacheStatusMiss.
+	Status string
+	// Age is how long ago a cached entry was stored. Zero on a miss.
+	Age time.Duration
+	// Key is the cache key the request hashed to, sent as X-Ferro-Cache-Key so
+	// operators can purge a specific entry by prefix.
+	Key string
+	// Control, when non-empty, is sent to the client as Cache-Control.
+	Control string
 }
 
 // Choice represents a single completion choice in the response.
@@ -315,6 +424,26 @@ type Usage struct {
 	ReasoningTokens  int `json:"reasoning_tokens,omitempty"`
 	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
 	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	// Estimated marks usage the gateway estimated because the provider
+	// reported none. Costs computed from it are approximate.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// MarshalJSON writes the flat fields and, when reasoning tokens were reported,
+// repeats them as OpenAI's completion_tokens_details.reasoning_tokens, which
+// is where OpenAI SDKs look for them whichever provider served the request.
+func (u Usage) MarshalJSON() ([]byte, error) {
+	type usageAlias Usage // avoid recursing into this method
+	if u.ReasoningTokens == 0 {
+		return json.Marshal(usageAlias(u))
+	}
+	type details struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	}
+	return json.Marshal(struct {
+		usageAlias
+		CompletionTokensDetails details `json:"completion_tokens_details"`
+	}{usageAlias(u), details{u.ReasoningTokens}})
 }
 
 // UnmarshalJSON decodes the OpenAI usage object, folding the nested
@@ -2,6 +2,7 @@ package core
 
 import (
 	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 )
@@ -48,3 +49,111 @@ func TestStreamToolCallDeltaOmitsEmptyMetadata(t *testing.T) {
 		t.Fatalf("stream tool-call delta dropped arguments: %s", body)
 	}
 }
+
+func TestReasoningAliasDecodesIntoReasoningContent(t *testing.T) {
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"role":"assistant","content":"42","reasoning":"think"}`), &msg); err != nil {
+		t.Fatalf("unmarshal message: %v", err)
+	}
+	if msg.ReasoningContent != "think" {
+		t.Fatalf("message reasoning = %q, want think", msg.ReasoningContent)
+	}
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal message: %v", err)
+	}
+	if !strings.Contains(string(raw), `"reasoning_content":"think"`) || strings.Contains(string(raw), `"reasoning":`) {
+		t.Fatalf("message should re-encode under reasoning_content only: %s", raw)
+	}
+
+	var delta MessageDelta
+	if err := json.Unmarshal([]byte(`{"content":"4","reasoning":"hm"}`), &delta); err != nil {
+		t.Fatalf("unmarshal delta: %v", err)
+	}
+	if delta.Content != "4" || delta.ReasoningContent != "hm" {
+		t.Fatalf("delta = %+v, want content 4 and reasoning hm", delta)
+	}
+	if err := json.Unmarshal([]byte(`{"reasoning_content":"a","reasoning":"b"}`), &delta); err != nil {
+		t.Fatalf("unmarshal delta: %v", err)
+	}
+	if delta.ReasoningContent != "a" {
+		t.Fatalf("reasoning_content should win over the alias, got %q", delta.ReasoningContent)
+	}
+}
+
+func TestJSONBodyReaderExtraMergesTopLevelFields(t *testing.T) {
+	req := Request{Model: "m", Extra: map[string]json.RawMessage{
+		"top_k":              json.RawMessage(`40`),
+		"repetition_penalty": json.RawMessage(`1.1`),
+	}}
+	body, n, release, err := JSONBodyReaderExtra(req, req.Extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	b, _ := io.ReadAll(body)
+	if len(b) != n {
+		t.Fatalf("content length %d, body is %d bytes", n, len(b))
+	}
+	want := `{"model":"m","messages":null,"repetition_penalty":1.1,"top_k":40}`
+	if string(b) != want {
+		t.Fatalf("body = %s, want %s", b, want)
+	}
+
+	body, _, release, err = JSONBodyReaderExtra(struct{}{}, req.Extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if b, _ := io.ReadAll(body); string(b) != `{"repetition_penalty":1.1,"top_k":40}` {
+		t.Fatalf("extras on an empty object = %s", b)
+	}
+
+	if _, _, _, err := JSONBodyReaderExtra(req, map[string]json.RawMessage{"x": json.RawMessage(`{`)}); err == nil {
+		t.Fatal("want an error for an extra that is not valid JSON")
+	}
+}
+
+func TestValidateRejectsExtraShadowingStandardParam(t *testing.T) {
+	req := Request{
+		Model:    "m",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+		Extra:    map[string]json.RawMessage{"repetition_penalty": json.RawMessage(`1.1`)},
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	req.Extra["top_k"] = json.RawMessage(`40`)
+	if err := req.Validate(); err == nil || !strings.Contains(err.Error(), "extra_body.top_k") {
+		t.Fatalf("Validate = %v, want extra_body.top_k rejected", err)
+	}
+}
+
+func TestValidateTopK(t *testing.T) {
+	req := Request{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}
+	for k, ok := range map[int]bool{1: true, 40: true, 0: false, -1: false} {
+		req.TopK = &k
+		if err := req.Validate(); (err == nil) != ok {
+			t.Errorf("top_k %d: Validate = %v", k, err)
+		}
+	}
+}
+
+func TestValidateFallbackModels(t *testing.T) {
+	req := Request{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}
+	req.FallbackModels = []string{"a", "b"}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("valid fallback models rejected: %v", err)
+	}
+	req.FallbackModels = []string{"a", ""}
+	if err := req.Validate(); err == nil {
+		t.Fatal("want an error for an empty fallback model")
+	}
+	req.FallbackModels = make([]string, MaxFallbackModels+1)
+	for i := range req.FallbackModels {
+		req.FallbackModels[i] = "m"
+	}
+	if err := req.Validate(); err == nil {
+		t.Fatal("want an error for too many fallback models")
+	}
+}
@@ -82,6 +82,14 @@ type EmbeddingProvider interface {
 	Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error)
 }
 
+// EmbeddingDimensionsSupporter is an optional interface for an
+// EmbeddingProvider that can tell whether a model honours
+// EmbeddingRequest.Dimensions. An EmbeddingProvider that does not implement it
+// is assumed to ignore the field.
+type EmbeddingDimensionsSupporter interface {
+	SupportsEmbeddingDimensions(model string) bool
+}
+
 // ImageProvider is an optional interface for providers that support
 // the /v1/images/generations endpoint.
 type ImageProvider interface {
@@ -89,6 +97,14 @@ type ImageProvider interface {
 	GenerateImage(ctx context.Context, req ImageRequest) (*ImageResponse, error)
 }
 
+// ModerationProvider is an optional interface for providers that support
+// the /v1/moderations endpoint. Implementations return normalized results
+// (see ModerationResponse.Normalize).
+type ModerationProvider interface {
+	Provider
+	Moderate(ctx context.Context, req ModerationRequest) (*ModerationResponse, error)
+}
+
 // DiscoveryProvider is an optional interface for providers that can
 // enumerate their available models live from the provider API.
 type DiscoveryProvider interface {
@@ -96,6 +112,14 @@ type DiscoveryProvider interface {
 	DiscoverModels(ctx context.Context) ([]ModelInfo, error)
 }
 
+// Warmer is an optional interface for providers that defer setup to their
+// first request, such as fetching cloud credentials or an OAuth token. The
+// gateway's startup warm-up calls Warm so that cost is paid before traffic
+// arrives rather than by the first caller.
+type Warmer interface {
+	Warm(ctx context.Context) error
+}
+
 // ProviderSource is a read-only view over a collection of registered providers.
 // Both *Registry and *Gateway implement this interface, enabling registry
 // consolidation: handlers that only need to read provider info can accept
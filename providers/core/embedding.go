@@ -11,7 +11,8 @@ type EmbeddingRequest struct {
 	// InputType is a non-OpenAI extension for providers that distinguish
 	// embedding intent — "search_document", "search_query", "classification",
 	// "clustering". Forwarded by the shared embeddings body (Cohere, NVIDIA NIM,
-	// OpenRouter, …). Empty lets the provider pick a default.
+	// OpenRouter, …); Gemini and Vertex AI map it onto their task types. Empty
+	// lets the provider pick a default.
 	InputType string `json:"input_type,omitempty"`
 }
 
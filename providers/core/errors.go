@@ -21,6 +21,40 @@ var ErrNoCapableProvider = errors.New("no capable provider for model")
 // surfaces it as 429 so callers back off instead of retrying immediately.
 var ErrProviderSaturated = errors.New("provider concurrency queue is full")
 
+// ErrTierLimitExceeded signals that the caller's API key has exhausted a limit
+// of its rate-limit tier — request rate, concurrent streams, or monthly tokens.
+// Like ErrProviderSaturated it is raised before any upstream call and surfaces
+// as HTTP 429.
+var ErrTierLimitExceeded = errors.New("rate limit tier exceeded")
+
+// ErrStreamLimitExceeded signals that a streaming request found every
+// concurrent-stream slot taken, either the caller's key's (its tier's
+// max_concurrent_streams) or the gateway's (Config.MaxConcurrentStreams). It
+// surfaces as HTTP 429; retrying once a stream ends will succeed.
+var ErrStreamLimitExceeded = errors.New("concurrent stream limit reached")
+
+// ErrResidencyUnsatisfied signals that a request bound to a data residency
+// requirement would have to be served by a target outside it. The request
+// fails closed instead, before any upstream call, and surfaces as HTTP 403.
+var ErrResidencyUnsatisfied = errors.New("data residency requirement not satisfied")
+
+// ErrMalformedResponse signals that a provider answered successfully but
+// with a response that breaks an invariant clients rely on, such as having no
+// choices. It is an upstream fault: it counts against the target's circuit
+// breaker, lets a fallback move on, and surfaces as HTTP 502.
+var ErrMalformedResponse = errors.New("provider returned a malformed response")
+
+// ErrRetryBudgetExhausted signals that a request's retry budget — the output
+// tokens or cost its attempts may generate between them — is spent, so no
+// further attempt was made. The upstream was not called; it surfaces as HTTP
+// 502, since what used the budget up were failed upstream attempts.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// ErrImageTooLarge signals that an inline image in a request is over the
+// configured byte or dimension limit and could not be downscaled to fit. The
+// upstream was not called; it surfaces as HTTP 400.
+var ErrImageTooLarge = errors.New("image too large")
+
 // statusCodePattern matches HTTP status codes formatted as "(NNN)" inside
 // provider error messages (e.g. "provider API error (429): ...").
 var statusCodePattern = regexp.MustCompile(`\((\d{3})\)`)
@@ -39,6 +73,10 @@ func ParseStatusCode(err error) int {
 	if errors.As(err, &unsupportedErr) {
 		return unsupportedErr.HTTPStatus()
 	}
+	var imageFetchErr *ImageFetchError
+	if errors.As(err, &imageFetchErr) {
+		return imageFetchErr.HTTPStatus()
+	}
 	var statusErr *HTTPStatusError
 	if errors.As(err, &statusErr) {
 		return statusErr.StatusCode
@@ -0,0 +1,63 @@
+package core
+
+import "math"
+
+// DefaultModerationModel is the model a moderation request without one is
+// routed to.
+const DefaultModerationModel = "omni-moderation-latest"
+
+// ModerationRequest mirrors the OpenAI /v1/moderations request schema.
+type ModerationRequest struct {
+	Model string `json:"model,omitempty"`
+	Input any    `json:"input"` // string, []string, or an array of text/image_url parts
+}
+
+// ModerationResponse mirrors the OpenAI /v1/moderations response schema.
+type ModerationResponse struct {
+	ID      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []ModerationResult `json:"results"`
+}
+
+// ModerationResult is the verdict for one input.
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// Normalize makes r consistent whatever the provider returned: every category
+// appears in both Categories and CategoryScores, scores are clamped to [0, 1]
+// (a NaN score becomes 0), and Flagged is set when any category is.
+func (r *ModerationResult) Normalize() {
+	if r.Categories == nil {
+		r.Categories = make(map[string]bool, len(r.CategoryScores))
+	}
+	if r.CategoryScores == nil {
+		r.CategoryScores = make(map[string]float64, len(r.Categories))
+	}
+	for category, score := range r.CategoryScores {
+		if math.IsNaN(score) {
+			score = 0
+		}
+		r.CategoryScores[category] = min(max(score, 0), 1)
+		if _, ok := r.Categories[category]; !ok {
+			r.Categories[category] = false
+		}
+	}
+	for category, flagged := range r.Categories {
+		if _, ok := r.CategoryScores[category]; !ok {
+			r.CategoryScores[category] = 0
+		}
+		if flagged {
+			r.Flagged = true
+		}
+	}
+}
+
+// Normalize normalizes every result in r.
+func (r *ModerationResponse) Normalize() {
+	for i := range r.Results {
+		r.Results[i].Normalize()
+	}
+}
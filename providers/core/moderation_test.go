@@ -0,0 +1,45 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestModerationResult_Normalize(t *testing.T) {
+	r := ModerationResult{
+		Categories: map[string]bool{"hate": false, "violence": true},
+		CategoryScores: map[string]float64{
+			"hate":      -0.2,
+			"sexual":    1.7,
+			"self-harm": math.NaN(),
+		},
+	}
+	r.Normalize()
+
+	if !r.Flagged {
+		t.Error("Flagged = false with a flagged category")
+	}
+	wantScores := map[string]float64{"hate": 0, "sexual": 1, "self-harm": 0, "violence": 0}
+	if len(r.CategoryScores) != len(wantScores) {
+		t.Fatalf("CategoryScores = %v, want %v", r.CategoryScores, wantScores)
+	}
+	for category, want := range wantScores {
+		if got := r.CategoryScores[category]; got != want {
+			t.Errorf("CategoryScores[%q] = %v, want %v", category, got, want)
+		}
+		if _, ok := r.Categories[category]; !ok {
+			t.Errorf("Categories is missing %q", category)
+		}
+	}
+	if r.Categories["sexual"] {
+		t.Error("a category only present in the scores was marked flagged")
+	}
+}
+
+func TestModerationResult_NormalizeNilMaps(t *testing.T) {
+	var r ModerationResult
+	r.Normalize()
+	if r.Flagged || r.Categories == nil || r.CategoryScores == nil {
+		t.Fatalf("Normalize of an empty result = %+v, want unflagged with empty maps", r)
+	}
+}
@@ -13,10 +13,12 @@ import (
 var optionalParamOrder = []string{
 	"temperature",
 	"top_p",
+	"top_k",
 	"n",
 	"seed",
 	"max_tokens",
 	"max_completion_tokens",
+	"reasoning_effort",
 	"presence_penalty",
 	"frequency_penalty",
 	"stop",
@@ -38,6 +40,8 @@ func ParamPopulated(req Request, name string) bool {
 		return req.Temperature != nil
 	case "top_p":
 		return req.TopP != nil
+	case "top_k":
+		return req.TopK != nil
 	case "n":
 		return req.N != nil
 	case "seed":
@@ -46,6 +50,8 @@ func ParamPopulated(req Request, name string) bool {
 		return req.MaxTokens != nil
 	case "max_completion_tokens":
 		return maxCompletionTokensPopulated(req)
+	case "reasoning_effort":
+		return req.ReasoningEffort != ""
 	case "presence_penalty":
 		return req.PresencePenalty != nil
 	case "frequency_penalty":
@@ -0,0 +1,109 @@
+package core
+
+import "strings"
+
+// ReasoningFamily classifies a model by how it takes reasoning-related
+// parameters.
+type ReasoningFamily int
+
+const (
+	// ReasoningNone is any model not known to be a reasoning model. Its
+	// parameters are forwarded as sent.
+	ReasoningNone ReasoningFamily = iota
+	// ReasoningOpenAI covers OpenAI's o-series and GPT-5 reasoning models:
+	// they take reasoning_effort and max_completion_tokens, and reject
+	// max_tokens and the sampling parameters.
+	ReasoningOpenAI
+	// ReasoningR1 covers DeepSeek-R1 style models (deepseek-reasoner and the
+	// R1 distills served by other providers): they take max_tokens, ignore
+	// the sampling parameters, and reject logprobs.
+	ReasoningR1
+)
+
+// ReasoningFamilyOf classifies model by name. Provider prefixes such as
+// "openai/" (OpenRouter) or "deepseek-ai/" (Together) are ignored.
+func ReasoningFamilyOf(model string) ReasoningFamily {
+	name := strings.ToLower(model)
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	switch {
+	case name == "deepseek-reasoner" || strings.Contains(name, "deepseek-r1"):
+		return ReasoningR1
+	case isOSeries(name):
+		return ReasoningOpenAI
+	case strings.HasPrefix(name, "gpt-5") && !strings.HasPrefix(name, "gpt-5-chat"):
+		return ReasoningOpenAI
+	default:
+		return ReasoningNone
+	}
+}
+
+// isOSeries reports whether name is an o-series model: "o" followed by a
+// version digit, as in o1, o3-mini, or o4-mini-2025-04-16.
+func isOSeries(name string) bool {
+	return len(name) >= 2 && name[0] == 'o' && name[1] >= '1' && name[1] <= '9'
+}
+
+// AdaptReasoningParams reshapes req for the reasoning family of its model, so
+// a client can send the same request to any model and have it accepted:
+//
+//   - OpenAI reasoning models lose temperature, top_p, and the penalties,
+//     which they reject, and get max_completion_tokens filled from max_tokens
+//     and reasoning_effort from the thinking option when it is unset.
+//   - R1 models lose the sampling parameters, which they ignore, and
+//     reasoning_effort and logprobs, which they reject.
+//
+// It returns the names of the parameters it dropped, for logging, and leaves
+// requests for other models untouched.
+func (r *Request) AdaptReasoningParams() []string {
+	family := ReasoningFamilyOf(r.Model)
+	if family == ReasoningNone {
+		return nil
+	}
+	var dropped []string
+	drop := func(name string, set bool, clear func()) {
+		if set {
+			clear()
+			dropped = append(dropped, name)
+		}
+	}
+	drop("temperature", r.Temperature != nil, func() { r.Temperature = nil })
+	drop("top_p", r.TopP != nil, func() { r.TopP = nil })
+	drop("presence_penalty", r.PresencePenalty != nil, func() { r.PresencePenalty = nil })
+	drop("frequency_penalty", r.FrequencyPenalty != nil, func() { r.FrequencyPenalty = nil })
+
+	switch family {
+	case ReasoningOpenAI:
+		if r.MaxCompletionTokens == nil && r.MaxTokens != nil {
+			v := *r.MaxTokens
+			r.MaxCompletionTokens = &v
+		}
+		if r.ReasoningEffort == "" && r.Thinking != nil {
+			r.ReasoningEffort = r.effortForThinking()
+		}
+	case ReasoningR1:
+		drop("reasoning_effort", r.ReasoningEffort != "", func() { r.ReasoningEffort = "" })
+		drop("logprobs", r.LogProbs, func() { r.LogProbs = false })
+		drop("top_logprobs", r.TopLogProbs != nil, func() { r.TopLogProbs = nil })
+	}
+	return dropped
+}
+
+// effortForThinking maps the thinking option onto an OpenAI reasoning model's
+// reasoning_effort. Thinking cannot be turned off on these models, so a
+// disabled option asks for the least effort the model takes: "minimal" on
+// GPT-5, "low" on the o-series.
+func (r *Request) effortForThinking() string {
+	if r.Thinking.Enabled {
+		return EffortForBudget(r.Thinking.BudgetTokens)
+	}
+	name := strings.ToLower(r.Model)
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	if isOSeries(name) {
+		return "low"
+	}
+	return "minimal"
+}
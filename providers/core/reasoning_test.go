@@ -0,0 +1,108 @@
+package core
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestReasoningFamilyOf(t *testing.T) {
+	tests := []struct {
+		model string
+		want  ReasoningFamily
+	}{
+		{"o1", ReasoningOpenAI},
+		{"o3-mini", ReasoningOpenAI},
+		{"openai/o4-mini", ReasoningOpenAI},
+		{"gpt-5-mini", ReasoningOpenAI},
+		{"gpt-5-chat-latest", ReasoningNone},
+		{"deepseek-reasoner", ReasoningR1},
+		{"deepseek-ai/DeepSeek-R1-Distill-Llama-70B", ReasoningR1},
+		{"gpt-4o", ReasoningNone},
+		{"omni-moderation", ReasoningNone},
+		{"deepseek-chat", ReasoningNone},
+	}
+	for _, tt := range tests {
+		if got := ReasoningFamilyOf(tt.model); got != tt.want {
+			t.Errorf("ReasoningFamilyOf(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestAdaptReasoningParams(t *testing.T) {
+	temp, topP, maxTokens, topLogProbs := 0.2, 0.9, 512, 3
+
+	req := Request{Model: "o3-mini", Temperature: &temp, TopP: &topP, MaxTokens: &maxTokens, ReasoningEffort: "high"}
+	dropped := req.AdaptReasoningParams()
+	if !slices.Equal(dropped, []string{"temperature", "top_p"}) {
+		t.Fatalf("o-series dropped %v, want temperature and top_p", dropped)
+	}
+	if req.Temperature != nil || req.TopP != nil || req.ReasoningEffort != "high" {
+		t.Fatalf("o-series request not adapted: %+v", req)
+	}
+	if req.MaxCompletionTokens == nil || *req.MaxCompletionTokens != 512 {
+		t.Fatalf("max_completion_tokens = %v, want it filled from max_tokens", req.MaxCompletionTokens)
+	}
+
+	req = Request{Model: "deepseek-reasoner", Temperature: &temp, ReasoningEffort: "low", LogProbs: true, TopLogProbs: &topLogProbs}
+	dropped = req.AdaptReasoningParams()
+	if !slices.Equal(dropped, []string{"temperature", "reasoning_effort", "logprobs", "top_logprobs"}) {
+		t.Fatalf("R1 dropped %v", dropped)
+	}
+	if req.ReasoningEffort != "" || req.LogProbs || req.TopLogProbs != nil {
+		t.Fatalf("R1 request not adapted: %+v", req)
+	}
+
+	req = Request{Model: "gpt-4o", Temperature: &temp, ReasoningEffort: "low"}
+	if dropped := req.AdaptReasoningParams(); dropped != nil || req.Temperature == nil || req.ReasoningEffort != "low" {
+		t.Fatalf("non-reasoning request changed: dropped %v, %+v", dropped, req)
+	}
+}
+
+func TestAdaptReasoningParams_Thinking(t *testing.T) {
+	for _, tt := range []struct {
+		model    string
+		thinking Thinking
+		effort   string
+		want     string
+	}{
+		{"o3", Thinking{Enabled: true, BudgetTokens: 1024}, "", "low"},
+		{"gpt-5", Thinking{Enabled: true, BudgetTokens: 8000}, "", "medium"},
+		{"openai/o4-mini", Thinking{Enabled: true, BudgetTokens: 32000}, "", "high"},
+		{"o3", Thinking{Enabled: true}, "", ""},
+		{"o3", Thinking{}, "", "low"},
+		{"gpt-5-mini", Thinking{}, "", "minimal"},
+		{"o3", Thinking{Enabled: true, BudgetTokens: 32000}, "low", "low"},
+	} {
+		req := Request{Model: tt.model, Thinking: &tt.thinking, ReasoningEffort: tt.effort}
+		req.AdaptReasoningParams()
+		if req.ReasoningEffort != tt.want {
+			t.Errorf("%s with %+v: reasoning_effort = %q, want %q", tt.model, tt.thinking, req.ReasoningEffort, tt.want)
+		}
+	}
+
+	req := Request{Model: "claude-sonnet-4-6", Thinking: &Thinking{Enabled: true, BudgetTokens: 4096}}
+	if req.AdaptReasoningParams(); req.ReasoningEffort != "" {
+		t.Errorf("non-reasoning model got reasoning_effort %q", req.ReasoningEffort)
+	}
+}
+
+func TestThinking_UnmarshalJSON(t *testing.T) {
+	for in, want := range map[string]Thinking{
+		`{"enabled":true,"budget_tokens":2048}`:   {Enabled: true, BudgetTokens: 2048},
+		`{"type":"enabled","budget_tokens":1024}`: {Enabled: true, BudgetTokens: 1024},
+		`{"type":"disabled"}`:                     {},
+		`{"enabled":false}`:                       {},
+	} {
+		var got Thinking
+		if err := json.Unmarshal([]byte(in), &got); err != nil || got != want {
+			t.Errorf("Unmarshal(%s) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{`{}`, `{"type":"adaptive"}`, `{"enabled":true,"budget_tokens":-1}`} {
+		var got Thinking
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("Unmarshal(%s) accepted", in)
+		}
+	}
+}
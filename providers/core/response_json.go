@@ -0,0 +1,113 @@
+package core
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// AppendJSON appends the JSON encoding of r to dst and returns the extended
+// slice. The output is byte-for-byte what encoding/json produces for r. The
+// common response, plain-text messages and no provider or plugin metadata, is
+// written without reflection; a response carrying multipart content or
+// metadata is handed to encoding/json.
+//
+// As with StreamChunk.AppendJSON, a field added to Response, Choice, or
+// Message must be added here too; TestResponseAppendJSON_FieldCoverage fails
+// until it is.
+func (r *Response) AppendJSON(dst []byte) ([]byte, error) {
+	if !r.plainJSON() {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return dst, err
+		}
+		return append(dst, b...), nil
+	}
+
+	dst = append(dst, `{"id":`...)
+	dst = appendJSONString(dst, r.ID)
+	if r.Object != "" {
+		dst = append(dst, `,"object":`...)
+		dst = appendJSONString(dst, r.Object)
+	}
+	if r.Created != 0 {
+		dst = append(dst, `,"created":`...)
+		dst = strconv.AppendInt(dst, r.Created, 10)
+	}
+	dst = append(dst, `,"model":`...)
+	dst = appendJSONString(dst, r.Model)
+	if r.Provider != "" {
+		dst = append(dst, `,"provider":`...)
+		dst = appendJSONString(dst, r.Provider)
+	}
+	dst = append(dst, `,"choices":`...)
+	if r.Choices == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '[')
+		for i := range r.Choices {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			c := &r.Choices[i]
+			dst = append(dst, `{"index":`...)
+			dst = strconv.AppendInt(dst, int64(c.Index), 10)
+			dst = append(dst, `,"message":`...)
+			dst = c.Message.appendJSON(dst)
+			dst = append(dst, `,"finish_reason":`...)
+			dst = appendJSONString(dst, c.FinishReason)
+			dst = append(dst, '}')
+		}
+		dst = append(dst, ']')
+	}
+	dst = append(dst, `,"usage":`...)
+	dst = r.Usage.appendJSON(dst)
+	if r.SystemFingerprint != "" {
+		dst = append(dst, `,"system_fingerprint":`...)
+		dst = appendJSONString(dst, r.SystemFingerprint)
+	}
+	return append(dst, '}'), nil
+}
+
+// plainJSON reports whether AppendJSON can write r without encoding/json.
+func (r *Response) plainJSON() bool {
+	if r.Metadata != nil || r.PluginMetadata != nil {
+		return false
+	}
+	for i := range r.Choices {
+		if len(r.Choices[i].Message.ContentParts) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// appendJSON matches Message.MarshalJSON for a message without ContentParts.
+func (m *Message) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"role":`...)
+	dst = appendJSONString(dst, m.Role)
+	dst = append(dst, `,"content":`...)
+	dst = appendJSONString(dst, m.Content)
+	if m.Name != "" {
+		dst = append(dst, `,"name":`...)
+		dst = appendJSONString(dst, m.Name)
+	}
+	if len(m.ToolCalls) > 0 {
+		dst = append(dst, `,"tool_calls":[`...)
+		for i := range m.ToolCalls {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = m.ToolCalls[i].appendJSON(dst)
+		}
+		dst = append(dst, ']')
+	}
+	if m.ToolCallID != "" {
+		dst = append(dst, `,"tool_call_id":`...)
+		dst = appendJSONString(dst, m.ToolCallID)
+	}
+	if m.ReasoningContent != "" {
+		dst = append(dst, `,"reasoning_content":`...)
+		dst = appendJSONString(dst, m.ReasoningContent)
+	}
+	return append(dst, '}')
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func responseFixtures() []Response {
+	return []Response{
+		{},
+		{ID: "chatcmpl-1", Object: "chat.completion", Created: 1710000000, Model: "gpt-4o", Provider: "openai",
+			Choices:           []Choice{{Message: Message{Role: "assistant", Content: "Hello <world> & \"friends\""}, FinishReason: "stop"}},
+			Usage:             Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15, ReasoningTokens: 2},
+			SystemFingerprint: "fp_1"},
+		{Choices: []Choice{
+			{Index: 0, Message: Message{Role: "assistant", Name: "bot", ReasoningContent: "hmm", ToolCallID: "call_0",
+				ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "f", Arguments: `{"a":1}`}}}},
+				FinishReason: "tool_calls"},
+			{Index: 1, Message: Message{Role: "assistant"}},
+		}},
+		// Multipart content and metadata go through encoding/json.
+		{Choices: []Choice{{Message: Message{Role: "assistant", ContentParts: []ContentPart{{Type: "text", Text: "hi"}}}}}},
+		{Model: "sonar", Metadata: map[string]any{"citations": []string{"https://example.com"}}},
+		{Model: "gpt-4o", PluginMetadata: map[string]any{"guardrail": "pass"}},
+	}
+}
+
+func TestResponseAppendJSON_MatchesEncodingJSON(t *testing.T) {
+	for i, r := range responseFixtures() {
+		want, err := json.Marshal(&r)
+		if err != nil {
+			t.Fatalf("fixture %d: json.Marshal: %v", i, err)
+		}
+		got, err := r.AppendJSON([]byte("prefix"))
+		if err != nil {
+			t.Fatalf("fixture %d: AppendJSON: %v", i, err)
+		}
+		if string(got) != "prefix"+string(want) {
+			t.Errorf("fixture %d:\n got %s\nwant prefix%s", i, got, want)
+		}
+	}
+}
+
+// TestResponseAppendJSON_FieldCoverage fails when a field is added to a type
+// Response.AppendJSON writes by hand, so the encoder is updated with it.
+func TestResponseAppendJSON_FieldCoverage(t *testing.T) {
+	for typ, fields := range map[reflect.Type]int{
+		reflect.TypeFor[Response](): 12,
+		reflect.TypeFor[Choice]():   3,
+		reflect.TypeFor[Message]():  7,
+	} {
+		if got := typ.NumField(); got != fields {
+			t.Errorf("%s has %d fields, AppendJSON encodes %d; update response_json.go", typ.Name(), got, fields)
+		}
+	}
+}
+
+func BenchmarkResponseAppendJSON(b *testing.B) {
+	r := responseFixtures()[1]
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for b.Loop() {
+		var err error
+		if buf, err = r.AppendJSON(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResponseEncodingJSON(b *testing.B) {
+	r := responseFixtures()[1]
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := json.Marshal(&r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
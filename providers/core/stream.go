@@ -1,6 +1,10 @@
 package core
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
 
 // SendChunk sends c on ch unless ctx is done. It returns false when ctx was
 // cancelled before the send completed, signalling the producer goroutine to
@@ -23,6 +27,9 @@ type StreamChunk struct {
 	Created int64          `json:"created"`
 	Model   string         `json:"model"`
 	Choices []StreamChoice `json:"choices"`
+	// SystemFingerprint mirrors Response.SystemFingerprint; providers that
+	// report it repeat it on every chunk.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
 	// Usage is populated in the final chunk by providers that support streaming
 	// usage reporting (e.g. OpenAI with stream_options.include_usage=true);
 	// non-final chunks leave this nil so it is omitted from SSE payloads.
@@ -46,3 +53,122 @@ type MessageDelta struct {
 	// models (e.g. deepseek-reasoner). Empty for models that don't emit it.
 	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
+
+// UnmarshalJSON decodes a MessageDelta, accepting the "reasoning" field that
+// OpenRouter and Groq use in place of reasoning_content.
+func (d *MessageDelta) UnmarshalJSON(b []byte) error {
+	type plain MessageDelta
+	var w struct {
+		plain
+		Reasoning string `json:"reasoning"`
+	}
+	if err := json.Unmarshal(b, &w); err != nil {
+		return err
+	}
+	*d = MessageDelta(w.plain)
+	if d.ReasoningContent == "" {
+		d.ReasoningContent = w.Reasoning
+	}
+	return nil
+}
+
+// MaxStreamChoices is the most choices CollectStream assembles, whatever the
+// request asked for; it matches the largest n OpenAI accepts.
+const MaxStreamChoices = 128
+
+// CollectStream reads ch to the end and assembles the chunks into the
+// non-streaming Response they add up to: content and reasoning are
+// concatenated per choice, tool-call deltas are merged by their index, and
+// the last usage block reported wins. n is the number of choices the request
+// asked for (its n; 0 means the default of one), capped at MaxStreamChoices; a
+// chunk for a choice index past it is an error rather than an allocation the
+// upstream picks the size of. It returns the first error, after draining ch so
+// the producer can finish. Usage is left zero when the stream reports none.
+func CollectStream(ch <-chan StreamChunk, n int) (*Response, error) {
+	n = min(max(n, 1), MaxStreamChoices)
+	resp := &Response{Object: "chat.completion"}
+	var err error
+	for chunk := range ch {
+		if err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			err = chunk.Error
+			continue
+		}
+		if resp.ID == "" {
+			resp.ID = chunk.ID
+		}
+		if resp.Created == 0 {
+			resp.Created = chunk.Created
+		}
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.SystemFingerprint != "" {
+			resp.SystemFingerprint = chunk.SystemFingerprint
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		for _, sc := range chunk.Choices {
+			if sc.Index < 0 {
+				continue
+			}
+			if sc.Index >= n {
+				err = fmt.Errorf("stream choice index %d is out of range for %d requested choice(s)", sc.Index, n)
+				break
+			}
+			for len(resp.Choices) <= sc.Index {
+				resp.Choices = append(resp.Choices, Choice{
+					Index:   len(resp.Choices),
+					Message: Message{Role: "assistant"},
+				})
+			}
+			c := &resp.Choices[sc.Index]
+			if sc.Delta.Role != "" {
+				c.Message.Role = sc.Delta.Role
+			}
+			c.Message.Content += sc.Delta.Content
+			c.Message.ReasoningContent += sc.Delta.ReasoningContent
+			for _, tc := range sc.Delta.ToolCalls {
+				c.Message.ToolCalls = mergeToolCallDelta(c.Message.ToolCalls, tc)
+			}
+			if sc.FinishReason != "" {
+				c.FinishReason = sc.FinishReason
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.Choices {
+		for j := range resp.Choices[i].Message.ToolCalls {
+			resp.Choices[i].Message.ToolCalls[j].Index = nil
+		}
+	}
+	return resp, nil
+}
+
+// mergeToolCallDelta folds one streamed tool-call delta into calls. A delta
+// continues the call with the same index, appending its argument fragment;
+// a delta without an index starts a new call.
+func mergeToolCallDelta(calls []ToolCall, delta ToolCall) []ToolCall {
+	if delta.Index != nil {
+		for i := range calls {
+			if calls[i].Index != nil && *calls[i].Index == *delta.Index {
+				call := &calls[i]
+				if delta.ID != "" {
+					call.ID = delta.ID
+				}
+				if delta.Type != "" {
+					call.Type = delta.Type
+				}
+				call.Function.Name += delta.Function.Name
+				call.Function.Arguments += delta.Function.Arguments
+				return calls
+			}
+		}
+	}
+	return append(calls, delta)
+}
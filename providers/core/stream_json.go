@@ -0,0 +1,213 @@
+package core
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// AppendJSON appends the JSON encoding of c to dst and returns the extended
+// slice. The output is byte-for-byte what encoding/json produces for c,
+// including its HTML-safe escaping of <, >, and &, but without reflection or
+// allocation beyond growing dst.
+//
+// Streaming writes one chunk per token delta, which makes this the hottest
+// encode in the gateway. A field added to StreamChunk or the types it embeds
+// must be added here too; TestStreamChunkAppendJSON_FieldCoverage fails until
+// it is.
+func (c *StreamChunk) AppendJSON(dst []byte) []byte {
+	dst = append(dst, `{"id":`...)
+	dst = appendJSONString(dst, c.ID)
+	dst = append(dst, `,"object":`...)
+	dst = appendJSONString(dst, c.Object)
+	dst = append(dst, `,"created":`...)
+	dst = strconv.AppendInt(dst, c.Created, 10)
+	dst = append(dst, `,"model":`...)
+	dst = appendJSONString(dst, c.Model)
+	dst = append(dst, `,"choices":`...)
+	if c.Choices == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '[')
+		for i := range c.Choices {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = c.Choices[i].appendJSON(dst)
+		}
+		dst = append(dst, ']')
+	}
+	if c.SystemFingerprint != "" {
+		dst = append(dst, `,"system_fingerprint":`...)
+		dst = appendJSONString(dst, c.SystemFingerprint)
+	}
+	if c.Usage != nil {
+		dst = append(dst, `,"usage":`...)
+		dst = c.Usage.appendJSON(dst)
+	}
+	return append(dst, '}')
+}
+
+func (sc *StreamChoice) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"index":`...)
+	dst = strconv.AppendInt(dst, int64(sc.Index), 10)
+	dst = append(dst, `,"delta":{`...)
+	d := &sc.Delta
+	first := true
+	field := func(dst []byte, name string) []byte {
+		if !first {
+			dst = append(dst, ',')
+		}
+		first = false
+		dst = append(dst, '"')
+		dst = append(dst, name...)
+		return append(dst, `":`...)
+	}
+	if d.Role != "" {
+		dst = field(dst, "role")
+		dst = appendJSONString(dst, d.Role)
+	}
+	if d.Content != "" {
+		dst = field(dst, "content")
+		dst = appendJSONString(dst, d.Content)
+	}
+	if len(d.ToolCalls) > 0 {
+		dst = field(dst, "tool_calls")
+		dst = append(dst, '[')
+		for i := range d.ToolCalls {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = d.ToolCalls[i].appendJSON(dst)
+		}
+		dst = append(dst, ']')
+	}
+	if d.ReasoningContent != "" {
+		dst = field(dst, "reasoning_content")
+		dst = appendJSONString(dst, d.ReasoningContent)
+	}
+	dst = append(dst, '}')
+	if sc.FinishReason != "" {
+		dst = append(dst, `,"finish_reason":`...)
+		dst = appendJSONString(dst, sc.FinishReason)
+	}
+	return append(dst, '}')
+}
+
+func (tc *ToolCall) appendJSON(dst []byte) []byte {
+	dst = append(dst, '{')
+	if tc.Index != nil {
+		dst = append(dst, `"index":`...)
+		dst = strconv.AppendInt(dst, int64(*tc.Index), 10)
+		dst = append(dst, ',')
+	}
+	if tc.ID != "" {
+		dst = append(dst, `"id":`...)
+		dst = appendJSONString(dst, tc.ID)
+		dst = append(dst, ',')
+	}
+	if tc.Type != "" {
+		dst = append(dst, `"type":`...)
+		dst = appendJSONString(dst, tc.Type)
+		dst = append(dst, ',')
+	}
+	dst = append(dst, `"function":{`...)
+	if tc.Function.Name != "" {
+		dst = append(dst, `"name":`...)
+		dst = appendJSONString(dst, tc.Function.Name)
+	}
+	if tc.Function.Arguments != "" {
+		if tc.Function.Name != "" {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `"arguments":`...)
+		dst = appendJSONString(dst, tc.Function.Arguments)
+	}
+	return append(dst, "}}"...)
+}
+
+// appendJSON matches Usage.MarshalJSON.
+func (u *Usage) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"prompt_tokens":`...)
+	dst = strconv.AppendInt(dst, int64(u.PromptTokens), 10)
+	dst = append(dst, `,"completion_tokens":`...)
+	dst = strconv.AppendInt(dst, int64(u.CompletionTokens), 10)
+	dst = append(dst, `,"total_tokens":`...)
+	dst = strconv.AppendInt(dst, int64(u.TotalTokens), 10)
+	if u.ReasoningTokens != 0 {
+		dst = append(dst, `,"reasoning_tokens":`...)
+		dst = strconv.AppendInt(dst, int64(u.ReasoningTokens), 10)
+	}
+	if u.CacheReadTokens != 0 {
+		dst = append(dst, `,"cache_read_tokens":`...)
+		dst = strconv.AppendInt(dst, int64(u.CacheReadTokens), 10)
+	}
+	if u.CacheWriteTokens != 0 {
+		dst = append(dst, `,"cache_write_tokens":`...)
+		dst = strconv.AppendInt(dst, int64(u.CacheWriteTokens), 10)
+	}
+	if u.Estimated {
+		dst = append(dst, `,"estimated":true`...)
+	}
+	if u.ReasoningTokens != 0 {
+		dst = append(dst, `,"completion_tokens_details":{"reasoning_tokens":`...)
+		dst = strconv.AppendInt(dst, int64(u.ReasoningTokens), 10)
+		dst = append(dst, '}')
+	}
+	return append(dst, '}')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string, escaped as encoding/json does
+// with HTML escaping on: control characters, <, >, &, U+2028, and U+2029 are
+// escaped, and each invalid UTF-8 byte is replaced with U+FFFD.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func streamChunkFixtures() []StreamChunk {
+	idx := 2
+	return []StreamChunk{
+		{},
+		{ID: "chatcmpl-1", Object: "chat.completion.chunk", Created: 1700000000, Model: "gpt-4o",
+			Choices: []StreamChoice{{Delta: MessageDelta{Role: "assistant", Content: "hello"}}}},
+		{ID: "c", Choices: []StreamChoice{}, SystemFingerprint: "fp_1",
+			Usage: &Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7}},
+		{Usage: &Usage{PromptTokens: 1, TotalTokens: 9, ReasoningTokens: 5, CacheReadTokens: 2, CacheWriteTokens: 3}},
+		{Usage: &Usage{PromptTokens: 6, CompletionTokens: 2, TotalTokens: 8, ReasoningTokens: 1, Estimated: true}},
+		{Choices: []StreamChoice{
+			{Index: 1, FinishReason: "tool_calls", Delta: MessageDelta{ToolCalls: []ToolCall{
+				{Index: &idx, ID: "call_1", Type: "function", Function: FunctionCall{Name: "lookup", Arguments: `{"q":"<a & b>"}`}},
+				{Function: FunctionCall{Arguments: `{"x"`}},
+				{},
+			}}},
+			{Delta: MessageDelta{ReasoningContent: "thinking"}, FinishReason: "stop"},
+		}},
+		{Choices: []StreamChoice{{Delta: MessageDelta{
+			Content: "quote \" backslash \\ ctrl \x00\x01\x1f \b\f\n\r\t html <>& seps \u2028\u2029 bad \xff\xfe utf8 héllo 世界 🎉",
+		}}}},
+	}
+}
+
+func TestStreamChunkAppendJSON_MatchesEncodingJSON(t *testing.T) {
+	for i, c := range streamChunkFixtures() {
+		want, err := json.Marshal(&c)
+		if err != nil {
+			t.Fatalf("fixture %d: json.Marshal: %v", i, err)
+		}
+		if got := c.AppendJSON(nil); string(got) != string(want) {
+			t.Errorf("fixture %d:\n got %s\nwant %s", i, got, want)
+		}
+	}
+}
+
+// TestStreamChunkAppendJSON_FieldCoverage fails when a field is added to a
+// type AppendJSON encodes, so the hand-written encoder is updated with it.
+func TestStreamChunkAppendJSON_FieldCoverage(t *testing.T) {
+	for typ, fields := range map[reflect.Type]int{
+		reflect.TypeFor[StreamChunk]():  8,
+		reflect.TypeFor[StreamChoice](): 3,
+		reflect.TypeFor[MessageDelta](): 4,
+		reflect.TypeFor[ToolCall]():     4,
+		reflect.TypeFor[FunctionCall](): 2,
+		reflect.TypeFor[Usage]():        7,
+	} {
+		if got := typ.NumField(); got != fields {
+			t.Errorf("%s has %d fields, AppendJSON encodes %d; update stream_json.go", typ.Name(), got, fields)
+		}
+	}
+}
+
+func FuzzStreamChunkAppendJSON(f *testing.F) {
+	f.Add("hello", "assistant", "")
+	f.Add("<script>\u2028", "", "\xff")
+	f.Fuzz(func(t *testing.T, content, role, reasoning string) {
+		c := StreamChunk{ID: content, Choices: []StreamChoice{{Delta: MessageDelta{Role: role, Content: content, ReasoningContent: reasoning}}}}
+		want, err := json.Marshal(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.AppendJSON(nil); string(got) != string(want) {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	})
+}
+
+func BenchmarkStreamChunkAppendJSON(b *testing.B) {
+	c := streamChunkFixtures()[1]
+	buf := make([]byte, 0, 512)
+	b.ReportAllocs()
+	for b.Loop() {
+		buf = c.AppendJSON(buf[:0])
+	}
+}
+
+func BenchmarkStreamChunkEncodingJSON(b *testing.B) {
+	c := streamChunkFixtures()[1]
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := json.Marshal(&c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
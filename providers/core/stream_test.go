@@ -0,0 +1,92 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func streamOf(chunks ...StreamChunk) <-chan StreamChunk {
+	ch := make(chan StreamChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func TestCollectStreamAssemblesResponse(t *testing.T) {
+	zero, one := 0, 1
+	resp, err := CollectStream(streamOf(
+		StreamChunk{ID: "c1", Model: "m", Created: 7, Choices: []StreamChoice{{Delta: MessageDelta{Role: "assistant", Content: "Hel"}}}},
+		StreamChunk{ID: "c1", Choices: []StreamChoice{{Delta: MessageDelta{Content: "lo"}}}},
+		StreamChunk{ID: "c1", Choices: []StreamChoice{{Delta: MessageDelta{ToolCalls: []ToolCall{
+			{Index: &zero, ID: "call_a", Type: "function", Function: FunctionCall{Name: "get", Arguments: `{"q":`}},
+			{Index: &one, ID: "call_b", Type: "function", Function: FunctionCall{Name: "put"}},
+		}}}}},
+		StreamChunk{ID: "c1", Choices: []StreamChoice{{Delta: MessageDelta{ToolCalls: []ToolCall{
+			{Index: &zero, Function: FunctionCall{Arguments: `"x"}`}},
+		}}, FinishReason: "tool_calls"}}},
+		StreamChunk{ID: "c1", Usage: &Usage{PromptTokens: 3, CompletionTokens: 5, TotalTokens: 8}},
+	), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "c1" || resp.Model != "m" || resp.Created != 7 || resp.Object != "chat.completion" {
+		t.Fatalf("envelope = %+v", resp)
+	}
+	if len(resp.Choices) != 1 {
+		t.Fatalf("choices = %d, want 1", len(resp.Choices))
+	}
+	c := resp.Choices[0]
+	if c.Message.Content != "Hello" || c.FinishReason != "tool_calls" {
+		t.Fatalf("choice = %+v", c)
+	}
+	calls := c.Message.ToolCalls
+	if len(calls) != 2 || calls[0].Function.Arguments != `{"q":"x"}` || calls[1].ID != "call_b" {
+		t.Fatalf("tool calls = %+v", calls)
+	}
+	if calls[0].Index != nil {
+		t.Fatal("assembled tool calls should not carry a stream index")
+	}
+	if resp.Usage.TotalTokens != 8 {
+		t.Fatalf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestCollectStreamReturnsStreamError(t *testing.T) {
+	boom := errors.New("upstream reset")
+	_, err := CollectStream(streamOf(
+		StreamChunk{Choices: []StreamChoice{{Delta: MessageDelta{Content: "partial"}}}},
+		StreamChunk{Error: boom},
+		StreamChunk{Choices: []StreamChoice{{Delta: MessageDelta{Content: "ignored"}}}},
+	), 0)
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want the stream's error", err)
+	}
+}
+
+func TestCollectStreamBoundsChoiceIndex(t *testing.T) {
+	resp, err := CollectStream(streamOf(
+		StreamChunk{Choices: []StreamChoice{{Index: 0, Delta: MessageDelta{Content: "a"}}, {Index: 1, Delta: MessageDelta{Content: "b"}}}},
+	), 2)
+	if err != nil || len(resp.Choices) != 2 {
+		t.Fatalf("n=2: err = %v, resp = %+v", err, resp)
+	}
+
+	for name, tc := range map[string]struct {
+		index, n int
+	}{
+		"past the default of one": {1, 0},
+		"past n":                  {2, 2},
+		"past the cap":            {MaxStreamChoices, MaxStreamChoices + 1},
+		"far past the cap":        {1 << 30, 1 << 30},
+	} {
+		_, err := CollectStream(streamOf(
+			StreamChunk{Choices: []StreamChoice{{Index: tc.index, Delta: MessageDelta{Content: "x"}}}},
+			StreamChunk{Choices: []StreamChoice{{Delta: MessageDelta{Content: "drained"}}}},
+		), tc.n)
+		if err == nil {
+			t.Errorf("%s: index %d with n=%d was accepted", name, tc.index, tc.n)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Thinking is the normalized extended-thinking option of a chat request: it
+// turns a model's visible reasoning on or off and bounds how many tokens the
+// reasoning may take. Anthropic takes it as its thinking parameter, Gemini as
+// its thinkingConfig; OpenAI reasoning models take it as a reasoning_effort
+// (see EffortForBudget).
+//
+// It decodes from {"enabled": true, "budget_tokens": 4096} and from
+// Anthropic's own {"type": "enabled", "budget_tokens": 4096}.
+type Thinking struct {
+	Enabled bool `json:"enabled"`
+	// BudgetTokens bounds the thinking tokens. 0 leaves the bound to the
+	// provider's default.
+	BudgetTokens int `json:"budget_tokens,omitempty"`
+}
+
+// UnmarshalJSON accepts both the normalized and the Anthropic form.
+func (t *Thinking) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Enabled      *bool  `json:"enabled"`
+		Type         string `json:"type"`
+		BudgetTokens int    `json:"budget_tokens"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch {
+	case raw.Enabled != nil:
+		t.Enabled = *raw.Enabled
+	case raw.Type == "enabled":
+		t.Enabled = true
+	case raw.Type == "disabled":
+		t.Enabled = false
+	default:
+		return errors.New(`thinking: set "enabled" or a "type" of enabled or disabled`)
+	}
+	if raw.BudgetTokens < 0 {
+		return fmt.Errorf("thinking: budget_tokens must not be negative, got %d", raw.BudgetTokens)
+	}
+	t.BudgetTokens = raw.BudgetTokens
+	return nil
+}
+
+// MinThinkingBudget is the smallest budget_tokens Anthropic accepts.
+const MinThinkingBudget = 1024
+
+// Thinking budget thresholds for EffortForBudget, in tokens.
+const (
+	lowEffortMaxBudget    = 2048
+	mediumEffortMaxBudget = 8192
+)
+
+// EffortForBudget returns the reasoning_effort closest to a thinking budget:
+// "low" up to 2048 tokens, "medium" up to 8192, and "high" above. A budget of
+// 0 returns "", leaving the effort to the model's default.
+func EffortForBudget(budget int) string {
+	switch {
+	case budget <= 0:
+		return ""
+	case budget <= lowEffortMaxBudget:
+		return "low"
+	case budget <= mediumEffortMaxBudget:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// effortRanks orders the reasoning_effort values from least to most.
+var effortRanks = map[string]int{"none": 0, "minimal": 1, "low": 2, "medium": 3, "high": 4}
+
+// EffortExceeds reports whether effort asks for more reasoning than limit.
+// An effort this package does not know, such as a newer "xhigh", exceeds
+// every limit.
+func EffortExceeds(effort, limit string) bool {
+	rank, ok := effortRanks[effort]
+	if !ok {
+		return true
+	}
+	return rank > effortRanks[limit]
+}
@@ -63,3 +63,31 @@ func TestUsage_FlatCacheReadTokensBeatsPromptCacheHitTokens(t *testing.T) {
 		t.Errorf("CacheReadTokens = %d, want 9 (flat precedence)", u.CacheReadTokens)
 	}
 }
+
+// TestUsage_MarshalReasoningDetails verifies reasoning tokens are written in
+// OpenAI's nested form as well as the flat field, and that the nested object
+// is omitted when there are none.
+func TestUsage_MarshalReasoningDetails(t *testing.T) {
+	b, err := json.Marshal(Usage{PromptTokens: 1, CompletionTokens: 9, TotalTokens: 10, ReasoningTokens: 6})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	details, _ := raw["completion_tokens_details"].(map[string]any)
+	if details["reasoning_tokens"] != float64(6) || raw["reasoning_tokens"] != float64(6) {
+		t.Fatalf("reasoning tokens not surfaced in both forms: %s", b)
+	}
+
+	var back Usage
+	if err := json.Unmarshal(b, &back); err != nil || back.ReasoningTokens != 6 {
+		t.Fatalf("round trip = %+v, %v", back, err)
+	}
+
+	b, _ = json.Marshal(Usage{TotalTokens: 3})
+	if string(b) != `{"prompt_tokens":0,"completion_tokens":0,"total_tokens":3}` {
+		t.Fatalf("usage without reasoning tokens = %s", b)
+	}
+}
@@ -131,6 +131,7 @@ func (u usage) toCore() core.Usage {
 
 // Complete sends a chat completion request to DeepSeek.
 func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Response, error) {
+	openaicompat.PrepareToolParams(&req)
 	bodyReader, _, release, err := openaicompat.BuildBody(req, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -169,6 +170,7 @@ func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Respon
 	for i := range pResp.Choices {
 		pResp.Choices[i].FinishReason = core.NormalizeFinishReason(pResp.Choices[i].FinishReason)
 	}
+	openaicompat.FillToolCalls(pResp.Choices)
 
 	return &core.Response{
 		ID:       pResp.ID,
@@ -27,12 +27,21 @@ type RequestSigner = core.RequestSigner
 // EmbeddingProvider is an alias for core.EmbeddingProvider.
 type EmbeddingProvider = core.EmbeddingProvider
 
+// EmbeddingDimensionsSupporter is an alias for core.EmbeddingDimensionsSupporter.
+type EmbeddingDimensionsSupporter = core.EmbeddingDimensionsSupporter
+
 // ImageProvider is an alias for core.ImageProvider.
 type ImageProvider = core.ImageProvider
 
+// ModerationProvider is an alias for core.ModerationProvider.
+type ModerationProvider = core.ModerationProvider
+
 // DiscoveryProvider is an alias for core.DiscoveryProvider.
 type DiscoveryProvider = core.DiscoveryProvider
 
+// Warmer is an alias for core.Warmer.
+type Warmer = core.Warmer
+
 // ProviderSource is an alias for core.ProviderSource.
 type ProviderSource = core.ProviderSource
 
@@ -41,6 +50,9 @@ type ProviderSource = core.ProviderSource
 // Request is an alias for core.Request.
 type Request = core.Request
 
+// Thinking is an alias for core.Thinking.
+type Thinking = core.Thinking
+
 // Response is an alias for core.Response.
 type Response = core.Response
 
@@ -107,6 +119,18 @@ type ImageResponse = core.ImageResponse
 // GeneratedImage is an alias for core.GeneratedImage.
 type GeneratedImage = core.GeneratedImage
 
+// ModerationRequest is an alias for core.ModerationRequest.
+type ModerationRequest = core.ModerationRequest
+
+// ModerationResponse is an alias for core.ModerationResponse.
+type ModerationResponse = core.ModerationResponse
+
+// ModerationResult is an alias for core.ModerationResult.
+type ModerationResult = core.ModerationResult
+
+// CacheInfo is an alias for core.CacheInfo.
+type CacheInfo = core.CacheInfo
+
 // ---------------------------------------------------------------- Constants --
 
 // Role constants — re-exported from core.
@@ -118,6 +142,12 @@ const (
 
 	ContentTypeText = core.ContentTypeText
 	SSEDone         = core.SSEDone
+
+	CacheStatusHit   = core.CacheStatusHit
+	CacheStatusMiss  = core.CacheStatusMiss
+	CacheStatusStale = core.CacheStatusStale
+
+	DefaultModerationModel = core.DefaultModerationModel
 )
 
 // ----------------------------------------------------------------- Functions -
@@ -125,11 +155,38 @@ const (
 // UnsupportedParamError re-exports core.UnsupportedParamError.
 type UnsupportedParamError = core.UnsupportedParamError
 
+// ImageFetchError re-exports core.ImageFetchError.
+type ImageFetchError = core.ImageFetchError
+
 // ErrProviderSaturated re-exports core.ErrProviderSaturated.
 var ErrProviderSaturated = core.ErrProviderSaturated
 
+// ErrTierLimitExceeded re-exports core.ErrTierLimitExceeded.
+var ErrTierLimitExceeded = core.ErrTierLimitExceeded
+
+// ErrStreamLimitExceeded re-exports core.ErrStreamLimitExceeded.
+var ErrStreamLimitExceeded = core.ErrStreamLimitExceeded
+
+// ErrResidencyUnsatisfied re-exports core.ErrResidencyUnsatisfied.
+var ErrResidencyUnsatisfied = core.ErrResidencyUnsatisfied
+
+// ErrMalformedResponse re-exports core.ErrMalformedResponse.
+var ErrMalformedResponse = core.ErrMalformedResponse
+
+// ErrRetryBudgetExhausted re-exports core.ErrRetryBudgetExhausted.
+var ErrRetryBudgetExhausted = core.ErrRetryBudgetExhausted
+
+// ErrImageTooLarge re-exports core.ErrImageTooLarge.
+var ErrImageTooLarge = core.ErrImageTooLarge
+
 // ParseStatusCode re-exports core.ParseStatusCode.
 var ParseStatusCode = core.ParseStatusCode
 
 // RetryAfterFrom re-exports core.RetryAfterFrom.
 var RetryAfterFrom = core.RetryAfterFrom
+
+// ProviderBodyError re-exports core.ProviderBodyError.
+type ProviderBodyError = core.ProviderBodyError
+
+// WithProviderBody re-exports core.WithProviderBody.
+var WithProviderBody = core.WithProviderBody
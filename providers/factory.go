@@ -1,10 +1,17 @@
 package providers
 
-import "os"
+import (
+	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/internal/secrets"
+)
 
 // ProviderConfigFromEnv reads environment variables for the given ProviderEntry
 // and returns a populated ProviderConfig, or nil if the provider is not configured.
 //
+// Each variable may instead be supplied as a file: OPENAI_API_KEY_FILE names a
+// file holding the key, for credentials mounted from a Kubernetes Secret. The
+// variable itself wins when both are set.
+//
 // "Not configured" means any EnvMapping with Required=true has an empty env var.
 // In that case, nil is returned and the provider should be silently skipped.
 //
@@ -14,7 +21,11 @@ import "os"
 func ProviderConfigFromEnv(entry ProviderEntry) ProviderConfig {
 	cfg := make(ProviderConfig, len(entry.EnvMappings))
 	for _, m := range entry.EnvMappings {
-		val := os.Getenv(m.EnvVar)
+		val, err := secrets.EnvOrFile(m.EnvVar)
+		if err != nil {
+			// An unreadable file leaves the variable unset.
+			logging.Logger.Warn("provider credential file unreadable", "env", m.EnvVar+"_FILE", "error", err)
+		}
 		if val == "" && m.Required {
 			return nil
 		}
@@ -68,8 +79,10 @@ const (
 	CfgKeySessionToken    = "session_token"     // AWS session token (optional)
 
 	// Ollama
-	CfgKeyHost   = "host"   // Ollama server host (primary required key)
-	CfgKeyModels = "models" // comma-separated model list
+	CfgKeyHost      = "host"       // Ollama server host (primary required key)
+	CfgKeyModels    = "models"     // comma-separated model list
+	CfgKeyKeepAlive = "keep_alive" // how long Ollama keeps a model loaded
+	CfgKeyNumCtx    = "num_ctx"    // Ollama context window, in tokens
 
 	// Replicate
 	CfgKeyAPIToken    = "api_token"    // Replicate API token (primary required key)
@@ -79,12 +92,13 @@ const (
 
 // Capability names for capability-based registry filtering.
 const (
-	CapabilityChat      = "chat"      // Provider.Complete  — always present
-	CapabilityStream    = "stream"    // StreamProvider
-	CapabilityEmbed     = "embed"     // EmbeddingProvider
-	CapabilityImage     = "image"     // ImageProvider
-	CapabilityDiscovery = "discovery" // DiscoveryProvider
-	CapabilityProxy     = "proxy"     // ProxiableProvider
+	CapabilityChat       = "chat"       // Provider.Complete  — always present
+	CapabilityStream     = "stream"     // StreamProvider
+	CapabilityEmbed      = "embed"      // EmbeddingProvider
+	CapabilityImage      = "image"      // ImageProvider
+	CapabilityModeration = "moderation" // ModerationProvider
+	CapabilityDiscovery  = "discovery"  // DiscoveryProvider
+	CapabilityProxy      = "proxy"      // ProxiableProvider
 )
 
 // EnvMapping maps a single ProviderConfig key to its environment variable.
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/ferro-labs/ai-gateway/providers/core"
+	"github.com/ferro-labs/ai-gateway/providers/internal/geminiwire"
 )
 
 type geminiEmbeddingContent struct {
@@ -18,6 +20,7 @@ type geminiEmbeddingContent struct {
 type geminiBatchEmbedContentRequest struct {
 	Model                string                 `json:"model"`
 	Content              geminiEmbeddingContent `json:"content"`
+	TaskType             string                 `json:"taskType,omitempty"`
 	OutputDimensionality *int                   `json:"outputDimensionality,omitempty"`
 }
 
@@ -35,12 +38,25 @@ type geminiBatchEmbedResponse struct {
 	} `json:"usageMetadata"`
 }
 
+// geminiMaxEmbedBatch is the most texts batchEmbedContents accepts in one
+// call; longer inputs are sent in consecutive batches.
+const geminiMaxEmbedBatch = 100
+
 func geminiModelResource(model string) string {
 	model = strings.TrimPrefix(model, "models/")
 	return "models/" + model
 }
 
-// Embed sends a text embedding request to Gemini's batchEmbedContents endpoint.
+// SupportsEmbeddingDimensions reports whether model honours a requested
+// output dimension: gemini-embedding and text-embedding-004 do.
+func (p *Provider) SupportsEmbeddingDimensions(model string) bool {
+	model = strings.TrimPrefix(model, "models/")
+	return strings.HasPrefix(model, "gemini-embedding") || strings.HasPrefix(model, "text-embedding-004")
+}
+
+// Embed sends a text embedding request to Gemini's batchEmbedContents
+// endpoint, in batches of at most geminiMaxEmbedBatch texts. input_type is
+// sent as the task type (see geminiwire.ResolveTaskType).
 func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.EmbeddingResponse, error) {
 	if err := core.ValidateEmbeddingEncodingFormat(req.EncodingFormat); err != nil {
 		return nil, err
@@ -49,22 +65,62 @@ func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.
 	if err != nil {
 		return nil, err
 	}
+	taskType, err := geminiwire.ResolveTaskType(req.InputType)
+	if err != nil {
+		return nil, err
+	}
 
 	model := strings.TrimPrefix(req.Model, "models/")
 	modelResource := geminiModelResource(req.Model)
-	geminiReq := geminiBatchEmbedRequest{
-		Requests: make([]geminiBatchEmbedContentRequest, 0, len(texts)),
-	}
-	for _, text := range texts {
-		geminiReq.Requests = append(geminiReq.Requests, geminiBatchEmbedContentRequest{
-			Model:                modelResource,
-			Content:              geminiEmbeddingContent{Parts: []geminiPart{{Text: text}}},
-			OutputDimensionality: req.Dimensions,
-		})
+	endpoint := fmt.Sprintf("%s/v1beta/models/%s:batchEmbedContents", p.baseURL, url.PathEscape(model))
+
+	data := make([]core.Embedding, 0, len(texts))
+	var usage core.EmbeddingUsage
+	for batch := range slices.Chunk(texts, geminiMaxEmbedBatch) {
+		geminiReq := geminiBatchEmbedRequest{
+			Requests: make([]geminiBatchEmbedContentRequest, 0, len(batch)),
+		}
+		for _, text := range batch {
+			geminiReq.Requests = append(geminiReq.Requests, geminiBatchEmbedContentRequest{
+				Model:                modelResource,
+				Content:              geminiEmbeddingContent{Parts: []geminiPart{{Text: text}}},
+				TaskType:             taskType,
+				OutputDimensionality: req.Dimensions,
+			})
+		}
+		geminiResp, err := p.embedBatch(ctx, endpoint, geminiReq)
+		if err != nil {
+			return nil, err
+		}
+		if len(geminiResp.Embeddings) != len(batch) {
+			return nil, fmt.Errorf("gemini embed API returned %d embeddings for %d inputs", len(geminiResp.Embeddings), len(batch))
+		}
+		for _, embedding := range geminiResp.Embeddings {
+			data = append(data, core.Embedding{
+				Object:    "embedding",
+				Embedding: embedding.Values,
+				Index:     len(data),
+			})
+		}
+		totalTokens := geminiResp.UsageMetadata.TotalTokenCount
+		if totalTokens == 0 {
+			totalTokens = geminiResp.UsageMetadata.PromptTokenCount
+		}
+		usage.PromptTokens += geminiResp.UsageMetadata.PromptTokenCount
+		usage.TotalTokens += totalTokens
 	}
 
-	url := fmt.Sprintf("%s/v1beta/models/%s:batchEmbedContents", p.baseURL, url.PathEscape(model))
-	httpResp, release, err := p.doJSONRequest(ctx, url, "embed ", geminiReq)
+	return &core.EmbeddingResponse{
+		Object: "list",
+		Data:   data,
+		Model:  req.Model,
+		Usage:  usage,
+	}, nil
+}
+
+// embedBatch sends one batchEmbedContents call.
+func (p *Provider) embedBatch(ctx context.Context, endpoint string, geminiReq geminiBatchEmbedRequest) (*geminiBatchEmbedResponse, error) {
+	httpResp, release, err := p.doJSONRequest(ctx, endpoint, "embed ", geminiReq)
 	if err != nil {
 		return nil, err
 	}
@@ -84,30 +140,5 @@ func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.
 	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal embed response: %w", err)
 	}
-	if len(geminiResp.Embeddings) != len(texts) {
-		return nil, fmt.Errorf("gemini embed API returned %d embeddings for %d inputs", len(geminiResp.Embeddings), len(texts))
-	}
-
-	data := make([]core.Embedding, len(geminiResp.Embeddings))
-	for i, embedding := range geminiResp.Embeddings {
-		data[i] = core.Embedding{
-			Object:    "embedding",
-			Embedding: embedding.Values,
-			Index:     i,
-		}
-	}
-
-	totalTokens := geminiResp.UsageMetadata.TotalTokenCount
-	if totalTokens == 0 {
-		totalTokens = geminiResp.UsageMetadata.PromptTokenCount
-	}
-	return &core.EmbeddingResponse{
-		Object: "list",
-		Data:   data,
-		Model:  req.Model,
-		Usage: core.EmbeddingUsage{
-			PromptTokens: geminiResp.UsageMetadata.PromptTokenCount,
-			TotalTokens:  totalTokens,
-		},
-	}, nil
+	return &geminiResp, nil
 }
@@ -122,7 +122,11 @@ func (p *Provider) Models() []core.ModelInfo {
 }
 
 type geminiPart struct {
-	Text             string                  `json:"text,omitempty"`
+	Text string `json:"text,omitempty"`
+	// Thought marks a response part as a thought summary rather than answer
+	// text; it is returned only when the request enables thinking (see
+	// geminiThinking), and surfaces as reasoning content.
+	Thought          bool                    `json:"thought,omitempty"`
 	InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
 	FileData         *geminiFileData         `json:"fileData,omitempty"`
 	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
@@ -163,6 +167,7 @@ type geminiContent struct {
 type geminiGenerationConfig struct {
 	Temperature      *float64 `json:"temperature,omitempty"`
 	TopP             *float64 `json:"topP,omitempty"`
+	TopK             *int     `json:"topK,omitempty"`
 	CandidateCount   *int     `json:"candidateCount,omitempty"`
 	Seed             *int64   `json:"seed,omitempty"`
 	MaxOutputTokens  *int     `json:"maxOutputTokens,omitempty"`
@@ -170,6 +175,31 @@ type geminiGenerationConfig struct {
 	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
 	StopSequences    []string `json:"stopSequences,omitempty"`
 	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+
+	ThinkingConfig *geminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// geminiThinkingConfig asks a thinking model to return its thought summaries
+// and, optionally, bounds the tokens it spends thinking.
+type geminiThinkingConfig struct {
+	IncludeThoughts bool `json:"includeThoughts,omitempty"`
+	ThinkingBudget  *int `json:"thinkingBudget,omitempty"`
+}
+
+// geminiThinking maps the request's normalized thinking option onto Gemini's
+// thinkingConfig. Only an enabled option maps: Gemini's 2.5 Pro models cannot
+// turn thinking off, so a disabled option leaves the model's default, which
+// returns no thoughts.
+func geminiThinking(t *core.Thinking) *geminiThinkingConfig {
+	if t == nil || !t.Enabled {
+		return nil
+	}
+	cfg := &geminiThinkingConfig{IncludeThoughts: true}
+	if t.BudgetTokens > 0 {
+		budget := t.BudgetTokens
+		cfg.ThinkingBudget = &budget
+	}
+	return cfg
 }
 
 type geminiRequest struct {
@@ -378,12 +408,14 @@ func buildRequest(req core.Request) geminiRequest {
 	cfg := geminiGenerationConfig{
 		Temperature:      req.Temperature,
 		TopP:             req.TopP,
+		TopK:             req.TopK,
 		CandidateCount:   req.N,
 		Seed:             req.Seed,
 		MaxOutputTokens:  req.MaxTokens,
 		PresencePenalty:  req.PresencePenalty,
 		FrequencyPenalty: req.FrequencyPenalty,
 		StopSequences:    req.Stop,
+		ThinkingConfig:   geminiThinking(req.Thinking),
 	}
 	// Map OpenAI response_format JSON modes to Gemini's responseMimeType. The
 	// schema itself is not forwarded (Gemini uses a restricted schema dialect),
@@ -391,9 +423,10 @@ func buildRequest(req core.Request) geminiRequest {
 	if rf := req.ResponseFormat; rf != nil && (rf.Type == "json_object" || rf.Type == "json_schema") {
 		cfg.ResponseMimeType = "application/json"
 	}
-	hasConfig := cfg.Temperature != nil || cfg.TopP != nil || cfg.CandidateCount != nil ||
+	hasConfig := cfg.Temperature != nil || cfg.TopP != nil || cfg.TopK != nil || cfg.CandidateCount != nil ||
 		cfg.Seed != nil || cfg.MaxOutputTokens != nil || cfg.PresencePenalty != nil ||
-		cfg.FrequencyPenalty != nil || len(cfg.StopSequences) > 0 || cfg.ResponseMimeType != ""
+		cfg.FrequencyPenalty != nil || len(cfg.StopSequences) > 0 || cfg.ResponseMimeType != "" ||
+		cfg.ThinkingConfig != nil
 	if hasConfig {
 		r.GenerationConfig = &cfg
 	}
@@ -526,8 +559,8 @@ func (p *Provider) doJSONRequest(ctx context.Context, reqURL, label string, body
 	return httpResp, release, nil
 }
 
-// parseCandidateParts accumulates text and tool calls from one candidate's
-// parts. When withIndex is set, each tool call carries its position index
+// parseCandidateParts accumulates text, thought text, and tool calls from one
+// candidate's parts. When withIndex is set, each tool call carries its position index
 // (required for streaming deltas). candidateIndex seeds synthetic tool-call IDs
 // when the provider omits them. toolCallCounter, when non-nil, tracks the
 // running tool-call count for this candidate across the whole stream: Gemini
@@ -535,10 +568,12 @@ func (p *Provider) doJSONRequest(ctx context.Context, reqURL, label string, body
 // per-chunk counter would restart at 0 and misalign indices/IDs across chunks.
 // Pass nil for single-shot (non-streaming) parsing, where a fresh count is
 // correct.
-func parseCandidateParts(parts []geminiPart, candidateIndex int, withIndex bool, toolCallCounter *int) (string, []core.ToolCall) {
-	var text string
-	var toolCalls []core.ToolCall
+func parseCandidateParts(parts []geminiPart, candidateIndex int, withIndex bool, toolCallCounter *int) (text, reasoning string, toolCalls []core.ToolCall) {
 	for _, part := range parts {
+		if part.Thought {
+			reasoning += part.Text
+			continue
+		}
 		text += part.Text
 		if part.FunctionCall != nil {
 			args := string(part.FunctionCall.Args)
@@ -571,7 +606,7 @@ func parseCandidateParts(parts []geminiPart, candidateIndex int, withIndex bool,
 			toolCalls = append(toolCalls, tc)
 		}
 	}
-	return text, toolCalls
+	return text, reasoning, toolCalls
 }
 
 // Complete sends a chat completion request to Gemini.
@@ -607,13 +642,14 @@ func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Respon
 
 	var choices []core.Choice
 	for i, candidate := range geminiResp.Candidates {
-		text, toolCalls := parseCandidateParts(candidate.Content.Parts, i, false, nil)
+		text, reasoning, toolCalls := parseCandidateParts(candidate.Content.Parts, i, false, nil)
 		choices = append(choices, core.Choice{
 			Index: i,
 			Message: core.Message{
-				Role:      "assistant",
-				Content:   text,
-				ToolCalls: toolCalls,
+				Role:             "assistant",
+				Content:          text,
+				ReasoningContent: reasoning,
+				ToolCalls:        toolCalls,
 			},
 			FinishReason: geminiFinishReason(candidate.FinishReason, toolCalls),
 		})
@@ -686,14 +722,15 @@ func (p *Provider) CompleteStream(ctx context.Context, req core.Request) (<-chan
 			}
 			for i, candidate := range chunk.Candidates {
 				counter := toolCallCounters[i]
-				text, toolCalls := parseCandidateParts(candidate.Content.Parts, i, true, &counter)
+				text, reasoning, toolCalls := parseCandidateParts(candidate.Content.Parts, i, true, &counter)
 				toolCallCounters[i] = counter
 				sc.Choices = append(sc.Choices, core.StreamChoice{
 					Index: i,
 					Delta: core.MessageDelta{
-						Role:      "assistant",
-						Content:   text,
-						ToolCalls: toolCalls,
+						Role:             "assistant",
+						Content:          text,
+						ReasoningContent: reasoning,
+						ToolCalls:        toolCalls,
 					},
 					FinishReason: geminiFinishReason(candidate.FinishReason, toolCalls),
 				})
@@ -17,7 +17,7 @@ func intp(i int) *int        { return &i }
 
 // TestComplete_MapsSupportedParamsToGenerationConfig verifies #140 native wiring
 // for Gemini: OpenAI sampling params land under generationConfig with Gemini
-// field names (topP, candidateCount, seed, stopSequences, penalties), and
+// field names (topP, topK, candidateCount, seed, stopSequences, penalties), and
 // response_format JSON mode maps to responseMimeType.
 func TestComplete_MapsSupportedParamsToGenerationConfig(t *testing.T) {
 	var captured struct {
@@ -41,6 +41,7 @@ func TestComplete_MapsSupportedParamsToGenerationConfig(t *testing.T) {
 		Messages:         []core.Message{{Role: "user", Content: "hi"}},
 		Temperature:      f64(0.7),
 		TopP:             f64(0.9),
+		TopK:             intp(40),
 		N:                intp(2),
 		Seed:             i64(42),
 		MaxTokens:        intp(64),
@@ -56,7 +57,7 @@ func TestComplete_MapsSupportedParamsToGenerationConfig(t *testing.T) {
 		t.Fatalf("generationConfig missing from request body")
 	}
 	for _, k := range []string{
-		"temperature", "topP", "candidateCount", "seed", "maxOutputTokens",
+		"temperature", "topP", "topK", "candidateCount", "seed", "maxOutputTokens",
 		"presencePenalty", "frequencyPenalty", "stopSequences", "responseMimeType",
 	} {
 		if _, ok := gc[k]; !ok {
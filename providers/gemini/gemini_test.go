@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -172,6 +173,56 @@ func TestGeminiProvider_Embed_BatchSuccess(t *testing.T) {
 	}
 }
 
+func TestGeminiProvider_Embed_TaskTypeAndBatching(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Requests []struct {
+				TaskType string `json:"taskType"`
+			} `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		batches = append(batches, len(body.Requests))
+		for _, r := range body.Requests {
+			if r.TaskType != "RETRIEVAL_QUERY" {
+				t.Fatalf("taskType = %q, want RETRIEVAL_QUERY", r.TaskType)
+			}
+		}
+		embeddings := make([]string, len(body.Requests))
+		for i := range embeddings {
+			embeddings[i] = `{"values":[0.5]}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"embeddings":[%s],"usageMetadata":{"promptTokenCount":%d}}`, strings.Join(embeddings, ","), len(body.Requests))
+	}))
+	defer srv.Close()
+
+	texts := make([]string, geminiMaxEmbedBatch+20)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+	p, _ := New("test-key", srv.URL)
+	resp, err := p.Embed(context.Background(), core.EmbeddingRequest{
+		Model:     "gemini-embedding-001",
+		Input:     texts,
+		InputType: "search_query",
+	})
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if len(batches) != 2 || batches[0] != geminiMaxEmbedBatch || batches[1] != 20 {
+		t.Errorf("batches = %v, want [%d 20]", batches, geminiMaxEmbedBatch)
+	}
+	if len(resp.Data) != len(texts) || resp.Data[len(texts)-1].Index != len(texts)-1 {
+		t.Fatalf("got %d embeddings, want %d indexed in order", len(resp.Data), len(texts))
+	}
+	if resp.Usage.PromptTokens != len(texts) || resp.Usage.TotalTokens != len(texts) {
+		t.Errorf("usage = %+v, want %d summed over batches", resp.Usage, len(texts))
+	}
+}
+
 func TestGeminiProvider_Embed_StringInput(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var body struct {
@@ -616,6 +667,49 @@ func TestGeminiProvider_Complete_SurfacesProviderIDAndTokens(t *testing.T) {
 	}
 }
 
+// TestGeminiProvider_Complete_ThoughtParts verifies thought-summary parts are
+// surfaced as reasoning content and kept out of the answer text.
+func TestGeminiProvider_Complete_ThoughtParts(t *testing.T) {
+	_, resp := geminiCompleteBody(t, core.Request{
+		Model:    "gemini-2.5-flash",
+		Messages: []core.Message{{Role: core.RoleUser, Content: "hi"}},
+	}, `{"candidates":[{"content":{"parts":[{"text":"weighing it","thought":true},{"text":"42"}]},"finishReason":"STOP"}],"usageMetadata":{"totalTokenCount":1}}`)
+
+	msg := resp.Choices[0].Message
+	if msg.Content != "42" || msg.ReasoningContent != "weighing it" {
+		t.Fatalf("content/reasoning = %q/%q, want 42/weighing it", msg.Content, msg.ReasoningContent)
+	}
+}
+
+// TestGeminiProvider_Complete_ThinkingConfig verifies the thinking option asks
+// Gemini for its thought summaries, and a disabled one sends nothing.
+func TestGeminiProvider_Complete_ThinkingConfig(t *testing.T) {
+	for name, tc := range map[string]struct {
+		thinking *core.Thinking
+		want     string
+	}{
+		"enabled with a budget": {&core.Thinking{Enabled: true, BudgetTokens: 2048}, `{"includeThoughts":true,"thinkingBudget":2048}`},
+		"enabled":               {&core.Thinking{Enabled: true}, `{"includeThoughts":true}`},
+		"disabled":              {&core.Thinking{}, ""},
+		"unset":                 {nil, ""},
+	} {
+		captured, _ := geminiCompleteBody(t, core.Request{
+			Model:    "gemini-2.5-flash",
+			Messages: []core.Message{{Role: core.RoleUser, Content: "hi"}},
+			Thinking: tc.thinking,
+		}, `{"candidates":[{"content":{"parts":[{"text":"42"}]},"finishReason":"STOP"}]}`)
+		var cfg struct {
+			ThinkingConfig json.RawMessage `json:"thinkingConfig"`
+		}
+		if raw := captured["generationConfig"]; raw != nil {
+			_ = json.Unmarshal(raw, &cfg)
+		}
+		if got := string(cfg.ThinkingConfig); got != tc.want {
+			t.Errorf("%s: thinkingConfig = %s, want %s", name, got, tc.want)
+		}
+	}
+}
+
 // TestGeminiProvider_FinishReason_ContentFilter verifies Gemini's content-block
 // reasons normalize to the canonical content_filter value (#264).
 func TestGeminiProvider_FinishReason_ContentFilter(t *testing.T) {
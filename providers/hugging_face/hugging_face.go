@@ -167,6 +167,10 @@ func (p *Provider) postTask(ctx context.Context, url string, body io.Reader) ([]
 	return respBody, nil
 }
 
+// SupportsEmbeddingDimensions reports false: the task API has no output
+// dimension parameter.
+func (p *Provider) SupportsEmbeddingDimensions(string) bool { return false }
+
 // Embed sends a feature-extraction request to Hugging Face. The task API is not
 // OpenAI-shaped: it takes {"inputs": <string|[]string>} and returns a bare JSON
 // array of float vectors ([]float64 for a single input, [][]float64 for a
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/ferro-labs/ai-gateway/internal/logging"
+	"github.com/ferro-labs/ai-gateway/providers/core"
 )
 
 // maxAnthropicTemperature is the upper bound the Anthropic Messages API accepts
@@ -30,3 +31,44 @@ func ClampTemperature(ctx context.Context, provider, model string, temp *float64
 	clamped := maxAnthropicTemperature
 	return &clamped
 }
+
+// minThinkingBudget is the smallest thinking budget Anthropic accepts, and
+// the budget given to thinking enabled without one.
+const minThinkingBudget = 1024
+
+// Thinking is Anthropic's extended-thinking request parameter.
+type Thinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens,omitempty"`
+}
+
+// MapThinking maps the request's normalized thinking option onto Anthropic's
+// thinking parameter and the max_tokens to send with it. A budget below
+// Anthropic's minimum of 1024 tokens is raised to it. Anthropic counts the
+// thinking against max_tokens and requires max_tokens to exceed the budget,
+// so a maxTokens at or below the budget is raised by answerTokens, the
+// provider's default reply length. It returns nil and maxTokens unchanged when
+// req sets no thinking option.
+func MapThinking(ctx context.Context, provider string, req core.Request, maxTokens, answerTokens int) (*Thinking, int) {
+	t := req.Thinking
+	if t == nil {
+		return nil, maxTokens
+	}
+	if !t.Enabled {
+		return &Thinking{Type: "disabled"}, maxTokens
+	}
+	budget := max(t.BudgetTokens, minThinkingBudget)
+	if maxTokens <= budget {
+		logging.FromContext(ctx).Debug("max_tokens does not exceed the thinking budget; raising it",
+			"provider", provider, "model", req.Model, "max_tokens", maxTokens, "budget_tokens", budget)
+		maxTokens = budget + answerTokens
+	}
+	return &Thinking{Type: "enabled", BudgetTokens: budget}, maxTokens
+}
+
+// Enabled reports whether t turns extended thinking on. Anthropic rejects
+// temperature, top_k, and a top_p below 0.95 alongside it, so the request
+// builders drop those when it does.
+func (t *Thinking) Enabled() bool {
+	return t != nil && t.Type == "enabled"
+}
@@ -3,6 +3,8 @@ package anthropicwire
 import (
 	"context"
 	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers/core"
 )
 
 func f64(v float64) *float64 { return &v }
@@ -39,3 +41,28 @@ func TestClampTemperature(t *testing.T) {
 		})
 	}
 }
+
+func TestMapThinking(t *testing.T) {
+	cases := []struct {
+		name          string
+		thinking      *core.Thinking
+		maxTokens     int
+		wantThinking  *Thinking
+		wantMaxTokens int
+	}{
+		{"unset", nil, 1024, nil, 1024},
+		{"disabled", &core.Thinking{}, 1024, &Thinking{Type: "disabled"}, 1024},
+		{"within max_tokens", &core.Thinking{Enabled: true, BudgetTokens: 2048}, 8192, &Thinking{Type: "enabled", BudgetTokens: 2048}, 8192},
+		{"max_tokens raised past budget", &core.Thinking{Enabled: true, BudgetTokens: 4096}, 4096, &Thinking{Type: "enabled", BudgetTokens: 4096}, 5120},
+		{"budget raised to minimum", &core.Thinking{Enabled: true, BudgetTokens: 100}, 8192, &Thinking{Type: "enabled", BudgetTokens: 1024}, 8192},
+		{"no budget", &core.Thinking{Enabled: true}, 1024, &Thinking{Type: "enabled", BudgetTokens: 1024}, 2048},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, maxTokens := MapThinking(context.Background(), "anthropic", core.Request{Model: "claude", Thinking: tc.thinking}, tc.maxTokens, 1024)
+			if (got == nil) != (tc.wantThinking == nil) || (got != nil && *got != *tc.wantThinking) || maxTokens != tc.wantMaxTokens {
+				t.Fatalf("MapThinking = %+v, %d; want %+v, %d", got, maxTokens, tc.wantThinking, tc.wantMaxTokens)
+			}
+		})
+	}
+}
@@ -22,14 +22,15 @@ type Usage struct {
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 }
 
-// ContentBlock is one block of an Anthropic Messages response (a text block or a
-// tool_use block). Only the fields relevant to Type are populated.
+// ContentBlock is one block of an Anthropic Messages response (a text,
+// thinking, or tool_use block). Only the fields relevant to Type are populated.
 type ContentBlock struct {
-	Type  string          `json:"type"`
-	Text  string          `json:"text"`
-	ID    string          `json:"id"`
-	Name  string          `json:"name"`
-	Input json.RawMessage `json:"input"`
+	Type     string          `json:"type"`
+	Text     string          `json:"text"`
+	Thinking string          `json:"thinking"`
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Input    json.RawMessage `json:"input"`
 }
 
 // Response is the non-streaming Anthropic Messages API response body, shared by
@@ -73,6 +74,19 @@ func DecodeContent(blocks []ContentBlock) (text string, toolCalls []core.ToolCal
 	return b.String(), toolCalls
 }
 
+// DecodeThinking concatenates the text of a response's extended-thinking
+// blocks, in order. Redacted thinking blocks carry only an encrypted payload
+// and contribute nothing.
+func DecodeThinking(blocks []ContentBlock) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		if block.Type == "thinking" {
+			b.WriteString(block.Thinking)
+		}
+	}
+	return b.String()
+}
+
 // ParseDataURI splits a data URI of the form
 // "data:<media-type>[;param]...;base64,<data>" into its media type and base64
 // payload. ok is false for any non-base64 data URI or a plain remote URL,
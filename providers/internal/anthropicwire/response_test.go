@@ -2,6 +2,21 @@ package anthropicwire
 
 import "testing"
 
+func TestDecodeThinking(t *testing.T) {
+	blocks := []ContentBlock{
+		{Type: "thinking", Thinking: "first, "},
+		{Type: "redacted_thinking"},
+		{Type: "thinking", Thinking: "then"},
+		{Type: "text", Text: "answer"},
+	}
+	if got := DecodeThinking(blocks); got != "first, then" {
+		t.Fatalf("DecodeThinking = %q, want %q", got, "first, then")
+	}
+	if text, _ := DecodeContent(blocks); text != "answer" {
+		t.Fatalf("DecodeContent = %q, want thinking excluded", text)
+	}
+}
+
 func TestParseDataURI(t *testing.T) {
 	cases := []struct {
 		name      string
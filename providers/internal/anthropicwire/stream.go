@@ -149,6 +149,7 @@ func (d *StreamDecoder) contentBlockDelta(data []byte) []core.StreamChunk {
 		Delta struct {
 			Type        string `json:"type"`
 			Text        string `json:"text"`
+			Thinking    string `json:"thinking"`
 			PartialJSON string `json:"partial_json"`
 		} `json:"delta"`
 	}
@@ -180,6 +181,15 @@ func (d *StreamDecoder) contentBlockDelta(data []byte) []core.StreamChunk {
 				Delta: core.MessageDelta{Content: evt.Delta.Text},
 			}},
 		}}
+	case "thinking_delta":
+		return []core.StreamChunk{{
+			ID:    d.msgID,
+			Model: d.chunkModel(),
+			Choices: []core.StreamChoice{{
+				Index: 0,
+				Delta: core.MessageDelta{ReasoningContent: evt.Delta.Thinking},
+			}},
+		}}
 	default:
 		return nil
 	}
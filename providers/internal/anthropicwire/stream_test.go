@@ -80,6 +80,27 @@ func TestStreamDecoder_FallbackModelAndUsage(t *testing.T) {
 	}
 }
 
+func TestStreamDecoder_ThinkingDelta(t *testing.T) {
+	d := NewStreamDecoder("anthropic", "")
+	chunks, err := drain(d,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"let me see"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"abc"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"42"}}`,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %d, want 2 (signature deltas are dropped): %#v", len(chunks), chunks)
+	}
+	if got := chunks[0].Choices[0].Delta; got.ReasoningContent != "let me see" || got.Content != "" {
+		t.Fatalf("thinking delta = %#v, want reasoning content only", got)
+	}
+	if got := chunks[1].Choices[0].Delta.Content; got != "42" {
+		t.Fatalf("text = %q, want 42", got)
+	}
+}
+
 func TestStreamDecoder_ToolUseSequenceAndZeroArgTool(t *testing.T) {
 	d := NewStreamDecoder("anthropic", "")
 	// Two tool calls: the first receives arguments, the second is zero-argument
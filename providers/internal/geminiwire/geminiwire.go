@@ -0,0 +1,40 @@
+// Package geminiwire holds embedding request mapping shared by the Gemini
+// provider and the Vertex AI provider, whose embedding models take the same
+// task types over different endpoints.
+package geminiwire
+
+import (
+	"fmt"
+	"strings"
+)
+
+// taskTypes are the embedding task types Gemini embedding models accept.
+var taskTypes = map[string]bool{
+	"RETRIEVAL_QUERY":      true,
+	"RETRIEVAL_DOCUMENT":   true,
+	"SEMANTIC_SIMILARITY":  true,
+	"CLASSIFICATION":       true,
+	"CLUSTERING":           true,
+	"QUESTION_ANSWERING":   true,
+	"FACT_VERIFICATION":    true,
+	"CODE_RETRIEVAL_QUERY": true,
+}
+
+// ResolveTaskType maps a caller's input_type onto an embedding task type. The
+// Cohere-style search_document and search_query become RETRIEVAL_DOCUMENT and
+// RETRIEVAL_QUERY; any other value must name a task type, in either case.
+// Empty leaves the choice to the model.
+func ResolveTaskType(inputType string) (string, error) {
+	switch inputType {
+	case "":
+		return "", nil
+	case "search_document":
+		return "RETRIEVAL_DOCUMENT", nil
+	case "search_query":
+		return "RETRIEVAL_QUERY", nil
+	}
+	if taskType := strings.ToUpper(inputType); taskTypes[taskType] {
+		return taskType, nil
+	}
+	return "", fmt.Errorf("embed: unsupported input_type %q; want search_document, search_query, or an embedding task type such as semantic_similarity", inputType)
+}
@@ -0,0 +1,20 @@
+package geminiwire
+
+import "testing"
+
+func TestResolveTaskType(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                    "",
+		"search_document":     "RETRIEVAL_DOCUMENT",
+		"search_query":        "RETRIEVAL_QUERY",
+		"clustering":          "CLUSTERING",
+		"SEMANTIC_SIMILARITY": "SEMANTIC_SIMILARITY",
+	} {
+		if got, err := ResolveTaskType(in); err != nil || got != want {
+			t.Errorf("ResolveTaskType(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ResolveTaskType("image"); err == nil {
+		t.Error("ResolveTaskType accepted an unknown input_type")
+	}
+}
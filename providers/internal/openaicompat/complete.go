@@ -18,6 +18,8 @@ type ChatResponse struct {
 	Model   string        `json:"model"`
 	Choices []core.Choice `json:"choices"`
 	Usage   core.Usage    `json:"usage"`
+
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
 }
 
 // APIError builds a provider error from a non-200 response body. It delegates to
@@ -73,6 +75,7 @@ func newChatRequest(ctx context.Context, p ChatParams, req core.Request, stream
 	if err := enforceUnsupportedParams(ctx, p, &req); err != nil {
 		return nil, nil, err
 	}
+	PrepareToolParams(&req)
 	// The observability.AttrFerroForwardedParams attribute is not emitted here:
 	// the shared builder has no span in scope, and threading one through
 	// ChatParams for a debug-only attribute costs more than it returns. The
@@ -84,7 +87,7 @@ func newChatRequest(ctx context.Context, p ChatParams, req core.Request, stream
 	)
 	if p.BodyTransform != nil {
 		req.Stream = stream
-		bodyReader, _, release, err = core.JSONBodyReader(p.BodyTransform(req))
+		bodyReader, _, release, err = core.JSONBodyReaderExtra(p.BodyTransform(req), req.Extra)
 	} else {
 		bodyReader, _, release, err = BuildBody(req, stream)
 	}
@@ -175,6 +178,8 @@ func clearParam(req *core.Request, name string) {
 		req.Temperature = nil
 	case "top_p":
 		req.TopP = nil
+	case "top_k":
+		req.TopK = nil
 	case "n":
 		req.N = nil
 	case "seed":
@@ -183,6 +188,8 @@ func clearParam(req *core.Request, name string) {
 		req.MaxTokens = nil
 	case "max_completion_tokens":
 		req.MaxCompletionTokens = nil
+	case "reasoning_effort":
+		req.ReasoningEffort = ""
 	case "presence_penalty":
 		req.PresencePenalty = nil
 	case "frequency_penalty":
@@ -234,12 +241,15 @@ func PostChat(ctx context.Context, p ChatParams, req core.Request) (*core.Respon
 	for i := range pResp.Choices {
 		pResp.Choices[i].FinishReason = core.NormalizeFinishReason(pResp.Choices[i].FinishReason)
 	}
+	FillToolCalls(pResp.Choices)
 	resp := &core.Response{
 		ID:       pResp.ID,
 		Model:    pResp.Model,
 		Provider: p.Provider,
 		Choices:  pResp.Choices,
 		Usage:    pResp.Usage,
+
+		SystemFingerprint: pResp.SystemFingerprint,
 	}
 	if meta := captureExtraFields(respBody, p.ExtraResponseFields); meta != nil {
 		resp.Metadata = meta
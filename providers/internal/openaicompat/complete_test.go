@@ -143,6 +143,38 @@ func TestPostChat_NormalizesFinishReason(t *testing.T) {
 	}
 }
 
+// TestPostChat_SystemFingerprint verifies the fingerprint that identifies a
+// seeded response's backend is carried through, on both response shapes.
+func TestPostChat_SystemFingerprint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"x","model":"m","system_fingerprint":"fp_abc","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	resp, err := PostChat(context.Background(), ChatParams{
+		HTTPClient: srv.Client(),
+		URL:        srv.URL,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Provider:   "test",
+		Label:      "test",
+	}, core.Request{Model: "m", Messages: []core.Message{{Role: core.RoleUser, Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("PostChat: %v", err)
+	}
+	if resp.SystemFingerprint != "fp_abc" {
+		t.Errorf("system_fingerprint = %q, want fp_abc", resp.SystemFingerprint)
+	}
+
+	chunk, err := DecodeStreamChunk([]byte(`{"system_fingerprint":"fp_abc","choices":[{"index":0,"delta":{"content":"hi"}}]}`))
+	if err != nil {
+		t.Fatalf("DecodeStreamChunk: %v", err)
+	}
+	if chunk.SystemFingerprint != "fp_abc" {
+		t.Errorf("chunk system_fingerprint = %q, want fp_abc", chunk.SystemFingerprint)
+	}
+}
+
 // TestDecodeStreamChunk_NormalizesFinishReason verifies the shared stream decoder
 // normalizes a provider-specific finish reason directly (not only through a
 // provider round-trip).
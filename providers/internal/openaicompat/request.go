@@ -20,11 +20,12 @@ import (
 // core.Request. Every OpenAI-shaped field carried by core.Request is forwarded
 // as-is; nothing is silently dropped. The stream argument sets the upstream
 // "stream" flag (core.Request.Stream is omitempty, so false is omitted to match
-// the previous per-provider behaviour).
+// the previous per-provider behaviour). Provider-specific parameters from
+// req.Extra are merged into the top level of the body.
 //
 // The returned release func MUST be called once the caller is done with the
 // reader to return the pooled buffer; it mirrors core.JSONBodyReader.
 func BuildBody(req core.Request, stream bool) (body io.Reader, contentLen int, release func(), err error) {
 	req.Stream = stream
-	return core.JSONBodyReader(req)
+	return core.JSONBodyReaderExtra(req, req.Extra)
 }
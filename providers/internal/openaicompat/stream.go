@@ -32,7 +32,8 @@ func DecodeStreamChunk(data []byte) (core.StreamChunk, error) {
 // (the terminating "[DONE]" sentinel, EOF, or a scan error), closes body, and
 // closes the channel. Lines that fail to decode are skipped so benign non-JSON
 // keep-alive frames don't abort an otherwise healthy stream; a scanner read
-// error is surfaced as a final chunk.
+// error is surfaced as a final chunk. Tool-call deltas are numbered per stream
+// the way OpenAI numbers them (see toolCallIndexer).
 //
 // Callers must perform the non-200 status check before handing the body over.
 // ctx cancellation stops the reader promptly: a pending send is abandoned and
@@ -43,6 +44,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser) <-chan core.StreamChunk
 		defer close(ch)
 		defer func() { _ = body.Close() }()
 
+		var tools toolCallIndexer
 		scanner := core.NewSSEScanner(body)
 		for scanner.Scan() {
 			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
@@ -56,6 +58,7 @@ func StreamSSE(ctx context.Context, body io.ReadCloser) <-chan core.StreamChunk
 			if err != nil {
 				continue
 			}
+			tools.fix(&chunk)
 			if !core.SendChunk(ctx, ch, chunk) {
 				return
 			}
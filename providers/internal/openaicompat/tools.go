@@ -0,0 +1,130 @@
+package openaicompat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// Tool calling is forwarded in the OpenAI shape, which every compatible
+// provider accepts, but the providers disagree at the edges. The helpers here
+// smooth those edges once for all of them, so a client sees the same
+// tool_choice handling and the same tool-call shape whichever provider
+// answers; quirks specific to one provider stay in its adapter.
+
+// PrepareToolParams drops tool_choice and parallel_tool_calls from a request
+// that carries no tools. OpenAI, Groq, and others reject either without tools
+// with a 400, while some providers ignore them, so a request a client built for
+// one provider would fail on another after a fallback.
+func PrepareToolParams(req *core.Request) {
+	if len(req.Tools) > 0 {
+		return
+	}
+	req.ToolChoice = nil
+	req.ParallelToolCalls = nil
+}
+
+// FillToolCalls completes the tool calls of a non-streaming response. Some
+// providers omit a call's type, and some the id a client needs to answer the
+// call with a tool message; a missing type is set to "function" and a missing
+// id is generated.
+func FillToolCalls(choices []core.Choice) {
+	for i := range choices {
+		calls := choices[i].Message.ToolCalls
+		for j := range calls {
+			if calls[j].Type == "" {
+				calls[j].Type = "function"
+			}
+			if calls[j].ID == "" {
+				calls[j].ID = newToolCallID()
+			}
+		}
+	}
+}
+
+// newToolCallID returns an id for a tool call the provider left unnamed, in
+// OpenAI's "call_" form.
+func newToolCallID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "call_" + hex.EncodeToString(b[:])
+}
+
+// toolCallIndexer numbers the tool-call deltas of one stream the way OpenAI
+// does. Clients assemble streamed calls by index, but some providers omit it
+// or send every call of a parallel batch, each complete, at index 0, which
+// a client would merge into one call. A delta that starts a call — it carries
+// an id not seen before, or a name and no index — gets its own index unless a
+// call already has it, else the next free one; a delta that continues a call
+// takes that call's index. Calls missing an id or type get them too.
+type toolCallIndexer struct {
+	choices map[int]*toolCallIndexes
+}
+
+// toolCallIndexes is the numbering of one choice's tool calls.
+type toolCallIndexes struct {
+	byIndex map[int]int    // provider index → client index
+	byID    map[string]int // call id → client index
+	used    map[int]bool   // client indexes assigned
+	last    int            // client index of the latest call
+	next    int            // lowest client index above every assigned one
+}
+
+// fix renumbers the tool-call deltas of chunk in place.
+func (x *toolCallIndexer) fix(chunk *core.StreamChunk) {
+	for i := range chunk.Choices {
+		deltas := chunk.Choices[i].Delta.ToolCalls
+		if len(deltas) == 0 {
+			continue
+		}
+		if x.choices == nil {
+			x.choices = map[int]*toolCallIndexes{}
+		}
+		c := x.choices[chunk.Choices[i].Index]
+		if c == nil {
+			c = &toolCallIndexes{byIndex: map[int]int{}, byID: map[string]int{}, used: map[int]bool{}}
+			x.choices[chunk.Choices[i].Index] = c
+		}
+		for j := range deltas {
+			deltas[j].Index = c.number(&deltas[j])
+		}
+	}
+}
+
+// number returns the client index of d, completing d when it starts a call.
+func (c *toolCallIndexes) number(d *core.ToolCall) *int {
+	idx, known := c.byID[d.ID]
+	starts := !known && (d.ID != "" || d.Index == nil && d.Function.Name != "")
+	switch {
+	case known:
+	case starts && d.Index != nil && !c.used[*d.Index]:
+		idx = *d.Index
+	case starts:
+		idx = c.next
+	case d.Index != nil:
+		mapped, ok := c.byIndex[*d.Index]
+		if !ok {
+			mapped = *d.Index
+		}
+		idx = mapped
+	default:
+		idx = c.last
+	}
+	if starts {
+		if d.ID == "" {
+			d.ID = newToolCallID()
+		}
+		if d.Type == "" {
+			d.Type = "function"
+		}
+		c.byID[d.ID] = idx
+	}
+	if d.Index != nil && (starts || known) {
+		c.byIndex[*d.Index] = idx
+	}
+	c.used[idx] = true
+	c.last = idx
+	c.next = max(c.next, idx+1)
+	return &idx
+}
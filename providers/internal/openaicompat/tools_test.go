@@ -0,0 +1,186 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+func TestPostChat_DropsToolChoiceWithoutTools(t *testing.T) {
+	var captured map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &captured)
+		_, _ = io.WriteString(w, `{"id":"x","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	parallel := false
+	req := core.Request{
+		Model:             "m",
+		Messages:          []core.Message{{Role: "user", Content: "hi"}},
+		ToolChoice:        "required",
+		ParallelToolCalls: &parallel,
+	}
+	if _, err := PostChat(context.Background(), ChatParams{HTTPClient: srv.Client(), URL: srv.URL, Provider: "groq", Label: "groq"}, req); err != nil {
+		t.Fatalf("PostChat: %v", err)
+	}
+	for _, k := range []string{"tool_choice", "parallel_tool_calls"} {
+		if _, ok := captured[k]; ok {
+			t.Errorf("%s forwarded without tools", k)
+		}
+	}
+
+	req.Tools = []core.Tool{{Type: "function", Function: core.Function{Name: "lookup"}}}
+	if _, err := PostChat(context.Background(), ChatParams{HTTPClient: srv.Client(), URL: srv.URL, Provider: "groq", Label: "groq"}, req); err != nil {
+		t.Fatalf("PostChat: %v", err)
+	}
+	if string(captured["tool_choice"]) != `"required"` || string(captured["parallel_tool_calls"]) != "false" {
+		t.Errorf("tool_choice = %s, parallel_tool_calls = %s, want both forwarded with tools", captured["tool_choice"], captured["parallel_tool_calls"])
+	}
+}
+
+// TestPostChat_ToolChoiceVariants verifies each OpenAI tool_choice form and
+// parallel_tool_calls reach the upstream body as sent, under the capability
+// profile of each provider that relies on PostChat for tool calling.
+func TestPostChat_ToolChoiceVariants(t *testing.T) {
+	var captured map[string]json.RawMessage
+	srv := capturingServer(t, &captured)
+	defer srv.Close()
+
+	parallel := true
+	for _, provider := range []string{"groq", "deepseek", "together", "fireworks"} {
+		for _, choice := range []any{
+			"auto",
+			"none",
+			"required",
+			map[string]any{"type": "function", "function": map[string]any{"name": "lookup"}},
+		} {
+			captured = nil
+			_, err := PostChat(context.Background(), ChatParams{HTTPClient: srv.Client(), URL: srv.URL, Provider: provider, Label: provider}, core.Request{
+				Model:             "m",
+				Messages:          []core.Message{{Role: "user", Content: "weather in SF and NY?"}},
+				Tools:             []core.Tool{{Type: "function", Function: core.Function{Name: "lookup", Parameters: json.RawMessage(`{"type":"object"}`)}}},
+				ToolChoice:        choice,
+				ParallelToolCalls: &parallel,
+			})
+			if err != nil {
+				t.Fatalf("%s: PostChat(%v): %v", provider, choice, err)
+			}
+			want, _ := json.Marshal(choice)
+			if string(captured["tool_choice"]) != string(want) {
+				t.Errorf("%s: tool_choice = %s, want %s", provider, captured["tool_choice"], want)
+			}
+			if string(captured["parallel_tool_calls"]) != "true" {
+				t.Errorf("%s: parallel_tool_calls = %s, want true", provider, captured["parallel_tool_calls"])
+			}
+		}
+	}
+}
+
+func TestPostChat_FillsToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"x","choices":[{"index":0,"message":{"role":"assistant","tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"a","arguments":"{}"}},
+			{"function":{"name":"b","arguments":"{}"}}
+		]},"finish_reason":"tool_calls"}]}`)
+	}))
+	defer srv.Close()
+
+	resp, err := PostChat(context.Background(), ChatParams{HTTPClient: srv.Client(), URL: srv.URL, Provider: "together", Label: "together"}, core.Request{Model: "m"})
+	if err != nil {
+		t.Fatalf("PostChat: %v", err)
+	}
+	calls := resp.Choices[0].Message.ToolCalls
+	if calls[0].ID != "call_1" {
+		t.Errorf("id = %q, want the provider's id kept", calls[0].ID)
+	}
+	if !strings.HasPrefix(calls[1].ID, "call_") || calls[1].Type != "function" {
+		t.Errorf("call = %+v, want a generated id and the function type", calls[1])
+	}
+}
+
+// TestStreamSSE_NumbersToolCalls covers the tool-call shapes providers stream:
+// OpenAI's own, which passes through, a parallel batch sent complete at index
+// 0, deltas without an index, and an id repeated on every delta.
+func TestStreamSSE_NumbersToolCalls(t *testing.T) {
+	for name, tc := range map[string]struct {
+		frames []string
+		want   []core.ToolCall
+	}{
+		"openai": {
+			frames: []string{
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"a","arguments":""}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"x\":1}"}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"b","arguments":"{}"}}]}}]}`,
+			},
+			want: []core.ToolCall{
+				{ID: "call_a", Type: "function", Function: core.FunctionCall{Name: "a", Arguments: `{"x":1}`}},
+				{ID: "call_b", Type: "function", Function: core.FunctionCall{Name: "b", Arguments: "{}"}},
+			},
+		},
+		"parallel batch at index 0": {
+			frames: []string{
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"abc123def","function":{"name":"a","arguments":"{}"}},{"index":0,"id":"xyz789uvw","function":{"name":"b","arguments":"{}"}}]}}]}`,
+			},
+			want: []core.ToolCall{
+				{ID: "abc123def", Type: "function", Function: core.FunctionCall{Name: "a", Arguments: "{}"}},
+				{ID: "xyz789uvw", Type: "function", Function: core.FunctionCall{Name: "b", Arguments: "{}"}},
+			},
+		},
+		"no index": {
+			frames: []string{
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"id":"call_a","function":{"name":"a","arguments":"{\"x\""}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"function":{"arguments":":1}"}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"id":"call_b","function":{"name":"b","arguments":"{}"}}]}}]}`,
+			},
+			want: []core.ToolCall{
+				{ID: "call_a", Type: "function", Function: core.FunctionCall{Name: "a", Arguments: `{"x":1}`}},
+				{ID: "call_b", Type: "function", Function: core.FunctionCall{Name: "b", Arguments: "{}"}},
+			},
+		},
+		"id on every delta": {
+			frames: []string{
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"a","arguments":"{\"x\""}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"arguments":":1}"}}]}}]}`,
+			},
+			want: []core.ToolCall{
+				{ID: "call_a", Type: "function", Function: core.FunctionCall{Name: "a", Arguments: `{"x":1}`}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			body := "data: " + strings.Join(tc.frames, "\n\ndata: ") + "\n\ndata: [DONE]\n\n"
+			chunks := StreamSSE(context.Background(), sseBody(body))
+			resp, err := core.CollectStream(chunks, 0)
+			if err != nil {
+				t.Fatalf("CollectStream: %v", err)
+			}
+			got := resp.Choices[0].Message.ToolCalls
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d tool calls %+v, want %d", len(got), got, len(tc.want))
+			}
+			for i := range got {
+				if got[i].ID != tc.want[i].ID || got[i].Type != tc.want[i].Type || got[i].Function != tc.want[i].Function {
+					t.Errorf("call %d = %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStreamSSE_GeneratesMissingToolCallIDs(t *testing.T) {
+	body := `data: {"choices":[{"index":0,"delta":{"tool_calls":[{"function":{"name":"a","arguments":"{}"}}]}}]}` + "\n\n" +
+		"data: [DONE]\n\n"
+	chunks := collect(StreamSSE(context.Background(), sseBody(body)))
+	call := chunks[0].Choices[0].Delta.ToolCalls[0]
+	if call.Index == nil || *call.Index != 0 || !strings.HasPrefix(call.ID, "call_") || call.Type != "function" {
+		t.Errorf("tool call = %+v, want index 0, a generated id, and the function type", call)
+	}
+}
@@ -2,6 +2,7 @@ package mistral
 
 import (
 	"context"
+	"strings"
 
 	"github.com/ferro-labs/ai-gateway/providers/core"
 	"github.com/ferro-labs/ai-gateway/providers/internal/openaicompat"
@@ -30,6 +31,12 @@ func mistralEmbeddingTransform(req core.EmbeddingRequest, input any) any {
 	}
 }
 
+// SupportsEmbeddingDimensions reports whether model honours a requested
+// output dimension: codestral-embed does, mistral-embed has a fixed one.
+func (p *Provider) SupportsEmbeddingDimensions(model string) bool {
+	return strings.HasPrefix(model, "codestral-embed")
+}
+
 // Embed sends an OpenAI-compatible embedding request to Mistral.
 func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.EmbeddingResponse, error) {
 	if err := core.ValidateEmbeddingEncodingFormat(req.EncodingFormat); err != nil {
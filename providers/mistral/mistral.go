@@ -3,6 +3,8 @@ package mistral
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"strings"
 
@@ -115,11 +117,79 @@ type mistralChatBody struct {
 }
 
 // mistralChatTransform maps core.Request onto Mistral's chat body, renaming
-// seed → random_seed.
+// seed → random_seed and rewriting tool-call ids Mistral would reject.
 func mistralChatTransform(req core.Request) any {
+	req.Messages = mistralToolCallIDs(req.Messages)
 	return mistralChatBody{Request: req, RandomSeed: req.Seed}
 }
 
+// mistralToolCallIDLen is the length of the tool-call ids Mistral issues and
+// accepts: exactly nine ASCII letters and digits.
+const mistralToolCallIDLen = 9
+
+// mistralToolCallIDs rewrites the tool-call ids of messages into Mistral's
+// format. A conversation whose earlier turns came from another provider — after
+// a fallback, or a client switching models — carries ids like "call_abc…",
+// which Mistral rejects with a 400. Each foreign id is replaced by a prefix of
+// its hash, so an assistant's tool call and the tool message answering it
+// still agree. Ids Mistral issued pass through, and messages are copied only
+// when one changes.
+func mistralToolCallIDs(messages []core.Message) []core.Message {
+	var out []core.Message
+	for i, m := range messages {
+		if !foreignToolCallIDs(m) {
+			continue
+		}
+		if out == nil {
+			out = append([]core.Message(nil), messages...)
+		}
+		out[i].ToolCallID = mistralToolCallID(m.ToolCallID)
+		if len(m.ToolCalls) > 0 {
+			out[i].ToolCalls = append([]core.ToolCall(nil), m.ToolCalls...)
+			for j := range out[i].ToolCalls {
+				out[i].ToolCalls[j].ID = mistralToolCallID(m.ToolCalls[j].ID)
+			}
+		}
+	}
+	if out == nil {
+		return messages
+	}
+	return out
+}
+
+func foreignToolCallIDs(m core.Message) bool {
+	if m.ToolCallID != "" && !validMistralToolCallID(m.ToolCallID) {
+		return true
+	}
+	for _, tc := range m.ToolCalls {
+		if tc.ID != "" && !validMistralToolCallID(tc.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+func validMistralToolCallID(id string) bool {
+	if len(id) != mistralToolCallIDLen {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// mistralToolCallID maps id into Mistral's format, deterministically.
+func mistralToolCallID(id string) string {
+	if id == "" || validMistralToolCallID(id) {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])[:mistralToolCallIDLen]
+}
+
 // Complete sends a chat completion request to Mistral.
 func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Response, error) {
 	return openaicompat.PostChat(ctx, openaicompat.ChatParams{
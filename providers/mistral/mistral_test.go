@@ -466,3 +466,13 @@ func assertMistralEmbeddingInput(t *testing.T, got any, want any) {
 		t.Fatalf("unsupported test input type %T", want)
 	}
 }
+
+func TestMistralProvider_SupportsEmbeddingDimensions(t *testing.T) {
+	p, _ := New("test-key", "")
+	if p.SupportsEmbeddingDimensions(testEmbeddingModel) {
+		t.Errorf("%s has a fixed dimension", testEmbeddingModel)
+	}
+	if !p.SupportsEmbeddingDimensions("codestral-embed-2505") {
+		t.Error("codestral-embed honours output_dimension")
+	}
+}
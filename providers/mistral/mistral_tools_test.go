@@ -0,0 +1,105 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// TestComplete_ToolChoiceVariants verifies each OpenAI tool_choice form and
+// parallel_tool_calls reach the upstream body as sent, and that the tool calls
+// in the response come back whole.
+func TestComplete_ToolChoiceVariants(t *testing.T) {
+	var captured map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		captured = nil
+		_ = json.Unmarshal(b, &captured)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"x","model":"m","choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"abc123def","type":"function","function":{"name":"lookup","arguments":"{\"city\":\"SF\"}"}},{"function":{"name":"lookup","arguments":"{\"city\":\"NY\"}"}}]},"finish_reason":"tool_calls"}],"usage":{}}`)
+	}))
+	defer srv.Close()
+
+	p, err := New("test-key", srv.URL)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	parallel := true
+	for _, choice := range []any{
+		"auto",
+		"none",
+		"required",
+		map[string]any{"type": "function", "function": map[string]any{"name": "lookup"}},
+	} {
+		resp, err := p.Complete(context.Background(), core.Request{
+			Model:             "mistral-large-latest",
+			Messages:          []core.Message{{Role: "user", Content: "weather in SF and NY?"}},
+			Tools:             []core.Tool{{Type: "function", Function: core.Function{Name: "lookup", Parameters: json.RawMessage(`{"type":"object"}`)}}},
+			ToolChoice:        choice,
+			ParallelToolCalls: &parallel,
+		})
+		if err != nil {
+			t.Fatalf("Complete(%v): %v", choice, err)
+		}
+		want, _ := json.Marshal(choice)
+		if string(captured["tool_choice"]) != string(want) {
+			t.Errorf("tool_choice = %s, want %s", captured["tool_choice"], want)
+		}
+		if string(captured["parallel_tool_calls"]) != "true" {
+			t.Errorf("parallel_tool_calls = %s, want true", captured["parallel_tool_calls"])
+		}
+		calls := resp.Choices[0].Message.ToolCalls
+		if resp.Choices[0].FinishReason != core.FinishReasonToolCalls || len(calls) != 2 {
+			t.Fatalf("response = %+v, want two tool calls", resp.Choices[0])
+		}
+		for _, c := range calls {
+			if c.ID == "" || c.Type != "function" || c.Function.Name != "lookup" {
+				t.Errorf("tool call = %+v, want an id, the function type, and the name", c)
+			}
+		}
+	}
+}
+
+func TestMistralProvider_Complete_RewritesForeignToolCallIDs(t *testing.T) {
+	var body struct {
+		Messages []core.Message `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = io.WriteString(w, `{"id":"x","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"sunny"},"finish_reason":"stop"}],"usage":{}}`)
+	}))
+	defer srv.Close()
+
+	messages := []core.Message{
+		{Role: "user", Content: "weather in SF?"},
+		{Role: "assistant", ToolCalls: []core.ToolCall{
+			{ID: "call_Xk3pQ9rT2vB7nM1s", Type: "function", Function: core.FunctionCall{Name: "lookup", Arguments: "{}"}},
+			{ID: "abc123def", Type: "function", Function: core.FunctionCall{Name: "lookup", Arguments: "{}"}},
+		}},
+		{Role: "tool", ToolCallID: "call_Xk3pQ9rT2vB7nM1s", Content: "sunny"},
+		{Role: "tool", ToolCallID: "abc123def", Content: "sunny"},
+	}
+	p, _ := New("test-key", srv.URL)
+	if _, err := p.Complete(context.Background(), core.Request{Model: "mistral-large-latest", Messages: messages}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	rewritten := body.Messages[1].ToolCalls[0].ID
+	if !validMistralToolCallID(rewritten) {
+		t.Errorf("id = %q, want nine letters and digits", rewritten)
+	}
+	if body.Messages[2].ToolCallID != rewritten {
+		t.Errorf("tool message id = %q, want it to match the call's %q", body.Messages[2].ToolCallID, rewritten)
+	}
+	if body.Messages[1].ToolCalls[1].ID != "abc123def" || body.Messages[3].ToolCallID != "abc123def" {
+		t.Error("an id Mistral issued was rewritten")
+	}
+	if messages[1].ToolCalls[0].ID != "call_Xk3pQ9rT2vB7nM1s" || messages[2].ToolCallID != "call_Xk3pQ9rT2vB7nM1s" {
+		t.Error("the caller's messages were modified")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	anthropicpkg "github.com/ferro-labs/ai-gateway/providers/anthropic"
 	azurefoundrypkg "github.com/ferro-labs/ai-gateway/providers/azure_foundry"
 	azureopenaipkg "github.com/ferro-labs/ai-gateway/providers/azure_openai"
-	bedrockpkg "github.com/ferro-labs/ai-gateway/providers/bedrock"
 	cerebraspkg "github.com/ferro-labs/ai-gateway/providers/cerebras"
 	cloudflarepkg "github.com/ferro-labs/ai-gateway/providers/cloudflare"
 	coherepkg "github.com/ferro-labs/ai-gateway/providers/cohere"
@@ -97,8 +96,10 @@ const (
 	// NameHuggingFace is the canonical name for the Hugging Face provider.
 	NameHuggingFace = huggingfacepkg.Name
 
-	// NameBedrock is the canonical name for the AWS Bedrock provider.
-	NameBedrock = bedrockpkg.Name
+	// NameBedrock is the canonical name for the AWS Bedrock provider. Unlike
+	// the other names it is spelled out rather than taken from its package, so
+	// the nobedrock build tag can leave that package, and the AWS SDK, out.
+	NameBedrock = "bedrock"
 
 	// NameCerebras is the canonical name for the Cerebras provider.
 	NameCerebras = cerebraspkg.Name
@@ -0,0 +1,358 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+// Native chat: Ollama's OpenAI-compatible endpoint cannot set a model's
+// context size or how long it stays loaded, and ignores top_k, so a provider
+// configured with KeepAlive or NumCtx, or a request with top_k, goes to the
+// native /api/chat endpoint instead, translating the request and response
+// here. max_tokens maps to num_predict on both endpoints.
+
+// ollamaChatRequest is the native Ollama /api/chat request schema.
+type ollamaChatRequest struct {
+	Model     string              `json:"model"`
+	Messages  []ollamaChatMessage `json:"messages"`
+	Tools     []core.Tool         `json:"tools,omitempty"`
+	Format    json.RawMessage     `json:"format,omitempty"`
+	Options   *ollamaOptions      `json:"options,omitempty"`
+	Stream    bool                `json:"stream"`
+	KeepAlive any                 `json:"keep_alive,omitempty"`
+}
+
+type ollamaChatMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// ollamaToolCall carries its arguments as a JSON object, not the JSON-encoded
+// string OpenAI uses.
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaOptions are the model parameters of a native request.
+type ollamaOptions struct {
+	NumCtx           int      `json:"num_ctx,omitempty"`
+	NumPredict       *int     `json:"num_predict,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	TopK             *int     `json:"top_k,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+}
+
+// ollamaChatResponse is one native /api/chat response, or one line of a
+// streamed one; the last line has Done set and carries the counts.
+type ollamaChatResponse struct {
+	Model           string            `json:"model"`
+	CreatedAt       time.Time         `json:"created_at"`
+	Message         ollamaChatMessage `json:"message"`
+	Done            bool              `json:"done"`
+	DoneReason      string            `json:"done_reason"`
+	PromptEvalCount int               `json:"prompt_eval_count"`
+	EvalCount       int               `json:"eval_count"`
+}
+
+// nativeChat reports whether req must use the native endpoint.
+func (p *Provider) nativeChat(req core.Request) bool {
+	return p.keepAlive != nil || p.numCtx > 0 || req.TopK != nil
+}
+
+// buildChatRequest translates req into a native /api/chat request.
+func (p *Provider) buildChatRequest(req core.Request, stream bool) (ollamaChatRequest, error) {
+	out := ollamaChatRequest{
+		Model:     req.Model,
+		Messages:  make([]ollamaChatMessage, 0, len(req.Messages)),
+		Tools:     req.Tools,
+		Stream:    stream,
+		KeepAlive: p.keepAlive,
+	}
+	toolNames := make(map[string]string) // tool call ID → function name
+	for i, m := range req.Messages {
+		msg := ollamaChatMessage{Role: m.Role, Content: m.Content, Thinking: m.ReasoningContent}
+		for _, part := range m.ContentParts {
+			if part.Type != "image_url" || part.ImageURL == nil {
+				continue
+			}
+			data, ok := imageDataURIPayload(part.ImageURL.URL)
+			if !ok {
+				return ollamaChatRequest{}, fmt.Errorf("messages[%d]: ollama accepts images as base64 data URIs only", i)
+			}
+			msg.Images = append(msg.Images, data)
+		}
+		for _, tc := range m.ToolCalls {
+			toolNames[tc.ID] = tc.Function.Name
+			var call ollamaToolCall
+			call.Function.Name = tc.Function.Name
+			call.Function.Arguments = json.RawMessage(tc.Function.Arguments)
+			if strings.TrimSpace(tc.Function.Arguments) == "" {
+				call.Function.Arguments = json.RawMessage("{}")
+			} else if !json.Valid(call.Function.Arguments) {
+				return ollamaChatRequest{}, fmt.Errorf("messages[%d]: tool call %q has arguments that are not JSON", i, tc.ID)
+			}
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		if m.Role == "tool" {
+			msg.ToolName = toolNames[m.ToolCallID]
+		}
+		out.Messages = append(out.Messages, msg)
+	}
+
+	if rf := req.ResponseFormat; rf != nil {
+		switch rf.Type {
+		case "json_object":
+			out.Format = json.RawMessage(`"json"`)
+		case "json_schema":
+			var spec struct {
+				Schema json.RawMessage `json:"schema"`
+			}
+			if json.Unmarshal(rf.JSONSchema, &spec) == nil && len(spec.Schema) > 0 {
+				out.Format = spec.Schema
+			} else {
+				out.Format = json.RawMessage(`"json"`)
+			}
+		}
+	}
+
+	opts := ollamaOptions{
+		NumCtx:           p.numCtx,
+		NumPredict:       req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		TopK:             req.TopK,
+		Seed:             req.Seed,
+		Stop:             req.Stop,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+	}
+	if req.MaxCompletionTokens != nil {
+		opts.NumPredict = req.MaxCompletionTokens
+	}
+	out.Options = &opts
+	return out, nil
+}
+
+// imageDataURIPayload returns the base64 payload of a "data:<mime>;base64,"
+// URI. ok is false for a remote URL, which Ollama cannot fetch.
+func imageDataURIPayload(uri string) (string, bool) {
+	meta, payload, found := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !found || !strings.HasPrefix(uri, "data:") || !strings.Contains(meta, ";base64") {
+		return "", false
+	}
+	return payload, true
+}
+
+// postChat sends a native chat request, returning the response on a 200.
+func (p *Provider) postChat(ctx context.Context, body ollamaChatRequest) (*http.Response, error) {
+	bodyReader, _, release, err := core.JSONBodyReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chat request: %w", err)
+	}
+	defer release()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		defer func() { _ = httpResp.Body.Close() }()
+		respBody, err := core.ReadResponseBody(httpResp.Body, core.MaxProviderResponseBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, ollamaAPIError(httpResp, respBody)
+	}
+	return httpResp, nil
+}
+
+// completeNative serves Complete through /api/chat.
+func (p *Provider) completeNative(ctx context.Context, req core.Request) (*core.Response, error) {
+	if err := core.EnforceUnsupportedParams(ctx, p.name, req.Model, req); err != nil {
+		return nil, err
+	}
+	body, err := p.buildChatRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := p.postChat(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	respBody, err := core.ReadResponseBody(httpResp.Body, core.MaxProviderResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	var oResp ollamaChatResponse
+	if err := json.Unmarshal(respBody, &oResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chat response: %w", err)
+	}
+
+	toolCalls := convertToolCalls(oResp.Message.ToolCalls, 0, false)
+	return &core.Response{
+		ID:       chatID(oResp),
+		Object:   "chat.completion",
+		Created:  oResp.CreatedAt.Unix(),
+		Model:    oResp.Model,
+		Provider: p.name,
+		Choices: []core.Choice{{
+			Message: core.Message{
+				Role:             "assistant",
+				Content:          oResp.Message.Content,
+				ReasoningContent: oResp.Message.Thinking,
+				ToolCalls:        toolCalls,
+			},
+			FinishReason: finishReason(oResp.DoneReason, len(toolCalls) > 0),
+		}},
+		Usage: usageOf(oResp),
+	}, nil
+}
+
+// completeStreamNative serves CompleteStream through /api/chat, which
+// streams newline-delimited JSON objects rather than SSE.
+func (p *Provider) completeStreamNative(ctx context.Context, req core.Request) (<-chan core.StreamChunk, error) {
+	if err := core.EnforceUnsupportedParams(ctx, p.name, req.Model, req); err != nil {
+		return nil, err
+	}
+	body, err := p.buildChatRequest(req, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := p.postChat(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan core.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer func() { _ = httpResp.Body.Close() }()
+
+		var id string
+		toolCallCount := 0 // tool calls so far, which numbers the next ones
+		scanner := core.NewSSEScanner(httpResp.Body)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			var oResp ollamaChatResponse
+			if err := json.Unmarshal(line, &oResp); err != nil {
+				core.SendChunk(ctx, ch, core.StreamChunk{Error: fmt.Errorf("ollama stream: malformed line: %w", err)})
+				return
+			}
+			if id == "" {
+				id = chatID(oResp)
+			}
+			toolCalls := convertToolCalls(oResp.Message.ToolCalls, toolCallCount, true)
+			toolCallCount += len(toolCalls)
+			sc := core.StreamChunk{
+				ID:      id,
+				Object:  "chat.completion.chunk",
+				Created: oResp.CreatedAt.Unix(),
+				Model:   oResp.Model,
+				Choices: []core.StreamChoice{{
+					Delta: core.MessageDelta{
+						Role:             "assistant",
+						Content:          oResp.Message.Content,
+						ReasoningContent: oResp.Message.Thinking,
+						ToolCalls:        toolCalls,
+					},
+				}},
+			}
+			if oResp.Done {
+				sc.Choices[0].FinishReason = finishReason(oResp.DoneReason, toolCallCount > 0)
+				usage := usageOf(oResp)
+				sc.Usage = &usage
+			}
+			if !core.SendChunk(ctx, ch, sc) {
+				return
+			}
+			if oResp.Done {
+				return
+			}
+		}
+		err := scanner.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		core.SendChunk(ctx, ch, core.StreamChunk{Error: fmt.Errorf("ollama stream ended before done: %w", err)})
+	}()
+	return ch, nil
+}
+
+// convertToolCalls converts native tool calls, which carry no IDs, numbering
+// them from first and giving each a synthetic ID. Streamed calls also get
+// their index.
+func convertToolCalls(calls []ollamaToolCall, first int, withIndex bool) []core.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	out := make([]core.ToolCall, len(calls))
+	for j, c := range calls {
+		i := first + j
+		args := string(c.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		out[j] = core.ToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Type:     "function",
+			Function: core.FunctionCall{Name: c.Function.Name, Arguments: args},
+		}
+		if withIndex {
+			out[j].Index = core.Ptr(i)
+		}
+	}
+	return out
+}
+
+// finishReason maps a native done_reason, which does not report tool calls.
+func finishReason(doneReason string, toolCalls bool) string {
+	if toolCalls {
+		return core.FinishReasonToolCalls
+	}
+	if doneReason == "" {
+		return core.FinishReasonStop
+	}
+	return core.NormalizeFinishReason(doneReason)
+}
+
+func usageOf(r ollamaChatResponse) core.Usage {
+	return core.Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// chatID derives a response ID, which the native API does not return.
+func chatID(r ollamaChatResponse) string {
+	return fmt.Sprintf("chatcmpl-ollama-%d", r.CreatedAt.UnixNano())
+}
@@ -0,0 +1,205 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ferro-labs/ai-gateway/providers/core"
+)
+
+func TestNewWithOptions_KeepAlive(t *testing.T) {
+	for in, want := range map[string]any{
+		"":    nil,
+		"30m": "30m",
+		"-1":  -1,
+		"300": 300,
+	} {
+		p, err := NewWithOptions(Options{KeepAlive: in})
+		if err != nil {
+			t.Fatalf("NewWithOptions(KeepAlive: %q) error: %v", in, err)
+		}
+		if p.keepAlive != want {
+			t.Errorf("keepAlive for %q = %#v, want %#v", in, p.keepAlive, want)
+		}
+	}
+	if _, err := NewWithOptions(Options{KeepAlive: "forever"}); err == nil {
+		t.Error("NewWithOptions accepted keep_alive \"forever\"")
+	}
+	if _, err := NewWithOptions(Options{NumCtx: -1}); err == nil {
+		t.Error("NewWithOptions accepted a negative num_ctx")
+	}
+}
+
+func TestOllamaProvider_Complete_NativeChat(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"model":"llama3.2","created_at":"2026-01-02T03:04:05Z",` +
+			`"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"lookup","arguments":{"city":"Oslo"}}}]},` +
+			`"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":5}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewWithOptions(Options{BaseURL: srv.URL, KeepAlive: "1h", NumCtx: 8192})
+	resp, err := p.Complete(context.Background(), core.Request{
+		Model:     "llama3.2",
+		MaxTokens: core.Ptr(64),
+		Messages: []core.Message{
+			{Role: "user", Content: "Weather?", ContentParts: []core.ContentPart{
+				{Type: "text", Text: "Weather?"},
+				{Type: "image_url", ImageURL: &core.ImageURLPart{URL: "data:image/png;base64,aGk="}},
+			}},
+			{Role: "assistant", ToolCalls: []core.ToolCall{{ID: "call_a", Type: "function", Function: core.FunctionCall{Name: "lookup", Arguments: `{"city":"Oslo"}`}}}},
+			{Role: "tool", ToolCallID: "call_a", Content: "sunny"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+
+	if body["keep_alive"] != "1h" || body["stream"] != false {
+		t.Errorf("keep_alive, stream = %v, %v; want 1h, false", body["keep_alive"], body["stream"])
+	}
+	opts, _ := body["options"].(map[string]any)
+	if opts["num_ctx"] != float64(8192) || opts["num_predict"] != float64(64) {
+		t.Errorf("options = %v, want num_ctx 8192 and num_predict 64", opts)
+	}
+	msgs, _ := body["messages"].([]any)
+	if len(msgs) != 3 {
+		t.Fatalf("messages = %v, want 3", msgs)
+	}
+	if images, _ := msgs[0].(map[string]any)["images"].([]any); len(images) != 1 || images[0] != "aGk=" {
+		t.Errorf("images = %v, want the data URI payload", images)
+	}
+	call := msgs[1].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)["function"].(map[string]any)
+	if args, _ := call["arguments"].(map[string]any); args["city"] != "Oslo" {
+		t.Errorf("tool call arguments = %v, want an object", call["arguments"])
+	}
+	if msgs[2].(map[string]any)["tool_name"] != "lookup" {
+		t.Errorf("tool result = %v, want tool_name lookup", msgs[2])
+	}
+
+	if resp.Provider != Name || resp.Model != "llama3.2" {
+		t.Errorf("response = %q/%q", resp.Provider, resp.Model)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason != core.FinishReasonToolCalls || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("choice = %+v, want one tool call", choice)
+	}
+	if tc := choice.Message.ToolCalls[0]; tc.ID == "" || tc.Function.Arguments != `{"city":"Oslo"}` {
+		t.Errorf("tool call = %+v", tc)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 5 || resp.Usage.TotalTokens != 17 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestOllamaProvider_CompleteStream_NativeChat(t *testing.T) {
+	ndjson := `{"model":"llama3.2","created_at":"2026-01-02T03:04:05Z","message":{"role":"assistant","content":"Hel"},"done":false}` + "\n" +
+		`{"model":"llama3.2","created_at":"2026-01-02T03:04:05Z","message":{"role":"assistant","content":"lo"},"done":false}` + "\n" +
+		`{"model":"llama3.2","created_at":"2026-01-02T03:04:05Z","message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":3,"eval_count":2}` + "\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(ndjson))
+	}))
+	defer srv.Close()
+
+	p, _ := NewWithOptions(Options{BaseURL: srv.URL, NumCtx: 4096})
+	ch, err := p.CompleteStream(context.Background(), core.Request{
+		Model:    "llama3.2",
+		Messages: []core.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error: %v", err)
+	}
+	resp, err := core.CollectStream(ch, 0)
+	if err != nil {
+		t.Fatalf("stream error: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "Hello" || resp.Choices[0].FinishReason != core.FinishReasonLength {
+		t.Errorf("collected = %+v", resp.Choices[0])
+	}
+	if resp.Usage.TotalTokens != 5 {
+		t.Errorf("usage = %+v, want 5 total", resp.Usage)
+	}
+}
+
+func TestOllamaProvider_CompleteStream_NativeChatTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"Hel"},"done":false}` + "\n"))
+	}))
+	defer srv.Close()
+
+	p, _ := NewWithOptions(Options{BaseURL: srv.URL, KeepAlive: "5m"})
+	ch, err := p.CompleteStream(context.Background(), core.Request{
+		Model:    "llama3.2",
+		Messages: []core.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error: %v", err)
+	}
+	if _, err := core.CollectStream(ch, 0); err == nil {
+		t.Error("a stream that ended before done reported no error")
+	}
+}
+
+func TestOllamaProvider_Embed_ForwardsKeepAliveAndNumCtx(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1]],"prompt_eval_count":1}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewWithOptions(Options{BaseURL: srv.URL, KeepAlive: "-1", NumCtx: 2048})
+	if _, err := p.Embed(context.Background(), core.EmbeddingRequest{Model: "nomic-embed-text", Input: "hi"}); err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if body["keep_alive"] != float64(-1) {
+		t.Errorf("keep_alive = %v, want -1", body["keep_alive"])
+	}
+	if opts, _ := body["options"].(map[string]any); opts["num_ctx"] != float64(2048) {
+		t.Errorf("options = %v, want num_ctx 2048", body["options"])
+	}
+}
+
+// A request with top_k goes to the native endpoint even without KeepAlive or
+// NumCtx, since the OpenAI-compatible one ignores it.
+func TestOllamaProvider_Complete_TopKUsesNativeChat(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop"}`))
+	}))
+	defer srv.Close()
+
+	p, _ := New(srv.URL, nil)
+	if _, err := p.Complete(context.Background(), core.Request{
+		Model:    "llama3.2",
+		TopK:     core.Ptr(40),
+		Messages: []core.Message{{Role: "user", Content: "Hi"}},
+	}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if opts, _ := body["options"].(map[string]any); opts["top_k"] != float64(40) {
+		t.Errorf("options = %v, want top_k 40", opts)
+	}
+}
@@ -11,9 +11,11 @@ import (
 
 // ollamaEmbedRequest is the native Ollama /api/embed request schema.
 type ollamaEmbedRequest struct {
-	Model      string `json:"model"`
-	Input      any    `json:"input"` // string or []string
-	Dimensions *int   `json:"dimensions,omitempty"`
+	Model      string         `json:"model"`
+	Input      any            `json:"input"` // string or []string
+	Dimensions *int           `json:"dimensions,omitempty"`
+	KeepAlive  any            `json:"keep_alive,omitempty"`
+	Options    *ollamaOptions `json:"options,omitempty"`
 }
 
 // ollamaEmbedResponse is the native Ollama /api/embed response schema.
@@ -40,6 +42,10 @@ func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.
 		Model:      req.Model,
 		Input:      input,
 		Dimensions: req.Dimensions,
+		KeepAlive:  p.keepAlive,
+	}
+	if p.numCtx > 0 {
+		pReq.Options = &ollamaOptions{NumCtx: p.numCtx}
 	}
 	bodyReader, _, release, err := core.JSONBodyReader(pReq)
 	if err != nil {
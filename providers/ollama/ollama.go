@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +26,23 @@ type Provider struct {
 	baseURL    string
 	httpClient *http.Client
 	models     []string
+	keepAlive  any // nil, seconds as an int, or a duration string
+	numCtx     int
+}
+
+// Options configures an Ollama provider.
+type Options struct {
+	// BaseURL is the Ollama server; empty means http://localhost:11434.
+	BaseURL string
+	// Models is the advertised model list; empty means llama3.2.
+	Models []string
+	// KeepAlive is how long Ollama keeps a model loaded after a request: a
+	// duration such as "30m", a number of seconds, "-1" to keep it loaded,
+	// or "0" to unload it at once. Empty leaves the server's default.
+	KeepAlive string
+	// NumCtx is the context window, in tokens, Ollama loads models with.
+	// 0 leaves the model's default.
+	NumCtx int
 }
 
 // Compile-time interface assertions.
@@ -38,7 +56,14 @@ var (
 
 // New creates a new Ollama provider.
 func New(baseURL string, models []string) (*Provider, error) {
-	baseURL = strings.TrimSpace(baseURL)
+	return NewWithOptions(Options{BaseURL: baseURL, Models: models})
+}
+
+// NewWithOptions creates a new Ollama provider. Setting KeepAlive or NumCtx
+// sends chat to Ollama's native /api/chat endpoint, the only one that honours
+// them, instead of its OpenAI-compatible one.
+func NewWithOptions(opts Options) (*Provider, error) {
+	baseURL := strings.TrimSpace(opts.BaseURL)
 	if baseURL == "" {
 		baseURL = defaultBaseURL
 	}
@@ -47,18 +72,44 @@ func New(baseURL string, models []string) (*Provider, error) {
 		return nil, err
 	}
 
+	models := opts.Models
 	if len(models) == 0 {
 		models = []string{"llama3.2"}
 	}
+	keepAlive, err := parseKeepAlive(opts.KeepAlive)
+	if err != nil {
+		return nil, err
+	}
+	if opts.NumCtx < 0 {
+		return nil, fmt.Errorf("%s: num_ctx cannot be negative", Name)
+	}
 
 	return &Provider{
 		name:       Name,
 		baseURL:    baseURL,
 		httpClient: providerhttp.ForProvider(Name),
 		models:     models,
+		keepAlive:  keepAlive,
+		numCtx:     opts.NumCtx,
 	}, nil
 }
 
+// parseKeepAlive converts a keep-alive setting to its wire form: Ollama reads
+// a number as seconds and a string as a Go duration.
+func parseKeepAlive(s string) (any, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	if _, err := time.ParseDuration(s); err != nil {
+		return nil, fmt.Errorf("%s: keep_alive %q is neither a number of seconds nor a duration", Name, s)
+	}
+	return s, nil
+}
+
 // Name implements core.Provider.
 func (p *Provider) Name() string { return p.name }
 
@@ -82,8 +133,13 @@ func (p *Provider) Models() []core.ModelInfo {
 
 // Complete sends a chat completion request and returns the full response. It
 // speaks Ollama's OpenAI-compatible /v1/chat/completions endpoint via the shared
-// helper, which sets core.Response.Provider and normalizes finish reasons.
+// helper, which sets core.Response.Provider and normalizes finish reasons. A
+// provider with KeepAlive or NumCtx, or a request with top_k, uses the native
+// /api/chat instead.
 func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Response, error) {
+	if p.nativeChat(req) {
+		return p.completeNative(ctx, req)
+	}
 	return openaicompat.PostChat(ctx, openaicompat.ChatParams{
 		HTTPClient: p.httpClient,
 		URL:        p.baseURL + "/v1/chat/completions",
@@ -95,6 +151,9 @@ func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Respon
 
 // CompleteStream sends a streaming chat completion request to Ollama.
 func (p *Provider) CompleteStream(ctx context.Context, req core.Request) (<-chan core.StreamChunk, error) {
+	if p.nativeChat(req) {
+		return p.completeStreamNative(ctx, req)
+	}
 	return openaicompat.PostStream(ctx, openaicompat.ChatParams{
 		HTTPClient: p.httpClient,
 		URL:        p.baseURL + "/v1/chat/completions",
@@ -134,6 +193,7 @@ func ollamaAPIError(resp *http.Response, body []byte) error {
 		StatusCode: resp.StatusCode,
 		Message:    fmt.Sprintf("ollama API error (%d): %s", resp.StatusCode, msg),
 		RetryAfter: core.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		Body:       core.ErrorBody(body),
 	}
 }
 
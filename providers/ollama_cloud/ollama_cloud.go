@@ -197,6 +197,7 @@ func apiError(resp *http.Response, body []byte) error {
 		StatusCode: statusCode,
 		Message:    fmt.Sprintf("ollama-cloud API error (%d): %s", statusCode, msg),
 		RetryAfter: core.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		Body:       core.ErrorBody(body),
 	}
 }
 
@@ -1,7 +1,8 @@
-// Package openai provides a client for the OpenAI API. Chat completions use a
-// direct HTTP + JSON path so every core.Request field is forwarded verbatim on
-// both the streaming and non-streaming paths (they cannot diverge); embeddings
-// and image generation use the official Go SDK.
+// Package openai provides a client for the OpenAI API. Every endpoint uses a
+// direct HTTP + JSON path rather than the official Go SDK, so every
+// core.Request field is forwarded verbatim on both the streaming and
+// non-streaming paths (they cannot diverge), and the gateway, and libraries
+// embedding it, do not link the SDK.
 package openai
 
 import (
@@ -13,12 +14,10 @@ import (
 	"net/url"
 	"strings"
 
-	oai "github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
-
 	"github.com/ferro-labs/ai-gateway/internal/discovery"
 	providerhttp "github.com/ferro-labs/ai-gateway/internal/httpclient"
 	"github.com/ferro-labs/ai-gateway/providers/core"
+	"github.com/ferro-labs/ai-gateway/providers/internal/openaicompat"
 )
 
 // Name is the canonical provider identifier.
@@ -26,48 +25,41 @@ const Name = "openai"
 
 const defaultBaseURL = "https://api.openai.com"
 
-// Provider implements the OpenAI API client using the official Go SDK.
+// Provider implements the OpenAI API client.
 type Provider struct {
 	name       string
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
-	client     oai.Client
 }
 
 // Compile-time interface assertions.
 var (
-	_ core.Provider          = (*Provider)(nil)
-	_ core.StreamProvider    = (*Provider)(nil)
-	_ core.EmbeddingProvider = (*Provider)(nil)
-	_ core.ImageProvider     = (*Provider)(nil)
-	_ core.ProxiableProvider = (*Provider)(nil)
-	_ core.DiscoveryProvider = (*Provider)(nil)
+	_ core.Provider           = (*Provider)(nil)
+	_ core.StreamProvider     = (*Provider)(nil)
+	_ core.EmbeddingProvider  = (*Provider)(nil)
+	_ core.ImageProvider      = (*Provider)(nil)
+	_ core.ModerationProvider = (*Provider)(nil)
+	_ core.ProxiableProvider  = (*Provider)(nil)
+	_ core.DiscoveryProvider  = (*Provider)(nil)
 )
 
 // New creates a new OpenAI provider.
 // The optional baseURL parameter allows overriding the API endpoint (pass "" for the default).
 func New(apiKey, baseURL string) (*Provider, error) {
-	opts := []option.RequestOption{
-		option.WithAPIKey(apiKey),
-		option.WithHTTPClient(providerhttp.ForProvider(Name)),
-	}
 	resolvedBase := defaultBaseURL
 	if baseURL != "" {
 		u, err := url.Parse(baseURL)
 		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
 			return nil, fmt.Errorf("openai: invalid base URL %q: must be http or https with a host", baseURL)
 		}
-		opts = append(opts, option.WithBaseURL(baseURL))
 		resolvedBase = baseURL
 	}
-	client := oai.NewClient(opts...)
 	return &Provider{
 		name:       Name,
 		apiKey:     apiKey,
 		baseURL:    strings.TrimRight(resolvedBase, "/"),
 		httpClient: providerhttp.ForProvider(Name),
-		client:     client,
 	}, nil
 }
 
@@ -116,7 +108,7 @@ func (p *Provider) SupportedModels() []string {
 
 // SupportsModel returns true if the model matches known OpenAI prefixes.
 func (p *Provider) SupportsModel(model string) bool {
-	for _, prefix := range []string{"gpt-", "chatgpt-", "codex-", "sora-", "dall-e-", "whisper-", "tts-", "text-embedding-", "ft:", "babbage-", "davinci-"} {
+	for _, prefix := range []string{"gpt-", "chatgpt-", "codex-", "sora-", "dall-e-", "whisper-", "tts-", "text-embedding-", "omni-moderation-", "text-moderation-", "ft:", "babbage-", "davinci-"} {
 		if strings.HasPrefix(model, prefix) {
 			return true
 		}
@@ -141,114 +133,133 @@ func (p *Provider) DiscoverModels(ctx context.Context) ([]core.ModelInfo, error)
 	return discovery.DiscoverOpenAICompatibleModels(ctx, p.httpClient, url, p.apiKey, p.name)
 }
 
+// SupportsEmbeddingDimensions reports whether model honours a requested
+// output dimension: the text-embedding-3 models do, ada-002 does not.
+func (p *Provider) SupportsEmbeddingDimensions(model string) bool {
+	return strings.HasPrefix(model, "text-embedding-3")
+}
+
 // Embed sends an embedding request to OpenAI.
 func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.EmbeddingResponse, error) {
-	params := oai.EmbeddingNewParams{
-		Model: req.Model,
-	}
-
-	normalized, err := core.NormalizeEmbeddingInput(req.Input)
-	if err != nil {
-		return nil, err
-	}
-	switch v := normalized.(type) {
-	case string:
-		params.Input = oai.EmbeddingNewParamsInputUnion{OfString: oai.String(v)}
-	case []string:
-		params.Input = oai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: v}
-	}
-
 	switch req.EncodingFormat {
-	case "", "float":
-		params.EncodingFormat = oai.EmbeddingNewParamsEncodingFormatFloat
-	case "base64":
-		params.EncodingFormat = oai.EmbeddingNewParamsEncodingFormatBase64
+	case "", "float", "base64":
 	default:
 		return nil, fmt.Errorf("embed: unsupported encoding_format %q; valid values are \"float\" and \"base64\"", req.EncodingFormat)
 	}
+	return openaicompat.PostEmbeddings(ctx, openaicompat.EmbeddingParams{
+		HTTPClient: p.httpClient,
+		URL:        p.endpoint("/embeddings"),
+		Headers:    p.headers(),
+		Label:      p.name,
+	}, req)
+}
+
+// imageRequest is the body of an OpenAI images/generations request.
+type imageRequest struct {
+	Model          string `json:"model,omitempty"`
+	Prompt         string `json:"prompt"`
+	N              *int   `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`
+	Quality        string `json:"quality,omitempty"`
+	Style          string `json:"style,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+	User           string `json:"user,omitempty"`
+}
 
-	if req.Dimensions != nil {
-		params.Dimensions = oai.Int(int64(*req.Dimensions))
+// GenerateImage sends an image generation request to OpenAI (DALL-E).
+func (p *Provider) GenerateImage(ctx context.Context, req core.ImageRequest) (*core.ImageResponse, error) {
+	payload := imageRequest{
+		Model:   req.Model,
+		Prompt:  req.Prompt,
+		N:       req.N,
+		Size:    req.Size,
+		Quality: req.Quality,
+		Style:   req.Style,
+		User:    req.User,
 	}
-	if req.User != "" {
-		params.User = oai.String(req.User)
+	// response_format is only valid for the DALL·E models. gpt-image-* rejects it
+	// and always returns base64, so omit it entirely for that family and read the
+	// result from B64JSON.
+	if isDallEModel(req.Model) {
+		payload.ResponseFormat = "url"
+		if req.ResponseFormat == "b64_json" {
+			payload.ResponseFormat = "b64_json"
+		}
 	}
 
-	result, err := p.client.Embeddings.New(ctx, params)
+	body, contentLen, release, err := core.JSONBodyReader(payload)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("openai: failed to marshal image request: %w", err)
 	}
+	defer release()
 
-	embeddings := make([]core.Embedding, len(result.Data))
-	for i, d := range result.Data {
-		embeddings[i] = core.Embedding{
-			Object:    string(d.Object),
-			Embedding: d.Embedding,
-			Index:     int(d.Index),
-		}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint("/images/generations"), body)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to create image request: %w", err)
+	}
+	httpReq.ContentLength = int64(contentLen)
+	for k, v := range p.headers() {
+		httpReq.Header.Set(k, v)
 	}
 
-	return &core.EmbeddingResponse{
-		Object: string(result.Object),
-		Data:   embeddings,
-		Model:  result.Model,
-		Usage: core.EmbeddingUsage{
-			PromptTokens: int(result.Usage.PromptTokens),
-			TotalTokens:  int(result.Usage.TotalTokens),
-		},
-	}, nil
-}
-
-// GenerateImage sends an image generation request to OpenAI (DALL-E).
-func (p *Provider) GenerateImage(ctx context.Context, req core.ImageRequest) (*core.ImageResponse, error) {
-	params := oai.ImageGenerateParams{
-		Prompt: req.Prompt,
-		Model:  req.Model,
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("openai: image request failed: %w", err)
 	}
-	if req.N != nil {
-		params.N = oai.Int(int64(*req.N))
+	defer func() { _ = httpResp.Body.Close() }()
+
+	respBody, err := core.ReadResponseBody(httpResp.Body, core.MaxProviderResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to read image response: %w", err)
 	}
-	if req.Size != "" {
-		params.Size = oai.ImageGenerateParamsSize(req.Size)
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, core.APIErrorFromResponse(p.name, httpResp, respBody)
 	}
-	if req.Quality != "" {
-		params.Quality = oai.ImageGenerateParamsQuality(req.Quality)
+
+	var decoded core.ImageResponse
+	if err := json.Unmarshal(respBody, &decoded); err != nil {
+		return nil, fmt.Errorf("openai: failed to decode image response: %w", err)
 	}
-	if req.Style != "" {
-		params.Style = oai.ImageGenerateParamsStyle(req.Style)
+	return &decoded, nil
+}
+
+// Moderate sends a moderation request to OpenAI.
+func (p *Provider) Moderate(ctx context.Context, req core.ModerationRequest) (*core.ModerationResponse, error) {
+	body, contentLen, release, err := core.JSONBodyReader(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to marshal moderation request: %w", err)
 	}
-	// response_format is only valid for the DALL·E models. gpt-image-* rejects it
-	// and always returns base64, so omit it entirely for that family and read the
-	// result from B64JSON.
-	if isDallEModel(req.Model) {
-		if req.ResponseFormat == "b64_json" {
-			params.ResponseFormat = oai.ImageGenerateParamsResponseFormatB64JSON
-		} else {
-			params.ResponseFormat = oai.ImageGenerateParamsResponseFormatURL
-		}
+	defer release()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint("/moderations"), body)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to create moderation request: %w", err)
 	}
-	if req.User != "" {
-		params.User = oai.String(req.User)
+	httpReq.ContentLength = int64(contentLen)
+	for k, v := range p.headers() {
+		httpReq.Header.Set(k, v)
 	}
 
-	result, err := p.client.Images.Generate(ctx, params)
+	httpResp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("openai: moderation request failed: %w", err)
 	}
+	defer func() { _ = httpResp.Body.Close() }()
 
-	images := make([]core.GeneratedImage, len(result.Data))
-	for i, d := range result.Data {
-		images[i] = core.GeneratedImage{
-			URL:           d.URL,
-			B64JSON:       d.B64JSON,
-			RevisedPrompt: d.RevisedPrompt,
-		}
+	respBody, err := core.ReadResponseBody(httpResp.Body, core.MaxProviderResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to read moderation response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, core.APIErrorFromResponse(p.name, httpResp, respBody)
 	}
 
-	return &core.ImageResponse{
-		Created: result.Created,
-		Data:    images,
-	}, nil
+	var decoded core.ModerationResponse
+	if err := json.Unmarshal(respBody, &decoded); err != nil {
+		return nil, fmt.Errorf("openai: failed to decode moderation response: %w", err)
+	}
+	decoded.Normalize()
+	return &decoded, nil
 }
 
 // Complete sends a chat completion request to OpenAI.
@@ -258,7 +269,7 @@ func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Respon
 	// token fields populated, so forward only the modern max_completion_tokens.
 	req.PreferCompletionTokens()
 
-	bodyReader, _, release, err := core.JSONBodyReader(req)
+	bodyReader, _, release, err := core.JSONBodyReaderExtra(req, req.Extra)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -310,6 +321,7 @@ func (p *Provider) Complete(ctx context.Context, req core.Request) (*core.Respon
 			ReasoningTokens:  completion.Usage.CompletionTokensDetails.ReasoningTokens,
 			CacheReadTokens:  completion.Usage.PromptTokensDetails.CachedTokens,
 		},
+		SystemFingerprint: completion.SystemFingerprint,
 	}, nil
 }
 
@@ -359,7 +371,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req core.Request) (<-chan
 	sreq := streamingRequest{Request: req, StreamOptions: streamOptions{IncludeUsage: true}}
 	sreq.Stream = true
 
-	bodyReader, _, release, err := core.JSONBodyReader(sreq)
+	bodyReader, _, release, err := core.JSONBodyReaderExtra(sreq, sreq.Extra)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -443,13 +455,29 @@ type openAIChatCompletionResponse struct {
 	Model   string        `json:"model"`
 	Choices []core.Choice `json:"choices"`
 	Usage   openAIUsage   `json:"usage"`
+
+	SystemFingerprint string `json:"system_fingerprint"`
 }
 
 func (p *Provider) chatCompletionsEndpoint() string {
+	return p.endpoint("/chat/completions")
+}
+
+// endpoint returns the URL of the API path under the base URL, which may or
+// may not already end in /v1.
+func (p *Provider) endpoint(path string) string {
 	if strings.HasSuffix(p.baseURL, "/v1") {
-		return p.baseURL + "/chat/completions"
+		return p.baseURL + path
+	}
+	return p.baseURL + "/v1" + path
+}
+
+// headers returns the auth and content-type headers for a JSON request.
+func (p *Provider) headers() map[string]string {
+	return map[string]string{
+		"Authorization": "Bearer " + p.apiKey,
+		"Content-Type":  "application/json",
 	}
-	return p.baseURL + "/v1/chat/completions"
 }
 
 // isDallEModel reports whether the image model is a DALL·E model — the only
@@ -463,9 +491,10 @@ func isDallEModel(model string) bool {
 // core.Usage decoder does not, so streaming preserves the same accounting as the
 // non-streaming path.
 type openAIStreamChunk struct {
-	ID      string `json:"id"`
-	Model   string `json:"model"`
-	Choices []struct {
+	ID                string `json:"id"`
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
 		Index int `json:"index"`
 		Delta struct {
 			Role      string                 `json:"role"`
@@ -491,7 +520,7 @@ type openAIStreamToolCall struct {
 // stream chunk, carrying usage (including reasoning/cache detail) on the frame
 // that reports it. OpenAI finish reasons are already canonical.
 func (c openAIStreamChunk) toStreamChunk() core.StreamChunk {
-	sc := core.StreamChunk{ID: c.ID, Model: c.Model}
+	sc := core.StreamChunk{ID: c.ID, Model: c.Model, SystemFingerprint: c.SystemFingerprint}
 	for _, choice := range c.Choices {
 		sc.Choices = append(sc.Choices, core.StreamChoice{
 			Index: choice.Index,
@@ -68,6 +68,7 @@ func TestOpenAIProvider_SupportsModel(t *testing.T) {
 		{"unknown model passthrough", "gpt-99", true},
 		{"codex family supported", "codex-mini-latest", true},
 		{"sora family supported", "sora-2-pro", true},
+		{"moderation model supported", "omni-moderation-latest", true},
 	}
 
 	for _, tt := range tests {
@@ -624,3 +625,68 @@ func TestOpenAIProvider_DiscoverModels_WireEndpoint(t *testing.T) {
 
 func floatPtr(f float64) *float64 { return &f }
 func intPtr(i int) *int           { return &i }
+
+// TestOpenAIProvider_Moderate_MockHTTP verifies Moderate posts to
+// <base>/v1/moderations and returns normalized results.
+func TestOpenAIProvider_Moderate_MockHTTP(t *testing.T) {
+	var gotPath string
+	var gotBody core.ModerationRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"modr-1","model":"omni-moderation-latest","results":[{"flagged":false,"categories":{"violence":true},"category_scores":{"violence":0.9,"hate":1.2}}]}`)
+	}))
+	defer srv.Close()
+
+	p, _ := New("sk-test", srv.URL)
+	resp, err := p.Moderate(context.Background(), core.ModerationRequest{Model: "omni-moderation-latest", Input: "text"})
+	if err != nil {
+		t.Fatalf("Moderate: %v", err)
+	}
+	if gotPath != "/v1/moderations" {
+		t.Errorf("request path = %q, want /v1/moderations", gotPath)
+	}
+	if gotBody.Model != "omni-moderation-latest" || gotBody.Input != "text" {
+		t.Errorf("request body = %+v", gotBody)
+	}
+	if len(resp.Results) != 1 {
+		t.Fatalf("results = %d, want 1", len(resp.Results))
+	}
+	r := resp.Results[0]
+	if !r.Flagged {
+		t.Error("Flagged not derived from the flagged category")
+	}
+	if r.CategoryScores["hate"] != 1 {
+		t.Errorf("hate score = %v, want clamped to 1", r.CategoryScores["hate"])
+	}
+	if _, ok := r.Categories["hate"]; !ok {
+		t.Error("hate missing from categories after normalization")
+	}
+}
+
+func TestOpenAIProvider_Moderate_MockHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":{"message":"bad input","type":"invalid_request_error"}}`)
+	}))
+	defer srv.Close()
+
+	p, _ := New("sk-test", srv.URL)
+	if _, err := p.Moderate(context.Background(), core.ModerationRequest{Input: "text"}); err == nil {
+		t.Fatal("Moderate succeeded on a 400")
+	}
+}
+
+func TestOpenAIProvider_SupportsEmbeddingDimensions(t *testing.T) {
+	provider, _ := New("sk-test-key", "")
+	for model, want := range map[string]bool{
+		"text-embedding-3-small": true,
+		"text-embedding-3-large": true,
+		"text-embedding-ada-002": false,
+	} {
+		if got := provider.SupportsEmbeddingDimensions(model); got != want {
+			t.Errorf("SupportsEmbeddingDimensions(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
@@ -2,13 +2,13 @@ package providers
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	ai21pkg "github.com/ferro-labs/ai-gateway/providers/ai21"
 	anthropicpkg "github.com/ferro-labs/ai-gateway/providers/anthropic"
 	azurefoundrypkg "github.com/ferro-labs/ai-gateway/providers/azure_foundry"
 	azureopenaipkg "github.com/ferro-labs/ai-gateway/providers/azure_openai"
-	bedrockpkg "github.com/ferro-labs/ai-gateway/providers/bedrock"
 	cerebraspkg "github.com/ferro-labs/ai-gateway/providers/cerebras"
 	cloudflarepkg "github.com/ferro-labs/ai-gateway/providers/cloudflare"
 	coherepkg "github.com/ferro-labs/ai-gateway/providers/cohere"
@@ -119,15 +119,9 @@ var allProviders = []ProviderEntry{
 		ConfiguredFn: func(cfg ProviderConfig) bool {
 			return cfg[CfgKeyAPIKey] != "" || cfg[CfgKeyRegion] != "" || cfg[CfgKeyAccessKeyID] != ""
 		},
-		Build: func(cfg ProviderConfig) (Provider, error) {
-			return bedrockpkg.NewWithOptions(bedrockpkg.Options{
-				Region:          cfg[CfgKeyRegion],
-				BearerToken:     cfg[CfgKeyAPIKey],
-				AccessKeyID:     cfg[CfgKeyAccessKeyID],
-				SecretAccessKey: cfg[CfgKeySecretAccessKey],
-				SessionToken:    cfg[CfgKeySessionToken],
-			})
-		},
+		// Build is buildBedrock, which the nobedrock build tag swaps for a
+		// stub (see bedrock_build.go).
+		Build: buildBedrock,
 	},
 	{
 		ID:           NameCerebras,
@@ -291,13 +285,22 @@ var allProviders = []ProviderEntry{
 		EnvMappings: []EnvMapping{
 			{CfgKeyHost, "OLLAMA_HOST", true},
 			{CfgKeyModels, "OLLAMA_MODELS", false},
+			{CfgKeyKeepAlive, "OLLAMA_KEEP_ALIVE", false},
+			{CfgKeyNumCtx, "OLLAMA_NUM_CTX", false},
 		},
 		Build: func(cfg ProviderConfig) (Provider, error) {
-			var models []string
+			opts := ollamapkg.Options{BaseURL: cfg[CfgKeyHost], KeepAlive: cfg[CfgKeyKeepAlive]}
 			if m := cfg[CfgKeyModels]; m != "" {
-				models = strings.Split(m, ",")
+				opts.Models = strings.Split(m, ",")
+			}
+			if n := cfg[CfgKeyNumCtx]; n != "" {
+				numCtx, err := strconv.Atoi(n)
+				if err != nil {
+					return nil, fmt.Errorf("%s: num_ctx (OLLAMA_NUM_CTX) must be an integer, got %q", NameOllama, n)
+				}
+				opts.NumCtx = numCtx
 			}
-			return ollamapkg.New(cfg[CfgKeyHost], models)
+			return ollamapkg.NewWithOptions(opts)
 		},
 	},
 	{
@@ -318,7 +321,7 @@ var allProviders = []ProviderEntry{
 	},
 	{
 		ID:           NameOpenAI,
-		Capabilities: []string{CapabilityChat, CapabilityStream, CapabilityEmbed, CapabilityImage, CapabilityProxy, CapabilityDiscovery},
+		Capabilities: []string{CapabilityChat, CapabilityStream, CapabilityEmbed, CapabilityImage, CapabilityModeration, CapabilityProxy, CapabilityDiscovery},
 		EnvMappings: []EnvMapping{
 			{CfgKeyAPIKey, "OPENAI_API_KEY", true},
 			{CfgKeyBaseURL, "OPENAI_BASE_URL", false},
@@ -175,6 +175,7 @@ type replicatePredictionInput struct {
 	MaxTokens        int      `json:"max_tokens,omitempty"`
 	Temperature      float64  `json:"temperature,omitempty"`
 	TopP             float64  `json:"top_p,omitempty"`
+	TopK             int      `json:"top_k,omitempty"`
 	Seed             int64    `json:"seed,omitempty"`
 	Stop             []string `json:"stop_sequences,omitempty"`
 	PresencePenalty  float64  `json:"presence_penalty,omitempty"`
@@ -228,6 +229,9 @@ func buildTextInput(req core.Request) replicatePredictionInput {
 	if req.TopP != nil {
 		input.TopP = *req.TopP
 	}
+	if req.TopK != nil {
+		input.TopK = *req.TopK
+	}
 	if req.Seed != nil {
 		input.Seed = *req.Seed
 	}
@@ -1,6 +1,8 @@
 package providers
 
 import (
+	"os"
+	"path/filepath"
 	"slices"
 	"testing"
 
@@ -522,6 +524,22 @@ func TestProviderImageCapabilityMatchesInterface(t *testing.T) {
 	}
 }
 
+// TestProviderModerationCapabilityMatchesInterface keeps factory metadata
+// aligned with the optional ModerationProvider interface used by
+// /v1/moderations routing.
+func TestProviderModerationCapabilityMatchesInterface(t *testing.T) {
+	for _, tc := range providerNameStabilityCases() {
+		t.Run(tc.wantName, func(t *testing.T) {
+			p := tc.build(t)
+			_, implements := p.(ModerationProvider)
+			declares := ProviderHasCapability(tc.wantName, CapabilityModeration)
+			if implements != declares {
+				t.Errorf("provider %q moderation capability mismatch: implements ModerationProvider=%v, declares %q=%v", tc.wantName, implements, CapabilityModeration, declares)
+			}
+		})
+	}
+}
+
 // TestProviderDiscoveryCapabilityMatchesInterface keeps factory metadata aligned
 // with the optional DiscoveryProvider interface used by auto-discovery refresh.
 func TestProviderDiscoveryCapabilityMatchesInterface(t *testing.T) {
@@ -665,3 +683,32 @@ func TestBedrockProviderConfiguredFnAcceptsBearerRegionOrAccessKey(t *testing.T)
 		})
 	}
 }
+
+func TestProviderConfigFromEnv_SecretFile(t *testing.T) {
+	entry, ok := GetProviderEntry(NameOpenAI)
+	if !ok {
+		t.Fatal("OpenAI provider entry missing")
+	}
+	path := filepath.Join(t.TempDir(), "openai")
+	if err := os.WriteFile(path, []byte("sk-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY_FILE", path)
+
+	cfg := ProviderConfigFromEnv(entry)
+	if got := cfg[CfgKeyAPIKey]; got != "sk-from-file" {
+		t.Errorf("api_key = %q, want the trimmed file contents", got)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-from-env")
+	if got := ProviderConfigFromEnv(entry)[CfgKeyAPIKey]; got != "sk-from-env" {
+		t.Errorf("api_key = %q, want the env var to win over the file", got)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+	if cfg := ProviderConfigFromEnv(entry); cfg != nil {
+		t.Errorf("ProviderConfigFromEnv() = %v, want nil for an unreadable key file", cfg)
+	}
+}
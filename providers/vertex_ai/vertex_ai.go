@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"golang.org/x/oauth2"
@@ -14,6 +15,7 @@ import (
 
 	providerhttp "github.com/ferro-labs/ai-gateway/internal/httpclient"
 	"github.com/ferro-labs/ai-gateway/providers/core"
+	"github.com/ferro-labs/ai-gateway/providers/internal/geminiwire"
 	"github.com/ferro-labs/ai-gateway/providers/internal/openaicompat"
 )
 
@@ -45,6 +47,7 @@ var (
 	_ core.ImageProvider         = (*Provider)(nil)
 	_ core.ProxiableProvider     = (*Provider)(nil)
 	_ core.NonOpenAIWireProvider = (*Provider)(nil)
+	_ core.Warmer                = (*Provider)(nil)
 )
 
 // New creates a new Vertex AI provider.
@@ -113,6 +116,14 @@ func (p *Provider) SetBaseURL(url string) { p.baseURL = url }
 // core.NonOpenAIWireProvider.
 func (*Provider) NonOpenAIWire() {}
 
+// Warm implements core.Warmer. In service-account and Application Default
+// Credentials mode the first OAuth token is otherwise fetched by the first
+// request; Warm fetches it, and the token source caches it for that request.
+func (p *Provider) Warm(_ context.Context) error {
+	_, _, err := p.authHeader()
+	return err
+}
+
 // authHeader returns the single auth header for Vertex AI — the api-key header
 // when an API key is configured, otherwise a Bearer token from the token source
 // (service-account JSON or Application Default Credentials). It returns a clear
@@ -183,7 +194,8 @@ type vertexAIEmbeddingRequest struct {
 }
 
 type vertexAIEmbeddingInstance struct {
-	Content string `json:"content"`
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
 }
 
 type vertexAIEmbeddingParameters struct {
@@ -261,6 +273,21 @@ func (p *Provider) chatAuthHeaders() (map[string]string, error) {
 	return map[string]string{"Content-Type": "application/json", name: value}, nil
 }
 
+// Vertex AI's limits on the texts one predict call may embed: gemini-embedding
+// takes a single text, the older text embedding models up to 250. Longer
+// inputs are sent in consecutive calls.
+const (
+	vertexAIMaxEmbedBatch       = 250
+	vertexAIMaxGeminiEmbedBatch = 1
+)
+
+func vertexAIEmbedBatchSize(model string) int {
+	if strings.HasPrefix(vertexAIModelID(model), "gemini-embedding-") {
+		return vertexAIMaxGeminiEmbedBatch
+	}
+	return vertexAIMaxEmbedBatch
+}
+
 func isVertexAITextEmbeddingModel(model string) bool {
 	model = vertexAIModelID(model)
 	return model == "gemini-embedding-001" ||
@@ -319,7 +346,22 @@ func (p *Provider) doPredict(ctx context.Context, model string, body any, label
 	return respBody, nil
 }
 
-// Embed sends a text embedding request to Vertex AI's publisher model predict endpoint.
+// SupportsEmbeddingDimensions reports whether model honours a requested
+// output dimension: gemini-embedding, text-embedding-004 and later, and
+// text-multilingual-embedding-002 do; the gecko models do not.
+func (p *Provider) SupportsEmbeddingDimensions(model string) bool {
+	for _, prefix := range []string{"gemini-embedding", "text-embedding-00", "text-multilingual-embedding-002"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Embed sends a text embedding request to Vertex AI's publisher model predict
+// endpoint, in as many calls as the model's batch limit requires (see
+// vertexAIEmbedBatchSize). input_type is sent as each text's task type (see
+// geminiwire.ResolveTaskType).
 func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.EmbeddingResponse, error) {
 	if !isVertexAITextEmbeddingModel(req.Model) {
 		return nil, fmt.Errorf("embed: unsupported Vertex AI text embedding model %q", req.Model)
@@ -331,44 +373,52 @@ func (p *Provider) Embed(ctx context.Context, req core.EmbeddingRequest) (*core.
 	if err != nil {
 		return nil, err
 	}
-
-	vertexReq := vertexAIEmbeddingRequest{
-		Instances: make([]vertexAIEmbeddingInstance, 0, len(texts)),
-	}
-	for _, text := range texts {
-		vertexReq.Instances = append(vertexReq.Instances, vertexAIEmbeddingInstance{Content: text})
-	}
-	if req.Dimensions != nil {
-		vertexReq.Parameters = &vertexAIEmbeddingParameters{OutputDimensionality: req.Dimensions}
-	}
-
-	respBody, err := p.doPredict(ctx, req.Model, vertexReq, "embed")
+	taskType, err := geminiwire.ResolveTaskType(req.InputType)
 	if err != nil {
 		return nil, err
 	}
 
-	var vertexResp vertexAIEmbeddingResponse
-	if err := json.Unmarshal(respBody, &vertexResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal embed response: %w", err)
-	}
-	if len(vertexResp.Predictions) != len(texts) {
-		return nil, fmt.Errorf("vertex ai embed API returned %d embeddings for %d inputs", len(vertexResp.Predictions), len(texts))
-	}
+	data := make([]core.Embedding, 0, len(texts))
+	promptTokens := 0
+	for batch := range slices.Chunk(texts, vertexAIEmbedBatchSize(req.Model)) {
+		vertexReq := vertexAIEmbeddingRequest{
+			Instances: make([]vertexAIEmbeddingInstance, 0, len(batch)),
+		}
+		for _, text := range batch {
+			vertexReq.Instances = append(vertexReq.Instances, vertexAIEmbeddingInstance{Content: text, TaskType: taskType})
+		}
+		if req.Dimensions != nil {
+			vertexReq.Parameters = &vertexAIEmbeddingParameters{OutputDimensionality: req.Dimensions}
+		}
+
+		respBody, err := p.doPredict(ctx, req.Model, vertexReq, "embed")
+		if err != nil {
+			return nil, err
+		}
 
-	data := make([]core.Embedding, len(vertexResp.Predictions))
-	promptTokens := vertexResp.Metadata.TokenMetadata.InputTokenCount.TotalTokens
-	statisticsTokens := 0
-	for i, prediction := range vertexResp.Predictions {
-		values, tokenCount := vertexAIEmbeddingValues(prediction)
-		data[i] = core.Embedding{
-			Object:    "embedding",
-			Embedding: values,
-			Index:     i,
+		var vertexResp vertexAIEmbeddingResponse
+		if err := json.Unmarshal(respBody, &vertexResp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal embed response: %w", err)
 		}
-		statisticsTokens += tokenCount
-	}
-	if promptTokens == 0 {
-		promptTokens = statisticsTokens
+		if len(vertexResp.Predictions) != len(batch) {
+			return nil, fmt.Errorf("vertex ai embed API returned %d embeddings for %d inputs", len(vertexResp.Predictions), len(batch))
+		}
+
+		batchTokens := vertexResp.Metadata.TokenMetadata.InputTokenCount.TotalTokens
+		statisticsTokens := 0
+		for _, prediction := range vertexResp.Predictions {
+			values, tokenCount := vertexAIEmbeddingValues(prediction)
+			data = append(data, core.Embedding{
+				Object:    "embedding",
+				Embedding: values,
+				Index:     len(data),
+			})
+			statisticsTokens += tokenCount
+		}
+		if batchTokens == 0 {
+			batchTokens = statisticsTokens
+		}
+		promptTokens += batchTokens
 	}
 
 	return &core.EmbeddingResponse{
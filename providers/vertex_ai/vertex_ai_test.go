@@ -187,6 +187,56 @@ func TestVertexAIProvider_Embed_BatchSuccess_APIKey(t *testing.T) {
 	}
 }
 
+func TestVertexAIProvider_Embed_GeminiEmbeddingOneTextPerCall(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var body struct {
+			Instances []struct {
+				Content  string `json:"content"`
+				TaskType string `json:"task_type"`
+			} `json:"instances"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if len(body.Instances) != 1 {
+			t.Fatalf("instances = %d, want 1 per gemini-embedding call", len(body.Instances))
+		}
+		if body.Instances[0].TaskType != "RETRIEVAL_DOCUMENT" {
+			t.Errorf("task_type = %q, want RETRIEVAL_DOCUMENT", body.Instances[0].TaskType)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"predictions":[{"embeddings":{"values":[0.1],"statistics":{"token_count":2}}}]}`))
+	}))
+	defer srv.Close()
+
+	p, _ := New(Options{
+		ProjectID: "demo-project",
+		Region:    "us-central1",
+		APIKey:    "test-key",
+	})
+	p.SetBaseURL(srv.URL + "/v1/projects/demo-project/locations/us-central1/endpoints/openapi")
+
+	resp, err := p.Embed(context.Background(), core.EmbeddingRequest{
+		Model:     "gemini-embedding-001",
+		Input:     []string{"a", "b", "c"},
+		InputType: "search_document",
+	})
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("predict calls = %d, want 3", calls)
+	}
+	if len(resp.Data) != 3 || resp.Data[2].Index != 2 {
+		t.Fatalf("response data = %+v", resp.Data)
+	}
+	if resp.Usage.PromptTokens != 6 {
+		t.Errorf("usage = %+v, want 6 prompt tokens", resp.Usage)
+	}
+}
+
 func TestVertexAIProvider_Embed_StringInput(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/publishers/google/models/textembedding-gecko@003:predict" {
@@ -110,7 +110,7 @@ func TestPostgresStore_RevokeAndRotate(t *testing.T) {
 		t.Fatalf("create: %v", err)
 	}
 
-	rotated, err := store.RotateKey(t.Context(), created.ID)
+	rotated, err := store.RotateKey(t.Context(), created.ID, 0)
 	if err != nil {
 		t.Fatalf("rotate: %v", err)
 	}
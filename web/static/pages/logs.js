@@ -3,6 +3,9 @@
 var logsOffset = 0;
 var logsLimit = 50;
 var logsTotal = 0;
+// liveTail is the AbortController of the running live tail, or null.
+var liveTail = null;
+var liveMaxRows = 200;
 
 document.addEventListener('DOMContentLoaded', function() {
   localStorage.setItem('gw-visited-logs', 'true');
@@ -10,7 +13,15 @@ document.addEventListener('DOMContentLoaded', function() {
   loadLogs();
 
   var applyBtn = document.getElementById('logs-apply-btn');
-  if (applyBtn) applyBtn.addEventListener('click', function() { logsOffset = 0; loadLogs(); });
+  if (applyBtn) applyBtn.addEventListener('click', function() {
+    logsOffset = 0;
+    if (liveTail) startLiveTail(); else loadLogs();
+  });
+
+  var liveBtn = document.getElementById('logs-live-btn');
+  if (liveBtn) liveBtn.addEventListener('click', function() {
+    if (liveTail) stopLiveTail(); else startLiveTail();
+  });
 
   var clearBtn = document.getElementById('logs-clear-btn');
   if (clearBtn) clearBtn.addEventListener('click', function() {
@@ -23,7 +34,7 @@ document.addEventListener('DOMContentLoaded', function() {
     if (stageSel) stageSel.value = '';
     if (sinceInput) sinceInput.value = '';
     logsOffset = 0;
-    loadLogs();
+    if (liveTail) startLiveTail(); else loadLogs();
   });
 });
 
@@ -51,11 +62,8 @@ async function populateFilterOptions() {
   }
 }
 
-function buildQueryParams() {
+function buildFilterParams() {
   var params = new URLSearchParams();
-  params.set('limit', String(logsLimit));
-  params.set('offset', String(logsOffset));
-
   var provider = document.getElementById('filter-provider');
   if (provider && provider.value) params.set('provider', provider.value);
 
@@ -64,6 +72,13 @@ function buildQueryParams() {
 
   var stage = document.getElementById('filter-stage');
   if (stage && stage.value) params.set('stage', stage.value);
+  return params;
+}
+
+function buildQueryParams() {
+  var params = buildFilterParams();
+  params.set('limit', String(logsLimit));
+  params.set('offset', String(logsOffset));
 
   var since = document.getElementById('filter-since');
   if (since && since.value) {
@@ -142,38 +157,42 @@ function renderLogsTable(entries) {
   }
 
   entries.forEach(function(entry) {
-    var stage = entry.stage || entry.Stage || '';
-    var provider = entry.provider || entry.Provider || '-';
-    var model = entry.model || entry.Model || '-';
-    var totalTokens = entry.total_tokens != null ? entry.total_tokens : (entry.TotalTokens != null ? entry.TotalTokens : null);
-    var errorMsg = entry.error_message || entry.ErrorMessage || '';
-    var createdAt = entry.created_at || entry.CreatedAt || '';
-    var latencyMs = entry.latency_ms != null ? entry.latency_ms : (entry.LatencyMS != null ? entry.LatencyMS : null);
-
-    var stageBadge = createEl('span', { className: stageBadgeClass(stage), textContent: stage || '-' });
-
-    var latencyCell = '-';
-    if (latencyMs != null) {
-      latencyCell = latencyMs + 'ms';
-    }
-
-    var errorCell = createEl('span', { className: 'mono', textContent: errorMsg ? errorMsg.substring(0, 60) + (errorMsg.length > 60 ? '…' : '') : '-' });
-    if (errorMsg) errorCell.title = errorMsg;
-
-    var tr = createEl('tr', null, [
-      createEl('td', { className: 'mono', textContent: timeAgo(createdAt) }),
-      createEl('td', { textContent: provider }),
-      createEl('td', { className: 'mono', textContent: model }),
-      createEl('td', null, [stageBadge]),
-      createEl('td', null, [statusBadge(entry)]),
-      createEl('td', { className: 'mono', textContent: latencyCell }),
-      createEl('td', { className: 'mono', textContent: totalTokens != null ? formatNumber(totalTokens) : '-' }),
-      createEl('td', null, [errorCell])
-    ]);
-    tbody.appendChild(tr);
+    tbody.appendChild(logRow(entry));
   });
 }
 
+function logRow(entry) {
+  var stage = entry.stage || entry.Stage || '';
+  var provider = entry.provider || entry.Provider || '-';
+  var model = entry.model || entry.Model || '-';
+  var totalTokens = entry.total_tokens != null ? entry.total_tokens : (entry.TotalTokens != null ? entry.TotalTokens : null);
+  var errorMsg = entry.error_message || entry.ErrorMessage || '';
+  var createdAt = entry.created_at || entry.CreatedAt || '';
+  var latencyMs = entry.latency_ms != null ? entry.latency_ms : (entry.LatencyMS != null ? entry.LatencyMS : null);
+
+  var stageBadge = createEl('span', { className: stageBadgeClass(stage), textContent: stage || '-' });
+
+  var latencyCell = '-';
+  if (latencyMs != null) {
+    latencyCell = latencyMs + 'ms';
+  }
+
+  var errorCell = createEl('span', { className: 'mono', textContent: errorMsg ? errorMsg.substring(0, 60) + (errorMsg.length > 60 ? '…' : '') : '-' });
+  if (errorMsg) errorCell.title = errorMsg;
+
+  var tr = createEl('tr', null, [
+    createEl('td', { className: 'mono', textContent: timeAgo(createdAt) }),
+    createEl('td', { textContent: provider }),
+    createEl('td', { className: 'mono', textContent: model }),
+    createEl('td', null, [stageBadge]),
+    createEl('td', null, [statusBadge(entry)]),
+    createEl('td', { className: 'mono', textContent: latencyCell }),
+    createEl('td', { className: 'mono', textContent: totalTokens != null ? formatNumber(totalTokens) : '-' }),
+    createEl('td', null, [errorCell])
+  ]);
+  return tr;
+}
+
 function renderPagination() {
   var container = document.getElementById('logs-pagination');
   if (!container) return;
@@ -217,3 +236,88 @@ function renderPagination() {
   container.appendChild(info);
   container.appendChild(nextBtn);
 }
+
+// startLiveTail streams new entries matching the filters from
+// /admin/logs/stream, newest first. It reads the stream with fetch rather
+// than EventSource, which cannot send the Authorization header.
+async function startLiveTail() {
+  stopLiveTail(true);
+  var controller = new AbortController();
+  liveTail = controller;
+  setLiveButton(true);
+
+  var tbody = document.getElementById('logs-tbody');
+  var pagination = document.getElementById('logs-pagination');
+  if (pagination) clearEl(pagination);
+  clearEl(tbody);
+  var waiting = createEl('tr', null, [
+    createEl('td', { colspan: '8' }, [
+      createEl('div', { className: 'empty-state', textContent: 'Waiting for new requests...' })
+    ])
+  ]);
+  tbody.appendChild(waiting);
+
+  try {
+    var res = await fetch('/admin/logs/stream?' + buildFilterParams().toString(), {
+      headers: { 'Authorization': 'Bearer ' + getToken() },
+      signal: controller.signal
+    });
+    if (!res.ok) {
+      var data = await res.json().catch(function() { return null; });
+      throw new Error((data && data.error && data.error.message) || 'Live tail failed');
+    }
+    var reader = res.body.getReader();
+    var decoder = new TextDecoder();
+    var buffer = '';
+    for (;;) {
+      var chunk = await reader.read();
+      if (chunk.done) break;
+      buffer += decoder.decode(chunk.value, { stream: true });
+      var events = buffer.split('\n\n');
+      buffer = events.pop();
+      events.forEach(function(event) {
+        var entry = parseLogEvent(event);
+        if (!entry) return;
+        if (waiting.parentNode) waiting.remove();
+        tbody.insertBefore(logRow(entry), tbody.firstChild);
+        while (tbody.children.length > liveMaxRows) tbody.lastChild.remove();
+      });
+    }
+  } catch (e) {
+    if (controller.signal.aborted) return;
+    showToast(e.message || 'Live tail disconnected', 'error');
+  }
+  if (liveTail === controller) {
+    liveTail = null;
+    setLiveButton(false);
+  }
+}
+
+function parseLogEvent(event) {
+  var data = null;
+  event.split('\n').forEach(function(line) {
+    if (line.indexOf('data: ') === 0) data = line.substring(6);
+  });
+  if (data === null) return null;
+  try { return JSON.parse(data); } catch (e) { return null; }
+}
+
+// stopLiveTail ends the live tail; unless restarting, it goes back to the
+// paged view.
+function stopLiveTail(restarting) {
+  if (liveTail) {
+    liveTail.abort();
+    liveTail = null;
+  }
+  if (!restarting) {
+    setLiveButton(false);
+    loadLogs();
+  }
+}
+
+function setLiveButton(on) {
+  var btn = document.getElementById('logs-live-btn');
+  if (!btn) return;
+  btn.className = on ? 'btn btn-primary' : 'btn btn-secondary';
+  btn.textContent = on ? 'Stop live' : 'Live';
+}
@@ -0,0 +1,132 @@
+'use strict';
+
+var routingRefreshMs = 5000;
+var routingTimer = null;
+
+var breakerBadges = {
+  closed: 'badge-success',
+  half_open: 'badge-warning',
+  open: 'badge-error'
+};
+
+function errorRateColor(rate) {
+  if (rate < 0.01) return 'var(--color-success, #10B981)';
+  if (rate < 0.05) return 'var(--color-warning, #F59E0B)';
+  return 'var(--color-error, #EF4444)';
+}
+
+function renderWeights(state) {
+  var container = document.getElementById('routing-weights');
+  if (!container) return;
+  clearEl(container);
+
+  var targets = state.targets || [];
+  if (targets.length === 0) {
+    container.appendChild(createEl('p', { className: 'empty-state', textContent: 'No targets configured.' }));
+    return;
+  }
+  if (state.strategy !== 'loadbalance') {
+    container.appendChild(createEl('p', {
+      textContent: 'Weights only steer traffic in loadbalance mode; the ' + (state.strategy || 'current') + ' strategy ignores them.',
+      style: 'font-size:12px;color:var(--text-muted);margin-bottom:10px;'
+    }));
+  }
+  targets.forEach(function(t) {
+    var pct = (t.weight_share || 0) * 100;
+    var fill = createEl('div', { className: 'bar-chart-fill', style: 'width:' + pct + '%' });
+    var track = createEl('div', { className: 'bar-chart-track' }, [fill]);
+    var label = createEl('div', { className: 'bar-chart-label', textContent: t.virtual_key });
+    var value = createEl('div', { className: 'bar-chart-value', textContent: pct.toFixed(1) + '%' });
+    container.appendChild(createEl('div', { className: 'bar-chart-row' }, [label, track, value]));
+  });
+}
+
+// weightCell shows the configured weight and, while the target is throttled
+// after rate-limit responses, the factor currently scaling it.
+function weightCell(t) {
+  var cell = createEl('td', { textContent: t.weight > 0 ? String(t.weight) : '-' });
+  if (t.throttle_factor > 0 && t.throttle_factor < 1) {
+    cell.appendChild(createEl('span', {
+      className: 'badge badge-warning',
+      textContent: 'throttled \u00d7' + t.throttle_factor.toFixed(2),
+      style: 'margin-left:6px;'
+    }));
+  }
+  return cell;
+}
+
+function targetRow(t) {
+  var nameCell = createEl('td', null, [
+    createEl('span', { className: 'status-dot ' + (t.registered ? 'available' : 'unavailable') }),
+    createEl('span', { className: 'mono', textContent: ' ' + t.virtual_key })
+  ]);
+
+  var breakerCell = createEl('td');
+  if (t.circuit_breaker) {
+    breakerCell.appendChild(createEl('span', {
+      className: 'badge ' + (breakerBadges[t.circuit_breaker] || 'badge-muted'),
+      textContent: t.circuit_breaker.replace('_', '-')
+    }));
+  } else {
+    breakerCell.appendChild(createEl('span', { className: 'badge badge-muted', textContent: 'none' }));
+  }
+
+  var latency = t.latency_p50_ms > 0 ? Math.round(t.latency_p50_ms) + 'ms' : '-';
+  var rateCell = createEl('td', { textContent: '-' });
+  if (t.recent_requests > 0) {
+    rateCell.textContent = (t.error_rate * 100).toFixed(1) + '% (' + formatNumber(t.recent_errors) + ')';
+    rateCell.style.color = errorRateColor(t.error_rate);
+  }
+
+  return createEl('tr', null, [
+    nameCell,
+    weightCell(t),
+    breakerCell,
+    createEl('td', { textContent: latency }),
+    createEl('td', { textContent: formatNumber(t.recent_requests || 0) }),
+    rateCell
+  ]);
+}
+
+function renderTargets(targets) {
+  var tbody = document.getElementById('routing-body');
+  if (!tbody) return;
+  clearEl(tbody);
+  if (targets.length === 0) {
+    tbody.appendChild(createEl('tr', null, [
+      createEl('td', { colspan: '6', className: 'empty-state', textContent: 'No targets configured.' })
+    ]));
+    return;
+  }
+  targets.forEach(function(t) { tbody.appendChild(targetRow(t)); });
+}
+
+function loadRoutingState() {
+  apiRequest('/admin/routing/state')
+    .then(function(state) {
+      var targets = state.targets || [];
+      var open = targets.filter(function(t) { return t.circuit_breaker === 'open'; }).length;
+
+      document.getElementById('stat-strategy').textContent = state.strategy || '-';
+      document.getElementById('stat-targets').textContent = formatNumber(targets.length);
+      var openEl = document.getElementById('stat-open-breakers');
+      openEl.textContent = formatNumber(open);
+      openEl.style.color = open > 0 ? 'var(--color-error, #EF4444)' : '';
+
+      renderWeights(state);
+      renderTargets(targets);
+    })
+    .catch(function(err) {
+      // One toast, not one per poll, while the endpoint stays unreachable.
+      clearInterval(routingTimer);
+      routingTimer = null;
+      showToast('Failed to load routing state: ' + err.message, 'error');
+    });
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+  loadRoutingState();
+  routingTimer = setInterval(function() {
+    if (!document.hidden) loadRoutingState();
+  }, routingRefreshMs);
+});